@@ -16,16 +16,27 @@ type LeptonComponentEvents struct {
 	StartTime time.Time          `json:"startTime"`
 	EndTime   time.Time          `json:"endTime"`
 	Events    []components.Event `json:"events"`
+
+	// Node lifecycle state (e.g., "in-service", "repairing") when the data was exported.
+	Lifecycle string `json:"lifecycle,omitempty"`
 }
 
 type LeptonComponentStates struct {
 	Component string             `json:"component"`
 	States    []components.State `json:"states"`
+
+	// Operator annotations (e.g., ticket ID) set via the API.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Node lifecycle state (e.g., "in-service", "repairing") when the data was exported.
+	Lifecycle string `json:"lifecycle,omitempty"`
 }
 
 type LeptonComponentMetrics struct {
 	Component string              `json:"component"`
 	Metrics   []components.Metric `json:"metrics"`
+
+	// Node lifecycle state (e.g., "in-service", "repairing") when the data was exported.
+	Lifecycle string `json:"lifecycle,omitempty"`
 }
 
 type LeptonComponentInfo struct {
@@ -33,4 +44,24 @@ type LeptonComponentInfo struct {
 	StartTime time.Time       `json:"startTime"`
 	EndTime   time.Time       `json:"endTime"`
 	Info      components.Info `json:"info"`
+
+	// Node lifecycle state (e.g., "in-service", "repairing") when the data was exported.
+	Lifecycle string `json:"lifecycle,omitempty"`
+}
+
+// LeptonNodeStatesAt is the node health reconstructed from the states history as of a past time.
+type LeptonNodeStatesAt struct {
+	Time time.Time `json:"time"`
+	// Healthy is true if all the components with the known states were healthy.
+	Healthy    bool                      `json:"healthy"`
+	Components []LeptonComponentStatesAt `json:"components"`
+}
+
+type LeptonComponentStatesAt struct {
+	Component string `json:"component"`
+	// Known is false if no state was recorded around the time (e.g., gpud was not running).
+	Known bool `json:"known"`
+	// Since is the time from which the component had the same health.
+	Since  time.Time          `json:"since,omitempty"`
+	States []components.State `json:"states"`
 }
@@ -0,0 +1,28 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/units"
+)
+
+// MetricUnit returns the SI unit of the metric value (e.g., "watts"),
+// falling back to the unit inferred from the metric name
+// for the responses of the older servers without the unit metadata.
+func MetricUnit(m components.Metric) string {
+	if m.Unit != "" {
+		return m.Unit
+	}
+	return units.FromMetricName(m.MetricName)
+}
+
+// ConvertMetricValue converts the metric value to the unit
+// (e.g., "milliwatts", "megahertz", "mebibytes"), as defined in "pkg/units".
+func ConvertMetricValue(m components.Metric, to string) (float64, error) {
+	from := MetricUnit(m)
+	if from == "" {
+		return 0, fmt.Errorf("metric %q has no unit", m.MetricName)
+	}
+	return units.Convert(m.Value, from, to)
+}
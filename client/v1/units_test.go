@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/leptonai/gpud/components"
+	components_metrics_state "github.com/leptonai/gpud/components/metrics/state"
+	"github.com/leptonai/gpud/pkg/units"
+)
+
+func TestConvertMetricValue(t *testing.T) {
+	power := components.Metric{
+		Metric: components_metrics_state.Metric{MetricName: "accelerator_nvidia_power_current_usage_watts", Value: 350.5},
+	}
+	if u := MetricUnit(power); u != units.Watts {
+		t.Fatalf("expected unit %q, got %q", units.Watts, u)
+	}
+	mw, err := ConvertMetricValue(power, units.MilliWatts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mw != 350500 {
+		t.Errorf("expected 350500 milliwatts, got %v", mw)
+	}
+
+	mem := components.Metric{
+		Metric: components_metrics_state.Metric{MetricName: "accelerator_nvidia_memory_used", Value: 2 << 20},
+		Unit:   units.Bytes,
+	}
+	mib, err := ConvertMetricValue(mem, units.MiB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mib != 2 {
+		t.Errorf("expected 2 MiB, got %v", mib)
+	}
+
+	count := components.Metric{
+		Metric: components_metrics_state.Metric{MetricName: "accelerator_nvidia_ecc_volatile_total_corrected", Value: 1},
+	}
+	if _, err := ConvertMetricValue(count, units.Bytes); err == nil {
+		t.Error("expected error for the metric without unit")
+	}
+	if _, err := ConvertMetricValue(power, units.Bytes); err == nil {
+		t.Error("expected error for the incompatible units")
+	}
+}
@@ -0,0 +1,72 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/urfave/cli"
+)
+
+func cmdAckEvent(cliContext *cli.Context) error {
+	stateFile, err := config.DefaultStateFile()
+	if err != nil {
+		return fmt.Errorf("failed to get state file: %w", err)
+	}
+	db, err := sqlite.Open(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := state.CreateTableEventAcks(ctx, db); err != nil {
+		return fmt.Errorf("failed to create event acks table: %w", err)
+	}
+
+	if cliContext.Bool("list") {
+		acks, err := state.ReadEventAcks(ctx, db, true)
+		if err != nil {
+			return err
+		}
+		for _, ack := range acks {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n", ack.AckedAt.Format(time.RFC3339), ack.Component, ack.EventName, ack.AckedBy, ack.Note)
+		}
+		return nil
+	}
+
+	component := cliContext.String("component")
+	if component == "" {
+		return errors.New("--component must be set")
+	}
+	eventName := cliContext.String("event")
+
+	if cliContext.Bool("resolve") {
+		resolved, err := state.ResolveEventAck(ctx, db, component, eventName, time.Now())
+		if err != nil {
+			return err
+		}
+		if !resolved {
+			return fmt.Errorf("no active ack found for %q %q", component, eventName)
+		}
+		fmt.Printf("%s successfully resolved the ack\n", checkMark)
+		return nil
+	}
+
+	if err := state.AckEvent(ctx, db, state.EventAck{
+		Component: component,
+		EventName: eventName,
+		AckedBy:   getRequestedBy(),
+		Note:      cliContext.String("note"),
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("%s successfully acknowledged the events\n", checkMark)
+	return nil
+}
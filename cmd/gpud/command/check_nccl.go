@@ -0,0 +1,135 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	nvidia_query_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+	nvidia_query_nccl_allreduce "github.com/leptonai/gpud/components/accelerator/nvidia/query/nccl-allreduce"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/urfave/cli"
+)
+
+func cmdCheckNCCL(cliContext *cli.Context) error {
+	reason := cliContext.String("reason")
+	if reason == "" {
+		return errors.New("--reason must be set (e.g., acceptance, repair ticket)")
+	}
+	tolerance := cliContext.Float64("tolerance")
+	if tolerance < 0 || tolerance >= 100 {
+		return fmt.Errorf("--tolerance must be between 0 and 100, got %v", tolerance)
+	}
+
+	indexes, err := nvidia_query_nvml.GetGPUIndexes()
+	if err != nil {
+		return fmt.Errorf("failed to get gpu indexes: %w", err)
+	}
+	// ring in the NVML index order
+	uuids := make([]string, 0, len(indexes))
+	for uuid := range indexes {
+		uuids = append(uuids, uuid)
+	}
+	sort.Slice(uuids, func(i, j int) bool { return indexes[uuids[i]] < indexes[uuids[j]] })
+	if len(uuids) < 2 {
+		return fmt.Errorf("nccl all-reduce requires at least 2 gpus, found %d", len(uuids))
+	}
+
+	stateFile, err := config.DefaultStateFile()
+	if err != nil {
+		return fmt.Errorf("failed to get state file: %w", err)
+	}
+	db, err := sqlite.Open(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer db.Close()
+
+	rootCtx, rootCancel := context.WithCancel(context.Background())
+	defer rootCancel()
+
+	if err := nvidia_query_gpu_ledger.CreateTableGPULedger(rootCtx, db); err != nil {
+		return fmt.Errorf("failed to create gpu ledger table: %w", err)
+	}
+
+	fmt.Printf("running nccl all-reduce across %d gpu(s), then across each pair of the neighboring gpus\n", len(uuids))
+
+	start := time.Now().UTC()
+	cctx, ccancel := context.WithTimeout(rootCtx, cliContext.Duration("timeout"))
+	res, err := nvidia_query_nccl_allreduce.Run(cctx, uuids, tolerance, func(r nvidia_query_nccl_allreduce.Ring) {
+		if r.Error != "" {
+			fmt.Printf("%s ring %s failed: %s\n", warningSign, r, r.Error)
+			return
+		}
+		fmt.Printf("ring %s bus bandwidth %.1f GB/s (average %.1f GB/s)\n", r, r.Output.MaxBusBandwidthGBps, r.Output.AvgBusBandwidthGBps)
+	})
+	ccancel()
+	if err != nil {
+		return fmt.Errorf("nccl all-reduce self-test did not complete: %w", err)
+	}
+	took := time.Since(start)
+
+	failedRings := res.Failed()
+	for _, r := range failedRings {
+		if r.Slow {
+			fmt.Printf("%s ring %s bus bandwidth %.1f GB/s is more than %.0f%% below the median %.1f GB/s of the peer links\n",
+				warningSign, r, r.Output.MaxBusBandwidthGBps, tolerance, res.MedianPairBusBandwidthGBps)
+		}
+	}
+	if len(res.SuspectGPUs) > 0 {
+		fmt.Printf("%s all the links of %s failed or are slow, the gpu(s) likely at fault\n", warningSign, strings.Join(res.SuspectGPUs, ", "))
+	}
+
+	// records the verdict per GPU, failing the GPUs in any failed or slow ring
+	requestedBy := getRequestedBy()
+	for _, uuid := range uuids {
+		var reasons []string
+		for _, r := range failedRings {
+			if !slices.Contains(r.UUIDs, uuid) {
+				continue
+			}
+			if r.Error != "" {
+				reasons = append(reasons, fmt.Sprintf("ring %s failed: %s", r, r.Error))
+			} else {
+				reasons = append(reasons, fmt.Sprintf("ring %s slow: %.1f GB/s", r, r.Output.MaxBusBandwidthGBps))
+			}
+		}
+
+		verdict := nvidia_query_gpu_ledger.VerdictPass
+		if len(reasons) > 0 {
+			verdict = nvidia_query_gpu_ledger.VerdictFail
+		}
+		details := fmt.Sprintf("gpus=%d all_busbw=%.1f median_pair_busbw=%.1f", len(uuids), res.All.Output.MaxBusBandwidthGBps, res.MedianPairBusBandwidthGBps)
+		if slices.Contains(res.SuspectGPUs, uuid) {
+			details += " suspect=true"
+		}
+		if len(reasons) > 0 {
+			details += "\n" + strings.Join(reasons, "\n")
+		}
+		if err := nvidia_query_gpu_ledger.InsertEntry(rootCtx, db, nvidia_query_gpu_ledger.Entry{
+			UnixSeconds:     start.Unix(),
+			GPUUUID:         uuid,
+			Test:            nvidia_query_nccl_allreduce.TestName,
+			Verdict:         verdict,
+			RequestedBy:     requestedBy,
+			Reason:          reason,
+			DurationSeconds: took.Seconds(),
+			Details:         details,
+		}); err != nil {
+			return fmt.Errorf("failed to record nccl test verdict: %w", err)
+		}
+	}
+
+	if !res.Passed() {
+		return fmt.Errorf("%d ring(s) failed or performed below the peers in the nccl all-reduce self-test", len(failedRings))
+	}
+	fmt.Printf("%s nccl all-reduce self-test passed (bus bandwidth %.1f GB/s across %d gpus)\n", checkMark, res.All.Output.MaxBusBandwidthGBps, len(uuids))
+	return nil
+}
@@ -2,9 +2,19 @@ package command
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	nvidia_query_dcgm_diag "github.com/leptonai/gpud/components/accelerator/nvidia/query/dcgm-diag"
+	nvidia_query_memtest "github.com/leptonai/gpud/components/accelerator/nvidia/query/memtest"
+	nvidia_query_nccl_allreduce "github.com/leptonai/gpud/components/accelerator/nvidia/query/nccl-allreduce"
+	nvidia_query_pciebw "github.com/leptonai/gpud/components/accelerator/nvidia/query/pciebw"
+	metrics_export "github.com/leptonai/gpud/components/metrics/export"
 	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/internal/explain"
+	"github.com/leptonai/gpud/internal/npd"
+	"github.com/leptonai/gpud/pkg/locale"
+	"github.com/leptonai/gpud/pkg/lowoverhead"
 	"github.com/leptonai/gpud/version"
 
 	"github.com/urfave/cli"
@@ -26,6 +36,8 @@ var (
 
 	annotations   string
 	listenAddress string
+	addressFamily string
+	caBundle      string
 
 	pprof bool
 
@@ -53,6 +65,15 @@ var (
 
 	dockerIgnoreConnectionErrors  bool
 	kubeletIgnoreConnectionErrors bool
+
+	profile    string
+	localeName string
+
+	highFrequencyMetricsPath string
+
+	lowOverhead bool
+
+	gpuExpected bool
 )
 
 const (
@@ -178,6 +199,16 @@ sudo rm /etc/systemd/system/gpud.service
 					Destination: &listenAddress,
 					Value:       fmt.Sprintf("0.0.0.0:%d", config.DefaultGPUdPort),
 				},
+				&cli.StringFlag{
+					Name:        "address-family",
+					Usage:       "set the IP address family of the listener and the push endpoints [dual, ipv4, ipv6] (e.g., ipv6 for the IPv6-only clusters, with --listen-address [::]:15132)",
+					Destination: &addressFamily,
+				},
+				&cli.StringFlag{
+					Name:        "ca-bundle",
+					Usage:       "set the path to the PEM encoded CA certificates to trust for the outbound calls, in addition to the system roots (e.g., the TLS inspection proxy CA; use HTTP_PROXY, HTTPS_PROXY, and NO_PROXY for the egress proxy)",
+					Destination: &caBundle,
+				},
 				&cli.StringFlag{
 					Name:        "annotations",
 					Usage:       "set the annotations",
@@ -262,6 +293,31 @@ sudo rm /etc/systemd/system/gpud.service
 					Usage:       "ignore connection errors to kubelet read-only port, useful when kubelet readOnlyPort is disabled (default: false)",
 					Destination: &kubeletIgnoreConnectionErrors,
 				},
+				&cli.StringFlag{
+					Name:        "profile",
+					Usage:       fmt.Sprintf("set the named group of the components to enable %v (default: all the auto-detected components)", config.Profiles),
+					Destination: &profile,
+				},
+				&cli.StringFlag{
+					Name:        "locale",
+					Usage:       fmt.Sprintf("set the locale of the humanized durations and sizes (e.g., '3 hours ago', '83 MB') in the component outputs %v, 'none' to disable the humanization; the rest of the reasons stay in English (default: en)", locale.Supported),
+					Destination: &localeName,
+				},
+				&cli.StringFlag{
+					Name:        "high-frequency-metrics-path",
+					Usage:       fmt.Sprintf("enable the shared-memory ring buffer of the high-frequency (%v) GPU utilization and power samples at the path (e.g., %q), for a co-located profiler to read without HTTP (default: disabled)", config.DefaultHighFrequencyMetricsInterval.Duration, config.DefaultHighFrequencyMetricsPath),
+					Destination: &highFrequencyMetricsPath,
+				},
+				&cli.BoolFlag{
+					Name:        "low-overhead",
+					Usage:       fmt.Sprintf("enable the low-overhead mode for the latency-sensitive nodes, which polls at most every %v, disables the active probes, and keeps the gpud cpu usage below %v%% of one core (default: false)", lowoverhead.DefaultMinPollInterval, lowoverhead.DefaultCPUBudgetPercent),
+					Destination: &lowOverhead,
+				},
+				&cli.BoolFlag{
+					Name:        "gpu-expected",
+					Usage:       "set true if the node is expected to have the NVIDIA GPUs, to report the NVIDIA components unhealthy when the NVIDIA driver is missing, rather than not applicable (default: false)",
+					Destination: &gpuExpected,
+				},
 			},
 		},
 
@@ -533,6 +589,335 @@ cat summary.txt
 				},
 			},
 		},
+		{
+			Name:  "metrics",
+			Usage: "manage the stored metrics",
+			Subcommands: []cli.Command{
+				{
+					Name:  "export",
+					Usage: "export the stored metrics as the CSV or Parquet file (e.g., to analyze the GPU health trends in notebooks)",
+					UsageText: `# to export the metrics of the last 7 days as the Parquet file
+sudo gpud metrics export --format parquet --since 7d --output metrics.parquet
+
+# to print the metrics since a time as CSV
+sudo gpud metrics export --format csv --since 2024-01-02T15:04:05Z --output -
+`,
+					Action: cmdMetricsExport,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "format",
+							Usage: "output format (csv, parquet)",
+							Value: metrics_export.FormatCSV,
+						},
+						cli.StringFlag{
+							Name:  "since",
+							Usage: "export the metrics since the duration ago (e.g., 7d, 36h) or the RFC3339 time, up to the retention period",
+							Value: "1d",
+						},
+						cli.StringFlag{
+							Name:  "output",
+							Usage: "output file path, or \"-\" for the stdout (default: gpud-metrics.<format>)",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:  "npd-plugin",
+			Usage: "check a component once as a node-problem-detector (NPD) custom plugin (exit code 0: OK, 1: NonOK, 2: Unknown, with the message in the stdout), without running the gpud daemon",
+			UsageText: `# to check the GPU ECC errors from the NPD custom plugin monitor
+gpud npd-plugin --component accelerator-nvidia-ecc
+
+# supported components
+` + strings.Join(npd.SupportedComponents(), "\n") + `
+`,
+			Action: cmdNPDPlugin,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:        "log-level,l",
+					Usage:       "set the logging level [debug, info, warn, error, fatal, panic, dpanic]",
+					Value:       "error",
+					Destination: &logLevel,
+				},
+				cli.StringFlag{
+					Name:  "component",
+					Usage: "component name to check (see the supported components above)",
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "timeout to wait for the component states (shorter than the NPD plugin timeout)",
+					Value: npd.DefaultTimeout,
+				},
+				cli.IntFlag{
+					Name:  "max-output-length",
+					Usage: "max length of the message (same as the NPD \"max_output_length\")",
+					Value: npd.DefaultMaxOutputLength,
+				},
+			},
+		},
+		{
+			Name:  "explain",
+			Usage: "run a component once and explain its health verdict (the states, and the rules evaluated against the observed values with the thresholds applied), without running the gpud daemon",
+			UsageText: `# to explain the GPU temperature health verdict (e.g., to tune the thresholds)
+gpud explain accelerator-nvidia-temperature
+
+# to print the report in JSON
+gpud explain --json accelerator-nvidia-power
+
+# supported components
+` + strings.Join(npd.SupportedComponents(), "\n") + `
+`,
+			Action: cmdExplain,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:        "log-level,l",
+					Usage:       "set the logging level [debug, info, warn, error, fatal, panic, dpanic]",
+					Value:       "error",
+					Destination: &logLevel,
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "timeout to wait for the component states",
+					Value: explain.DefaultTimeout,
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "print the report in JSON",
+				},
+			},
+		},
+		{
+			Name:  "lock-gpu-clocks",
+			Usage: "lock the NVIDIA GPU graphics clocks (equivalent to 'nvidia-smi --lock-gpu-clocks')",
+			UsageText: `# to lock the graphics clocks of all the GPUs
+sudo gpud lock-gpu-clocks --min-mhz 1980 --max-mhz 1980
+
+# to reset the locked clocks
+sudo gpud lock-gpu-clocks --reset
+`,
+			Action: cmdLockGPUClocks,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "uuid",
+					Usage: "GPU UUID to lock the clocks (default: all GPUs)",
+				},
+				cli.UintFlag{
+					Name:  "min-mhz",
+					Usage: "minimum graphics clock in MHz",
+				},
+				cli.UintFlag{
+					Name:  "max-mhz",
+					Usage: "maximum graphics clock in MHz",
+				},
+				cli.BoolFlag{
+					Name:  "reset",
+					Usage: "reset the locked clocks to the default",
+				},
+			},
+		},
+		{
+			Name:  "reset-gpu-counters",
+			Usage: "reset the NVIDIA GPU volatile ECC or NVLink error counters, recording the pre-reset values",
+			UsageText: `# to reset the volatile ECC error counters of all the GPUs after a repair
+sudo gpud reset-gpu-counters --type ecc --reason "replaced HBM (ticket 1234)"
+
+# to reset the NVLink error counters of a GPU even with running processes
+sudo gpud reset-gpu-counters --type nvlink --uuid GPU-... --reason "reseated cable" --force
+`,
+			Action: cmdResetGPUCounters,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "type",
+					Usage: "counter type to reset (ecc, nvlink)",
+					Value: "ecc",
+				},
+				cli.StringFlag{
+					Name:  "uuid",
+					Usage: "GPU UUID to reset the counters (default: all GPUs)",
+				},
+				cli.StringFlag{
+					Name:  "reason",
+					Usage: "reason of the reset to record in the audit history (required)",
+				},
+				cli.BoolFlag{
+					Name:  "force",
+					Usage: "reset the counters even if compute processes are running on the GPU",
+				},
+			},
+		},
+		{
+			Name:  "memtest",
+			Usage: "run the extensive GPU memory test (after DBE events or before returning a node to service), recording the pass/fail verdict per GPU",
+			UsageText: `# to test all the GPUs with the DCGM memory test, after draining the node
+sudo gpud memtest --reason "post-DBE validation (ticket 1234)" --drain-command "kubectl drain $(hostname) --ignore-daemonsets"
+
+# to test a GPU with cuda_memtest
+sudo gpud memtest --tool cuda --uuid GPU-... --reason "before returning to service"
+`,
+			Action: cmdMemtest,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "tool",
+					Usage: "memory test tool (dcgm, cuda)",
+					Value: "dcgm",
+				},
+				cli.StringFlag{
+					Name:  "uuid",
+					Usage: "GPU UUID to test (default: all GPUs)",
+				},
+				cli.StringFlag{
+					Name:  "reason",
+					Usage: "reason of the test to record in the GPU ledger (required)",
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "timeout of the memory test per GPU",
+					Value: nvidia_query_memtest.DefaultTimeout,
+				},
+				cli.StringFlag{
+					Name:  "drain-command",
+					Usage: "command to drain the node before the test (e.g., kubectl drain), then waits for the GPU processes to exit",
+				},
+				cli.DurationFlag{
+					Name:  "drain-timeout",
+					Usage: "timeout to wait for the GPU processes to exit",
+					Value: 30 * time.Minute,
+				},
+			},
+		},
+		{
+			Name:  "dcgm-diag",
+			Usage: "run the DCGM diagnostics (dcgmi diag) on all the GPUs, recording the pass/fail verdict per GPU",
+			UsageText: `# to run the quick deployment checks
+sudo gpud dcgm-diag --reason "acceptance"
+
+# to run the extended stress tests on a drained node
+sudo gpud dcgm-diag --level 3 --reason "post-repair validation (ticket 1234)"
+`,
+			Action: cmdDCGMDiag,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "level",
+					Usage: "diagnostics level (1: quick, 2: medium, 3: long)",
+					Value: nvidia_query_dcgm_diag.LevelQuick,
+				},
+				cli.StringFlag{
+					Name:  "reason",
+					Usage: "reason of the diagnostics to record in the GPU ledger (required)",
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "timeout of the diagnostics",
+					Value: time.Hour,
+				},
+			},
+		},
+		{
+			Name:  "pcie-test",
+			Usage: "run the PCIe bandwidth stress test per GPU slot and compare against the slot baselines (e.g., after riser/cable repairs)",
+			UsageText: `# to record the slot baselines on a known-good node
+sudo gpud pcie-test --set-baseline --reason "acceptance"
+
+# to validate a slot after replacing its riser
+sudo gpud pcie-test --uuid GPU-... --reason "riser replaced (ticket 1234)"
+`,
+			Action: cmdPCIeTest,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "uuid",
+					Usage: "GPU UUID of the slot to test (default: all GPUs)",
+				},
+				cli.StringFlag{
+					Name:  "reason",
+					Usage: "reason of the test to record in the GPU ledger (required)",
+				},
+				cli.IntFlag{
+					Name:  "iterations",
+					Usage: "number of the bandwidth test iterations per slot",
+					Value: nvidia_query_pciebw.DefaultIterations,
+				},
+				cli.Float64Flag{
+					Name:  "tolerance",
+					Usage: "allowed bandwidth drop from the slot baseline in percent",
+					Value: nvidia_query_pciebw.DefaultTolerancePercent,
+				},
+				cli.BoolFlag{
+					Name:  "set-baseline",
+					Usage: "record the passing results as the new slot baselines",
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "timeout of the bandwidth test per slot",
+					Value: nvidia_query_pciebw.DefaultTimeout,
+				},
+			},
+		},
+		{
+			Name:  "check",
+			Usage: "run the node acceptance self-tests",
+			Subcommands: []cli.Command{
+				{
+					Name:  "nccl",
+					Usage: "run the short single-node NCCL all-reduce across all the GPUs and each pair of the neighboring GPUs, flagging the GPUs/links performing far below the peers",
+					UsageText: `# to run the all-reduce self-test on a drained node
+sudo gpud check nccl --reason "acceptance"
+`,
+					Action: cmdCheckNCCL,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "reason",
+							Usage: "reason of the test to record in the GPU ledger (required)",
+						},
+						cli.Float64Flag{
+							Name:  "tolerance",
+							Usage: "allowed bus bandwidth drop of a link from the median of the peer links in percent",
+							Value: nvidia_query_nccl_allreduce.DefaultTolerancePercent,
+						},
+						cli.DurationFlag{
+							Name:  "timeout",
+							Usage: "timeout of the whole self-test",
+							Value: nvidia_query_nccl_allreduce.DefaultTimeout,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:  "ack-event",
+			Usage: "acknowledge the component events as a known issue (not notified again until resolved)",
+			UsageText: `# to acknowledge the xid events with a note
+gpud ack-event --component accelerator-nvidia-error-xid --event xid --note "RMA in progress (ticket 1234)"
+
+# to resolve the acknowledgment
+gpud ack-event --component accelerator-nvidia-error-xid --event xid --resolve
+
+# to list the active acknowledgments
+gpud ack-event --list
+`,
+			Action: cmdAckEvent,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "component",
+					Usage: "component name of the events",
+				},
+				cli.StringFlag{
+					Name:  "event",
+					Usage: "event name to acknowledge (default: all events of the component)",
+				},
+				cli.StringFlag{
+					Name:  "note",
+					Usage: "note for the acknowledgment",
+				},
+				cli.BoolFlag{
+					Name:  "resolve",
+					Usage: "resolve the active acknowledgment",
+				},
+				cli.BoolFlag{
+					Name:  "list",
+					Usage: "list the active acknowledgments",
+				},
+			},
+		},
 		{
 			Name:  "join",
 			Usage: "join gpud machine into a lepton cluster",
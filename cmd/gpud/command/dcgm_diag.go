@@ -0,0 +1,96 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	nvidia_query_dcgm_diag "github.com/leptonai/gpud/components/accelerator/nvidia/query/dcgm-diag"
+	nvidia_query_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/urfave/cli"
+)
+
+func cmdDCGMDiag(cliContext *cli.Context) error {
+	level := cliContext.Int("level")
+	reason := cliContext.String("reason")
+	if reason == "" {
+		return errors.New("--reason must be set (e.g., post-repair validation, repair ticket)")
+	}
+	if _, err := nvidia_query_dcgm_diag.Command(level); err != nil {
+		return err
+	}
+
+	indexes, err := nvidia_query_nvml.GetGPUIndexes()
+	if err != nil {
+		return fmt.Errorf("failed to list gpus: %w", err)
+	}
+	if len(indexes) == 0 {
+		return errors.New("no gpu found")
+	}
+
+	stateFile, err := config.DefaultStateFile()
+	if err != nil {
+		return fmt.Errorf("failed to get state file: %w", err)
+	}
+	db, err := sqlite.Open(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer db.Close()
+
+	rootCtx, rootCancel := context.WithCancel(context.Background())
+	defer rootCancel()
+
+	if err := nvidia_query_gpu_ledger.CreateTableGPULedger(rootCtx, db); err != nil {
+		return fmt.Errorf("failed to create gpu ledger table: %w", err)
+	}
+
+	fmt.Printf("running level %d dcgm diagnostics on %d gpu(s)\n", level, len(indexes))
+	start := time.Now().UTC()
+	cctx, ccancel := context.WithTimeout(rootCtx, cliContext.Duration("timeout"))
+	result := nvidia_query_dcgm_diag.Run(cctx, level)
+	ccancel()
+
+	if err := nvidia_query_dcgm_diag.RecordVerdicts(rootCtx, db, result, indexes, start, getRequestedBy(), reason); err != nil {
+		return fmt.Errorf("failed to record dcgm diagnostics verdicts: %w", err)
+	}
+
+	if result.Error != "" {
+		return fmt.Errorf("dcgm diagnostics failed (took %v): %s", result.Duration.Round(time.Second), result.Error)
+	}
+	for _, t := range result.Tests {
+		mark := checkMark
+		if t.Status == nvidia_query_dcgm_diag.StatusFail || t.Status == nvidia_query_dcgm_diag.StatusWarn {
+			mark = warningSign
+		}
+		fmt.Printf("%s [%s] %s: %s\n", mark, t.Category, t.Name, t.Status)
+		for _, d := range t.Details {
+			fmt.Printf("    %s\n", d)
+		}
+	}
+
+	uuids := make([]string, 0, len(indexes))
+	for uuid := range indexes {
+		uuids = append(uuids, uuid)
+	}
+	sort.Slice(uuids, func(i, j int) bool { return indexes[uuids[i]] < indexes[uuids[j]] })
+
+	failed := 0
+	for _, uuid := range uuids {
+		if tests := result.FailedTests(indexes[uuid]); len(tests) > 0 {
+			fmt.Printf("%s %s (index %d) failed %v\n", warningSign, uuid, indexes[uuid], tests)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d gpu(s) failed the dcgm diagnostics (took %v)", failed, len(indexes), result.Duration.Round(time.Second))
+	}
+	fmt.Printf("%s %d gpu(s) passed the dcgm diagnostics (took %v)\n", checkMark, len(indexes), result.Duration.Round(time.Second))
+	return nil
+}
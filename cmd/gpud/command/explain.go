@@ -0,0 +1,44 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/leptonai/gpud/internal/explain"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/urfave/cli"
+	"go.uber.org/zap"
+)
+
+func cmdExplain(cliContext *cli.Context) error {
+	if logLevel != "" {
+		zapLvl, err := zap.ParseAtomicLevel(logLevel)
+		if err != nil {
+			return err
+		}
+		lCfg := log.DefaultLoggerConfig()
+		lCfg.Level = zapLvl
+		log.Logger = log.CreateLogger(lCfg)
+	}
+
+	name := cliContext.Args().First()
+	if name == "" {
+		return errors.New("component name must be set (e.g., 'gpud explain accelerator-nvidia-temperature')")
+	}
+
+	report, err := explain.Explain(context.Background(), name, cliContext.Duration("timeout"))
+	if err != nil {
+		return fmt.Errorf("failed to explain %s: %w", name, err)
+	}
+
+	if cliContext.Bool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return report.Print(os.Stdout)
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+
+	"github.com/urfave/cli"
+)
+
+func cmdLockGPUClocks(cliContext *cli.Context) error {
+	uuid := cliContext.String("uuid")
+
+	if cliContext.Bool("reset") {
+		if err := nvidia_query_nvml.ResetGPULockedClocks(uuid); err != nil {
+			return err
+		}
+		fmt.Printf("%s successfully reset gpu locked clocks\n", checkMark)
+		return nil
+	}
+
+	minMHz := cliContext.Uint("min-mhz")
+	maxMHz := cliContext.Uint("max-mhz")
+	if maxMHz == 0 {
+		return errors.New("--max-mhz must be set")
+	}
+
+	locked := nvidia_query_nvml.LockedClocks{
+		GraphicsMinMHz: uint32(minMHz),
+		GraphicsMaxMHz: uint32(maxMHz),
+	}
+	if err := nvidia_query_nvml.LockGPUClocks(uuid, locked); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s successfully locked gpu clocks to %d-%d MHz\n", checkMark, locked.GraphicsMinMHz, locked.GraphicsMaxMHz)
+	return nil
+}
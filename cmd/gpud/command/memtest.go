@@ -0,0 +1,130 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"time"
+
+	nvidia_query_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+	nvidia_query_memtest "github.com/leptonai/gpud/components/accelerator/nvidia/query/memtest"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/urfave/cli"
+)
+
+func cmdMemtest(cliContext *cli.Context) error {
+	tool := cliContext.String("tool")
+	reason := cliContext.String("reason")
+	if reason == "" {
+		return errors.New("--reason must be set (e.g., post-DBE validation, repair ticket)")
+	}
+	if _, _, err := nvidia_query_memtest.Command(tool, "", 0); err != nil {
+		return err
+	}
+
+	indexes, err := nvidia_query_nvml.GetGPUIndexes()
+	if err != nil {
+		return fmt.Errorf("failed to list gpus: %w", err)
+	}
+	var uuids []string
+	if uuid := cliContext.String("uuid"); uuid != "" {
+		if _, ok := indexes[uuid]; !ok {
+			return fmt.Errorf("gpu %q not found", uuid)
+		}
+		uuids = []string{uuid}
+	} else {
+		for uuid := range indexes {
+			uuids = append(uuids, uuid)
+		}
+		sort.Slice(uuids, func(i, j int) bool { return indexes[uuids[i]] < indexes[uuids[j]] })
+	}
+	if len(uuids) == 0 {
+		return errors.New("no gpu found")
+	}
+
+	stateFile, err := config.DefaultStateFile()
+	if err != nil {
+		return fmt.Errorf("failed to get state file: %w", err)
+	}
+	db, err := sqlite.Open(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer db.Close()
+
+	rootCtx, rootCancel := context.WithCancel(context.Background())
+	defer rootCancel()
+
+	if err := nvidia_query_gpu_ledger.CreateTableGPULedger(rootCtx, db); err != nil {
+		return fmt.Errorf("failed to create gpu ledger table: %w", err)
+	}
+
+	// the memory test requires the exclusive access to the gpu memory
+	if drainCmd := cliContext.String("drain-command"); drainCmd != "" {
+		fmt.Printf("running drain command %q\n", drainCmd)
+		cmd := exec.CommandContext(rootCtx, "bash", "-c", drainCmd)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("drain command failed: %w", err)
+		}
+	}
+	fmt.Printf("waiting for the processes on %d gpu(s) to drain\n", len(uuids))
+	dctx, dcancel := context.WithTimeout(rootCtx, cliContext.Duration("drain-timeout"))
+	err = nvidia_query_memtest.WaitForDrain(dctx, uuids, nvidia_query_memtest.DefaultDrainInterval, nvidia_query_nvml.GetGPULoads)
+	dcancel()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s gpus drained\n", checkMark)
+
+	requestedBy := getRequestedBy()
+	failed := 0
+	for i, uuid := range uuids {
+		fmt.Printf("\n[%d/%d] running %s memory test on %s (index %d)\n", i+1, len(uuids), tool, uuid, indexes[uuid])
+
+		start := time.Now().UTC()
+		cctx, ccancel := context.WithTimeout(rootCtx, cliContext.Duration("timeout"))
+		result := nvidia_query_memtest.Run(cctx, tool, uuid, indexes[uuid], func(line string) {
+			fmt.Printf("[%s %v] %s\n", uuid, time.Since(start).Round(time.Second), line)
+		})
+		ccancel()
+
+		verdict := nvidia_query_gpu_ledger.VerdictPass
+		details := fmt.Sprintf("tool=%s", tool)
+		if !result.Passed {
+			verdict = nvidia_query_gpu_ledger.VerdictFail
+			details = fmt.Sprintf("tool=%s error=%s\n%s", tool, result.Error, result.Output)
+			failed++
+		}
+		if err := nvidia_query_gpu_ledger.InsertEntry(rootCtx, db, nvidia_query_gpu_ledger.Entry{
+			UnixSeconds:     start.Unix(),
+			GPUUUID:         uuid,
+			Test:            nvidia_query_memtest.TestName,
+			Verdict:         verdict,
+			RequestedBy:     requestedBy,
+			Reason:          reason,
+			DurationSeconds: result.Duration.Seconds(),
+			Details:         details,
+		}); err != nil {
+			return fmt.Errorf("failed to record memory test verdict: %w", err)
+		}
+
+		if result.Passed {
+			fmt.Printf("%s %s passed the memory test (took %v)\n", checkMark, uuid, result.Duration.Round(time.Second))
+		} else {
+			fmt.Printf("%s %s failed the memory test (took %v): %s\n", warningSign, uuid, result.Duration.Round(time.Second), result.Error)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d gpu(s) failed the memory test", failed, len(uuids))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	metrics_export "github.com/leptonai/gpud/components/metrics/export"
+	metrics_state "github.com/leptonai/gpud/components/metrics/state"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/urfave/cli"
+)
+
+func cmdMetricsExport(cliContext *cli.Context) error {
+	format := cliContext.String("format")
+	if format != metrics_export.FormatCSV && format != metrics_export.FormatParquet {
+		return fmt.Errorf("unknown format %q (supported: %s, %s)", format, metrics_export.FormatCSV, metrics_export.FormatParquet)
+	}
+	since, err := metrics_export.ParseSince(cliContext.String("since"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	stateFile, err := config.DefaultStateFile()
+	if err != nil {
+		return fmt.Errorf("failed to get state file: %w", err)
+	}
+	db, err := sqlite.Open(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// no-op if the daemon already created the table
+	if err := metrics_state.CreateTableMetrics(ctx, db, metrics_state.DefaultTableName); err != nil {
+		return fmt.Errorf("failed to create metrics table: %w", err)
+	}
+	metrics, err := metrics_state.ReadAllMetricsSince(ctx, db, metrics_state.DefaultTableName, since)
+	if err != nil {
+		return fmt.Errorf("failed to read metrics: %w", err)
+	}
+
+	output := cliContext.String("output")
+	if output == "" {
+		output = "gpud-metrics." + format
+	}
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := metrics_export.Write(w, format, metrics); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if output != "-" {
+		fmt.Printf("%s exported %d metrics since %s to %s\n", checkMark, len(metrics), since.UTC().Format(time.RFC3339), output)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/leptonai/gpud/internal/npd"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/urfave/cli"
+	"go.uber.org/zap"
+)
+
+func cmdNPDPlugin(cliContext *cli.Context) error {
+	// the logs go to the stderr, and NPD only reads the stdout
+	if logLevel != "" {
+		zapLvl, err := zap.ParseAtomicLevel(logLevel)
+		if err != nil {
+			return err
+		}
+		lCfg := log.DefaultLoggerConfig()
+		lCfg.Level = zapLvl
+		log.Logger = log.CreateLogger(lCfg)
+	}
+
+	name := cliContext.String("component")
+	if name == "" {
+		fmt.Println("--component must be set")
+		os.Exit(npd.ExitCodeUnknown)
+	}
+
+	code, msg := npd.Check(context.Background(), name, cliContext.Duration("timeout"), cliContext.Int("max-output-length"))
+	fmt.Println(msg)
+	os.Exit(code)
+	return nil
+}
@@ -0,0 +1,161 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	nvidia_query_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	nvidia_query_pciebw "github.com/leptonai/gpud/components/accelerator/nvidia/query/pciebw"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/urfave/cli"
+)
+
+func cmdPCIeTest(cliContext *cli.Context) error {
+	reason := cliContext.String("reason")
+	if reason == "" {
+		return errors.New("--reason must be set (e.g., riser replaced, repair ticket)")
+	}
+	iterations := cliContext.Int("iterations")
+	if iterations <= 0 {
+		return errors.New("--iterations must be positive")
+	}
+	tolerance := cliContext.Float64("tolerance")
+	if tolerance < 0 || tolerance >= 100 {
+		return fmt.Errorf("--tolerance must be between 0 and 100, got %v", tolerance)
+	}
+	setBaseline := cliContext.Bool("set-baseline")
+
+	links, err := nvidia_query_nvml.GetPCIeLinks()
+	if err != nil {
+		return fmt.Errorf("failed to get pcie links: %w", err)
+	}
+	if uuid := cliContext.String("uuid"); uuid != "" {
+		var filtered []nvidia_query_nvml.PCIeLink
+		for _, l := range links {
+			if l.UUID == uuid {
+				filtered = append(filtered, l)
+			}
+		}
+		if len(filtered) == 0 {
+			return fmt.Errorf("gpu %q not found", uuid)
+		}
+		links = filtered
+	}
+	if len(links) == 0 {
+		return errors.New("no gpu found")
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].Index < links[j].Index })
+
+	stateFile, err := config.DefaultStateFile()
+	if err != nil {
+		return fmt.Errorf("failed to get state file: %w", err)
+	}
+	db, err := sqlite.Open(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer db.Close()
+
+	rootCtx, rootCancel := context.WithCancel(context.Background())
+	defer rootCancel()
+
+	if err := nvidia_query_gpu_ledger.CreateTableGPULedger(rootCtx, db); err != nil {
+		return fmt.Errorf("failed to create gpu ledger table: %w", err)
+	}
+	if err := nvidia_query_pciebw.CreateTableBaseline(rootCtx, db); err != nil {
+		return fmt.Errorf("failed to create pcie baseline table: %w", err)
+	}
+	baselines, err := nvidia_query_pciebw.ReadBaselines(rootCtx, db)
+	if err != nil {
+		return fmt.Errorf("failed to read pcie baselines: %w", err)
+	}
+
+	requestedBy := getRequestedBy()
+	failed := 0
+	for i, link := range links {
+		fmt.Printf("\n[%d/%d] running pcie bandwidth test on %s (slot %s, gen %d/%d, width x%d/x%d)\n",
+			i+1, len(links), link.UUID, link.BusID, link.CurrentGen, link.MaxGen, link.CurrentWidth, link.MaxWidth)
+
+		start := time.Now().UTC()
+		cctx, ccancel := context.WithTimeout(rootCtx, cliContext.Duration("timeout"))
+		m, err := nvidia_query_pciebw.Measure(cctx, link, iterations, func(iteration int, h2d float64, d2h float64) {
+			fmt.Printf("[%s %d/%d] h2d %.1f GB/s, d2h %.1f GB/s\n", link.BusID, iteration, iterations, h2d, d2h)
+		})
+		ccancel()
+		took := time.Since(start)
+
+		var baseline *nvidia_query_pciebw.Baseline
+		if b, ok := baselines[link.BusID]; ok && !setBaseline {
+			baseline = &b
+		}
+
+		var (
+			passed  bool
+			reasons []string
+		)
+		if err != nil {
+			reasons = append(reasons, err.Error())
+		} else {
+			passed, reasons = nvidia_query_pciebw.Compare(m, baseline, tolerance)
+		}
+
+		verdict := nvidia_query_gpu_ledger.VerdictPass
+		if !passed {
+			verdict = nvidia_query_gpu_ledger.VerdictFail
+			failed++
+		}
+		details := fmt.Sprintf("slot=%s h2d=%.1f d2h=%.1f min_h2d=%.1f min_d2h=%.1f width=x%d/x%d", link.BusID, m.H2DGBps, m.D2HGBps, m.MinH2DGBps, m.MinD2HGBps, link.CurrentWidth, link.MaxWidth)
+		if baseline != nil {
+			details += fmt.Sprintf(" baseline_h2d=%.1f baseline_d2h=%.1f", baseline.H2DGBps, baseline.D2HGBps)
+		}
+		if len(reasons) > 0 {
+			details += "\n" + strings.Join(reasons, "\n")
+		}
+		if err := nvidia_query_gpu_ledger.InsertEntry(rootCtx, db, nvidia_query_gpu_ledger.Entry{
+			UnixSeconds:     start.Unix(),
+			GPUUUID:         link.UUID,
+			Test:            nvidia_query_pciebw.TestName,
+			Verdict:         verdict,
+			RequestedBy:     requestedBy,
+			Reason:          reason,
+			DurationSeconds: took.Seconds(),
+			Details:         details,
+		}); err != nil {
+			return fmt.Errorf("failed to record pcie test verdict: %w", err)
+		}
+
+		if !passed {
+			fmt.Printf("%s slot %s (%s) failed the pcie bandwidth test: %s\n", warningSign, link.BusID, link.UUID, strings.Join(reasons, "; "))
+			continue
+		}
+
+		switch {
+		case setBaseline:
+			if err := nvidia_query_pciebw.SetBaseline(rootCtx, db, nvidia_query_pciebw.Baseline{
+				BusID:   link.BusID,
+				GPUUUID: link.UUID,
+				H2DGBps: m.H2DGBps,
+				D2HGBps: m.D2HGBps,
+			}); err != nil {
+				return fmt.Errorf("failed to set pcie baseline: %w", err)
+			}
+			fmt.Printf("%s slot %s baseline set to h2d %.1f GB/s, d2h %.1f GB/s\n", checkMark, link.BusID, m.H2DGBps, m.D2HGBps)
+		case baseline == nil:
+			fmt.Printf("%s slot %s passed the pcie bandwidth test (h2d %.1f GB/s, d2h %.1f GB/s, no baseline to compare -- run with --set-baseline on a known-good slot)\n", checkMark, link.BusID, m.H2DGBps, m.D2HGBps)
+		default:
+			fmt.Printf("%s slot %s passed the pcie bandwidth test (h2d %.1f/%.1f GB/s, d2h %.1f/%.1f GB/s against baseline)\n", checkMark, link.BusID, m.H2DGBps, baseline.H2DGBps, m.D2HGBps, baseline.D2HGBps)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d gpu slot(s) failed the pcie bandwidth test", failed, len(links))
+	}
+	return nil
+}
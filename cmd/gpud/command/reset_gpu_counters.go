@@ -0,0 +1,80 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	nvidia_query_counter_reset_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/counter-reset-state"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/urfave/cli"
+)
+
+func cmdResetGPUCounters(cliContext *cli.Context) error {
+	counterType := cliContext.String("type")
+	uuid := cliContext.String("uuid")
+	reason := cliContext.String("reason")
+	if reason == "" {
+		return errors.New("--reason must be set (e.g., repair ticket)")
+	}
+
+	stateFile, err := config.DefaultStateFile()
+	if err != nil {
+		return fmt.Errorf("failed to get state file: %w", err)
+	}
+	db, err := sqlite.Open(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := nvidia_query_counter_reset_state.CreateTableCounterResetHistory(ctx, db); err != nil {
+		return fmt.Errorf("failed to create counter reset state table: %w", err)
+	}
+
+	// record the snapshots even if the reset failed partway
+	snapshots, resetErr := nvidia_query_nvml.ResetCounters(uuid, counterType, cliContext.Bool("force"))
+
+	requestedBy := getRequestedBy()
+	now := time.Now().UTC()
+	for _, snapshot := range snapshots {
+		b, err := json.Marshal(snapshot)
+		if err != nil {
+			return fmt.Errorf("failed to marshal counter snapshot: %w", err)
+		}
+		if err := nvidia_query_counter_reset_state.InsertEvent(ctx, db, nvidia_query_counter_reset_state.Event{
+			UnixSeconds: now.Unix(),
+			RequestedBy: requestedBy,
+			Reason:      reason,
+			CounterType: counterType,
+			GPUUUID:     snapshot.UUID,
+			Snapshot:    string(b),
+		}); err != nil {
+			return fmt.Errorf("failed to record counter reset: %w", err)
+		}
+		fmt.Printf("%s successfully reset %s counters on %s\n", checkMark, counterType, snapshot.UUID)
+	}
+	return resetErr
+}
+
+// getRequestedBy returns the user who runs the command,
+// including the original user if run via sudo.
+func getRequestedBy() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && sudoUser != name {
+		name = fmt.Sprintf("%s (sudo by %s)", name, sudoUser)
+	}
+	return name
+}
@@ -11,6 +11,9 @@ import (
 	lepServer "github.com/leptonai/gpud/internal/server"
 	"github.com/leptonai/gpud/log"
 	"github.com/leptonai/gpud/manager"
+	"github.com/leptonai/gpud/pkg/locale"
+	"github.com/leptonai/gpud/pkg/lowoverhead"
+	"github.com/leptonai/gpud/pkg/netutil"
 	pkd_systemd "github.com/leptonai/gpud/pkg/systemd"
 	"github.com/leptonai/gpud/version"
 
@@ -43,6 +46,8 @@ func cmdRun(cliContext *cli.Context) error {
 		config.WithFilesToCheck(filesToCheck...),
 		config.WithDockerIgnoreConnectionErrors(dockerIgnoreConnectionErrors),
 		config.WithKubeletIgnoreConnectionErrors(kubeletIgnoreConnectionErrors),
+		config.WithProfile(config.Profile(profile)),
+		config.WithGPUExpected(gpuExpected),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
@@ -62,6 +67,15 @@ func cmdRun(cliContext *cli.Context) error {
 	if listenAddress != "" {
 		cfg.Address = listenAddress
 	}
+	if addressFamily != "" {
+		cfg.AddressFamily = netutil.AddressFamily(addressFamily)
+	}
+	if caBundle != "" {
+		if cfg.Proxy == nil {
+			cfg.Proxy = &netutil.ProxyConfig{}
+		}
+		cfg.Proxy.CABundle = caBundle
+	}
 	if pprof {
 		cfg.Pprof = true
 	}
@@ -78,12 +92,31 @@ func cmdRun(cliContext *cli.Context) error {
 		cfg.Web.RefreshPeriod = metav1.Duration{Duration: webRefreshPeriod}
 	}
 
+	if localeName != "" {
+		cfg.Locale = localeName
+	}
+
+	if lowOverhead {
+		cfg.LowOverhead = &lowoverhead.Config{}
+	}
+
+	if highFrequencyMetricsPath != "" {
+		cfg.HighFrequencyMetrics = &config.HighFrequencyMetrics{
+			Path:     highFrequencyMetricsPath,
+			Interval: config.DefaultHighFrequencyMetricsInterval,
+			Capacity: config.DefaultHighFrequencyMetricsCapacity,
+		}
+	}
+
 	cfg.EnableAutoUpdate = enableAutoUpdate
 	cfg.AutoUpdateExitCode = autoUpdateExitCode
 
 	if err := cfg.Validate(); err != nil {
 		return err
 	}
+	if err := locale.Set(cfg.Locale); err != nil {
+		return err
+	}
 
 	rootCtx, rootCancel := context.WithCancel(context.Background())
 	defer rootCancel()
@@ -0,0 +1,3 @@
+// Package amd contains the AMD accelerator (e.g., Instinct MI250, MI300) components
+// and its query interface.
+package amd
@@ -0,0 +1,153 @@
+package ecc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/leptonai/gpud/components"
+	amd_query "github.com/leptonai/gpud/components/accelerator/amd/query"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	"github.com/leptonai/gpud/components/common"
+)
+
+// ToOutput converts amd_query.Output to Output.
+// It returns an empty non-nil object, if the input is nil.
+func ToOutput(i *amd_query.Output) *Output {
+	if i == nil {
+		return &Output{}
+	}
+
+	o := &Output{}
+	for _, g := range i.GPUs {
+		o.ErrorCounts = append(o.ErrorCounts, ErrorCount{
+			GPU: g.Key(),
+			ECC: g.ECC,
+		})
+		if g.ECC.UncorrectableCount > 0 {
+			o.UncorrectableErrors = append(o.UncorrectableErrors, fmt.Sprintf("[%s] %d uncorrectable errors", g.Key(), g.ECC.UncorrectableCount))
+		}
+	}
+	return o
+}
+
+type Output struct {
+	ErrorCounts []ErrorCount `json:"error_counts,omitempty"`
+
+	// UncorrectableErrors is the list of the GPUs with the uncorrectable errors,
+	// which the driver may not recover from without the GPU reset
+	// (the faulty memory pages are retired on the next driver load).
+	UncorrectableErrors []string `json:"uncorrectable_errors,omitempty"`
+}
+
+type ErrorCount struct {
+	// GPU is the PCI bus ID of the GPU.
+	GPU string `json:"gpu"`
+	amd_query.ECC
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameECC = "ecc"
+
+	StateKeyECCData           = "data"
+	StateKeyECCEncoding       = "encoding"
+	StateValueECCEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameECC, "per-GPU ECC error counts"),
+		nvidia_query.GPUStateSchema(StateNameECC, "ECC health of the GPU, one state per GPU"),
+	)
+}
+
+func ParseStateECC(m map[string]string) (*Output, error) {
+	data := m[StateKeyECCData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameECC:
+			o, err := ParseStateECC(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			if nvidia_query.IsGPUStateName(StateNameECC, state.Name) {
+				// per-GPU states, derived from the component state
+				continue
+			}
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+var uncorrectableActions = &common.SuggestedActions{
+	Descriptions: []string{
+		"reset the GPU (or reboot the system) to retire the faulty memory pages, and inspect the GPU if the errors persist",
+	},
+	RepairActions: []common.RepairActionType{
+		common.RepairActionTypeRebootSystem,
+		common.RepairActionTypeHardwareInspection,
+	},
+}
+
+// States returns the ECC state, unhealthy if any GPU has the uncorrectable errors.
+func (o *Output) States() ([]components.State, error) {
+	healthy := true
+	reason := "no uncorrectable error found"
+	var suggestedActions *common.SuggestedActions
+	if len(o.UncorrectableErrors) > 0 {
+		healthy = false
+		reason = fmt.Sprintf("uncorrectable errors found: %s", strings.Join(o.UncorrectableErrors, ", "))
+		suggestedActions = uncorrectableActions
+	}
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameECC,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyECCData:     string(b),
+			StateKeyECCEncoding: StateValueECCEncodingJSON,
+		},
+		SuggestedActions: suggestedActions,
+	}
+	return append([]components.State{state}, o.GPUStates()...), nil
+}
+
+// GPUStates returns the per-GPU states (e.g., "ecc_0000:05:00.0"),
+// unhealthy if the GPU has the uncorrectable errors.
+func (o *Output) GPUStates() []components.State {
+	gs := nvidia_query.NewGPUStates(StateNameECC)
+	for _, ec := range o.ErrorCounts {
+		gs.Add(ec.GPU)
+		if ec.UncorrectableCount == 0 {
+			continue
+		}
+		gs.Unhealthy(ec.GPU,
+			fmt.Sprintf("%d uncorrectable errors (%d deferred)", ec.UncorrectableCount, ec.DeferredCount),
+			uncorrectableActions,
+		)
+	}
+	return gs.States()
+}
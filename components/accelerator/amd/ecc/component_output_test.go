@@ -0,0 +1,45 @@
+package ecc
+
+import (
+	"testing"
+
+	amd_query "github.com/leptonai/gpud/components/accelerator/amd/query"
+)
+
+func TestOutputStates(t *testing.T) {
+	o := ToOutput(&amd_query.Output{
+		SMIExists: true,
+		GPUs: []amd_query.GPU{
+			{ID: 0, BDF: "0000:05:00.0", ECC: amd_query.ECC{Supported: true, CorrectableCount: 12}},
+			{ID: 1, BDF: "0000:26:00.0", ECC: amd_query.ECC{Supported: true, UncorrectableCount: 2, DeferredCount: 1}},
+		},
+	})
+	if len(o.UncorrectableErrors) != 1 {
+		t.Fatalf("expected 1 uncorrectable error, got %v", o.UncorrectableErrors)
+	}
+
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 3 {
+		t.Fatalf("expected 3 states, got %d", len(states))
+	}
+	if states[0].Healthy || states[0].SuggestedActions == nil {
+		t.Errorf("expected unhealthy component state with suggested actions, got %+v", states[0])
+	}
+	if !states[1].Healthy || states[1].Name != "ecc_0000:05:00.0" {
+		t.Errorf("unexpected gpu state %+v", states[1])
+	}
+	if states[2].Healthy || states[2].Name != "ecc_0000:26:00.0" {
+		t.Errorf("unexpected gpu state %+v", states[2])
+	}
+
+	parsed, err := ParseStatesToOutput(states...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.ErrorCounts) != 2 || parsed.ErrorCounts[1].UncorrectableCount != 2 {
+		t.Errorf("unexpected parsed output %+v", parsed)
+	}
+}
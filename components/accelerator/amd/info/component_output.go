@@ -0,0 +1,135 @@
+package info
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/leptonai/gpud/components"
+	amd_query "github.com/leptonai/gpud/components/accelerator/amd/query"
+	"github.com/leptonai/gpud/pkg/locale"
+)
+
+// ToOutput converts amd_query.Output to Output.
+// It returns an empty non-nil object, if the input is nil.
+func ToOutput(i *amd_query.Output) *Output {
+	if i == nil {
+		return &Output{}
+	}
+
+	o := &Output{
+		PCIGPUCount: i.PCIGPUCount,
+		SMIGPUCount: len(i.GPUs),
+	}
+	for _, g := range i.GPUs {
+		o.GPUs = append(o.GPUs, GPU{
+			ID:                 g.ID,
+			BDF:                g.BDF,
+			MarketName:         g.MarketName,
+			DeviceID:           g.DeviceID,
+			Serial:             g.Serial,
+			DriverVersion:      g.DriverVersion,
+			VBIOSVersion:       g.VBIOSVersion,
+			VRAMType:           g.VRAMType,
+			VRAMTotalBytes:     g.VRAMTotalBytes,
+			VRAMTotalHumanized: locale.Bytes(g.VRAMTotalBytes),
+		})
+	}
+	return o
+}
+
+type Output struct {
+	// PCIGPUCount is the AMD GPU count from the PCI bus.
+	PCIGPUCount int `json:"pci_gpu_count"`
+	// SMIGPUCount is the GPU count reported by amd-smi.
+	SMIGPUCount int `json:"smi_gpu_count"`
+
+	GPUs []GPU `json:"gpus,omitempty"`
+}
+
+type GPU struct {
+	ID  int    `json:"id"`
+	BDF string `json:"bdf"`
+
+	MarketName    string `json:"market_name"`
+	DeviceID      string `json:"device_id"`
+	Serial        string `json:"serial"`
+	DriverVersion string `json:"driver_version"`
+	VBIOSVersion  string `json:"vbios_version"`
+
+	VRAMType           string `json:"vram_type"`
+	VRAMTotalBytes     uint64 `json:"vram_total_bytes"`
+	VRAMTotalHumanized string `json:"vram_total_humanized"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameInfo = "info"
+
+	StateKeyInfoData           = "data"
+	StateKeyInfoEncoding       = "encoding"
+	StateValueInfoEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameInfo, "AMD GPU inventory"),
+	)
+}
+
+func ParseStateInfo(m map[string]string) (*Output, error) {
+	data := m[StateKeyInfoData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameInfo:
+			o, err := ParseStateInfo(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// States returns the inventory state, unhealthy if amd-smi reports fewer GPUs
+// than found on the PCI bus (e.g., the GPU fell off the bus or failed to initialize).
+func (o *Output) States() ([]components.State, error) {
+	healthy := true
+	reason := fmt.Sprintf("%d gpu(s) found", o.SMIGPUCount)
+	if o.SMIGPUCount < o.PCIGPUCount {
+		healthy = false
+		reason = fmt.Sprintf("amd-smi found %d gpu(s) but %d gpu(s) found on the pci bus", o.SMIGPUCount, o.PCIGPUCount)
+	}
+
+	b, _ := o.JSON()
+	return []components.State{
+		{
+			Name:    StateNameInfo,
+			Healthy: healthy,
+			Reason:  reason,
+			ExtraInfo: map[string]string{
+				StateKeyInfoData:     string(b),
+				StateKeyInfoEncoding: StateValueInfoEncodingJSON,
+			},
+		},
+	}, nil
+}
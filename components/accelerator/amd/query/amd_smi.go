@@ -0,0 +1,227 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/leptonai/gpud/pkg/execlimit"
+	"github.com/leptonai/gpud/pkg/file"
+)
+
+// SMIExists returns true if the amd-smi (ROCm SMI successor) is installed.
+func SMIExists() bool {
+	p, err := file.LocateExecutable("amd-smi")
+	if err != nil {
+		return false
+	}
+	return p != ""
+}
+
+// RunSMI runs the amd-smi command with the arguments and returns the standard output.
+func RunSMI(ctx context.Context, args ...string) ([]byte, error) {
+	p, err := file.LocateExecutable("amd-smi")
+	if err != nil {
+		return nil, fmt.Errorf("amd-smi not found (%w)", err)
+	}
+
+	if err := execlimit.Wait(ctx, "amd-smi"); err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, p, args...)
+
+	// in case of the driver issue, the amd-smi may get stuck in the uninterruptible sleep
+	// (same as nvidia-smi), thus returns on the context cancellation without waiting for the process
+	errc := make(chan error, 1)
+	var output []byte
+	go func() {
+		var err error
+		output, err = cmd.Output()
+		errc <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case err := <-errc:
+		if err != nil {
+			return nil, fmt.Errorf("amd-smi command failed: %w", err)
+		}
+		return output, nil
+	}
+}
+
+// Value is the amd-smi numeric value, either a number, a numeric string with the unit (e.g., "196592 MB"),
+// "N/A" if not supported, or an object with the value and the unit (e.g., {"value": 35, "unit": "C"}),
+// depending on the amd-smi version.
+type Value struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+	// Valid is false if the value is not available (e.g., "N/A").
+	Valid bool `json:"valid"`
+}
+
+func (v *Value) UnmarshalJSON(b []byte) error {
+	*v = Value{}
+
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || string(b) == "null" {
+		return nil
+	}
+
+	switch b[0] {
+	case '{':
+		var obj struct {
+			Value json.RawMessage `json:"value"`
+			Unit  string          `json:"unit"`
+		}
+		if err := json.Unmarshal(b, &obj); err != nil {
+			return err
+		}
+		if err := v.UnmarshalJSON(obj.Value); err != nil {
+			return err
+		}
+		if obj.Unit != "" {
+			v.Unit = obj.Unit
+		}
+		return nil
+
+	case '"':
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		fields := strings.Fields(s)
+		if len(fields) == 0 {
+			return nil
+		}
+		f, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			// e.g., "N/A"
+			return nil
+		}
+		v.Value, v.Valid = f, true
+		if len(fields) > 1 {
+			v.Unit = fields[1]
+		}
+		return nil
+
+	default:
+		f, err := strconv.ParseFloat(string(b), 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse amd-smi value %q: %w", string(b), err)
+		}
+		v.Value, v.Valid = f, true
+		return nil
+	}
+}
+
+// Text is the amd-smi text value, which may be a non-string value in some versions.
+type Text string
+
+func (t *Text) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = Text(s)
+		return nil
+	}
+	*t = Text(strings.TrimSpace(string(b)))
+	return nil
+}
+
+// StaticGPU is the "amd-smi static --json" output of a GPU.
+type StaticGPU struct {
+	GPU  int `json:"gpu"`
+	ASIC struct {
+		MarketName Text `json:"market_name"`
+		VendorID   Text `json:"vendor_id"`
+		DeviceID   Text `json:"device_id"`
+		ASICSerial Text `json:"asic_serial"`
+	} `json:"asic"`
+	Bus struct {
+		BDF Text `json:"bdf"`
+	} `json:"bus"`
+	Driver struct {
+		Name    Text `json:"name"`
+		Version Text `json:"version"`
+	} `json:"driver"`
+	VBIOS struct {
+		Version Text `json:"version"`
+	} `json:"vbios"`
+	VRAM struct {
+		Type Text  `json:"type"`
+		Size Value `json:"size"`
+	} `json:"vram"`
+}
+
+// MetricGPU is the "amd-smi metric --temperature --ecc --xgmi-err --json" output of a GPU.
+type MetricGPU struct {
+	GPU         int `json:"gpu"`
+	Temperature struct {
+		Edge    Value `json:"edge"`
+		Hotspot Value `json:"hotspot"`
+		Mem     Value `json:"mem"`
+	} `json:"temperature"`
+	ECC struct {
+		TotalCorrectableCount   Value `json:"total_correctable_count"`
+		TotalUncorrectableCount Value `json:"total_uncorrectable_count"`
+		TotalDeferredCount      Value `json:"total_deferred_count"`
+	} `json:"ecc"`
+	XGMIErr Text `json:"xgmi_err"`
+}
+
+// ParseStatic parses the "amd-smi static --json" output.
+func ParseStatic(b []byte) ([]StaticGPU, error) {
+	var gpus []StaticGPU
+	if err := unmarshalGPUs(b, &gpus); err != nil {
+		return nil, fmt.Errorf("failed to parse amd-smi static output: %w", err)
+	}
+	return gpus, nil
+}
+
+// ParseMetric parses the "amd-smi metric --json" output.
+func ParseMetric(b []byte) ([]MetricGPU, error) {
+	var gpus []MetricGPU
+	if err := unmarshalGPUs(b, &gpus); err != nil {
+		return nil, fmt.Errorf("failed to parse amd-smi metric output: %w", err)
+	}
+	return gpus, nil
+}
+
+// unmarshalGPUs unmarshals the per-GPU list, either at the top level (ROCm 6.0-6.3)
+// or under the "gpu_data" key (ROCm 6.4+).
+func unmarshalGPUs(b []byte, v any) error {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return errors.New("empty output")
+	}
+	if b[0] == '[' {
+		return json.Unmarshal(b, v)
+	}
+	var wrapped struct {
+		GPUData json.RawMessage `json:"gpu_data"`
+	}
+	if err := json.Unmarshal(b, &wrapped); err != nil {
+		return err
+	}
+	if len(wrapped.GPUData) == 0 {
+		return errors.New("no gpu data found")
+	}
+	return json.Unmarshal(wrapped.GPUData, v)
+}
+
+// IsXGMIError returns true if the amd-smi XGMI error status reports the link errors
+// (e.g., "Single error detected since last read", "Multiple errors detected since last read").
+func IsXGMIError(status string) bool {
+	s := strings.ToLower(strings.TrimSpace(status))
+	if s == "" || s == "n/a" || strings.HasPrefix(s, "no error") {
+		return false
+	}
+	return strings.Contains(s, "error")
+}
@@ -0,0 +1,89 @@
+package query
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestValueUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Value
+	}{
+		{`35`, Value{Value: 35, Valid: true}},
+		{`"196592 MB"`, Value{Value: 196592, Unit: "MB", Valid: true}},
+		{`{"value": 42, "unit": "C"}`, Value{Value: 42, Unit: "C", Valid: true}},
+		{`{"value": "N/A", "unit": "C"}`, Value{Unit: "C"}},
+		{`"N/A"`, Value{}},
+		{`null`, Value{}},
+	}
+	for _, tt := range tests {
+		var v Value
+		if err := json.Unmarshal([]byte(tt.in), &v); err != nil {
+			t.Fatalf("%s: %v", tt.in, err)
+		}
+		if v != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.in, v, tt.want)
+		}
+	}
+}
+
+func TestParseAndMerge(t *testing.T) {
+	sb, err := os.ReadFile("testdata/amd-smi-static.mi300x.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	static, err := ParseStatic(sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := os.ReadFile("testdata/amd-smi-metric.mi300x.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metric, err := ParseMetric(mb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gpus := Merge(static, metric)
+	if len(gpus) != 2 {
+		t.Fatalf("expected 2 gpus, got %d", len(gpus))
+	}
+
+	g0 := gpus[0]
+	if g0.MarketName != "AMD Instinct MI300X" || g0.BDF != "0000:05:00.0" || g0.DriverVersion != "6.7.0" {
+		t.Errorf("unexpected inventory %+v", g0)
+	}
+	if g0.VRAMTotalBytes != 196592*1024*1024 || gpus[1].VRAMTotalBytes != g0.VRAMTotalBytes {
+		t.Errorf("unexpected vram %d, %d", g0.VRAMTotalBytes, gpus[1].VRAMTotalBytes)
+	}
+	if g0.Temperature.EdgeCelsius != 0 || g0.Temperature.HotspotCelsius != 42 || gpus[1].Temperature.HotspotCelsius != 51 {
+		t.Errorf("unexpected temperatures %+v, %+v", g0.Temperature, gpus[1].Temperature)
+	}
+	if !g0.ECC.Supported || g0.ECC.CorrectableCount != 12 || gpus[1].ECC.UncorrectableCount != 2 || gpus[1].ECC.DeferredCount != 1 {
+		t.Errorf("unexpected ecc %+v, %+v", g0.ECC, gpus[1].ECC)
+	}
+	if g0.XGMI.Error || !gpus[1].XGMI.Error {
+		t.Errorf("unexpected xgmi %+v, %+v", g0.XGMI, gpus[1].XGMI)
+	}
+
+	if _, err := ParseMetric([]byte(`{"error": "amdsmi not initialized"}`)); err == nil {
+		t.Error("expected error for the output without gpu data")
+	}
+}
+
+func TestIsXGMIError(t *testing.T) {
+	for status, want := range map[string]bool{
+		"No errors detected since last read":       false,
+		"Single error detected since last read":    true,
+		"Multiple errors detected since last read": true,
+		"N/A": false,
+		"":    false,
+	} {
+		if got := IsXGMIError(status); got != want {
+			t.Errorf("IsXGMIError(%q) = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/pci"
+)
+
+// Returns true if the local machine has AMD GPUs installed,
+// that is, amd-smi is installed and the AMD GPUs are found on the PCI bus.
+func GPUsInstalled(ctx context.Context) (bool, error) {
+	if !SMIExists() {
+		return false, nil
+	}
+	log.Logger.Debugw("amd-smi installed")
+
+	pciDevices, err := ListAMDPCIs(ctx)
+	if err != nil {
+		return false, err
+	}
+	if CountAMDGPUPCIs(pciDevices) == 0 {
+		return false, nil
+	}
+	log.Logger.Debugw("amd gpu PCI devices found", "devices", len(pciDevices))
+
+	return true, nil
+}
+
+var (
+	pciCache          = pci.NewCache(pci.DefaultDevicesDir)
+	pciCacheWatchOnce sync.Once
+)
+
+// Lists all PCI devices of the AMD vendor (e.g., GPUs, the host bridges on the AMD CPUs),
+// from the sysfs, equivalent to "lspci -d 1002:".
+func ListAMDPCIs(ctx context.Context) ([]pci.Device, error) {
+	pciCacheWatchOnce.Do(func() {
+		// watch for the lifetime of the process
+		if err := pciCache.Watch(context.Background()); err != nil {
+			log.Logger.Debugw("pci hotplug events not available, rescanning pci devices on every list", "error", err)
+		}
+	})
+
+	devs, err := pciCache.List()
+	if err != nil {
+		// e.g., no sysfs on the non-linux hosts
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	amdDevs := make([]pci.Device, 0)
+	for _, dev := range devs {
+		if dev.Vendor == pci.VendorAMD {
+			amdDevs = append(amdDevs, dev)
+		}
+	}
+	return amdDevs, nil
+}
+
+// CountAMDGPUPCIs counts the GPU functions in the AMD PCI devices,
+// excluding the other functions (e.g., the host bridges, the audio devices).
+func CountAMDGPUPCIs(devices []pci.Device) int {
+	count := 0
+	for _, dev := range devices {
+		if dev.IsAMDGPU() {
+			count++
+		}
+	}
+	return count
+}
+
+// LoadGPUDeviceName returns the product name of the first GPU (e.g., "AMD Instinct MI300X").
+func LoadGPUDeviceName(ctx context.Context) (string, error) {
+	cctx, ccancel := context.WithTimeout(ctx, smiTimeout)
+	b, err := RunSMI(cctx, "static", "--asic", "--json")
+	ccancel()
+	if err != nil {
+		return "", err
+	}
+	gpus, err := ParseStatic(b)
+	if err != nil {
+		return "", err
+	}
+	for _, g := range gpus {
+		if g.ASIC.MarketName != "" {
+			return string(g.ASIC.MarketName), nil
+		}
+	}
+	return "", errors.New("amd gpu product name not found")
+}
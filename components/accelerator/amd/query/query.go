@@ -0,0 +1,222 @@
+// Package query implements the AMD GPU queries via amd-smi (the ROCm SMI successor),
+// shared by the AMD accelerator components.
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components/query"
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since the amd-smi queries are shared by the amd components
+func SetDefaultPoller() {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(
+			"shared-amd-poller",
+			query_config.Config{
+				Interval:  metav1.Duration{Duration: query_config.DefaultPollInterval},
+				QueueSize: query_config.DefaultQueueSize,
+				State: &query_config.State{
+					Retention: metav1.Duration{Duration: query_config.DefaultStateRetention},
+				},
+			},
+			Get,
+		)
+	})
+}
+
+func GetDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+// timeout of each amd-smi command, since a broken GPU may block the command
+const smiTimeout = 30 * time.Second
+
+// Get queries the AMD GPUs via amd-smi.
+func Get(ctx context.Context) (any, error) {
+	o := &Output{
+		SMIExists: SMIExists(),
+	}
+
+	pciDevices, err := ListAMDPCIs(ctx)
+	if err != nil {
+		log.Logger.Warnw("failed to list amd pci devices", "error", err)
+	}
+	o.PCIGPUCount = CountAMDGPUPCIs(pciDevices)
+
+	if !o.SMIExists {
+		return o, nil
+	}
+
+	cctx, ccancel := context.WithTimeout(ctx, smiTimeout)
+	sb, err := RunSMI(cctx, "static", "--json")
+	ccancel()
+	var static []StaticGPU
+	if err == nil {
+		static, err = ParseStatic(sb)
+	}
+	if err != nil {
+		o.SMIErrors = append(o.SMIErrors, err.Error())
+	}
+
+	cctx, ccancel = context.WithTimeout(ctx, smiTimeout)
+	mb, err := RunSMI(cctx, "metric", "--temperature", "--ecc", "--xgmi-err", "--json")
+	ccancel()
+	var metric []MetricGPU
+	if err == nil {
+		metric, err = ParseMetric(mb)
+	}
+	if err != nil {
+		o.SMIErrors = append(o.SMIErrors, err.Error())
+	}
+
+	o.GPUs = Merge(static, metric)
+	return o, nil
+}
+
+// Output is the AMD GPU query output.
+type Output struct {
+	// SMIExists is true if amd-smi is installed.
+	SMIExists bool `json:"smi_exists"`
+	// PCIGPUCount is the GPU count from the PCI bus (sysfs).
+	PCIGPUCount int `json:"pci_gpu_count"`
+
+	GPUs []GPU `json:"gpus,omitempty"`
+	// SMIErrors are the amd-smi command or parsing errors.
+	SMIErrors []string `json:"smi_errors,omitempty"`
+}
+
+// GPU is the inventory and the health data of an AMD GPU.
+type GPU struct {
+	// ID is the amd-smi GPU index.
+	ID  int    `json:"id"`
+	BDF string `json:"bdf"`
+
+	MarketName    string `json:"market_name"`
+	DeviceID      string `json:"device_id"`
+	Serial        string `json:"serial"`
+	DriverVersion string `json:"driver_version"`
+	VBIOSVersion  string `json:"vbios_version"`
+
+	VRAMType       string `json:"vram_type"`
+	VRAMTotalBytes uint64 `json:"vram_total_bytes"`
+
+	Temperature Temperature `json:"temperature"`
+	ECC         ECC         `json:"ecc"`
+	XGMI        XGMI        `json:"xgmi"`
+}
+
+// Key returns the GPU identifier of the per-GPU states, the PCI bus ID (stable across reboots)
+// or the amd-smi GPU index if the bus ID is unknown.
+func (g GPU) Key() string {
+	if g.BDF != "" {
+		return g.BDF
+	}
+	return fmt.Sprintf("gpu%d", g.ID)
+}
+
+// Temperature is the GPU temperatures, zero if not available
+// (e.g., the edge sensor is not available on MI300).
+type Temperature struct {
+	EdgeCelsius    float64 `json:"edge_celsius"`
+	HotspotCelsius float64 `json:"hotspot_celsius"`
+	MemoryCelsius  float64 `json:"memory_celsius"`
+}
+
+// ECC is the total ECC error counts of the GPU.
+type ECC struct {
+	// Supported is false if the ECC counts are not available.
+	Supported          bool   `json:"supported"`
+	CorrectableCount   uint64 `json:"correctable_count"`
+	UncorrectableCount uint64 `json:"uncorrectable_count"`
+	// DeferredCount is the uncorrectable errors deferred to be handled (poisoned) on the consumption.
+	DeferredCount uint64 `json:"deferred_count"`
+}
+
+// XGMI is the XGMI (the GPU-to-GPU link) error status of the GPU.
+type XGMI struct {
+	// Status is the amd-smi XGMI error status (e.g., "No errors detected since last read").
+	Status string `json:"status"`
+	// Error is true if the status reports the link errors.
+	Error bool `json:"error"`
+}
+
+// Merge merges the static and the metric outputs by the GPU index, sorted by the GPU index.
+func Merge(static []StaticGPU, metric []MetricGPU) []GPU {
+	gpus := make(map[int]*GPU)
+	get := func(id int) *GPU {
+		g, ok := gpus[id]
+		if !ok {
+			g = &GPU{ID: id}
+			gpus[id] = g
+		}
+		return g
+	}
+
+	for _, s := range static {
+		g := get(s.GPU)
+		g.BDF = string(s.Bus.BDF)
+		g.MarketName = string(s.ASIC.MarketName)
+		g.DeviceID = string(s.ASIC.DeviceID)
+		g.Serial = string(s.ASIC.ASICSerial)
+		g.DriverVersion = string(s.Driver.Version)
+		g.VBIOSVersion = string(s.VBIOS.Version)
+		g.VRAMType = string(s.VRAM.Type)
+		if s.VRAM.Size.Valid {
+			g.VRAMTotalBytes = toBytes(s.VRAM.Size)
+		}
+	}
+
+	for _, m := range metric {
+		g := get(m.GPU)
+		g.Temperature = Temperature{
+			EdgeCelsius:    m.Temperature.Edge.Value,
+			HotspotCelsius: m.Temperature.Hotspot.Value,
+			MemoryCelsius:  m.Temperature.Mem.Value,
+		}
+		g.ECC = ECC{
+			Supported:          m.ECC.TotalCorrectableCount.Valid || m.ECC.TotalUncorrectableCount.Valid,
+			CorrectableCount:   uint64(m.ECC.TotalCorrectableCount.Value),
+			UncorrectableCount: uint64(m.ECC.TotalUncorrectableCount.Value),
+			DeferredCount:      uint64(m.ECC.TotalDeferredCount.Value),
+		}
+		g.XGMI = XGMI{
+			Status: string(m.XGMIErr),
+			Error:  IsXGMIError(string(m.XGMIErr)),
+		}
+	}
+
+	rs := make([]GPU, 0, len(gpus))
+	for _, g := range gpus {
+		rs = append(rs, *g)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].ID < rs[j].ID })
+	return rs
+}
+
+// amd-smi reports the memory sizes in MB (MiB)
+func toBytes(v Value) uint64 {
+	switch v.Unit {
+	case "B":
+		return uint64(v.Value)
+	case "KB":
+		return uint64(v.Value * 1024)
+	case "GB":
+		return uint64(v.Value * 1024 * 1024 * 1024)
+	default:
+		return uint64(v.Value * 1024 * 1024)
+	}
+}
@@ -0,0 +1,69 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/common"
+)
+
+const (
+	// StateKeySMIExists is the extra info key set to "true" if amd-smi is installed.
+	StateKeySMIExists = "amd_smi_exists"
+	// StateKeyPCIGPUCount is the extra info key of the AMD GPU count from the PCI bus.
+	StateKeyPCIGPUCount = "pci_gpu_count"
+)
+
+// SMIStates returns the state of the component when amd-smi cannot report the GPUs,
+// that is, amd-smi is not installed while the AMD GPUs are found on the PCI bus,
+// or the amd-smi commands failed.
+// Returns false if amd-smi reported the GPUs.
+func (o *Output) SMIStates(name string) ([]components.State, bool) {
+	extraInfo := map[string]string{
+		StateKeySMIExists:   strconv.FormatBool(o.SMIExists),
+		StateKeyPCIGPUCount: strconv.Itoa(o.PCIGPUCount),
+	}
+
+	if !o.SMIExists {
+		if o.PCIGPUCount == 0 {
+			return []components.State{
+				{
+					Name:      name,
+					Healthy:   true,
+					Reason:    "not applicable, no amd gpu found",
+					ExtraInfo: extraInfo,
+				},
+			}, true
+		}
+		return []components.State{
+			{
+				Name:      name,
+				Healthy:   false,
+				Reason:    fmt.Sprintf("%d amd gpu(s) found on the pci bus but amd-smi not found", o.PCIGPUCount),
+				ExtraInfo: extraInfo,
+				SuggestedActions: &common.SuggestedActions{
+					Descriptions: []string{
+						"install the ROCm amd-smi tool to monitor the AMD GPUs",
+					},
+				},
+			},
+		}, true
+	}
+
+	if len(o.SMIErrors) > 0 {
+		cs := make([]components.State, 0, len(o.SMIErrors))
+		for _, e := range o.SMIErrors {
+			cs = append(cs, components.State{
+				Name:      name,
+				Healthy:   false,
+				Error:     e,
+				Reason:    "amd-smi query failed with " + e,
+				ExtraInfo: extraInfo,
+			})
+		}
+		return cs, true
+	}
+
+	return nil, false
+}
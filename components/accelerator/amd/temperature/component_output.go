@@ -0,0 +1,112 @@
+package temperature
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/leptonai/gpud/components"
+	amd_query "github.com/leptonai/gpud/components/accelerator/amd/query"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+)
+
+// ToOutput converts amd_query.Output to Output.
+// It returns an empty non-nil object, if the input is nil.
+func ToOutput(i *amd_query.Output) *Output {
+	if i == nil {
+		return &Output{}
+	}
+
+	o := &Output{}
+	for _, g := range i.GPUs {
+		o.Temperatures = append(o.Temperatures, Temperature{
+			GPU:         g.Key(),
+			Temperature: g.Temperature,
+		})
+	}
+	return o
+}
+
+type Output struct {
+	Temperatures []Temperature `json:"temperatures,omitempty"`
+}
+
+type Temperature struct {
+	// GPU is the PCI bus ID of the GPU.
+	GPU string `json:"gpu"`
+	amd_query.Temperature
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameTemperature = "temperature"
+
+	StateKeyTemperatureData           = "data"
+	StateKeyTemperatureEncoding       = "encoding"
+	StateValueTemperatureEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameTemperature, "per-GPU edge, hotspot (junction), and memory temperatures"),
+		nvidia_query.GPUStateSchema(StateNameTemperature, "temperature of the GPU, one state per GPU"),
+	)
+}
+
+func ParseStateTemperature(m map[string]string) (*Output, error) {
+	data := m[StateKeyTemperatureData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameTemperature:
+			o, err := ParseStateTemperature(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			if nvidia_query.IsGPUStateName(StateNameTemperature, state.Name) {
+				// per-GPU states, derived from the component state
+				continue
+			}
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// States returns the temperature state, always healthy since the GPU firmware
+// throttles the clocks on the thermal limits.
+func (o *Output) States() ([]components.State, error) {
+	gs := nvidia_query.NewGPUStates(StateNameTemperature)
+	for _, t := range o.Temperatures {
+		gs.Add(t.GPU)
+	}
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameTemperature,
+		Healthy: true,
+		Reason:  fmt.Sprintf("%d gpu(s) checked", len(o.Temperatures)),
+		ExtraInfo: map[string]string{
+			StateKeyTemperatureData:     string(b),
+			StateKeyTemperatureEncoding: StateValueTemperatureEncodingJSON,
+		},
+	}
+	return append([]components.State{state}, gs.States()...), nil
+}
@@ -0,0 +1,146 @@
+package xgmi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/leptonai/gpud/components"
+	amd_query "github.com/leptonai/gpud/components/accelerator/amd/query"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	"github.com/leptonai/gpud/components/common"
+)
+
+// ToOutput converts amd_query.Output to Output.
+// It returns an empty non-nil object, if the input is nil.
+func ToOutput(i *amd_query.Output) *Output {
+	if i == nil {
+		return &Output{}
+	}
+
+	o := &Output{}
+	for _, g := range i.GPUs {
+		o.Links = append(o.Links, Link{
+			GPU:  g.Key(),
+			XGMI: g.XGMI,
+		})
+		if g.XGMI.Error {
+			o.LinkErrors = append(o.LinkErrors, fmt.Sprintf("[%s] %s", g.Key(), g.XGMI.Status))
+		}
+	}
+	return o
+}
+
+type Output struct {
+	Links []Link `json:"links,omitempty"`
+
+	// LinkErrors is the list of the GPUs with the XGMI errors since the last read.
+	LinkErrors []string `json:"link_errors,omitempty"`
+}
+
+type Link struct {
+	// GPU is the PCI bus ID of the GPU.
+	GPU string `json:"gpu"`
+	amd_query.XGMI
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameXGMI = "xgmi"
+
+	StateKeyXGMIData           = "data"
+	StateKeyXGMIEncoding       = "encoding"
+	StateValueXGMIEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameXGMI, "per-GPU XGMI link error status"),
+		nvidia_query.GPUStateSchema(StateNameXGMI, "XGMI link health of the GPU, one state per GPU"),
+	)
+}
+
+func ParseStateXGMI(m map[string]string) (*Output, error) {
+	data := m[StateKeyXGMIData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameXGMI:
+			o, err := ParseStateXGMI(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			if nvidia_query.IsGPUStateName(StateNameXGMI, state.Name) {
+				// per-GPU states, derived from the component state
+				continue
+			}
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+var linkErrorActions = &common.SuggestedActions{
+	Descriptions: []string{
+		"inspect the XGMI links of the GPU (e.g., the OAM seating and the baseboard), the errors degrade the collective communication",
+	},
+	RepairActions: []common.RepairActionType{
+		common.RepairActionTypeHardwareInspection,
+	},
+}
+
+// States returns the XGMI state, unhealthy if any GPU reports the link errors.
+func (o *Output) States() ([]components.State, error) {
+	healthy := true
+	reason := "no xgmi error found"
+	var suggestedActions *common.SuggestedActions
+	if len(o.LinkErrors) > 0 {
+		healthy = false
+		reason = fmt.Sprintf("xgmi errors found: %s", strings.Join(o.LinkErrors, ", "))
+		suggestedActions = linkErrorActions
+	}
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameXGMI,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyXGMIData:     string(b),
+			StateKeyXGMIEncoding: StateValueXGMIEncodingJSON,
+		},
+		SuggestedActions: suggestedActions,
+	}
+	return append([]components.State{state}, o.GPUStates()...), nil
+}
+
+// GPUStates returns the per-GPU states (e.g., "xgmi_0000:05:00.0"),
+// unhealthy if the GPU reports the link errors.
+func (o *Output) GPUStates() []components.State {
+	gs := nvidia_query.NewGPUStates(StateNameXGMI)
+	for _, l := range o.Links {
+		gs.Add(l.GPU)
+		if l.Error {
+			gs.Unhealthy(l.GPU, "xgmi link errors: "+l.Status, linkErrorActions)
+		}
+	}
+	return gs.States()
+}
@@ -0,0 +1,53 @@
+package xgmi
+
+import (
+	"testing"
+
+	amd_query "github.com/leptonai/gpud/components/accelerator/amd/query"
+)
+
+func TestOutputStates(t *testing.T) {
+	healthy := ToOutput(&amd_query.Output{
+		SMIExists: true,
+		GPUs: []amd_query.GPU{
+			{ID: 0, BDF: "0000:05:00.0", XGMI: amd_query.XGMI{Status: "No errors detected since last read"}},
+		},
+	})
+	states, err := healthy.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 || !states[0].Healthy || !states[1].Healthy {
+		t.Fatalf("expected healthy states, got %+v", states)
+	}
+
+	o := ToOutput(&amd_query.Output{
+		SMIExists: true,
+		GPUs: []amd_query.GPU{
+			{ID: 0, BDF: "0000:05:00.0", XGMI: amd_query.XGMI{Status: "No errors detected since last read"}},
+			{ID: 1, XGMI: amd_query.XGMI{Status: "Multiple errors detected since last read", Error: true}},
+		},
+	})
+	states, err = o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 3 || states[0].Healthy {
+		t.Fatalf("expected unhealthy component state, got %+v", states)
+	}
+	// per-GPU states are sorted by the GPU key, "0000:05:00.0" before "gpu1"
+	if !states[1].Healthy || states[1].Name != "xgmi_0000:05:00.0" {
+		t.Errorf("unexpected gpu state %+v", states[1])
+	}
+	if states[2].Healthy || states[2].Name != "xgmi_gpu1" {
+		t.Errorf("unexpected gpu state %+v", states[2])
+	}
+
+	parsed, err := ParseStatesToOutput(states...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.LinkErrors) != 1 {
+		t.Errorf("unexpected parsed output %+v", parsed)
+	}
+}
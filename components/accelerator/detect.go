@@ -3,6 +3,7 @@ package accelerator
 import (
 	"context"
 
+	amd_query "github.com/leptonai/gpud/components/accelerator/amd/query"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	"github.com/leptonai/gpud/pkg/file"
 )
@@ -12,9 +13,10 @@ type Type string
 const (
 	TypeUnknown Type = "unknown"
 	TypeNVIDIA  Type = "nvidia"
+	TypeAMD     Type = "amd"
 )
 
-// Returns the GPU type (e.g., "NVIDIA") and product name (e.g., "A100")
+// Returns the GPU type (e.g., "NVIDIA") and product name (e.g., "A100", "AMD Instinct MI300X")
 func DetectTypeAndProductName(ctx context.Context) (Type, string, error) {
 	if p, err := file.LocateExecutable("nvidia-smi"); p != "" && err == nil {
 		productName, err := nvidia_query.LoadGPUDeviceName(ctx)
@@ -24,5 +26,13 @@ func DetectTypeAndProductName(ctx context.Context) (Type, string, error) {
 		return TypeNVIDIA, productName, nil
 	}
 
+	if amd_query.SMIExists() {
+		productName, err := amd_query.LoadGPUDeviceName(ctx)
+		if err != nil {
+			return TypeAMD, "unknown", err
+		}
+		return TypeAMD, productName, nil
+	}
+
 	return TypeUnknown, "unknown", nil
 }
@@ -0,0 +1,180 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/leptonai/gpud/components"
+	neuron_query "github.com/leptonai/gpud/components/accelerator/neuron/query"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	"github.com/leptonai/gpud/components/common"
+)
+
+// ToOutput converts neuron_query.Output to Output.
+// It returns an empty non-nil object, if the input is nil.
+func ToOutput(i *neuron_query.Output) *Output {
+	if i == nil {
+		return &Output{}
+	}
+
+	o := &Output{
+		DeviceFileCount: i.DeviceFileCount,
+		Devices:         i.Devices,
+		Runtimes:        i.Runtimes,
+	}
+	for _, d := range i.Devices {
+		if d.ECC != nil && d.ECC.Uncorrected() > 0 {
+			o.UncorrectedECCErrors = append(o.UncorrectedECCErrors, fmt.Sprintf("[%s] %d uncorrected memory errors, %d uncorrected sram errors", d.Key(), d.ECC.MemUncorrected, d.ECC.SRAMUncorrected))
+		}
+	}
+	for _, r := range i.Runtimes {
+		if r.Errors.Hardware > 0 {
+			o.RuntimeHardwareErrors = append(o.RuntimeHardwareErrors, fmt.Sprintf("[pid %d] %d hardware errors", r.PID, r.Errors.Hardware))
+		}
+	}
+	return o
+}
+
+type Output struct {
+	// DeviceFileCount is the number of the Neuron device files (e.g., "/dev/neuron0").
+	DeviceFileCount int `json:"device_file_count"`
+
+	Devices  []neuron_query.Device  `json:"devices,omitempty"`
+	Runtimes []neuron_query.Runtime `json:"runtimes,omitempty"`
+
+	// UncorrectedECCErrors is the list of the devices with the uncorrected ECC errors since the driver load.
+	UncorrectedECCErrors []string `json:"uncorrected_ecc_errors,omitempty"`
+	// RuntimeHardwareErrors is the list of the runtimes with the hardware errors
+	// within the last "neuron-monitor" report period.
+	RuntimeHardwareErrors []string `json:"runtime_hardware_errors,omitempty"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameDevice = "device"
+
+	StateKeyDeviceData           = "data"
+	StateKeyDeviceEncoding       = "encoding"
+	StateValueDeviceEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameDevice, "Neuron device inventory, ECC errors, and runtime errors"),
+		nvidia_query.GPUStateSchema(StateNameDevice, "health of the Neuron device, one state per device"),
+	)
+}
+
+func ParseStateDevice(m map[string]string) (*Output, error) {
+	data := m[StateKeyDeviceData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameDevice:
+			o, err := ParseStateDevice(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			if nvidia_query.IsGPUStateName(StateNameDevice, state.Name) {
+				// per-device states, derived from the component state
+				continue
+			}
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+var uncorrectedECCActions = &common.SuggestedActions{
+	Descriptions: []string{
+		"reboot the system to reload the Neuron driver, and inspect the device if the uncorrected errors persist",
+	},
+	RepairActions: []common.RepairActionType{
+		common.RepairActionTypeRebootSystem,
+		common.RepairActionTypeHardwareInspection,
+	},
+}
+
+// Evaluate returns the unhealthy reasons and the suggested actions,
+// for the missing devices, the uncorrected ECC errors, or the runtime hardware errors.
+func (o *Output) Evaluate() ([]string, *common.SuggestedActions) {
+	var reasons []string
+	actions := &common.SuggestedActions{}
+	if len(o.Devices) < o.DeviceFileCount {
+		reasons = append(reasons, fmt.Sprintf("neuron-ls found %d device(s) but %d neuron device file(s) found", len(o.Devices), o.DeviceFileCount))
+		actions.Add(&common.SuggestedActions{
+			Descriptions:  []string{"reboot the system to reload the Neuron driver, and inspect the missing device if it does not come back"},
+			RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+		})
+	}
+	if len(o.UncorrectedECCErrors) > 0 {
+		reasons = append(reasons, "uncorrected ecc errors found: "+strings.Join(o.UncorrectedECCErrors, ", "))
+		actions.Add(uncorrectedECCActions)
+	}
+	if len(o.RuntimeHardwareErrors) > 0 {
+		reasons = append(reasons, "runtime hardware errors found: "+strings.Join(o.RuntimeHardwareErrors, ", "))
+		actions.Add(&common.SuggestedActions{
+			Descriptions:  []string{"check the application logs and the Neuron devices used by the application"},
+			RepairActions: []common.RepairActionType{common.RepairActionTypeCheckUserAppAndGPU},
+		})
+	}
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+	return reasons, actions
+}
+
+func (o *Output) States() ([]components.State, error) {
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameDevice,
+		Healthy: true,
+		Reason:  fmt.Sprintf("%d neuron device(s) found, no issue detected", len(o.Devices)),
+		ExtraInfo: map[string]string{
+			StateKeyDeviceData:     string(b),
+			StateKeyDeviceEncoding: StateValueDeviceEncodingJSON,
+		},
+	}
+	if reasons, actions := o.Evaluate(); len(reasons) > 0 {
+		state.Healthy = false
+		state.Reason = strings.Join(reasons, "; ")
+		state.SuggestedActions = actions
+	}
+	return append([]components.State{state}, o.DeviceStates()...), nil
+}
+
+// DeviceStates returns the per-device states (e.g., "device_neuron0"),
+// unhealthy if the device has the uncorrected ECC errors.
+func (o *Output) DeviceStates() []components.State {
+	ds := nvidia_query.NewGPUStates(StateNameDevice)
+	for _, d := range o.Devices {
+		ds.Add(d.Key())
+		if d.ECC == nil || d.ECC.Uncorrected() == 0 {
+			continue
+		}
+		ds.Unhealthy(d.Key(),
+			fmt.Sprintf("%d uncorrected memory errors, %d uncorrected sram errors", d.ECC.MemUncorrected, d.ECC.SRAMUncorrected),
+			uncorrectedECCActions,
+		)
+	}
+	return ds.States()
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"testing"
+
+	neuron_query "github.com/leptonai/gpud/components/accelerator/neuron/query"
+)
+
+func TestOutputStates(t *testing.T) {
+	o := ToOutput(&neuron_query.Output{
+		NeuronLsExists:  true,
+		DeviceFileCount: 2,
+		Devices: []neuron_query.Device{
+			{Index: 0, BDF: "10:1c.0", ECC: &neuron_query.ECC{MemCorrected: 3}},
+			{Index: 1, BDF: "10:1d.0", ECC: &neuron_query.ECC{MemUncorrected: 1}},
+		},
+	})
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 3 {
+		t.Fatalf("expected 3 states, got %d", len(states))
+	}
+	if states[0].Healthy || states[0].SuggestedActions == nil {
+		t.Errorf("expected unhealthy state with suggested actions, got %+v", states[0])
+	}
+	if !states[1].Healthy || states[1].Name != "device_neuron0" {
+		t.Errorf("unexpected device state %+v", states[1])
+	}
+	if states[2].Healthy || states[2].Name != "device_neuron1" {
+		t.Errorf("unexpected device state %+v", states[2])
+	}
+
+	parsed, err := ParseStatesToOutput(states...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Devices) != 2 || len(parsed.UncorrectedECCErrors) != 1 {
+		t.Errorf("unexpected parsed output %+v", parsed)
+	}
+}
+
+func TestOutputEvaluate(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  *Output
+		reasons int
+	}{
+		{
+			name:   "healthy",
+			output: ToOutput(&neuron_query.Output{DeviceFileCount: 1, Devices: []neuron_query.Device{{Index: 0}}}),
+		},
+		{
+			name:    "device missing",
+			output:  ToOutput(&neuron_query.Output{DeviceFileCount: 2, Devices: []neuron_query.Device{{Index: 0}}}),
+			reasons: 1,
+		},
+		{
+			name: "runtime hardware errors",
+			output: ToOutput(&neuron_query.Output{
+				DeviceFileCount: 1,
+				Devices:         []neuron_query.Device{{Index: 0}},
+				Runtimes:        []neuron_query.Runtime{{PID: 1, Errors: neuron_query.ErrorSummary{Hardware: 2}}},
+			}),
+			reasons: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasons, actions := tt.output.Evaluate()
+			if len(reasons) != tt.reasons {
+				t.Errorf("expected %d reasons, got %v", tt.reasons, reasons)
+			}
+			if (actions != nil) != (tt.reasons > 0) {
+				t.Errorf("unexpected suggested actions %+v", actions)
+			}
+		})
+	}
+}
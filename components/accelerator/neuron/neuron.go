@@ -0,0 +1,3 @@
+// Package neuron contains the AWS Neuron accelerator (e.g., Trainium, Inferentia) components
+// and its query interface.
+package neuron
@@ -0,0 +1,46 @@
+package query
+
+import (
+	"path/filepath"
+
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/file"
+)
+
+// DefaultDeviceFileGlob matches the Neuron device files created by the Neuron driver.
+const DefaultDeviceFileGlob = "/dev/neuron[0-9]*"
+
+// Returns true if the local machine has the Neuron devices installed,
+// that is, the Neuron driver created the device files.
+func DevicesInstalled() (bool, error) {
+	n, err := CountDeviceFiles()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+	log.Logger.Debugw("neuron device files found", "count", n)
+	return true, nil
+}
+
+// CountDeviceFiles counts the Neuron device files (e.g., "/dev/neuron0").
+func CountDeviceFiles() (int, error) {
+	matches, err := filepath.Glob(DefaultDeviceFileGlob)
+	if err != nil {
+		return 0, err
+	}
+	return len(matches), nil
+}
+
+// NeuronLsExists returns true if the "neuron-ls" (aws-neuronx-tools) is installed.
+func NeuronLsExists() bool {
+	p, err := file.LocateExecutable("neuron-ls")
+	return err == nil && p != ""
+}
+
+// NeuronMonitorExists returns true if the "neuron-monitor" (aws-neuronx-tools) is installed.
+func NeuronMonitorExists() bool {
+	p, err := file.LocateExecutable("neuron-monitor")
+	return err == nil && p != ""
+}
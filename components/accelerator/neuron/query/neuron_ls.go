@@ -0,0 +1,54 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/leptonai/gpud/pkg/execlimit"
+	"github.com/leptonai/gpud/pkg/file"
+)
+
+// NeuronLsDevice is a device in the "neuron-ls --json-output" output.
+type NeuronLsDevice struct {
+	NeuronDevice    int               `json:"neuron_device"`
+	BDF             string            `json:"bdf"`
+	ConnectedTo     []int             `json:"connected_to"`
+	NCCount         int               `json:"nc_count"`
+	MemorySize      uint64            `json:"memory_size"`
+	NeuronProcesses []NeuronLsProcess `json:"neuron_processes"`
+}
+
+// NeuronLsProcess is a process using the device in the "neuron-ls --json-output" output.
+type NeuronLsProcess struct {
+	PID                  int    `json:"pid"`
+	Command              string `json:"command"`
+	NeuronRuntimeVersion string `json:"neuron_runtime_version"`
+}
+
+// ParseNeuronLs parses the "neuron-ls --json-output" output.
+func ParseNeuronLs(b []byte) ([]NeuronLsDevice, error) {
+	var devs []NeuronLsDevice
+	if err := json.Unmarshal(b, &devs); err != nil {
+		return nil, fmt.Errorf("failed to parse neuron-ls output: %w", err)
+	}
+	return devs, nil
+}
+
+// RunNeuronLs runs "neuron-ls --json-output" and returns the devices.
+func RunNeuronLs(ctx context.Context) ([]NeuronLsDevice, error) {
+	p, err := file.LocateExecutable("neuron-ls")
+	if err != nil {
+		return nil, errors.New("neuron-ls not found")
+	}
+	if err := execlimit.Wait(ctx, "neuron-ls"); err != nil {
+		return nil, err
+	}
+	b, err := exec.CommandContext(ctx, p, "--json-output").Output()
+	if err != nil {
+		return nil, fmt.Errorf("neuron-ls failed: %w", err)
+	}
+	return ParseNeuronLs(b)
+}
@@ -0,0 +1,128 @@
+package query
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/leptonai/gpud/pkg/execlimit"
+	"github.com/leptonai/gpud/pkg/file"
+)
+
+// MonitorReport is a report in the "neuron-monitor" output, printed as a JSON line every period.
+// Only the runtime errors and the hardware counters are parsed.
+// ref. https://awsdocs-neuron.readthedocs-hosted.com/en/latest/tools/neuron-sys-tools/neuron-monitor-user-guide.html
+type MonitorReport struct {
+	NeuronRuntimeData []MonitorRuntime `json:"neuron_runtime_data"`
+	SystemData        struct {
+		NeuronHWCounters *MonitorHWCounters `json:"neuron_hw_counters"`
+	} `json:"system_data"`
+	NeuronHardwareInfo struct {
+		NeuronDeviceCount        int    `json:"neuron_device_count"`
+		NeuroncorePerDeviceCount int    `json:"neuroncore_per_device_count"`
+		Error                    string `json:"error"`
+	} `json:"neuron_hardware_info"`
+}
+
+// MonitorRuntime is the report of a Neuron runtime (an application process).
+type MonitorRuntime struct {
+	PID              int    `json:"pid"`
+	NeuronRuntimeTag string `json:"neuron_runtime_tag"`
+	Error            string `json:"error"`
+	Report           struct {
+		ExecutionStats *struct {
+			ErrorSummary ErrorSummary `json:"error_summary"`
+		} `json:"execution_stats"`
+	} `json:"report"`
+}
+
+// ErrorSummary is the execution error counts of a Neuron runtime within the report period.
+type ErrorSummary struct {
+	Generic   uint64 `json:"generic"`
+	Numerical uint64 `json:"numerical"`
+	Transient uint64 `json:"transient"`
+	Model     uint64 `json:"model"`
+	Runtime   uint64 `json:"runtime"`
+	Hardware  uint64 `json:"hardware"`
+}
+
+// Total returns the sum of the error counts.
+func (e ErrorSummary) Total() uint64 {
+	return e.Generic + e.Numerical + e.Transient + e.Model + e.Runtime + e.Hardware
+}
+
+// MonitorHWCounters is the hardware counters of the Neuron devices.
+type MonitorHWCounters struct {
+	NeuronDevices []MonitorDeviceCounters `json:"neuron_devices"`
+	Error         string                  `json:"error"`
+}
+
+// MonitorDeviceCounters is the ECC counters of a Neuron device, since the driver load.
+type MonitorDeviceCounters struct {
+	NeuronDeviceIndex  int    `json:"neuron_device_index"`
+	MemECCCorrected    uint64 `json:"mem_ecc_corrected"`
+	MemECCUncorrected  uint64 `json:"mem_ecc_uncorrected"`
+	SRAMECCCorrected   uint64 `json:"sram_ecc_corrected"`
+	SRAMECCUncorrected uint64 `json:"sram_ecc_uncorrected"`
+}
+
+// ParseMonitorReport parses a "neuron-monitor" report line.
+func ParseMonitorReport(b []byte) (*MonitorReport, error) {
+	r := new(MonitorReport)
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("failed to parse neuron-monitor output: %w", err)
+	}
+	return r, nil
+}
+
+// maximum size of a "neuron-monitor" report line, which grows with the number of the runtimes
+const maxMonitorReportBytes = 16 * 1024 * 1024
+
+// RunNeuronMonitorOnce runs "neuron-monitor" until it prints the first report, and returns the report.
+// "neuron-monitor" keeps printing a report every period (default 5 seconds) until stopped.
+func RunNeuronMonitorOnce(ctx context.Context) (*MonitorReport, error) {
+	p, err := file.LocateExecutable("neuron-monitor")
+	if err != nil {
+		return nil, errors.New("neuron-monitor not found")
+	}
+	if err := execlimit.Wait(ctx, "neuron-monitor"); err != nil {
+		return nil, err
+	}
+
+	cctx, ccancel := context.WithCancel(ctx)
+	defer ccancel()
+
+	cmd := exec.CommandContext(cctx, p)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("neuron-monitor failed to start: %w", err)
+	}
+	defer func() {
+		// stop the monitor after the first report
+		ccancel()
+		_ = cmd.Wait()
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMonitorReportBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		return ParseMonitorReport(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read neuron-monitor output: %w", err)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return nil, errors.New("neuron-monitor exited without a report")
+}
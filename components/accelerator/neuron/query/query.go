@@ -0,0 +1,203 @@
+// Package query implements the AWS Neuron device queries via "neuron-ls" and "neuron-monitor"
+// (aws-neuronx-tools), shared by the Neuron accelerator components.
+package query
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components/query"
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since the neuron queries are shared by the neuron components
+func SetDefaultPoller() {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(
+			"shared-neuron-poller",
+			query_config.Config{
+				Interval:  metav1.Duration{Duration: query_config.DefaultPollInterval},
+				QueueSize: query_config.DefaultQueueSize,
+				State: &query_config.State{
+					Retention: metav1.Duration{Duration: query_config.DefaultStateRetention},
+				},
+			},
+			Get,
+		)
+	})
+}
+
+func GetDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+// timeout of each neuron tool command, including the first "neuron-monitor" report period
+const toolTimeout = 30 * time.Second
+
+// Get queries the Neuron devices via "neuron-ls" and "neuron-monitor".
+func Get(ctx context.Context) (any, error) {
+	o := &Output{
+		NeuronLsExists:      NeuronLsExists(),
+		NeuronMonitorExists: NeuronMonitorExists(),
+	}
+
+	var err error
+	o.DeviceFileCount, err = CountDeviceFiles()
+	if err != nil {
+		log.Logger.Warnw("failed to count neuron device files", "error", err)
+	}
+
+	var devs []NeuronLsDevice
+	if o.NeuronLsExists {
+		cctx, ccancel := context.WithTimeout(ctx, toolTimeout)
+		devs, err = RunNeuronLs(cctx)
+		ccancel()
+		if err != nil {
+			o.ToolErrors = append(o.ToolErrors, err.Error())
+		}
+	}
+
+	var report *MonitorReport
+	if o.NeuronMonitorExists {
+		cctx, ccancel := context.WithTimeout(ctx, toolTimeout)
+		report, err = RunNeuronMonitorOnce(cctx)
+		ccancel()
+		if err != nil {
+			o.ToolErrors = append(o.ToolErrors, err.Error())
+		}
+	}
+
+	o.Devices, o.Runtimes = Merge(devs, report)
+	if report != nil && report.SystemData.NeuronHWCounters != nil && report.SystemData.NeuronHWCounters.Error != "" {
+		o.ToolErrors = append(o.ToolErrors, "neuron-monitor hardware counters: "+report.SystemData.NeuronHWCounters.Error)
+	}
+	return o, nil
+}
+
+// Output is the Neuron device query output.
+type Output struct {
+	NeuronLsExists      bool `json:"neuron_ls_exists"`
+	NeuronMonitorExists bool `json:"neuron_monitor_exists"`
+	// DeviceFileCount is the number of the Neuron device files (e.g., "/dev/neuron0").
+	DeviceFileCount int `json:"device_file_count"`
+
+	Devices  []Device  `json:"devices,omitempty"`
+	Runtimes []Runtime `json:"runtimes,omitempty"`
+
+	// ToolErrors are the "neuron-ls" or "neuron-monitor" command or parsing errors.
+	ToolErrors []string `json:"tool_errors,omitempty"`
+}
+
+// Device is the inventory and the health data of a Neuron device.
+type Device struct {
+	Index int    `json:"index"`
+	BDF   string `json:"bdf"`
+
+	// CoreCount is the number of the NeuronCores in the device.
+	CoreCount   int    `json:"core_count"`
+	MemoryBytes uint64 `json:"memory_bytes"`
+	// ConnectedTo is the indexes of the devices connected via NeuronLink.
+	ConnectedTo []int `json:"connected_to,omitempty"`
+	// ProcessCount is the number of the processes using the device.
+	ProcessCount int `json:"process_count"`
+
+	// ECC is the ECC counters of the device, nil if not reported by "neuron-monitor".
+	ECC *ECC `json:"ecc,omitempty"`
+}
+
+// Key returns the device identifier of the per-device states (e.g., "neuron0").
+func (d Device) Key() string {
+	return DeviceKey(d.Index)
+}
+
+// DeviceKey returns the device identifier of the device index, same as the device file name.
+func DeviceKey(index int) string {
+	return "neuron" + strconv.Itoa(index)
+}
+
+// ECC is the ECC error counts of a Neuron device since the driver load.
+type ECC struct {
+	MemCorrected    uint64 `json:"mem_corrected"`
+	MemUncorrected  uint64 `json:"mem_uncorrected"`
+	SRAMCorrected   uint64 `json:"sram_corrected"`
+	SRAMUncorrected uint64 `json:"sram_uncorrected"`
+}
+
+// Uncorrected returns the sum of the uncorrected memory (HBM) and SRAM errors.
+func (e ECC) Uncorrected() uint64 {
+	return e.MemUncorrected + e.SRAMUncorrected
+}
+
+// Runtime is the execution errors of a Neuron runtime (an application process)
+// within the last "neuron-monitor" report period.
+type Runtime struct {
+	PID    int          `json:"pid"`
+	Tag    string       `json:"tag,omitempty"`
+	Errors ErrorSummary `json:"errors"`
+	// Error is the "neuron-monitor" error collecting the runtime, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Merge merges the "neuron-ls" devices and the "neuron-monitor" report by the device index,
+// sorted by the device index, and returns the devices and the runtimes.
+func Merge(devs []NeuronLsDevice, report *MonitorReport) ([]Device, []Runtime) {
+	devices := make(map[int]*Device)
+	get := func(idx int) *Device {
+		d, ok := devices[idx]
+		if !ok {
+			d = &Device{Index: idx}
+			devices[idx] = d
+		}
+		return d
+	}
+
+	for _, ld := range devs {
+		d := get(ld.NeuronDevice)
+		d.BDF = ld.BDF
+		d.CoreCount = ld.NCCount
+		d.MemoryBytes = ld.MemorySize
+		d.ConnectedTo = ld.ConnectedTo
+		d.ProcessCount = len(ld.NeuronProcesses)
+	}
+
+	var runtimes []Runtime
+	if report != nil {
+		if hw := report.SystemData.NeuronHWCounters; hw != nil {
+			for _, c := range hw.NeuronDevices {
+				d := get(c.NeuronDeviceIndex)
+				d.ECC = &ECC{
+					MemCorrected:    c.MemECCCorrected,
+					MemUncorrected:  c.MemECCUncorrected,
+					SRAMCorrected:   c.SRAMECCCorrected,
+					SRAMUncorrected: c.SRAMECCUncorrected,
+				}
+			}
+		}
+		for _, rt := range report.NeuronRuntimeData {
+			r := Runtime{PID: rt.PID, Tag: rt.NeuronRuntimeTag, Error: rt.Error}
+			if rt.Report.ExecutionStats != nil {
+				r.Errors = rt.Report.ExecutionStats.ErrorSummary
+			}
+			runtimes = append(runtimes, r)
+		}
+		sort.Slice(runtimes, func(i, j int) bool { return runtimes[i].PID < runtimes[j].PID })
+	}
+
+	rs := make([]Device, 0, len(devices))
+	for _, d := range devices {
+		rs = append(rs, *d)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Index < rs[j].Index })
+	return rs, runtimes
+}
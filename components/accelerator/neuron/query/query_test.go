@@ -0,0 +1,60 @@
+package query
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseAndMerge(t *testing.T) {
+	lb, err := os.ReadFile("testdata/neuron-ls.trn1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	devs, err := ParseNeuronLs(lb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := os.ReadFile("testdata/neuron-monitor.trn1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := ParseMonitorReport(mb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.NeuronHardwareInfo.NeuronDeviceCount != 16 {
+		t.Errorf("unexpected device count %d", report.NeuronHardwareInfo.NeuronDeviceCount)
+	}
+
+	devices, runtimes := Merge(devs, report)
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(devices))
+	}
+	d0, d1 := devices[0], devices[1]
+	if d0.Key() != "neuron0" || d0.BDF != "10:1c.0" || d0.CoreCount != 2 || d0.MemoryBytes != 34359738368 || d0.ProcessCount != 1 {
+		t.Errorf("unexpected device %+v", d0)
+	}
+	if d0.ECC == nil || d0.ECC.MemCorrected != 3 || d0.ECC.Uncorrected() != 0 {
+		t.Errorf("unexpected ecc %+v", d0.ECC)
+	}
+	if d1.ECC == nil || d1.ECC.Uncorrected() != 1 {
+		t.Errorf("unexpected ecc %+v", d1.ECC)
+	}
+	if len(runtimes) != 1 || runtimes[0].PID != 11325 || runtimes[0].Errors.Hardware != 1 || runtimes[0].Errors.Total() != 1 {
+		t.Errorf("unexpected runtimes %+v", runtimes)
+	}
+}
+
+func TestToolStates(t *testing.T) {
+	if _, ok := (&Output{NeuronLsExists: true}).ToolStates("test"); ok {
+		t.Error("expected no tool states when neuron-ls reported the devices")
+	}
+	states, ok := (&Output{DeviceFileCount: 16}).ToolStates("test")
+	if !ok || len(states) != 1 || states[0].Healthy {
+		t.Errorf("expected unhealthy state when neuron-ls is missing, got %+v", states)
+	}
+	states, ok = (&Output{}).ToolStates("test")
+	if !ok || len(states) != 1 || !states[0].Healthy {
+		t.Errorf("expected not applicable state without the devices, got %+v", states)
+	}
+}
@@ -0,0 +1,69 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/common"
+)
+
+const (
+	// StateKeyNeuronLsExists is the extra info key set to "true" if "neuron-ls" is installed.
+	StateKeyNeuronLsExists = "neuron_ls_exists"
+	// StateKeyDeviceFileCount is the extra info key of the Neuron device file count.
+	StateKeyDeviceFileCount = "device_file_count"
+)
+
+// ToolStates returns the state of the component when the Neuron tools cannot report the devices,
+// that is, "neuron-ls" is not installed while the Neuron device files exist,
+// or the tool commands failed.
+// Returns false if the tools reported the devices.
+func (o *Output) ToolStates(name string) ([]components.State, bool) {
+	extraInfo := map[string]string{
+		StateKeyNeuronLsExists:  strconv.FormatBool(o.NeuronLsExists),
+		StateKeyDeviceFileCount: strconv.Itoa(o.DeviceFileCount),
+	}
+
+	if !o.NeuronLsExists {
+		if o.DeviceFileCount == 0 {
+			return []components.State{
+				{
+					Name:      name,
+					Healthy:   true,
+					Reason:    "not applicable, no neuron device found",
+					ExtraInfo: extraInfo,
+				},
+			}, true
+		}
+		return []components.State{
+			{
+				Name:      name,
+				Healthy:   false,
+				Reason:    fmt.Sprintf("%d neuron device file(s) found but neuron-ls not found", o.DeviceFileCount),
+				ExtraInfo: extraInfo,
+				SuggestedActions: &common.SuggestedActions{
+					Descriptions: []string{
+						"install the aws-neuronx-tools package to monitor the Neuron devices",
+					},
+				},
+			},
+		}, true
+	}
+
+	if len(o.ToolErrors) > 0 {
+		cs := make([]components.State, 0, len(o.ToolErrors))
+		for _, e := range o.ToolErrors {
+			cs = append(cs, components.State{
+				Name:      name,
+				Healthy:   false,
+				Error:     e,
+				Reason:    "neuron tool query failed with " + e,
+				ExtraInfo: extraInfo,
+			})
+		}
+		return cs, true
+	}
+
+	return nil, false
+}
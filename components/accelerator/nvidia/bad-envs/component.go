@@ -53,6 +53,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -109,6 +112,9 @@ func (c *component) Output() (any, error) {
 	if err != nil {
 		return nil, err
 	}
+	if nvidia_query.IsStackAbsent(last.Error) {
+		return nil, nil
+	}
 	if last.Error != nil {
 		return nil, last.Error
 	}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/leptonai/gpud/components"
+	bad_envs_id "github.com/leptonai/gpud/components/accelerator/nvidia/bad-envs/id"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 )
 
@@ -50,6 +51,12 @@ const (
 	StateValueUtilizationEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(bad_envs_id.Name,
+		components.JSONStateSchema(StateNameBadEnvs, "bad environment variables globally set for the NVIDIA GPUs"),
+	)
+}
+
 func ParseStateBadEnvs(m map[string]string) (*Output, error) {
 	data := m[StateKeyUtilizationData]
 	return ParseOutputJSON([]byte(data))
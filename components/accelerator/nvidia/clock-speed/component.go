@@ -5,31 +5,48 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_metrics_clockspeed "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/clock-speed"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
 
 	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const Name = "accelerator-nvidia-clock-speed"
 
 func New(ctx context.Context, cfg Config) components.Component {
 	cfg.Query.SetDefaultsIfNotSet()
+	if cfg.DriftToleranceMHz == 0 {
+		cfg.DriftToleranceMHz = DefaultDriftToleranceMHz
+	}
 
 	cctx, ccancel := context.WithCancel(ctx)
 	nvidia_query.SetDefaultPoller(cfg.Query.State.DB)
 	nvidia_query.GetDefaultPoller().Start(cctx, cfg.Query, Name)
 
-	return &component{
+	c := &component{
 		rootCtx: ctx,
 		cancel:  ccancel,
 		poller:  nvidia_query.GetDefaultPoller(),
+		cfg:     cfg,
+
+		lockGPUClocks:  nvidia_query_nvml.LockGPUClocks,
+		nvmlGeneration: func() uint64 { return pkg_nvml.Default().Generation() },
+	}
+	if cfg.LockedClocks != nil {
+		var lctx context.Context
+		lctx, c.lockCancel = context.WithCancel(ctx)
+		go c.lockLoop(lctx)
 	}
+	return c
 }
 
 var _ components.Component = (*component)(nil)
@@ -39,6 +56,22 @@ type component struct {
 	cancel   context.CancelFunc
 	poller   query.Poller
 	gatherer prometheus.Gatherer
+	cfg      Config
+
+	lockCancel context.CancelFunc
+	// injected for testing
+	lockGPUClocks  func(uuid string, locked nvidia_query_nvml.LockedClocks) error
+	nvmlGeneration func() uint64
+	// NVML session generation when the clocks were locked,
+	// zero if not locked yet (only accessed by the lock loop)
+	lockedGeneration uint64
+
+	lockMu      sync.RWMutex
+	driftedGPUs []string
+	lockErrors  []string
+
+	eventsMu sync.RWMutex
+	events   []components.Event
 }
 
 func (c *component) Name() string { return Name }
@@ -58,6 +91,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -96,27 +132,149 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 		return cs, nil
 	}
 	output := ToOutput(allOutput)
+	if c.cfg.LockedClocks != nil {
+		output.LockedClocks = c.cfg.LockedClocks
+
+		c.lockMu.RLock()
+		output.DriftedGPUs = c.driftedGPUs
+		output.LockErrors = c.lockErrors
+		c.lockMu.RUnlock()
+	}
 	return output.States()
 }
 
+// lockLoop locks the clocks on the first poll, and re-applies the locked clocks once per poll
+// if NVML was re-initialized or the clocks drifted since.
+func (c *component) lockLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Query.Interval.Duration)
+	defer ticker.Stop()
+
+	var lastPolled time.Time
+	for {
+		var lockErrs []string
+		if err := c.lockIfReinitialized(); err != nil {
+			lockErrs = append(lockErrs, err.Error())
+		}
+
+		drifted := c.driftedGPUs
+		if last, err := c.poller.Last(); err == nil && last.Error == nil && last.Output != nil && last.Time.Time.After(lastPolled) {
+			lastPolled = last.Time.Time
+			if allOutput, ok := last.Output.(*nvidia_query.Output); ok {
+				var errs []string
+				drifted, errs = c.reapplyDriftedClocks(ToOutput(allOutput).ClockSpeeds)
+				lockErrs = append(lockErrs, errs...)
+			}
+		}
+
+		c.lockMu.Lock()
+		c.driftedGPUs = drifted
+		c.lockErrors = lockErrs
+		c.lockMu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lockIfReinitialized locks the clocks of all the GPUs if not locked yet,
+// or NVML was re-initialized since the lock (the driver reload or the GPU reset resets the locked clocks).
+func (c *component) lockIfReinitialized() error {
+	if c.lockedGeneration != 0 && c.lockedGeneration == c.nvmlGeneration() {
+		return nil
+	}
+
+	relock := c.lockedGeneration != 0
+	log.Logger.Infow("locking gpu clocks", "graphicsMinMHz", c.cfg.LockedClocks.GraphicsMinMHz, "graphicsMaxMHz", c.cfg.LockedClocks.GraphicsMaxMHz, "relock", relock)
+	if err := c.lockGPUClocks("", *c.cfg.LockedClocks); err != nil {
+		log.Logger.Warnw("failed to lock gpu clocks", "error", err)
+		return err
+	}
+	c.lockedGeneration = c.nvmlGeneration()
+
+	if relock {
+		c.addEvent(components.Event{
+			Time:    metav1.Time{Time: time.Now().UTC()},
+			Name:    EventNameLockedClocksReapplied,
+			Type:    components.EventTypeWarn,
+			Message: fmt.Sprintf("re-applied locked clocks %d-%d MHz after NVML re-initialization (e.g., driver reload or GPU reset)", c.cfg.LockedClocks.GraphicsMinMHz, c.cfg.LockedClocks.GraphicsMaxMHz),
+		})
+	}
+	return nil
+}
+
+// reapplyDriftedClocks re-applies the locked clocks to the GPUs
+// whose clocks exceed the locked range (see "LockedClocks.Drifted").
+func (c *component) reapplyDriftedClocks(clockSpeeds []nvidia_query_nvml.ClockSpeed) (drifted []string, lockErrs []string) {
+	for _, cs := range clockSpeeds {
+		if !c.cfg.LockedClocks.Drifted(cs, c.cfg.DriftToleranceMHz) {
+			continue
+		}
+		drifted = append(drifted, cs.UUID)
+
+		log.Logger.Warnw("gpu clocks drifted -- re-applying locked clocks", "uuid", cs.UUID, "graphicsMHz", cs.GraphicsMHz)
+		ev := components.Event{
+			Time:    metav1.Time{Time: time.Now().UTC()},
+			Name:    EventNameLockedClocksReapplied,
+			Type:    components.EventTypeWarn,
+			Message: fmt.Sprintf("gpu %s graphics clock %d MHz above locked range %d-%d MHz, re-applied locked clocks", cs.UUID, cs.GraphicsMHz, c.cfg.LockedClocks.GraphicsMinMHz, c.cfg.LockedClocks.GraphicsMaxMHz),
+		}
+		if err := c.lockGPUClocks(cs.UUID, *c.cfg.LockedClocks); err != nil {
+			lockErrs = append(lockErrs, fmt.Sprintf("%s: %v", cs.UUID, err))
+			ev.Message = fmt.Sprintf("gpu %s graphics clock %d MHz above locked range %d-%d MHz, failed to re-apply locked clocks (%v)", cs.UUID, cs.GraphicsMHz, c.cfg.LockedClocks.GraphicsMinMHz, c.cfg.LockedClocks.GraphicsMaxMHz, err)
+		}
+		c.addEvent(ev)
+	}
+	return drifted, lockErrs
+}
+
+func (c *component) addEvent(ev components.Event) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+
+	c.events = append(c.events, ev)
+	if len(c.events) > maxEvents {
+		c.events = c.events[len(c.events)-maxEvents:]
+	}
+}
+
+const (
+	EventNameLockedClocksReapplied = "locked_clocks_reapplied"
+
+	// maxEvents is the maximum number of in-memory events to keep.
+	maxEvents = 100
+)
+
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
-	return nil, nil
+	c.eventsMu.RLock()
+	defer c.eventsMu.RUnlock()
+
+	var evs []components.Event
+	for _, ev := range c.events {
+		if ev.Time.Time.Before(since) {
+			continue
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
 }
 
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
-	graphicsMHzs, err := nvidia_query_metrics_clockspeed.ReadGraphicsMHzs(ctx, since)
+	graphicsHertz, err := nvidia_query_metrics_clockspeed.ReadGraphicsHertz(ctx, since)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read graphics clock speed: %w", err)
 	}
-	memoryMHzs, err := nvidia_query_metrics_clockspeed.ReadMemoryMHzs(ctx, since)
+	memoryHertz, err := nvidia_query_metrics_clockspeed.ReadMemoryHertz(ctx, since)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read memory clock speed: %w", err)
 	}
 
-	ms := make([]components.Metric, 0, len(graphicsMHzs)+len(memoryMHzs))
-	for _, m := range graphicsMHzs {
+	ms := make([]components.Metric, 0, len(graphicsHertz)+len(memoryHertz))
+	for _, m := range graphicsHertz {
 		ms = append(ms, components.Metric{
 			Metric: m,
 			ExtraInfo: map[string]string{
@@ -124,7 +282,7 @@ func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.
 			},
 		})
 	}
-	for _, m := range memoryMHzs {
+	for _, m := range memoryHertz {
 		ms = append(ms, components.Metric{
 			Metric: m,
 			ExtraInfo: map[string]string{
@@ -142,6 +300,10 @@ func (c *component) Close() error {
 	// safe to call stop multiple times
 	_ = c.poller.Stop(Name)
 
+	if c.lockCancel != nil {
+		c.lockCancel()
+	}
+
 	return nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
@@ -32,6 +33,13 @@ func ToOutput(i *nvidia_query.Output) *Output {
 
 type Output struct {
 	ClockSpeeds []nvidia_query_nvml.ClockSpeed `json:"clock_speeds"`
+
+	// LockedClocks is the configured graphics clock range, if any.
+	LockedClocks *nvidia_query_nvml.LockedClocks `json:"locked_clocks,omitempty"`
+	// DriftedGPUs is the list of GPU UUIDs whose clocks drifted out of the locked range.
+	DriftedGPUs []string `json:"drifted_gpus,omitempty"`
+	// LockErrors is the list of errors from re-applying the locked clocks.
+	LockErrors []string `json:"lock_errors,omitempty"`
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -54,6 +62,12 @@ const (
 	StateValueUtilizationEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameUtilization, "per-GPU clock speeds"),
+	)
+}
+
 func ParseStateClockSpeed(m map[string]string) (*Output, error) {
 	data := m[StateKeyUtilizationData]
 	return ParseOutputJSON([]byte(data))
@@ -82,7 +96,16 @@ func (o *Output) Evaluate() (string, bool, error) {
 	if err != nil {
 		return "", false, err
 	}
-	return string(yb), true, nil
+	reason := string(yb)
+
+	if o.LockedClocks != nil && len(o.DriftedGPUs) > 0 {
+		reason += fmt.Sprintf("\nclocks above locked range %d-%d MHz on %s (re-applied)", o.LockedClocks.GraphicsMinMHz, o.LockedClocks.GraphicsMaxMHz, strings.Join(o.DriftedGPUs, ", "))
+	}
+	if len(o.LockErrors) > 0 {
+		reason += fmt.Sprintf("\nfailed to lock clocks: %s", strings.Join(o.LockErrors, "; "))
+		return reason, false, nil
+	}
+	return reason, true, nil
 }
 
 func (o *Output) States() ([]components.State, error) {
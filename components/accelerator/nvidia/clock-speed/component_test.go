@@ -0,0 +1,132 @@
+package clockspeed
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+type lockCall struct {
+	uuid   string
+	locked nvidia_query_nvml.LockedClocks
+}
+
+func newTestComponent(generation *uint64, calls *[]lockCall, lockErr *error) *component {
+	return &component{
+		cfg: Config{
+			LockedClocks:      &nvidia_query_nvml.LockedClocks{GraphicsMinMHz: 1400, GraphicsMaxMHz: 1600},
+			DriftToleranceMHz: DefaultDriftToleranceMHz,
+		},
+		lockGPUClocks: func(uuid string, locked nvidia_query_nvml.LockedClocks) error {
+			*calls = append(*calls, lockCall{uuid: uuid, locked: locked})
+			return *lockErr
+		},
+		nvmlGeneration: func() uint64 { return *generation },
+	}
+}
+
+func TestLockIfReinitialized(t *testing.T) {
+	var (
+		generation uint64 = 1
+		calls      []lockCall
+		lockErr    error
+	)
+	c := newTestComponent(&generation, &calls, &lockErr)
+
+	// first lock of all the GPUs, no event
+	if err := c.lockIfReinitialized(); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || calls[0].uuid != "" || calls[0].locked != *c.cfg.LockedClocks {
+		t.Fatalf("unexpected lock calls %+v", calls)
+	}
+	if evs, _ := c.Events(context.Background(), time.Time{}); len(evs) != 0 {
+		t.Fatalf("expected no event on the first lock, got %+v", evs)
+	}
+
+	// same NVML session, not locked again
+	if err := c.lockIfReinitialized(); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected no re-lock in the same session, got %+v", calls)
+	}
+
+	// NVML re-initialized (e.g., driver reload), but the lock fails
+	generation = 2
+	lockErr = errors.New("insufficient permissions")
+	if err := c.lockIfReinitialized(); err == nil {
+		t.Fatal("expected lock error")
+	}
+	if len(calls) != 2 || c.lockedGeneration != 1 {
+		t.Fatalf("unexpected lock calls %+v (generation %d)", calls, c.lockedGeneration)
+	}
+
+	// retried on the next poll
+	lockErr = nil
+	if err := c.lockIfReinitialized(); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 || c.lockedGeneration != 2 {
+		t.Fatalf("unexpected lock calls %+v (generation %d)", calls, c.lockedGeneration)
+	}
+	evs, err := c.Events(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 1 || evs[0].Name != EventNameLockedClocksReapplied || !strings.Contains(evs[0].Message, "NVML re-initialization") {
+		t.Fatalf("unexpected events %+v", evs)
+	}
+}
+
+func TestReapplyDriftedClocks(t *testing.T) {
+	var (
+		generation uint64 = 1
+		calls      []lockCall
+		lockErr    error
+	)
+	c := newTestComponent(&generation, &calls, &lockErr)
+
+	clockSpeeds := []nvidia_query_nvml.ClockSpeed{
+		{UUID: "GPU-0", GraphicsMHz: 1500},
+		// reset by "nvidia-smi --reset-gpu-clocks"
+		{UUID: "GPU-1", GraphicsMHz: 1980},
+		// throttled, not a drift
+		{UUID: "GPU-2", GraphicsMHz: 900},
+		// within the tolerance
+		{UUID: "GPU-3", GraphicsMHz: 1610},
+	}
+	drifted, lockErrs := c.reapplyDriftedClocks(clockSpeeds)
+	if !reflect.DeepEqual(drifted, []string{"GPU-1"}) || len(lockErrs) != 0 {
+		t.Fatalf("unexpected drifted %v, errors %v", drifted, lockErrs)
+	}
+	if len(calls) != 1 || calls[0].uuid != "GPU-1" {
+		t.Fatalf("unexpected lock calls %+v", calls)
+	}
+	evs, err := c.Events(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 1 || evs[0].Name != EventNameLockedClocksReapplied || !strings.Contains(evs[0].Message, "re-applied locked clocks") {
+		t.Fatalf("unexpected events %+v", evs)
+	}
+
+	// failed re-apply is reported in the errors and the event
+	lockErr = errors.New("gpu is lost")
+	drifted, lockErrs = c.reapplyDriftedClocks(clockSpeeds)
+	if !reflect.DeepEqual(drifted, []string{"GPU-1"}) || !reflect.DeepEqual(lockErrs, []string{"GPU-1: gpu is lost"}) {
+		t.Fatalf("unexpected drifted %v, errors %v", drifted, lockErrs)
+	}
+	evs, err = c.Events(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 2 || !strings.Contains(evs[1].Message, "failed to re-apply locked clocks (gpu is lost)") {
+		t.Fatalf("unexpected events %+v", evs)
+	}
+}
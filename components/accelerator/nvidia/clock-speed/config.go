@@ -3,12 +3,27 @@ package clockspeed
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	query_config "github.com/leptonai/gpud/components/query/config"
 )
 
+// DefaultDriftToleranceMHz is the default tolerance for the locked clocks drift check.
+const DefaultDriftToleranceMHz = 15
+
 type Config struct {
 	Query query_config.Config `json:"query"`
+
+	// LockedClocks is the graphics clock range to lock all the GPUs to.
+	// If set, the clocks are locked on the first poll, and re-applied whenever NVML is
+	// re-initialized (e.g., after the driver reload or the GPU reset, which reset the locked clocks)
+	// or the clocks exceed the locked range (e.g., reset by "nvidia-smi --reset-gpu-clocks").
+	// Useful for the benchmarking fleets that require deterministic clocks.
+	LockedClocks *nvidia_query_nvml.LockedClocks `json:"locked_clocks,omitempty"`
+	// DriftToleranceMHz is the tolerance in MHz to check the locked clocks drift.
+	DriftToleranceMHz uint32 `json:"drift_tolerance_mhz"`
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -28,5 +43,13 @@ func ParseConfig(b any, db *sql.DB) (*Config, error) {
 }
 
 func (cfg Config) Validate() error {
+	if cfg.LockedClocks != nil {
+		if cfg.LockedClocks.GraphicsMaxMHz == 0 {
+			return errors.New("locked clocks max graphics clock must be set")
+		}
+		if cfg.LockedClocks.GraphicsMinMHz > cfg.LockedClocks.GraphicsMaxMHz {
+			return fmt.Errorf("locked clocks min graphics clock %d MHz must be <= max %d MHz", cfg.LockedClocks.GraphicsMinMHz, cfg.LockedClocks.GraphicsMaxMHz)
+		}
+	}
 	return nil
 }
@@ -19,16 +19,18 @@ import (
 const Name = "accelerator-nvidia-clock"
 
 func New(ctx context.Context, cfg Config) components.Component {
-	cfg.Query.SetDefaultsIfNotSet()
+	cfg.SetDefaultsIfNotSet()
 
 	cctx, ccancel := context.WithCancel(ctx)
 	nvidia_query.SetDefaultPoller(cfg.Query.State.DB)
 	nvidia_query.GetDefaultPoller().Start(cctx, cfg.Query, Name)
 
 	return &component{
-		rootCtx: ctx,
-		cancel:  ccancel,
-		poller:  nvidia_query.GetDefaultPoller(),
+		rootCtx:   ctx,
+		cancel:    ccancel,
+		poller:    nvidia_query.GetDefaultPoller(),
+		cfg:       cfg,
+		throttles: newThrottleTracker(),
 	}
 }
 
@@ -39,6 +41,9 @@ type component struct {
 	cancel   context.CancelFunc
 	poller   query.Poller
 	gatherer prometheus.Gatherer
+	cfg      Config
+
+	throttles *throttleTracker
 }
 
 func (c *component) Name() string { return Name }
@@ -58,6 +63,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -96,6 +104,7 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 		return cs, nil
 	}
 	output := ToOutput(allOutput)
+	output.ObserveThrottles(c.throttles, last.Time.Time, c.cfg.ThrottleWindow.Duration)
 	return output.States()
 }
 
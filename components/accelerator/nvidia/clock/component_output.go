@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 )
 
@@ -41,6 +43,47 @@ func ToOutput(i *nvidia_query.Output) *Output {
 type Output struct {
 	HWSlowdownSMI   HWSlowdownSMI                   `json:"hw_slowdown_smi"`
 	ClockEventsNVML []nvidia_query_nvml.ClockEvents `json:"clock_events_nvml"`
+
+	// ActiveThrottles are the throttle reasons active at the time of the query.
+	ActiveThrottles []string `json:"active_throttles,omitempty"`
+	// SustainedThrottles are the throttle reasons active for at least the throttle window.
+	SustainedThrottles []Throttle `json:"sustained_throttles,omitempty"`
+	// ThrottleWindow is the duration a throttle reason must stay active to mark the GPU unhealthy.
+	ThrottleWindow metav1.Duration `json:"throttle_window"`
+}
+
+// throttleKeys returns the active throttle reasons keyed by the GPU and the reason.
+// The nvidia-smi slowdowns are only used when NVML does not report the clock events,
+// not to count the same slowdown twice.
+func (o *Output) throttleKeys() []string {
+	keys := make([]string, 0)
+	for _, ev := range o.ClockEventsNVML {
+		if ev.HWSlowdown {
+			keys = append(keys, ev.UUID+" hw slowdown")
+		}
+		if ev.HWSlowdownThermal {
+			keys = append(keys, ev.UUID+" hw slowdown thermal")
+		}
+		if ev.HWSlowdownPowerBrake {
+			keys = append(keys, ev.UUID+" hw slowdown power brake")
+		}
+		if ev.SWThermalSlowdown {
+			keys = append(keys, ev.UUID+" sw thermal slowdown")
+		}
+	}
+	if len(o.ClockEventsNVML) == 0 {
+		keys = append(keys, o.HWSlowdownSMI.Errors...)
+	}
+	return keys
+}
+
+// ObserveThrottles records the active throttle reasons at the given time,
+// and sets the throttle reasons active for at least the window.
+func (o *Output) ObserveThrottles(tracker *throttleTracker, ts time.Time, window time.Duration) {
+	o.ActiveThrottles = o.throttleKeys()
+	tracker.Observe(o.ActiveThrottles, ts)
+	o.SustainedThrottles = tracker.Sustained(ts, window)
+	o.ThrottleWindow = metav1.Duration{Duration: window}
 }
 
 type HWSlowdownSMI struct {
@@ -67,6 +110,12 @@ const (
 	StateValueHWSlowdownEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameHWSlowdown, "per-GPU clock events (e.g., HW slowdown)"),
+	)
+}
+
 func ParseStateHWSlowdown(m map[string]string) (*Output, error) {
 	data := m[StateKeyHWSlowdownData]
 	return ParseOutputJSON([]byte(data))
@@ -89,66 +138,40 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 	return nil, errors.New("no state found")
 }
 
+// States returns the throttle state, unhealthy if any throttle reason
+// stayed active for at least the throttle window (see ObserveThrottles).
 func (o *Output) States() ([]components.State, error) {
 	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameHWSlowdown,
+		Healthy: true,
+		Reason:  "no critical clock event error found",
+		ExtraInfo: map[string]string{
+			StateKeyHWSlowdownData:     string(b),
+			StateKeyHWSlowdownEncoding: StateValueHWSlowdownEncodingJSON,
+		},
+	}
 
-	hasClockEventError := false
-	reasons := []string{}
-	for _, clockEvents := range o.ClockEventsNVML {
-		if len(clockEvents.Reasons) == 0 {
-			continue
-		}
-		if clockEvents.HWSlowdown {
-			hasClockEventError = true
-			reasons = append(reasons, clockEvents.UUID+" hw slowdown")
-			break
-		}
-		if clockEvents.HWSlowdownThermal {
-			hasClockEventError = true
-			reasons = append(reasons, clockEvents.UUID+" hw slowdown thermal")
-			break
+	if len(o.SustainedThrottles) > 0 {
+		reasons := make([]string, 0, len(o.SustainedThrottles))
+		for _, th := range o.SustainedThrottles {
+			reasons = append(reasons, fmt.Sprintf("%s since %s", th.Key, th.Since.UTC().Format(time.RFC3339)))
 		}
-		if clockEvents.HWSlowdownPowerBrake {
-			hasClockEventError = true
-			reasons = append(reasons, clockEvents.UUID+" hw slowdown power brake")
-			break
+		yb, err := yaml.Marshal(reasons)
+		if err != nil {
+			return nil, err
 		}
+		state.Healthy = false
+		state.Reason = fmt.Sprintf("sustained clock throttling found (active for at least %s)\n\n%s", o.ThrottleWindow.Duration, string(yb))
+		return []components.State{state}, nil
 	}
 
-	yb, err := yaml.Marshal(reasons)
-	if err != nil {
-		return nil, err
-	}
-
-	if !hasClockEventError && len(o.HWSlowdownSMI.Errors) == 0 {
-		rm := "no critical clock event error found"
-		if len(reasons) > 0 {
-			rm = "\n\n(below are other non-critical reasons found)\n\n" + string(yb)
+	if len(o.ActiveThrottles) > 0 {
+		yb, err := yaml.Marshal(o.ActiveThrottles)
+		if err != nil {
+			return nil, err
 		}
-		return []components.State{
-			{
-				Name:    StateNameHWSlowdown,
-				Healthy: true,
-				Reason:  rm,
-				ExtraInfo: map[string]string{
-					StateKeyHWSlowdownData:     string(b),
-					StateKeyHWSlowdownEncoding: StateValueHWSlowdownEncodingJSON,
-				},
-			},
-		}, nil
+		state.Reason = fmt.Sprintf("clock event found (not sustained for %s yet)\n\n%s", o.ThrottleWindow.Duration, string(yb))
 	}
-
-	healthy := !hasClockEventError && len(o.HWSlowdownSMI.Errors) == 0
-
-	return []components.State{
-		{
-			Name:    StateNameHWSlowdown,
-			Healthy: healthy,
-			Reason:  "clock event found\n\n" + string(yb),
-			ExtraInfo: map[string]string{
-				StateKeyHWSlowdownData:     string(b),
-				StateKeyHWSlowdownEncoding: StateValueHWSlowdownEncodingJSON,
-			},
-		},
-	}, nil
+	return []components.State{state}, nil
 }
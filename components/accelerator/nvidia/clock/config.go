@@ -3,12 +3,27 @@ package clock
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 
 	query_config "github.com/leptonai/gpud/components/query/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type Config struct {
 	Query query_config.Config `json:"query"`
+
+	// ThrottleWindow is the duration a throttle reason (HW slowdown, HW thermal slowdown,
+	// HW power brake slowdown, or SW thermal slowdown) must stay active to mark the GPU unhealthy.
+	// If not set, it defaults to DefaultThrottleWindow.
+	ThrottleWindow metav1.Duration `json:"throttle_window"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	cfg.Query.SetDefaultsIfNotSet()
+	if cfg.ThrottleWindow.Duration == 0 {
+		cfg.ThrottleWindow = metav1.Duration{Duration: DefaultThrottleWindow}
+	}
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -28,5 +43,8 @@ func ParseConfig(b any, db *sql.DB) (*Config, error) {
 }
 
 func (cfg Config) Validate() error {
+	if cfg.ThrottleWindow.Duration < 0 {
+		return fmt.Errorf("throttle window must be non-negative, got %s", cfg.ThrottleWindow.Duration)
+	}
 	return nil
 }
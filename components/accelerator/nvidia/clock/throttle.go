@@ -0,0 +1,70 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultThrottleWindow is the default duration a throttle reason must stay active
+// to mark the GPU unhealthy, so that the transient slowdowns (e.g., a short power brake)
+// are reported but do not flip the health.
+const DefaultThrottleWindow = 5 * time.Minute
+
+// Throttle is a throttle reason active since the time.
+type Throttle struct {
+	// Key is the GPU UUID and the throttle reason (e.g., "GPU-0 hw slowdown thermal").
+	Key   string    `json:"key"`
+	Since time.Time `json:"since"`
+}
+
+// throttleTracker tracks the time each throttle reason became active,
+// and resets it once the reason is observed inactive.
+type throttleTracker struct {
+	mu           sync.Mutex
+	lastObserved time.Time
+	active       map[string]time.Time
+}
+
+func newThrottleTracker() *throttleTracker {
+	return &throttleTracker{active: make(map[string]time.Time)}
+}
+
+// Observe records the active throttle reasons at the given time,
+// and the reasons not in the list are considered inactive.
+// Observations older than or equal to the last observation are ignored,
+// so it is safe to observe the same sample multiple times.
+func (t *throttleTracker) Observe(activeKeys []string, ts time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !ts.After(t.lastObserved) {
+		return
+	}
+	t.lastObserved = ts
+
+	active := make(map[string]time.Time, len(activeKeys))
+	for _, k := range activeKeys {
+		since, ok := t.active[k]
+		if !ok {
+			since = ts
+		}
+		active[k] = since
+	}
+	t.active = active
+}
+
+// Sustained returns the throttle reasons active for at least the window as of the given time, sorted by the key.
+func (t *throttleTracker) Sustained(now time.Time, window time.Duration) []Throttle {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var ths []Throttle
+	for k, since := range t.active {
+		if now.Sub(since) >= window {
+			ths = append(ths, Throttle{Key: k, Since: since})
+		}
+	}
+	sort.Slice(ths, func(i, j int) bool { return ths[i].Key < ths[j].Key })
+	return ths
+}
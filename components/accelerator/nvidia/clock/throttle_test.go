@@ -0,0 +1,103 @@
+package clock
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+func TestThrottleTracker(t *testing.T) {
+	t.Parallel()
+
+	base := time.Unix(1700000000, 0)
+	tr := newThrottleTracker()
+
+	tr.Observe([]string{"GPU-0 hw slowdown", "GPU-1 sw thermal slowdown"}, base)
+	tr.Observe([]string{"GPU-0 hw slowdown", "GPU-1 sw thermal slowdown"}, base.Add(3*time.Minute))
+	// GPU-1 recovered, then throttled again
+	tr.Observe([]string{"GPU-0 hw slowdown"}, base.Add(4*time.Minute))
+	tr.Observe([]string{"GPU-0 hw slowdown", "GPU-1 sw thermal slowdown"}, base.Add(5*time.Minute))
+	// older observation is ignored
+	tr.Observe(nil, base.Add(time.Minute))
+
+	got := tr.Sustained(base.Add(5*time.Minute), 5*time.Minute)
+	want := []Throttle{{Key: "GPU-0 hw slowdown", Since: base}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sustained() = %+v, want %+v", got, want)
+	}
+	if got := tr.Sustained(base.Add(5*time.Minute), 0); len(got) != 2 {
+		t.Errorf("expected 2 active throttles with zero window, got %+v", got)
+	}
+
+	tr.Observe(nil, base.Add(6*time.Minute))
+	if got := tr.Sustained(base.Add(6*time.Minute), 0); len(got) != 0 {
+		t.Errorf("expected no throttle, got %+v", got)
+	}
+}
+
+func TestOutputObserveThrottles(t *testing.T) {
+	t.Parallel()
+
+	base := time.Unix(1700000000, 0)
+	window := 5 * time.Minute
+	tr := newThrottleTracker()
+
+	newOutput := func(thermal bool) *Output {
+		return &Output{
+			ClockEventsNVML: []nvidia_query_nvml.ClockEvents{
+				{UUID: "GPU-0", HWSlowdown: thermal, HWSlowdownThermal: thermal},
+				{UUID: "GPU-1"},
+			},
+			// ignored when NVML reports the clock events
+			HWSlowdownSMI: HWSlowdownSMI{Errors: []string{"GPU-0: ClockEventReasons.HWSlowdown.ThermalSlowdown Active"}},
+		}
+	}
+
+	// transient throttling is reported, but healthy
+	o := newOutput(true)
+	o.ObserveThrottles(tr, base, window)
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !states[0].Healthy || !strings.Contains(states[0].Reason, "GPU-0 hw slowdown thermal") {
+		t.Errorf("expected healthy state with the active throttles, got %+v", states[0])
+	}
+
+	// sustained over the window
+	o = newOutput(true)
+	o.ObserveThrottles(tr, base.Add(window), window)
+	states, err = o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if states[0].Healthy || !strings.Contains(states[0].Reason, "sustained clock throttling found (active for at least 5m0s)") {
+		t.Errorf("expected unhealthy state, got %+v", states[0])
+	}
+	if !reflect.DeepEqual(o.ActiveThrottles, []string{"GPU-0 hw slowdown", "GPU-0 hw slowdown thermal"}) {
+		t.Errorf("unexpected active throttles: %v", o.ActiveThrottles)
+	}
+
+	// recovered
+	o = newOutput(false)
+	o.ObserveThrottles(tr, base.Add(window+time.Minute), window)
+	states, err = o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !states[0].Healthy || states[0].Reason != "no critical clock event error found" {
+		t.Errorf("expected healthy state, got %+v", states[0])
+	}
+}
+
+func TestOutputThrottleKeysSMI(t *testing.T) {
+	t.Parallel()
+
+	o := &Output{HWSlowdownSMI: HWSlowdownSMI{Errors: []string{"GPU-0: ClockEventReasons.HWSlowdown.PowerBrakeSlowdown Active"}}}
+	if got := o.throttleKeys(); !reflect.DeepEqual(got, []string{"GPU-0: ClockEventReasons.HWSlowdown.PowerBrakeSlowdown Active"}) {
+		t.Errorf("unexpected throttle keys: %v", got)
+	}
+}
@@ -0,0 +1,333 @@
+package compatibility
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/execlimit"
+	"github.com/leptonai/gpud/pkg/file"
+)
+
+const (
+	MismatchDriverLibrary    = "driver_library"
+	MismatchCUDA             = "cuda"
+	MismatchContainerToolkit = "container_toolkit"
+)
+
+// Mismatch is an incompatibility between the versions.
+type Mismatch struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+type Output struct {
+	// KernelDriverVersion is the loaded kernel module version.
+	KernelDriverVersion string `json:"kernel_driver_version,omitempty"`
+	// LibraryVersion is the installed NVML user-space library version.
+	LibraryVersion string `json:"library_version,omitempty"`
+	// CUDADriverVersion is the maximum CUDA version supported by the driver (e.g., "12.4").
+	CUDADriverVersion string `json:"cuda_driver_version,omitempty"`
+	// CUDARuntimeVersion is the installed CUDA toolkit version (e.g., "12.2.2").
+	CUDARuntimeVersion string `json:"cuda_runtime_version,omitempty"`
+	// ContainerToolkitVersion is the installed nvidia-container-toolkit version.
+	ContainerToolkitVersion string `json:"container_toolkit_version,omitempty"`
+
+	// NVMLError is the NVML error, if any.
+	NVMLError string `json:"nvml_error,omitempty"`
+
+	Mismatches []Mismatch `json:"mismatches,omitempty"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameCompatibility = "compatibility"
+
+	StateKeyCompatibilityData           = "data"
+	StateKeyCompatibilityEncoding       = "encoding"
+	StateValueCompatibilityEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameCompatibility, "driver, library, CUDA, and container toolkit versions against the compatibility matrix"),
+	)
+}
+
+func ParseStateCompatibility(m map[string]string) (*Output, error) {
+	data := m[StateKeyCompatibilityData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameCompatibility:
+			o, err := ParseStateCompatibility(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Check validates the collected versions against the config,
+// and sets the mismatches. The unknown versions are not checked.
+func (o *Output) Check(cfg Config) error {
+	o.Mismatches = nil
+
+	if o.KernelDriverVersion != "" && o.LibraryVersion != "" && o.KernelDriverVersion != o.LibraryVersion {
+		o.Mismatches = append(o.Mismatches, Mismatch{
+			Kind:    MismatchDriverLibrary,
+			Message: fmt.Sprintf("Driver/library version mismatch (kernel module %s, library %s)", o.KernelDriverVersion, o.LibraryVersion),
+		})
+	}
+
+	driverVersion := o.KernelDriverVersion
+	if driverVersion == "" {
+		driverVersion = o.LibraryVersion
+	}
+	if o.CUDARuntimeVersion != "" && driverVersion != "" {
+		runtimeMajor, err := majorVersion(o.CUDARuntimeVersion)
+		if err != nil {
+			return err
+		}
+		if o.CUDADriverVersion != "" {
+			driverMajor, err := majorVersion(o.CUDADriverVersion)
+			if err != nil {
+				return err
+			}
+			if runtimeMajor > driverMajor {
+				o.Mismatches = append(o.Mismatches, Mismatch{
+					Kind:    MismatchCUDA,
+					Message: fmt.Sprintf("CUDA %s requires a newer driver (driver %s supports up to CUDA %s)", o.CUDARuntimeVersion, driverVersion, o.CUDADriverVersion),
+				})
+			}
+		}
+		for _, r := range cfg.Matrix {
+			if r.CUDAMajor != runtimeMajor {
+				continue
+			}
+			cmp, err := CompareVersions(driverVersion, r.MinDriverVersion)
+			if err != nil {
+				return err
+			}
+			if cmp < 0 {
+				o.Mismatches = append(o.Mismatches, Mismatch{
+					Kind:    MismatchCUDA,
+					Message: fmt.Sprintf("CUDA %s requires the driver %s or newer (found %s)", o.CUDARuntimeVersion, r.MinDriverVersion, driverVersion),
+				})
+			}
+		}
+	}
+
+	if cfg.MinContainerToolkitVersion != "" && o.ContainerToolkitVersion != "" {
+		cmp, err := CompareVersions(o.ContainerToolkitVersion, cfg.MinContainerToolkitVersion)
+		if err != nil {
+			return err
+		}
+		if cmp < 0 {
+			o.Mismatches = append(o.Mismatches, Mismatch{
+				Kind:    MismatchContainerToolkit,
+				Message: fmt.Sprintf("nvidia-container-toolkit %s is older than the required %s", o.ContainerToolkitVersion, cfg.MinContainerToolkitVersion),
+			})
+		}
+	}
+	return nil
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool) {
+	if len(o.Mismatches) > 0 {
+		msgs := make([]string, 0, len(o.Mismatches))
+		for _, m := range o.Mismatches {
+			msgs = append(msgs, m.Message)
+		}
+		return strings.Join(msgs, "; "), false
+	}
+
+	versions := []string{
+		"kernel driver " + orUnknown(o.KernelDriverVersion),
+		"library " + orUnknown(o.LibraryVersion),
+		"CUDA driver " + orUnknown(o.CUDADriverVersion),
+		"CUDA runtime " + orUnknown(o.CUDARuntimeVersion),
+		"container toolkit " + orUnknown(o.ContainerToolkitVersion),
+	}
+	return "compatible versions (" + strings.Join(versions, ", ") + ")", true
+}
+
+func orUnknown(v string) string {
+	if v == "" {
+		return "unknown"
+	}
+	return v
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, healthy := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameCompatibility,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyCompatibilityData:     string(b),
+			StateKeyCompatibilityEncoding: StateValueCompatibilityEncodingJSON,
+		},
+	}
+	for _, m := range o.Mismatches {
+		var actions *common.SuggestedActions
+		switch m.Kind {
+		case MismatchDriverLibrary:
+			// the driver was upgraded without reloading the kernel module
+			actions = &common.SuggestedActions{
+				Descriptions: []string{
+					"the loaded kernel module does not match the installed driver libraries (e.g., driver upgraded without the reload), reload the NVIDIA driver or reboot the system",
+				},
+				RepairActions: []common.RepairActionType{
+					common.RepairActionTypeReloadDriver,
+				},
+			}
+		case MismatchCUDA:
+			actions = &common.SuggestedActions{
+				Descriptions: []string{
+					"upgrade the NVIDIA driver, or install the CUDA toolkit supported by the driver",
+				},
+			}
+		case MismatchContainerToolkit:
+			actions = &common.SuggestedActions{
+				Descriptions: []string{
+					"upgrade the nvidia-container-toolkit",
+				},
+			}
+		}
+		if state.SuggestedActions == nil {
+			state.SuggestedActions = actions
+		} else {
+			state.SuggestedActions.Add(actions)
+		}
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the configured matrix
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg))
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+func CreateGet(cfg Config) query.GetFunc {
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		o := &Output{}
+
+		if b, err := os.ReadFile(DefaultProcDriverVersionPath); err == nil {
+			o.KernelDriverVersion = ParseProcDriverVersion(string(b))
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		var opts []file.OpOption
+		if len(cfg.LibrarySearchDirs) > 0 {
+			opts = append(opts, file.WithSearchDirs(cfg.LibrarySearchDirs...))
+		}
+		if p, err := file.FindLibrary(DefaultNVMLLibrary, opts...); err == nil && p != "" {
+			o.LibraryVersion = ParseLibraryVersion(p)
+		} else if err != nil {
+			log.Logger.Debugw("failed to find nvml library", "error", err)
+		}
+
+		// fails with "Driver/library version mismatch" if the versions mismatch,
+		// the library and kernel versions are checked regardless
+		if v, err := nvidia_query_nvml.GetCUDADriverVersion(); err == nil {
+			o.CUDADriverVersion = v
+		} else {
+			o.NVMLError = err.Error()
+		}
+
+		if b, err := os.ReadFile(DefaultCUDAVersionJSONPath); err == nil {
+			v, err := ParseCUDAVersionJSON(b)
+			if err != nil {
+				return nil, err
+			}
+			o.CUDARuntimeVersion = v
+		} else if b, err := os.ReadFile(DefaultCUDAVersionTxtPath); err == nil {
+			o.CUDARuntimeVersion = ParseCUDAVersionTxt(string(b))
+		}
+
+		o.ContainerToolkitVersion = getContainerToolkitVersion(ctx)
+
+		if err := o.Check(cfg); err != nil {
+			return nil, err
+		}
+		return o, nil
+	}
+}
+
+// getContainerToolkitVersion returns the nvidia-container-toolkit version,
+// or an empty string if not installed.
+func getContainerToolkitVersion(ctx context.Context) string {
+	for _, bin := range []string{"nvidia-ctk", "nvidia-container-cli"} {
+		p, err := file.LocateExecutable(bin)
+		if err != nil || p == "" {
+			continue
+		}
+		if err := execlimit.Wait(ctx, Name); err != nil {
+			return ""
+		}
+		b, err := exec.CommandContext(ctx, p, "--version").CombinedOutput()
+		if err != nil {
+			log.Logger.Debugw("failed to get container toolkit version", "binary", bin, "error", err)
+			continue
+		}
+		if v := ParseContainerToolkitVersion(string(b)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package compatibility
+
+import (
+	"testing"
+)
+
+func TestParseVersions(t *testing.T) {
+	if v := ParseProcDriverVersion("NVRM version: NVIDIA UNIX x86_64 Kernel Module  535.129.03  Thu Oct 19 18:56:32 UTC 2023\nGCC version:  gcc version 11.4.0"); v != "535.129.03" {
+		t.Errorf("unexpected kernel driver version %q", v)
+	}
+	if v := ParseProcDriverVersion("NVRM version: NVIDIA UNIX Open Kernel Module for x86_64  550.54.14  Release Build  (dvs-builder@U16-I3-B03-4-3)  Thu Feb 22 01:25:25 UTC 2024"); v != "550.54.14" {
+		t.Errorf("unexpected open kernel driver version %q", v)
+	}
+	if v := ParseLibraryVersion("/usr/lib/x86_64-linux-gnu/libnvidia-ml.so.535.129.03"); v != "535.129.03" {
+		t.Errorf("unexpected library version %q", v)
+	}
+	if v := ParseLibraryVersion("/usr/lib/x86_64-linux-gnu/libnvidia-ml.so.1"); v != "" {
+		t.Errorf("unexpected library version %q", v)
+	}
+	v, err := ParseCUDAVersionJSON([]byte(`{"cuda": {"name": "CUDA SDK", "version": "12.2.2"}, "cuda_cudart": {"name": "CUDA Runtime (cudart)", "version": "12.2.140"}}`))
+	if err != nil || v != "12.2.2" {
+		t.Errorf("unexpected cuda version %q (%v)", v, err)
+	}
+	if v := ParseCUDAVersionTxt("CUDA Version 11.0.228\n"); v != "11.0.228" {
+		t.Errorf("unexpected cuda version %q", v)
+	}
+	if v := ParseContainerToolkitVersion("NVIDIA Container Toolkit CLI version 1.14.3\ncommit: d167812ce3a55ec04ae2582eff1654ec812f42e1"); v != "1.14.3" {
+		t.Errorf("unexpected container toolkit version %q", v)
+	}
+	if v := ParseContainerToolkitVersion("cli-version: 1.17.8\nlib-version: 1.17.8"); v != "1.17.8" {
+		t.Errorf("unexpected container toolkit version %q", v)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"535.129.03", "535.54.03", 1},
+		{"525.60.13", "525.60.13", 0},
+		{"470.42.01", "525.60.13", -1},
+		{"1.17", "1.17.0", 0},
+	}
+	for _, tt := range tests {
+		got, err := CompareVersions(tt.a, tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if _, err := CompareVersions("535.x", "535.1"); err == nil {
+		t.Error("expected an error for the invalid version")
+	}
+}
+
+func TestOutputCheck(t *testing.T) {
+	cfg := Config{MinContainerToolkitVersion: "1.17.8"}
+	cfg.SetDefaultsIfNotSet()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		output     Output
+		wantKinds  []string
+		wantReload bool
+	}{
+		{
+			name: "compatible",
+			output: Output{
+				KernelDriverVersion:     "535.129.03",
+				LibraryVersion:          "535.129.03",
+				CUDADriverVersion:       "12.2",
+				CUDARuntimeVersion:      "12.2.2",
+				ContainerToolkitVersion: "1.17.8",
+			},
+		},
+		{
+			name: "driver library mismatch",
+			output: Output{
+				KernelDriverVersion: "535.129.03",
+				LibraryVersion:      "550.54.14",
+			},
+			wantKinds:  []string{MismatchDriverLibrary},
+			wantReload: true,
+		},
+		{
+			name: "cuda too new",
+			output: Output{
+				KernelDriverVersion: "470.42.01",
+				CUDADriverVersion:   "11.4",
+				CUDARuntimeVersion:  "12.2.2",
+			},
+			wantKinds: []string{MismatchCUDA, MismatchCUDA},
+		},
+		{
+			name: "old container toolkit",
+			output: Output{
+				LibraryVersion:          "535.129.03",
+				ContainerToolkitVersion: "1.14.3",
+			},
+			wantKinds: []string{MismatchContainerToolkit},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.output
+			if err := o.Check(cfg); err != nil {
+				t.Fatal(err)
+			}
+			if len(o.Mismatches) != len(tt.wantKinds) {
+				t.Fatalf("expected %d mismatches, got %+v", len(tt.wantKinds), o.Mismatches)
+			}
+			for i, m := range o.Mismatches {
+				if m.Kind != tt.wantKinds[i] {
+					t.Errorf("expected mismatch %q, got %q", tt.wantKinds[i], m.Kind)
+				}
+			}
+
+			states, err := o.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if states[0].Healthy != (len(tt.wantKinds) == 0) {
+				t.Errorf("unexpected healthy %+v", states[0])
+			}
+			if got := states[0].SuggestedActions.RequiresDriverReload(); got != tt.wantReload {
+				t.Errorf("expected driver reload %v, got %v", tt.wantReload, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package compatibility
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+// Rule is the minimum driver version required by the CUDA toolkit major version,
+// with the CUDA minor version compatibility.
+// ref. https://docs.nvidia.com/deploy/cuda-compatibility/index.html#minor-version-compatibility
+type Rule struct {
+	CUDAMajor        int    `json:"cuda_major"`
+	MinDriverVersion string `json:"min_driver_version"`
+}
+
+// DefaultMatrix is the default compatibility matrix.
+// ref. https://docs.nvidia.com/cuda/cuda-toolkit-release-notes/index.html#id5
+var DefaultMatrix = []Rule{
+	{CUDAMajor: 11, MinDriverVersion: "450.80.02"},
+	{CUDAMajor: 12, MinDriverVersion: "525.60.13"},
+}
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// Matrix is the minimum driver versions per CUDA toolkit major version.
+	// If not set, it defaults to the CUDA minor version compatibility requirements.
+	Matrix []Rule `json:"matrix"`
+
+	// MinContainerToolkitVersion is the minimum nvidia-container-toolkit version
+	// (e.g., "1.17.8" for the known container escape fixes).
+	// Not checked if empty, or the toolkit is not installed.
+	MinContainerToolkitVersion string `json:"min_container_toolkit_version"`
+
+	// LibrarySearchDirs is the directories to search the NVML library.
+	LibrarySearchDirs []string `json:"library_search_dirs"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if len(cfg.Matrix) == 0 {
+		cfg.Matrix = DefaultMatrix
+	}
+}
+
+func (cfg Config) Validate() error {
+	for _, r := range cfg.Matrix {
+		if r.CUDAMajor <= 0 {
+			return fmt.Errorf("invalid cuda major version %d", r.CUDAMajor)
+		}
+		if _, err := CompareVersions(r.MinDriverVersion, "0"); err != nil {
+			return err
+		}
+	}
+	if cfg.MinContainerToolkitVersion != "" {
+		if _, err := CompareVersions(cfg.MinContainerToolkitVersion, "0"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
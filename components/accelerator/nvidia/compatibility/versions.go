@@ -0,0 +1,128 @@
+package compatibility
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultProcDriverVersionPath is the version file of the loaded NVIDIA kernel module.
+	DefaultProcDriverVersionPath = "/proc/driver/nvidia/version"
+
+	// DefaultNVMLLibrary is the NVML user-space library, symlinked to the versioned library
+	// (e.g., "libnvidia-ml.so.535.129.03").
+	DefaultNVMLLibrary = "libnvidia-ml.so.1"
+
+	// DefaultCUDAVersionJSONPath and DefaultCUDAVersionTxtPath are the CUDA toolkit version files
+	// (the "version.txt" for the toolkit 11.0 and older).
+	DefaultCUDAVersionJSONPath = "/usr/local/cuda/version.json"
+	DefaultCUDAVersionTxtPath  = "/usr/local/cuda/version.txt"
+)
+
+var (
+	// e.g.,
+	// NVRM version: NVIDIA UNIX x86_64 Kernel Module  535.129.03  Thu Oct 19 18:56:32 UTC 2023
+	// NVRM version: NVIDIA UNIX Open Kernel Module for x86_64  550.54.14  Release Build  (dvs-builder@U16-I3-B03-4-3)  Thu Feb 22 01:25:25 UTC 2024
+	regexProcDriverVersion = regexp.MustCompile(`Kernel Module(?: for \S+)?\s+([0-9]+\.[0-9]+(?:\.[0-9]+)?)`)
+
+	// e.g.,
+	// CUDA Version 11.0.228
+	regexCUDAVersionTxt = regexp.MustCompile(`CUDA Version ([0-9]+\.[0-9]+(?:\.[0-9]+)?)`)
+
+	// e.g.,
+	// NVIDIA Container Toolkit CLI version 1.14.3
+	// cli-version: 1.14.3
+	regexContainerToolkitVersion = regexp.MustCompile(`version:?\s+v?([0-9]+\.[0-9]+(?:\.[0-9]+)?)`)
+)
+
+// ParseProcDriverVersion parses the kernel driver version from the "/proc/driver/nvidia/version" contents.
+// Returns an empty string if not found.
+func ParseProcDriverVersion(s string) string {
+	if match := regexProcDriverVersion.FindStringSubmatch(s); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// ParseLibraryVersion parses the version from the resolved library path
+// (e.g., "535.129.03" from "/usr/lib/x86_64-linux-gnu/libnvidia-ml.so.535.129.03").
+// Returns an empty string if the library is not versioned.
+func ParseLibraryVersion(path string) string {
+	_, ver, ok := strings.Cut(filepath.Base(path), ".so.")
+	if !ok {
+		return ""
+	}
+	// "libnvidia-ml.so.1" is the SONAME, not the driver version
+	if !strings.Contains(ver, ".") {
+		return ""
+	}
+	return ver
+}
+
+// ParseCUDAVersionJSON parses the CUDA toolkit version from the "version.json" contents.
+func ParseCUDAVersionJSON(b []byte) (string, error) {
+	var v struct {
+		CUDA struct {
+			Version string `json:"version"`
+		} `json:"cuda"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return "", err
+	}
+	return v.CUDA.Version, nil
+}
+
+// ParseCUDAVersionTxt parses the CUDA toolkit version from the "version.txt" contents.
+// Returns an empty string if not found.
+func ParseCUDAVersionTxt(s string) string {
+	if match := regexCUDAVersionTxt.FindStringSubmatch(s); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// ParseContainerToolkitVersion parses the version from the "nvidia-ctk --version"
+// or "nvidia-container-cli --version" output. Returns an empty string if not found.
+func ParseContainerToolkitVersion(s string) string {
+	if match := regexContainerToolkitVersion.FindStringSubmatch(s); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// CompareVersions compares the dotted numeric versions (e.g., "535.129.03" and "535.54.03"),
+// returning -1, 0, or 1. The missing components are treated as zero.
+func CompareVersions(a, b string) (int, error) {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		av, bv := 0, 0
+		var err error
+		if i < len(as) {
+			if av, err = strconv.Atoi(as[i]); err != nil {
+				return 0, fmt.Errorf("invalid version %q: %w", a, err)
+			}
+		}
+		if i < len(bs) {
+			if bv, err = strconv.Atoi(bs[i]); err != nil {
+				return 0, fmt.Errorf("invalid version %q: %w", b, err)
+			}
+		}
+		switch {
+		case av < bv:
+			return -1, nil
+		case av > bv:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// majorVersion returns the major version of the dotted version (e.g., 12 for "12.2.2").
+func majorVersion(v string) (int, error) {
+	major, _, _ := strings.Cut(v, ".")
+	return strconv.Atoi(major)
+}
@@ -0,0 +1,135 @@
+package confidentialcompute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+)
+
+type Output struct {
+	State nvidia_query_nvml.ConfidentialComputeState `json:"state"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameConfidentialCompute = "confidential_compute"
+
+	StateKeyConfidentialComputeData           = "data"
+	StateKeyConfidentialComputeEncoding       = "encoding"
+	StateValueConfidentialComputeEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameConfidentialCompute, "confidential computing mode and attestation readiness"),
+	)
+}
+
+func ParseStateConfidentialCompute(m map[string]string) (*Output, error) {
+	data := m[StateKeyConfidentialComputeData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameConfidentialCompute:
+			o, err := ParseStateConfidentialCompute(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate(expectedMode string) (string, bool) {
+	st := o.State
+	if expectedMode != "" && st.Mode != expectedMode {
+		if expectedMode != nvidia_query_nvml.ConfidentialComputeModeOff && !st.GPUsCapable {
+			return fmt.Sprintf("confidential compute mode %q expected but the GPUs are not capable (cpu capability %q)", expectedMode, st.CPUCapability), false
+		}
+		return fmt.Sprintf("confidential compute mode %q does not match the expected mode %q", st.Mode, expectedMode), false
+	}
+
+	if st.Mode == nvidia_query_nvml.ConfidentialComputeModeOff {
+		return fmt.Sprintf("confidential compute mode is off (gpus capable %v, cpu capability %q)", st.GPUsCapable, st.CPUCapability), true
+	}
+
+	// the GPUs do not accept the work until the attestation succeeds
+	if !st.ReadyForWork {
+		return fmt.Sprintf("confidential compute mode is %q but the GPUs are not ready for work (attestation pending or failed)", st.Mode), false
+	}
+	return fmt.Sprintf("confidential compute mode is %q and the GPUs are ready for work (environment %q)", st.Mode, st.Environment), true
+}
+
+func (o *Output) States(cfg Config) ([]components.State, error) {
+	reason, healthy := o.Evaluate(cfg.ExpectedMode)
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameConfidentialCompute,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyConfidentialComputeData:     string(b),
+			StateKeyConfidentialComputeEncoding: StateValueConfidentialComputeEncodingJSON,
+		},
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the nvml library
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, Get)
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+func Get(ctx context.Context) (_ any, e error) {
+	defer func() {
+		if e != nil {
+			components_metrics.SetGetFailed(Name)
+		} else {
+			components_metrics.SetGetSuccess(Name)
+		}
+	}()
+
+	st, err := nvidia_query_nvml.GetConfidentialComputeState()
+	if err != nil {
+		return nil, err
+	}
+	return &Output{State: st}, nil
+}
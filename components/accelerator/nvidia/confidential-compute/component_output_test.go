@@ -0,0 +1,61 @@
+package confidentialcompute
+
+import (
+	"testing"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+func TestOutputEvaluate(t *testing.T) {
+	tests := []struct {
+		name         string
+		state        nvidia_query_nvml.ConfidentialComputeState
+		expectedMode string
+		healthy      bool
+	}{
+		{
+			name:    "Off without policy",
+			state:   nvidia_query_nvml.ConfidentialComputeState{Mode: nvidia_query_nvml.ConfidentialComputeModeOff},
+			healthy: true,
+		},
+		{
+			name:         "On and ready",
+			state:        nvidia_query_nvml.ConfidentialComputeState{Mode: nvidia_query_nvml.ConfidentialComputeModeOn, GPUsCapable: true, ReadyForWork: true},
+			expectedMode: nvidia_query_nvml.ConfidentialComputeModeOn,
+			healthy:      true,
+		},
+		{
+			name:         "On but attestation pending",
+			state:        nvidia_query_nvml.ConfidentialComputeState{Mode: nvidia_query_nvml.ConfidentialComputeModeOn, GPUsCapable: true},
+			expectedMode: nvidia_query_nvml.ConfidentialComputeModeOn,
+			healthy:      false,
+		},
+		{
+			name:         "Off but expected on",
+			state:        nvidia_query_nvml.ConfidentialComputeState{Mode: nvidia_query_nvml.ConfidentialComputeModeOff, GPUsCapable: true},
+			expectedMode: nvidia_query_nvml.ConfidentialComputeModeOn,
+			healthy:      false,
+		},
+		{
+			name:         "Expected on but not capable",
+			state:        nvidia_query_nvml.ConfidentialComputeState{Mode: nvidia_query_nvml.ConfidentialComputeModeOff},
+			expectedMode: nvidia_query_nvml.ConfidentialComputeModeOn,
+			healthy:      false,
+		},
+		{
+			name:         "Devtools but expected off",
+			state:        nvidia_query_nvml.ConfidentialComputeState{Mode: nvidia_query_nvml.ConfidentialComputeModeDevTools, GPUsCapable: true, ReadyForWork: true},
+			expectedMode: nvidia_query_nvml.ConfidentialComputeModeOff,
+			healthy:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Output{State: tt.state}
+			reason, healthy := o.Evaluate(tt.expectedMode)
+			if healthy != tt.healthy {
+				t.Errorf("Evaluate() = %v (%s), want %v", healthy, reason, tt.healthy)
+			}
+		})
+	}
+}
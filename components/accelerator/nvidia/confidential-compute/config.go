@@ -0,0 +1,47 @@
+package confidentialcompute
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// ExpectedMode is the fleet policy for the confidential computing mode ("on", "off", or "devtools").
+	// The component is marked unhealthy if the current mode does not match.
+	// Leave empty to only report the current mode.
+	ExpectedMode string `json:"expected_mode,omitempty"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	switch cfg.ExpectedMode {
+	case "",
+		nvidia_query_nvml.ConfidentialComputeModeOn,
+		nvidia_query_nvml.ConfidentialComputeModeOff,
+		nvidia_query_nvml.ConfidentialComputeModeDevTools:
+		return nil
+	default:
+		return fmt.Errorf("invalid expected confidential compute mode %q", cfg.ExpectedMode)
+	}
+}
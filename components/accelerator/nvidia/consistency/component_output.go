@@ -0,0 +1,266 @@
+package consistency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	"github.com/leptonai/gpud/components/common"
+)
+
+const (
+	FieldDeviceCount     = "device_count"
+	FieldDriverVersion   = "driver_version"
+	FieldDevice          = "device"
+	FieldProductName     = "product_name"
+	FieldPersistenceMode = "persistence_mode"
+	FieldECCMode         = "ecc_mode"
+	FieldQuery           = "query"
+)
+
+// Discrepancy is a field whose value differs between nvidia-smi and NVML.
+type Discrepancy struct {
+	Field string `json:"field"`
+	// PCI bus ID of the GPU (e.g., "00000000:53:00.0"), empty for the node-wide fields.
+	BusID string `json:"bus_id,omitempty"`
+	SMI   string `json:"smi"`
+	NVML  string `json:"nvml"`
+}
+
+func (d Discrepancy) String() string {
+	if d.BusID == "" {
+		return fmt.Sprintf("%s (nvidia-smi %q, nvml %q)", d.Field, d.SMI, d.NVML)
+	}
+	return fmt.Sprintf("%s of %s (nvidia-smi %q, nvml %q)", d.Field, d.BusID, d.SMI, d.NVML)
+}
+
+type Output struct {
+	SMIExists bool `json:"smi_exists"`
+	// Compared is false if either nvidia-smi or NVML output is not available.
+	Compared      bool          `json:"compared"`
+	Discrepancies []Discrepancy `json:"discrepancies,omitempty"`
+}
+
+// ToOutput compares the nvidia-smi output against the NVML output and the driver version
+// from the currently loaded NVML library (empty if not available).
+// The GPUs are matched by the PCI bus ID.
+func ToOutput(i *nvidia_query.Output, nvmlDriverVersion string) *Output {
+	if i == nil {
+		return &Output{}
+	}
+
+	o := &Output{SMIExists: i.SMIExists}
+	if !i.SMIExists {
+		return o
+	}
+
+	smiOK := i.SMI != nil && len(i.SMIQueryErrors) == 0
+	nvmlOK := i.NVML != nil && len(i.NVMLErrors) == 0
+
+	// e.g., "Failed to initialize NVML: Driver/library version mismatch" from nvidia-smi
+	// while the gpud NVML library loaded before the upgrade still works
+	if smiOK != nvmlOK {
+		d := Discrepancy{Field: FieldQuery, SMI: "ok", NVML: "ok"}
+		if !smiOK {
+			d.SMI = "failed: " + strings.Join(i.SMIQueryErrors, "; ")
+		}
+		if !nvmlOK {
+			d.NVML = "failed: " + strings.Join(i.NVMLErrors, "; ")
+		}
+		o.Discrepancies = append(o.Discrepancies, d)
+		return o
+	}
+	if !smiOK {
+		return o
+	}
+	o.Compared = true
+
+	if len(i.SMI.GPUs) != len(i.NVML.DeviceInfos) {
+		o.Discrepancies = append(o.Discrepancies, Discrepancy{
+			Field: FieldDeviceCount,
+			SMI:   strconv.Itoa(len(i.SMI.GPUs)),
+			NVML:  strconv.Itoa(len(i.NVML.DeviceInfos)),
+		})
+	}
+	if nvmlDriverVersion != "" && i.SMI.DriverVersion != "" && nvmlDriverVersion != i.SMI.DriverVersion {
+		o.Discrepancies = append(o.Discrepancies, Discrepancy{
+			Field: FieldDriverVersion,
+			SMI:   i.SMI.DriverVersion,
+			NVML:  nvmlDriverVersion,
+		})
+	}
+
+	devs := make(map[uint32]int, len(i.NVML.DeviceInfos))
+	for idx, dev := range i.NVML.DeviceInfos {
+		devs[dev.BusID] = idx
+	}
+	matched := make(map[uint32]bool, len(devs))
+	for _, g := range i.SMI.GPUs {
+		busID := strings.TrimPrefix(g.ID, "GPU ")
+		bus, ok := ParseBus(busID)
+		if !ok {
+			continue
+		}
+		idx, ok := devs[bus]
+		if !ok {
+			o.Discrepancies = append(o.Discrepancies, Discrepancy{Field: FieldDevice, BusID: busID, SMI: "found", NVML: "not found"})
+			continue
+		}
+		matched[bus] = true
+		dev := i.NVML.DeviceInfos[idx]
+
+		if g.ProductName != "" && dev.Name != "" && g.ProductName != dev.Name {
+			o.Discrepancies = append(o.Discrepancies, Discrepancy{Field: FieldProductName, BusID: busID, SMI: g.ProductName, NVML: dev.Name})
+		}
+		if smiEnabled := g.GetSMIGPUPersistenceMode().Enabled; smiEnabled != dev.PersistenceMode.Enabled {
+			o.Discrepancies = append(o.Discrepancies, Discrepancy{
+				Field: FieldPersistenceMode,
+				BusID: busID,
+				SMI:   strconv.FormatBool(smiEnabled),
+				NVML:  strconv.FormatBool(dev.PersistenceMode.Enabled),
+			})
+		}
+		if g.ECCMode != nil && g.ECCMode.Current != "" && g.ECCMode.Current != "N/A" {
+			smiEnabled := g.ECCMode.Current == "Enabled"
+			if smiEnabled != dev.ECCMode.EnabledCurrent {
+				o.Discrepancies = append(o.Discrepancies, Discrepancy{
+					Field: FieldECCMode,
+					BusID: busID,
+					SMI:   strconv.FormatBool(smiEnabled),
+					NVML:  strconv.FormatBool(dev.ECCMode.EnabledCurrent),
+				})
+			}
+		}
+	}
+	var unmatched []uint32
+	for bus := range devs {
+		if !matched[bus] {
+			unmatched = append(unmatched, bus)
+		}
+	}
+	sort.Slice(unmatched, func(a, b int) bool { return unmatched[a] < unmatched[b] })
+	for _, bus := range unmatched {
+		o.Discrepancies = append(o.Discrepancies, Discrepancy{
+			Field: FieldDevice,
+			BusID: fmt.Sprintf("bus %02x", bus),
+			SMI:   "not found",
+			NVML:  "found",
+		})
+	}
+
+	return o
+}
+
+// ParseBus parses the bus number from the nvidia-smi PCI bus ID
+// (e.g., 0x53 from "00000000:53:00.0"), to match the NVML device bus ID.
+func ParseBus(busID string) (uint32, bool) {
+	parts := strings.Split(busID, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(v), true
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameConsistency = "consistency"
+
+	StateKeyConsistencyData           = "data"
+	StateKeyConsistencyEncoding       = "encoding"
+	StateValueConsistencyEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameConsistency, "nvidia-smi output cross-validated against NVML"),
+	)
+}
+
+func ParseStateConsistency(m map[string]string) (*Output, error) {
+	data := m[StateKeyConsistencyData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameConsistency:
+			o, err := ParseStateConsistency(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool, error) {
+	if len(o.Discrepancies) > 0 {
+		ds := make([]string, 0, len(o.Discrepancies))
+		for _, d := range o.Discrepancies {
+			ds = append(ds, d.String())
+		}
+		return fmt.Sprintf("nvidia-smi and nvml disagree on %s", strings.Join(ds, ", ")), false, nil
+	}
+	if !o.SMIExists {
+		return "nvidia-smi not found (nothing to compare)", true, nil
+	}
+	if !o.Compared {
+		return "nvidia-smi and nvml both failed (nothing to compare)", true, nil
+	}
+	return "nvidia-smi and nvml agree", true, nil
+}
+
+func (o *Output) States() ([]components.State, error) {
+	outputReasons, healthy, err := o.Evaluate()
+	if err != nil {
+		return nil, err
+	}
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameConsistency,
+		Healthy: healthy,
+		Reason:  outputReasons,
+		ExtraInfo: map[string]string{
+			StateKeyConsistencyData:     string(b),
+			StateKeyConsistencyEncoding: StateValueConsistencyEncodingJSON,
+		},
+	}
+	if !healthy {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"check for the driver/library version mismatch (e.g., a half-upgraded driver package)",
+				"reboot the system to load the installed driver",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRebootSystem,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
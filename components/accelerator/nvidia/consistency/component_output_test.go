@@ -0,0 +1,153 @@
+package consistency
+
+import (
+	"reflect"
+	"testing"
+
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+func TestParseBus(t *testing.T) {
+	tests := []struct {
+		busID    string
+		expected uint32
+		ok       bool
+	}{
+		{busID: "00000000:53:00.0", expected: 0x53, ok: true},
+		{busID: "00000000:1B:00.0", expected: 0x1b, ok: true},
+		{busID: "invalid", ok: false},
+		{busID: "00000000:zz:00.0", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.busID, func(t *testing.T) {
+			got, ok := ParseBus(tt.busID)
+			if ok != tt.ok || got != tt.expected {
+				t.Errorf("ParseBus(%q) = %d, %v, want %d, %v", tt.busID, got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestToOutput(t *testing.T) {
+	smiGPU := func(id string, persistence string, ecc string) nvidia_query.NvidiaSMIGPU {
+		return nvidia_query.NvidiaSMIGPU{
+			ID:              id,
+			ProductName:     "NVIDIA H100 80GB HBM3",
+			PersistenceMode: persistence,
+			ECCMode:         &nvidia_query.SMIECCMode{Current: ecc},
+		}
+	}
+	nvmlDev := func(bus uint32, persistence bool, ecc bool) *nvidia_query_nvml.DeviceInfo {
+		return &nvidia_query_nvml.DeviceInfo{
+			BusID:           bus,
+			Name:            "NVIDIA H100 80GB HBM3",
+			PersistenceMode: nvidia_query_nvml.PersistenceMode{Enabled: persistence},
+			ECCMode:         nvidia_query_nvml.ECCMode{EnabledCurrent: ecc},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		input             *nvidia_query.Output
+		nvmlDriverVersion string
+		expected          *Output
+		expectedHealthy   bool
+	}{
+		{
+			name:            "nil input",
+			expected:        &Output{},
+			expectedHealthy: true,
+		},
+		{
+			name: "agree",
+			input: &nvidia_query.Output{
+				SMIExists: true,
+				SMI: &nvidia_query.SMIOutput{
+					DriverVersion: "550.90.07",
+					GPUs:          []nvidia_query.NvidiaSMIGPU{smiGPU("GPU 00000000:53:00.0", "Enabled", "Enabled")},
+				},
+				NVML: &nvidia_query_nvml.Output{
+					DeviceInfos: []*nvidia_query_nvml.DeviceInfo{nvmlDev(0x53, true, true)},
+				},
+			},
+			nvmlDriverVersion: "550.90.07",
+			expected:          &Output{SMIExists: true, Compared: true},
+			expectedHealthy:   true,
+		},
+		{
+			name: "nvidia-smi fails but nvml works",
+			input: &nvidia_query.Output{
+				SMIExists:      true,
+				SMIQueryErrors: []string{"Failed to initialize NVML: Driver/library version mismatch"},
+				NVML: &nvidia_query_nvml.Output{
+					DeviceInfos: []*nvidia_query_nvml.DeviceInfo{nvmlDev(0x53, true, true)},
+				},
+			},
+			expected: &Output{
+				SMIExists: true,
+				Discrepancies: []Discrepancy{
+					{Field: FieldQuery, SMI: "failed: Failed to initialize NVML: Driver/library version mismatch", NVML: "ok"},
+				},
+			},
+		},
+		{
+			name: "disagree",
+			input: &nvidia_query.Output{
+				SMIExists: true,
+				SMI: &nvidia_query.SMIOutput{
+					DriverVersion: "560.35.03",
+					GPUs: []nvidia_query.NvidiaSMIGPU{
+						smiGPU("GPU 00000000:53:00.0", "Disabled", "Enabled"),
+						smiGPU("GPU 00000000:64:00.0", "Enabled", "Enabled"),
+					},
+				},
+				NVML: &nvidia_query_nvml.Output{
+					DeviceInfos: []*nvidia_query_nvml.DeviceInfo{
+						nvmlDev(0x53, true, false),
+						nvmlDev(0x75, true, true),
+					},
+				},
+			},
+			nvmlDriverVersion: "550.90.07",
+			expected: &Output{
+				SMIExists: true,
+				Compared:  true,
+				Discrepancies: []Discrepancy{
+					{Field: FieldDriverVersion, SMI: "560.35.03", NVML: "550.90.07"},
+					{Field: FieldPersistenceMode, BusID: "00000000:53:00.0", SMI: "false", NVML: "true"},
+					{Field: FieldECCMode, BusID: "00000000:53:00.0", SMI: "true", NVML: "false"},
+					{Field: FieldDevice, BusID: "00000000:64:00.0", SMI: "found", NVML: "not found"},
+					{Field: FieldDevice, BusID: "bus 75", SMI: "not found", NVML: "found"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToOutput(tt.input, tt.nvmlDriverVersion)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ToOutput() = %+v, want %+v", got, tt.expected)
+			}
+
+			states, err := got.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if states[0].Healthy != tt.expectedHealthy {
+				t.Errorf("healthy = %v, want %v (reason %q)", states[0].Healthy, tt.expectedHealthy, states[0].Reason)
+			}
+			if !tt.expectedHealthy && states[0].SuggestedActions == nil {
+				t.Error("expected suggested actions")
+			}
+
+			parsed, err := ParseStatesToOutput(states...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parsed, got) {
+				t.Errorf("parsed = %+v, want %+v", parsed, got)
+			}
+		})
+	}
+}
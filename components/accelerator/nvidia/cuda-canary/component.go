@@ -0,0 +1,146 @@
+// Package cudacanary periodically launches a tiny CUDA workload (e.g., "vectorAdd") on each GPU,
+// to catch the GPUs that look healthy to NVML but fail or are slow to run the kernels
+// (e.g., the silent hangs or the broken CUDA contexts).
+package cudacanary
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_metrics_cuda_canary "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/cuda-canary"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const Name = "accelerator-nvidia-cuda-canary"
+
+func New(ctx context.Context, cfg Config) components.Component {
+	cfg.SetDefaultsIfNotSet()
+	setDefaultPoller(cfg)
+
+	cctx, ccancel := context.WithCancel(ctx)
+	getDefaultPoller().Start(cctx, cfg.Query, Name)
+
+	return &component{
+		rootCtx: ctx,
+		cancel:  ccancel,
+		poller:  getDefaultPoller(),
+		cfg:     cfg,
+	}
+}
+
+var _ components.Component = (*component)(nil)
+
+type component struct {
+	rootCtx  context.Context
+	cancel   context.CancelFunc
+	poller   query.Poller
+	gatherer prometheus.Gatherer
+	cfg      Config
+}
+
+func (c *component) Name() string { return Name }
+
+func (c *component) States(ctx context.Context) ([]components.State, error) {
+	last, err := c.poller.Last()
+	if err == query.ErrNoData { // no data
+		log.Logger.Debugw("nothing found in last state (no data collected yet)", "component", Name)
+		return []components.State{
+			{
+				Name:    Name,
+				Healthy: true,
+				Reason:  query.ErrNoData.Error(),
+			},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
+	if last.Error != nil {
+		return []components.State{
+			{
+				Name:    Name,
+				Healthy: false,
+				Error:   last.Error.Error(),
+				Reason:  "last query failed",
+			},
+		}, nil
+	}
+	if last.Output == nil {
+		return []components.State{
+			{
+				Name:    Name,
+				Healthy: true,
+				Reason:  "no output",
+			},
+		}, nil
+	}
+
+	output, ok := last.Output.(*Output)
+	if !ok {
+		return nil, fmt.Errorf("invalid output type: %T", last.Output)
+	}
+	return output.States()
+}
+
+func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	return nil, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	log.Logger.Debugw("querying metrics", "since", since)
+
+	launchLatencySeconds, err := nvidia_query_metrics_cuda_canary.ReadLaunchLatencySeconds(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read launch latency seconds: %w", err)
+	}
+	success, err := nvidia_query_metrics_cuda_canary.ReadSuccess(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read success: %w", err)
+	}
+
+	ms := make([]components.Metric, 0, len(launchLatencySeconds)+len(success))
+	for _, m := range launchLatencySeconds {
+		ms = append(ms, components.Metric{
+			Metric: m,
+			ExtraInfo: map[string]string{
+				"gpu_id": m.MetricSecondaryName,
+			},
+		})
+	}
+	for _, m := range success {
+		ms = append(ms, components.Metric{
+			Metric: m,
+			ExtraInfo: map[string]string{
+				"gpu_id": m.MetricSecondaryName,
+			},
+		})
+	}
+
+	return ms, nil
+}
+
+func (c *component) Close() error {
+	log.Logger.Debugw("closing component")
+
+	// safe to call stop multiple times
+	_ = c.poller.Stop(Name)
+
+	return nil
+}
+
+var _ components.PromRegisterer = (*component)(nil)
+
+func (c *component) RegisterCollectors(reg *prometheus.Registry, db *sql.DB, tableName string) error {
+	c.gatherer = reg
+	return nvidia_query_metrics_cuda_canary.Register(reg, db, tableName)
+}
@@ -0,0 +1,268 @@
+package cudacanary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_cuda_canary "github.com/leptonai/gpud/components/accelerator/nvidia/query/cuda-canary"
+	nvidia_query_metrics_cuda_canary "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/cuda-canary"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/probegate"
+)
+
+// Output is the canary results of the GPUs.
+type Output struct {
+	Tool string `json:"tool"`
+	// Results are the canary results of the last run, sorted by the GPU UUID.
+	Results []nvidia_query_cuda_canary.Result `json:"results"`
+	// RunUnixSeconds is when the results were collected.
+	RunUnixSeconds int64 `json:"run_unix_seconds"`
+
+	// LatencyThreshold is the latency above which the GPU is reported degraded.
+	LatencyThreshold time.Duration `json:"latency_threshold"`
+
+	// Skipped is set if the last run was skipped (e.g., the node busy),
+	// in which case the results are of the previous run, if any.
+	Skipped    bool   `json:"skipped,omitempty"`
+	SkipReason string `json:"skip_reason,omitempty"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameCUDACanary = "cuda_canary"
+
+	StateKeyCUDACanaryData           = "data"
+	StateKeyCUDACanaryEncoding       = "encoding"
+	StateValueCUDACanaryEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameCUDACanary, "CUDA canary workload results of the GPUs"),
+		nvidia_query.GPUStateSchema(StateNameCUDACanary, "CUDA canary workload result of the GPU"),
+	)
+}
+
+func ParseStateCUDACanary(m map[string]string) (*Output, error) {
+	data := m[StateKeyCUDACanaryData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameCUDACanary:
+			o, err := ParseStateCUDACanary(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			if nvidia_query.IsGPUStateName(StateNameCUDACanary, state.Name) {
+				continue
+			}
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Failed returns the GPUs that failed to run the canary workload.
+func (o *Output) Failed() []nvidia_query_cuda_canary.Result {
+	var rs []nvidia_query_cuda_canary.Result
+	for _, r := range o.Results {
+		if !r.Success {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// Slow returns the GPUs that ran the canary workload slower than the latency threshold.
+func (o *Output) Slow() []nvidia_query_cuda_canary.Result {
+	if o.LatencyThreshold <= 0 {
+		return nil
+	}
+	var rs []nvidia_query_cuda_canary.Result
+	for _, r := range o.Results {
+		if r.Success && r.Latency > o.LatencyThreshold {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+var failedActions = &common.SuggestedActions{
+	Descriptions: []string{
+		"the GPU failed to run the CUDA kernels while NVML reports no issue, reset the GPU (or reboot the system), and inspect the hardware if the canary still fails",
+	},
+	RepairActions: []common.RepairActionType{
+		common.RepairActionTypeResetGPU,
+	},
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool) {
+	var reasons []string
+	if o.Skipped {
+		reasons = append(reasons, "last run skipped ("+o.SkipReason+")")
+	}
+
+	failed := o.Failed()
+	for _, r := range failed {
+		reasons = append(reasons, fmt.Sprintf("%s failed the canary workload (%s)", r.UUID, r.Error))
+	}
+	for _, r := range o.Slow() {
+		reasons = append(reasons, fmt.Sprintf("%s ran the canary workload in %v (threshold %v)", r.UUID, r.Latency.Round(time.Millisecond), o.LatencyThreshold))
+	}
+	if len(failed) == 0 && len(o.Results) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d GPU(s) ran the canary workload", len(o.Results)-len(failed)))
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "no canary run yet")
+	}
+	return strings.Join(reasons, "; "), len(failed) == 0
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, healthy := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:     StateNameCUDACanary,
+		Healthy:  healthy,
+		Degraded: healthy && len(o.Slow()) > 0,
+		Reason:   reason,
+		ExtraInfo: map[string]string{
+			StateKeyCUDACanaryData:     string(b),
+			StateKeyCUDACanaryEncoding: StateValueCUDACanaryEncodingJSON,
+		},
+	}
+	if !healthy {
+		state.SuggestedActions = failedActions
+	}
+	return append([]components.State{state}, o.GPUStates()...), nil
+}
+
+// GPUStates returns the per-GPU states (e.g., "cuda_canary_GPU-1234"),
+// unhealthy if the GPU failed to run the canary workload.
+func (o *Output) GPUStates() []components.State {
+	gs := nvidia_query.NewGPUStates(StateNameCUDACanary)
+	for _, r := range o.Results {
+		gs.Add(r.UUID)
+		if !r.Success {
+			gs.Unhealthy(r.UUID, fmt.Sprintf("failed the canary workload (%s)", r.Error), failedActions)
+		}
+	}
+	return gs.States()
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the nvml library
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg, probegate.New(cfg.Gate, nvidia_query_nvml.GetGPULoads), nvidia_query_nvml.GetGPUIndexes))
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+// CreateGet returns the get function that runs the canary workload on each GPU one by one,
+// skipping the run if the node is busy.
+func CreateGet(cfg Config, gate *probegate.Gate, getIndexes func() (map[string]int, error)) query.GetFunc {
+	var (
+		mu   sync.Mutex
+		last *Output
+	)
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if idle, reason := gate.Check(ctx); !idle {
+			log.Logger.Infow("skipping cuda canary", "reason", "node busy ("+reason+")")
+			o := &Output{Tool: cfg.Tool, LatencyThreshold: cfg.LatencyThreshold.Duration}
+			if last != nil {
+				cp := *last
+				o = &cp
+			}
+			o.Skipped = true
+			o.SkipReason = "node busy (" + reason + ")"
+			return o, nil
+		}
+
+		indexes, err := getIndexes()
+		if err != nil {
+			return nil, err
+		}
+		uuids := make([]string, 0, len(indexes))
+		for uuid := range indexes {
+			uuids = append(uuids, uuid)
+		}
+		sort.Strings(uuids)
+
+		now := time.Now().UTC()
+		o := &Output{
+			Tool:             cfg.Tool,
+			RunUnixSeconds:   now.Unix(),
+			LatencyThreshold: cfg.LatencyThreshold.Duration,
+		}
+		for _, uuid := range uuids {
+			cctx, ccancel := context.WithTimeout(ctx, cfg.Timeout.Duration)
+			r := nvidia_query_cuda_canary.Run(cctx, cfg.Tool, cfg.Path, uuid, indexes[uuid])
+			ccancel()
+			if !r.Success {
+				log.Logger.Warnw("cuda canary failed", "uuid", uuid, "error", r.Error)
+			}
+			o.Results = append(o.Results, r)
+
+			if err := nvidia_query_metrics_cuda_canary.SetLaunchLatencySeconds(ctx, uuid, r.Latency.Seconds(), now); err != nil {
+				return nil, err
+			}
+			if err := nvidia_query_metrics_cuda_canary.SetSuccess(ctx, uuid, r.Success, now); err != nil {
+				return nil, err
+			}
+		}
+		nvidia_query_metrics_cuda_canary.SetLastUpdateUnixSeconds(float64(now.Unix()))
+
+		last = o
+		return o, nil
+	}
+}
@@ -0,0 +1,102 @@
+package cudacanary
+
+import (
+	"testing"
+	"time"
+
+	nvidia_query_cuda_canary "github.com/leptonai/gpud/components/accelerator/nvidia/query/cuda-canary"
+)
+
+func TestOutputStates(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       Output
+		wantHealthy  bool
+		wantDegraded bool
+		wantStates   int
+	}{
+		{
+			name:        "no run yet",
+			output:      Output{},
+			wantHealthy: true,
+			wantStates:  1,
+		},
+		{
+			name: "all passed",
+			output: Output{
+				LatencyThreshold: 10 * time.Second,
+				Results: []nvidia_query_cuda_canary.Result{
+					{UUID: "GPU-a", Success: true, Latency: time.Second},
+					{UUID: "GPU-b", Success: true, Latency: 2 * time.Second},
+				},
+			},
+			wantHealthy: true,
+			wantStates:  3,
+		},
+		{
+			name: "slow",
+			output: Output{
+				LatencyThreshold: 10 * time.Second,
+				Results: []nvidia_query_cuda_canary.Result{
+					{UUID: "GPU-a", Success: true, Latency: time.Second},
+					{UUID: "GPU-b", Success: true, Latency: 30 * time.Second},
+				},
+			},
+			wantHealthy:  true,
+			wantDegraded: true,
+			wantStates:   3,
+		},
+		{
+			name: "failed",
+			output: Output{
+				LatencyThreshold: 10 * time.Second,
+				Results: []nvidia_query_cuda_canary.Result{
+					{UUID: "GPU-a", Success: true, Latency: time.Second},
+					{UUID: "GPU-b", Success: false, Error: "context deadline exceeded"},
+				},
+			},
+			wantHealthy: false,
+			wantStates:  3,
+		},
+		{
+			name: "skipped keeps the previous results",
+			output: Output{
+				Results: []nvidia_query_cuda_canary.Result{
+					{UUID: "GPU-a", Success: false, Error: "exit status 1"},
+				},
+				Skipped:    true,
+				SkipReason: "node busy (gpu GPU-a used 90%)",
+			},
+			wantHealthy: false,
+			wantStates:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states, err := tt.output.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != tt.wantStates {
+				t.Fatalf("expected %d states, got %d", tt.wantStates, len(states))
+			}
+			if states[0].Healthy != tt.wantHealthy {
+				t.Errorf("expected healthy %v, got %v (%s)", tt.wantHealthy, states[0].Healthy, states[0].Reason)
+			}
+			if states[0].Degraded != tt.wantDegraded {
+				t.Errorf("expected degraded %v, got %v (%s)", tt.wantDegraded, states[0].Degraded, states[0].Reason)
+			}
+			if !tt.wantHealthy && states[0].SuggestedActions == nil {
+				t.Error("expected suggested actions")
+			}
+
+			parsed, err := ParseStatesToOutput(states...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed.Results) != len(tt.output.Results) || parsed.Skipped != tt.output.Skipped {
+				t.Errorf("unexpected parsed output %+v", parsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package cudacanary
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	nvidia_query_cuda_canary "github.com/leptonai/gpud/components/accelerator/nvidia/query/cuda-canary"
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/pkg/probegate"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultInterval is the default interval of the canary runs (the query interval).
+	DefaultInterval         = 10 * time.Minute
+	DefaultTimeout          = time.Minute
+	DefaultLatencyThreshold = 10 * time.Second
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// Tool is the canary workload tool ("vector-add" or "dcgmproftester"), defaults to "vector-add".
+	Tool string `json:"tool"`
+	// Path is the binary path of the tool.
+	// Defaults to the bundled "vectorAdd" or "dcgmproftester12" in the PATH.
+	Path string `json:"path,omitempty"`
+	// Timeout of the workload on a GPU, defaults to 1 minute.
+	Timeout metav1.Duration `json:"timeout"`
+	// LatencyThreshold marks the GPU degraded if the workload takes longer, defaults to 10 seconds.
+	LatencyThreshold metav1.Duration `json:"latency_threshold"`
+
+	// Gate defines when the node is busy, the canary is skipped on a busy node
+	// (e.g., a GPU in the exclusive compute mode fails the kernel launches while a job runs).
+	Gate probegate.Config `json:"gate"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.Query.Interval.Duration == 0 {
+		cfg.Query.Interval.Duration = DefaultInterval
+	}
+	cfg.Query.SetDefaultsIfNotSet()
+	cfg.Gate.SetDefaultsIfNotSet()
+	if cfg.Tool == "" {
+		cfg.Tool = nvidia_query_cuda_canary.ToolVectorAdd
+	}
+	if cfg.Path == "" {
+		cfg.Path = nvidia_query_cuda_canary.DefaultPath(cfg.Tool)
+	}
+	if cfg.Timeout.Duration == 0 {
+		cfg.Timeout.Duration = DefaultTimeout
+	}
+	if cfg.LatencyThreshold.Duration == 0 {
+		cfg.LatencyThreshold.Duration = DefaultLatencyThreshold
+	}
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Tool != "" {
+		if _, _, err := nvidia_query_cuda_canary.Command(cfg.Tool, cfg.Path, "", 0); err != nil {
+			return err
+		}
+	}
+	if cfg.Timeout.Duration < 0 {
+		return fmt.Errorf("invalid timeout %v", cfg.Timeout.Duration)
+	}
+	if cfg.LatencyThreshold.Duration < 0 {
+		return fmt.Errorf("invalid latency threshold %v", cfg.LatencyThreshold.Duration)
+	}
+	return cfg.Gate.Validate()
+}
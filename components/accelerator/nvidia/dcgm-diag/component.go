@@ -0,0 +1,204 @@
+// Package dcgmdiag runs the DCGM diagnostics on a schedule, and reports the latest per-GPU verdicts
+// of the scheduled and on-demand ("gpud dcgm-diag") runs.
+package dcgmdiag
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query_dcgm_diag "github.com/leptonai/gpud/components/accelerator/nvidia/query/dcgm-diag"
+	nvidia_query_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/cron"
+	"github.com/leptonai/gpud/pkg/probegate"
+)
+
+const Name = "accelerator-nvidia-dcgm-diag"
+
+const (
+	// checkInterval is the interval to check the scheduled run.
+	checkInterval = time.Minute
+
+	// requestedByScheduler is the requester recorded in the GPU ledger for the scheduled runs.
+	requestedByScheduler = "gpud"
+	// reasonScheduled is the reason recorded in the GPU ledger for the scheduled runs.
+	reasonScheduled = "scheduled"
+)
+
+func New(ctx context.Context, cfg Config) (components.Component, error) {
+	cfg.SetDefaultsIfNotSet()
+
+	var db *sql.DB
+	if cfg.Query.State != nil {
+		db = cfg.Query.State.DB
+	}
+	if db == nil {
+		return nil, fmt.Errorf("%s requires the state database", Name)
+	}
+	if err := nvidia_query_gpu_ledger.CreateTableGPULedger(ctx, db); err != nil {
+		return nil, err
+	}
+
+	cctx, ccancel := context.WithCancel(ctx)
+	c := &component{
+		cancel:     ccancel,
+		cfg:        cfg,
+		db:         db,
+		gate:       probegate.New(cfg.Gate, nvidia_query_nvml.GetGPULoads),
+		getIndexes: nvidia_query_nvml.GetGPUIndexes,
+	}
+	if cfg.Schedule != "" {
+		s, err := cron.Parse(cfg.Schedule)
+		if err != nil {
+			ccancel()
+			return nil, fmt.Errorf("invalid schedule %q: %w", cfg.Schedule, err)
+		}
+		go c.schedule(cctx, s)
+	}
+	return c, nil
+}
+
+var _ components.Component = (*component)(nil)
+
+type component struct {
+	cancel context.CancelFunc
+	cfg    Config
+	db     *sql.DB
+
+	gate       *probegate.Gate
+	getIndexes func() (map[string]int, error)
+
+	mu      sync.RWMutex
+	running bool
+	next    time.Time
+}
+
+func (c *component) Name() string { return Name }
+
+func (c *component) States(ctx context.Context) ([]components.State, error) {
+	since := time.Now().UTC().Add(-c.cfg.Lookback.Duration)
+	entries, err := nvidia_query_gpu_ledger.ReadEntries(ctx, c.db, since, "", nvidia_query_dcgm_diag.TestName)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := ToOutput(entries).States()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	states[0].ExtraInfo["level"] = fmt.Sprintf("%d", c.cfg.Level)
+	states[0].ExtraInfo["running"] = fmt.Sprintf("%v", c.running)
+	if !c.next.IsZero() {
+		states[0].ExtraInfo["next_run"] = c.next.UTC().Format(time.RFC3339)
+	}
+	return states, nil
+}
+
+func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	entries, err := nvidia_query_gpu_ledger.ReadEntries(ctx, c.db, since, "", nvidia_query_dcgm_diag.TestName)
+	if err != nil {
+		return nil, err
+	}
+	evs := make([]components.Event, 0, len(entries))
+	for _, e := range entries {
+		evs = append(evs, e.ToComponentEvent())
+	}
+	return evs, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	return nil, nil
+}
+
+func (c *component) Close() error {
+	log.Logger.Debugw("closing component", "component", Name)
+	c.cancel()
+	return nil
+}
+
+// schedule checks the due run every minute, and runs the diagnostics in the background
+// (at most one run at a time).
+// The run is skipped until the next scheduled time if the node is busy.
+func (c *component) schedule(ctx context.Context, s *cron.Schedule) {
+	c.mu.Lock()
+	c.next = s.Next(time.Now())
+	c.mu.Unlock()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		c.mu.Lock()
+		if now.Before(c.next) {
+			c.mu.Unlock()
+			continue
+		}
+		c.next = s.Next(now)
+		if c.running {
+			c.mu.Unlock()
+			log.Logger.Warnw("skipping scheduled dcgm diagnostics", "reason", "previous run still in progress")
+			continue
+		}
+		if idle, reason := c.gate.Check(ctx); !idle {
+			c.mu.Unlock()
+			log.Logger.Warnw("skipping scheduled dcgm diagnostics", "reason", "node busy ("+reason+")")
+			continue
+		}
+		c.running = true
+		c.mu.Unlock()
+
+		go func() {
+			defer func() {
+				c.mu.Lock()
+				c.running = false
+				c.mu.Unlock()
+			}()
+			c.run(ctx)
+		}()
+	}
+}
+
+func (c *component) run(ctx context.Context) {
+	log.Logger.Infow("running scheduled dcgm diagnostics", "level", c.cfg.Level)
+
+	indexes, err := c.getIndexes()
+	if err != nil {
+		log.Logger.Warnw("failed to list gpus for dcgm diagnostics", "error", err)
+		return
+	}
+
+	start := time.Now().UTC()
+	var result nvidia_query_dcgm_diag.Result
+	err = c.gate.Run(ctx, func(pctx context.Context) error {
+		cctx, ccancel := context.WithTimeout(pctx, c.cfg.Timeout.Duration)
+		defer ccancel()
+
+		result = nvidia_query_dcgm_diag.Run(cctx, c.cfg.Level)
+		return nil
+	})
+	if errors.Is(err, probegate.ErrAborted) {
+		// not a diagnostics failure, the node became busy
+		log.Logger.Warnw("scheduled dcgm diagnostics aborted", "error", err)
+		return
+	}
+
+	if err := nvidia_query_dcgm_diag.RecordVerdicts(ctx, c.db, result, indexes, start, requestedByScheduler, reasonScheduled); err != nil {
+		log.Logger.Warnw("failed to record dcgm diagnostics verdicts", "error", err)
+	}
+}
@@ -0,0 +1,152 @@
+package dcgmdiag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+	"github.com/leptonai/gpud/components/common"
+)
+
+// Verdict is the latest diagnostics verdict of a GPU.
+type Verdict struct {
+	UUID        string    `json:"uuid"`
+	Time        time.Time `json:"time"`
+	Verdict     string    `json:"verdict"`
+	RequestedBy string    `json:"requested_by"`
+	Details     string    `json:"details,omitempty"`
+}
+
+type Output struct {
+	Verdicts []Verdict `json:"verdicts"`
+}
+
+// ToOutput returns the latest verdict of each GPU from the ledger entries
+// in the ascending order of the test time.
+func ToOutput(entries []nvidia_query_gpu_ledger.Entry) *Output {
+	latest := make(map[string]nvidia_query_gpu_ledger.Entry)
+	for _, e := range entries {
+		latest[e.GPUUUID] = e
+	}
+
+	o := &Output{}
+	for _, e := range latest {
+		o.Verdicts = append(o.Verdicts, Verdict{
+			UUID:        e.GPUUUID,
+			Time:        time.Unix(e.UnixSeconds, 0).UTC(),
+			Verdict:     e.Verdict,
+			RequestedBy: e.RequestedBy,
+			Details:     e.Details,
+		})
+	}
+	sort.Slice(o.Verdicts, func(i, j int) bool { return o.Verdicts[i].UUID < o.Verdicts[j].UUID })
+	return o
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameDCGMDiag = "dcgm_diag"
+
+	StateKeyDCGMDiagData           = "data"
+	StateKeyDCGMDiagEncoding       = "encoding"
+	StateValueDCGMDiagEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameDCGMDiag, "latest per-GPU DCGM diagnostic verdicts",
+			components.ExtraInfoSchema{Key: "level", Type: components.ExtraInfoTypeInt, Description: "DCGM diagnostic level"},
+			components.ExtraInfoSchema{Key: "running", Type: components.ExtraInfoTypeBool, Description: "true if the diagnostic is running"},
+			components.ExtraInfoSchema{Key: "next_run", Type: components.ExtraInfoTypeString, Description: "next scheduled run in RFC3339"},
+		),
+	)
+}
+
+func ParseStateDCGMDiag(m map[string]string) (*Output, error) {
+	data := m[StateKeyDCGMDiagData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameDCGMDiag:
+			o, err := ParseStateDCGMDiag(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool) {
+	if len(o.Verdicts) == 0 {
+		return "no dcgm diagnostics run found", true
+	}
+
+	var failed []string
+	for _, v := range o.Verdicts {
+		if v.Verdict == nvidia_query_gpu_ledger.VerdictPass {
+			continue
+		}
+		failed = append(failed, fmt.Sprintf("%s failed dcgm diagnostics at %s (%s)", v.UUID, v.Time.Format(time.RFC3339), firstLine(v.Details)))
+	}
+	if len(failed) > 0 {
+		return strings.Join(failed, "; "), false
+	}
+	return fmt.Sprintf("%d GPU(s) passed the latest dcgm diagnostics", len(o.Verdicts)), true
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, healthy := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameDCGMDiag,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyDCGMDiagData:     string(b),
+			StateKeyDCGMDiagEncoding: StateValueDCGMDiagEncodingJSON,
+		},
+	}
+	if !healthy {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"run 'gpud dcgm-diag --level 3' on the drained node to confirm the failure",
+				"inspect or replace the failed GPU if the failure persists",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeHardwareInspection,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
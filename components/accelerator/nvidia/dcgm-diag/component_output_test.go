@@ -0,0 +1,56 @@
+package dcgmdiag
+
+import (
+	"testing"
+
+	nvidia_query_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+)
+
+func TestToOutputLatestVerdict(t *testing.T) {
+	t.Parallel()
+
+	entries := []nvidia_query_gpu_ledger.Entry{
+		{UnixSeconds: 100, GPUUUID: "GPU-1", Verdict: nvidia_query_gpu_ledger.VerdictPass},
+		{UnixSeconds: 100, GPUUUID: "GPU-0", Verdict: nvidia_query_gpu_ledger.VerdictPass},
+		{UnixSeconds: 200, GPUUUID: "GPU-1", Verdict: nvidia_query_gpu_ledger.VerdictFail, Details: "level=2 failed=GPU Memory\nmore"},
+	}
+
+	o := ToOutput(entries)
+	if len(o.Verdicts) != 2 {
+		t.Fatalf("expected 2 verdicts, got %d", len(o.Verdicts))
+	}
+	if o.Verdicts[0].UUID != "GPU-0" || o.Verdicts[1].UUID != "GPU-1" {
+		t.Fatalf("unexpected order: %+v", o.Verdicts)
+	}
+	if o.Verdicts[1].Verdict != nvidia_query_gpu_ledger.VerdictFail {
+		t.Fatalf("expected the latest verdict fail, got %q", o.Verdicts[1].Verdict)
+	}
+
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if states[0].Healthy {
+		t.Fatalf("expected unhealthy, got %+v", states[0])
+	}
+	if states[0].SuggestedActions == nil {
+		t.Fatal("expected suggested actions")
+	}
+
+	parsed, err := ParseStatesToOutput(states...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Verdicts) != 2 {
+		t.Fatalf("expected 2 parsed verdicts, got %d", len(parsed.Verdicts))
+	}
+}
+
+func TestEvaluateNoRun(t *testing.T) {
+	t.Parallel()
+
+	reason, healthy := (&Output{}).Evaluate()
+	if !healthy {
+		t.Fatalf("expected healthy without any run, got %q", reason)
+	}
+}
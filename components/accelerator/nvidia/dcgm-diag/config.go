@@ -0,0 +1,86 @@
+package dcgmdiag
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	nvidia_query_dcgm_diag "github.com/leptonai/gpud/components/accelerator/nvidia/query/dcgm-diag"
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/pkg/cron"
+	"github.com/leptonai/gpud/pkg/probegate"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultTimeout  = time.Hour
+	DefaultLookback = 7 * 24 * time.Hour
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// Level is the diagnostics level of the scheduled runs (1, 2, or 3), defaults to 1.
+	Level int `json:"level"`
+	// Schedule is the cron expression in the local time for the scheduled runs (e.g., "0 3 * * 0").
+	// Leave empty to only run on demand ("gpud dcgm-diag").
+	Schedule string `json:"schedule,omitempty"`
+	// Timeout of a scheduled run, defaults to 1 hour.
+	Timeout metav1.Duration `json:"timeout"`
+
+	// Lookback is the period to report the latest diagnostics verdicts of the GPUs, defaults to 7 days.
+	Lookback metav1.Duration `json:"lookback"`
+
+	// Gate defines when the node is busy, the scheduled run is skipped on a busy node
+	// and aborted if a training job starts on the node.
+	Gate probegate.Config `json:"gate"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	cfg.Query.SetDefaultsIfNotSet()
+	cfg.Gate.SetDefaultsIfNotSet()
+	if cfg.Level == 0 {
+		cfg.Level = nvidia_query_dcgm_diag.LevelQuick
+	}
+	if cfg.Timeout.Duration == 0 {
+		cfg.Timeout.Duration = DefaultTimeout
+	}
+	if cfg.Lookback.Duration == 0 {
+		cfg.Lookback.Duration = DefaultLookback
+	}
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Level != 0 {
+		if _, err := nvidia_query_dcgm_diag.Command(cfg.Level); err != nil {
+			return err
+		}
+	}
+	if cfg.Schedule != "" {
+		if _, err := cron.Parse(cfg.Schedule); err != nil {
+			return fmt.Errorf("invalid schedule %q: %w", cfg.Schedule, err)
+		}
+	}
+	if cfg.Timeout.Duration < 0 {
+		return fmt.Errorf("invalid timeout %v", cfg.Timeout.Duration)
+	}
+	return cfg.Gate.Validate()
+}
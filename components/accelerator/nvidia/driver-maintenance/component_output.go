@@ -0,0 +1,157 @@
+package drivermaintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query_driver_maintenance "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-maintenance"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+)
+
+type Output struct {
+	Status nvidia_query_driver_maintenance.Status `json:"status"`
+	// Activities are the running processes and the journal entries in the quiet period.
+	Activities []nvidia_query_driver_maintenance.Activity `json:"activities,omitempty"`
+	// JournalError is set if the journal could not be read (e.g., no journalctl),
+	// in which case only the running processes are checked.
+	JournalError string `json:"journal_error,omitempty"`
+}
+
+// ToOutput returns the maintenance status from the running processes and the journal entries.
+func ToOutput(procs []nvidia_query_driver_maintenance.Activity, journal []nvidia_query_driver_maintenance.Activity) *Output {
+	acts := make([]nvidia_query_driver_maintenance.Activity, 0, len(procs)+len(journal))
+	acts = append(acts, journal...)
+	acts = append(acts, procs...)
+	return &Output{
+		Status:     nvidia_query_driver_maintenance.ToStatus(acts),
+		Activities: acts,
+	}
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameDriverMaintenance = "driver_maintenance"
+
+	StateKeyDriverMaintenanceData           = "data"
+	StateKeyDriverMaintenanceEncoding       = "encoding"
+	StateValueDriverMaintenanceEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameDriverMaintenance, "in-progress driver install or upgrade activity"),
+	)
+}
+
+func ParseStateDriverMaintenance(m map[string]string) (*Output, error) {
+	data := m[StateKeyDriverMaintenanceData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameDriverMaintenance:
+			o, err := ParseStateDriverMaintenance(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+// The maintenance itself is not a failure, thus always healthy.
+func (o *Output) Evaluate() (string, bool) {
+	if !o.Status.InProgress {
+		return "no driver install/upgrade activity found", true
+	}
+	return fmt.Sprintf("driver maintenance in progress since %s (%s)", o.Status.Since.Format(time.RFC3339), o.Status.Reason), true
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, healthy := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameDriverMaintenance,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyDriverMaintenanceData:     string(b),
+			StateKeyDriverMaintenanceEncoding: StateValueDriverMaintenanceEncodingJSON,
+		},
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since the maintenance status is node-wide
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg))
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+// CreateGet returns the get function that checks the driver install/upgrade activity
+// in the quiet period, and sets the node-wide maintenance status.
+func CreateGet(cfg Config) query.GetFunc {
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		now := time.Now().UTC()
+		procs, err := nvidia_query_driver_maintenance.FindProcesses(nvidia_query_driver_maintenance.DefaultProcDir, now)
+		if err != nil {
+			return nil, err
+		}
+
+		journal, jerr := nvidia_query_driver_maintenance.ReadJournal(ctx, now.Add(-cfg.QuietPeriod.Duration))
+		if jerr != nil {
+			log.Logger.Debugw("failed to read journal -- only checking the running processes", "error", jerr)
+		}
+
+		o := ToOutput(procs, journal)
+		if jerr != nil {
+			o.JournalError = jerr.Error()
+		}
+		nvidia_query_driver_maintenance.SetCurrent(o.Status)
+		return o, nil
+	}
+}
@@ -0,0 +1,45 @@
+package drivermaintenance
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	nvidia_query_driver_maintenance "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-maintenance"
+)
+
+func TestOutputStates(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+	o := ToOutput(
+		[]nvidia_query_driver_maintenance.Activity{{Source: nvidia_query_driver_maintenance.SourceProcess, Time: now, PID: 100, Message: "apt-get install nvidia-driver-550"}},
+		[]nvidia_query_driver_maintenance.Activity{{Source: nvidia_query_driver_maintenance.SourceJournal, Time: now.Add(-time.Minute), Message: "dkms[1234]: Building module nvidia/550.90.07"}},
+	)
+	if !o.Status.InProgress {
+		t.Fatal("expected maintenance in progress")
+	}
+
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !states[0].Healthy {
+		t.Fatal("expected the maintenance state healthy")
+	}
+	if !strings.Contains(states[0].Reason, "apt-get install nvidia-driver-550") {
+		t.Errorf("expected the latest activity in the reason, got %q", states[0].Reason)
+	}
+
+	parsed, err := ParseStatesToOutput(states...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Activities) != 2 {
+		t.Fatalf("expected 2 activities, got %d", len(parsed.Activities))
+	}
+
+	if ToOutput(nil, nil).Status.InProgress {
+		t.Fatal("expected no maintenance without activity")
+	}
+}
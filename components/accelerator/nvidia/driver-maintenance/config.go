@@ -0,0 +1,54 @@
+package drivermaintenance
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultQuietPeriod is the period after the last activity to keep the maintenance in progress,
+// to cover the reboot and the module reload following the upgrade.
+const DefaultQuietPeriod = 15 * time.Minute
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// QuietPeriod is the period after the last driver install/upgrade activity
+	// to keep the maintenance in progress, defaults to 15 minutes.
+	QuietPeriod metav1.Duration `json:"quiet_period"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	if cfg.QuietPeriod.Duration < 0 {
+		return fmt.Errorf("invalid quiet period %v", cfg.QuietPeriod.Duration)
+	}
+	return nil
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	cfg.Query.SetDefaultsIfNotSet()
+	if cfg.QuietPeriod.Duration == 0 {
+		cfg.QuietPeriod.Duration = DefaultQuietPeriod
+	}
+}
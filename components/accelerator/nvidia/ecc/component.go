@@ -4,32 +4,67 @@ package ecc
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_counter_reset_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/counter-reset-state"
+	nvidia_query_ecc_addresses "github.com/leptonai/gpud/components/accelerator/nvidia/query/ecc-addresses"
+	nvidia_query_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+	nvidia_query_memory_scrub "github.com/leptonai/gpud/components/accelerator/nvidia/query/memory-scrub"
+	nvidia_query_memtest "github.com/leptonai/gpud/components/accelerator/nvidia/query/memtest"
 	nvidia_query_metrics_ecc "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/ecc"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	nvidia_query_threshold_breach_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/threshold-breach-state"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/execlimit"
+	"github.com/leptonai/gpud/pkg/probegate"
 
 	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const Name = "accelerator-nvidia-ecc"
 
 func New(ctx context.Context, cfg Config) components.Component {
 	cfg.Query.SetDefaultsIfNotSet()
+	if cfg.Scrub != nil {
+		cfg.Scrub.SetDefaultsIfNotSet()
+	}
 
 	cctx, ccancel := context.WithCancel(ctx)
 	nvidia_query.SetDefaultPoller(cfg.Query.State.DB)
 	nvidia_query.GetDefaultPoller().Start(cctx, cfg.Query, Name)
 
-	return &component{
+	c := &component{
 		rootCtx: ctx,
 		cancel:  ccancel,
 		poller:  nvidia_query.GetDefaultPoller(),
+		cfg:     cfg,
+
+		thresholds: nvidia_query_threshold_breach_state.NewTracker(cfg.Query.State.DB, Name),
+	}
+	if cfg.EnforceECCMode != nil {
+		c.eccModeSet = make(map[string]string)
+
+		var ectx context.Context
+		ectx, c.enforceCancel = context.WithCancel(ctx)
+		go c.enforceECCModeLoop(ectx)
+	}
+	if cfg.Scrub != nil {
+		c.scrubGate = probegate.New(cfg.Scrub.GateConfig(), nvidia_query_nvml.GetGPULoads)
+
+		var sctx context.Context
+		sctx, c.scrubCancel = context.WithCancel(ctx)
+		go c.scheduleScrubs(sctx)
 	}
+	return c
 }
 
 var _ components.Component = (*component)(nil)
@@ -39,6 +74,22 @@ type component struct {
 	cancel   context.CancelFunc
 	poller   query.Poller
 	gatherer prometheus.Gatherer
+	cfg      Config
+
+	scrubCancel context.CancelFunc
+	scrubGate   *probegate.Gate
+
+	enforceCancel context.CancelFunc
+	eccModeSetMu  sync.RWMutex
+	// eccModeSet maps the GPU UUID to the result of setting its pending ECC mode
+	// (empty if succeeded, or the error message), so the mode is set at most once per GPU
+	// (the polled data may not reflect the pending mode until the next poll).
+	eccModeSet map[string]string
+
+	thresholds *nvidia_query_threshold_breach_state.Tracker
+
+	eventsMu sync.RWMutex
+	events   []components.Event
 }
 
 func (c *component) Name() string { return Name }
@@ -58,6 +109,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -96,11 +150,282 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 		return cs, nil
 	}
 	output := ToOutput(allOutput)
+	output.ThresholdPreset, output.Thresholds = nvidia_query.GetGPUThresholds(allOutput.GPUProductName(), c.cfg.Thresholds)
+	c.setECCModeStatus(output)
+	if _, err := c.thresholds.Observe(ctx, last.Time.Time, output.ThresholdReadings()...); err != nil {
+		log.Logger.Warnw("failed to record threshold breach events", "component", Name, "error", err)
+	}
 	return output.States()
 }
 
+const (
+	EventNameECCModeSet   = "ecc_mode_set"
+	EventNameMemoryScrub  = nvidia_query_memory_scrub.EventNameMemoryScrub
+	EventKeyScrubOutput   = nvidia_query_memory_scrub.EventKeyScrubOutput
+	EventKeyScrubDuration = nvidia_query_memory_scrub.EventKeyScrubDuration
+
+	// maxEvents is the maximum number of in-memory events to keep.
+	maxEvents = 100
+
+	// scrubCheckInterval is the interval to check if a scrub is due and the GPUs are idle.
+	scrubCheckInterval = time.Minute
+	// maxScrubOutputBytes is the maximum number of the scrub output bytes to keep in the result.
+	maxScrubOutputBytes = 4096
+)
+
+// enforceECCModeLoop sets the pending ECC mode once per poll.
+func (c *component) enforceECCModeLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Query.Interval.Duration)
+	defer ticker.Stop()
+
+	var lastPolled time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		last, err := c.poller.Last()
+		if err != nil || last.Error != nil || last.Output == nil {
+			continue
+		}
+		if !last.Time.Time.After(lastPolled) {
+			continue
+		}
+		lastPolled = last.Time.Time
+
+		allOutput, ok := last.Output.(*nvidia_query.Output)
+		if !ok {
+			continue
+		}
+		c.enforceECCMode(ToOutput(allOutput).ECCModes)
+	}
+}
+
+// enforceECCMode sets the pending ECC mode of the GPUs that differ from the desired mode,
+// skipping the GPUs whose pending mode is already set (or failed to set).
+// The new mode only takes effect after the reboot.
+func (c *component) enforceECCMode(modes []nvidia_query_nvml.ECCMode) {
+	want := *c.cfg.EnforceECCMode
+	toSet, _ := FindECCModeMismatches(modes, want)
+
+	for _, uuid := range toSet {
+		c.eccModeSetMu.RLock()
+		_, set := c.eccModeSet[uuid]
+		c.eccModeSetMu.RUnlock()
+		if set {
+			continue
+		}
+
+		log.Logger.Warnw("ecc mode does not match the desired mode -- setting", "uuid", uuid, "enabled", want)
+		ev := components.Event{
+			Time:    metav1.Time{Time: time.Now().UTC()},
+			Name:    EventNameECCModeSet,
+			Type:    components.EventTypeWarn,
+			Message: fmt.Sprintf("set pending ecc mode (enabled %v) on %s, reboot required to apply", want, uuid),
+		}
+		result := ""
+		if err := nvidia_query_nvml.SetECCMode(uuid, want); err != nil {
+			result = err.Error()
+			ev.Type = components.EventTypeError
+			ev.Message = fmt.Sprintf("failed to set pending ecc mode (enabled %v) on %s (%v)", want, uuid, err)
+		}
+		c.eccModeSetMu.Lock()
+		c.eccModeSet[uuid] = result
+		c.eccModeSetMu.Unlock()
+
+		c.addEvent(ev)
+	}
+}
+
+// setECCModeStatus sets the desired ECC mode and the GPUs waiting for the reboot
+// (or failed to set the pending mode) in the output.
+func (c *component) setECCModeStatus(o *Output) {
+	if c.cfg.EnforceECCMode == nil {
+		return
+	}
+	o.DesiredECCMode = c.cfg.EnforceECCMode
+
+	toSet, pendingReboot := FindECCModeMismatches(o.ECCModes, *c.cfg.EnforceECCMode)
+	o.ECCModePendingReboot = pendingReboot
+
+	c.eccModeSetMu.RLock()
+	defer c.eccModeSetMu.RUnlock()
+	for _, uuid := range toSet {
+		result, set := c.eccModeSet[uuid]
+		switch {
+		case !set:
+			// not set yet (the next poll sets it)
+		case result != "":
+			o.EnforceErrors = append(o.EnforceErrors, fmt.Sprintf("%s: %s", uuid, result))
+		default:
+			// set, but not reflected in the polled data yet
+			o.ECCModePendingReboot = append(o.ECCModePendingReboot, uuid)
+		}
+	}
+}
+
+// scheduleScrubs runs the memory scrub command during the idle windows,
+// at most once per the configured interval, and records the results.
+func (c *component) scheduleScrubs(ctx context.Context) {
+	ticker := time.NewTicker(scrubCheckInterval)
+	defer ticker.Stop()
+
+	// resume the interval from the last recorded scrub, not to scrub again on every restart
+	var lastScrub time.Time
+	if db := c.db(); db != nil {
+		var err error
+		lastScrub, err = nvidia_query_memory_scrub.LastScrubbed(ctx, db)
+		if err != nil {
+			log.Logger.Warnw("failed to read last memory scrub", "error", err)
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !lastScrub.IsZero() && time.Since(lastScrub) < c.cfg.Scrub.Interval.Duration {
+			continue
+		}
+		if idle, reason := c.scrubGate.Check(ctx); !idle {
+			log.Logger.Debugw("node busy -- skipping memory scrub", "reason", reason)
+			continue
+		}
+
+		lastScrub = time.Now().UTC()
+		c.recordScrub(ctx, c.runScrub(ctx))
+	}
+}
+
+func (c *component) runScrub(ctx context.Context) nvidia_query_memory_scrub.Scrub {
+	log.Logger.Infow("running memory scrub", "command", c.cfg.Scrub.Command)
+
+	start := time.Now().UTC()
+	var b []byte
+	err := c.scrubGate.Run(ctx, func(pctx context.Context) error {
+		cctx, ccancel := context.WithTimeout(pctx, c.cfg.Scrub.Timeout.Duration)
+		defer ccancel()
+
+		if err := execlimit.Wait(cctx, Name); err != nil {
+			return err
+		}
+		var err error
+		b, err = exec.CommandContext(cctx, "bash", "-c", c.cfg.Scrub.Command).CombinedOutput()
+		return err
+	})
+	took := time.Since(start)
+
+	out := strings.TrimSpace(string(b))
+	if len(out) > maxScrubOutputBytes {
+		out = out[len(out)-maxScrubOutputBytes:]
+	}
+
+	s := nvidia_query_memory_scrub.Scrub{
+		UnixSeconds:     start.Unix(),
+		Result:          nvidia_query_memory_scrub.ResultSucceeded,
+		DurationSeconds: took.Seconds(),
+		Output:          out,
+	}
+	switch {
+	case errors.Is(err, probegate.ErrAborted):
+		log.Logger.Warnw("memory scrub aborted", "error", err)
+		s.Result = nvidia_query_memory_scrub.ResultAborted
+		s.Error = err.Error()
+	case err != nil:
+		log.Logger.Warnw("memory scrub failed", "error", err)
+		s.Result = nvidia_query_memory_scrub.ResultFailed
+		s.Error = err.Error()
+	}
+	return s
+}
+
+// recordScrub persists the scrub result,
+// or keeps it in memory if the database is not available.
+func (c *component) recordScrub(ctx context.Context, s nvidia_query_memory_scrub.Scrub) {
+	if db := c.db(); db != nil {
+		err := nvidia_query_memory_scrub.InsertScrub(ctx, db, s)
+		if err == nil {
+			return
+		}
+		log.Logger.Warnw("failed to record memory scrub", "error", err)
+	}
+	c.addEvent(s.ToComponentEvent())
+}
+
+func (c *component) db() *sql.DB {
+	if c.cfg.Query.State == nil {
+		return nil
+	}
+	return c.cfg.Query.State.DB
+}
+
+func (c *component) addEvent(ev components.Event) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+
+	c.events = append(c.events, ev)
+	if len(c.events) > maxEvents {
+		c.events = c.events[len(c.events)-maxEvents:]
+	}
+}
+
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
-	return nil, nil
+	c.eventsMu.RLock()
+	var evs []components.Event
+	for _, ev := range c.events {
+		if ev.Time.Time.Before(since) {
+			continue
+		}
+		evs = append(evs, ev)
+	}
+	c.eventsMu.RUnlock()
+
+	if c.cfg.Query.State != nil && c.cfg.Query.State.DB != nil {
+		resets, err := nvidia_query_counter_reset_state.ReadEvents(ctx, c.cfg.Query.State.DB, since, nvidia_query_nvml.CounterTypeECC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ecc counter reset events: %w", err)
+		}
+		for _, ev := range resets {
+			evs = append(evs, ev.ToComponentEvent())
+		}
+
+		memtests, err := nvidia_query_gpu_ledger.ReadEntries(ctx, c.cfg.Query.State.DB, since, "", nvidia_query_memtest.TestName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read memory test verdicts: %w", err)
+		}
+		for _, e := range memtests {
+			evs = append(evs, e.ToComponentEvent())
+		}
+
+		overlaps, err := nvidia_query_ecc_addresses.ReadOverlaps(ctx, c.cfg.Query.State.DB, since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ecc address overlaps: %w", err)
+		}
+		for _, o := range overlaps {
+			evs = append(evs, o.ToComponentEvent())
+		}
+
+		breaches, err := nvidia_query_threshold_breach_state.ReadEvents(ctx, c.cfg.Query.State.DB, since, Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read threshold breach events: %w", err)
+		}
+		for _, ev := range breaches {
+			evs = append(evs, ev.ToComponentEvent())
+		}
+
+		scrubs, err := nvidia_query_memory_scrub.ReadScrubs(ctx, c.cfg.Query.State.DB, since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read memory scrubs: %w", err)
+		}
+		for _, s := range scrubs {
+			evs = append(evs, s.ToComponentEvent())
+		}
+	}
+	return evs, nil
 }
 
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
@@ -166,6 +491,13 @@ func (c *component) Close() error {
 	// safe to call stop multiple times
 	_ = c.poller.Stop(Name)
 
+	if c.scrubCancel != nil {
+		c.scrubCancel()
+	}
+	if c.enforceCancel != nil {
+		c.enforceCancel()
+	}
+
 	return nil
 }
 
@@ -9,6 +9,8 @@ import (
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	nvidia_query_threshold_breach_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/threshold-breach-state"
+	"github.com/leptonai/gpud/components/common"
 )
 
 // ToOutput converts nvidia_query.Output to Output.
@@ -65,6 +67,92 @@ type Output struct {
 	// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceEnumvs.html#group__nvmlDeviceEnumvs_1gc5469bd68b9fdcf78734471d86becb24
 	VolatileUncorrectedErrorsFromSMI  []string `json:"volatile_uncorrected_errors_from_smi"`
 	VolatileUncorrectedErrorsFromNVML []string `json:"volatile_uncorrected_errors_from_nvml"`
+
+	// DesiredECCMode is the enforced ECC mode, only set when the enforcement is enabled.
+	DesiredECCMode *bool `json:"desired_ecc_mode,omitempty"`
+	// ECCModePendingReboot is the list of the GPU UUIDs whose current ECC mode
+	// differs from the desired mode, waiting for the reboot to apply the pending mode.
+	ECCModePendingReboot []string `json:"ecc_mode_pending_reboot,omitempty"`
+	// EnforceErrors is the list of errors from setting the ECC mode.
+	EnforceErrors []string `json:"enforce_errors,omitempty"`
+
+	// ThresholdPreset is the threshold preset picked based on the GPU product name
+	// (empty if no preset is defined for the product).
+	ThresholdPreset string `json:"threshold_preset,omitempty"`
+	// Thresholds is the preset thresholds with the config overrides applied.
+	Thresholds nvidia_query.GPUThresholds `json:"thresholds"`
+}
+
+// FindVolatileCorrectedErrorsOverThreshold returns the GPUs whose volatile corrected error counts
+// are at or above the threshold (nil if the threshold is not set).
+func (o *Output) FindVolatileCorrectedErrorsOverThreshold() []string {
+	if o.Thresholds.MaxVolatileCorrectedECCErrors == 0 {
+		return nil
+	}
+	var over []string
+	for _, es := range o.ErrorCountsNVML {
+		if es.Volatile.Total.Corrected >= o.Thresholds.MaxVolatileCorrectedECCErrors {
+			over = append(over, fmt.Sprintf("[%s] %d corrected errors", es.UUID, es.Volatile.Total.Corrected))
+		}
+	}
+	return over
+}
+
+// FindVolatileUncorrectedErrorsOverThreshold returns the GPUs whose volatile uncorrected error counts
+// are at or above the threshold (nil if the threshold is not set).
+func (o *Output) FindVolatileUncorrectedErrorsOverThreshold() []string {
+	if o.Thresholds.MaxVolatileUncorrectedECCErrors == 0 {
+		return nil
+	}
+	var over []string
+	for _, es := range o.ErrorCountsNVML {
+		if es.Volatile.Total.Uncorrected >= o.Thresholds.MaxVolatileUncorrectedECCErrors {
+			over = append(over, fmt.Sprintf("[%s] %d uncorrected errors", es.UUID, es.Volatile.Total.Uncorrected))
+		}
+	}
+	return over
+}
+
+// FindAggregateUncorrectedErrorsOverThreshold returns the GPUs whose aggregate (lifetime) uncorrected error counts
+// are at or above the threshold (nil if the threshold is not set).
+func (o *Output) FindAggregateUncorrectedErrorsOverThreshold() []string {
+	if o.Thresholds.MaxAggregateUncorrectedECCErrors == 0 {
+		return nil
+	}
+	var over []string
+	for _, es := range o.ErrorCountsNVML {
+		if es.Aggregate.Total.Uncorrected >= o.Thresholds.MaxAggregateUncorrectedECCErrors {
+			over = append(over, fmt.Sprintf("[%s] %d uncorrected errors", es.UUID, es.Aggregate.Total.Uncorrected))
+		}
+	}
+	return over
+}
+
+// ThresholdReadings returns the per-GPU volatile corrected, volatile uncorrected, and aggregate uncorrected
+// error counts against the configured thresholds, to track the threshold crossings.
+func (o *Output) ThresholdReadings() []nvidia_query_threshold_breach_state.Reading {
+	rs := make([]nvidia_query_threshold_breach_state.Reading, 0, 3*len(o.ErrorCountsNVML))
+	for _, es := range o.ErrorCountsNVML {
+		rs = append(rs, nvidia_query_threshold_breach_state.Reading{
+			ThresholdName: nvidia_query.GPUThresholdNameMaxVolatileCorrectedECCErrors,
+			GPUUUID:       es.UUID,
+			Value:         float64(es.Volatile.Total.Corrected),
+			Threshold:     float64(o.Thresholds.MaxVolatileCorrectedECCErrors),
+		})
+		rs = append(rs, nvidia_query_threshold_breach_state.Reading{
+			ThresholdName: nvidia_query.GPUThresholdNameMaxVolatileUncorrectedECCErrors,
+			GPUUUID:       es.UUID,
+			Value:         float64(es.Volatile.Total.Uncorrected),
+			Threshold:     float64(o.Thresholds.MaxVolatileUncorrectedECCErrors),
+		})
+		rs = append(rs, nvidia_query_threshold_breach_state.Reading{
+			ThresholdName: nvidia_query.GPUThresholdNameMaxAggregateUncorrectedECCErrors,
+			GPUUUID:       es.UUID,
+			Value:         float64(es.Aggregate.Total.Uncorrected),
+			Threshold:     float64(o.Thresholds.MaxAggregateUncorrectedECCErrors),
+		})
+	}
+	return rs
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -87,6 +175,13 @@ const (
 	StateValueECCEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameECC, "per-GPU ECC errors and modes"),
+		nvidia_query.GPUStateSchema(StateNameECC, "ECC health of the GPU, one state per GPU"),
+	)
+}
+
 func ParseStateECCErrors(m map[string]string) (*Output, error) {
 	data := m[StateKeyECCData]
 	return ParseOutputJSON([]byte(data))
@@ -103,6 +198,10 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 			return o, nil
 
 		default:
+			if nvidia_query.IsGPUStateName(StateNameECC, state.Name) {
+				// per-GPU states, derived from the component state
+				continue
+			}
 			return nil, fmt.Errorf("unknown state name: %s", state.Name)
 		}
 	}
@@ -133,20 +232,179 @@ func (o *Output) States() ([]components.State, error) {
 		reason = fmt.Sprintf("note that when an uncorrectable ECC error is detected, the NVIDIA driver software will perform error recovery -- %s", reason)
 	}
 
+	healthy := true
+	var suggestedActions *common.SuggestedActions
+	if o.DesiredECCMode != nil && len(o.ECCModePendingReboot) > 0 {
+		healthy = false
+		reason = fmt.Sprintf("%s; ecc mode does not match the desired mode (enabled %v) on %s, reboot required to apply the pending mode",
+			reason,
+			*o.DesiredECCMode,
+			strings.Join(o.ECCModePendingReboot, ", "),
+		)
+		suggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"reboot the system to apply the pending ECC mode",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRebootSystem,
+			},
+		}
+	}
+	if len(o.EnforceErrors) > 0 {
+		healthy = false
+		reason = fmt.Sprintf("%s; failed to set ecc mode: %s", reason, strings.Join(o.EnforceErrors, "; "))
+	}
+	if over := o.FindVolatileCorrectedErrorsOverThreshold(); len(over) > 0 {
+		healthy = false
+		reason = fmt.Sprintf("%s; volatile corrected errors at or above the threshold %d (preset %q): %s",
+			reason,
+			o.Thresholds.MaxVolatileCorrectedECCErrors,
+			o.ThresholdPreset,
+			strings.Join(over, ", "),
+		)
+		if suggestedActions == nil {
+			suggestedActions = &common.SuggestedActions{}
+		}
+		suggestedActions.Descriptions = append(suggestedActions.Descriptions,
+			"inspect the GPU memory for the degrading cells (e.g., run the memory scrub or check the row remapping)",
+		)
+		suggestedActions.RepairActions = append(suggestedActions.RepairActions, common.RepairActionTypeHardwareInspection)
+	}
+	if over := o.FindVolatileUncorrectedErrorsOverThreshold(); len(over) > 0 {
+		healthy = false
+		reason = fmt.Sprintf("%s; volatile uncorrected errors at or above the threshold %d (preset %q): %s",
+			reason,
+			o.Thresholds.MaxVolatileUncorrectedECCErrors,
+			o.ThresholdPreset,
+			strings.Join(over, ", "),
+		)
+		if suggestedActions == nil {
+			suggestedActions = &common.SuggestedActions{}
+		}
+		suggestedActions.Descriptions = append(suggestedActions.Descriptions,
+			"reboot the system to retire (or remap) the GPU memory with the uncorrected errors",
+		)
+		suggestedActions.RepairActions = append(suggestedActions.RepairActions, common.RepairActionTypeRebootSystem)
+	}
+	if over := o.FindAggregateUncorrectedErrorsOverThreshold(); len(over) > 0 {
+		healthy = false
+		reason = fmt.Sprintf("%s; aggregate uncorrected errors at or above the threshold %d (preset %q): %s",
+			reason,
+			o.Thresholds.MaxAggregateUncorrectedECCErrors,
+			o.ThresholdPreset,
+			strings.Join(over, ", "),
+		)
+		if suggestedActions == nil {
+			suggestedActions = &common.SuggestedActions{}
+		}
+		suggestedActions.Descriptions = append(suggestedActions.Descriptions,
+			"inspect the GPU for the repair, the uncorrected errors keep recurring across the reboots",
+		)
+		suggestedActions.RepairActions = append(suggestedActions.RepairActions, common.RepairActionTypeHardwareInspection)
+	}
+
 	b, _ := o.JSON()
 	state := components.State{
 		Name: StateNameECC,
 
 		// no reason to mark this unhealthy as "when an uncorrectable ECC error is detected, the NVIDIA driver software will perform error recovery."
 		// we only mark this unhealthy when the pending row remapping is >0 (which requires GPU reset)
+		// or when the ECC mode does not match the enforced mode
+		// or when the corrected errors exceed the per-SKU threshold
+		// or when the uncorrected errors exceed the configured thresholds
 		// ref. https://docs.nvidia.com/deploy/a100-gpu-mem-error-mgmt/index.html
-		Healthy: true,
+		Healthy: healthy,
 
 		Reason: reason,
 		ExtraInfo: map[string]string{
 			StateKeyECCData:     string(b),
 			StateKeyECCEncoding: StateValueECCEncodingJSON,
 		},
+		SuggestedActions: suggestedActions,
+	}
+	return append([]components.State{state}, o.GPUStates()...), nil
+}
+
+// GPUStates returns the per-GPU states (e.g., "ecc_GPU-1234"),
+// unhealthy if the ECC mode of the GPU is pending the reboot
+// or the error counts of the GPU are at or above the thresholds.
+func (o *Output) GPUStates() []components.State {
+	gs := nvidia_query.NewGPUStates(StateNameECC)
+	for _, es := range o.ErrorCountsNVML {
+		gs.Add(es.UUID)
+	}
+	for _, m := range o.ECCModes {
+		gs.Add(m.UUID)
+	}
+
+	if o.DesiredECCMode != nil {
+		for _, uuid := range o.ECCModePendingReboot {
+			gs.Unhealthy(uuid,
+				fmt.Sprintf("ecc mode does not match the desired mode (enabled %v), reboot required to apply the pending mode", *o.DesiredECCMode),
+				&common.SuggestedActions{
+					Descriptions:  []string{"reboot the system to apply the pending ECC mode"},
+					RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+				},
+			)
+		}
+	}
+
+	if o.Thresholds.MaxVolatileCorrectedECCErrors > 0 {
+		for _, es := range o.ErrorCountsNVML {
+			if es.Volatile.Total.Corrected < o.Thresholds.MaxVolatileCorrectedECCErrors {
+				continue
+			}
+			gs.Unhealthy(es.UUID,
+				fmt.Sprintf("%d volatile corrected errors at or above the threshold %d (preset %q)",
+					es.Volatile.Total.Corrected,
+					o.Thresholds.MaxVolatileCorrectedECCErrors,
+					o.ThresholdPreset,
+				),
+				&common.SuggestedActions{
+					Descriptions:  []string{"inspect the GPU memory for the degrading cells (e.g., run the memory scrub or check the row remapping)"},
+					RepairActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+				},
+			)
+		}
+	}
+
+	if o.Thresholds.MaxVolatileUncorrectedECCErrors > 0 {
+		for _, es := range o.ErrorCountsNVML {
+			if es.Volatile.Total.Uncorrected < o.Thresholds.MaxVolatileUncorrectedECCErrors {
+				continue
+			}
+			gs.Unhealthy(es.UUID,
+				fmt.Sprintf("%d volatile uncorrected errors at or above the threshold %d (preset %q)",
+					es.Volatile.Total.Uncorrected,
+					o.Thresholds.MaxVolatileUncorrectedECCErrors,
+					o.ThresholdPreset,
+				),
+				&common.SuggestedActions{
+					Descriptions:  []string{"reboot the system to retire (or remap) the GPU memory with the uncorrected errors"},
+					RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+				},
+			)
+		}
 	}
-	return []components.State{state}, nil
+
+	if o.Thresholds.MaxAggregateUncorrectedECCErrors > 0 {
+		for _, es := range o.ErrorCountsNVML {
+			if es.Aggregate.Total.Uncorrected < o.Thresholds.MaxAggregateUncorrectedECCErrors {
+				continue
+			}
+			gs.Unhealthy(es.UUID,
+				fmt.Sprintf("%d aggregate uncorrected errors at or above the threshold %d (preset %q)",
+					es.Aggregate.Total.Uncorrected,
+					o.Thresholds.MaxAggregateUncorrectedECCErrors,
+					o.ThresholdPreset,
+				),
+				&common.SuggestedActions{
+					Descriptions:  []string{"inspect the GPU for the repair, the uncorrected errors keep recurring across the reboots"},
+					RepairActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+				},
+			)
+		}
+	}
+
+	return gs.States()
 }
@@ -4,8 +4,10 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
 )
 
 func TestToOutput(t *testing.T) {
@@ -179,3 +181,160 @@ func TestToOutputECCMode(t *testing.T) {
 		})
 	}
 }
+
+func TestOutputStatesCorrectedErrorsThreshold(t *testing.T) {
+	errorCounts := func(corrected uint64) []nvidia_query_nvml.ECCErrors {
+		return []nvidia_query_nvml.ECCErrors{
+			{
+				UUID: "GPU-1",
+				Volatile: nvidia_query_nvml.AllECCErrorCounts{
+					Total: nvidia_query_nvml.ECCErrorCounts{Corrected: corrected},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		output          *Output
+		expectedHealthy bool
+	}{
+		{
+			name:            "no threshold",
+			output:          &Output{ErrorCountsNVML: errorCounts(5000)},
+			expectedHealthy: true,
+		},
+		{
+			name: "below threshold",
+			output: &Output{
+				ErrorCountsNVML: errorCounts(99),
+				ThresholdPreset: nvidia_query.GPUThresholdPresetL40S,
+				Thresholds:      nvidia_query.GPUThresholdPresets[nvidia_query.GPUThresholdPresetL40S],
+			},
+			expectedHealthy: true,
+		},
+		{
+			name: "at threshold",
+			output: &Output{
+				ErrorCountsNVML: errorCounts(100),
+				ThresholdPreset: nvidia_query.GPUThresholdPresetL40S,
+				Thresholds:      nvidia_query.GPUThresholdPresets[nvidia_query.GPUThresholdPresetL40S],
+			},
+			expectedHealthy: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states, err := tt.output.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != 2 {
+				t.Fatalf("expected 2 states (component and GPU-1), got %d", len(states))
+			}
+			for _, st := range states {
+				if st.Healthy != tt.expectedHealthy {
+					t.Errorf("%s healthy = %v, want %v (reason %q)", st.Name, st.Healthy, tt.expectedHealthy, st.Reason)
+				}
+				if !tt.expectedHealthy && st.SuggestedActions == nil {
+					t.Errorf("%s expected suggested actions", st.Name)
+				}
+			}
+			if err := components.ValidateStates(Name, states...); err != nil {
+				t.Errorf("states do not match the schema: %v", err)
+			}
+			if states[1].Name != "ecc_GPU-1" || states[1].ExtraInfo[nvidia_query.StateKeyGPUUUID] != "GPU-1" {
+				t.Errorf("unexpected per-GPU state %+v", states[1])
+			}
+
+			o, err := ParseStatesToOutput(states...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(o.ErrorCountsNVML) != 1 {
+				t.Errorf("expected 1 error count, got %d", len(o.ErrorCountsNVML))
+			}
+		})
+	}
+}
+
+func TestOutputStatesUncorrectedErrorsThresholds(t *testing.T) {
+	errorCounts := func(volatile, aggregate uint64) []nvidia_query_nvml.ECCErrors {
+		return []nvidia_query_nvml.ECCErrors{
+			{
+				UUID: "GPU-1",
+				Volatile: nvidia_query_nvml.AllECCErrorCounts{
+					Total: nvidia_query_nvml.ECCErrorCounts{Uncorrected: volatile},
+				},
+				Aggregate: nvidia_query_nvml.AllECCErrorCounts{
+					Total: nvidia_query_nvml.ECCErrorCounts{Uncorrected: aggregate},
+				},
+			},
+		}
+	}
+	thresholds := nvidia_query.GPUThresholds{
+		MaxVolatileUncorrectedECCErrors:  1,
+		MaxAggregateUncorrectedECCErrors: 10,
+	}
+
+	tests := []struct {
+		name            string
+		output          *Output
+		expectedHealthy bool
+		expectedActions []common.RepairActionType
+	}{
+		{
+			name:            "no threshold",
+			output:          &Output{ErrorCountsNVML: errorCounts(5, 50)},
+			expectedHealthy: true,
+		},
+		{
+			name:            "below thresholds",
+			output:          &Output{ErrorCountsNVML: errorCounts(0, 9), Thresholds: thresholds},
+			expectedHealthy: true,
+		},
+		{
+			name:            "volatile at threshold",
+			output:          &Output{ErrorCountsNVML: errorCounts(1, 9), Thresholds: thresholds},
+			expectedHealthy: false,
+			expectedActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+		},
+		{
+			name:            "aggregate at threshold",
+			output:          &Output{ErrorCountsNVML: errorCounts(0, 10), Thresholds: thresholds},
+			expectedHealthy: false,
+			expectedActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+		},
+		{
+			name:            "both at thresholds",
+			output:          &Output{ErrorCountsNVML: errorCounts(1, 10), Thresholds: thresholds},
+			expectedHealthy: false,
+			expectedActions: []common.RepairActionType{common.RepairActionTypeRebootSystem, common.RepairActionTypeHardwareInspection},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states, err := tt.output.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != 2 {
+				t.Fatalf("expected 2 states (component and GPU-1), got %d", len(states))
+			}
+			for _, st := range states {
+				if st.Healthy != tt.expectedHealthy {
+					t.Errorf("%s healthy = %v, want %v (reason %q)", st.Name, st.Healthy, tt.expectedHealthy, st.Reason)
+				}
+				if tt.expectedHealthy {
+					continue
+				}
+				if st.SuggestedActions == nil {
+					t.Fatalf("%s expected suggested actions", st.Name)
+				}
+				if !reflect.DeepEqual(st.SuggestedActions.RepairActions, tt.expectedActions) {
+					t.Errorf("%s repair actions = %v, want %v", st.Name, st.SuggestedActions.RepairActions, tt.expectedActions)
+				}
+			}
+		})
+	}
+}
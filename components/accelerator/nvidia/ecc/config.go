@@ -3,12 +3,89 @@ package ecc
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/pkg/probegate"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type Config struct {
 	Query query_config.Config `json:"query"`
+
+	// EnforceECCMode is the desired ECC mode of all the GPUs.
+	// If set, the pending ECC mode is set to the desired mode whenever the poll finds it differs
+	// (at most once per GPU, failures are not retried until GPUd restarts),
+	// and the component is marked unhealthy until the reboot applies the mode.
+	// Leave empty to not enforce the ECC mode.
+	EnforceECCMode *bool `json:"enforce_ecc_mode,omitempty"`
+
+	// Scrub is the optional memory scrub schedule.
+	// Leave empty to disable the memory scrubs.
+	Scrub *ScrubConfig `json:"scrub,omitempty"`
+
+	// Thresholds overrides the thresholds of the preset
+	// picked based on the detected GPU product name (e.g., per fleet).
+	// Leave empty to use the preset thresholds.
+	Thresholds *nvidia_query.GPUThresholds `json:"thresholds,omitempty"`
+}
+
+const (
+	// DefaultScrubCommand runs the DCGM memory test, which writes and verifies the GPU memory.
+	DefaultScrubCommand = "dcgmi diag -r memory"
+
+	DefaultScrubInterval          = 7 * 24 * time.Hour
+	DefaultScrubTimeout           = 30 * time.Minute
+	DefaultScrubMaxGPUUsedPercent = 5
+)
+
+// ScrubConfig defines the memory scrub schedule.
+// Scrubs only run during the idle windows, when no process is running on any GPU,
+// the GPU utilization is below the threshold, and no other gate signal is busy.
+type ScrubConfig struct {
+	// Command is the scrub command to run.
+	Command string `json:"command"`
+	// Interval is the minimum interval between two scrubs.
+	Interval metav1.Duration `json:"interval"`
+	// Timeout is the timeout of a single scrub.
+	Timeout metav1.Duration `json:"timeout"`
+	// MaxGPUUsedPercent is the maximum GPU utilization to consider the GPU idle.
+	MaxGPUUsedPercent uint32 `json:"max_gpu_used_percent"`
+	// Gate optionally defines the other busy signals (e.g., CPU utilization, running jobs).
+	// A running scrub is aborted if a training job starts on the node.
+	Gate *probegate.Config `json:"gate,omitempty"`
+}
+
+// GateConfig returns the gate config of the scrubs,
+// with the scrub max GPU utilization unless the gate sets its own.
+func (cfg ScrubConfig) GateConfig() probegate.Config {
+	var gcfg probegate.Config
+	if cfg.Gate != nil {
+		gcfg = *cfg.Gate
+	}
+	if gcfg.MaxGPUUsedPercent == 0 {
+		gcfg.MaxGPUUsedPercent = cfg.MaxGPUUsedPercent
+	}
+	return gcfg
+}
+
+func (cfg *ScrubConfig) SetDefaultsIfNotSet() {
+	if cfg.Command == "" {
+		cfg.Command = DefaultScrubCommand
+	}
+	if cfg.Interval.Duration == 0 {
+		cfg.Interval.Duration = DefaultScrubInterval
+	}
+	if cfg.Timeout.Duration == 0 {
+		cfg.Timeout.Duration = DefaultScrubTimeout
+	}
+	if cfg.MaxGPUUsedPercent == 0 {
+		cfg.MaxGPUUsedPercent = DefaultScrubMaxGPUUsedPercent
+	}
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -28,5 +105,21 @@ func ParseConfig(b any, db *sql.DB) (*Config, error) {
 }
 
 func (cfg Config) Validate() error {
+	if cfg.Scrub != nil {
+		if cfg.Scrub.Interval.Duration < 0 {
+			return errors.New("scrub interval must be non-negative")
+		}
+		if cfg.Scrub.Timeout.Duration < 0 {
+			return errors.New("scrub timeout must be non-negative")
+		}
+		if cfg.Scrub.MaxGPUUsedPercent > 100 {
+			return errors.New("scrub max gpu used percent must be <= 100")
+		}
+		if cfg.Scrub.Gate != nil {
+			if err := cfg.Scrub.Gate.Validate(); err != nil {
+				return fmt.Errorf("invalid scrub gate: %w", err)
+			}
+		}
+	}
 	return nil
 }
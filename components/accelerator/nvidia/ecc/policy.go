@@ -0,0 +1,42 @@
+package ecc
+
+import (
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+// FindECCModeMismatches returns the GPUs whose pending ECC mode differs from the desired mode
+// (thus the pending mode needs to be set), and the GPUs whose pending mode already matches
+// but the current mode does not (thus waiting for the reboot to apply).
+func FindECCModeMismatches(modes []nvidia_query_nvml.ECCMode, enabled bool) (toSet []string, pendingReboot []string) {
+	for _, m := range modes {
+		if m.EnabledPending != enabled {
+			toSet = append(toSet, m.UUID)
+			continue
+		}
+		if m.EnabledCurrent != enabled {
+			pendingReboot = append(pendingReboot, m.UUID)
+		}
+	}
+	return toSet, pendingReboot
+}
+
+// IsIdle returns true if no process is running on any of the GPUs
+// and all the GPU utilizations are at or below the threshold.
+// Returns false if no GPU is found.
+func IsIdle(devs []*nvidia_query_nvml.DeviceInfo, maxGPUUsedPercent uint32) bool {
+	if len(devs) == 0 {
+		return false
+	}
+	for _, dev := range devs {
+		if dev == nil {
+			return false
+		}
+		if len(dev.Processes.RunningProcesses) > 0 {
+			return false
+		}
+		if dev.Utilization.GPUUsedPercent > maxGPUUsedPercent {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package ecc
+
+import (
+	"reflect"
+	"testing"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+func TestFindECCModeMismatches(t *testing.T) {
+	modes := []nvidia_query_nvml.ECCMode{
+		{UUID: "GPU-0", EnabledCurrent: true, EnabledPending: true},
+		{UUID: "GPU-1", EnabledCurrent: false, EnabledPending: false},
+		{UUID: "GPU-2", EnabledCurrent: false, EnabledPending: true},
+	}
+
+	tests := []struct {
+		name              string
+		enabled           bool
+		wantToSet         []string
+		wantPendingReboot []string
+	}{
+		{
+			name:              "enforce enabled",
+			enabled:           true,
+			wantToSet:         []string{"GPU-1"},
+			wantPendingReboot: []string{"GPU-2"},
+		},
+		{
+			name:              "enforce disabled",
+			enabled:           false,
+			wantToSet:         []string{"GPU-0", "GPU-2"},
+			wantPendingReboot: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toSet, pendingReboot := FindECCModeMismatches(modes, tt.enabled)
+			if !reflect.DeepEqual(toSet, tt.wantToSet) {
+				t.Errorf("toSet = %v, want %v", toSet, tt.wantToSet)
+			}
+			if !reflect.DeepEqual(pendingReboot, tt.wantPendingReboot) {
+				t.Errorf("pendingReboot = %v, want %v", pendingReboot, tt.wantPendingReboot)
+			}
+		})
+	}
+}
+
+func TestIsIdle(t *testing.T) {
+	tests := []struct {
+		name string
+		devs []*nvidia_query_nvml.DeviceInfo
+		want bool
+	}{
+		{
+			name: "no gpu",
+			devs: nil,
+			want: false,
+		},
+		{
+			name: "idle",
+			devs: []*nvidia_query_nvml.DeviceInfo{
+				{UUID: "GPU-0", Utilization: nvidia_query_nvml.Utilization{GPUUsedPercent: 1}},
+			},
+			want: true,
+		},
+		{
+			name: "busy",
+			devs: []*nvidia_query_nvml.DeviceInfo{
+				{UUID: "GPU-0"},
+				{UUID: "GPU-1", Utilization: nvidia_query_nvml.Utilization{GPUUsedPercent: 80}},
+			},
+			want: false,
+		},
+		{
+			name: "running process",
+			devs: []*nvidia_query_nvml.DeviceInfo{
+				{UUID: "GPU-0", Processes: nvidia_query_nvml.Processes{RunningProcesses: []nvidia_query_nvml.Process{{PID: 1}}}},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsIdle(tt.devs, DefaultScrubMaxGPUUsedPercent); got != tt.want {
+				t.Errorf("IsIdle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package errorxidsxid
+
+import (
+	"context"
+	"time"
+
+	nvidia_query_bug_report "github.com/leptonai/gpud/components/accelerator/nvidia/query/bug-report"
+	nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+	"github.com/leptonai/gpud/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultBugReportDir         = "/var/lib/gpud/bug-reports"
+	DefaultBugReportMinInterval = 6 * time.Hour
+	DefaultBugReportTimeout     = 15 * time.Minute
+)
+
+// EventKeyBugReport is the event extra info key of the bug report archive path.
+const EventKeyBugReport = "bug_report"
+
+// BugReportPolicy defines when "nvidia-bug-report.sh" is collected on the fatal Xid/SXid errors.
+type BugReportPolicy struct {
+	// Dir is the directory to store the bug report archives.
+	Dir string `json:"dir"`
+	// MinInterval is the minimum interval between two collections,
+	// since the script takes minutes and the archives are large.
+	MinInterval metav1.Duration `json:"min_interval"`
+	// Timeout is the timeout of a collection (the script hangs on a stuck driver).
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+func (p *BugReportPolicy) SetDefaultsIfNotSet() {
+	if p.Dir == "" {
+		p.Dir = DefaultBugReportDir
+	}
+	if p.MinInterval.Duration == 0 {
+		p.MinInterval = metav1.Duration{Duration: DefaultBugReportMinInterval}
+	}
+	if p.Timeout.Duration == 0 {
+		p.Timeout = metav1.Duration{Duration: DefaultBugReportTimeout}
+	}
+}
+
+// FindFatalEvents returns the Xid/SXid events marked as critical by GPUd.
+func FindFatalEvents(events []nvidia_xid_sxid_state.Event) []nvidia_xid_sxid_state.Event {
+	var fatal []nvidia_xid_sxid_state.Event
+	for _, ev := range events {
+		if d := ev.ToXidDetail(); d != nil && d.IsMarkedAsCriticalByGPUd() {
+			fatal = append(fatal, ev)
+			continue
+		}
+		if d := ev.ToSXidDetail(); d != nil && d.CriticalErrorMarkedByGPUd {
+			fatal = append(fatal, ev)
+		}
+	}
+	return fatal
+}
+
+func (c *component) scheduleBugReports(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// only the events after the start trigger the collection,
+	// so a restart does not collect again for the old events
+	since := time.Now().UTC()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		since = c.checkBugReport(ctx, since)
+	}
+}
+
+// checkBugReport collects a bug report if a fatal Xid/SXid is observed since the given time,
+// at most once per the minimum interval, and returns the time to check from in the next run.
+func (c *component) checkBugReport(ctx context.Context, since time.Time) time.Time {
+	now := time.Now().UTC()
+	events, err := nvidia_xid_sxid_state.ReadEvents(ctx, c.db, nvidia_xid_sxid_state.WithSince(since))
+	if err != nil {
+		log.Logger.Warnw("failed to read xid events for bug report", "error", err)
+		return since
+	}
+	fatal := FindFatalEvents(events)
+	if len(fatal) == 0 {
+		return now
+	}
+
+	last, err := nvidia_query_bug_report.LastCollected(ctx, c.db)
+	if err != nil {
+		log.Logger.Warnw("failed to read last bug report", "error", err)
+		return since
+	}
+	if !last.IsZero() && now.Sub(last) < c.bugReport.MinInterval.Duration {
+		log.Logger.Warnw("skipping bug report collection", "reason", "rate limited", "lastCollected", last, "fatalEvents", len(fatal))
+		return now
+	}
+
+	log.Logger.Warnw("fatal xid/sxid detected, collecting bug report", "fatalEvents", len(fatal), "dir", c.bugReport.Dir)
+	cctx, ccancel := context.WithTimeout(ctx, c.bugReport.Timeout.Duration)
+	archive, err := nvidia_query_bug_report.Collect(cctx, c.bugReport.Dir, now)
+	ccancel()
+
+	var errMsg string
+	if err != nil {
+		log.Logger.Warnw("failed to collect bug report", "error", err)
+		errMsg = err.Error()
+	} else {
+		log.Logger.Infow("collected bug report", "archive", archive)
+	}
+	for _, ev := range fatal {
+		if err := nvidia_query_bug_report.InsertReport(ctx, c.db, nvidia_query_bug_report.Report{
+			UnixSeconds:      now.Unix(),
+			EventUnixSeconds: ev.UnixSeconds,
+			EventType:        ev.EventType,
+			EventID:          ev.EventID,
+			ArchivePath:      archive,
+			Error:            errMsg,
+		}); err != nil {
+			log.Logger.Warnw("failed to record bug report", "error", err)
+		}
+	}
+	return now
+}
+
+// bugReportPaths returns the bug report archive paths by the event key, for the events since the given time.
+func (c *component) bugReportPaths(ctx context.Context, since time.Time) (map[string]string, error) {
+	if c.bugReport == nil {
+		return nil, nil
+	}
+	reports, err := nvidia_query_bug_report.ReadReports(ctx, c.db, since)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string, len(reports))
+	for _, r := range reports {
+		if r.ArchivePath != "" {
+			paths[r.Key()] = r.ArchivePath
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,35 @@
+package errorxidsxid
+
+import (
+	"testing"
+
+	nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+)
+
+func TestFindFatalEvents(t *testing.T) {
+	events := []nvidia_xid_sxid_state.Event{
+		{UnixSeconds: 1, EventType: "xid", EventID: 9},
+		{UnixSeconds: 2, EventType: "xid", EventID: 1},
+		{UnixSeconds: 3, EventType: "sxid", EventID: 11004},
+		{UnixSeconds: 4, EventType: "sxid", EventID: 11012},
+		{UnixSeconds: 5, EventType: "xid", EventID: 999999},
+	}
+	fatal := FindFatalEvents(events)
+	if len(fatal) != 2 {
+		t.Fatalf("expected 2 fatal events, got %+v", fatal)
+	}
+	if fatal[0].UnixSeconds != 1 || fatal[1].UnixSeconds != 3 {
+		t.Errorf("unexpected fatal events: %+v", fatal)
+	}
+	if got := FindFatalEvents(nil); len(got) != 0 {
+		t.Errorf("FindFatalEvents(nil) = %v, want empty", got)
+	}
+}
+
+func TestBugReportPolicyDefaults(t *testing.T) {
+	p := &BugReportPolicy{}
+	p.SetDefaultsIfNotSet()
+	if p.Dir != DefaultBugReportDir || p.MinInterval.Duration != DefaultBugReportMinInterval || p.Timeout.Duration != DefaultBugReportTimeout {
+		t.Errorf("unexpected defaults: %+v", p)
+	}
+}
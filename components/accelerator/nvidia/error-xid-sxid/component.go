@@ -6,33 +6,56 @@ import (
 	"database/sql"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/leptonai/gpud/components"
 	nvidia_error_xid_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error-xid-sxid/id"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_bug_report "github.com/leptonai/gpud/components/accelerator/nvidia/query/bug-report"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
 	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/components/state"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/locale"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func New(ctx context.Context, cfg Config) components.Component {
 	cfg.Query.SetDefaultsIfNotSet()
+	cfg.DBEPolicy.SetDefaultsIfNotSet()
 
 	// this starts the Xid poller via "nvml.StartDefaultInstance"
 	cctx, ccancel := context.WithCancel(ctx)
 	nvidia_query.SetDefaultPoller(cfg.Query.State.DB)
 	nvidia_query.GetDefaultPoller().Start(cctx, cfg.Query, nvidia_error_xid_sxid_id.Name)
 
-	return &component{
-		rootCtx: ctx,
-		cancel:  ccancel,
-		poller:  nvidia_query.GetDefaultPoller(),
-		db:      cfg.Query.State.DB,
+	c := &component{
+		rootCtx:   ctx,
+		cancel:    ccancel,
+		poller:    nvidia_query.GetDefaultPoller(),
+		db:        cfg.Query.State.DB,
+		dbePolicy: cfg.DBEPolicy,
 	}
+	if cfg.DriverRecovery != nil {
+		cfg.DriverRecovery.SetDefaultsIfNotSet()
+		c.recoverer = newDriverRecoverer(*cfg.DriverRecovery)
+		c.loadIncident(ctx)
+		go c.scheduleDriverRecovery(cctx, cfg.Query.Interval.Duration)
+	}
+	if cfg.BugReport != nil {
+		cfg.BugReport.SetDefaultsIfNotSet()
+		if err := nvidia_query_bug_report.CreateTableBugReports(ctx, c.db); err != nil {
+			log.Logger.Warnw("failed to create bug reports table -- disabling bug report collection", "error", err)
+		} else {
+			c.bugReport = cfg.BugReport
+			go c.scheduleBugReports(cctx, cfg.Query.Interval.Duration)
+		}
+	}
+	return c
 }
 
 var _ components.Component = (*component)(nil)
@@ -42,12 +65,65 @@ type component struct {
 	cancel  context.CancelFunc
 	poller  query.Poller
 	db      *sql.DB
+
+	dbePolicy DBEPolicy
+
+	recoverer   *driverRecoverer
+	incidentsMu sync.RWMutex
+	incidents   []*DriverRecoveryIncident
+
+	bugReport *BugReportPolicy
 }
 
 func (c *component) Name() string { return nvidia_error_xid_sxid_id.Name }
 
+// States runs the double-bit ECC error workflow against the recent Xid events
+// and the latest NVML device information.
 func (c *component) States(ctx context.Context) ([]components.State, error) {
-	return nil, nil
+	since := time.Now().UTC().Add(-c.dbePolicy.LookbackPeriod.Duration)
+	events, err := nvidia_xid_sxid_state.ReadEvents(ctx, c.db, nvidia_xid_sxid_state.WithSince(since))
+	if err != nil {
+		return nil, err
+	}
+
+	var devs []*nvidia_query_nvml.DeviceInfo
+	last, err := c.poller.Last()
+	if err != nil && err != query.ErrNoData {
+		return nil, err
+	}
+	if last != nil && last.Error == nil && last.Output != nil {
+		allOutput, ok := last.Output.(*nvidia_query.Output)
+		if !ok {
+			return nil, fmt.Errorf("invalid output type: %T", last.Output)
+		}
+		if allOutput.NVML != nil {
+			devs = allOutput.NVML.DeviceInfos
+		}
+	}
+
+	decision := EvaluateDBEWorkflow(events, devs, c.dbePolicy)
+	if decision != nil && len(decision.AffectedGPUs) > 0 {
+		as, err := state.ReadGPUAnnotations(ctx, c.db, "")
+		if err != nil {
+			log.Logger.Warnw("failed to read gpu annotations", "error", err)
+		} else {
+			decision.ApplyGPUAnnotations(state.GPUAnnotationsByUUID(as))
+		}
+	}
+	state, err := decision.State()
+	if err != nil {
+		return nil, err
+	}
+	states := []components.State{state}
+
+	if c.recoverer != nil {
+		recoveryState, err := c.lastIncident().State()
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, recoveryState)
+	}
+	return states, nil
 }
 
 const (
@@ -58,6 +134,10 @@ const (
 	EventKeyData           = "data"
 	EventKeyEncoding       = "encoding"
 	EventValueEncodingJSON = "json"
+
+	// set only if the identical events were merged into one event
+	EventKeyOccurrences     = "occurrences"
+	EventKeyLastUnixSeconds = "last_unix_seconds"
 )
 
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
@@ -68,21 +148,33 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 
 	if len(events) == 0 {
 		log.Logger.Debugw("no event found", "component", c.Name(), "since", humanize.Time(since))
-		return nil, nil
+		return c.incidentEvents(since)
 	}
 
 	log.Logger.Debugw("found events", "component", c.Name(), "since", humanize.Time(since), "count", len(events))
-	convertedEvents := make([]components.Event, 0, len(events))
+	convertedEvents, err := c.incidentEvents(since)
+	if err != nil {
+		return nil, err
+	}
+	fallenOffBus, err := c.fallenOffBusEvents(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	convertedEvents = append(convertedEvents, fallenOffBus...)
+	bugReports, err := c.bugReportPaths(ctx, since)
+	if err != nil {
+		return nil, err
+	}
 	for _, event := range events {
 		if xidDetail := event.ToXidDetail(); xidDetail != nil {
 			msg := fmt.Sprintf("xid %d detected by %s (%s)",
 				event.EventID,
 				event.DataSource,
-				humanize.Time(time.Unix(event.UnixSeconds, 0)),
+				locale.Time(time.Unix(event.UnixSeconds, 0)),
 			)
 			xidBytes, _ := xidDetail.JSON()
 
-			convertedEvents = append(convertedEvents, components.Event{
+			ev := components.Event{
 				Time:    metav1.Time{Time: time.Unix(event.UnixSeconds, 0)},
 				Name:    EventNameErroXid,
 				Message: msg,
@@ -91,7 +183,12 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 					EventKeyData:        string(xidBytes),
 					EventKeyEncoding:    EventValueEncodingJSON,
 				},
-			})
+			}
+			if p, ok := bugReports[nvidia_query_bug_report.EventKey(event.UnixSeconds, event.EventType, event.EventID)]; ok {
+				ev.ExtraInfo[EventKeyBugReport] = p
+			}
+			setOccurrences(&ev, event)
+			convertedEvents = append(convertedEvents, ev)
 			continue
 		}
 
@@ -99,11 +196,11 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 			msg := fmt.Sprintf("sxid %d detected by %s (%s)",
 				event.EventID,
 				event.DataSource,
-				humanize.Time(time.Unix(event.UnixSeconds, 0)),
+				locale.Time(time.Unix(event.UnixSeconds, 0)),
 			)
 			sxidBytes, _ := sxidDetail.JSON()
 
-			convertedEvents = append(convertedEvents, components.Event{
+			ev := components.Event{
 				Time:    metav1.Time{Time: time.Unix(event.UnixSeconds, 0)},
 				Name:    EventNameErroSXid,
 				Message: msg,
@@ -112,13 +209,29 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 					EventKeyData:        string(sxidBytes),
 					EventKeyEncoding:    EventValueEncodingJSON,
 				},
-			})
+			}
+			if p, ok := bugReports[nvidia_query_bug_report.EventKey(event.UnixSeconds, event.EventType, event.EventID)]; ok {
+				ev.ExtraInfo[EventKeyBugReport] = p
+			}
+			setOccurrences(&ev, event)
+			convertedEvents = append(convertedEvents, ev)
 			continue
 		}
 	}
 	return convertedEvents, nil
 }
 
+// setOccurrences annotates the event merged from the repeated lines
+// with the occurrence counter and the last observed timestamp.
+func setOccurrences(ev *components.Event, event nvidia_xid_sxid_state.Event) {
+	if event.Occurrences <= 1 {
+		return
+	}
+	ev.Message += fmt.Sprintf(", repeated %d times until %s", event.Occurrences, locale.Time(time.Unix(event.LastUnixSeconds, 0)))
+	ev.ExtraInfo[EventKeyOccurrences] = strconv.FormatInt(event.Occurrences, 10)
+	ev.ExtraInfo[EventKeyLastUnixSeconds] = strconv.FormatInt(event.LastUnixSeconds, 10)
+}
+
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
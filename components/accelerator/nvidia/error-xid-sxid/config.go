@@ -3,12 +3,25 @@ package errorxidsxid
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 
 	query_config "github.com/leptonai/gpud/components/query/config"
 )
 
 type Config struct {
 	Query query_config.Config `json:"query"`
+
+	// DBEPolicy defines how the double-bit ECC error workflow schedules its actions.
+	DBEPolicy DBEPolicy `json:"dbe_policy"`
+
+	// DriverRecovery defines the recovery sequence when NVML is unresponsive
+	// and Xid 119/120 is observed (nvidia-smi triage, module reload, then reboot).
+	// Leave empty to disable the automatic driver recovery.
+	DriverRecovery *DriverRecoveryPolicy `json:"driver_recovery,omitempty"`
+
+	// BugReport defines when "nvidia-bug-report.sh" is collected on the fatal Xid/SXid errors.
+	// Leave empty to disable the automatic bug report collection.
+	BugReport *BugReportPolicy `json:"bug_report,omitempty"`
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -28,5 +41,21 @@ func ParseConfig(b any, db *sql.DB) (*Config, error) {
 }
 
 func (cfg Config) Validate() error {
+	if cfg.DriverRecovery != nil {
+		if cfg.DriverRecovery.Cooldown.Duration < 0 {
+			return errors.New("driver recovery cooldown must be non-negative")
+		}
+		if cfg.DriverRecovery.TriageTimeout.Duration < 0 {
+			return errors.New("driver recovery triage timeout must be non-negative")
+		}
+	}
+	if cfg.BugReport != nil {
+		if cfg.BugReport.MinInterval.Duration < 0 {
+			return errors.New("bug report min interval must be non-negative")
+		}
+		if cfg.BugReport.Timeout.Duration < 0 {
+			return errors.New("bug report timeout must be non-negative")
+		}
+	}
 	return nil
 }
@@ -0,0 +1,341 @@
+package errorxidsxid
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_error_xid_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error-xid-sxid/id"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+	"github.com/leptonai/gpud/components/common"
+	"github.com/leptonai/gpud/components/state"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Xids related to the double-bit ECC (DBE) errors.
+// ref. https://docs.nvidia.com/deploy/gpu-debug-guidelines/index.html#xid-messages
+// ref. https://docs.nvidia.com/deploy/a100-gpu-mem-error-mgmt/index.html
+const (
+	// "Double Bit ECC Error"
+	XidDBE = 48
+	// "ECC page retirement or row remapping recording event"
+	XidRowRemappingRecorded = 63
+	// "ECC page retirement or row remapper recording failure"
+	XidRowRemappingFailed = 64
+)
+
+// IsDBERelatedXid returns true if the Xid is part of the double-bit ECC error workflow.
+func IsDBERelatedXid(xid int) bool {
+	return xid == XidDBE || xid == XidRowRemappingRecorded || xid == XidRowRemappingFailed
+}
+
+// DefaultDBELookbackPeriod is the default period to look back for the DBE related Xid events.
+const DefaultDBELookbackPeriod = 24 * time.Hour
+
+// DBEPolicy defines how the double-bit ECC error workflow schedules its actions.
+type DBEPolicy struct {
+	// Set true to prefer the GPU reset over the system reboot,
+	// when the GPU reset is sufficient to activate the pending row remapping.
+	AllowGPUReset bool `json:"allow_gpu_reset"`
+	// Set true to schedule the action only after all the processes
+	// on the affected GPUs have exited (drained).
+	WaitForDrain bool `json:"wait_for_drain"`
+	// Period to look back for the DBE related Xid events.
+	LookbackPeriod metav1.Duration `json:"lookback_period"`
+}
+
+func (p *DBEPolicy) SetDefaultsIfNotSet() {
+	if p.LookbackPeriod.Duration == 0 {
+		p.LookbackPeriod = metav1.Duration{Duration: DefaultDBELookbackPeriod}
+	}
+}
+
+// DBEAction is the action decided by the double-bit ECC error workflow.
+type DBEAction string
+
+const (
+	DBEActionNone         DBEAction = "none"
+	DBEActionResetGPU     DBEAction = "reset_gpu"
+	DBEActionRebootSystem DBEAction = "reboot_system"
+	DBEActionRMA          DBEAction = "rma"
+)
+
+// severity is used to pick the most severe action across the GPUs.
+func (a DBEAction) severity() int {
+	switch a {
+	case DBEActionResetGPU:
+		return 1
+	case DBEActionRebootSystem:
+		return 2
+	case DBEActionRMA:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// DBEDecision is the result of the double-bit ECC error workflow.
+type DBEDecision struct {
+	// Xids are the DBE related Xids observed within the lookback period.
+	Xids []int `json:"xids"`
+	// LastXidTime is the time of the latest DBE related Xid event.
+	LastXidTime metav1.Time `json:"last_xid_time"`
+
+	// Action is the most severe action across all the affected GPUs.
+	Action DBEAction `json:"action"`
+	// AffectedGPUs is the list of GPU UUIDs that require the action.
+	AffectedGPUs []string `json:"affected_gpus"`
+	Reasons      []string `json:"reasons"`
+
+	// Scheduled is true if the action is ready to be executed per the policy.
+	// False when the policy requires to wait for the GPU processes to drain.
+	Scheduled bool `json:"scheduled"`
+	// Verified is true if the post-action ECC state shows that the action
+	// has taken effect (e.g., no volatile uncorrectable errors, no pending row remapping).
+	Verified bool `json:"verified"`
+
+	// SuspectGPUs is the list of affected GPU UUIDs annotated as "suspect" by the operator.
+	// The suspect GPUs are never reset (or rebooted) automatically, only paged.
+	SuspectGPUs []string `json:"suspect_gpus,omitempty"`
+}
+
+// DecideDBEAction decides the action for a single GPU, based on the DBE related Xids
+// and the remapped rows state of the GPU.
+// ref. https://docs.nvidia.com/deploy/a100-gpu-mem-error-mgmt/index.html#row-remapping
+func DecideDBEAction(xids []int, rr nvidia_query_nvml.RemappedRows, policy DBEPolicy) (DBEAction, string) {
+	has := make(map[int]bool)
+	for _, x := range xids {
+		has[x] = true
+	}
+
+	// "the RMA criteria is met when the row-remapping failure flag is set"
+	if rr.QualifiesForRMA() {
+		return DBEActionRMA, fmt.Sprintf("row remapping failed with %d uncorrectable rows remapped (qualifies for RMA)", rr.RemappedDueToUncorrectableErrors)
+	}
+	if has[XidRowRemappingFailed] && rr.RemappingFailed {
+		return DBEActionRMA, "xid 64 with row remapping failure (requires hardware inspection)"
+	}
+
+	resetOrReboot := DBEActionRebootSystem
+	if policy.AllowGPUReset {
+		resetOrReboot = DBEActionResetGPU
+	}
+
+	// "A reset will be required to actually remap the row"
+	if rr.RequiresReset() {
+		return resetOrReboot, "row remapping pending (requires GPU reset or system reboot)"
+	}
+
+	// "If Xid 48 is followed by Xid 63 or 64: Drain/cordon the node, wait for all work to complete, and reset GPU(s)"
+	if has[XidDBE] && (has[XidRowRemappingRecorded] || has[XidRowRemappingFailed]) {
+		return resetOrReboot, "xid 48 followed by row remapping event (requires GPU reset or system reboot)"
+	}
+
+	// "A GPU reset or node reboot is needed to clear this error"
+	if has[XidDBE] {
+		return DBEActionRebootSystem, "xid 48 without row remapping event (requires system reboot)"
+	}
+
+	return DBEActionNone, "no action required"
+}
+
+// VerifyDBEAction returns true if the post-action ECC state shows the action has taken effect.
+// Volatile ECC counts are reset each time the driver loads (e.g., GPU reset, system reboot),
+// so no volatile uncorrectable error and no pending row remapping indicate the recovery.
+func VerifyDBEAction(eccErrs nvidia_query_nvml.ECCErrors, rr nvidia_query_nvml.RemappedRows) (bool, string) {
+	if errs := eccErrs.Volatile.FindUncorrectedErrs(); len(errs) > 0 {
+		return false, fmt.Sprintf("volatile uncorrectable errors still present (%s)", strings.Join(errs, ", "))
+	}
+	if rr.RequiresReset() {
+		return false, "row remapping still pending"
+	}
+	return true, "no volatile uncorrectable error and no pending row remapping"
+}
+
+// EvaluateDBEWorkflow runs the double-bit ECC error workflow against the Xid events
+// and the latest NVML device information.
+// Each GPU is evaluated with its own Xids (resolved from the event details),
+// plus the Xids whose GPU is unknown (e.g., the dmesg line without the PCI bus ID).
+// Returns nil if there is no DBE related Xid event.
+func EvaluateDBEWorkflow(events []nvidia_xid_sxid_state.Event, devs []*nvidia_query_nvml.DeviceInfo, policy DBEPolicy) *DBEDecision {
+	busIDs := make(map[uint32]string, len(devs))
+	for _, dev := range devs {
+		busIDs[dev.BusID] = dev.UUID
+	}
+
+	xidsSeen := make(map[int]struct{})
+	// Xids by the GPU UUID, empty key for the Xids whose GPU is unknown
+	xidsByGPU := make(map[string][]int)
+	var lastXidTime time.Time
+	for _, ev := range events {
+		if ev.EventType != nvidia_xid_sxid_state.EventTypeXid || !IsDBERelatedXid(int(ev.EventID)) {
+			continue
+		}
+		xidsSeen[int(ev.EventID)] = struct{}{}
+		uuid := ev.GPUUUID(busIDs)
+		xidsByGPU[uuid] = append(xidsByGPU[uuid], int(ev.EventID))
+		if t := time.Unix(ev.UnixSeconds, 0); t.After(lastXidTime) {
+			lastXidTime = t
+		}
+	}
+	if len(xidsSeen) == 0 {
+		return nil
+	}
+
+	d := &DBEDecision{
+		LastXidTime: metav1.Time{Time: lastXidTime.UTC()},
+		Action:      DBEActionNone,
+		Scheduled:   true,
+		Verified:    true,
+	}
+	for x := range xidsSeen {
+		d.Xids = append(d.Xids, x)
+	}
+	sort.Ints(d.Xids)
+
+	// cannot verify the post-action ECC state without the device information
+	if len(devs) == 0 {
+		d.Action = DBEActionRebootSystem
+		d.Verified = false
+		d.Reasons = append(d.Reasons, "no NVML device information available to check the row remapping and ECC state")
+		return d
+	}
+
+	for _, dev := range devs {
+		xids := append(append([]int{}, xidsByGPU[""]...), xidsByGPU[dev.UUID]...)
+		if len(xids) == 0 {
+			continue
+		}
+		action, reason := DecideDBEAction(xids, dev.RemappedRows, policy)
+		verified, verifyReason := VerifyDBEAction(dev.ECCErrors, dev.RemappedRows)
+
+		// the GPU does not need any action if the ECC state is already recovered,
+		// unless it qualifies for RMA (which requires hardware replacement)
+		if action == DBEActionNone || (verified && action != DBEActionRMA) {
+			continue
+		}
+
+		d.AffectedGPUs = append(d.AffectedGPUs, dev.UUID)
+		d.Reasons = append(d.Reasons, fmt.Sprintf("%s: %s, %s", dev.UUID, reason, verifyReason))
+		d.Verified = false
+
+		if action.severity() > d.Action.severity() {
+			d.Action = action
+		}
+		if policy.WaitForDrain && len(dev.Processes.RunningProcesses) > 0 {
+			d.Scheduled = false
+			d.Reasons = append(d.Reasons, fmt.Sprintf("%s: waiting for %d running process(es) to drain", dev.UUID, len(dev.Processes.RunningProcesses)))
+		}
+	}
+
+	return d
+}
+
+// ApplyGPUAnnotations records the affected GPUs annotated as "suspect",
+// which makes the decision page the operator instead of the automated reset or reboot.
+func (d *DBEDecision) ApplyGPUAnnotations(gpus state.GPUAnnotations) {
+	if d == nil {
+		return
+	}
+	for _, uuid := range d.AffectedGPUs {
+		if gpus.IsSuspect(uuid) {
+			d.SuspectGPUs = append(d.SuspectGPUs, uuid)
+		}
+	}
+}
+
+// pageOnly returns true if the action would reset a suspect GPU.
+func (d *DBEDecision) pageOnly() bool {
+	return len(d.SuspectGPUs) > 0 && (d.Action == DBEActionResetGPU || d.Action == DBEActionRebootSystem)
+}
+
+// SuggestedActions converts the decision to the suggested actions.
+// Returns nil if no action is required or the action is not yet scheduled per policy.
+func (d *DBEDecision) SuggestedActions() *common.SuggestedActions {
+	if d == nil || d.Verified || !d.Scheduled {
+		return nil
+	}
+
+	if d.pageOnly() {
+		return &common.SuggestedActions{
+			Descriptions:  []string{fmt.Sprintf("GPU(s) %s annotated as suspect, page the operator instead of the automated %q", strings.Join(d.SuspectGPUs, ", "), d.Action)},
+			RepairActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+		}
+	}
+
+	switch d.Action {
+	case DBEActionResetGPU:
+		return &common.SuggestedActions{
+			Descriptions:  []string{fmt.Sprintf("reset GPU(s) %s to activate the row remapping (reboot the system if the GPU reset is not supported)", strings.Join(d.AffectedGPUs, ", "))},
+			RepairActions: []common.RepairActionType{common.RepairActionTypeResetGPU},
+		}
+	case DBEActionRebootSystem:
+		return &common.SuggestedActions{
+			Descriptions:  []string{fmt.Sprintf("reboot the system to clear the double-bit ECC errors on GPU(s) %s", strings.Join(d.AffectedGPUs, ", "))},
+			RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+		}
+	case DBEActionRMA:
+		return &common.SuggestedActions{
+			Descriptions:  []string{fmt.Sprintf("GPU(s) %s qualify for RMA", strings.Join(d.AffectedGPUs, ", "))},
+			RepairActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+		}
+	}
+	return nil
+}
+
+const (
+	StateNameDBEWorkflow = "dbe_workflow"
+
+	StateKeyDBEWorkflowData           = "data"
+	StateKeyDBEWorkflowEncoding       = "encoding"
+	StateValueDBEWorkflowEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(nvidia_error_xid_sxid_id.Name,
+		components.JSONStateSchema(StateNameDBEWorkflow, "double-bit ECC error workflow status"),
+	)
+}
+
+// State converts the decision to the component state.
+func (d *DBEDecision) State() (components.State, error) {
+	if d == nil {
+		return components.State{
+			Name:    StateNameDBEWorkflow,
+			Healthy: true,
+			Reason:  "no double-bit ECC related xid event",
+		}, nil
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return components.State{}, err
+	}
+
+	state := components.State{
+		Name:    StateNameDBEWorkflow,
+		Healthy: d.Verified,
+		ExtraInfo: map[string]string{
+			StateKeyDBEWorkflowData:     string(b),
+			StateKeyDBEWorkflowEncoding: StateValueDBEWorkflowEncodingJSON,
+		},
+		SuggestedActions: d.SuggestedActions(),
+	}
+
+	switch {
+	case d.Verified:
+		state.Reason = fmt.Sprintf("xids %v recovered (verified post-action ECC state)", d.Xids)
+	case d.pageOnly():
+		state.Reason = fmt.Sprintf("xids %v require %q on suspect GPU(s) %s (paging only): %s", d.Xids, d.Action, strings.Join(d.SuspectGPUs, ", "), strings.Join(d.Reasons, "; "))
+	case !d.Scheduled:
+		state.Reason = fmt.Sprintf("xids %v require %q (pending drain): %s", d.Xids, d.Action, strings.Join(d.Reasons, "; "))
+	default:
+		state.Reason = fmt.Sprintf("xids %v require %q: %s", d.Xids, d.Action, strings.Join(d.Reasons, "; "))
+	}
+	return state, nil
+}
@@ -0,0 +1,239 @@
+package errorxidsxid
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+	"github.com/leptonai/gpud/components/common"
+	"github.com/leptonai/gpud/components/state"
+)
+
+func TestDecideDBEAction(t *testing.T) {
+	tests := []struct {
+		name   string
+		xids   []int
+		rr     nvidia_query_nvml.RemappedRows
+		policy DBEPolicy
+		want   DBEAction
+	}{
+		{
+			name: "no dbe xid",
+			xids: nil,
+			want: DBEActionNone,
+		},
+		{
+			name: "xid 48 only",
+			xids: []int{48},
+			want: DBEActionRebootSystem,
+		},
+		{
+			name: "xid 48 followed by 63 without gpu reset",
+			xids: []int{48, 63},
+			want: DBEActionRebootSystem,
+		},
+		{
+			name:   "xid 48 followed by 63 with gpu reset",
+			xids:   []int{48, 63},
+			policy: DBEPolicy{AllowGPUReset: true},
+			want:   DBEActionResetGPU,
+		},
+		{
+			name:   "remapping pending",
+			xids:   []int{63},
+			rr:     nvidia_query_nvml.RemappedRows{RemappingPending: true},
+			policy: DBEPolicy{AllowGPUReset: true},
+			want:   DBEActionResetGPU,
+		},
+		{
+			name: "xid 64 with remapping failure",
+			xids: []int{64},
+			rr:   nvidia_query_nvml.RemappedRows{RemappingFailed: true},
+			want: DBEActionRMA,
+		},
+		{
+			name: "qualifies for rma",
+			xids: []int{48},
+			rr:   nvidia_query_nvml.RemappedRows{RemappingFailed: true, RemappedDueToUncorrectableErrors: 8},
+			want: DBEActionRMA,
+		},
+		{
+			name: "xid 63 only without pending remapping",
+			xids: []int{63},
+			want: DBEActionNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := DecideDBEAction(tt.xids, tt.rr, tt.policy)
+			if got != tt.want {
+				t.Errorf("DecideDBEAction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateDBEWorkflow(t *testing.T) {
+	events := []nvidia_xid_sxid_state.Event{
+		{UnixSeconds: 100, DataSource: "dmesg", EventType: "xid", EventID: 48},
+		{UnixSeconds: 200, DataSource: "dmesg", EventType: "xid", EventID: 63},
+		{UnixSeconds: 300, DataSource: "dmesg", EventType: "xid", EventID: 79},
+	}
+
+	if d := EvaluateDBEWorkflow(events[2:], nil, DBEPolicy{}); d != nil {
+		t.Fatalf("expected nil decision for non-DBE xids, got %+v", d)
+	}
+
+	pendingDev := &nvidia_query_nvml.DeviceInfo{
+		UUID:         "GPU-0",
+		RemappedRows: nvidia_query_nvml.RemappedRows{UUID: "GPU-0", RemappingPending: true},
+		Processes: nvidia_query_nvml.Processes{
+			UUID:             "GPU-0",
+			RunningProcesses: []nvidia_query_nvml.Process{{PID: 1}},
+		},
+	}
+	healthyDev := &nvidia_query_nvml.DeviceInfo{UUID: "GPU-1"}
+
+	d := EvaluateDBEWorkflow(events, []*nvidia_query_nvml.DeviceInfo{pendingDev, healthyDev}, DBEPolicy{AllowGPUReset: true})
+	if d == nil {
+		t.Fatal("expected non-nil decision")
+	}
+	if d.Action != DBEActionResetGPU {
+		t.Errorf("expected action %q, got %q", DBEActionResetGPU, d.Action)
+	}
+	if len(d.AffectedGPUs) != 1 || d.AffectedGPUs[0] != "GPU-0" {
+		t.Errorf("unexpected affected GPUs %v", d.AffectedGPUs)
+	}
+	if d.Verified || !d.Scheduled {
+		t.Errorf("expected unverified and scheduled decision, got %+v", d)
+	}
+	if d.LastXidTime.Unix() != 200 {
+		t.Errorf("expected last xid time 200, got %d", d.LastXidTime.Unix())
+	}
+	if acts := d.SuggestedActions(); acts == nil || acts.RepairActions[0] != common.RepairActionTypeResetGPU {
+		t.Errorf("expected the gpu reset suggested, got %+v", acts)
+	}
+
+	d = EvaluateDBEWorkflow(events, []*nvidia_query_nvml.DeviceInfo{pendingDev}, DBEPolicy{WaitForDrain: true})
+	if d.Scheduled {
+		t.Error("expected the action not scheduled while processes are running")
+	}
+	if d.SuggestedActions() != nil {
+		t.Error("expected no suggested actions before drain")
+	}
+
+	// post-action: volatile counters and pending remapping are cleared
+	d = EvaluateDBEWorkflow(events, []*nvidia_query_nvml.DeviceInfo{healthyDev}, DBEPolicy{})
+	if !d.Verified {
+		t.Errorf("expected verified decision, got %+v", d)
+	}
+	state, err := d.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Healthy {
+		t.Errorf("expected healthy state, got %+v", state)
+	}
+}
+
+func TestEvaluateDBEWorkflowPerGPU(t *testing.T) {
+	// volatile uncorrectable errors on both GPUs, but the DBE Xids only on GPU-0
+	uncorrected := nvidia_query_nvml.ECCErrors{Volatile: nvidia_query_nvml.AllECCErrorCounts{Total: nvidia_query_nvml.ECCErrorCounts{Uncorrected: 1}}}
+	devs := []*nvidia_query_nvml.DeviceInfo{
+		{UUID: "GPU-0", BusID: 0x05, ECCErrors: uncorrected},
+		{UUID: "GPU-1", BusID: 0x06, ECCErrors: uncorrected},
+	}
+
+	tests := []struct {
+		name   string
+		events []nvidia_xid_sxid_state.Event
+		want   []string
+	}{
+		{
+			name: "nvml events",
+			events: []nvidia_xid_sxid_state.Event{
+				{UnixSeconds: 100, DataSource: "nvml", EventType: nvidia_xid_sxid_state.EventTypeXid, EventID: 48, EventDetails: "GPU-0"},
+			},
+			want: []string{"GPU-0"},
+		},
+		{
+			name: "dmesg events with the pci bus id",
+			events: []nvidia_xid_sxid_state.Event{
+				{UnixSeconds: 100, DataSource: "dmesg", EventType: nvidia_xid_sxid_state.EventTypeXid, EventID: 48, EventDetails: "NVRM: Xid (PCI:0000:05:00): 48, pid=1234, name=python, An uncorrectable double bit error (DBE) has been detected on GPU in the framebuffer at partition 0, subpartition 0."},
+			},
+			want: []string{"GPU-0"},
+		},
+		{
+			name: "dmesg events without the gpu",
+			events: []nvidia_xid_sxid_state.Event{
+				{UnixSeconds: 100, DataSource: "dmesg", EventType: nvidia_xid_sxid_state.EventTypeXid, EventID: 48},
+			},
+			want: []string{"GPU-0", "GPU-1"},
+		},
+		{
+			name: "sxid events are ignored",
+			events: []nvidia_xid_sxid_state.Event{
+				{UnixSeconds: 100, DataSource: "dmesg", EventType: nvidia_xid_sxid_state.EventTypeSXid, EventID: 48},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := EvaluateDBEWorkflow(tt.events, devs, DBEPolicy{})
+			if tt.want == nil {
+				if d != nil {
+					t.Fatalf("expected nil decision, got %+v", d)
+				}
+				return
+			}
+			if d == nil {
+				t.Fatal("expected non-nil decision")
+			}
+			if !reflect.DeepEqual(d.AffectedGPUs, tt.want) {
+				t.Errorf("expected affected GPUs %v, got %v", tt.want, d.AffectedGPUs)
+			}
+			if d.Action != DBEActionRebootSystem {
+				t.Errorf("expected action %q, got %q", DBEActionRebootSystem, d.Action)
+			}
+		})
+	}
+}
+
+func TestDBEDecisionSuspectGPUs(t *testing.T) {
+	events := []nvidia_xid_sxid_state.Event{
+		{UnixSeconds: 100, DataSource: "dmesg", EventType: "xid", EventID: 48},
+		{UnixSeconds: 200, DataSource: "dmesg", EventType: "xid", EventID: 63},
+	}
+	devs := []*nvidia_query_nvml.DeviceInfo{
+		{UUID: "GPU-0", RemappedRows: nvidia_query_nvml.RemappedRows{UUID: "GPU-0", RemappingPending: true}},
+	}
+
+	d := EvaluateDBEWorkflow(events, devs, DBEPolicy{AllowGPUReset: true})
+	d.ApplyGPUAnnotations(state.GPUAnnotations{"GPU-1": {state.GPUAnnotationKeySuspect: "true"}})
+	if len(d.SuspectGPUs) != 0 {
+		t.Fatalf("unexpected suspect GPUs %v", d.SuspectGPUs)
+	}
+	if acts := d.SuggestedActions(); acts == nil || acts.RepairActions[0] != common.RepairActionTypeResetGPU {
+		t.Fatalf("expected the reset suggested, got %+v", acts)
+	}
+
+	d.ApplyGPUAnnotations(state.GPUAnnotations{"GPU-0": {state.GPUAnnotationKeySuspect: "OPS-1"}})
+	if !reflect.DeepEqual(d.SuspectGPUs, []string{"GPU-0"}) {
+		t.Fatalf("unexpected suspect GPUs %v", d.SuspectGPUs)
+	}
+	acts := d.SuggestedActions()
+	if acts == nil || acts.RepairActions[0] != common.RepairActionTypeHardwareInspection {
+		t.Fatalf("expected the suspect GPU paged instead of reset, got %+v", acts)
+	}
+	st, err := d.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Healthy || !strings.Contains(st.Reason, "paging only") {
+		t.Errorf("unexpected state %+v", st)
+	}
+}
@@ -0,0 +1,560 @@
+package errorxidsxid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_error_xid_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error-xid-sxid/id"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+	"github.com/leptonai/gpud/components/common"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/execlimit"
+	"github.com/leptonai/gpud/pkg/reboot"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Xids related to the GSP (GPU System Processor) failures,
+// which leave the driver (and NVML) unresponsive.
+// ref. https://docs.nvidia.com/deploy/xid-errors/index.html#xid-119-120-gsp-rpc-timeout-gsp-error
+const (
+	// "GSP RPC Timeout"
+	XidGSPRPCTimeout = 119
+	// "GSP Error"
+	XidGSPError = 120
+)
+
+// IsGSPXid returns true if the Xid is part of the driver recovery sequence.
+func IsGSPXid(xid int) bool {
+	return xid == XidGSPRPCTimeout || xid == XidGSPError
+}
+
+const (
+	DefaultDriverRecoveryCooldown      = 30 * time.Minute
+	DefaultDriverRecoveryTriageTimeout = time.Minute
+)
+
+// DriverRecoveryPolicy defines how the driver recovery sequence runs
+// when NVML is unresponsive and Xid 119/120 is observed.
+type DriverRecoveryPolicy struct {
+	// Set true to reboot the system when the module reload fails.
+	// Otherwise, the reboot is only suggested.
+	AllowReboot bool `json:"allow_reboot"`
+	// Cooldown is the minimum interval between two recovery sequences,
+	// which prevents the reboot loops when the GPU keeps failing.
+	Cooldown metav1.Duration `json:"cooldown"`
+	// TriageTimeout is the timeout of the "nvidia-smi" triage, which hangs on a stuck driver.
+	TriageTimeout metav1.Duration `json:"triage_timeout"`
+}
+
+func (p *DriverRecoveryPolicy) SetDefaultsIfNotSet() {
+	if p.Cooldown.Duration == 0 {
+		p.Cooldown = metav1.Duration{Duration: DefaultDriverRecoveryCooldown}
+	}
+	if p.TriageTimeout.Duration == 0 {
+		p.TriageTimeout = metav1.Duration{Duration: DefaultDriverRecoveryTriageTimeout}
+	}
+}
+
+// DriverRecoveryStep is a step of the driver recovery sequence.
+type DriverRecoveryStep string
+
+const (
+	DriverRecoveryStepTriage       DriverRecoveryStep = "nvidia_smi_triage"
+	DriverRecoveryStepModuleReload DriverRecoveryStep = "module_reload"
+	DriverRecoveryStepReboot       DriverRecoveryStep = "reboot"
+)
+
+// DriverRecoveryOutcome is the outcome of the driver recovery sequence.
+type DriverRecoveryOutcome string
+
+const (
+	// The driver responds to "nvidia-smi" (after the triage or the module reload).
+	DriverRecoveryOutcomeRecovered DriverRecoveryOutcome = "recovered"
+	// The module reload is skipped as the processes still hold the GPU devices.
+	DriverRecoveryOutcomeBlocked DriverRecoveryOutcome = "blocked"
+	// The module reload failed and the reboot is not allowed per policy (or failed).
+	DriverRecoveryOutcomeRebootRequired DriverRecoveryOutcome = "reboot_required"
+	// The module reload failed and the system reboot is triggered.
+	DriverRecoveryOutcomeRebooting DriverRecoveryOutcome = "rebooting"
+	// The system rebooted after the reboot escalation.
+	// A new incident is started if NVML is still unresponsive with the new Xids.
+	DriverRecoveryOutcomeRebooted DriverRecoveryOutcome = "rebooted"
+)
+
+// DriverRecoveryStepResult is the result of a single step.
+type DriverRecoveryStepResult struct {
+	Step    DriverRecoveryStep `json:"step"`
+	Time    metav1.Time        `json:"time"`
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+}
+
+// DriverRecoveryIncident records a single run of the driver recovery sequence.
+type DriverRecoveryIncident struct {
+	StartTime metav1.Time `json:"start_time"`
+	EndTime   metav1.Time `json:"end_time"`
+
+	// Xids are the GSP related Xids that triggered the sequence.
+	Xids []int `json:"xids"`
+	// NVMLErrors are the NVML errors that triggered the sequence.
+	NVMLErrors []string `json:"nvml_errors"`
+
+	Steps   []DriverRecoveryStepResult `json:"steps"`
+	Outcome DriverRecoveryOutcome      `json:"outcome"`
+}
+
+// FindGSPXids returns the sorted GSP related Xids in the events.
+func FindGSPXids(events []nvidia_xid_sxid_state.Event) []int {
+	seen := make(map[int]struct{})
+	for _, ev := range events {
+		if ev.EventType != nvidia_xid_sxid_state.EventTypeXid || !IsGSPXid(int(ev.EventID)) {
+			continue
+		}
+		seen[int(ev.EventID)] = struct{}{}
+	}
+	xids := make([]int, 0, len(seen))
+	for x := range seen {
+		xids = append(xids, x)
+	}
+	sort.Ints(xids)
+	return xids
+}
+
+// driverRecoverer runs the driver recovery sequence.
+// The steps are overridable for testing.
+type driverRecoverer struct {
+	policy DriverRecoveryPolicy
+
+	triage      func(ctx context.Context) (string, error)
+	findHolders func() ([]deviceHolder, error)
+
+	// stops and starts the NVIDIA daemons holding the GPU devices around the module reload
+	stopServices  func(ctx context.Context, units []string) error
+	startServices func(ctx context.Context, units []string) error
+
+	// releases and restores the NVML session of gpud itself around the module reload
+	suspendNVML func() error
+	resumeNVML  func() error
+
+	reloadModules func(ctx context.Context) (string, error)
+	reboot        func(ctx context.Context) error
+}
+
+func newDriverRecoverer(policy DriverRecoveryPolicy) *driverRecoverer {
+	return &driverRecoverer{
+		policy:        policy,
+		triage:        runNvidiaSMI,
+		findHolders:   func() ([]deviceHolder, error) { return findDeviceHolders("/proc", os.Getpid()) },
+		stopServices:  func(ctx context.Context, units []string) error { return runSystemctl(ctx, "stop", units) },
+		startServices: func(ctx context.Context, units []string) error { return runSystemctl(ctx, "start", units) },
+		suspendNVML:   nvidia_query_nvml.SuspendDefaultInstance,
+		resumeNVML:    nvidia_query_nvml.ReinitDefaultInstance,
+		reloadModules: func(ctx context.Context) (string, error) { return reloadNvidiaModules(ctx, "/proc/modules") },
+		reboot: func(ctx context.Context) error {
+			// delay to persist the incident and to report the state
+			return reboot.Reboot(ctx, reboot.WithDelaySeconds(10))
+		},
+	}
+}
+
+// run runs the driver recovery sequence:
+// 1. "nvidia-smi" triage, done if the driver responds
+// 2. module reload, only when no process other than the known NVIDIA daemons holds the GPU devices
+// (the daemons are stopped during the reload, and gpud releases its own NVML session)
+// 3. system reboot, only when the module reload fails (and allowed per policy)
+func (r *driverRecoverer) run(ctx context.Context, xids []int, nvmlErrs []string) *DriverRecoveryIncident {
+	inc := &DriverRecoveryIncident{
+		StartTime:  metav1.Time{Time: time.Now().UTC()},
+		Xids:       xids,
+		NVMLErrors: nvmlErrs,
+	}
+	defer func() {
+		inc.EndTime = metav1.Time{Time: time.Now().UTC()}
+	}()
+
+	out, err := r.runTriage(ctx)
+	if err == nil {
+		inc.record(DriverRecoveryStepTriage, true, "nvidia-smi responded: "+out)
+		inc.Outcome = DriverRecoveryOutcomeRecovered
+		return inc
+	}
+	inc.record(DriverRecoveryStepTriage, false, fmt.Sprintf("nvidia-smi failed: %v", err))
+
+	holders, err := r.findHolders()
+	if err != nil {
+		inc.record(DriverRecoveryStepModuleReload, false, fmt.Sprintf("skipped: failed to find the processes holding the GPU devices: %v", err))
+		inc.Outcome = DriverRecoveryOutcomeBlocked
+		return inc
+	}
+	units, blocking := splitDeviceHolders(holders)
+	if len(blocking) > 0 {
+		inc.record(DriverRecoveryStepModuleReload, false, fmt.Sprintf("skipped: %d process(es) hold the GPU devices %v", len(blocking), blocking))
+		inc.Outcome = DriverRecoveryOutcomeBlocked
+		return inc
+	}
+
+	out, err = r.reload(ctx, units)
+	if err == nil {
+		// verify the driver responds after the reload
+		_, err = r.runTriage(ctx)
+	}
+	if err == nil {
+		inc.record(DriverRecoveryStepModuleReload, true, "modules reloaded and nvidia-smi responded")
+		inc.Outcome = DriverRecoveryOutcomeRecovered
+		return inc
+	}
+	msg := fmt.Sprintf("module reload failed: %v", err)
+	if out != "" {
+		msg += " (" + out + ")"
+	}
+	inc.record(DriverRecoveryStepModuleReload, false, msg)
+
+	if !r.policy.AllowReboot {
+		inc.Outcome = DriverRecoveryOutcomeRebootRequired
+		return inc
+	}
+	if err := r.reboot(ctx); err != nil {
+		inc.record(DriverRecoveryStepReboot, false, fmt.Sprintf("failed to reboot: %v", err))
+		inc.Outcome = DriverRecoveryOutcomeRebootRequired
+		return inc
+	}
+	inc.record(DriverRecoveryStepReboot, true, "reboot triggered")
+	inc.Outcome = DriverRecoveryOutcomeRebooting
+	return inc
+}
+
+// reload stops the NVIDIA daemons and releases the NVML session of gpud,
+// reloads the modules, and then restores the NVML session and the daemons,
+// even if the reload fails.
+func (r *driverRecoverer) reload(ctx context.Context, units []string) (string, error) {
+	if len(units) > 0 {
+		if err := r.stopServices(ctx, units); err != nil {
+			// still restarts the partially stopped daemons
+			if serr := r.startServices(ctx, units); serr != nil {
+				log.Logger.Warnw("failed to restart the nvidia daemons", "units", units, "error", serr)
+			}
+			return "", fmt.Errorf("failed to stop %v: %w", units, err)
+		}
+		defer func() {
+			if err := r.startServices(ctx, units); err != nil {
+				log.Logger.Warnw("failed to restart the nvidia daemons", "units", units, "error", err)
+			}
+		}()
+	}
+
+	if err := r.suspendNVML(); err != nil {
+		// the NVML shutdown failure does not prevent the reload,
+		// as the modules fail to unload if the device files are still open
+		log.Logger.Warnw("failed to suspend NVML", "error", err)
+	}
+	defer func() {
+		// the driver may take a while to respond, NVML re-initializes on the next query otherwise
+		if err := r.resumeNVML(); err != nil {
+			log.Logger.Warnw("failed to re-initialize NVML", "error", err)
+		}
+	}()
+
+	return r.reloadModules(ctx)
+}
+
+func (r *driverRecoverer) runTriage(ctx context.Context) (string, error) {
+	cctx, cancel := context.WithTimeout(ctx, r.policy.TriageTimeout.Duration)
+	defer cancel()
+	return r.triage(cctx)
+}
+
+func (inc *DriverRecoveryIncident) record(step DriverRecoveryStep, success bool, msg string) {
+	inc.Steps = append(inc.Steps, DriverRecoveryStepResult{
+		Step:    step,
+		Time:    metav1.Time{Time: time.Now().UTC()},
+		Success: success,
+		Message: msg,
+	})
+}
+
+// maxCommandOutputBytes is the maximum command output to keep in the incident.
+const maxCommandOutputBytes = 512
+
+func runNvidiaSMI(ctx context.Context) (string, error) {
+	if err := execlimit.Wait(ctx, "nvidia-smi"); err != nil {
+		return "", err
+	}
+	b, err := exec.CommandContext(ctx, "nvidia-smi", "-L").CombinedOutput()
+	return truncateOutput(b), err
+}
+
+// nvidiaModules are the NVIDIA kernel modules in the unload order
+// (the dependent modules first, e.g., "nvidia_peermem" pins "nvidia" for GPUDirect RDMA).
+var nvidiaModules = []string{"nvidia_peermem", "nvidia_uvm", "nvidia_drm", "nvidia_modeset", "nvidia"}
+
+// reloadNvidiaModules unloads the loaded NVIDIA kernel modules, and loads them back
+// (at least "nvidia" and "nvidia_uvm") in the reverse order.
+// Fails if any module is still in use (e.g., by the display server).
+func reloadNvidiaModules(ctx context.Context, procModules string) (string, error) {
+	b, err := os.ReadFile(procModules)
+	if err != nil {
+		return "", err
+	}
+	unload := loadedNvidiaModules(b)
+
+	// "modprobe -r" fails on the modules not loaded (e.g., "nvidia_drm" on the headless nodes)
+	if len(unload) > 0 {
+		args := append([]string{"-r"}, unload...)
+		if err := execlimit.Wait(ctx, "modprobe"); err != nil {
+			return "", err
+		}
+		if b, err := exec.CommandContext(ctx, "modprobe", args...).CombinedOutput(); err != nil {
+			return truncateOutput(b), err
+		}
+	}
+	for _, m := range modulesToLoad(unload) {
+		if err := execlimit.Wait(ctx, "modprobe"); err != nil {
+			return "", err
+		}
+		if b, err := exec.CommandContext(ctx, "modprobe", m).CombinedOutput(); err != nil {
+			return truncateOutput(b), err
+		}
+	}
+	return "", nil
+}
+
+// loadedNvidiaModules returns the loaded NVIDIA kernel modules in the unload order,
+// from the "/proc/modules" content.
+func loadedNvidiaModules(procModules []byte) []string {
+	loaded := make(map[string]struct{})
+	for _, line := range strings.Split(string(procModules), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			loaded[fields[0]] = struct{}{}
+		}
+	}
+	var mods []string
+	for _, m := range nvidiaModules {
+		if _, ok := loaded[m]; ok {
+			mods = append(mods, m)
+		}
+	}
+	return mods
+}
+
+// modulesToLoad returns the modules to load after unloading the given modules,
+// in the reverse order of the unload order.
+func modulesToLoad(unloaded []string) []string {
+	mods := []string{"nvidia", "nvidia_uvm"}
+	for i := len(unloaded) - 1; i >= 0; i-- {
+		m := unloaded[i]
+		if m == "nvidia" || m == "nvidia_uvm" {
+			continue
+		}
+		mods = append(mods, m)
+	}
+	return mods
+}
+
+func runSystemctl(ctx context.Context, verb string, units []string) error {
+	args := append([]string{verb}, units...)
+	if err := execlimit.Wait(ctx, "systemctl"); err != nil {
+		return err
+	}
+	if b, err := exec.CommandContext(ctx, "systemctl", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("systemctl %s failed: %w (%s)", verb, err, truncateOutput(b))
+	}
+	return nil
+}
+
+func truncateOutput(b []byte) string {
+	out := strings.TrimSpace(string(b))
+	if len(out) > maxCommandOutputBytes {
+		out = out[len(out)-maxCommandOutputBytes:]
+	}
+	return out
+}
+
+// nvidiaDaemons maps the NVIDIA daemons that keep the GPU devices open
+// to their systemd units, which are stopped during the module reload
+// rather than blocking it.
+var nvidiaDaemons = map[string]string{
+	"nvidia-persistenced": "nvidia-persistenced",
+	"nv-fabricmanager":    "nvidia-fabricmanager",
+	// DCGM host engine
+	"nv-hostengine": "nvidia-dcgm",
+}
+
+// deviceHolder is a process holding the GPU devices.
+type deviceHolder struct {
+	PID  int
+	Name string
+}
+
+func (h deviceHolder) String() string {
+	if h.Name == "" {
+		return strconv.Itoa(h.PID)
+	}
+	return fmt.Sprintf("%d (%s)", h.PID, h.Name)
+}
+
+// splitDeviceHolders returns the sorted systemd units of the known NVIDIA daemons in the holders,
+// and the other holders which block the module reload.
+func splitDeviceHolders(holders []deviceHolder) ([]string, []deviceHolder) {
+	seen := make(map[string]struct{})
+	var units []string
+	var blocking []deviceHolder
+	for _, h := range holders {
+		unit, ok := nvidiaDaemons[h.Name]
+		if !ok {
+			blocking = append(blocking, h)
+			continue
+		}
+		if _, ok := seen[unit]; !ok {
+			seen[unit] = struct{}{}
+			units = append(units, unit)
+		}
+	}
+	sort.Strings(units)
+	return units, blocking
+}
+
+// findDeviceHolders returns the processes (sorted by PID)
+// with any open file descriptor to the "/dev/nvidia*" devices,
+// excluding the given PID (e.g., gpud itself).
+func findDeviceHolders(procDir string, excludePID int) ([]deviceHolder, error) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var holders []deviceHolder
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == excludePID {
+			continue
+		}
+		fds, err := os.ReadDir(filepath.Join(procDir, e.Name(), "fd"))
+		if err != nil {
+			// process exited or not permitted
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(procDir, e.Name(), "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+			if strings.HasPrefix(target, "/dev/nvidia") {
+				holders = append(holders, deviceHolder{PID: pid, Name: processName(filepath.Join(procDir, e.Name()))})
+				break
+			}
+		}
+	}
+	sort.Slice(holders, func(i, j int) bool { return holders[i].PID < holders[j].PID })
+	return holders, nil
+}
+
+// processName returns the executable name of the process from its command line
+// (the "comm" is truncated to 15 characters, e.g., "nv-fabricmanage").
+func processName(pidDir string) string {
+	b, err := os.ReadFile(filepath.Join(pidDir, "cmdline"))
+	if err != nil || len(b) == 0 {
+		return ""
+	}
+	argv0, _, _ := strings.Cut(string(b), "\x00")
+	return filepath.Base(argv0)
+}
+
+// SuggestedActions converts the incident to the suggested actions.
+// Returns nil if the driver recovered or the reboot is in progress.
+func (inc *DriverRecoveryIncident) SuggestedActions() *common.SuggestedActions {
+	if inc == nil {
+		return nil
+	}
+	switch inc.Outcome {
+	case DriverRecoveryOutcomeBlocked:
+		return &common.SuggestedActions{
+			Descriptions:  []string{"drain the GPU processes and reboot the system to recover the unresponsive driver"},
+			RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+		}
+	case DriverRecoveryOutcomeRebootRequired:
+		return &common.SuggestedActions{
+			Descriptions:  []string{"reboot the system to recover the unresponsive driver (module reload failed)"},
+			RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+		}
+	}
+	return nil
+}
+
+const (
+	StateNameDriverRecovery = "driver_recovery"
+
+	StateKeyDriverRecoveryData           = "data"
+	StateKeyDriverRecoveryEncoding       = "encoding"
+	StateValueDriverRecoveryEncodingJSON = "json"
+
+	EventNameDriverRecoveryIncident = "driver_recovery_incident"
+)
+
+func init() {
+	components.RegisterStateSchemas(nvidia_error_xid_sxid_id.Name,
+		components.JSONStateSchema(StateNameDriverRecovery, "driver recovery incidents"),
+	)
+}
+
+func (inc *DriverRecoveryIncident) extraInfo() (map[string]string, error) {
+	b, err := json.Marshal(inc)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		StateKeyDriverRecoveryData:     string(b),
+		StateKeyDriverRecoveryEncoding: StateValueDriverRecoveryEncodingJSON,
+	}, nil
+}
+
+func (inc *DriverRecoveryIncident) summary() string {
+	return fmt.Sprintf("driver recovery for xids %v: %s", inc.Xids, inc.Outcome)
+}
+
+// State converts the latest incident to the component state.
+func (inc *DriverRecoveryIncident) State() (components.State, error) {
+	if inc == nil {
+		return components.State{
+			Name:    StateNameDriverRecovery,
+			Healthy: true,
+			Reason:  "no driver recovery incident",
+		}, nil
+	}
+	extraInfo, err := inc.extraInfo()
+	if err != nil {
+		return components.State{}, err
+	}
+	return components.State{
+		Name:             StateNameDriverRecovery,
+		Healthy:          inc.Outcome == DriverRecoveryOutcomeRecovered || inc.Outcome == DriverRecoveryOutcomeRebooted,
+		Reason:           inc.summary(),
+		ExtraInfo:        extraInfo,
+		SuggestedActions: inc.SuggestedActions(),
+	}, nil
+}
+
+// Event converts the incident to the component event.
+func (inc *DriverRecoveryIncident) Event() (components.Event, error) {
+	extraInfo, err := inc.extraInfo()
+	if err != nil {
+		return components.Event{}, err
+	}
+	return components.Event{
+		Time:      inc.StartTime,
+		Name:      EventNameDriverRecoveryIncident,
+		Message:   inc.summary(),
+		ExtraInfo: extraInfo,
+	}, nil
+}
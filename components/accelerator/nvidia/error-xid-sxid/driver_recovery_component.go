@@ -0,0 +1,152 @@
+package errorxidsxid
+
+import (
+	"context"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_error_xid_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error-xid-sxid/id"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/warmstate"
+)
+
+// incidentWarmStateKey is the warm state key of the latest driver recovery incident,
+// persisted so that the incident (and its cooldown) survives the reboot escalation.
+const incidentWarmStateKey = "driver-recovery/" + nvidia_error_xid_sxid_id.Name
+
+// maxIncidents is the maximum number of in-memory incidents to keep.
+const maxIncidents = 10
+
+func (c *component) scheduleDriverRecovery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		c.checkDriverRecovery(ctx)
+	}
+}
+
+// checkDriverRecovery runs the driver recovery sequence if NVML is unresponsive
+// and Xid 119/120 is observed since the last incident.
+func (c *component) checkDriverRecovery(ctx context.Context) {
+	last := c.lastIncident()
+	if last != nil && time.Since(last.StartTime.Time) < c.recoverer.policy.Cooldown.Duration {
+		return
+	}
+
+	nvmlErrs := c.nvmlErrors()
+	if len(nvmlErrs) == 0 {
+		return
+	}
+
+	// only the xids after the last incident trigger a new incident
+	since := time.Now().UTC().Add(-c.dbePolicy.LookbackPeriod.Duration)
+	if last != nil && last.EndTime.After(since) {
+		since = last.EndTime.Time
+	}
+	events, err := nvidia_xid_sxid_state.ReadEvents(ctx, c.db, nvidia_xid_sxid_state.WithSince(since))
+	if err != nil {
+		log.Logger.Warnw("failed to read xid events for driver recovery", "error", err)
+		return
+	}
+	xids := FindGSPXids(events)
+	if len(xids) == 0 {
+		return
+	}
+
+	log.Logger.Warnw("nvml unresponsive with gsp xids, starting driver recovery", "xids", xids, "nvmlErrors", nvmlErrs)
+	inc := c.recoverer.run(ctx, xids, nvmlErrs)
+	log.Logger.Warnw("driver recovery finished", "xids", xids, "outcome", inc.Outcome)
+	c.addIncident(ctx, inc)
+}
+
+// nvmlErrors returns the errors from the last NVML query, if any.
+func (c *component) nvmlErrors() []string {
+	last, err := c.poller.Last()
+	if err != nil || last == nil {
+		// no data yet
+		return nil
+	}
+	if last.Error != nil {
+		return []string{last.Error.Error()}
+	}
+	allOutput, ok := last.Output.(*nvidia_query.Output)
+	if !ok || allOutput == nil {
+		return nil
+	}
+	return allOutput.NVMLErrors
+}
+
+func (c *component) lastIncident() *DriverRecoveryIncident {
+	c.incidentsMu.RLock()
+	defer c.incidentsMu.RUnlock()
+
+	if len(c.incidents) == 0 {
+		return nil
+	}
+	return c.incidents[len(c.incidents)-1]
+}
+
+func (c *component) addIncident(ctx context.Context, inc *DriverRecoveryIncident) {
+	c.incidentsMu.Lock()
+	c.incidents = append(c.incidents, inc)
+	if len(c.incidents) > maxIncidents {
+		c.incidents = c.incidents[len(c.incidents)-maxIncidents:]
+	}
+	c.incidentsMu.Unlock()
+
+	if c.db == nil {
+		return
+	}
+	if err := warmstate.Save(ctx, c.db, incidentWarmStateKey, inc, time.Now()); err != nil {
+		log.Logger.Warnw("failed to save driver recovery incident", "error", err)
+	}
+}
+
+// loadIncident restores the latest incident saved before the restart (if any).
+func (c *component) loadIncident(ctx context.Context) {
+	if c.db == nil {
+		return
+	}
+	inc := new(DriverRecoveryIncident)
+	ok, err := warmstate.Load(ctx, c.db, incidentWarmStateKey, warmstate.DefaultMaxAge, inc)
+	if err != nil {
+		log.Logger.Warnw("failed to load driver recovery incident", "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	if inc.Outcome == DriverRecoveryOutcomeRebooting {
+		// restarted after the reboot escalation
+		inc.record(DriverRecoveryStepReboot, true, "system rebooted")
+		inc.Outcome = DriverRecoveryOutcomeRebooted
+	}
+	log.Logger.Infow("restored driver recovery incident", "start", inc.StartTime, "outcome", inc.Outcome)
+	c.incidents = append(c.incidents, inc)
+}
+
+func (c *component) incidentEvents(since time.Time) ([]components.Event, error) {
+	c.incidentsMu.RLock()
+	defer c.incidentsMu.RUnlock()
+
+	var evs []components.Event
+	for _, inc := range c.incidents {
+		if inc.StartTime.Time.Before(since) {
+			continue
+		}
+		ev, err := inc.Event()
+		if err != nil {
+			return nil, err
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
+}
@@ -0,0 +1,272 @@
+package errorxidsxid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+)
+
+func TestFindGSPXids(t *testing.T) {
+	events := []nvidia_xid_sxid_state.Event{
+		{EventType: "xid", EventID: 120},
+		{EventType: "xid", EventID: 48},
+		{EventType: "xid", EventID: 119},
+		{EventType: "xid", EventID: 120},
+		{EventType: "sxid", EventID: 119},
+	}
+	if got := FindGSPXids(events); !reflect.DeepEqual(got, []int{119, 120}) {
+		t.Errorf("FindGSPXids() = %v, want [119 120]", got)
+	}
+	if got := FindGSPXids(nil); len(got) != 0 {
+		t.Errorf("FindGSPXids(nil) = %v, want empty", got)
+	}
+}
+
+func TestDriverRecovererRun(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name        string
+		allowReboot bool
+		triageErrs  []error
+		holders     []deviceHolder
+		holdersErr  error
+		reloadErr   error
+		rebootErr   error
+
+		wantOutcome  DriverRecoveryOutcome
+		wantSteps    []DriverRecoveryStep
+		wantReloaded bool
+		wantRebooted bool
+		// the sequence of the stop/start services and the NVML suspend/resume around the reload
+		wantCalls []string
+	}{
+		{
+			name:        "nvidia-smi responds",
+			triageErrs:  []error{nil},
+			wantOutcome: DriverRecoveryOutcomeRecovered,
+			wantSteps:   []DriverRecoveryStep{DriverRecoveryStepTriage},
+		},
+		{
+			name:        "processes hold the devices",
+			triageErrs:  []error{errFailed},
+			holders:     []deviceHolder{{PID: 100, Name: "python"}, {PID: 200, Name: "nvidia-persistenced"}},
+			wantOutcome: DriverRecoveryOutcomeBlocked,
+			wantSteps:   []DriverRecoveryStep{DriverRecoveryStepTriage, DriverRecoveryStepModuleReload},
+		},
+		{
+			name:        "failed to find holders",
+			triageErrs:  []error{errFailed},
+			holdersErr:  errFailed,
+			wantOutcome: DriverRecoveryOutcomeBlocked,
+			wantSteps:   []DriverRecoveryStep{DriverRecoveryStepTriage, DriverRecoveryStepModuleReload},
+		},
+		{
+			name:         "module reload recovers",
+			allowReboot:  true,
+			triageErrs:   []error{errFailed, nil},
+			wantOutcome:  DriverRecoveryOutcomeRecovered,
+			wantSteps:    []DriverRecoveryStep{DriverRecoveryStepTriage, DriverRecoveryStepModuleReload},
+			wantReloaded: true,
+			wantCalls:    []string{"suspend", "reload", "resume"},
+		},
+		{
+			name:       "nvidia daemons stopped during the reload",
+			triageErrs: []error{errFailed, nil},
+			holders: []deviceHolder{
+				{PID: 100, Name: "nvidia-persistenced"},
+				{PID: 200, Name: "nv-fabricmanager"},
+				{PID: 300, Name: "nv-hostengine"},
+			},
+			wantOutcome:  DriverRecoveryOutcomeRecovered,
+			wantSteps:    []DriverRecoveryStep{DriverRecoveryStepTriage, DriverRecoveryStepModuleReload},
+			wantReloaded: true,
+			wantCalls: []string{
+				"stop [nvidia-dcgm nvidia-fabricmanager nvidia-persistenced]",
+				"suspend", "reload", "resume",
+				"start [nvidia-dcgm nvidia-fabricmanager nvidia-persistenced]",
+			},
+		},
+		{
+			name:         "module reload fails without reboot allowed",
+			triageErrs:   []error{errFailed},
+			holders:      []deviceHolder{{PID: 100, Name: "nvidia-persistenced"}},
+			reloadErr:    errFailed,
+			wantOutcome:  DriverRecoveryOutcomeRebootRequired,
+			wantSteps:    []DriverRecoveryStep{DriverRecoveryStepTriage, DriverRecoveryStepModuleReload},
+			wantReloaded: true,
+			// restores the daemons and NVML even if the reload fails
+			wantCalls: []string{"stop [nvidia-persistenced]", "suspend", "reload", "resume", "start [nvidia-persistenced]"},
+		},
+		{
+			name:         "nvidia-smi still fails after reload, reboot",
+			allowReboot:  true,
+			triageErrs:   []error{errFailed, errFailed},
+			wantOutcome:  DriverRecoveryOutcomeRebooting,
+			wantSteps:    []DriverRecoveryStep{DriverRecoveryStepTriage, DriverRecoveryStepModuleReload, DriverRecoveryStepReboot},
+			wantReloaded: true,
+			wantRebooted: true,
+		},
+		{
+			name:         "reboot fails",
+			allowReboot:  true,
+			triageErrs:   []error{errFailed},
+			reloadErr:    errFailed,
+			rebootErr:    errFailed,
+			wantOutcome:  DriverRecoveryOutcomeRebootRequired,
+			wantSteps:    []DriverRecoveryStep{DriverRecoveryStepTriage, DriverRecoveryStepModuleReload, DriverRecoveryStepReboot},
+			wantReloaded: true,
+			wantRebooted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := DriverRecoveryPolicy{AllowReboot: tt.allowReboot}
+			policy.SetDefaultsIfNotSet()
+
+			triaged, reloaded, rebooted := 0, false, false
+			var calls []string
+			r := &driverRecoverer{
+				policy: policy,
+				triage: func(ctx context.Context) (string, error) {
+					err := tt.triageErrs[triaged]
+					triaged++
+					return "", err
+				},
+				findHolders: func() ([]deviceHolder, error) { return tt.holders, tt.holdersErr },
+				stopServices: func(ctx context.Context, units []string) error {
+					calls = append(calls, fmt.Sprintf("stop %v", units))
+					return nil
+				},
+				startServices: func(ctx context.Context, units []string) error {
+					calls = append(calls, fmt.Sprintf("start %v", units))
+					return nil
+				},
+				suspendNVML: func() error {
+					calls = append(calls, "suspend")
+					return nil
+				},
+				resumeNVML: func() error {
+					calls = append(calls, "resume")
+					return nil
+				},
+				reloadModules: func(ctx context.Context) (string, error) {
+					reloaded = true
+					calls = append(calls, "reload")
+					return "", tt.reloadErr
+				},
+				reboot: func(ctx context.Context) error {
+					rebooted = true
+					return tt.rebootErr
+				},
+			}
+
+			inc := r.run(context.Background(), []int{XidGSPRPCTimeout}, []string{"nvml timeout"})
+			if inc.Outcome != tt.wantOutcome {
+				t.Errorf("outcome = %q, want %q", inc.Outcome, tt.wantOutcome)
+			}
+			var steps []DriverRecoveryStep
+			for _, s := range inc.Steps {
+				steps = append(steps, s.Step)
+			}
+			if !reflect.DeepEqual(steps, tt.wantSteps) {
+				t.Errorf("steps = %v, want %v", steps, tt.wantSteps)
+			}
+			if reloaded != tt.wantReloaded {
+				t.Errorf("reloaded = %v, want %v", reloaded, tt.wantReloaded)
+			}
+			if rebooted != tt.wantRebooted {
+				t.Errorf("rebooted = %v, want %v", rebooted, tt.wantRebooted)
+			}
+			if tt.wantCalls != nil && !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if inc.EndTime.IsZero() {
+				t.Error("expected end time to be set")
+			}
+
+			state, err := inc.State()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantHealthy := tt.wantOutcome == DriverRecoveryOutcomeRecovered
+			if state.Healthy != wantHealthy {
+				t.Errorf("healthy = %v, want %v", state.Healthy, wantHealthy)
+			}
+			if tt.wantOutcome == DriverRecoveryOutcomeBlocked || tt.wantOutcome == DriverRecoveryOutcomeRebootRequired {
+				if state.SuggestedActions == nil {
+					t.Error("expected suggested actions")
+				}
+			}
+		})
+	}
+}
+
+func TestFindDeviceHolders(t *testing.T) {
+	procDir := t.TempDir()
+
+	mkfd := func(pid, fd, target string) {
+		dir := filepath.Join(procDir, pid, "fd")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, filepath.Join(dir, fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mkcmdline := func(pid, cmdline string) {
+		if err := os.WriteFile(filepath.Join(procDir, pid, "cmdline"), []byte(cmdline), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mkfd("100", "3", "/dev/nvidia0")
+	mkfd("100", "4", "/dev/nvidiactl")
+	mkfd("200", "3", "/dev/null")
+	mkfd("300", "5", "/dev/nvidia-uvm")
+	mkfd("400", "3", "/dev/nvidia1")
+	mkcmdline("100", "/usr/bin/nvidia-persistenced\x00--user\x00nvidia-persistenced\x00")
+	if err := os.MkdirAll(filepath.Join(procDir, "self"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	holders, err := findDeviceHolders(procDir, 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []deviceHolder{{PID: 100, Name: "nvidia-persistenced"}, {PID: 300}}
+	if !reflect.DeepEqual(holders, want) {
+		t.Errorf("findDeviceHolders() = %v, want %v", holders, want)
+	}
+
+	units, blocking := splitDeviceHolders(holders)
+	if !reflect.DeepEqual(units, []string{"nvidia-persistenced"}) {
+		t.Errorf("units = %v, want [nvidia-persistenced]", units)
+	}
+	if !reflect.DeepEqual(blocking, []deviceHolder{{PID: 300}}) {
+		t.Errorf("blocking = %v, want [300]", blocking)
+	}
+}
+
+func TestNvidiaModulesToReload(t *testing.T) {
+	procModules := []byte(`nvidia_uvm 1855488 0 - Live 0x0000000000000000
+nvidia_peermem 16384 0 - Live 0x0000000000000000
+nvidia 56446976 2 nvidia_uvm,nvidia_peermem, Live 0x0000000000000000
+ib_core 454656 1 nvidia_peermem, Live 0x0000000000000000
+`)
+	unload := loadedNvidiaModules(procModules)
+	if !reflect.DeepEqual(unload, []string{"nvidia_peermem", "nvidia_uvm", "nvidia"}) {
+		t.Errorf("loadedNvidiaModules() = %v", unload)
+	}
+	if load := modulesToLoad(unload); !reflect.DeepEqual(load, []string{"nvidia", "nvidia_uvm", "nvidia_peermem"}) {
+		t.Errorf("modulesToLoad() = %v", load)
+	}
+	if load := modulesToLoad(nil); !reflect.DeepEqual(load, []string{"nvidia", "nvidia_uvm"}) {
+		t.Errorf("modulesToLoad(nil) = %v", load)
+	}
+}
@@ -0,0 +1,213 @@
+package errorxidsxid
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_pcie_aer "github.com/leptonai/gpud/components/accelerator/nvidia/query/pcie-aer"
+	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+	"github.com/leptonai/gpud/components/common"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// "GPU has fallen off the bus"
+// ref. https://docs.nvidia.com/deploy/gpu-debug-guidelines/index.html#xid-messages
+const XidFallenOffBus = 79
+
+const (
+	EventNameGPUFallenOffBus = "gpu_fallen_off_bus"
+
+	EventKeyBusID           = "bus_id"
+	EventKeyAERErrors       = "aer_errors"
+	EventKeyAERSeverities   = "aer_severities"
+	EventKeyRecurrences     = "recurrences"
+	EventKeyNVMLDeviceCount = "nvml_device_count"
+	EventKeyDevDeviceCount  = "dev_device_count"
+)
+
+const (
+	// DefaultFallenOffBusWindow is the window around the Xid 79 to correlate the PCIe AER errors,
+	// and to merge the repeated Xid 79 lines of the same GPU (e.g., both the dmesg and NVML).
+	DefaultFallenOffBusWindow = time.Minute
+
+	// fallenOffBusLookback is how far back the Xid 79 history is read to find the recurrences
+	// (bounded by the events retention period).
+	fallenOffBusLookback = 7 * 24 * time.Hour
+)
+
+// FallenOffBusIncident is the Xid 79 of a GPU consolidated with the PCIe AER errors on the same device.
+type FallenOffBusIncident struct {
+	Time time.Time
+	// BusID is the PCI bus ID of the GPU from the Xid line (e.g., "0000:05:00").
+	BusID string
+	// XidCount is the number of the Xid 79 lines merged into the incident.
+	XidCount int
+	// AERErrors is the PCIe AER errors on the same device within the window.
+	AERErrors []nvidia_query_pcie_aer.Error
+	// Recurrences is the number of the incidents on the same GPU so far, including this one.
+	Recurrences int
+
+	// NVMLDeviceCount and DevDeviceCount are the GPU counts seen by NVML and in the /dev directory,
+	// zero if unknown. The NVML count drops below the /dev count when the GPU is gone.
+	NVMLDeviceCount int
+	DevDeviceCount  int
+}
+
+// CorrelateFallenOffBus consolidates the Xid 79 events from the dmesg with the PCIe AER errors
+// on the same bus/device, in the ascending order of the time.
+func CorrelateFallenOffBus(events []nvidia_xid_sxid_state.Event, aerErrors []nvidia_query_pcie_aer.Error, window time.Duration) []FallenOffBusIncident {
+	sorted := make([]nvidia_xid_sxid_state.Event, 0, len(events))
+	for _, ev := range events {
+		if ev.EventType == nvidia_xid_sxid_state.EventTypeXid && ev.EventID == XidFallenOffBus && ev.DataSource == "dmesg" {
+			sorted = append(sorted, ev)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].UnixSeconds < sorted[j].UnixSeconds
+	})
+
+	var incidents []FallenOffBusIncident
+	last := make(map[string]int)
+	recurrences := make(map[string]int)
+	for _, ev := range sorted {
+		busID := nvidia_query_xid.ExtractNVRMXidDeviceBusID(ev.EventDetails)
+		if busID == "" {
+			continue
+		}
+		dev := nvidia_query_pcie_aer.NormalizeDevice(busID)
+		ts := time.Unix(ev.UnixSeconds, 0).UTC()
+
+		if i, ok := last[dev]; ok && ts.Sub(incidents[i].Time) <= window {
+			incidents[i].XidCount++
+			continue
+		}
+		recurrences[dev]++
+		incidents = append(incidents, FallenOffBusIncident{
+			Time:        ts,
+			BusID:       busID,
+			XidCount:    1,
+			Recurrences: recurrences[dev],
+		})
+		last[dev] = len(incidents) - 1
+	}
+
+	for i := range incidents {
+		for _, e := range aerErrors {
+			if !e.SameDevice(incidents[i].BusID) {
+				continue
+			}
+			d := time.Unix(e.UnixSeconds, 0).Sub(incidents[i].Time)
+			if d < -window || d > window {
+				continue
+			}
+			incidents[i].AERErrors = append(incidents[i].AERErrors, e)
+		}
+	}
+	return incidents
+}
+
+// DeviceCountDropped returns true if NVML sees fewer GPUs than the /dev directory.
+func (inc FallenOffBusIncident) DeviceCountDropped() bool {
+	return inc.DevDeviceCount > 0 && inc.NVMLDeviceCount < inc.DevDeviceCount
+}
+
+// ToEvent converts the incident to one consolidated event,
+// suggesting the reboot first, and the RMA if the GPU keeps falling off the bus.
+func (inc FallenOffBusIncident) ToEvent() components.Event {
+	msg := fmt.Sprintf("GPU %s has fallen off the bus (Xid %d)", inc.BusID, XidFallenOffBus)
+
+	severities := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, e := range inc.AERErrors {
+		if _, ok := seen[e.Severity]; ok {
+			continue
+		}
+		seen[e.Severity] = struct{}{}
+		severities = append(severities, e.Severity)
+	}
+	if len(inc.AERErrors) > 0 {
+		msg += fmt.Sprintf(" with %d PCIe AER error(s) (%s)", len(inc.AERErrors), strings.Join(severities, ", "))
+	}
+	if inc.DeviceCountDropped() {
+		msg += fmt.Sprintf(", NVML sees %d of %d GPUs", inc.NVMLDeviceCount, inc.DevDeviceCount)
+	}
+	if inc.Recurrences > 1 {
+		msg += fmt.Sprintf(", recurred %d times", inc.Recurrences)
+	}
+
+	ev := components.Event{
+		Time:    metav1.Time{Time: inc.Time},
+		Name:    EventNameGPUFallenOffBus,
+		Type:    components.EventTypeError,
+		Message: msg,
+		ExtraInfo: map[string]string{
+			EventKeyBusID:         inc.BusID,
+			EventKeyAERErrors:     strconv.Itoa(len(inc.AERErrors)),
+			EventKeyAERSeverities: strings.Join(severities, ","),
+			EventKeyRecurrences:   strconv.Itoa(inc.Recurrences),
+		},
+	}
+	if inc.DevDeviceCount > 0 {
+		ev.ExtraInfo[EventKeyNVMLDeviceCount] = strconv.Itoa(inc.NVMLDeviceCount)
+		ev.ExtraInfo[EventKeyDevDeviceCount] = strconv.Itoa(inc.DevDeviceCount)
+	}
+
+	if inc.Recurrences > 1 {
+		ev.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				fmt.Sprintf("GPU %s has fallen off the bus %d times, the GPU (or its PCIe slot/riser) needs to be inspected and RMAed", inc.BusID, inc.Recurrences),
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeHardwareInspection,
+			},
+		}
+	} else {
+		ev.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"reboot the system to recover the GPU, then RMA if the GPU falls off the bus again",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRebootSystem,
+			},
+		}
+	}
+	return ev
+}
+
+// fallenOffBusEvents returns the consolidated Xid 79 events since the given time.
+func (c *component) fallenOffBusEvents(ctx context.Context, since time.Time) ([]components.Event, error) {
+	lookback := since.Add(-fallenOffBusLookback)
+	xids, err := nvidia_xid_sxid_state.ReadEvents(ctx, c.db, nvidia_xid_sxid_state.WithSince(lookback))
+	if err != nil {
+		return nil, err
+	}
+	aerErrors, err := nvidia_query_pcie_aer.ReadErrors(ctx, c.db, lookback.Add(-DefaultFallenOffBusWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	nvmlCount, devCount := 0, 0
+	if last, err := c.poller.Last(); err == nil && last != nil && last.Output != nil {
+		if allOutput, ok := last.Output.(*nvidia_query.Output); ok {
+			nvmlCount, devCount = allOutput.GPUCountFromNVML(), allOutput.GPUDeviceCount
+		}
+	}
+
+	var evs []components.Event
+	for _, inc := range CorrelateFallenOffBus(xids, aerErrors, DefaultFallenOffBusWindow) {
+		if inc.Time.Before(since) {
+			continue
+		}
+		inc.NVMLDeviceCount, inc.DevDeviceCount = nvmlCount, devCount
+		evs = append(evs, inc.ToEvent())
+	}
+	return evs, nil
+}
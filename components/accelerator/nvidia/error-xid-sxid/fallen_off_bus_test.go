@@ -0,0 +1,89 @@
+package errorxidsxid
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	nvidia_query_pcie_aer "github.com/leptonai/gpud/components/accelerator/nvidia/query/pcie-aer"
+	nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+	"github.com/leptonai/gpud/components/common"
+)
+
+func TestCorrelateFallenOffBus(t *testing.T) {
+	xid79 := func(ts int64, busID string) nvidia_xid_sxid_state.Event {
+		return nvidia_xid_sxid_state.Event{
+			UnixSeconds:  ts,
+			DataSource:   "dmesg",
+			EventType:    "xid",
+			EventID:      XidFallenOffBus,
+			EventDetails: "NVRM: Xid (PCI:" + busID + "): 79, pid=0, GPU has fallen off the bus.",
+		}
+	}
+
+	events := []nvidia_xid_sxid_state.Event{
+		xid79(1000, "0000:05:00"),
+		xid79(1010, "0000:05:00"), // merged into the first one
+		xid79(1005, "0000:06:00"),
+		{UnixSeconds: 1000, DataSource: "dmesg", EventType: "xid", EventID: 48, EventDetails: "NVRM: Xid (PCI:0000:05:00): 48, pid=0"},
+		{UnixSeconds: 1000, DataSource: "nvml", EventType: "xid", EventID: XidFallenOffBus},
+		xid79(5000, "0000:05:00"),
+	}
+	aers := []nvidia_query_pcie_aer.Error{
+		{UnixSeconds: 995, BDF: "0000:05:00.0", Severity: nvidia_query_pcie_aer.SeverityFatal},
+		{UnixSeconds: 995, BDF: "0000:07:00.0", Severity: nvidia_query_pcie_aer.SeverityFatal},
+		{UnixSeconds: 3000, BDF: "0000:05:00.0", Severity: nvidia_query_pcie_aer.SeverityCorrected},
+	}
+
+	incidents := CorrelateFallenOffBus(events, aers, DefaultFallenOffBusWindow)
+	if len(incidents) != 3 {
+		t.Fatalf("expected 3 incidents, got %d: %+v", len(incidents), incidents)
+	}
+
+	if incidents[0].BusID != "0000:05:00" || incidents[0].XidCount != 2 || len(incidents[0].AERErrors) != 1 || incidents[0].Recurrences != 1 {
+		t.Errorf("unexpected first incident: %+v", incidents[0])
+	}
+	if incidents[1].BusID != "0000:06:00" || len(incidents[1].AERErrors) != 0 || incidents[1].Recurrences != 1 {
+		t.Errorf("unexpected second incident: %+v", incidents[1])
+	}
+	if incidents[2].BusID != "0000:05:00" || len(incidents[2].AERErrors) != 0 || incidents[2].Recurrences != 2 {
+		t.Errorf("unexpected third incident: %+v", incidents[2])
+	}
+}
+
+func TestFallenOffBusIncidentToEvent(t *testing.T) {
+	inc := FallenOffBusIncident{
+		Time:     time.Unix(1000, 0),
+		BusID:    "0000:05:00",
+		XidCount: 1,
+		AERErrors: []nvidia_query_pcie_aer.Error{
+			{UnixSeconds: 995, BDF: "0000:05:00.0", Severity: nvidia_query_pcie_aer.SeverityFatal},
+		},
+		Recurrences:     1,
+		NVMLDeviceCount: 7,
+		DevDeviceCount:  8,
+	}
+
+	ev := inc.ToEvent()
+	if ev.Name != EventNameGPUFallenOffBus {
+		t.Errorf("unexpected event name %q", ev.Name)
+	}
+	if !strings.Contains(ev.Message, "NVML sees 7 of 8 GPUs") {
+		t.Errorf("expected the device count in the message, got %q", ev.Message)
+	}
+	if ev.ExtraInfo[EventKeyAERSeverities] != nvidia_query_pcie_aer.SeverityFatal {
+		t.Errorf("unexpected aer severities %q", ev.ExtraInfo[EventKeyAERSeverities])
+	}
+	if !ev.SuggestedActions.RequiresReboot() || ev.SuggestedActions.RequiresRepair() {
+		t.Errorf("expected reboot for the first incident, got %+v", ev.SuggestedActions)
+	}
+
+	inc.Recurrences = 2
+	ev = inc.ToEvent()
+	if ev.SuggestedActions.RequiresReboot() || !ev.SuggestedActions.RequiresRepair() {
+		t.Errorf("expected hardware inspection for the recurring incident, got %+v", ev.SuggestedActions)
+	}
+	if len(ev.SuggestedActions.RepairActions) != 1 || ev.SuggestedActions.RepairActions[0] != common.RepairActionTypeHardwareInspection {
+		t.Errorf("unexpected repair actions %v", ev.SuggestedActions.RepairActions)
+	}
+}
@@ -51,6 +51,12 @@ const (
 	StateValueErrorEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameError, "NVIDIA GPU errors from the nvidia-smi queries"),
+	)
+}
+
 func ParseStateError(m map[string]string) (*Output, error) {
 	data := m[StateKeyErrorData]
 	return ParseOutputJSON([]byte(data))
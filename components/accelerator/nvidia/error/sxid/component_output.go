@@ -9,10 +9,11 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	nvidia_component_error_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error/sxid/id"
 	nvidia_query_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/query/sxid"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/locale"
 
-	"github.com/dustin/go-humanize"
 	"sigs.k8s.io/yaml"
 )
 
@@ -52,6 +53,12 @@ const (
 	StateValueErrorSXidEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(nvidia_component_error_sxid_id.Name,
+		components.JSONStateSchema(StateNameErrorSXid, "NVSwitch SXid errors"),
+	)
+}
+
 func ParseStateErrorSXid(m map[string]string) (*Output, error) {
 	data := m[StateKeyErrorSXidData]
 	return ParseOutputJSON([]byte(data))
@@ -109,7 +116,7 @@ func (o *Output) GetReason() Reason {
 	for _, e := range reason.Errors {
 		reason.Messages = append(reason.Messages,
 			fmt.Sprintf("sxid %d detected by %s (%s)",
-				e.SXid, e.DataSource, humanize.Time(e.Time.UTC()),
+				e.SXid, e.DataSource, locale.Time(e.Time.UTC()),
 			),
 		)
 	}
@@ -5,16 +5,21 @@ package xid
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_component_error_xid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error/xid/id"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	nvidia_query_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
 	"github.com/leptonai/gpud/components/dmesg"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
+
+	"github.com/shirou/gopsutil/v4/host"
 )
 
 func New(ctx context.Context, cfg Config) components.Component {
@@ -23,11 +28,19 @@ func New(ctx context.Context, cfg Config) components.Component {
 
 	cctx, ccancel := context.WithCancel(ctx)
 	getDefaultPoller().Start(cctx, cfg.Query, nvidia_component_error_xid_id.Name)
+	if pusher, ok := getDefaultPoller().(query.Pusher); ok {
+		startWatchingXidEvents(cctx, pusher)
+	}
 
+	var db *sql.DB
+	if cfg.Query.State != nil {
+		db = cfg.Query.State.DB
+	}
 	return &component{
 		rootCtx: ctx,
 		cancel:  ccancel,
 		poller:  getDefaultPoller(),
+		db:      db,
 	}
 }
 
@@ -37,43 +50,83 @@ type component struct {
 	rootCtx context.Context
 	cancel  context.CancelFunc
 	poller  query.Poller
+	db      *sql.DB
 }
 
 func (c *component) Name() string { return nvidia_component_error_xid_id.Name }
 
-// Just checks if the xid poller is working.
-func (c *component) States(_ context.Context) ([]components.State, error) {
+// Checks if the xid poller is working,
+// and returns the per-GPU states with the critical Xid errors since the last boot.
+func (c *component) States(ctx context.Context) ([]components.State, error) {
 	last, err := c.poller.Last()
 
+	var state components.State
+	switch {
 	// no data yet from realtime xid poller
-	// just return whatever we got from dmesg
-	if err == query.ErrNoData {
+	case err == query.ErrNoData:
 		log.Logger.Debugw("nothing found in last state (no data collected yet)", "component", nvidia_component_error_xid_id.Name)
-		return []components.State{
-			{
-				Name:    StateNameErrorXid,
-				Healthy: true,
-				Reason:  "no xid error event",
-			},
-		}, nil
-	}
+		state = components.State{
+			Name:    StateNameErrorXid,
+			Healthy: true,
+			Reason:  "no xid error event",
+		}
 
 	// something went wrong in the poller
 	// just return an error to surface the issue
-	if err != nil {
+	case err != nil:
 		return nil, err
-	}
-	if last.Error != nil {
+	case last.Error != nil:
 		return nil, last.Error
-	}
 
-	return []components.State{
-		{
+	default:
+		state = components.State{
 			Name:    StateNameErrorXid,
 			Healthy: true,
 			Reason:  "xid event polling is working",
-		},
-	}, nil
+		}
+	}
+
+	gpuStates, err := c.gpuStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append([]components.State{state}, gpuStates...), nil
+}
+
+// gpuStates reads the Xid events persisted from the dmesg and NVML,
+// and returns the per-GPU states for the GPUs visible to NVML.
+func (c *component) gpuStates(ctx context.Context) ([]components.State, error) {
+	if c.db == nil {
+		return nil, nil
+	}
+
+	since := time.Now().UTC().Add(-DefaultGPUStatesLookbackPeriod)
+	if bootTime, err := host.BootTimeWithContext(ctx); err != nil {
+		log.Logger.Warnw("failed to get boot time", "error", err)
+	} else if bt := time.Unix(int64(bootTime), 0).UTC(); bt.After(since) {
+		since = bt
+	}
+	events, err := nvidia_query_xid_sxid_state.ReadEvents(ctx, c.db, nvidia_query_xid_sxid_state.WithSince(since))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		uuids  []string
+		busIDs map[uint32]string
+	)
+	if poller := nvidia_query.GetDefaultPoller(); poller != nil {
+		if last, err := poller.Last(); err == nil && last != nil && last.Output != nil {
+			if o, ok := last.Output.(*nvidia_query.Output); ok && o.NVML != nil {
+				busIDs = make(map[uint32]string, len(o.NVML.DeviceInfos))
+				for _, dev := range o.NVML.DeviceInfos {
+					uuids = append(uuids, dev.UUID)
+					busIDs[dev.BusID] = dev.UUID
+				}
+			}
+		}
+	}
+	return GPUStates(events, uuids, busIDs), nil
 }
 
 // tailScan fetches the latest output from the dmesg and the NVML poller
@@ -18,8 +18,8 @@ import (
 	components_metrics "github.com/leptonai/gpud/components/metrics"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/locale"
 
-	"github.com/dustin/go-humanize"
 	"sigs.k8s.io/yaml"
 )
 
@@ -89,6 +89,21 @@ const (
 	StateValueErrorXidEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(nvidia_component_error_xid_id.Name,
+		components.JSONStateSchema(StateNameErrorXid, "Xid errors from dmesg and NVML"),
+		components.StateSchema{
+			Name:        StateNamePrefixErrorXidGPU,
+			NamePrefix:  true,
+			Description: "critical Xid errors of the GPU since the last boot, one state per GPU",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyGPUUUID, Type: components.ExtraInfoTypeString, Description: "GPU UUID"},
+				{Key: StateKeyXids, Type: components.ExtraInfoTypeString, Description: "comma-separated critical Xids"},
+			},
+		},
+	)
+}
+
 func ParseStateErrorXid(m map[string]string) (*Output, error) {
 	data := m[StateKeyErrorXidData]
 	return ParseOutputJSON([]byte(data))
@@ -166,7 +181,7 @@ func (o *Output) GetReason() Reason {
 	for _, e := range reason.Errors {
 		reason.Messages = append(reason.Messages,
 			fmt.Sprintf("xid %d detected by %s (%s)",
-				e.Xid, e.DataSource, humanize.Time(e.Time.UTC()),
+				e.Xid, e.DataSource, locale.Time(e.Time.UTC()),
 			),
 		)
 	}
@@ -234,6 +249,8 @@ func getDefaultPoller() query.Poller {
 
 // DO NOT for-loop here
 // the query.GetFunc is already called periodically in a loop by the poller
+// the NVML Xid events are pushed to the poller by the watcher as soon as they fire (see startWatchingXidEvents),
+// so the get function only checks the NVML instance readiness
 func CreateGet() query.GetFunc {
 	return func(ctx context.Context) (_ any, e error) {
 		defer func() {
@@ -249,18 +266,45 @@ func CreateGet() query.GetFunc {
 			return nil, ctx.Err()
 		case <-nvidia_query_nvml.DefaultInstanceReady():
 		}
+		return nil, nil
+	}
+}
+
+var startWatchingXidEventsOnce sync.Once
+
+// startWatchingXidEvents starts watching the NVML event set in the background (e.g., XidCriticalError, DoubleBitEccError),
+// and pushes each event to the poller queue as soon as it fires, instead of waiting for the next poll.
+func startWatchingXidEvents(ctx context.Context, pusher query.Pusher) {
+	startWatchingXidEventsOnce.Do(func() {
+		go watchXidEvents(ctx, pusher, func() <-chan *nvidia_query_nvml.XidEvent {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-nvidia_query_nvml.DefaultInstanceReady():
+			}
+			return nvidia_query_nvml.DefaultInstance().RecvXidEvents()
+		})
+	})
+}
 
-		// if there's no registered event, the channel blocks
-		// then just return nil
+func watchXidEvents(ctx context.Context, pusher query.Pusher, recv func() <-chan *nvidia_query_nvml.XidEvent) {
+	// if there's no registered event (e.g., xid events not supported), the channel blocks
+	ch := recv()
+	if ch == nil {
+		return
+	}
+	for {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
-
-		case ev := <-nvidia_query_nvml.DefaultInstance().RecvXidEvents():
-			return ev, nil
-
-		default:
-			return nil, nil
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			pusher.Push(query.Item{
+				Time:   ev.Time,
+				Output: ev,
+			})
 		}
 	}
 }
@@ -1,14 +1,17 @@
 package xid
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	"github.com/leptonai/gpud/components/query"
 	query_log "github.com/leptonai/gpud/components/query/log"
 
 	"github.com/dustin/go-humanize"
@@ -158,3 +161,38 @@ func TestOutputGetReason(t *testing.T) {
 		})
 	}
 }
+
+type testPusher struct {
+	mu    sync.Mutex
+	items []query.Item
+}
+
+func (p *testPusher) Push(item query.Item) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.items = append(p.items, item)
+}
+
+func TestWatchXidEvents(t *testing.T) {
+	ch := make(chan *nvidia_query_nvml.XidEvent, 2)
+	ts := metav1.NewTime(time.Now().UTC())
+	ch <- &nvidia_query_nvml.XidEvent{Time: ts, DeviceUUID: "GPU-0", Xid: 79}
+	ch <- &nvidia_query_nvml.XidEvent{Time: ts, DeviceUUID: "GPU-1", Xid: 48, NVMLEventTypeDoubleBitEccError: true}
+	close(ch)
+
+	p := &testPusher{}
+	watchXidEvents(context.Background(), p, func() <-chan *nvidia_query_nvml.XidEvent { return ch })
+
+	if len(p.items) != 2 {
+		t.Fatalf("expected 2 pushed items, got %d", len(p.items))
+	}
+	for i, want := range []uint64{79, 48} {
+		ev, ok := p.items[i].Output.(*nvidia_query_nvml.XidEvent)
+		if !ok || ev.Xid != want || !p.items[i].Time.Equal(&ts) {
+			t.Errorf("item %d: expected xid %d at %v, got %+v", i, want, ts, p.items[i])
+		}
+	}
+
+	// no registered event
+	watchXidEvents(context.Background(), p, func() <-chan *nvidia_query_nvml.XidEvent { return nil })
+}
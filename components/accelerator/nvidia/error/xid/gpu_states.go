@@ -0,0 +1,125 @@
+package xid
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	nvidia_query_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+	"github.com/leptonai/gpud/components/common"
+)
+
+// DefaultGPUStatesLookbackPeriod is the default period to look back for the critical Xid events per GPU.
+// The events before the last boot are not counted, since most critical Xids are cleared by the reboot.
+const DefaultGPUStatesLookbackPeriod = 24 * time.Hour
+
+const (
+	// StateNamePrefixErrorXidGPU is the prefix of the per-GPU state names (e.g., "error_xid_GPU-1234").
+	StateNamePrefixErrorXidGPU = "error_xid_"
+
+	StateKeyGPUUUID = "gpu_uuid"
+	StateKeyXids    = "xids"
+)
+
+// GPUStates returns the per-GPU states, unhealthy if any Xid marked as critical by GPUd
+// was reported for the GPU, with the suggested actions from the Xid catalog.
+// The GPUs are the given UUIDs (e.g., all the GPUs visible to NVML) and the ones found in the events,
+// and the dmesg events are resolved to the GPU UUIDs by the PCI bus number.
+// The events with an unknown GPU are not counted.
+func GPUStates(events []nvidia_query_xid_sxid_state.Event, uuids []string, busIDs map[uint32]string) []components.State {
+	type xidSeen struct {
+		detail *nvidia_query_xid.Detail
+		last   time.Time
+	}
+	critical := make(map[string]map[int]xidSeen)
+	for _, uuid := range uuids {
+		critical[uuid] = nil
+	}
+	for _, ev := range events {
+		uuid := ev.GPUUUID(busIDs)
+		if uuid == "" {
+			continue
+		}
+		if _, ok := critical[uuid]; !ok {
+			critical[uuid] = nil
+		}
+
+		d := ev.ToXidDetail()
+		if d == nil || !d.IsMarkedAsCriticalByGPUd() {
+			continue
+		}
+		if critical[uuid] == nil {
+			critical[uuid] = make(map[int]xidSeen)
+		}
+		ts := time.Unix(ev.UnixSeconds, 0).UTC()
+		if ts.After(critical[uuid][d.Xid].last) {
+			critical[uuid][d.Xid] = xidSeen{detail: d, last: ts}
+		}
+	}
+
+	all := make([]string, 0, len(critical))
+	for uuid := range critical {
+		all = append(all, uuid)
+	}
+	sort.Strings(all)
+
+	states := make([]components.State, 0, len(all))
+	for _, uuid := range all {
+		st := components.State{
+			Name:    StateNamePrefixErrorXidGPU + uuid,
+			Healthy: true,
+			Reason:  "no critical xid error",
+			ExtraInfo: map[string]string{
+				StateKeyGPUUUID: uuid,
+			},
+		}
+
+		seen := make([]xidSeen, 0, len(critical[uuid]))
+		for _, s := range critical[uuid] {
+			seen = append(seen, s)
+		}
+		if len(seen) == 0 {
+			states = append(states, st)
+			continue
+		}
+		sort.Slice(seen, func(i, j int) bool { return seen[i].detail.Xid < seen[j].detail.Xid })
+
+		reasons := make([]string, 0, len(seen))
+		xids := make([]string, 0, len(seen))
+		actions := &common.SuggestedActions{}
+		for _, s := range seen {
+			reasons = append(reasons, fmt.Sprintf("xid %d (%s) at %s", s.detail.Xid, s.detail.Name, s.last.Format(time.RFC3339)))
+			xids = append(xids, strconv.Itoa(s.detail.Xid))
+			mergeSuggestedActions(actions, s.detail.SuggestedActionsByGPUd)
+		}
+
+		st.Healthy = false
+		st.Reason = "critical xid error(s) found: " + strings.Join(reasons, ", ")
+		st.ExtraInfo[StateKeyXids] = strings.Join(xids, ",")
+		st.SuggestedActions = actions
+		states = append(states, st)
+	}
+	return states
+}
+
+// mergeSuggestedActions appends the descriptions and the repair actions not yet in the merged actions.
+func mergeSuggestedActions(merged *common.SuggestedActions, actions *common.SuggestedActions) {
+	if actions == nil {
+		return
+	}
+	for _, desc := range actions.Descriptions {
+		if !slices.Contains(merged.Descriptions, desc) {
+			merged.Descriptions = append(merged.Descriptions, desc)
+		}
+	}
+	for _, a := range actions.RepairActions {
+		if !slices.Contains(merged.RepairActions, a) {
+			merged.RepairActions = append(merged.RepairActions, a)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package xid
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	nvidia_query_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+	"github.com/leptonai/gpud/components/common"
+)
+
+func TestGPUStates(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0).UTC()
+	events := []nvidia_query_xid_sxid_state.Event{
+		// critical, from the dmesg resolved by the bus number
+		{UnixSeconds: now.Unix(), DataSource: "dmesg", EventType: "xid", EventID: 79, EventDetails: "NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus."},
+		// same xid from the nvml, the later one is reported
+		{UnixSeconds: now.Add(time.Second).Unix(), DataSource: "nvml", EventType: "xid", EventID: 79, EventDetails: "GPU-0"},
+		{UnixSeconds: now.Unix(), DataSource: "nvml", EventType: "xid", EventID: 48, EventDetails: "GPU-0"},
+		// non-critical
+		{UnixSeconds: now.Unix(), DataSource: "nvml", EventType: "xid", EventID: 13, EventDetails: "GPU-1"},
+		// unknown gpu
+		{UnixSeconds: now.Unix(), DataSource: "dmesg", EventType: "xid", EventID: 79, EventDetails: "NVRM: Xid (PCI:0000:99:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus."},
+		{UnixSeconds: now.Unix(), DataSource: "nvml", EventType: "xid", EventID: 79},
+		// sxid is not per gpu
+		{UnixSeconds: now.Unix(), DataSource: "dmesg", EventType: "sxid", EventID: 12028},
+		// gpu not visible to nvml (e.g., fallen off the bus)
+		{UnixSeconds: now.Unix(), DataSource: "nvml", EventType: "xid", EventID: 79, EventDetails: "GPU-3"},
+	}
+
+	states := GPUStates(events, []string{"GPU-0", "GPU-1", "GPU-2"}, map[uint32]string{0x05: "GPU-0", 0x19: "GPU-1"})
+	if len(states) != 4 {
+		t.Fatalf("expected 4 states, got %+v", states)
+	}
+
+	names := make([]string, 0, len(states))
+	for _, s := range states {
+		names = append(names, s.Name)
+	}
+	if want := []string{"error_xid_GPU-0", "error_xid_GPU-1", "error_xid_GPU-2", "error_xid_GPU-3"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected state names %v, want %v", names, want)
+	}
+
+	gpu0 := states[0]
+	if gpu0.Healthy || gpu0.ExtraInfo[StateKeyGPUUUID] != "GPU-0" || gpu0.ExtraInfo[StateKeyXids] != "48,79" {
+		t.Errorf("unexpected GPU-0 state: %+v", gpu0)
+	}
+	if want := "critical xid error(s) found: xid 48 (Double Bit ECC Error) at 2023-11-14T22:13:20Z, xid 79 (GPU has fallen off the bus) at 2023-11-14T22:13:21Z"; gpu0.Reason != want {
+		t.Errorf("unexpected reason %q, want %q", gpu0.Reason, want)
+	}
+	if gpu0.SuggestedActions == nil || !reflect.DeepEqual(gpu0.SuggestedActions.RepairActions, []common.RepairActionType{common.RepairActionTypeRebootSystem, common.RepairActionTypeHardwareInspection}) {
+		t.Errorf("unexpected suggested actions: %+v", gpu0.SuggestedActions)
+	}
+
+	for _, s := range states[1:3] {
+		if !s.Healthy || s.SuggestedActions != nil {
+			t.Errorf("expected healthy state, got %+v", s)
+		}
+	}
+	if states[3].Healthy || states[3].ExtraInfo[StateKeyXids] != "79" {
+		t.Errorf("unexpected GPU-3 state: %+v", states[3])
+	}
+}
+
+func TestGPUStatesEmpty(t *testing.T) {
+	t.Parallel()
+
+	if states := GPUStates(nil, nil, nil); len(states) != 0 {
+		t.Errorf("expected no state, got %+v", states)
+	}
+}
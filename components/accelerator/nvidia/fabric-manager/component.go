@@ -1,18 +1,24 @@
-// Package fabricmanager tracks the NVIDIA fabric manager version and its activeness.
+// Package fabricmanager tracks the NVIDIA fabric manager version, its activeness, and its compatibility with the driver.
 // And streams the fabric manager logs for any errors and events.
 package fabricmanager
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	fabric_manager_log "github.com/leptonai/gpud/components/accelerator/nvidia/query/fabric-manager-log"
+	nvidia_query_nvswitch_counters "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvswitch-counters"
 	"github.com/leptonai/gpud/components/query"
 	query_log "github.com/leptonai/gpud/components/query/log"
 	"github.com/leptonai/gpud/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const Name = "accelerator-nvidia-fabric-manager"
@@ -36,12 +42,22 @@ func New(ctx context.Context, cfg Config) (components.Component, error) {
 	}
 	fabric_manager_log.GetDefaultPoller().Start(cctx, cfg.Query, Name)
 
-	return &component{
+	c := &component{
 		rootCtx:   ctx,
 		cancel:    ccancel,
+		cfg:       cfg,
 		poller:    nvidia_query.GetDefaultPoller(),
 		logPoller: fabric_manager_log.GetDefaultPoller(),
-	}, nil
+	}
+	if cfg.PortCounters != nil {
+		cfg.PortCounters.SetDefaultsIfNotSet()
+		c.portCounters = nvidia_query_nvswitch_counters.NewTracker(cfg.PortCounters.Window.Duration)
+
+		var pctx context.Context
+		pctx, c.portCountersCancel = context.WithCancel(ctx)
+		go c.pollPortCounters(pctx)
+	}
+	return c, nil
 }
 
 var _ components.Component = (*component)(nil)
@@ -49,8 +65,15 @@ var _ components.Component = (*component)(nil)
 type component struct {
 	rootCtx   context.Context
 	cancel    context.CancelFunc
+	cfg       Config
 	poller    query.Poller
 	logPoller query_log.Poller
+
+	portCounters       *nvidia_query_nvswitch_counters.Tracker
+	portCountersCancel context.CancelFunc
+
+	eventsMu sync.RWMutex
+	events   []components.Event
 }
 
 func (c *component) Name() string { return Name }
@@ -70,6 +93,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -117,7 +143,96 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 		return cs, nil
 	}
 	output := ToOutput(allOutput)
-	return output.States()
+	output.NVSwitchInitFailures = c.findNVSwitchInitFailures(time.Now().Add(-nvswitchInitFailureLookback))
+	states, err := output.States()
+	if err != nil {
+		return nil, err
+	}
+	if c.portCounters != nil {
+		states = append(states, PortCountersState(c.portCounters.Status(), c.cfg.PortCounters.MaxLinkErrorsPerWindow))
+	}
+	return states, nil
+}
+
+const (
+	EventNamePortErrors = "nvswitch_port_errors"
+
+	// maxEvents is the maximum number of in-memory events to keep.
+	maxEvents = 100
+)
+
+// pollPortCounters polls the NVSwitch port counters, and records an event
+// whenever any port counts the new errors.
+func (c *component) pollPortCounters(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.PortCounters.Interval.Duration)
+	defer ticker.Stop()
+
+	for {
+		cctx, ccancel := context.WithTimeout(ctx, c.cfg.PortCounters.Timeout.Duration)
+		counters, err := nvidia_query_nvswitch_counters.Collect(cctx, c.cfg.PortCounters.Command)
+		ccancel()
+		if err != nil {
+			log.Logger.Warnw("failed to poll nvswitch port counters", "error", err)
+		}
+
+		now := time.Now().UTC()
+		if deltas := c.portCounters.Observe(now, counters, err); len(deltas) > 0 {
+			c.addEvent(portErrorsEvent(now, deltas))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func portErrorsEvent(now time.Time, deltas []nvidia_query_nvswitch_counters.PortCounters) components.Event {
+	ev := components.Event{
+		Time: metav1.Time{Time: now},
+		Name: EventNamePortErrors,
+		Type: components.EventTypeWarn,
+	}
+	ports := make([]string, 0, len(deltas))
+	for _, d := range deltas {
+		if d.FatalErrors > 0 {
+			ev.Type = components.EventTypeError
+		}
+		ports = append(ports, fmt.Sprintf("%s (fatal %d, non-fatal %d, link %d, ecc %d)", d.Key(), d.FatalErrors, d.NonFatalErrors, d.LinkErrors(), d.ECCErrors))
+	}
+	ev.Message = fmt.Sprintf("new errors on %d nvswitch port(s): %s", len(deltas), strings.Join(ports, ", "))
+	return ev
+}
+
+func (c *component) addEvent(ev components.Event) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+
+	c.events = append(c.events, ev)
+	if len(c.events) > maxEvents {
+		c.events = c.events[len(c.events)-maxEvents:]
+	}
+}
+
+// nvswitchInitFailureLookback is the period to look back for the NVSwitch initialization failures
+// in the fabric manager log, so that the component recovers once the fabric manager is restarted successfully.
+const nvswitchInitFailureLookback = 30 * time.Minute
+
+// findNVSwitchInitFailures returns the NVSwitch initialization failure log lines since the given time.
+func (c *component) findNVSwitchInitFailures(since time.Time) []string {
+	items, err := c.logPoller.Find(since, filterNVSwitchInitFailure)
+	if err != nil {
+		if !errors.Is(err, query.ErrNoData) {
+			log.Logger.Warnw("failed to find nvswitch initialization failures", "error", err)
+		}
+		return nil
+	}
+	var lines []string
+	for _, item := range items {
+		lines = append(lines, item.Line)
+	}
+	return lines
 }
 
 const (
@@ -130,11 +245,18 @@ const (
 // Returns `github.com/leptonai/gpud/components/query.ErrNoData` if there is no event found.
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
 	items, err := c.logPoller.Find(since)
-	if err != nil {
+	if err != nil && (!errors.Is(err, query.ErrNoData) || c.portCounters == nil) {
 		return nil, err
 	}
 
 	evs := make([]components.Event, 0)
+	c.eventsMu.RLock()
+	for _, ev := range c.events {
+		if !ev.Time.Time.Before(since) {
+			evs = append(evs, ev)
+		}
+	}
+	c.eventsMu.RUnlock()
 	for _, ev := range items {
 		b, _ := ev.Matched.JSON()
 		es := ""
@@ -171,5 +293,9 @@ func (c *component) Close() error {
 	_ = c.poller.Stop(Name)
 	c.logPoller.Stop(Name)
 
+	if c.portCountersCancel != nil {
+		c.portCountersCancel()
+	}
+
 	return nil
 }
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvswitch_counters "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvswitch-counters"
+	"github.com/leptonai/gpud/components/common"
 )
 
 // ToOutput converts nvidia_query.Output to Output.
@@ -21,12 +24,20 @@ func ToOutput(i *nvidia_query.Output) *Output {
 	if i.FabricManager != nil {
 		o.FabricManager = *i.FabricManager
 	}
+	if i.SMI != nil {
+		o.DriverVersion = i.SMI.DriverVersion
+	}
 
 	return o
 }
 
 type Output struct {
 	FabricManager nvidia_query.FabricManagerOutput `json:"fabric_manager"`
+
+	// DriverVersion is the NVIDIA driver version, which must match the fabric manager version.
+	DriverVersion string `json:"driver_version,omitempty"`
+	// NVSwitchInitFailures is the recent fabric manager log lines of the NVSwitch initialization failures.
+	NVSwitchInitFailures []string `json:"nvswitch_init_failures,omitempty"`
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -49,8 +60,17 @@ const (
 	StateValueFabricManagerEncodingJSON = "json"
 
 	// TODO: support compressed gzip
+
+	StateNamePortCounters = "nvswitch_port_counters"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameFabricManager, "fabric manager status"),
+		components.JSONStateSchema(StateNamePortCounters, "NVSwitch port error counter increments within the window"),
+	)
+}
+
 func ParseStateFabricManager(m map[string]string) (*Output, error) {
 	o := &Output{}
 	data := m[StateKeyFabricManagerData]
@@ -70,6 +90,10 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 			}
 			return o, nil
 
+		case StateNamePortCounters:
+			// derived from the port counter polling, not the fabric manager output
+			continue
+
 		default:
 			return nil, fmt.Errorf("unknown state name: %s", state.Name)
 		}
@@ -79,10 +103,31 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 
 // Returns the output evaluation reason and its healthy-ness.
 func (o *Output) Evaluate() (string, bool, error) {
-	if o.FabricManager.Active {
-		return "fabric-manager active", true, nil
+	reasons := make([]string, 0)
+	if !o.FabricManager.Active {
+		reasons = append(reasons, "fabric-manager inactive")
+	}
+	if !versionsCompatible(o.DriverVersion, o.FabricManager.Version) {
+		reasons = append(reasons, fmt.Sprintf("fabric-manager version %s does not match driver version %s", o.FabricManager.Version, o.DriverVersion))
 	}
-	return "fabric-manager inactive", true, nil
+	if len(o.NVSwitchInitFailures) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d nvswitch initialization failure(s) in fabric-manager log (latest %q)", len(o.NVSwitchInitFailures), o.NVSwitchInitFailures[len(o.NVSwitchInitFailures)-1]))
+	}
+	if len(reasons) > 0 {
+		return strings.Join(reasons, ", "), false, nil
+	}
+	return "fabric-manager active", true, nil
+}
+
+// versionsCompatible returns true if the fabric manager version matches the driver version,
+// or either version is unknown.
+// The fabric manager must be the exact same version as the driver (e.g., "535.161.08"),
+// otherwise the fabric manager fails to start or the NVSwitch initialization fails.
+func versionsCompatible(driverVersion, fabricManagerVersion string) bool {
+	if driverVersion == "" || fabricManagerVersion == "" {
+		return true
+	}
+	return driverVersion == fabricManagerVersion
 }
 
 func (o *Output) States() ([]components.State, error) {
@@ -100,5 +145,57 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyFabricManagerEncoding: StateValueFabricManagerEncodingJSON,
 		},
 	}
+	if !healthy {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"restart the nvidia-fabricmanager service (e.g., 'systemctl restart nvidia-fabricmanager'), after installing the fabric manager of the same version as the driver if mismatched",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRestartService,
+			},
+		}
+	}
 	return []components.State{state}, nil
 }
+
+// PortCountersState returns the NVSwitch port counters state, unhealthy if any port has the fatal errors
+// or the link errors above the threshold within the window.
+func PortCountersState(s *nvidia_query_nvswitch_counters.Status, maxLinkErrorsPerWindow uint64) components.State {
+	if s == nil {
+		return components.State{
+			Name:    StateNamePortCounters,
+			Healthy: true,
+			Reason:  "nvswitch port counters not polled yet",
+		}
+	}
+
+	b, _ := json.Marshal(s)
+	state := components.State{
+		Name:    StateNamePortCounters,
+		Healthy: true,
+		Reason:  fmt.Sprintf("%d nvswitch port(s) polled, %d port(s) with errors in the last %v", s.Ports, len(s.Increments), s.Window.Duration),
+		ExtraInfo: map[string]string{
+			StateKeyFabricManagerData:     string(b),
+			StateKeyFabricManagerEncoding: StateValueFabricManagerEncodingJSON,
+		},
+	}
+	if s.Error != "" {
+		state.Healthy = false
+		state.Error = s.Error
+		state.Reason = "nvswitch port counters poll failed"
+		return state
+	}
+	if reasons := s.Evaluate(maxLinkErrorsPerWindow); len(reasons) > 0 {
+		state.Healthy = false
+		state.Reason = strings.Join(reasons, ", ")
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"inspect the NVLink connections of the NVSwitch ports (e.g., the cables, the connectors, and the GPU baseboard)",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeHardwareInspection,
+			},
+		}
+	}
+	return state
+}
@@ -0,0 +1,83 @@
+package fabricmanager
+
+import (
+	"testing"
+	"time"
+
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvswitch_counters "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvswitch-counters"
+	"github.com/leptonai/gpud/components/common"
+)
+
+func TestOutputStates(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  Output
+		healthy bool
+	}{
+		{
+			name:    "active",
+			output:  Output{FabricManager: nvidia_query.FabricManagerOutput{Version: "535.161.08", Active: true}, DriverVersion: "535.161.08"},
+			healthy: true,
+		},
+		{
+			name:    "unknown driver version",
+			output:  Output{FabricManager: nvidia_query.FabricManagerOutput{Version: "535.161.08", Active: true}},
+			healthy: true,
+		},
+		{
+			name:   "inactive",
+			output: Output{FabricManager: nvidia_query.FabricManagerOutput{Version: "535.161.08"}, DriverVersion: "535.161.08"},
+		},
+		{
+			name:   "version mismatch",
+			output: Output{FabricManager: nvidia_query.FabricManagerOutput{Version: "535.161.08", Active: true}, DriverVersion: "535.183.01"},
+		},
+		{
+			name: "nvswitch init failure",
+			output: Output{
+				FabricManager:        nvidia_query.FabricManagerOutput{Version: "535.161.08", Active: true},
+				NVSwitchInitFailures: []string{"[ERROR] [tid 1203] failed to initialize NVSwitch pci bus id 00000000:86:00.0"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states, err := tt.output.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != 1 {
+				t.Fatalf("expected 1 state, got %d", len(states))
+			}
+			if states[0].Healthy != tt.healthy {
+				t.Errorf("expected healthy %v, got %v (%s)", tt.healthy, states[0].Healthy, states[0].Reason)
+			}
+			restart := states[0].SuggestedActions != nil &&
+				len(states[0].SuggestedActions.RepairActions) == 1 &&
+				states[0].SuggestedActions.RepairActions[0] == common.RepairActionTypeRestartService
+			if restart == tt.healthy {
+				t.Errorf("expected restart service suggested %v, got %+v", !tt.healthy, states[0].SuggestedActions)
+			}
+		})
+	}
+}
+
+func TestPortCountersState(t *testing.T) {
+	if st := PortCountersState(nil, 0); !st.Healthy {
+		t.Errorf("expected healthy state before the first poll, got %+v", st)
+	}
+
+	tr := nvidia_query_nvswitch_counters.NewTracker(time.Hour)
+	now := time.Now().UTC()
+	tr.Observe(now, []nvidia_query_nvswitch_counters.PortCounters{{Switch: "0000:86:00.0", Port: 33}}, nil)
+	tr.Observe(now.Add(time.Minute), []nvidia_query_nvswitch_counters.PortCounters{{Switch: "0000:86:00.0", Port: 33, ReplayErrors: 50}}, nil)
+
+	if st := PortCountersState(tr.Status(), 0); !st.Healthy {
+		t.Errorf("expected healthy state without the threshold, got %+v", st)
+	}
+	st := PortCountersState(tr.Status(), 10)
+	if st.Healthy || st.SuggestedActions == nil || st.SuggestedActions.RepairActions[0] != common.RepairActionTypeHardwareInspection {
+		t.Errorf("expected unhealthy state with the hardware inspection, got %+v", st)
+	}
+}
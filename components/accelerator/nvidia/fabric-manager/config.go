@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	fabric_manager_log "github.com/leptonai/gpud/components/accelerator/nvidia/query/fabric-manager-log"
+	nvidia_query_nvswitch_counters "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvswitch-counters"
 	query_config "github.com/leptonai/gpud/components/query/config"
 	query_log_common "github.com/leptonai/gpud/components/query/log/common"
 	query_log_config "github.com/leptonai/gpud/components/query/log/config"
@@ -15,6 +16,10 @@ import (
 type Config struct {
 	Query query_config.Config     `json:"query"`
 	Log   query_log_config.Config `json:"log"`
+
+	// PortCounters is the optional NVSwitch port error counter polling.
+	// Leave empty to only track the SXids from the fabric manager log.
+	PortCounters *nvidia_query_nvswitch_counters.Config `json:"port_counters,omitempty"`
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -34,6 +39,11 @@ func ParseConfig(b any, db *sql.DB) (*Config, error) {
 }
 
 func (cfg Config) Validate() error {
+	if cfg.PortCounters != nil {
+		if err := cfg.PortCounters.Validate(); err != nil {
+			return err
+		}
+	}
 	return cfg.Log.Validate()
 }
 
@@ -51,6 +61,10 @@ const (
 	// e.g.,
 	// [Sep 17 2024 06:01:46] [ERROR] [tid 1230079] failed to find the GPU handle 5410063385821516767 in the multicast team request setup 6130285411925746235.
 	eventNVSwitchNVLinkFailure = "accelerator-nvidia-fabric-manager-nvlink-failure"
+
+	// e.g.,
+	// [Oct 02 2024 10:15:31] [ERROR] [tid 1203] request to query NVSwitch device information from NVSwitch driver failed with error:WARNING Nothing to do
+	eventNVSwitchInitFailure = "accelerator-nvidia-fabric-manager-nvswitch-init-failure"
 )
 
 var (
@@ -70,6 +84,13 @@ var (
 			Regex:           ptr.To(fabric_manager_log.RegexNVSwitchNVLinkFailureFromLog),
 			OwnerReferences: []string{Name},
 		},
+		filterNVSwitchInitFailure,
+	}
+
+	filterNVSwitchInitFailure = &query_log_common.Filter{
+		Name:            eventNVSwitchInitFailure,
+		Regex:           ptr.To(fabric_manager_log.RegexNVSwitchInitFailureFromLog),
+		OwnerReferences: []string{Name},
 	}
 )
 
@@ -51,6 +51,12 @@ const (
 	StateValueGPMEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameGPM, "per-GPU GPM metrics"),
+	)
+}
+
 func ParseStateGPM(m map[string]string) (*Output, error) {
 	data := m[StateKeyGPMData]
 	return ParseOutputJSON([]byte(data))
@@ -0,0 +1,156 @@
+package gpucount
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	"github.com/leptonai/gpud/components/common"
+)
+
+type Output struct {
+	// GPU count from the PCI bus (sysfs), zero if not available.
+	PCICount int `json:"pci_count"`
+	// NVMLAvailable is false if the NVML query failed, in which case the NVML count is not compared.
+	NVMLAvailable bool `json:"nvml_available"`
+	// GPU count from the NVML library.
+	NVMLCount int `json:"nvml_count"`
+	// GPU device count from the /dev directory.
+	DeviceFileCount int `json:"device_file_count"`
+	// ExpectedCount is the operator-configured GPU count, zero if not set.
+	ExpectedCount int `json:"expected_count"`
+}
+
+func ToOutput(i *nvidia_query.Output, expectedCount int) *Output {
+	o := &Output{ExpectedCount: expectedCount}
+	if i == nil {
+		return o
+	}
+
+	o.PCICount = i.PCIGPUCount
+	o.DeviceFileCount = i.GPUDeviceCount
+	if i.NVML != nil && len(i.NVMLErrors) == 0 {
+		o.NVMLAvailable = true
+		o.NVMLCount = len(i.NVML.DeviceInfos)
+	}
+	return o
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameGPUCount = "gpu_count"
+
+	StateKeyGPUCountData           = "data"
+	StateKeyGPUCountEncoding       = "encoding"
+	StateValueGPUCountEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameGPUCount, "GPU counts across PCI, NVML, and the device files"),
+	)
+}
+
+func ParseStateGPUCount(m map[string]string) (*Output, error) {
+	data := m[StateKeyGPUCountData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameGPUCount:
+			o, err := ParseStateGPUCount(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reasons, its healthy-ness,
+// and the suggested actions if unhealthy.
+func (o *Output) Evaluate() (string, bool, *common.SuggestedActions) {
+	var reasons []string
+	actions := &common.SuggestedActions{}
+
+	// the PCI count is not available without the sysfs
+	if o.PCICount > 0 && o.NVMLAvailable {
+		switch {
+		case o.PCICount > o.NVMLCount:
+			// e.g., the GPU fallen off the driver (Xid 79) but still enumerated on the bus
+			reasons = append(reasons, fmt.Sprintf("%d GPU(s) on the PCI bus but only %d visible to NVML", o.PCICount, o.NVMLCount))
+			actions.Add(&common.SuggestedActions{
+				Descriptions:  []string{"reboot the system to re-initialize the GPUs missing from NVML"},
+				RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+			})
+		case o.PCICount < o.NVMLCount:
+			reasons = append(reasons, fmt.Sprintf("%d GPU(s) visible to NVML but only %d on the PCI bus", o.NVMLCount, o.PCICount))
+			actions.Add(&common.SuggestedActions{
+				Descriptions:  []string{"reboot the system to re-enumerate the PCI bus"},
+				RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+			})
+		}
+	}
+
+	if o.ExpectedCount > 0 {
+		found := o.PCICount
+		if o.NVMLAvailable && o.NVMLCount > found {
+			found = o.NVMLCount
+		}
+		if found < o.ExpectedCount {
+			// the GPU is missing from both the bus and NVML, no reboot would bring it back
+			reasons = append(reasons, fmt.Sprintf("expected %d GPU(s) but found %d", o.ExpectedCount, found))
+			actions.Add(&common.SuggestedActions{
+				Descriptions:  []string{"inspect the GPU hardware (e.g., the GPU missing from the PCI bus, the riser or baseboard failure)"},
+				RepairActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+			})
+		} else if found > o.ExpectedCount {
+			reasons = append(reasons, fmt.Sprintf("expected %d GPU(s) but found %d (check the expected_count config)", o.ExpectedCount, found))
+		}
+	}
+
+	if len(reasons) > 0 {
+		if len(actions.Descriptions) == 0 {
+			actions = nil
+		}
+		return strings.Join(reasons, ", "), false, actions
+	}
+	return fmt.Sprintf("gpu count consistent (pci %d, nvml %d, device files %d)", o.PCICount, o.NVMLCount, o.DeviceFileCount), true, nil
+}
+
+func (o *Output) States() ([]components.State, error) {
+	outputReasons, healthy, actions := o.Evaluate()
+	b, _ := o.JSON()
+	return []components.State{
+		{
+			Name:             StateNameGPUCount,
+			Healthy:          healthy,
+			Reason:           outputReasons,
+			SuggestedActions: actions,
+			ExtraInfo: map[string]string{
+				StateKeyGPUCountData:     string(b),
+				StateKeyGPUCountEncoding: StateValueGPUCountEncodingJSON,
+			},
+		},
+	}, nil
+}
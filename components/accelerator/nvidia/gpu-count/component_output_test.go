@@ -0,0 +1,125 @@
+package gpucount
+
+import (
+	"reflect"
+	"testing"
+
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+)
+
+func TestToOutput(t *testing.T) {
+	devs := func(n int) []*nvidia_query_nvml.DeviceInfo {
+		infos := make([]*nvidia_query_nvml.DeviceInfo, n)
+		for i := range infos {
+			infos[i] = &nvidia_query_nvml.DeviceInfo{BusID: uint32(i)}
+		}
+		return infos
+	}
+
+	tests := []struct {
+		name     string
+		input    *nvidia_query.Output
+		expected *Output
+	}{
+		{
+			name:     "nil input",
+			expected: &Output{ExpectedCount: 8},
+		},
+		{
+			name: "nvml available",
+			input: &nvidia_query.Output{
+				GPUDeviceCount: 8,
+				PCIGPUCount:    8,
+				NVML:           &nvidia_query_nvml.Output{DeviceInfos: devs(7)},
+			},
+			expected: &Output{PCICount: 8, NVMLAvailable: true, NVMLCount: 7, DeviceFileCount: 8, ExpectedCount: 8},
+		},
+		{
+			name: "nvml failed",
+			input: &nvidia_query.Output{
+				PCIGPUCount: 8,
+				NVML:        &nvidia_query_nvml.Output{DeviceInfos: devs(7)},
+				NVMLErrors:  []string{"failed"},
+			},
+			expected: &Output{PCICount: 8, ExpectedCount: 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToOutput(tt.input, 8)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ToOutput() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name            string
+		output          *Output
+		expectedHealthy bool
+		expectedActions []common.RepairActionType
+	}{
+		{
+			name:            "all consistent",
+			output:          &Output{PCICount: 8, NVMLAvailable: true, NVMLCount: 8, ExpectedCount: 8},
+			expectedHealthy: true,
+		},
+		{
+			name:            "no expected count",
+			output:          &Output{PCICount: 8, NVMLAvailable: true, NVMLCount: 8},
+			expectedHealthy: true,
+		},
+		{
+			name:            "lspci not found",
+			output:          &Output{NVMLAvailable: true, NVMLCount: 8},
+			expectedHealthy: true,
+		},
+		{
+			name:            "nvml failed",
+			output:          &Output{PCICount: 8},
+			expectedHealthy: true,
+		},
+		{
+			name:            "gpu missing from nvml but on the bus",
+			output:          &Output{PCICount: 8, NVMLAvailable: true, NVMLCount: 7, ExpectedCount: 8},
+			expectedHealthy: false,
+			expectedActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+		},
+		{
+			name:            "gpu missing from the bus but in nvml",
+			output:          &Output{PCICount: 7, NVMLAvailable: true, NVMLCount: 8},
+			expectedHealthy: false,
+			expectedActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+		},
+		{
+			name:            "gpu missing from both",
+			output:          &Output{PCICount: 7, NVMLAvailable: true, NVMLCount: 7, ExpectedCount: 8},
+			expectedHealthy: false,
+			expectedActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+		},
+		{
+			name:            "more gpus than expected",
+			output:          &Output{PCICount: 8, NVMLAvailable: true, NVMLCount: 8, ExpectedCount: 4},
+			expectedHealthy: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, healthy, actions := tt.output.Evaluate()
+			if healthy != tt.expectedHealthy {
+				t.Errorf("Evaluate() healthy = %v, want %v (reason %q)", healthy, tt.expectedHealthy, reason)
+			}
+			var gotActions []common.RepairActionType
+			if actions != nil {
+				gotActions = actions.RepairActions
+			}
+			if !reflect.DeepEqual(gotActions, tt.expectedActions) {
+				t.Errorf("Evaluate() actions = %v, want %v", gotActions, tt.expectedActions)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package gpucount
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// ExpectedCount is the number of GPUs expected on the node (e.g., 8 for the HGX systems).
+	// Zero to skip the expected count check.
+	ExpectedCount int `json:"expected_count"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	if cfg.ExpectedCount < 0 {
+		return errors.New("expected_count must be non-negative")
+	}
+	return nil
+}
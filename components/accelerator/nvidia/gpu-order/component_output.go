@@ -0,0 +1,422 @@
+package gpuorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query_gpu_order "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-order"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/shirou/gopsutil/v4/host"
+)
+
+const (
+	// CUDADeviceOrderFastestFirst orders the CUDA devices by the compute capability (the CUDA default),
+	// which only matches the PCI bus order when all the GPUs are the same model.
+	CUDADeviceOrderFastestFirst = "FASTEST_FIRST"
+	// CUDADeviceOrderPCIBusID orders the CUDA devices by the PCI bus ID, same as NVML by default.
+	CUDADeviceOrderPCIBusID = "PCI_BUS_ID"
+)
+
+const (
+	// IssueKindInconsistent is the NVML, PCI bus, and CUDA orders disagreeing in the current boot.
+	IssueKindInconsistent = "inconsistent"
+	// IssueKindReordered is a GPU enumerated differently than in the previous boot.
+	IssueKindReordered = "reordered"
+	// IssueKindCUDAVisibleDevices is a CUDA_VISIBLE_DEVICES entry that selects a different or no GPU.
+	IssueKindCUDAVisibleDevices = "cuda_visible_devices"
+)
+
+// GPU is the enumeration order of a GPU.
+type GPU struct {
+	UUID        string `json:"uuid"`
+	Name        string `json:"name"`
+	NVMLIndex   int    `json:"nvml_index"`
+	BusID       string `json:"bus_id"`
+	MinorNumber int    `json:"minor_number"`
+	// CUDAIndex is the CUDA device index, or -1 if the CUDA order is not deterministic.
+	CUDAIndex int `json:"cuda_index"`
+}
+
+type Issue struct {
+	Kind    string `json:"kind"`
+	UUID    string `json:"uuid,omitempty"`
+	Message string `json:"message"`
+}
+
+type Output struct {
+	BootUnixSeconds int64 `json:"boot_unix_seconds"`
+	// PreviousBootUnixSeconds is the boot compared against, or zero if no previous boot is recorded.
+	PreviousBootUnixSeconds int64 `json:"previous_boot_unix_seconds,omitempty"`
+
+	CUDADeviceOrder    string `json:"cuda_device_order"`
+	CUDAVisibleDevices string `json:"cuda_visible_devices,omitempty"`
+
+	// GPUs in the NVML index order.
+	GPUs   []GPU   `json:"gpus"`
+	Issues []Issue `json:"issues,omitempty"`
+}
+
+// CUDAIndexes returns the CUDA device index by the GPU UUID for the given CUDA_DEVICE_ORDER.
+// Returns nil if the order is not deterministic (mixed GPU models with "FASTEST_FIRST").
+func CUDAIndexes(orders []nvidia_query_nvml.GPUOrder, deviceOrder string) map[string]int {
+	sorted := make([]nvidia_query_nvml.GPUOrder, len(orders))
+	copy(sorted, orders)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].BusID < sorted[j].BusID })
+
+	if deviceOrder != CUDADeviceOrderPCIBusID {
+		// CUDA breaks the ties of the same compute capability by the PCI bus ID
+		for _, o := range sorted {
+			if o.Name != sorted[0].Name {
+				return nil
+			}
+		}
+	}
+
+	indexes := make(map[string]int, len(sorted))
+	for i, o := range sorted {
+		indexes[o.UUID] = i
+	}
+	return indexes
+}
+
+// ToOutput checks the NVML index, PCI bus, and CUDA orders of the current boot against each other,
+// and against the previous boot (if any) to detect the reorderings that break the pinned device assignments.
+func ToOutput(bootUnixSeconds int64, orders []nvidia_query_nvml.GPUOrder, previous []nvidia_query_gpu_order.Entry, cudaDeviceOrder string, cudaVisibleDevices string) *Output {
+	o := &Output{
+		BootUnixSeconds:    bootUnixSeconds,
+		CUDADeviceOrder:    cudaDeviceOrder,
+		CUDAVisibleDevices: cudaVisibleDevices,
+	}
+	if len(previous) > 0 {
+		o.PreviousBootUnixSeconds = previous[0].BootUnixSeconds
+	}
+
+	cudaIndexes := CUDAIndexes(orders, cudaDeviceOrder)
+	for _, g := range orders {
+		cudaIndex, ok := cudaIndexes[g.UUID]
+		if !ok {
+			cudaIndex = -1
+		}
+		o.GPUs = append(o.GPUs, GPU{
+			UUID:        g.UUID,
+			Name:        g.Name,
+			NVMLIndex:   g.Index,
+			BusID:       g.BusID,
+			MinorNumber: g.MinorNumber,
+			CUDAIndex:   cudaIndex,
+		})
+	}
+	sort.SliceStable(o.GPUs, func(i, j int) bool { return o.GPUs[i].NVMLIndex < o.GPUs[j].NVMLIndex })
+
+	o.checkConsistency(cudaIndexes != nil)
+	o.checkReorderings(previous)
+	o.checkCUDAVisibleDevices(previous)
+	return o
+}
+
+func (o *Output) checkConsistency(cudaDeterministic bool) {
+	if !cudaDeterministic {
+		o.Issues = append(o.Issues, Issue{
+			Kind:    IssueKindInconsistent,
+			Message: fmt.Sprintf("CUDA device order is not deterministic with mixed GPU models and CUDA_DEVICE_ORDER=%s", o.CUDADeviceOrder),
+		})
+	}
+
+	busIDs := make([]string, 0, len(o.GPUs))
+	for _, g := range o.GPUs {
+		busIDs = append(busIDs, g.BusID)
+	}
+	sort.Strings(busIDs)
+
+	for i, g := range o.GPUs {
+		if busIDs[i] != g.BusID {
+			o.Issues = append(o.Issues, Issue{
+				Kind:    IssueKindInconsistent,
+				UUID:    g.UUID,
+				Message: fmt.Sprintf("nvml index %d of %s does not follow the pci bus order (%s)", g.NVMLIndex, g.UUID, g.BusID),
+			})
+		}
+		if g.CUDAIndex >= 0 && g.CUDAIndex != g.NVMLIndex {
+			o.Issues = append(o.Issues, Issue{
+				Kind:    IssueKindInconsistent,
+				UUID:    g.UUID,
+				Message: fmt.Sprintf("cuda index %d of %s does not match the nvml index %d", g.CUDAIndex, g.UUID, g.NVMLIndex),
+			})
+		}
+	}
+}
+
+func (o *Output) checkReorderings(previous []nvidia_query_gpu_order.Entry) {
+	prev := make(map[string]nvidia_query_gpu_order.Entry, len(previous))
+	for _, e := range previous {
+		prev[e.GPUUUID] = e
+	}
+
+	for _, g := range o.GPUs {
+		p, ok := prev[g.UUID]
+		if !ok {
+			continue
+		}
+		var changes []string
+		if p.NVMLIndex != g.NVMLIndex {
+			changes = append(changes, fmt.Sprintf("nvml index %d -> %d", p.NVMLIndex, g.NVMLIndex))
+		}
+		if p.BusID != g.BusID {
+			changes = append(changes, fmt.Sprintf("pci bus %s -> %s", p.BusID, g.BusID))
+		}
+		if p.MinorNumber >= 0 && g.MinorNumber >= 0 && p.MinorNumber != g.MinorNumber {
+			changes = append(changes, fmt.Sprintf("minor number %d -> %d", p.MinorNumber, g.MinorNumber))
+		}
+		if p.CUDAIndex >= 0 && g.CUDAIndex >= 0 && p.CUDAIndex != g.CUDAIndex {
+			changes = append(changes, fmt.Sprintf("cuda index %d -> %d", p.CUDAIndex, g.CUDAIndex))
+		}
+		if len(changes) > 0 {
+			o.Issues = append(o.Issues, Issue{
+				Kind:    IssueKindReordered,
+				UUID:    g.UUID,
+				Message: fmt.Sprintf("%s reordered since the previous boot (%s)", g.UUID, strings.Join(changes, ", ")),
+			})
+		}
+	}
+}
+
+func (o *Output) checkCUDAVisibleDevices(previous []nvidia_query_gpu_order.Entry) {
+	if o.CUDAVisibleDevices == "" {
+		return
+	}
+
+	current := make(map[int]string, len(o.GPUs))
+	for _, g := range o.GPUs {
+		if g.CUDAIndex >= 0 {
+			current[g.CUDAIndex] = g.UUID
+		}
+	}
+	prev := make(map[int]string, len(previous))
+	for _, e := range previous {
+		if e.CUDAIndex >= 0 {
+			prev[e.CUDAIndex] = e.GPUUUID
+		}
+	}
+
+	for _, entry := range strings.Split(o.CUDAVisibleDevices, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		idx, err := strconv.Atoi(entry)
+		if err != nil {
+			// pinned by the (prefix of) GPU UUID, which is stable across reboots
+			found := false
+			for _, g := range o.GPUs {
+				if strings.HasPrefix(g.UUID, entry) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				o.Issues = append(o.Issues, Issue{
+					Kind:    IssueKindCUDAVisibleDevices,
+					Message: fmt.Sprintf("CUDA_VISIBLE_DEVICES entry %q does not match any GPU", entry),
+				})
+			}
+			continue
+		}
+
+		if idx < 0 || idx >= len(o.GPUs) {
+			o.Issues = append(o.Issues, Issue{
+				Kind:    IssueKindCUDAVisibleDevices,
+				Message: fmt.Sprintf("CUDA_VISIBLE_DEVICES entry %d is out of range (%d GPUs)", idx, len(o.GPUs)),
+			})
+			continue
+		}
+		cur, ok := current[idx]
+		if !ok {
+			continue
+		}
+		if was, ok := prev[idx]; ok && was != cur {
+			o.Issues = append(o.Issues, Issue{
+				Kind:    IssueKindCUDAVisibleDevices,
+				UUID:    cur,
+				Message: fmt.Sprintf("CUDA_VISIBLE_DEVICES entry %d selects %s (was %s in the previous boot)", idx, cur, was),
+			})
+		}
+	}
+}
+
+// Entries returns the GPU orders to record for the current boot.
+func (o *Output) Entries() []nvidia_query_gpu_order.Entry {
+	entries := make([]nvidia_query_gpu_order.Entry, 0, len(o.GPUs))
+	for _, g := range o.GPUs {
+		entries = append(entries, nvidia_query_gpu_order.Entry{
+			BootUnixSeconds: o.BootUnixSeconds,
+			GPUUUID:         g.UUID,
+			Name:            g.Name,
+			NVMLIndex:       g.NVMLIndex,
+			BusID:           g.BusID,
+			MinorNumber:     g.MinorNumber,
+			CUDAIndex:       g.CUDAIndex,
+		})
+	}
+	return entries
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameGPUOrder = "gpu_order"
+
+	StateKeyGPUOrderData           = "data"
+	StateKeyGPUOrderEncoding       = "encoding"
+	StateValueGPUOrderEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameGPUOrder, "GPU index order against the PCI bus order"),
+	)
+}
+
+func ParseStateGPUOrder(m map[string]string) (*Output, error) {
+	data := m[StateKeyGPUOrderData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameGPUOrder:
+			o, err := ParseStateGPUOrder(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool) {
+	if len(o.Issues) > 0 {
+		msgs := make([]string, 0, len(o.Issues))
+		for _, is := range o.Issues {
+			msgs = append(msgs, is.Message)
+		}
+		return strings.Join(msgs, "; "), false
+	}
+	if o.PreviousBootUnixSeconds == 0 {
+		return fmt.Sprintf("%d GPU(s) enumerated consistently (no previous boot to compare)", len(o.GPUs)), true
+	}
+	return fmt.Sprintf("%d GPU(s) enumerated consistently and in the same order as the previous boot", len(o.GPUs)), true
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, healthy := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameGPUOrder,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyGPUOrderData:     string(b),
+			StateKeyGPUOrderEncoding: StateValueGPUOrderEncodingJSON,
+		},
+	}
+	if !healthy {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"pin the GPUs by UUID instead of the index in the job specs (e.g., CUDA_VISIBLE_DEVICES=GPU-...)",
+				"set CUDA_DEVICE_ORDER=PCI_BUS_ID to match the nvidia-smi order",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeCheckUserAppAndGPU,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the nvml library
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg))
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+// CreateGet returns the get function that checks the GPU orders,
+// and records them for the current boot if the state database is configured.
+func CreateGet(cfg Config) query.GetFunc {
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		bootTime, err := host.BootTimeWithContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		orders, err := nvidia_query_nvml.GetGPUOrders(nvidia_query_nvml.DefaultProcDriverGPUsDir)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Query.State == nil || cfg.Query.State.DB == nil {
+			return ToOutput(int64(bootTime), orders, nil, cfg.CUDADeviceOrder, cfg.CUDAVisibleDevices), nil
+		}
+		db := cfg.Query.State.DB
+
+		if err := nvidia_query_gpu_order.CreateTableGPUOrder(ctx, db); err != nil {
+			return nil, err
+		}
+		previous, err := nvidia_query_gpu_order.ReadPreviousBootEntries(ctx, db, int64(bootTime))
+		if err != nil {
+			return nil, err
+		}
+
+		o := ToOutput(int64(bootTime), orders, previous, cfg.CUDADeviceOrder, cfg.CUDAVisibleDevices)
+		if err := nvidia_query_gpu_order.UpsertEntries(ctx, db, o.Entries()); err != nil {
+			log.Logger.Warnw("failed to record gpu orders", "error", err)
+		}
+		return o, nil
+	}
+}
@@ -0,0 +1,140 @@
+package gpuorder
+
+import (
+	"testing"
+
+	nvidia_query_gpu_order "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-order"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+func TestCUDAIndexes(t *testing.T) {
+	orders := []nvidia_query_nvml.GPUOrder{
+		{UUID: "GPU-b", Name: "H100", Index: 0, BusID: "0000:19:00.0"},
+		{UUID: "GPU-a", Name: "H100", Index: 1, BusID: "0000:18:00.0"},
+	}
+	indexes := CUDAIndexes(orders, CUDADeviceOrderFastestFirst)
+	if indexes["GPU-a"] != 0 || indexes["GPU-b"] != 1 {
+		t.Errorf("unexpected cuda indexes %v", indexes)
+	}
+
+	orders[1].Name = "A100"
+	if indexes := CUDAIndexes(orders, CUDADeviceOrderFastestFirst); indexes != nil {
+		t.Errorf("expected nil for mixed models, got %v", indexes)
+	}
+	if indexes := CUDAIndexes(orders, CUDADeviceOrderPCIBusID); len(indexes) != 2 {
+		t.Errorf("expected pci bus order for mixed models, got %v", indexes)
+	}
+}
+
+func TestToOutput(t *testing.T) {
+	stable := []nvidia_query_nvml.GPUOrder{
+		{UUID: "GPU-a", Name: "H100", Index: 0, BusID: "0000:18:00.0", MinorNumber: 0},
+		{UUID: "GPU-b", Name: "H100", Index: 1, BusID: "0000:19:00.0", MinorNumber: 1},
+	}
+	previous := []nvidia_query_gpu_order.Entry{
+		{BootUnixSeconds: 100, GPUUUID: "GPU-a", Name: "H100", NVMLIndex: 0, BusID: "0000:18:00.0", MinorNumber: 0, CUDAIndex: 0},
+		{BootUnixSeconds: 100, GPUUUID: "GPU-b", Name: "H100", NVMLIndex: 1, BusID: "0000:19:00.0", MinorNumber: 1, CUDAIndex: 1},
+	}
+	// re-enumerated after a reboot (e.g., BIOS PCI renumbering)
+	moved := []nvidia_query_nvml.GPUOrder{
+		{UUID: "GPU-b", Name: "H100", Index: 0, BusID: "0000:18:00.0", MinorNumber: 0},
+		{UUID: "GPU-a", Name: "H100", Index: 1, BusID: "0000:19:00.0", MinorNumber: 1},
+	}
+
+	tests := []struct {
+		name               string
+		orders             []nvidia_query_nvml.GPUOrder
+		previous           []nvidia_query_gpu_order.Entry
+		cudaDeviceOrder    string
+		cudaVisibleDevices string
+		wantHealthy        bool
+		wantKinds          []string
+	}{
+		{
+			name:            "no previous boot",
+			orders:          stable,
+			cudaDeviceOrder: CUDADeviceOrderFastestFirst,
+			wantHealthy:     true,
+		},
+		{
+			name:               "same order as previous boot",
+			orders:             stable,
+			previous:           previous,
+			cudaDeviceOrder:    CUDADeviceOrderFastestFirst,
+			cudaVisibleDevices: "0,1",
+			wantHealthy:        true,
+		},
+		{
+			name:            "nvml index does not follow pci bus order",
+			orders:          []nvidia_query_nvml.GPUOrder{{UUID: "GPU-a", Name: "H100", Index: 0, BusID: "0000:19:00.0"}, {UUID: "GPU-b", Name: "H100", Index: 1, BusID: "0000:18:00.0"}},
+			cudaDeviceOrder: CUDADeviceOrderPCIBusID,
+			wantHealthy:     false,
+			wantKinds:       []string{IssueKindInconsistent, IssueKindInconsistent, IssueKindInconsistent, IssueKindInconsistent},
+		},
+		{
+			name:            "mixed models with fastest first",
+			orders:          []nvidia_query_nvml.GPUOrder{{UUID: "GPU-a", Name: "H100", Index: 0, BusID: "0000:18:00.0"}, {UUID: "GPU-b", Name: "A100", Index: 1, BusID: "0000:19:00.0"}},
+			cudaDeviceOrder: CUDADeviceOrderFastestFirst,
+			wantHealthy:     false,
+			wantKinds:       []string{IssueKindInconsistent},
+		},
+		{
+			name:               "reordered since previous boot",
+			orders:             moved,
+			previous:           previous,
+			cudaDeviceOrder:    CUDADeviceOrderFastestFirst,
+			cudaVisibleDevices: "1",
+			wantHealthy:        false,
+			wantKinds:          []string{IssueKindReordered, IssueKindReordered, IssueKindCUDAVisibleDevices},
+		},
+		{
+			name:               "pinned by uuid",
+			orders:             moved,
+			previous:           previous,
+			cudaDeviceOrder:    CUDADeviceOrderFastestFirst,
+			cudaVisibleDevices: "GPU-a,GPU-c",
+			wantHealthy:        false,
+			wantKinds:          []string{IssueKindReordered, IssueKindReordered, IssueKindCUDAVisibleDevices},
+		},
+		{
+			name:               "index out of range",
+			orders:             stable,
+			cudaDeviceOrder:    CUDADeviceOrderFastestFirst,
+			cudaVisibleDevices: "0,2",
+			wantHealthy:        false,
+			wantKinds:          []string{IssueKindCUDAVisibleDevices},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := ToOutput(200, tt.orders, tt.previous, tt.cudaDeviceOrder, tt.cudaVisibleDevices)
+			reason, healthy := o.Evaluate()
+			if healthy != tt.wantHealthy {
+				t.Errorf("expected healthy %v, got %v (%s)", tt.wantHealthy, healthy, reason)
+			}
+			if len(o.Issues) != len(tt.wantKinds) {
+				t.Fatalf("expected %d issues, got %+v", len(tt.wantKinds), o.Issues)
+			}
+			for i, kind := range tt.wantKinds {
+				if o.Issues[i].Kind != kind {
+					t.Errorf("issue %d: expected kind %q, got %+v", i, kind, o.Issues[i])
+				}
+			}
+
+			states, err := o.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := ParseStatesToOutput(states...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed.GPUs) != len(o.GPUs) || len(parsed.Issues) != len(o.Issues) {
+				t.Errorf("expected %+v, got %+v", o, parsed)
+			}
+			if got := len(o.Entries()); got != len(tt.orders) {
+				t.Errorf("expected %d entries, got %d", len(tt.orders), got)
+			}
+		})
+	}
+}
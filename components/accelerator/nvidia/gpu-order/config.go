@@ -0,0 +1,54 @@
+package gpuorder
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// CUDADeviceOrder is the CUDA_DEVICE_ORDER of the jobs ("FASTEST_FIRST" or "PCI_BUS_ID").
+	// Defaults to "FASTEST_FIRST", the CUDA default.
+	CUDADeviceOrder string `json:"cuda_device_order,omitempty"`
+
+	// CUDAVisibleDevices is the CUDA_VISIBLE_DEVICES value pinned in the job specs (e.g., "0,1,2,3").
+	// The component is marked unhealthy if an index entry selects a different GPU than in the previous boot.
+	// Leave empty to only check the enumeration orders.
+	CUDAVisibleDevices string `json:"cuda_visible_devices,omitempty"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	switch cfg.CUDADeviceOrder {
+	case "", CUDADeviceOrderFastestFirst, CUDADeviceOrderPCIBusID:
+		return nil
+	default:
+		return fmt.Errorf("invalid cuda device order %q", cfg.CUDADeviceOrder)
+	}
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	cfg.Query.SetDefaultsIfNotSet()
+	if cfg.CUDADeviceOrder == "" {
+		cfg.CUDADeviceOrder = CUDADeviceOrderFastestFirst
+	}
+}
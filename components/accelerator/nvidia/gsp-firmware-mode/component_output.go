@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/leptonai/gpud/components"
+	nvidia_gsp_firmware_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode/id"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 )
@@ -52,6 +53,12 @@ const (
 	StateValueMemoryUsageEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(nvidia_gsp_firmware_mode_id.Name,
+		components.JSONStateSchema(StateNameGSPFirmwareMode, "per-GPU GSP firmware mode"),
+	)
+}
+
 func ParseStatePersistenceMode(m map[string]string) (*Output, error) {
 	data := m[StateKeyGSPFirmwareModeData]
 	return ParseOutputJSON([]byte(data))
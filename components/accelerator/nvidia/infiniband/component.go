@@ -5,6 +5,7 @@ package infiniband
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/leptonai/gpud/components"
@@ -12,10 +13,12 @@ import (
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/linkflap"
+	"github.com/leptonai/gpud/pkg/warmstate"
 )
 
 func New(ctx context.Context, cfg Config) components.Component {
-	cfg.Query.SetDefaultsIfNotSet()
+	cfg.SetDefaultsIfNotSet()
 
 	cctx, ccancel := context.WithCancel(ctx)
 	nvidia_query.SetDefaultPoller(cfg.Query.State.DB)
@@ -26,9 +29,34 @@ func New(ctx context.Context, cfg Config) components.Component {
 		cancel:  ccancel,
 		poller:  nvidia_query.GetDefaultPoller(),
 		cfg:     cfg,
+		flaps:   newFlapDetector(ctx, cfg),
 	}
 }
 
+// flapsStateKey is the warm state key of the port flap detector.
+const flapsStateKey = "linkflap/" + nvidia_infiniband_id.Name
+
+// newFlapDetector creates the port flap detector, restoring the flap counts
+// saved before the restart (if any), so a restart does not reset a flapping port to healthy.
+func newFlapDetector(ctx context.Context, cfg Config) *linkflap.Detector {
+	d := linkflap.New(cfg.FlapWindow.Duration)
+	if cfg.Query.State == nil || cfg.Query.State.DB == nil {
+		return d
+	}
+
+	var s linkflap.Snapshot
+	ok, err := warmstate.Load(ctx, cfg.Query.State.DB, flapsStateKey, cfg.FlapWindow.Duration, &s)
+	if err != nil {
+		log.Logger.Warnw("failed to load port flaps state", "error", err)
+		return d
+	}
+	if ok {
+		log.Logger.Debugw("restored port flaps state", "links", len(s.Links))
+		d.Restore(s)
+	}
+	return d
+}
+
 var _ components.Component = (*component)(nil)
 
 type component struct {
@@ -36,6 +64,10 @@ type component struct {
 	cancel  context.CancelFunc
 	poller  query.Poller
 	cfg     Config
+	flaps   *linkflap.Detector
+
+	flapsSavedMu sync.Mutex
+	flapsSavedAt time.Time
 }
 
 func (c *component) Name() string { return nvidia_infiniband_id.Name }
@@ -55,6 +87,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -79,6 +114,8 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	}
 
 	output := ToOutput(allOutput)
+	output.ObservePortFlaps(c.flaps, last.Time.Time, c.cfg.FlapThreshold)
+	c.saveFlaps(ctx, last.Time.Time)
 	return output.States(c.cfg)
 }
 
@@ -100,3 +137,21 @@ func (c *component) Close() error {
 
 	return nil
 }
+
+// saveFlaps persists the flap detector state once per poll.
+func (c *component) saveFlaps(ctx context.Context, observed time.Time) {
+	if c.cfg.Query.State == nil || c.cfg.Query.State.DB == nil {
+		return
+	}
+
+	c.flapsSavedMu.Lock()
+	defer c.flapsSavedMu.Unlock()
+	if !observed.After(c.flapsSavedAt) {
+		return
+	}
+	if err := warmstate.Save(ctx, c.cfg.Query.State.DB, flapsStateKey, c.flaps.Snapshot(), time.Now()); err != nil {
+		log.Logger.Warnw("failed to save port flaps state", "error", err)
+		return
+	}
+	c.flapsSavedAt = observed
+}
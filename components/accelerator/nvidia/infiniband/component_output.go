@@ -9,9 +9,11 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	nvidia_infiniband_id "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband/id"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	"github.com/leptonai/gpud/components/accelerator/nvidia/query/infiniband"
 	"github.com/leptonai/gpud/components/common"
+	"github.com/leptonai/gpud/pkg/linkflap"
 )
 
 // ToOutput converts nvidia_query.Output to Output.
@@ -46,6 +48,32 @@ type Output struct {
 	InfinibandClassExists bool                    `json:"infiniband_class_exists"`
 	IbstatExists          bool                    `json:"ibstat_exists"`
 	Ibstat                infiniband.IbstatOutput `json:"ibstat"`
+
+	// PortFlaps is the number of port down to up transitions
+	// within the flap window, keyed by the port ID (e.g., "mlx5_0/1").
+	PortFlaps map[string]int `json:"port_flaps,omitempty"`
+	// FlappingPorts is the list of ports whose flap count
+	// is equal to or greater than the flap threshold.
+	FlappingPorts []string `json:"flapping_ports,omitempty"`
+}
+
+// IBPortID returns the port identifier used for the flap detection.
+func IBPortID(ca string, port int) string {
+	return fmt.Sprintf("%s/%d", ca, port)
+}
+
+// ObservePortFlaps records the current ibstat port states of all the ports to the flap detector,
+// and sets the flap counts and the flapping ports with the threshold.
+// A port is considered up when its state is "Active" and physical state is "LinkUp".
+func (o *Output) ObservePortFlaps(detector *linkflap.Detector, ts time.Time, threshold int) {
+	for _, card := range o.Ibstat.Parsed {
+		for _, port := range card.Ports {
+			up := port.State == "Active" && port.PhysicalState == "LinkUp"
+			detector.Observe(IBPortID(card.Name, port.Port), up, ts)
+		}
+	}
+	o.PortFlaps = detector.Flaps(ts)
+	o.FlappingPorts = detector.Flapping(ts, threshold)
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -68,6 +96,15 @@ const (
 	StateValueIbstatEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(nvidia_infiniband_id.Name,
+		components.JSONStateSchema(StateNameIbstat, "ibstat port states",
+			components.ExtraInfoSchema{Key: nvidia_query.StateKeyGPUProductName, Type: components.ExtraInfoTypeString, Description: "GPU product name"},
+			components.ExtraInfoSchema{Key: nvidia_query.StateKeyIbstatExists, Type: components.ExtraInfoTypeBool, Description: "true if the ibstat binary exists"},
+		),
+	)
+}
+
 func ParseStateIbstat(m map[string]string) (*Output, error) {
 	o := &Output{}
 	data := m[StateKeyIbstatData]
@@ -100,6 +137,16 @@ func (o *Output) Evaluate(cfg Config) (string, bool, error) {
 		return fmt.Sprintf("%q GPUs do not support infiniband", o.GPUProductName), true, nil
 	}
 
+	// flapping ports are reported as unhealthy even if the ports are currently up,
+	// checked before counting the pci buses, as the flaps are only observed on the ports found by ibstat
+	if len(o.FlappingPorts) > 0 {
+		flaps := make([]string, 0, len(o.FlappingPorts))
+		for _, port := range o.FlappingPorts {
+			flaps = append(flaps, fmt.Sprintf("%s (%d flaps)", port, o.PortFlaps[port]))
+		}
+		return fmt.Sprintf("flapping infiniband port(s): %s", strings.Join(flaps, ", ")), false, nil
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	count, err := infiniband.CountInfinibandPCIBuses(ctx)
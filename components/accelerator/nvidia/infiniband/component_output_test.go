@@ -0,0 +1,87 @@
+package infiniband
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components/accelerator/nvidia/query/infiniband"
+	"github.com/leptonai/gpud/pkg/linkflap"
+)
+
+func TestObservePortFlaps(t *testing.T) {
+	// "mlx5_0" port 2 flaps while port 1 stays up,
+	// so the flaps must be observed on every port, not only on "Port 1"
+	newOutput := func(port2Up bool) *Output {
+		port2 := infiniband.IBStatPort{Port: 2, State: "Down", PhysicalState: "Polling"}
+		if port2Up {
+			port2 = infiniband.IBStatPort{Port: 2, State: "Active", PhysicalState: "LinkUp"}
+		}
+		port1 := infiniband.IBStatPort{Port: 1, State: "Active", PhysicalState: "LinkUp"}
+		return &Output{
+			GPUProductName: "NVIDIA H100 80GB HBM3",
+			Ibstat: infiniband.IbstatOutput{
+				Parsed: infiniband.IBStatCards{
+					{Name: "mlx5_0", Port1: port1, Ports: []infiniband.IBStatPort{port1, port2}},
+					{Name: "mlx5_1", Port1: port1, Ports: []infiniband.IBStatPort{port1}},
+				},
+			},
+		}
+	}
+
+	const threshold = 2
+	detector := linkflap.New(time.Hour)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// up -> down -> up -> down -> up, two flaps at +2m and +4m
+	var o *Output
+	for i, up := range []bool{true, false, true, false, true} {
+		o = newOutput(up)
+		o.ObservePortFlaps(detector, base.Add(time.Duration(i)*time.Minute), threshold)
+	}
+	if !reflect.DeepEqual(o.PortFlaps, map[string]int{"mlx5_0/2": 2}) {
+		t.Errorf("unexpected port flaps %v", o.PortFlaps)
+	}
+	if !reflect.DeepEqual(o.FlappingPorts, []string{"mlx5_0/2"}) {
+		t.Errorf("unexpected flapping ports %v", o.FlappingPorts)
+	}
+
+	states, err := o.States(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states[0].Healthy {
+		t.Fatalf("expected 1 unhealthy state, got %+v", states)
+	}
+	if !strings.Contains(states[0].Reason, "mlx5_0/2 (2 flaps)") {
+		t.Errorf("unexpected reason %q", states[0].Reason)
+	}
+	if states[0].SuggestedActions == nil {
+		t.Error("expected suggested actions for the flapping port")
+	}
+
+	// same flaps, but below the higher threshold
+	o = newOutput(true)
+	o.ObservePortFlaps(detector, base.Add(4*time.Minute), threshold+1)
+	if len(o.FlappingPorts) != 0 {
+		t.Errorf("expected no flapping port with threshold %d, got %v", threshold+1, o.FlappingPorts)
+	}
+
+	// the first flap slides out of the window
+	o = newOutput(true)
+	o.ObservePortFlaps(detector, base.Add(62*time.Minute+30*time.Second), threshold)
+	if !reflect.DeepEqual(o.PortFlaps, map[string]int{"mlx5_0/2": 1}) {
+		t.Errorf("unexpected port flaps %v", o.PortFlaps)
+	}
+	if len(o.FlappingPorts) != 0 {
+		t.Errorf("expected no flapping port, got %v", o.FlappingPorts)
+	}
+
+	// both flaps slide out of the window
+	o = newOutput(true)
+	o.ObservePortFlaps(detector, base.Add(65*time.Minute), threshold)
+	if len(o.PortFlaps) != 0 || len(o.FlappingPorts) != 0 {
+		t.Errorf("expected no flap, got %v, %v", o.PortFlaps, o.FlappingPorts)
+	}
+}
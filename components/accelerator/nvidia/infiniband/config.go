@@ -3,14 +3,36 @@ package infiniband
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 
 	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/pkg/linkflap"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type Config struct {
 	Query query_config.Config `json:"query"`
 
 	ExpectedPortStates
+
+	// FlapThreshold is the number of port down to up transitions within the flap window
+	// to report the port as unhealthy, even if the port is currently up.
+	// If not set, it defaults to linkflap.DefaultThreshold.
+	FlapThreshold int `json:"flap_threshold"`
+	// FlapWindow is the sliding window to count the port flaps.
+	// If not set, it defaults to linkflap.DefaultWindow.
+	FlapWindow metav1.Duration `json:"flap_window"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	cfg.Query.SetDefaultsIfNotSet()
+	if cfg.FlapThreshold == 0 {
+		cfg.FlapThreshold = linkflap.DefaultThreshold
+	}
+	if cfg.FlapWindow.Duration == 0 {
+		cfg.FlapWindow = metav1.Duration{Duration: linkflap.DefaultWindow}
+	}
 }
 
 // Configures the expected state of the ports.
@@ -41,5 +63,8 @@ func ParseConfig(b any, db *sql.DB) (*Config, error) {
 }
 
 func (cfg *Config) Validate() error {
+	if cfg.FlapThreshold < 0 {
+		return fmt.Errorf("flap threshold must be non-negative, got %d", cfg.FlapThreshold)
+	}
 	return nil
 }
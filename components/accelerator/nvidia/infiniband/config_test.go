@@ -26,6 +26,20 @@ func TestParseConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "valid config with flap threshold",
+			input: map[string]interface{}{
+				"port_count":     8,
+				"flap_threshold": 5,
+			},
+			wantErr: false,
+			want: Config{
+				ExpectedPortStates: ExpectedPortStates{
+					PortCount: 8,
+				},
+				FlapThreshold: 5,
+			},
+		},
 		{
 			name:    "empty config",
 			input:   map[string]interface{}{},
@@ -8,6 +8,8 @@ import (
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_driver_upgrade "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-upgrade"
+	nvidia_query_tray "github.com/leptonai/gpud/components/accelerator/nvidia/query/tray"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
 )
@@ -17,11 +19,16 @@ const Name = "accelerator-nvidia-info"
 func New(ctx context.Context, cfg Config) components.Component {
 	cfg.Query.SetDefaultsIfNotSet()
 
+	if cfg.Tray != nil {
+		nvidia_query_tray.SetDefaultSource(nvidia_query_tray.NewSource(*cfg.Tray))
+	}
+
 	cctx, ccancel := context.WithCancel(ctx)
 	nvidia_query.SetDefaultPoller(cfg.Query.State.DB)
 	nvidia_query.GetDefaultPoller().Start(cctx, cfg.Query, Name)
 
 	return &component{
+		cfg:     cfg,
 		rootCtx: ctx,
 		cancel:  ccancel,
 		poller:  nvidia_query.GetDefaultPoller(),
@@ -31,6 +38,7 @@ func New(ctx context.Context, cfg Config) components.Component {
 var _ components.Component = (*component)(nil)
 
 type component struct {
+	cfg     Config
 	rootCtx context.Context
 	cancel  context.CancelFunc
 	poller  query.Poller
@@ -53,6 +61,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -95,7 +106,19 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 }
 
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
-	return nil, nil
+	if c.cfg.Query.State == nil || c.cfg.Query.State.DB == nil {
+		return nil, nil
+	}
+
+	verdicts, err := nvidia_query_driver_upgrade.ReadVerdicts(ctx, c.cfg.Query.State.DB, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read driver upgrade verdicts: %w", err)
+	}
+	var evs []components.Event
+	for _, v := range verdicts {
+		evs = append(evs, v.ToComponentEvent())
+	}
+	return evs, nil
 }
 
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
@@ -124,6 +147,9 @@ func (c *component) Output() (any, error) {
 		log.Logger.Debugw("nothing found in last state (no data collected yet)", "component", Name)
 		return nil, query.ErrNoData
 	}
+	if nvidia_query.IsStackAbsent(last.Error) {
+		return nil, nil
+	}
 	if last.Error != nil {
 		return nil, last.Error
 	}
@@ -1,12 +1,15 @@
 package info
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
-	"github.com/dustin/go-humanize"
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_tray "github.com/leptonai/gpud/components/accelerator/nvidia/query/tray"
+	"github.com/leptonai/gpud/pkg/locale"
 )
 
 // ToOutput converts nvidia_query.Output to Output.
@@ -26,7 +29,7 @@ func ToOutput(i *nvidia_query.Output) *Output {
 			totalMemHumanized = parsed.TotalHumanized
 		} else if i.NVML != nil && len(i.NVML.DeviceInfos) > 0 {
 			totalMem = i.NVML.DeviceInfos[0].Memory.TotalBytes
-			totalMemHumanized = humanize.Bytes(i.NVML.DeviceInfos[0].Memory.TotalBytes)
+			totalMemHumanized = locale.Bytes(i.NVML.DeviceInfos[0].Memory.TotalBytes)
 		}
 	}
 
@@ -41,6 +44,10 @@ func ToOutput(i *nvidia_query.Output) *Output {
 		},
 	}
 
+	if i.Tray != nil || len(i.TrayErrors) > 0 {
+		o.Tray = &Tray{Data: i.Tray, Errors: i.TrayErrors}
+	}
+
 	if i.SMI != nil {
 		o.Driver.Version = i.SMI.DriverVersion
 		o.CUDA.Version = i.SMI.CUDAVersion
@@ -64,6 +71,14 @@ type Output struct {
 	GPU     GPU     `json:"gpu"`
 	Memory  Memory  `json:"memory"`
 	Product Product `json:"products"`
+
+	// Tray is the DGX/HGX tray-level data, only set if a tray source is configured.
+	Tray *Tray `json:"tray,omitempty"`
+}
+
+type Tray struct {
+	Data   *nvidia_query_tray.Data `json:"data,omitempty"`
+	Errors []string                `json:"errors,omitempty"`
 }
 
 type Driver struct {
@@ -113,8 +128,58 @@ const (
 	StateKeyProductName         = "name"
 	StateKeyProductBrand        = "brand"
 	StateKeyProductArchitecture = "architecture"
+
+	StateKeyTray         = "tray"
+	StateKeyTrayData     = "data"
+	StateKeyTrayEncoding = "encoding"
+	StateValueTrayJSON   = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateKeyDriver,
+			Description: "NVIDIA driver information",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyDriverVersion, Type: components.ExtraInfoTypeString, Description: "driver version"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateKeyCUDA,
+			Description: "CUDA information",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyCUDAVersion, Type: components.ExtraInfoTypeString, Description: "CUDA version"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateKeyGPU,
+			Description: "GPU counts, unhealthy if the device files and the attached GPUs mismatch",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyGPUDeviceCount, Type: components.ExtraInfoTypeInt, Unit: "GPUs", Description: "number of the GPU device files in /dev"},
+				{Key: StateKeyGPUAttached, Type: components.ExtraInfoTypeInt, Unit: "GPUs", Description: "number of the attached GPUs"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateKeyMemory,
+			Description: "GPU memory information",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyMemoryTotalBytes, Type: components.ExtraInfoTypeUint, Unit: "bytes", Description: "total memory per GPU"},
+				{Key: StateKeyMemoryTotalHumanized, Type: components.ExtraInfoTypeString, Description: "total memory per GPU, humanized"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateKeyProduct,
+			Description: "GPU product information",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyProductName, Type: components.ExtraInfoTypeString, Description: "product name (e.g., \"NVIDIA H100 80GB HBM3\")"},
+				{Key: StateKeyProductBrand, Type: components.ExtraInfoTypeString, Description: "product brand"},
+				{Key: StateKeyProductArchitecture, Type: components.ExtraInfoTypeString, Description: "product architecture (e.g., \"Hopper\")"},
+			},
+		},
+		components.JSONStateSchema(StateKeyTray, "GPU tray sensors from the BMC or nvsm"),
+	)
+}
+
 func ParseStateKeyDriver(m map[string]string) (Driver, error) {
 	d := Driver{}
 	d.Version = m[StateKeyDriver]
@@ -161,6 +226,14 @@ func ParseStateKeyProduct(m map[string]string) (Product, error) {
 	return p, nil
 }
 
+func ParseStateKeyTray(m map[string]string) (*Tray, error) {
+	t := new(Tray)
+	if err := json.Unmarshal([]byte(m[StateKeyTrayData]), t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
 func ParseStatesToOutput(states ...components.State) (*Output, error) {
 	o := &Output{}
 	for _, state := range states {
@@ -200,6 +273,13 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 			}
 			o.Product = product
 
+		case StateKeyTray:
+			tray, err := ParseStateKeyTray(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			o.Tray = tray
+
 		default:
 			return nil, fmt.Errorf("unknown state name: %s", state.Name)
 		}
@@ -254,5 +334,39 @@ func (o *Output) States() ([]components.State, error) {
 			},
 		},
 	}
+	if o.Tray != nil {
+		cs = append(cs, o.Tray.State())
+	}
 	return cs, nil
 }
+
+// State returns the tray state, unhealthy if any tray sensor is reported as critical.
+// The collection errors do not make the state unhealthy, since the BMC may be temporarily unreachable.
+func (t *Tray) State() components.State {
+	b, _ := json.Marshal(t)
+	state := components.State{
+		Name:    StateKeyTray,
+		Healthy: true,
+		ExtraInfo: map[string]string{
+			StateKeyTrayData:     string(b),
+			StateKeyTrayEncoding: StateValueTrayJSON,
+		},
+	}
+
+	unhealthy := t.Data.Unhealthy()
+	switch {
+	case len(unhealthy) > 0:
+		names := make([]string, 0, len(unhealthy))
+		for _, s := range unhealthy {
+			names = append(names, fmt.Sprintf("%s (%s)", s.Name, s.Kind))
+		}
+		state.Healthy = false
+		state.Reason = fmt.Sprintf("%d critical tray sensor(s) reported by %s: %s", len(unhealthy), t.Data.Source, strings.Join(names, ", "))
+	case t.Data != nil:
+		state.Reason = fmt.Sprintf("%d tray sensor(s) reported by %s", len(t.Data.Sensors), t.Data.Source)
+	default:
+		state.Reason = "failed to collect tray data: " + strings.Join(t.Errors, "; ")
+		state.Error = strings.Join(t.Errors, "; ")
+	}
+	return state
+}
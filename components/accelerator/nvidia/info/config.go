@@ -4,11 +4,17 @@ import (
 	"database/sql"
 	"encoding/json"
 
+	nvidia_query_tray "github.com/leptonai/gpud/components/accelerator/nvidia/query/tray"
 	query_config "github.com/leptonai/gpud/components/query/config"
 )
 
 type Config struct {
 	Query query_config.Config `json:"query"`
+
+	// Tray selects the source of the DGX/HGX tray-level data (DGX nvsm or HGX BMC Redfish),
+	// merged into the shared NVIDIA query output.
+	// Leave empty to not collect the tray-level data.
+	Tray *nvidia_query_tray.Config `json:"tray,omitempty"`
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -28,5 +34,10 @@ func ParseConfig(b any, db *sql.DB) (*Config, error) {
 }
 
 func (cfg Config) Validate() error {
+	if cfg.Tray != nil {
+		if err := cfg.Tray.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
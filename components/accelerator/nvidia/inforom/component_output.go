@@ -0,0 +1,202 @@
+package inforom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+)
+
+type Output struct {
+	InfoROMs []nvidia_query_nvml.InfoROM `json:"inforoms"`
+
+	// SMICorruptedBusIDs is the PCI bus IDs of the GPUs
+	// that "nvidia-smi" warns with the corrupted InfoROM.
+	SMICorruptedBusIDs []string `json:"smi_corrupted_bus_ids,omitempty"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameInfoROM = "inforom"
+
+	StateKeyInfoROMData           = "data"
+	StateKeyInfoROMEncoding       = "encoding"
+	StateValueInfoROMEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameInfoROM, "per-GPU InfoROM versions and validity"),
+	)
+}
+
+func ParseStateInfoROM(m map[string]string) (*Output, error) {
+	data := m[StateKeyInfoROMData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameInfoROM:
+			o, err := ParseStateInfoROM(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// CorruptedGPUs returns the GPUs with the corrupted InfoROM,
+// either failed the NVML checksum validation or warned by "nvidia-smi".
+// The GPUs are identified by the UUID, or by the bus ID if not found in the NVML output.
+func (o *Output) CorruptedGPUs() []string {
+	corrupted := make(map[string]struct{})
+	byBusID := make(map[string]string, len(o.InfoROMs))
+	for _, rom := range o.InfoROMs {
+		byBusID[nvidia_query.NormalizeBusID(rom.BusID)] = rom.UUID
+		if rom.Corrupted {
+			corrupted[rom.UUID] = struct{}{}
+		}
+	}
+	for _, id := range o.SMICorruptedBusIDs {
+		if uuid, ok := byBusID[nvidia_query.NormalizeBusID(id)]; ok {
+			corrupted[uuid] = struct{}{}
+			continue
+		}
+		corrupted[id] = struct{}{}
+	}
+
+	gpus := make([]string, 0, len(corrupted))
+	for id := range corrupted {
+		gpus = append(gpus, id)
+	}
+	sort.Strings(gpus)
+	return gpus
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool) {
+	if corrupted := o.CorruptedGPUs(); len(corrupted) > 0 {
+		return fmt.Sprintf("InfoROM corrupted on %d GPU(s): %s", len(corrupted), strings.Join(corrupted, ", ")), false
+	}
+
+	vbios := make(map[string]struct{})
+	images := make(map[string]struct{})
+	for _, rom := range o.InfoROMs {
+		if rom.VBIOSVersion != "" {
+			vbios[rom.VBIOSVersion] = struct{}{}
+		}
+		if rom.ImageVersion != "" {
+			images[rom.ImageVersion] = struct{}{}
+		}
+	}
+	return fmt.Sprintf("InfoROM valid on %d GPU(s) (VBIOS versions %s, InfoROM image versions %s)", len(o.InfoROMs), joinKeys(vbios), joinKeys(images)), true
+}
+
+func joinKeys(m map[string]struct{}) string {
+	if len(m) == 0 {
+		return "unknown"
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, healthy := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameInfoROM,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyInfoROMData:     string(b),
+			StateKeyInfoROMEncoding: StateValueInfoROMEncodingJSON,
+		},
+	}
+	if !healthy {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"the corrupted InfoROM may not record the ECC errors and row remappings, the GPU needs to be reflashed or replaced",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeHardwareInspection,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the nvml library
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, Get)
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+func Get(ctx context.Context) (_ any, e error) {
+	defer func() {
+		if e != nil {
+			components_metrics.SetGetFailed(Name)
+		} else {
+			components_metrics.SetGetSuccess(Name)
+		}
+	}()
+
+	roms, err := nvidia_query_nvml.GetInfoROMs()
+	if err != nil {
+		return nil, err
+	}
+	o := &Output{InfoROMs: roms}
+
+	if nvidia_query.SMIExists() {
+		// the NVML validation is the primary check, "nvidia-smi" only adds its warnings
+		b, err := nvidia_query.RunSMI(ctx)
+		if err != nil {
+			log.Logger.Debugw("nvidia-smi failed", "component", Name, "error", err)
+		}
+		o.SMICorruptedBusIDs = nvidia_query.FindInfoROMCorruptions(string(b))
+	}
+	return o, nil
+}
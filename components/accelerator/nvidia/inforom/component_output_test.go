@@ -0,0 +1,57 @@
+package inforom
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+func TestOutputEvaluate(t *testing.T) {
+	t.Parallel()
+
+	roms := []nvidia_query_nvml.InfoROM{
+		{UUID: "GPU-0", BusID: "00000000:05:00.0", VBIOSVersion: "96.00.89.00.01", ImageVersion: "G520.0200.00.05", Supported: true},
+		{UUID: "GPU-1", BusID: "00000000:0B:00.0", VBIOSVersion: "96.00.89.00.01", ImageVersion: "G520.0200.00.05", Supported: true},
+	}
+
+	o := &Output{InfoROMs: roms}
+	reason, healthy := o.Evaluate()
+	if !healthy {
+		t.Fatalf("expected healthy, got %q", reason)
+	}
+	if !strings.Contains(reason, "96.00.89.00.01") || !strings.Contains(reason, "G520.0200.00.05") {
+		t.Errorf("expected the versions in the reason, got %q", reason)
+	}
+
+	corrupted := append([]nvidia_query_nvml.InfoROM{}, roms...)
+	corrupted[1].Corrupted = true
+	o = &Output{
+		InfoROMs: corrupted,
+		// the same GPU as the NVML result, and a GPU not visible to NVML
+		SMICorruptedBusIDs: []string{"0000:0b:00.0", "0000:3A:00.0"},
+	}
+	if got, want := o.CorruptedGPUs(), []string{"0000:3A:00.0", "GPU-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CorruptedGPUs() = %v, want %v", got, want)
+	}
+
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states[0].Healthy {
+		t.Fatalf("expected 1 unhealthy state, got %+v", states)
+	}
+	if states[0].SuggestedActions == nil || len(states[0].SuggestedActions.RepairActions) != 1 {
+		t.Fatalf("expected hardware repair suggested action, got %+v", states[0].SuggestedActions)
+	}
+
+	parsed, err := ParseStatesToOutput(states...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, o) {
+		t.Errorf("expected %+v, got %+v", o, parsed)
+	}
+}
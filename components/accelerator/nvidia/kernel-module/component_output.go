@@ -0,0 +1,224 @@
+package kernelmodule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/common"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+)
+
+type Output struct {
+	// Expected is the kernel modules expected to be loaded.
+	Expected []string `json:"expected"`
+	// Loaded is the loaded NVIDIA kernel modules.
+	Loaded []Module `json:"loaded"`
+	// Missing is the expected modules not loaded.
+	Missing []string `json:"missing,omitempty"`
+	// NotLive is the expected modules loaded but not in the "Live" state
+	// (e.g., stuck in "Loading" after a failed initialization).
+	NotLive []string `json:"not_live,omitempty"`
+
+	// Taints is the kernel taint flags.
+	Taints []TaintFlag `json:"taints,omitempty"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameKernelModule = "kernel_module"
+
+	StateKeyKernelModuleData           = "data"
+	StateKeyKernelModuleEncoding       = "encoding"
+	StateValueKernelModuleEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameKernelModule, "expected NVIDIA kernel modules loaded"),
+	)
+}
+
+func ParseStateKernelModule(m map[string]string) (*Output, error) {
+	data := m[StateKeyKernelModuleData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameKernelModule:
+			o, err := ParseStateKernelModule(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// ModuleLoadFailureTaints returns the taint flags from the forced or failed module loads.
+func (o *Output) ModuleLoadFailureTaints() []TaintFlag {
+	var flags []TaintFlag
+	for _, f := range o.Taints {
+		if f.ModuleLoadFailure {
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool) {
+	var reasons []string
+	if len(o.Missing) > 0 {
+		reasons = append(reasons, fmt.Sprintf("kernel module(s) not loaded: %s", strings.Join(o.Missing, ", ")))
+	}
+	if len(o.NotLive) > 0 {
+		reasons = append(reasons, fmt.Sprintf("kernel module(s) not live: %s", strings.Join(o.NotLive, ", ")))
+	}
+	if taints := o.ModuleLoadFailureTaints(); len(taints) > 0 {
+		descs := make([]string, 0, len(taints))
+		for _, f := range taints {
+			descs = append(descs, fmt.Sprintf("%s (%s)", f.Letter, f.Description))
+		}
+		reasons = append(reasons, fmt.Sprintf("kernel tainted: %s", strings.Join(descs, ", ")))
+	}
+	if len(reasons) > 0 {
+		return strings.Join(reasons, "; "), false
+	}
+	return fmt.Sprintf("all %d expected kernel module(s) loaded (%s)", len(o.Expected), strings.Join(o.Expected, ", ")), true
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, healthy := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameKernelModule,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyKernelModuleData:     string(b),
+			StateKeyKernelModuleEncoding: StateValueKernelModuleEncodingJSON,
+		},
+	}
+
+	switch {
+	case len(o.Missing) > 0 || len(o.NotLive) > 0:
+		modules := make([]string, 0, len(o.Missing)+len(o.NotLive))
+		modules = append(modules, o.Missing...)
+		modules = append(modules, o.NotLive...)
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				fmt.Sprintf("reload the NVIDIA driver to load the missing kernel module(s) (e.g., 'modprobe %s'), and reboot the system if the reload fails", strings.Join(modules, " ")),
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeReloadDriver,
+			},
+		}
+	case !healthy:
+		// the taint flags are only cleared by the reboot
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"the kernel is tainted by a forced or failed module load, reboot the system",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRebootSystem,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the configured modules
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg))
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+func CreateGet(cfg Config) query.GetFunc {
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		b, err := os.ReadFile(DefaultProcModulesPath)
+		if err != nil {
+			return nil, err
+		}
+		modules, err := ParseProcModules(b)
+		if err != nil {
+			return nil, err
+		}
+		o := newOutput(cfg.Modules, modules)
+
+		b, err = os.ReadFile(DefaultTaintedPath)
+		if err != nil {
+			return nil, err
+		}
+		o.Taints, err = ParseTainted(b)
+		if err != nil {
+			return nil, err
+		}
+		return o, nil
+	}
+}
+
+func newOutput(expected []string, modules []Module) *Output {
+	o := &Output{Expected: expected}
+
+	loaded := make(map[string]Module)
+	for _, m := range modules {
+		if strings.HasPrefix(m.Name, "nvidia") {
+			o.Loaded = append(o.Loaded, m)
+		}
+		loaded[m.Name] = m
+	}
+	for _, name := range expected {
+		m, ok := loaded[name]
+		if !ok {
+			o.Missing = append(o.Missing, name)
+			continue
+		}
+		if m.State != ModuleStateLive {
+			o.NotLive = append(o.NotLive, name)
+		}
+	}
+	return o
+}
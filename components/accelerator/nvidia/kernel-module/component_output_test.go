@@ -0,0 +1,126 @@
+package kernelmodule
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testProcModules = `nvidia_peermem 16384 0 - Live 0x0000000000000000 (POE)
+nvidia_uvm 1437696 0 - Loading 0x0000000000000000 (POE)
+nvidia_drm 86016 0 - Live 0x0000000000000000 (POE)
+nvidia 54403072 3 nvidia_peermem,nvidia_uvm,nvidia_drm, Live 0x0000000000000000 (POE)
+ib_core 434176 9 nvidia_peermem,rdma_cm,ib_cm, Live 0x0000000000000000
+`
+
+func TestParseProcModules(t *testing.T) {
+	modules, err := ParseProcModules([]byte(testProcModules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Module{
+		{Name: "ib_core", RefCount: 9, State: "Live"},
+		{Name: "nvidia", RefCount: 3, State: "Live", Taints: "POE"},
+		{Name: "nvidia_drm", RefCount: 0, State: "Live", Taints: "POE"},
+		{Name: "nvidia_peermem", RefCount: 0, State: "Live", Taints: "POE"},
+		{Name: "nvidia_uvm", RefCount: 0, State: "Loading", Taints: "POE"},
+	}
+	if !reflect.DeepEqual(modules, want) {
+		t.Errorf("expected %+v, got %+v", want, modules)
+	}
+
+	if _, err := ParseProcModules([]byte("nvidia 54403072\n")); err == nil {
+		t.Error("expected an error for the invalid line")
+	}
+}
+
+func TestParseTainted(t *testing.T) {
+	// P, O, E (4097 + 8192)
+	flags, err := ParseTainted([]byte("12289\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	letters := ""
+	for _, f := range flags {
+		letters += f.Letter
+	}
+	if letters != "POE" {
+		t.Errorf("expected POE, got %q", letters)
+	}
+	if o := (&Output{Taints: flags}); len(o.ModuleLoadFailureTaints()) != 0 {
+		t.Errorf("expected no module load failure taint, got %+v", o.ModuleLoadFailureTaints())
+	}
+
+	// P, F
+	flags, err = ParseTainted([]byte("3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := (&Output{Taints: flags}); len(o.ModuleLoadFailureTaints()) != 1 {
+		t.Errorf("expected the force loaded taint, got %+v", o.ModuleLoadFailureTaints())
+	}
+}
+
+func TestOutputStates(t *testing.T) {
+	modules, err := ParseProcModules([]byte(testProcModules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		expected   []string
+		taints     []TaintFlag
+		wantHealth bool
+		wantReload bool
+		wantReboot bool
+	}{
+		{
+			name:       "all loaded",
+			expected:   []string{"nvidia", "nvidia_peermem"},
+			taints:     []TaintFlag{taintFlags[0], taintFlags[12], taintFlags[13]},
+			wantHealth: true,
+		},
+		{
+			name:       "missing and loading",
+			expected:   []string{"nvidia", "nvidia_uvm", "nvidia_modeset"},
+			wantReload: true,
+		},
+		{
+			name:       "force loaded",
+			expected:   []string{"nvidia"},
+			taints:     []TaintFlag{taintFlags[1]},
+			wantReboot: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOutput(tt.expected, modules)
+			o.Taints = tt.taints
+			if len(o.Loaded) != 4 {
+				t.Errorf("expected 4 nvidia modules, got %d", len(o.Loaded))
+			}
+
+			states, err := o.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if states[0].Healthy != tt.wantHealth {
+				t.Errorf("expected healthy %v, got %+v", tt.wantHealth, states[0])
+			}
+			if got := states[0].SuggestedActions.RequiresDriverReload(); got != tt.wantReload {
+				t.Errorf("expected driver reload %v, got %v", tt.wantReload, got)
+			}
+			if got := states[0].SuggestedActions.RequiresReboot(); got != tt.wantReboot {
+				t.Errorf("expected reboot %v, got %v", tt.wantReboot, got)
+			}
+
+			parsed, err := ParseStatesToOutput(states...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parsed.Missing, o.Missing) || !reflect.DeepEqual(parsed.NotLive, o.NotLive) {
+				t.Errorf("expected %+v, got %+v", o, parsed)
+			}
+		})
+	}
+}
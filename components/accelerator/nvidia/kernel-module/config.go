@@ -0,0 +1,55 @@
+package kernelmodule
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+// DefaultModules is the NVIDIA kernel modules expected to be loaded by default.
+// "nvidia_peermem" is only expected with the RDMA devices (GPUDirect RDMA).
+var DefaultModules = []string{"nvidia", "nvidia_uvm"}
+
+// ModulePeermem is the kernel module for GPUDirect RDMA.
+const ModulePeermem = "nvidia_peermem"
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// Modules is the kernel modules expected to be loaded.
+	// If not set, it defaults to "nvidia" and "nvidia_uvm".
+	Modules []string `json:"modules"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if len(cfg.Modules) == 0 {
+		cfg.Modules = DefaultModules
+	}
+}
+
+func (cfg Config) Validate() error {
+	for _, m := range cfg.Modules {
+		if m == "" {
+			return errors.New("empty module name")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package kernelmodule
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultProcModulesPath = "/proc/modules"
+	DefaultTaintedPath     = "/proc/sys/kernel/tainted"
+)
+
+const (
+	// ModuleStateLive is the state of the module loaded successfully.
+	ModuleStateLive = "Live"
+)
+
+// Module is a loaded kernel module in "/proc/modules".
+type Module struct {
+	Name     string `json:"name"`
+	RefCount int    `json:"ref_count"`
+	// State is the module state ("Live", "Loading", or "Unloading").
+	State string `json:"state"`
+	// Taints is the module taint flags (e.g., "POE"), empty if not tainted.
+	Taints string `json:"taints,omitempty"`
+}
+
+// ParseProcModules parses the "/proc/modules" file, for example:
+//
+//	nvidia_uvm 1437696 0 - Live 0x0000000000000000 (POE)
+//	nvidia 54403072 1 nvidia_uvm, Live 0x0000000000000000 (POE)
+//
+// ref. https://man7.org/linux/man-pages/man5/proc_modules.5.html
+func ParseProcModules(b []byte) ([]Module, error) {
+	var modules []Module
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("invalid module line %q", scanner.Text())
+		}
+		refCount, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid module reference count %q: %w", fields[2], err)
+		}
+		m := Module{
+			Name:     fields[0],
+			RefCount: refCount,
+			State:    fields[4],
+		}
+		if len(fields) > 6 {
+			m.Taints = strings.Trim(fields[6], "()")
+		}
+		modules = append(modules, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Name < modules[j].Name
+	})
+	return modules, nil
+}
+
+// TaintFlag is a kernel taint flag.
+// ref. https://docs.kernel.org/admin-guide/tainted-kernels.html
+type TaintFlag struct {
+	Bit         int    `json:"bit"`
+	Letter      string `json:"letter"`
+	Description string `json:"description"`
+	// ModuleLoadFailure is true if the taint indicates a forced (or failed) module load or unload,
+	// or a kernel oops (e.g., in the module initialization).
+	ModuleLoadFailure bool `json:"module_load_failure"`
+}
+
+var taintFlags = []TaintFlag{
+	{Bit: 0, Letter: "P", Description: "proprietary module was loaded"},
+	{Bit: 1, Letter: "F", Description: "module was force loaded", ModuleLoadFailure: true},
+	{Bit: 2, Letter: "S", Description: "kernel running on an out of specification system"},
+	{Bit: 3, Letter: "R", Description: "module was force unloaded", ModuleLoadFailure: true},
+	{Bit: 4, Letter: "M", Description: "processor reported a machine check exception"},
+	{Bit: 5, Letter: "B", Description: "bad page referenced or some unexpected page flags"},
+	{Bit: 6, Letter: "U", Description: "taint requested by userspace application"},
+	{Bit: 7, Letter: "D", Description: "kernel died recently, i.e. there was an OOPS or BUG", ModuleLoadFailure: true},
+	{Bit: 8, Letter: "A", Description: "ACPI table overridden by user"},
+	{Bit: 9, Letter: "W", Description: "kernel issued warning"},
+	{Bit: 10, Letter: "C", Description: "staging driver was loaded"},
+	{Bit: 11, Letter: "I", Description: "workaround for bug in platform firmware applied"},
+	{Bit: 12, Letter: "O", Description: "externally-built (out-of-tree) module was loaded"},
+	{Bit: 13, Letter: "E", Description: "unsigned module was loaded"},
+	{Bit: 14, Letter: "L", Description: "soft lockup occurred"},
+	{Bit: 15, Letter: "K", Description: "kernel has been live patched"},
+	{Bit: 16, Letter: "X", Description: "auxiliary taint, defined for and used by distros"},
+	{Bit: 17, Letter: "T", Description: "kernel was built with the struct randomization plugin"},
+	{Bit: 18, Letter: "N", Description: "an in-kernel test has been run"},
+}
+
+// ParseTainted parses the "/proc/sys/kernel/tainted" value into the taint flags.
+func ParseTainted(b []byte) ([]TaintFlag, error) {
+	v, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tainted value %q: %w", strings.TrimSpace(string(b)), err)
+	}
+	var flags []TaintFlag
+	for _, f := range taintFlags {
+		if v&(1<<f.Bit) != 0 {
+			flags = append(flags, f)
+		}
+	}
+	return flags, nil
+}
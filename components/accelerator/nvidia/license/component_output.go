@@ -0,0 +1,165 @@
+package license
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+)
+
+type Output struct {
+	Licenses []nvidia_query_nvml.GPULicense `json:"licenses"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameLicense = "license"
+
+	StateKeyLicenseData           = "data"
+	StateKeyLicenseEncoding       = "encoding"
+	StateValueLicenseEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameLicense, "per-GPU enterprise feature entitlements"),
+	)
+}
+
+func ParseStateLicense(m map[string]string) (*Output, error) {
+	data := m[StateKeyLicenseData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameLicense:
+			o, err := ParseStateLicense(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate(requiredFeature string, now time.Time) (string, bool) {
+	var (
+		licensed   []string
+		unlicensed []string
+	)
+	for _, l := range o.Licenses {
+		found := false
+		for _, f := range l.Features {
+			if !f.Licensed {
+				continue
+			}
+			if f.Expired(now) {
+				if f.Feature == requiredFeature {
+					unlicensed = append(unlicensed, fmt.Sprintf("%s %q license expired", l.UUID, f.Feature))
+					found = true
+				}
+				continue
+			}
+			licensed = append(licensed, fmt.Sprintf("%s %q", l.UUID, f.Feature))
+			if f.Feature == requiredFeature {
+				found = true
+			}
+		}
+		if requiredFeature != "" && !found {
+			unlicensed = append(unlicensed, fmt.Sprintf("%s not licensed for %q (virtualization mode %q)", l.UUID, requiredFeature, l.VirtualizationMode))
+		}
+	}
+
+	if len(unlicensed) > 0 {
+		return strings.Join(unlicensed, "; "), false
+	}
+	if len(licensed) == 0 {
+		return fmt.Sprintf("no licensed enterprise feature found on %d GPU(s)", len(o.Licenses)), true
+	}
+	return "licensed: " + strings.Join(licensed, ", "), true
+}
+
+func (o *Output) States(cfg Config) ([]components.State, error) {
+	reason, healthy := o.Evaluate(cfg.RequiredFeature, time.Now().UTC())
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameLicense,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyLicenseData:     string(b),
+			StateKeyLicenseEncoding: StateValueLicenseEncodingJSON,
+		},
+	}
+	if !healthy {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"check the license server (client configuration token and nvidia-gridd service) and renew the expired licenses",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRestartService,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the nvml library
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, Get)
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+func Get(ctx context.Context) (_ any, e error) {
+	defer func() {
+		if e != nil {
+			components_metrics.SetGetFailed(Name)
+		} else {
+			components_metrics.SetGetSuccess(Name)
+		}
+	}()
+
+	licenses, err := nvidia_query_nvml.GetGPULicenses()
+	if err != nil {
+		return nil, err
+	}
+	return &Output{Licenses: licenses}, nil
+}
@@ -0,0 +1,88 @@
+package license
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(24 * time.Hour)
+
+	licensed := nvidia_query_nvml.GPULicense{
+		UUID:               "GPU-0",
+		VirtualizationMode: nvidia_query_nvml.VirtualizationModeVGPU,
+		Supported:          true,
+		Features: []nvidia_query_nvml.LicensedFeature{
+			{Feature: nvidia_query_nvml.LicenseFeatureCompute, Enabled: true, Licensed: true, ExpiryStatus: nvidia_query_nvml.LicenseExpiryValid, Expiry: &future},
+		},
+	}
+	expired := nvidia_query_nvml.GPULicense{
+		UUID:      "GPU-1",
+		Supported: true,
+		Features: []nvidia_query_nvml.LicensedFeature{
+			{Feature: nvidia_query_nvml.LicenseFeatureCompute, Enabled: true, Licensed: true, ExpiryStatus: nvidia_query_nvml.LicenseExpiryValid, Expiry: &past},
+		},
+	}
+	bareMetal := nvidia_query_nvml.GPULicense{UUID: "GPU-2", VirtualizationMode: nvidia_query_nvml.VirtualizationModeNone}
+
+	tests := []struct {
+		name         string
+		licenses     []nvidia_query_nvml.GPULicense
+		required     string
+		wantHealthy  bool
+		wantContains string
+	}{
+		{
+			name:         "bare-metal without license",
+			licenses:     []nvidia_query_nvml.GPULicense{bareMetal},
+			wantHealthy:  true,
+			wantContains: "no licensed enterprise feature",
+		},
+		{
+			name:         "licensed and required",
+			licenses:     []nvidia_query_nvml.GPULicense{licensed},
+			required:     nvidia_query_nvml.LicenseFeatureCompute,
+			wantHealthy:  true,
+			wantContains: `GPU-0 "compute"`,
+		},
+		{
+			name:         "expired but not required",
+			licenses:     []nvidia_query_nvml.GPULicense{expired},
+			wantHealthy:  true,
+			wantContains: "no licensed enterprise feature",
+		},
+		{
+			name:         "expired and required",
+			licenses:     []nvidia_query_nvml.GPULicense{licensed, expired},
+			required:     nvidia_query_nvml.LicenseFeatureCompute,
+			wantHealthy:  false,
+			wantContains: "GPU-1 \"compute\" license expired",
+		},
+		{
+			name:         "required but not licensed",
+			licenses:     []nvidia_query_nvml.GPULicense{bareMetal},
+			required:     nvidia_query_nvml.LicenseFeatureVGPU,
+			wantHealthy:  false,
+			wantContains: "GPU-2 not licensed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Output{Licenses: tt.licenses}
+			reason, healthy := o.Evaluate(tt.required, now)
+			if healthy != tt.wantHealthy {
+				t.Errorf("healthy = %v, want %v (reason %q)", healthy, tt.wantHealthy, reason)
+			}
+			if !strings.Contains(reason, tt.wantContains) {
+				t.Errorf("reason %q does not contain %q", reason, tt.wantContains)
+			}
+		})
+	}
+}
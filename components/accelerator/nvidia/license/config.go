@@ -0,0 +1,49 @@
+package license
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// RequiredFeature is the enterprise feature that every GPU must be licensed for
+	// ("vgpu", "nvidia-rtx", "gaming", or "compute" for NVIDIA AI Enterprise / vCS).
+	// The component is marked unhealthy if a GPU is not licensed or the license expired.
+	// Leave empty to only report the entitlements.
+	RequiredFeature string `json:"required_feature,omitempty"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	switch cfg.RequiredFeature {
+	case "",
+		nvidia_query_nvml.LicenseFeatureVGPU,
+		nvidia_query_nvml.LicenseFeatureRTX,
+		nvidia_query_nvml.LicenseFeatureGaming,
+		nvidia_query_nvml.LicenseFeatureCompute:
+		return nil
+	default:
+		return fmt.Errorf("invalid required license feature %q", cfg.RequiredFeature)
+	}
+}
@@ -67,6 +67,12 @@ const (
 	StateValueMemoryUsageEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameMemoryUsage, "per-GPU memory usage"),
+	)
+}
+
 func ParseStateMemoryUsage(m map[string]string) (*Output, error) {
 	data := m[StateKeyMemoryUsageData]
 	return ParseOutputJSON([]byte(data))
@@ -92,18 +98,22 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 // Returns the output evaluation reason and its healthy-ness.
 func (o *Output) Evaluate() (string, bool, error) {
 	type mem struct {
-		UUID        string `json:"uuid"`
-		TotalBytes  string `json:"total_bytes"`
-		UsedBytes   string `json:"used_bytes"`
-		UsedPercent string `json:"used_percent"`
+		UUID           string `json:"uuid"`
+		TotalBytes     uint64 `json:"total_bytes"`
+		TotalHumanized string `json:"total_humanized"`
+		UsedBytes      uint64 `json:"used_bytes"`
+		UsedHumanized  string `json:"used_humanized"`
+		UsedPercent    string `json:"used_percent"`
 	}
 	mems := make([]mem, len(o.UsagesNVML))
 	for i, u := range o.UsagesNVML {
 		mems[i] = mem{
-			UUID:        u.UUID,
-			TotalBytes:  u.TotalHumanized,
-			UsedBytes:   u.UsedHumanized,
-			UsedPercent: u.UsedPercent,
+			UUID:           u.UUID,
+			TotalBytes:     u.TotalBytes,
+			TotalHumanized: u.TotalHumanized,
+			UsedBytes:      u.UsedBytes,
+			UsedHumanized:  u.UsedHumanized,
+			UsedPercent:    u.UsedPercent,
 		}
 	}
 	yb, err := yaml.Marshal(mems)
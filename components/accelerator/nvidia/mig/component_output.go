@@ -0,0 +1,253 @@
+package mig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia/mig/metrics"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/pkg/locale"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Output struct {
+	Slices []Slice `json:"slices"`
+
+	// ReclaimableSlices is the list of the MIG device UUIDs
+	// that are allocated but idle for longer than the configured duration.
+	ReclaimableSlices []string `json:"reclaimable_slices,omitempty"`
+	// ReclaimableMemoryBytes is the total memory bytes of the reclaimable slices.
+	ReclaimableMemoryBytes uint64 `json:"reclaimable_memory_bytes"`
+
+	// MIGModes is the MIG mode and the MIG devices (GPU instance and compute instance pairs) of each GPU.
+	MIGModes []nvidia_query_nvml.MIGMode `json:"mig_modes,omitempty"`
+	// Drifts is the list of the MIG configuration drifts from the expected configuration
+	// (e.g., MIG mode disabled, unexpected profiles, pending mode change).
+	Drifts []string `json:"drifts,omitempty"`
+}
+
+// Slice is the MIG slice utilization with its idle duration.
+type Slice struct {
+	nvidia_query_nvml.MIGSlice
+
+	// IdleDuration is how long the allocated slice has been idle (zero if not idle).
+	IdleDuration metav1.Duration `json:"idle_duration"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameMIG = "mig"
+
+	StateKeyMIGData           = "data"
+	StateKeyMIGEncoding       = "encoding"
+	StateValueMIGEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameMIG, "MIG mode and instances"),
+	)
+}
+
+func ParseStateMIG(m map[string]string) (*Output, error) {
+	data := m[StateKeyMIGData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameMIG:
+			o, err := ParseStateMIG(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+// Idle slices are a capacity concern, not a health issue,
+// thus only the MIG configuration drifts are unhealthy.
+func (o *Output) Evaluate() (string, bool) {
+	if len(o.Drifts) > 0 {
+		return fmt.Sprintf("mig configuration drifted from the expected: %s", strings.Join(o.Drifts, "; ")), false
+	}
+	return o.evaluateIdle(), true
+}
+
+func (o *Output) evaluateIdle() string {
+	if len(o.Slices) == 0 {
+		return "no mig slice found"
+	}
+	if len(o.ReclaimableSlices) == 0 {
+		return fmt.Sprintf("no reclaimable mig slice found (%d slice(s))", len(o.Slices))
+	}
+	return fmt.Sprintf("%d of %d mig slice(s) allocated but idle (%s reclaimable): %s",
+		len(o.ReclaimableSlices),
+		len(o.Slices),
+		locale.Bytes(o.ReclaimableMemoryBytes),
+		strings.Join(o.ReclaimableSlices, ", "),
+	)
+}
+
+func (o *Output) States() ([]components.State, error) {
+	b, _ := o.JSON()
+	reason, healthy := o.Evaluate()
+	state := components.State{
+		Name:    StateNameMIG,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyMIGData:     string(b),
+			StateKeyMIGEncoding: StateValueMIGEncodingJSON,
+		},
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the idle tracker
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, createGetFunc(cfg))
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+// gpmSampleInterval is the interval between the two GPM samples to compute the utilization.
+const gpmSampleInterval = 5 * time.Second
+
+func createGetFunc(cfg Config) query.GetFunc {
+	tracker := newIdleTracker()
+
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		slices, err := nvidia_query_nvml.GetMIGSlices(ctx, gpmSampleInterval)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now().UTC()
+		metrics.SetLastUpdateUnixSeconds(float64(now.Unix()))
+
+		modes, err := nvidia_query_nvml.GetMIGModes()
+		if err != nil {
+			return nil, err
+		}
+
+		idle := tracker.observe(slices, cfg.IdleUtilPercentThreshold, now)
+		o := buildOutput(slices, idle, cfg.IdleDuration.Duration)
+		o.MIGModes = modes
+		o.Drifts = findDrifts(modes, cfg.Expected)
+		return o, nil
+	}
+}
+
+// findDrifts returns the MIG configuration drifts of the GPUs from the expected configuration.
+// The pending MIG mode change is always reported, since the GPU runs with the stale mode
+// until the next GPU reset or reboot.
+func findDrifts(modes []nvidia_query_nvml.MIGMode, expected *ExpectedMIG) []string {
+	var drifts []string
+	for _, m := range modes {
+		if !m.Supported {
+			continue
+		}
+		if m.PendingChange() {
+			drifts = append(drifts, fmt.Sprintf("%s mig mode change pending (enabled %v, pending enabled %v, requires gpu reset)", m.UUID, m.Enabled, m.PendingEnabled))
+		}
+		if expected == nil {
+			continue
+		}
+		if m.Enabled != expected.Enabled {
+			drifts = append(drifts, fmt.Sprintf("%s mig mode enabled %v (expected %v)", m.UUID, m.Enabled, expected.Enabled))
+			continue
+		}
+		if !m.Enabled || len(expected.Profiles) == 0 {
+			continue
+		}
+
+		counts := m.ProfileCounts()
+		profiles := make([]string, 0, len(expected.Profiles)+len(counts))
+		for p := range expected.Profiles {
+			profiles = append(profiles, p)
+		}
+		for p := range counts {
+			if _, ok := expected.Profiles[p]; !ok {
+				profiles = append(profiles, p)
+			}
+		}
+		sort.Strings(profiles)
+		for _, p := range profiles {
+			if counts[p] != expected.Profiles[p] {
+				drifts = append(drifts, fmt.Sprintf("%s has %d mig device(s) of profile %s (expected %d)", m.UUID, counts[p], p, expected.Profiles[p]))
+			}
+		}
+	}
+	return drifts
+}
+
+// buildOutput builds the output from the slices and their idle durations,
+// and updates the metrics.
+func buildOutput(slices []nvidia_query_nvml.MIGSlice, idle map[string]time.Duration, idleThreshold time.Duration) *Output {
+	o := &Output{}
+	perProfile := make(map[string]int)
+	for _, s := range slices {
+		d := idle[s.UUID]
+		o.Slices = append(o.Slices, Slice{MIGSlice: s, IdleDuration: metav1.Duration{Duration: d}})
+
+		if s.GraphicsUtilPercent != nil {
+			metrics.SetSliceGraphicsUtilPercent(s.GPUUUID, s.UUID, s.Profile, *s.GraphicsUtilPercent)
+		}
+		metrics.SetSliceIdleSeconds(s.GPUUUID, s.UUID, s.Profile, d.Seconds())
+
+		if _, ok := idle[s.UUID]; ok && d >= idleThreshold {
+			o.ReclaimableSlices = append(o.ReclaimableSlices, s.UUID)
+			o.ReclaimableMemoryBytes += s.MemoryTotalBytes
+			perProfile[s.Profile]++
+		}
+	}
+	metrics.SetReclaimableSlices(perProfile)
+	metrics.SetReclaimableMemoryBytes(o.ReclaimableMemoryBytes)
+	return o
+}
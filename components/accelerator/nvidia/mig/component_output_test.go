@@ -0,0 +1,73 @@
+package mig
+
+import (
+	"reflect"
+	"testing"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+func TestFindDrifts(t *testing.T) {
+	devices := func(profiles ...string) []nvidia_query_nvml.MIGDevice {
+		var ds []nvidia_query_nvml.MIGDevice
+		for i, p := range profiles {
+			ds = append(ds, nvidia_query_nvml.MIGDevice{UUID: "MIG-" + p, GPUInstanceID: i, Profile: p})
+		}
+		return ds
+	}
+
+	tests := []struct {
+		name     string
+		modes    []nvidia_query_nvml.MIGMode
+		expected *ExpectedMIG
+		want     []string
+	}{
+		{
+			name:  "no expectation",
+			modes: []nvidia_query_nvml.MIGMode{{UUID: "GPU-0", Supported: true, Enabled: true, PendingEnabled: true, Devices: devices("1g.10gb")}},
+		},
+		{
+			name:     "not supported",
+			modes:    []nvidia_query_nvml.MIGMode{{UUID: "GPU-0"}},
+			expected: &ExpectedMIG{Enabled: true},
+		},
+		{
+			name:  "pending change without expectation",
+			modes: []nvidia_query_nvml.MIGMode{{UUID: "GPU-0", Supported: true, Enabled: false, PendingEnabled: true}},
+			want:  []string{"GPU-0 mig mode change pending (enabled false, pending enabled true, requires gpu reset)"},
+		},
+		{
+			name:     "mode disabled",
+			modes:    []nvidia_query_nvml.MIGMode{{UUID: "GPU-0", Supported: true}},
+			expected: &ExpectedMIG{Enabled: true, Profiles: map[string]int{"1g.10gb": 7}},
+			want:     []string{"GPU-0 mig mode enabled false (expected true)"},
+		},
+		{
+			name:     "profiles match",
+			modes:    []nvidia_query_nvml.MIGMode{{UUID: "GPU-0", Supported: true, Enabled: true, PendingEnabled: true, Devices: devices("1g.10gb", "1g.10gb", "3g.40gb")}},
+			expected: &ExpectedMIG{Enabled: true, Profiles: map[string]int{"1g.10gb": 2, "3g.40gb": 1}},
+		},
+		{
+			name:     "profiles drifted",
+			modes:    []nvidia_query_nvml.MIGMode{{UUID: "GPU-0", Supported: true, Enabled: true, PendingEnabled: true, Devices: devices("1g.10gb", "2g.20gb")}},
+			expected: &ExpectedMIG{Enabled: true, Profiles: map[string]int{"1g.10gb": 2}},
+			want: []string{
+				"GPU-0 has 1 mig device(s) of profile 1g.10gb (expected 2)",
+				"GPU-0 has 1 mig device(s) of profile 2g.20gb (expected 0)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findDrifts(tt.modes, tt.expected)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findDrifts() = %v, want %v", got, tt.want)
+			}
+
+			o := &Output{Drifts: got}
+			if _, healthy := o.Evaluate(); healthy != (len(tt.want) == 0) {
+				t.Errorf("Evaluate() healthy = %v, want %v", healthy, len(tt.want) == 0)
+			}
+		})
+	}
+}
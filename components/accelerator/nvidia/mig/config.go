@@ -0,0 +1,92 @@
+package mig
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultIdleUtilPercentThreshold is the default graphics utilization percent
+	// at or below which the allocated MIG slice is considered idle.
+	DefaultIdleUtilPercentThreshold = 1.0
+	// DefaultIdleDuration is the default duration that the allocated MIG slice
+	// must stay idle to be reported as reclaimable.
+	DefaultIdleDuration = 30 * time.Minute
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// IdleUtilPercentThreshold is the graphics utilization percent
+	// at or below which the allocated MIG slice is considered idle.
+	IdleUtilPercentThreshold float64 `json:"idle_util_percent_threshold"`
+	// IdleDuration is the duration that the allocated MIG slice
+	// must stay idle to be reported as reclaimable.
+	IdleDuration metav1.Duration `json:"idle_duration"`
+
+	// Expected is the expected MIG configuration of each GPU.
+	// If set, the component reports unhealthy when the MIG mode or the MIG devices drift from it.
+	Expected *ExpectedMIG `json:"expected,omitempty"`
+}
+
+// ExpectedMIG is the expected MIG configuration, applied to each MIG-capable GPU.
+type ExpectedMIG struct {
+	// Enabled is the expected MIG mode.
+	Enabled bool `json:"enabled"`
+	// Profiles is the expected number of the MIG devices per profile name
+	// on each MIG-enabled GPU (e.g., {"1g.10gb": 7}).
+	// Not checked if empty.
+	Profiles map[string]int `json:"profiles,omitempty"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.IdleUtilPercentThreshold == 0 {
+		cfg.IdleUtilPercentThreshold = DefaultIdleUtilPercentThreshold
+	}
+	if cfg.IdleDuration.Duration == 0 {
+		cfg.IdleDuration.Duration = DefaultIdleDuration
+	}
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	if cfg.IdleUtilPercentThreshold < 0 || cfg.IdleUtilPercentThreshold > 100 {
+		return errors.New("idle util percent threshold must be between 0 and 100")
+	}
+	if cfg.IdleDuration.Duration < 0 {
+		return errors.New("idle duration must be non-negative")
+	}
+	if cfg.Expected != nil {
+		if !cfg.Expected.Enabled && len(cfg.Expected.Profiles) > 0 {
+			return errors.New("expected mig profiles require expected mig mode enabled")
+		}
+		for profile, n := range cfg.Expected.Profiles {
+			if n < 0 {
+				return fmt.Errorf("expected mig profile %q count must be non-negative", profile)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package mig
+
+import (
+	"sync"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+// idleTracker tracks since when each allocated MIG slice has been idle.
+type idleTracker struct {
+	mu sync.Mutex
+	// maps from the MIG device UUID to the time it was first observed idle
+	idleSince map[string]time.Time
+}
+
+func newIdleTracker() *idleTracker {
+	return &idleTracker{idleSince: make(map[string]time.Time)}
+}
+
+// observe updates the idle start times with the latest slices,
+// and returns the idle duration of each allocated idle slice.
+// Slices without the utilization (GPM not supported) are never considered idle.
+func (t *idleTracker) observe(slices []nvidia_query_nvml.MIGSlice, utilThreshold float64, now time.Time) map[string]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seen := make(map[string]struct{}, len(slices))
+	idle := make(map[string]time.Duration)
+	for _, s := range slices {
+		seen[s.UUID] = struct{}{}
+
+		if !s.Allocated() || s.GraphicsUtilPercent == nil || *s.GraphicsUtilPercent > utilThreshold {
+			delete(t.idleSince, s.UUID)
+			continue
+		}
+
+		since, ok := t.idleSince[s.UUID]
+		if !ok {
+			since = now
+			t.idleSince[s.UUID] = now
+		}
+		idle[s.UUID] = now.Sub(since)
+	}
+
+	// MIG devices may be destroyed and re-created
+	for uuid := range t.idleSince {
+		if _, ok := seen[uuid]; !ok {
+			delete(t.idleSince, uuid)
+		}
+	}
+	return idle
+}
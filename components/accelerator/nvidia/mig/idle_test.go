@@ -0,0 +1,45 @@
+package mig
+
+import (
+	"testing"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+func TestIdleTracker(t *testing.T) {
+	util := func(v float64) *float64 { return &v }
+
+	tr := newIdleTracker()
+	now := time.Now()
+
+	slices := []nvidia_query_nvml.MIGSlice{
+		{UUID: "MIG-idle", RunningProcesses: 1, GraphicsUtilPercent: util(0)},
+		{UUID: "MIG-busy", RunningProcesses: 1, GraphicsUtilPercent: util(80)},
+		{UUID: "MIG-free", RunningProcesses: 0, GraphicsUtilPercent: util(0)},
+		{UUID: "MIG-no-gpm", RunningProcesses: 1},
+	}
+
+	idle := tr.observe(slices, DefaultIdleUtilPercentThreshold, now)
+	if len(idle) != 1 || idle["MIG-idle"] != 0 {
+		t.Fatalf("unexpected idle slices %v", idle)
+	}
+
+	idle = tr.observe(slices, DefaultIdleUtilPercentThreshold, now.Add(time.Hour))
+	if idle["MIG-idle"] != time.Hour {
+		t.Fatalf("expected 1h idle, got %v", idle["MIG-idle"])
+	}
+
+	// becomes busy, resets the idle start time
+	slices[0].GraphicsUtilPercent = util(50)
+	idle = tr.observe(slices, DefaultIdleUtilPercentThreshold, now.Add(2*time.Hour))
+	if len(idle) != 0 {
+		t.Fatalf("expected no idle slices, got %v", idle)
+	}
+
+	// destroyed slice is forgotten
+	tr.observe(nil, DefaultIdleUtilPercentThreshold, now.Add(3*time.Hour))
+	if len(tr.idleSince) != 0 {
+		t.Fatalf("expected no tracked slices, got %v", tr.idleSince)
+	}
+}
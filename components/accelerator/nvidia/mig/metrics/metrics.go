@@ -0,0 +1,101 @@
+// Package metrics implements the NVIDIA MIG slice utilization and reclaimable capacity metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const SubSystem = "accelerator_nvidia_mig"
+
+var (
+	lastUpdateUnixSeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "last_update_unix_seconds",
+			Help:      "tracks the last update time in unix seconds",
+		},
+	)
+
+	sliceGraphicsUtilPercent = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "slice_graphics_util_percent",
+			Help:      "tracks the current graphics utilization percent per MIG slice",
+		},
+		[]string{"gpu_id", "mig_id", "profile"},
+	)
+
+	sliceIdleSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "slice_idle_seconds",
+			Help:      "tracks how long the allocated MIG slice has been idle in seconds (zero if not idle)",
+		},
+		[]string{"gpu_id", "mig_id", "profile"},
+	)
+
+	reclaimableSlices = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "reclaimable_slices",
+			Help:      "tracks the number of the allocated MIG slices idle for longer than the threshold per profile",
+		},
+		[]string{"profile"},
+	)
+
+	reclaimableMemoryBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "reclaimable_memory_bytes",
+			Help:      "tracks the total memory bytes of the reclaimable MIG slices",
+		},
+	)
+)
+
+func SetLastUpdateUnixSeconds(unixSeconds float64) {
+	lastUpdateUnixSeconds.Set(unixSeconds)
+}
+
+func SetSliceGraphicsUtilPercent(gpuID string, migID string, profile string, pct float64) {
+	sliceGraphicsUtilPercent.WithLabelValues(gpuID, migID, profile).Set(pct)
+}
+
+func SetSliceIdleSeconds(gpuID string, migID string, profile string, secs float64) {
+	sliceIdleSeconds.WithLabelValues(gpuID, migID, profile).Set(secs)
+}
+
+// SetReclaimableSlices resets and sets the reclaimable slice counts per profile.
+func SetReclaimableSlices(perProfile map[string]int) {
+	reclaimableSlices.Reset()
+	for profile, n := range perProfile {
+		reclaimableSlices.WithLabelValues(profile).Set(float64(n))
+	}
+}
+
+func SetReclaimableMemoryBytes(bytes uint64) {
+	reclaimableMemoryBytes.Set(float64(bytes))
+}
+
+func Register(reg *prometheus.Registry) error {
+	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
+		return err
+	}
+	if err := reg.Register(sliceGraphicsUtilPercent); err != nil {
+		return err
+	}
+	if err := reg.Register(sliceIdleSeconds); err != nil {
+		return err
+	}
+	if err := reg.Register(reclaimableSlices); err != nil {
+		return err
+	}
+	if err := reg.Register(reclaimableMemoryBytes); err != nil {
+		return err
+	}
+	return nil
+}
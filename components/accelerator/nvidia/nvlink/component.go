@@ -5,13 +5,19 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_counter_reset_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/counter-reset-state"
 	nvidia_query_metrics_nvlink "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/nvlink"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	nvidia_query_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/linkflap"
+	"github.com/leptonai/gpud/pkg/warmstate"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -19,7 +25,7 @@ import (
 const Name = "accelerator-nvidia-nvlink"
 
 func New(ctx context.Context, cfg Config) components.Component {
-	cfg.Query.SetDefaultsIfNotSet()
+	cfg.SetDefaultsIfNotSet()
 
 	cctx, ccancel := context.WithCancel(ctx)
 	nvidia_query.SetDefaultPoller(cfg.Query.State.DB)
@@ -29,7 +35,35 @@ func New(ctx context.Context, cfg Config) components.Component {
 		rootCtx: ctx,
 		cancel:  ccancel,
 		poller:  nvidia_query.GetDefaultPoller(),
+		cfg:     cfg,
+		flaps:   newFlapDetector(ctx, cfg),
+
+		linkErrors: newLinkErrorTracker(cfg.ErrorWindow.Duration),
+	}
+}
+
+// flapsStateKey is the warm state key of the link flap detector.
+const flapsStateKey = "linkflap/" + Name
+
+// newFlapDetector creates the link flap detector, restoring the flap counts
+// saved before the restart (if any), so a restart does not reset a flapping link to healthy.
+func newFlapDetector(ctx context.Context, cfg Config) *linkflap.Detector {
+	d := linkflap.New(cfg.FlapWindow.Duration)
+	if cfg.Query.State == nil || cfg.Query.State.DB == nil {
+		return d
+	}
+
+	var s linkflap.Snapshot
+	ok, err := warmstate.Load(ctx, cfg.Query.State.DB, flapsStateKey, cfg.FlapWindow.Duration, &s)
+	if err != nil {
+		log.Logger.Warnw("failed to load link flaps state", "error", err)
+		return d
 	}
+	if ok {
+		log.Logger.Debugw("restored link flaps state", "links", len(s.Links))
+		d.Restore(s)
+	}
+	return d
 }
 
 var _ components.Component = (*component)(nil)
@@ -39,6 +73,14 @@ type component struct {
 	cancel   context.CancelFunc
 	poller   query.Poller
 	gatherer prometheus.Gatherer
+
+	cfg   Config
+	flaps *linkflap.Detector
+
+	linkErrors *linkErrorTracker
+
+	flapsSavedMu sync.Mutex
+	flapsSavedAt time.Time
 }
 
 func (c *component) Name() string { return Name }
@@ -58,6 +100,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -89,11 +134,55 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 		}, nil
 	}
 	output := ToOutput(allOutput)
+	output.ObserveLinkFlaps(c.flaps, last.Time.Time, c.cfg.FlapThreshold)
+	c.saveFlaps(ctx, last.Time.Time)
+	output.ObserveLinkErrors(c.linkErrors, last.Time.Time, c.cfg.MaxLinkErrorsPerWindow)
+	if output.HasLinkIssues() {
+		output.SXids = c.readSXids(ctx, last.Time.Time.Add(-c.cfg.ErrorWindow.Duration))
+	}
 	return output.States()
 }
 
+// readSXids returns the SXid errors found in the dmesg since the given time,
+// to correlate with the nvlink issues (nil if not found or the state database is not set).
+func (c *component) readSXids(ctx context.Context, since time.Time) []string {
+	if c.cfg.Query.State == nil || c.cfg.Query.State.DB == nil {
+		return nil
+	}
+
+	events, err := nvidia_query_xid_sxid_state.ReadEvents(ctx, c.cfg.Query.State.DB, nvidia_query_xid_sxid_state.WithSince(since))
+	if err != nil {
+		log.Logger.Warnw("failed to read sxid events", "error", err)
+		return nil
+	}
+	var sxids []string
+	for _, ev := range events {
+		if ev.EventType != "sxid" {
+			continue
+		}
+		name := "unknown"
+		if d := ev.ToSXidDetail(); d != nil {
+			name = d.Name
+		}
+		sxids = append(sxids, fmt.Sprintf("sxid %d (%s) at %s", ev.EventID, name, time.Unix(ev.UnixSeconds, 0).UTC().Format(time.RFC3339)))
+	}
+	return sxids
+}
+
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
-	return nil, nil
+	if c.cfg.Query.State == nil || c.cfg.Query.State.DB == nil {
+		return nil, nil
+	}
+
+	resets, err := nvidia_query_counter_reset_state.ReadEvents(ctx, c.cfg.Query.State.DB, since, nvidia_query_nvml.CounterTypeNVLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nvlink counter reset events: %w", err)
+	}
+	var evs []components.Event
+	for _, ev := range resets {
+		evs = append(evs, ev.ToComponentEvent())
+	}
+	return evs, nil
 }
 
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
@@ -192,3 +281,21 @@ func (c *component) RegisterCollectors(reg *prometheus.Registry, db *sql.DB, tab
 	c.gatherer = reg
 	return nvidia_query_metrics_nvlink.Register(reg, db, tableName)
 }
+
+// saveFlaps persists the flap detector state once per poll.
+func (c *component) saveFlaps(ctx context.Context, observed time.Time) {
+	if c.cfg.Query.State == nil || c.cfg.Query.State.DB == nil {
+		return
+	}
+
+	c.flapsSavedMu.Lock()
+	defer c.flapsSavedMu.Unlock()
+	if !observed.After(c.flapsSavedAt) {
+		return
+	}
+	if err := warmstate.Save(ctx, c.cfg.Query.State.DB, flapsStateKey, c.flaps.Snapshot(), time.Now()); err != nil {
+		log.Logger.Warnw("failed to save link flaps state", "error", err)
+		return
+	}
+	c.flapsSavedAt = observed
+}
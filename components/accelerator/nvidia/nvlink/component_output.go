@@ -4,10 +4,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+	"github.com/leptonai/gpud/pkg/linkflap"
 )
 
 // ToOutput converts nvidia_query.Output to Output.
@@ -22,14 +27,100 @@ func ToOutput(i *nvidia_query.Output) *Output {
 	if i.NVML != nil {
 		for _, device := range i.NVML.DeviceInfos {
 			o.NVLinkDevices = append(o.NVLinkDevices, device.NVLink)
+			o.DownLinks = append(o.DownLinks, FindDownLinks(device.NVLink)...)
 		}
 	}
 
 	return o
 }
 
+// FindDownLinks returns the links whose nvlink feature is disabled
+// while the other links of the same GPU are enabled (e.g., the link failed to train),
+// as the GPUs without the nvlink report all the links disabled.
+func FindDownLinks(nvlink nvidia_query_nvml.NVLink) []string {
+	anyUp := false
+	for _, link := range nvlink.States {
+		if link.FeatureEnabled {
+			anyUp = true
+			break
+		}
+	}
+	if !anyUp {
+		return nil
+	}
+
+	var down []string
+	for _, link := range nvlink.States {
+		if !link.FeatureEnabled {
+			down = append(down, NVLinkID(nvlink.UUID, link.Link))
+		}
+	}
+	return down
+}
+
 type Output struct {
 	NVLinkDevices []nvidia_query_nvml.NVLink `json:"nvlink_devices"`
+
+	// LinkFlaps is the number of link down to up transitions
+	// within the flap window, keyed by "<GPU UUID>/<link number>".
+	LinkFlaps map[string]int `json:"link_flaps,omitempty"`
+	// FlappingLinks is the list of links whose flap count
+	// is equal to or greater than the flap threshold.
+	FlappingLinks []string `json:"flapping_links,omitempty"`
+
+	// DownLinks is the list of links whose nvlink feature is disabled
+	// while the other links of the same GPU are enabled.
+	DownLinks []string `json:"down_links,omitempty"`
+
+	// LinkErrorIncrements is the number of the link error (CRC, replay, and recovery) increments
+	// within the error window, keyed by "<GPU UUID>/<link number>".
+	LinkErrorIncrements map[string]uint64 `json:"link_error_increments,omitempty"`
+	// LinksWithErrors is the list of links whose error increments
+	// are greater than the threshold.
+	LinksWithErrors []string `json:"links_with_errors,omitempty"`
+
+	// SXids is the list of the NVSwitch SXid errors from the dmesg within the error window,
+	// only set when any link is down or accumulating the errors, to correlate
+	// the GPU side of the link failure with the NVSwitch side.
+	SXids []string `json:"sxids,omitempty"`
+}
+
+// NVLinkID returns the link identifier used for the flap detection.
+func NVLinkID(uuid string, link int) string {
+	return fmt.Sprintf("%s/%d", uuid, link)
+}
+
+// ObserveLinkFlaps records the current nvlink states to the flap detector,
+// and sets the flap counts and the flapping links with the threshold.
+func (o *Output) ObserveLinkFlaps(detector *linkflap.Detector, ts time.Time, threshold int) {
+	for _, device := range o.NVLinkDevices {
+		for _, link := range device.States {
+			detector.Observe(NVLinkID(device.UUID, link.Link), link.FeatureEnabled, ts)
+		}
+	}
+	o.LinkFlaps = detector.Flaps(ts)
+	o.FlappingLinks = detector.Flapping(ts, threshold)
+}
+
+// ObserveLinkErrors records the current link error counters to the error tracker,
+// and sets the error increments and the links with the errors over the threshold
+// (zero threshold to only set the increments).
+func (o *Output) ObserveLinkErrors(tracker *linkErrorTracker, ts time.Time, threshold uint64) {
+	for _, device := range o.NVLinkDevices {
+		for _, link := range device.States {
+			tracker.Observe(NVLinkID(device.UUID, link.Link), link.CRCErrors+link.ReplayErrors+link.RecoveryErrors, ts)
+		}
+	}
+	o.LinkErrorIncrements = tracker.Increments(ts)
+	if threshold > 0 {
+		o.LinksWithErrors = tracker.Exceeding(ts, threshold)
+	}
+}
+
+// HasLinkIssues returns true if any link is down, flapping, or accumulating the errors,
+// to correlate with the SXid errors.
+func (o *Output) HasLinkIssues() bool {
+	return len(o.DownLinks) > 0 || len(o.FlappingLinks) > 0 || len(o.LinkErrorIncrements) > 0
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -52,6 +143,12 @@ const (
 	StateValueNVLinkDevicesEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameNVLinkDevices, "per-GPU NVLink states"),
+	)
+}
+
 func ParseStateNVLinkDevices(m map[string]string) (*Output, error) {
 	data := m[StateKeyNVLinkDevicesData]
 	return ParseOutputJSON([]byte(data))
@@ -91,7 +188,45 @@ func (o *Output) Evaluate() (string, bool, error) {
 		reason += fmt.Sprintf("\n- %s: %d crc, %d relay, %d recovery errors (total %d links)", device.UUID, allCRCErrs, allRelayErrs, allRecErrs, len(device.States))
 	}
 
-	return reason, true, nil
+	healthy := true
+	if len(o.DownLinks) > 0 {
+		reason += fmt.Sprintf("\ndown nvlink(s): %s", strings.Join(o.DownLinks, ", "))
+		healthy = false
+	}
+
+	// flapping links are reported as unhealthy even if the links are currently up
+	if len(o.FlappingLinks) > 0 {
+		flaps := make([]string, 0, len(o.FlappingLinks))
+		for _, link := range o.FlappingLinks {
+			flaps = append(flaps, fmt.Sprintf("%s (%d flaps)", link, o.LinkFlaps[link]))
+		}
+		reason += fmt.Sprintf("\nflapping nvlink(s): %s", strings.Join(flaps, ", "))
+		healthy = false
+	}
+
+	// the increments below the threshold are reported without marking unhealthy
+	if len(o.LinkErrorIncrements) > 0 {
+		links := make([]string, 0, len(o.LinkErrorIncrements))
+		for link := range o.LinkErrorIncrements {
+			links = append(links, link)
+		}
+		sort.Strings(links)
+		incs := make([]string, 0, len(links))
+		for _, link := range links {
+			incs = append(incs, fmt.Sprintf("%s (%d errors)", link, o.LinkErrorIncrements[link]))
+		}
+		reason += fmt.Sprintf("\nnvlink(s) accumulating errors: %s", strings.Join(incs, ", "))
+	}
+	if len(o.LinksWithErrors) > 0 {
+		reason += fmt.Sprintf("\nnvlink(s) with errors over the threshold: %s", strings.Join(o.LinksWithErrors, ", "))
+		healthy = false
+	}
+
+	if len(o.SXids) > 0 {
+		reason += fmt.Sprintf("\nsxid(s) within the error window: %s", strings.Join(o.SXids, ", "))
+	}
+
+	return reason, healthy, nil
 }
 
 func (o *Output) States() ([]components.State, error) {
@@ -109,5 +244,31 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyNVLinkDevicesEncoding: StateValueNVLinkDevicesEncodingJSON,
 		},
 	}
+	if !healthy {
+		state.SuggestedActions = o.suggestedActions()
+	}
 	return []components.State{state}, nil
 }
+
+func (o *Output) suggestedActions() *common.SuggestedActions {
+	actions := &common.SuggestedActions{}
+	if len(o.DownLinks) > 0 {
+		actions.Descriptions = append(actions.Descriptions,
+			"down nvlink(s) reduce the GPU-to-GPU bandwidth, reboot the system to retrain the links, and inspect the nvlink/nvswitch hardware if the links stay down",
+		)
+		actions.RepairActions = append(actions.RepairActions, common.RepairActionTypeRebootSystem)
+	}
+	if len(o.FlappingLinks) > 0 {
+		actions.Descriptions = append(actions.Descriptions,
+			"flapping nvlink(s) degrade the collective communication performance intermittently, inspect the nvlink/nvswitch hardware",
+		)
+		actions.RepairActions = append(actions.RepairActions, common.RepairActionTypeHardwareInspection)
+	}
+	if len(o.LinksWithErrors) > 0 {
+		actions.Descriptions = append(actions.Descriptions,
+			"nvlink(s) accumulating the crc, replay, or recovery errors are likely degrading, inspect the nvlink/nvswitch hardware",
+		)
+		actions.RepairActions = append(actions.RepairActions, common.RepairActionTypeHardwareInspection)
+	}
+	return actions
+}
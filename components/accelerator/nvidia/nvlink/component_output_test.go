@@ -0,0 +1,175 @@
+package nvlink
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+	"github.com/leptonai/gpud/pkg/linkflap"
+)
+
+func TestFindDownLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		nvlink   nvidia_query_nvml.NVLink
+		expected []string
+	}{
+		{
+			name:   "all links up",
+			nvlink: nvidia_query_nvml.NVLink{UUID: "GPU-1", States: nvidia_query_nvml.NVLinkStates{{Link: 0, FeatureEnabled: true}, {Link: 1, FeatureEnabled: true}}},
+		},
+		{
+			name:   "no nvlink",
+			nvlink: nvidia_query_nvml.NVLink{UUID: "GPU-1", States: nvidia_query_nvml.NVLinkStates{{Link: 0}, {Link: 1}}},
+		},
+		{
+			name:     "one link down",
+			nvlink:   nvidia_query_nvml.NVLink{UUID: "GPU-1", States: nvidia_query_nvml.NVLinkStates{{Link: 0, FeatureEnabled: true}, {Link: 1}}},
+			expected: []string{"GPU-1/1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindDownLinks(tt.nvlink); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("FindDownLinks() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOutputStates(t *testing.T) {
+	tests := []struct {
+		name            string
+		output          *Output
+		expectedHealthy bool
+		expectedActions []common.RepairActionType
+	}{
+		{
+			name:            "no issue",
+			output:          &Output{},
+			expectedHealthy: true,
+		},
+		{
+			name:            "errors below the threshold",
+			output:          &Output{LinkErrorIncrements: map[string]uint64{"GPU-1/0": 3}},
+			expectedHealthy: true,
+		},
+		{
+			name:            "down link",
+			output:          &Output{DownLinks: []string{"GPU-1/1"}},
+			expectedHealthy: false,
+			expectedActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+		},
+		{
+			name: "errors over the threshold",
+			output: &Output{
+				LinkErrorIncrements: map[string]uint64{"GPU-1/0": 300},
+				LinksWithErrors:     []string{"GPU-1/0"},
+				SXids:               []string{"sxid 20034 (LTSSM Fault Up) at 2024-01-01T00:00:00Z"},
+			},
+			expectedHealthy: false,
+			expectedActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states, err := tt.output.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != 1 {
+				t.Fatalf("expected 1 state, got %d", len(states))
+			}
+			if states[0].Healthy != tt.expectedHealthy {
+				t.Errorf("healthy = %v, want %v (reason %q)", states[0].Healthy, tt.expectedHealthy, states[0].Reason)
+			}
+			if tt.expectedHealthy {
+				if states[0].SuggestedActions != nil {
+					t.Errorf("unexpected suggested actions %+v", states[0].SuggestedActions)
+				}
+				return
+			}
+			if !reflect.DeepEqual(states[0].SuggestedActions.RepairActions, tt.expectedActions) {
+				t.Errorf("repair actions = %v, want %v", states[0].SuggestedActions.RepairActions, tt.expectedActions)
+			}
+		})
+	}
+}
+
+func TestObserveLinkFlaps(t *testing.T) {
+	// "GPU-1" link 1 flaps while link 0 stays up
+	newOutput := func(link1Up bool) *Output {
+		return &Output{
+			NVLinkDevices: []nvidia_query_nvml.NVLink{
+				{UUID: "GPU-1", States: nvidia_query_nvml.NVLinkStates{{Link: 0, FeatureEnabled: true}, {Link: 1, FeatureEnabled: link1Up}}},
+				{UUID: "GPU-2", States: nvidia_query_nvml.NVLinkStates{{Link: 0, FeatureEnabled: true}}},
+			},
+		}
+	}
+
+	const threshold = 2
+	detector := linkflap.New(time.Hour)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// up -> down -> up -> down -> up, two flaps at +2m and +4m
+	var o *Output
+	for i, up := range []bool{true, false, true, false, true} {
+		o = newOutput(up)
+		o.ObserveLinkFlaps(detector, base.Add(time.Duration(i)*time.Minute), threshold)
+	}
+	if !reflect.DeepEqual(o.LinkFlaps, map[string]int{"GPU-1/1": 2}) {
+		t.Errorf("unexpected link flaps %v", o.LinkFlaps)
+	}
+	if !reflect.DeepEqual(o.FlappingLinks, []string{"GPU-1/1"}) {
+		t.Errorf("unexpected flapping links %v", o.FlappingLinks)
+	}
+
+	// the link is up now, but still reported as flapping
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states[0].Healthy {
+		t.Fatalf("expected 1 unhealthy state, got %+v", states)
+	}
+	if !strings.Contains(states[0].Reason, "GPU-1/1 (2 flaps)") {
+		t.Errorf("unexpected reason %q", states[0].Reason)
+	}
+	if !reflect.DeepEqual(states[0].SuggestedActions.RepairActions, []common.RepairActionType{common.RepairActionTypeHardwareInspection}) {
+		t.Errorf("unexpected repair actions %v", states[0].SuggestedActions.RepairActions)
+	}
+
+	// same flaps, but below the higher threshold
+	o = newOutput(true)
+	o.ObserveLinkFlaps(detector, base.Add(4*time.Minute), threshold+1)
+	if len(o.FlappingLinks) != 0 {
+		t.Errorf("expected no flapping link with threshold %d, got %v", threshold+1, o.FlappingLinks)
+	}
+
+	// the first flap slides out of the window
+	o = newOutput(true)
+	o.ObserveLinkFlaps(detector, base.Add(62*time.Minute+30*time.Second), threshold)
+	if !reflect.DeepEqual(o.LinkFlaps, map[string]int{"GPU-1/1": 1}) {
+		t.Errorf("unexpected link flaps %v", o.LinkFlaps)
+	}
+	if len(o.FlappingLinks) != 0 {
+		t.Errorf("expected no flapping link, got %v", o.FlappingLinks)
+	}
+
+	// both flaps slide out of the window, and the links are healthy again
+	o = newOutput(true)
+	o.ObserveLinkFlaps(detector, base.Add(65*time.Minute), threshold)
+	if len(o.LinkFlaps) != 0 || len(o.FlappingLinks) != 0 {
+		t.Errorf("expected no flap, got %v, %v", o.LinkFlaps, o.FlappingLinks)
+	}
+	states, err = o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !states[0].Healthy {
+		t.Errorf("expected healthy after the flaps slide out of the window, got %q", states[0].Reason)
+	}
+}
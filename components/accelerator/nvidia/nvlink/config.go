@@ -3,12 +3,45 @@ package nvlink
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 
 	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/pkg/linkflap"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type Config struct {
 	Query query_config.Config `json:"query"`
+
+	// FlapThreshold is the number of link down to up transitions within the flap window
+	// to report the link as unhealthy, even if the link is currently up.
+	// If not set, it defaults to linkflap.DefaultThreshold.
+	FlapThreshold int `json:"flap_threshold"`
+	// FlapWindow is the sliding window to count the link flaps.
+	// If not set, it defaults to linkflap.DefaultWindow.
+	FlapWindow metav1.Duration `json:"flap_window"`
+
+	// ErrorWindow is the sliding window to count the link error (CRC, replay, and recovery) increments.
+	// If not set, it defaults to DefaultErrorWindow.
+	ErrorWindow metav1.Duration `json:"error_window"`
+	// MaxLinkErrorsPerWindow is the maximum link error increments of a link within the error window,
+	// above which the link is reported unhealthy.
+	// Zero to only report the increments without marking unhealthy.
+	MaxLinkErrorsPerWindow uint64 `json:"max_link_errors_per_window"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	cfg.Query.SetDefaultsIfNotSet()
+	if cfg.FlapThreshold == 0 {
+		cfg.FlapThreshold = linkflap.DefaultThreshold
+	}
+	if cfg.FlapWindow.Duration == 0 {
+		cfg.FlapWindow = metav1.Duration{Duration: linkflap.DefaultWindow}
+	}
+	if cfg.ErrorWindow.Duration == 0 {
+		cfg.ErrorWindow = metav1.Duration{Duration: DefaultErrorWindow}
+	}
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -28,5 +61,11 @@ func ParseConfig(b any, db *sql.DB) (*Config, error) {
 }
 
 func (cfg Config) Validate() error {
+	if cfg.FlapThreshold < 0 {
+		return fmt.Errorf("flap threshold must be non-negative, got %d", cfg.FlapThreshold)
+	}
+	if cfg.ErrorWindow.Duration < 0 {
+		return fmt.Errorf("error window must be non-negative, got %v", cfg.ErrorWindow.Duration)
+	}
 	return nil
 }
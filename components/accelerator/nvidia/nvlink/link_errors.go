@@ -0,0 +1,98 @@
+package nvlink
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultErrorWindow is the default sliding window to count the link error increments.
+const DefaultErrorWindow = time.Hour
+
+// linkErrorTracker keeps the cumulative link error counter (CRC, replay, and recovery) samples
+// within the sliding window per link, to find the links accumulating the errors,
+// as the counters are cumulative since the driver load and a high count alone
+// does not tell whether the link is still degrading.
+type linkErrorTracker struct {
+	mu      sync.Mutex
+	window  time.Duration
+	samples map[string][]linkErrorSample
+}
+
+type linkErrorSample struct {
+	ts     time.Time
+	errors uint64
+}
+
+func newLinkErrorTracker(window time.Duration) *linkErrorTracker {
+	if window == 0 {
+		window = DefaultErrorWindow
+	}
+	return &linkErrorTracker{
+		window:  window,
+		samples: make(map[string][]linkErrorSample),
+	}
+}
+
+// Observe records the cumulative error count of the link at the given time.
+// Observations older than or equal to the last observation are ignored,
+// so it is safe to observe the same sample multiple times.
+// A count lower than the last observation (e.g., the driver reload or the counter reset)
+// restarts the samples of the link.
+func (t *linkErrorTracker) Observe(link string, errors uint64, ts time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ss := t.samples[link]
+	if len(ss) > 0 {
+		last := ss[len(ss)-1]
+		if !ts.After(last.ts) {
+			return
+		}
+		if errors < last.errors {
+			ss = nil
+		}
+	}
+	ss = append(ss, linkErrorSample{ts: ts, errors: errors})
+
+	// keep the last sample before the window as the baseline of the increments
+	since := ts.Add(-t.window)
+	drop := 0
+	for drop < len(ss)-1 && !ss[drop+1].ts.After(since) {
+		drop++
+	}
+	t.samples[link] = ss[drop:]
+}
+
+// Increments returns the error increments per link within the window ending at the given time.
+// Links without any increment are omitted.
+func (t *linkErrorTracker) Increments(now time.Time) map[string]uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	since := now.Add(-t.window)
+	incs := make(map[string]uint64)
+	for link, ss := range t.samples {
+		first := 0
+		for first < len(ss)-1 && !ss[first+1].ts.After(since) {
+			first++
+		}
+		if inc := ss[len(ss)-1].errors - ss[first].errors; inc > 0 {
+			incs[link] = inc
+		}
+	}
+	return incs
+}
+
+// Exceeding returns the sorted links whose error increments within the window
+// are greater than the threshold.
+func (t *linkErrorTracker) Exceeding(now time.Time, threshold uint64) []string {
+	var links []string
+	for link, inc := range t.Increments(now) {
+		if inc > threshold {
+			links = append(links, link)
+		}
+	}
+	sort.Strings(links)
+	return links
+}
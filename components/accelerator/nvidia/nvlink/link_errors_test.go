@@ -0,0 +1,44 @@
+package nvlink
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLinkErrorTracker(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tr := newLinkErrorTracker(time.Hour)
+
+	tr.Observe("GPU-1/0", 10, now)
+	tr.Observe("GPU-1/1", 5, now)
+	if incs := tr.Increments(now); len(incs) != 0 {
+		t.Fatalf("expected no increment on the first observation, got %v", incs)
+	}
+
+	tr.Observe("GPU-1/0", 30, now.Add(30*time.Minute))
+	tr.Observe("GPU-1/1", 5, now.Add(30*time.Minute))
+	// ignores the older or duplicate observations
+	tr.Observe("GPU-1/0", 100, now.Add(30*time.Minute))
+	if incs := tr.Increments(now.Add(30 * time.Minute)); !reflect.DeepEqual(incs, map[string]uint64{"GPU-1/0": 20}) {
+		t.Fatalf("unexpected increments %v", incs)
+	}
+	if links := tr.Exceeding(now.Add(30*time.Minute), 19); !reflect.DeepEqual(links, []string{"GPU-1/0"}) {
+		t.Fatalf("unexpected links %v", links)
+	}
+	if links := tr.Exceeding(now.Add(30*time.Minute), 20); len(links) != 0 {
+		t.Fatalf("unexpected links %v", links)
+	}
+
+	// the increments before the window are not counted
+	tr.Observe("GPU-1/0", 31, now.Add(100*time.Minute))
+	if incs := tr.Increments(now.Add(100 * time.Minute)); !reflect.DeepEqual(incs, map[string]uint64{"GPU-1/0": 1}) {
+		t.Fatalf("unexpected increments %v", incs)
+	}
+
+	// the counter reset restarts the samples
+	tr.Observe("GPU-1/0", 2, now.Add(101*time.Minute))
+	if incs := tr.Increments(now.Add(101 * time.Minute)); len(incs) != 0 {
+		t.Fatalf("expected no increment after the counter reset, got %v", incs)
+	}
+}
@@ -0,0 +1,148 @@
+package pcie
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+)
+
+// DefaultBusyGPUUsedPercent is the GPU utilization at or above which the link is expected
+// to run at the max generation, since the link steps down the generation when the GPU is idle.
+const DefaultBusyGPUUsedPercent = 50
+
+// Link is the PCIe link state of a GPU with its utilization at the time of the query.
+type Link struct {
+	nvidia_query_nvml.PCIeLink
+	GPUUsedPercent uint32 `json:"gpu_used_percent"`
+}
+
+// WidthDegraded returns true if the link trained at a narrower width than the max.
+func (l Link) WidthDegraded() bool {
+	return l.Degraded()
+}
+
+// GenDegraded returns true if the link runs at a lower generation than the max while the GPU is busy.
+func (l Link) GenDegraded() bool {
+	return l.GPUUsedPercent >= DefaultBusyGPUUsedPercent && l.GenDowngraded()
+}
+
+func (l Link) String() string {
+	return fmt.Sprintf("x%d Gen%d (max x%d Gen%d)", l.CurrentWidth, l.CurrentGen, l.MaxWidth, l.MaxGen)
+}
+
+type Output struct {
+	Links []Link `json:"links"`
+}
+
+func ToOutput(i *nvidia_query.Output) *Output {
+	if i == nil || i.NVML == nil {
+		return &Output{}
+	}
+
+	o := &Output{}
+	for _, dev := range i.NVML.DeviceInfos {
+		o.Links = append(o.Links, Link{
+			PCIeLink:       dev.PCIeLink,
+			GPUUsedPercent: dev.Utilization.GPUUsedPercent,
+		})
+	}
+	return o
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNamePCIe = "pcie"
+
+	StateKeyPCIeData           = "data"
+	StateKeyPCIeEncoding       = "encoding"
+	StateValuePCIeEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNamePCIe, "per-GPU PCIe link width and generation"),
+	)
+}
+
+func ParseStatePCIe(m map[string]string) (*Output, error) {
+	data := m[StateKeyPCIeData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNamePCIe:
+			o, err := ParseStatePCIe(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool) {
+	var degraded []string
+	for _, l := range o.Links {
+		switch {
+		case l.WidthDegraded():
+			degraded = append(degraded, fmt.Sprintf("%s (%s) link width degraded to %s", l.UUID, l.BusID, l))
+		case l.GenDegraded():
+			degraded = append(degraded, fmt.Sprintf("%s (%s) link generation degraded to %s at %d%% utilization", l.UUID, l.BusID, l, l.GPUUsedPercent))
+		}
+	}
+	if len(degraded) > 0 {
+		return strings.Join(degraded, "; "), false
+	}
+	return fmt.Sprintf("no degraded PCIe link found (%d GPU(s))", len(o.Links)), true
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, healthy := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNamePCIe,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyPCIeData:     string(b),
+			StateKeyPCIeEncoding: StateValuePCIeEncodingJSON,
+		},
+	}
+	if !healthy {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"check the riser, slot, and cable seating of the GPU (a degraded link usually indicates a hardware issue)",
+				"reboot the system to retrain the link",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeHardwareInspection,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
@@ -0,0 +1,85 @@
+package pcie
+
+import (
+	"testing"
+
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+)
+
+func TestToOutputEvaluate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		link        nvidia_query_nvml.PCIeLink
+		used        uint32
+		wantHealthy bool
+	}{
+		{
+			name:        "full link",
+			link:        nvidia_query_nvml.PCIeLink{UUID: "GPU-0", BusID: "0000:18:00.0", CurrentGen: 4, MaxGen: 4, CurrentWidth: 16, MaxWidth: 16},
+			used:        90,
+			wantHealthy: true,
+		},
+		{
+			name:        "idle gen step down",
+			link:        nvidia_query_nvml.PCIeLink{UUID: "GPU-0", BusID: "0000:18:00.0", CurrentGen: 1, MaxGen: 4, CurrentWidth: 16, MaxWidth: 16},
+			used:        0,
+			wantHealthy: true,
+		},
+		{
+			name:        "busy gen degraded",
+			link:        nvidia_query_nvml.PCIeLink{UUID: "GPU-0", BusID: "0000:18:00.0", CurrentGen: 1, MaxGen: 4, CurrentWidth: 16, MaxWidth: 16},
+			used:        95,
+			wantHealthy: false,
+		},
+		{
+			name:        "width degraded while idle",
+			link:        nvidia_query_nvml.PCIeLink{UUID: "GPU-0", BusID: "0000:18:00.0", CurrentGen: 1, MaxGen: 4, CurrentWidth: 4, MaxWidth: 16},
+			used:        0,
+			wantHealthy: false,
+		},
+		{
+			name:        "not supported",
+			link:        nvidia_query_nvml.PCIeLink{UUID: "GPU-0"},
+			wantHealthy: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &nvidia_query.Output{
+				NVML: &nvidia_query_nvml.Output{
+					DeviceInfos: []*nvidia_query_nvml.DeviceInfo{
+						{
+							UUID:        tt.link.UUID,
+							PCIeLink:    tt.link,
+							Utilization: nvidia_query_nvml.Utilization{UUID: tt.link.UUID, GPUUsedPercent: tt.used},
+						},
+					},
+				},
+			}
+			o := ToOutput(i)
+			reason, healthy := o.Evaluate()
+			if healthy != tt.wantHealthy {
+				t.Errorf("expected healthy %v, got %v (%s)", tt.wantHealthy, healthy, reason)
+			}
+
+			states, err := o.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !healthy && (states[0].SuggestedActions == nil || states[0].SuggestedActions.RepairActions[0] != common.RepairActionTypeHardwareInspection) {
+				t.Errorf("expected hardware inspection, got %+v", states[0].SuggestedActions)
+			}
+			parsed, err := ParseStatesToOutput(states...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed.Links) != 1 || parsed.Links[0] != o.Links[0] {
+				t.Errorf("expected %+v, got %+v", o.Links, parsed.Links)
+			}
+		})
+	}
+}
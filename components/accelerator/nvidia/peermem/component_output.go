@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/leptonai/gpud/components"
+	nvidia_peermem_id "github.com/leptonai/gpud/components/accelerator/nvidia/peermem/id"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	"github.com/leptonai/gpud/components/accelerator/nvidia/query/peermem"
 )
@@ -56,6 +57,12 @@ const (
 	// TODO: support compressed gzip
 )
 
+func init() {
+	components.RegisterStateSchemas(nvidia_peermem_id.Name,
+		components.JSONStateSchema(StateNameLsmodPeermem, "nvidia_peermem kernel module status"),
+	)
+}
+
 func ParseStateLsmodPeermem(m map[string]string) (*Output, error) {
 	o := &Output{}
 	data := m[StateKeyLsmodPeermemData]
@@ -4,13 +4,19 @@ package persistencemode
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_persistence_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode/id"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func New(ctx context.Context, cfg Config) components.Component {
@@ -20,11 +26,23 @@ func New(ctx context.Context, cfg Config) components.Component {
 	nvidia_query.SetDefaultPoller(cfg.Query.State.DB)
 	nvidia_query.GetDefaultPoller().Start(cctx, cfg.Query, nvidia_persistence_mode_id.Name)
 
-	return &component{
+	c := &component{
 		rootCtx: ctx,
 		cancel:  ccancel,
 		poller:  nvidia_query.GetDefaultPoller(),
+		cfg:     cfg,
+		modes:   newModeHistory(),
+
+		persistencedJournal: nvidia_query.GetLatestPersistencedOutput,
+		startPersistenced:   nvidia_query.StartPersistenced,
+		setPersistenceMode:  nvidia_query_nvml.SetPersistenceMode,
+	}
+	if cfg.Enforce {
+		var ectx context.Context
+		ectx, c.enforceCancel = context.WithCancel(ctx)
+		go c.enforceLoop(ectx)
 	}
+	return c
 }
 
 var _ components.Component = (*component)(nil)
@@ -33,6 +51,23 @@ type component struct {
 	rootCtx context.Context
 	cancel  context.CancelFunc
 	poller  query.Poller
+	cfg     Config
+	modes   *modeHistory
+
+	enforceCancel context.CancelFunc
+	enforceMu     sync.RWMutex
+	// lastDisabled is the set of the disabled GPUs (joined) of the last enforcement,
+	// to record the event only once per set of the disabled GPUs
+	lastDisabled  string
+	enforceErrors []string
+
+	// injected for testing
+	persistencedJournal func(ctx context.Context) (string, error)
+	startPersistenced   func(ctx context.Context) error
+	setPersistenceMode  func(uuid string, enabled bool) error
+
+	eventsMu sync.RWMutex
+	events   []components.Event
 }
 
 func (c *component) Name() string { return nvidia_persistence_mode_id.Name }
@@ -53,6 +88,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -77,11 +115,171 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	}
 
 	output := ToOutput(allOutput)
+	output.FlappingGPUs = c.modes.observe(last.Time.Time, output.PersistenceModesNVML)
+	if c.cfg.Enforce && !output.PersistencedRunning && len(output.DisabledGPUs()) > 0 {
+		c.enforceMu.RLock()
+		output.EnforceErrors = c.enforceErrors
+		c.enforceMu.RUnlock()
+	}
 	return output.States()
 }
 
+const (
+	EventNamePersistenceModeReenabled = "persistence_mode_reenabled"
+
+	// maxEvents is the maximum number of in-memory events to keep.
+	maxEvents = 100
+
+	// journalLinesInEvent is the number of the latest "nvidia-persistenced" journal lines
+	// to include in the event, to help find out what stopped the daemon.
+	journalLinesInEvent = 5
+)
+
+// enforceLoop re-enables the persistence mode once per poll.
+func (c *component) enforceLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Query.Interval.Duration)
+	defer ticker.Stop()
+
+	var lastPolled time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		last, err := c.poller.Last()
+		if err != nil || last.Error != nil || last.Output == nil {
+			continue
+		}
+		if !last.Time.Time.After(lastPolled) {
+			continue
+		}
+		lastPolled = last.Time.Time
+
+		allOutput, ok := last.Output.(*nvidia_query.Output)
+		if !ok {
+			continue
+		}
+		output := ToOutput(allOutput)
+		c.enforcePersistenceMode(ctx, output)
+
+		c.enforceMu.Lock()
+		c.enforceErrors = output.EnforceErrors
+		c.enforceMu.Unlock()
+	}
+}
+
+// enforcePersistenceMode re-enables the persistence mode when it is found disabled.
+// If "nvidia-persistenced" is installed, it restarts the daemon first,
+// and falls back to the legacy persistence mode via NVML (equivalent to "nvidia-smi -pm 1").
+// The event is recorded once per set of the disabled GPUs, while the re-enabling is retried every poll.
+func (c *component) enforcePersistenceMode(ctx context.Context, o *Output) {
+	if o.PersistencedRunning {
+		return
+	}
+	disabled := o.DisabledGPUs()
+	sorted := append([]string{}, disabled...)
+	sort.Strings(sorted)
+	disabledKey := strings.Join(sorted, ",")
+
+	c.enforceMu.Lock()
+	recorded := disabledKey == c.lastDisabled
+	c.lastDisabled = disabledKey
+	c.enforceMu.Unlock()
+
+	if len(disabled) == 0 {
+		return
+	}
+
+	// without nvidia-persistenced, there is no record of what disabled the persistence mode
+	// (e.g., "nvidia-smi -pm 0", driver reload, or reboot)
+	cause := "unknown cause (nvidia-persistenced not installed)"
+	action := ""
+	if o.PersistencedExists {
+		cause = "nvidia-persistenced is installed but not running, unknown cause (no journal)"
+		cctx, ccancel := context.WithTimeout(ctx, 30*time.Second)
+		out, err := c.persistencedJournal(cctx)
+		ccancel()
+		if err == nil && strings.TrimSpace(out) != "" {
+			cause = "nvidia-persistenced is installed but not running (latest journal: " + lastLines(out, journalLinesInEvent) + ")"
+		}
+
+		log.Logger.Warnw("nvidia-persistenced not running -- starting", "disabledGPUs", disabled)
+		cctx, ccancel = context.WithTimeout(ctx, 30*time.Second)
+		err = c.startPersistenced(cctx)
+		ccancel()
+		if err == nil {
+			action = "started nvidia-persistenced"
+		} else {
+			log.Logger.Warnw("failed to start nvidia-persistenced -- falling back to legacy persistence mode", "error", err)
+			o.EnforceErrors = append(o.EnforceErrors, err.Error())
+		}
+	}
+
+	if action == "" {
+		uuids := disabled
+		if len(o.PersistenceModesNVML) == 0 {
+			// nvidia-smi reports the bus IDs, not the UUIDs
+			uuids = []string{""}
+		}
+		reenabled := make([]string, 0, len(uuids))
+		for _, uuid := range uuids {
+			log.Logger.Warnw("persistence mode disabled -- re-enabling", "uuid", uuid)
+			if err := c.setPersistenceMode(uuid, true); err != nil {
+				o.EnforceErrors = append(o.EnforceErrors, fmt.Sprintf("%s: %v", uuid, err))
+				continue
+			}
+			if uuid == "" {
+				uuid = "all GPUs"
+			}
+			reenabled = append(reenabled, uuid)
+		}
+		action = "re-enabled persistence mode on " + strings.Join(reenabled, ", ")
+		if len(reenabled) == 0 {
+			action = "failed to re-enable persistence mode (" + strings.Join(o.EnforceErrors, "; ") + ")"
+		}
+	}
+
+	if recorded {
+		log.Logger.Debugw("persistence mode disabled on the same gpus -- skipping event", "disabledGPUs", disabled, "action", action)
+		return
+	}
+	ev := components.Event{
+		Time:    metav1.Time{Time: time.Now().UTC()},
+		Name:    EventNamePersistenceModeReenabled,
+		Type:    components.EventTypeWarn,
+		Message: fmt.Sprintf("persistence mode disabled on %s: %s; %s", strings.Join(disabled, ", "), cause, action),
+	}
+
+	c.eventsMu.Lock()
+	c.events = append(c.events, ev)
+	if len(c.events) > maxEvents {
+		c.events = c.events[len(c.events)-maxEvents:]
+	}
+	c.eventsMu.Unlock()
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, " | ")
+}
+
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
-	return nil, nil
+	c.eventsMu.RLock()
+	defer c.eventsMu.RUnlock()
+
+	var evs []components.Event
+	for _, ev := range c.events {
+		if ev.Time.Time.Before(since) {
+			continue
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
 }
 
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
@@ -96,5 +294,9 @@ func (c *component) Close() error {
 	// safe to call stop multiple times
 	_ = c.poller.Stop(nvidia_persistence_mode_id.Name)
 
+	if c.enforceCancel != nil {
+		c.enforceCancel()
+	}
+
 	return nil
 }
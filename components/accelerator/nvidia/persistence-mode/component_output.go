@@ -4,11 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/leptonai/gpud/components"
+	nvidia_persistence_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode/id"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
 )
 
 // ToOutput converts nvidia_query.Output to Output.
@@ -19,8 +24,9 @@ func ToOutput(i *nvidia_query.Output) *Output {
 	}
 
 	o := &Output{
-		PersistencedExists:  i.PersistencedExists,
-		PersistencedRunning: i.PersistencedRunning,
+		PersistencedExists:            i.PersistencedExists,
+		PersistencedRunning:           i.PersistencedRunning,
+		PersistencedNoPersistenceMode: i.PersistencedNoPersistenceMode,
 	}
 
 	if i.SMI != nil {
@@ -41,9 +47,57 @@ func ToOutput(i *nvidia_query.Output) *Output {
 type Output struct {
 	PersistencedExists  bool `json:"persistenced_exists"`
 	PersistencedRunning bool `json:"persistenced_running"`
+	// PersistencedNoPersistenceMode is true if "nvidia-persistenced" runs with "--no-persistence-mode".
+	PersistencedNoPersistenceMode bool `json:"persistenced_no_persistence_mode,omitempty"`
 
 	PersistenceModesSMI  []nvidia_query.SMIGPUPersistenceMode `json:"persistence_modes_smi"`
 	PersistenceModesNVML []nvidia_query_nvml.PersistenceMode  `json:"persistence_modes_nvml"`
+
+	// EnforceErrors is the list of errors from re-enabling the persistence mode,
+	// only set when the enforcement is enabled.
+	EnforceErrors []string `json:"enforce_errors,omitempty"`
+
+	// FlappingGPUs is the list of the GPUs whose persistence mode keeps toggling,
+	// when multiple methods (e.g., "nvidia-persistenced" and "nvidia-smi -pm") fight over it.
+	FlappingGPUs []string `json:"flapping_gpus,omitempty"`
+}
+
+// Conflicts returns the conflicting persistence configurations, which usually slow down the CUDA initialization.
+func (o *Output) Conflicts() []string {
+	conflicts := []string{}
+	if o.PersistencedRunning {
+		if disabled := o.DisabledGPUs(); len(disabled) > 0 {
+			cause := "disabled by 'nvidia-smi -pm 0'"
+			if o.PersistencedNoPersistenceMode {
+				cause = "started with --no-persistence-mode"
+			}
+			conflicts = append(conflicts, fmt.Sprintf("nvidia-persistenced is running but persistence mode is disabled on %s (%s)", strings.Join(disabled, ", "), cause))
+		}
+	}
+	if len(o.FlappingGPUs) > 0 {
+		conflicts = append(conflicts, fmt.Sprintf("persistence mode keeps toggling on %s (nvidia-persistenced and 'nvidia-smi -pm' fighting)", strings.Join(o.FlappingGPUs, ", ")))
+	}
+	return conflicts
+}
+
+// DisabledGPUs returns the IDs of the GPUs with the persistence mode disabled.
+// NVML results are preferred, falling back to nvidia-smi results.
+func (o *Output) DisabledGPUs() []string {
+	ids := []string{}
+	if len(o.PersistenceModesNVML) > 0 {
+		for _, p := range o.PersistenceModesNVML {
+			if !p.Enabled {
+				ids = append(ids, p.UUID)
+			}
+		}
+		return ids
+	}
+	for _, p := range o.PersistenceModesSMI {
+		if !p.Enabled {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -66,6 +120,12 @@ const (
 	StateValueMemoryUsageEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(nvidia_persistence_mode_id.Name,
+		components.JSONStateSchema(StateNamePersistenceMode, "per-GPU persistence mode"),
+	)
+}
+
 func ParseStatePersistenceMode(m map[string]string) (*Output, error) {
 	data := m[StateKeyPersistenceModeData]
 	return ParseOutputJSON([]byte(data))
@@ -92,6 +152,8 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 func (o *Output) Evaluate() (string, bool, error) {
 	reasons := []string{}
 
+	// the disabled persistence mode degrades the component (see Degraded)
+	// rather than making it unhealthy, since the GPUs still work
 	enabled := true
 	for _, p := range o.PersistenceModesSMI {
 		if o.PersistencedRunning {
@@ -103,7 +165,6 @@ func (o *Output) Evaluate() (string, bool, error) {
 		// we cannot guarantee that the NVIDIA Persistence Daemon will be running. This would be a feature regression as persistence mode might not be available out-of- the-box."
 		if !p.Enabled {
 			reasons = append(reasons, fmt.Sprintf("persistence mode is not enabled on %s (nvidia-smi)", p.ID))
-			enabled = false
 		}
 	}
 
@@ -117,7 +178,6 @@ func (o *Output) Evaluate() (string, bool, error) {
 		// we cannot guarantee that the NVIDIA Persistence Daemon will be running. This would be a feature regression as persistence mode might not be available out-of- the-box."
 		if !p.Enabled {
 			reasons = append(reasons, fmt.Sprintf("persistence mode is not enabled on %s (NVML)", p.UUID))
-			enabled = false
 		}
 	}
 
@@ -130,9 +190,24 @@ func (o *Output) Evaluate() (string, bool, error) {
 		reasons = append(reasons, "nvidia-persistenced exists but not running (start 'nvidia-persistenced' or run 'nvidia-smi -pm 1')")
 	}
 
+	if len(o.EnforceErrors) > 0 {
+		reasons = append(reasons, "failed to re-enable persistence mode: "+strings.Join(o.EnforceErrors, "; "))
+	}
+
+	if conflicts := o.Conflicts(); len(conflicts) > 0 {
+		reasons = append(reasons, conflicts...)
+		enabled = false
+	}
+
 	return strings.Join(reasons, "; "), enabled, nil
 }
 
+// Degraded returns true if the persistence mode is disabled on any GPU without "nvidia-persistenced" running,
+// which slows down the CUDA initialization and makes the NVML queries flaky.
+func (o *Output) Degraded() bool {
+	return !o.PersistencedRunning && len(o.DisabledGPUs()) > 0
+}
+
 func (o *Output) States() ([]components.State, error) {
 	outputReasons, healthy, err := o.Evaluate()
 	if err != nil {
@@ -148,5 +223,79 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyPersistenceModeEncoding: StateValueMemoryUsageEncodingJSON,
 		},
 	}
+	if healthy && o.Degraded() {
+		state.Degraded = true
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"enable the persistence mode (start nvidia-persistenced or run 'nvidia-smi -pm 1'), or set 'enforce' in the component config to enable it automatically",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeEnablePersistenceMode,
+			},
+		}
+	}
+	if len(o.Conflicts()) > 0 {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"use only nvidia-persistenced to enable the persistence mode (remove 'nvidia-smi -pm' from the boot scripts and cron jobs)",
+				"restart nvidia-persistenced without --no-persistence-mode",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRestartService,
+			},
+		}
+	}
 	return []components.State{state}, nil
 }
+
+const (
+	// DefaultFlapWindow is the window to count the persistence mode transitions of a GPU.
+	DefaultFlapWindow = 30 * time.Minute
+	// DefaultFlapMinTransitions is the number of transitions within the window to consider the mode flapping
+	// (two on/off cycles, so that a single re-enable after "nvidia-smi -pm 0" is not counted).
+	DefaultFlapMinTransitions = 4
+)
+
+// modeHistory tracks the persistence mode transitions of the GPUs across the polls.
+type modeHistory struct {
+	mu          sync.Mutex
+	last        map[string]bool
+	transitions map[string][]time.Time
+}
+
+func newModeHistory() *modeHistory {
+	return &modeHistory{
+		last:        make(map[string]bool),
+		transitions: make(map[string][]time.Time),
+	}
+}
+
+// observe records the persistence modes polled at the given time,
+// and returns the UUIDs of the GPUs whose mode is flapping.
+// Observing the same poll multiple times does not add a transition.
+func (h *modeHistory) observe(polled time.Time, modes []nvidia_query_nvml.PersistenceMode) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var flapping []string
+	for _, m := range modes {
+		if prev, ok := h.last[m.UUID]; ok && prev != m.Enabled {
+			h.transitions[m.UUID] = append(h.transitions[m.UUID], polled)
+		}
+		h.last[m.UUID] = m.Enabled
+
+		recent := h.transitions[m.UUID][:0]
+		for _, t := range h.transitions[m.UUID] {
+			if polled.Sub(t) <= DefaultFlapWindow {
+				recent = append(recent, t)
+			}
+		}
+		h.transitions[m.UUID] = recent
+
+		if len(recent) >= DefaultFlapMinTransitions {
+			flapping = append(flapping, m.UUID)
+		}
+	}
+	sort.Strings(flapping)
+	return flapping
+}
@@ -0,0 +1,119 @@
+package persistencemode
+
+import (
+	"testing"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+)
+
+func TestEvaluateConflicts(t *testing.T) {
+	t.Parallel()
+
+	enabled := []nvidia_query_nvml.PersistenceMode{{UUID: "GPU-0", Enabled: true}}
+	disabled := []nvidia_query_nvml.PersistenceMode{{UUID: "GPU-0", Enabled: false}}
+
+	tests := []struct {
+		name          string
+		output        Output
+		wantHealthy   bool
+		wantDegraded  bool
+		wantConflicts int
+	}{
+		{
+			name:        "persistenced running and mode enabled",
+			output:      Output{PersistencedExists: true, PersistencedRunning: true, PersistenceModesNVML: enabled},
+			wantHealthy: true,
+		},
+		{
+			name:        "legacy mode without persistenced",
+			output:      Output{PersistenceModesNVML: enabled},
+			wantHealthy: true,
+		},
+		{
+			name:         "legacy mode disabled without persistenced",
+			output:       Output{PersistencedExists: true, PersistenceModesNVML: disabled},
+			wantHealthy:  true,
+			wantDegraded: true,
+		},
+		{
+			name:          "persistenced running but mode disabled",
+			output:        Output{PersistencedExists: true, PersistencedRunning: true, PersistenceModesNVML: disabled},
+			wantHealthy:   false,
+			wantConflicts: 1,
+		},
+		{
+			name:          "persistenced running with no persistence mode",
+			output:        Output{PersistencedExists: true, PersistencedRunning: true, PersistencedNoPersistenceMode: true, PersistenceModesNVML: disabled},
+			wantHealthy:   false,
+			wantConflicts: 1,
+		},
+		{
+			name:          "flapping",
+			output:        Output{PersistencedExists: true, PersistencedRunning: true, PersistenceModesNVML: enabled, FlappingGPUs: []string{"GPU-0"}},
+			wantHealthy:   false,
+			wantConflicts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.output.Conflicts()); got != tt.wantConflicts {
+				t.Errorf("expected %d conflicts, got %v", tt.wantConflicts, tt.output.Conflicts())
+			}
+			reason, healthy, err := tt.output.Evaluate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if healthy != tt.wantHealthy {
+				t.Errorf("expected healthy %v, got %v (%s)", tt.wantHealthy, healthy, reason)
+			}
+
+			states, err := tt.output.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			hasAction := states[0].SuggestedActions != nil && states[0].SuggestedActions.RepairActions[0] == common.RepairActionTypeRestartService
+			if hasAction != (tt.wantConflicts > 0) {
+				t.Errorf("expected suggested action %v, got %+v", tt.wantConflicts > 0, states[0].SuggestedActions)
+			}
+			if states[0].Degraded != tt.wantDegraded {
+				t.Errorf("expected degraded %v, got %v", tt.wantDegraded, states[0].Degraded)
+			}
+			if got := states[0].SuggestedActions.RequiresPersistenceModeEnable(); got != tt.wantDegraded {
+				t.Errorf("expected persistence mode enable action %v, got %+v", tt.wantDegraded, states[0].SuggestedActions)
+			}
+		})
+	}
+}
+
+func TestModeHistoryObserve(t *testing.T) {
+	t.Parallel()
+
+	h := newModeHistory()
+	now := time.Now()
+	mode := func(enabled bool) []nvidia_query_nvml.PersistenceMode {
+		return []nvidia_query_nvml.PersistenceMode{{UUID: "GPU-0", Enabled: enabled}}
+	}
+
+	// a single re-enable is not flapping, and observing the same poll twice adds no transition
+	for i, enabled := range []bool{true, false, true, true} {
+		if flapping := h.observe(now.Add(time.Duration(i)*time.Minute), mode(enabled)); len(flapping) > 0 {
+			t.Fatalf("unexpected flapping at %d: %v", i, flapping)
+		}
+	}
+	if flapping := h.observe(now.Add(3*time.Minute), mode(true)); len(flapping) > 0 {
+		t.Fatalf("unexpected flapping on the same poll: %v", flapping)
+	}
+
+	h.observe(now.Add(4*time.Minute), mode(false))
+	flapping := h.observe(now.Add(5*time.Minute), mode(true))
+	if len(flapping) != 1 || flapping[0] != "GPU-0" {
+		t.Fatalf("expected GPU-0 flapping, got %v", flapping)
+	}
+
+	// the transitions age out of the window
+	if flapping := h.observe(now.Add(5*time.Minute+DefaultFlapWindow), mode(true)); len(flapping) > 0 {
+		t.Fatalf("expected no flapping after the window, got %v", flapping)
+	}
+}
@@ -0,0 +1,161 @@
+package persistencemode
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+type fakeEnforcer struct {
+	journal    string
+	startErr   error
+	setModeErr error
+
+	starts  int
+	setMode []string
+}
+
+func (f *fakeEnforcer) component() *component {
+	return &component{
+		persistencedJournal: func(ctx context.Context) (string, error) { return f.journal, nil },
+		startPersistenced: func(ctx context.Context) error {
+			f.starts++
+			return f.startErr
+		},
+		setPersistenceMode: func(uuid string, enabled bool) error {
+			f.setMode = append(f.setMode, uuid)
+			return f.setModeErr
+		},
+	}
+}
+
+func disabledOutput(persistencedExists bool, disabled ...string) *Output {
+	o := &Output{PersistencedExists: persistencedExists}
+	for _, uuid := range []string{"GPU-0", "GPU-1", "GPU-2"} {
+		enabled := true
+		for _, d := range disabled {
+			if d == uuid {
+				enabled = false
+			}
+		}
+		o.PersistenceModesNVML = append(o.PersistenceModesNVML, nvidia_query_nvml.PersistenceMode{UUID: uuid, Enabled: enabled})
+	}
+	return o
+}
+
+func TestEnforcePersistenceModeEventOncePerDisabledSet(t *testing.T) {
+	f := &fakeEnforcer{}
+	c := f.component()
+	ctx := context.Background()
+
+	c.enforcePersistenceMode(ctx, disabledOutput(false, "GPU-1"))
+	// same set, re-enabled again but not recorded again
+	c.enforcePersistenceMode(ctx, disabledOutput(false, "GPU-1"))
+	// new set
+	c.enforcePersistenceMode(ctx, disabledOutput(false, "GPU-1", "GPU-2"))
+	// recovered, then the same set disabled again
+	c.enforcePersistenceMode(ctx, disabledOutput(false))
+	c.enforcePersistenceMode(ctx, disabledOutput(false, "GPU-1"))
+
+	if !reflect.DeepEqual(f.setMode, []string{"GPU-1", "GPU-1", "GPU-1", "GPU-2", "GPU-1"}) {
+		t.Errorf("unexpected set persistence mode calls %v", f.setMode)
+	}
+	if f.starts != 0 {
+		t.Errorf("expected no nvidia-persistenced start, got %d", f.starts)
+	}
+
+	evs, err := c.Events(ctx, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 3 {
+		t.Fatalf("expected 3 events, got %+v", evs)
+	}
+	for _, ev := range evs {
+		if ev.Name != EventNamePersistenceModeReenabled {
+			t.Errorf("unexpected event name %q", ev.Name)
+		}
+	}
+	if !strings.Contains(evs[0].Message, "persistence mode disabled on GPU-1: unknown cause (nvidia-persistenced not installed); re-enabled persistence mode on GPU-1") {
+		t.Errorf("unexpected event message %q", evs[0].Message)
+	}
+	if !strings.Contains(evs[1].Message, "re-enabled persistence mode on GPU-1, GPU-2") {
+		t.Errorf("unexpected event message %q", evs[1].Message)
+	}
+}
+
+func TestEnforcePersistenceModePersistenced(t *testing.T) {
+	ctx := context.Background()
+
+	// the daemon started, no fallback to the legacy persistence mode
+	f := &fakeEnforcer{journal: "line 1\nline 2\n"}
+	c := f.component()
+	o := disabledOutput(true, "GPU-0")
+	c.enforcePersistenceMode(ctx, o)
+	if f.starts != 1 || len(f.setMode) != 0 || len(o.EnforceErrors) != 0 {
+		t.Fatalf("unexpected enforcement %+v, errors %v", f, o.EnforceErrors)
+	}
+	evs, _ := c.Events(ctx, time.Time{})
+	if len(evs) != 1 || !strings.Contains(evs[0].Message, "(latest journal: line 1 | line 2); started nvidia-persistenced") {
+		t.Fatalf("unexpected events %+v", evs)
+	}
+
+	// the daemon failed to start, falls back to the legacy persistence mode
+	f = &fakeEnforcer{startErr: errors.New("unit not found")}
+	c = f.component()
+	o = disabledOutput(true, "GPU-0")
+	c.enforcePersistenceMode(ctx, o)
+	if f.starts != 1 || !reflect.DeepEqual(f.setMode, []string{"GPU-0"}) {
+		t.Fatalf("unexpected enforcement %+v", f)
+	}
+	if !reflect.DeepEqual(o.EnforceErrors, []string{"unit not found"}) {
+		t.Errorf("unexpected enforce errors %v", o.EnforceErrors)
+	}
+	evs, _ = c.Events(ctx, time.Time{})
+	if len(evs) != 1 || !strings.Contains(evs[0].Message, "unknown cause (no journal); re-enabled persistence mode on GPU-0") {
+		t.Fatalf("unexpected events %+v", evs)
+	}
+
+	// both failed
+	f = &fakeEnforcer{startErr: errors.New("unit not found"), setModeErr: errors.New("no permission")}
+	c = f.component()
+	o = disabledOutput(true, "GPU-0")
+	c.enforcePersistenceMode(ctx, o)
+	evs, _ = c.Events(ctx, time.Time{})
+	if len(evs) != 1 || !strings.Contains(evs[0].Message, "failed to re-enable persistence mode (unit not found; GPU-0: no permission)") {
+		t.Fatalf("unexpected events %+v", evs)
+	}
+
+	// the daemon running is not enforced
+	f = &fakeEnforcer{}
+	c = f.component()
+	o = disabledOutput(true, "GPU-0")
+	o.PersistencedRunning = true
+	c.enforcePersistenceMode(ctx, o)
+	if f.starts != 0 || len(f.setMode) != 0 {
+		t.Fatalf("unexpected enforcement %+v", f)
+	}
+}
+
+func TestLastLines(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{in: "a\nb\nc\n", n: 5, want: "a | b | c"},
+		{in: "a\nb\nc\nd\ne\nf\n", n: 5, want: "b | c | d | e | f"},
+		{in: "\n\na\n", n: 1, want: "a"},
+		{in: "a", n: 1, want: "a"},
+	}
+	for _, tt := range tests {
+		if got := lastLines(tt.in, tt.n); got != tt.want {
+			t.Errorf("lastLines(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
@@ -9,6 +9,10 @@ import (
 
 type Config struct {
 	Query query_config.Config `json:"query"`
+
+	// Enforce is set true to automatically re-enable the persistence mode
+	// (or restart "nvidia-persistenced") when it is found disabled.
+	Enforce bool `json:"enforce"`
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -10,6 +10,7 @@ import (
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_metrics_power "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/power"
+	nvidia_query_threshold_breach_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/threshold-breach-state"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
 
@@ -29,6 +30,9 @@ func New(ctx context.Context, cfg Config) components.Component {
 		rootCtx: ctx,
 		cancel:  ccancel,
 		poller:  nvidia_query.GetDefaultPoller(),
+		cfg:     cfg,
+
+		thresholds: nvidia_query_threshold_breach_state.NewTracker(cfg.Query.State.DB, Name),
 	}
 }
 
@@ -39,6 +43,9 @@ type component struct {
 	cancel   context.CancelFunc
 	poller   query.Poller
 	gatherer prometheus.Gatherer
+	cfg      Config
+
+	thresholds *nvidia_query_threshold_breach_state.Tracker
 }
 
 func (c *component) Name() string { return Name }
@@ -58,6 +65,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -96,31 +106,69 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 		return cs, nil
 	}
 	output := ToOutput(allOutput)
+	output.ThresholdPreset, output.Thresholds = nvidia_query.GetGPUThresholds(allOutput.GPUProductName(), c.cfg.Thresholds)
+	output.AllowReducedPowerLimit = c.cfg.AllowReducedPowerLimit
+	if _, err := c.thresholds.Observe(ctx, last.Time.Time, output.ThresholdReadings()...); err != nil {
+		log.Logger.Warnw("failed to record threshold breach events", "component", Name, "error", err)
+	}
 	return output.States()
 }
 
+var _ components.Explainer = (*component)(nil)
+
+// Explain returns the per-GPU rules evaluated on the last collected data.
+func (c *component) Explain(ctx context.Context) ([]components.Rule, error) {
+	last, err := c.poller.Last()
+	if err != nil {
+		return nil, err
+	}
+	if nvidia_query.IsStackAbsent(last.Error) {
+		return nil, nil
+	}
+	if last.Error != nil {
+		return nil, last.Error
+	}
+	allOutput, ok := last.Output.(*nvidia_query.Output)
+	if !ok {
+		return nil, fmt.Errorf("invalid output type: %T", last.Output)
+	}
+	output := ToOutput(allOutput)
+	output.ThresholdPreset, output.Thresholds = nvidia_query.GetGPUThresholds(allOutput.GPUProductName(), c.cfg.Thresholds)
+	output.AllowReducedPowerLimit = c.cfg.AllowReducedPowerLimit
+	overridden := c.cfg.Thresholds != nil && c.cfg.Thresholds.MaxPowerUsedPercent > 0
+	return output.Rules(nvidia_query.GPUThresholdSource(output.ThresholdPreset, overridden)), nil
+}
+
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
-	return nil, nil
+	breaches, err := nvidia_query_threshold_breach_state.ReadEvents(ctx, c.cfg.Query.State.DB, since, Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read threshold breach events: %w", err)
+	}
+	evs := make([]components.Event, 0, len(breaches))
+	for _, ev := range breaches {
+		evs = append(evs, ev.ToComponentEvent())
+	}
+	return evs, nil
 }
 
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
-	currentUsageMilliWatts, err := nvidia_query_metrics_power.ReadCurrentUsageMilliWatts(ctx, since)
+	currentUsageWatts, err := nvidia_query_metrics_power.ReadCurrentUsageWatts(ctx, since)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read current usage milli watts: %w", err)
+		return nil, fmt.Errorf("failed to read current usage watts: %w", err)
 	}
-	enforcedLimitMilliWatts, err := nvidia_query_metrics_power.ReadEnforcedLimitMilliWatts(ctx, since)
+	enforcedLimitWatts, err := nvidia_query_metrics_power.ReadEnforcedLimitWatts(ctx, since)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read enforced limit milli watts: %w", err)
+		return nil, fmt.Errorf("failed to read enforced limit watts: %w", err)
 	}
 	usedPercents, err := nvidia_query_metrics_power.ReadUsedPercents(ctx, since)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read used percents: %w", err)
 	}
 
-	ms := make([]components.Metric, 0, len(currentUsageMilliWatts)+len(enforcedLimitMilliWatts)+len(usedPercents))
-	for _, m := range currentUsageMilliWatts {
+	ms := make([]components.Metric, 0, len(currentUsageWatts)+len(enforcedLimitWatts)+len(usedPercents))
+	for _, m := range currentUsageWatts {
 		ms = append(ms, components.Metric{
 			Metric: m,
 			ExtraInfo: map[string]string{
@@ -128,7 +176,7 @@ func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.
 			},
 		})
 	}
-	for _, m := range enforcedLimitMilliWatts {
+	for _, m := range enforcedLimitWatts {
 		ms = append(ms, components.Metric{
 			Metric: m,
 			ExtraInfo: map[string]string{
@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	nvidia_query_threshold_breach_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/threshold-breach-state"
+	"github.com/leptonai/gpud/components/common"
 
 	"sigs.k8s.io/yaml"
 )
@@ -37,6 +41,9 @@ func ToOutput(i *nvidia_query.Output) *Output {
 	if i.NVML != nil {
 		for _, device := range i.NVML.DeviceInfos {
 			o.UsagesNVML = append(o.UsagesNVML, device.Power)
+			if device.ClockEvents != nil && device.ClockEvents.HWSlowdownPowerBrake {
+				o.PowerBrakeSlowdowns = append(o.PowerBrakeSlowdowns, device.UUID)
+			}
 		}
 	}
 
@@ -46,6 +53,18 @@ func ToOutput(i *nvidia_query.Output) *Output {
 type Output struct {
 	UsagesSMI  []nvidia_query.ParsedSMIPowerReading `json:"usages_smi"`
 	UsagesNVML []nvidia_query_nvml.Power            `json:"usages_nvml"`
+
+	// ThresholdPreset is the threshold preset picked based on the GPU product name
+	// (empty if no preset is defined for the product).
+	ThresholdPreset string `json:"threshold_preset,omitempty"`
+	// Thresholds is the preset thresholds with the config overrides applied.
+	Thresholds nvidia_query.GPUThresholds `json:"thresholds"`
+
+	// PowerBrakeSlowdowns is the list of GPU UUIDs with the active HW power brake slowdown
+	// (the external power brake assertion, e.g., by the power supply or the baseboard).
+	PowerBrakeSlowdowns []string `json:"power_brake_slowdowns,omitempty"`
+	// AllowReducedPowerLimit is set true to not report the enforced power limit below the default.
+	AllowReducedPowerLimit bool `json:"allow_reduced_power_limit,omitempty"`
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -68,6 +87,13 @@ const (
 	StateValuePowerUsageEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNamePowerUsage, "per-GPU power usage and limits"),
+		nvidia_query.GPUStateSchema(StateNamePowerUsage, "power health of the GPU, one state per GPU"),
+	)
+}
+
 func ParseStatePowerUsage(m map[string]string) (*Output, error) {
 	data := m[StateKeyPowerUsageData]
 	return ParseOutputJSON([]byte(data))
@@ -84,6 +110,10 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 			return o, nil
 
 		default:
+			if nvidia_query.IsGPUStateName(StateNamePowerUsage, state.Name) {
+				// per-GPU states, derived from the component state
+				continue
+			}
 			return nil, fmt.Errorf("unknown state name: %s", state.Name)
 		}
 	}
@@ -93,27 +123,138 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 // Returns the output evaluation reason and its healthy-ness.
 func (o *Output) Evaluate() (string, bool, error) {
 	type temp struct {
-		UUID        string `json:"uuid"`
-		LimitW      string `json:"limit_w"`
-		UsageW      string `json:"usage_w"`
-		UsedPercent string `json:"used_percent"`
+		UUID          string `json:"uuid"`
+		LimitW        string `json:"limit_w"`
+		DefaultLimitW string `json:"default_limit_w,omitempty"`
+		UsageW        string `json:"usage_w"`
+		AverageW      string `json:"average_w,omitempty"`
+		UsedPercent   string `json:"used_percent"`
+		ViolationTime string `json:"violation_time"`
 	}
 	pows := make([]temp, len(o.UsagesNVML))
+	over := []string{}
+	reduced := []string{}
 	for i, u := range o.UsagesNVML {
 		pows[i] = temp{
-			UUID:        u.UUID,
-			LimitW:      fmt.Sprintf("%.2f W", float64(u.EnforcedLimitMilliWatts)/1000.0),
-			UsageW:      fmt.Sprintf("%.2f W", float64(u.UsageMilliWatts)/1000.0),
-			UsedPercent: u.UsedPercent,
+			UUID:          u.UUID,
+			LimitW:        fmt.Sprintf("%.2f W", float64(u.EnforcedLimitMilliWatts)/1000.0),
+			UsageW:        fmt.Sprintf("%.2f W", float64(u.UsageMilliWatts)/1000.0),
+			UsedPercent:   u.UsedPercent,
+			ViolationTime: time.Duration(u.PowerViolationNanoseconds).String(),
+		}
+		if u.DefaultLimitMilliWatts > 0 {
+			pows[i].DefaultLimitW = fmt.Sprintf("%.2f W", float64(u.DefaultLimitMilliWatts)/1000.0)
+		}
+		if u.AverageUsageMilliWatts > 0 {
+			pows[i].AverageW = fmt.Sprintf("%.2f W", float64(u.AverageUsageMilliWatts)/1000.0)
+		}
+		if !o.AllowReducedPowerLimit && u.LimitBelowDefault() {
+			reduced = append(reduced, fmt.Sprintf("%s (%s < %s)", u.UUID, pows[i].LimitW, pows[i].DefaultLimitW))
+		}
+
+		if o.Thresholds.MaxPowerUsedPercent <= 0 {
+			continue
+		}
+		usedPercent, err := u.GetUsedPercent()
+		if err != nil {
+			continue
+		}
+		if usedPercent >= o.Thresholds.MaxPowerUsedPercent {
+			over = append(over, fmt.Sprintf("%s (%s%%)", u.UUID, u.UsedPercent))
 		}
 	}
 	yb, err := yaml.Marshal(pows)
 	if err != nil {
 		return "", false, err
 	}
+
+	var reasons []string
+	if len(over) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d GPU(s) at or above the %.0f%% power limit threshold (preset %q): %s",
+			len(over),
+			o.Thresholds.MaxPowerUsedPercent,
+			o.ThresholdPreset,
+			strings.Join(over, ", "),
+		))
+	}
+	if len(reduced) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d GPU(s) with the enforced power limit below the default: %s",
+			len(reduced),
+			strings.Join(reduced, ", "),
+		))
+	}
+	if len(o.PowerBrakeSlowdowns) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d GPU(s) with the HW power brake slowdown: %s",
+			len(o.PowerBrakeSlowdowns),
+			strings.Join(o.PowerBrakeSlowdowns, ", "),
+		))
+	}
+	if len(reasons) > 0 {
+		return strings.Join(reasons, "; "), false, nil
+	}
 	return string(yb), true, nil
 }
 
+// Rules returns the per-GPU power rules evaluated by Evaluate,
+// with the threshold source of the max power usage (see nvidia_query.GPUThresholdSource).
+func (o *Output) Rules(thresholdSource string) []components.Rule {
+	rules := make([]components.Rule, 0, len(o.UsagesNVML))
+	for _, u := range o.UsagesNVML {
+		r := components.Rule{
+			Name:            nvidia_query.GPUThresholdNameMaxPowerUsedPercent,
+			Subject:         u.UUID,
+			Observed:        u.UsedPercent + "%",
+			ThresholdSource: thresholdSource,
+			Passed:          true,
+		}
+		if o.Thresholds.MaxPowerUsedPercent > 0 {
+			r.Threshold = fmt.Sprintf("%.0f%%", o.Thresholds.MaxPowerUsedPercent)
+			if usedPercent, err := u.GetUsedPercent(); err == nil {
+				r.Passed = usedPercent < o.Thresholds.MaxPowerUsedPercent
+			}
+		}
+		rules = append(rules, r)
+
+		if !o.AllowReducedPowerLimit && u.DefaultLimitMilliWatts > 0 {
+			rules = append(rules, components.Rule{
+				Name:            "enforced_power_limit_not_below_default",
+				Subject:         u.UUID,
+				Observed:        fmt.Sprintf("%.2f W", float64(u.EnforcedLimitMilliWatts)/1000.0),
+				Threshold:       fmt.Sprintf("%.2f W", float64(u.DefaultLimitMilliWatts)/1000.0),
+				ThresholdSource: "default power limit (allow_reduced_power_limit disabled)",
+				Passed:          !u.LimitBelowDefault(),
+			})
+		}
+	}
+	for _, s := range o.PowerBrakeSlowdowns {
+		rules = append(rules, components.Rule{
+			Name:     "no_hw_power_brake_slowdown",
+			Observed: s,
+			Passed:   false,
+		})
+	}
+	return rules
+}
+
+// ThresholdReadings returns the per-GPU power usages against the configured threshold,
+// to track the threshold crossings.
+func (o *Output) ThresholdReadings() []nvidia_query_threshold_breach_state.Reading {
+	rs := make([]nvidia_query_threshold_breach_state.Reading, 0, len(o.UsagesNVML))
+	for _, u := range o.UsagesNVML {
+		usedPercent, err := u.GetUsedPercent()
+		if err != nil {
+			continue
+		}
+		rs = append(rs, nvidia_query_threshold_breach_state.Reading{
+			ThresholdName: nvidia_query.GPUThresholdNameMaxPowerUsedPercent,
+			GPUUUID:       u.UUID,
+			Value:         usedPercent,
+			Threshold:     o.Thresholds.MaxPowerUsedPercent,
+		})
+	}
+	return rs
+}
+
 func (o *Output) States() ([]components.State, error) {
 	outputReasons, healthy, err := o.Evaluate()
 	if err != nil {
@@ -129,5 +270,44 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyPowerUsageEncoding: StateValuePowerUsageEncodingJSON,
 		},
 	}
-	return []components.State{state}, nil
+	if len(o.PowerBrakeSlowdowns) > 0 {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions:  []string{fmt.Sprintf("inspect the power supply and the baseboard of GPU(s) %s (power brake asserted)", strings.Join(o.PowerBrakeSlowdowns, ", "))},
+			RepairActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+		}
+	}
+	return append([]components.State{state}, o.GPUStates()...), nil
+}
+
+// GPUStates returns the per-GPU states (e.g., "power_usage_GPU-1234"),
+// unhealthy if the GPU is at or above the power usage threshold,
+// its enforced power limit is below the default, or its HW power brake slowdown is active.
+func (o *Output) GPUStates() []components.State {
+	gs := nvidia_query.NewGPUStates(StateNamePowerUsage)
+	for _, u := range o.UsagesNVML {
+		gs.Add(u.UUID)
+
+		if o.Thresholds.MaxPowerUsedPercent > 0 {
+			if usedPercent, err := u.GetUsedPercent(); err == nil && usedPercent >= o.Thresholds.MaxPowerUsedPercent {
+				gs.Unhealthy(u.UUID, fmt.Sprintf("%s%% at or above the %.0f%% power limit threshold (preset %q)",
+					u.UsedPercent,
+					o.Thresholds.MaxPowerUsedPercent,
+					o.ThresholdPreset,
+				), nil)
+			}
+		}
+		if !o.AllowReducedPowerLimit && u.LimitBelowDefault() {
+			gs.Unhealthy(u.UUID, fmt.Sprintf("enforced power limit below the default (%.2f W < %.2f W)",
+				float64(u.EnforcedLimitMilliWatts)/1000.0,
+				float64(u.DefaultLimitMilliWatts)/1000.0,
+			), nil)
+		}
+	}
+	for _, uuid := range o.PowerBrakeSlowdowns {
+		gs.Unhealthy(uuid, "HW power brake slowdown", &common.SuggestedActions{
+			Descriptions:  []string{"inspect the power supply and the baseboard of the GPU (power brake asserted)"},
+			RepairActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+		})
+	}
+	return gs.States()
 }
@@ -0,0 +1,125 @@
+package power
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+)
+
+func TestOutputEvaluate(t *testing.T) {
+	normal := nvidia_query_nvml.Power{
+		UUID:                    "GPU-0",
+		UsageMilliWatts:         300000,
+		AverageUsageMilliWatts:  310000,
+		EnforcedLimitMilliWatts: 700000,
+		DefaultLimitMilliWatts:  700000,
+		UsedPercent:             "42.86",
+	}
+	capped := nvidia_query_nvml.Power{
+		UUID:                    "GPU-1",
+		UsageMilliWatts:         300000,
+		EnforcedLimitMilliWatts: 400000,
+		DefaultLimitMilliWatts:  700000,
+		UsedPercent:             "75.00",
+	}
+	unknownDefault := nvidia_query_nvml.Power{
+		UUID:                    "GPU-2",
+		UsageMilliWatts:         300000,
+		EnforcedLimitMilliWatts: 400000,
+		UsedPercent:             "75.00",
+	}
+
+	tests := []struct {
+		name          string
+		output        Output
+		wantHealthy   bool
+		wantReason    string
+		wantInspected bool
+		wantUnhealthy []string
+	}{
+		{
+			name:        "healthy",
+			output:      Output{UsagesNVML: []nvidia_query_nvml.Power{normal, unknownDefault}},
+			wantHealthy: true,
+		},
+		{
+			name:          "enforced limit below default",
+			output:        Output{UsagesNVML: []nvidia_query_nvml.Power{normal, capped}},
+			wantHealthy:   false,
+			wantReason:    "1 GPU(s) with the enforced power limit below the default: GPU-1 (400.00 W < 700.00 W)",
+			wantUnhealthy: []string{"GPU-1"},
+		},
+		{
+			name:        "reduced limit allowed",
+			output:      Output{UsagesNVML: []nvidia_query_nvml.Power{normal, capped}, AllowReducedPowerLimit: true},
+			wantHealthy: true,
+		},
+		{
+			name: "over threshold",
+			output: Output{
+				UsagesNVML: []nvidia_query_nvml.Power{capped},
+				Thresholds: nvidia_query.GPUThresholds{MaxPowerUsedPercent: 70},
+			},
+			wantHealthy:   false,
+			wantReason:    "1 GPU(s) at or above the 70% power limit threshold",
+			wantUnhealthy: []string{"GPU-1"},
+		},
+		{
+			name:          "power brake",
+			output:        Output{UsagesNVML: []nvidia_query_nvml.Power{normal}, PowerBrakeSlowdowns: []string{"GPU-0"}},
+			wantHealthy:   false,
+			wantReason:    "1 GPU(s) with the HW power brake slowdown: GPU-0",
+			wantInspected: true,
+			wantUnhealthy: []string{"GPU-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states, err := tt.output.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != 1+len(tt.output.UsagesNVML) {
+				t.Fatalf("expected %d states, got %d", 1+len(tt.output.UsagesNVML), len(states))
+			}
+			if err := components.ValidateStates(Name, states...); err != nil {
+				t.Errorf("states do not match the schema: %v", err)
+			}
+			var unhealthy []string
+			for _, gst := range states[1:] {
+				if gst.Name != StateNamePowerUsage+"_"+gst.ExtraInfo[nvidia_query.StateKeyGPUUUID] {
+					t.Errorf("unexpected per-GPU state name %q", gst.Name)
+				}
+				if !gst.Healthy {
+					unhealthy = append(unhealthy, gst.ExtraInfo[nvidia_query.StateKeyGPUUUID])
+				}
+			}
+			if !reflect.DeepEqual(unhealthy, tt.wantUnhealthy) {
+				t.Errorf("expected unhealthy GPUs %v, got %v", tt.wantUnhealthy, unhealthy)
+			}
+			st := states[0]
+			if st.Healthy != tt.wantHealthy {
+				t.Errorf("expected healthy %v, got %v (%s)", tt.wantHealthy, st.Healthy, st.Reason)
+			}
+			if tt.wantReason != "" && !strings.Contains(st.Reason, tt.wantReason) {
+				t.Errorf("expected reason to contain %q, got %q", tt.wantReason, st.Reason)
+			}
+			passed := true
+			for _, r := range tt.output.Rules("config override") {
+				passed = passed && r.Passed
+			}
+			if passed != tt.wantHealthy {
+				t.Errorf("expected rules passed %v, got %v", tt.wantHealthy, passed)
+			}
+			inspected := st.SuggestedActions != nil && st.SuggestedActions.RepairActions[0] == common.RepairActionTypeHardwareInspection
+			if inspected != tt.wantInspected {
+				t.Errorf("expected hardware inspection %v, got %+v", tt.wantInspected, st.SuggestedActions)
+			}
+		})
+	}
+}
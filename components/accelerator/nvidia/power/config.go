@@ -4,11 +4,21 @@ import (
 	"database/sql"
 	"encoding/json"
 
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	query_config "github.com/leptonai/gpud/components/query/config"
 )
 
 type Config struct {
 	Query query_config.Config `json:"query"`
+
+	// Thresholds overrides the thresholds of the preset
+	// picked based on the detected GPU product name (e.g., per fleet).
+	// Leave empty to use the preset thresholds.
+	Thresholds *nvidia_query.GPUThresholds `json:"thresholds,omitempty"`
+
+	// Set true to not report the enforced power limit below the default limit
+	// (e.g., the fleet intentionally caps the GPU power).
+	AllowReducedPowerLimit bool `json:"allow_reduced_power_limit"`
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -58,6 +58,12 @@ const (
 	StateValueProcessesEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameProcesses, "per-GPU processes"),
+	)
+}
+
 func ParseStateProcesses(m map[string]string) (*Output, error) {
 	data := m[StateKeyProcessesData]
 	return ParseOutputJSON([]byte(data))
@@ -0,0 +1,211 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	driver_baseline "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-baseline"
+	"github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/locale"
+)
+
+// ErrStackAbsent is the query error when the NVIDIA driver is not loaded or the NVIDIA libraries
+// are not found (e.g., CPU-only node, or the driver being reinstalled).
+var ErrStackAbsent = errors.New("nvidia driver not loaded or nvidia library not found")
+
+// IsStackAbsent returns true if the query error is caused by the absent NVIDIA stack.
+func IsStackAbsent(err error) bool {
+	return errors.Is(err, ErrStackAbsent)
+}
+
+// StackAbsentError is the query error when the NVIDIA stack is absent,
+// with the driver last seen working on the node, if any.
+type StackAbsentError struct {
+	// Err is the NVML initialization error.
+	Err error
+	// Baseline is the driver last seen working on the node,
+	// or nil if never seen working (e.g., the driver never installed).
+	Baseline *driver_baseline.Baseline
+}
+
+func (e *StackAbsentError) Error() string {
+	if e.Baseline == nil {
+		return fmt.Sprintf("%v: %v", ErrStackAbsent, e.Err)
+	}
+	return fmt.Sprintf("%v (driver %s last seen working at %s): %v", ErrStackAbsent, e.Baseline.DriverVersion, e.Baseline.LastSeen.Format(time.RFC3339), e.Err)
+}
+
+func (e *StackAbsentError) Unwrap() []error {
+	return []error{ErrStackAbsent, e.Err}
+}
+
+// Crashed returns true if the driver previously worked on the node,
+// thus crashed or unloaded rather than never installed.
+func (e *StackAbsentError) Crashed() bool {
+	return e.Baseline != nil
+}
+
+// newStackAbsentError returns the stack absent error with the driver baseline read from the database.
+func newStackAbsentError(ctx context.Context, db *sql.DB, err error) error {
+	e := &StackAbsentError{Err: err}
+	if db == nil {
+		return e
+	}
+	b, rerr := driver_baseline.ReadBaseline(ctx, db)
+	if rerr != nil {
+		log.Logger.Debugw("failed to read nvidia driver baseline", "error", rerr)
+		return e
+	}
+	e.Baseline = b
+	return e
+}
+
+// the driver baseline is refreshed at most once per interval,
+// unless the driver version or the GPU count changes
+const driverBaselineRefreshInterval = time.Hour
+
+var (
+	lastDriverBaselineMu sync.Mutex
+	lastDriverBaseline   driver_baseline.Baseline
+)
+
+// recordDriverBaseline records the driver seen working, to tell the driver crashed or unloaded
+// from the driver never installed when the NVIDIA stack is absent later.
+func recordDriverBaseline(ctx context.Context, db *sql.DB, gpuCount int) {
+	if db == nil {
+		return
+	}
+	ver, err := nvml.GetDriverVersion()
+	if err != nil {
+		log.Logger.Debugw("failed to get driver version for the baseline", "error", err)
+		return
+	}
+
+	lastDriverBaselineMu.Lock()
+	defer lastDriverBaselineMu.Unlock()
+
+	now := time.Now().UTC()
+	if lastDriverBaseline.DriverVersion == ver && lastDriverBaseline.GPUCount == gpuCount && now.Sub(lastDriverBaseline.LastSeen) < driverBaselineRefreshInterval {
+		return
+	}
+
+	b := driver_baseline.Baseline{DriverVersion: ver, GPUCount: gpuCount, LastSeen: now}
+	if err := driver_baseline.SetBaseline(ctx, db, b); err != nil {
+		log.Logger.Debugw("failed to record nvidia driver baseline", "error", err)
+		return
+	}
+	lastDriverBaseline = b
+}
+
+const (
+	// StateKeyStackAbsent is the extra info key set to "true" when the NVIDIA stack is absent.
+	StateKeyStackAbsent = "nvidia_stack_absent"
+	// StateKeyGPUExpected is the extra info key of the "gpu_expected" configuration.
+	StateKeyGPUExpected = "gpu_expected"
+	// StateKeyDriverStatus is the extra info key of the driver status when the NVIDIA stack is absent,
+	// either "not_installed" or "crashed".
+	StateKeyDriverStatus = "nvidia_driver_status"
+
+	// the driver never seen working on the node
+	DriverStatusNotInstalled = "not_installed"
+	// the driver seen working on the node, then crashed or unloaded
+	DriverStatusCrashed = "crashed"
+)
+
+var gpuExpected atomic.Bool
+
+// SetGPUExpected sets whether the node is expected to have the NVIDIA GPUs,
+// in which case the absent NVIDIA stack is reported as unhealthy ("driver missing"),
+// otherwise as healthy ("not applicable").
+func SetGPUExpected(expected bool) {
+	gpuExpected.Store(expected)
+}
+
+// GPUExpected returns true if the node is expected to have the NVIDIA GPUs.
+func GPUExpected() bool {
+	return gpuExpected.Load()
+}
+
+// StackAbsentStates returns the state of the component when the query failed
+// due to the absent NVIDIA stack, instead of the query error.
+// The driver crashed or unloaded after previously working is always unhealthy
+// (the node had the working GPUs), suggesting the driver reload,
+// while the driver never installed is unhealthy only if the GPUs are expected.
+// Returns false if the error is not caused by the absent NVIDIA stack.
+func StackAbsentStates(name string, err error) ([]components.State, bool) {
+	if !IsStackAbsent(err) {
+		return nil, false
+	}
+
+	extraInfo := map[string]string{
+		StateKeyStackAbsent:  "true",
+		StateKeyGPUExpected:  strconv.FormatBool(GPUExpected()),
+		StateKeyDriverStatus: DriverStatusNotInstalled,
+	}
+
+	var absentErr *StackAbsentError
+	if errors.As(err, &absentErr) && absentErr.Crashed() {
+		extraInfo[StateKeyDriverStatus] = DriverStatusCrashed
+		b := absentErr.Baseline
+		return []components.State{
+			{
+				Name:    name,
+				Healthy: false,
+				Error:   err.Error(),
+				Reason: fmt.Sprintf("nvidia driver crashed or unloaded, driver %s with %d GPU(s) last seen working %s",
+					b.DriverVersion,
+					b.GPUCount,
+					locale.Time(b.LastSeen),
+				),
+				ExtraInfo: extraInfo,
+				SuggestedActions: &common.SuggestedActions{
+					Descriptions: []string{
+						"the nvidia driver previously worked on the node, reload the NVIDIA driver (e.g., 'modprobe nvidia'), and reboot the system if the reload fails",
+					},
+					RepairActions: []common.RepairActionType{
+						common.RepairActionTypeReloadDriver,
+						common.RepairActionTypeRebootSystem,
+					},
+				},
+			},
+		}, true
+	}
+
+	if !GPUExpected() {
+		return []components.State{
+			{
+				Name:      name,
+				Healthy:   true,
+				Reason:    "not applicable, nvidia driver or library not found (gpu not expected)",
+				ExtraInfo: extraInfo,
+			},
+		}, true
+	}
+
+	return []components.State{
+		{
+			Name:      name,
+			Healthy:   false,
+			Error:     err.Error(),
+			Reason:    "nvidia driver missing, gpu expected but nvidia driver never seen working on the node",
+			ExtraInfo: extraInfo,
+			SuggestedActions: &common.SuggestedActions{
+				Descriptions: []string{
+					"gpu expected but the nvidia driver is not installed, install the NVIDIA driver",
+				},
+				RepairActions: []common.RepairActionType{
+					common.RepairActionTypeInstallDriver,
+				},
+			},
+		},
+	}, true
+}
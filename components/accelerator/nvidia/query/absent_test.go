@@ -0,0 +1,59 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	driver_baseline "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-baseline"
+)
+
+func TestStackAbsentStates(t *testing.T) {
+	defer SetGPUExpected(false)
+
+	if _, ok := StackAbsentStates("test", errors.New("other error")); ok {
+		t.Fatal("expected not absent for the other errors")
+	}
+
+	err := fmt.Errorf("%w: failed to initialize NVML", ErrStackAbsent)
+
+	SetGPUExpected(false)
+	states, ok := StackAbsentStates("test", err)
+	if !ok || len(states) != 1 {
+		t.Fatalf("expected 1 state, got %v", states)
+	}
+	if !states[0].Healthy || states[0].ExtraInfo[StateKeyGPUExpected] != "false" {
+		t.Errorf("expected healthy not applicable state, got %+v", states[0])
+	}
+
+	SetGPUExpected(true)
+	states, ok = StackAbsentStates("test", err)
+	if !ok || len(states) != 1 {
+		t.Fatalf("expected 1 state, got %v", states)
+	}
+	if states[0].Healthy || states[0].ExtraInfo[StateKeyGPUExpected] != "true" || states[0].Error == "" {
+		t.Errorf("expected unhealthy driver missing state, got %+v", states[0])
+	}
+
+	if states[0].ExtraInfo[StateKeyDriverStatus] != DriverStatusNotInstalled || !states[0].SuggestedActions.RequiresDriverInstall() {
+		t.Errorf("expected driver not installed, got %+v", states[0])
+	}
+
+	// previously working driver is unhealthy regardless of the gpu expected
+	SetGPUExpected(false)
+	crashed := &StackAbsentError{
+		Err:      errors.New("NVIDIA driver not loaded"),
+		Baseline: &driver_baseline.Baseline{DriverVersion: "535.161.08", GPUCount: 8, LastSeen: time.Now().Add(-time.Hour)},
+	}
+	if !IsStackAbsent(crashed) {
+		t.Fatal("expected stack absent")
+	}
+	states, ok = StackAbsentStates("test", fmt.Errorf("query failed: %w", crashed))
+	if !ok || len(states) != 1 {
+		t.Fatalf("expected 1 state, got %v", states)
+	}
+	if states[0].Healthy || states[0].ExtraInfo[StateKeyDriverStatus] != DriverStatusCrashed || !states[0].SuggestedActions.RequiresDriverReload() {
+		t.Errorf("expected unhealthy driver crashed state, got %+v", states[0])
+	}
+}
@@ -0,0 +1,197 @@
+// Package bugreport collects the NVIDIA bug reports ("nvidia-bug-report.sh") on the fatal Xid/SXid errors,
+// and records the archive paths per event so the support tickets can be filed with the full context.
+package bugreport
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/process"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Command is the NVIDIA bug report script shipped with the driver.
+const Command = "nvidia-bug-report.sh"
+
+const TableNameBugReports = "components_accelerator_nvidia_query_bug_reports"
+
+const (
+	// unix timestamp in seconds when the bug report was collected
+	ColumnUnixSeconds = "unix_seconds"
+
+	// unix timestamp in seconds of the event that triggered the bug report
+	ColumnEventUnixSeconds = "event_unix_seconds"
+
+	// either "xid" or "sxid"
+	ColumnEventType = "event_type"
+
+	// event id; xid or sxid
+	ColumnEventID = "event_id"
+
+	// path to the bug report archive, empty if the collection failed
+	ColumnArchivePath = "archive_path"
+
+	// collection error, empty if succeeded
+	ColumnError = "error"
+)
+
+// Report is a bug report collected for an event.
+type Report struct {
+	UnixSeconds      int64
+	EventUnixSeconds int64
+	EventType        string
+	EventID          int64
+	ArchivePath      string
+	Error            string
+}
+
+// Key returns the key of the triggering event.
+func (r Report) Key() string {
+	return EventKey(r.EventUnixSeconds, r.EventType, r.EventID)
+}
+
+// EventKey returns the key of the Xid/SXid event to look up the bug report.
+func EventKey(unixSeconds int64, eventType string, eventID int64) string {
+	return fmt.Sprintf("%d/%s/%d", unixSeconds, eventType, eventID)
+}
+
+func CreateTableBugReports(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT,
+	%s TEXT
+);`, TableNameBugReports,
+		ColumnUnixSeconds,
+		ColumnEventUnixSeconds,
+		ColumnEventType,
+		ColumnEventID,
+		ColumnArchivePath,
+		ColumnError,
+	))
+	return err
+}
+
+func InsertReport(ctx context.Context, db *sql.DB, r Report) error {
+	log.Logger.Debugw("inserting bug report", "eventType", r.EventType, "eventID", r.EventID, "archivePath", r.ArchivePath)
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''));`,
+		TableNameBugReports,
+		ColumnUnixSeconds,
+		ColumnEventUnixSeconds,
+		ColumnEventType,
+		ColumnEventID,
+		ColumnArchivePath,
+		ColumnError,
+	), r.UnixSeconds, r.EventUnixSeconds, r.EventType, r.EventID, r.ArchivePath, r.Error)
+	return err
+}
+
+// ReadReports returns the bug reports of the events since the given time (if non-zero),
+// in the ascending order of the collection time.
+// Returns nil if no report is found.
+func ReadReports(ctx context.Context, db *sql.DB, since time.Time) ([]Report, error) {
+	query := fmt.Sprintf(`SELECT %s, %s, %s, %s, COALESCE(%s, ''), COALESCE(%s, '')
+FROM %s
+WHERE %s >= ?
+ORDER BY %s ASC;`,
+		ColumnUnixSeconds,
+		ColumnEventUnixSeconds,
+		ColumnEventType,
+		ColumnEventID,
+		ColumnArchivePath,
+		ColumnError,
+		TableNameBugReports,
+		ColumnEventUnixSeconds,
+		ColumnUnixSeconds,
+	)
+	var sinceUnix int64
+	if !since.IsZero() {
+		sinceUnix = since.UTC().Unix()
+	}
+
+	rows, err := db.QueryContext(ctx, query, sinceUnix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []Report
+	for rows.Next() {
+		var r Report
+		if err := rows.Scan(&r.UnixSeconds, &r.EventUnixSeconds, &r.EventType, &r.EventID, &r.ArchivePath, &r.Error); err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// LastCollected returns the time of the latest bug report collection (succeeded or not),
+// or zero time if none is found.
+func LastCollected(ctx context.Context, db *sql.DB) (time.Time, error) {
+	var unixSeconds sql.NullInt64
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT MAX(%s) FROM %s;`, ColumnUnixSeconds, TableNameBugReports)).Scan(&unixSeconds)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !unixSeconds.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(unixSeconds.Int64, 0).UTC(), nil
+}
+
+// Collect runs the bug report script until it completes or the context is canceled,
+// and returns the path to the archive in the directory.
+func Collect(ctx context.Context, dir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	// the script appends ".gz" to the output file
+	base := filepath.Join(dir, fmt.Sprintf("nvidia-bug-report-%d.log", now.Unix()))
+	logFile, err := os.Create(base + ".out")
+	if err != nil {
+		return "", err
+	}
+	defer logFile.Close()
+
+	proc, err := process.New(
+		process.WithCommand(Command, "--output-file", base),
+		process.WithOutputFile(logFile),
+	)
+	if err != nil {
+		return "", err
+	}
+	if err := proc.Start(ctx); err != nil {
+		return "", err
+	}
+
+	select {
+	case <-ctx.Done():
+		_ = proc.Abort(context.Background())
+		return "", ctx.Err()
+	case err := <-proc.Wait():
+		if err != nil {
+			return "", fmt.Errorf("%s failed (see %s): %w", Command, logFile.Name(), err)
+		}
+	}
+
+	archive := base + ".gz"
+	if _, err := os.Stat(archive); err != nil {
+		return "", fmt.Errorf("%s completed without the archive: %w", Command, err)
+	}
+	return archive, nil
+}
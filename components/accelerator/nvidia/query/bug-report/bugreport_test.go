@@ -0,0 +1,75 @@
+package bugreport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestInsertAndReadReports(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableBugReports(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	last, err := LastCollected(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !last.IsZero() {
+		t.Fatalf("expected zero time without reports, got %v", last)
+	}
+
+	now := time.Now().UTC()
+	reports := []Report{
+		{UnixSeconds: now.Add(-time.Hour).Unix(), EventUnixSeconds: now.Add(-2 * time.Hour).Unix(), EventType: "xid", EventID: 79, ArchivePath: "/var/lib/gpud/bug-reports/a.log.gz"},
+		{UnixSeconds: now.Unix(), EventUnixSeconds: now.Add(-time.Minute).Unix(), EventType: "sxid", EventID: 20034, Error: "timed out"},
+	}
+	for _, r := range reports {
+		if err := InsertReport(ctx, db, r); err != nil {
+			t.Fatalf("InsertReport failed: %v", err)
+		}
+	}
+
+	all, err := ReadReports(ctx, db, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(all))
+	}
+	if all[0].ArchivePath != reports[0].ArchivePath || all[1].Error != "timed out" || all[1].ArchivePath != "" {
+		t.Errorf("unexpected reports: %+v", all)
+	}
+	if all[0].Key() != EventKey(reports[0].EventUnixSeconds, "xid", 79) {
+		t.Errorf("unexpected key %q", all[0].Key())
+	}
+
+	recent, err := ReadReports(ctx, db, now.Add(-90*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 1 || recent[0].EventID != 20034 {
+		t.Errorf("unexpected recent reports: %+v", recent)
+	}
+
+	last, err = LastCollected(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Unix() != now.Unix() {
+		t.Errorf("expected last collected %v, got %v", now, last)
+	}
+}
@@ -0,0 +1,513 @@
+// Package coolingtrend tracks the GPU temperature at the fixed power draw across weeks,
+// to detect the cooling degradation (e.g., dried thermal paste, clogged heat sink, failing fans)
+// before the GPU starts to throttle.
+package coolingtrend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TableNameCoolingTrendBuckets stores the daily temperature sums per GPU and power bin.
+	TableNameCoolingTrendBuckets = "components_accelerator_nvidia_query_cooling_trend_buckets"
+	// TableNameCoolingTrendAdvisories stores the cooling degradation advisories.
+	TableNameCoolingTrendAdvisories = "components_accelerator_nvidia_query_cooling_trend_advisories"
+)
+
+const (
+	// unix timestamp in seconds of the day (UTC) for the buckets,
+	// or when the advisory was recorded
+	ColumnUnixSeconds = "unix_seconds"
+
+	// GPU UUID
+	ColumnGPUUUID = "gpu_uuid"
+
+	// lower bound of the power bin in watts
+	ColumnPowerBinWatts = "power_bin_watts"
+
+	// sum of the observed temperatures in the bucket
+	ColumnSumCelsius = "sum_celsius"
+
+	// number of the observed temperatures in the bucket
+	ColumnCount = "count"
+
+	// temperature increase at the fixed power per week
+	ColumnSlopeCelsiusPerWeek = "slope_celsius_per_week"
+
+	// number of days between the first and the last observation of the analysis
+	ColumnSpanDays = "span_days"
+)
+
+const (
+	EventNameCoolingDegradation = "cooling_degradation"
+
+	EventKeyGPUUUID             = "gpu_uuid"
+	EventKeySlopeCelsiusPerWeek = "slope_celsius_per_week"
+	EventKeySpanDays            = "span_days"
+)
+
+const (
+	DefaultWindow                       = 8 * 7 * 24 * time.Hour
+	DefaultMinSpan                      = 14 * 24 * time.Hour
+	DefaultMinDays                      = 7
+	DefaultPowerBinWatts                = 50
+	DefaultMinPowerWatts                = 100
+	DefaultSlopeThresholdCelsiusPerWeek = 0.5
+	DefaultAnalyzeInterval              = 24 * time.Hour
+
+	// the same GPU is advised at most once a week
+	advisoryInterval = 7 * 24 * time.Hour
+)
+
+// Policy defines how the temperature-at-power trend is analyzed.
+type Policy struct {
+	// Disabled disables the cooling trend analysis.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Window is how far back the observations are kept and analyzed.
+	Window metav1.Duration `json:"window"`
+	// MinSpan is the minimum span of the observations to analyze the trend,
+	// so that the short-term ambient changes are not reported.
+	MinSpan metav1.Duration `json:"min_span"`
+	// MinDays is the minimum number of the days with the observations to analyze the trend.
+	MinDays int `json:"min_days"`
+
+	// PowerBinWatts is the width of the power bins to compare the temperatures at.
+	PowerBinWatts int `json:"power_bin_watts"`
+	// MinPowerWatts is the minimum power draw to track,
+	// since the idle temperatures are dominated by the ambient temperature.
+	MinPowerWatts int `json:"min_power_watts"`
+
+	// SlopeThresholdCelsiusPerWeek is the weekly temperature increase at the fixed power
+	// at or above which the cooling degradation advisory is recorded.
+	SlopeThresholdCelsiusPerWeek float64 `json:"slope_threshold_celsius_per_week"`
+
+	// AnalyzeInterval is the interval between two analyses.
+	AnalyzeInterval metav1.Duration `json:"analyze_interval"`
+}
+
+func (p *Policy) SetDefaultsIfNotSet() {
+	if p.Window.Duration == 0 {
+		p.Window = metav1.Duration{Duration: DefaultWindow}
+	}
+	if p.MinSpan.Duration == 0 {
+		p.MinSpan = metav1.Duration{Duration: DefaultMinSpan}
+	}
+	if p.MinDays == 0 {
+		p.MinDays = DefaultMinDays
+	}
+	if p.PowerBinWatts == 0 {
+		p.PowerBinWatts = DefaultPowerBinWatts
+	}
+	if p.MinPowerWatts == 0 {
+		p.MinPowerWatts = DefaultMinPowerWatts
+	}
+	if p.SlopeThresholdCelsiusPerWeek == 0 {
+		p.SlopeThresholdCelsiusPerWeek = DefaultSlopeThresholdCelsiusPerWeek
+	}
+	if p.AnalyzeInterval.Duration == 0 {
+		p.AnalyzeInterval = metav1.Duration{Duration: DefaultAnalyzeInterval}
+	}
+}
+
+func (p Policy) Validate() error {
+	if p.Window.Duration < 0 || p.MinSpan.Duration < 0 || p.AnalyzeInterval.Duration < 0 {
+		return fmt.Errorf("cooling trend durations must be non-negative")
+	}
+	if p.MinSpan.Duration > p.Window.Duration && p.Window.Duration > 0 {
+		return fmt.Errorf("cooling trend min span %s exceeds the window %s", p.MinSpan.Duration, p.Window.Duration)
+	}
+	if p.MinDays < 0 || p.PowerBinWatts < 0 || p.MinPowerWatts < 0 {
+		return fmt.Errorf("cooling trend min days and power watts must be non-negative")
+	}
+	if p.SlopeThresholdCelsiusPerWeek < 0 {
+		return fmt.Errorf("cooling trend slope threshold must be non-negative")
+	}
+	return nil
+}
+
+// Bucket is the temperatures of a GPU observed in a day within a power bin.
+type Bucket struct {
+	UnixSeconds   int64
+	GPUUUID       string
+	PowerBinWatts int
+	SumCelsius    float64
+	Count         int64
+}
+
+// MeanCelsius returns the mean temperature of the bucket.
+func (b Bucket) MeanCelsius() float64 {
+	if b.Count == 0 {
+		return 0
+	}
+	return b.SumCelsius / float64(b.Count)
+}
+
+// Advisory is the cooling degradation advisory of a GPU.
+type Advisory struct {
+	UnixSeconds         int64
+	GPUUUID             string
+	SlopeCelsiusPerWeek float64
+	SpanDays            int
+}
+
+// ToComponentEvent converts the advisory to the component event.
+func (a Advisory) ToComponentEvent() components.Event {
+	return components.Event{
+		Time:    metav1.Time{Time: time.Unix(a.UnixSeconds, 0).UTC()},
+		Name:    EventNameCoolingDegradation,
+		Type:    components.EventTypeWarn,
+		Message: fmt.Sprintf("%s temperature at the same power draw rising %.2f °C/week over %d days (check the thermal paste, heat sink, and fans)", a.GPUUUID, a.SlopeCelsiusPerWeek, a.SpanDays),
+		ExtraInfo: map[string]string{
+			EventKeyGPUUUID:             a.GPUUUID,
+			EventKeySlopeCelsiusPerWeek: fmt.Sprintf("%.2f", a.SlopeCelsiusPerWeek),
+			EventKeySpanDays:            fmt.Sprintf("%d", a.SpanDays),
+		},
+	}
+}
+
+func CreateTables(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s REAL NOT NULL,
+	%s INTEGER NOT NULL,
+	UNIQUE(%s, %s, %s)
+);`, TableNameCoolingTrendBuckets,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnPowerBinWatts,
+		ColumnSumCelsius,
+		ColumnCount,
+		ColumnUnixSeconds, ColumnGPUUUID, ColumnPowerBinWatts,
+	)); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s REAL NOT NULL,
+	%s INTEGER NOT NULL
+);`, TableNameCoolingTrendAdvisories,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnSlopeCelsiusPerWeek,
+		ColumnSpanDays,
+	))
+	return err
+}
+
+// AddToBucket adds the observed temperature to the daily bucket of the GPU and power bin.
+func AddToBucket(ctx context.Context, db *sql.DB, day time.Time, gpuUUID string, powerBinWatts int, celsius float64) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, 1)
+ON CONFLICT(%s, %s, %s) DO UPDATE SET %s = %s + excluded.%s, %s = %s + 1;
+`,
+		TableNameCoolingTrendBuckets,
+		ColumnUnixSeconds, ColumnGPUUUID, ColumnPowerBinWatts, ColumnSumCelsius, ColumnCount,
+		ColumnUnixSeconds, ColumnGPUUUID, ColumnPowerBinWatts,
+		ColumnSumCelsius, ColumnSumCelsius, ColumnSumCelsius,
+		ColumnCount, ColumnCount,
+	), day.UTC().Unix(), gpuUUID, powerBinWatts, celsius)
+	return err
+}
+
+// ReadBuckets returns the buckets since the given time, in the ascending order of the day.
+// Returns nil if no bucket is found.
+func ReadBuckets(ctx context.Context, db *sql.DB, since time.Time) ([]Bucket, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT %s, %s, %s, %s, %s
+FROM %s
+WHERE %s >= ?
+ORDER BY %s ASC`,
+		ColumnUnixSeconds, ColumnGPUUUID, ColumnPowerBinWatts, ColumnSumCelsius, ColumnCount,
+		TableNameCoolingTrendBuckets,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+	), since.UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []Bucket
+	for rows.Next() {
+		var b Bucket
+		if err := rows.Scan(&b.UnixSeconds, &b.GPUUUID, &b.PowerBinWatts, &b.SumCelsius, &b.Count); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// PurgeBuckets deletes the buckets before the given time.
+func PurgeBuckets(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?`, TableNameCoolingTrendBuckets, ColumnUnixSeconds), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
+func InsertAdvisory(ctx context.Context, db *sql.DB, a Advisory) error {
+	log.Logger.Debugw("inserting cooling degradation advisory", "gpuUUID", a.GPUUUID, "slopeCelsiusPerWeek", a.SlopeCelsiusPerWeek)
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?);
+`,
+		TableNameCoolingTrendAdvisories,
+		ColumnUnixSeconds, ColumnGPUUUID, ColumnSlopeCelsiusPerWeek, ColumnSpanDays,
+	), a.UnixSeconds, a.GPUUUID, a.SlopeCelsiusPerWeek, a.SpanDays)
+	return err
+}
+
+// ReadAdvisories returns the advisories since the given time, in the ascending order of the time.
+// Returns nil if no advisory is found.
+func ReadAdvisories(ctx context.Context, db *sql.DB, since time.Time) ([]Advisory, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT %s, %s, %s, %s
+FROM %s
+WHERE %s >= ?
+ORDER BY %s ASC`,
+		ColumnUnixSeconds, ColumnGPUUUID, ColumnSlopeCelsiusPerWeek, ColumnSpanDays,
+		TableNameCoolingTrendAdvisories,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+	), since.UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var advisories []Advisory
+	for rows.Next() {
+		var a Advisory
+		if err := rows.Scan(&a.UnixSeconds, &a.GPUUUID, &a.SlopeCelsiusPerWeek, &a.SpanDays); err != nil {
+			return nil, err
+		}
+		advisories = append(advisories, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return advisories, nil
+}
+
+// Trend is the temperature-at-power trend of a GPU.
+type Trend struct {
+	GPUUUID string
+	// SlopeCelsiusPerWeek is the weekly temperature change at the same power bin.
+	SlopeCelsiusPerWeek float64
+	// Days is the number of the distinct days with the observations.
+	Days int
+	// SpanDays is the number of days between the first and the last observation.
+	SpanDays int
+}
+
+// Analyze fits the temperature trend per GPU from the daily bucket means.
+// The slope is pooled within the power bins (each bin is compared against its own mean),
+// so that the changing workload mix does not show up as a temperature trend.
+// Returns the trends sorted by the GPU UUID.
+func Analyze(buckets []Bucket) []Trend {
+	type point struct {
+		day  float64
+		mean float64
+	}
+	type gpuPoints struct {
+		bins    map[int][]point
+		days    map[int64]struct{}
+		minUnix int64
+		maxUnix int64
+	}
+
+	perGPU := make(map[string]*gpuPoints)
+	for _, b := range buckets {
+		if b.Count == 0 {
+			continue
+		}
+		g, ok := perGPU[b.GPUUUID]
+		if !ok {
+			g = &gpuPoints{bins: make(map[int][]point), days: make(map[int64]struct{}), minUnix: b.UnixSeconds, maxUnix: b.UnixSeconds}
+			perGPU[b.GPUUUID] = g
+		}
+		g.bins[b.PowerBinWatts] = append(g.bins[b.PowerBinWatts], point{day: float64(b.UnixSeconds) / 86400, mean: b.MeanCelsius()})
+		g.days[b.UnixSeconds] = struct{}{}
+		if b.UnixSeconds < g.minUnix {
+			g.minUnix = b.UnixSeconds
+		}
+		if b.UnixSeconds > g.maxUnix {
+			g.maxUnix = b.UnixSeconds
+		}
+	}
+
+	trends := make([]Trend, 0, len(perGPU))
+	for uuid, g := range perGPU {
+		var sxy, sxx float64
+		for _, pts := range g.bins {
+			if len(pts) < 2 {
+				continue
+			}
+			var mx, my float64
+			for _, p := range pts {
+				mx += p.day
+				my += p.mean
+			}
+			mx /= float64(len(pts))
+			my /= float64(len(pts))
+			for _, p := range pts {
+				sxy += (p.day - mx) * (p.mean - my)
+				sxx += (p.day - mx) * (p.day - mx)
+			}
+		}
+
+		tr := Trend{
+			GPUUUID:  uuid,
+			Days:     len(g.days),
+			SpanDays: int((g.maxUnix - g.minUnix) / 86400),
+		}
+		if sxx > 0 {
+			tr.SlopeCelsiusPerWeek = sxy / sxx * 7
+		}
+		trends = append(trends, tr)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		return trends[i].GPUUUID < trends[j].GPUUUID
+	})
+	return trends
+}
+
+// Degraded returns true if the trend is long enough and rising at or above the policy threshold.
+func (p Policy) Degraded(tr Trend) bool {
+	if tr.Days < p.MinDays {
+		return false
+	}
+	if time.Duration(tr.SpanDays)*24*time.Hour < p.MinSpan.Duration {
+		return false
+	}
+	return tr.SlopeCelsiusPerWeek >= p.SlopeThresholdCelsiusPerWeek
+}
+
+// Sample is an observed temperature of a GPU at the power draw.
+type Sample struct {
+	GPUUUID    string
+	PowerWatts float64
+	Celsius    float64
+}
+
+// Tracker accumulates the samples into the daily buckets,
+// and periodically analyzes the trends to record the cooling degradation advisories.
+type Tracker struct {
+	db     *sql.DB
+	policy Policy
+
+	mu           sync.Mutex
+	lastObserved time.Time
+	lastAnalyzed time.Time
+}
+
+func NewTracker(db *sql.DB, policy Policy) *Tracker {
+	policy.SetDefaultsIfNotSet()
+	return &Tracker{
+		db:     db,
+		policy: policy,
+	}
+}
+
+// Observe adds the samples observed at the given time, and analyzes the trends
+// if the analyze interval has elapsed. The samples at or before the last observed time
+// are ignored, so that the same poll result is not counted twice.
+// Returns the recorded advisories.
+func (t *Tracker) Observe(ctx context.Context, ts time.Time, samples ...Sample) ([]Advisory, error) {
+	if t.policy.Disabled || t.db == nil {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !ts.After(t.lastObserved) {
+		return nil, nil
+	}
+	t.lastObserved = ts
+
+	day := ts.UTC().Truncate(24 * time.Hour)
+	for _, s := range samples {
+		if s.PowerWatts < float64(t.policy.MinPowerWatts) {
+			continue
+		}
+		bin := int(s.PowerWatts) / t.policy.PowerBinWatts * t.policy.PowerBinWatts
+		if err := AddToBucket(ctx, t.db, day, s.GPUUUID, bin, s.Celsius); err != nil {
+			return nil, err
+		}
+	}
+
+	if !t.lastAnalyzed.IsZero() && ts.Sub(t.lastAnalyzed) < t.policy.AnalyzeInterval.Duration {
+		return nil, nil
+	}
+	t.lastAnalyzed = ts
+
+	return t.analyze(ctx, ts)
+}
+
+func (t *Tracker) analyze(ctx context.Context, now time.Time) ([]Advisory, error) {
+	windowStart := now.Add(-t.policy.Window.Duration)
+	if _, err := PurgeBuckets(ctx, t.db, windowStart); err != nil {
+		return nil, err
+	}
+	buckets, err := ReadBuckets(ctx, t.db, windowStart)
+	if err != nil {
+		return nil, err
+	}
+
+	recent, err := ReadAdvisories(ctx, t.db, now.Add(-advisoryInterval))
+	if err != nil {
+		return nil, err
+	}
+	advised := make(map[string]struct{}, len(recent))
+	for _, a := range recent {
+		if now.UTC().Unix()-a.UnixSeconds < int64(advisoryInterval.Seconds()) {
+			advised[a.GPUUUID] = struct{}{}
+		}
+	}
+
+	var advisories []Advisory
+	for _, tr := range Analyze(buckets) {
+		if !t.policy.Degraded(tr) {
+			continue
+		}
+		if _, ok := advised[tr.GPUUUID]; ok {
+			continue
+		}
+		a := Advisory{
+			UnixSeconds:         now.UTC().Unix(),
+			GPUUUID:             tr.GPUUUID,
+			SlopeCelsiusPerWeek: tr.SlopeCelsiusPerWeek,
+			SpanDays:            tr.SpanDays,
+		}
+		if err := InsertAdvisory(ctx, t.db, a); err != nil {
+			return advisories, err
+		}
+		advisories = append(advisories, a)
+	}
+	return advisories, nil
+}
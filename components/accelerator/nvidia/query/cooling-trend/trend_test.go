@@ -0,0 +1,135 @@
+package coolingtrend
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var buckets []Bucket
+	for d := 0; d < 21; d++ {
+		day := base.Add(time.Duration(d) * 24 * time.Hour).Unix()
+		// GPU-0 warms up 1 °C/week at both power bins,
+		// GPU-1 is flat but shifts to the higher power bin later
+		buckets = append(buckets,
+			Bucket{UnixSeconds: day, GPUUUID: "GPU-0", PowerBinWatts: 300, SumCelsius: 60 + float64(d)/7, Count: 1},
+			Bucket{UnixSeconds: day, GPUUUID: "GPU-0", PowerBinWatts: 600, SumCelsius: 2 * (75 + float64(d)/7), Count: 2},
+		)
+		bin := 300
+		celsius := 60.0
+		if d >= 10 {
+			bin, celsius = 600, 75
+		}
+		buckets = append(buckets, Bucket{UnixSeconds: day, GPUUUID: "GPU-1", PowerBinWatts: bin, SumCelsius: celsius, Count: 1})
+	}
+
+	trends := Analyze(buckets)
+	if len(trends) != 2 {
+		t.Fatalf("expected 2 trends, got %+v", trends)
+	}
+	if trends[0].GPUUUID != "GPU-0" || math.Abs(trends[0].SlopeCelsiusPerWeek-1) > 1e-6 {
+		t.Errorf("unexpected GPU-0 trend %+v", trends[0])
+	}
+	if trends[0].Days != 21 || trends[0].SpanDays != 20 {
+		t.Errorf("unexpected GPU-0 days %+v", trends[0])
+	}
+	if trends[1].GPUUUID != "GPU-1" || math.Abs(trends[1].SlopeCelsiusPerWeek) > 1e-6 {
+		t.Errorf("unexpected GPU-1 trend %+v", trends[1])
+	}
+
+	p := Policy{}
+	p.SetDefaultsIfNotSet()
+	if !p.Degraded(trends[0]) {
+		t.Errorf("expected GPU-0 degraded")
+	}
+	if p.Degraded(trends[1]) {
+		t.Errorf("expected GPU-1 not degraded")
+	}
+	if p.Degraded(Trend{SlopeCelsiusPerWeek: 5, Days: 3, SpanDays: 3}) {
+		t.Errorf("expected the short trend not degraded")
+	}
+}
+
+func TestTrackerObserve(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTables(ctx, db); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	tr := NewTracker(db, Policy{})
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var advisories []Advisory
+	for d := 0; d < 28; d++ {
+		ts := base.Add(time.Duration(d) * 24 * time.Hour)
+		for _, offset := range []time.Duration{0, time.Hour} {
+			advs, err := tr.Observe(ctx, ts.Add(offset),
+				Sample{GPUUUID: "GPU-0", PowerWatts: 420, Celsius: 65 + float64(d)*0.2},
+				Sample{GPUUUID: "GPU-1", PowerWatts: 420, Celsius: 65},
+				// idle samples are ignored
+				Sample{GPUUUID: "GPU-0", PowerWatts: 60, Celsius: 30},
+			)
+			if err != nil {
+				t.Fatalf("day %d: Observe failed: %v", d, err)
+			}
+			advisories = append(advisories, advs...)
+		}
+
+		// the same poll result is not counted twice
+		if _, err := tr.Observe(ctx, ts.Add(time.Hour), Sample{GPUUUID: "GPU-0", PowerWatts: 420, Celsius: 100}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buckets, err := ReadBuckets(ctx, db, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range buckets {
+		if b.PowerBinWatts != 400 || b.Count != 2 {
+			t.Fatalf("unexpected bucket %+v", b)
+		}
+	}
+
+	// first advised once the min span is reached, then at most once a week
+	if len(advisories) != 2 {
+		t.Fatalf("expected 2 advisories, got %+v", advisories)
+	}
+	for _, a := range advisories {
+		if a.GPUUUID != "GPU-0" || math.Abs(a.SlopeCelsiusPerWeek-1.4) > 1e-6 {
+			t.Errorf("unexpected advisory %+v", a)
+		}
+	}
+	if got := advisories[1].UnixSeconds - advisories[0].UnixSeconds; got != int64((7 * 24 * time.Hour).Seconds()) {
+		t.Errorf("expected advisories a week apart, got %d seconds", got)
+	}
+
+	read, err := ReadAdvisories(ctx, db, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 2 {
+		t.Fatalf("expected 2 stored advisories, got %d", len(read))
+	}
+	ev := read[0].ToComponentEvent()
+	if ev.Name != EventNameCoolingDegradation || ev.ExtraInfo[EventKeyGPUUUID] != "GPU-0" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
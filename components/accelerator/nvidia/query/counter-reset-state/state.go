@@ -0,0 +1,178 @@
+// Package counterresetstate provides the persistent audit storage layer for the GPU error counter resets.
+package counterresetstate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const TableNameCounterResetHistory = "components_accelerator_nvidia_query_counter_reset_history"
+
+const (
+	// unix timestamp in seconds when the counters were reset
+	ColumnUnixSeconds = "unix_seconds"
+
+	// who reset the counters (e.g., "root (sudo by alice)")
+	ColumnRequestedBy = "requested_by"
+
+	// reason of the reset (e.g., repair ticket)
+	ColumnReason = "reason"
+
+	// either "ecc" or "nvlink"
+	ColumnCounterType = "counter_type"
+
+	// GPU UUID
+	ColumnGPUUUID = "gpu_uuid"
+
+	// pre-reset counter values in JSON
+	ColumnSnapshot = "snapshot"
+)
+
+const EventNameCounterReset = "counter_reset"
+
+type Event struct {
+	UnixSeconds int64
+	RequestedBy string
+	Reason      string
+	CounterType string
+	GPUUUID     string
+	Snapshot    string
+}
+
+// ToComponentEvent converts the reset record to the component event.
+func (e Event) ToComponentEvent() components.Event {
+	return components.Event{
+		Time:    metav1.Time{Time: time.Unix(e.UnixSeconds, 0).UTC()},
+		Name:    EventNameCounterReset,
+		Type:    components.EventTypeInfo,
+		Message: fmt.Sprintf("%s counters reset on %s by %s (reason %q)", e.CounterType, e.GPUUUID, e.RequestedBy, e.Reason),
+		ExtraInfo: map[string]string{
+			ColumnCounterType: e.CounterType,
+			ColumnGPUUUID:     e.GPUUUID,
+			ColumnRequestedBy: e.RequestedBy,
+			ColumnReason:      e.Reason,
+			ColumnSnapshot:    e.Snapshot,
+		},
+	}
+}
+
+func CreateTableCounterResetHistory(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT
+);`, TableNameCounterResetHistory,
+		ColumnUnixSeconds,
+		ColumnRequestedBy,
+		ColumnReason,
+		ColumnCounterType,
+		ColumnGPUUUID,
+		ColumnSnapshot,
+	))
+	return err
+}
+
+func InsertEvent(ctx context.Context, db *sql.DB, event Event) error {
+	log.Logger.Debugw("inserting counter reset event", "requestedBy", event.RequestedBy, "counterType", event.CounterType, "gpuUUID", event.GPUUUID)
+
+	insertStatement := fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s, %s, %s) VALUES (?, ?, NULLIF(?, ''), ?, ?, NULLIF(?, ''));
+`,
+		TableNameCounterResetHistory,
+		ColumnUnixSeconds,
+		ColumnRequestedBy,
+		ColumnReason,
+		ColumnCounterType,
+		ColumnGPUUUID,
+		ColumnSnapshot,
+	)
+	_, err := db.ExecContext(
+		ctx,
+		insertStatement,
+		event.UnixSeconds,
+		event.RequestedBy,
+		event.Reason,
+		event.CounterType,
+		event.GPUUUID,
+		event.Snapshot,
+	)
+	return err
+}
+
+// ReadEvents returns the reset events since the given time (if non-zero), optionally filtered
+// by the counter type (if non-empty), in the ascending order of the reset time.
+// Returns nil if no event is found.
+func ReadEvents(ctx context.Context, db *sql.DB, since time.Time, counterType string) ([]Event, error) {
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, COALESCE(%s, ''), %s, %s, COALESCE(%s, '')
+FROM %s
+WHERE %s >= ?`,
+		ColumnUnixSeconds,
+		ColumnRequestedBy,
+		ColumnReason,
+		ColumnCounterType,
+		ColumnGPUUUID,
+		ColumnSnapshot,
+		TableNameCounterResetHistory,
+		ColumnUnixSeconds,
+	)
+	args := []any{since.UTC().Unix()}
+	if since.IsZero() {
+		args[0] = 0
+	}
+	if counterType != "" {
+		selectStatement += fmt.Sprintf(" AND %s = ?", ColumnCounterType)
+		args = append(args, counterType)
+	}
+	selectStatement += "\nORDER BY " + ColumnUnixSeconds + " ASC"
+
+	rows, err := db.QueryContext(ctx, selectStatement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		if err := rows.Scan(
+			&event.UnixSeconds,
+			&event.RequestedBy,
+			&event.Reason,
+			&event.CounterType,
+			&event.GPUUUID,
+			&event.Snapshot,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Purge deletes the reset events before the given time.
+func Purge(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?`, TableNameCounterResetHistory, ColumnUnixSeconds), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
@@ -0,0 +1,69 @@
+package counterresetstate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestInsertAndReadEvents(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableCounterResetHistory(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	now := time.Now().UTC()
+	events := []Event{
+		{UnixSeconds: now.Add(-2 * time.Hour).Unix(), RequestedBy: "root", CounterType: "ecc", GPUUUID: "GPU-0", Snapshot: `{"volatile":{}}`},
+		{UnixSeconds: now.Add(-time.Hour).Unix(), RequestedBy: "root", Reason: "post-repair", CounterType: "nvlink", GPUUUID: "GPU-1"},
+	}
+	for _, ev := range events {
+		if err := InsertEvent(ctx, db, ev); err != nil {
+			t.Fatalf("InsertEvent failed: %v", err)
+		}
+	}
+
+	read, err := ReadEvents(ctx, db, time.Time{}, "")
+	if err != nil {
+		t.Fatalf("ReadEvents failed: %v", err)
+	}
+	if len(read) != 2 || read[0].GPUUUID != "GPU-0" || read[1].Reason != "post-repair" {
+		t.Fatalf("unexpected events %+v", read)
+	}
+
+	read, err = ReadEvents(ctx, db, now.Add(-90*time.Minute), "")
+	if err != nil {
+		t.Fatalf("ReadEvents failed: %v", err)
+	}
+	if len(read) != 1 || read[0].CounterType != "nvlink" {
+		t.Fatalf("unexpected events %+v", read)
+	}
+
+	read, err = ReadEvents(ctx, db, time.Time{}, "ecc")
+	if err != nil {
+		t.Fatalf("ReadEvents failed: %v", err)
+	}
+	if len(read) != 1 || read[0].Snapshot == "" {
+		t.Fatalf("unexpected events %+v", read)
+	}
+
+	purged, err := Purge(ctx, db, now.Add(-90*time.Minute))
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged, got %d", purged)
+	}
+}
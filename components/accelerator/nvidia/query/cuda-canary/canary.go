@@ -0,0 +1,117 @@
+// Package cudacanary launches a tiny CUDA workload on a GPU (e.g., the CUDA samples "vectorAdd"),
+// to catch the GPUs that look fine in NVML but fail to run the kernels.
+package cudacanary
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ToolVectorAdd runs the bundled CUDA samples "vectorAdd" binary
+	// (ref. https://github.com/NVIDIA/cuda-samples/tree/master/Samples/0_Introduction/vectorAdd).
+	ToolVectorAdd = "vector-add"
+	// ToolDCGMProfTester runs the DCGM "dcgmproftester" for a second.
+	ToolDCGMProfTester = "dcgmproftester"
+)
+
+const (
+	DefaultVectorAddPath      = "/usr/local/gpud/bin/vectorAdd"
+	DefaultDCGMProfTesterPath = "dcgmproftester12"
+
+	// maxOutputBytes is the maximum number of the output bytes to keep in the result.
+	maxOutputBytes = 4096
+)
+
+// DefaultPath returns the default binary path of the tool.
+func DefaultPath(tool string) string {
+	switch tool {
+	case ToolDCGMProfTester:
+		return DefaultDCGMProfTesterPath
+	default:
+		return DefaultVectorAddPath
+	}
+}
+
+// Command returns the canary command of the tool for the GPU,
+// with the extra environment variables.
+func Command(tool string, path string, uuid string, index int) ([]string, []string, error) {
+	if path == "" {
+		path = DefaultPath(tool)
+	}
+	switch tool {
+	case ToolVectorAdd:
+		return []string{path}, []string{"CUDA_VISIBLE_DEVICES=" + uuid}, nil
+	case ToolDCGMProfTester:
+		// 1002 is the SM active test, "-d" is the duration in seconds
+		return []string{path, "--no-dcgm-validation", "-t", "1002", "-d", "1", "-i", strconv.Itoa(index)}, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown cuda canary tool %q (expected %q or %q)", tool, ToolVectorAdd, ToolDCGMProfTester)
+	}
+}
+
+// IsPassed returns true if the output of the succeeded command reports the workload passed.
+// The tools without the pass line only rely on the exit code.
+func IsPassed(tool string, output string) bool {
+	switch tool {
+	case ToolVectorAdd:
+		// e.g., "Test PASSED"
+		return strings.Contains(output, "Test PASSED")
+	default:
+		return true
+	}
+}
+
+// Result is the canary result of a GPU.
+type Result struct {
+	UUID    string `json:"uuid"`
+	Tool    string `json:"tool"`
+	Success bool   `json:"success"`
+	// Latency is the time to launch and complete the workload,
+	// including the CUDA context creation.
+	Latency time.Duration `json:"latency"`
+	// Output is the last bytes of the command output.
+	Output string `json:"output,omitempty"`
+	// Error is set if the workload failed or could not run.
+	Error string `json:"error,omitempty"`
+}
+
+// Run runs the canary workload on the GPU until it completes or the context is canceled.
+func Run(ctx context.Context, tool string, path string, uuid string, index int) Result {
+	r := Result{UUID: uuid, Tool: tool}
+
+	args, envs, err := Command(tool, path, uuid, index)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), envs...)
+
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	r.Latency = time.Since(start)
+
+	out = bytes.TrimSpace(out)
+	if len(out) > maxOutputBytes {
+		out = out[len(out)-maxOutputBytes:]
+	}
+	r.Output = string(out)
+
+	switch {
+	case err != nil:
+		r.Error = fmt.Sprintf("%q failed: %v", strings.Join(args, " "), err)
+	case !IsPassed(tool, r.Output):
+		r.Error = fmt.Sprintf("%q did not report the workload passed", strings.Join(args, " "))
+	default:
+		r.Success = true
+	}
+	return r
+}
@@ -0,0 +1,64 @@
+package cudacanary
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	args, envs, err := Command(ToolVectorAdd, "", "GPU-0", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args, []string{DefaultVectorAddPath}) || !reflect.DeepEqual(envs, []string{"CUDA_VISIBLE_DEVICES=GPU-0"}) {
+		t.Errorf("unexpected command %v %v", args, envs)
+	}
+
+	args, _, err = Command(ToolDCGMProfTester, "/usr/bin/dcgmproftester11", "GPU-1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[0] != "/usr/bin/dcgmproftester11" || args[len(args)-1] != "1" {
+		t.Errorf("unexpected command %v", args)
+	}
+
+	if _, _, err := Command("unknown", "", "GPU-0", 0); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+}
+
+func TestIsPassed(t *testing.T) {
+	out := `[Vector addition of 50000 elements]
+Copy input data from the host memory to the CUDA device
+CUDA kernel launch with 196 blocks of 256 threads
+Copy output data from the CUDA device to the host memory
+Test PASSED
+Done`
+	if !IsPassed(ToolVectorAdd, out) {
+		t.Error("expected passed")
+	}
+	if IsPassed(ToolVectorAdd, "Failed to launch vectorAdd kernel (error code no CUDA-capable device is detected)!") {
+		t.Error("expected not passed")
+	}
+	if !IsPassed(ToolDCGMProfTester, "") {
+		t.Error("expected passed by the exit code")
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := Run(context.Background(), ToolVectorAdd, "true", "GPU-0", 0)
+	if r.Success || r.Error == "" {
+		t.Errorf("expected failure without the pass line, got %+v", r)
+	}
+
+	r = Run(context.Background(), ToolDCGMProfTester, "true", "GPU-0", 0)
+	if !r.Success || r.Latency <= 0 {
+		t.Errorf("expected success, got %+v", r)
+	}
+
+	r = Run(context.Background(), ToolDCGMProfTester, "/non-existent-binary", "GPU-0", 0)
+	if r.Success || r.Error == "" {
+		t.Errorf("expected failure, got %+v", r)
+	}
+}
@@ -0,0 +1,258 @@
+// Package dcgmdiag runs the DCGM diagnostics ("dcgmi diag -r <level>") and parses the per-test results,
+// for the deeper hardware validation than the passive kernel message watching.
+package dcgmdiag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	nvidia_query_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+)
+
+// TestName is the test name recorded in the GPU ledger.
+const TestName = "dcgm-diag"
+
+const (
+	// LevelQuick runs the quick deployment checks (seconds).
+	LevelQuick = 1
+	// LevelMedium adds the short hardware tests, e.g., PCIe and memory (about 2 minutes).
+	LevelMedium = 2
+	// LevelLong adds the extended stress tests (about 15 minutes or longer).
+	LevelLong = 3
+)
+
+const (
+	StatusPass = "Pass"
+	StatusFail = "Fail"
+	StatusWarn = "Warn"
+	StatusSkip = "Skip"
+)
+
+// maxOutputBytes is the maximum size of the diagnostics output to keep in the result.
+const maxOutputBytes = 4096
+
+// Command returns the diagnostics command of the level.
+func Command(level int) ([]string, error) {
+	if level < LevelQuick || level > LevelLong {
+		return nil, fmt.Errorf("invalid dcgm diagnostics level %d (expected %d, %d, or %d)", level, LevelQuick, LevelMedium, LevelLong)
+	}
+	return []string{"dcgmi", "diag", "-r", strconv.Itoa(level)}, nil
+}
+
+// TestResult is the result of a diagnostics test (e.g., "PCIe", "Memory").
+type TestResult struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	// FailedGPUs is the indexes of the failed GPUs, empty if the test failed on all the GPUs.
+	FailedGPUs []int `json:"failed_gpus,omitempty"`
+	// Details is the warning and info messages of the test.
+	Details []string `json:"details,omitempty"`
+}
+
+// FailedOn returns true if the test failed on the GPU with the given index.
+func (t TestResult) FailedOn(index int) bool {
+	if t.Status != StatusFail {
+		return false
+	}
+	if len(t.FailedGPUs) == 0 {
+		return true
+	}
+	for _, i := range t.FailedGPUs {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// Result is the diagnostics result of all the GPUs.
+type Result struct {
+	Level    int           `json:"level"`
+	Tests    []TestResult  `json:"tests"`
+	Duration time.Duration `json:"duration"`
+	// Output is the tail of the diagnostics output.
+	Output string `json:"output"`
+	// Error is set if the diagnostics could not run.
+	Error string `json:"error,omitempty"`
+}
+
+// FailedTests returns the names of the tests failed on the GPU with the given index.
+func (r Result) FailedTests(index int) []string {
+	var names []string
+	for _, t := range r.Tests {
+		if t.FailedOn(index) {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// Run runs the diagnostics of the level on all the GPUs until it completes or the context is canceled.
+func Run(ctx context.Context, level int) Result {
+	r := Result{Level: level}
+
+	args, err := Command(level)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	start := time.Now()
+	b, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	r.Duration = time.Since(start)
+
+	out := strings.TrimSpace(string(b))
+	r.Tests = Parse(out)
+	if len(out) > maxOutputBytes {
+		out = out[len(out)-maxOutputBytes:]
+	}
+	r.Output = out
+
+	// "dcgmi diag" exits non-zero when a test fails,
+	// so only treat it as an error when no result is found
+	if err != nil && len(r.Tests) == 0 {
+		r.Error = fmt.Sprintf("%q failed: %v", strings.Join(args, " "), err)
+	}
+	return r
+}
+
+// Parse parses the "dcgmi diag" result table, e.g.,
+//
+//	|-----  Deployment  --------+------------------------------------------------|
+//	| Denylist                  | Pass                                           |
+//	+-----  Hardware  ----------+------------------------------------------------+
+//	| GPU Memory                | Fail - GPU: 0, 2                               |
+//	| Warning                   | GPU 0 Thermal violations totaling 13.3 ...     |
+//
+// The metadata rows (e.g., "DCGM Version") are skipped.
+func Parse(out string) []TestResult {
+	var (
+		tests    []TestResult
+		category string
+	)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+
+		// category separator, e.g., "|-----  Deployment  --------+-----|"
+		if strings.HasPrefix(line, "|-----") || strings.HasPrefix(line, "+-----") {
+			head, _, _ := strings.Cut(line[1:], "+")
+			head, _, _ = strings.Cut(head, "|")
+			category = strings.TrimSpace(strings.Trim(head, "- "))
+			continue
+		}
+		if !strings.HasPrefix(line, "|") || category == "" || category == "Metadata" {
+			continue
+		}
+
+		fields := strings.Split(strings.Trim(line, "|"), "|")
+		if len(fields) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		if value == "" {
+			continue
+		}
+
+		switch name {
+		case "Warning", "Info":
+			if len(tests) > 0 {
+				tests[len(tests)-1].Details = append(tests[len(tests)-1].Details, value)
+			}
+			continue
+		case "":
+			// continuation of the previous test (e.g., "| | Fail - GPU: 2 |")
+			if len(tests) > 0 {
+				mergeStatus(&tests[len(tests)-1], value)
+			}
+			continue
+		}
+
+		t := TestResult{Category: category, Name: name}
+		mergeStatus(&t, value)
+		tests = append(tests, t)
+	}
+	return tests
+}
+
+// mergeStatus merges the result value (e.g., "Pass - All", "Fail - GPU: 0, 2") into the test result,
+// where the failure takes the precedence.
+func mergeStatus(t *TestResult, value string) {
+	status, gpus, _ := strings.Cut(value, " - ")
+	status = strings.TrimSpace(status)
+	switch status {
+	case StatusPass, StatusFail, StatusWarn, StatusSkip:
+	default:
+		// not a status (e.g., wrapped message)
+		t.Details = append(t.Details, value)
+		return
+	}
+
+	if status != StatusFail {
+		if t.Status == "" {
+			t.Status = status
+		}
+		return
+	}
+
+	wasFailed := t.Status == StatusFail
+	allFailed := wasFailed && len(t.FailedGPUs) == 0
+	t.Status = StatusFail
+	if allFailed {
+		return
+	}
+
+	_, list, ok := strings.Cut(gpus, "GPU:")
+	if !ok {
+		// e.g., "Fail - All"
+		t.FailedGPUs = nil
+		return
+	}
+	for _, s := range strings.Split(list, ",") {
+		if i, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+			t.FailedGPUs = append(t.FailedGPUs, i)
+		}
+	}
+	sort.Ints(t.FailedGPUs)
+}
+
+// RecordVerdicts records the per-GPU verdicts of the diagnostics result in the GPU ledger,
+// given the NVML device index by the GPU UUID.
+func RecordVerdicts(ctx context.Context, db *sql.DB, r Result, indexes map[string]int, start time.Time, requestedBy string, reason string) error {
+	uuids := make([]string, 0, len(indexes))
+	for uuid := range indexes {
+		uuids = append(uuids, uuid)
+	}
+	sort.Slice(uuids, func(i, j int) bool { return indexes[uuids[i]] < indexes[uuids[j]] })
+
+	for _, uuid := range uuids {
+		verdict := nvidia_query_gpu_ledger.VerdictPass
+		details := fmt.Sprintf("level=%d", r.Level)
+		if r.Error != "" {
+			verdict = nvidia_query_gpu_ledger.VerdictFail
+			details = fmt.Sprintf("level=%d error=%s\n%s", r.Level, r.Error, r.Output)
+		} else if failed := r.FailedTests(indexes[uuid]); len(failed) > 0 {
+			verdict = nvidia_query_gpu_ledger.VerdictFail
+			details = fmt.Sprintf("level=%d failed=%s", r.Level, strings.Join(failed, ","))
+		}
+		if err := nvidia_query_gpu_ledger.InsertEntry(ctx, db, nvidia_query_gpu_ledger.Entry{
+			UnixSeconds:     start.Unix(),
+			GPUUUID:         uuid,
+			Test:            TestName,
+			Verdict:         verdict,
+			RequestedBy:     requestedBy,
+			Reason:          reason,
+			DurationSeconds: r.Duration.Seconds(),
+			Details:         details,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package dcgmdiag
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	nvidia_query_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+const testOutput = `Successfully ran diagnostic for group.
++---------------------------+------------------------------------------------+
+| Diagnostic                | Result                                         |
++===========================+================================================+
+|-----  Metadata  ----------+------------------------------------------------|
+| DCGM Version              | 3.3.5                                          |
+| Driver Version Detected   | 535.129.03                                     |
+| GPU Device IDs Detected   | 2330,2330,2330                                 |
+|-----  Deployment  --------+------------------------------------------------|
+| Denylist                  | Pass                                           |
+| NVML Library              | Pass                                           |
+| Persistence Mode          | Pass                                           |
+| Environment Variables     | Pass                                           |
+| Page Retirement/Row Remap | Fail - GPU: 1                                  |
+| Warning                   | GPU 1 had uncorrectable memory errors and row  |
+|                           | remapping failed.                              |
++-----  Integration  -------+------------------------------------------------+
+| PCIe                      | Pass - GPU: 0, 1                               |
+|                           | Fail - GPU: 2                                  |
+| Warning                   | GPU 2 Error using CUDA API cudaDeviceGetByPCIB |
++-----  Hardware  ----------+------------------------------------------------+
+| GPU Memory                | Pass - All                                     |
+| Diagnostic                | Skip - All                                     |
++---------------------------+------------------------------------------------+
+`
+
+func TestParse(t *testing.T) {
+	tests := Parse(testOutput)
+
+	names := make([]string, 0, len(tests))
+	for _, tt := range tests {
+		names = append(names, tt.Name)
+	}
+	want := []string{"Denylist", "NVML Library", "Persistence Mode", "Environment Variables", "Page Retirement/Row Remap", "PCIe", "GPU Memory", "Diagnostic"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected tests %v, got %v", want, names)
+	}
+
+	remap := tests[4]
+	if remap.Category != "Deployment" || remap.Status != StatusFail || !reflect.DeepEqual(remap.FailedGPUs, []int{1}) {
+		t.Errorf("unexpected result %+v", remap)
+	}
+	if len(remap.Details) != 2 {
+		t.Errorf("expected 2 detail lines, got %v", remap.Details)
+	}
+
+	pcie := tests[5]
+	if pcie.Category != "Integration" || pcie.Status != StatusFail || !reflect.DeepEqual(pcie.FailedGPUs, []int{2}) {
+		t.Errorf("unexpected result %+v", pcie)
+	}
+	if tests[6].Status != StatusPass || tests[7].Status != StatusSkip {
+		t.Errorf("unexpected results %+v, %+v", tests[6], tests[7])
+	}
+
+	r := Result{Level: LevelMedium, Tests: tests}
+	for index, want := range map[int][]string{
+		0: nil,
+		1: {"Page Retirement/Row Remap"},
+		2: {"PCIe"},
+	} {
+		if got := r.FailedTests(index); !reflect.DeepEqual(got, want) {
+			t.Errorf("gpu %d: expected failed tests %v, got %v", index, want, got)
+		}
+	}
+}
+
+func TestMergeStatusFailAll(t *testing.T) {
+	tr := TestResult{Name: "Memory"}
+	mergeStatus(&tr, "Fail - GPU: 0")
+	mergeStatus(&tr, "Fail - All")
+	mergeStatus(&tr, "Fail - GPU: 3")
+	if tr.Status != StatusFail || len(tr.FailedGPUs) != 0 {
+		t.Fatalf("expected failure on all gpus, got %+v", tr)
+	}
+	if !tr.FailedOn(5) {
+		t.Error("expected failure on any gpu")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if _, err := Command(0); err == nil {
+		t.Error("expected error for level 0")
+	}
+	args, err := Command(LevelMedium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args, []string{"dcgmi", "diag", "-r", "2"}) {
+		t.Errorf("unexpected command %v", args)
+	}
+}
+
+func TestRecordVerdicts(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := nvidia_query_gpu_ledger.CreateTableGPULedger(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	r := Result{Level: LevelMedium, Tests: Parse(testOutput), Duration: time.Minute}
+	indexes := map[string]int{"GPU-0": 0, "GPU-1": 1, "GPU-2": 2}
+	if err := RecordVerdicts(ctx, db, r, indexes, time.Now(), "root", "scheduled"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := nvidia_query_gpu_ledger.ReadEntries(ctx, db, time.Time{}, "", TestName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verdicts := make(map[string]string)
+	for _, e := range entries {
+		verdicts[e.GPUUUID] = e.Verdict
+	}
+	want := map[string]string{
+		"GPU-0": nvidia_query_gpu_ledger.VerdictPass,
+		"GPU-1": nvidia_query_gpu_ledger.VerdictFail,
+		"GPU-2": nvidia_query_gpu_ledger.VerdictFail,
+	}
+	if !reflect.DeepEqual(verdicts, want) {
+		t.Errorf("expected verdicts %v, got %v", want, verdicts)
+	}
+}
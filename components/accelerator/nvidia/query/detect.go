@@ -1,14 +1,14 @@
 package query
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"strings"
+	"os"
+	"sync"
 
 	"github.com/leptonai/gpud/log"
-	"github.com/leptonai/gpud/pkg/file"
-	"github.com/leptonai/gpud/pkg/process"
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+	"github.com/leptonai/gpud/pkg/pci"
 
 	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
 	nvinfo "github.com/NVIDIA/go-nvlib/pkg/nvlib/info"
@@ -47,9 +47,9 @@ func GPUsInstalled(ctx context.Context) (bool, error) {
 
 // Loads the product name of the NVIDIA GPU device.
 func LoadGPUDeviceName(ctx context.Context) (string, error) {
-	nvmlLib := nvml.New()
-	if ret := nvmlLib.Init(); ret != nvml.SUCCESS {
-		return "", fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return "", err
 	}
 
 	deviceLib := device.New(nvmlLib)
@@ -81,65 +81,48 @@ func LoadGPUDeviceName(ctx context.Context) (string, error) {
 	return "", nil
 }
 
-// Lists all PCI devices that are compatible with NVIDIA.
-func ListNVIDIAPCIs(ctx context.Context) ([]string, error) {
-	lspciPath, err := file.LocateExecutable("lspci")
-	if err != nil {
-		return nil, nil
-	}
-	if lspciPath == "" {
-		return nil, nil
-	}
-
-	p, err := process.New(
-		process.WithCommand(lspciPath),
-		process.WithRunAsBashScript(),
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := p.Start(ctx); err != nil {
-		return nil, err
-	}
-
-	lines := make([]string, 0)
-
-	scanner := bufio.NewScanner(p.StdoutReader())
-	for scanner.Scan() { // returns false at the end of the output
-		line := scanner.Text()
+var (
+	pciCache          = pci.NewCache(pci.DefaultDevicesDir)
+	pciCacheWatchOnce sync.Once
+)
 
-		// e.g.,
-		// 01:00.0 VGA compatible controller: NVIDIA Corporation Device 2684 (rev a1)
-		// 01:00.1 Audio device: NVIDIA Corporation Device 22ba (rev a1)
-		if strings.Contains(line, "NVIDIA") {
-			lines = append(lines, line)
+// Lists all PCI devices that are compatible with NVIDIA (e.g., GPUs, NVSwitches, HDMI audio),
+// from the sysfs. The devices are cached and only rescanned after a PCI device
+// is hot-added or removed, or on every call if the hotplug events are not available.
+func ListNVIDIAPCIs(ctx context.Context) ([]pci.Device, error) {
+	pciCacheWatchOnce.Do(func() {
+		// watch for the lifetime of the process
+		if err := pciCache.Watch(context.Background()); err != nil {
+			log.Logger.Debugw("pci hotplug events not available, rescanning pci devices on every list", "error", err)
 		}
+	})
 
-		select {
-		case err := <-p.Wait():
-			if err != nil {
-				return nil, err
-			}
-		default:
+	devs, err := pciCache.List()
+	if err != nil {
+		// e.g., no sysfs on the non-linux hosts
+		if os.IsNotExist(err) {
+			return nil, nil
 		}
+		return nil, err
 	}
-	if serr := scanner.Err(); serr != nil {
-		// process already dead, thus ignore
-		// e.g., "read |0: file already closed"
-		if !strings.Contains(serr.Error(), "file already closed") {
-			return nil, serr
+
+	nvidiaDevs := make([]pci.Device, 0)
+	for _, dev := range devs {
+		if dev.Vendor == pci.VendorNVIDIA {
+			nvidiaDevs = append(nvidiaDevs, dev)
 		}
 	}
+	return nvidiaDevs, nil
+}
 
-	select {
-	case err := <-p.Wait():
-		if err != nil {
-			return nil, err
+// CountNVIDIAGPUPCIs counts the GPU functions in the NVIDIA PCI devices,
+// excluding the other functions (e.g., the HDMI audio, the NVSwitch bridges).
+func CountNVIDIAGPUPCIs(devices []pci.Device) int {
+	count := 0
+	for _, dev := range devices {
+		if dev.IsNVIDIAGPU() {
+			count++
 		}
-	case <-ctx.Done():
-		return nil, ctx.Err()
 	}
-
-	return lines, nil
+	return count
 }
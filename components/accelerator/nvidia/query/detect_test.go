@@ -0,0 +1,22 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/leptonai/gpud/pkg/pci"
+)
+
+func TestCountNVIDIAGPUPCIs(t *testing.T) {
+	devices := []pci.Device{
+		{BDF: "0000:01:00.0", Vendor: pci.VendorNVIDIA, Device: 0x2684, Class: 0x030000}, // VGA compatible controller
+		{BDF: "0000:01:00.1", Vendor: pci.VendorNVIDIA, Device: 0x22ba, Class: 0x040300}, // Audio device
+		{BDF: "0000:18:00.0", Vendor: pci.VendorNVIDIA, Device: 0x2330, Class: 0x030200}, // 3D controller
+		{BDF: "0000:07:00.0", Vendor: pci.VendorNVIDIA, Device: 0x22a3, Class: 0x068000}, // Bridge (NVSwitch)
+	}
+	if got := CountNVIDIAGPUPCIs(devices); got != 2 {
+		t.Errorf("expected 2, got %d", got)
+	}
+	if got := CountNVIDIAGPUPCIs(nil); got != 0 {
+		t.Errorf("expected 0, got %d", got)
+	}
+}
@@ -0,0 +1,90 @@
+// Package driverbaseline persists the NVIDIA driver last seen working on the node,
+// to tell the driver never installed from the driver crashed or unloaded after previously working.
+package driverbaseline
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const TableNameDriverBaseline = "components_accelerator_nvidia_query_driver_baseline"
+
+const (
+	// always 1, the node has a single baseline
+	ColumnID = "id"
+
+	// driver version last seen working (e.g., "535.161.08")
+	ColumnDriverVersion = "driver_version"
+
+	// number of the GPUs last seen by the driver
+	ColumnGPUCount = "gpu_count"
+
+	// unix timestamp in seconds when the driver was last seen working
+	ColumnUnixSeconds = "unix_seconds"
+)
+
+// Baseline is the NVIDIA driver last seen working on the node.
+type Baseline struct {
+	DriverVersion string    `json:"driver_version"`
+	GPUCount      int       `json:"gpu_count"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+func CreateTableBaseline(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL PRIMARY KEY,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL
+);`, TableNameDriverBaseline,
+		ColumnID,
+		ColumnDriverVersion,
+		ColumnGPUCount,
+		ColumnUnixSeconds,
+	))
+	return err
+}
+
+// SetBaseline inserts or replaces the baseline.
+func SetBaseline(ctx context.Context, db *sql.DB, b Baseline) error {
+	if b.LastSeen.IsZero() {
+		b.LastSeen = time.Now()
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`INSERT OR REPLACE INTO %s (%s, %s, %s, %s) VALUES (1, ?, ?, ?);`,
+		TableNameDriverBaseline,
+		ColumnID,
+		ColumnDriverVersion,
+		ColumnGPUCount,
+		ColumnUnixSeconds,
+	), b.DriverVersion, b.GPUCount, b.LastSeen.UTC().Unix())
+	return err
+}
+
+// ReadBaseline returns the baseline, or nil if the driver was never seen working on the node.
+func ReadBaseline(ctx context.Context, db *sql.DB) (*Baseline, error) {
+	var (
+		b        Baseline
+		unixSecs int64
+	)
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT %s, %s, %s FROM %s WHERE %s = 1;`,
+		ColumnDriverVersion,
+		ColumnGPUCount,
+		ColumnUnixSeconds,
+		TableNameDriverBaseline,
+		ColumnID,
+	)).Scan(&b.DriverVersion, &b.GPUCount, &unixSecs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.LastSeen = time.Unix(unixSecs, 0).UTC()
+	return &b, nil
+}
@@ -0,0 +1,47 @@
+package driverbaseline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestBaseline(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableBaseline(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+	b, err := ReadBaseline(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b != nil {
+		t.Fatalf("expected no baseline, got %+v", b)
+	}
+
+	if err := SetBaseline(ctx, db, Baseline{DriverVersion: "535.161.08", GPUCount: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetBaseline(ctx, db, Baseline{DriverVersion: "550.54.15", GPUCount: 8}); err != nil {
+		t.Fatal(err)
+	}
+	b, err = ReadBaseline(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b == nil || b.DriverVersion != "550.54.15" || b.GPUCount != 8 || b.LastSeen.IsZero() {
+		t.Errorf("unexpected baseline %+v", b)
+	}
+}
@@ -0,0 +1,242 @@
+// Package drivermaintenance detects the NVIDIA driver install/upgrade activity
+// (DKMS builds, package manager runs, and the NVIDIA installer) from the running processes
+// and the systemd journal, so the GPU component failures during the upgrades are not alerted.
+package drivermaintenance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProcDir is the procfs directory to scan the running processes.
+const DefaultProcDir = "/proc"
+
+// JournalIdentifiers are the syslog identifiers of the package managers and the driver build tools
+// that log to the systemd journal.
+var JournalIdentifiers = []string{
+	"apt",
+	"apt-get",
+	"dkms",
+	"dnf",
+	"dpkg",
+	"nvidia-installer",
+	"packagekitd",
+	"unattended-upgrade",
+	"yum",
+	"zypper",
+}
+
+// packageManagers are the process names that only count when their arguments touch an NVIDIA package.
+var packageManagers = map[string]struct{}{
+	"apt":     {},
+	"apt-get": {},
+	"dnf":     {},
+	"dpkg":    {},
+	"rpm":     {},
+	"yum":     {},
+	"zypper":  {},
+}
+
+// Activity is a driver install/upgrade activity.
+type Activity struct {
+	// Source is either "process" or "journal".
+	Source string `json:"source"`
+	// Time is the journal entry time, or the scan time of the running process.
+	Time time.Time `json:"time"`
+	// PID is the process ID, zero for the journal entries.
+	PID     int    `json:"pid,omitempty"`
+	Message string `json:"message"`
+}
+
+const (
+	SourceProcess = "process"
+	SourceJournal = "journal"
+)
+
+// touchesNVIDIA returns true if the arguments refer to an NVIDIA package or module
+// (e.g., "nvidia-driver-550", "cuda-drivers", "nvidia/550.90.07").
+func touchesNVIDIA(args []string) bool {
+	for _, a := range args {
+		a = strings.ToLower(a)
+		if strings.Contains(a, "nvidia") || strings.Contains(a, "cuda-drivers") {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchCommand returns true if the process command line is a driver install/upgrade activity.
+func MatchCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	name := filepath.Base(args[0])
+
+	// the NVIDIA runfile installer (e.g., "NVIDIA-Linux-x86_64-550.90.07.run")
+	// the DKMS builds the NVIDIA modules for the new kernels without naming them (e.g., "dkms autoinstall")
+	if name == "dkms" {
+		return true
+	}
+	if name == "nvidia-installer" || (strings.HasPrefix(name, "NVIDIA-Linux-") && strings.HasSuffix(name, ".run")) {
+		return true
+	}
+
+	// e.g., "sh NVIDIA-Linux-x86_64-550.90.07.run --silent"
+	if (name == "sh" || name == "bash") && len(args) > 1 {
+		script := filepath.Base(args[1])
+		if strings.HasPrefix(script, "NVIDIA-Linux-") && strings.HasSuffix(script, ".run") {
+			return true
+		}
+	}
+
+	if _, ok := packageManagers[name]; ok {
+		return touchesNVIDIA(args[1:])
+	}
+	return false
+}
+
+// FindProcesses returns the running driver install/upgrade processes in the procfs directory.
+func FindProcesses(procDir string, now time.Time) ([]Activity, error) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var acts []Activity
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(procDir, e.Name(), "cmdline"))
+		if err != nil {
+			// process already exited
+			continue
+		}
+		args := strings.Split(strings.TrimRight(string(b), "\x00"), "\x00")
+		if !MatchCommand(args) {
+			continue
+		}
+		acts = append(acts, Activity{
+			Source:  SourceProcess,
+			Time:    now,
+			PID:     pid,
+			Message: strings.Join(args, " "),
+		})
+	}
+	sort.Slice(acts, func(i, j int) bool { return acts[i].PID < acts[j].PID })
+	return acts, nil
+}
+
+// ReadJournal returns the journal entries of the package managers and the driver build tools
+// since the given time that touch an NVIDIA package.
+func ReadJournal(ctx context.Context, since time.Time) ([]Activity, error) {
+	p, err := exec.LookPath("journalctl")
+	if err != nil {
+		return nil, fmt.Errorf("requires journalctl: %w", err)
+	}
+
+	args := []string{"--no-pager", "--quiet", "--output", "short-iso", "--since", "@" + strconv.FormatInt(since.Unix(), 10)}
+	for _, id := range JournalIdentifiers {
+		args = append(args, "--identifier", id)
+	}
+	b, err := exec.CommandContext(ctx, p, args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("journalctl failed: %w (%s)", err, strings.TrimSpace(string(b)))
+	}
+	return ParseJournal(string(b)), nil
+}
+
+// ParseJournal parses the "journalctl --output short-iso" output, e.g.,
+//
+//	2024-10-01T10:00:00+0000 host dkms[1234]: Building module nvidia/550.90.07 ...
+//
+// and returns the entries that touch an NVIDIA package.
+func ParseJournal(out string) []Activity {
+	var acts []Activity
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(fields[3]), "nvidia") && !strings.Contains(fields[3], "cuda-drivers") {
+			continue
+		}
+		ts, err := time.Parse("2006-01-02T15:04:05-0700", fields[0])
+		if err != nil {
+			continue
+		}
+		acts = append(acts, Activity{
+			Source:  SourceJournal,
+			Time:    ts.UTC(),
+			Message: fields[2] + " " + fields[3],
+		})
+	}
+	return acts
+}
+
+// Status is the driver maintenance status of the node.
+type Status struct {
+	InProgress bool `json:"in_progress"`
+	// Reason summarizes the latest activity, empty if not in progress.
+	Reason string `json:"reason,omitempty"`
+	// Since is the time of the earliest activity in the window.
+	Since time.Time `json:"since,omitempty"`
+}
+
+// ToStatus returns the maintenance status from the activities found in the quiet period.
+func ToStatus(acts []Activity) Status {
+	if len(acts) == 0 {
+		return Status{}
+	}
+	s := Status{InProgress: true, Since: acts[0].Time}
+	latest := acts[0]
+	for _, a := range acts[1:] {
+		if a.Time.Before(s.Since) {
+			s.Since = a.Time
+		}
+		if !a.Time.Before(latest.Time) {
+			latest = a
+		}
+	}
+	s.Reason = fmt.Sprintf("%d driver install/upgrade activity(s), latest %s: %s", len(acts), latest.Source, latest.Message)
+	return s
+}
+
+var (
+	mu      sync.RWMutex
+	current Status
+)
+
+// SetCurrent sets the current maintenance status of the node.
+func SetCurrent(s Status) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = s
+}
+
+// Affects returns true if the component failures are expected while the driver maintenance is in progress
+// (the NVIDIA components).
+func (s Status) Affects(component string) bool {
+	return s.InProgress && strings.HasPrefix(component, "accelerator-nvidia-")
+}
+
+// Current returns the current maintenance status of the node,
+// not in progress if the maintenance component is not enabled.
+func Current() Status {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
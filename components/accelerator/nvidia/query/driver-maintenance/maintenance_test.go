@@ -0,0 +1,105 @@
+package drivermaintenance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{args: []string{"/usr/bin/apt-get", "install", "-y", "nvidia-driver-550"}, want: true},
+		{args: []string{"/usr/bin/apt-get", "install", "-y", "curl"}, want: false},
+		{args: []string{"dnf", "upgrade", "cuda-drivers"}, want: true},
+		{args: []string{"/usr/sbin/dkms", "autoinstall"}, want: true},
+		{args: []string{"./NVIDIA-Linux-x86_64-550.90.07.run", "--silent"}, want: true},
+		{args: []string{"sh", "/tmp/NVIDIA-Linux-x86_64-550.90.07.run"}, want: true},
+		{args: []string{"/usr/bin/nvidia-smi"}, want: false},
+		{args: nil, want: false},
+	}
+	for _, tt := range tests {
+		if got := MatchCommand(tt.args); got != tt.want {
+			t.Errorf("MatchCommand(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestFindProcesses(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for pid, cmdline := range map[string]string{
+		"100":  "apt-get\x00install\x00nvidia-driver-550\x00",
+		"200":  "sleep\x0010\x00",
+		"self": "dkms\x00",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, pid), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, pid, "cmdline"), []byte(cmdline), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	acts, err := FindProcesses(dir, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acts) != 1 || acts[0].PID != 100 || acts[0].Message != "apt-get install nvidia-driver-550" {
+		t.Fatalf("unexpected activities: %+v", acts)
+	}
+}
+
+func TestParseJournal(t *testing.T) {
+	t.Parallel()
+
+	out := `2024-10-01T10:00:00+0000 host dkms[1234]: Building module nvidia/550.90.07 for kernel 6.8.0-45-generic
+2024-10-01T10:00:05+0000 host dpkg[1300]: status installed curl:amd64 8.5.0
+-- Boot 1234 --
+2024-10-01T10:01:00+0000 host unattended-upgrade[1400]: Packages that will be upgraded: nvidia-utils-550
+`
+	acts := ParseJournal(out)
+	if len(acts) != 2 {
+		t.Fatalf("expected 2 activities, got %+v", acts)
+	}
+	if !strings.HasPrefix(acts[0].Message, "dkms[1234]:") {
+		t.Errorf("unexpected message %q", acts[0].Message)
+	}
+
+	s := ToStatus(acts)
+	if !s.InProgress {
+		t.Fatal("expected in progress")
+	}
+	if !s.Since.Equal(time.Date(2024, 10, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected since %v", s.Since)
+	}
+	if !strings.Contains(s.Reason, "unattended-upgrade") {
+		t.Errorf("expected the latest activity in the reason, got %q", s.Reason)
+	}
+
+	if ToStatus(nil).InProgress {
+		t.Error("expected not in progress without activity")
+	}
+}
+
+func TestStatusAffects(t *testing.T) {
+	t.Parallel()
+
+	s := Status{InProgress: true}
+	if !s.Affects("accelerator-nvidia-ecc") {
+		t.Error("expected the nvidia components affected")
+	}
+	if s.Affects("cpu") {
+		t.Error("expected the non-nvidia components not affected")
+	}
+	if (Status{}).Affects("accelerator-nvidia-ecc") {
+		t.Error("expected no component affected without maintenance")
+	}
+}
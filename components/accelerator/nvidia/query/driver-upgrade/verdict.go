@@ -0,0 +1,169 @@
+// Package driverupgrade provides the persistent storage layer for the driver upgrade verdicts,
+// the results of the canary checks run after the NVIDIA driver version changes
+// (compared against the baselines taken before the upgrade).
+package driverupgrade
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const TableNameDriverUpgradeVerdicts = "components_accelerator_nvidia_query_driver_upgrade_verdicts"
+
+const (
+	// unix timestamp in seconds when the canary checks completed
+	ColumnUnixSeconds = "unix_seconds"
+
+	// driver version before the upgrade
+	ColumnFromVersion = "from_version"
+
+	// driver version after the upgrade
+	ColumnToVersion = "to_version"
+
+	// either "pass" or "fail"
+	ColumnVerdict = "verdict"
+
+	// per-check results (e.g., "smoke-test: passed; ecc: failed (...)")
+	ColumnDetails = "details"
+)
+
+const (
+	VerdictPass = "pass"
+	VerdictFail = "fail"
+)
+
+const EventNameDriverUpgradeVerdict = "driver_upgrade_verdict"
+
+type Verdict struct {
+	UnixSeconds int64
+	FromVersion string
+	ToVersion   string
+	Verdict     string
+	Details     string
+}
+
+// ToComponentEvent converts the verdict to the component event.
+func (v Verdict) ToComponentEvent() components.Event {
+	ev := components.Event{
+		Time:    metav1.Time{Time: time.Unix(v.UnixSeconds, 0).UTC()},
+		Name:    EventNameDriverUpgradeVerdict,
+		Type:    components.EventTypeInfo,
+		Message: fmt.Sprintf("driver upgrade from %s to %s %s the canary checks (%s)", v.FromVersion, v.ToVersion, v.verb(), v.Details),
+		ExtraInfo: map[string]string{
+			ColumnFromVersion: v.FromVersion,
+			ColumnToVersion:   v.ToVersion,
+			ColumnVerdict:     v.Verdict,
+			ColumnDetails:     v.Details,
+		},
+	}
+	if v.Verdict != VerdictPass {
+		ev.Type = components.EventTypeError
+	}
+	return ev
+}
+
+func (v Verdict) verb() string {
+	if v.Verdict == VerdictPass {
+		return "passed"
+	}
+	return "failed"
+}
+
+func CreateTableDriverUpgradeVerdicts(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT
+);`, TableNameDriverUpgradeVerdicts,
+		ColumnUnixSeconds,
+		ColumnFromVersion,
+		ColumnToVersion,
+		ColumnVerdict,
+		ColumnDetails,
+	))
+	return err
+}
+
+func InsertVerdict(ctx context.Context, db *sql.DB, v Verdict) error {
+	log.Logger.Debugw("inserting driver upgrade verdict", "from", v.FromVersion, "to", v.ToVersion, "verdict", v.Verdict)
+
+	insertStatement := fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, NULLIF(?, ''));
+`,
+		TableNameDriverUpgradeVerdicts,
+		ColumnUnixSeconds,
+		ColumnFromVersion,
+		ColumnToVersion,
+		ColumnVerdict,
+		ColumnDetails,
+	)
+	_, err := db.ExecContext(
+		ctx,
+		insertStatement,
+		v.UnixSeconds,
+		v.FromVersion,
+		v.ToVersion,
+		v.Verdict,
+		v.Details,
+	)
+	return err
+}
+
+// ReadVerdicts returns the verdicts since the given time (if non-zero),
+// in the ascending order of the time.
+// Returns nil if no verdict is found.
+func ReadVerdicts(ctx context.Context, db *sql.DB, since time.Time) ([]Verdict, error) {
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, %s, COALESCE(%s, '')
+FROM %s
+WHERE %s >= ?
+ORDER BY %s ASC`,
+		ColumnUnixSeconds,
+		ColumnFromVersion,
+		ColumnToVersion,
+		ColumnVerdict,
+		ColumnDetails,
+		TableNameDriverUpgradeVerdicts,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+	)
+	sinceUnixSeconds := since.UTC().Unix()
+	if since.IsZero() {
+		sinceUnixSeconds = 0
+	}
+
+	rows, err := db.QueryContext(ctx, selectStatement, sinceUnixSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var verdicts []Verdict
+	for rows.Next() {
+		var v Verdict
+		if err := rows.Scan(
+			&v.UnixSeconds,
+			&v.FromVersion,
+			&v.ToVersion,
+			&v.Verdict,
+			&v.Details,
+		); err != nil {
+			return nil, err
+		}
+		verdicts = append(verdicts, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return verdicts, nil
+}
@@ -0,0 +1,64 @@
+package driverupgrade
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestInsertAndReadVerdicts(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableDriverUpgradeVerdicts(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	now := time.Now().UTC()
+	verdicts := []Verdict{
+		{UnixSeconds: now.Add(-2 * time.Hour).Unix(), FromVersion: "535.161.08", ToVersion: "550.90.07", Verdict: VerdictFail, Details: "ecc: failed (GPU-0 uncorrected errors 0 -> 2)"},
+		{UnixSeconds: now.Add(-time.Hour).Unix(), FromVersion: "535.161.08", ToVersion: "550.90.07", Verdict: VerdictPass},
+	}
+	for _, v := range verdicts {
+		if err := InsertVerdict(ctx, db, v); err != nil {
+			t.Fatalf("InsertVerdict failed: %v", err)
+		}
+	}
+
+	all, err := ReadVerdicts(ctx, db, time.Time{})
+	if err != nil {
+		t.Fatalf("ReadVerdicts failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 verdicts, got %d", len(all))
+	}
+	if all[0].Verdict != VerdictFail || all[1].Verdict != VerdictPass {
+		t.Errorf("unexpected order: %+v", all)
+	}
+
+	recent, err := ReadVerdicts(ctx, db, now.Add(-90*time.Minute))
+	if err != nil {
+		t.Fatalf("ReadVerdicts failed: %v", err)
+	}
+	if len(recent) != 1 || recent[0].Details != "" {
+		t.Errorf("unexpected recent verdicts: %+v", recent)
+	}
+
+	if ev := all[0].ToComponentEvent(); ev.Type != components.EventTypeError || ev.Name != EventNameDriverUpgradeVerdict || ev.ExtraInfo[ColumnToVersion] != "550.90.07" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+	if ev := all[1].ToComponentEvent(); ev.Type != components.EventTypeInfo {
+		t.Errorf("unexpected event type: %s", ev.Type)
+	}
+}
@@ -0,0 +1,422 @@
+// Package eccaddresses provides the persistent storage layer for the physical memory addresses
+// of the past ECC errors per GPU (e.g., the retired pages and the remapped rows), and detects
+// the new errors that hit the previously retired/remapped memory regions across reboots,
+// which is a strong signal to RMA the GPU.
+package eccaddresses
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/common"
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	TableNameECCErrorAddresses  = "components_accelerator_nvidia_query_ecc_error_addresses"
+	TableNameECCAddressOverlaps = "components_accelerator_nvidia_query_ecc_address_overlaps"
+)
+
+const (
+	// GPU UUID
+	ColumnGPUUUID = "gpu_uuid"
+
+	// physical memory address of the error
+	ColumnAddress = "address"
+
+	// where the address was reported (e.g., "retired-page-dbe", "row-remapper")
+	ColumnSource = "source"
+
+	// unix timestamp in seconds when the error was first observed at the address
+	ColumnFirstUnixSeconds = "first_unix_seconds"
+
+	// unix timestamp in seconds when the error was last observed at the address
+	ColumnLastUnixSeconds = "last_unix_seconds"
+
+	// number of the errors observed at the address
+	ColumnCount = "count"
+
+	// unix timestamp in seconds when the overlapping error was observed
+	ColumnUnixSeconds = "unix_seconds"
+
+	// address of the previous error that the new error overlaps with
+	ColumnKnownAddress = "known_address"
+
+	// source of the previous error that the new error overlaps with
+	ColumnKnownSource = "known_source"
+
+	// unix timestamp in seconds when the previous error was first observed
+	ColumnKnownFirstUnixSeconds = "known_first_unix_seconds"
+)
+
+const (
+	// pages retired due to the multiple single bit ECC errors (pre-Ampere dynamic page retirement)
+	SourceRetiredPageSBE = "retired-page-sbe"
+	// pages retired due to the double bit ECC error (pre-Ampere dynamic page retirement)
+	SourceRetiredPageDBE = "retired-page-dbe"
+	// rows marked for remapping (Ampere and later row remapping), from the Xid 63/64 kernel messages
+	SourceRowRemapper = "row-remapper"
+)
+
+// RegionBytes is the size of the memory region to compare the error addresses at.
+// The errors whose addresses fall into the same aligned region are considered overlapping,
+// as the addresses of the same row are reported at the different offsets.
+const RegionBytes = 64 * 1024
+
+// Region returns the aligned memory region of the address.
+func Region(address uint64) uint64 {
+	return address &^ (RegionBytes - 1)
+}
+
+const EventNameECCAddressOverlap = "ecc_address_overlap"
+
+// Entry is an error address observed on a GPU.
+type Entry struct {
+	GPUUUID          string
+	Address          uint64
+	Source           string
+	FirstUnixSeconds int64
+	LastUnixSeconds  int64
+	Count            int64
+}
+
+// Overlap is a new error that hit the region of a previously observed error address.
+type Overlap struct {
+	UnixSeconds           int64
+	GPUUUID               string
+	Address               uint64
+	Source                string
+	KnownAddress          uint64
+	KnownSource           string
+	KnownFirstUnixSeconds int64
+}
+
+// ToComponentEvent converts the overlap record to the component event.
+func (o Overlap) ToComponentEvent() components.Event {
+	return components.Event{
+		Time: metav1.Time{Time: time.Unix(o.UnixSeconds, 0).UTC()},
+		Name: EventNameECCAddressOverlap,
+		Type: components.EventTypeError,
+		Message: fmt.Sprintf("%s error at 0x%x on %s overlaps with the %s error at 0x%x first seen at %s",
+			o.Source, o.Address, o.GPUUUID, o.KnownSource, o.KnownAddress, time.Unix(o.KnownFirstUnixSeconds, 0).UTC().Format(time.RFC3339)),
+		ExtraInfo: map[string]string{
+			ColumnGPUUUID:      o.GPUUUID,
+			ColumnAddress:      fmt.Sprintf("0x%x", o.Address),
+			ColumnSource:       o.Source,
+			ColumnKnownAddress: fmt.Sprintf("0x%x", o.KnownAddress),
+			ColumnKnownSource:  o.KnownSource,
+		},
+		SuggestedActions: &common.SuggestedActions{
+			Descriptions: []string{
+				"new memory error hit a previously retired or remapped memory region, indicating the degrading GPU memory",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeHardwareInspection,
+			},
+		},
+	}
+}
+
+func CreateTables(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	UNIQUE(%s, %s)
+);`, TableNameECCErrorAddresses,
+		ColumnGPUUUID,
+		ColumnAddress,
+		ColumnSource,
+		ColumnFirstUnixSeconds,
+		ColumnLastUnixSeconds,
+		ColumnCount,
+		ColumnGPUUUID,
+		ColumnAddress,
+	)); err != nil {
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL
+);`, TableNameECCAddressOverlaps,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnAddress,
+		ColumnSource,
+		ColumnKnownAddress,
+		ColumnKnownSource,
+		ColumnKnownFirstUnixSeconds,
+	))
+	return err
+}
+
+// Record records the error observed at the address on the GPU at the given time.
+// It returns the overlap if the error hits the region of an error address observed before
+// (e.g., in the previous boot), and nil otherwise.
+// An error observed at the same address no later than the last observation
+// (e.g., the same retired page listed again) is not a new error, thus ignored.
+// If the observed time is zero (unknown), the error is recorded at the current time
+// only if the address is not known yet.
+func Record(ctx context.Context, db *sql.DB, gpuUUID string, address uint64, source string, observed time.Time) (*Overlap, error) {
+	unixSeconds := observed.UTC().Unix()
+	if observed.IsZero() {
+		unixSeconds = time.Now().UTC().Unix()
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var known *Entry
+	var lastUnixSeconds int64
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE %s = ? AND %s = ?`,
+		ColumnLastUnixSeconds,
+		TableNameECCErrorAddresses,
+		ColumnGPUUUID,
+		ColumnAddress,
+	), gpuUUID, int64(address)).Scan(&lastUnixSeconds)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// new address, find the previous errors in the same region
+		known, err = findInRegion(ctx, tx, gpuUUID, address, unixSeconds)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, 1)`,
+			TableNameECCErrorAddresses,
+			ColumnGPUUUID,
+			ColumnAddress,
+			ColumnSource,
+			ColumnFirstUnixSeconds,
+			ColumnLastUnixSeconds,
+			ColumnCount,
+		), gpuUUID, int64(address), source, unixSeconds, unixSeconds); err != nil {
+			return nil, err
+		}
+
+	case err != nil:
+		return nil, err
+
+	case observed.IsZero() || unixSeconds <= lastUnixSeconds:
+		return nil, nil
+
+	default:
+		// new error at the known address
+		known, err = findInRegion(ctx, tx, gpuUUID, address, unixSeconds)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET %s = ?, %s = %s + 1 WHERE %s = ? AND %s = ?`,
+			TableNameECCErrorAddresses,
+			ColumnLastUnixSeconds,
+			ColumnCount,
+			ColumnCount,
+			ColumnGPUUUID,
+			ColumnAddress,
+		), unixSeconds, gpuUUID, int64(address)); err != nil {
+			return nil, err
+		}
+	}
+
+	var overlap *Overlap
+	if known != nil {
+		overlap = &Overlap{
+			UnixSeconds:           unixSeconds,
+			GPUUUID:               gpuUUID,
+			Address:               address,
+			Source:                source,
+			KnownAddress:          known.Address,
+			KnownSource:           known.Source,
+			KnownFirstUnixSeconds: known.FirstUnixSeconds,
+		}
+		log.Logger.Warnw("ecc error address overlaps with the known bad region", "gpuUUID", gpuUUID, "address", address, "knownAddress", known.Address)
+
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			TableNameECCAddressOverlaps,
+			ColumnUnixSeconds,
+			ColumnGPUUUID,
+			ColumnAddress,
+			ColumnSource,
+			ColumnKnownAddress,
+			ColumnKnownSource,
+			ColumnKnownFirstUnixSeconds,
+		),
+			overlap.UnixSeconds,
+			overlap.GPUUUID,
+			int64(overlap.Address),
+			overlap.Source,
+			int64(overlap.KnownAddress),
+			overlap.KnownSource,
+			overlap.KnownFirstUnixSeconds,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	return overlap, tx.Commit()
+}
+
+// findInRegion returns the earliest error in the same region of the address
+// first observed before the given time, or nil if not found.
+func findInRegion(ctx context.Context, tx *sql.Tx, gpuUUID string, address uint64, beforeUnixSeconds int64) (*Entry, error) {
+	region := Region(address)
+
+	e := Entry{GPUUUID: gpuUUID}
+	var addr int64
+	err := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT %s, %s, %s, %s, %s FROM %s
+WHERE %s = ? AND %s >= ? AND %s < ? AND %s < ?
+ORDER BY %s ASC
+LIMIT 1`,
+		ColumnAddress,
+		ColumnSource,
+		ColumnFirstUnixSeconds,
+		ColumnLastUnixSeconds,
+		ColumnCount,
+		TableNameECCErrorAddresses,
+		ColumnGPUUUID,
+		ColumnAddress,
+		ColumnAddress,
+		ColumnFirstUnixSeconds,
+		ColumnFirstUnixSeconds,
+	), gpuUUID, int64(region), int64(region+RegionBytes), beforeUnixSeconds).Scan(
+		&addr,
+		&e.Source,
+		&e.FirstUnixSeconds,
+		&e.LastUnixSeconds,
+		&e.Count,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.Address = uint64(addr)
+	return &e, nil
+}
+
+// ReadEntries returns the error addresses of the GPU (or all GPUs if empty),
+// in the ascending order of the GPU UUID and the address.
+// Returns nil if no entry is found.
+func ReadEntries(ctx context.Context, db *sql.DB, gpuUUID string) ([]Entry, error) {
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, %s
+FROM %s`,
+		ColumnGPUUUID,
+		ColumnAddress,
+		ColumnSource,
+		ColumnFirstUnixSeconds,
+		ColumnLastUnixSeconds,
+		ColumnCount,
+		TableNameECCErrorAddresses,
+	)
+	var args []any
+	if gpuUUID != "" {
+		selectStatement += fmt.Sprintf("\nWHERE %s = ?", ColumnGPUUUID)
+		args = append(args, gpuUUID)
+	}
+	selectStatement += fmt.Sprintf("\nORDER BY %s ASC, %s ASC", ColumnGPUUUID, ColumnAddress)
+
+	rows, err := db.QueryContext(ctx, selectStatement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var addr int64
+		if err := rows.Scan(
+			&e.GPUUUID,
+			&addr,
+			&e.Source,
+			&e.FirstUnixSeconds,
+			&e.LastUnixSeconds,
+			&e.Count,
+		); err != nil {
+			return nil, err
+		}
+		e.Address = uint64(addr)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReadOverlaps returns the overlaps since the given time (if non-zero),
+// in the ascending order of the time.
+// Returns nil if no overlap is found.
+func ReadOverlaps(ctx context.Context, db *sql.DB, since time.Time) ([]Overlap, error) {
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, %s, %s
+FROM %s
+WHERE %s >= ?
+ORDER BY %s ASC`,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnAddress,
+		ColumnSource,
+		ColumnKnownAddress,
+		ColumnKnownSource,
+		ColumnKnownFirstUnixSeconds,
+		TableNameECCAddressOverlaps,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+	)
+	sinceUnixSeconds := since.UTC().Unix()
+	if since.IsZero() {
+		sinceUnixSeconds = 0
+	}
+
+	rows, err := db.QueryContext(ctx, selectStatement, sinceUnixSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overlaps []Overlap
+	for rows.Next() {
+		var o Overlap
+		var addr, knownAddr int64
+		if err := rows.Scan(
+			&o.UnixSeconds,
+			&o.GPUUUID,
+			&addr,
+			&o.Source,
+			&knownAddr,
+			&o.KnownSource,
+			&o.KnownFirstUnixSeconds,
+		); err != nil {
+			return nil, err
+		}
+		o.Address, o.KnownAddress = uint64(addr), uint64(knownAddr)
+		overlaps = append(overlaps, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return overlaps, nil
+}
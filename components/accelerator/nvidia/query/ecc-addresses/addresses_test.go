@@ -0,0 +1,178 @@
+package eccaddresses
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestRegion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		address uint64
+		want    uint64
+	}{
+		{address: 0, want: 0},
+		{address: 0xffff, want: 0},
+		{address: 0x10000, want: 0x10000},
+		{address: 0x87c8b200, want: 0x87c80000},
+	}
+	for _, tt := range tests {
+		if got := Region(tt.address); got != tt.want {
+			t.Errorf("Region(0x%x) = 0x%x, want 0x%x", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTables(ctx, db); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	boot1 := time.Unix(1_700_000_000, 0).UTC()
+	boot2 := boot1.Add(24 * time.Hour)
+
+	// first error at the address
+	overlap, err := Record(ctx, db, "GPU-0", 0x87c8b200, SourceRowRemapper, boot1)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if overlap != nil {
+		t.Fatalf("expected no overlap for the first error, got %+v", overlap)
+	}
+
+	// same error seen again (e.g., the dmesg replayed after the restart)
+	overlap, err = Record(ctx, db, "GPU-0", 0x87c8b200, SourceRowRemapper, boot1)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if overlap != nil {
+		t.Fatalf("expected no overlap for the same error, got %+v", overlap)
+	}
+
+	// different region and different GPU
+	for _, r := range []struct {
+		uuid string
+		addr uint64
+	}{
+		{"GPU-0", 0x10000000},
+		{"GPU-1", 0x87c8b300},
+	} {
+		overlap, err = Record(ctx, db, r.uuid, r.addr, SourceRowRemapper, boot1.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		if overlap != nil {
+			t.Fatalf("expected no overlap for %s 0x%x, got %+v", r.uuid, r.addr, overlap)
+		}
+	}
+
+	// new error in the same region after the reboot
+	overlap, err = Record(ctx, db, "GPU-0", 0x87c8b300, SourceRowRemapper, boot2)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if overlap == nil {
+		t.Fatal("expected overlap")
+	}
+	if overlap.KnownAddress != 0x87c8b200 || overlap.KnownFirstUnixSeconds != boot1.Unix() || overlap.Address != 0x87c8b300 {
+		t.Errorf("unexpected overlap: %+v", overlap)
+	}
+
+	// new error at the exact known address
+	overlap, err = Record(ctx, db, "GPU-0", 0x10000000, SourceRetiredPageDBE, boot2.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if overlap == nil || overlap.KnownAddress != 0x10000000 || overlap.KnownSource != SourceRowRemapper {
+		t.Errorf("unexpected overlap: %+v", overlap)
+	}
+
+	entries, err := ReadEntries(ctx, db, "GPU-0")
+	if err != nil {
+		t.Fatalf("ReadEntries failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	if entries[0].Address != 0x10000000 || entries[0].Count != 2 || entries[0].LastUnixSeconds != boot2.Add(time.Minute).Unix() {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+	all, err := ReadEntries(ctx, db, "")
+	if err != nil {
+		t.Fatalf("ReadEntries failed: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("expected 4 entries, got %d", len(all))
+	}
+
+	overlaps, err := ReadOverlaps(ctx, db, time.Time{})
+	if err != nil {
+		t.Fatalf("ReadOverlaps failed: %v", err)
+	}
+	if len(overlaps) != 2 {
+		t.Fatalf("expected 2 overlaps, got %+v", overlaps)
+	}
+	overlaps, err = ReadOverlaps(ctx, db, boot2.Add(time.Second))
+	if err != nil {
+		t.Fatalf("ReadOverlaps failed: %v", err)
+	}
+	if len(overlaps) != 1 || overlaps[0].Source != SourceRetiredPageDBE {
+		t.Fatalf("unexpected overlaps: %+v", overlaps)
+	}
+
+	ev := overlaps[0].ToComponentEvent()
+	if ev.Name != EventNameECCAddressOverlap || ev.ExtraInfo[ColumnAddress] != "0x10000000" || !ev.SuggestedActions.RequiresRepair() {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+func TestRecordUnknownTime(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTables(ctx, db); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	// the same page listed in every poll
+	for i := 0; i < 3; i++ {
+		overlap, err := Record(ctx, db, "GPU-0", 0x1000, SourceRetiredPageDBE, time.Time{})
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		if overlap != nil {
+			t.Fatalf("expected no overlap, got %+v", overlap)
+		}
+	}
+
+	entries, err := ReadEntries(ctx, db, "GPU-0")
+	if err != nil {
+		t.Fatalf("ReadEntries failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Count != 1 || entries[0].FirstUnixSeconds == 0 {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
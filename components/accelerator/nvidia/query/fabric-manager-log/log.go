@@ -12,4 +12,9 @@ const (
 	// e.g.,
 	// [Sep 17 2024 06:01:46] [ERROR] [tid 1230079] failed to find the GPU handle 5410063385821516767 in the multicast team request setup 6130285411925746235.
 	RegexNVSwitchNVLinkFailureFromLog = `.+failed to find the GPU handle \d+ in the multicast team .*`
+
+	// e.g.,
+	// [Oct 02 2024 10:15:31] [ERROR] [tid 1203] request to query NVSwitch device information from NVSwitch driver failed with error:WARNING Nothing to do
+	// [Oct 02 2024 10:15:31] [ERROR] [tid 1203] failed to initialize NVSwitch pci bus id 00000000:86:00.0
+	RegexNVSwitchInitFailureFromLog = `.+(request to query NVSwitch device information from NVSwitch driver failed|failed to (initialize|open) .*NVSwitch|NVSwitch .*initialization failed)`
 )
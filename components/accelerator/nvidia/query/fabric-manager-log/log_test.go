@@ -70,3 +70,29 @@ func TestRegexNVSwitchNVLinkFailureFromLog(t *testing.T) {
 		}
 	}
 }
+
+func TestRegexNVSwitchInitFailureFromLog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		log     string
+		matches bool
+	}{
+		{"[Oct 02 2024 10:15:31] [ERROR] [tid 1203] request to query NVSwitch device information from NVSwitch driver failed with error:WARNING Nothing to do", true},
+		{"[Oct 02 2024 10:15:31] [ERROR] [tid 1203] failed to initialize NVSwitch pci bus id 00000000:86:00.0", true},
+		{"[Oct 02 2024 10:15:31] [ERROR] [tid 1203] NVSwitch 00000000:86:00.0 initialization failed", true},
+		{"[May 02 2024 18:41:23] [INFO] [tid 404868] Abort CUDA jobs when FM exits = 1", false},
+		{"[Jul 23 2024 07:53:55] [ERROR] [tid 841] detected NVSwitch fatal error 20034 on fid 0 on NVSwitch pci bus id 00000000:86:00.0 physical id 3 port 33", false},
+	}
+
+	re, err := regexp.Compile(RegexNVSwitchInitFailureFromLog)
+	if err != nil {
+		t.Fatalf("Error compiling regex: %v", err)
+	}
+	for _, test := range tests {
+		matched := re.MatchString(test.log)
+		if matched != test.matches {
+			t.Errorf("Expected match: %v, got: %v for log: %s", test.matches, matched, test.log)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Package failureattribution builds the GPU failure attribution report, which aggregates
+// the GPU Xid errors in a time range by the pods/processes that were using the failed GPUs,
+// so that the job owners can be notified once per job, not once per event.
+package failureattribution
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	gpu_processes "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-processes"
+	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	xidsxidstate "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultWindow is the slack around the first and last seen times of a process,
+	// as the GPU processes are only sampled at the nvidia query poll interval.
+	DefaultWindow = time.Minute
+
+	// the same Xid may be recorded by both the NVML event and the dmesg,
+	// with slightly different timestamps
+	duplicateWindow = 5 * time.Second
+)
+
+// Error is the GPU Xid errors aggregated by the Xid.
+type Error struct {
+	Xid       int64       `json:"xid"`
+	Name      string      `json:"name,omitempty"`
+	Count     int         `json:"count"`
+	FirstTime metav1.Time `json:"first_time"`
+	LastTime  metav1.Time `json:"last_time"`
+}
+
+// GPU is the errors observed on a GPU.
+type GPU struct {
+	UUID   string  `json:"uuid"`
+	Errors []Error `json:"errors"`
+}
+
+// Process is a process attributed with the GPU errors.
+type Process struct {
+	PID     uint32 `json:"pid"`
+	Command string `json:"command,omitempty"`
+}
+
+// Job is the pod (or the process, if not running in a pod) that was using the GPUs
+// when the errors occurred, with all its errors aggregated.
+type Job struct {
+	PodUID    string    `json:"pod_uid,omitempty"`
+	PodName   string    `json:"pod_name,omitempty"`
+	Processes []Process `json:"processes"`
+	GPUUUIDs  []string  `json:"gpu_uuids"`
+	Errors    []Error   `json:"errors"`
+}
+
+// Report is the GPU failure attribution report for the time range.
+type Report struct {
+	Start metav1.Time `json:"start"`
+	End   metav1.Time `json:"end"`
+
+	// GPUs is the list of the GPUs that experienced errors, sorted by the UUID.
+	GPUs []GPU `json:"gpus"`
+	// Jobs is the list of the jobs attributed with the errors, sorted by the first error time.
+	Jobs []Job `json:"jobs"`
+
+	// UnattributedErrors is the number of the errors with no process running on the GPU at the time.
+	UnattributedErrors int `json:"unattributed_errors"`
+	// UnknownGPUErrors is the errors whose GPU could not be identified
+	// (e.g., the bus ID in the dmesg does not match any GPU).
+	UnknownGPUErrors []Error `json:"unknown_gpu_errors,omitempty"`
+}
+
+// Build builds the report from the Xid events and the GPU process history in the time range.
+// The busIDs maps the PCI bus number to the GPU UUID, used to resolve the GPU of the dmesg Xid events.
+func Build(events []xidsxidstate.Event, procs []gpu_processes.Entry, busIDs map[uint32]string, start time.Time, end time.Time, window time.Duration) Report {
+	start, end = start.UTC(), end.UTC()
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].UnixSeconds < events[j].UnixSeconds
+	})
+
+	gpus := make(map[string]*errorCounts)
+	unknown := &errorCounts{}
+	jobs := make(map[string]*job)
+	var jobKeys []string
+	seen := make(map[string][]xidsxidstate.Event)
+
+	rep := Report{
+		Start: metav1.Time{Time: start},
+		End:   metav1.Time{Time: end},
+	}
+	for _, ev := range events {
+		if ev.EventType != "xid" {
+			continue
+		}
+		ts := time.Unix(ev.UnixSeconds, 0).UTC()
+		if ts.Before(start) || ts.After(end) {
+			continue
+		}
+
+		uuid := ev.GPUUUID(busIDs)
+		if uuid == "" {
+			unknown.add(ev.EventID, ts)
+			continue
+		}
+
+		dupKey := fmt.Sprintf("%s/%d", uuid, ev.EventID)
+		if isDuplicate(seen[dupKey], ev) {
+			continue
+		}
+		seen[dupKey] = append(seen[dupKey], ev)
+
+		if gpus[uuid] == nil {
+			gpus[uuid] = &errorCounts{}
+		}
+		gpus[uuid].add(ev.EventID, ts)
+
+		attributed := attribute(ev, uuid, ts, procs, window)
+		if len(attributed) == 0 {
+			rep.UnattributedErrors++
+			continue
+		}
+
+		// count the error once per job, even if multiple processes of the same pod were running
+		counted := make(map[string]bool)
+		for _, p := range attributed {
+			key := jobKey(p)
+			j, ok := jobs[key]
+			if !ok {
+				j = &job{
+					Job:       Job{PodUID: p.PodUID, PodName: p.PodName},
+					gpus:      make(map[string]bool),
+					processes: make(map[Process]bool),
+					errors:    &errorCounts{},
+				}
+				jobs[key] = j
+				jobKeys = append(jobKeys, key)
+			}
+			if j.PodName == "" {
+				j.PodName = p.PodName
+			}
+			j.gpus[uuid] = true
+			j.processes[Process{PID: p.PID, Command: p.Command}] = true
+			if !counted[key] {
+				j.errors.add(ev.EventID, ts)
+				counted[key] = true
+			}
+		}
+	}
+
+	uuids := make([]string, 0, len(gpus))
+	for uuid := range gpus {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	for _, uuid := range uuids {
+		rep.GPUs = append(rep.GPUs, GPU{UUID: uuid, Errors: gpus[uuid].list()})
+	}
+
+	// jobs are created in the order of their first errors
+	for _, key := range jobKeys {
+		rep.Jobs = append(rep.Jobs, jobs[key].build())
+	}
+
+	rep.UnknownGPUErrors = unknown.list()
+
+	return rep
+}
+
+// isDuplicate returns true if the same Xid on the same GPU was already counted
+// from the other data source at about the same time.
+func isDuplicate(counted []xidsxidstate.Event, ev xidsxidstate.Event) bool {
+	for _, prev := range counted {
+		if prev.DataSource == ev.DataSource {
+			continue
+		}
+		d := time.Duration(ev.UnixSeconds-prev.UnixSeconds) * time.Second
+		if d < 0 {
+			d = -d
+		}
+		if d <= duplicateWindow {
+			return true
+		}
+	}
+	return false
+}
+
+// attribute returns the processes that were running on the GPU at the time of the Xid event.
+// If the dmesg names the process that triggered the Xid, only that process is returned.
+func attribute(ev xidsxidstate.Event, uuid string, ts time.Time, procs []gpu_processes.Entry, window time.Duration) []gpu_processes.Entry {
+	var running []gpu_processes.Entry
+	for _, p := range procs {
+		if p.GPUUUID != uuid {
+			continue
+		}
+		first := time.Unix(p.FirstSeenUnixSeconds, 0).Add(-window)
+		last := time.Unix(p.LastSeenUnixSeconds, 0).Add(window)
+		if ts.Before(first) || ts.After(last) {
+			continue
+		}
+		running = append(running, p)
+	}
+
+	if ev.DataSource != "dmesg" {
+		return running
+	}
+	pid, name := nvidia_query_xid.ExtractNVRMXidProcess(ev.EventDetails)
+	if pid == 0 {
+		return running
+	}
+	for _, p := range running {
+		if p.PID == uint32(pid) {
+			return []gpu_processes.Entry{p}
+		}
+	}
+	// the process exited before it was sampled
+	return []gpu_processes.Entry{{GPUUUID: uuid, PID: uint32(pid), Command: name}}
+}
+
+func jobKey(p gpu_processes.Entry) string {
+	if p.PodUID != "" {
+		return "pod/" + p.PodUID
+	}
+	return fmt.Sprintf("process/%d/%d", p.PID, p.CreateUnixSeconds)
+}
+
+type job struct {
+	Job
+	gpus      map[string]bool
+	processes map[Process]bool
+	errors    *errorCounts
+}
+
+func (j *job) build() Job {
+	out := j.Job
+	for uuid := range j.gpus {
+		out.GPUUUIDs = append(out.GPUUUIDs, uuid)
+	}
+	sort.Strings(out.GPUUUIDs)
+	for p := range j.processes {
+		out.Processes = append(out.Processes, p)
+	}
+	sort.Slice(out.Processes, func(a, b int) bool {
+		if out.Processes[a].PID != out.Processes[b].PID {
+			return out.Processes[a].PID < out.Processes[b].PID
+		}
+		return out.Processes[a].Command < out.Processes[b].Command
+	})
+	out.Errors = j.errors.list()
+	return out
+}
+
+type errorCounts struct {
+	byXid map[int64]*Error
+}
+
+func (ec *errorCounts) add(xid int64, ts time.Time) {
+	if ec.byXid == nil {
+		ec.byXid = make(map[int64]*Error)
+	}
+	e, ok := ec.byXid[xid]
+	if !ok {
+		e = &Error{Xid: xid, FirstTime: metav1.Time{Time: ts}}
+		if d, ok := nvidia_query_xid.GetDetail(int(xid)); ok {
+			e.Name = d.Name
+		}
+		ec.byXid[xid] = e
+	}
+	e.Count++
+	e.LastTime = metav1.Time{Time: ts}
+}
+
+func (ec *errorCounts) list() []Error {
+	if len(ec.byXid) == 0 {
+		return nil
+	}
+	errs := make([]Error, 0, len(ec.byXid))
+	for _, e := range ec.byXid {
+		errs = append(errs, *e)
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Xid < errs[j].Xid
+	})
+	return errs
+}
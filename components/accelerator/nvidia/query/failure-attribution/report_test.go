@@ -0,0 +1,110 @@
+package failureattribution
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	gpu_processes "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-processes"
+	xidsxidstate "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+)
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0).UTC()
+	at := func(d time.Duration) int64 { return now.Add(d).Unix() }
+
+	procs := []gpu_processes.Entry{
+		// pod "train" running on GPU-0 and GPU-1 for an hour with two processes
+		{GPUUUID: "GPU-0", PID: 100, Command: "python train.py", PodUID: "uid-train", PodName: "train-0", FirstSeenUnixSeconds: at(0), LastSeenUnixSeconds: at(time.Hour)},
+		{GPUUUID: "GPU-0", PID: 101, Command: "python worker.py", PodUID: "uid-train", PodName: "train-0", FirstSeenUnixSeconds: at(0), LastSeenUnixSeconds: at(time.Hour)},
+		{GPUUUID: "GPU-1", PID: 102, Command: "python train.py", PodUID: "uid-train", PodName: "train-0", FirstSeenUnixSeconds: at(0), LastSeenUnixSeconds: at(time.Hour)},
+		// host process on GPU-1 in the second hour
+		{GPUUUID: "GPU-1", PID: 200, CreateUnixSeconds: at(time.Hour), Command: "./bench", FirstSeenUnixSeconds: at(time.Hour + 5*time.Minute), LastSeenUnixSeconds: at(2 * time.Hour)},
+	}
+	busIDs := map[uint32]string{0x05: "GPU-0", 0x19: "GPU-1"}
+
+	events := []xidsxidstate.Event{
+		// attributed to the pod "train", counted once although two processes were running
+		{UnixSeconds: at(10 * time.Minute), DataSource: "nvml", EventType: "xid", EventID: 79, EventDetails: "GPU-0"},
+		// the same Xid from the dmesg, de-duplicated
+		{UnixSeconds: at(10*time.Minute + 2*time.Second), DataSource: "dmesg", EventType: "xid", EventID: 79, EventDetails: "NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus."},
+		{UnixSeconds: at(20 * time.Minute), DataSource: "dmesg", EventType: "xid", EventID: 79, EventDetails: "NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus."},
+		{UnixSeconds: at(30 * time.Minute), DataSource: "nvml", EventType: "xid", EventID: 31, EventDetails: "GPU-1"},
+		// within the attribution window after the pod was last seen
+		{UnixSeconds: at(time.Hour + 30*time.Second), DataSource: "nvml", EventType: "xid", EventID: 31, EventDetails: "GPU-1"},
+		// dmesg names the process that triggered the error
+		{UnixSeconds: at(90 * time.Minute), DataSource: "dmesg", EventType: "xid", EventID: 13, EventDetails: "NVRM: Xid (PCI:0000:19:00): 13, pid=200, name=bench, Graphics Exception"},
+		// process exited before it was sampled
+		{UnixSeconds: at(100 * time.Minute), DataSource: "dmesg", EventType: "xid", EventID: 13, EventDetails: "NVRM: Xid (PCI:0000:05:00): 13, pid=300, name=short, Graphics Exception"},
+		// no process running
+		{UnixSeconds: at(110 * time.Minute), DataSource: "nvml", EventType: "xid", EventID: 48, EventDetails: "GPU-0"},
+		// unknown GPU (recorded before the GPU UUID was stored)
+		{UnixSeconds: at(115 * time.Minute), DataSource: "nvml", EventType: "xid", EventID: 48},
+		// sxid is not attributed to the GPUs
+		{UnixSeconds: at(116 * time.Minute), DataSource: "dmesg", EventType: "sxid", EventID: 12028},
+		// out of the range
+		{UnixSeconds: at(3 * time.Hour), DataSource: "nvml", EventType: "xid", EventID: 79, EventDetails: "GPU-0"},
+	}
+
+	rep := Build(events, procs, busIDs, now, now.Add(2*time.Hour), DefaultWindow)
+
+	if len(rep.GPUs) != 2 {
+		t.Fatalf("expected 2 gpus, got %+v", rep.GPUs)
+	}
+	gpu0 := map[int64]int{}
+	for _, e := range rep.GPUs[0].Errors {
+		gpu0[e.Xid] = e.Count
+	}
+	if rep.GPUs[0].UUID != "GPU-0" || !reflect.DeepEqual(gpu0, map[int64]int{13: 1, 48: 1, 79: 2}) {
+		t.Errorf("unexpected GPU-0 errors: %+v", rep.GPUs[0])
+	}
+	if rep.UnattributedErrors != 1 {
+		t.Errorf("expected 1 unattributed error, got %d", rep.UnattributedErrors)
+	}
+	if len(rep.UnknownGPUErrors) != 1 || rep.UnknownGPUErrors[0].Xid != 48 {
+		t.Errorf("unexpected unknown gpu errors: %+v", rep.UnknownGPUErrors)
+	}
+
+	if len(rep.Jobs) != 3 {
+		t.Fatalf("expected 3 jobs, got %+v", rep.Jobs)
+	}
+
+	train := rep.Jobs[0]
+	if train.PodUID != "uid-train" || train.PodName != "train-0" {
+		t.Errorf("unexpected first job: %+v", train)
+	}
+	if !reflect.DeepEqual(train.GPUUUIDs, []string{"GPU-0", "GPU-1"}) {
+		t.Errorf("unexpected train gpus: %v", train.GPUUUIDs)
+	}
+	if len(train.Processes) != 3 {
+		t.Errorf("expected 3 train processes, got %+v", train.Processes)
+	}
+	if len(train.Errors) != 2 || train.Errors[0].Xid != 31 || train.Errors[0].Count != 2 || train.Errors[1].Xid != 79 || train.Errors[1].Count != 2 {
+		t.Errorf("unexpected train errors: %+v", train.Errors)
+	}
+	if !train.Errors[1].FirstTime.Time.Equal(now.Add(10 * time.Minute)) {
+		t.Errorf("unexpected first time: %v", train.Errors[1].FirstTime)
+	}
+
+	bench := rep.Jobs[1]
+	if bench.PodUID != "" || !reflect.DeepEqual(bench.Processes, []Process{{PID: 200, Command: "./bench"}}) || len(bench.Errors) != 1 || bench.Errors[0].Xid != 13 {
+		t.Errorf("unexpected bench job: %+v", bench)
+	}
+
+	short := rep.Jobs[2]
+	if !reflect.DeepEqual(short.Processes, []Process{{PID: 300, Command: "short"}}) || !reflect.DeepEqual(short.GPUUUIDs, []string{"GPU-0"}) {
+		t.Errorf("unexpected short job: %+v", short)
+	}
+}
+
+func TestBuildEmpty(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	rep := Build(nil, nil, nil, now.Add(-time.Hour), now, DefaultWindow)
+	if len(rep.GPUs) != 0 || len(rep.Jobs) != 0 || rep.UnattributedErrors != 0 || len(rep.UnknownGPUErrors) != 0 {
+		t.Errorf("expected empty report, got %+v", rep)
+	}
+}
@@ -0,0 +1,201 @@
+// Package gpuledger provides the persistent storage layer for the per-GPU (by UUID) test verdicts
+// (e.g., the memory test results after the DBE events or before returning a node to service).
+package gpuledger
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const TableNameGPULedger = "components_accelerator_nvidia_query_gpu_ledger"
+
+const (
+	// unix timestamp in seconds when the test started
+	ColumnUnixSeconds = "unix_seconds"
+
+	// GPU UUID
+	ColumnGPUUUID = "gpu_uuid"
+
+	// test name (e.g., "memtest")
+	ColumnTest = "test"
+
+	// either "pass" or "fail"
+	ColumnVerdict = "verdict"
+
+	// who requested the test (e.g., "root (sudo by alice)")
+	ColumnRequestedBy = "requested_by"
+
+	// reason of the test (e.g., repair ticket)
+	ColumnReason = "reason"
+
+	// test duration in seconds
+	ColumnDurationSeconds = "duration_seconds"
+
+	// test details (e.g., tool and the output tail)
+	ColumnDetails = "details"
+)
+
+const (
+	VerdictPass = "pass"
+	VerdictFail = "fail"
+)
+
+const EventNameGPUTest = "gpu_test"
+
+type Entry struct {
+	UnixSeconds     int64
+	GPUUUID         string
+	Test            string
+	Verdict         string
+	RequestedBy     string
+	Reason          string
+	DurationSeconds float64
+	Details         string
+}
+
+// ToComponentEvent converts the ledger entry to the component event.
+func (e Entry) ToComponentEvent() components.Event {
+	ev := components.Event{
+		Time:    metav1.Time{Time: time.Unix(e.UnixSeconds, 0).UTC()},
+		Name:    EventNameGPUTest,
+		Type:    components.EventTypeInfo,
+		Message: fmt.Sprintf("%s %s on %s (requested by %s, reason %q)", e.Test, e.Verdict, e.GPUUUID, e.RequestedBy, e.Reason),
+		ExtraInfo: map[string]string{
+			ColumnGPUUUID:         e.GPUUUID,
+			ColumnTest:            e.Test,
+			ColumnVerdict:         e.Verdict,
+			ColumnRequestedBy:     e.RequestedBy,
+			ColumnReason:          e.Reason,
+			ColumnDurationSeconds: fmt.Sprintf("%.0f", e.DurationSeconds),
+			ColumnDetails:         e.Details,
+		},
+	}
+	if e.Verdict != VerdictPass {
+		ev.Type = components.EventTypeError
+	}
+	return ev
+}
+
+func CreateTableGPULedger(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT,
+	%s REAL NOT NULL,
+	%s TEXT
+);`, TableNameGPULedger,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnTest,
+		ColumnVerdict,
+		ColumnRequestedBy,
+		ColumnReason,
+		ColumnDurationSeconds,
+		ColumnDetails,
+	))
+	return err
+}
+
+func InsertEntry(ctx context.Context, db *sql.DB, entry Entry) error {
+	log.Logger.Debugw("inserting gpu ledger entry", "gpuUUID", entry.GPUUUID, "test", entry.Test, "verdict", entry.Verdict)
+
+	insertStatement := fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), ?, NULLIF(?, ''));
+`,
+		TableNameGPULedger,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnTest,
+		ColumnVerdict,
+		ColumnRequestedBy,
+		ColumnReason,
+		ColumnDurationSeconds,
+		ColumnDetails,
+	)
+	_, err := db.ExecContext(
+		ctx,
+		insertStatement,
+		entry.UnixSeconds,
+		entry.GPUUUID,
+		entry.Test,
+		entry.Verdict,
+		entry.RequestedBy,
+		entry.Reason,
+		entry.DurationSeconds,
+		entry.Details,
+	)
+	return err
+}
+
+// ReadEntries returns the ledger entries since the given time (if non-zero), optionally filtered
+// by the GPU UUID and the test (if non-empty), in the ascending order of the test time.
+// Returns nil if no entry is found.
+func ReadEntries(ctx context.Context, db *sql.DB, since time.Time, gpuUUID string, test string) ([]Entry, error) {
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, COALESCE(%s, ''), %s, COALESCE(%s, '')
+FROM %s
+WHERE %s >= ?`,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnTest,
+		ColumnVerdict,
+		ColumnRequestedBy,
+		ColumnReason,
+		ColumnDurationSeconds,
+		ColumnDetails,
+		TableNameGPULedger,
+		ColumnUnixSeconds,
+	)
+	args := []any{since.UTC().Unix()}
+	if since.IsZero() {
+		args[0] = 0
+	}
+	if gpuUUID != "" {
+		selectStatement += fmt.Sprintf(" AND %s = ?", ColumnGPUUUID)
+		args = append(args, gpuUUID)
+	}
+	if test != "" {
+		selectStatement += fmt.Sprintf(" AND %s = ?", ColumnTest)
+		args = append(args, test)
+	}
+	selectStatement += "\nORDER BY " + ColumnUnixSeconds + " ASC"
+
+	rows, err := db.QueryContext(ctx, selectStatement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var entry Entry
+		if err := rows.Scan(
+			&entry.UnixSeconds,
+			&entry.GPUUUID,
+			&entry.Test,
+			&entry.Verdict,
+			&entry.RequestedBy,
+			&entry.Reason,
+			&entry.DurationSeconds,
+			&entry.Details,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
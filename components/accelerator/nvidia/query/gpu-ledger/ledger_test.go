@@ -0,0 +1,65 @@
+package gpuledger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestInsertAndReadEntries(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableGPULedger(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	now := time.Now().UTC()
+	entries := []Entry{
+		{UnixSeconds: now.Add(-2 * time.Hour).Unix(), GPUUUID: "GPU-0", Test: "memtest", Verdict: VerdictFail, RequestedBy: "root", Reason: "post-dbe", DurationSeconds: 600, Details: "tool=dcgm"},
+		{UnixSeconds: now.Add(-time.Hour).Unix(), GPUUUID: "GPU-0", Test: "memtest", Verdict: VerdictPass, RequestedBy: "root", DurationSeconds: 620},
+		{UnixSeconds: now.Add(-time.Hour).Unix(), GPUUUID: "GPU-1", Test: "memtest", Verdict: VerdictPass, RequestedBy: "root", DurationSeconds: 615},
+	}
+	for _, e := range entries {
+		if err := InsertEntry(ctx, db, e); err != nil {
+			t.Fatalf("InsertEntry failed: %v", err)
+		}
+	}
+
+	read, err := ReadEntries(ctx, db, time.Time{}, "", "")
+	if err != nil {
+		t.Fatalf("ReadEntries failed: %v", err)
+	}
+	if len(read) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(read))
+	}
+	if read[0] != entries[0] {
+		t.Errorf("expected %+v, got %+v", entries[0], read[0])
+	}
+
+	read, err = ReadEntries(ctx, db, now.Add(-90*time.Minute), "GPU-0", "memtest")
+	if err != nil {
+		t.Fatalf("ReadEntries failed: %v", err)
+	}
+	if len(read) != 1 || read[0].Verdict != VerdictPass {
+		t.Fatalf("expected 1 passed entry, got %+v", read)
+	}
+
+	if ev := entries[0].ToComponentEvent(); ev.Type != components.EventTypeError {
+		t.Errorf("expected failed verdict event type %q, got %q", components.EventTypeError, ev.Type)
+	}
+	if ev := entries[1].ToComponentEvent(); ev.Type != components.EventTypeInfo {
+		t.Errorf("expected passed verdict event type %q, got %q", components.EventTypeInfo, ev.Type)
+	}
+}
@@ -0,0 +1,159 @@
+// Package gpuorder provides the persistent storage layer for the per-boot GPU enumeration orders
+// (NVML index, PCI bus ID, device minor number, and CUDA index by the GPU UUID),
+// to detect the reorderings across reboots.
+package gpuorder
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const TableNameGPUOrder = "components_accelerator_nvidia_query_gpu_order"
+
+const (
+	// boot time in unix seconds, to identify the boot
+	ColumnBootUnixSeconds = "boot_unix_seconds"
+
+	// GPU UUID
+	ColumnGPUUUID = "gpu_uuid"
+
+	// GPU product name
+	ColumnName = "name"
+
+	// NVML device index
+	ColumnNVMLIndex = "nvml_index"
+
+	// PCI bus ID (e.g., "0000:18:00.0")
+	ColumnBusID = "bus_id"
+
+	// device minor number (-1 if not available)
+	ColumnMinorNumber = "minor_number"
+
+	// CUDA device index (-1 if not deterministic)
+	ColumnCUDAIndex = "cuda_index"
+)
+
+type Entry struct {
+	BootUnixSeconds int64
+	GPUUUID         string
+	Name            string
+	NVMLIndex       int
+	BusID           string
+	MinorNumber     int
+	CUDAIndex       int
+}
+
+func CreateTableGPUOrder(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	PRIMARY KEY (%s, %s)
+);`, TableNameGPUOrder,
+		ColumnBootUnixSeconds,
+		ColumnGPUUUID,
+		ColumnName,
+		ColumnNVMLIndex,
+		ColumnBusID,
+		ColumnMinorNumber,
+		ColumnCUDAIndex,
+		ColumnBootUnixSeconds,
+		ColumnGPUUUID,
+	))
+	return err
+}
+
+// UpsertEntries records the GPU orders of a boot, replacing the existing ones of the same boot and GPU.
+func UpsertEntries(ctx context.Context, db *sql.DB, entries []Entry) error {
+	log.Logger.Debugw("upserting gpu order entries", "entries", len(entries))
+
+	insertStatement := fmt.Sprintf(`
+INSERT OR REPLACE INTO %s (%s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?);
+`,
+		TableNameGPUOrder,
+		ColumnBootUnixSeconds,
+		ColumnGPUUUID,
+		ColumnName,
+		ColumnNVMLIndex,
+		ColumnBusID,
+		ColumnMinorNumber,
+		ColumnCUDAIndex,
+	)
+	for _, e := range entries {
+		if _, err := db.ExecContext(
+			ctx,
+			insertStatement,
+			e.BootUnixSeconds,
+			e.GPUUUID,
+			e.Name,
+			e.NVMLIndex,
+			e.BusID,
+			e.MinorNumber,
+			e.CUDAIndex,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadPreviousBootEntries returns the GPU orders of the latest boot before the given boot time,
+// in the ascending order of the NVML index.
+// Returns nil if no previous boot is found.
+func ReadPreviousBootEntries(ctx context.Context, db *sql.DB, bootUnixSeconds int64) ([]Entry, error) {
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, %s, %s
+FROM %s
+WHERE %s = (SELECT MAX(%s) FROM %s WHERE %s < ?)
+ORDER BY %s ASC`,
+		ColumnBootUnixSeconds,
+		ColumnGPUUUID,
+		ColumnName,
+		ColumnNVMLIndex,
+		ColumnBusID,
+		ColumnMinorNumber,
+		ColumnCUDAIndex,
+		TableNameGPUOrder,
+		ColumnBootUnixSeconds,
+		ColumnBootUnixSeconds,
+		TableNameGPUOrder,
+		ColumnBootUnixSeconds,
+		ColumnNVMLIndex,
+	)
+
+	rows, err := db.QueryContext(ctx, selectStatement, bootUnixSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(
+			&e.BootUnixSeconds,
+			&e.GPUUUID,
+			&e.Name,
+			&e.NVMLIndex,
+			&e.BusID,
+			&e.MinorNumber,
+			&e.CUDAIndex,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
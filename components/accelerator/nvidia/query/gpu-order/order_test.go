@@ -0,0 +1,67 @@
+package gpuorder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestUpsertAndReadPreviousBootEntries(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableGPUOrder(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	entries, err := ReadPreviousBootEntries(ctx, db, 300)
+	if err != nil {
+		t.Fatalf("ReadPreviousBootEntries failed: %v", err)
+	}
+	if entries != nil {
+		t.Fatalf("expected no entries, got %+v", entries)
+	}
+
+	boot1 := []Entry{
+		{BootUnixSeconds: 100, GPUUUID: "GPU-1", Name: "H100", NVMLIndex: 1, BusID: "0000:19:00.0", MinorNumber: 1, CUDAIndex: 1},
+		{BootUnixSeconds: 100, GPUUUID: "GPU-0", Name: "H100", NVMLIndex: 0, BusID: "0000:18:00.0", MinorNumber: 0, CUDAIndex: 0},
+	}
+	boot2 := []Entry{
+		{BootUnixSeconds: 200, GPUUUID: "GPU-0", Name: "H100", NVMLIndex: 1, BusID: "0000:18:00.0", MinorNumber: 0, CUDAIndex: 1},
+		{BootUnixSeconds: 200, GPUUUID: "GPU-1", Name: "H100", NVMLIndex: 0, BusID: "0000:19:00.0", MinorNumber: 1, CUDAIndex: 0},
+	}
+	for _, es := range [][]Entry{boot1, boot2, boot2} {
+		if err := UpsertEntries(ctx, db, es); err != nil {
+			t.Fatalf("UpsertEntries failed: %v", err)
+		}
+	}
+
+	entries, err = ReadPreviousBootEntries(ctx, db, 300)
+	if err != nil {
+		t.Fatalf("ReadPreviousBootEntries failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", entries)
+	}
+	if entries[0] != boot2[1] || entries[1] != boot2[0] {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	entries, err = ReadPreviousBootEntries(ctx, db, 200)
+	if err != nil {
+		t.Fatalf("ReadPreviousBootEntries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0] != boot1[1] {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
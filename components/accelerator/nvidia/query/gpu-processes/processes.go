@@ -0,0 +1,186 @@
+// Package gpuprocesses provides the persistent storage layer for the processes seen running on each GPU,
+// so that the past GPU errors can be attributed to the pods/processes that were using the GPU at the time.
+package gpuprocesses
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const TableNameGPUProcessHistory = "components_accelerator_nvidia_query_gpu_process_history"
+
+const (
+	// GPU UUID
+	ColumnGPUUUID = "gpu_uuid"
+
+	// process ID
+	ColumnPID = "pid"
+
+	// unix timestamp in seconds when the process was created
+	// (distinguishes the processes with the reused PIDs)
+	ColumnCreateUnixSeconds = "create_unix_seconds"
+
+	// process command line
+	ColumnCommand = "command"
+
+	// Kubernetes pod UID, empty if the process does not run in a pod
+	ColumnPodUID = "pod_uid"
+
+	// Kubernetes pod name, empty if unknown
+	ColumnPodName = "pod_name"
+
+	// unix timestamp in seconds when the process was first seen on the GPU
+	ColumnFirstSeenUnixSeconds = "first_seen_unix_seconds"
+
+	// unix timestamp in seconds when the process was last seen on the GPU
+	ColumnLastSeenUnixSeconds = "last_seen_unix_seconds"
+)
+
+type Entry struct {
+	GPUUUID              string
+	PID                  uint32
+	CreateUnixSeconds    int64
+	Command              string
+	PodUID               string
+	PodName              string
+	FirstSeenUnixSeconds int64
+	LastSeenUnixSeconds  int64
+}
+
+func CreateTableGPUProcessHistory(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT,
+	%s TEXT,
+	%s TEXT,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	UNIQUE(%s, %s, %s)
+);`, TableNameGPUProcessHistory,
+		ColumnGPUUUID,
+		ColumnPID,
+		ColumnCreateUnixSeconds,
+		ColumnCommand,
+		ColumnPodUID,
+		ColumnPodName,
+		ColumnFirstSeenUnixSeconds,
+		ColumnLastSeenUnixSeconds,
+		ColumnGPUUUID,
+		ColumnPID,
+		ColumnCreateUnixSeconds,
+	))
+	return err
+}
+
+// RecordSeen records the process as running on the GPU at the given time.
+// If the same process (by GPU UUID, PID, and the create time) was already recorded,
+// it only extends the last seen time.
+func RecordSeen(ctx context.Context, db *sql.DB, entry Entry, seen time.Time) error {
+	log.Logger.Debugw("recording gpu process", "gpuUUID", entry.GPUUUID, "pid", entry.PID, "podUID", entry.PodUID)
+
+	insertStatement := fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?, ?)
+ON CONFLICT(%s, %s, %s) DO UPDATE SET %s = excluded.%s;
+`,
+		TableNameGPUProcessHistory,
+		ColumnGPUUUID,
+		ColumnPID,
+		ColumnCreateUnixSeconds,
+		ColumnCommand,
+		ColumnPodUID,
+		ColumnPodName,
+		ColumnFirstSeenUnixSeconds,
+		ColumnLastSeenUnixSeconds,
+		ColumnGPUUUID,
+		ColumnPID,
+		ColumnCreateUnixSeconds,
+		ColumnLastSeenUnixSeconds,
+		ColumnLastSeenUnixSeconds,
+	)
+	_, err := db.ExecContext(
+		ctx,
+		insertStatement,
+		entry.GPUUUID,
+		entry.PID,
+		entry.CreateUnixSeconds,
+		entry.Command,
+		entry.PodUID,
+		entry.PodName,
+		seen.UTC().Unix(),
+		seen.UTC().Unix(),
+	)
+	return err
+}
+
+// ReadEntries returns the processes that were seen on the GPUs at any point
+// between the start and the end time (inclusive), in the ascending order of the first seen time.
+// Returns nil if no entry is found.
+func ReadEntries(ctx context.Context, db *sql.DB, start time.Time, end time.Time) ([]Entry, error) {
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, COALESCE(%s, ''), COALESCE(%s, ''), COALESCE(%s, ''), %s, %s
+FROM %s
+WHERE %s <= ? AND %s >= ?
+ORDER BY %s ASC`,
+		ColumnGPUUUID,
+		ColumnPID,
+		ColumnCreateUnixSeconds,
+		ColumnCommand,
+		ColumnPodUID,
+		ColumnPodName,
+		ColumnFirstSeenUnixSeconds,
+		ColumnLastSeenUnixSeconds,
+		TableNameGPUProcessHistory,
+		ColumnFirstSeenUnixSeconds,
+		ColumnLastSeenUnixSeconds,
+		ColumnFirstSeenUnixSeconds,
+	)
+
+	rows, err := db.QueryContext(ctx, selectStatement, end.UTC().Unix(), start.UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var entry Entry
+		if err := rows.Scan(
+			&entry.GPUUUID,
+			&entry.PID,
+			&entry.CreateUnixSeconds,
+			&entry.Command,
+			&entry.PodUID,
+			&entry.PodName,
+			&entry.FirstSeenUnixSeconds,
+			&entry.LastSeenUnixSeconds,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Purge deletes the processes last seen before the given time.
+func Purge(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?`, TableNameGPUProcessHistory, ColumnLastSeenUnixSeconds), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
@@ -0,0 +1,73 @@
+package gpuprocesses
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestRecordSeenReadAndPurge(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableGPUProcessHistory(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	now := time.Unix(1_700_000_000, 0).UTC()
+	train := Entry{GPUUUID: "GPU-0", PID: 100, CreateUnixSeconds: now.Add(-time.Hour).Unix(), Command: "python train.py", PodUID: "pod-a", PodName: "train-0"}
+	for i := 0; i < 3; i++ {
+		if err := RecordSeen(ctx, db, train, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("RecordSeen failed: %v", err)
+		}
+	}
+	// same pid reused by a different process later on
+	reused := Entry{GPUUUID: "GPU-0", PID: 100, CreateUnixSeconds: now.Add(time.Hour).Unix(), Command: "python eval.py"}
+	if err := RecordSeen(ctx, db, reused, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("RecordSeen failed: %v", err)
+	}
+
+	entries, err := ReadEntries(ctx, db, now.Add(-time.Hour), now.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("ReadEntries failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].FirstSeenUnixSeconds != now.Unix() || entries[0].LastSeenUnixSeconds != now.Add(2*time.Minute).Unix() {
+		t.Errorf("unexpected seen times: %+v", entries[0])
+	}
+	if entries[0].PodUID != "pod-a" || entries[0].PodName != "train-0" || entries[0].Command != "python train.py" {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+	if entries[1].PodUID != "" || entries[1].Command != "python eval.py" {
+		t.Errorf("unexpected entry: %+v", entries[1])
+	}
+
+	// only overlapping the first process
+	entries, err = ReadEntries(ctx, db, now.Add(time.Minute), now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ReadEntries failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Command != "python train.py" {
+		t.Errorf("expected only the first process, got %+v", entries)
+	}
+
+	purged, err := Purge(ctx, db, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("expected 1 purged, got %d", purged)
+	}
+}
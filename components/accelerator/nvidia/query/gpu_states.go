@@ -0,0 +1,112 @@
+package query
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/common"
+)
+
+// StateKeyGPUUUID is the extra info key of the GPU UUID in the per-GPU states.
+const StateKeyGPUUUID = "gpu_uuid"
+
+// GPUStateName returns the per-GPU state name of the component state name
+// (e.g., "ecc_GPU-1234" for the "ecc" state).
+func GPUStateName(stateName string, uuid string) string {
+	return stateName + "_" + uuid
+}
+
+// IsGPUStateName returns true if the state name is a per-GPU state of the component state name.
+func IsGPUStateName(stateName string, name string) bool {
+	return strings.HasPrefix(name, stateName+"_")
+}
+
+// GPUStateSchema returns the schema of the per-GPU states of the component state name.
+func GPUStateSchema(stateName string, description string) components.StateSchema {
+	return components.StateSchema{
+		Name:        stateName + "_",
+		NamePrefix:  true,
+		Description: description,
+		ExtraInfo: []components.ExtraInfoSchema{
+			{Key: StateKeyGPUUUID, Type: components.ExtraInfoTypeString, Description: "GPU UUID"},
+		},
+	}
+}
+
+// GPUStates collects the health of each GPU to report one state per GPU UUID,
+// in addition to the component state aggregating all the GPUs,
+// so that the schedulers can cordon only the unhealthy GPU rather than the whole node.
+type GPUStates struct {
+	stateName string
+	gpus      map[string]*gpuState
+}
+
+type gpuState struct {
+	reasons []string
+	actions *common.SuggestedActions
+}
+
+// NewGPUStates creates the per-GPU states of the component state name.
+func NewGPUStates(stateName string) *GPUStates {
+	return &GPUStates{
+		stateName: stateName,
+		gpus:      make(map[string]*gpuState),
+	}
+}
+
+// Add adds the GPU, healthy unless an issue is reported with "Unhealthy".
+func (s *GPUStates) Add(uuid string) {
+	if uuid == "" {
+		return
+	}
+	if _, ok := s.gpus[uuid]; !ok {
+		s.gpus[uuid] = &gpuState{}
+	}
+}
+
+// Unhealthy marks the GPU unhealthy with the reason and the optional suggested actions.
+func (s *GPUStates) Unhealthy(uuid string, reason string, actions *common.SuggestedActions) {
+	if uuid == "" {
+		return
+	}
+	s.Add(uuid)
+
+	g := s.gpus[uuid]
+	g.reasons = append(g.reasons, reason)
+	if actions != nil {
+		if g.actions == nil {
+			g.actions = &common.SuggestedActions{}
+		}
+		g.actions.Add(actions)
+	}
+}
+
+// States returns the per-GPU states sorted by the GPU UUID.
+func (s *GPUStates) States() []components.State {
+	uuids := make([]string, 0, len(s.gpus))
+	for uuid := range s.gpus {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	states := make([]components.State, 0, len(uuids))
+	for _, uuid := range uuids {
+		g := s.gpus[uuid]
+		st := components.State{
+			Name:    GPUStateName(s.stateName, uuid),
+			Healthy: true,
+			Reason:  "no issue detected",
+			ExtraInfo: map[string]string{
+				StateKeyGPUUUID: uuid,
+			},
+		}
+		if len(g.reasons) > 0 {
+			st.Healthy = false
+			st.Reason = strings.Join(g.reasons, "; ")
+			st.SuggestedActions = g.actions
+		}
+		states = append(states, st)
+	}
+	return states
+}
@@ -0,0 +1,46 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/leptonai/gpud/components/common"
+)
+
+func TestGPUStates(t *testing.T) {
+	gs := NewGPUStates("ecc")
+	gs.Add("GPU-1")
+	gs.Add("GPU-0")
+	gs.Add("")
+	gs.Unhealthy("GPU-1", "first", &common.SuggestedActions{
+		RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+	})
+	gs.Unhealthy("GPU-1", "second", &common.SuggestedActions{
+		RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem, common.RepairActionTypeHardwareInspection},
+	})
+
+	states := gs.States()
+	if len(states) != 2 {
+		t.Fatalf("expected 2 states, got %d", len(states))
+	}
+
+	if states[0].Name != "ecc_GPU-0" || !states[0].Healthy || states[0].ExtraInfo[StateKeyGPUUUID] != "GPU-0" {
+		t.Errorf("unexpected state %+v", states[0])
+	}
+	if states[0].SuggestedActions != nil {
+		t.Errorf("expected no suggested actions, got %+v", states[0].SuggestedActions)
+	}
+
+	if states[1].Name != "ecc_GPU-1" || states[1].Healthy || states[1].Reason != "first; second" {
+		t.Errorf("unexpected state %+v", states[1])
+	}
+	if states[1].SuggestedActions == nil || len(states[1].SuggestedActions.RepairActions) != 2 {
+		t.Errorf("expected the merged suggested actions, got %+v", states[1].SuggestedActions)
+	}
+
+	if !IsGPUStateName("ecc", states[1].Name) {
+		t.Errorf("expected %q to be a per-GPU state name", states[1].Name)
+	}
+	if IsGPUStateName("ecc", "ecc") {
+		t.Error("expected the component state name to not be a per-GPU state name")
+	}
+}
@@ -0,0 +1,154 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GPUThresholds defines the GPU health thresholds of the temperature, power, and ECC components.
+// The zero value of a field disables the check.
+type GPUThresholds struct {
+	// MaxTemperatureCelsius is the GPU core temperature
+	// at or above which the GPU is reported unhealthy.
+	MaxTemperatureCelsius uint32 `json:"max_temperature_celsius,omitempty"`
+
+	// MaxPowerUsedPercent is the power usage over the enforced power limit
+	// at or above which the GPU is reported unhealthy.
+	MaxPowerUsedPercent float64 `json:"max_power_used_percent,omitempty"`
+
+	// MaxVolatileCorrectedECCErrors is the number of the corrected (single bit) ECC errors
+	// since the driver load at or above which the GPU is reported unhealthy.
+	// The HBM GPUs correct (and remap) the single bit errors at a higher rate than the GDDR GPUs.
+	MaxVolatileCorrectedECCErrors uint64 `json:"max_volatile_corrected_ecc_errors,omitempty"`
+
+	// MaxVolatileUncorrectedECCErrors is the number of the uncorrected (double bit) ECC errors
+	// since the driver load at or above which the GPU is reported unhealthy with the reboot,
+	// as the reboot retires (or remaps) the affected memory and resets the volatile counts.
+	MaxVolatileUncorrectedECCErrors uint64 `json:"max_volatile_uncorrected_ecc_errors,omitempty"`
+
+	// MaxAggregateUncorrectedECCErrors is the number of the uncorrected (double bit) ECC errors
+	// over the lifetime of the GPU at or above which the GPU is reported unhealthy with the hardware inspection,
+	// as the errors keep recurring across the reboots.
+	MaxAggregateUncorrectedECCErrors uint64 `json:"max_aggregate_uncorrected_ecc_errors,omitempty"`
+}
+
+// Threshold names used in the threshold crossing events.
+const (
+	GPUThresholdNameMaxTemperatureCelsius            = "max_temperature_celsius"
+	GPUThresholdNameMaxPowerUsedPercent              = "max_power_used_percent"
+	GPUThresholdNameMaxVolatileCorrectedECCErrors    = "max_volatile_corrected_ecc_errors"
+	GPUThresholdNameMaxVolatileUncorrectedECCErrors  = "max_volatile_uncorrected_ecc_errors"
+	GPUThresholdNameMaxAggregateUncorrectedECCErrors = "max_aggregate_uncorrected_ecc_errors"
+)
+
+// WithOverrides returns the thresholds overwritten by the non-zero fields of the overrides
+// (e.g., the per-fleet thresholds in the component config).
+func (t GPUThresholds) WithOverrides(overrides *GPUThresholds) GPUThresholds {
+	if overrides == nil {
+		return t
+	}
+	if overrides.MaxTemperatureCelsius > 0 {
+		t.MaxTemperatureCelsius = overrides.MaxTemperatureCelsius
+	}
+	if overrides.MaxPowerUsedPercent > 0 {
+		t.MaxPowerUsedPercent = overrides.MaxPowerUsedPercent
+	}
+	if overrides.MaxVolatileCorrectedECCErrors > 0 {
+		t.MaxVolatileCorrectedECCErrors = overrides.MaxVolatileCorrectedECCErrors
+	}
+	if overrides.MaxVolatileUncorrectedECCErrors > 0 {
+		t.MaxVolatileUncorrectedECCErrors = overrides.MaxVolatileUncorrectedECCErrors
+	}
+	if overrides.MaxAggregateUncorrectedECCErrors > 0 {
+		t.MaxAggregateUncorrectedECCErrors = overrides.MaxAggregateUncorrectedECCErrors
+	}
+	return t
+}
+
+const (
+	GPUThresholdPresetH100SXM  = "h100-sxm"
+	GPUThresholdPresetH100PCIe = "h100-pcie"
+	GPUThresholdPresetA100SXM  = "a100-sxm"
+	GPUThresholdPresetA100PCIe = "a100-pcie"
+	GPUThresholdPresetL40S     = "l40s"
+)
+
+// GPUThresholdPresets are the per-SKU thresholds.
+// The temperatures are set a few degrees below the slowdown temperatures of the SKUs,
+// as the air-cooled PCIe cards slow down earlier than the SXM modules.
+// The power thresholds allow the short bursts over the enforced limit.
+var GPUThresholdPresets = map[string]GPUThresholds{
+	GPUThresholdPresetH100SXM: {
+		MaxTemperatureCelsius:         85,
+		MaxPowerUsedPercent:           105,
+		MaxVolatileCorrectedECCErrors: 1000,
+	},
+	GPUThresholdPresetH100PCIe: {
+		MaxTemperatureCelsius:         82,
+		MaxPowerUsedPercent:           102,
+		MaxVolatileCorrectedECCErrors: 1000,
+	},
+	GPUThresholdPresetA100SXM: {
+		MaxTemperatureCelsius:         85,
+		MaxPowerUsedPercent:           105,
+		MaxVolatileCorrectedECCErrors: 1000,
+	},
+	GPUThresholdPresetA100PCIe: {
+		MaxTemperatureCelsius:         80,
+		MaxPowerUsedPercent:           102,
+		MaxVolatileCorrectedECCErrors: 1000,
+	},
+	GPUThresholdPresetL40S: {
+		MaxTemperatureCelsius:         85,
+		MaxPowerUsedPercent:           102,
+		MaxVolatileCorrectedECCErrors: 100,
+	},
+}
+
+// GetGPUThresholdPreset returns the threshold preset name based on the GPU product name
+// (e.g., "NVIDIA H100 80GB HBM3", "NVIDIA A100-SXM4-80GB", "NVIDIA L40S").
+// Returns an empty string if no preset is defined for the product.
+func GetGPUThresholdPreset(gpuProductName string) string {
+	p := strings.ToLower(gpuProductName)
+	switch {
+	// H100 NVL is a dual-slot PCIe card
+	case strings.Contains(p, "h100") && (strings.Contains(p, "pcie") || strings.Contains(p, "nvl")):
+		return GPUThresholdPresetH100PCIe
+
+	case strings.Contains(p, "h100"):
+		return GPUThresholdPresetH100SXM
+
+	case strings.Contains(p, "a100") && strings.Contains(p, "pcie"):
+		return GPUThresholdPresetA100PCIe
+
+	case strings.Contains(p, "a100"):
+		return GPUThresholdPresetA100SXM
+
+	case strings.Contains(p, "l40s"):
+		return GPUThresholdPresetL40S
+
+	default:
+		return ""
+	}
+}
+
+// GPUThresholdSource explains why the threshold applies (e.g., in the explain mode),
+// either the config override, the preset of the GPU product, or none (disabled).
+func GPUThresholdSource(preset string, overridden bool) string {
+	switch {
+	case overridden:
+		return "config override"
+	case preset != "":
+		return fmt.Sprintf("preset %q", preset)
+	default:
+		return "no preset for the GPU product (disabled unless overridden)"
+	}
+}
+
+// GetGPUThresholds returns the preset name and the thresholds for the GPU product,
+// with the overrides applied. All checks are disabled for the products without a preset,
+// unless overridden.
+func GetGPUThresholds(gpuProductName string, overrides *GPUThresholds) (string, GPUThresholds) {
+	preset := GetGPUThresholdPreset(gpuProductName)
+	return preset, GPUThresholdPresets[preset].WithOverrides(overrides)
+}
@@ -0,0 +1,83 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetGPUThresholdPreset(t *testing.T) {
+	tests := []struct {
+		gpuProductName string
+		expected       string
+	}{
+		{gpuProductName: "NVIDIA H100 80GB HBM3", expected: GPUThresholdPresetH100SXM},
+		{gpuProductName: "NVIDIA H100 PCIe", expected: GPUThresholdPresetH100PCIe},
+		{gpuProductName: "NVIDIA H100 NVL", expected: GPUThresholdPresetH100PCIe},
+		{gpuProductName: "NVIDIA A100-SXM4-80GB", expected: GPUThresholdPresetA100SXM},
+		{gpuProductName: "NVIDIA A100 80GB PCIe", expected: GPUThresholdPresetA100PCIe},
+		{gpuProductName: "NVIDIA A100-PCIE-40GB", expected: GPUThresholdPresetA100PCIe},
+		{gpuProductName: "NVIDIA L40S", expected: GPUThresholdPresetL40S},
+		{gpuProductName: "NVIDIA A10", expected: ""},
+		{gpuProductName: "", expected: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.gpuProductName, func(t *testing.T) {
+			if got := GetGPUThresholdPreset(tt.gpuProductName); got != tt.expected {
+				t.Errorf("GetGPUThresholdPreset(%q) = %q, want %q", tt.gpuProductName, got, tt.expected)
+			}
+			if _, ok := GPUThresholdPresets[tt.expected]; tt.expected != "" && !ok {
+				t.Errorf("preset %q not defined", tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetGPUThresholds(t *testing.T) {
+	tests := []struct {
+		name           string
+		gpuProductName string
+		overrides      *GPUThresholds
+		expectedPreset string
+		expected       GPUThresholds
+	}{
+		{
+			name:           "preset without overrides",
+			gpuProductName: "NVIDIA L40S",
+			expectedPreset: GPUThresholdPresetL40S,
+			expected:       GPUThresholdPresets[GPUThresholdPresetL40S],
+		},
+		{
+			name:           "preset with partial overrides",
+			gpuProductName: "NVIDIA H100 80GB HBM3",
+			overrides:      &GPUThresholds{MaxTemperatureCelsius: 80},
+			expectedPreset: GPUThresholdPresetH100SXM,
+			expected: GPUThresholds{
+				MaxTemperatureCelsius:         80,
+				MaxPowerUsedPercent:           GPUThresholdPresets[GPUThresholdPresetH100SXM].MaxPowerUsedPercent,
+				MaxVolatileCorrectedECCErrors: GPUThresholdPresets[GPUThresholdPresetH100SXM].MaxVolatileCorrectedECCErrors,
+			},
+		},
+		{
+			name:           "unknown product",
+			gpuProductName: "NVIDIA GeForce RTX 4090",
+			expected:       GPUThresholds{},
+		},
+		{
+			name:           "unknown product with overrides",
+			gpuProductName: "NVIDIA GeForce RTX 4090",
+			overrides:      &GPUThresholds{MaxPowerUsedPercent: 110},
+			expected:       GPUThresholds{MaxPowerUsedPercent: 110},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preset, got := GetGPUThresholds(tt.gpuProductName, tt.overrides)
+			if preset != tt.expectedPreset {
+				t.Errorf("preset = %q, want %q", preset, tt.expectedPreset)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("thresholds = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/execlimit"
 )
 
 // Returns true if the product supports infiniband.
@@ -51,6 +52,9 @@ func CountInfinibandPCIBuses(ctx context.Context) (int, error) {
 	if err != nil {
 		return 0, fmt.Errorf("lspci not found (%w)", err)
 	}
+	if err := execlimit.Wait(ctx, "lspci"); err != nil {
+		return 0, err
+	}
 	b, err := exec.CommandContext(ctx, p).CombinedOutput()
 	if err != nil {
 		return 0, err
@@ -101,6 +105,9 @@ func RunIbstat(ctx context.Context) (*IbstatOutput, error) {
 	if err != nil {
 		return nil, fmt.Errorf("ibstat not found (%w)", err)
 	}
+	if err := execlimit.Wait(ctx, "ibstat"); err != nil {
+		return nil, err
+	}
 	b, err := exec.CommandContext(ctx, p).CombinedOutput()
 	if err != nil {
 		return nil, err
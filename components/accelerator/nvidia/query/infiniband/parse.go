@@ -2,6 +2,7 @@ package infiniband
 
 import (
 	"bufio"
+	"strconv"
 	"strings"
 
 	"sigs.k8s.io/yaml"
@@ -48,9 +49,15 @@ type IBStatCard struct {
 	NodeGUID        string     `json:"Node GUID"`
 	SystemImageGUID string     `json:"System image GUID"`
 	Port1           IBStatPort `json:"Port 1"`
+
+	// Ports is the list of all the ports of the card (including "Port 1"),
+	// in the order of the ibstat output.
+	Ports []IBStatPort `json:"Ports,omitempty"`
 }
 
 type IBStatPort struct {
+	// Port is the port number (e.g., 1 for "Port 1").
+	Port          int    `json:"Port"`
 	State         string `json:"State"`
 	PhysicalState string `json:"Physical state"`
 	Rate          int    `json:"Rate"`
@@ -63,6 +70,7 @@ func ParseIBStat(input string) (IBStatCards, error) {
 	scanner := bufio.NewScanner(strings.NewReader(input))
 
 	lines := make([]string, 0)
+	portsStarted := false
 	for scanner.Scan() {
 		line := scanner.Text()
 		if strings.TrimSpace(line) == "" {
@@ -79,6 +87,7 @@ func ParseIBStat(input string) (IBStatCards, error) {
 			line = strings.TrimPrefix(line, "CA '")
 			line = strings.TrimSuffix(line, "'")
 			lines = append(lines, "- CA name: "+line)
+			portsStarted = false
 			continue
 		}
 
@@ -119,8 +128,18 @@ func ParseIBStat(input string) (IBStatCards, error) {
 		}
 
 		// Port 1:
-		if strings.HasPrefix(strings.TrimSpace(line), "Port 1:") {
-			lines = append(lines, "  "+strings.TrimSpace(line))
+		// Port 2:
+		// should be
+		//   Ports:
+		//   - Port: 1
+		//   - Port: 2
+		// with the port fields indented under each port
+		if portNum, ok := parsePortHeader(strings.TrimSpace(line)); ok {
+			if !portsStarted {
+				lines = append(lines, "  Ports:")
+				portsStarted = true
+			}
+			lines = append(lines, "  - Port: "+strconv.Itoa(portNum))
 			continue
 		}
 
@@ -160,5 +179,26 @@ func ParseIBStat(input string) (IBStatCards, error) {
 	if err := yaml.Unmarshal([]byte(txt), &cards); err != nil {
 		return nil, err
 	}
+	for i := range cards {
+		for _, port := range cards[i].Ports {
+			if port.Port == 1 {
+				cards[i].Port1 = port
+				break
+			}
+		}
+	}
 	return cards, nil
 }
+
+// parsePortHeader parses the port header line (e.g., "Port 1:"),
+// and returns the port number.
+func parsePortHeader(line string) (int, bool) {
+	if !strings.HasPrefix(line, "Port ") || !strings.HasSuffix(line, ":") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "Port "), ":"))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -32,6 +32,58 @@ func TestParseIBStat(t *testing.T) {
 	t.Logf("parsed:\n%+v", parsed)
 }
 
+func TestParseIBStatMultiplePorts(t *testing.T) {
+	input := `CA 'mlx5_0'
+	CA type: MT4123
+	Number of ports: 2
+	Firmware version: 20.31.1014
+	Hardware version: 0
+	Node GUID: 0x1070fd0300176530
+	System image GUID: 0x1070fd0300176530
+	Port 1:
+		State: Active
+		Physical state: LinkUp
+		Rate: 200
+		Base lid: 12
+		Port GUID: 0x1070fd0300176530
+		Link layer: InfiniBand
+	Port 2:
+		State: Down
+		Physical state: Polling
+		Rate: 10
+		Base lid: 65535
+		Port GUID: 0x1070fd0300176531
+		Link layer: InfiniBand
+CA 'mlx5_1'
+	CA type: MT4123
+	Number of ports: 1
+	Port 1:
+		State: Active
+		Physical state: LinkUp
+		Rate: 200
+		Link layer: InfiniBand`
+
+	parsed, err := ParseIBStat(input)
+	if err != nil {
+		t.Fatalf("Failed to parse ibstat output: %v", err)
+	}
+	if len(parsed) != 2 {
+		t.Fatalf("expected 2 cards, got %d", len(parsed))
+	}
+	if len(parsed[0].Ports) != 2 || len(parsed[1].Ports) != 1 {
+		t.Fatalf("unexpected ports %+v", parsed)
+	}
+	if p := parsed[0].Ports[1]; p.Port != 2 || p.State != "Down" || p.PhysicalState != "Polling" || p.Rate != 10 {
+		t.Errorf("unexpected port 2 %+v", p)
+	}
+	if p := parsed[0].Port1; p.Port != 1 || p.State != "Active" || p.PhysicalState != "LinkUp" || p.Rate != 200 {
+		t.Errorf("unexpected port 1 %+v", p)
+	}
+	if cnt := parsed.CountByRates(200, "Active", "LinkUp"); cnt != 2 {
+		t.Errorf("expected 2 cards with port 1 active, got %d", cnt)
+	}
+}
+
 func TestParseIBStatFiles(t *testing.T) {
 	files, err := filepath.Glob("testdata/ibstat.*")
 	if err != nil {
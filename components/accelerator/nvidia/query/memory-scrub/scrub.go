@@ -0,0 +1,180 @@
+// Package memoryscrub persists the results of the scheduled GPU memory scrubs,
+// so the scrub history and the scrub interval survive the gpud restarts.
+package memoryscrub
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const TableNameMemoryScrubs = "components_accelerator_nvidia_query_memory_scrubs"
+
+const (
+	// unix timestamp in seconds when the scrub started
+	ColumnUnixSeconds = "unix_seconds"
+
+	// either "succeeded", "failed", or "aborted"
+	ColumnResult = "result"
+
+	// scrub duration in seconds
+	ColumnDurationSeconds = "duration_seconds"
+
+	// scrub error, empty if succeeded
+	ColumnError = "error"
+
+	// tail of the scrub command output
+	ColumnOutput = "output"
+)
+
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+	// ResultAborted is the scrub aborted as a job started on the GPUs.
+	ResultAborted = "aborted"
+)
+
+const (
+	EventNameMemoryScrub  = "memory_scrub"
+	EventKeyScrubOutput   = "output"
+	EventKeyScrubDuration = "duration"
+)
+
+// Scrub is the result of a memory scrub run.
+type Scrub struct {
+	UnixSeconds     int64
+	Result          string
+	DurationSeconds float64
+	Error           string
+	Output          string
+}
+
+// ToComponentEvent converts the scrub result to the component event.
+func (s Scrub) ToComponentEvent() components.Event {
+	took := (time.Duration(s.DurationSeconds * float64(time.Second))).Round(time.Second)
+	ev := components.Event{
+		Time:    metav1.Time{Time: time.Unix(s.UnixSeconds, 0).UTC()},
+		Name:    EventNameMemoryScrub,
+		Type:    components.EventTypeInfo,
+		Message: fmt.Sprintf("memory scrub succeeded (took %v)", took),
+		ExtraInfo: map[string]string{
+			EventKeyScrubOutput:   s.Output,
+			EventKeyScrubDuration: took.String(),
+		},
+	}
+	switch s.Result {
+	case ResultAborted:
+		ev.Type = components.EventTypeWarn
+		ev.Message = fmt.Sprintf("memory scrub aborted (took %v): %s", took, s.Error)
+	case ResultFailed:
+		ev.Type = components.EventTypeError
+		ev.Message = fmt.Sprintf("memory scrub failed (took %v): %s", took, s.Error)
+	}
+	return ev
+}
+
+func CreateTableMemoryScrubs(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s REAL NOT NULL,
+	%s TEXT,
+	%s TEXT
+);`, TableNameMemoryScrubs,
+		ColumnUnixSeconds,
+		ColumnResult,
+		ColumnDurationSeconds,
+		ColumnError,
+		ColumnOutput,
+	))
+	return err
+}
+
+func InsertScrub(ctx context.Context, db *sql.DB, s Scrub) error {
+	log.Logger.Debugw("inserting memory scrub", "result", s.Result, "durationSeconds", s.DurationSeconds)
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''));`,
+		TableNameMemoryScrubs,
+		ColumnUnixSeconds,
+		ColumnResult,
+		ColumnDurationSeconds,
+		ColumnError,
+		ColumnOutput,
+	), s.UnixSeconds, s.Result, s.DurationSeconds, s.Error, s.Output)
+	return err
+}
+
+// ReadScrubs returns the memory scrubs since the given time (if non-zero),
+// in the ascending order of the scrub time.
+// Returns nil if no scrub is found.
+func ReadScrubs(ctx context.Context, db *sql.DB, since time.Time) ([]Scrub, error) {
+	var sinceUnix int64
+	if !since.IsZero() {
+		sinceUnix = since.UTC().Unix()
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT %s, %s, %s, COALESCE(%s, ''), COALESCE(%s, '')
+FROM %s
+WHERE %s >= ?
+ORDER BY %s ASC;`,
+		ColumnUnixSeconds,
+		ColumnResult,
+		ColumnDurationSeconds,
+		ColumnError,
+		ColumnOutput,
+		TableNameMemoryScrubs,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+	), sinceUnix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scrubs []Scrub
+	for rows.Next() {
+		var s Scrub
+		if err := rows.Scan(&s.UnixSeconds, &s.Result, &s.DurationSeconds, &s.Error, &s.Output); err != nil {
+			return nil, err
+		}
+		scrubs = append(scrubs, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return scrubs, nil
+}
+
+// LastScrubbed returns the time of the latest memory scrub (succeeded or not),
+// or zero time if none is found.
+func LastScrubbed(ctx context.Context, db *sql.DB) (time.Time, error) {
+	var unixSeconds sql.NullInt64
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT MAX(%s) FROM %s;`, ColumnUnixSeconds, TableNameMemoryScrubs)).Scan(&unixSeconds)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !unixSeconds.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(unixSeconds.Int64, 0).UTC(), nil
+}
+
+// Purge deletes the memory scrubs before the given time.
+func Purge(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?`, TableNameMemoryScrubs, ColumnUnixSeconds), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
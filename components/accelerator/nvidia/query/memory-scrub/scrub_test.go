@@ -0,0 +1,90 @@
+package memoryscrub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestInsertAndReadScrubs(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableMemoryScrubs(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	last, err := LastScrubbed(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !last.IsZero() {
+		t.Fatalf("expected zero time without scrubs, got %v", last)
+	}
+
+	now := time.Unix(1700000000, 0).UTC()
+	scrubs := []Scrub{
+		{UnixSeconds: now.Add(-2 * time.Hour).Unix(), Result: ResultSucceeded, DurationSeconds: 600, Output: "Memory ..... Pass"},
+		{UnixSeconds: now.Add(-time.Hour).Unix(), Result: ResultAborted, DurationSeconds: 30, Error: "gpu busy"},
+		{UnixSeconds: now.Unix(), Result: ResultFailed, DurationSeconds: 120, Error: "exit status 1", Output: "Memory ..... Fail"},
+	}
+	for _, s := range scrubs {
+		if err := InsertScrub(ctx, db, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := ReadScrubs(ctx, db, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0] != scrubs[0] || all[1] != scrubs[1] || all[2] != scrubs[2] {
+		t.Fatalf("unexpected scrubs %+v", all)
+	}
+
+	last, err = LastScrubbed(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !last.Equal(now) {
+		t.Errorf("expected last scrub %v, got %v", now, last)
+	}
+
+	evs := []components.Event{all[0].ToComponentEvent(), all[1].ToComponentEvent(), all[2].ToComponentEvent()}
+	if evs[0].Type != components.EventTypeInfo || evs[0].Message != "memory scrub succeeded (took 10m0s)" || evs[0].ExtraInfo[EventKeyScrubOutput] != "Memory ..... Pass" {
+		t.Errorf("unexpected event %+v", evs[0])
+	}
+	if evs[1].Type != components.EventTypeWarn || evs[1].Message != "memory scrub aborted (took 30s): gpu busy" {
+		t.Errorf("unexpected event %+v", evs[1])
+	}
+	if evs[2].Type != components.EventTypeError || evs[2].Message != "memory scrub failed (took 2m0s): exit status 1" {
+		t.Errorf("unexpected event %+v", evs[2])
+	}
+
+	recent, err := ReadScrubs(ctx, db, now.Add(-90*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 {
+		t.Fatalf("expected 2 recent scrubs, got %d", len(recent))
+	}
+
+	purged, err := Purge(ctx, db, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Errorf("expected 2 purged, got %d", purged)
+	}
+}
@@ -0,0 +1,176 @@
+// Package memtest runs the extensive GPU memory tests (DCGM memtest or cuda_memtest) per GPU,
+// e.g., after the double-bit ECC errors or before returning a node to service.
+package memtest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/probegate"
+)
+
+// TestName is the test name recorded in the GPU ledger.
+const TestName = "memtest"
+
+const (
+	// ToolDCGM runs the DCGM memory test plugin ("dcgmi diag -r memtest").
+	ToolDCGM = "dcgm"
+	// ToolCUDA runs the cuda_memtest (ref. https://github.com/ComputationalRadiationPhysics/cuda_memtest).
+	ToolCUDA = "cuda"
+)
+
+const (
+	DefaultTimeout       = 2 * time.Hour
+	DefaultDrainInterval = 10 * time.Second
+
+	// maxOutputLines is the maximum number of the output lines to keep in the result.
+	maxOutputLines = 50
+)
+
+// Command returns the memory test command of the tool for the GPU,
+// with the extra environment variables.
+func Command(tool string, uuid string, index int) ([]string, []string, error) {
+	switch tool {
+	case ToolDCGM:
+		return []string{"dcgmi", "diag", "-r", "memtest", "-i", strconv.Itoa(index)}, nil, nil
+	case ToolCUDA:
+		return []string{"cuda_memtest", "--stress", "--num_passes", "1"}, []string{"CUDA_VISIBLE_DEVICES=" + uuid}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown memory test tool %q (expected %q or %q)", tool, ToolDCGM, ToolCUDA)
+	}
+}
+
+// IsFailureLine returns true if the output line of the tool reports a memory test failure.
+func IsFailureLine(tool string, line string) bool {
+	switch tool {
+	case ToolDCGM:
+		// e.g., "| Memtest                   | Fail - GPU: 0                        |"
+		return strings.Contains(line, "| Fail")
+	case ToolCUDA:
+		// e.g., "ERROR: the last 2 error addresses are ..."
+		return strings.HasPrefix(strings.TrimSpace(line), "ERROR")
+	}
+	return false
+}
+
+// Result is the memory test result of a GPU.
+type Result struct {
+	UUID     string        `json:"uuid"`
+	Tool     string        `json:"tool"`
+	Passed   bool          `json:"passed"`
+	Duration time.Duration `json:"duration"`
+	// Output is the last lines of the test output.
+	Output string `json:"output"`
+	// Error is set if the test failed or could not run.
+	Error string `json:"error,omitempty"`
+}
+
+// Run runs the memory test on the GPU until it completes or the context is canceled,
+// and streams each output line to the progress function (if not nil).
+// The GPU passes only if the command succeeds and no output line reports a failure.
+func Run(ctx context.Context, tool string, uuid string, index int, progress func(line string)) Result {
+	r := Result{UUID: uuid, Tool: tool}
+
+	args, envs, err := Command(tool, uuid, index)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	start := time.Now()
+	defer func() {
+		r.Duration = time.Since(start)
+	}()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), envs...)
+
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	if err := cmd.Start(); err != nil {
+		r.Error = fmt.Sprintf("failed to start %q: %v", strings.Join(args, " "), err)
+		return r
+	}
+
+	waitErr := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		_ = pw.Close()
+		waitErr <- err
+	}()
+
+	var (
+		lines  []string
+		failed []string
+	)
+	scanner := bufio.NewScanner(pr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if progress != nil {
+			progress(line)
+		}
+		if IsFailureLine(tool, line) {
+			failed = append(failed, strings.TrimSpace(line))
+		}
+		lines = append(lines, line)
+		if len(lines) > maxOutputLines {
+			lines = lines[len(lines)-maxOutputLines:]
+		}
+	}
+	r.Output = strings.Join(lines, "\n")
+
+	err = <-waitErr
+	switch {
+	case err != nil:
+		r.Error = fmt.Sprintf("%q failed: %v", strings.Join(args, " "), err)
+	case len(failed) > 0:
+		r.Error = fmt.Sprintf("memory test reported failure(s): %s", strings.Join(failed, "; "))
+	default:
+		r.Passed = true
+	}
+	return r
+}
+
+// ErrDrainTimeout is returned when the GPU processes do not exit within the timeout.
+var ErrDrainTimeout = errors.New("timed out waiting for the gpu processes to drain")
+
+// WaitForDrain waits until no compute process is running on any of the GPUs,
+// since the memory test requires the exclusive access to the GPU memory.
+func WaitForDrain(ctx context.Context, uuids []string, interval time.Duration, gpuLoads probegate.GPULoadsFunc) error {
+	targets := make(map[string]struct{}, len(uuids))
+	for _, uuid := range uuids {
+		targets[uuid] = struct{}{}
+	}
+
+	for {
+		loads, err := gpuLoads(ctx)
+		if err != nil {
+			return err
+		}
+
+		var busy []string
+		for _, l := range loads {
+			if _, ok := targets[l.UUID]; ok && l.RunningProcesses > 0 {
+				busy = append(busy, fmt.Sprintf("%s (%d process(es))", l.UUID, l.RunningProcesses))
+			}
+		}
+		if len(busy) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s", ErrDrainTimeout, strings.Join(busy, ", "))
+		case <-time.After(interval):
+		}
+	}
+}
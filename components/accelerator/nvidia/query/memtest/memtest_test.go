@@ -0,0 +1,81 @@
+package memtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/probegate"
+)
+
+func TestIsFailureLine(t *testing.T) {
+	tests := []struct {
+		tool string
+		line string
+		want bool
+	}{
+		{ToolDCGM, "| Memtest                   | Pass                                   |", false},
+		{ToolDCGM, "| Memtest                   | Fail - GPU: 0                          |", true},
+		{ToolCUDA, "Test10 [Memory stress test] passed", false},
+		{ToolCUDA, "  ERROR: the last 2 error addresses are: 0x7f...", true},
+		{"unknown", "| Fail", false},
+	}
+	for _, tt := range tests {
+		if got := IsFailureLine(tt.tool, tt.line); got != tt.want {
+			t.Errorf("IsFailureLine(%q, %q) = %v, want %v", tt.tool, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	args, envs, err := Command(ToolDCGM, "GPU-0", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[len(args)-1] != "3" || len(envs) != 0 {
+		t.Errorf("unexpected dcgm command %v %v", args, envs)
+	}
+
+	_, envs, err = Command(ToolCUDA, "GPU-0", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) != 1 || envs[0] != "CUDA_VISIBLE_DEVICES=GPU-0" {
+		t.Errorf("unexpected cuda envs %v", envs)
+	}
+
+	if _, _, err := Command("unknown", "GPU-0", 0); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+}
+
+func TestWaitForDrain(t *testing.T) {
+	calls := 0
+	gpuLoads := func(ctx context.Context) ([]probegate.GPULoad, error) {
+		calls++
+		procs := 0
+		if calls < 3 {
+			procs = 1
+		}
+		return []probegate.GPULoad{
+			{UUID: "GPU-0", RunningProcesses: procs},
+			{UUID: "GPU-1", RunningProcesses: 1},
+		}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitForDrain(ctx, []string{"GPU-0"}, time.Millisecond, gpuLoads); err != nil {
+		t.Fatalf("WaitForDrain() = %v, want nil", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 checks, got %d", calls)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel2()
+	if err := WaitForDrain(ctx2, []string{"GPU-1"}, time.Millisecond, gpuLoads); !errors.Is(err, ErrDrainTimeout) {
+		t.Fatalf("WaitForDrain() = %v, want %v", err, ErrDrainTimeout)
+	}
+}
@@ -27,7 +27,68 @@ var (
 		},
 	)
 
-	graphicsMHz = prometheus.NewGaugeVec(
+	graphicsHertz = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "graphics_hertz",
+			Help:      "tracks the current GPU clock speeds in hertz",
+		},
+		[]string{"gpu_id"},
+	)
+	graphicsHertzAverager = components_metrics.NewNoOpAverager()
+	graphicsHertzAverage  = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "graphics_hertz_avg",
+			Help:      "tracks the GPU clock speeds in hertz with average for the last period",
+		},
+		[]string{"gpu_id", "last_period"},
+	)
+	graphicsHertzEMA = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "graphics_hertz_ema",
+			Help:      "tracks the GPU clock speeds in hertz with exponential moving average",
+		},
+		[]string{"gpu_id", "ema_period"},
+	)
+
+	memoryHertz = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "memory_hertz",
+			Help:      "tracks the current GPU memory clock speed in hertz",
+		},
+		[]string{"gpu_id"},
+	)
+	memoryHertzAverager = components_metrics.NewNoOpAverager()
+	memoryHertzAverage  = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "memory_hertz_avg",
+			Help:      "tracks the GPU memory clock speed in hertz with average for the last period",
+		},
+		[]string{"gpu_id", "last_period"},
+	)
+	memoryHertzEMA = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "memory_hertz_ema",
+			Help:      "tracks the GPU memory clock speed in hertz with exponential moving average",
+		},
+		[]string{"gpu_id", "ema_period"},
+	)
+)
+
+// the metrics in MHz before the SI unit rename, still exported for the existing dashboards
+var (
+	graphicsMHz = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
@@ -35,9 +96,10 @@ var (
 			Help:      "tracks the current GPU clock speeds in MHz",
 		},
 		[]string{"gpu_id"},
+		SubSystem+"_graphics_hertz",
+		1e-6,
 	)
-	graphicsMHzAverager = components_metrics.NewNoOpAverager()
-	graphicsMHzAverage  = prometheus.NewGaugeVec(
+	graphicsMHzAverage = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
@@ -45,8 +107,10 @@ var (
 			Help:      "tracks the GPU clock speeds in MHz with average for the last period",
 		},
 		[]string{"gpu_id", "last_period"},
+		SubSystem+"_graphics_hertz_avg",
+		1e-6,
 	)
-	graphicsMHzEMA = prometheus.NewGaugeVec(
+	graphicsMHzEMA = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
@@ -54,19 +118,21 @@ var (
 			Help:      "tracks the GPU clock speeds in MHz with exponential moving average",
 		},
 		[]string{"gpu_id", "ema_period"},
+		SubSystem+"_graphics_hertz_ema",
+		1e-6,
 	)
-
-	memoryMHz = prometheus.NewGaugeVec(
+	memoryMHz = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
 			Name:      "memory_mhz",
-			Help:      "tracks the current GPU memory utilization percent",
+			Help:      "tracks the current GPU memory clock speed in MHz",
 		},
 		[]string{"gpu_id"},
+		SubSystem+"_memory_hertz",
+		1e-6,
 	)
-	memoryMHzAverager = components_metrics.NewNoOpAverager()
-	memoryMHzAverage  = prometheus.NewGaugeVec(
+	memoryMHzAverage = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
@@ -74,8 +140,10 @@ var (
 			Help:      "tracks the GPU memory clock speed in MHz with average for the last period",
 		},
 		[]string{"gpu_id", "last_period"},
+		SubSystem+"_memory_hertz_avg",
+		1e-6,
 	)
-	memoryMHzEMA = prometheus.NewGaugeVec(
+	memoryMHzEMA = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
@@ -83,32 +151,42 @@ var (
 			Help:      "tracks the GPU memory clock speed in MHz with exponential moving average",
 		},
 		[]string{"gpu_id", "ema_period"},
+		SubSystem+"_memory_hertz_ema",
+		1e-6,
 	)
 )
 
+// renamedMetrics are the averager metric names before the SI unit rename,
+// to re-key the rows recorded by the previous versions.
+var renamedMetrics = []components_metrics.RenamedMetric{
+	{From: SubSystem + "_graphics_mhz", To: SubSystem + "_graphics_hertz", Factor: 1e6},
+	{From: SubSystem + "_memory_mhz", To: SubSystem + "_memory_hertz", Factor: 1e6},
+}
+
 func InitAveragers(db *sql.DB, tableName string) {
-	graphicsMHzAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_graphics_mhz")
-	memoryMHzAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_memory_mhz")
+	graphicsHertzAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_graphics_hertz")
+	memoryHertzAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_memory_hertz")
 }
 
-func ReadGraphicsMHzs(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
-	return graphicsMHzAverager.Read(ctx, components_metrics.WithSince(since))
+func ReadGraphicsHertz(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
+	return graphicsHertzAverager.Read(ctx, components_metrics.WithSince(since))
 }
 
-func ReadMemoryMHzs(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
-	return memoryMHzAverager.Read(ctx, components_metrics.WithSince(since))
+func ReadMemoryHertz(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
+	return memoryHertzAverager.Read(ctx, components_metrics.WithSince(since))
 }
 
 func SetLastUpdateUnixSeconds(unixSeconds float64) {
 	lastUpdateUnixSeconds.Set(unixSeconds)
 }
 
-func SetGraphicsMHz(ctx context.Context, gpuID string, pct uint32, currentTime time.Time) error {
-	graphicsMHz.WithLabelValues(gpuID).Set(float64(pct))
+func SetGraphicsHertz(ctx context.Context, gpuID string, hertz float64, currentTime time.Time) error {
+	graphicsHertz.WithLabelValues(gpuID).Set(hertz)
+	graphicsMHz.Set(hertz, gpuID)
 
-	if err := graphicsMHzAverager.Observe(
+	if err := graphicsHertzAverager.Observe(
 		ctx,
-		float64(pct),
+		hertz,
 		components_metrics.WithCurrentTime(currentTime),
 		components_metrics.WithMetricSecondaryName(gpuID),
 	); err != nil {
@@ -116,7 +194,7 @@ func SetGraphicsMHz(ctx context.Context, gpuID string, pct uint32, currentTime t
 	}
 
 	for _, duration := range defaultPeriods {
-		avg, err := graphicsMHzAverager.Avg(
+		avg, err := graphicsHertzAverager.Avg(
 			ctx,
 			components_metrics.WithSince(currentTime.Add(-duration)),
 			components_metrics.WithMetricSecondaryName(gpuID),
@@ -124,9 +202,10 @@ func SetGraphicsMHz(ctx context.Context, gpuID string, pct uint32, currentTime t
 		if err != nil {
 			return err
 		}
-		graphicsMHzAverage.WithLabelValues(gpuID, duration.String()).Set(avg)
+		graphicsHertzAverage.WithLabelValues(gpuID, duration.String()).Set(avg)
+		graphicsMHzAverage.Set(avg, gpuID, duration.String())
 
-		ema, err := graphicsMHzAverager.EMA(
+		ema, err := graphicsHertzAverager.EMA(
 			ctx,
 			components_metrics.WithEMAPeriod(duration),
 			components_metrics.WithMetricSecondaryName(gpuID),
@@ -134,18 +213,20 @@ func SetGraphicsMHz(ctx context.Context, gpuID string, pct uint32, currentTime t
 		if err != nil {
 			return err
 		}
-		graphicsMHzEMA.WithLabelValues(gpuID, duration.String()).Set(ema)
+		graphicsHertzEMA.WithLabelValues(gpuID, duration.String()).Set(ema)
+		graphicsMHzEMA.Set(ema, gpuID, duration.String())
 	}
 
 	return nil
 }
 
-func SetMemoryMHz(ctx context.Context, gpuID string, pct uint32, currentTime time.Time) error {
-	memoryMHz.WithLabelValues(gpuID).Set(float64(pct))
+func SetMemoryHertz(ctx context.Context, gpuID string, hertz float64, currentTime time.Time) error {
+	memoryHertz.WithLabelValues(gpuID).Set(hertz)
+	memoryMHz.Set(hertz, gpuID)
 
-	if err := memoryMHzAverager.Observe(
+	if err := memoryHertzAverager.Observe(
 		ctx,
-		float64(pct),
+		hertz,
 		components_metrics.WithCurrentTime(currentTime),
 		components_metrics.WithMetricSecondaryName(gpuID),
 	); err != nil {
@@ -153,7 +234,7 @@ func SetMemoryMHz(ctx context.Context, gpuID string, pct uint32, currentTime tim
 	}
 
 	for _, duration := range defaultPeriods {
-		avg, err := memoryMHzAverager.Avg(
+		avg, err := memoryHertzAverager.Avg(
 			ctx,
 			components_metrics.WithSince(currentTime.Add(-duration)),
 			components_metrics.WithMetricSecondaryName(gpuID),
@@ -161,9 +242,10 @@ func SetMemoryMHz(ctx context.Context, gpuID string, pct uint32, currentTime tim
 		if err != nil {
 			return err
 		}
-		memoryMHzAverage.WithLabelValues(gpuID, duration.String()).Set(avg)
+		memoryHertzAverage.WithLabelValues(gpuID, duration.String()).Set(avg)
+		memoryMHzAverage.Set(avg, gpuID, duration.String())
 
-		ema, err := memoryMHzAverager.EMA(
+		ema, err := memoryHertzAverager.EMA(
 			ctx,
 			components_metrics.WithEMAPeriod(duration),
 			components_metrics.WithMetricSecondaryName(gpuID),
@@ -171,35 +253,51 @@ func SetMemoryMHz(ctx context.Context, gpuID string, pct uint32, currentTime tim
 		if err != nil {
 			return err
 		}
-		memoryMHzEMA.WithLabelValues(gpuID, duration.String()).Set(ema)
+		memoryHertzEMA.WithLabelValues(gpuID, duration.String()).Set(ema)
+		memoryMHzEMA.Set(ema, gpuID, duration.String())
 	}
 
 	return nil
 }
 
 func Register(reg *prometheus.Registry, db *sql.DB, tableName string) error {
+	if err := components_metrics.MigrateRenamedMetrics(db, tableName, renamedMetrics...); err != nil {
+		return err
+	}
 	InitAveragers(db, tableName)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
 	}
-	if err := reg.Register(graphicsMHz); err != nil {
+	if err := reg.Register(graphicsHertz); err != nil {
 		return err
 	}
-	if err := reg.Register(graphicsMHzAverage); err != nil {
+	if err := reg.Register(graphicsHertzAverage); err != nil {
 		return err
 	}
-	if err := reg.Register(graphicsMHzEMA); err != nil {
+	if err := reg.Register(graphicsHertzEMA); err != nil {
 		return err
 	}
-	if err := reg.Register(memoryMHz); err != nil {
+	if err := reg.Register(memoryHertz); err != nil {
 		return err
 	}
-	if err := reg.Register(memoryMHzAverage); err != nil {
+	if err := reg.Register(memoryHertzAverage); err != nil {
 		return err
 	}
-	if err := reg.Register(memoryMHzEMA); err != nil {
+	if err := reg.Register(memoryHertzEMA); err != nil {
 		return err
 	}
+	for _, g := range []*components_metrics.DeprecatedGaugeVec{
+		graphicsMHz,
+		graphicsMHzAverage,
+		graphicsMHzEMA,
+		memoryMHz,
+		memoryMHzAverage,
+		memoryMHzEMA,
+	} {
+		if err := reg.Register(g); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,114 @@
+// Package cudacanary provides the NVIDIA CUDA canary workload metrics collection and reporting.
+package cudacanary
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	components_metrics_state "github.com/leptonai/gpud/components/metrics/state"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const SubSystem = "accelerator_nvidia_cuda_canary"
+
+var (
+	lastUpdateUnixSeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "last_update_unix_seconds",
+			Help:      "tracks the last update time in unix seconds",
+		},
+	)
+
+	launchLatencySeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "launch_latency_seconds",
+			Help:      "tracks the time in seconds to launch and complete the canary workload on the GPU",
+		},
+		[]string{"gpu_id"},
+	)
+	launchLatencySecondsAverager = components_metrics.NewNoOpAverager()
+
+	success = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "success",
+			Help:      "tracks whether the last canary workload succeeded on the GPU (1 if succeeded, 0 otherwise)",
+		},
+		[]string{"gpu_id"},
+	)
+	successAverager = components_metrics.NewNoOpAverager()
+)
+
+func InitAveragers(db *sql.DB, tableName string) {
+	launchLatencySecondsAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_launch_latency_seconds")
+	successAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_success")
+}
+
+func ReadLaunchLatencySeconds(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
+	return launchLatencySecondsAverager.Read(ctx, components_metrics.WithSince(since))
+}
+
+func ReadSuccess(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
+	return successAverager.Read(ctx, components_metrics.WithSince(since))
+}
+
+func SetLastUpdateUnixSeconds(unixSeconds float64) {
+	lastUpdateUnixSeconds.Set(unixSeconds)
+}
+
+func SetLaunchLatencySeconds(ctx context.Context, gpuID string, seconds float64, currentTime time.Time) error {
+	launchLatencySeconds.WithLabelValues(gpuID).Set(seconds)
+
+	if err := launchLatencySecondsAverager.Observe(
+		ctx,
+		seconds,
+		components_metrics.WithCurrentTime(currentTime),
+		components_metrics.WithMetricSecondaryName(gpuID),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func SetSuccess(ctx context.Context, gpuID string, succeeded bool, currentTime time.Time) error {
+	v := 0.0
+	if succeeded {
+		v = 1.0
+	}
+	success.WithLabelValues(gpuID).Set(v)
+
+	if err := successAverager.Observe(
+		ctx,
+		v,
+		components_metrics.WithCurrentTime(currentTime),
+		components_metrics.WithMetricSecondaryName(gpuID),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Register(reg *prometheus.Registry, db *sql.DB, tableName string) error {
+	InitAveragers(db, tableName)
+
+	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
+		return err
+	}
+	if err := reg.Register(launchLatencySeconds); err != nil {
+		return err
+	}
+	if err := reg.Register(success); err != nil {
+		return err
+	}
+	return nil
+}
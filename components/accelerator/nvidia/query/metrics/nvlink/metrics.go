@@ -4,6 +4,7 @@ package nvlink
 import (
 	"context"
 	"database/sql"
+	"strconv"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/components/metrics"
@@ -107,6 +108,65 @@ var (
 		[]string{"gpu_id"},
 	)
 	rxBytesAverager = components_metrics.NewNoOpAverager()
+
+	// per-link metrics are only exported to prometheus (not stored in the metrics table),
+	// as the per-GPU aggregated metrics hide a single degrading link
+	linkLabels = []string{"gpu_id", "link"}
+
+	linkFeatureEnabled = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "link_feature_enabled",
+			Help:      "tracks the NVLink feature enabled per link",
+		},
+		linkLabels,
+	)
+	linkReplayErrors = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "link_replay_errors",
+			Help:      "tracks the replay errors per NVLink link",
+		},
+		linkLabels,
+	)
+	linkRecoveryErrors = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "link_recovery_errors",
+			Help:      "tracks the recovery errors per NVLink link",
+		},
+		linkLabels,
+	)
+	linkCRCErrors = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "link_crc_errors",
+			Help:      "tracks the CRC errors per NVLink link",
+		},
+		linkLabels,
+	)
+	linkTxBytesTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "link_tx_bytes_total",
+			Help:      "tracks the total number of bytes transmitted (cumulative) per NVLink link",
+		},
+		linkLabels,
+	)
+	linkRxBytesTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "link_rx_bytes_total",
+			Help:      "tracks the total number of bytes received (cumulative) per NVLink link",
+		},
+		linkLabels,
+	)
 )
 
 func InitAveragers(db *sql.DB, tableName string) {
@@ -266,6 +326,21 @@ func SetRxBytes(ctx context.Context, gpuID string, bytes float64, currentTime ti
 	return nil
 }
 
+// SetLinkCounters sets the per-link metrics of the GPU.
+func SetLinkCounters(gpuID string, link int, enabled bool, replayErrors, recoveryErrors, crcErrors, rxBytes, txBytes uint64) {
+	l := strconv.Itoa(link)
+	v := float64(0)
+	if enabled {
+		v = float64(1)
+	}
+	linkFeatureEnabled.WithLabelValues(gpuID, l).Set(v)
+	linkReplayErrors.WithLabelValues(gpuID, l).Set(float64(replayErrors))
+	linkRecoveryErrors.WithLabelValues(gpuID, l).Set(float64(recoveryErrors))
+	linkCRCErrors.WithLabelValues(gpuID, l).Set(float64(crcErrors))
+	linkRxBytesTotal.WithLabelValues(gpuID, l).Set(float64(rxBytes))
+	linkTxBytesTotal.WithLabelValues(gpuID, l).Set(float64(txBytes))
+}
+
 func Register(reg *prometheus.Registry, db *sql.DB, tableName string) error {
 	InitAveragers(db, tableName)
 
@@ -296,5 +371,17 @@ func Register(reg *prometheus.Registry, db *sql.DB, tableName string) error {
 	if err := reg.Register(rxBytesDelta); err != nil {
 		return err
 	}
+	for _, c := range []prometheus.Collector{
+		linkFeatureEnabled,
+		linkReplayErrors,
+		linkRecoveryErrors,
+		linkCRCErrors,
+		linkTxBytesTotal,
+		linkRxBytesTotal,
+	} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
 	return nil
 }
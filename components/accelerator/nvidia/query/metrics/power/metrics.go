@@ -27,7 +27,68 @@ var (
 		},
 	)
 
-	currentUsageMilliWatts = prometheus.NewGaugeVec(
+	currentUsageWatts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "current_usage_watts",
+			Help:      "tracks the current power in watts",
+		},
+		[]string{"gpu_id"},
+	)
+	currentUsageWattsAverager = components_metrics.NewNoOpAverager()
+	currentUsageWattsAverage  = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "current_usage_watts_avg",
+			Help:      "tracks the current power in watts with average for the last period",
+		},
+		[]string{"gpu_id", "last_period"},
+	)
+	currentUsageWattsEMA = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "current_usage_watts_ema",
+			Help:      "tracks the current power in watts with exponential moving average",
+		},
+		[]string{"gpu_id", "ema_period"},
+	)
+
+	enforcedLimitWatts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "enforced_limit_watts",
+			Help:      "tracks the enforced power limit in watts",
+		},
+		[]string{"gpu_id"},
+	)
+	enforcedLimitWattsAverager = components_metrics.NewNoOpAverager()
+
+	averageUsageWatts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "average_usage_watts",
+			Help:      "tracks the power in watts averaged over the last second as reported by the driver",
+		},
+		[]string{"gpu_id"},
+	)
+
+	defaultLimitWatts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "default_limit_watts",
+			Help:      "tracks the default power limit in watts",
+		},
+		[]string{"gpu_id"},
+	)
+
+	// the metrics in milliwatts before the SI unit rename, still exported for the existing dashboards
+	currentUsageMilliWatts = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
@@ -35,9 +96,10 @@ var (
 			Help:      "tracks the current power in milliwatts",
 		},
 		[]string{"gpu_id"},
+		SubSystem+"_current_usage_watts",
+		1e3,
 	)
-	currentUsageMilliWattsAverager = components_metrics.NewNoOpAverager()
-	currentUsageMilliWattsAverage  = prometheus.NewGaugeVec(
+	currentUsageMilliWattsAverage = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
@@ -45,8 +107,10 @@ var (
 			Help:      "tracks the current power in milliwatts with average for the last period",
 		},
 		[]string{"gpu_id", "last_period"},
+		SubSystem+"_current_usage_watts_avg",
+		1e3,
 	)
-	currentUsageMilliWattsEMA = prometheus.NewGaugeVec(
+	currentUsageMilliWattsEMA = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
@@ -54,9 +118,10 @@ var (
 			Help:      "tracks the current power in milliwatts with exponential moving average",
 		},
 		[]string{"gpu_id", "ema_period"},
+		SubSystem+"_current_usage_watts_ema",
+		1e3,
 	)
-
-	enforcedLimitMilliWatts = prometheus.NewGaugeVec(
+	enforcedLimitMilliWatts = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
@@ -64,8 +129,19 @@ var (
 			Help:      "tracks the enforced power limit in milliwatts",
 		},
 		[]string{"gpu_id"},
+		SubSystem+"_enforced_limit_watts",
+		1e3,
+	)
+
+	violationSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "violation_seconds",
+			Help:      "tracks the accumulated time in seconds the clocks were reduced due to the power limit since the driver load",
+		},
+		[]string{"gpu_id"},
 	)
-	enforcedLimitMilliWattsAverager = components_metrics.NewNoOpAverager()
 
 	usedPercent = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -97,18 +173,25 @@ var (
 	)
 )
 
+// renamedMetrics are the averager metric names before the SI unit rename,
+// to re-key the rows recorded by the previous versions.
+var renamedMetrics = []components_metrics.RenamedMetric{
+	{From: SubSystem + "_current_usage_milli_watts", To: SubSystem + "_current_usage_watts", Factor: 1e-3},
+	{From: SubSystem + "_enforced_limit_milli_watts", To: SubSystem + "_enforced_limit_watts", Factor: 1e-3},
+}
+
 func InitAveragers(db *sql.DB, tableName string) {
-	currentUsageMilliWattsAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_current_usage_milli_watts")
-	enforcedLimitMilliWattsAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_enforced_limit_milli_watts")
+	currentUsageWattsAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_current_usage_watts")
+	enforcedLimitWattsAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_enforced_limit_watts")
 	usedPercentAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_used_percent")
 }
 
-func ReadCurrentUsageMilliWatts(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
-	return currentUsageMilliWattsAverager.Read(ctx, components_metrics.WithSince(since))
+func ReadCurrentUsageWatts(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
+	return currentUsageWattsAverager.Read(ctx, components_metrics.WithSince(since))
 }
 
-func ReadEnforcedLimitMilliWatts(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
-	return enforcedLimitMilliWattsAverager.Read(ctx, components_metrics.WithSince(since))
+func ReadEnforcedLimitWatts(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
+	return enforcedLimitWattsAverager.Read(ctx, components_metrics.WithSince(since))
 }
 
 func ReadUsedPercents(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -119,12 +202,13 @@ func SetLastUpdateUnixSeconds(unixSeconds float64) {
 	lastUpdateUnixSeconds.Set(unixSeconds)
 }
 
-func SetUsageMilliWatts(ctx context.Context, gpuID string, milliWatts float64, currentTime time.Time) error {
-	currentUsageMilliWatts.WithLabelValues(gpuID).Set(milliWatts)
+func SetUsageWatts(ctx context.Context, gpuID string, watts float64, currentTime time.Time) error {
+	currentUsageWatts.WithLabelValues(gpuID).Set(watts)
+	currentUsageMilliWatts.Set(watts, gpuID)
 
-	if err := currentUsageMilliWattsAverager.Observe(
+	if err := currentUsageWattsAverager.Observe(
 		ctx,
-		milliWatts,
+		watts,
 		components_metrics.WithCurrentTime(currentTime),
 		components_metrics.WithMetricSecondaryName(gpuID),
 	); err != nil {
@@ -132,7 +216,7 @@ func SetUsageMilliWatts(ctx context.Context, gpuID string, milliWatts float64, c
 	}
 
 	for _, duration := range defaultPeriods {
-		avg, err := currentUsageMilliWattsAverager.Avg(
+		avg, err := currentUsageWattsAverager.Avg(
 			ctx,
 			components_metrics.WithSince(currentTime.Add(-duration)),
 			components_metrics.WithMetricSecondaryName(gpuID),
@@ -140,9 +224,10 @@ func SetUsageMilliWatts(ctx context.Context, gpuID string, milliWatts float64, c
 		if err != nil {
 			return err
 		}
-		currentUsageMilliWattsAverage.WithLabelValues(gpuID, duration.String()).Set(avg)
+		currentUsageWattsAverage.WithLabelValues(gpuID, duration.String()).Set(avg)
+		currentUsageMilliWattsAverage.Set(avg, gpuID, duration.String())
 
-		ema, err := currentUsageMilliWattsAverager.EMA(
+		ema, err := currentUsageWattsAverager.EMA(
 			ctx,
 			components_metrics.WithEMAPeriod(duration),
 			components_metrics.WithMetricSecondaryName(gpuID),
@@ -150,18 +235,20 @@ func SetUsageMilliWatts(ctx context.Context, gpuID string, milliWatts float64, c
 		if err != nil {
 			return err
 		}
-		currentUsageMilliWattsEMA.WithLabelValues(gpuID, duration.String()).Set(ema)
+		currentUsageWattsEMA.WithLabelValues(gpuID, duration.String()).Set(ema)
+		currentUsageMilliWattsEMA.Set(ema, gpuID, duration.String())
 	}
 
 	return nil
 }
 
-func SetEnforcedLimitMilliWatts(ctx context.Context, gpuID string, milliWatts float64, currentTime time.Time) error {
-	enforcedLimitMilliWatts.WithLabelValues(gpuID).Set(milliWatts)
+func SetEnforcedLimitWatts(ctx context.Context, gpuID string, watts float64, currentTime time.Time) error {
+	enforcedLimitWatts.WithLabelValues(gpuID).Set(watts)
+	enforcedLimitMilliWatts.Set(watts, gpuID)
 
-	if err := enforcedLimitMilliWattsAverager.Observe(
+	if err := enforcedLimitWattsAverager.Observe(
 		ctx,
-		milliWatts,
+		watts,
 		components_metrics.WithCurrentTime(currentTime),
 		components_metrics.WithMetricSecondaryName(gpuID),
 	); err != nil {
@@ -171,6 +258,18 @@ func SetEnforcedLimitMilliWatts(ctx context.Context, gpuID string, milliWatts fl
 	return nil
 }
 
+func SetAverageUsageWatts(gpuID string, watts float64) {
+	averageUsageWatts.WithLabelValues(gpuID).Set(watts)
+}
+
+func SetDefaultLimitWatts(gpuID string, watts float64) {
+	defaultLimitWatts.WithLabelValues(gpuID).Set(watts)
+}
+
+func SetViolationSeconds(gpuID string, seconds float64) {
+	violationSeconds.WithLabelValues(gpuID).Set(seconds)
+}
+
 func SetUsedPercent(ctx context.Context, gpuID string, pct float64, currentTime time.Time) error {
 	usedPercent.WithLabelValues(gpuID).Set(pct)
 
@@ -209,21 +308,43 @@ func SetUsedPercent(ctx context.Context, gpuID string, pct float64, currentTime
 }
 
 func Register(reg *prometheus.Registry, db *sql.DB, tableName string) error {
+	if err := components_metrics.MigrateRenamedMetrics(db, tableName, renamedMetrics...); err != nil {
+		return err
+	}
 	InitAveragers(db, tableName)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
 	}
-	if err := reg.Register(currentUsageMilliWatts); err != nil {
+	if err := reg.Register(currentUsageWatts); err != nil {
+		return err
+	}
+	if err := reg.Register(currentUsageWattsAverage); err != nil {
+		return err
+	}
+	if err := reg.Register(currentUsageWattsEMA); err != nil {
+		return err
+	}
+	if err := reg.Register(enforcedLimitWatts); err != nil {
 		return err
 	}
-	if err := reg.Register(currentUsageMilliWattsAverage); err != nil {
+	if err := reg.Register(averageUsageWatts); err != nil {
 		return err
 	}
-	if err := reg.Register(currentUsageMilliWattsEMA); err != nil {
+	if err := reg.Register(defaultLimitWatts); err != nil {
 		return err
 	}
-	if err := reg.Register(enforcedLimitMilliWatts); err != nil {
+	for _, g := range []*components_metrics.DeprecatedGaugeVec{
+		currentUsageMilliWatts,
+		currentUsageMilliWattsAverage,
+		currentUsageMilliWattsEMA,
+		enforcedLimitMilliWatts,
+	} {
+		if err := reg.Register(g); err != nil {
+			return err
+		}
+	}
+	if err := reg.Register(violationSeconds); err != nil {
 		return err
 	}
 	if err := reg.Register(usedPercent); err != nil {
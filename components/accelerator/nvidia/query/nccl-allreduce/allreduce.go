@@ -0,0 +1,297 @@
+// Package ncclallreduce runs the short single-node NCCL all-reduce self-test (nccl-tests "all_reduce_perf")
+// across all the local GPUs and across each pair of the neighboring GPUs in the ring,
+// and flags the GPUs and links performing far below their peers.
+package ncclallreduce
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TestName is the test name recorded in the GPU ledger.
+const TestName = "nccl_all_reduce"
+
+const (
+	// DefaultTolerancePercent is the percentage below the median bus bandwidth of the peer rings
+	// at which a ring is flagged as slow.
+	DefaultTolerancePercent = 30
+	DefaultTimeout          = 10 * time.Minute
+
+	// DefaultMinBytes and DefaultMaxBytes are the message size range of the all-reduce sweep,
+	// large enough to saturate the links while keeping the test short.
+	DefaultMinBytes = "64M"
+	DefaultMaxBytes = "1G"
+)
+
+// Command returns the nccl-tests all-reduce command over the GPUs,
+// with the extra environment variables to select the GPUs in the ring order.
+// ref. https://github.com/NVIDIA/nccl-tests
+func Command(uuids []string) ([]string, []string) {
+	args := []string{"all_reduce_perf", "-b", DefaultMinBytes, "-e", DefaultMaxBytes, "-f", "2", "-g", strconv.Itoa(len(uuids))}
+	return args, []string{"CUDA_VISIBLE_DEVICES=" + strings.Join(uuids, ",")}
+}
+
+// Output is the parsed all-reduce output.
+type Output struct {
+	// MaxBusBandwidthGBps is the out-of-place bus bandwidth of the largest message size,
+	// which reflects the link bandwidth (the small sizes are latency bound).
+	MaxBusBandwidthGBps float64 `json:"max_bus_bandwidth_gbps"`
+	// AvgBusBandwidthGBps is the average bus bandwidth across the message sizes.
+	AvgBusBandwidthGBps float64 `json:"avg_bus_bandwidth_gbps"`
+	// Wrong is the number of the wrong elements found by the correctness check,
+	// non-zero means the data corruption.
+	Wrong int64 `json:"wrong"`
+}
+
+// ParseOutput parses the all-reduce output, for example:
+//
+//	#       size         count      type   redop    root     time   algbw   busbw #wrong     time   algbw   busbw #wrong
+//	#        (B)    (elements)                               (us)  (GB/s)  (GB/s)            (us)  (GB/s)  (GB/s)
+//	    67108864      16777216     float     sum      -1    612.3  109.60  191.80      0    610.1  110.00  192.50      0
+//	  1073741824     268435456     float     sum      -1   4641.2  231.35  404.86      0   4640.8  231.37  404.89      0
+//	# Out of bounds values : 0 OK
+//	# Avg bus bandwidth    : 298.512
+func ParseOutput(b []byte) (Output, error) {
+	var (
+		o        Output
+		maxSize  int64 = -1
+		foundAvg bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			k, v, ok := strings.Cut(strings.TrimPrefix(line, "#"), ":")
+			if !ok || strings.TrimSpace(k) != "Avg bus bandwidth" {
+				continue
+			}
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return o, fmt.Errorf("failed to parse average bus bandwidth %q: %w", line, err)
+			}
+			o.AvgBusBandwidthGBps = f
+			foundAvg = true
+			continue
+		}
+
+		// size count type redop root time algbw busbw #wrong (out-of-place), then the in-place columns
+		fields := strings.Fields(line)
+		if len(fields) < 9 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			// e.g., the NCCL INFO/WARN lines
+			continue
+		}
+		busbw, err := strconv.ParseFloat(fields[7], 64)
+		if err != nil {
+			return o, fmt.Errorf("failed to parse bus bandwidth %q: %w", line, err)
+		}
+		for _, i := range []int{8, 12} {
+			if i >= len(fields) {
+				continue
+			}
+			// "N/A" if the correctness check is disabled
+			if wrong, err := strconv.ParseInt(fields[i], 10, 64); err == nil {
+				o.Wrong += wrong
+			}
+		}
+		if size > maxSize {
+			maxSize = size
+			o.MaxBusBandwidthGBps = busbw
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return o, err
+	}
+	if maxSize < 0 || !foundAvg {
+		return o, errors.New("no bus bandwidth found in the output")
+	}
+	return o, nil
+}
+
+// Ring is the all-reduce result over the GPUs, in the ring order.
+type Ring struct {
+	UUIDs  []string `json:"uuids"`
+	Output Output   `json:"output"`
+	// Error is set if the all-reduce failed or could not run.
+	Error string `json:"error,omitempty"`
+	// Slow is set if the bus bandwidth is far below the peer rings.
+	Slow bool `json:"slow,omitempty"`
+}
+
+func (r Ring) String() string {
+	return strings.Join(r.UUIDs, " <-> ")
+}
+
+// RunRing runs the all-reduce over the GPUs.
+func RunRing(ctx context.Context, uuids []string) Ring {
+	r := Ring{UUIDs: uuids}
+
+	args, envs := Command(uuids)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), envs...)
+	b, err := cmd.CombinedOutput()
+	if err != nil {
+		r.Error = fmt.Sprintf("%q failed: %v (%s)", strings.Join(args, " "), err, lastLines(b, 5))
+		return r
+	}
+	r.Output, err = ParseOutput(b)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	if r.Output.Wrong > 0 {
+		r.Error = fmt.Sprintf("%d wrong element(s) in the all-reduce results", r.Output.Wrong)
+	}
+	return r
+}
+
+// PairRings returns the pairs of the neighboring GPUs in the ring over the GPUs,
+// (e.g., 0-1, 1-2, ..., 7-0 for 8 GPUs), so that each link is tested in isolation.
+// Returns nil if fewer than 3 GPUs (the pair is the whole ring).
+func PairRings(uuids []string) [][]string {
+	if len(uuids) < 3 {
+		return nil
+	}
+	pairs := make([][]string, 0, len(uuids))
+	for i := range uuids {
+		pairs = append(pairs, []string{uuids[i], uuids[(i+1)%len(uuids)]})
+	}
+	return pairs
+}
+
+// Result is the all-reduce self-test result.
+type Result struct {
+	// All is the all-reduce across all the GPUs.
+	All Ring `json:"all"`
+	// Pairs are the all-reduces across each pair of the neighboring GPUs.
+	Pairs []Ring `json:"pairs,omitempty"`
+	// MedianPairBusBandwidthGBps is the median bus bandwidth of the pair rings.
+	MedianPairBusBandwidthGBps float64 `json:"median_pair_bus_bandwidth_gbps"`
+	// SuspectGPUs are the GPUs whose pair rings all failed or are slow,
+	// thus likely the GPU (rather than a single link) is at fault.
+	SuspectGPUs []string `json:"suspect_gpus,omitempty"`
+}
+
+// Passed returns true if no ring failed or is slow.
+func (r Result) Passed() bool {
+	if r.All.Error != "" {
+		return false
+	}
+	for _, p := range r.Pairs {
+		if p.Error != "" || p.Slow {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the rings that failed or are slow, to report the links performing below the peers.
+func (r Result) Failed() []Ring {
+	var rs []Ring
+	if r.All.Error != "" {
+		rs = append(rs, r.All)
+	}
+	for _, p := range r.Pairs {
+		if p.Error != "" || p.Slow {
+			rs = append(rs, p)
+		}
+	}
+	return rs
+}
+
+// Run runs the all-reduce across all the GPUs, then across each pair of the neighboring GPUs,
+// and reports the progress after each ring (if not nil).
+func Run(ctx context.Context, uuids []string, tolerancePercent float64, progress func(r Ring)) (Result, error) {
+	if len(uuids) < 2 {
+		return Result{}, fmt.Errorf("all-reduce requires at least 2 GPUs, found %d", len(uuids))
+	}
+
+	res := Result{All: RunRing(ctx, uuids)}
+	if progress != nil {
+		progress(res.All)
+	}
+	for _, pair := range PairRings(uuids) {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		r := RunRing(ctx, pair)
+		if progress != nil {
+			progress(r)
+		}
+		res.Pairs = append(res.Pairs, r)
+	}
+
+	Evaluate(&res, tolerancePercent)
+	return res, nil
+}
+
+// Evaluate flags the pair rings whose bus bandwidth is more than the tolerance percentage
+// below the median of the pair rings, and the GPUs whose pair rings all failed or are slow.
+func Evaluate(res *Result, tolerancePercent float64) {
+	var bws []float64
+	for _, p := range res.Pairs {
+		if p.Error == "" {
+			bws = append(bws, p.Output.MaxBusBandwidthGBps)
+		}
+	}
+	res.MedianPairBusBandwidthGBps = median(bws)
+
+	threshold := res.MedianPairBusBandwidthGBps * (1 - tolerancePercent/100)
+	bad := make(map[string]int)
+	total := make(map[string]int)
+	for i := range res.Pairs {
+		p := &res.Pairs[i]
+		p.Slow = p.Error == "" && p.Output.MaxBusBandwidthGBps < threshold
+		for _, uuid := range p.UUIDs {
+			total[uuid]++
+			if p.Error != "" || p.Slow {
+				bad[uuid]++
+			}
+		}
+	}
+
+	res.SuspectGPUs = nil
+	for uuid, n := range bad {
+		if n == total[uuid] {
+			res.SuspectGPUs = append(res.SuspectGPUs, uuid)
+		}
+	}
+	sort.Strings(res.SuspectGPUs)
+}
+
+func median(vs []float64) float64 {
+	if len(vs) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), vs...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func lastLines(b []byte, n int) string {
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
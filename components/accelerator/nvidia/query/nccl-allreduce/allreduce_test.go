@@ -0,0 +1,88 @@
+package ncclallreduce
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOutput(t *testing.T) {
+	out := []byte(`# nThread 1 nGpus 8 minBytes 67108864 maxBytes 1073741824 step: 2(factor) warmup iters: 5 iters: 20 agg iters: 1 validation: 1 graph: 0
+#
+# Using devices
+#  Rank  0 Group  0 Pid  12345 on   node-1 device  0 [0x18] NVIDIA H100 80GB HBM3
+#
+#                                                              out-of-place                       in-place
+#       size         count      type   redop    root     time   algbw   busbw #wrong     time   algbw   busbw #wrong
+#        (B)    (elements)                               (us)  (GB/s)  (GB/s)            (us)  (GB/s)  (GB/s)
+    67108864      16777216     float     sum      -1    612.3  109.60  191.80      0    610.1  110.00  192.50      0
+   134217728      33554432     float     sum      -1   1001.2  134.05  234.59      0   1000.8  134.11  234.69      0
+  1073741824     268435456     float     sum      -1   4641.2  231.35  404.86      0   4640.8  231.37  404.89      1
+# Out of bounds values : 0 OK
+# Avg bus bandwidth    : 298.512
+#
+`)
+	o, err := ParseOutput(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Output{MaxBusBandwidthGBps: 404.86, AvgBusBandwidthGBps: 298.512, Wrong: 1}
+	if o != want {
+		t.Errorf("ParseOutput() = %+v, want %+v", o, want)
+	}
+
+	if _, err := ParseOutput([]byte("node-1: Test NCCL failure common.cu:1005 'unhandled system error'")); err == nil {
+		t.Error("expected error for the output without bandwidth")
+	}
+}
+
+func TestPairRings(t *testing.T) {
+	if pairs := PairRings([]string{"a", "b"}); pairs != nil {
+		t.Errorf("expected no pairs for 2 GPUs, got %v", pairs)
+	}
+	want := [][]string{{"a", "b"}, {"b", "c"}, {"c", "a"}}
+	if pairs := PairRings([]string{"a", "b", "c"}); !reflect.DeepEqual(pairs, want) {
+		t.Errorf("PairRings() = %v, want %v", pairs, want)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	ring := func(bw float64, uuids ...string) Ring {
+		return Ring{UUIDs: uuids, Output: Output{MaxBusBandwidthGBps: bw}}
+	}
+
+	// one slow link
+	res := Result{
+		All: ring(350, "a", "b", "c", "d"),
+		Pairs: []Ring{
+			ring(400, "a", "b"),
+			ring(150, "b", "c"),
+			ring(390, "c", "d"),
+			ring(405, "d", "a"),
+		},
+	}
+	Evaluate(&res, DefaultTolerancePercent)
+	if res.Passed() {
+		t.Fatal("expected failed")
+	}
+	if failed := res.Failed(); len(failed) != 1 || failed[0].String() != "b <-> c" {
+		t.Errorf("unexpected failed rings %v", failed)
+	}
+	if len(res.SuspectGPUs) != 0 {
+		t.Errorf("expected no suspect gpu for a single slow link, got %v", res.SuspectGPUs)
+	}
+
+	// both links of a GPU slow or failed
+	res.Pairs[0] = Ring{UUIDs: []string{"a", "b"}, Error: "exit status 1"}
+	Evaluate(&res, DefaultTolerancePercent)
+	if !reflect.DeepEqual(res.SuspectGPUs, []string{"b"}) {
+		t.Errorf("expected suspect gpu b, got %v", res.SuspectGPUs)
+	}
+
+	// all healthy
+	res.Pairs[0] = ring(400, "a", "b")
+	res.Pairs[1] = ring(395, "b", "c")
+	Evaluate(&res, DefaultTolerancePercent)
+	if !res.Passed() {
+		t.Errorf("expected passed, got failed %v", res.Failed())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/leptonai/gpud/pkg/execlimit"
 	"github.com/leptonai/gpud/pkg/systemd"
 )
 
@@ -22,6 +23,9 @@ func CheckFabricManagerVersion(ctx context.Context) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("fabric manager version check requires 'nv-fabricmanager' (%w)", err)
 	}
+	if err := execlimit.Wait(ctx, "nv-fabricmanager"); err != nil {
+		return "", err
+	}
 	b, err := exec.CommandContext(ctx, p, "--version").CombinedOutput()
 	if err != nil {
 		return "", err
@@ -1,9 +1,15 @@
 package query
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/leptonai/gpud/pkg/file"
+	"github.com/leptonai/gpud/pkg/systemd"
 )
 
 // Returns true if the local machine has "nvidia-persistenced".
@@ -21,3 +27,53 @@ func PersistencedRunning() bool {
 	err := exec.Command("pidof", "nvidia-persistenced").Run()
 	return err == nil
 }
+
+// Returns true if "nvidia-persistenced" is running with "--no-persistence-mode",
+// which keeps the daemon running without enabling the persistence mode on the GPUs.
+// ref. https://docs.nvidia.com/deploy/driver-persistence/index.html#usage
+func PersistencedNoPersistenceMode() bool {
+	out, err := exec.Command("pidof", "nvidia-persistenced").Output()
+	if err != nil {
+		return false
+	}
+	for _, pid := range strings.Fields(string(out)) {
+		cmdline, err := os.ReadFile("/proc/" + pid + "/cmdline")
+		if err != nil {
+			continue
+		}
+		if hasNoPersistenceModeFlag(cmdline) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNoPersistenceModeFlag returns true if the null-separated command line has "--no-persistence-mode".
+func hasNoPersistenceModeFlag(cmdline []byte) bool {
+	for _, arg := range bytes.Split(cmdline, []byte{0}) {
+		if string(arg) == "--no-persistence-mode" {
+			return true
+		}
+	}
+	return false
+}
+
+// Starts the "nvidia-persistenced" systemd service.
+// Equivalent to "systemctl start nvidia-persistenced".
+func StartPersistenced(ctx context.Context) error {
+	p, err := exec.LookPath("systemctl")
+	if err != nil {
+		return fmt.Errorf("starting nvidia-persistenced requires systemctl (%w)", err)
+	}
+	b, err := exec.CommandContext(ctx, p, "start", "nvidia-persistenced").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to start nvidia-persistenced: %w (%s)", err, string(b))
+	}
+	return nil
+}
+
+// Returns the latest nvidia-persistenced output using journalctl.
+// Equivalent to "journalctl -xeu nvidia-persistenced.service --no-pager".
+func GetLatestPersistencedOutput(ctx context.Context) (string, error) {
+	return systemd.GetLatestJournalctlOutput(ctx, "nvidia-persistenced")
+}
@@ -0,0 +1,19 @@
+package query
+
+import "testing"
+
+func TestHasNoPersistenceModeFlag(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		want    bool
+	}{
+		{cmdline: "/usr/bin/nvidia-persistenced\x00--user\x00nvidia-persistenced\x00", want: false},
+		{cmdline: "/usr/bin/nvidia-persistenced\x00--user\x00nvidia-persistenced\x00--no-persistence-mode\x00--verbose\x00", want: true},
+		{cmdline: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := hasNoPersistenceModeFlag([]byte(tt.cmdline)); got != tt.want {
+			t.Errorf("hasNoPersistenceModeFlag(%q) = %v, want %v", tt.cmdline, got, tt.want)
+		}
+	}
+}
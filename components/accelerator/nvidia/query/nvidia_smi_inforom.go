@@ -0,0 +1,50 @@
+package query
+
+import (
+	"strings"
+)
+
+// infoROMCorruptedMarker is the warning "nvidia-smi" prints for each GPU with the corrupted InfoROM.
+// e.g.,
+// WARNING: infoROM is corrupted at gpu 0000:05:00.0
+const infoROMCorruptedMarker = "infoROM is corrupted at gpu"
+
+// FindInfoROMCorruptions returns the PCI bus IDs of the GPUs
+// reported with the corrupted InfoROM in the "nvidia-smi" output,
+// in the order of appearance without duplicates.
+func FindInfoROMCorruptions(s string) []string {
+	var ids []string
+	seen := make(map[string]struct{})
+	for _, line := range strings.Split(s, "\n") {
+		idx := strings.Index(line, infoROMCorruptedMarker)
+		if idx < 0 {
+			continue
+		}
+		id := strings.TrimSpace(line[idx+len(infoROMCorruptedMarker):])
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// NormalizeBusID returns the lower-cased PCI bus ID with the 4-digit domain,
+// since "nvidia-smi" and NVML print the domain with different widths
+// (e.g., "0000:05:00.0" and "00000000:05:00.0").
+func NormalizeBusID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 {
+		return id
+	}
+	domain := strings.TrimLeft(parts[0], "0")
+	if len(domain) < 4 {
+		domain = strings.Repeat("0", 4-len(domain)) + domain
+	}
+	return domain + ":" + parts[1]
+}
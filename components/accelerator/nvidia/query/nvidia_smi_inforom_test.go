@@ -0,0 +1,42 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindInfoROMCorruptions(t *testing.T) {
+	out := `WARNING: infoROM is corrupted at gpu 0000:05:00.0
+WARNING: infoROM is corrupted at gpu 0000:0B:00.0
+WARNING: infoROM is corrupted at gpu 0000:05:00.0
+Mon Oct 14 10:00:00 2024
++-----------------------------------------------------------------------------------------+
+| NVIDIA-SMI 550.90.07              Driver Version: 550.90.07      CUDA Version: 12.4     |
+`
+	got := FindInfoROMCorruptions(out)
+	want := []string{"0000:05:00.0", "0000:0B:00.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindInfoROMCorruptions() = %v, want %v", got, want)
+	}
+
+	if got := FindInfoROMCorruptions("| NVIDIA-SMI 550.90.07 |"); got != nil {
+		t.Errorf("expected no corruption, got %v", got)
+	}
+}
+
+func TestNormalizeBusID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"0000:0B:00.0", "0000:0b:00.0"},
+		{"00000000:0B:00.0", "0000:0b:00.0"},
+		{"00000001:0b:00.0", "0001:0b:00.0"},
+		{"invalid", "invalid"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeBusID(tt.in); got != tt.want {
+			t.Errorf("NormalizeBusID(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
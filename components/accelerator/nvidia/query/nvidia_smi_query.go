@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/leptonai/gpud/pkg/execlimit"
 	"github.com/leptonai/gpud/pkg/file"
 
 	"sigs.k8s.io/yaml"
@@ -31,6 +32,9 @@ func RunSMI(ctx context.Context, args ...string) ([]byte, error) {
 		return nil, fmt.Errorf("nvidia-smi not found (%w)", err)
 	}
 
+	if err := execlimit.Wait(ctx, "nvidia-smi"); err != nil {
+		return nil, err
+	}
 	cmd := exec.CommandContext(ctx, p, args...)
 
 	// in case of driver issue, the nvidia-smi is stuck in "state:D" -- uninterruptible sleep state
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/leptonai/gpud/pkg/locale"
+
 	"github.com/dustin/go-humanize"
 )
 
@@ -16,6 +18,9 @@ type NvidiaSMIGPU struct {
 	// e.g., "GPU 00000000:53:00.0"
 	ID string `json:"ID"`
 
+	// UUID is the GPU UUID (e.g., "GPU-313bbff0-b0a0-fd26-4820-0578bdef3a12").
+	UUID string `json:"GPU UUID"`
+
 	ProductName         string `json:"Product Name"`
 	ProductBrand        string `json:"Product Brand"`
 	ProductArchitecture string `json:"Product Architecture"`
@@ -761,42 +766,38 @@ func (f *SMIFBMemoryUsage) Parse() (ParsedMemoryUsage, error) {
 	u := ParsedMemoryUsage{}
 	u.ID = f.ID
 
-	u.TotalHumanized = f.Total
 	b, err := humanize.ParseBytes(f.Total)
 	if err != nil {
 		return ParsedMemoryUsage{}, err
 	}
 	u.TotalBytes = b
-	u.TotalHumanized = humanize.Bytes(u.TotalBytes)
+	u.TotalHumanized = locale.Bytes(u.TotalBytes)
 
-	u.ReservedHumanized = f.Reserved
 	b, err = humanize.ParseBytes(f.Reserved)
 	if err != nil {
 		return ParsedMemoryUsage{}, err
 	}
 	u.ReservedBytes = b
-	u.ReservedHumanized = humanize.Bytes(u.ReservedBytes)
+	u.ReservedHumanized = locale.Bytes(u.ReservedBytes)
 
-	u.UsedHumanized = f.Used
 	b, err = humanize.ParseBytes(f.Used)
 	if err != nil {
 		return ParsedMemoryUsage{}, err
 	}
 	u.UsedBytes = b
-	u.UsedHumanized = humanize.Bytes(u.UsedBytes)
+	u.UsedHumanized = locale.Bytes(u.UsedBytes)
 
 	u.UsedPercent = "0.0"
 	if u.TotalBytes > 0 {
 		u.UsedPercent = fmt.Sprintf("%0.2f", float64(u.UsedBytes)/float64(u.TotalBytes)*100)
 	}
 
-	u.FreeHumanized = f.Free
 	b, err = humanize.ParseBytes(f.Free)
 	if err != nil {
 		return ParsedMemoryUsage{}, err
 	}
 	u.FreeBytes = b
-	u.FreeHumanized = humanize.Bytes(u.FreeBytes)
+	u.FreeHumanized = locale.Bytes(u.FreeBytes)
 
 	return u, nil
 }
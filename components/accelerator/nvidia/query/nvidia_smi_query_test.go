@@ -104,6 +104,9 @@ func TestParseWithProcesses(t *testing.T) {
 	if parsed.GPUs[0].ID != "GPU 00000000:01:00.0" {
 		t.Errorf("GPU0.ID mismatch: %+v", parsed.GPUs[0].ID)
 	}
+	if parsed.GPUs[0].UUID != "GPU-313bbff0-b0a0-fd26-4820-0578bdef3a12" {
+		t.Errorf("GPU0.UUID mismatch: %+v", parsed.GPUs[0].UUID)
+	}
 	if parsed.GPUs[0].ClockEventReasons.HWThermalSlowdown != ClockEventsNotActive {
 		t.Errorf("HWThermalSlowdown mismatch: %+v", parsed.GPUs[0].ClockEventReasons.HWThermalSlowdown)
 	}
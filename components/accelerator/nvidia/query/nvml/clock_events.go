@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/leptonai/gpud/log"
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
 
 	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
@@ -15,11 +15,10 @@ import (
 // Returns false if any device does not support clock events.
 // ref. undefined symbol: nvmlDeviceGetCurrentClocksEventReasons for older nvidia drivers
 func ClockEventsSupported() (bool, error) {
-	nvmlLib := nvml.New()
-	if ret := nvmlLib.Init(); ret != nvml.SUCCESS {
-		return false, fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return false, err
 	}
-	log.Logger.Debugw("successfully initialized NVML")
 
 	deviceLib := device.New(nvmlLib)
 	devices, err := deviceLib.GetDevices()
@@ -75,6 +74,8 @@ type ClockEvents struct {
 	HWSlowdownThermal bool `json:"hw_thermal_slowdown"`
 	// Set true if the HW Power Brake Slowdown reason due to the external power brake assertion is active.
 	HWSlowdownPowerBrake bool `json:"hw_slowdown_power_brake"`
+	// Set true if the SW Thermal Slowdown reason to keep the GPU and memory temperatures within the operating limits is active.
+	SWThermalSlowdown bool `json:"sw_thermal_slowdown"`
 }
 
 func (evs *ClockEvents) JSON() ([]byte, error) {
@@ -117,13 +118,30 @@ func GetClockEvents(uuid string, dev device.Device) (ClockEvents, error) {
 	clockEvents.HWSlowdown = reasons&reasonHWSlowdown != 0
 	clockEvents.HWSlowdownThermal = reasons&reasonHWSlowdownThermal != 0
 	clockEvents.HWSlowdownPowerBrake = reasons&reasonHWSlowdownPowerBrake != 0
+	clockEvents.SWThermalSlowdown = reasons&reasonSWThermalSlowdown != 0
 
 	return clockEvents, nil
 }
 
+// ClockEventReasonDescriptions returns the human-readable reasons of the clock events bitmask,
+// in the ascending order of the reason flags.
+func ClockEventReasonDescriptions(bitmask uint64) []string {
+	var descs []string
+	for flag := uint64(1); flag != 0 && flag <= bitmask; flag <<= 1 {
+		if bitmask&flag == 0 {
+			continue
+		}
+		if description, ok := clockEventReasons[flag]; ok {
+			descs = append(descs, description)
+		}
+	}
+	return descs
+}
+
 // 0x0000000000000000 is none
 const (
 	reasonHWSlowdown           uint64 = 0x0000000000000008
+	reasonSWThermalSlowdown    uint64 = 0x0000000000000020
 	reasonHWSlowdownThermal    uint64 = 0x0000000000000040
 	reasonHWSlowdownPowerBrake uint64 = 0x0000000000000080
 )
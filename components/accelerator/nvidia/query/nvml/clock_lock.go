@@ -0,0 +1,86 @@
+package nvml
+
+import (
+	"fmt"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// LockedClocks represents the locked GPU graphics clock range in MHz.
+// Equivalent to "nvidia-smi --lock-gpu-clocks=<min>,<max>".
+type LockedClocks struct {
+	GraphicsMinMHz uint32 `json:"graphics_min_mhz"`
+	GraphicsMaxMHz uint32 `json:"graphics_max_mhz"`
+}
+
+// Drifted returns true if the current graphics clock exceeds the locked max clock
+// with the tolerance in MHz, which only happens when the locked clocks are no longer applied
+// (e.g., reset by "nvidia-smi --reset-gpu-clocks").
+// The clock below the locked min clock is not a drift, since the locked clocks
+// do not prevent the idle GPU or the throttling (e.g., power or thermal) from lowering the clock.
+func (l LockedClocks) Drifted(cur ClockSpeed, toleranceMHz uint32) bool {
+	return cur.GraphicsMHz > l.GraphicsMaxMHz+toleranceMHz
+}
+
+// LockGPUClocks locks the graphics clocks of the GPU to the range.
+// If the uuid is empty, it locks the clocks of all the GPUs.
+// Requires root privileges.
+// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceCommands.html
+func LockGPUClocks(uuid string, locked LockedClocks) error {
+	if locked.GraphicsMinMHz > locked.GraphicsMaxMHz {
+		return fmt.Errorf("invalid locked clocks: min %d MHz > max %d MHz", locked.GraphicsMinMHz, locked.GraphicsMaxMHz)
+	}
+	return forEachDevice(uuid, func(dev nvml.Device) error {
+		if ret := dev.SetGpuLockedClocks(locked.GraphicsMinMHz, locked.GraphicsMaxMHz); ret != nvml.SUCCESS {
+			return fmt.Errorf("failed to lock gpu clocks: %v", nvml.ErrorString(ret))
+		}
+		return nil
+	})
+}
+
+// ResetGPULockedClocks resets the locked graphics clocks of the GPU to the default.
+// If the uuid is empty, it resets the clocks of all the GPUs.
+// Equivalent to "nvidia-smi --reset-gpu-clocks".
+// Requires root privileges.
+func ResetGPULockedClocks(uuid string) error {
+	return forEachDevice(uuid, func(dev nvml.Device) error {
+		if ret := dev.ResetGpuLockedClocks(); ret != nvml.SUCCESS {
+			return fmt.Errorf("failed to reset gpu locked clocks: %v", nvml.ErrorString(ret))
+		}
+		return nil
+	})
+}
+
+// forEachDevice runs the function on the GPU with the uuid,
+// or all the GPUs if the uuid is empty.
+func forEachDevice(uuid string, f func(dev nvml.Device) error) error {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return err
+	}
+
+	if uuid != "" {
+		dev, ret := nvmlLib.DeviceGetHandleByUUID(uuid)
+		if ret != nvml.SUCCESS {
+			return fmt.Errorf("failed to get device %q: %v", uuid, nvml.ErrorString(ret))
+		}
+		return f(dev)
+	}
+
+	count, ret := nvmlLib.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return fmt.Errorf("failed to get device count: %v", nvml.ErrorString(ret))
+	}
+	for i := 0; i < count; i++ {
+		dev, ret := nvmlLib.DeviceGetHandleByIndex(i)
+		if ret != nvml.SUCCESS {
+			return fmt.Errorf("failed to get device %d: %v", i, nvml.ErrorString(ret))
+		}
+		if err := f(dev); err != nil {
+			return fmt.Errorf("device %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package nvml
+
+import "testing"
+
+func TestLockedClocksDrifted(t *testing.T) {
+	locked := LockedClocks{GraphicsMinMHz: 1400, GraphicsMaxMHz: 1600}
+	tests := []struct {
+		name        string
+		graphicsMHz uint32
+		tolerance   uint32
+		want        bool
+	}{
+		{name: "within range", graphicsMHz: 1500, tolerance: 15, want: false},
+		{name: "at max", graphicsMHz: 1600, tolerance: 15, want: false},
+		{name: "above max within tolerance", graphicsMHz: 1615, tolerance: 15, want: false},
+		{name: "above max outside tolerance", graphicsMHz: 1616, tolerance: 15, want: true},
+		{name: "above max without tolerance", graphicsMHz: 1601, tolerance: 0, want: true},
+		{name: "boost clock after reset", graphicsMHz: 1980, tolerance: 15, want: true},
+		{name: "throttled below min", graphicsMHz: 1100, tolerance: 15, want: false},
+		{name: "idle", graphicsMHz: 345, tolerance: 15, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := locked.Drifted(ClockSpeed{UUID: "GPU-0", GraphicsMHz: tt.graphicsMHz}, tt.tolerance); got != tt.want {
+				t.Errorf("Drifted(%d MHz, %d) = %v, want %v", tt.graphicsMHz, tt.tolerance, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockGPUClocksInvalidRange(t *testing.T) {
+	// rejected before loading NVML
+	if err := LockGPUClocks("", LockedClocks{GraphicsMinMHz: 1600, GraphicsMaxMHz: 1400}); err == nil {
+		t.Fatal("expected error for min > max")
+	}
+}
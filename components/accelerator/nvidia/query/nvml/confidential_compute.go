@@ -0,0 +1,94 @@
+package nvml
+
+import (
+	"fmt"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+const (
+	ConfidentialComputeModeOn       = "on"
+	ConfidentialComputeModeOff      = "off"
+	ConfidentialComputeModeDevTools = "devtools"
+)
+
+// ConfidentialComputeState is the system-wide confidential computing (CC) state.
+// CC mode is supported on Hopper and later GPUs, with the CPU TEE (AMD SEV-SNP or Intel TDX).
+// ref. https://docs.nvidia.com/confidential-computing/index.html
+type ConfidentialComputeState struct {
+	// CPUCapability is the CPU TEE capability ("none", "amd-sev", or "intel-tdx").
+	CPUCapability string `json:"cpu_capability"`
+	// GPUsCapable is set true if all the GPUs are capable of confidential computing.
+	GPUsCapable bool `json:"gpus_capable"`
+
+	// Mode is the current CC mode ("on", "off", or "devtools").
+	Mode string `json:"mode"`
+	// Environment is the CC environment ("prod", "sim", or "unavailable").
+	Environment string `json:"environment"`
+
+	// ReadyForWork is set true if the GPUs are accepting the client requests,
+	// which is set after the GPU attestation succeeds in the CC mode.
+	ReadyForWork bool `json:"ready_for_work"`
+}
+
+// GetConfidentialComputeState returns the system-wide confidential computing state.
+func GetConfidentialComputeState() (ConfidentialComputeState, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return ConfidentialComputeState{}, err
+	}
+
+	st := ConfidentialComputeState{
+		CPUCapability: "none",
+		Mode:          ConfidentialComputeModeOff,
+		Environment:   "unavailable",
+	}
+
+	caps, ret := nvmlLib.SystemGetConfComputeCapabilities()
+	if ret == nvml.ERROR_NOT_SUPPORTED {
+		return st, nil
+	}
+	if ret != nvml.SUCCESS {
+		return ConfidentialComputeState{}, fmt.Errorf("failed to get confidential compute capabilities: %v", nvml.ErrorString(ret))
+	}
+	switch caps.CpuCaps {
+	case nvml.CC_SYSTEM_CPU_CAPS_AMD_SEV:
+		st.CPUCapability = "amd-sev"
+	case nvml.CC_SYSTEM_CPU_CAPS_INTEL_TDX:
+		st.CPUCapability = "intel-tdx"
+	}
+	st.GPUsCapable = caps.GpusCaps == nvml.CC_SYSTEM_GPUS_CC_CAPABLE
+
+	// not in the library interface, but NVML is initialized process-wide by the shared session
+	state, ret := nvml.SystemGetConfComputeState()
+	if ret == nvml.ERROR_NOT_SUPPORTED {
+		return st, nil
+	}
+	if ret != nvml.SUCCESS {
+		return ConfidentialComputeState{}, fmt.Errorf("failed to get confidential compute state: %v", nvml.ErrorString(ret))
+	}
+	if state.CcFeature == nvml.CC_SYSTEM_FEATURE_ENABLED {
+		st.Mode = ConfidentialComputeModeOn
+		if state.DevToolsMode == nvml.CC_SYSTEM_DEVTOOLS_MODE_ON {
+			st.Mode = ConfidentialComputeModeDevTools
+		}
+	}
+	switch state.Environment {
+	case nvml.CC_SYSTEM_ENVIRONMENT_PROD:
+		st.Environment = "prod"
+	case nvml.CC_SYSTEM_ENVIRONMENT_SIM:
+		st.Environment = "sim"
+	}
+
+	if st.Mode != ConfidentialComputeModeOff {
+		ready, ret := nvml.SystemGetConfComputeGpusReadyState()
+		if ret != nvml.SUCCESS {
+			return ConfidentialComputeState{}, fmt.Errorf("failed to get confidential compute gpus ready state: %v", nvml.ErrorString(ret))
+		}
+		st.ReadyForWork = ready == nvml.CC_ACCEPTING_CLIENT_REQUESTS_TRUE
+	}
+
+	return st, nil
+}
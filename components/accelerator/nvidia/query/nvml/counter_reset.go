@@ -0,0 +1,134 @@
+package nvml
+
+import (
+	"fmt"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+const (
+	// CounterTypeECC is the volatile ECC error counters.
+	CounterTypeECC = "ecc"
+	// CounterTypeNVLink is the NVLink error counters.
+	CounterTypeNVLink = "nvlink"
+)
+
+// CounterSnapshot is the counter values of a GPU right before the reset.
+type CounterSnapshot struct {
+	// Represents the GPU UUID.
+	UUID string `json:"uuid"`
+
+	// Set if the counter type is "ecc".
+	ECCErrors *ECCErrors `json:"ecc_errors,omitempty"`
+	// Set if the counter type is "nvlink".
+	NVLink *NVLink `json:"nvlink,omitempty"`
+
+	// Number of the running compute processes on the GPU at the time of the reset.
+	RunningProcesses int `json:"running_processes"`
+}
+
+// ResetCounters snapshots and then resets the error counters of the given type
+// on the GPU with the uuid, or all the GPUs if the uuid is empty.
+// The ECC reset only clears the volatile counters (aggregate counters persist).
+// Equivalent to "nvidia-smi -p 0" (ECC) or "nvidia-smi nvlink -r" (NVLink).
+// Requires root privileges.
+//
+// If allowRunningProcesses is false, it refuses to reset any counter
+// when a compute process is running on any of the target GPUs.
+//
+// Returns the pre-reset snapshots of the GPUs whose counters were reset,
+// which can be non-empty even if the error is non-nil.
+func ResetCounters(uuid string, counterType string, allowRunningProcesses bool) ([]CounterSnapshot, error) {
+	if counterType != CounterTypeECC && counterType != CounterTypeNVLink {
+		return nil, fmt.Errorf("unknown counter type %q (must be %q or %q)", counterType, CounterTypeECC, CounterTypeNVLink)
+	}
+
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := device.New(nvmlLib).GetDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	type target struct {
+		uuid string
+		dev  device.Device
+	}
+	targets := make([]target, 0, len(devices))
+	for _, dev := range devices {
+		devUUID, ret := dev.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device uuid: %v", nvml.ErrorString(ret))
+		}
+		if uuid != "" && devUUID != uuid {
+			continue
+		}
+		targets = append(targets, target{uuid: devUUID, dev: dev})
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no gpu found with uuid %q", uuid)
+	}
+
+	snapshots := make([]CounterSnapshot, 0, len(targets))
+	for _, t := range targets {
+		procs, ret := t.dev.GetComputeRunningProcesses()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("%s: failed to get device compute processes: %v", t.uuid, nvml.ErrorString(ret))
+		}
+		if len(procs) > 0 && !allowRunningProcesses {
+			return nil, fmt.Errorf("%s: %d compute process(es) running -- refusing to reset counters", t.uuid, len(procs))
+		}
+
+		snapshot := CounterSnapshot{UUID: t.uuid, RunningProcesses: len(procs)}
+		switch counterType {
+		case CounterTypeECC:
+			eccMode, err := GetECCModeEnabled(t.uuid, t.dev)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", t.uuid, err)
+			}
+			eccErrors, err := GetECCErrors(t.uuid, t.dev, eccMode.EnabledCurrent)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", t.uuid, err)
+			}
+			snapshot.ECCErrors = &eccErrors
+
+		case CounterTypeNVLink:
+			nvlink, err := GetNVLink(t.uuid, t.dev)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", t.uuid, err)
+			}
+			snapshot.NVLink = &nvlink
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	reset := make([]CounterSnapshot, 0, len(snapshots))
+	for i, t := range targets {
+		switch counterType {
+		case CounterTypeECC:
+			// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceCommands.html
+			if ret := t.dev.ClearEccErrorCounts(nvml.VOLATILE_ECC); ret != nvml.SUCCESS {
+				return reset, fmt.Errorf("%s: failed to clear volatile ecc error counts: %v", t.uuid, nvml.ErrorString(ret))
+			}
+
+		case CounterTypeNVLink:
+			// ref. https://docs.nvidia.com/deploy/nvml-api/group__NvLink.html
+			for _, state := range snapshots[i].NVLink.States {
+				if !state.FeatureEnabled {
+					continue
+				}
+				if ret := t.dev.ResetNvLinkErrorCounters(state.Link); ret != nvml.SUCCESS {
+					return reset, fmt.Errorf("%s: failed to reset nvlink %d error counters: %v", t.uuid, state.Link, nvml.ErrorString(ret))
+				}
+			}
+		}
+		reset = append(reset, snapshots[i])
+	}
+	return reset, nil
+}
@@ -35,3 +35,22 @@ type ECCMode struct {
 	// "pending" ECC mode refers to the target mode following the next reboot.
 	EnabledPending bool `json:"enabled_pending"`
 }
+
+// SetECCMode sets the pending ECC mode of the GPU.
+// If the uuid is empty, it sets the ECC mode of all the GPUs.
+// The new mode takes effect after the next reboot (or GPU reset).
+// Equivalent to "nvidia-smi -e 1" (or "nvidia-smi -e 0").
+// Requires root privileges.
+// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceCommands.html
+func SetECCMode(uuid string, enabled bool) error {
+	mode := nvml.FEATURE_DISABLED
+	if enabled {
+		mode = nvml.FEATURE_ENABLED
+	}
+	return forEachDevice(uuid, func(dev nvml.Device) error {
+		if ret := dev.SetEccMode(mode); ret != nvml.SUCCESS {
+			return fmt.Errorf("failed to set ecc mode: %v", nvml.ErrorString(ret))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,156 @@
+package nvml
+
+import (
+	"fmt"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// FeatureMatrix is the compute capability and the supported features of all the GPUs,
+// so that the job schedulers can match the workloads to the nodes without probing the GPUs.
+type FeatureMatrix struct {
+	DriverVersion     string `json:"driver_version"`
+	CUDADriverVersion string `json:"cuda_driver_version"`
+
+	DriverCapabilities DriverCapabilities `json:"driver_capabilities"`
+
+	GPUs []GPUFeatures `json:"gpus"`
+}
+
+// DriverCapabilities is the set of the driver capability bits.
+type DriverCapabilities struct {
+	// Set true if the driver supports the clock events (>= 535).
+	ClockEvents bool `json:"clock_events"`
+	// Set true if the system (CPU and GPUs) is capable of confidential computing.
+	ConfidentialCompute bool `json:"confidential_compute"`
+}
+
+// GPUFeatures is the compute capability and the supported features of a GPU.
+type GPUFeatures struct {
+	UUID         string `json:"uuid"`
+	Name         string `json:"name"`
+	Architecture string `json:"architecture"`
+	// ComputeCapability is the CUDA compute capability (e.g., "9.0" for Hopper).
+	ComputeCapability string `json:"compute_capability"`
+
+	Features Features `json:"features"`
+
+	// Set true if the MIG mode is currently enabled.
+	MIGEnabled bool `json:"mig_enabled"`
+}
+
+// Features is the set of the GPU features.
+type Features struct {
+	TF32 bool `json:"tf32"`
+	BF16 bool `json:"bf16"`
+	FP8  bool `json:"fp8"`
+
+	MIG bool `json:"mig"`
+	GPM bool `json:"gpm"`
+	// NVLinkC2C is set true if the chip-to-chip NVLink interconnect is enabled (e.g., Grace Hopper).
+	NVLinkC2C bool `json:"nvlink_c2c"`
+	// ConfidentialCompute is set true if the GPU supports the confidential computing mode (Hopper+).
+	ConfidentialCompute bool `json:"confidential_compute"`
+}
+
+// ComputeCapabilityFeatures returns the numeric precision features supported by the compute capability.
+// ref. https://docs.nvidia.com/cuda/cuda-c-programming-guide/index.html#compute-capabilities
+func ComputeCapabilityFeatures(major int, minor int) Features {
+	return Features{
+		// Ampere+
+		TF32: major >= 8,
+		BF16: major >= 8,
+		// Ada (8.9) and Hopper+
+		FP8: major > 8 || (major == 8 && minor >= 9),
+		// Hopper+
+		ConfidentialCompute: major >= 9,
+	}
+}
+
+// GetFeatureMatrix returns the compute capability and the supported features of all the GPUs.
+func GetFeatureMatrix() (*FeatureMatrix, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &FeatureMatrix{}
+
+	ver, ret := nvmlLib.SystemGetDriverVersion()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get driver version: %v", nvml.ErrorString(ret))
+	}
+	m.DriverVersion = ver
+	if major, _, _, err := ParseDriverVersion(ver); err == nil {
+		m.DriverCapabilities.ClockEvents = ClockEventsSupportedVersion(major)
+	}
+
+	if cudaVer, ret := nvmlLib.SystemGetCudaDriverVersion(); ret == nvml.SUCCESS {
+		m.CUDADriverVersion = formatCUDADriverVersion(cudaVer)
+	}
+
+	// not supported in older drivers or non-CC systems
+	if caps, ret := nvmlLib.SystemGetConfComputeCapabilities(); ret == nvml.SUCCESS {
+		m.DriverCapabilities.ConfidentialCompute = caps.CpuCaps != nvml.CC_SYSTEM_CPU_CAPS_NONE && caps.GpusCaps == nvml.CC_SYSTEM_GPUS_CC_CAPABLE
+	}
+
+	devices, err := device.New(nvmlLib).GetDevices()
+	if err != nil {
+		return nil, err
+	}
+	for _, dev := range devices {
+		f, err := getGPUFeatures(dev)
+		if err != nil {
+			return nil, err
+		}
+		m.GPUs = append(m.GPUs, f)
+	}
+	return m, nil
+}
+
+func getGPUFeatures(dev device.Device) (GPUFeatures, error) {
+	uuid, ret := dev.GetUUID()
+	if ret != nvml.SUCCESS {
+		return GPUFeatures{}, fmt.Errorf("failed to get device uuid: %v", nvml.ErrorString(ret))
+	}
+	f := GPUFeatures{UUID: uuid}
+
+	name, ret := dev.GetName()
+	if ret != nvml.SUCCESS {
+		return GPUFeatures{}, fmt.Errorf("failed to get device name: %v", nvml.ErrorString(ret))
+	}
+	f.Name = name
+
+	arch, err := dev.GetArchitectureAsString()
+	if err != nil {
+		return GPUFeatures{}, err
+	}
+	f.Architecture = arch
+
+	major, minor, ret := dev.GetCudaComputeCapability()
+	if ret != nvml.SUCCESS {
+		return GPUFeatures{}, fmt.Errorf("failed to get cuda compute capability: %v", nvml.ErrorString(ret))
+	}
+	f.ComputeCapability = fmt.Sprintf("%d.%d", major, minor)
+	f.Features = ComputeCapabilityFeatures(major, minor)
+
+	if migCapable, err := dev.IsMigCapable(); err == nil {
+		f.Features.MIG = migCapable
+	}
+	if migEnabled, err := dev.IsMigEnabled(); err == nil {
+		f.MIGEnabled = migEnabled
+	}
+	if gpm, err := GPMSupportedByDevice(dev); err == nil {
+		f.Features.GPM = gpm
+	}
+
+	// not supported on the GPUs without the C2C interconnect
+	if c2c, ret := dev.GetC2cModeInfoV().V1(); ret == nvml.SUCCESS {
+		f.Features.NVLinkC2C = c2c.IsC2cEnabled != 0
+	}
+
+	return f, nil
+}
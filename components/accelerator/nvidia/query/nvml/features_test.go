@@ -0,0 +1,44 @@
+package nvml
+
+import "testing"
+
+func TestComputeCapabilityFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		major    int
+		minor    int
+		expected Features
+	}{
+		{
+			name:     "Volta",
+			major:    7,
+			minor:    0,
+			expected: Features{},
+		},
+		{
+			name:     "Ampere",
+			major:    8,
+			minor:    0,
+			expected: Features{TF32: true, BF16: true},
+		},
+		{
+			name:     "Ada",
+			major:    8,
+			minor:    9,
+			expected: Features{TF32: true, BF16: true, FP8: true},
+		},
+		{
+			name:     "Hopper",
+			major:    9,
+			minor:    0,
+			expected: Features{TF32: true, BF16: true, FP8: true, ConfidentialCompute: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeCapabilityFeatures(tt.major, tt.minor); got != tt.expected {
+				t.Errorf("ComputeCapabilityFeatures(%d, %d) = %+v, want %+v", tt.major, tt.minor, got, tt.expected)
+			}
+		})
+	}
+}
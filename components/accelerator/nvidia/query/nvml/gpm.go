@@ -8,6 +8,7 @@ import (
 
 	metrics_gpm "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/gpm"
 	"github.com/leptonai/gpud/log"
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
 
 	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
@@ -18,11 +19,10 @@ import (
 // Returns true if GPM is supported by all devices.
 // Returns false if any device does not support GPM.
 func GPMSupported() (bool, error) {
-	nvmlLib := nvml.New()
-	if ret := nvmlLib.Init(); ret != nvml.SUCCESS {
-		return false, fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return false, err
 	}
-	log.Logger.Debugw("successfully initialized NVML")
 
 	deviceLib := device.New(nvmlLib)
 	devices, err := deviceLib.GetDevices()
@@ -70,14 +70,15 @@ func (inst *instance) RecvGPMEvents() <-chan *GPMEvent {
 	inst.mu.RLock()
 	defer inst.mu.RUnlock()
 
-	if inst.nvmlLib == nil {
+	// the channel is kept across the re-initializations
+	if inst.rootCtx.Err() != nil {
 		return nil
 	}
 
 	return inst.gpmEventCh
 }
 
-func (inst *instance) pollGPMEvents() {
+func (inst *instance) pollGPMEvents(ctx context.Context, devices []*DeviceInfo) {
 	log.Logger.Debugw("polling gpm metrics events")
 
 	ticker := time.NewTicker(1)
@@ -85,15 +86,15 @@ func (inst *instance) pollGPMEvents() {
 
 	for {
 		select {
-		case <-inst.rootCtx.Done():
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
 			ticker.Reset(inst.gpmPollInterval)
 		}
 
-		mss, err := inst.collectGPMMetrics()
+		mss, err := inst.collectGPMMetrics(ctx, devices)
 		select {
-		case <-inst.rootCtx.Done():
+		case <-ctx.Done():
 			return
 		case inst.gpmEventCh <- &GPMEvent{
 			Metrics: mss,
@@ -122,7 +123,7 @@ type GPMMetrics struct {
 
 // Collects the GPM metrics for all the devices and returns the map from the device UUID to the metrics.
 // Blocks for the duration of the sample interval.
-func (inst *instance) collectGPMMetrics() ([]GPMMetrics, error) {
+func (inst *instance) collectGPMMetrics(ctx context.Context, devices []*DeviceInfo) ([]GPMMetrics, error) {
 	if inst.gpmPollInterval == 0 {
 		return nil, errors.New("gpm sample interval is not set")
 	}
@@ -132,19 +133,19 @@ func (inst *instance) collectGPMMetrics() ([]GPMMetrics, error) {
 	if len(inst.gpmMetricsIDs) > 98 {
 		return nil, fmt.Errorf("too many metric IDs provided (%d > 98)", len(inst.gpmMetricsIDs))
 	}
-	for uuid, dev := range inst.devices {
+	for _, dev := range devices {
 		supported, err := GPMSupportedByDevice(dev.device)
 		if err != nil {
 			return nil, err
 		}
 		if !supported {
-			return nil, fmt.Errorf("device %s is not supported by GPM", uuid)
+			return nil, fmt.Errorf("device %s is not supported by GPM", dev.UUID)
 		}
 	}
 
-	metrics := make([]GPMMetrics, 0, len(inst.devices))
-	for _, dev := range inst.devices {
-		ms, err := GetGPMMetrics(inst.rootCtx, dev.device, inst.gpmMetricsIDs...)
+	metrics := make([]GPMMetrics, 0, len(devices))
+	for _, dev := range devices {
+		ms, err := GetGPMMetrics(ctx, dev.device, inst.gpmMetricsIDs...)
 		if err != nil {
 			return nil, fmt.Errorf("device %q failed to get gpm metrics: %w", dev.UUID, err)
 		}
@@ -163,7 +164,7 @@ func (inst *instance) collectGPMMetrics() ([]GPMMetrics, error) {
 
 		gpuID := m.UUID
 		for gpmMetricsID, v := range m.Metrics {
-			if err := metrics_gpm.SetGPUUtilPercent(inst.rootCtx, gpmMetricsID, gpuID, v, now); err != nil {
+			if err := metrics_gpm.SetGPUUtilPercent(ctx, gpmMetricsID, gpuID, v, now); err != nil {
 				return nil, fmt.Errorf("failed to set gpm metric %v for gpu %s: %w", gpmMetricsID, gpuID, err)
 			}
 		}
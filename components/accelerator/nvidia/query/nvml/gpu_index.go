@@ -0,0 +1,38 @@
+package nvml
+
+import (
+	"fmt"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// GetGPUIndexes returns the NVML device index by the GPU UUID,
+// used to target a GPU with the tools that take the index (e.g., "dcgmi diag -i").
+func GetGPUIndexes() (map[string]int, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := device.New(nvmlLib).GetDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string]int, len(devices))
+	for _, dev := range devices {
+		uuid, ret := dev.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device uuid: %v", nvml.ErrorString(ret))
+		}
+		idx, ret := dev.GetIndex()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("%s: failed to get device index: %v", uuid, nvml.ErrorString(ret))
+		}
+		indexes[uuid] = idx
+	}
+	return indexes, nil
+}
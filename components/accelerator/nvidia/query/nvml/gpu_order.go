@@ -0,0 +1,100 @@
+package nvml
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// DefaultProcDriverGPUsDir is the directory with the per-GPU driver information files,
+// named by the PCI bus ID (e.g., "/proc/driver/nvidia/gpus/0000:18:00.0/information").
+const DefaultProcDriverGPUsDir = "/proc/driver/nvidia/gpus"
+
+// GPUOrder is the enumeration order of a GPU, as seen by NVML and the driver.
+type GPUOrder struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+	// Index is the NVML device index (also used by nvidia-smi).
+	Index int `json:"index"`
+	// BusID is the PCI bus ID of the GPU (e.g., "0000:18:00.0").
+	BusID string `json:"bus_id"`
+	// MinorNumber is the device minor number from the driver (e.g., 0 for "/dev/nvidia0"),
+	// or -1 if not available.
+	MinorNumber int `json:"minor_number"`
+}
+
+// GetGPUOrders returns the enumeration orders of all the GPUs in the NVML index order.
+// The minor numbers are read from the driver information files in the given directory,
+// since NVML may return the same minor number for all the GPUs.
+func GetGPUOrders(procDriverGPUsDir string) ([]GPUOrder, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := device.New(nvmlLib).GetDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]GPUOrder, 0, len(devices))
+	for _, dev := range devices {
+		uuid, ret := dev.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device uuid: %v", nvml.ErrorString(ret))
+		}
+		o := GPUOrder{UUID: uuid, MinorNumber: -1}
+
+		o.Name, ret = dev.GetName()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("%s: failed to get device name: %v", uuid, nvml.ErrorString(ret))
+		}
+		o.Index, ret = dev.GetIndex()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("%s: failed to get device index: %v", uuid, nvml.ErrorString(ret))
+		}
+		o.BusID, err = dev.GetPCIBusID()
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to get pci bus id: %w", uuid, err)
+		}
+		o.BusID = strings.ToLower(o.BusID)
+
+		if minor, err := ReadDeviceMinor(procDriverGPUsDir, o.BusID); err == nil {
+			o.MinorNumber = minor
+		}
+
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
+
+// ReadDeviceMinor reads the "Device Minor" of the GPU with the given PCI bus ID
+// from the driver information file.
+func ReadDeviceMinor(procDriverGPUsDir string, busID string) (int, error) {
+	f, err := os.Open(filepath.Join(procDriverGPUsDir, busID, "information"))
+	if err != nil {
+		return -1, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(k) != "Device Minor" {
+			continue
+		}
+		return strconv.Atoi(strings.TrimSpace(v))
+	}
+	if err := scanner.Err(); err != nil {
+		return -1, err
+	}
+	return -1, fmt.Errorf("device minor not found for %s", busID)
+}
@@ -0,0 +1,43 @@
+package nvml
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadDeviceMinor(t *testing.T) {
+	dir := t.TempDir()
+
+	busID := "0000:18:00.0"
+	if err := os.MkdirAll(filepath.Join(dir, busID), 0755); err != nil {
+		t.Fatal(err)
+	}
+	information := `Model: 		 NVIDIA H100 80GB HBM3
+IRQ:   		 534
+GPU UUID: 	 GPU-3b1c7a56-1b6f-4d3a-9d8c-0d6f3e6b2a11
+Video BIOS: 	 96.00.74.00.01
+Bus Type: 	 PCIe
+DMA Size: 	 52 bits
+DMA Mask: 	 0xfffffffffffff
+Bus Location: 	 0000:18:00.0
+Device Minor: 	 3
+GPU Firmware: 	 535.129.03
+GPU Excluded:	 No
+`
+	if err := os.WriteFile(filepath.Join(dir, busID, "information"), []byte(information), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	minor, err := ReadDeviceMinor(dir, busID)
+	if err != nil {
+		t.Fatalf("ReadDeviceMinor failed: %v", err)
+	}
+	if minor != 3 {
+		t.Errorf("expected minor 3, got %d", minor)
+	}
+
+	if _, err := ReadDeviceMinor(dir, "0000:19:00.0"); err == nil {
+		t.Error("expected error for missing device")
+	}
+}
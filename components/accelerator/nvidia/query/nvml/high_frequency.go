@@ -0,0 +1,39 @@
+package nvml
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/shmring"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// SampleHighFrequency queries only the utilization and the power usage of the device,
+// which is cheap enough for the sub-second sampling (unlike the full "Get").
+// The device info must be returned from the "Get" of the instance.
+func SampleHighFrequency(devInfo *DeviceInfo, now time.Time) (shmring.Sample, error) {
+	if devInfo.device == nil {
+		return shmring.Sample{}, fmt.Errorf("device %s not initialized", devInfo.UUID)
+	}
+
+	// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html#group__nvmlDeviceQueries_1g540824faa6cef45500e0d1dc2f50b321
+	rates, ret := devInfo.device.GetUtilizationRates()
+	if ret != nvml.SUCCESS {
+		return shmring.Sample{}, fmt.Errorf("failed to get device utilization rates: %v", nvml.ErrorString(ret))
+	}
+
+	// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html#group__nvmlDeviceQueries_1g7ef7dff0ff14238d08a19ad7fb23fc87
+	powerUsage, ret := devInfo.device.GetPowerUsage()
+	if ret != nvml.SUCCESS {
+		return shmring.Sample{}, fmt.Errorf("failed to get device power usage: %v", nvml.ErrorString(ret))
+	}
+
+	return shmring.Sample{
+		Time:                now,
+		MinorNumber:         uint32(devInfo.MinorNumberID),
+		GPUUsedPercent:      rates.Gpu,
+		MemoryUsedPercent:   rates.Memory,
+		PowerUsageMilliWatt: powerUsage,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package nvml
+
+import (
+	"fmt"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// InfoROM is the InfoROM and VBIOS image versions of a GPU,
+// and whether the InfoROM passed the checksum validation.
+type InfoROM struct {
+	// Represents the GPU UUID.
+	UUID string `json:"uuid"`
+	// Represents the PCI bus ID (e.g., "00000000:05:00.0").
+	BusID string `json:"bus_id"`
+
+	VBIOSVersion string `json:"vbios_version"`
+	ImageVersion string `json:"image_version"`
+	OEMVersion   string `json:"oem_version,omitempty"`
+	ECCVersion   string `json:"ecc_version,omitempty"`
+	PowerVersion string `json:"power_version,omitempty"`
+
+	// Supported is false if the GPU does not support the InfoROM validation.
+	Supported bool `json:"supported"`
+	// Corrupted is true if the InfoROM checksum validation failed.
+	Corrupted bool `json:"corrupted"`
+	// ValidationError is the error returned by the InfoROM validation (if any).
+	ValidationError string `json:"validation_error,omitempty"`
+}
+
+// GetInfoROMs returns the InfoROM and VBIOS versions of all the GPUs,
+// validating the InfoROM checksums.
+func GetInfoROMs() ([]InfoROM, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := device.New(nvmlLib).GetDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	roms := make([]InfoROM, 0, len(devices))
+	for _, dev := range devices {
+		uuid, ret := dev.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device uuid: %v", nvml.ErrorString(ret))
+		}
+		rom := InfoROM{UUID: uuid}
+
+		pciInfo, ret := dev.GetPciInfo()
+		if ret == nvml.SUCCESS {
+			rom.BusID = int8sToString(pciInfo.BusId[:])
+		}
+
+		// the versions are best-effort, the corrupted InfoROM may fail to report them
+		if v, ret := dev.GetVbiosVersion(); ret == nvml.SUCCESS {
+			rom.VBIOSVersion = v
+		}
+		if v, ret := dev.GetInforomImageVersion(); ret == nvml.SUCCESS {
+			rom.ImageVersion = v
+		}
+		if v, ret := dev.GetInforomVersion(nvml.INFOROM_OEM); ret == nvml.SUCCESS {
+			rom.OEMVersion = v
+		}
+		if v, ret := dev.GetInforomVersion(nvml.INFOROM_ECC); ret == nvml.SUCCESS {
+			rom.ECCVersion = v
+		}
+		if v, ret := dev.GetInforomVersion(nvml.INFOROM_POWER); ret == nvml.SUCCESS {
+			rom.PowerVersion = v
+		}
+
+		ret = dev.ValidateInforom()
+		switch ret {
+		case nvml.SUCCESS:
+			rom.Supported = true
+		case nvml.ERROR_NOT_SUPPORTED:
+		case nvml.ERROR_CORRUPTED_INFOROM:
+			rom.Supported = true
+			rom.Corrupted = true
+			rom.ValidationError = nvml.ErrorString(ret)
+		default:
+			rom.Supported = true
+			rom.ValidationError = nvml.ErrorString(ret)
+		}
+
+		roms = append(roms, rom)
+	}
+	return roms, nil
+}
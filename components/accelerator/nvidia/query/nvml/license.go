@@ -0,0 +1,181 @@
+package nvml
+
+import (
+	"fmt"
+	"time"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+const (
+	LicenseFeatureVGPU    = "vgpu"
+	LicenseFeatureRTX     = "nvidia-rtx"
+	LicenseFeatureGaming  = "gaming"
+	LicenseFeatureCompute = "compute"
+	LicenseFeatureUnknown = "unknown"
+)
+
+const (
+	LicenseExpiryPermanent     = "permanent"
+	LicenseExpiryValid         = "valid"
+	LicenseExpiryInvalid       = "invalid"
+	LicenseExpiryNotAvailable  = "not-available"
+	LicenseExpiryNotApplicable = "not-applicable"
+)
+
+const (
+	VirtualizationModeNone        = "none"
+	VirtualizationModePassthrough = "passthrough"
+	VirtualizationModeVGPU        = "vgpu"
+	VirtualizationModeHostVGPU    = "host-vgpu"
+	VirtualizationModeHostVSGA    = "host-vsga"
+)
+
+// LicensedFeature is a licensable enterprise feature of the GPU (e.g., vGPU, NVIDIA AI Enterprise as "compute").
+type LicensedFeature struct {
+	// Feature is the feature code ("vgpu", "nvidia-rtx", "gaming", or "compute").
+	Feature string `json:"feature"`
+	// ProductName is the licensed product (e.g., "NVIDIA Virtual Compute Server").
+	ProductName string `json:"product_name,omitempty"`
+	// LicenseInfo is the license details reported by the driver.
+	LicenseInfo string `json:"license_info,omitempty"`
+
+	Enabled  bool `json:"enabled"`
+	Licensed bool `json:"licensed"`
+
+	// ExpiryStatus is the license expiry status ("permanent", "valid", "invalid", "not-available", or "not-applicable").
+	ExpiryStatus string `json:"expiry_status"`
+	// Expiry is the license expiry time, only set if the expiry status is "valid".
+	Expiry *time.Time `json:"expiry,omitempty"`
+}
+
+// Expired returns true if the license has expired at the given time.
+func (f LicensedFeature) Expired(now time.Time) bool {
+	return f.ExpiryStatus == LicenseExpiryInvalid || (f.Expiry != nil && now.After(*f.Expiry))
+}
+
+// GPULicense is the virtualization mode and the licensable features of the GPU.
+type GPULicense struct {
+	UUID string `json:"uuid"`
+	// VirtualizationMode is "none" on the bare-metal, "vgpu" in the guest VM, or "passthrough".
+	VirtualizationMode string `json:"virtualization_mode"`
+	// Supported is false if the GPU (or the driver) does not support the enterprise licensing.
+	Supported bool              `json:"supported"`
+	Features  []LicensedFeature `json:"features,omitempty"`
+}
+
+// GetGPULicenses returns the licensing status of all the GPUs.
+// The data center GPUs on the bare-metal do not require the license, thus reported as not supported.
+func GetGPULicenses() ([]GPULicense, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := device.New(nvmlLib).GetDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	licenses := make([]GPULicense, 0, len(devices))
+	for _, dev := range devices {
+		uuid, ret := dev.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device uuid: %v", nvml.ErrorString(ret))
+		}
+		l := GPULicense{UUID: uuid, VirtualizationMode: VirtualizationModeNone}
+
+		mode, ret := dev.GetVirtualizationMode()
+		switch ret {
+		case nvml.SUCCESS:
+			l.VirtualizationMode = virtualizationModeString(mode)
+		case nvml.ERROR_NOT_SUPPORTED:
+		default:
+			return nil, fmt.Errorf("%s: failed to get virtualization mode: %v", uuid, nvml.ErrorString(ret))
+		}
+
+		features, ret := dev.GetGridLicensableFeatures()
+		switch ret {
+		case nvml.SUCCESS:
+			l.Supported = features.IsGridLicenseSupported != 0
+			for i := 0; i < int(features.LicensableFeaturesCount) && i < len(features.GridLicensableFeatures); i++ {
+				l.Features = append(l.Features, toLicensedFeature(features.GridLicensableFeatures[i]))
+			}
+		case nvml.ERROR_NOT_SUPPORTED:
+		default:
+			return nil, fmt.Errorf("%s: failed to get licensable features: %v", uuid, nvml.ErrorString(ret))
+		}
+
+		licenses = append(licenses, l)
+	}
+	return licenses, nil
+}
+
+func virtualizationModeString(mode nvml.GpuVirtualizationMode) string {
+	switch mode {
+	case nvml.GPU_VIRTUALIZATION_MODE_PASSTHROUGH:
+		return VirtualizationModePassthrough
+	case nvml.GPU_VIRTUALIZATION_MODE_VGPU:
+		return VirtualizationModeVGPU
+	case nvml.GPU_VIRTUALIZATION_MODE_HOST_VGPU:
+		return VirtualizationModeHostVGPU
+	case nvml.GPU_VIRTUALIZATION_MODE_HOST_VSGA:
+		return VirtualizationModeHostVSGA
+	default:
+		return VirtualizationModeNone
+	}
+}
+
+func toLicensedFeature(f nvml.GridLicensableFeature) LicensedFeature {
+	lf := LicensedFeature{
+		ProductName: int8sToString(f.ProductName[:]),
+		LicenseInfo: int8sToString(f.LicenseInfo[:]),
+		Enabled:     f.FeatureEnabled != 0,
+		Licensed:    f.FeatureState != 0,
+	}
+
+	switch nvml.GridLicenseFeatureCode(f.FeatureCode) {
+	case nvml.GRID_LICENSE_FEATURE_CODE_VGPU:
+		lf.Feature = LicenseFeatureVGPU
+	case nvml.GRID_LICENSE_FEATURE_CODE_NVIDIA_RTX:
+		lf.Feature = LicenseFeatureRTX
+	case nvml.GRID_LICENSE_FEATURE_CODE_GAMING:
+		lf.Feature = LicenseFeatureGaming
+	case nvml.GRID_LICENSE_FEATURE_CODE_COMPUTE:
+		lf.Feature = LicenseFeatureCompute
+	default:
+		lf.Feature = LicenseFeatureUnknown
+	}
+
+	switch f.LicenseExpiry.Status {
+	case nvml.GRID_LICENSE_EXPIRY_PERMANENT:
+		lf.ExpiryStatus = LicenseExpiryPermanent
+	case nvml.GRID_LICENSE_EXPIRY_VALID:
+		lf.ExpiryStatus = LicenseExpiryValid
+		e := f.LicenseExpiry
+		t := time.Date(int(e.Year), time.Month(e.Month), int(e.Day), int(e.Hour), int(e.Min), int(e.Sec), 0, time.UTC)
+		lf.Expiry = &t
+	case nvml.GRID_LICENSE_EXPIRY_INVALID:
+		lf.ExpiryStatus = LicenseExpiryInvalid
+	case nvml.GRID_LICENSE_EXPIRY_NOT_APPLICABLE:
+		lf.ExpiryStatus = LicenseExpiryNotApplicable
+	default:
+		lf.ExpiryStatus = LicenseExpiryNotAvailable
+	}
+	return lf
+}
+
+// int8sToString converts the NUL-terminated C string to the Go string.
+func int8sToString(cs []int8) string {
+	b := make([]byte, 0, len(cs))
+	for _, c := range cs {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
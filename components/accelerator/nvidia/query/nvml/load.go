@@ -0,0 +1,51 @@
+package nvml
+
+import (
+	"context"
+	"fmt"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+	"github.com/leptonai/gpud/pkg/probegate"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// GetGPULoads returns the current load of all the GPUs,
+// used to check whether the node is idle before running any active probe (e.g., diagnostics, memory scrub).
+func GetGPULoads(ctx context.Context) ([]probegate.GPULoad, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := device.New(nvmlLib).GetDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	loads := make([]probegate.GPULoad, 0, len(devices))
+	for _, dev := range devices {
+		uuid, ret := dev.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device uuid: %v", nvml.ErrorString(ret))
+		}
+
+		// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html#group__nvmlDeviceQueries_1g540824faa6cef45500e0d1dc2f50b321
+		util, ret := dev.GetUtilizationRates()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("%s: failed to get utilization rates: %v", uuid, nvml.ErrorString(ret))
+		}
+		procs, ret := dev.GetComputeRunningProcesses()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("%s: failed to get compute processes: %v", uuid, nvml.ErrorString(ret))
+		}
+
+		loads = append(loads, probegate.GPULoad{
+			UUID:             uuid,
+			UsedPercent:      util.Gpu,
+			RunningProcesses: len(procs),
+		})
+	}
+	return loads, nil
+}
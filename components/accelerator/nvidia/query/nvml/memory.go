@@ -4,9 +4,10 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/leptonai/gpud/pkg/locale"
+
 	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
-	"github.com/dustin/go-humanize"
 )
 
 type Memory struct {
@@ -54,10 +55,10 @@ func GetMemory(uuid string, dev device.Device) (Memory, error) {
 		mem.FreeBytes = infoV2.Free
 		mem.UsedBytes = infoV2.Used
 	}
-	mem.TotalHumanized = humanize.Bytes(mem.TotalBytes)
-	mem.ReservedHumanized = humanize.Bytes(mem.ReservedBytes)
-	mem.FreeHumanized = humanize.Bytes(mem.FreeBytes)
-	mem.UsedHumanized = humanize.Bytes(mem.UsedBytes)
+	mem.TotalHumanized = locale.Bytes(mem.TotalBytes)
+	mem.ReservedHumanized = locale.Bytes(mem.ReservedBytes)
+	mem.FreeHumanized = locale.Bytes(mem.FreeBytes)
+	mem.UsedHumanized = locale.Bytes(mem.UsedBytes)
 
 	if mem.TotalBytes > 0 {
 		mem.UsedPercent = fmt.Sprintf("%.2f", float64(mem.UsedBytes)/float64(mem.TotalBytes)*100)
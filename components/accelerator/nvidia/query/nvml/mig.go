@@ -0,0 +1,304 @@
+package nvml
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// MIGSlice is the utilization of a MIG (Multi-Instance GPU) device.
+type MIGSlice struct {
+	// GPUUUID is the UUID of the parent GPU.
+	GPUUUID string `json:"gpu_uuid"`
+	// UUID is the MIG device UUID.
+	UUID              string `json:"uuid"`
+	GPUInstanceID     int    `json:"gpu_instance_id"`
+	ComputeInstanceID int    `json:"compute_instance_id"`
+	// Profile is the MIG profile name (e.g., "1g.10gb").
+	Profile string `json:"profile"`
+
+	// GraphicsUtilPercent is the graphics engine utilization percent from the GPM metrics.
+	// Nil if GPM is not supported (GPM requires Hopper or later).
+	GraphicsUtilPercent *float64 `json:"graphics_util_percent,omitempty"`
+
+	MemoryUsedBytes  uint64 `json:"memory_used_bytes"`
+	MemoryTotalBytes uint64 `json:"memory_total_bytes"`
+
+	// RunningProcesses is the number of the compute processes running on the MIG device.
+	// Non-zero means the slice is allocated (e.g., held by a pod).
+	RunningProcesses int `json:"running_processes"`
+}
+
+// Allocated returns true if any process is running on the MIG device.
+func (s MIGSlice) Allocated() bool {
+	return s.RunningProcesses > 0
+}
+
+type migSample struct {
+	parent  device.Device
+	slice   MIGSlice
+	sample1 nvml.GpmSample
+	sample2 nvml.GpmSample
+}
+
+// GetMIGSlices returns the utilization of all the MIG devices on the MIG-enabled GPUs.
+// The utilization is computed from the two GPM samples taken with the sample interval.
+func GetMIGSlices(ctx context.Context, sampleInterval time.Duration) ([]MIGSlice, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := device.New(nvmlLib).GetDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []*migSample
+	defer func() {
+		for _, s := range samples {
+			if s.sample1 != nil {
+				_ = s.sample1.Free()
+			}
+			if s.sample2 != nil {
+				_ = s.sample2.Free()
+			}
+		}
+	}()
+
+	for _, dev := range devices {
+		enabled, err := dev.IsMigEnabled()
+		if err != nil || !enabled {
+			continue
+		}
+		gpuUUID, ret := dev.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device uuid: %v", nvml.ErrorString(ret))
+		}
+		gpmSupported, _ := GPMSupportedByDevice(dev)
+
+		err = dev.VisitMigDevices(func(_ int, m device.MigDevice) error {
+			s, err := newMIGSample(gpuUUID, m)
+			if err != nil {
+				return err
+			}
+			s.parent = dev
+			samples = append(samples, s)
+
+			if !gpmSupported {
+				return nil
+			}
+			if s.sample1, ret = nvml.GpmSampleAlloc(); ret != nvml.SUCCESS {
+				return fmt.Errorf("could not allocate sample: %v", nvml.ErrorString(ret))
+			}
+			if s.sample2, ret = nvml.GpmSampleAlloc(); ret != nvml.SUCCESS {
+				return fmt.Errorf("could not allocate sample: %v", nvml.ErrorString(ret))
+			}
+			if ret := dev.GpmMigSampleGet(s.slice.GPUInstanceID, s.sample1); ret != nvml.SUCCESS {
+				return fmt.Errorf("could not get mig sample: %v", nvml.ErrorString(ret))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hasGPM := false
+	for _, s := range samples {
+		if s.sample1 != nil {
+			hasGPM = true
+			break
+		}
+	}
+	if hasGPM {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(sampleInterval):
+		}
+	}
+
+	slices := make([]MIGSlice, 0, len(samples))
+	for _, s := range samples {
+		if s.sample1 != nil {
+			if ret := s.parent.GpmMigSampleGet(s.slice.GPUInstanceID, s.sample2); ret != nvml.SUCCESS {
+				return nil, fmt.Errorf("could not get mig sample: %v", nvml.ErrorString(ret))
+			}
+			gpmMetric := nvml.GpmMetricsGetType{
+				NumMetrics: 1,
+				Sample1:    s.sample1,
+				Sample2:    s.sample2,
+				Metrics:    [98]nvml.GpmMetric{{MetricId: uint32(nvml.GPM_METRIC_GRAPHICS_UTIL)}},
+			}
+			if ret := nvml.GpmMetricsGet(&gpmMetric); ret != nvml.SUCCESS {
+				return nil, fmt.Errorf("failed to get gpm metric: %v", nvml.ErrorString(ret))
+			}
+			util := gpmMetric.Metrics[0].Value
+			s.slice.GraphicsUtilPercent = &util
+		}
+		slices = append(slices, s.slice)
+	}
+	return slices, nil
+}
+
+func newMIGSample(gpuUUID string, m device.MigDevice) (*migSample, error) {
+	uuid, ret := m.GetUUID()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get mig device uuid: %v", nvml.ErrorString(ret))
+	}
+	giID, ret := m.GetGpuInstanceId()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get gpu instance id: %v", nvml.ErrorString(ret))
+	}
+	ciID, ret := m.GetComputeInstanceId()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get compute instance id: %v", nvml.ErrorString(ret))
+	}
+	slice := MIGSlice{
+		GPUUUID:           gpuUUID,
+		UUID:              uuid,
+		GPUInstanceID:     giID,
+		ComputeInstanceID: ciID,
+	}
+
+	if profile, err := m.GetProfile(); err == nil {
+		slice.Profile = profile.String()
+	}
+	if mem, ret := m.GetMemoryInfo(); ret == nvml.SUCCESS {
+		slice.MemoryUsedBytes = mem.Used
+		slice.MemoryTotalBytes = mem.Total
+	}
+	procs, ret := m.GetComputeRunningProcesses()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get mig device running processes: %v", nvml.ErrorString(ret))
+	}
+	slice.RunningProcesses = len(procs)
+
+	return &migSample{slice: slice}, nil
+}
+
+// MIGMode is the MIG mode of the GPU and its MIG devices (GPU instance and compute instance pairs).
+// ref. https://docs.nvidia.com/datacenter/tesla/mig-user-guide/index.html
+type MIGMode struct {
+	UUID string `json:"uuid"`
+	// Supported is false if the GPU does not support MIG (e.g., pre-Ampere or consumer GPUs).
+	Supported bool `json:"supported"`
+	// Enabled is the current MIG mode.
+	Enabled bool `json:"enabled"`
+	// PendingEnabled is the MIG mode that takes effect after the next GPU reset or reboot.
+	PendingEnabled bool `json:"pending_enabled"`
+
+	Devices []MIGDevice `json:"devices,omitempty"`
+}
+
+// PendingChange returns true if the MIG mode was changed but not yet applied
+// (requires a GPU reset or reboot).
+func (m MIGMode) PendingChange() bool {
+	return m.Supported && m.Enabled != m.PendingEnabled
+}
+
+// ProfileCounts returns the number of the MIG devices per profile name.
+// Returns nil if there is no MIG device.
+func (m MIGMode) ProfileCounts() map[string]int {
+	var counts map[string]int
+	for _, d := range m.Devices {
+		if counts == nil {
+			counts = make(map[string]int)
+		}
+		counts[d.Profile]++
+	}
+	return counts
+}
+
+// MIGDevice is a MIG device, which is a compute instance within a GPU instance.
+type MIGDevice struct {
+	UUID              string `json:"uuid"`
+	GPUInstanceID     int    `json:"gpu_instance_id"`
+	ComputeInstanceID int    `json:"compute_instance_id"`
+	// Profile is the MIG profile name (e.g., "1g.10gb").
+	Profile string `json:"profile"`
+}
+
+// GetMIGMode returns the MIG mode of the GPU, and enumerates its MIG devices if MIG is enabled.
+func GetMIGMode(uuid string, dev device.Device) (MIGMode, error) {
+	mode := MIGMode{
+		UUID: uuid,
+	}
+
+	current, pending, ret := dev.GetMigMode()
+	if ret == nvml.ERROR_NOT_SUPPORTED {
+		return mode, nil
+	}
+	if ret != nvml.SUCCESS {
+		return MIGMode{}, fmt.Errorf("failed to get mig mode: %v", nvml.ErrorString(ret))
+	}
+	mode.Supported = true
+	mode.Enabled = current == nvml.DEVICE_MIG_ENABLE
+	mode.PendingEnabled = pending == nvml.DEVICE_MIG_ENABLE
+
+	if !mode.Enabled {
+		return mode, nil
+	}
+
+	err := dev.VisitMigDevices(func(_ int, m device.MigDevice) error {
+		migUUID, ret := m.GetUUID()
+		if ret != nvml.SUCCESS {
+			return fmt.Errorf("failed to get mig device uuid: %v", nvml.ErrorString(ret))
+		}
+		giID, ret := m.GetGpuInstanceId()
+		if ret != nvml.SUCCESS {
+			return fmt.Errorf("failed to get gpu instance id: %v", nvml.ErrorString(ret))
+		}
+		ciID, ret := m.GetComputeInstanceId()
+		if ret != nvml.SUCCESS {
+			return fmt.Errorf("failed to get compute instance id: %v", nvml.ErrorString(ret))
+		}
+		d := MIGDevice{
+			UUID:              migUUID,
+			GPUInstanceID:     giID,
+			ComputeInstanceID: ciID,
+		}
+		if profile, err := m.GetProfile(); err == nil {
+			d.Profile = profile.String()
+		}
+		mode.Devices = append(mode.Devices, d)
+		return nil
+	})
+	if err != nil {
+		return MIGMode{}, err
+	}
+	return mode, nil
+}
+
+// GetMIGModes returns the MIG modes of all the GPUs.
+func GetMIGModes() ([]MIGMode, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := device.New(nvmlLib).GetDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	modes := make([]MIGMode, 0, len(devices))
+	for _, dev := range devices {
+		uuid, ret := dev.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device uuid: %v", nvml.ErrorString(ret))
+		}
+		mode, err := GetMIGMode(uuid, dev)
+		if err != nil {
+			return nil, err
+		}
+		modes = append(modes, mode)
+	}
+	return modes, nil
+}
@@ -11,8 +11,11 @@ import (
 	"sync"
 	"time"
 
+	components_nvidia_ecc_addresses "github.com/leptonai/gpud/components/accelerator/nvidia/query/ecc-addresses"
+	components_nvidia_gpu_processes "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-processes"
 	components_nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
 	"github.com/leptonai/gpud/log"
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
 
 	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
 	nvinfo "github.com/NVIDIA/go-nvlib/pkg/nvlib/info"
@@ -36,6 +39,13 @@ type Instance interface {
 	GPMMetricsSupported() bool
 	RecvGPMEvents() <-chan *GPMEvent
 
+	// Release stops the event pollers and releases the device handles and the event set,
+	// until "Reinit" (e.g., while the NVIDIA kernel modules are being reloaded).
+	Release()
+	// Reinit re-initializes the device handles and the event set from the shared NVML session,
+	// and restarts the event pollers, keeping the event channels.
+	Reinit() error
+
 	Shutdown() error
 	Get() (*Output, error)
 }
@@ -51,6 +61,16 @@ type instance struct {
 	rootCtx    context.Context
 	rootCancel context.CancelFunc
 
+	// cancels the event pollers of the current device handles
+	pollCancel context.CancelFunc
+	pollWg     sync.WaitGroup
+
+	// the shared NVML session generation of the device handles,
+	// to re-initialize when the session is re-initialized
+	sessionGeneration uint64
+	// set while released for the driver reload, until "Reinit"
+	released bool
+
 	nvmlExists    bool
 	nvmlExistsMsg string
 
@@ -68,6 +88,7 @@ type instance struct {
 	xidEventSet         nvml.EventSet
 	xidEventCh          chan *XidEvent
 	xidEventChCloseOnce sync.Once
+	xidEventChClosed    bool
 
 	gpmPollInterval time.Duration
 
@@ -75,6 +96,7 @@ type instance struct {
 	gpmMetricsIDs       []nvml.GpmMetricId
 	gpmEventCh          chan *GPMEvent
 	gpmEventChCloseOnce sync.Once
+	gpmEventChClosed    bool
 }
 
 // TODO
@@ -88,7 +110,7 @@ type instance struct {
 
 type DeviceInfo struct {
 	// Note that k8s-device-plugin has a different logic for MIG devices.
-	// TODO: implement MIG device UUID fetching using NVML.
+	// The MIG device UUIDs are listed in the MIG mode.
 	UUID string `json:"uuid"`
 
 	// MinorNumberID is the minor number ID of the device.
@@ -107,31 +129,37 @@ type DeviceInfo struct {
 	// Set true if the device supports GPM metrics.
 	GPMMetricsSupported bool `json:"gpm_metrics_supported"`
 
-	GSPFirmwareMode GSPFirmwareMode `json:"gsp_firmware_mode"`
-	PersistenceMode PersistenceMode `json:"persistence_mode"`
-	ClockEvents     *ClockEvents    `json:"clock_events,omitempty"`
-	ClockSpeed      ClockSpeed      `json:"clock_speed"`
-	Memory          Memory          `json:"memory"`
-	NVLink          NVLink          `json:"nvlink"`
-	Power           Power           `json:"power"`
-	Temperature     Temperature     `json:"temperature"`
-	Utilization     Utilization     `json:"utilization"`
-	Processes       Processes       `json:"processes"`
-	ECCMode         ECCMode         `json:"ecc_mode"`
-	ECCErrors       ECCErrors       `json:"ecc_errors"`
-	RemappedRows    RemappedRows    `json:"remapped_rows"`
+	GSPFirmwareMode  GSPFirmwareMode  `json:"gsp_firmware_mode"`
+	PersistenceMode  PersistenceMode  `json:"persistence_mode"`
+	MIGMode          MIGMode          `json:"mig_mode"`
+	ClockEvents      *ClockEvents     `json:"clock_events,omitempty"`
+	ClockSpeed       ClockSpeed       `json:"clock_speed"`
+	PerformanceState PerformanceState `json:"performance_state"`
+	Memory           Memory           `json:"memory"`
+	NVLink           NVLink           `json:"nvlink"`
+	Power            Power            `json:"power"`
+	Temperature      Temperature      `json:"temperature"`
+	Utilization      Utilization      `json:"utilization"`
+	Processes        Processes        `json:"processes"`
+	ECCMode          ECCMode          `json:"ecc_mode"`
+	ECCErrors        ECCErrors        `json:"ecc_errors"`
+	RemappedRows     RemappedRows     `json:"remapped_rows"`
+	RetiredPages     RetiredPages     `json:"retired_pages"`
+	PCIeLink         PCIeLink         `json:"pcie_link"`
 
 	device device.Device `json:"-"`
 }
 
 func GetDriverVersion() (string, error) {
-	nvmlLib := nvml.New()
-	if ret := nvmlLib.Init(); ret != nvml.SUCCESS {
-		return "", fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return "", err
 	}
 
 	ver, ret := nvmlLib.SystemGetDriverVersion()
 	if ret != nvml.SUCCESS {
+		// re-initializes on the next call if the driver was reloaded
+		pkg_nvml.Default().Check(ret)
 		return "", fmt.Errorf("failed to get driver version: %v", nvml.ErrorString(ret))
 	}
 
@@ -141,6 +169,25 @@ func GetDriverVersion() (string, error) {
 	return ver, nil
 }
 
+// GetCUDADriverVersion returns the maximum CUDA version supported by the driver (e.g., "12.4").
+func GetCUDADriverVersion() (string, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return "", err
+	}
+
+	ver, ret := nvmlLib.SystemGetCudaDriverVersion()
+	if ret != nvml.SUCCESS {
+		return "", fmt.Errorf("failed to get cuda driver version: %v", nvml.ErrorString(ret))
+	}
+	return formatCUDADriverVersion(ver), nil
+}
+
+// formatCUDADriverVersion formats the NVML CUDA driver version (e.g., 12040 to "12.4").
+func formatCUDADriverVersion(ver int) string {
+	return fmt.Sprintf("%d.%d", ver/1000, (ver%1000)/10)
+}
+
 func ParseDriverVersion(version string) (major, minor, patch int, err error) {
 	var parsed [3]int
 	if _, err = fmt.Sscanf(version, "%d.%d.%d", &parsed[0], &parsed[1], &parsed[2]); err != nil {
@@ -169,17 +216,48 @@ func NewInstance(ctx context.Context, opts ...OpOption) (Instance, error) {
 		gpmMetricsIDs = append(gpmMetricsIDs, id)
 	}
 
-	nvmlLib := nvml.New()
-	if ret := nvmlLib.Init(); ret != nvml.SUCCESS {
-		return nil, fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	rootCtx, rootCancel := context.WithCancel(ctx)
+	inst := &instance{
+		rootCtx:    rootCtx,
+		rootCancel: rootCancel,
+
+		db: op.db,
+
+		xidErrorSupported:   false,
+		xidEventMask:        defaultXidEventMask,
+		xidEventCh:          make(chan *XidEvent, 100),
+		xidEventChCloseOnce: sync.Once{},
+
+		gpmPollInterval: time.Minute,
+
+		gpmMetricsSupported: false,
+		gpmMetricsIDs:       gpmMetricsIDs,
+		gpmEventCh:          make(chan *GPMEvent, 100),
+		gpmEventChCloseOnce: sync.Once{},
 	}
+	if err := inst.initLib(); err != nil {
+		rootCancel()
+		return nil, err
+	}
+	return inst, nil
+}
+
+// initLib initializes the NVML library and the event set from the shared NVML session.
+// Must be called with the lock held (or before the instance is shared).
+func (inst *instance) initLib() error {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return err
+	}
+	sessionGeneration := pkg_nvml.Default().Generation()
+
 	driverVersion, err := GetDriverVersion()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	major, _, _, err := ParseDriverVersion(driverVersion)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	clockEventsSupported := ClockEventsSupportedVersion(major)
 	if !clockEventsSupported {
@@ -203,39 +281,22 @@ func NewInstance(ctx context.Context, opts ...OpOption) (Instance, error) {
 	// ref. https://github.com/NVIDIA/k8s-device-plugin/blob/main/internal/rm/health.go
 	xidEventSet, ret := nvmlLib.EventSetCreate()
 	if ret != nvml.SUCCESS {
-		return nil, fmt.Errorf("failed to create event set: %v", nvml.ErrorString(ret))
+		return fmt.Errorf("failed to create event set: %v", nvml.ErrorString(ret))
 	}
 
-	rootCtx, rootCancel := context.WithCancel(ctx)
-	return &instance{
-		rootCtx:    rootCtx,
-		rootCancel: rootCancel,
-
-		driverVersion:        driverVersion,
-		clockEventsSupported: clockEventsSupported,
-
-		nvmlLib:   nvmlLib,
-		deviceLib: deviceLib,
-		infoLib:   infoLib,
-
-		nvmlExists:    nvmlExists,
-		nvmlExistsMsg: nvmlExistsMsg,
-
-		db: op.db,
+	inst.driverVersion = driverVersion
+	inst.clockEventsSupported = clockEventsSupported
 
-		xidErrorSupported:   false,
-		xidEventSet:         xidEventSet,
-		xidEventMask:        defaultXidEventMask,
-		xidEventCh:          make(chan *XidEvent, 100),
-		xidEventChCloseOnce: sync.Once{},
+	inst.nvmlLib = nvmlLib
+	inst.deviceLib = deviceLib
+	inst.infoLib = infoLib
+	inst.sessionGeneration = sessionGeneration
 
-		gpmPollInterval: time.Minute,
+	inst.nvmlExists = nvmlExists
+	inst.nvmlExistsMsg = nvmlExistsMsg
 
-		gpmMetricsSupported: false,
-		gpmMetricsIDs:       gpmMetricsIDs,
-		gpmEventCh:          make(chan *GPMEvent, 100),
-		gpmEventChCloseOnce: sync.Once{},
-	}, nil
+	inst.xidEventSet = xidEventSet
+	return nil
 }
 
 // Starts an NVML instance and starts polling for XID events.
@@ -256,7 +317,20 @@ func (inst *instance) Start() error {
 	if err := components_nvidia_xid_sxid_state.CreateTableXidSXidEventHistory(ctx, inst.db); err != nil {
 		return err
 	}
+	if err := components_nvidia_gpu_processes.CreateTableGPUProcessHistory(ctx, inst.db); err != nil {
+		return err
+	}
+	if err := components_nvidia_ecc_addresses.CreateTables(ctx, inst.db); err != nil {
+		return err
+	}
 
+	return inst.startDevices()
+}
+
+// startDevices enumerates the devices, registers the devices to the event set,
+// and starts the event pollers.
+// Must be called with the lock held.
+func (inst *instance) startDevices() error {
 	devices, err := inst.deviceLib.GetDevices()
 	if err != nil {
 		return err
@@ -336,27 +410,139 @@ func (inst *instance) Start() error {
 		}
 	}
 
-	if inst.xidErrorSupported {
-		go inst.pollXidEvents()
+	pollCtx, pollCancel := context.WithCancel(inst.rootCtx)
+	inst.pollCancel = pollCancel
+
+	// the closed channel cannot be re-opened, so the pollers are not restarted
+	// if the events were not supported before the re-initialization
+	if inst.xidErrorSupported && !inst.xidEventChClosed {
+		inst.pollWg.Add(1)
+		go func(eventSet nvml.EventSet) {
+			defer inst.pollWg.Done()
+			inst.pollXidEvents(pollCtx, eventSet)
+		}(inst.xidEventSet)
 	} else {
 		inst.xidEventChCloseOnce.Do(func() {
 			log.Logger.Warnw("xid error not supported")
 			close(inst.xidEventCh)
+			inst.xidEventChClosed = true
 		})
 	}
 
-	if inst.gpmMetricsSupported && len(inst.gpmMetricsIDs) > 0 {
-		go inst.pollGPMEvents()
+	if inst.gpmMetricsSupported && len(inst.gpmMetricsIDs) > 0 && !inst.gpmEventChClosed {
+		devices := make([]*DeviceInfo, 0, len(inst.devices))
+		for _, dev := range inst.devices {
+			devices = append(devices, dev)
+		}
+		inst.pollWg.Add(1)
+		go func() {
+			defer inst.pollWg.Done()
+			inst.pollGPMEvents(pollCtx, devices)
+		}()
 	} else {
 		inst.gpmEventChCloseOnce.Do(func() {
 			log.Logger.Warnw("gpm metrics not supported")
 			close(inst.gpmEventCh)
+			inst.gpmEventChClosed = true
 		})
 	}
 
 	return nil
 }
 
+// stopDevices stops the event pollers, and frees the event set and the device handles,
+// so that the instance holds no NVML resource.
+// Must be called with the lock held.
+func (inst *instance) stopDevices() {
+	if inst.pollCancel != nil {
+		inst.pollCancel()
+		inst.pollCancel = nil
+	}
+	// the xid poller returns within the event set wait timeout
+	inst.pollWg.Wait()
+
+	if inst.xidEventSet != nil {
+		if ret := inst.xidEventSet.Free(); ret != nvml.SUCCESS {
+			log.Logger.Warnw("failed to free event set", "error", nvml.ErrorString(ret))
+		}
+	}
+	inst.xidEventSet = nil
+
+	inst.devices = nil
+	inst.infoLib = nil
+	inst.deviceLib = nil
+
+	// the shared NVML session is shut down by the daemon on exit
+	inst.nvmlLib = nil
+}
+
+func (inst *instance) Release() {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	log.Logger.Infow("releasing NVML devices")
+	inst.released = true
+	inst.stopDevices()
+}
+
+func (inst *instance) Reinit() error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	if inst.rootCtx.Err() != nil {
+		return errors.New("nvml instance shut down")
+	}
+
+	log.Logger.Infow("re-initializing NVML devices")
+	inst.released = false
+	inst.stopDevices()
+	if err := inst.initLib(); err != nil {
+		return err
+	}
+	return inst.startDevices()
+}
+
+// refresh re-initializes the instance if the shared NVML session was re-initialized
+// (e.g., after the driver reload or the GPU reset), as the cached device handles
+// and the event set belong to the previous NVML library.
+func (inst *instance) refresh() error {
+	inst.mu.RLock()
+	released, nvmlLib, sessionGeneration := inst.released, inst.nvmlLib, inst.sessionGeneration
+	inst.mu.RUnlock()
+
+	if released {
+		return errors.New("nvml released, waiting for re-initialization")
+	}
+	if inst.rootCtx.Err() != nil {
+		return errors.New("nvml instance shut down")
+	}
+
+	// probes the session, re-initializing on the driver errors
+	if _, err := pkg_nvml.Default().Lib(); err != nil {
+		return err
+	}
+	if nvmlLib != nil && pkg_nvml.Default().Generation() == sessionGeneration {
+		return nil
+	}
+	// also retries the previously failed re-initialization
+	return inst.Reinit()
+}
+
+// checkDevices checks the device handles after a failed query,
+// and resets the shared NVML session if any device handle requires
+// the re-initialization (e.g., the GPU was lost or reset),
+// to re-initialize on the next "Get".
+func (inst *instance) checkDevices() {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+
+	for _, dev := range inst.devices {
+		if _, ret := dev.device.GetUUID(); pkg_nvml.Default().Check(ret) {
+			return
+		}
+	}
+}
+
 func (inst *instance) NVMLExists() bool {
 	inst.mu.RLock()
 	defer inst.mu.RUnlock()
@@ -374,20 +560,7 @@ func (inst *instance) Shutdown() error {
 
 	log.Logger.Debugw("shutting down NVML")
 	inst.rootCancel()
-
-	if inst.xidEventSet != nil {
-		ret := inst.xidEventSet.Free()
-		if ret != nvml.SUCCESS {
-			return fmt.Errorf("failed to free event set: %v", nvml.ErrorString(ret))
-		}
-	}
-	inst.xidEventSet = nil
-
-	ret := inst.nvmlLib.Shutdown()
-	if ret != nvml.SUCCESS {
-		return fmt.Errorf("failed to shutdown NVML: %v", nvml.ErrorString(ret))
-	}
-	inst.nvmlLib = nil
+	inst.stopDevices()
 
 	return nil
 }
@@ -396,6 +569,18 @@ func (inst *instance) Shutdown() error {
 // and returns the state.
 // If error happens, returns whatever queried successfully and the error.
 func (inst *instance) Get() (*Output, error) {
+	if err := inst.refresh(); err != nil {
+		return nil, err
+	}
+
+	st, err := inst.get()
+	if err != nil {
+		inst.checkDevices()
+	}
+	return st, err
+}
+
+func (inst *instance) get() (*Output, error) {
 	inst.mu.RLock()
 	defer inst.mu.RUnlock()
 
@@ -440,6 +625,11 @@ func (inst *instance) Get() (*Output, error) {
 			return st, err
 		}
 
+		latestInfo.MIGMode, err = GetMIGMode(devInfo.UUID, devInfo.device)
+		if err != nil {
+			return st, err
+		}
+
 		if inst.clockEventsSupported {
 			clockEvents, err := GetClockEvents(devInfo.UUID, devInfo.device)
 			if err != nil {
@@ -453,6 +643,11 @@ func (inst *instance) Get() (*Output, error) {
 			return st, err
 		}
 
+		latestInfo.PerformanceState, err = GetPerformanceState(devInfo.UUID, devInfo.device)
+		if err != nil {
+			return st, err
+		}
+
 		latestInfo.Memory, err = GetMemory(devInfo.UUID, devInfo.device)
 		if err != nil {
 			return st, err
@@ -497,6 +692,16 @@ func (inst *instance) Get() (*Output, error) {
 		if err != nil {
 			return st, err
 		}
+
+		latestInfo.RetiredPages, err = GetRetiredPages(devInfo.UUID, devInfo.device)
+		if err != nil {
+			return st, err
+		}
+
+		latestInfo.PCIeLink, err = GetPCIeLink(devInfo.UUID, devInfo.device)
+		if err != nil {
+			return st, err
+		}
 	}
 
 	sort.Slice(st.DeviceInfos, func(i, j int) bool {
@@ -566,6 +771,35 @@ func DefaultInstance() Instance {
 	return defaultInstance
 }
 
+// SuspendDefaultInstance releases the NVML resources of the default instance
+// and suspends the shared NVML session, so that gpud does not keep the NVIDIA device files open
+// (e.g., to unload the NVIDIA kernel modules), until "ReinitDefaultInstance".
+func SuspendDefaultInstance() error {
+	defaultInstanceMu.RLock()
+	inst := defaultInstance
+	defaultInstanceMu.RUnlock()
+
+	if inst != nil {
+		inst.Release()
+	}
+	return pkg_nvml.Default().Suspend()
+}
+
+// ReinitDefaultInstance resumes the shared NVML session and re-initializes the default instance
+// (e.g., after the NVIDIA kernel modules reload or the GPU reset).
+func ReinitDefaultInstance() error {
+	pkg_nvml.Default().Resume()
+
+	defaultInstanceMu.RLock()
+	inst := defaultInstance
+	defaultInstanceMu.RUnlock()
+
+	if inst == nil {
+		return nil
+	}
+	return inst.Reinit()
+}
+
 func DefaultInstanceReady() <-chan any {
 	defaultInstanceMu.RLock()
 	defer defaultInstanceMu.RUnlock()
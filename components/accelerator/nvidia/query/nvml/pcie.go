@@ -0,0 +1,97 @@
+package nvml
+
+import (
+	"fmt"
+
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// PCIeLink is the PCIe link state of a GPU slot.
+type PCIeLink struct {
+	UUID  string `json:"uuid"`
+	Index int    `json:"index"`
+	// BusID is the PCI bus ID of the slot (e.g., "0000:18:00.0").
+	BusID string `json:"bus_id"`
+
+	// The current link generation may be lower than the max when the GPU is idle (power saving),
+	// while the current link width stays the same unless the link is degraded.
+	CurrentGen   int `json:"current_gen"`
+	MaxGen       int `json:"max_gen"`
+	CurrentWidth int `json:"current_width"`
+	MaxWidth     int `json:"max_width"`
+}
+
+// Degraded returns true if the link trained at a narrower width than the max.
+func (l PCIeLink) Degraded() bool {
+	return l.MaxWidth > 0 && l.CurrentWidth < l.MaxWidth
+}
+
+// GenDowngraded returns true if the link runs at a lower generation than the max,
+// which is only meaningful when the GPU is busy (the link steps down the generation when idle).
+func (l PCIeLink) GenDowngraded() bool {
+	return l.MaxGen > 0 && l.CurrentGen < l.MaxGen
+}
+
+// GetPCIeLinks returns the PCIe link states of all the GPUs.
+func GetPCIeLinks() ([]PCIeLink, error) {
+	nvmlLib, err := pkg_nvml.Default().Lib()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := device.New(nvmlLib).GetDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]PCIeLink, 0, len(devices))
+	for _, dev := range devices {
+		uuid, ret := dev.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device uuid: %v", nvml.ErrorString(ret))
+		}
+		link, err := GetPCIeLink(uuid, dev)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// GetPCIeLink returns the PCIe link state of the GPU.
+func GetPCIeLink(uuid string, dev device.Device) (PCIeLink, error) {
+	link := PCIeLink{UUID: uuid}
+
+	var ret nvml.Return
+	link.Index, ret = dev.GetIndex()
+	if ret != nvml.SUCCESS {
+		return PCIeLink{}, fmt.Errorf("%s: failed to get device index: %v", uuid, nvml.ErrorString(ret))
+	}
+	var err error
+	link.BusID, err = dev.GetPCIBusID()
+	if err != nil {
+		return PCIeLink{}, fmt.Errorf("%s: failed to get pci bus id: %w", uuid, err)
+	}
+
+	link.CurrentGen, ret = dev.GetCurrPcieLinkGeneration()
+	if ret != nvml.SUCCESS {
+		return PCIeLink{}, fmt.Errorf("%s: failed to get current pcie link generation: %v", uuid, nvml.ErrorString(ret))
+	}
+	link.MaxGen, ret = dev.GetMaxPcieLinkGeneration()
+	if ret != nvml.SUCCESS {
+		return PCIeLink{}, fmt.Errorf("%s: failed to get max pcie link generation: %v", uuid, nvml.ErrorString(ret))
+	}
+	link.CurrentWidth, ret = dev.GetCurrPcieLinkWidth()
+	if ret != nvml.SUCCESS {
+		return PCIeLink{}, fmt.Errorf("%s: failed to get current pcie link width: %v", uuid, nvml.ErrorString(ret))
+	}
+	link.MaxWidth, ret = dev.GetMaxPcieLinkWidth()
+	if ret != nvml.SUCCESS {
+		return PCIeLink{}, fmt.Errorf("%s: failed to get max pcie link width: %v", uuid, nvml.ErrorString(ret))
+	}
+	return link, nil
+}
@@ -41,3 +41,21 @@ func GetPersistenceMode(uuid string, dev device.Device) (PersistenceMode, error)
 
 	return mode, nil
 }
+
+// SetPersistenceMode enables or disables the persistence mode of the GPU.
+// If the uuid is empty, it sets the persistence mode of all the GPUs.
+// Equivalent to "nvidia-smi -pm 1" (or "nvidia-smi -pm 0").
+// Requires root privileges.
+// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceCommands.html
+func SetPersistenceMode(uuid string, enabled bool) error {
+	mode := nvml.FEATURE_DISABLED
+	if enabled {
+		mode = nvml.FEATURE_ENABLED
+	}
+	return forEachDevice(uuid, func(dev nvml.Device) error {
+		if ret := dev.SetPersistenceMode(mode); ret != nvml.SUCCESS {
+			return fmt.Errorf("failed to set persistence mode: %v", nvml.ErrorString(ret))
+		}
+		return nil
+	})
+}
@@ -1,9 +1,12 @@
 package nvml
 
 import (
+	"encoding/binary"
 	"fmt"
 	"strconv"
 
+	"github.com/leptonai/gpud/log"
+
 	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
 )
@@ -16,6 +19,14 @@ type Power struct {
 	EnforcedLimitMilliWatts   uint32 `json:"enforced_limit_milli_watts"`
 	ManagementLimitMilliWatts uint32 `json:"management_limit_milli_watts"`
 
+	// Represents the power usage averaged over the last second (zero if not supported).
+	AverageUsageMilliWatts uint32 `json:"average_usage_milli_watts,omitempty"`
+	// Represents the default power management limit (zero if not supported).
+	DefaultLimitMilliWatts uint32 `json:"default_limit_milli_watts,omitempty"`
+	// Represents the accumulated time in nanoseconds the clocks were reduced
+	// due to the power limit (power capping) since the driver load.
+	PowerViolationNanoseconds uint64 `json:"power_violation_nanoseconds"`
+
 	UsedPercent string `json:"used_percent"`
 }
 
@@ -23,6 +34,13 @@ func (power Power) GetUsedPercent() (float64, error) {
 	return strconv.ParseFloat(power.UsedPercent, 64)
 }
 
+// LimitBelowDefault returns true if the enforced power limit is below the default power limit
+// (e.g., a power cap was set with "nvidia-smi -pl" and never reverted).
+// Returns false if the default limit is unknown.
+func (power Power) LimitBelowDefault() bool {
+	return power.DefaultLimitMilliWatts > 0 && power.EnforcedLimitMilliWatts > 0 && power.EnforcedLimitMilliWatts < power.DefaultLimitMilliWatts
+}
+
 func GetPower(uuid string, dev device.Device) (Power, error) {
 	power := Power{
 		UUID: uuid,
@@ -49,6 +67,30 @@ func GetPower(uuid string, dev device.Device) (Power, error) {
 	}
 	power.ManagementLimitMilliWatts = managementPowerLimit
 
+	// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html
+	defaultPowerLimit, ret := dev.GetPowerManagementDefaultLimit()
+	if ret == nvml.SUCCESS {
+		power.DefaultLimitMilliWatts = defaultPowerLimit
+	} else {
+		log.Logger.Debugw("failed to get device default power limit", "uuid", uuid, "error", nvml.ErrorString(ret))
+	}
+
+	// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html
+	violation, ret := dev.GetViolationStatus(nvml.PERF_POLICY_POWER)
+	if ret == nvml.SUCCESS {
+		power.PowerViolationNanoseconds = violation.ViolationTime
+	} else {
+		log.Logger.Debugw("failed to get device power violation status", "uuid", uuid, "error", nvml.ErrorString(ret))
+	}
+
+	// only supported on Ampere (except GA100) or newer architectures
+	// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlFieldValueQueries.html#group__nvmlFieldValueQueries_1g0b02941a262ee4327eb82831f91a1bc0
+	values := []nvml.FieldValue{{FieldId: nvml.FI_DEV_POWER_AVERAGE}}
+	ret = dev.GetFieldValues(values)
+	if ret == nvml.SUCCESS && nvml.Return(values[0].NvmlReturn) == nvml.SUCCESS {
+		power.AverageUsageMilliWatts = binary.NativeEndian.Uint32(values[0].Value[:4])
+	}
+
 	total := enforcedPowerLimit
 	if total == 0 {
 		total = managementPowerLimit
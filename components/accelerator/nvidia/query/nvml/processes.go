@@ -4,14 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"regexp"
 	"sort"
 	"strings"
 
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/locale"
 
 	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
-	"github.com/dustin/go-humanize"
 	"github.com/shirou/gopsutil/v4/process"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
@@ -27,10 +29,29 @@ type Processes struct {
 
 	// A list of running processes.
 	RunningProcesses []Process `json:"running_processes"`
+
+	// NotRunningProcesses is the processes that NVML reports on the GPU (e.g., holding the GPU memory)
+	// but no longer run on the host, which indicates the leaked GPU contexts
+	// (or GPUd not running in the host PID namespace).
+	// Only the PID, the type, and the GPU used memory are set.
+	NotRunningProcesses []Process `json:"not_running_processes,omitempty"`
 }
 
+const (
+	// ProcessTypeCompute is the process using the GPU for compute (e.g., CUDA).
+	ProcessTypeCompute = "compute"
+	// ProcessTypeGraphics is the process using the GPU for graphics (e.g., OpenGL, Vulkan).
+	ProcessTypeGraphics = "graphics"
+	// ProcessTypeComputeGraphics is the process using the GPU for both compute and graphics.
+	ProcessTypeComputeGraphics = "compute+graphics"
+)
+
 type Process struct {
-	PID    uint32   `json:"pid"`
+	PID uint32 `json:"pid"`
+	// Name is the process name (e.g., "python3").
+	Name string `json:"name,omitempty"`
+	// Type is the process type (e.g., "compute", "graphics").
+	Type   string   `json:"type,omitempty"`
 	Status []string `json:"status,omitempty"`
 
 	// ZombieStatus is set to true if the process is defunct
@@ -43,6 +64,13 @@ type Process struct {
 	// This implements "DCGM_FR_BAD_CUDA_ENV" logic in DCGM.
 	BadEnvVarsForCUDA map[string]string `json:"bad_env_vars_for_cuda,omitempty"`
 
+	// PodUID is the Kubernetes pod UID parsed from the process cgroup.
+	// Empty if the process does not run in a Kubernetes pod.
+	PodUID string `json:"pod_uid,omitempty"`
+	// PodName is the pod name read from the "HOSTNAME" environment variable
+	// of the process (best effort, e.g., the node name for the host network pods).
+	PodName string `json:"pod_name,omitempty"`
+
 	CmdArgs                     []string    `json:"cmd_args,omitempty"`
 	CreateTime                  metav1.Time `json:"create_time,omitempty"`
 	GPUUsedPercent              uint32      `json:"gpu_used_percent,omitempty"`
@@ -69,17 +97,37 @@ func GetProcesses(uuid string, dev device.Device) (Processes, error) {
 		return Processes{}, fmt.Errorf("failed to get device compute processes: %v", nvml.ErrorString(ret))
 	}
 
-	for _, proc := range computeProcs {
+	// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html
+	graphicsProcs, ret := dev.GetGraphicsRunningProcesses()
+	if ret != nvml.SUCCESS {
+		if ret != nvml.ERROR_NOT_SUPPORTED {
+			return Processes{}, fmt.Errorf("failed to get device graphics processes: %v", nvml.ErrorString(ret))
+		}
+		graphicsProcs = nil
+	}
+
+	for _, proc := range mergeProcessInfos(computeProcs, graphicsProcs) {
 		procObject, err := process.NewProcess(int32(proc.Pid))
 		if err != nil {
 			// ref. process does not exist
 			if errors.Is(err, process.ErrorProcessNotRunning) {
 				log.Logger.Debugw("process not running -- skipping", "pid", proc.Pid, "error", err)
+				procs.NotRunningProcesses = append(procs.NotRunningProcesses, Process{
+					PID:                         proc.Pid,
+					Type:                        proc.typ,
+					GPUUsedMemoryBytes:          proc.UsedGpuMemory,
+					GPUUsedMemoryBytesHumanized: locale.Bytes(proc.UsedGpuMemory),
+				})
 				continue
 			}
 			return Processes{}, fmt.Errorf("failed to get process %d: %v", proc.Pid, err)
 		}
 
+		name, err := procObject.Name()
+		if err != nil {
+			log.Logger.Debugw("failed to get process name", "pid", proc.Pid, "error", err)
+		}
+
 		args, err := procObject.CmdlineSlice()
 		if err != nil {
 			return Processes{}, fmt.Errorf("failed to get process %d args: %v", proc.Pid, err)
@@ -133,12 +181,17 @@ func GetProcesses(uuid string, dev device.Device) (Processes, error) {
 			return Processes{}, fmt.Errorf("failed to get process %d environ: %v", proc.Pid, err)
 		}
 
+		hostname := ""
 		badEnvVars := make(map[string]string)
 		for _, env := range envs {
 			parts := strings.SplitN(env, "=", 2)
 			if len(parts) == 2 {
 				key, value := parts[0], parts[1]
 
+				if key == "HOSTNAME" {
+					hostname = value
+				}
+
 				// implementing "DCGM_FR_BAD_CUDA_ENV"
 				if _, ok := BAD_CUDA_ENV_KEYS[key]; ok {
 					badEnvVars[key] = value
@@ -149,14 +202,25 @@ func GetProcesses(uuid string, dev device.Device) (Processes, error) {
 			badEnvVars = nil
 		}
 
+		podUID := readPodUID(proc.Pid)
+		podName := ""
+		if podUID != "" {
+			podName = hostname
+		}
+
 		procs.RunningProcesses = append(procs.RunningProcesses, Process{
-			PID: proc.Pid,
+			PID:  proc.Pid,
+			Name: name,
+			Type: proc.typ,
 
 			Status:       status,
 			ZombieStatus: isZombie,
 
 			BadEnvVarsForCUDA: badEnvVars,
 
+			PodUID:  podUID,
+			PodName: podName,
+
 			CmdArgs:    args,
 			CreateTime: createTime,
 
@@ -165,9 +229,74 @@ func GetProcesses(uuid string, dev device.Device) (Processes, error) {
 			// "Amount of used GPU memory in bytes."
 			// ref. https://docs.nvidia.com/deploy/nvml-api/structnvmlProcessInfo__t.html#structnvmlProcessInfo__t
 			GPUUsedMemoryBytes:          proc.UsedGpuMemory,
-			GPUUsedMemoryBytesHumanized: humanize.Bytes(proc.UsedGpuMemory),
+			GPUUsedMemoryBytesHumanized: locale.Bytes(proc.UsedGpuMemory),
 		})
 	}
 
 	return procs, nil
 }
+
+type processInfo struct {
+	nvml.ProcessInfo
+	typ string
+}
+
+// mergeProcessInfos merges the compute and graphics processes by PID, in the order of the first appearance.
+// NVML lists the process using both (e.g., CUDA and OpenGL) in each, so the larger GPU used memory
+// is kept rather than double counting.
+func mergeProcessInfos(compute []nvml.ProcessInfo, graphics []nvml.ProcessInfo) []processInfo {
+	merged := make([]processInfo, 0, len(compute)+len(graphics))
+	indexes := make(map[uint32]int, len(compute)+len(graphics))
+	for _, procs := range []struct {
+		infos []nvml.ProcessInfo
+		typ   string
+	}{
+		{compute, ProcessTypeCompute},
+		{graphics, ProcessTypeGraphics},
+	} {
+		for _, info := range procs.infos {
+			idx, ok := indexes[info.Pid]
+			if !ok {
+				indexes[info.Pid] = len(merged)
+				merged = append(merged, processInfo{ProcessInfo: info, typ: procs.typ})
+				continue
+			}
+			if merged[idx].typ != procs.typ {
+				merged[idx].typ = ProcessTypeComputeGraphics
+			}
+			if info.UsedGpuMemory > merged[idx].UsedGpuMemory {
+				merged[idx].UsedGpuMemory = info.UsedGpuMemory
+			}
+		}
+	}
+	return merged
+}
+
+// e.g.,
+// "0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod3b5c6f2a_1a2b_4c3d_8e9f_0123456789ab.slice/cri-containerd-...scope" (systemd driver)
+// "12:memory:/kubepods/burstable/pod3b5c6f2a-1a2b-4c3d-8e9f-0123456789ab/..." (cgroupfs driver)
+var regexPodUIDInCgroup = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+
+// ParsePodUIDFromCgroup returns the Kubernetes pod UID from the "/proc/[pid]/cgroup" contents.
+// Returns an empty string if the process does not run in a Kubernetes pod.
+func ParsePodUIDFromCgroup(cgroup string) string {
+	for _, line := range strings.Split(cgroup, "\n") {
+		if !strings.Contains(line, "kubepods") {
+			continue
+		}
+		if match := regexPodUIDInCgroup.FindStringSubmatch(line); match != nil {
+			// systemd cgroup driver escapes "-" with "_"
+			return strings.ReplaceAll(match[1], "_", "-")
+		}
+	}
+	return ""
+}
+
+func readPodUID(pid uint32) string {
+	b, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		log.Logger.Debugw("failed to read process cgroup", "pid", pid, "error", err)
+		return ""
+	}
+	return ParsePodUIDFromCgroup(string(b))
+}
@@ -0,0 +1,73 @@
+package nvml
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+func TestParsePodUIDFromCgroup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		cgroup string
+		want   string
+	}{
+		{
+			name:   "systemd cgroup driver",
+			cgroup: "0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod3b5c6f2a_1a2b_4c3d_8e9f_0123456789ab.slice/cri-containerd-0123.scope\n",
+			want:   "3b5c6f2a-1a2b-4c3d-8e9f-0123456789ab",
+		},
+		{
+			name:   "cgroupfs driver",
+			cgroup: "13:pids:/system.slice\n12:memory:/kubepods/burstable/pod3b5c6f2a-1a2b-4c3d-8e9f-0123456789ab/0123456789\n",
+			want:   "3b5c6f2a-1a2b-4c3d-8e9f-0123456789ab",
+		},
+		{
+			name:   "not in a pod",
+			cgroup: "0::/user.slice/user-1000.slice/session-1.scope\n",
+			want:   "",
+		},
+		{
+			name:   "empty",
+			cgroup: "",
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParsePodUIDFromCgroup(tt.cgroup); got != tt.want {
+				t.Errorf("ParsePodUIDFromCgroup() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeProcessInfos(t *testing.T) {
+	t.Parallel()
+
+	compute := []nvml.ProcessInfo{
+		{Pid: 100, UsedGpuMemory: 1024},
+		{Pid: 200, UsedGpuMemory: 2048},
+	}
+	graphics := []nvml.ProcessInfo{
+		{Pid: 200, UsedGpuMemory: 4096},
+		{Pid: 300, UsedGpuMemory: 512},
+	}
+
+	got := mergeProcessInfos(compute, graphics)
+	want := []processInfo{
+		{ProcessInfo: nvml.ProcessInfo{Pid: 100, UsedGpuMemory: 1024}, typ: ProcessTypeCompute},
+		{ProcessInfo: nvml.ProcessInfo{Pid: 200, UsedGpuMemory: 4096}, typ: ProcessTypeComputeGraphics},
+		{ProcessInfo: nvml.ProcessInfo{Pid: 300, UsedGpuMemory: 512}, typ: ProcessTypeGraphics},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeProcessInfos() = %+v, want %+v", got, want)
+	}
+
+	if got := mergeProcessInfos(nil, nil); len(got) != 0 {
+		t.Errorf("expected no process, got %+v", got)
+	}
+}
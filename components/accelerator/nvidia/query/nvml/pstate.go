@@ -0,0 +1,58 @@
+package nvml
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// PStateUnknown is the performance state when not supported or unknown.
+const PStateUnknown = -1
+
+// PerformanceState represents the data from the nvmlDeviceGetPerformanceState API.
+// The performance state ranges from P0 (maximum performance) to P15 (minimum performance).
+// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html
+type PerformanceState struct {
+	// Represents the GPU UUID.
+	UUID string `json:"uuid"`
+
+	// Set true if the performance state is supported by the device.
+	Supported bool `json:"supported"`
+	// Represents the performance state (e.g., 0 for "P0"), or -1 if unknown.
+	PState int `json:"pstate"`
+}
+
+// String returns the performance state name (e.g., "P0"), or "unknown".
+func (p PerformanceState) String() string {
+	return PStateName(p.PState)
+}
+
+// PStateName returns the performance state name (e.g., "P0"), or "unknown".
+func PStateName(pstate int) string {
+	if pstate < 0 || pstate > 15 {
+		return "unknown"
+	}
+	return fmt.Sprintf("P%d", pstate)
+}
+
+func GetPerformanceState(uuid string, dev device.Device) (PerformanceState, error) {
+	ps := PerformanceState{
+		UUID:   uuid,
+		PState: PStateUnknown,
+	}
+
+	// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html
+	state, ret := dev.GetPerformanceState()
+	if ret == nvml.ERROR_NOT_SUPPORTED {
+		return ps, nil
+	}
+	if ret != nvml.SUCCESS {
+		return ps, fmt.Errorf("failed to get device performance state: %v", nvml.ErrorString(ret))
+	}
+	ps.Supported = true
+	if state != nvml.PSTATE_UNKNOWN {
+		ps.PState = int(state)
+	}
+	return ps, nil
+}
@@ -0,0 +1,71 @@
+package nvml
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// RetiredPages represents the framebuffer pages retired by the dynamic page retirement.
+// Only supported on the pre-Ampere GPUs, where the row remapping replaces the page retirement.
+// ref. https://docs.nvidia.com/deploy/dynamic-page-retirement/index.html
+// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html
+type RetiredPages struct {
+	// Represents the GPU UUID.
+	UUID string `json:"uuid"`
+
+	// Set to true if the GPU supports the dynamic page retirement.
+	Supported bool `json:"supported"`
+
+	// Pages retired due to the multiple single bit ECC errors.
+	MultipleSingleBitECCErrors []RetiredPage `json:"multiple_single_bit_ecc_errors,omitempty"`
+	// Pages retired due to the double bit ECC error.
+	DoubleBitECCErrors []RetiredPage `json:"double_bit_ecc_errors,omitempty"`
+}
+
+type RetiredPage struct {
+	// Physical address of the retired page.
+	Address uint64 `json:"address"`
+	// Timestamp when the page was retired, in unix seconds.
+	Timestamp uint64 `json:"timestamp"`
+}
+
+func GetRetiredPages(uuid string, dev device.Device) (RetiredPages, error) {
+	pages := RetiredPages{
+		UUID: uuid,
+	}
+
+	for _, cause := range []nvml.PageRetirementCause{
+		nvml.PAGE_RETIREMENT_CAUSE_MULTIPLE_SINGLE_BIT_ECC_ERRORS,
+		nvml.PAGE_RETIREMENT_CAUSE_DOUBLE_BIT_ECC_ERROR,
+	} {
+		addrs, timestamps, ret := dev.GetRetiredPages_v2(cause)
+		if ret == nvml.ERROR_NOT_SUPPORTED {
+			return RetiredPages{UUID: uuid}, nil
+		}
+		if ret != nvml.SUCCESS {
+			return RetiredPages{}, fmt.Errorf("failed to get device retired pages: %v", nvml.ErrorString(ret))
+		}
+		pages.Supported = true
+
+		retired := make([]RetiredPage, 0, len(addrs))
+		for i, addr := range addrs {
+			p := RetiredPage{Address: addr}
+			if i < len(timestamps) {
+				p.Timestamp = timestamps[i]
+			}
+			retired = append(retired, p)
+		}
+		if len(retired) == 0 {
+			continue
+		}
+		if cause == nvml.PAGE_RETIREMENT_CAUSE_DOUBLE_BIT_ECC_ERROR {
+			pages.DoubleBitECCErrors = retired
+		} else {
+			pages.MultipleSingleBitECCErrors = retired
+		}
+	}
+
+	return pages, nil
+}
@@ -8,6 +8,7 @@ import (
 	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
 	components_nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
 	"github.com/leptonai/gpud/log"
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
 
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -56,7 +57,8 @@ func (inst *instance) RecvXidEvents() <-chan *XidEvent {
 	inst.mu.RLock()
 	defer inst.mu.RUnlock()
 
-	if inst.nvmlLib == nil {
+	// the channel is kept across the re-initializations
+	if inst.rootCtx.Err() != nil {
 		return nil
 	}
 
@@ -70,13 +72,30 @@ func (inst *instance) RecvXidEvents() <-chan *XidEvent {
 // ref. https://github.com/NVIDIA/go-nvml/blob/main/gen/nvml/nvml.h
 const defaultXidEventMask = uint64(nvml.EventTypeAll)
 
+// xidDoubleBitECCError is the Xid for the uncorrectable double bit ECC errors (DBE).
+const xidDoubleBitECCError = 48
+
+// eventXid returns the Xid of the NVML event.
+// The double bit ECC error event does not carry the Xid in its event data,
+// so it is reported as Xid 48 (DBE), in order to be captured as soon as the event fires.
+// Returns false for the other events without the Xid (e.g., clock, pstate changes).
+func eventXid(eventType uint64, eventData uint64) (uint64, bool) {
+	if eventData != 0 {
+		return eventData, true
+	}
+	if eventType&nvml.EventTypeDoubleBitEccError != 0 {
+		return xidDoubleBitECCError, true
+	}
+	return 0, false
+}
+
 // ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlEvents.html#group__nvmlEvents
-func (inst *instance) pollXidEvents() {
+func (inst *instance) pollXidEvents(ctx context.Context, eventSet nvml.EventSet) {
 	log.Logger.Debugw("polling xid events")
 
 	for {
 		select {
-		case <-inst.rootCtx.Done():
+		case <-ctx.Done():
 			return
 		default:
 		}
@@ -89,7 +108,7 @@ func (inst *instance) pollXidEvents() {
 
 		// waits 5 seconds
 		// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlEvents.html#group__nvmlEvents
-		e, ret := inst.xidEventSet.Wait(5000)
+		e, ret := eventSet.Wait(5000)
 
 		if ret == nvml.ERROR_NOT_SUPPORTED {
 			log.Logger.Warnw("xid events not supported -- skipping", "error", nvml.ErrorString(ret))
@@ -101,10 +120,17 @@ func (inst *instance) pollXidEvents() {
 			continue
 		}
 
+		// the event set is no longer valid (e.g., driver reloaded),
+		// the poller is restarted with the new event set on re-initialization
+		if pkg_nvml.Default().Check(ret) {
+			log.Logger.Warnw("event set invalidated -- stopping xid poller", "error", nvml.ErrorString(ret))
+			return
+		}
+
 		if ret != nvml.SUCCESS {
 			log.Logger.Warnw("notifying event set wait failure", "error", nvml.ErrorString(ret))
 			select {
-			case <-inst.rootCtx.Done():
+			case <-ctx.Done():
 				return
 
 			case inst.xidEventCh <- &XidEvent{
@@ -120,10 +146,9 @@ func (inst *instance) pollXidEvents() {
 			continue
 		}
 
-		xid := e.EventData
-
-		if xid == 0 {
-			log.Logger.Debugw("received xid 0 as an event -- skipping", "return", ret)
+		xid, ok := eventXid(e.EventType, e.EventData)
+		if !ok {
+			log.Logger.Debugw("received non-critical event without xid -- skipping", "eventType", e.EventType, "eventData", e.EventData)
 			continue
 		}
 
@@ -168,13 +193,13 @@ func (inst *instance) pollXidEvents() {
 		log.Logger.Warnw("detected xid event", "xid", xid, "message", msg)
 
 		// no need to check duplicate entries, assuming nvml event poller does not return old events
-		ctx, cancel := context.WithTimeout(inst.rootCtx, 10*time.Second)
-		werr := components_nvidia_xid_sxid_state.InsertEvent(ctx, inst.db, components_nvidia_xid_sxid_state.Event{
+		cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		werr := components_nvidia_xid_sxid_state.InsertEvent(cctx, inst.db, components_nvidia_xid_sxid_state.Event{
 			UnixSeconds:  event.Time.Unix(),
 			DataSource:   "nvml",
 			EventType:    "xid",
 			EventID:      int64(event.Xid),
-			EventDetails: "",
+			EventDetails: event.DeviceUUID,
 		})
 		cancel()
 		if werr != nil {
@@ -182,7 +207,7 @@ func (inst *instance) pollXidEvents() {
 		}
 
 		select {
-		case <-inst.rootCtx.Done():
+		case <-ctx.Done():
 			return
 		case inst.xidEventCh <- event:
 			log.Logger.Warnw("notified xid event", "event", event)
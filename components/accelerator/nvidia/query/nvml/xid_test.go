@@ -0,0 +1,31 @@
+package nvml
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+func TestEventXid(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType uint64
+		eventData uint64
+		wantXid   uint64
+		wantOK    bool
+	}{
+		{name: "xid critical error", eventType: nvml.EventTypeXidCriticalError, eventData: 79, wantXid: 79, wantOK: true},
+		{name: "double bit ecc error", eventType: nvml.EventTypeDoubleBitEccError, wantXid: 48, wantOK: true},
+		{name: "single bit ecc error", eventType: nvml.EventTypeSingleBitEccError},
+		{name: "pstate", eventType: nvml.EventTypePState},
+		{name: "xid critical error without xid", eventType: nvml.EventTypeXidCriticalError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xid, ok := eventXid(tt.eventType, tt.eventData)
+			if xid != tt.wantXid || ok != tt.wantOK {
+				t.Errorf("eventXid() = (%d, %v), want (%d, %v)", xid, ok, tt.wantXid, tt.wantOK)
+			}
+		})
+	}
+}
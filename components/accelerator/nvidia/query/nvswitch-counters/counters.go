@@ -0,0 +1,306 @@
+// Package nvswitchcounters polls the per-port NVSwitch error counters, to quantify the NVLink health
+// between the SXid occurrences (e.g., the replay and the CRC errors increasing on a port before
+// the link goes down). The counters are read from the NSCQ library or the fabric manager API
+// through a helper command, since neither is exposed via NVML.
+package nvswitchcounters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/execlimit"
+	"github.com/leptonai/gpud/pkg/file"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultInterval = time.Minute
+	DefaultTimeout  = 30 * time.Second
+
+	// DefaultWindow is the period to evaluate the counter increments over.
+	DefaultWindow = time.Hour
+)
+
+// Config defines the NVSwitch port counter polling.
+type Config struct {
+	// Command is the helper command (and its arguments) that reads the counters
+	// from the NSCQ library or the fabric manager API, and prints the JSON list of the port counters
+	// to the standard output, e.g.,
+	//
+	//	[{"switch": "0000:86:00.0", "port": 33, "fatal_errors": 0, "replay_errors": 12, ...}]
+	//
+	// The counters must be cumulative (e.g., since the driver load).
+	Command []string `json:"command"`
+	// Interval is the polling interval.
+	Interval metav1.Duration `json:"interval"`
+	// Timeout is the timeout of a single command run.
+	Timeout metav1.Duration `json:"timeout"`
+	// Window is the period to evaluate the counter increments over.
+	Window metav1.Duration `json:"window"`
+	// MaxLinkErrorsPerWindow is the maximum link errors (replay, recovery, and CRC) of a port
+	// within the window, above which the port is reported unhealthy.
+	// Zero to only report the increments without marking unhealthy.
+	MaxLinkErrorsPerWindow uint64 `json:"max_link_errors_per_window"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.Interval.Duration == 0 {
+		cfg.Interval.Duration = DefaultInterval
+	}
+	if cfg.Timeout.Duration == 0 {
+		cfg.Timeout.Duration = DefaultTimeout
+	}
+	if cfg.Window.Duration == 0 {
+		cfg.Window.Duration = DefaultWindow
+	}
+}
+
+func (cfg Config) Validate() error {
+	if len(cfg.Command) == 0 || cfg.Command[0] == "" {
+		return errors.New("nvswitch port counters command is required")
+	}
+	if cfg.Interval.Duration < 0 || cfg.Timeout.Duration < 0 || cfg.Window.Duration < 0 {
+		return errors.New("nvswitch port counters interval, timeout, and window must be non-negative")
+	}
+	return nil
+}
+
+// PortCounters is the cumulative error counters of an NVSwitch port (or the increments, see "Sub").
+type PortCounters struct {
+	// Switch is the NVSwitch identifier (e.g., the PCI bus ID "0000:86:00.0").
+	Switch string `json:"switch"`
+	// Port is the NVSwitch port (link) number.
+	Port int `json:"port"`
+
+	FatalErrors    uint64 `json:"fatal_errors"`
+	NonFatalErrors uint64 `json:"non_fatal_errors"`
+	ReplayErrors   uint64 `json:"replay_errors"`
+	RecoveryErrors uint64 `json:"recovery_errors"`
+	FlitCRCErrors  uint64 `json:"flit_crc_errors"`
+	DataCRCErrors  uint64 `json:"data_crc_errors"`
+	ECCErrors      uint64 `json:"ecc_errors"`
+}
+
+// Key returns the switch and the port (e.g., "0000:86:00.0/33").
+func (c PortCounters) Key() string {
+	return fmt.Sprintf("%s/%d", c.Switch, c.Port)
+}
+
+// LinkErrors returns the sum of the link-level errors (replay, recovery, and CRC),
+// which the link recovers from but indicates the degrading link (e.g., the cable or the connector).
+func (c PortCounters) LinkErrors() uint64 {
+	return c.ReplayErrors + c.RecoveryErrors + c.FlitCRCErrors + c.DataCRCErrors
+}
+
+// IsZero returns true if no error is counted.
+func (c PortCounters) IsZero() bool {
+	return c.FatalErrors == 0 && c.NonFatalErrors == 0 && c.ECCErrors == 0 && c.LinkErrors() == 0
+}
+
+// Sub returns the increments since the previous counters of the same port.
+// Returns false if any counter decreased (e.g., the counters reset on the driver reload),
+// in which case the current counters are the increments since the reset.
+func (c PortCounters) Sub(prev PortCounters) (PortCounters, bool) {
+	if c.FatalErrors < prev.FatalErrors ||
+		c.NonFatalErrors < prev.NonFatalErrors ||
+		c.ReplayErrors < prev.ReplayErrors ||
+		c.RecoveryErrors < prev.RecoveryErrors ||
+		c.FlitCRCErrors < prev.FlitCRCErrors ||
+		c.DataCRCErrors < prev.DataCRCErrors ||
+		c.ECCErrors < prev.ECCErrors {
+		return c, false
+	}
+	return PortCounters{
+		Switch:         c.Switch,
+		Port:           c.Port,
+		FatalErrors:    c.FatalErrors - prev.FatalErrors,
+		NonFatalErrors: c.NonFatalErrors - prev.NonFatalErrors,
+		ReplayErrors:   c.ReplayErrors - prev.ReplayErrors,
+		RecoveryErrors: c.RecoveryErrors - prev.RecoveryErrors,
+		FlitCRCErrors:  c.FlitCRCErrors - prev.FlitCRCErrors,
+		DataCRCErrors:  c.DataCRCErrors - prev.DataCRCErrors,
+		ECCErrors:      c.ECCErrors - prev.ECCErrors,
+	}, true
+}
+
+func (c PortCounters) add(o PortCounters) PortCounters {
+	c.FatalErrors += o.FatalErrors
+	c.NonFatalErrors += o.NonFatalErrors
+	c.ReplayErrors += o.ReplayErrors
+	c.RecoveryErrors += o.RecoveryErrors
+	c.FlitCRCErrors += o.FlitCRCErrors
+	c.DataCRCErrors += o.DataCRCErrors
+	c.ECCErrors += o.ECCErrors
+	return c
+}
+
+// Parse parses the JSON list of the port counters printed by the helper command.
+func Parse(b []byte) ([]PortCounters, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, errors.New("empty nvswitch port counters output")
+	}
+	var cs []PortCounters
+	if err := json.Unmarshal(b, &cs); err != nil {
+		return nil, fmt.Errorf("failed to parse nvswitch port counters: %w", err)
+	}
+	return cs, nil
+}
+
+// Collect runs the helper command and returns the port counters.
+func Collect(ctx context.Context, command []string) ([]PortCounters, error) {
+	p, err := file.LocateExecutable(command[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s not found (%w)", command[0], err)
+	}
+	if err := execlimit.Wait(ctx, command[0]); err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p, command[1:]...)
+	cmd.Stderr = &stderr
+	b, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w (%s)", strings.Join(command, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return Parse(b)
+}
+
+// Status is the port counter increments within the window.
+type Status struct {
+	// Time is the last poll time.
+	Time metav1.Time `json:"time"`
+	// Window is the period of the increments.
+	Window metav1.Duration `json:"window"`
+	// Ports is the number of the polled ports.
+	Ports int `json:"ports"`
+	// Increments is the counter increments of the ports with any error within the window,
+	// sorted by the switch and the port.
+	Increments []PortCounters `json:"increments,omitempty"`
+	// Error is the last poll error, if any.
+	Error string `json:"error,omitempty"`
+}
+
+type sample struct {
+	time   time.Time
+	deltas []PortCounters
+}
+
+// Tracker keeps the last counters of each port and the increments within the window.
+type Tracker struct {
+	window time.Duration
+
+	mu      sync.RWMutex
+	last    map[string]PortCounters
+	samples []sample
+	status  Status
+}
+
+func NewTracker(window time.Duration) *Tracker {
+	return &Tracker{
+		window: window,
+		last:   make(map[string]PortCounters),
+	}
+}
+
+// Observe records the polled counters, and returns the non-zero increments since the last poll.
+// The first poll of a port only records the baseline, since the counters are cumulative
+// since the driver load (possibly days ago).
+func (t *Tracker) Observe(now time.Time, counters []PortCounters, pollErr error) []PortCounters {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.Time = metav1.Time{Time: now}
+	t.status.Window = metav1.Duration{Duration: t.window}
+	t.status.Error = ""
+	if pollErr != nil {
+		t.status.Error = pollErr.Error()
+		return nil
+	}
+
+	var deltas []PortCounters
+	for _, c := range counters {
+		prev, ok := t.last[c.Key()]
+		t.last[c.Key()] = c
+		if !ok {
+			continue
+		}
+		d, _ := c.Sub(prev)
+		if !d.IsZero() {
+			deltas = append(deltas, d)
+		}
+	}
+	t.samples = append(t.samples, sample{time: now, deltas: deltas})
+
+	// purge the samples out of the window
+	cutoff := now.Add(-t.window)
+	i := 0
+	for i < len(t.samples) && t.samples[i].time.Before(cutoff) {
+		i++
+	}
+	t.samples = t.samples[i:]
+
+	sums := make(map[string]PortCounters)
+	for _, s := range t.samples {
+		for _, d := range s.deltas {
+			sum, ok := sums[d.Key()]
+			if !ok {
+				sum = PortCounters{Switch: d.Switch, Port: d.Port}
+			}
+			sums[d.Key()] = sum.add(d)
+		}
+	}
+	incs := make([]PortCounters, 0, len(sums))
+	for _, s := range sums {
+		incs = append(incs, s)
+	}
+	sort.Slice(incs, func(i, j int) bool {
+		if incs[i].Switch != incs[j].Switch {
+			return incs[i].Switch < incs[j].Switch
+		}
+		return incs[i].Port < incs[j].Port
+	})
+	t.status.Ports = len(counters)
+	t.status.Increments = incs
+
+	return deltas
+}
+
+// Status returns the last status, or nil if not polled yet.
+func (t *Tracker) Status() *Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.status.Time.IsZero() {
+		return nil
+	}
+	s := t.status
+	s.Increments = append([]PortCounters(nil), t.status.Increments...)
+	return &s
+}
+
+// Evaluate returns the unhealthy reasons of the status,
+// that is, the ports with the fatal errors or the link errors above the threshold within the window.
+func (s *Status) Evaluate(maxLinkErrorsPerWindow uint64) []string {
+	var reasons []string
+	for _, inc := range s.Increments {
+		if inc.FatalErrors > 0 {
+			reasons = append(reasons, fmt.Sprintf("nvswitch %s port %d: %d fatal errors", inc.Switch, inc.Port, inc.FatalErrors))
+			continue
+		}
+		if maxLinkErrorsPerWindow > 0 && inc.LinkErrors() > maxLinkErrorsPerWindow {
+			reasons = append(reasons, fmt.Sprintf("nvswitch %s port %d: %d link errors (threshold %d)", inc.Switch, inc.Port, inc.LinkErrors(), maxLinkErrorsPerWindow))
+		}
+	}
+	return reasons
+}
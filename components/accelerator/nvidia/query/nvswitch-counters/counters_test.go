@@ -0,0 +1,76 @@
+package nvswitchcounters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerObserve(t *testing.T) {
+	base := time.Unix(1700000000, 0).UTC()
+	tr := NewTracker(time.Hour)
+	if tr.Status() != nil {
+		t.Fatal("expected nil status before the first poll")
+	}
+
+	// first poll only records the baseline
+	deltas := tr.Observe(base, []PortCounters{
+		{Switch: "0000:86:00.0", Port: 33, ReplayErrors: 100},
+		{Switch: "0000:86:00.0", Port: 34},
+	}, nil)
+	if len(deltas) != 0 {
+		t.Fatalf("expected no deltas on the first poll, got %+v", deltas)
+	}
+
+	deltas = tr.Observe(base.Add(time.Minute), []PortCounters{
+		{Switch: "0000:86:00.0", Port: 33, ReplayErrors: 110, FlitCRCErrors: 5},
+		{Switch: "0000:86:00.0", Port: 34},
+	}, nil)
+	if len(deltas) != 1 || deltas[0].ReplayErrors != 10 || deltas[0].LinkErrors() != 15 {
+		t.Fatalf("unexpected deltas %+v", deltas)
+	}
+
+	// counters reset (e.g., driver reload), the current counters are the increments
+	deltas = tr.Observe(base.Add(2*time.Minute), []PortCounters{
+		{Switch: "0000:86:00.0", Port: 33, ReplayErrors: 3},
+		{Switch: "0000:86:00.0", Port: 34, FatalErrors: 1},
+	}, nil)
+	if len(deltas) != 2 {
+		t.Fatalf("unexpected deltas %+v", deltas)
+	}
+
+	s := tr.Status()
+	if s == nil || s.Ports != 2 || len(s.Increments) != 2 {
+		t.Fatalf("unexpected status %+v", s)
+	}
+	if s.Increments[0].Port != 33 || s.Increments[0].LinkErrors() != 18 {
+		t.Errorf("unexpected increments %+v", s.Increments[0])
+	}
+	if reasons := s.Evaluate(0); len(reasons) != 1 {
+		t.Errorf("expected only the fatal error reported, got %v", reasons)
+	}
+	if reasons := s.Evaluate(10); len(reasons) != 2 {
+		t.Errorf("expected the fatal and the link errors reported, got %v", reasons)
+	}
+
+	// increments out of the window are purged
+	tr.Observe(base.Add(2*time.Hour), []PortCounters{
+		{Switch: "0000:86:00.0", Port: 33, ReplayErrors: 3},
+		{Switch: "0000:86:00.0", Port: 34, FatalErrors: 1},
+	}, nil)
+	if s := tr.Status(); len(s.Increments) != 0 {
+		t.Errorf("expected no increments within the window, got %+v", s.Increments)
+	}
+}
+
+func TestParse(t *testing.T) {
+	cs, err := Parse([]byte(`[{"switch": "0000:86:00.0", "port": 33, "replay_errors": 12, "data_crc_errors": 1}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) != 1 || cs[0].Key() != "0000:86:00.0/33" || cs[0].LinkErrors() != 13 {
+		t.Errorf("unexpected counters %+v", cs)
+	}
+	if _, err := Parse([]byte("  ")); err == nil {
+		t.Error("expected error for the empty output")
+	}
+}
@@ -0,0 +1,212 @@
+// Package pcieaer parses and persists the PCIe Advanced Error Reporting (AER) errors from the dmesg,
+// to correlate them with the GPU errors (e.g., Xid 79 "GPU has fallen off the bus").
+package pcieaer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// e.g.,
+	// pcieport 0000:00:01.1: AER: Uncorrected (Fatal) error received: 0000:05:00.0
+	// nvidia 0000:05:00.0: AER: PCIe Bus Error: severity=Uncorrected (Fatal), type=Transaction Layer, (Receiver ID)
+	// pcieport 0000:00:01.1: AER: Corrected error received: 0000:05:00.0
+	//
+	// ref. https://docs.kernel.org/PCI/pcieaer-howto.html
+	RegexPCIeAERDmesg = `AER: (?:.*error received|PCIe Bus Error)`
+
+	// e.g., "0000:05:00.0" (domain:bus:device.function)
+	regexBDF = `[0-9a-fA-F]{4,8}:[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]`
+)
+
+var (
+	CompiledRegexPCIeAERDmesg = regexp.MustCompile(RegexPCIeAERDmesg)
+
+	compiledRegexReceived = regexp.MustCompile(`error received: (` + regexBDF + `)`)
+	compiledRegexReporter = regexp.MustCompile(`(` + regexBDF + `): AER:`)
+)
+
+const (
+	SeverityFatal     = "fatal"
+	SeverityNonFatal  = "non_fatal"
+	SeverityCorrected = "corrected"
+)
+
+// Error is a PCIe AER error of a device.
+type Error struct {
+	UnixSeconds int64
+	// BDF is the PCI address of the erroring device (e.g., "0000:05:00.0").
+	BDF      string
+	Severity string
+	Line     string
+}
+
+// ParseDmesgLine parses the AER dmesg line.
+// Returns false if the line is not an AER error line or the device is not found.
+func ParseDmesgLine(ts time.Time, line string) (Error, bool) {
+	if !CompiledRegexPCIeAERDmesg.MatchString(line) {
+		return Error{}, false
+	}
+
+	// the root port reports the error received from the downstream device,
+	// otherwise the device reports its own error
+	bdf := ""
+	if m := compiledRegexReceived.FindStringSubmatch(line); m != nil {
+		bdf = m[1]
+	} else if m := compiledRegexReporter.FindStringSubmatch(line); m != nil {
+		bdf = m[1]
+	}
+	if bdf == "" {
+		return Error{}, false
+	}
+
+	severity := SeverityCorrected
+	switch {
+	case strings.Contains(line, "(Non-Fatal)"):
+		severity = SeverityNonFatal
+	case strings.Contains(line, "(Fatal)"):
+		severity = SeverityFatal
+	}
+
+	return Error{
+		UnixSeconds: ts.UTC().Unix(),
+		BDF:         strings.ToLower(bdf),
+		Severity:    severity,
+		Line:        line,
+	}, true
+}
+
+// SameDevice returns true if the AER error is on the device of the bus ID
+// in the Xid dmesg format (e.g., "0000:05:00" or "00000000:05:00"), regardless of the function.
+func (e Error) SameDevice(busID string) bool {
+	return NormalizeDevice(e.BDF) == NormalizeDevice(busID)
+}
+
+// NormalizeDevice returns the "domain:bus:device" with the 4-digit domain, without the function.
+func NormalizeDevice(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if i := strings.LastIndex(id, "."); i >= 0 {
+		id = id[:i]
+	}
+	parts := strings.Split(id, ":")
+	if len(parts) != 3 {
+		return id
+	}
+	domain := strings.TrimLeft(parts[0], "0")
+	if len(domain) < 4 {
+		domain = strings.Repeat("0", 4-len(domain)) + domain
+	}
+	return domain + ":" + parts[1] + ":" + parts[2]
+}
+
+const TableNamePCIeAERErrors = "components_accelerator_nvidia_query_pcie_aer_errors"
+
+const (
+	// unix timestamp in seconds when the error was logged
+	ColumnUnixSeconds = "unix_seconds"
+
+	// PCI address of the erroring device
+	ColumnBDF = "bdf"
+
+	// "fatal", "non_fatal", or "corrected"
+	ColumnSeverity = "severity"
+
+	// dmesg log line
+	ColumnLine = "line"
+)
+
+func CreateTablePCIeAERErrors(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL
+);`, TableNamePCIeAERErrors,
+		ColumnUnixSeconds,
+		ColumnBDF,
+		ColumnSeverity,
+		ColumnLine,
+	))
+	return err
+}
+
+// InsertError inserts the AER error, unless the same line was already recorded at the same time
+// (e.g., the dmesg re-read after a restart).
+// Returns false if the error already exists.
+func InsertError(ctx context.Context, db *sql.DB, e Error) (bool, error) {
+	var cnt int
+	if err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s = ? AND %s = ?`,
+		TableNamePCIeAERErrors, ColumnUnixSeconds, ColumnLine,
+	), e.UnixSeconds, e.Line).Scan(&cnt); err != nil {
+		return false, err
+	}
+	if cnt > 0 {
+		return false, nil
+	}
+
+	log.Logger.Debugw("inserting pcie aer error", "bdf", e.BDF, "severity", e.Severity)
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?);
+`,
+		TableNamePCIeAERErrors,
+		ColumnUnixSeconds,
+		ColumnBDF,
+		ColumnSeverity,
+		ColumnLine,
+	), e.UnixSeconds, e.BDF, e.Severity, e.Line)
+	return err == nil, err
+}
+
+// ReadErrors returns the AER errors since the given time, in the ascending order of the time.
+// Returns nil if no error is found.
+func ReadErrors(ctx context.Context, db *sql.DB, since time.Time) ([]Error, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT %s, %s, %s, %s
+FROM %s
+WHERE %s >= ?
+ORDER BY %s ASC`,
+		ColumnUnixSeconds, ColumnBDF, ColumnSeverity, ColumnLine,
+		TableNamePCIeAERErrors,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+	), since.UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var errs []Error
+	for rows.Next() {
+		var e Error
+		if err := rows.Scan(&e.UnixSeconds, &e.BDF, &e.Severity, &e.Line); err != nil {
+			return nil, err
+		}
+		errs = append(errs, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// Purge deletes the AER errors before the given time.
+func Purge(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?`, TableNamePCIeAERErrors, ColumnUnixSeconds), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
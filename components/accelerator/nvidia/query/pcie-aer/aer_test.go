@@ -0,0 +1,116 @@
+package pcieaer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestParseDmesgLine(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Unix(1700000000, 0)
+	tests := []struct {
+		line         string
+		wantOK       bool
+		wantBDF      string
+		wantSeverity string
+	}{
+		{
+			line:         "pcieport 0000:00:01.1: AER: Uncorrected (Fatal) error received: 0000:05:00.0",
+			wantOK:       true,
+			wantBDF:      "0000:05:00.0",
+			wantSeverity: SeverityFatal,
+		},
+		{
+			line:         "nvidia 0000:0B:00.0: AER: PCIe Bus Error: severity=Uncorrected (Non-Fatal), type=Transaction Layer, (Requester ID)",
+			wantOK:       true,
+			wantBDF:      "0000:0b:00.0",
+			wantSeverity: SeverityNonFatal,
+		},
+		{
+			line:         "pcieport 0000:00:01.1: AER: Corrected error received: 0000:05:00.0",
+			wantOK:       true,
+			wantBDF:      "0000:05:00.0",
+			wantSeverity: SeverityCorrected,
+		},
+		{
+			line: "nvidia 0000:05:00.0: AER:   device [10de:2330] error status/mask=00004000/00000000",
+		},
+		{
+			line: "NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus.",
+		},
+	}
+	for _, tt := range tests {
+		e, ok := ParseDmesgLine(ts, tt.line)
+		if ok != tt.wantOK {
+			t.Errorf("%q: ok = %v, want %v", tt.line, ok, tt.wantOK)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if e.BDF != tt.wantBDF || e.Severity != tt.wantSeverity || e.UnixSeconds != ts.Unix() {
+			t.Errorf("%q: unexpected error %+v", tt.line, e)
+		}
+	}
+}
+
+func TestSameDevice(t *testing.T) {
+	t.Parallel()
+
+	e := Error{BDF: "0000:05:00.0"}
+	for _, busID := range []string{"0000:05:00", "00000000:05:00", "0000:05:00.1"} {
+		if !e.SameDevice(busID) {
+			t.Errorf("expected %q to be the same device", busID)
+		}
+	}
+	if e.SameDevice("0000:06:00") {
+		t.Error("expected a different device")
+	}
+}
+
+func TestInsertReadPurge(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTablePCIeAERErrors(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	base := time.Unix(1700000000, 0).UTC()
+	e1, _ := ParseDmesgLine(base, "pcieport 0000:00:01.1: AER: Uncorrected (Fatal) error received: 0000:05:00.0")
+	e2, _ := ParseDmesgLine(base.Add(time.Hour), "pcieport 0000:00:01.1: AER: Corrected error received: 0000:0b:00.0")
+	for _, e := range []Error{e1, e2} {
+		inserted, err := InsertError(ctx, db, e)
+		if err != nil || !inserted {
+			t.Fatalf("expected inserted, got %v, %v", inserted, err)
+		}
+	}
+	if inserted, err := InsertError(ctx, db, e1); err != nil || inserted {
+		t.Fatalf("expected the duplicate skipped, got %v, %v", inserted, err)
+	}
+
+	errs, err := ReadErrors(ctx, db, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 2 || errs[0] != e1 || errs[1] != e2 {
+		t.Fatalf("unexpected errors %+v", errs)
+	}
+
+	purged, err := Purge(ctx, db, base.Add(time.Minute))
+	if err != nil || purged != 1 {
+		t.Fatalf("expected 1 purged, got %d, %v", purged, err)
+	}
+}
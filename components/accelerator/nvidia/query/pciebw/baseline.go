@@ -0,0 +1,105 @@
+package pciebw
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const TableNamePCIeBaseline = "components_accelerator_nvidia_query_pcie_baseline"
+
+const (
+	// PCI bus ID of the GPU slot (primary key)
+	ColumnBusID = "bus_id"
+
+	// GPU UUID in the slot when the baseline was recorded
+	ColumnGPUUUID = "gpu_uuid"
+
+	// baseline bandwidths in GB/s
+	ColumnH2DGBps = "h2d_gbps"
+	ColumnD2HGBps = "d2h_gbps"
+
+	// unix timestamp in seconds when the baseline was recorded
+	ColumnUnixSeconds = "unix_seconds"
+)
+
+// Baseline is the known-good PCIe bandwidth of a GPU slot.
+// Keyed by the slot (PCI bus ID) rather than the GPU, since the riser/cable
+// of the slot determines the link quality.
+type Baseline struct {
+	BusID     string    `json:"bus_id"`
+	GPUUUID   string    `json:"gpu_uuid"`
+	H2DGBps   float64   `json:"h2d_gbps"`
+	D2HGBps   float64   `json:"d2h_gbps"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func CreateTableBaseline(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s TEXT NOT NULL PRIMARY KEY,
+	%s TEXT NOT NULL,
+	%s REAL NOT NULL,
+	%s REAL NOT NULL,
+	%s INTEGER NOT NULL
+);`, TableNamePCIeBaseline,
+		ColumnBusID,
+		ColumnGPUUUID,
+		ColumnH2DGBps,
+		ColumnD2HGBps,
+		ColumnUnixSeconds,
+	))
+	return err
+}
+
+// SetBaseline inserts or replaces the baseline of the slot.
+func SetBaseline(ctx context.Context, db *sql.DB, b Baseline) error {
+	if b.UpdatedAt.IsZero() {
+		b.UpdatedAt = time.Now()
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`INSERT OR REPLACE INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?);`,
+		TableNamePCIeBaseline,
+		ColumnBusID,
+		ColumnGPUUUID,
+		ColumnH2DGBps,
+		ColumnD2HGBps,
+		ColumnUnixSeconds,
+	), b.BusID, b.GPUUUID, b.H2DGBps, b.D2HGBps, b.UpdatedAt.UTC().Unix())
+	return err
+}
+
+// ReadBaselines returns the baselines by the PCI bus ID.
+func ReadBaselines(ctx context.Context, db *sql.DB) (map[string]Baseline, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT %s, %s, %s, %s, %s FROM %s;`,
+		ColumnBusID,
+		ColumnGPUUUID,
+		ColumnH2DGBps,
+		ColumnD2HGBps,
+		ColumnUnixSeconds,
+		TableNamePCIeBaseline,
+	))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	baselines := make(map[string]Baseline)
+	for rows.Next() {
+		var (
+			b        Baseline
+			unixSecs int64
+		)
+		if err := rows.Scan(&b.BusID, &b.GPUUUID, &b.H2DGBps, &b.D2HGBps, &unixSecs); err != nil {
+			return nil, err
+		}
+		b.UpdatedAt = time.Unix(unixSecs, 0).UTC()
+		baselines[b.BusID] = b
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return baselines, nil
+}
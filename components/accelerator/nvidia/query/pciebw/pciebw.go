@@ -0,0 +1,143 @@
+// Package pciebw runs the PCIe bandwidth stress test per GPU slot and compares the results
+// against the slot baselines, e.g., to confirm the riser/cable repairs fixed the degraded links.
+package pciebw
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+// TestName is the test name recorded in the GPU ledger.
+const TestName = "pcie_bandwidth"
+
+const (
+	DefaultIterations       = 5
+	DefaultTolerancePercent = 10
+	DefaultTimeout          = 10 * time.Minute
+)
+
+// Command returns the CUDA sample bandwidth test command for the GPU index,
+// which measures the pinned host-to-device and device-to-host copy bandwidths over the PCIe link.
+// ref. https://github.com/NVIDIA/cuda-samples/tree/master/Samples/1_Utilities/bandwidthTest
+func Command(index int) []string {
+	return []string{"bandwidthTest", "--device=" + strconv.Itoa(index), "--memory=pinned", "--htod", "--dtoh", "--csv"}
+}
+
+// ParseOutput parses the host-to-device and device-to-host bandwidths in GB/s
+// from the bandwidth test CSV output lines, for example:
+//
+//	bandwidthTest-H2D-Pinned, Bandwidth = 24.6 GB/s, Size = 32000000 bytes, NumDevsUsed = 1
+//	bandwidthTest-D2H-Pinned, Bandwidth = 26.3 GB/s, Size = 32000000 bytes, NumDevsUsed = 1
+func ParseOutput(b []byte) (float64, float64, error) {
+	var h2d, d2h float64
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var dst *float64
+		switch {
+		case strings.HasPrefix(line, "bandwidthTest-H2D"):
+			dst = &h2d
+		case strings.HasPrefix(line, "bandwidthTest-D2H"):
+			dst = &d2h
+		default:
+			continue
+		}
+		for _, field := range strings.Split(line, ",") {
+			k, v, ok := strings.Cut(strings.TrimSpace(field), "=")
+			if !ok || strings.TrimSpace(k) != "Bandwidth" {
+				continue
+			}
+			f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), " GB/s"), 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to parse bandwidth %q: %w", line, err)
+			}
+			*dst = f
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	if h2d == 0 || d2h == 0 {
+		return 0, 0, errors.New("no bandwidth found in the output")
+	}
+	return h2d, d2h, nil
+}
+
+// Measurement is the PCIe bandwidth stress test result of a GPU slot.
+type Measurement struct {
+	Link nvidia_query_nvml.PCIeLink `json:"link"`
+
+	Iterations int `json:"iterations"`
+	// Average bandwidths in GB/s across the iterations.
+	H2DGBps float64 `json:"h2d_gbps"`
+	D2HGBps float64 `json:"d2h_gbps"`
+	// Minimum bandwidths in GB/s across the iterations,
+	// a flapping link shows a large gap from the average.
+	MinH2DGBps float64 `json:"min_h2d_gbps"`
+	MinD2HGBps float64 `json:"min_d2h_gbps"`
+}
+
+// Measure runs the bandwidth test on the GPU for the iterations,
+// and reports the progress after each iteration (if not nil).
+func Measure(ctx context.Context, link nvidia_query_nvml.PCIeLink, iterations int, progress func(iteration int, h2d float64, d2h float64)) (Measurement, error) {
+	m := Measurement{Link: link, Iterations: iterations}
+	if iterations <= 0 {
+		return m, errors.New("iterations must be positive")
+	}
+
+	args := Command(link.Index)
+	for i := 0; i < iterations; i++ {
+		b, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+		if err != nil {
+			return m, fmt.Errorf("%q failed: %w (%s)", strings.Join(args, " "), err, strings.TrimSpace(string(b)))
+		}
+		h2d, d2h, err := ParseOutput(b)
+		if err != nil {
+			return m, err
+		}
+		if progress != nil {
+			progress(i+1, h2d, d2h)
+		}
+
+		m.H2DGBps += h2d / float64(iterations)
+		m.D2HGBps += d2h / float64(iterations)
+		if i == 0 || h2d < m.MinH2DGBps {
+			m.MinH2DGBps = h2d
+		}
+		if i == 0 || d2h < m.MinD2HGBps {
+			m.MinD2HGBps = d2h
+		}
+	}
+	return m, nil
+}
+
+// Compare compares the measurement against the slot baseline (nil if none),
+// and returns false with the reasons if the link is degraded or the bandwidth
+// is lower than the baseline by more than the tolerance.
+func Compare(m Measurement, baseline *Baseline, tolerancePercent float64) (bool, []string) {
+	var reasons []string
+	if m.Link.Degraded() {
+		reasons = append(reasons, fmt.Sprintf("link width x%d below max x%d", m.Link.CurrentWidth, m.Link.MaxWidth))
+	}
+	if baseline == nil {
+		return len(reasons) == 0, reasons
+	}
+
+	threshold := 1 - tolerancePercent/100
+	if m.H2DGBps < baseline.H2DGBps*threshold {
+		reasons = append(reasons, fmt.Sprintf("h2d %.1f GB/s below baseline %.1f GB/s (tolerance %.0f%%)", m.H2DGBps, baseline.H2DGBps, tolerancePercent))
+	}
+	if m.D2HGBps < baseline.D2HGBps*threshold {
+		reasons = append(reasons, fmt.Sprintf("d2h %.1f GB/s below baseline %.1f GB/s (tolerance %.0f%%)", m.D2HGBps, baseline.D2HGBps, tolerancePercent))
+	}
+	return len(reasons) == 0, reasons
+}
@@ -0,0 +1,102 @@
+package pciebw
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestParseOutput(t *testing.T) {
+	out := []byte(`[CUDA Bandwidth Test] - Starting...
+Running on...
+
+ Device 0: NVIDIA H100 80GB HBM3
+ Quick Mode
+
+bandwidthTest-H2D-Pinned, Bandwidth = 24.6 GB/s, Size = 32000000 bytes, NumDevsUsed = 1
+bandwidthTest-D2H-Pinned, Bandwidth = 26.3 GB/s, Size = 32000000 bytes, NumDevsUsed = 1
+Result = PASS
+`)
+	h2d, d2h, err := ParseOutput(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h2d != 24.6 || d2h != 26.3 {
+		t.Errorf("ParseOutput() = %v, %v, want 24.6, 26.3", h2d, d2h)
+	}
+
+	if _, _, err := ParseOutput([]byte("Result = FAIL")); err == nil {
+		t.Error("expected error for the output without bandwidth")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	healthyLink := nvidia_query_nvml.PCIeLink{BusID: "0000:18:00.0", CurrentWidth: 16, MaxWidth: 16}
+	degradedLink := nvidia_query_nvml.PCIeLink{BusID: "0000:18:00.0", CurrentWidth: 8, MaxWidth: 16}
+	baseline := &Baseline{BusID: "0000:18:00.0", H2DGBps: 25, D2HGBps: 26}
+
+	tests := []struct {
+		name     string
+		m        Measurement
+		baseline *Baseline
+		want     bool
+	}{
+		{"no baseline", Measurement{Link: healthyLink, H2DGBps: 10, D2HGBps: 10}, nil, true},
+		{"no baseline degraded link", Measurement{Link: degradedLink, H2DGBps: 10, D2HGBps: 10}, nil, false},
+		{"within tolerance", Measurement{Link: healthyLink, H2DGBps: 23, D2HGBps: 24}, baseline, true},
+		{"h2d below baseline", Measurement{Link: healthyLink, H2DGBps: 12, D2HGBps: 26}, baseline, false},
+		{"d2h below baseline", Measurement{Link: healthyLink, H2DGBps: 25, D2HGBps: 13}, baseline, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passed, reasons := Compare(tt.m, tt.baseline, DefaultTolerancePercent)
+			if passed != tt.want {
+				t.Errorf("Compare() = %v (%v), want %v", passed, reasons, tt.want)
+			}
+			if !passed && len(reasons) == 0 {
+				t.Error("expected reasons when failed")
+			}
+		})
+	}
+}
+
+func TestBaselines(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableBaseline(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+	if err := SetBaseline(ctx, db, Baseline{BusID: "0000:18:00.0", GPUUUID: "GPU-0", H2DGBps: 25, D2HGBps: 26}); err != nil {
+		t.Fatal(err)
+	}
+	// replaced GPU in the same slot
+	if err := SetBaseline(ctx, db, Baseline{BusID: "0000:18:00.0", GPUUUID: "GPU-9", H2DGBps: 24, D2HGBps: 25}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetBaseline(ctx, db, Baseline{BusID: "0000:2a:00.0", GPUUUID: "GPU-1", H2DGBps: 25, D2HGBps: 26}); err != nil {
+		t.Fatal(err)
+	}
+
+	baselines, err := ReadBaselines(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(baselines) != 2 {
+		t.Fatalf("expected 2 baselines, got %d", len(baselines))
+	}
+	if b := baselines["0000:18:00.0"]; b.GPUUUID != "GPU-9" || b.H2DGBps != 24 {
+		t.Errorf("unexpected baseline %+v", b)
+	}
+}
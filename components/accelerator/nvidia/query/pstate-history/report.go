@@ -0,0 +1,54 @@
+package pstatehistory
+
+import (
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+// Entry is the transition with the human-readable states and reasons,
+// and the time spent in the state.
+type Entry struct {
+	Transition
+
+	PStateName         string `json:"pstate_name"`
+	PreviousPStateName string `json:"previous_pstate_name"`
+	// ClockEventReasons are the active clock event (throttle) reasons at the transition.
+	ClockEventReasons []string `json:"clock_event_reasons,omitempty"`
+
+	// DurationSeconds is the time spent in the state,
+	// until the next transition of the GPU or the end of the report.
+	DurationSeconds int64 `json:"duration_seconds"`
+}
+
+// Report is the performance state history of the GPUs in a time range.
+type Report struct {
+	StartUnixSeconds int64 `json:"start_unix_seconds"`
+	EndUnixSeconds   int64 `json:"end_unix_seconds"`
+
+	// GPUs are the transitions by the GPU UUID, in the ascending order of the transition time.
+	GPUs map[string][]Entry `json:"gpus"`
+}
+
+// Build builds the report from the transitions in the ascending order of the transition time.
+func Build(trs []Transition, start time.Time, end time.Time) Report {
+	r := Report{
+		StartUnixSeconds: start.UTC().Unix(),
+		EndUnixSeconds:   end.UTC().Unix(),
+		GPUs:             make(map[string][]Entry),
+	}
+	for _, tr := range trs {
+		entries := r.GPUs[tr.GPUUUID]
+		if n := len(entries); n > 0 {
+			entries[n-1].DurationSeconds = tr.UnixSeconds - entries[n-1].UnixSeconds
+		}
+		r.GPUs[tr.GPUUUID] = append(entries, Entry{
+			Transition:         tr,
+			PStateName:         nvidia_query_nvml.PStateName(tr.PState),
+			PreviousPStateName: nvidia_query_nvml.PStateName(tr.PreviousPState),
+			ClockEventReasons:  nvidia_query_nvml.ClockEventReasonDescriptions(tr.ClockEventReasonsBitmask),
+			DurationSeconds:    r.EndUnixSeconds - tr.UnixSeconds,
+		})
+	}
+	return r
+}
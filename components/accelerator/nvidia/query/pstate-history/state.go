@@ -0,0 +1,287 @@
+// Package pstatehistory provides the persistent storage layer for the per-GPU performance state (P-state)
+// transitions with the active clock event (throttle) reasons, so that the job slowdowns can be correlated
+// with the clock state changes after the fact, without profiling on the node.
+package pstatehistory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const TableNamePStateHistory = "components_accelerator_nvidia_query_pstate_history"
+
+const (
+	// unix timestamp in seconds when the transition was observed
+	ColumnUnixSeconds = "unix_seconds"
+
+	// GPU UUID
+	ColumnGPUUUID = "gpu_uuid"
+
+	// performance state after the transition (e.g., 0 for "P0"), or -1 if unknown
+	ColumnPState = "pstate"
+
+	// performance state before the transition, or -1 if not observed before (e.g., the first sample)
+	ColumnPreviousPState = "previous_pstate"
+
+	// graphics clock in MHz at the transition
+	ColumnGraphicsMHz = "graphics_mhz"
+
+	// memory clock in MHz at the transition
+	ColumnMemoryMHz = "memory_mhz"
+
+	// bitmask of the active clock event (throttle) reasons at the transition
+	ColumnClockEventReasonsBitmask = "clock_event_reasons_bitmask"
+)
+
+// Transition is the performance state or the clock event reasons change of a GPU.
+type Transition struct {
+	UnixSeconds    int64  `json:"unix_seconds"`
+	GPUUUID        string `json:"gpu_uuid"`
+	PState         int    `json:"pstate"`
+	PreviousPState int    `json:"previous_pstate"`
+
+	GraphicsMHz uint32 `json:"graphics_mhz"`
+	MemoryMHz   uint32 `json:"memory_mhz"`
+
+	ClockEventReasonsBitmask uint64 `json:"clock_event_reasons_bitmask"`
+}
+
+func CreateTablePStateHistory(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL
+);`, TableNamePStateHistory,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnPState,
+		ColumnPreviousPState,
+		ColumnGraphicsMHz,
+		ColumnMemoryMHz,
+		ColumnClockEventReasonsBitmask,
+	))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s(%s);`,
+		TableNamePStateHistory,
+		ColumnUnixSeconds,
+		TableNamePStateHistory,
+		ColumnUnixSeconds,
+	))
+	return err
+}
+
+func InsertTransition(ctx context.Context, db *sql.DB, tr Transition) error {
+	log.Logger.Debugw("inserting pstate transition", "gpuUUID", tr.GPUUUID, "pstate", tr.PState, "previous", tr.PreviousPState)
+
+	insertStatement := fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?);
+`,
+		TableNamePStateHistory,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnPState,
+		ColumnPreviousPState,
+		ColumnGraphicsMHz,
+		ColumnMemoryMHz,
+		ColumnClockEventReasonsBitmask,
+	)
+	_, err := db.ExecContext(
+		ctx,
+		insertStatement,
+		tr.UnixSeconds,
+		tr.GPUUUID,
+		tr.PState,
+		tr.PreviousPState,
+		tr.GraphicsMHz,
+		tr.MemoryMHz,
+		int64(tr.ClockEventReasonsBitmask),
+	)
+	return err
+}
+
+// ReadTransitions returns the transitions between the start and the end time (inclusive)
+// of the GPU (or all the GPUs if empty), in the ascending order of the transition time.
+// Returns nil if no transition is found.
+func ReadTransitions(ctx context.Context, db *sql.DB, start time.Time, end time.Time, gpuUUID string) ([]Transition, error) {
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, %s, %s
+FROM %s
+WHERE %s >= ? AND %s <= ?`,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnPState,
+		ColumnPreviousPState,
+		ColumnGraphicsMHz,
+		ColumnMemoryMHz,
+		ColumnClockEventReasonsBitmask,
+		TableNamePStateHistory,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+	)
+	params := []any{start.UTC().Unix(), end.UTC().Unix()}
+	if gpuUUID != "" {
+		selectStatement += fmt.Sprintf(" AND %s = ?", ColumnGPUUUID)
+		params = append(params, gpuUUID)
+	}
+	selectStatement += fmt.Sprintf(" ORDER BY %s ASC, rowid ASC", ColumnUnixSeconds)
+
+	rows, err := db.QueryContext(ctx, selectStatement, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trs []Transition
+	for rows.Next() {
+		var (
+			tr      Transition
+			bitmask int64
+		)
+		if err := rows.Scan(
+			&tr.UnixSeconds,
+			&tr.GPUUUID,
+			&tr.PState,
+			&tr.PreviousPState,
+			&tr.GraphicsMHz,
+			&tr.MemoryMHz,
+			&bitmask,
+		); err != nil {
+			return nil, err
+		}
+		tr.ClockEventReasonsBitmask = uint64(bitmask)
+		trs = append(trs, tr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return trs, nil
+}
+
+// readLatest returns the latest transition of each GPU.
+func readLatest(ctx context.Context, db *sql.DB) (map[string]Transition, error) {
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, %s
+FROM %s
+ORDER BY %s ASC, rowid ASC`,
+		ColumnUnixSeconds,
+		ColumnGPUUUID,
+		ColumnPState,
+		ColumnClockEventReasonsBitmask,
+		TableNamePStateHistory,
+		ColumnUnixSeconds,
+	)
+	rows, err := db.QueryContext(ctx, selectStatement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	latest := make(map[string]Transition)
+	for rows.Next() {
+		var (
+			tr      Transition
+			bitmask int64
+		)
+		if err := rows.Scan(&tr.UnixSeconds, &tr.GPUUUID, &tr.PState, &bitmask); err != nil {
+			return nil, err
+		}
+		tr.ClockEventReasonsBitmask = uint64(bitmask)
+		latest[tr.GPUUUID] = tr
+	}
+	return latest, rows.Err()
+}
+
+// Purge deletes the transitions observed before the given time.
+func Purge(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?`, TableNamePStateHistory, ColumnUnixSeconds), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
+// Sample is the current performance state and clocks of a GPU.
+type Sample struct {
+	GPUUUID                  string
+	PState                   int
+	GraphicsMHz              uint32
+	MemoryMHz                uint32
+	ClockEventReasonsBitmask uint64
+}
+
+// Recorder records the transitions of the sampled GPUs, that is,
+// only when the performance state or the clock event reasons change from the last sample,
+// since the GPUs stay in the same state most of the time.
+type Recorder struct {
+	db *sql.DB
+
+	mu     sync.Mutex
+	loaded bool
+	last   map[string]Transition
+}
+
+// NewRecorder creates a recorder, which loads the last recorded states on the first sample
+// so that the restart does not record a spurious transition.
+func NewRecorder(db *sql.DB) *Recorder {
+	return &Recorder{db: db}
+}
+
+// Record records the transition if the sample differs from the last sample of the GPU,
+// and returns true if recorded.
+func (r *Recorder) Record(ctx context.Context, s Sample, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.loaded {
+		// the table may not exist yet if the query runs without the server (e.g., "gpud scan")
+		if err := CreateTablePStateHistory(ctx, r.db); err != nil {
+			return false, err
+		}
+		latest, err := readLatest(ctx, r.db)
+		if err != nil {
+			return false, err
+		}
+		r.last = latest
+		r.loaded = true
+	}
+
+	prev, ok := r.last[s.GPUUUID]
+	if ok && prev.PState == s.PState && prev.ClockEventReasonsBitmask == s.ClockEventReasonsBitmask {
+		return false, nil
+	}
+
+	tr := Transition{
+		UnixSeconds:              now.UTC().Unix(),
+		GPUUUID:                  s.GPUUUID,
+		PState:                   s.PState,
+		PreviousPState:           -1,
+		GraphicsMHz:              s.GraphicsMHz,
+		MemoryMHz:                s.MemoryMHz,
+		ClockEventReasonsBitmask: s.ClockEventReasonsBitmask,
+	}
+	if ok {
+		tr.PreviousPState = prev.PState
+	}
+	if err := InsertTransition(ctx, r.db, tr); err != nil {
+		return false, err
+	}
+	r.last[s.GPUUUID] = tr
+	return true, nil
+}
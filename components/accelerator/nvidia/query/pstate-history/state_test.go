@@ -0,0 +1,119 @@
+package pstatehistory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestRecorderRecord(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTablePStateHistory(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	base := time.Unix(1700000000, 0).UTC()
+	r := NewRecorder(db)
+	steps := []struct {
+		sample Sample
+		want   bool
+	}{
+		// first sample is recorded as the starting state
+		{Sample{GPUUUID: "GPU-0", PState: 8, GraphicsMHz: 345}, true},
+		{Sample{GPUUUID: "GPU-0", PState: 8, GraphicsMHz: 345}, false},
+		{Sample{GPUUUID: "GPU-0", PState: 0, GraphicsMHz: 1980}, true},
+		// same pstate but throttled (sw power cap)
+		{Sample{GPUUUID: "GPU-0", PState: 0, GraphicsMHz: 1410, ClockEventReasonsBitmask: 0x4}, true},
+		{Sample{GPUUUID: "GPU-1", PState: 0, GraphicsMHz: 1980}, true},
+	}
+	for i, s := range steps {
+		recorded, err := r.Record(ctx, s.sample, base.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recorded != s.want {
+			t.Errorf("step %d: expected recorded %v, got %v", i, s.want, recorded)
+		}
+	}
+
+	trs, err := ReadTransitions(ctx, db, base, base.Add(time.Hour), "GPU-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trs) != 3 {
+		t.Fatalf("expected 3 transitions, got %d", len(trs))
+	}
+	if trs[0].PreviousPState != -1 || trs[1].PreviousPState != 8 || trs[1].PState != 0 {
+		t.Errorf("unexpected transitions %+v", trs)
+	}
+	if trs[2].ClockEventReasonsBitmask != 0x4 || trs[2].GraphicsMHz != 1410 {
+		t.Errorf("unexpected throttled transition %+v", trs[2])
+	}
+
+	all, err := ReadTransitions(ctx, db, base, base.Add(time.Hour), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("expected 4 transitions, got %d", len(all))
+	}
+
+	// restart does not record the unchanged state again
+	r2 := NewRecorder(db)
+	recorded, err := r2.Record(ctx, Sample{GPUUUID: "GPU-0", PState: 0, GraphicsMHz: 1410, ClockEventReasonsBitmask: 0x4}, base.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded {
+		t.Error("expected the unchanged state not recorded after restart")
+	}
+
+	// only the first transition (step 0) is before the cutoff,
+	// as step 1 was not recorded and step 2 is at the cutoff
+	purged, err := Purge(ctx, db, base.Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Errorf("expected 1 purged, got %d", purged)
+	}
+	remaining, err := ReadTransitions(ctx, db, base, base.Add(time.Hour), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 3 || remaining[0].UnixSeconds != base.Add(2*time.Minute).Unix() {
+		t.Errorf("unexpected remaining transitions %+v", remaining)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	base := time.Unix(1700000000, 0).UTC()
+	trs := []Transition{
+		{UnixSeconds: base.Unix(), GPUUUID: "GPU-0", PState: 8, PreviousPState: -1},
+		{UnixSeconds: base.Unix() + 60, GPUUUID: "GPU-1", PState: 0, PreviousPState: 8},
+		{UnixSeconds: base.Unix() + 100, GPUUUID: "GPU-0", PState: 0, PreviousPState: 8, ClockEventReasonsBitmask: 0x4},
+	}
+	r := Build(trs, base, base.Add(5*time.Minute))
+	if len(r.GPUs) != 2 || len(r.GPUs["GPU-0"]) != 2 {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	first, second := r.GPUs["GPU-0"][0], r.GPUs["GPU-0"][1]
+	if first.DurationSeconds != 100 || first.PStateName != "P8" || first.PreviousPStateName != "unknown" {
+		t.Errorf("unexpected first entry %+v", first)
+	}
+	if second.DurationSeconds != 200 || second.PStateName != "P0" || len(second.ClockEventReasons) != 1 {
+		t.Errorf("unexpected second entry %+v", second)
+	}
+}
@@ -0,0 +1,47 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	pstate_history "github.com/leptonai/gpud/components/accelerator/nvidia/query/pstate-history"
+	"github.com/leptonai/gpud/log"
+)
+
+var (
+	pstateRecorderMu sync.Mutex
+	pstateRecorderDB *sql.DB
+	pstateRecorder   *pstate_history.Recorder
+)
+
+// recordPStateTransition records the performance state transition of the device, if any,
+// with the clocks and the active clock event reasons at the time.
+func recordPStateTransition(ctx context.Context, db *sql.DB, dev *nvml.DeviceInfo, now time.Time) {
+	if db == nil || dev == nil || !dev.PerformanceState.Supported {
+		return
+	}
+
+	pstateRecorderMu.Lock()
+	if pstateRecorder == nil || pstateRecorderDB != db {
+		pstateRecorder = pstate_history.NewRecorder(db)
+		pstateRecorderDB = db
+	}
+	recorder := pstateRecorder
+	pstateRecorderMu.Unlock()
+
+	s := pstate_history.Sample{
+		GPUUUID:     dev.UUID,
+		PState:      dev.PerformanceState.PState,
+		GraphicsMHz: dev.ClockSpeed.GraphicsMHz,
+		MemoryMHz:   dev.ClockSpeed.MemoryMHz,
+	}
+	if dev.ClockEvents != nil {
+		s.ClockEventReasonsBitmask = dev.ClockEvents.ReasonsBitmask
+	}
+	if _, err := recorder.Record(ctx, s, now); err != nil {
+		log.Logger.Warnw("failed to record pstate transition", "uuid", dev.UUID, "error", err)
+	}
+}
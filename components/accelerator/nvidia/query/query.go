@@ -5,11 +5,15 @@ package query
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
+	ecc_addresses "github.com/leptonai/gpud/components/accelerator/nvidia/query/ecc-addresses"
+	gpu_processes "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-processes"
 	"github.com/leptonai/gpud/components/accelerator/nvidia/query/infiniband"
 	metrics_clock "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/clock"
 	metrics_clockspeed "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/clock-speed"
@@ -23,10 +27,14 @@ import (
 	metrics_utilization "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/utilization"
 	"github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	"github.com/leptonai/gpud/components/accelerator/nvidia/query/peermem"
+	"github.com/leptonai/gpud/components/accelerator/nvidia/query/sxid"
+	"github.com/leptonai/gpud/components/accelerator/nvidia/query/tray"
 	"github.com/leptonai/gpud/components/query"
 	query_config "github.com/leptonai/gpud/components/query/config"
 	"github.com/leptonai/gpud/components/systemd"
 	"github.com/leptonai/gpud/log"
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
+	"github.com/leptonai/gpud/pkg/units"
 
 	go_nvml "github.com/NVIDIA/go-nvml/pkg/nvml"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -75,8 +83,10 @@ func CreateGet(db *sql.DB) query.GetFunc {
 }
 
 // Get all nvidia component queries.
+// If NVML fails to initialize (e.g., driver/library version mismatch, or NVML not found in the container),
+// it falls back to nvidia-smi, to still report the GPU inventory, temperature, and ECC errors.
 func Get(ctx context.Context, db *sql.DB) (output any, err error) {
-	if err := nvml.StartDefaultInstance(
+	nvmlErr := nvml.StartDefaultInstance(
 		ctx,
 		nvml.WithDB(db),
 		nvml.WithGPMMetricsID(
@@ -90,17 +100,31 @@ func Get(ctx context.Context, db *sql.DB) (output any, err error) {
 			go_nvml.GPM_METRIC_FP32_UTIL,
 			go_nvml.GPM_METRIC_FP16_UTIL,
 		),
-	); err != nil {
-		return nil, err
+	)
+	if nvmlErr != nil {
+		// e.g., CPU-only node, or the driver being reinstalled
+		if errors.Is(nvmlErr, pkg_nvml.ErrDriverNotLoaded) || (errors.Is(nvmlErr, pkg_nvml.ErrLibraryNotFound) && !SMIExists()) {
+			return nil, newStackAbsentError(ctx, db, nvmlErr)
+		}
+		if !SMIExists() {
+			return nil, nvmlErr
+		}
+		log.Logger.Warnw("failed to start nvml, falling back to nvidia-smi", "error", nvmlErr)
 	}
 
 	o := &Output{
-		SMIExists:             SMIExists(),
-		PersistencedExists:    PersistencedExists(),
-		PersistencedRunning:   PersistencedRunning(),
-		FabricManagerExists:   FabricManagerExists(),
-		InfinibandClassExists: infiniband.CountInfinibandClass() > 0,
-		IbstatExists:          infiniband.IbstatExists(),
+		SMIExists:                     SMIExists(),
+		PersistencedExists:            PersistencedExists(),
+		PersistencedRunning:           PersistencedRunning(),
+		PersistencedNoPersistenceMode: PersistencedNoPersistenceMode(),
+		FabricManagerExists:           FabricManagerExists(),
+		InfinibandClassExists:         infiniband.CountInfinibandClass() > 0,
+		IbstatExists:                  infiniband.IbstatExists(),
+	}
+
+	if nvmlErr != nil {
+		o.NVMLErrors = append(o.NVMLErrors, nvmlErr.Error())
+		o.NVMLFallbackToSMI = true
 	}
 
 	o.GPUDeviceCount, err = CountAllDevicesFromDevDir()
@@ -108,6 +132,12 @@ func Get(ctx context.Context, db *sql.DB) (output any, err error) {
 		log.Logger.Warnw("failed to count gpu devices", "error", err)
 	}
 
+	pciDevices, err := ListNVIDIAPCIs(ctx)
+	if err != nil {
+		log.Logger.Warnw("failed to list nvidia pci devices", "error", err)
+	}
+	o.PCIGPUCount = CountNVIDIAGPUPCIs(pciDevices)
+
 	defer func() {
 		getSuccessOnceCloseOnce.Do(func() {
 			close(getSuccessOnce)
@@ -198,6 +228,35 @@ func Get(ctx context.Context, db *sql.DB) (output any, err error) {
 		o.LsmodPeermemErrors = append(o.LsmodPeermemErrors, err.Error())
 	}
 
+	// only collected when a tray source is configured (e.g., DGX nvsm, HGX Redfish)
+	o.Tray, err = tray.Collect(ctx)
+	if err != nil {
+		o.TrayErrors = append(o.TrayErrors, err.Error())
+	}
+
+	if o.NVMLFallbackToSMI {
+		// the nvml instance is not ready, retries on the next query
+		if o.SMI != nil {
+			now := time.Now().UTC()
+			metrics_temperature.SetLastUpdateUnixSeconds(float64(now.Unix()))
+			for _, g := range o.SMI.GPUs {
+				if g.UUID == "" || g.Temperature == nil {
+					continue
+				}
+				cur, err := g.Temperature.GetCurrentCelsius()
+				if err != nil {
+					continue
+				}
+				if err := metrics_temperature.SetCurrentCelsius(ctx, g.UUID, cur, now); err != nil {
+					return nil, err
+				}
+			}
+		}
+		o.MemoryErrorManagementCapabilities = GetMemoryErrorManagementCapabilities(o.GPUProductName())
+		setNVSwitchGeneration(o.GPUProductName())
+		return o, nil
+	}
+
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -214,6 +273,8 @@ func Get(ctx context.Context, db *sql.DB) (output any, err error) {
 		log.Logger.Warnw("nvml get failed", "error", err)
 		o.NVMLErrors = append(o.NVMLErrors, err.Error())
 	} else {
+		recordDriverBaseline(ctx, db, len(o.NVML.DeviceInfos))
+
 		now := time.Now().UTC()
 		nowUnix := float64(now.Unix())
 
@@ -243,12 +304,13 @@ func Get(ctx context.Context, db *sql.DB) (output any, err error) {
 				}
 			}
 
-			if err := metrics_clockspeed.SetGraphicsMHz(ctx, dev.UUID, dev.ClockSpeed.GraphicsMHz, now); err != nil {
+			if err := metrics_clockspeed.SetGraphicsHertz(ctx, dev.UUID, units.MHzToHertz(float64(dev.ClockSpeed.GraphicsMHz)), now); err != nil {
 				return nil, err
 			}
-			if err := metrics_clockspeed.SetMemoryMHz(ctx, dev.UUID, dev.ClockSpeed.MemoryMHz, now); err != nil {
+			if err := metrics_clockspeed.SetMemoryHertz(ctx, dev.UUID, units.MHzToHertz(float64(dev.ClockSpeed.MemoryMHz)), now); err != nil {
 				return nil, err
 			}
+			recordPStateTransition(ctx, db, dev, now)
 
 			if err := metrics_ecc.SetAggregateTotalCorrected(ctx, dev.UUID, float64(dev.ECCErrors.Aggregate.Total.Corrected), now); err != nil {
 				return nil, err
@@ -298,13 +360,23 @@ func Get(ctx context.Context, db *sql.DB) (output any, err error) {
 			if err := metrics_nvlink.SetTxBytes(ctx, dev.UUID, float64(dev.NVLink.States.TotalThroughputRawTxBytes()), now); err != nil {
 				return nil, err
 			}
+			for _, link := range dev.NVLink.States {
+				metrics_nvlink.SetLinkCounters(dev.UUID, link.Link, link.FeatureEnabled, link.ReplayErrors, link.RecoveryErrors, link.CRCErrors, link.ThroughputRawRxBytes, link.ThroughputRawTxBytes)
+			}
 
-			if err := metrics_power.SetUsageMilliWatts(ctx, dev.UUID, float64(dev.Power.UsageMilliWatts), now); err != nil {
+			if err := metrics_power.SetUsageWatts(ctx, dev.UUID, units.MilliWattsToWatts(float64(dev.Power.UsageMilliWatts)), now); err != nil {
 				return nil, err
 			}
-			if err := metrics_power.SetEnforcedLimitMilliWatts(ctx, dev.UUID, float64(dev.Power.EnforcedLimitMilliWatts), now); err != nil {
+			if err := metrics_power.SetEnforcedLimitWatts(ctx, dev.UUID, units.MilliWattsToWatts(float64(dev.Power.EnforcedLimitMilliWatts)), now); err != nil {
 				return nil, err
 			}
+			if dev.Power.AverageUsageMilliWatts > 0 {
+				metrics_power.SetAverageUsageWatts(dev.UUID, units.MilliWattsToWatts(float64(dev.Power.AverageUsageMilliWatts)))
+			}
+			if dev.Power.DefaultLimitMilliWatts > 0 {
+				metrics_power.SetDefaultLimitWatts(dev.UUID, units.MilliWattsToWatts(float64(dev.Power.DefaultLimitMilliWatts)))
+			}
+			metrics_power.SetViolationSeconds(dev.UUID, float64(dev.Power.PowerViolationNanoseconds)/float64(time.Second))
 			usedPercent, err = dev.Power.GetUsedPercent()
 			if err != nil {
 				o.NVMLErrors = append(o.NVMLErrors, err.Error())
@@ -339,6 +411,18 @@ func Get(ctx context.Context, db *sql.DB) (output any, err error) {
 			if err := metrics_processes.SetRunningProcessesTotal(ctx, dev.UUID, len(dev.Processes.RunningProcesses), now); err != nil {
 				return nil, err
 			}
+			for _, proc := range dev.Processes.RunningProcesses {
+				if err := gpu_processes.RecordSeen(ctx, db, gpu_processes.Entry{
+					GPUUUID:           dev.UUID,
+					PID:               proc.PID,
+					CreateUnixSeconds: proc.CreateTime.Unix(),
+					Command:           strings.Join(proc.CmdArgs, " "),
+					PodUID:            proc.PodUID,
+					PodName:           proc.PodName,
+				}, now); err != nil {
+					log.Logger.Warnw("failed to record gpu process", "uuid", dev.UUID, "pid", proc.PID, "error", err)
+				}
+			}
 
 			if err := metrics_remapped_rows.SetRemappedDueToUncorrectableErrors(ctx, dev.UUID, uint32(dev.RemappedRows.RemappedDueToCorrectableErrors), now); err != nil {
 				return nil, err
@@ -349,14 +433,43 @@ func Get(ctx context.Context, db *sql.DB) (output any, err error) {
 			if err := metrics_remapped_rows.SetRemappingFailed(ctx, dev.UUID, dev.RemappedRows.RemappingFailed, now); err != nil {
 				return nil, err
 			}
+
+			for source, pages := range map[string][]nvml.RetiredPage{
+				ecc_addresses.SourceRetiredPageSBE: dev.RetiredPages.MultipleSingleBitECCErrors,
+				ecc_addresses.SourceRetiredPageDBE: dev.RetiredPages.DoubleBitECCErrors,
+			} {
+				for _, p := range pages {
+					var retired time.Time
+					if p.Timestamp > 0 {
+						retired = time.Unix(int64(p.Timestamp), 0)
+					}
+					if _, err := ecc_addresses.Record(ctx, db, dev.UUID, p.Address, source, retired); err != nil {
+						log.Logger.Warnw("failed to record retired page", "uuid", dev.UUID, "address", p.Address, "error", err)
+					}
+				}
+			}
 		}
 	}
 
 	o.MemoryErrorManagementCapabilities = GetMemoryErrorManagementCapabilities(o.GPUProductName())
+	setNVSwitchGeneration(o.GPUProductName())
 
 	return o, nil
 }
 
+// setNVSwitchGeneration selects the SXid details of the NVSwitch generation paired with the GPU product,
+// no-op if the product name is not known yet (e.g., nvidia-smi not yet queried).
+func setNVSwitchGeneration(gpuProductName string) {
+	if gpuProductName == "" {
+		return
+	}
+	g := sxid.GenerationFromGPUProductName(gpuProductName)
+	if g != sxid.CurrentGeneration() {
+		log.Logger.Infow("detected nvswitch generation", "generation", g.String(), "gpuProductName", gpuProductName)
+		sxid.SetGeneration(g)
+	}
+}
+
 const (
 	StateKeyGPUProductName      = "gpu_product_name"
 	StateKeySMIExists           = "smi_exists"
@@ -367,6 +480,8 @@ const (
 type Output struct {
 	// GPU device count from the /dev directory.
 	GPUDeviceCount int `json:"gpu_device_count"`
+	// GPU count from the PCI bus (sysfs).
+	PCIGPUCount int `json:"pci_gpu_count"`
 
 	SMIExists      bool       `json:"smi_exists"`
 	SMI            *SMIOutput `json:"smi,omitempty"`
@@ -379,6 +494,8 @@ type Output struct {
 
 	PersistencedExists  bool `json:"persistenced_exists"`
 	PersistencedRunning bool `json:"persistenced_running"`
+	// PersistencedNoPersistenceMode is true if "nvidia-persistenced" runs with "--no-persistence-mode".
+	PersistencedNoPersistenceMode bool `json:"persistenced_no_persistence_mode,omitempty"`
 
 	FabricManagerExists bool                 `json:"fabric_manager_exists"`
 	FabricManager       *FabricManagerOutput `json:"fabric_manager,omitempty"`
@@ -391,8 +508,16 @@ type Output struct {
 	LsmodPeermem       *peermem.LsmodPeermemModuleOutput `json:"lsmod_peermem,omitempty"`
 	LsmodPeermemErrors []string                          `json:"lsmod_peermem_errors,omitempty"`
 
+	// Tray is the DGX/HGX tray-level data not visible through NVML
+	// (e.g., NVSwitch temperatures, midplane status, GPU tray power).
+	Tray       *tray.Data `json:"tray,omitempty"`
+	TrayErrors []string   `json:"tray_errors,omitempty"`
+
 	NVML       *nvml.Output `json:"nvml,omitempty"`
 	NVMLErrors []string     `json:"nvml_errors,omitempty"`
+	// NVMLFallbackToSMI is true if NVML failed to initialize,
+	// thus the GPU inventory, temperature, and ECC errors are from nvidia-smi only.
+	NVMLFallbackToSMI bool `json:"nvml_fallback_to_smi,omitempty"`
 
 	MemoryErrorManagementCapabilities MemoryErrorManagementCapabilities `json:"memory_error_management_capabilities,omitempty"`
 }
@@ -533,6 +658,9 @@ func (o *Output) PrintInfo(debug bool) {
 		fmt.Printf("%s successfully checked lsmod peermem\n", checkMark)
 	}
 
+	if o.NVMLFallbackToSMI {
+		fmt.Printf("%s nvml failed to initialize, falling back to nvidia-smi\n", warningSign)
+	}
 	if len(o.NVMLErrors) > 0 {
 		fmt.Printf("%s nvml check failed with %d error(s)\n", warningSign, len(o.NVMLErrors))
 		for _, err := range o.NVMLErrors {
@@ -563,6 +691,17 @@ func (o *Output) PrintInfo(debug bool) {
 				fmt.Printf("%s NVML persistence mode is disabled (nvidia-persistenced running %v)\n", warningSign, o.PersistencedRunning)
 			}
 
+			if dev.MIGMode.Supported {
+				if dev.MIGMode.PendingChange() {
+					fmt.Printf("%s NVML MIG mode change is pending (enabled %v, pending enabled %v, requires gpu reset)\n", warningSign, dev.MIGMode.Enabled, dev.MIGMode.PendingEnabled)
+				} else {
+					fmt.Printf("%s NVML MIG mode enabled %v (%d mig device(s))\n", checkMark, dev.MIGMode.Enabled, len(dev.MIGMode.Devices))
+				}
+				for _, d := range dev.MIGMode.Devices {
+					fmt.Printf("\t%s (gpu instance %d, compute instance %d, profile %s)\n", d.UUID, d.GPUInstanceID, d.ComputeInstanceID, d.Profile)
+				}
+			}
+
 			if dev.ClockEvents != nil {
 				if dev.ClockEvents.HWSlowdown || dev.ClockEvents.HWSlowdownThermal || dev.ClockEvents.HWSlowdownPowerBrake {
 					fmt.Printf("%s NVML found hw slowdown error(s)\n", warningSign)
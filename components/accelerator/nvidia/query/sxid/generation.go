@@ -0,0 +1,128 @@
+package sxid
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/leptonai/gpud/components/common"
+)
+
+// Generation is the NVSwitch generation, since some SXids are handled differently
+// (e.g., the guest VM recovery) depending on the NVSwitch generation.
+type Generation int
+
+const (
+	// GenerationUnknown uses the SXid details common to all the NVSwitch generations.
+	GenerationUnknown Generation = 0
+	// Generation2 is the second-generation NVSwitch (LR10) on the HGX A100 systems.
+	Generation2 Generation = 2
+	// Generation3 is the third-generation NVSwitch (LS10) on the HGX H100/H200 systems.
+	Generation3 Generation = 3
+	// Generation4 is the fourth-generation NVSwitch on the HGX B200 and GB200 systems.
+	Generation4 Generation = 4
+)
+
+func (g Generation) String() string {
+	switch g {
+	case Generation2:
+		return "gen2"
+	case Generation3:
+		return "gen3"
+	case Generation4:
+		return "gen4"
+	default:
+		return "unknown"
+	}
+}
+
+// GenerationFromGPUProductName returns the NVSwitch generation paired with the GPU product
+// (e.g., "NVIDIA H100 80GB HBM3"), or GenerationUnknown if the GPU is not an NVSwitch-based SKU.
+func GenerationFromGPUProductName(gpuProductName string) Generation {
+	p := strings.ToLower(gpuProductName)
+	switch {
+	case strings.Contains(p, "b100"), strings.Contains(p, "b200"):
+		return Generation4
+	case strings.Contains(p, "h100"), strings.Contains(p, "h200"), strings.Contains(p, "h800"), strings.Contains(p, "h20"):
+		return Generation3
+	case strings.Contains(p, "a100"), strings.Contains(p, "a800"):
+		return Generation2
+	default:
+		return GenerationUnknown
+	}
+}
+
+var currentGeneration atomic.Int32
+
+// SetGeneration sets the NVSwitch generation of the local machine,
+// to select the generation-specific SXid details.
+func SetGeneration(g Generation) {
+	currentGeneration.Store(int32(g))
+}
+
+// CurrentGeneration returns the NVSwitch generation of the local machine,
+// or GenerationUnknown if not detected yet.
+func CurrentGeneration() Generation {
+	return Generation(currentGeneration.Load())
+}
+
+// GetDetailForGeneration returns the SXid detail of the NVSwitch generation,
+// or the detail common to all the generations if the generation does not define its own.
+// The overrides (if set) take precedence over the embedded details.
+// Returns false if the SXid is not found.
+func GetDetailForGeneration(id int, g Generation) (*Detail, bool) {
+	if g != GenerationUnknown {
+		if e, ok := getOverride(id, g); ok {
+			e.Generation = g.String()
+			return &e, true
+		}
+	}
+	if e, ok := getOverride(id, GenerationUnknown); ok {
+		return &e, true
+	}
+	if e, ok := detailsByGeneration[g][id]; ok {
+		e.Generation = g.String()
+		return &e, true
+	}
+	return GetCommonDetail(id)
+}
+
+// detailsByGeneration defines the SXids whose handling differs by the NVSwitch generation,
+// overriding the common details.
+var detailsByGeneration = map[Generation]map[int]Detail{
+	Generation2: {
+		20034: ltssmFaultUp(Generation2),
+	},
+	Generation3: {
+		20034: ltssmFaultUp(Generation3),
+	},
+}
+
+// "Guest VM Recovery: In case of A100, restart the VM. In case of H100, reset the GPU (refer to section D.9)."
+// ref. https://docs.nvidia.com/datacenter/tesla/pdf/fabric-manager-user-guide.pdf
+func ltssmFaultUp(g Generation) Detail {
+	d := details[20034]
+	switch g {
+	case Generation2:
+		d.Recovery = defaultPotentialFatalErr.Recovery + `
+Guest VM Recovery: Restart the VM. If issue persists, report GPU issues.
+`
+	case Generation3:
+		d.Recovery = defaultPotentialFatalErr.Recovery + `
+Guest VM Recovery: Reset the GPU (refer to section D.9). If issue persists, report GPU issues.
+`
+		d.SuggestedActionsByGPUd = &common.SuggestedActions{
+			References: d.SuggestedActionsByGPUd.References,
+
+			Descriptions: []string{
+				"Reset the GPU connected to the NVSwitch access port.",
+				"If the same SXid is returned, the NVSwitch should be inspected and repaired.",
+			},
+
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeResetGPU,
+				common.RepairActionTypeHardwareInspection,
+			},
+		}
+	}
+	return d
+}
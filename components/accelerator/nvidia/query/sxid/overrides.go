@@ -0,0 +1,84 @@
+package sxid
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// overrides are the SXid details loaded at runtime (e.g., from the details file shipped by the fleet team),
+// keyed by the NVSwitch generation (GenerationUnknown for the details common to all the generations),
+// which take precedence over the embedded details, so that the SXids of the new driver releases
+// are classified without a gpud release.
+var overrides atomic.Pointer[map[Generation]map[int]Detail]
+
+// SetOverrides replaces the SXid details that take precedence over the embedded details.
+// The detail with the "nvswitch_generation" field (e.g., "gen3") only applies to the generation.
+// Set nil to use the embedded details only.
+// Returns an error without changing the current overrides, if any detail is invalid.
+func SetOverrides(ds []Detail) error {
+	m := make(map[Generation]map[int]Detail)
+	for _, d := range ds {
+		if err := d.Validate(); err != nil {
+			return fmt.Errorf("invalid sxid %d: %w", d.SXid, err)
+		}
+		g, ok := ParseGeneration(d.Generation)
+		if !ok {
+			return fmt.Errorf("invalid sxid %d: unknown nvswitch generation %q", d.SXid, d.Generation)
+		}
+		if _, ok := m[g][d.SXid]; ok {
+			return fmt.Errorf("duplicate sxid %d for the nvswitch generation %q", d.SXid, d.Generation)
+		}
+		if m[g] == nil {
+			m[g] = make(map[int]Detail)
+		}
+		m[g][d.SXid] = d
+	}
+	overrides.Store(&m)
+	return nil
+}
+
+// ParseGeneration parses the NVSwitch generation string (e.g., "gen3"),
+// returning GenerationUnknown for the empty string.
+func ParseGeneration(s string) (Generation, bool) {
+	switch s {
+	case "":
+		return GenerationUnknown, true
+	case Generation2.String():
+		return Generation2, true
+	case Generation3.String():
+		return Generation3, true
+	case Generation4.String():
+		return Generation4, true
+	default:
+		return GenerationUnknown, false
+	}
+}
+
+// Validate returns an error if the detail is missing the required fields,
+// or the suggested actions are inconsistent with the critical flag.
+func (d Detail) Validate() error {
+	if d.SXid <= 0 {
+		return errors.New("sxid must be greater than 0")
+	}
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	if d.CriticalErrorMarkedByGPUd && (d.SuggestedActionsByGPUd == nil || len(d.SuggestedActionsByGPUd.RepairActions) == 0) {
+		return errors.New("critical error must have repair actions")
+	}
+	if d.SuggestedActionsByGPUd != nil && len(d.SuggestedActionsByGPUd.Descriptions) > 0 &&
+		len(d.SuggestedActionsByGPUd.Descriptions) != len(d.SuggestedActionsByGPUd.RepairActions) {
+		return fmt.Errorf("%d descriptions and %d repair actions", len(d.SuggestedActionsByGPUd.Descriptions), len(d.SuggestedActionsByGPUd.RepairActions))
+	}
+	return nil
+}
+
+func getOverride(id int, g Generation) (Detail, bool) {
+	m := overrides.Load()
+	if m == nil {
+		return Detail{}, false
+	}
+	d, ok := (*m)[g][id]
+	return d, ok
+}
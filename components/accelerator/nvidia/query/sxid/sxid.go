@@ -27,15 +27,29 @@ type Detail struct {
 	Impact         string `json:"impact"`
 	Recovery       string `json:"recovery"`
 	OtherImpact    string `json:"other_impact"`
+
+	// Generation is the NVSwitch generation (e.g., "gen3") if the detail is specific to the generation,
+	// empty if common to all the generations.
+	Generation string `json:"nvswitch_generation,omitempty"`
 }
 
 func (d Detail) JSON() ([]byte, error) {
 	return json.Marshal(d)
 }
 
-// Returns the error if found.
+// Returns the error if found, specific to the NVSwitch generation of the local machine (if detected).
 // Otherwise, returns false.
 func GetDetail(id int) (*Detail, bool) {
+	return GetDetailForGeneration(id, CurrentGeneration())
+}
+
+// Returns the error common to all the NVSwitch generations if found,
+// from the overrides (if set) or the embedded details.
+// Otherwise, returns false.
+func GetCommonDetail(id int) (*Detail, bool) {
+	if e, ok := getOverride(id, GenerationUnknown); ok {
+		return &e, true
+	}
 	e, ok := details[id]
 	return &e, ok
 }
@@ -1,9 +1,22 @@
 package sxid
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/leptonai/gpud/components/common"
+)
 
 func TestDetailsValidation(t *testing.T) {
+	all := make([]Detail, 0, len(details))
 	for _, d := range details {
+		all = append(all, d)
+	}
+	for _, gds := range detailsByGeneration {
+		for _, d := range gds {
+			all = append(all, d)
+		}
+	}
+	for _, d := range all {
 		// test critical errors must have repair actions
 		if d.CriticalErrorMarkedByGPUd && len(d.SuggestedActionsByGPUd.RepairActions) == 0 {
 			t.Errorf("sxid %d is marked as critical in GPUd, but has no repair actions", d.SXid)
@@ -21,3 +34,41 @@ func TestDetailsValidation(t *testing.T) {
 		}
 	}
 }
+
+func TestGetDetailForGeneration(t *testing.T) {
+	for _, tt := range []struct {
+		productName string
+		generation  Generation
+		action      common.RepairActionType
+	}{
+		{"NVIDIA A100-SXM4-80GB", Generation2, common.RepairActionTypeRebootSystem},
+		{"NVIDIA H100 80GB HBM3", Generation3, common.RepairActionTypeResetGPU},
+		{"NVIDIA B200", Generation4, common.RepairActionTypeRebootSystem},
+		{"NVIDIA L40S", GenerationUnknown, common.RepairActionTypeRebootSystem},
+	} {
+		g := GenerationFromGPUProductName(tt.productName)
+		if g != tt.generation {
+			t.Errorf("%q: expected generation %v, got %v", tt.productName, tt.generation, g)
+		}
+		d, ok := GetDetailForGeneration(20034, g)
+		if !ok {
+			t.Fatalf("%q: expected sxid 20034 found", tt.productName)
+		}
+		if d.SuggestedActionsByGPUd.RepairActions[0] != tt.action {
+			t.Errorf("%q: expected %s, got %+v", tt.productName, tt.action, d.SuggestedActionsByGPUd.RepairActions)
+		}
+	}
+
+	// falls back to the common detail if the generation does not override
+	d, ok := GetDetailForGeneration(11004, Generation3)
+	if !ok || d.Generation != "" {
+		t.Errorf("expected the common detail, got %+v", d)
+	}
+	d, ok = GetDetailForGeneration(20034, Generation3)
+	if !ok || d.Generation != "gen3" {
+		t.Errorf("expected the gen3 detail, got %+v", d)
+	}
+	if cd, _ := GetCommonDetail(20034); cd.SuggestedActionsByGPUd.RepairActions[0] != common.RepairActionTypeRebootSystem {
+		t.Errorf("expected the common detail unchanged, got %+v", cd.SuggestedActionsByGPUd)
+	}
+}
@@ -0,0 +1,290 @@
+// Package thresholdbreachstate provides the persistent storage layer for the GPU threshold crossing events.
+package thresholdbreachstate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const TableNameThresholdBreachHistory = "components_accelerator_nvidia_query_threshold_breach_history"
+
+const (
+	// unix timestamp in seconds when the threshold crossing was observed
+	ColumnUnixSeconds = "unix_seconds"
+
+	// component name (e.g., "accelerator-nvidia-temperature")
+	ColumnComponent = "component"
+
+	// threshold name (e.g., "max_temperature_celsius")
+	ColumnThresholdName = "threshold_name"
+
+	// GPU UUID
+	ColumnGPUUUID = "gpu_uuid"
+
+	// either "up" (crossed to at or above the threshold) or "down" (recovered below the threshold)
+	ColumnDirection = "direction"
+
+	// observed value at the crossing
+	ColumnValue = "value"
+
+	// threshold at the crossing
+	ColumnThreshold = "threshold"
+)
+
+const (
+	EventNameThresholdBreach = "threshold_breach"
+
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+type Event struct {
+	UnixSeconds   int64
+	Component     string
+	ThresholdName string
+	GPUUUID       string
+	Direction     string
+	Value         float64
+	Threshold     float64
+}
+
+// ToComponentEvent converts the threshold crossing record to the component event.
+func (e Event) ToComponentEvent() components.Event {
+	ev := components.Event{
+		Time: metav1.Time{Time: time.Unix(e.UnixSeconds, 0).UTC()},
+		Name: EventNameThresholdBreach,
+		ExtraInfo: map[string]string{
+			ColumnThresholdName: e.ThresholdName,
+			ColumnGPUUUID:       e.GPUUUID,
+			ColumnDirection:     e.Direction,
+			ColumnValue:         fmt.Sprintf("%v", e.Value),
+			ColumnThreshold:     fmt.Sprintf("%v", e.Threshold),
+		},
+	}
+	if e.Direction == DirectionUp {
+		ev.Type = components.EventTypeWarn
+		ev.Message = fmt.Sprintf("%s on %s crossed up to %v (threshold %v)", e.ThresholdName, e.GPUUUID, e.Value, e.Threshold)
+	} else {
+		ev.Type = components.EventTypeInfo
+		ev.Message = fmt.Sprintf("%s on %s crossed down to %v (threshold %v)", e.ThresholdName, e.GPUUUID, e.Value, e.Threshold)
+	}
+	return ev
+}
+
+func CreateTableThresholdBreachHistory(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s REAL NOT NULL,
+	%s REAL NOT NULL
+);`, TableNameThresholdBreachHistory,
+		ColumnUnixSeconds,
+		ColumnComponent,
+		ColumnThresholdName,
+		ColumnGPUUUID,
+		ColumnDirection,
+		ColumnValue,
+		ColumnThreshold,
+	))
+	return err
+}
+
+func InsertEvent(ctx context.Context, db *sql.DB, event Event) error {
+	log.Logger.Debugw("inserting threshold breach event", "component", event.Component, "threshold", event.ThresholdName, "gpuUUID", event.GPUUUID, "direction", event.Direction)
+
+	insertStatement := fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?);
+`,
+		TableNameThresholdBreachHistory,
+		ColumnUnixSeconds,
+		ColumnComponent,
+		ColumnThresholdName,
+		ColumnGPUUUID,
+		ColumnDirection,
+		ColumnValue,
+		ColumnThreshold,
+	)
+	_, err := db.ExecContext(
+		ctx,
+		insertStatement,
+		event.UnixSeconds,
+		event.Component,
+		event.ThresholdName,
+		event.GPUUUID,
+		event.Direction,
+		event.Value,
+		event.Threshold,
+	)
+	return err
+}
+
+// ReadEvents returns the threshold crossing events of the component since the given time (if non-zero),
+// in the ascending order of the crossing time.
+// Returns nil if no event is found.
+func ReadEvents(ctx context.Context, db *sql.DB, since time.Time, component string) ([]Event, error) {
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, %s, %s
+FROM %s
+WHERE %s >= ? AND %s = ?
+ORDER BY %s ASC`,
+		ColumnUnixSeconds,
+		ColumnComponent,
+		ColumnThresholdName,
+		ColumnGPUUUID,
+		ColumnDirection,
+		ColumnValue,
+		ColumnThreshold,
+		TableNameThresholdBreachHistory,
+		ColumnUnixSeconds,
+		ColumnComponent,
+		ColumnUnixSeconds,
+	)
+	sinceUnix := since.UTC().Unix()
+	if since.IsZero() {
+		sinceUnix = 0
+	}
+
+	rows, err := db.QueryContext(ctx, selectStatement, sinceUnix, component)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		if err := rows.Scan(
+			&event.UnixSeconds,
+			&event.Component,
+			&event.ThresholdName,
+			&event.GPUUUID,
+			&event.Direction,
+			&event.Value,
+			&event.Threshold,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Purge deletes the threshold crossing events before the given time.
+func Purge(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?`, TableNameThresholdBreachHistory, ColumnUnixSeconds), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
+// Reading is an observed value of a GPU against its configured threshold.
+// The value at or above the threshold is a breach, and the zero threshold disables the check.
+type Reading struct {
+	ThresholdName string
+	GPUUUID       string
+	Value         float64
+	Threshold     float64
+}
+
+func (r Reading) breached() bool {
+	return r.Value >= r.Threshold
+}
+
+// Tracker records the threshold crossings of a component, separate from its state healthy flag.
+// An "up" event is recorded when a reading crosses to at or above its threshold,
+// and a "down" event when it recovers below. The first reading of a GPU below the threshold
+// is not recorded, and the last recorded direction is restored from the database
+// so that the restarts do not duplicate the events.
+type Tracker struct {
+	db        *sql.DB
+	component string
+
+	mu       sync.Mutex
+	loaded   bool
+	breached map[string]bool
+}
+
+func NewTracker(db *sql.DB, component string) *Tracker {
+	return &Tracker{
+		db:        db,
+		component: component,
+		breached:  make(map[string]bool),
+	}
+}
+
+func trackerKey(thresholdName, gpuUUID string) string {
+	return thresholdName + "/" + gpuUUID
+}
+
+// Observe compares the readings with the last observed directions,
+// and records the threshold crossing events (if any).
+// Returns the recorded events.
+func (t *Tracker) Observe(ctx context.Context, ts time.Time, readings ...Reading) ([]Event, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.loaded && t.db != nil {
+		prev, err := ReadEvents(ctx, t.db, time.Time{}, t.component)
+		if err != nil {
+			return nil, err
+		}
+		for _, ev := range prev {
+			t.breached[trackerKey(ev.ThresholdName, ev.GPUUUID)] = ev.Direction == DirectionUp
+		}
+	}
+	t.loaded = true
+
+	var events []Event
+	for _, r := range readings {
+		if r.Threshold <= 0 {
+			continue
+		}
+
+		k := trackerKey(r.ThresholdName, r.GPUUUID)
+		cur := r.breached()
+		if t.breached[k] == cur {
+			continue
+		}
+
+		ev := Event{
+			UnixSeconds:   ts.UTC().Unix(),
+			Component:     t.component,
+			ThresholdName: r.ThresholdName,
+			GPUUUID:       r.GPUUUID,
+			Direction:     DirectionDown,
+			Value:         r.Value,
+			Threshold:     r.Threshold,
+		}
+		if cur {
+			ev.Direction = DirectionUp
+		}
+		if t.db != nil {
+			if err := InsertEvent(ctx, t.db, ev); err != nil {
+				return events, err
+			}
+		}
+		t.breached[k] = cur
+		events = append(events, ev)
+	}
+	return events, nil
+}
@@ -0,0 +1,102 @@
+package thresholdbreachstate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestTrackerObserve(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableThresholdBreachHistory(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	base := time.Unix(1700000000, 0).UTC()
+	reading := func(uuid string, v, threshold float64) Reading {
+		return Reading{ThresholdName: "max_temperature_celsius", GPUUUID: uuid, Value: v, Threshold: threshold}
+	}
+
+	tr := NewTracker(db, "temperature")
+	steps := []struct {
+		readings   []Reading
+		directions []string
+	}{
+		// first readings below the threshold are the baseline, the disabled threshold is ignored
+		{readings: []Reading{reading("GPU-0", 70, 85), reading("GPU-1", 90, 0)}},
+		{readings: []Reading{reading("GPU-0", 85, 85)}, directions: []string{DirectionUp}},
+		{readings: []Reading{reading("GPU-0", 90, 85)}},
+		{readings: []Reading{reading("GPU-0", 80, 85)}, directions: []string{DirectionDown}},
+		{readings: []Reading{reading("GPU-0", 81, 85), reading("GPU-2", 86, 85)}, directions: []string{DirectionUp}},
+	}
+	for i, step := range steps {
+		evs, err := tr.Observe(ctx, base.Add(time.Duration(i)*time.Minute), step.readings...)
+		if err != nil {
+			t.Fatalf("step %d: Observe failed: %v", i, err)
+		}
+		if len(evs) != len(step.directions) {
+			t.Fatalf("step %d: expected %d events, got %+v", i, len(step.directions), evs)
+		}
+		for j, ev := range evs {
+			if ev.Direction != step.directions[j] {
+				t.Errorf("step %d: expected direction %q, got %q", i, step.directions[j], ev.Direction)
+			}
+		}
+	}
+
+	read, err := ReadEvents(ctx, db, time.Time{}, "temperature")
+	if err != nil {
+		t.Fatalf("ReadEvents failed: %v", err)
+	}
+	if len(read) != 3 {
+		t.Fatalf("expected 3 events, got %+v", read)
+	}
+	if read[0].GPUUUID != "GPU-0" || read[0].Value != 85 || read[0].Threshold != 85 || read[0].UnixSeconds != base.Add(time.Minute).Unix() {
+		t.Errorf("unexpected event %+v", read[0])
+	}
+	if read[2].GPUUUID != "GPU-2" || read[2].Direction != DirectionUp {
+		t.Errorf("unexpected event %+v", read[2])
+	}
+	if evs, err := ReadEvents(ctx, db, time.Time{}, "power"); err != nil || len(evs) != 0 {
+		t.Fatalf("expected no power events, got %+v (%v)", evs, err)
+	}
+
+	ev := read[0].ToComponentEvent()
+	if ev.Name != EventNameThresholdBreach || ev.Type != components.EventTypeWarn || ev.ExtraInfo[ColumnValue] != "85" {
+		t.Errorf("unexpected component event %+v", ev)
+	}
+	if ev := read[1].ToComponentEvent(); ev.Type != components.EventTypeInfo {
+		t.Errorf("unexpected component event %+v", ev)
+	}
+
+	// the restarted tracker restores the last directions, without duplicating the events
+	tr = NewTracker(db, "temperature")
+	evs, err := tr.Observe(ctx, base.Add(time.Hour), reading("GPU-0", 82, 85), reading("GPU-2", 88, 85))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 0 {
+		t.Fatalf("expected no event after restart, got %+v", evs)
+	}
+
+	purged, err := Purge(ctx, db, base.Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged, got %d", purged)
+	}
+}
@@ -0,0 +1,89 @@
+package topology
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const TableNameTopologyBaseline = "components_accelerator_nvidia_query_topology_baseline"
+
+const (
+	// always 1, the node has a single baseline
+	ColumnID = "id"
+
+	// topology in JSON
+	ColumnTopology = "topology"
+
+	// unix timestamp in seconds when the baseline was captured
+	ColumnUnixSeconds = "unix_seconds"
+)
+
+// Baseline is the known-good topology, captured at the first boot.
+type Baseline struct {
+	Topology   *Topology `json:"topology"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+func CreateTableBaseline(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL PRIMARY KEY,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL
+);`, TableNameTopologyBaseline,
+		ColumnID,
+		ColumnTopology,
+		ColumnUnixSeconds,
+	))
+	return err
+}
+
+// SetBaseline inserts or replaces the baseline.
+func SetBaseline(ctx context.Context, db *sql.DB, b Baseline) error {
+	if b.CapturedAt.IsZero() {
+		b.CapturedAt = time.Now()
+	}
+	raw, err := json.Marshal(b.Topology)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`INSERT OR REPLACE INTO %s (%s, %s, %s) VALUES (1, ?, ?);`,
+		TableNameTopologyBaseline,
+		ColumnID,
+		ColumnTopology,
+		ColumnUnixSeconds,
+	), string(raw), b.CapturedAt.UTC().Unix())
+	return err
+}
+
+// ReadBaseline returns the baseline, or nil if not captured yet.
+func ReadBaseline(ctx context.Context, db *sql.DB) (*Baseline, error) {
+	var (
+		raw      string
+		unixSecs int64
+	)
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s = 1;`,
+		ColumnTopology,
+		ColumnUnixSeconds,
+		TableNameTopologyBaseline,
+		ColumnID,
+	)).Scan(&raw, &unixSecs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := new(Topology)
+	if err := json.Unmarshal([]byte(raw), t); err != nil {
+		return nil, err
+	}
+	return &Baseline{Topology: t, CapturedAt: time.Unix(unixSecs, 0).UTC()}, nil
+}
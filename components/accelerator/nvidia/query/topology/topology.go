@@ -0,0 +1,183 @@
+// Package topology parses the GPU interconnect topology matrix ("nvidia-smi topo -m")
+// and compares it against the baseline, to detect the missing NVLink connections
+// and the changed CPU/NUMA affinities.
+package topology
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	columnCPUAffinity  = "CPU Affinity"
+	columnNUMAAffinity = "NUMA Affinity"
+
+	// the link to the device itself
+	linkSelf = "X"
+)
+
+// e.g., "\x1b[4m" (underline) in the header
+var regexANSIEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// Device is a row of the topology matrix.
+type Device struct {
+	// Name is the row label (e.g., "GPU0", "NIC0").
+	Name string `json:"name"`
+	// Links is the connection type to the other devices by the name
+	// (e.g., "NV18" for 18 bonded NVLinks, "PIX", "SYS").
+	Links        map[string]string `json:"links,omitempty"`
+	CPUAffinity  string            `json:"cpu_affinity,omitempty"`
+	NUMAAffinity string            `json:"numa_affinity,omitempty"`
+}
+
+// IsGPU returns true if the device is a GPU row (e.g., "GPU0").
+func (d Device) IsGPU() bool {
+	return strings.HasPrefix(d.Name, "GPU")
+}
+
+// Topology is the parsed topology matrix.
+type Topology struct {
+	Devices []Device `json:"devices"`
+}
+
+// Device returns the device by the name, or false if not found.
+func (t *Topology) Device(name string) (Device, bool) {
+	for _, d := range t.Devices {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Device{}, false
+}
+
+// Parse parses the "nvidia-smi topo -m" output, ignoring the legends.
+// e.g.,
+//
+//		GPU0	GPU1	NIC0	CPU Affinity	NUMA Affinity	GPU NUMA ID
+//	GPU0	 X 	NV18	SYS	0-55,112-167	0		N/A
+//	GPU1	NV18	 X 	SYS	0-55,112-167	0		N/A
+//	NIC0	SYS	SYS	 X
+func Parse(b []byte) (*Topology, error) {
+	var header []string
+	t := &Topology{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := regexANSIEscape.ReplaceAllString(scanner.Text(), "")
+		if strings.TrimSpace(line) == "" {
+			if header != nil && len(t.Devices) > 0 {
+				// end of the matrix, followed by the legends
+				break
+			}
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		if header == nil {
+			if fields[0] != "" {
+				// e.g., the warnings before the matrix
+				continue
+			}
+			header = fields[1:]
+			continue
+		}
+
+		dev := Device{Name: fields[0], Links: make(map[string]string)}
+		for i, v := range fields[1:] {
+			if i >= len(header) {
+				break
+			}
+			switch col := header[i]; {
+			case col == columnCPUAffinity:
+				dev.CPUAffinity = v
+			case col == columnNUMAAffinity:
+				dev.NUMAAffinity = v
+			case strings.Contains(col, " "):
+				// e.g., "GPU NUMA ID"
+			case v != "" && v != linkSelf:
+				dev.Links[col] = v
+			}
+		}
+		t.Devices = append(t.Devices, dev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if header == nil || len(t.Devices) == 0 {
+		return nil, fmt.Errorf("topology matrix not found")
+	}
+	return t, nil
+}
+
+// NVLinkCount returns the number of the bonded NVLinks of the connection
+// (e.g., 18 for "NV18"), or zero if not connected via NVLink.
+func NVLinkCount(link string) int {
+	if !strings.HasPrefix(link, "NV") {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(link, "NV"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// Compare returns the differences of the GPUs in the current topology from the baseline:
+// the missing GPUs, the missing or degraded NVLink connections, and the changed CPU/NUMA affinities.
+// The NVLink connections added since the baseline are not reported.
+func Compare(baseline *Topology, current *Topology) []string {
+	var diffs []string
+	for _, b := range baseline.Devices {
+		if !b.IsGPU() {
+			continue
+		}
+		c, ok := current.Device(b.Name)
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s missing", b.Name))
+			continue
+		}
+
+		peers := make([]string, 0, len(b.Links))
+		for peer := range b.Links {
+			peers = append(peers, peer)
+		}
+		sort.Strings(peers)
+		for _, peer := range peers {
+			was := NVLinkCount(b.Links[peer])
+			if was == 0 {
+				continue
+			}
+			// the GPU pairs are reported once
+			if pd, ok := baseline.Device(peer); ok && pd.IsGPU() && peer < b.Name {
+				continue
+			}
+			now := c.Links[peer]
+			switch n := NVLinkCount(now); {
+			case n == 0:
+				if now == "" {
+					now = "none"
+				}
+				diffs = append(diffs, fmt.Sprintf("nvlink %s-%s missing (%s -> %s)", b.Name, peer, b.Links[peer], now))
+			case n < was:
+				diffs = append(diffs, fmt.Sprintf("nvlink %s-%s degraded (%s -> %s)", b.Name, peer, b.Links[peer], now))
+			}
+		}
+
+		if b.CPUAffinity != c.CPUAffinity {
+			diffs = append(diffs, fmt.Sprintf("%s cpu affinity changed (%q -> %q)", b.Name, b.CPUAffinity, c.CPUAffinity))
+		}
+		if b.NUMAAffinity != c.NUMAAffinity {
+			diffs = append(diffs, fmt.Sprintf("%s numa affinity changed (%q -> %q)", b.Name, b.NUMAAffinity, c.NUMAAffinity))
+		}
+	}
+	return diffs
+}
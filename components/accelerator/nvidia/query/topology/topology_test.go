@@ -0,0 +1,133 @@
+package topology
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+const testTopo = "\x1b[4m\tGPU0\tGPU1\tGPU2\tNIC0\tCPU Affinity\tNUMA Affinity\tGPU NUMA ID\x1b[0m\n" +
+	"GPU0\t X \tNV18\tNV18\tPXB\t0-55,112-167\t0\t\tN/A\n" +
+	"GPU1\tNV18\t X \tNV18\tSYS\t0-55,112-167\t0\t\tN/A\n" +
+	"GPU2\tNV18\tNV18\t X \tSYS\t56-111,168-223\t1\t\tN/A\n" +
+	"NIC0\tPXB\tSYS\tSYS\t X \t\t\t\t\n" +
+	"\n" +
+	"Legend:\n" +
+	"\n" +
+	"  X    = Self\n" +
+	"  NV#  = Connection traversing a bonded set of # NVLinks\n" +
+	"\n" +
+	"NIC Legend:\n" +
+	"\n" +
+	"  NIC0: mlx5_0\n"
+
+func TestParse(t *testing.T) {
+	topo, err := Parse([]byte(testTopo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topo.Devices) != 4 {
+		t.Fatalf("expected 4 devices, got %d", len(topo.Devices))
+	}
+
+	expected := Device{
+		Name:         "GPU2",
+		Links:        map[string]string{"GPU0": "NV18", "GPU1": "NV18", "NIC0": "SYS"},
+		CPUAffinity:  "56-111,168-223",
+		NUMAAffinity: "1",
+	}
+	if got, _ := topo.Device("GPU2"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+	if nic, _ := topo.Device("NIC0"); nic.IsGPU() || nic.Links["GPU0"] != "PXB" {
+		t.Errorf("unexpected nic %+v", nic)
+	}
+
+	if _, err := Parse([]byte("No devices were found\n")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestNVLinkCount(t *testing.T) {
+	for link, expected := range map[string]int{"NV18": 18, "NV4": 4, "SYS": 0, "NODE": 0, "": 0, "NVx": 0} {
+		if got := NVLinkCount(link); got != expected {
+			t.Errorf("NVLinkCount(%q) = %d, want %d", link, got, expected)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline, err := Parse([]byte(testTopo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := Compare(baseline, baseline); len(diffs) != 0 {
+		t.Errorf("expected no diff, got %v", diffs)
+	}
+
+	current, err := Parse([]byte(testTopo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.Devices[0].Links["GPU1"] = "SYS"
+	current.Devices[1].Links["GPU0"] = "SYS"
+	current.Devices[1].Links["GPU2"] = "NV12"
+	current.Devices[2].Links["GPU1"] = "NV12"
+	current.Devices[2].NUMAAffinity = "0"
+
+	expected := []string{
+		`nvlink GPU0-GPU1 missing (NV18 -> SYS)`,
+		`nvlink GPU1-GPU2 degraded (NV18 -> NV12)`,
+		`GPU2 numa affinity changed ("1" -> "0")`,
+	}
+	if diffs := Compare(baseline, current); !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("expected %v, got %v", expected, diffs)
+	}
+
+	current.Devices = current.Devices[1:]
+	if diffs := Compare(baseline, current); len(diffs) == 0 || diffs[0] != "GPU0 missing" {
+		t.Errorf("expected GPU0 missing, got %v", diffs)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTableBaseline(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+	b, err := ReadBaseline(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b != nil {
+		t.Fatalf("expected no baseline, got %+v", b)
+	}
+
+	topo, err := Parse([]byte(testTopo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetBaseline(ctx, db, Baseline{Topology: topo}); err != nil {
+		t.Fatal(err)
+	}
+	b, err = ReadBaseline(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b == nil || !reflect.DeepEqual(b.Topology, topo) || b.CapturedAt.IsZero() {
+		t.Errorf("unexpected baseline %+v", b)
+	}
+}
@@ -0,0 +1,86 @@
+package tray
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/leptonai/gpud/pkg/execlimit"
+	"github.com/leptonai/gpud/pkg/file"
+)
+
+// NVSMSource collects the "nvsm show health" checks on the DGX systems.
+type NVSMSource struct {
+	run func(ctx context.Context) ([]byte, error)
+}
+
+func NewNVSMSource() *NVSMSource {
+	return &NVSMSource{run: runNVSMShowHealth}
+}
+
+func (s *NVSMSource) Name() string { return SourceNVSM }
+
+func (s *NVSMSource) Collect(ctx context.Context) (*Data, error) {
+	b, err := s.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Data{Source: SourceNVSM, Sensors: ParseNVSMHealth(b)}, nil
+}
+
+func runNVSMShowHealth(ctx context.Context) ([]byte, error) {
+	p, err := file.LocateExecutable("nvsm")
+	if err != nil {
+		return nil, errors.New("nvsm not found")
+	}
+	if err := execlimit.Wait(ctx, "nvsm"); err != nil {
+		return nil, err
+	}
+	return exec.CommandContext(ctx, p, "show", "health").Output()
+}
+
+// ParseNVSMHealth parses the "nvsm show health" check lines
+// into the tray sensors (the summary and the other lines are ignored).
+// e.g.,
+//
+//	Verify chassis fan presence ....................................... Healthy
+//	Check NVSwitch temperature [NVSwitch0] ............................ Unhealthy
+func ParseNVSMHealth(b []byte) []Sensor {
+	var sensors []Sensor
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		idx := strings.Index(line, " ...")
+		if idx < 0 {
+			continue
+		}
+		name := strings.TrimSpace(line[:idx])
+		status := strings.TrimSpace(strings.TrimLeft(line[idx:], " ."))
+		if name == "" || status == "" {
+			continue
+		}
+		sensors = append(sensors, Sensor{
+			Kind:   nvsmKind(name),
+			Name:   name,
+			Health: normalizeHealth(status),
+		})
+	}
+	return sensors
+}
+
+func nvsmKind(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "nvswitch") && strings.Contains(lower, "temperature"):
+		return KindNVSwitchTemperature
+	case strings.Contains(lower, "midplane"):
+		return KindMidplane
+	case strings.Contains(lower, "power") && (strings.Contains(lower, "gpu") || strings.Contains(lower, "tray")):
+		return KindGPUTrayPower
+	default:
+		return KindHealthCheck
+	}
+}
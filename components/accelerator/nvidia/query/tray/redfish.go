@@ -0,0 +1,190 @@
+package tray
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// RedfishConfig is the HGX BMC Redfish endpoint.
+type RedfishConfig struct {
+	// Endpoint is the BMC base URL (e.g., "https://10.0.0.10").
+	Endpoint string `json:"endpoint"`
+	// Username is the BMC user name.
+	Username string `json:"username,omitempty"`
+	// PasswordFile is the file containing the BMC password,
+	// to not expose the password in the config.
+	PasswordFile string `json:"password_file,omitempty"`
+	// InsecureSkipVerify is set true to skip the BMC certificate verification
+	// (the BMCs usually serve the self-signed certificates).
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+func (cfg RedfishConfig) Validate() error {
+	if cfg.Endpoint == "" {
+		return errors.New("redfish endpoint is required")
+	}
+	if !strings.HasPrefix(cfg.Endpoint, "https://") && !strings.HasPrefix(cfg.Endpoint, "http://") {
+		return fmt.Errorf("invalid redfish endpoint %q", cfg.Endpoint)
+	}
+	return nil
+}
+
+// RedfishSource collects the sensors and the chassis status from the HGX BMC Redfish endpoints.
+// ref. https://docs.nvidia.com/dgx/dgxh100-user-guide/redfish-api-supp.html
+type RedfishSource struct {
+	cfg    RedfishConfig
+	client *http.Client
+}
+
+func NewRedfishSource(cfg RedfishConfig) *RedfishSource {
+	return &RedfishSource{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}, //nolint:gosec
+			},
+		},
+	}
+}
+
+func (s *RedfishSource) Name() string { return SourceRedfish }
+
+type redfishCollection struct {
+	Members []struct {
+		ODataID string `json:"@odata.id"`
+	} `json:"Members"`
+}
+
+type redfishStatus struct {
+	Health string `json:"Health"`
+	State  string `json:"State"`
+}
+
+type redfishChassis struct {
+	ID     string        `json:"Id"`
+	Name   string        `json:"Name"`
+	Status redfishStatus `json:"Status"`
+}
+
+type redfishSensor struct {
+	ID           string        `json:"Id"`
+	Name         string        `json:"Name"`
+	Reading      *float64      `json:"Reading"`
+	ReadingType  string        `json:"ReadingType"`
+	ReadingUnits string        `json:"ReadingUnits"`
+	Status       redfishStatus `json:"Status"`
+}
+
+func (s *RedfishSource) Collect(ctx context.Context) (*Data, error) {
+	var chassisList redfishCollection
+	if err := s.get(ctx, "/redfish/v1/Chassis", &chassisList); err != nil {
+		return nil, err
+	}
+
+	d := &Data{Source: SourceRedfish}
+	for _, m := range chassisList.Members {
+		var ch redfishChassis
+		if err := s.get(ctx, m.ODataID, &ch); err != nil {
+			return nil, err
+		}
+		if !isHGXChassis(ch.ID) {
+			continue
+		}
+		if isMidplane(ch.ID) {
+			d.Sensors = append(d.Sensors, Sensor{
+				Kind:   KindMidplane,
+				Name:   ch.ID,
+				Health: normalizeHealth(ch.Status.Health),
+			})
+		}
+
+		var sensorList redfishCollection
+		if err := s.get(ctx, strings.TrimSuffix(m.ODataID, "/")+"/Sensors", &sensorList); err != nil {
+			// not every chassis exposes the sensors
+			continue
+		}
+		for _, sm := range sensorList.Members {
+			var sensor redfishSensor
+			if err := s.get(ctx, sm.ODataID, &sensor); err != nil {
+				return nil, err
+			}
+			kind := redfishKind(ch.ID, sensor.ReadingType)
+			if kind == "" {
+				continue
+			}
+			reading := Sensor{
+				Kind:   kind,
+				Name:   ch.ID + "/" + sensor.ID,
+				Units:  sensor.ReadingUnits,
+				Health: normalizeHealth(sensor.Status.Health),
+			}
+			if sensor.Reading != nil {
+				reading.Reading = *sensor.Reading
+			}
+			d.Sensors = append(d.Sensors, reading)
+		}
+	}
+	return d, nil
+}
+
+// e.g., "HGX_NVSwitch_0", "HGX_GPU_SXM_1", "HGX_Chassis_0"
+func isHGXChassis(id string) bool {
+	return strings.HasPrefix(id, "HGX_")
+}
+
+func isMidplane(id string) bool {
+	return strings.HasPrefix(id, "HGX_Chassis") || strings.Contains(id, "Midplane") || strings.Contains(id, "Baseboard")
+}
+
+// redfishKind returns the tray sensor kind of the Redfish sensor,
+// or empty if the sensor is not tracked (e.g., the voltages).
+func redfishKind(chassisID string, readingType string) string {
+	switch {
+	case strings.Contains(chassisID, "NVSwitch") && readingType == "Temperature":
+		return KindNVSwitchTemperature
+	case (strings.Contains(chassisID, "GPU") || strings.HasPrefix(chassisID, "HGX_Chassis")) && readingType == "Power":
+		return KindGPUTrayPower
+	default:
+		return ""
+	}
+}
+
+func (s *RedfishSource) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.cfg.Endpoint, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.cfg.Username != "" {
+		password := ""
+		if s.cfg.PasswordFile != "" {
+			b, err := os.ReadFile(s.cfg.PasswordFile)
+			if err != nil {
+				return fmt.Errorf("failed to read redfish password file: %w", err)
+			}
+			password = strings.TrimSpace(string(b))
+		}
+		req.SetBasicAuth(s.cfg.Username, password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("redfish %s returned %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,156 @@
+// Package tray collects the DGX/HGX tray-level data not visible through NVML
+// (e.g., NVSwitch temperatures, midplane status, GPU tray power),
+// from the DGX "nvsm" health checks or the HGX BMC Redfish endpoints.
+package tray
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SourceNVSM    = "nvsm"
+	SourceRedfish = "redfish"
+)
+
+const (
+	KindNVSwitchTemperature = "nvswitch_temperature"
+	KindGPUTrayPower        = "gpu_tray_power"
+	KindMidplane            = "midplane"
+	KindHealthCheck         = "health_check"
+)
+
+const (
+	HealthOK       = "OK"
+	HealthWarning  = "Warning"
+	HealthCritical = "Critical"
+	HealthUnknown  = "Unknown"
+)
+
+// Sensor is a tray-level reading or status.
+type Sensor struct {
+	// Kind is the kind of the reading (e.g., "nvswitch_temperature").
+	Kind string `json:"kind"`
+	// Name is the sensor or the check name reported by the source.
+	Name string `json:"name"`
+	// Reading is the sensor reading, zero for the status-only sensors.
+	Reading float64 `json:"reading,omitempty"`
+	// Units is the unit of the reading (e.g., "Cel", "W").
+	Units string `json:"units,omitempty"`
+	// Health is the health reported by the source ("OK", "Warning", "Critical", or "Unknown").
+	Health string `json:"health"`
+}
+
+// Data is the tray-level data collected from a source.
+type Data struct {
+	Source  string   `json:"source"`
+	Sensors []Sensor `json:"sensors,omitempty"`
+}
+
+// Unhealthy returns the sensors reported as critical by the source.
+func (d *Data) Unhealthy() []Sensor {
+	if d == nil {
+		return nil
+	}
+	var rs []Sensor
+	for _, s := range d.Sensors {
+		if s.Health == HealthCritical {
+			rs = append(rs, s)
+		}
+	}
+	return rs
+}
+
+// Source collects the tray-level data.
+type Source interface {
+	Name() string
+	Collect(ctx context.Context) (*Data, error)
+}
+
+// Config selects the source of the tray-level data.
+// Only one of the sources can be set.
+type Config struct {
+	// NVSM is set true to collect the "nvsm show health" checks on the DGX systems.
+	NVSM bool `json:"nvsm,omitempty"`
+	// Redfish is the HGX BMC Redfish endpoint to collect the sensors from.
+	Redfish *RedfishConfig `json:"redfish,omitempty"`
+}
+
+func (cfg Config) Validate() error {
+	if cfg.NVSM && cfg.Redfish != nil {
+		return errors.New("only one of nvsm and redfish tray sources can be set")
+	}
+	if cfg.Redfish != nil {
+		return cfg.Redfish.Validate()
+	}
+	return nil
+}
+
+// NewSource returns the source of the config, or nil if no source is set.
+func NewSource(cfg Config) Source {
+	if cfg.NVSM {
+		return NewNVSMSource()
+	}
+	if cfg.Redfish != nil {
+		return NewRedfishSource(*cfg.Redfish)
+	}
+	return nil
+}
+
+var (
+	defaultSourceMu sync.RWMutex
+	defaultSource   Source
+)
+
+// SetDefaultSource sets the source collected by the shared NVIDIA poller.
+func SetDefaultSource(src Source) {
+	defaultSourceMu.Lock()
+	defer defaultSourceMu.Unlock()
+	defaultSource = src
+}
+
+// GetDefaultSource returns the source collected by the shared NVIDIA poller,
+// or nil if not set.
+func GetDefaultSource() Source {
+	defaultSourceMu.RLock()
+	defer defaultSourceMu.RUnlock()
+	return defaultSource
+}
+
+// DefaultCollectTimeout is the timeout to collect the tray-level data,
+// since both "nvsm" and the BMC are slow to respond.
+const DefaultCollectTimeout = time.Minute
+
+// Collect collects the data from the default source with the default timeout.
+// Returns nil without an error if no source is set.
+func Collect(ctx context.Context) (*Data, error) {
+	src := GetDefaultSource()
+	if src == nil {
+		return nil, nil
+	}
+	cctx, ccancel := context.WithTimeout(ctx, DefaultCollectTimeout)
+	defer ccancel()
+
+	d, err := src.Collect(cctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect tray data from %s: %w", src.Name(), err)
+	}
+	return d, nil
+}
+
+func normalizeHealth(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "ok", "healthy":
+		return HealthOK
+	case "warning", "degraded":
+		return HealthWarning
+	case "critical", "unhealthy":
+		return HealthCritical
+	default:
+		return HealthUnknown
+	}
+}
@@ -0,0 +1,122 @@
+package tray
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseNVSMHealth(t *testing.T) {
+	t.Parallel()
+
+	out := `
+Info
+----
+Timestamp                                            :: Mon Oct 14 10:00:00 2024 -0700
+Version                                              :: 24.03.07
+
+Checks
+------
+Verify chassis fan presence ....................................... Healthy
+Check NVSwitch temperature [NVSwitch0] ............................ Unhealthy
+Verify midplane status ............................................ Healthy
+Check GPU tray power supply ....................................... Unknown
+
+Health Summary
+--------------
+220 out of 222 checks are healthy
+`
+	got := ParseNVSMHealth([]byte(out))
+	want := []Sensor{
+		{Kind: KindHealthCheck, Name: "Verify chassis fan presence", Health: HealthOK},
+		{Kind: KindNVSwitchTemperature, Name: "Check NVSwitch temperature [NVSwitch0]", Health: HealthCritical},
+		{Kind: KindMidplane, Name: "Verify midplane status", Health: HealthOK},
+		{Kind: KindGPUTrayPower, Name: "Check GPU tray power supply", Health: HealthUnknown},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseNVSMHealth() = %+v, want %+v", got, want)
+	}
+
+	d := &Data{Source: SourceNVSM, Sensors: got}
+	if unhealthy := d.Unhealthy(); len(unhealthy) != 1 || unhealthy[0].Kind != KindNVSwitchTemperature {
+		t.Errorf("unexpected unhealthy sensors %+v", unhealthy)
+	}
+}
+
+func TestRedfishCollect(t *testing.T) {
+	t.Parallel()
+
+	resources := map[string]any{
+		"/redfish/v1/Chassis": map[string]any{"Members": []map[string]string{
+			{"@odata.id": "/redfish/v1/Chassis/BMC_0"},
+			{"@odata.id": "/redfish/v1/Chassis/HGX_Chassis_0"},
+			{"@odata.id": "/redfish/v1/Chassis/HGX_NVSwitch_0"},
+		}},
+		"/redfish/v1/Chassis/BMC_0":          map[string]any{"Id": "BMC_0", "Status": map[string]string{"Health": "OK"}},
+		"/redfish/v1/Chassis/HGX_Chassis_0":  map[string]any{"Id": "HGX_Chassis_0", "Status": map[string]string{"Health": "Warning"}},
+		"/redfish/v1/Chassis/HGX_NVSwitch_0": map[string]any{"Id": "HGX_NVSwitch_0", "Status": map[string]string{"Health": "OK"}},
+		"/redfish/v1/Chassis/HGX_Chassis_0/Sensors": map[string]any{"Members": []map[string]string{
+			{"@odata.id": "/redfish/v1/Chassis/HGX_Chassis_0/Sensors/TotalGPU_Power_0"},
+			{"@odata.id": "/redfish/v1/Chassis/HGX_Chassis_0/Sensors/Voltage_0"},
+		}},
+		"/redfish/v1/Chassis/HGX_Chassis_0/Sensors/TotalGPU_Power_0": map[string]any{
+			"Id": "TotalGPU_Power_0", "Reading": 5400.5, "ReadingType": "Power", "ReadingUnits": "W", "Status": map[string]string{"Health": "OK"},
+		},
+		"/redfish/v1/Chassis/HGX_Chassis_0/Sensors/Voltage_0": map[string]any{
+			"Id": "Voltage_0", "Reading": 12.1, "ReadingType": "Voltage", "ReadingUnits": "V", "Status": map[string]string{"Health": "OK"},
+		},
+		"/redfish/v1/Chassis/HGX_NVSwitch_0/Sensors": map[string]any{"Members": []map[string]string{
+			{"@odata.id": "/redfish/v1/Chassis/HGX_NVSwitch_0/Sensors/HGX_NVSwitch_0_TEMP_0"},
+		}},
+		"/redfish/v1/Chassis/HGX_NVSwitch_0/Sensors/HGX_NVSwitch_0_TEMP_0": map[string]any{
+			"Id": "HGX_NVSwitch_0_TEMP_0", "Reading": 71.0, "ReadingType": "Temperature", "ReadingUnits": "Cel", "Status": map[string]string{"Health": "Critical"},
+		},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		v, ok := resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(v)
+	}))
+	defer srv.Close()
+
+	src := NewRedfishSource(RedfishConfig{Endpoint: srv.URL, Username: "admin"})
+	d, err := src.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Sensor{
+		{Kind: KindMidplane, Name: "HGX_Chassis_0", Health: HealthWarning},
+		{Kind: KindGPUTrayPower, Name: "HGX_Chassis_0/TotalGPU_Power_0", Reading: 5400.5, Units: "W", Health: HealthOK},
+		{Kind: KindNVSwitchTemperature, Name: "HGX_NVSwitch_0/HGX_NVSwitch_0_TEMP_0", Reading: 71, Units: "Cel", Health: HealthCritical},
+	}
+	if d.Source != SourceRedfish || !reflect.DeepEqual(d.Sensors, want) {
+		t.Errorf("unexpected data %+v", d)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := (Config{NVSM: true, Redfish: &RedfishConfig{Endpoint: "https://bmc"}}).Validate(); err == nil {
+		t.Error("expected error for both sources")
+	}
+	if err := (Config{Redfish: &RedfishConfig{Endpoint: "bmc"}}).Validate(); err == nil {
+		t.Error("expected error for invalid endpoint")
+	}
+	if NewSource(Config{}) != nil {
+		t.Error("expected nil source")
+	}
+}
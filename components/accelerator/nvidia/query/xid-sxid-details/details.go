@@ -0,0 +1,113 @@
+// Package xidsxiddetails loads the XID/SXid details file at runtime
+// (e.g., shipped by NVIDIA or the fleet team), which overrides or adds to the embedded
+// XID/SXid details, so that the error codes of the new driver releases are classified
+// without waiting for a gpud release.
+package xidsxiddetails
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	nvidia_query_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/query/sxid"
+	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	"github.com/leptonai/gpud/log"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultReloadInterval is the default interval to check the details file for the updates.
+const DefaultReloadInterval = time.Minute
+
+// File is the XID/SXid details file, in JSON or YAML.
+// Each detail uses the same fields as the embedded details (e.g., "xid", "name", "suggested_actions_by_gpud").
+type File struct {
+	Xids  []nvidia_query_xid.Detail  `json:"xids,omitempty"`
+	SXids []nvidia_query_sxid.Detail `json:"sxids,omitempty"`
+}
+
+// Parse parses the details file in JSON or YAML.
+func Parse(b []byte) (*File, error) {
+	f := new(File)
+	if err := yaml.UnmarshalStrict(b, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Apply validates the details and replaces the XID/SXid overrides.
+// Returns an error without changing the current overrides, if any detail is invalid.
+func (f *File) Apply() error {
+	// validate the xids before applying the sxids, to not apply the file partially
+	seen := make(map[int]struct{}, len(f.Xids))
+	for _, d := range f.Xids {
+		if err := d.Validate(); err != nil {
+			return fmt.Errorf("invalid xid %d: %w", d.Xid, err)
+		}
+		if _, ok := seen[d.Xid]; ok {
+			return fmt.Errorf("duplicate xid %d", d.Xid)
+		}
+		seen[d.Xid] = struct{}{}
+	}
+
+	if err := nvidia_query_sxid.SetOverrides(f.SXids); err != nil {
+		return err
+	}
+	return nvidia_query_xid.SetOverrides(f.Xids)
+}
+
+// Load reads, parses, and applies the details file.
+func Load(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	f, err := Parse(b)
+	if err != nil {
+		return fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	if err := f.Apply(); err != nil {
+		return fmt.Errorf("failed to apply %q: %w", path, err)
+	}
+	log.Logger.Infow("loaded xid/sxid details", "path", path, "xids", len(f.Xids), "sxids", len(f.SXids))
+	return nil
+}
+
+// Watch reloads the details file every interval when its modification time changes,
+// until the context is canceled. If the reload fails (e.g., the file being written),
+// it keeps the previously loaded details and retries at the next interval.
+func Watch(ctx context.Context, path string, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+
+	var lastModTime time.Time
+	if fi, err := os.Stat(path); err == nil {
+		lastModTime = fi.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			log.Logger.Warnw("failed to stat xid/sxid details file", "path", path, "error", err)
+			continue
+		}
+		if fi.ModTime().Equal(lastModTime) {
+			continue
+		}
+		if err := Load(path); err != nil {
+			log.Logger.Warnw("failed to reload xid/sxid details file, keeping the previous details", "path", path, "error", err)
+			continue
+		}
+		lastModTime = fi.ModTime()
+	}
+}
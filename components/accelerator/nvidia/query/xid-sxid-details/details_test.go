@@ -0,0 +1,88 @@
+package xidsxiddetails
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	nvidia_query_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/query/sxid"
+	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	"github.com/leptonai/gpud/components/common"
+)
+
+func TestLoad(t *testing.T) {
+	defer func() {
+		_ = nvidia_query_xid.SetOverrides(nil)
+		_ = nvidia_query_sxid.SetOverrides(nil)
+	}()
+
+	if _, ok := nvidia_query_xid.GetDetail(154); ok {
+		t.Fatal("expected xid 154 not embedded")
+	}
+	if err := Load(filepath.Join("testdata", "details.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	d, ok := nvidia_query_xid.GetDetail(154)
+	if !ok {
+		t.Fatal("expected xid 154 found")
+	}
+	if !d.CriticalErrorMarkedByGPUd || d.SuggestedActionsByGPUd.RepairActions[0] != common.RepairActionTypeRebootSystem {
+		t.Errorf("unexpected xid 154 detail %+v", d)
+	}
+	// the embedded details not in the file are kept
+	if _, ok := nvidia_query_xid.GetDetail(79); !ok {
+		t.Error("expected xid 79 found")
+	}
+
+	sd, ok := nvidia_query_sxid.GetDetailForGeneration(20034, nvidia_query_sxid.Generation4)
+	if !ok || sd.Generation != "gen4" || sd.SuggestedActionsByGPUd.RepairActions[0] != common.RepairActionTypeResetGPU {
+		t.Errorf("unexpected gen4 sxid 20034 detail %+v", sd)
+	}
+	// the generation-specific override does not apply to the other generations
+	sd, ok = nvidia_query_sxid.GetDetailForGeneration(20034, nvidia_query_sxid.Generation2)
+	if !ok || sd.Generation != "gen2" || sd.SuggestedActionsByGPUd.RepairActions[0] != common.RepairActionTypeRebootSystem {
+		t.Errorf("unexpected gen2 sxid 20034 detail %+v", sd)
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	defer func() {
+		_ = nvidia_query_xid.SetOverrides(nil)
+		_ = nvidia_query_sxid.SetOverrides(nil)
+	}()
+
+	for _, data := range []string{
+		// missing name
+		`{"xids": [{"xid": 154}]}`,
+		// critical without repair actions
+		`{"xids": [{"xid": 154, "name": "x", "critical_error_marked_by_gpud": true}]}`,
+		// duplicate
+		`{"xids": [{"xid": 154, "name": "x"}, {"xid": 154, "name": "y"}]}`,
+		// unknown generation, with a valid xid not to be applied partially
+		`{"xids": [{"xid": 154, "name": "x"}], "sxids": [{"sxid": 20034, "name": "x", "nvswitch_generation": "gen9"}]}`,
+	} {
+		f, err := Parse([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Apply(); err == nil {
+			t.Errorf("%s: expected error", data)
+		}
+		if _, ok := nvidia_query_xid.GetDetail(154); ok {
+			t.Errorf("%s: expected xid 154 not applied", data)
+		}
+	}
+
+	// unknown field (e.g., a typo)
+	if _, err := Parse([]byte(`{"xid": [{"xid": 154, "name": "x"}]}`)); err == nil {
+		t.Error("expected error for the unknown field")
+	}
+}
+
+func TestLoadNotExist(t *testing.T) {
+	err := Load(filepath.Join(t.TempDir(), "not-exist.yaml"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+}
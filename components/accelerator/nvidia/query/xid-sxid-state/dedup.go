@@ -0,0 +1,212 @@
+package xidsxidstate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDedupWindow is the window to merge the identical events into one row,
+	// so that a GPU spewing the same Xid line produces one event per window
+	// with the occurrence counter, rather than one row per line.
+	DefaultDedupWindow = 5 * time.Minute
+
+	// DefaultMaxDistinctEventsPerWindow is the maximum number of the rows per device and event ID
+	// within the dedup window, after which the events are merged into the latest row
+	// even if their details differ (e.g., the Xid lines with the different process IDs).
+	DefaultMaxDistinctEventsPerWindow = 10
+
+	// maximum number of the recently recorded events cached in memory
+	// to skip the database lookups for the repeated lines
+	maxSeenCacheSize = 10000
+)
+
+// Deduper records the events, merging the identical events within the window into one row
+// with the occurrence counter and the first/last timestamps, and skipping the events
+// already recorded (e.g., the dmesg lines scanned again after the restart).
+type Deduper struct {
+	db          *sql.DB
+	window      time.Duration
+	maxDistinct int
+
+	mu sync.Mutex
+	// dedup key to the last unix seconds recorded
+	seen map[string]int64
+}
+
+// NewDeduper creates a deduper with the window and the maximum number of the distinct events
+// per device and event ID within the window.
+// Uses the defaults if zero.
+func NewDeduper(db *sql.DB, window time.Duration, maxDistinct int) *Deduper {
+	if window <= 0 {
+		window = DefaultDedupWindow
+	}
+	if maxDistinct <= 0 {
+		maxDistinct = DefaultMaxDistinctEventsPerWindow
+	}
+	return &Deduper{
+		db:          db,
+		window:      window,
+		maxDistinct: maxDistinct,
+		seen:        make(map[string]int64),
+	}
+}
+
+// Record records the event, and returns true if a new row is inserted,
+// or false if the event is merged into the existing row or already recorded.
+func (d *Deduper) Record(ctx context.Context, event Event) (bool, error) {
+	key := dedupKey(event)
+
+	d.mu.Lock()
+	last, ok := d.seen[key]
+	d.mu.Unlock()
+	if ok && event.UnixSeconds <= last {
+		// e.g., the same line repeated within the same second
+		return false, nil
+	}
+
+	inserted, err := d.record(ctx, event)
+	if err != nil {
+		return false, err
+	}
+
+	d.mu.Lock()
+	if len(d.seen) >= maxSeenCacheSize {
+		d.seen = make(map[string]int64)
+	}
+	if event.UnixSeconds > d.seen[key] {
+		d.seen[key] = event.UnixSeconds
+	}
+	d.mu.Unlock()
+
+	return inserted, nil
+}
+
+func (d *Deduper) record(ctx context.Context, event Event) (bool, error) {
+	rows, err := d.readWindow(ctx, event)
+	if err != nil {
+		return false, err
+	}
+
+	for _, r := range rows {
+		// already recorded
+		if r.EventDetails == event.EventDetails && r.UnixSeconds <= event.UnixSeconds && event.UnixSeconds <= r.LastUnixSeconds {
+			return false, nil
+		}
+	}
+
+	// identical event within the window
+	for _, r := range rows {
+		if r.EventDetails == event.EventDetails {
+			return false, d.merge(ctx, r, event.UnixSeconds)
+		}
+	}
+
+	// too many distinct events from the same device (flood)
+	device := devicePrefix(event.EventDetails)
+	var sameDevice []rowEvent
+	for _, r := range rows {
+		if devicePrefix(r.EventDetails) == device {
+			sameDevice = append(sameDevice, r)
+		}
+	}
+	if len(sameDevice) >= d.maxDistinct {
+		return false, d.merge(ctx, sameDevice[0], event.UnixSeconds)
+	}
+
+	event.Occurrences = 1
+	event.LastUnixSeconds = event.UnixSeconds
+	if err := InsertEvent(ctx, d.db, event); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type rowEvent struct {
+	Event
+	rowID int64
+}
+
+// readWindow returns the events of the same data source, type, and ID
+// first observed within the window before the event, the latest first.
+func (d *Deduper) readWindow(ctx context.Context, event Event) ([]rowEvent, error) {
+	selectStatement := fmt.Sprintf(`SELECT rowid, %s, IFNULL(%s, ''), COALESCE(%s, 1), COALESCE(%s, %s)
+FROM %s
+WHERE %s = ? AND %s = ? AND %s = ? AND %s > ? AND %s <= ?
+ORDER BY %s DESC`,
+		ColumnUnixSeconds,
+		ColumnEventDetails,
+		ColumnOccurrences,
+		ColumnLastUnixSeconds,
+		ColumnUnixSeconds,
+		TableNameXidSXidEventHistory,
+		ColumnDataSource,
+		ColumnEventType,
+		ColumnEventID,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+	)
+
+	rows, err := d.db.QueryContext(ctx, selectStatement,
+		event.DataSource,
+		event.EventType,
+		event.EventID,
+		event.UnixSeconds-int64(d.window.Seconds()),
+		event.UnixSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var evs []rowEvent
+	for rows.Next() {
+		r := rowEvent{Event: Event{
+			DataSource: event.DataSource,
+			EventType:  event.EventType,
+			EventID:    event.EventID,
+		}}
+		if err := rows.Scan(
+			&r.rowID,
+			&r.UnixSeconds,
+			&r.EventDetails,
+			&r.Occurrences,
+			&r.LastUnixSeconds,
+		); err != nil {
+			return nil, err
+		}
+		evs = append(evs, r)
+	}
+	return evs, rows.Err()
+}
+
+func (d *Deduper) merge(ctx context.Context, r rowEvent, unixSeconds int64) error {
+	updateStatement := fmt.Sprintf(`UPDATE %s SET %s = COALESCE(%s, 1) + 1, %s = MAX(COALESCE(%s, %s), ?) WHERE rowid = ?;`,
+		TableNameXidSXidEventHistory,
+		ColumnOccurrences,
+		ColumnOccurrences,
+		ColumnLastUnixSeconds,
+		ColumnLastUnixSeconds,
+		ColumnUnixSeconds,
+	)
+	_, err := d.db.ExecContext(ctx, updateStatement, unixSeconds, r.rowID)
+	return err
+}
+
+func dedupKey(event Event) string {
+	return fmt.Sprintf("%s/%s/%d/%s", event.DataSource, event.EventType, event.EventID, event.EventDetails)
+}
+
+// devicePrefix returns the device and the event ID part of the details,
+// to rate limit the events per device.
+// e.g., "NVRM: Xid (PCI:0000:9b:00): 79" for "NVRM: Xid (PCI:0000:9b:00): 79, pid=1234, ...",
+// or the GPU UUID for the "nvml" data source.
+func devicePrefix(details string) string {
+	prefix, _, _ := strings.Cut(details, ",")
+	return prefix
+}
@@ -0,0 +1,153 @@
+package xidsxidstate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDeduperRecord(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	d := NewDeduper(db, time.Minute, 3)
+
+	baseTime := time.Now().Unix()
+	ev := Event{
+		UnixSeconds:  baseTime,
+		DataSource:   "dmesg",
+		EventType:    "xid",
+		EventID:      79,
+		EventDetails: "NVRM: Xid (PCI:0000:9b:00): 79, pid=1234, GPU has fallen off the bus.",
+	}
+
+	inserted, err := d.Record(ctx, ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inserted {
+		t.Fatal("expected the first event inserted")
+	}
+
+	// thousands of the identical lines within the window
+	for i := 0; i < 1000; i++ {
+		e := ev
+		e.UnixSeconds = baseTime + int64(i%30)
+		inserted, err = d.Record(ctx, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inserted {
+			t.Fatalf("expected the identical event merged, at %d", i)
+		}
+	}
+
+	events, err := ReadEvents(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if events[0].UnixSeconds != baseTime {
+		t.Errorf("expected first unix seconds %d, got %d", baseTime, events[0].UnixSeconds)
+	}
+	if events[0].LastUnixSeconds != baseTime+29 {
+		t.Errorf("expected last unix seconds %d, got %d", baseTime+29, events[0].LastUnixSeconds)
+	}
+	// each second merged once (the repeated lines within the same second are skipped)
+	if events[0].Occurrences != 30 {
+		t.Errorf("expected 30 occurrences, got %d", events[0].Occurrences)
+	}
+
+	// replaying the recorded lines (e.g., restart) with a new deduper does not change the counter
+	d2 := NewDeduper(db, time.Minute, 3)
+	for i := 0; i < 30; i++ {
+		e := ev
+		e.UnixSeconds = baseTime + int64(i)
+		if _, err = d2.Record(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events, err = ReadEvents(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Occurrences != 30 {
+		t.Fatalf("expected 1 event with 30 occurrences after replay, got %+v", events)
+	}
+
+	// outside the window creates a new row
+	e := ev
+	e.UnixSeconds = baseTime + 120
+	inserted, err = d.Record(ctx, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inserted {
+		t.Fatal("expected the event outside the window inserted")
+	}
+}
+
+func TestDeduperRecordFlood(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	d := NewDeduper(db, time.Minute, 3)
+
+	baseTime := time.Now().Unix()
+	for i := 0; i < 100; i++ {
+		ev := Event{
+			UnixSeconds:  baseTime,
+			DataSource:   "dmesg",
+			EventType:    "xid",
+			EventID:      13,
+			EventDetails: fmt.Sprintf("NVRM: Xid (PCI:0000:9b:00): 13, pid=%d, Graphics Exception", i),
+		}
+		if _, err := d.Record(ctx, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// other devices are not rate limited by the flooding device
+	other := Event{
+		UnixSeconds:  baseTime,
+		DataSource:   "dmesg",
+		EventType:    "xid",
+		EventID:      13,
+		EventDetails: "NVRM: Xid (PCI:0000:cb:00): 13, pid=1, Graphics Exception",
+	}
+	inserted, err := d.Record(ctx, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inserted {
+		t.Fatal("expected the event from the other device inserted")
+	}
+
+	events, err := ReadEvents(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+	total := int64(0)
+	for _, ev := range events {
+		total += ev.Occurrences
+	}
+	if total != 101 {
+		t.Errorf("expected 101 occurrences in total, got %d", total)
+	}
+}
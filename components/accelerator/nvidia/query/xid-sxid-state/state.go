@@ -29,8 +29,20 @@ const (
 	// event id; xid or sxid
 	ColumnEventID = "event_id"
 
-	// event details; dmesg log line
+	// event details; dmesg log line, or the GPU UUID for the "nvml" data source
 	ColumnEventDetails = "event_details"
+
+	// number of the identical events merged into the row (see "Deduper")
+	ColumnOccurrences = "occurrences"
+
+	// unix timestamp in seconds when the event was last observed,
+	// where the "unix_seconds" is when the event was first observed
+	ColumnLastUnixSeconds = "last_unix_seconds"
+)
+
+const (
+	EventTypeXid  = "xid"
+	EventTypeSXid = "sxid"
 )
 
 type Event struct {
@@ -39,10 +51,18 @@ type Event struct {
 	EventType    string
 	EventID      int64
 	EventDetails string
+
+	// Occurrences is the number of the identical events merged into this event,
+	// between UnixSeconds (first observed) and LastUnixSeconds (last observed).
+	// Defaults to 1 if not set.
+	Occurrences int64
+	// LastUnixSeconds is the unix timestamp in seconds when the event was last observed.
+	// Defaults to UnixSeconds if not set.
+	LastUnixSeconds int64
 }
 
 func (e Event) ToXidDetail() *nvidia_query_xid.Detail {
-	if e.EventType != "xid" {
+	if e.EventType != EventTypeXid {
 		return nil
 	}
 	d, ok := nvidia_query_xid.GetDetail(int(e.EventID))
@@ -52,8 +72,28 @@ func (e Event) ToXidDetail() *nvidia_query_xid.Detail {
 	return d
 }
 
+// GPUUUID returns the GPU UUID of the Xid event, or an empty string if unknown,
+// given the GPU UUIDs by the PCI bus number to resolve the dmesg events.
+func (e Event) GPUUUID(busIDs map[uint32]string) string {
+	if e.EventType != EventTypeXid {
+		return ""
+	}
+	switch e.DataSource {
+	case "nvml":
+		return e.EventDetails
+
+	case "dmesg":
+		bus, ok := nvidia_query_xid.ExtractNVRMXidBusNumber(e.EventDetails)
+		if !ok {
+			return ""
+		}
+		return busIDs[bus]
+	}
+	return ""
+}
+
 func (e Event) ToSXidDetail() *nvidia_query_sxid.Detail {
-	if e.EventType != "sxid" {
+	if e.EventType != EventTypeSXid {
 		return nil
 	}
 	d, ok := nvidia_query_sxid.GetDetail(int(e.EventID))
@@ -70,22 +110,60 @@ CREATE TABLE IF NOT EXISTS %s (
 	%s TEXT NOT NULL,
 	%s TEXT NOT NULL,
 	%s INTEGER NOT NULL,
-	%s TEXT
+	%s TEXT,
+	%s INTEGER NOT NULL DEFAULT 1,
+	%s INTEGER
 );`, TableNameXidSXidEventHistory,
 		ColumnUnixSeconds,
 		ColumnDataSource,
 		ColumnEventType,
 		ColumnEventID,
 		ColumnEventDetails,
+		ColumnOccurrences,
+		ColumnLastUnixSeconds,
 	))
+	if err != nil {
+		return err
+	}
+
+	// migrate the tables created before the occurrence columns
+	if err := addColumnIfNotExists(ctx, db, ColumnOccurrences, "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		return err
+	}
+	return addColumnIfNotExists(ctx, db, ColumnLastUnixSeconds, "INTEGER")
+}
+
+func addColumnIfNotExists(ctx context.Context, db *sql.DB, column string, definition string) error {
+	var count int
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?;",
+		TableNameXidSXidEventHistory,
+		column,
+	).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", TableNameXidSXidEventHistory, column, definition))
 	return err
 }
 
 func InsertEvent(ctx context.Context, db *sql.DB, event Event) error {
 	log.Logger.Debugw("inserting event", "dataSource", event.DataSource, "eventType", event.EventType, "eventID", event.EventID, "details", event.EventDetails)
 
+	occurrences := event.Occurrences
+	if occurrences <= 0 {
+		occurrences = 1
+	}
+	lastUnixSeconds := event.LastUnixSeconds
+	if lastUnixSeconds < event.UnixSeconds {
+		lastUnixSeconds = event.UnixSeconds
+	}
+
 	insertStatement := fmt.Sprintf(`
-INSERT OR REPLACE INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, NULLIF(?, ''));
+INSERT OR REPLACE INTO %s (%s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?);
 `,
 		TableNameXidSXidEventHistory,
 		ColumnUnixSeconds,
@@ -93,6 +171,8 @@ INSERT OR REPLACE INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, NULLIF(?, '')
 		ColumnEventType,
 		ColumnEventID,
 		ColumnEventDetails,
+		ColumnOccurrences,
+		ColumnLastUnixSeconds,
 	)
 	_, err := db.ExecContext(
 		ctx,
@@ -102,6 +182,8 @@ INSERT OR REPLACE INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, NULLIF(?, '')
 		event.EventType,
 		event.EventID,
 		event.EventDetails,
+		occurrences,
+		lastUnixSeconds,
 	)
 	return err
 }
@@ -169,15 +251,19 @@ func ReadEvents(ctx context.Context, db *sql.DB, opts ...OpOption) ([]Event, err
 	events := []Event{}
 	for rows.Next() {
 		var event Event
+		var details sql.NullString
 		if err := rows.Scan(
 			&event.UnixSeconds,
 			&event.DataSource,
 			&event.EventType,
 			&event.EventID,
-			&event.EventDetails,
+			&details,
+			&event.Occurrences,
+			&event.LastUnixSeconds,
 		); err != nil {
 			return nil, err
 		}
+		event.EventDetails = details.String
 		events = append(events, event)
 	}
 	if err := rows.Err(); err != nil {
@@ -196,13 +282,16 @@ func createSelectStatementAndArgs(opts ...OpOption) (string, []any, error) {
 		return "", nil, err
 	}
 
-	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s
+	selectStatement := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, COALESCE(%s, 1), COALESCE(%s, %s)
 FROM %s`,
 		ColumnUnixSeconds,
 		ColumnDataSource,
 		ColumnEventType,
 		ColumnEventID,
 		ColumnEventDetails,
+		ColumnOccurrences,
+		ColumnLastUnixSeconds,
+		ColumnUnixSeconds,
 		TableNameXidSXidEventHistory,
 	)
 
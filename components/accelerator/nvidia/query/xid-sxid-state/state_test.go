@@ -23,7 +23,7 @@ func TestCreateSelectStatement(t *testing.T) {
 		{
 			name: "no options",
 			opts: nil,
-			want: fmt.Sprintf(`SELECT %s, %s, %s, %s, %s
+			want: fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, COALESCE(%s, 1), COALESCE(%s, %s)
 FROM %s
 ORDER BY %s DESC`,
 				ColumnUnixSeconds,
@@ -31,6 +31,9 @@ ORDER BY %s DESC`,
 				ColumnEventType,
 				ColumnEventID,
 				ColumnEventDetails,
+				ColumnOccurrences,
+				ColumnLastUnixSeconds,
+				ColumnUnixSeconds,
 				TableNameXidSXidEventHistory,
 				ColumnUnixSeconds,
 			),
@@ -40,7 +43,7 @@ ORDER BY %s DESC`,
 		{
 			name: "with since unix seconds",
 			opts: []OpOption{WithSince(time.Unix(1234, 0))},
-			want: fmt.Sprintf(`SELECT %s, %s, %s, %s, %s
+			want: fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, COALESCE(%s, 1), COALESCE(%s, %s)
 FROM %s
 WHERE %s >= ?
 ORDER BY %s DESC`,
@@ -49,6 +52,9 @@ ORDER BY %s DESC`,
 				ColumnEventType,
 				ColumnEventID,
 				ColumnEventDetails,
+				ColumnOccurrences,
+				ColumnLastUnixSeconds,
+				ColumnUnixSeconds,
 				TableNameXidSXidEventHistory,
 				ColumnUnixSeconds,
 				ColumnUnixSeconds,
@@ -59,7 +65,7 @@ ORDER BY %s DESC`,
 		{
 			name: "with ascending order",
 			opts: []OpOption{WithSortUnixSecondsAscendingOrder()},
-			want: fmt.Sprintf(`SELECT %s, %s, %s, %s, %s
+			want: fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, COALESCE(%s, 1), COALESCE(%s, %s)
 FROM %s
 ORDER BY %s ASC`,
 				ColumnUnixSeconds,
@@ -67,6 +73,9 @@ ORDER BY %s ASC`,
 				ColumnEventType,
 				ColumnEventID,
 				ColumnEventDetails,
+				ColumnOccurrences,
+				ColumnLastUnixSeconds,
+				ColumnUnixSeconds,
 				TableNameXidSXidEventHistory,
 				ColumnUnixSeconds,
 			),
@@ -76,7 +85,7 @@ ORDER BY %s ASC`,
 		{
 			name: "with limit",
 			opts: []OpOption{WithLimit(10)},
-			want: fmt.Sprintf(`SELECT %s, %s, %s, %s, %s
+			want: fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, COALESCE(%s, 1), COALESCE(%s, %s)
 FROM %s
 ORDER BY %s DESC
 LIMIT 10`,
@@ -85,6 +94,9 @@ LIMIT 10`,
 				ColumnEventType,
 				ColumnEventID,
 				ColumnEventDetails,
+				ColumnOccurrences,
+				ColumnLastUnixSeconds,
+				ColumnUnixSeconds,
 				TableNameXidSXidEventHistory,
 				ColumnUnixSeconds,
 			),
@@ -98,7 +110,7 @@ LIMIT 10`,
 				WithSortUnixSecondsAscendingOrder(),
 				WithLimit(10),
 			},
-			want: fmt.Sprintf(`SELECT %s, %s, %s, %s, %s
+			want: fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, COALESCE(%s, 1), COALESCE(%s, %s)
 FROM %s
 WHERE %s >= ?
 ORDER BY %s ASC
@@ -108,6 +120,9 @@ LIMIT 10`,
 				ColumnEventType,
 				ColumnEventID,
 				ColumnEventDetails,
+				ColumnOccurrences,
+				ColumnLastUnixSeconds,
+				ColumnUnixSeconds,
 				TableNameXidSXidEventHistory,
 				ColumnUnixSeconds,
 				ColumnUnixSeconds,
@@ -505,3 +520,26 @@ func setupTestDB(t *testing.T) (*sql.DB, func()) {
 	}
 	return db, cleanup
 }
+
+func TestEventGPUUUID(t *testing.T) {
+	t.Parallel()
+
+	busIDs := map[uint32]string{0xcb: "GPU-0"}
+	tests := []struct {
+		name string
+		ev   Event
+		want string
+	}{
+		{name: "nvml", ev: Event{DataSource: "nvml", EventType: "xid", EventID: 79, EventDetails: "GPU-1"}, want: "GPU-1"},
+		{name: "dmesg", ev: Event{DataSource: "dmesg", EventType: "xid", EventID: 13, EventDetails: "NVRM: Xid (PCI:0000:cb:00): 13, pid='<unknown>', name=<unknown>, Graphics Exception"}, want: "GPU-0"},
+		{name: "dmesg unknown bus", ev: Event{DataSource: "dmesg", EventType: "xid", EventID: 13, EventDetails: "NVRM: Xid (PCI:0000:05:00): 13, pid='<unknown>', name=<unknown>, Graphics Exception"}},
+		{name: "sxid", ev: Event{DataSource: "dmesg", EventType: "sxid", EventID: 12028, EventDetails: "nvidia-nvswitch3: SXid (PCI:0000:05:00.0): 12028, Non-fatal"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ev.GPUUUID(busIDs); got != tt.want {
+				t.Errorf("GPUUUID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
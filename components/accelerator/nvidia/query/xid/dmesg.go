@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"regexp"
 	"strconv"
+	"strings"
 
 	query_log "github.com/leptonai/gpud/components/query/log"
 
@@ -24,6 +25,85 @@ const (
 
 var CompiledRegexNVRMXidDmesg = regexp.MustCompile(RegexNVRMXidDmesg)
 
+const (
+	// e.g.,
+	// NVRM: Xid (PCI:0000:05:00): 79, ...
+	// NVRM: Xid (0000:03:00): 14, ...
+	// NVRM: Xid (PCI:0000:01:00 GPU-I:05): 94, ...
+	RegexNVRMXidDeviceBusID = `NVRM: Xid \((?:PCI:)?([0-9a-fA-F]+:[0-9a-fA-F]+:[0-9a-fA-F]+)`
+
+	// e.g.,
+	// NVRM: Xid (PCI:0000:19:00): 119, pid=452531, name=cache_mgr_main, ...
+	// NVRM: Xid (PCI:0000:01:00): 94, pid=7062, Contained: ...
+	// NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, ...
+	RegexNVRMXidProcess = `NVRM: Xid .*?: \d+, pid='?(\d+)'?(?:, name=([^,]+))?`
+)
+
+const (
+	// e.g.,
+	// NVRM: Xid (PCI:0000:3b:00): 63, pid=1234, name=python, Row Remapper: New row (0x0000000087c8b200) marked for remapping, reset gpu to activate.
+	// NVRM: Xid (PCI:0000:3b:00): 64, pid=1234, name=python, Row Remapper Error: (0x0000000087c8b200) - All reserved rows for bank are remapped
+	RegexNVRMXidRowRemapperAddress = `NVRM: Xid .*?: 6[34], .*?\(0x([0-9a-fA-F]+)\)`
+)
+
+var (
+	CompiledRegexNVRMXidDeviceBusID        = regexp.MustCompile(RegexNVRMXidDeviceBusID)
+	CompiledRegexNVRMXidProcess            = regexp.MustCompile(RegexNVRMXidProcess)
+	CompiledRegexNVRMXidRowRemapperAddress = regexp.MustCompile(RegexNVRMXidRowRemapperAddress)
+)
+
+// Extracts the PCI bus ID of the GPU (e.g., "0000:05:00") from the dmesg Xid log line.
+// Returns an empty string if the bus ID is not found.
+func ExtractNVRMXidDeviceBusID(line string) string {
+	if match := CompiledRegexNVRMXidDeviceBusID.FindStringSubmatch(line); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// Extracts the PCI bus number of the GPU (e.g., 0x05 for "0000:05:00") from the dmesg Xid log line.
+// Returns false if the bus ID is not found.
+func ExtractNVRMXidBusNumber(line string) (uint32, bool) {
+	// e.g., "0000:05:00" (domain:bus:device)
+	parts := strings.Split(ExtractNVRMXidDeviceBusID(line), ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	bus, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(bus), true
+}
+
+// Extracts the memory address of the row marked for remapping from the Xid 63/64 dmesg log line.
+// Returns false if the address is not found.
+func ExtractNVRMXidRowRemapperAddress(line string) (uint64, bool) {
+	match := CompiledRegexNVRMXidRowRemapperAddress.FindStringSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+	addr, err := strconv.ParseUint(match[1], 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	return addr, true
+}
+
+// Extracts the process ID and name that triggered the Xid from the dmesg log line.
+// Returns 0 if the process is not found or unknown (e.g., "pid='<unknown>'").
+func ExtractNVRMXidProcess(line string) (int, string) {
+	match := CompiledRegexNVRMXidProcess.FindStringSubmatch(line)
+	if match == nil {
+		return 0, ""
+	}
+	pid, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, ""
+	}
+	return pid, match[2]
+}
+
 // Extracts the nvidia Xid error code from the dmesg log line.
 // Returns 0 if the error code is not found.
 // https://docs.nvidia.com/deploy/pdf/XID_Errors.pdf
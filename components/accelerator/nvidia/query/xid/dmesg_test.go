@@ -1,6 +1,9 @@
 package xid
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func TestExtractNVRMXid(t *testing.T) {
 	t.Parallel()
@@ -88,3 +91,103 @@ func TestExtractNVRMXid(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractNVRMXidDeviceBusIDAndProcess(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		input       string
+		wantBusID   string
+		wantPID     int
+		wantProcess string
+	}{
+		{
+			name:      "unknown process",
+			input:     "[111111111.111] NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus.",
+			wantBusID: "0000:05:00",
+		},
+		{
+			name:      "no process",
+			input:     "NVRM: Xid (PCI:0000:01:00): 79, GPU has fallen off the bus.",
+			wantBusID: "0000:01:00",
+		},
+		{
+			name:      "no PCI prefix",
+			input:     "[...] NVRM: Xid (0000:03:00): 14, Channel 00000001",
+			wantBusID: "0000:03:00",
+		},
+		{
+			name:      "pid without name with MIG enabled",
+			input:     "NVRM: Xid (PCI:0000:01:00 GPU-I:05): 94, pid=7194, Contained: CE User Channel (0x9). RST: No, D-RST: No",
+			wantBusID: "0000:01:00",
+			wantPID:   7194,
+		},
+		{
+			name:        "pid with name",
+			input:       "NVRM: Xid (PCI:0000:19:00): 119, pid=452531, name=cache_mgr_main, Timeout after 6s of waiting for RPC response from GPU0 GSP!",
+			wantBusID:   "0000:19:00",
+			wantPID:     452531,
+			wantProcess: "cache_mgr_main",
+		},
+		{
+			name:  "no match",
+			input: "Regular log content without Xid errors",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractNVRMXidDeviceBusID(tt.input); got != tt.wantBusID {
+				t.Errorf("ExtractNVRMXidDeviceBusID() = %q, want %q", got, tt.wantBusID)
+			}
+			if bus, ok := ExtractNVRMXidBusNumber(tt.input); ok != (tt.wantBusID != "") || (ok && fmt.Sprintf("0000:%02x:00", bus) != tt.wantBusID) {
+				t.Errorf("ExtractNVRMXidBusNumber() = (0x%x, %v), want %q", bus, ok, tt.wantBusID)
+			}
+			pid, name := ExtractNVRMXidProcess(tt.input)
+			if pid != tt.wantPID || name != tt.wantProcess {
+				t.Errorf("ExtractNVRMXidProcess() = (%d, %q), want (%d, %q)", pid, name, tt.wantPID, tt.wantProcess)
+			}
+		})
+	}
+}
+
+func TestExtractNVRMXidRowRemapperAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		input  string
+		want   uint64
+		wantOK bool
+	}{
+		{
+			name:   "xid 63",
+			input:  "NVRM: Xid (PCI:0000:3b:00): 63, pid=1234, name=python, Row Remapper: New row (0x0000000087c8b200) marked for remapping, reset gpu to activate.",
+			want:   0x87c8b200,
+			wantOK: true,
+		},
+		{
+			name:   "xid 64",
+			input:  "NVRM: Xid (PCI:0000:3b:00): 64, pid=1234, name=python, Row Remapper Error: (0x0000000087c8b200) - All reserved rows for bank are remapped",
+			want:   0x87c8b200,
+			wantOK: true,
+		},
+		{
+			name:  "other xid with hex",
+			input: "NVRM: Xid (PCI:0000:01:00): 94, pid=7062, Contained: CE User Channel (0x9). RST: No, D-RST: No",
+		},
+		{
+			name:  "xid 63 without address",
+			input: "NVRM: Xid (PCI:0000:3b:00): 63, Row Remapper: New row marked for remapping",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExtractNVRMXidRowRemapperAddress(tt.input)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ExtractNVRMXidRowRemapperAddress() = (0x%x, %v), want (0x%x, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
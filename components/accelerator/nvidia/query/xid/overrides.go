@@ -0,0 +1,58 @@
+package xid
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// overrides are the Xid details loaded at runtime (e.g., from the details file shipped by the fleet team),
+// which take precedence over the embedded details, so that the Xids of the new driver releases
+// are classified without a gpud release.
+var overrides atomic.Pointer[map[int]Detail]
+
+// SetOverrides replaces the Xid details that take precedence over the embedded details.
+// Set nil to use the embedded details only.
+// Returns an error without changing the current overrides, if any detail is invalid.
+func SetOverrides(ds []Detail) error {
+	m := make(map[int]Detail, len(ds))
+	for _, d := range ds {
+		if err := d.Validate(); err != nil {
+			return fmt.Errorf("invalid xid %d: %w", d.Xid, err)
+		}
+		if _, ok := m[d.Xid]; ok {
+			return fmt.Errorf("duplicate xid %d", d.Xid)
+		}
+		m[d.Xid] = d
+	}
+	overrides.Store(&m)
+	return nil
+}
+
+// Validate returns an error if the detail is missing the required fields,
+// or the suggested actions are inconsistent with the critical flag.
+func (d Detail) Validate() error {
+	if d.Xid <= 0 {
+		return errors.New("xid must be greater than 0")
+	}
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	if d.CriticalErrorMarkedByGPUd && (d.SuggestedActionsByGPUd == nil || len(d.SuggestedActionsByGPUd.RepairActions) == 0) {
+		return errors.New("critical error must have repair actions")
+	}
+	if d.SuggestedActionsByGPUd != nil && len(d.SuggestedActionsByGPUd.Descriptions) > 0 &&
+		len(d.SuggestedActionsByGPUd.Descriptions) != len(d.SuggestedActionsByGPUd.RepairActions) {
+		return fmt.Errorf("%d descriptions and %d repair actions", len(d.SuggestedActionsByGPUd.Descriptions), len(d.SuggestedActionsByGPUd.RepairActions))
+	}
+	return nil
+}
+
+func getOverride(id int) (Detail, bool) {
+	m := overrides.Load()
+	if m == nil {
+		return Detail{}, false
+	}
+	d, ok := (*m)[id]
+	return d, ok
+}
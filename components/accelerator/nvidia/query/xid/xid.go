@@ -98,9 +98,12 @@ func (d Detail) IsMarkedAsCriticalByGPUd() bool {
 	return d.CriticalErrorMarkedByGPUd
 }
 
-// Returns the error if found.
+// Returns the error if found, from the overrides (if set) or the embedded details.
 // Otherwise, returns false.
 func GetDetail(id int) (*Detail, bool) {
+	if e, ok := getOverride(id); ok {
+		return &e, true
+	}
 	e, ok := details[id]
 	return &e, ok
 }
@@ -55,6 +55,9 @@ func ToOutput(i *nvidia_query.Output) *Output {
 			if rma {
 				msg := fmt.Sprintf("nvidia-smi indicates GPU %s qualifies for RMA (remapping failure occurred %v, remapped due to uncorrectable errors %s)", parsed.ID, parsed.RemappingFailed, parsed.RemappedDueToUncorrectableErrors)
 				rmaMsgs = append(rmaMsgs, msg)
+			} else if failed, err := parsed.GetRemappingFailured(); err == nil && failed {
+				msg := fmt.Sprintf("nvidia-smi indicates GPU %s row remapping failure (validate with the field diagnostic for RMA)", parsed.ID)
+				rmaMsgs = append(rmaMsgs, msg)
 			}
 		}
 	}
@@ -73,6 +76,18 @@ func ToOutput(i *nvidia_query.Output) *Output {
 			if rma {
 				msg := fmt.Sprintf("NVML indicates GPU %s qualifies for RMA (remapping failure occurred %v)", device.UUID, device.RemappedRows.RemappingFailed)
 				rmaMsgs = append(rmaMsgs, msg)
+			} else if device.RemappedRows.RemappingFailed {
+				msg := fmt.Sprintf("NVML indicates GPU %s row remapping failure (validate with the field diagnostic for RMA)", device.UUID)
+				rmaMsgs = append(rmaMsgs, msg)
+			}
+
+			if !device.RetiredPages.Supported {
+				continue
+			}
+			o.RetiredPagesNVML = append(o.RetiredPagesNVML, device.RetiredPages)
+			if RetiredPagesQualifyForRMA(device.RetiredPages) {
+				msg := fmt.Sprintf("NVML indicates GPU %s qualifies for RMA (%d pages retired)", device.UUID, retiredPagesCount(device.RetiredPages))
+				rmaMsgs = append(rmaMsgs, msg)
 			}
 		}
 	}
@@ -101,12 +116,30 @@ type Output struct {
 	MemoryErrorManagementCapabilities nvidia_query.MemoryErrorManagementCapabilities `json:"memory_error_management_capabilities"`
 	RemappedRowsSMI                   []nvidia_query.ParsedSMIRemappedRows           `json:"remapped_rows_smi"`
 	RemappedRowsNVML                  []nvidia_query_nvml.RemappedRows               `json:"remapped_rows_nvml"`
+	// RetiredPagesNVML is the legacy dynamic page retirement of the pre-Ampere GPUs
+	// (only the GPUs supporting the page retirement).
+	RetiredPagesNVML []nvidia_query_nvml.RetiredPages `json:"retired_pages_nvml,omitempty"`
 
 	// Recommended course of actions for any of the GPUs with a known issue.
 	// For individual GPU details, see each per-GPU states.
 	SuggestedActions *common.SuggestedActions `json:"suggested_actions,omitempty"`
 }
 
+// RetiredPagesRMAThreshold is the number of the retired pages per GPU
+// at which the GPU qualifies for the RMA, as in the dynamic page retirement guide.
+// ref. https://docs.nvidia.com/deploy/dynamic-page-retirement/index.html
+const RetiredPagesRMAThreshold = 60
+
+// RetiredPagesQualifyForRMA returns true if the retired pages (both the single and double bit ECC errors)
+// reached the RMA threshold.
+func RetiredPagesQualifyForRMA(p nvidia_query_nvml.RetiredPages) bool {
+	return retiredPagesCount(p) >= RetiredPagesRMAThreshold
+}
+
+func retiredPagesCount(p nvidia_query_nvml.RetiredPages) int {
+	return len(p.MultipleSingleBitECCErrors) + len(p.DoubleBitECCErrors)
+}
+
 func (o *Output) JSON() ([]byte, error) {
 	return json.Marshal(o)
 }
@@ -127,6 +160,13 @@ const (
 	StateValueRemappedRowsEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameRemappedRows, "per-GPU remapped rows and retired pages"),
+		nvidia_query.GPUStateSchema(StateNameRemappedRows, "remapped rows health of the GPU, one state per GPU"),
+	)
+}
+
 func ParseStateRemappedRows(m map[string]string) (*Output, error) {
 	data := m[StateKeyRemappedRowsData]
 	return ParseOutputJSON([]byte(data))
@@ -143,6 +183,10 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 			return o, nil
 
 		default:
+			if nvidia_query.IsGPUStateName(StateNameRemappedRows, state.Name) {
+				// per-GPU states, derived from the component state
+				continue
+			}
 			return nil, fmt.Errorf("unknown state name: %s", state.Name)
 		}
 	}
@@ -155,13 +199,26 @@ func (o *Output) Evaluate() (string, bool, error) {
 		return "no data", true, nil
 	}
 
+	healthy := true
+	reasons := []string{}
+
+	for _, p := range o.RetiredPagesNVML {
+		if RetiredPagesQualifyForRMA(p) {
+			healthy = false
+			reasons = append(reasons, fmt.Sprintf("nvml GPU %s qualifies for RMA (%d pages retired due to multiple single bit ecc errors, %d due to double bit ecc errors)", p.UUID, len(p.MultipleSingleBitECCErrors), len(p.DoubleBitECCErrors)))
+		}
+	}
+
 	if !o.MemoryErrorManagementCapabilities.RowRemapping {
+		if len(reasons) > 0 {
+			return strings.Join(reasons, ", "), healthy, nil
+		}
+		if len(o.RetiredPagesNVML) > 0 {
+			return "row remapping is not supported, no page retirement issue detected", true, nil
+		}
 		return "row remapping is not supported", true, nil
 	}
 
-	healthy := true
-	reasons := []string{}
-
 	for _, r := range o.RemappedRowsSMI {
 		rma, err := r.QualifiesForRMA()
 		if err != nil {
@@ -172,6 +229,11 @@ func (o *Output) Evaluate() (string, bool, error) {
 		if rma {
 			healthy = false
 			reasons = append(reasons, fmt.Sprintf("nvidia-smi GPU %s qualifies for RMA (remapping failure occurred %v, remapped due to uncorrectable errors %s)", r.ID, r.RemappingFailed, r.RemappedDueToUncorrectableErrors))
+		} else if failed, err := r.GetRemappingFailured(); err == nil && failed {
+			// the remapping failure flag itself is the RMA criteria once validated by the field diagnostic
+			// ref. https://docs.nvidia.com/deploy/a100-gpu-mem-error-mgmt/index.html#rma-policy-thresholds-for-row-remapping
+			healthy = false
+			reasons = append(reasons, fmt.Sprintf("nvidia-smi GPU %s row remapping failure occurred (remapped due to uncorrectable errors %s)", r.ID, r.RemappedDueToUncorrectableErrors))
 		}
 
 		needsReset, err := r.RequiresReset()
@@ -190,6 +252,9 @@ func (o *Output) Evaluate() (string, bool, error) {
 		if r.QualifiesForRMA() {
 			healthy = false
 			reasons = append(reasons, fmt.Sprintf("nvml GPU %s qualifies for RMA (remapping failure occurred %v, remapped due to uncorrectable errors %d)", r.UUID, r.RemappingFailed, r.RemappedDueToUncorrectableErrors))
+		} else if r.RemappingFailed {
+			healthy = false
+			reasons = append(reasons, fmt.Sprintf("nvml GPU %s row remapping failure occurred (remapped due to uncorrectable errors %d)", r.UUID, r.RemappedDueToUncorrectableErrors))
 		}
 		if r.RequiresReset() {
 			healthy = false
@@ -226,5 +291,44 @@ func (o *Output) States() ([]components.State, error) {
 		state.SuggestedActions = o.SuggestedActions
 	}
 
-	return []components.State{state}, nil
+	return append([]components.State{state}, o.GPUStates()...), nil
+}
+
+// GPUStates returns the per-GPU states from NVML (e.g., "remapped_rows_GPU-1234"),
+// unhealthy if the GPU qualifies for RMA or needs reset.
+func (o *Output) GPUStates() []components.State {
+	gs := nvidia_query.NewGPUStates(StateNameRemappedRows)
+	if o == nil {
+		return gs.States()
+	}
+
+	rma := &common.SuggestedActions{
+		Descriptions:  []string{"inspect the GPU memory for RMA"},
+		RepairActions: []common.RepairActionType{common.RepairActionTypeHardwareInspection},
+	}
+	for _, p := range o.RetiredPagesNVML {
+		gs.Add(p.UUID)
+		if RetiredPagesQualifyForRMA(p) {
+			gs.Unhealthy(p.UUID, fmt.Sprintf("qualifies for RMA (%d pages retired)", retiredPagesCount(p)), rma)
+		}
+	}
+
+	if !o.MemoryErrorManagementCapabilities.RowRemapping {
+		return gs.States()
+	}
+	for _, r := range o.RemappedRowsNVML {
+		gs.Add(r.UUID)
+		if r.QualifiesForRMA() {
+			gs.Unhealthy(r.UUID, fmt.Sprintf("qualifies for RMA (remapping failure occurred %v, remapped due to uncorrectable errors %d)", r.RemappingFailed, r.RemappedDueToUncorrectableErrors), rma)
+		} else if r.RemappingFailed {
+			gs.Unhealthy(r.UUID, fmt.Sprintf("row remapping failure occurred (remapped due to uncorrectable errors %d)", r.RemappedDueToUncorrectableErrors), rma)
+		}
+		if r.RequiresReset() {
+			gs.Unhealthy(r.UUID, fmt.Sprintf("needs reset (pending remapping %v)", r.RemappingPending), &common.SuggestedActions{
+				Descriptions:  []string{"reboot the system to apply the pending row remapping"},
+				RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+			})
+		}
+	}
+	return gs.States()
 }
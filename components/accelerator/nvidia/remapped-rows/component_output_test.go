@@ -0,0 +1,128 @@
+package remappedrows
+
+import (
+	"testing"
+
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/components/common"
+)
+
+func retiredPages(n int) []nvidia_query_nvml.RetiredPage {
+	pages := make([]nvidia_query_nvml.RetiredPage, n)
+	for i := range pages {
+		pages[i].Address = uint64(i)
+	}
+	return pages
+}
+
+func TestToOutputEvaluate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		productName string
+		device      nvidia_query_nvml.DeviceInfo
+		wantHealthy bool
+		wantReason  string
+		wantAction  common.RepairActionType
+	}{
+		{
+			name:        "no issue",
+			productName: "NVIDIA H100 80GB HBM3",
+			device:      nvidia_query_nvml.DeviceInfo{UUID: "GPU-0", RemappedRows: nvidia_query_nvml.RemappedRows{UUID: "GPU-0", RemappedDueToCorrectableErrors: 1}},
+			wantHealthy: true,
+			wantReason:  "no issues detected",
+		},
+		{
+			name:        "remapping pending",
+			productName: "NVIDIA H100 80GB HBM3",
+			device:      nvidia_query_nvml.DeviceInfo{UUID: "GPU-0", RemappedRows: nvidia_query_nvml.RemappedRows{UUID: "GPU-0", RemappingPending: true}},
+			wantHealthy: false,
+			wantReason:  "nvml GPU GPU-0 needs reset (pending remapping true)",
+			wantAction:  common.RepairActionTypeRebootSystem,
+		},
+		{
+			name:        "remapping failure",
+			productName: "NVIDIA A100-SXM4-80GB",
+			device:      nvidia_query_nvml.DeviceInfo{UUID: "GPU-0", RemappedRows: nvidia_query_nvml.RemappedRows{UUID: "GPU-0", RemappedDueToUncorrectableErrors: 2, RemappingFailed: true}},
+			wantHealthy: false,
+			wantReason:  "nvml GPU GPU-0 row remapping failure occurred (remapped due to uncorrectable errors 2)",
+			wantAction:  common.RepairActionTypeHardwareInspection,
+		},
+		{
+			name:        "remapping failure qualifies for rma",
+			productName: "NVIDIA A100-SXM4-80GB",
+			device:      nvidia_query_nvml.DeviceInfo{UUID: "GPU-0", RemappedRows: nvidia_query_nvml.RemappedRows{UUID: "GPU-0", RemappedDueToUncorrectableErrors: 8, RemappingFailed: true}},
+			wantHealthy: false,
+			wantReason:  "nvml GPU GPU-0 qualifies for RMA (remapping failure occurred true, remapped due to uncorrectable errors 8)",
+			wantAction:  common.RepairActionTypeHardwareInspection,
+		},
+		{
+			name:        "legacy retired pages under threshold",
+			productName: "Tesla V100-SXM2-32GB",
+			device: nvidia_query_nvml.DeviceInfo{UUID: "GPU-0", RetiredPages: nvidia_query_nvml.RetiredPages{
+				UUID: "GPU-0", Supported: true, DoubleBitECCErrors: retiredPages(2),
+			}},
+			wantHealthy: true,
+			wantReason:  "row remapping is not supported, no page retirement issue detected",
+		},
+		{
+			name:        "legacy retired pages qualify for rma",
+			productName: "Tesla V100-SXM2-32GB",
+			device: nvidia_query_nvml.DeviceInfo{UUID: "GPU-0", RetiredPages: nvidia_query_nvml.RetiredPages{
+				UUID: "GPU-0", Supported: true, MultipleSingleBitECCErrors: retiredPages(58), DoubleBitECCErrors: retiredPages(2),
+			}},
+			wantHealthy: false,
+			wantReason:  "nvml GPU GPU-0 qualifies for RMA (58 pages retired due to multiple single bit ecc errors, 2 due to double bit ecc errors)",
+			wantAction:  common.RepairActionTypeHardwareInspection,
+		},
+		{
+			name:        "page retirement not supported",
+			productName: "Tesla T4",
+			device:      nvidia_query_nvml.DeviceInfo{UUID: "GPU-0"},
+			wantHealthy: true,
+			wantReason:  "row remapping is not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := tt.device
+			o := ToOutput(&nvidia_query.Output{
+				MemoryErrorManagementCapabilities: nvidia_query.GetMemoryErrorManagementCapabilities(tt.productName),
+				NVML:                              &nvidia_query_nvml.Output{DeviceInfos: []*nvidia_query_nvml.DeviceInfo{&dev}},
+			})
+
+			reason, healthy, err := o.Evaluate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if healthy != tt.wantHealthy || reason != tt.wantReason {
+				t.Errorf("Evaluate() = %q/%v, want %q/%v", reason, healthy, tt.wantReason, tt.wantHealthy)
+			}
+
+			gpuStates := o.GPUStates()
+			if !tt.wantHealthy && len(gpuStates) != 1 {
+				t.Fatalf("expected 1 per-GPU state, got %d", len(gpuStates))
+			}
+			for _, st := range gpuStates {
+				if st.Name != "remapped_rows_GPU-0" || st.Healthy != tt.wantHealthy {
+					t.Errorf("unexpected per-GPU state %+v", st)
+				}
+				if !tt.wantHealthy && (st.SuggestedActions == nil || st.SuggestedActions.RepairActions[0] != tt.wantAction) {
+					t.Errorf("expected per-GPU suggested action %s, got %+v", tt.wantAction, st.SuggestedActions)
+				}
+			}
+
+			if tt.wantAction == "" {
+				if o.SuggestedActions != nil {
+					t.Errorf("expected no suggested actions, got %+v", o.SuggestedActions)
+				}
+				return
+			}
+			if o.SuggestedActions == nil || len(o.SuggestedActions.RepairActions) != 1 || o.SuggestedActions.RepairActions[0] != tt.wantAction {
+				t.Errorf("expected suggested action %s, got %+v", tt.wantAction, o.SuggestedActions)
+			}
+		})
+	}
+}
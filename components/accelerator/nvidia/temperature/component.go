@@ -9,7 +9,9 @@ import (
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_cooling_trend "github.com/leptonai/gpud/components/accelerator/nvidia/query/cooling-trend"
 	nvidia_query_metrics_temperature "github.com/leptonai/gpud/components/accelerator/nvidia/query/metrics/temperature"
+	nvidia_query_threshold_breach_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/threshold-breach-state"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
 
@@ -25,10 +27,19 @@ func New(ctx context.Context, cfg Config) components.Component {
 	nvidia_query.SetDefaultPoller(cfg.Query.State.DB)
 	nvidia_query.GetDefaultPoller().Start(cctx, cfg.Query, Name)
 
+	coolingPolicy := nvidia_query_cooling_trend.Policy{}
+	if cfg.CoolingTrend != nil {
+		coolingPolicy = *cfg.CoolingTrend
+	}
+
 	return &component{
 		rootCtx: ctx,
 		cancel:  ccancel,
 		poller:  nvidia_query.GetDefaultPoller(),
+		cfg:     cfg,
+
+		thresholds: nvidia_query_threshold_breach_state.NewTracker(cfg.Query.State.DB, Name),
+		cooling:    nvidia_query_cooling_trend.NewTracker(cfg.Query.State.DB, coolingPolicy),
 	}
 }
 
@@ -39,6 +50,10 @@ type component struct {
 	cancel   context.CancelFunc
 	poller   query.Poller
 	gatherer prometheus.Gatherer
+	cfg      Config
+
+	thresholds *nvidia_query_threshold_breach_state.Tracker
+	cooling    *nvidia_query_cooling_trend.Tracker
 }
 
 func (c *component) Name() string { return Name }
@@ -58,6 +73,9 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
+	if states, ok := nvidia_query.StackAbsentStates(c.Name(), last.Error); ok {
+		return states, nil
+	}
 	if last.Error != nil {
 		return []components.State{
 			{
@@ -96,11 +114,76 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 		return cs, nil
 	}
 	output := ToOutput(allOutput)
+	output.ThresholdPreset, output.Thresholds = nvidia_query.GetGPUThresholds(allOutput.GPUProductName(), c.cfg.Thresholds)
+	if _, err := c.thresholds.Observe(ctx, last.Time.Time, output.ThresholdReadings()...); err != nil {
+		log.Logger.Warnw("failed to record threshold breach events", "component", Name, "error", err)
+	}
+	if _, err := c.cooling.Observe(ctx, last.Time.Time, coolingSamples(allOutput)...); err != nil {
+		log.Logger.Warnw("failed to record cooling trend", "component", Name, "error", err)
+	}
 	return output.States()
 }
 
+var _ components.Explainer = (*component)(nil)
+
+// Explain returns the per-GPU rules evaluated on the last collected data.
+func (c *component) Explain(ctx context.Context) ([]components.Rule, error) {
+	last, err := c.poller.Last()
+	if err != nil {
+		return nil, err
+	}
+	if nvidia_query.IsStackAbsent(last.Error) {
+		return nil, nil
+	}
+	if last.Error != nil {
+		return nil, last.Error
+	}
+	allOutput, ok := last.Output.(*nvidia_query.Output)
+	if !ok {
+		return nil, fmt.Errorf("invalid output type: %T", last.Output)
+	}
+	output := ToOutput(allOutput)
+	output.ThresholdPreset, output.Thresholds = nvidia_query.GetGPUThresholds(allOutput.GPUProductName(), c.cfg.Thresholds)
+	overridden := c.cfg.Thresholds != nil && c.cfg.Thresholds.MaxTemperatureCelsius > 0
+	return output.Rules(nvidia_query.GPUThresholdSource(output.ThresholdPreset, overridden)), nil
+}
+
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
-	return nil, nil
+	breaches, err := nvidia_query_threshold_breach_state.ReadEvents(ctx, c.cfg.Query.State.DB, since, Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read threshold breach events: %w", err)
+	}
+	advisories, err := nvidia_query_cooling_trend.ReadAdvisories(ctx, c.cfg.Query.State.DB, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cooling degradation advisories: %w", err)
+	}
+	evs := make([]components.Event, 0, len(breaches)+len(advisories))
+	for _, ev := range breaches {
+		evs = append(evs, ev.ToComponentEvent())
+	}
+	for _, a := range advisories {
+		evs = append(evs, a.ToComponentEvent())
+	}
+	return evs, nil
+}
+
+// coolingSamples returns the per-GPU temperatures at the current power draw.
+func coolingSamples(i *nvidia_query.Output) []nvidia_query_cooling_trend.Sample {
+	if i == nil || i.NVML == nil {
+		return nil
+	}
+	samples := make([]nvidia_query_cooling_trend.Sample, 0, len(i.NVML.DeviceInfos))
+	for _, device := range i.NVML.DeviceInfos {
+		if device.Power.UsageMilliWatts == 0 || device.Temperature.CurrentCelsiusGPUCore == 0 {
+			continue
+		}
+		samples = append(samples, nvidia_query_cooling_trend.Sample{
+			GPUUUID:    device.UUID,
+			PowerWatts: float64(device.Power.UsageMilliWatts) / 1000,
+			Celsius:    float64(device.Temperature.CurrentCelsiusGPUCore),
+		})
+	}
+	return samples
 }
 
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
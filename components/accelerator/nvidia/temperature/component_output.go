@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	nvidia_query_threshold_breach_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/threshold-breach-state"
 	"github.com/leptonai/gpud/log"
 
 	"sigs.k8s.io/yaml"
@@ -48,6 +50,12 @@ func ToOutput(i *nvidia_query.Output) *Output {
 type Output struct {
 	UsagesSMI  []nvidia_query.ParsedTemperature `json:"usages_smi"`
 	UsagesNVML []nvidia_query_nvml.Temperature  `json:"usages_nvml"`
+
+	// ThresholdPreset is the threshold preset picked based on the GPU product name
+	// (empty if no preset is defined for the product).
+	ThresholdPreset string `json:"threshold_preset,omitempty"`
+	// Thresholds is the preset thresholds with the config overrides applied.
+	Thresholds nvidia_query.GPUThresholds `json:"thresholds"`
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -70,6 +78,13 @@ const (
 	StateValueTemperatureEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameTemperature, "per-GPU temperatures"),
+		nvidia_query.GPUStateSchema(StateNameTemperature, "temperature health of the GPU, one state per GPU"),
+	)
+}
+
 func ParseStateTemperature(m map[string]string) (*Output, error) {
 	data := m[StateKeyTemperatureData]
 	return ParseOutputJSON([]byte(data))
@@ -86,6 +101,10 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 			return o, nil
 
 		default:
+			if nvidia_query.IsGPUStateName(StateNameTemperature, state.Name) {
+				// per-GPU states, derived from the component state
+				continue
+			}
 			return nil, fmt.Errorf("unknown state name: %s", state.Name)
 		}
 	}
@@ -95,27 +114,107 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 // Returns the output evaluation reason and its healthy-ness.
 func (o *Output) Evaluate() (string, bool, error) {
 	type temp struct {
-		UUID        string `json:"uuid"`
-		Limit       uint32 `json:"limit"`
-		Usage       uint32 `json:"usage"`
-		UsedPercent string `json:"used_percent"`
+		UUID         string `json:"uuid"`
+		LimitCelsius uint32 `json:"limit_celsius"`
+		Celsius      uint32 `json:"celsius"`
+		UsedPercent  string `json:"used_percent"`
 	}
 	ts := make([]temp, len(o.UsagesNVML))
+	hot := []string{}
 	for i, u := range o.UsagesNVML {
 		ts[i] = temp{
-			UUID:        u.UUID,
-			Limit:       u.ThresholdCelsiusSlowdown,
-			Usage:       u.CurrentCelsiusGPUCore,
-			UsedPercent: u.UsedPercentSlowdown,
+			UUID:         u.UUID,
+			LimitCelsius: u.ThresholdCelsiusSlowdown,
+			Celsius:      u.CurrentCelsiusGPUCore,
+			UsedPercent:  u.UsedPercentSlowdown,
+		}
+		if o.Thresholds.MaxTemperatureCelsius > 0 && u.CurrentCelsiusGPUCore >= o.Thresholds.MaxTemperatureCelsius {
+			hot = append(hot, fmt.Sprintf("%s (%d C)", u.UUID, u.CurrentCelsiusGPUCore))
+		}
+	}
+
+	// evaluates the nvidia-smi temperatures if NVML failed to initialize
+	if len(o.UsagesNVML) == 0 {
+		for _, u := range o.UsagesSMI {
+			cur, err := u.GetCurrentCelsius()
+			if err != nil {
+				continue
+			}
+			t := temp{
+				UUID:        u.ID,
+				Celsius:     uint32(cur),
+				UsedPercent: u.UsedPercent,
+			}
+			if slowdown, err := u.GetSlowdownCelsius(); err == nil {
+				t.LimitCelsius = uint32(slowdown)
+			}
+			ts = append(ts, t)
+			if o.Thresholds.MaxTemperatureCelsius > 0 && t.Celsius >= o.Thresholds.MaxTemperatureCelsius {
+				hot = append(hot, fmt.Sprintf("%s (%d C)", u.ID, t.Celsius))
+			}
 		}
 	}
 	yb, err := yaml.Marshal(ts)
 	if err != nil {
 		return "", false, err
 	}
+	if len(hot) > 0 {
+		return fmt.Sprintf("%d GPU(s) at or above the %d C threshold (preset %q): %s",
+			len(hot),
+			o.Thresholds.MaxTemperatureCelsius,
+			o.ThresholdPreset,
+			strings.Join(hot, ", "),
+		), false, nil
+	}
 	return string(yb), true, nil
 }
 
+// Rules returns the per-GPU max temperature rules evaluated by Evaluate,
+// with the threshold source (see nvidia_query.GPUThresholdSource).
+func (o *Output) Rules(thresholdSource string) []components.Rule {
+	rules := make([]components.Rule, 0, len(o.UsagesNVML))
+	add := func(uuid string, cur uint32) {
+		r := components.Rule{
+			Name:            nvidia_query.GPUThresholdNameMaxTemperatureCelsius,
+			Subject:         uuid,
+			Observed:        fmt.Sprintf("%d C", cur),
+			ThresholdSource: thresholdSource,
+			Passed:          true,
+		}
+		if o.Thresholds.MaxTemperatureCelsius > 0 {
+			r.Threshold = fmt.Sprintf("%d C", o.Thresholds.MaxTemperatureCelsius)
+			r.Passed = cur < o.Thresholds.MaxTemperatureCelsius
+		}
+		rules = append(rules, r)
+	}
+	for _, u := range o.UsagesNVML {
+		add(u.UUID, u.CurrentCelsiusGPUCore)
+	}
+	if len(o.UsagesNVML) == 0 {
+		for _, u := range o.UsagesSMI {
+			if cur, err := u.GetCurrentCelsius(); err == nil {
+				add(u.ID, uint32(cur))
+			}
+		}
+	}
+	return rules
+}
+
+// ThresholdReadings returns the per-GPU temperatures against the configured threshold,
+// to track the threshold crossings.
+func (o *Output) ThresholdReadings() []nvidia_query_threshold_breach_state.Reading {
+	rs := make([]nvidia_query_threshold_breach_state.Reading, 0, len(o.UsagesNVML))
+	for _, u := range o.UsagesNVML {
+		rs = append(rs, nvidia_query_threshold_breach_state.Reading{
+			ThresholdName: nvidia_query.GPUThresholdNameMaxTemperatureCelsius,
+			GPUUUID:       u.UUID,
+			Value:         float64(u.CurrentCelsiusGPUCore),
+			Threshold:     float64(o.Thresholds.MaxTemperatureCelsius),
+		})
+	}
+	return rs
+}
+
 func (o *Output) States() ([]components.State, error) {
 	outputReasons, healthy, err := o.Evaluate()
 	if err != nil {
@@ -131,5 +230,24 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyTemperatureEncoding: StateValueTemperatureEncodingJSON,
 		},
 	}
-	return []components.State{state}, nil
+	return append([]components.State{state}, o.GPUStates()...), nil
+}
+
+// GPUStates returns the per-GPU states (e.g., "temperature_GPU-1234"),
+// unhealthy if the GPU is at or above the max temperature threshold.
+// Only the NVML temperatures are keyed by the GPU UUID,
+// so no per-GPU state is reported when NVML is not available.
+func (o *Output) GPUStates() []components.State {
+	gs := nvidia_query.NewGPUStates(StateNameTemperature)
+	for _, u := range o.UsagesNVML {
+		gs.Add(u.UUID)
+		if o.Thresholds.MaxTemperatureCelsius > 0 && u.CurrentCelsiusGPUCore >= o.Thresholds.MaxTemperatureCelsius {
+			gs.Unhealthy(u.UUID, fmt.Sprintf("%d C at or above the %d C threshold (preset %q)",
+				u.CurrentCelsiusGPUCore,
+				o.Thresholds.MaxTemperatureCelsius,
+				o.ThresholdPreset,
+			), nil)
+		}
+	}
+	return gs.States()
 }
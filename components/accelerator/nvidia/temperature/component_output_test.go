@@ -0,0 +1,83 @@
+package temperature
+
+import (
+	"testing"
+
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+)
+
+func TestOutputEvaluate(t *testing.T) {
+	tests := []struct {
+		name        string
+		o           Output
+		wantHealthy bool
+	}{
+		{
+			name: "nvml below threshold",
+			o: Output{
+				UsagesNVML: []nvidia_query_nvml.Temperature{{UUID: "GPU-0", CurrentCelsiusGPUCore: 60}},
+				Thresholds: nvidia_query.GPUThresholds{MaxTemperatureCelsius: 85},
+			},
+			wantHealthy: true,
+		},
+		{
+			name: "nvml above threshold",
+			o: Output{
+				UsagesNVML: []nvidia_query_nvml.Temperature{{UUID: "GPU-0", CurrentCelsiusGPUCore: 90}},
+				Thresholds: nvidia_query.GPUThresholds{MaxTemperatureCelsius: 85},
+			},
+			wantHealthy: false,
+		},
+		{
+			name: "nvidia-smi fallback above threshold",
+			o: Output{
+				UsagesSMI:  []nvidia_query.ParsedTemperature{{ID: "GPU 00000000:53:00.0", CurrentCelsius: "91.00", SlowdownCelsius: "87.00"}},
+				Thresholds: nvidia_query.GPUThresholds{MaxTemperatureCelsius: 85},
+			},
+			wantHealthy: false,
+		},
+		{
+			name: "nvidia-smi ignored with nvml",
+			o: Output{
+				UsagesSMI:  []nvidia_query.ParsedTemperature{{ID: "GPU 00000000:53:00.0", CurrentCelsius: "91.00"}},
+				UsagesNVML: []nvidia_query_nvml.Temperature{{UUID: "GPU-0", CurrentCelsiusGPUCore: 60}},
+				Thresholds: nvidia_query.GPUThresholds{MaxTemperatureCelsius: 85},
+			},
+			wantHealthy: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, healthy, err := tt.o.Evaluate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if healthy != tt.wantHealthy {
+				t.Errorf("healthy = %v, want %v", healthy, tt.wantHealthy)
+			}
+
+			passed := true
+			for _, r := range tt.o.Rules("preset \"h100-sxm\"") {
+				passed = passed && r.Passed
+			}
+			if passed != tt.wantHealthy {
+				t.Errorf("rules passed = %v, want %v", passed, tt.wantHealthy)
+			}
+
+			// the per-GPU states are only from NVML
+			gpuStates := tt.o.GPUStates()
+			if len(gpuStates) != len(tt.o.UsagesNVML) {
+				t.Fatalf("expected %d per-GPU states, got %d", len(tt.o.UsagesNVML), len(gpuStates))
+			}
+			for _, st := range gpuStates {
+				if st.Name != "temperature_GPU-0" || st.ExtraInfo[nvidia_query.StateKeyGPUUUID] != "GPU-0" {
+					t.Errorf("unexpected per-GPU state %+v", st)
+				}
+				if st.Healthy != tt.wantHealthy {
+					t.Errorf("per-GPU healthy = %v, want %v", st.Healthy, tt.wantHealthy)
+				}
+			}
+		})
+	}
+}
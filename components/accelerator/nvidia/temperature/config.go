@@ -4,11 +4,23 @@ import (
 	"database/sql"
 	"encoding/json"
 
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_cooling_trend "github.com/leptonai/gpud/components/accelerator/nvidia/query/cooling-trend"
 	query_config "github.com/leptonai/gpud/components/query/config"
 )
 
 type Config struct {
 	Query query_config.Config `json:"query"`
+
+	// Thresholds overrides the thresholds of the preset
+	// picked based on the detected GPU product name (e.g., per fleet).
+	// Leave empty to use the preset thresholds.
+	Thresholds *nvidia_query.GPUThresholds `json:"thresholds,omitempty"`
+
+	// CoolingTrend defines the temperature-at-power trend analysis
+	// for the cooling degradation advisories.
+	// Leave empty to use the default policy.
+	CoolingTrend *nvidia_query_cooling_trend.Policy `json:"cooling_trend,omitempty"`
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -28,5 +40,8 @@ func ParseConfig(b any, db *sql.DB) (*Config, error) {
 }
 
 func (cfg Config) Validate() error {
+	if cfg.CoolingTrend != nil {
+		return cfg.CoolingTrend.Validate()
+	}
 	return nil
 }
@@ -0,0 +1,193 @@
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_topology "github.com/leptonai/gpud/components/accelerator/nvidia/query/topology"
+	"github.com/leptonai/gpud/components/common"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+)
+
+type Output struct {
+	Topology *nvidia_query_topology.Topology `json:"topology"`
+	// BaselineCapturedAt is the time the baseline was captured,
+	// or zero if the baseline is not stored (e.g., no state database).
+	BaselineCapturedAt time.Time `json:"baseline_captured_at,omitempty"`
+	// Diffs are the missing GPUs, the missing or degraded NVLink connections,
+	// and the changed CPU/NUMA affinities since the baseline.
+	Diffs []string `json:"diffs,omitempty"`
+}
+
+// ToOutput compares the current topology against the baseline (nil if not captured).
+func ToOutput(current *nvidia_query_topology.Topology, baseline *nvidia_query_topology.Baseline) *Output {
+	o := &Output{Topology: current}
+	if baseline == nil {
+		return o
+	}
+	o.BaselineCapturedAt = baseline.CapturedAt
+	o.Diffs = nvidia_query_topology.Compare(baseline.Topology, current)
+	return o
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameTopology = "topology"
+
+	StateKeyTopologyData           = "data"
+	StateKeyTopologyEncoding       = "encoding"
+	StateValueTopologyEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameTopology, "GPU interconnect topology against the baseline"),
+	)
+}
+
+func ParseStateTopology(m map[string]string) (*Output, error) {
+	data := m[StateKeyTopologyData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameTopology:
+			o, err := ParseStateTopology(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool) {
+	if len(o.Diffs) > 0 {
+		return fmt.Sprintf("topology changed since the baseline captured at %s: %s", o.BaselineCapturedAt.Format(time.RFC3339), strings.Join(o.Diffs, ", ")), false
+	}
+	if o.BaselineCapturedAt.IsZero() {
+		return "no baseline to compare", true
+	}
+	return fmt.Sprintf("topology matches the baseline captured at %s", o.BaselineCapturedAt.Format(time.RFC3339)), true
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, healthy := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameTopology,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyTopologyData:     string(b),
+			StateKeyTopologyEncoding: StateValueTopologyEncodingJSON,
+		},
+	}
+	if !healthy {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"check the NVLink/NVSwitch connections and the fabric manager status (e.g., 'nvidia-smi nvlink -s')",
+				"inspect the GPU baseboard and the NVLink cables if the connections stay missing after a reboot",
+				"set reset_baseline if the topology changed intentionally (e.g., GPUs moved to different slots)",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeHardwareInspection,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the nvidia-smi
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg))
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+// CreateGet returns the get function that collects the topology matrix and compares it against the baseline,
+// capturing the baseline if not stored yet (or if reset_baseline is set, once per process).
+func CreateGet(cfg Config) query.GetFunc {
+	var resetOnce sync.Once
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		b, err := nvidia_query.RunSMI(ctx, "topo", "-m")
+		if err != nil {
+			return nil, err
+		}
+		current, err := nvidia_query_topology.Parse(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Query.State == nil || cfg.Query.State.DB == nil {
+			return ToOutput(current, nil), nil
+		}
+		db := cfg.Query.State.DB
+
+		if err := nvidia_query_topology.CreateTableBaseline(ctx, db); err != nil {
+			return nil, err
+		}
+		baseline, err := nvidia_query_topology.ReadBaseline(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+
+		reset := false
+		if cfg.ResetBaseline {
+			resetOnce.Do(func() { reset = true })
+		}
+		if baseline == nil || reset {
+			log.Logger.Infow("capturing topology baseline", "devices", len(current.Devices), "reset", reset)
+			baseline = &nvidia_query_topology.Baseline{Topology: current, CapturedAt: time.Now().UTC()}
+			if err := nvidia_query_topology.SetBaseline(ctx, db, *baseline); err != nil {
+				return nil, err
+			}
+		}
+		return ToOutput(current, baseline), nil
+	}
+}
@@ -0,0 +1,75 @@
+package topology
+
+import (
+	"testing"
+	"time"
+
+	nvidia_query_topology "github.com/leptonai/gpud/components/accelerator/nvidia/query/topology"
+)
+
+func TestToOutput(t *testing.T) {
+	baseline := &nvidia_query_topology.Topology{
+		Devices: []nvidia_query_topology.Device{
+			{Name: "GPU0", Links: map[string]string{"GPU1": "NV18"}, NUMAAffinity: "0"},
+			{Name: "GPU1", Links: map[string]string{"GPU0": "NV18"}, NUMAAffinity: "0"},
+		},
+	}
+	degraded := &nvidia_query_topology.Topology{
+		Devices: []nvidia_query_topology.Device{
+			{Name: "GPU0", Links: map[string]string{"GPU1": "SYS"}, NUMAAffinity: "0"},
+			{Name: "GPU1", Links: map[string]string{"GPU0": "SYS"}, NUMAAffinity: "0"},
+		},
+	}
+	capturedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		current         *nvidia_query_topology.Topology
+		baseline        *nvidia_query_topology.Baseline
+		expectedDiffs   int
+		expectedHealthy bool
+	}{
+		{
+			name:            "no baseline",
+			current:         degraded,
+			expectedHealthy: true,
+		},
+		{
+			name:            "same as baseline",
+			current:         baseline,
+			baseline:        &nvidia_query_topology.Baseline{Topology: baseline, CapturedAt: capturedAt},
+			expectedHealthy: true,
+		},
+		{
+			name:            "nvlink missing",
+			current:         degraded,
+			baseline:        &nvidia_query_topology.Baseline{Topology: baseline, CapturedAt: capturedAt},
+			expectedDiffs:   1,
+			expectedHealthy: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := ToOutput(tt.current, tt.baseline)
+			if len(o.Diffs) != tt.expectedDiffs {
+				t.Errorf("expected %d diffs, got %v", tt.expectedDiffs, o.Diffs)
+			}
+			reason, healthy := o.Evaluate()
+			if healthy != tt.expectedHealthy {
+				t.Errorf("expected healthy %v, got %v (%s)", tt.expectedHealthy, healthy, reason)
+			}
+
+			states, err := o.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := ParseStatesToOutput(states...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed.Diffs) != len(o.Diffs) || !parsed.BaselineCapturedAt.Equal(o.BaselineCapturedAt) {
+				t.Errorf("unexpected parsed output %+v", parsed)
+			}
+		})
+	}
+}
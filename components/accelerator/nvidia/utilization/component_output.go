@@ -54,6 +54,12 @@ const (
 	StateValueUtilizationEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameUtilization, "per-GPU utilization"),
+	)
+}
+
 func ParseStateUtilization(m map[string]string) (*Output, error) {
 	data := m[StateKeyUtilizationData]
 	return ParseOutputJSON([]byte(data))
@@ -0,0 +1,201 @@
+// Package capability probes the host capabilities (privileges, files, binaries, and libraries)
+// required by the enabled components at startup, and reports the components that cannot possibly work.
+package capability
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	pkg_file "github.com/leptonai/gpud/pkg/file"
+)
+
+// Requirement is what a component requires on the host to possibly work.
+type Requirement struct {
+	// Root is true if the component requires the root privileges.
+	Root bool `json:"root,omitempty"`
+	// Binaries are the executables that must be found in the PATH.
+	Binaries []string `json:"binaries,omitempty"`
+	// Files are the files (or directories) that must exist.
+	Files []string `json:"files,omitempty"`
+	// Libraries are the shared libraries that must be found in the library search directories.
+	Libraries []string `json:"libraries,omitempty"`
+	// Components are the other components that must be enabled and capable.
+	Components []string `json:"components,omitempty"`
+}
+
+// ComponentReport is the capability check result of a component.
+type ComponentReport struct {
+	Component string `json:"component"`
+	// Capable is false if any requirement is not met,
+	// in which case the component is not started.
+	Capable bool `json:"capable"`
+	// Missing lists the requirements not met.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Report is the one-shot capability report of the enabled components at startup.
+type Report struct {
+	Components []ComponentReport `json:"components"`
+}
+
+// Op is the host probe functions, overridable for testing.
+type Op struct {
+	isRoot      func() bool
+	lookPath    func(string) (string, error)
+	stat        func(string) error
+	findLibrary func(string) error
+}
+
+type OpOption func(*Op)
+
+func (op *Op) applyOpts(opts []OpOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+	if op.isRoot == nil {
+		op.isRoot = func() bool { return os.Geteuid() == 0 }
+	}
+	if op.lookPath == nil {
+		op.lookPath = exec.LookPath
+	}
+	if op.stat == nil {
+		op.stat = func(p string) error {
+			_, err := os.Stat(p)
+			return err
+		}
+	}
+	if op.findLibrary == nil {
+		op.findLibrary = func(name string) error {
+			return findLibrary(name)
+		}
+	}
+}
+
+// WithLibrarySearchDirs sets the directories to search the required libraries.
+func WithLibrarySearchDirs(dirs ...string) OpOption {
+	return func(op *Op) {
+		op.findLibrary = func(name string) error {
+			return findLibrary(name, pkg_file.WithSearchDirs(dirs...))
+		}
+	}
+}
+
+func findLibrary(name string, opts ...pkg_file.OpOption) error {
+	p, err := pkg_file.FindLibrary(name, opts...)
+	if err != nil {
+		return err
+	}
+	if p == "" {
+		return pkg_file.ErrLibraryNotFound
+	}
+	return nil
+}
+
+// Check probes the requirements of the enabled components,
+// where the components without any requirement are always capable.
+// A component is not capable if any of its required components is not enabled or not capable.
+func Check(enabled []string, requirements map[string]Requirement, opts ...OpOption) Report {
+	op := &Op{}
+	op.applyOpts(opts)
+
+	names := append([]string(nil), enabled...)
+	sort.Strings(names)
+
+	missing := make(map[string][]string, len(names))
+	for _, name := range names {
+		missing[name] = op.probe(requirements[name])
+	}
+
+	// propagate the incapable components to the dependents,
+	// until no more component becomes incapable
+	for changed := true; changed; {
+		changed = false
+		for _, name := range names {
+			if len(missing[name]) > 0 {
+				continue
+			}
+			for _, dep := range requirements[name].Components {
+				depMissing, ok := missing[dep]
+				switch {
+				case !ok:
+					missing[name] = append(missing[name], fmt.Sprintf("component %q (not enabled)", dep))
+				case len(depMissing) > 0:
+					missing[name] = append(missing[name], fmt.Sprintf("component %q (not capable)", dep))
+				default:
+					continue
+				}
+				changed = true
+			}
+		}
+	}
+
+	r := Report{Components: make([]ComponentReport, 0, len(names))}
+	for _, name := range names {
+		r.Components = append(r.Components, ComponentReport{
+			Component: name,
+			Capable:   len(missing[name]) == 0,
+			Missing:   missing[name],
+		})
+	}
+	return r
+}
+
+func (op *Op) probe(req Requirement) []string {
+	var missing []string
+	if req.Root && !op.isRoot() {
+		missing = append(missing, "root privileges")
+	}
+	for _, bin := range req.Binaries {
+		if _, err := op.lookPath(bin); err != nil {
+			missing = append(missing, fmt.Sprintf("binary %q", bin))
+		}
+	}
+	for _, f := range req.Files {
+		if err := op.stat(f); err != nil {
+			missing = append(missing, fmt.Sprintf("file %q", f))
+		}
+	}
+	for _, lib := range req.Libraries {
+		if err := op.findLibrary(lib); err != nil {
+			if !errors.Is(err, pkg_file.ErrLibraryNotFound) {
+				missing = append(missing, fmt.Sprintf("library %q (%v)", lib, err))
+				continue
+			}
+			missing = append(missing, fmt.Sprintf("library %q", lib))
+		}
+	}
+	return missing
+}
+
+// Capable returns true if the component is capable or not checked.
+func (r Report) Capable(component string) bool {
+	for _, c := range r.Components {
+		if c.Component == component {
+			return c.Capable
+		}
+	}
+	return true
+}
+
+// Incapable returns the reports of the components that are not capable.
+func (r Report) Incapable() []ComponentReport {
+	var rs []ComponentReport
+	for _, c := range r.Components {
+		if !c.Capable {
+			rs = append(rs, c)
+		}
+	}
+	return rs
+}
+
+// String returns the human-readable summary of the incapable components.
+func (c ComponentReport) String() string {
+	if c.Capable {
+		return c.Component + ": capable"
+	}
+	return fmt.Sprintf("%s: missing %s", c.Component, strings.Join(c.Missing, ", "))
+}
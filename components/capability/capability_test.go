@@ -0,0 +1,87 @@
+package capability
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	existing := map[string]bool{
+		"dmesg":                 true,
+		"/proc/modules":         true,
+		"libnvidia-ml.so.1":     true,
+		"/sys/class/infiniband": false,
+	}
+	exists := func(name string) error {
+		if existing[name] {
+			return nil
+		}
+		return errors.New("not found")
+	}
+	withProbes := func(root bool) OpOption {
+		return func(op *Op) {
+			op.isRoot = func() bool { return root }
+			op.lookPath = func(name string) (string, error) { return name, exists(name) }
+			op.stat = exists
+			op.findLibrary = exists
+		}
+	}
+
+	reqs := map[string]Requirement{
+		"dmesg":         {Root: true, Binaries: []string{"dmesg"}},
+		"kernel-module": {Files: []string{"/proc/modules"}},
+		"nvidia-ecc":    {Libraries: []string{"libnvidia-ml.so.1"}},
+		"network-roce":  {Files: []string{"/sys/class/infiniband"}},
+		"nvidia-xid":    {Components: []string{"dmesg"}},
+		"nvidia-sxid":   {Components: []string{"dmesg-missing"}},
+	}
+	enabled := []string{"cpu", "dmesg", "kernel-module", "nvidia-ecc", "network-roce", "nvidia-xid", "nvidia-sxid"}
+
+	tests := []struct {
+		name          string
+		root          bool
+		wantIncapable map[string][]string
+	}{
+		{
+			name: "root",
+			root: true,
+			wantIncapable: map[string][]string{
+				"network-roce": {`file "/sys/class/infiniband"`},
+				"nvidia-sxid":  {`component "dmesg-missing" (not enabled)`},
+			},
+		},
+		{
+			name: "non-root propagates to the dependents",
+			root: false,
+			wantIncapable: map[string][]string{
+				"dmesg":        {"root privileges"},
+				"network-roce": {`file "/sys/class/infiniband"`},
+				"nvidia-xid":   {`component "dmesg" (not capable)`},
+				"nvidia-sxid":  {`component "dmesg-missing" (not enabled)`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Check(enabled, reqs, withProbes(tt.root))
+			if len(r.Components) != len(enabled) {
+				t.Fatalf("expected %d components, got %d", len(enabled), len(r.Components))
+			}
+
+			got := make(map[string][]string)
+			for _, c := range r.Incapable() {
+				got[c.Component] = c.Missing
+			}
+			if !reflect.DeepEqual(got, tt.wantIncapable) {
+				t.Errorf("incapable = %v, want %v", got, tt.wantIncapable)
+			}
+
+			if !r.Capable("cpu") || !r.Capable("not-enabled") {
+				t.Errorf("expected the components without requirements to be capable")
+			}
+		})
+	}
+}
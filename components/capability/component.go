@@ -0,0 +1,96 @@
+package capability
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/common"
+	"github.com/leptonai/gpud/log"
+)
+
+const Name = "capability"
+
+const (
+	StateNameCapabilityReport = "capability_report"
+
+	StateKeyCapable   = "capable"
+	StateKeyIncapable = "incapable"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateNameCapabilityReport,
+			Description: "capabilities of the enabled components checked at startup",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyCapable, Type: components.ExtraInfoTypeString, Description: "comma-separated names of the capable components"},
+				{Key: StateKeyIncapable, Type: components.ExtraInfoTypeString, Description: "semicolon-separated incapable components with the missing requirements"},
+			},
+		},
+	)
+}
+
+// New creates the component that reports the one-shot capability report at startup.
+func New(report Report) components.Component {
+	return &component{report: report}
+}
+
+var _ components.Component = (*component)(nil)
+
+type component struct {
+	report Report
+}
+
+func (c *component) Name() string { return Name }
+
+// States returns the unhealthy state if any enabled component is not started
+// due to the missing capabilities, so it does not silently go unmonitored.
+func (c *component) States(ctx context.Context) ([]components.State, error) {
+	var capable, incapable []string
+	for _, r := range c.report.Components {
+		if r.Capable {
+			capable = append(capable, r.Component)
+		} else {
+			incapable = append(incapable, r.String())
+		}
+	}
+
+	state := components.State{
+		Name:    StateNameCapabilityReport,
+		Healthy: len(incapable) == 0,
+		Reason:  "all enabled components are capable",
+		ExtraInfo: map[string]string{
+			StateKeyCapable: strings.Join(capable, ","),
+		},
+	}
+	if len(incapable) > 0 {
+		state.Reason = "component(s) not started due to the missing capabilities"
+		state.Error = strings.Join(incapable, "; ")
+		state.ExtraInfo[StateKeyIncapable] = strings.Join(incapable, "; ")
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"install the missing binaries/libraries or run gpud as root, then restart gpud",
+				"or disable the incapable components",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeIgnoreNoActionRequired,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
+
+func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	return nil, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	return nil, nil
+}
+
+func (c *component) Close() error {
+	log.Logger.Debugw("closing component", "component", Name)
+	return nil
+}
@@ -20,6 +20,35 @@ const (
 	// For instance, NVIDIA may report XID 45 as user app error, but the underlying GPU might have other issues
 	// thus requires further diagnosis of the application and the GPU.
 	RepairActionTypeCheckUserAppAndGPU RepairActionType = "CHECK_USER_APP_AND_GPU"
+
+	// RepairActionTypeRestartService represents a suggested action to restart the system service
+	// (e.g., "nvidia-fabricmanager"), without rebooting the system.
+	RepairActionTypeRestartService RepairActionType = "RESTART_SERVICE"
+
+	// RepairActionTypeResetGPU represents a suggested action to reset the GPU
+	// (e.g., "nvidia-smi --gpu-reset"), without rebooting the system.
+	// Requires no process to hold the GPU.
+	RepairActionTypeResetGPU RepairActionType = "RESET_GPU"
+
+	// RepairActionTypeEnablePersistenceMode represents a suggested action to enable the persistence mode
+	// (e.g., start "nvidia-persistenced" or "nvidia-smi -pm 1"), without rebooting the system.
+	// The disabled persistence mode slows down the CUDA initialization and makes the NVML queries flaky.
+	RepairActionTypeEnablePersistenceMode RepairActionType = "ENABLE_PERSISTENCE_MODE"
+
+	// RepairActionTypeDisableACS represents a suggested action to disable the PCIe ACS
+	// (Access Control Services) redirects on the PCIe switch ports (e.g., "setpci -s <bdf> ECAP_ACS+0x6.w=0000"),
+	// without rebooting the system. The enabled ACS forces the GPU peer-to-peer and GPUDirect RDMA traffic
+	// through the root complex.
+	RepairActionTypeDisableACS RepairActionType = "DISABLE_ACS"
+
+	// RepairActionTypeReloadDriver represents a suggested action to reload the NVIDIA driver kernel modules
+	// (e.g., "modprobe -r nvidia_uvm nvidia && modprobe nvidia nvidia_uvm"), without rebooting the system.
+	// Requires no process to hold the GPUs. Reboot the system if the reload fails.
+	RepairActionTypeReloadDriver RepairActionType = "RELOAD_DRIVER"
+
+	// RepairActionTypeInstallDriver represents a suggested action to install the NVIDIA driver,
+	// when the GPUs are expected on the node but the driver was never seen working.
+	RepairActionTypeInstallDriver RepairActionType = "INSTALL_DRIVER"
 )
 
 // SuggestedActions represents a set of suggested actions to mitigate an issue.
@@ -79,6 +108,81 @@ func (s *SuggestedActions) RequiresCheckUserAppAndGPU() bool {
 	return false
 }
 
+func (s *SuggestedActions) RequiresGPUReset() bool {
+	if s == nil {
+		return false
+	}
+	if len(s.RepairActions) == 0 {
+		return false
+	}
+	for _, action := range s.RepairActions {
+		if action == RepairActionTypeResetGPU {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SuggestedActions) RequiresPersistenceModeEnable() bool {
+	if s == nil {
+		return false
+	}
+	if len(s.RepairActions) == 0 {
+		return false
+	}
+	for _, action := range s.RepairActions {
+		if action == RepairActionTypeEnablePersistenceMode {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SuggestedActions) RequiresACSDisable() bool {
+	if s == nil {
+		return false
+	}
+	if len(s.RepairActions) == 0 {
+		return false
+	}
+	for _, action := range s.RepairActions {
+		if action == RepairActionTypeDisableACS {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SuggestedActions) RequiresDriverReload() bool {
+	if s == nil {
+		return false
+	}
+	if len(s.RepairActions) == 0 {
+		return false
+	}
+	for _, action := range s.RepairActions {
+		if action == RepairActionTypeReloadDriver {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SuggestedActions) RequiresDriverInstall() bool {
+	if s == nil {
+		return false
+	}
+	if len(s.RepairActions) == 0 {
+		return false
+	}
+	for _, action := range s.RepairActions {
+		if action == RepairActionTypeInstallDriver {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *SuggestedActions) Add(other *SuggestedActions) {
 	if other == nil {
 		return
@@ -52,3 +52,178 @@ func TestSuggestedActions_RequiresReboot(t *testing.T) {
 		})
 	}
 }
+
+func TestSuggestedActions_RequiresGPUReset(t *testing.T) {
+	tests := []struct {
+		name string
+		sa   *SuggestedActions
+		want bool
+	}{
+		{
+			name: "nil",
+			sa:   nil,
+			want: false,
+		},
+		{
+			name: "requires gpu reset",
+			sa: &SuggestedActions{
+				RepairActions: []RepairActionType{RepairActionTypeResetGPU},
+			},
+			want: true,
+		},
+		{
+			name: "requires reboot only",
+			sa: &SuggestedActions{
+				RepairActions: []RepairActionType{RepairActionTypeRebootSystem},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sa.RequiresGPUReset(); got != tt.want {
+				t.Errorf("SuggestedActions.RequiresGPUReset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestedActions_RequiresPersistenceModeEnable(t *testing.T) {
+	tests := []struct {
+		name string
+		sa   *SuggestedActions
+		want bool
+	}{
+		{
+			name: "nil",
+			sa:   nil,
+			want: false,
+		},
+		{
+			name: "requires persistence mode enable",
+			sa: &SuggestedActions{
+				RepairActions: []RepairActionType{RepairActionTypeEnablePersistenceMode},
+			},
+			want: true,
+		},
+		{
+			name: "requires service restart only",
+			sa: &SuggestedActions{
+				RepairActions: []RepairActionType{RepairActionTypeRestartService},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sa.RequiresPersistenceModeEnable(); got != tt.want {
+				t.Errorf("SuggestedActions.RequiresPersistenceModeEnable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestedActions_RequiresACSDisable(t *testing.T) {
+	tests := []struct {
+		name string
+		sa   *SuggestedActions
+		want bool
+	}{
+		{
+			name: "nil",
+			sa:   nil,
+			want: false,
+		},
+		{
+			name: "requires acs disable",
+			sa: &SuggestedActions{
+				RepairActions: []RepairActionType{RepairActionTypeDisableACS},
+			},
+			want: true,
+		},
+		{
+			name: "requires reboot only",
+			sa: &SuggestedActions{
+				RepairActions: []RepairActionType{RepairActionTypeRebootSystem},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sa.RequiresACSDisable(); got != tt.want {
+				t.Errorf("SuggestedActions.RequiresACSDisable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestedActions_RequiresDriverReload(t *testing.T) {
+	tests := []struct {
+		name string
+		sa   *SuggestedActions
+		want bool
+	}{
+		{
+			name: "nil",
+			sa:   nil,
+			want: false,
+		},
+		{
+			name: "requires driver reload",
+			sa: &SuggestedActions{
+				RepairActions: []RepairActionType{RepairActionTypeReloadDriver},
+			},
+			want: true,
+		},
+		{
+			name: "requires gpu reset only",
+			sa: &SuggestedActions{
+				RepairActions: []RepairActionType{RepairActionTypeResetGPU},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sa.RequiresDriverReload(); got != tt.want {
+				t.Errorf("SuggestedActions.RequiresDriverReload() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestedActions_RequiresDriverInstall(t *testing.T) {
+	tests := []struct {
+		name string
+		sa   *SuggestedActions
+		want bool
+	}{
+		{
+			name: "nil",
+			sa:   nil,
+			want: false,
+		},
+		{
+			name: "requires driver install",
+			sa: &SuggestedActions{
+				RepairActions: []RepairActionType{RepairActionTypeInstallDriver},
+			},
+			want: true,
+		},
+		{
+			name: "requires driver reload only",
+			sa: &SuggestedActions{
+				RepairActions: []RepairActionType{RepairActionTypeReloadDriver},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sa.RequiresDriverInstall(); got != tt.want {
+				t.Errorf("SuggestedActions.RequiresDriverInstall() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -66,14 +67,43 @@ type PromRegisterer interface {
 	RegisterCollectors(reg *prometheus.Registry, db *sql.DB, tableName string) error
 }
 
+// Explainer is an optional component interface that explains its health verdict,
+// with the rules evaluated against the observed values (e.g., for the operators tuning the thresholds).
+type Explainer interface {
+	// Explain returns the rules evaluated on the last collected data.
+	Explain(ctx context.Context) ([]Rule, error)
+}
+
+// Rule is a health rule evaluated by the component.
+type Rule struct {
+	// Name is the rule name (e.g., "max_temperature_celsius").
+	Name string `json:"name"`
+	// Subject is what the rule is evaluated on (e.g., the GPU UUID).
+	// Empty if the rule is evaluated on the whole component.
+	Subject string `json:"subject,omitempty"`
+	// Observed is the observed value (e.g., "72 C").
+	Observed string `json:"observed"`
+	// Threshold is the threshold applied (e.g., "85 C").
+	// Empty if the rule is disabled.
+	Threshold string `json:"threshold,omitempty"`
+	// ThresholdSource explains why the threshold applies
+	// (e.g., the preset of the GPU product, or the config override).
+	ThresholdSource string `json:"threshold_source,omitempty"`
+	// Passed is true if the observed value is within the threshold (or the rule is disabled).
+	Passed bool `json:"passed"`
+}
+
 type State struct {
 	Name      string            `json:"name,omitempty"`
 	Healthy   bool              `json:"healthy,omitempty"`
+	Degraded  bool              `json:"degraded,omitempty"`   // set true if impaired but still working, or acknowledged as a known issue (reported as healthy)
 	Reason    string            `json:"reason,omitempty"`     // a detailed and processed reason on why the component is not healthy
 	Error     string            `json:"error,omitempty"`      // the unprocessed error returned from the component
 	ExtraInfo map[string]string `json:"extra_info,omitempty"` // any extra information the component may want to expose
 
 	SuggestedActions *common.SuggestedActions `json:"suggested_actions,omitempty"`
+
+	Annotations map[string]string `json:"annotations,omitempty"` // operator GPU annotations (e.g., suspect GPU) set via the API
 }
 
 type Event struct {
@@ -84,6 +114,30 @@ type Event struct {
 	ExtraInfo map[string]string `json:"extra_info,omitempty"` // any extra information the component may want to expose
 
 	SuggestedActions *common.SuggestedActions `json:"suggested_actions,omitempty"`
+
+	Annotations map[string]string `json:"annotations,omitempty"` // operator annotations (e.g., ticket ID) set via the API
+}
+
+// SortStates sorts the states by name, preserving the order of the states with the same name,
+// so that the states output is deterministic across runs.
+func SortStates(states []State) {
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+}
+
+// SortEvents sorts the events by time, then by name and message,
+// preserving the order of the otherwise equal events.
+func SortEvents(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Time.Equal(&events[j].Time) {
+			return events[i].Time.Before(&events[j].Time)
+		}
+		if events[i].Name != events[j].Name {
+			return events[i].Name < events[j].Name
+		}
+		return events[i].Message < events[j].Message
+	})
 }
 
 const (
@@ -95,6 +149,10 @@ const (
 
 type Metric struct {
 	components_metrics_state.Metric
+	// Unit is the SI unit of the metric value (e.g., "bytes", "celsius", "watts"),
+	// empty if the metric has no unit (e.g., the counts).
+	// See "pkg/units" for the conversion helpers.
+	Unit      string            `json:"unit,omitempty"`
 	ExtraInfo map[string]string `json:"extra_info,omitempty"` // any extra information the component may want to expose
 }
 
@@ -2,9 +2,13 @@ package components
 
 import (
 	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/leptonai/gpud/errdefs"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGetComponentErrors(t *testing.T) {
@@ -15,3 +19,45 @@ func TestGetComponentErrors(t *testing.T) {
 		t.Errorf("expected ErrNotFound, got %v", err)
 	}
 }
+
+func TestSortStates(t *testing.T) {
+	states := []State{
+		{Name: "xid", Reason: "first"},
+		{Name: "gpu_states"},
+		{Name: "xid", Reason: "second"},
+		{Name: "error"},
+	}
+	SortStates(states)
+
+	want := []State{
+		{Name: "error"},
+		{Name: "gpu_states"},
+		{Name: "xid", Reason: "first"},
+		{Name: "xid", Reason: "second"},
+	}
+	if !reflect.DeepEqual(states, want) {
+		t.Errorf("SortStates() = %+v, want %+v", states, want)
+	}
+}
+
+func TestSortEvents(t *testing.T) {
+	t0 := metav1.NewTime(time.Unix(1700000000, 0))
+	t1 := metav1.NewTime(t0.Add(time.Minute))
+	events := []Event{
+		{Time: t1, Name: "b"},
+		{Time: t0, Name: "b", Message: "2"},
+		{Time: t0, Name: "a"},
+		{Time: t0, Name: "b", Message: "1"},
+	}
+	SortEvents(events)
+
+	want := []Event{
+		{Time: t0, Name: "a"},
+		{Time: t0, Name: "b", Message: "1"},
+		{Time: t0, Name: "b", Message: "2"},
+		{Time: t1, Name: "b"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("SortEvents() = %+v, want %+v", events, want)
+	}
+}
@@ -43,6 +43,12 @@ const (
 	StateValuePodSandboxEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNamePodSandbox, "containerd pod sandboxes"),
+	)
+}
+
 func ParseStatePodSandbox(m map[string]string) (PodSandbox, error) {
 	pod := PodSandbox{}
 	pod.ID = m[StateKeyPodSandboxID]
@@ -19,6 +19,8 @@ import (
 	"github.com/leptonai/gpud/components/cpu/metrics"
 	components_metrics "github.com/leptonai/gpud/components/metrics"
 	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+	pkg_host "github.com/leptonai/gpud/pkg/host"
 
 	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/host"
@@ -66,6 +68,11 @@ type Usage struct {
 	LoadAvg5Min string `json:"load_avg_5min"`
 	// Load average for the last 15-minutes, with the scale of 1.00.
 	LoadAvg15Min string `json:"load_avg_15min"`
+
+	// Steal time in percentage, the time that the virtual CPU waited
+	// for the hypervisor to service another virtual processor.
+	// Only collected when the host is a VM guest (e.g., KVM, Xen).
+	StealPercent string `json:"steal_percent,omitempty"`
 }
 
 func (u Usage) GetUsedPercent() (float64, error) {
@@ -84,6 +91,10 @@ func (u Usage) GetLoadAvg15Min() (float64, error) {
 	return strconv.ParseFloat(u.LoadAvg15Min, 64)
 }
 
+func (u Usage) GetStealPercent() (float64, error) {
+	return strconv.ParseFloat(u.StealPercent, 64)
+}
+
 const (
 	StateNameInfo         = "info"
 	StateKeyInfoCPU       = "cpu"
@@ -101,8 +112,44 @@ const (
 	StateKeyUsageLoadAvg1Min  = "load_avg_1min"
 	StateKeyUsageLoadAvg5Min  = "load_avg_5min"
 	StateKeyUsageLoadAvg15Min = "load_avg_15min"
+	StateKeyUsageStealPercent = "steal_percent"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateNameInfo,
+			Description: "CPU information",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyInfoArch, Type: components.ExtraInfoTypeString, Description: "CPU architecture (e.g., \"x86_64\")"},
+				{Key: StateKeyInfoCPU, Type: components.ExtraInfoTypeString, Description: "CPU index"},
+				{Key: StateKeyInfoFamily, Type: components.ExtraInfoTypeString, Description: "CPU family"},
+				{Key: StateKeyInfoModel, Type: components.ExtraInfoTypeString, Description: "CPU model"},
+				{Key: StateKeyInfoModelName, Type: components.ExtraInfoTypeString, Description: "CPU model name"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateNameCores,
+			Description: "CPU core counts",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyCoresPhysical, Type: components.ExtraInfoTypeInt, Unit: "cores", Description: "number of the physical cores"},
+				{Key: StateKeyCoresLogical, Type: components.ExtraInfoTypeInt, Unit: "cores", Description: "number of the logical cores"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateNameUsage,
+			Description: "CPU usage and load averages",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyUsageUsedPercent, Type: components.ExtraInfoTypeFloat, Unit: "percent", Description: "CPU usage"},
+				{Key: StateKeyUsageLoadAvg1Min, Type: components.ExtraInfoTypeFloat, Description: "1-minute load average"},
+				{Key: StateKeyUsageLoadAvg5Min, Type: components.ExtraInfoTypeFloat, Description: "5-minute load average"},
+				{Key: StateKeyUsageLoadAvg15Min, Type: components.ExtraInfoTypeFloat, Description: "15-minute load average"},
+				{Key: StateKeyUsageStealPercent, Type: components.ExtraInfoTypeFloat, Unit: "percent", Description: "CPU steal time of the virtual machines"},
+			},
+		},
+	)
+}
+
 func ParseStateInfo(m map[string]string) (Info, error) {
 	i := Info{}
 	i.Arch = m[StateKeyInfoArch]
@@ -135,6 +182,7 @@ func ParseStateUsage(m map[string]string) (Usage, error) {
 	u.LoadAvg1Min = m[StateKeyUsageLoadAvg1Min]
 	u.LoadAvg5Min = m[StateKeyUsageLoadAvg5Min]
 	u.LoadAvg15Min = m[StateKeyUsageLoadAvg15Min]
+	u.StealPercent = m[StateKeyUsageStealPercent]
 	return u, nil
 }
 
@@ -171,6 +219,18 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 }
 
 func (o *Output) States() ([]components.State, error) {
+	usageReason := fmt.Sprintf("used_percent: %s, load_avg_1min: %s, load_avg_5min: %s, load_avg_15min: %s", o.Usage.UsedPercent, o.Usage.LoadAvg1Min, o.Usage.LoadAvg5Min, o.Usage.LoadAvg15Min)
+	usageExtraInfo := map[string]string{
+		StateKeyUsageUsedPercent:  o.Usage.UsedPercent,
+		StateKeyUsageLoadAvg1Min:  o.Usage.LoadAvg1Min,
+		StateKeyUsageLoadAvg5Min:  o.Usage.LoadAvg5Min,
+		StateKeyUsageLoadAvg15Min: o.Usage.LoadAvg15Min,
+	}
+	if o.Usage.StealPercent != "" {
+		usageReason += fmt.Sprintf(", steal_percent: %s", o.Usage.StealPercent)
+		usageExtraInfo[StateKeyUsageStealPercent] = o.Usage.StealPercent
+	}
+
 	return []components.State{
 		{
 			Name:    StateNameInfo,
@@ -195,15 +255,10 @@ func (o *Output) States() ([]components.State, error) {
 			},
 		},
 		{
-			Name:    StateNameUsage,
-			Healthy: true,
-			Reason:  fmt.Sprintf("used_percent: %s, load_avg_1min: %s, load_avg_5min: %s, load_avg_15min: %s", o.Usage.UsedPercent, o.Usage.LoadAvg1Min, o.Usage.LoadAvg5Min, o.Usage.LoadAvg15Min),
-			ExtraInfo: map[string]string{
-				StateKeyUsageUsedPercent:  o.Usage.UsedPercent,
-				StateKeyUsageLoadAvg1Min:  o.Usage.LoadAvg1Min,
-				StateKeyUsageLoadAvg5Min:  o.Usage.LoadAvg5Min,
-				StateKeyUsageLoadAvg15Min: o.Usage.LoadAvg15Min,
-			},
+			Name:      StateNameUsage,
+			Healthy:   true,
+			Reason:    usageReason,
+			ExtraInfo: usageExtraInfo,
 		},
 	}, nil
 }
@@ -328,6 +383,18 @@ func Get(ctx context.Context) (_ any, e error) {
 		o.Usage = Usage{
 			UsedPercent: fmt.Sprintf("%.2f", pct),
 		}
+
+		// steal time is only meaningful when running as a VM guest
+		// (e.g., KVM, Xen, Hyper-V), where the hypervisor may preempt vCPUs
+		// (the detection failure only skips the steal time check, not the usage)
+		virtEnv, err := pkg_host.GetVirtualizationEnvironment(ctx)
+		if err != nil {
+			log.Logger.Warnw("failed to get virtualization environment -- skipping steal time check", "error", err)
+		} else if virtEnv.IsVMGuest() {
+			stealPct := calculateSteal(*prev, cur)
+			metrics.SetStealPercent(stealPct)
+			o.Usage.StealPercent = fmt.Sprintf("%.2f", stealPct)
+		}
 	}
 	setPrevTimeStat(cur)
 
@@ -370,6 +437,18 @@ func calculateBusy(t1, t2 cpu.TimesStat) float64 {
 	return math.Min(100, math.Max(0, (t2Busy-t1Busy)/(t2All-t1All)*100))
 }
 
+// calculateSteal returns the percentage of the time stolen by the hypervisor
+// between the two time stats.
+func calculateSteal(t1, t2 cpu.TimesStat) float64 {
+	t1All, _ := getAllBusy(t1)
+	t2All, _ := getAllBusy(t2)
+
+	if t2.Steal <= t1.Steal || t2All <= t1All {
+		return 0
+	}
+	return math.Min(100, math.Max(0, (t2.Steal-t1.Steal)/(t2All-t1All)*100))
+}
+
 // copied from https://pkg.go.dev/github.com/shirou/gopsutil/v4/cpu#PercentWithContext
 func getAllBusy(t cpu.TimesStat) (float64, float64) {
 	//nolint:staticcheck // Allowing use of deprecated fields
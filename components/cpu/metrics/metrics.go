@@ -57,6 +57,16 @@ var (
 		},
 		[]string{"last_period"},
 	)
+
+	// only set when the host is a VM guest
+	stealPercent = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "steal_percent",
+			Help:      "tracks the percentage of the CPU time stolen by the hypervisor",
+		},
+	)
 )
 
 func InitAveragers(db *sql.DB, tableName string) {
@@ -120,6 +130,10 @@ func SetUsedPercent(ctx context.Context, pct float64, currentTime time.Time) err
 	return nil
 }
 
+func SetStealPercent(pct float64) {
+	stealPercent.Set(pct)
+}
+
 func Register(reg *prometheus.Registry, db *sql.DB, tableName string) error {
 	InitAveragers(db, tableName)
 
@@ -135,5 +149,8 @@ func Register(reg *prometheus.Registry, db *sql.DB, tableName string) error {
 	if err := reg.Register(usedPercentAverage); err != nil {
 		return err
 	}
+	if err := reg.Register(stealPercent); err != nil {
+		return err
+	}
 	return nil
 }
@@ -170,7 +170,17 @@ func run(ctx context.Context, dir string, opts ...OpOption) error {
 		return err
 	}
 
-	if commandExists("ipmitool") {
+	// IPMI (BMC) is not exposed to the VM guests, thus skip the check
+	virtEnv, err := host.GetVirtualizationEnvironment(ctx)
+	if err != nil {
+		o.Results = append(o.Results, CommandResult{
+			Command: "virtualization detection",
+			Error:   err.Error(),
+		})
+	}
+	if virtEnv.IsVMGuest() {
+		o.CheckSummary = append(o.CheckSummary, fmt.Sprintf("skipped ipmitool check in VM guest (%s)", virtEnv.Type))
+	} else if commandExists("ipmitool") {
 		if err := o.runCommand(ctx, "ipmitool", "ipmitool", "fru", "list"); err != nil {
 			return err
 		}
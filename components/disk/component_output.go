@@ -13,8 +13,8 @@ import (
 	"github.com/leptonai/gpud/components/disk/metrics"
 	components_metrics "github.com/leptonai/gpud/components/metrics"
 	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/pkg/locale"
 
-	"github.com/dustin/go-humanize"
 	"github.com/shirou/gopsutil/v4/disk"
 )
 
@@ -78,11 +78,11 @@ func getUsage(path string) (Usage, error) {
 		MountPoint:             usage.Path,
 		Fstype:                 usage.Fstype,
 		TotalBytes:             usage.Total,
-		TotalHumanized:         humanize.Bytes(usage.Total),
+		TotalHumanized:         locale.Bytes(usage.Total),
 		FreeBytes:              usage.Free,
-		FreeHumanized:          humanize.Bytes(usage.Free),
+		FreeHumanized:          locale.Bytes(usage.Free),
 		UsedBytes:              usage.Used,
-		UsedHumanized:          humanize.Bytes(usage.Used),
+		UsedHumanized:          locale.Bytes(usage.Used),
 		UsedPercent:            fmt.Sprintf("%.2f", usage.UsedPercent),
 		UsedPercentFloat:       usage.UsedPercent,
 		InodesTotal:            usage.InodesTotal,
@@ -133,6 +133,39 @@ const (
 	StateKeyDiskUsageInodesUsedPercent = "inodes_used_percent"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateNameDiskExtPartition,
+			Description: "ext4 partition, one state per partition",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyDiskPartitionDevice, Type: components.ExtraInfoTypeString, Description: "device path (e.g., \"/dev/sda1\")"},
+				{Key: StateKeyDiskPartitionMountPoint, Type: components.ExtraInfoTypeString, Description: "mount point"},
+				{Key: StateKeyDiskPartitionFstype, Type: components.ExtraInfoTypeString, Description: "file system type"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateNameDiskUsage,
+			Description: "disk usage, one state per mount point",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyDiskUsageMountPoint, Type: components.ExtraInfoTypeString, Description: "mount point"},
+				{Key: StateKeyDiskUsageFstype, Type: components.ExtraInfoTypeString, Description: "file system type"},
+				{Key: StateKeyDiskUsageTotalBytes, Type: components.ExtraInfoTypeUint, Unit: "bytes", Description: "total size"},
+				{Key: StateKeyDiskUsageTotalHumanized, Type: components.ExtraInfoTypeString, Description: "total size, humanized"},
+				{Key: StateKeyDiskUsageFreeBytes, Type: components.ExtraInfoTypeUint, Unit: "bytes", Description: "free size"},
+				{Key: StateKeyDiskUsageFreeHumanized, Type: components.ExtraInfoTypeString, Description: "free size, humanized"},
+				{Key: StateKeyDiskUsageUsedBytes, Type: components.ExtraInfoTypeUint, Unit: "bytes", Description: "used size"},
+				{Key: StateKeyDiskUsageUsedHumanized, Type: components.ExtraInfoTypeString, Description: "used size, humanized"},
+				{Key: StateKeyDiskUsageUsedPercent, Type: components.ExtraInfoTypeFloat, Unit: "percent", Description: "used size"},
+				{Key: StateKeyDiskUsageInodesTotal, Type: components.ExtraInfoTypeUint, Unit: "inodes", Description: "total inodes"},
+				{Key: StateKeyDiskUsageInodesUsed, Type: components.ExtraInfoTypeUint, Unit: "inodes", Description: "used inodes"},
+				{Key: StateKeyDiskUsageInodesFree, Type: components.ExtraInfoTypeUint, Unit: "inodes", Description: "free inodes"},
+				{Key: StateKeyDiskUsageInodesUsedPercent, Type: components.ExtraInfoTypeFloat, Unit: "percent", Description: "used inodes"},
+			},
+		},
+	)
+}
+
 func (p Partition) Map() map[string]string {
 	return map[string]string{
 		StateKeyDiskPartitionDevice:     p.Device,
@@ -0,0 +1,91 @@
+package dmesg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	query_log_common "github.com/leptonai/gpud/components/query/log/common"
+	query_log_tail "github.com/leptonai/gpud/components/query/log/tail"
+	"github.com/leptonai/gpud/log"
+	pkg_dmesg "github.com/leptonai/gpud/pkg/dmesg"
+	"github.com/leptonai/gpud/pkg/systemd"
+)
+
+// DefaultScanWindow is the window of the default tail scan commands ("--since '1 hour ago'"),
+// thus the backfill is only needed on the first start or after a longer downtime.
+const DefaultScanWindow = time.Hour
+
+// maximum number of lines to read from each backfill source
+const backfillLinesToTail = 1000000
+
+// reads the full kernel ring buffer (current boot)
+const backfillDmesgCommand = "dmesg --time-format=iso --nopager --buffer-size 163920"
+
+// reads the kernel messages of the previous boots from the journal,
+// since the ring buffer only holds the current boot
+func backfillJournalCommand(since time.Time, until time.Time) string {
+	return fmt.Sprintf("journalctl -k -q --no-pager -o short-iso-precise --since @%d --until @%d", since.Unix(), until.Unix())
+}
+
+// BackfillSince returns the time to backfill the events since, given the last time gpud was seen running
+// (or not found on the first start), and false if the default tail scan already covers the downtime.
+// The backfill goes back at most the max lookback (e.g., the retention period).
+func BackfillSince(lastSeen time.Time, found bool, now time.Time, maxLookback time.Duration) (time.Time, bool) {
+	since := now.Add(-maxLookback)
+	if !found {
+		return since, true
+	}
+	if now.Sub(lastSeen) <= DefaultScanWindow {
+		return time.Time{}, false
+	}
+	if lastSeen.After(since) {
+		since = lastSeen
+	}
+	return since, true
+}
+
+// Backfill scans the full kernel ring buffer, and the kernel journal before the boot time (if journalctl exists),
+// and processes the matched lines since the given time with their original timestamps
+// (e.g., the errors before gpud was installed or while gpud was down).
+// Returns the number of the processed lines.
+func (c *Component) Backfill(ctx context.Context, since time.Time, bootTime time.Time) (int, error) {
+	processed := 0
+	processMatched := func(ts time.Time, line []byte, matchedFilter *query_log_common.Filter) {
+		if ts.Before(since) {
+			return
+		}
+		processed++
+		if c.processMatched != nil {
+			c.processMatched(ts, line, matchedFilter)
+		}
+	}
+
+	if systemd.JournalctlExists() && bootTime.After(since) {
+		log.Logger.Infow("backfilling kernel journal", "since", since, "until", bootTime)
+		if _, err := c.logPoller.TailScan(
+			ctx,
+			query_log_tail.WithFile(""),
+			query_log_tail.WithCommands([][]string{{backfillJournalCommand(since, bootTime)}}),
+			query_log_tail.WithLinesToTail(backfillLinesToTail),
+			query_log_tail.WithExtractTime(pkg_dmesg.ParseJournalISOTimeWithError),
+			query_log_tail.WithProcessMatched(processMatched),
+		); err != nil {
+			// e.g., old journalctl without the "short-iso-precise" output mode
+			log.Logger.Warnw("failed to backfill kernel journal", "error", err)
+		}
+	}
+
+	log.Logger.Infow("backfilling dmesg", "since", since)
+	if _, err := c.logPoller.TailScan(
+		ctx,
+		query_log_tail.WithFile(""),
+		query_log_tail.WithCommands([][]string{{backfillDmesgCommand}}),
+		query_log_tail.WithLinesToTail(backfillLinesToTail),
+		query_log_tail.WithExtractTime(pkg_dmesg.ParseISOtimeWithError),
+		query_log_tail.WithProcessMatched(processMatched),
+	); err != nil {
+		return processed, err
+	}
+	return processed, nil
+}
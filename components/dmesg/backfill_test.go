@@ -0,0 +1,45 @@
+package dmesg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackfillSince(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0).UTC()
+	lookback := 5 * 24 * time.Hour
+
+	tests := []struct {
+		name      string
+		lastSeen  time.Time
+		found     bool
+		want      time.Time
+		wantFound bool
+	}{
+		{name: "first start", want: now.Add(-lookback), wantFound: true},
+		{name: "restart", lastSeen: now.Add(-10 * time.Minute), found: true},
+		{name: "downtime within scan window", lastSeen: now.Add(-DefaultScanWindow), found: true},
+		{name: "long downtime", lastSeen: now.Add(-3 * time.Hour), found: true, want: now.Add(-3 * time.Hour), wantFound: true},
+		{name: "downtime beyond lookback", lastSeen: now.Add(-30 * 24 * time.Hour), found: true, want: now.Add(-lookback), wantFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := BackfillSince(tt.lastSeen, tt.found, now, lookback)
+			if ok != tt.wantFound || !got.Equal(tt.want) {
+				t.Errorf("BackfillSince() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestBackfillJournalCommand(t *testing.T) {
+	t.Parallel()
+
+	got := backfillJournalCommand(time.Unix(100, 0), time.Unix(200, 0))
+	want := "journalctl -k -q --no-pager -o short-iso-precise --since @100 --until @200"
+	if got != want {
+		t.Errorf("backfillJournalCommand() = %q, want %q", got, want)
+	}
+}
@@ -4,14 +4,19 @@ package dmesg
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/dmesg/metrics"
 	query_log "github.com/leptonai/gpud/components/query/log"
 	query_log_common "github.com/leptonai/gpud/components/query/log/common"
+	query_log_queue "github.com/leptonai/gpud/components/query/log/queue"
 	query_log_tail "github.com/leptonai/gpud/components/query/log/tail"
 	"github.com/leptonai/gpud/log"
 	pkg_dmesg "github.com/leptonai/gpud/pkg/dmesg"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 const Name = "dmesg"
@@ -21,8 +26,31 @@ func New(ctx context.Context, cfg Config, processMatched query_log_common.Proces
 		return nil, err
 	}
 	cfg.Log.SetDefaultsIfNotSet()
+	if err := cfg.Queue.Validate(); err != nil {
+		return nil, err
+	}
+	cfg.Queue.SetDefaultsIfNotSet()
+
+	// the realtime lines are queued to write in batches, off the log tailer
+	// (the tail scans and the backfills are processed directly, since they are bounded)
+	realtimeProcessMatched := processMatched
+	if processMatched != nil {
+		q := query_log_queue.New(ctx, cfg.Queue, func(_ context.Context, b query_log_queue.Batch) {
+			for filter, n := range b.Dropped {
+				metrics.AddDroppedLines(filter, n)
+			}
+			for _, l := range b.Lines {
+				processMatched(l.Time, l.Line, l.Filter)
+			}
+			metrics.SetQueuedLines(b.Remaining)
+		})
+		realtimeProcessMatched = func(parsedTime time.Time, line []byte, filter *query_log_common.Filter) {
+			q.Push(parsedTime, line, filter)
+			metrics.SetQueuedLines(q.Len())
+		}
+	}
 
-	if err := createDefaultLogPoller(ctx, cfg, processMatched); err != nil {
+	if err := createDefaultLogPoller(ctx, cfg, realtimeProcessMatched); err != nil {
 		return nil, err
 	}
 
@@ -106,6 +134,12 @@ func (c *Component) Metrics(ctx context.Context, since time.Time) ([]components.
 	return nil, nil
 }
 
+var _ components.PromRegisterer = (*Component)(nil)
+
+func (c *Component) RegisterCollectors(reg *prometheus.Registry, db *sql.DB, tableName string) error {
+	return metrics.Register(reg)
+}
+
 func (c *Component) Close() error {
 	log.Logger.Debugw("closing component")
 
@@ -48,6 +48,30 @@ const (
 	StateKeyDmesgTailScanMatchedError       = "error"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateNameDmesg,
+			Description: "dmesg file being scanned",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyDmesgFile, Type: components.ExtraInfoTypeString, Description: "scanned file path"},
+				{Key: StateKeyDmesgLastSeekOffset, Type: components.ExtraInfoTypeInt, Unit: "bytes", Description: "last seek offset"},
+				{Key: StateKeyDmesgLastSeekWhence, Type: components.ExtraInfoTypeInt, Description: "last seek whence (as in io.Seeker)"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateNameDmesgTailScanMatched,
+			Description: "dmesg line matched by a filter, one state per line",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyDmesgTailScanMatchedUnixSeconds, Type: components.ExtraInfoTypeInt, Unit: "seconds", Description: "unix time of the line"},
+				{Key: StateKeyDmesgTailScanMatchedLine, Type: components.ExtraInfoTypeString, Description: "matched line"},
+				{Key: StateKeyDmesgTailScanMatchedFilter, Type: components.ExtraInfoTypeJSON, Description: "matched filter"},
+				{Key: StateKeyDmesgTailScanMatchedError, Type: components.ExtraInfoTypeString, Description: "error from matching the line"},
+			},
+		},
+	)
+}
+
 func ParseStateDmesg(s *State, m map[string]string) error {
 	s.File = m[StateKeyDmesgFile]
 
@@ -9,10 +9,16 @@ import (
 
 	query_config "github.com/leptonai/gpud/components/query/config"
 	query_log_config "github.com/leptonai/gpud/components/query/log/config"
+	query_log_queue "github.com/leptonai/gpud/components/query/log/queue"
 )
 
 type Config struct {
 	Log query_log_config.Config `json:"log"`
+
+	// Queue is the event queue between the dmesg watcher and the event store writes,
+	// which batches the writes and drops (and counts) the lines when full,
+	// so that the dmesg error storms cannot wedge the daemon.
+	Queue query_log_queue.Config `json:"queue"`
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -34,7 +40,10 @@ func ParseConfig(b any, db *sql.DB) (*Config, error) {
 }
 
 func (cfg Config) Validate() error {
-	return cfg.Log.Validate()
+	if err := cfg.Log.Validate(); err != nil {
+		return err
+	}
+	return cfg.Queue.Validate()
 }
 
 func DmesgExists() bool {
@@ -55,6 +55,7 @@ func DefaultLogFilters(ctx context.Context) ([]*query_log_common.Filter, error)
 			OwnerReferences: []string{memory.Name},
 		},
 	}
+	defaultFilters = append(defaultFilters, DefaultDmesgFiltersForNIC()...)
 
 	nvidiaInstalled, err := nvidia_query.GPUsInstalled(ctx)
 	if err != nil {
@@ -0,0 +1,23 @@
+package dmesg
+
+import (
+	network_query_nic "github.com/leptonai/gpud/components/network/query/nic"
+	network_roce_id "github.com/leptonai/gpud/components/network/roce/id"
+	query_log_common "github.com/leptonai/gpud/components/query/log/common"
+
+	"k8s.io/utils/ptr"
+)
+
+// DefaultDmesgFiltersForNIC returns the filters for the common NIC driver failures (mlx5, bnxt),
+// owned by the fabric NIC component.
+func DefaultDmesgFiltersForNIC() []*query_log_common.Filter {
+	filters := make([]*query_log_common.Filter, 0, len(network_query_nic.Rules))
+	for _, r := range network_query_nic.Rules {
+		filters = append(filters, &query_log_common.Filter{
+			Name:            r.Name,
+			Regex:           ptr.To(r.Regex),
+			OwnerReferences: []string{network_roce_id.Name},
+		})
+	}
+	return filters
+}
@@ -1,11 +1,13 @@
 package dmesg
 
 import (
+	nvidia_component_error_xid_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error-xid-sxid/id"
 	nvidia_component_error_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error/sxid/id"
 	nvidia_component_error_xid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error/xid/id"
 	nvidia_nccl_id "github.com/leptonai/gpud/components/accelerator/nvidia/nccl/id"
 	nvidia_peermem_id "github.com/leptonai/gpud/components/accelerator/nvidia/peermem/id"
 	nvidia_query_nccl "github.com/leptonai/gpud/components/accelerator/nvidia/query/nccl"
+	nvidia_query_pcie_aer "github.com/leptonai/gpud/components/accelerator/nvidia/query/pcie-aer"
 	nvidia_query_peermem "github.com/leptonai/gpud/components/accelerator/nvidia/query/peermem"
 	nvidia_query_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/query/sxid"
 	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
@@ -44,6 +46,12 @@ const (
 	// e.g.,
 	// [Thu Oct 10 03:06:53 2024] pt_main_thread[2536443]: segfault at 7f797fe00000 ip 00007f7c7ac69996 sp 00007f7c12fd7c30 error 4 in libnccl.so.2[7f7c7ac00000+d3d3000]
 	EventNvidiaNCCLSegfaultInLibnccl = "nvidia_nccl_segfault_in_libnccl"
+
+	// correlated with the Xid 79 (GPU has fallen off the bus) on the same device
+	// e.g.,
+	// [Thu Oct 10 03:06:53 2024] pcieport 0000:00:01.1: AER: Uncorrected (Fatal) error received: 0000:05:00.0
+	// [Thu Oct 10 03:06:53 2024] nvidia 0000:05:00.0: AER: PCIe Bus Error: severity=Uncorrected (Fatal), type=Transaction Layer, (Receiver ID)
+	EventNvidiaPCIeAER = "nvidia_pcie_aer"
 )
 
 func DefaultDmesgFiltersForNvidia() []*query_log_common.Filter {
@@ -68,5 +76,10 @@ func DefaultDmesgFiltersForNvidia() []*query_log_common.Filter {
 			Regex:           ptr.To(nvidia_query_nccl.RegexSegfaultInLibnccl),
 			OwnerReferences: []string{nvidia_nccl_id.Name},
 		},
+		{
+			Name:            EventNvidiaPCIeAER,
+			Regex:           ptr.To(nvidia_query_pcie_aer.RegexPCIeAERDmesg),
+			OwnerReferences: []string{nvidia_component_error_xid_sxid_id.Name},
+		},
 	}
 }
@@ -0,0 +1,47 @@
+// Package metrics implements the dmesg event queue metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const SubSystem = "dmesg"
+
+var (
+	queuedLines = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "queued_lines",
+			Help:      "tracks the number of the matched dmesg lines queued to be written to the event store",
+		},
+	)
+
+	droppedLinesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "dropped_lines_total",
+			Help:      "tracks the number of the matched dmesg lines dropped because the event queue was full (e.g., error storms)",
+		},
+		[]string{"filter"},
+	)
+)
+
+func SetQueuedLines(n int) {
+	queuedLines.Set(float64(n))
+}
+
+func AddDroppedLines(filter string, n int) {
+	droppedLinesTotal.WithLabelValues(filter).Add(float64(n))
+}
+
+func Register(reg *prometheus.Registry) error {
+	if err := reg.Register(queuedLines); err != nil {
+		return err
+	}
+	if err := reg.Register(droppedLinesTotal); err != nil {
+		return err
+	}
+	return nil
+}
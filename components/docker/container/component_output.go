@@ -58,6 +58,12 @@ const (
 	PodNamespaceLabel = "io.kubernetes.pod.namespace"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameDockerContainer, "docker containers"),
+	)
+}
+
 func ParseStateDockerContainer(m map[string]string) ([]DockerContainer, error) {
 	var containers []DockerContainer
 	data := m[StateKeyDockerContainerData]
@@ -77,14 +83,30 @@ func (o *Output) describeReason() string {
 	if o.ConnectionError != "" {
 		return fmt.Sprintf("connection error to docker daemon -- %s", o.ConnectionError)
 	}
+	if incompatible := o.cudaIncompatibleContainers(); len(incompatible) > 0 {
+		return fmt.Sprintf("total %d containers, %d container(s) with cuda driver incompatibility -- %s", len(o.Containers), len(incompatible), strings.Join(incompatible, "; "))
+	}
 	return fmt.Sprintf("total %d containers", len(o.Containers))
 }
 
+func (o *Output) cudaIncompatibleContainers() []string {
+	var incompatible []string
+	for _, c := range o.Containers {
+		if c.CUDACompat != nil && !c.CUDACompat.Compatible {
+			incompatible = append(incompatible, fmt.Sprintf("%s (%s): %s", c.Name, c.Image, c.CUDACompat.Reason))
+		}
+	}
+	return incompatible
+}
+
 func (o *Output) States(cfg Config) ([]components.State, error) {
 	healthy := o.ConnectionError == ""
 	if cfg.IgnoreConnectionErrors {
 		healthy = true
 	}
+	if len(o.cudaIncompatibleContainers()) > 0 {
+		healthy = false
+	}
 
 	b, _ := o.JSON()
 	return []components.State{{
@@ -177,7 +199,15 @@ func CreateGet(cfg Config) query.GetFunc {
 		for _, c := range dockerContainers {
 			containers = append(containers, ConvertToDockerContainer(c))
 		}
-		return &Output{Containers: containers, DockerPidFound: dockerRunning}, nil
+		o := &Output{Containers: containers, DockerPidFound: dockerRunning}
+
+		if len(cfg.CUDACompatImages) > 0 {
+			if err := checkCUDACompats(ctx, cfg.CUDACompatImages, o.Containers); err != nil {
+				log.Logger.Warnw("failed to check cuda compatibility", "error", err)
+				o.Message = "failed to check cuda compatibility -- " + err.Error()
+			}
+		}
+		return o, nil
 	}
 }
 
@@ -239,6 +269,10 @@ type DockerContainer struct {
 	State        string `json:"state,omitempty"`
 	PodName      string `json:"pod_name,omitempty"`
 	PodNamespace string `json:"pod_namespace,omitempty"`
+
+	// CUDACompat is the CUDA library and driver compatibility check result,
+	// only set for the running containers of the configured images.
+	CUDACompat *CUDACompat `json:"cuda_compat,omitempty"`
 }
 
 // IsErrDockerClientVersionNewerThanDaemon returns true if the docker client version is newer than the daemon version.
@@ -13,6 +13,11 @@ type Config struct {
 	// In case the docker daemon is not running, we ignore such errors as
 	// 'Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?'.
 	IgnoreConnectionErrors bool `json:"ignore_connection_errors"`
+
+	// CUDACompatImages is the list of the image name prefixes (e.g., "nvcr.io/nvidia/pytorch")
+	// whose running containers are checked for the CUDA library and driver compatibility.
+	// Leave empty to disable the check.
+	CUDACompatImages []string `json:"cuda_compat_images,omitempty"`
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
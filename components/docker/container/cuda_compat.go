@@ -0,0 +1,141 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/log"
+
+	docker_client "github.com/docker/docker/client"
+)
+
+// CUDACompat is the CUDA library and driver compatibility of a container.
+type CUDACompat struct {
+	// ContainerCUDAVersion is the CUDA toolkit version in the container image
+	// (from the "CUDA_VERSION" environment variable set in the NVIDIA CUDA base images).
+	ContainerCUDAVersion string `json:"container_cuda_version"`
+	// DriverCUDAVersion is the maximum CUDA version supported by the host driver.
+	DriverCUDAVersion string `json:"driver_cuda_version"`
+	// ForwardCompatFound is set true if the forward compatibility package
+	// ("cuda-compat", "/usr/local/cuda/compat/libcuda.so.*") is found in the container.
+	ForwardCompatFound bool `json:"forward_compat_found"`
+
+	// Compatible is set false if the container will fail at the first CUDA call
+	// (e.g., "CUDA driver version is insufficient for CUDA runtime version").
+	Compatible bool   `json:"compatible"`
+	Reason     string `json:"reason"`
+}
+
+// EvaluateCUDACompat returns whether the container CUDA version can run on the host driver, with the reason.
+// ref. https://docs.nvidia.com/deploy/cuda-compatibility/index.html
+func EvaluateCUDACompat(containerCUDA string, driverCUDA string, forwardCompatFound bool) (bool, string) {
+	cMajor, cMinor, err := parseCUDAVersion(containerCUDA)
+	if err != nil {
+		return true, fmt.Sprintf("unknown container cuda version %q", containerCUDA)
+	}
+	dMajor, dMinor, err := parseCUDAVersion(driverCUDA)
+	if err != nil {
+		return true, fmt.Sprintf("unknown driver cuda version %q", driverCUDA)
+	}
+
+	if cMajor < dMajor || (cMajor == dMajor && cMinor <= dMinor) {
+		return true, fmt.Sprintf("container cuda %s is supported by driver cuda %s", containerCUDA, driverCUDA)
+	}
+	if forwardCompatFound {
+		return true, fmt.Sprintf("container cuda %s is newer than driver cuda %s but uses the forward compatibility package", containerCUDA, driverCUDA)
+	}
+	if cMajor == dMajor {
+		// minor version compatibility (CUDA 11+), PTX JIT and newer APIs may still fail
+		return true, fmt.Sprintf("container cuda %s is newer than driver cuda %s but runs with minor version compatibility", containerCUDA, driverCUDA)
+	}
+	return false, fmt.Sprintf("container cuda %s requires a newer driver than cuda %s and no forward compatibility package found (install 'cuda-compat' in the image or upgrade the driver)", containerCUDA, driverCUDA)
+}
+
+// parseCUDAVersion parses the major and minor versions (e.g., "12.4.1" or "12.4").
+func parseCUDAVersion(ver string) (int, int, error) {
+	var major, minor int
+	if _, err := fmt.Sscanf(ver, "%d.%d", &major, &minor); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse cuda version %q: %w", ver, err)
+	}
+	return major, minor, nil
+}
+
+// matchesImage returns true if the image matches any of the image name prefixes.
+func matchesImage(image string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(image, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCUDACompat inspects the running container for the CUDA version and the forward compatibility package.
+// The container root filesystem is read via "/proc/[pid]/root", thus requires root privileges.
+func CheckCUDACompat(ctx context.Context, cli *docker_client.Client, containerID string, driverCUDA string) (*CUDACompat, error) {
+	resp, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContainerJSONBase == nil || resp.State == nil || resp.Config == nil {
+		return nil, fmt.Errorf("container %s has no state or config", containerID)
+	}
+
+	containerCUDA := ""
+	for _, env := range resp.Config.Env {
+		if v, ok := strings.CutPrefix(env, "CUDA_VERSION="); ok {
+			containerCUDA = v
+			break
+		}
+	}
+	if containerCUDA == "" {
+		// not a CUDA image
+		return nil, nil
+	}
+
+	found := false
+	if resp.State.Pid > 0 {
+		matches, _ := filepath.Glob(filepath.Join("/proc", fmt.Sprint(resp.State.Pid), "root", "usr", "local", "cuda", "compat", "libcuda.so.*"))
+		found = len(matches) > 0
+	}
+
+	compatible, reason := EvaluateCUDACompat(containerCUDA, driverCUDA, found)
+	return &CUDACompat{
+		ContainerCUDAVersion: containerCUDA,
+		DriverCUDAVersion:    driverCUDA,
+		ForwardCompatFound:   found,
+		Compatible:           compatible,
+		Reason:               reason,
+	}, nil
+}
+
+// checkCUDACompats checks the running containers of the matching images,
+// and sets the CUDA compatibility results in place.
+func checkCUDACompats(ctx context.Context, imagePrefixes []string, containers []DockerContainer) error {
+	driverCUDA, err := nvidia_query_nvml.GetCUDADriverVersion()
+	if err != nil {
+		return err
+	}
+
+	cli, err := docker_client.NewClientWithOpts(docker_client.FromEnv)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	for i := range containers {
+		if containers[i].State != "running" || !matchesImage(containers[i].Image, imagePrefixes) {
+			continue
+		}
+		compat, err := CheckCUDACompat(ctx, cli, containers[i].ID, driverCUDA)
+		if err != nil {
+			log.Logger.Warnw("failed to check cuda compatibility", "container", containers[i].ID, "error", err)
+			continue
+		}
+		containers[i].CUDACompat = compat
+	}
+	return nil
+}
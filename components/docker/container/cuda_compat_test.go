@@ -0,0 +1,69 @@
+package container
+
+import "testing"
+
+func TestEvaluateCUDACompat(t *testing.T) {
+	tests := []struct {
+		name               string
+		containerCUDA      string
+		driverCUDA         string
+		forwardCompatFound bool
+		expected           bool
+	}{
+		{
+			name:          "Older container cuda",
+			containerCUDA: "12.2.0",
+			driverCUDA:    "12.4",
+			expected:      true,
+		},
+		{
+			name:          "Same cuda",
+			containerCUDA: "12.4.1",
+			driverCUDA:    "12.4",
+			expected:      true,
+		},
+		{
+			name:          "Newer minor version",
+			containerCUDA: "12.6.0",
+			driverCUDA:    "12.4",
+			expected:      true,
+		},
+		{
+			name:          "Newer major version without forward compatibility",
+			containerCUDA: "12.1.0",
+			driverCUDA:    "11.8",
+			expected:      false,
+		},
+		{
+			name:               "Newer major version with forward compatibility",
+			containerCUDA:      "12.1.0",
+			driverCUDA:         "11.8",
+			forwardCompatFound: true,
+			expected:           true,
+		},
+		{
+			name:          "Unknown container cuda",
+			containerCUDA: "invalid",
+			driverCUDA:    "12.4",
+			expected:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compatible, reason := EvaluateCUDACompat(tt.containerCUDA, tt.driverCUDA, tt.forwardCompatFound)
+			if compatible != tt.expected {
+				t.Errorf("EvaluateCUDACompat() = %v (%s), want %v", compatible, reason, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatchesImage(t *testing.T) {
+	prefixes := []string{"nvcr.io/nvidia/pytorch", "nvcr.io/nvidia/tritonserver"}
+	if !matchesImage("nvcr.io/nvidia/pytorch:24.05-py3", prefixes) {
+		t.Error("expected pytorch image to match")
+	}
+	if matchesImage("ubuntu:22.04", prefixes) {
+		t.Error("expected ubuntu image not to match")
+	}
+}
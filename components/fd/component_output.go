@@ -71,6 +71,24 @@ const (
 	StateKeyThresholdUsedPercent = "threshold_used_percent"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateNameFileDescriptors,
+			Description: "file descriptor usage and limits",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyRunningPIDs, Type: components.ExtraInfoTypeUint, Unit: "processes", Description: "number of the running processes"},
+				{Key: StateKeyUsage, Type: components.ExtraInfoTypeUint, Unit: "file descriptors", Description: "number of the allocated file descriptors"},
+				{Key: StateKeyLimit, Type: components.ExtraInfoTypeUint, Unit: "file descriptors", Description: "system-wide file descriptor limit"},
+				{Key: StateKeyUsedPercent, Type: components.ExtraInfoTypeFloat, Unit: "percent", Description: "usage against the limit"},
+				{Key: StateKeyFDLimitSupported, Type: components.ExtraInfoTypeBool, Description: "true if the file descriptor limit is supported by the host"},
+				{Key: StateKeyThresholdLimit, Type: components.ExtraInfoTypeUint, Unit: "file descriptors", Description: "configured threshold limit"},
+				{Key: StateKeyThresholdUsedPercent, Type: components.ExtraInfoTypeFloat, Unit: "percent", Description: "usage against the threshold limit"},
+			},
+		},
+	)
+}
+
 func ParseStateFileDescriptors(m map[string]string) (*Output, error) {
 	o := &Output{}
 
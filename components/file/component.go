@@ -12,6 +12,15 @@ import (
 	"github.com/leptonai/gpud/log"
 )
 
+func init() {
+	components.RegisterStateSchemas(file_id.Name,
+		components.StateSchema{
+			Name:        file_id.Name,
+			Description: "files to check, unhealthy if any file does not exist",
+		},
+	)
+}
+
 func New(filesToCheck []string) components.Component {
 	return &component{filesToCheck: filesToCheck}
 }
@@ -40,6 +40,25 @@ const (
 	StateNameAnnotations = "annotations"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateNameDaemon,
+			Description: "gpud daemon information",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyDaemonVersion, Type: components.ExtraInfoTypeString, Description: "gpud version"},
+				{Key: StateKeyMacAddress, Type: components.ExtraInfoTypeString, Description: "MAC address of the first network interface"},
+				{Key: StateKeyPackages, Type: components.ExtraInfoTypeJSON, Description: "status of the gpud managed packages"},
+			},
+		},
+		components.StateSchema{
+			Name:             StateNameAnnotations,
+			Description:      "server annotations (e.g., machine ID), as the extra info",
+			DynamicExtraInfo: true,
+		},
+	)
+}
+
 func (c *component) States(ctx context.Context) ([]components.State, error) {
 	interfaces, err := net.Interfaces()
 	if err != nil {
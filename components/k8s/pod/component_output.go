@@ -49,6 +49,12 @@ const (
 	StateValuePodEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNamePod, "kubernetes pods on the node"),
+	)
+}
+
 func ParseStatePod(m map[string]string) (*Output, error) {
 	o := &Output{}
 	data := m[StateKeyPodData]
@@ -14,6 +14,21 @@ import (
 	"github.com/leptonai/gpud/log"
 )
 
+// StateKeyModules is the extra info key of the modules set in "/etc/modules".
+const StateKeyModules = "modules"
+
+func init() {
+	components.RegisterStateSchemas(kernel_module_id.Name,
+		components.StateSchema{
+			Name:        kernel_module_id.Name,
+			Description: "kernel modules set in /etc/modules against the modules to check",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyModules, Type: components.ExtraInfoTypeJSON, Description: "modules set in /etc/modules"},
+			},
+		},
+	)
+}
+
 func New(modulesToCheck []string) components.Component {
 	return &component{modulesToCheck: modulesToCheck}
 }
@@ -54,7 +69,7 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 		Healthy: true,
 		Reason:  fmt.Sprintf("found %d modules in %q and %d module(s) to check", len(modules), DefaultEtcModulesPath, len(c.modulesToCheck)),
 		ExtraInfo: map[string]string{
-			"modules": string(modulesInJSON),
+			StateKeyModules: string(modulesInJSON),
 		},
 	}
 
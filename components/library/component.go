@@ -19,6 +19,15 @@ type Config struct {
 	SearchDirs []string
 }
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        Name,
+			Description: "libraries to check, unhealthy if any library does not exist",
+		},
+	)
+}
+
 func New(cfg Config) components.Component {
 	libraries := make(map[string]any)
 	for _, lib := range cfg.Libraries {
@@ -12,8 +12,8 @@ import (
 	"github.com/leptonai/gpud/components/memory/metrics"
 	components_metrics "github.com/leptonai/gpud/components/metrics"
 	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/pkg/locale"
 
-	"github.com/dustin/go-humanize"
 	"github.com/shirou/gopsutil/v4/mem"
 )
 
@@ -63,6 +63,26 @@ const (
 	StateKeyFreeHumanized      = "free_humanized"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateKeyVirtualMemory,
+			Description: "host virtual memory usage",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyTotalBytes, Type: components.ExtraInfoTypeUint, Unit: "bytes", Description: "total memory"},
+				{Key: StateKeyTotalHumanized, Type: components.ExtraInfoTypeString, Description: "total memory, humanized"},
+				{Key: StateKeyAvailableBytes, Type: components.ExtraInfoTypeUint, Unit: "bytes", Description: "available memory"},
+				{Key: StateKeyAvailableHumanized, Type: components.ExtraInfoTypeString, Description: "available memory, humanized"},
+				{Key: StateKeyUsedBytes, Type: components.ExtraInfoTypeUint, Unit: "bytes", Description: "used memory"},
+				{Key: StateKeyUsedHumanized, Type: components.ExtraInfoTypeString, Description: "used memory, humanized"},
+				{Key: StateKeyUsedPercent, Type: components.ExtraInfoTypeFloat, Unit: "percent", Description: "used memory"},
+				{Key: StateKeyFreeBytes, Type: components.ExtraInfoTypeUint, Unit: "bytes", Description: "free memory"},
+				{Key: StateKeyFreeHumanized, Type: components.ExtraInfoTypeString, Description: "free memory, humanized"},
+			},
+		},
+	)
+}
+
 func ParseStateKeyVirtualMemory(m map[string]string) (*Output, error) {
 	o := &Output{}
 
@@ -176,13 +196,13 @@ func Get(ctx context.Context) (_ any, e error) {
 
 	return &Output{
 		TotalBytes:         vm.Total,
-		TotalHumanized:     humanize.Bytes(vm.Total),
+		TotalHumanized:     locale.Bytes(vm.Total),
 		AvailableBytes:     vm.Available,
-		AvailableHumanized: humanize.Bytes(vm.Available),
+		AvailableHumanized: locale.Bytes(vm.Available),
 		UsedBytes:          vm.Used,
-		UsedHumanized:      humanize.Bytes(vm.Used),
+		UsedHumanized:      locale.Bytes(vm.Used),
 		UsedPercent:        fmt.Sprintf("%.2f", vm.UsedPercent),
 		FreeBytes:          vm.Free,
-		FreeHumanized:      humanize.Bytes(vm.Free),
+		FreeHumanized:      locale.Bytes(vm.Free),
 	}, nil
 }
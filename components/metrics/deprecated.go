@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components/metrics/state"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DeprecatedGaugeVec keeps exporting a gauge under its name before the SI unit rename
+// (e.g., "_milli_watts" for "_watts"), with the value converted back to the old unit,
+// so that the existing dashboards and alerts keep working until migrated.
+type DeprecatedGaugeVec struct {
+	*prometheus.GaugeVec
+	// factor converts the value in the new unit to the old unit (e.g., 1e3 from watts to milliwatts)
+	factor float64
+}
+
+// NewDeprecatedGaugeVec creates a gauge with the old name, replaced by the named metric.
+func NewDeprecatedGaugeVec(opts prometheus.GaugeOpts, labelNames []string, replacedBy string, factor float64) *DeprecatedGaugeVec {
+	opts.Help = "deprecated: use " + replacedBy + " instead, " + opts.Help
+	return &DeprecatedGaugeVec{
+		GaugeVec: prometheus.NewGaugeVec(opts, labelNames),
+		factor:   factor,
+	}
+}
+
+// Set sets the value in the new unit, converted to the old unit.
+func (g *DeprecatedGaugeVec) Set(value float64, labelValues ...string) {
+	g.GaugeVec.WithLabelValues(labelValues...).Set(value * g.factor)
+}
+
+// RenamedMetric is the averager metric renamed with the SI unit rename.
+type RenamedMetric struct {
+	From string
+	To   string
+	// Factor converts the value in the old unit to the new unit (e.g., 1e-3 from milliwatts to watts).
+	Factor float64
+}
+
+// MigrateRenamedMetrics re-keys the averager rows recorded under the old metric names
+// (see "components/metrics/state.RenameMetric"), so the averages and the "/v1/metrics" API
+// keep the history across the upgrade. No-op once migrated, or if the db is nil.
+func MigrateRenamedMetrics(db *sql.DB, tableName string, renamed ...RenamedMetric) error {
+	if db == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, r := range renamed {
+		n, err := state.RenameMetric(ctx, db, tableName, r.From, r.To, r.Factor)
+		if err != nil {
+			return fmt.Errorf("failed to rename metric %q to %q: %w", r.From, r.To, err)
+		}
+		if n > 0 {
+			log.Logger.Infow("renamed metric rows", "from", r.From, "to", r.To, "rows", n)
+		}
+	}
+	return nil
+}
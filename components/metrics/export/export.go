@@ -0,0 +1,105 @@
+// Package export writes the stored metrics as the CSV or Parquet files,
+// for the offline analysis (e.g., the fleet GPU health trends in the notebooks)
+// without scraping the Prometheus endpoint.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics_state "github.com/leptonai/gpud/components/metrics/state"
+	"github.com/leptonai/gpud/pkg/parquet"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// Columns are the exported columns, same as the metrics table.
+var Columns = []string{
+	metrics_state.ColumnUnixSeconds,
+	metrics_state.ColumnMetricName,
+	metrics_state.ColumnMetricSecondaryName,
+	metrics_state.ColumnMetricValue,
+}
+
+// Write writes the metrics in the given format.
+func Write(w io.Writer, format string, metrics metrics_state.Metrics) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, metrics)
+	case FormatParquet:
+		return WriteParquet(w, metrics)
+	default:
+		return fmt.Errorf("unknown format %q (supported: %s, %s)", format, FormatCSV, FormatParquet)
+	}
+}
+
+// WriteCSV writes the metrics as the CSV with the header row.
+func WriteCSV(w io.Writer, metrics metrics_state.Metrics) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return err
+	}
+	for _, m := range metrics {
+		if err := cw.Write([]string{
+			strconv.FormatInt(m.UnixSeconds, 10),
+			m.MetricName,
+			m.MetricSecondaryName,
+			strconv.FormatFloat(m.Value, 'f', -1, 64),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteParquet writes the metrics as the Parquet file.
+func WriteParquet(w io.Writer, metrics metrics_state.Metrics) error {
+	pw := parquet.NewWriter(w, []parquet.Column{
+		{Name: metrics_state.ColumnUnixSeconds, Type: parquet.TypeInt64},
+		{Name: metrics_state.ColumnMetricName, Type: parquet.TypeByteArray, UTF8: true},
+		{Name: metrics_state.ColumnMetricSecondaryName, Type: parquet.TypeByteArray, UTF8: true},
+		{Name: metrics_state.ColumnMetricValue, Type: parquet.TypeDouble},
+	})
+	for _, m := range metrics {
+		if err := pw.Write(m.UnixSeconds, m.MetricName, m.MetricSecondaryName, m.Value); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+// ParseSince parses the start time of the export,
+// either the duration before now with the day unit support (e.g., "7d", "36h"),
+// or the RFC3339 time (e.g., "2024-01-02T15:04:05Z").
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid since %q: %w", s, err)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		d, err = time.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid since %q: %w", s, err)
+		}
+	}
+	if d < 0 {
+		return time.Time{}, fmt.Errorf("invalid since %q: negative duration", s)
+	}
+	return now.Add(-d), nil
+}
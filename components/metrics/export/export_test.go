@@ -0,0 +1,74 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	metrics_state "github.com/leptonai/gpud/components/metrics/state"
+)
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	err := Write(&buf, FormatCSV, metrics_state.Metrics{
+		{UnixSeconds: 1700000000, MetricName: "accelerator_nvidia_temperature_current_celsius", MetricSecondaryName: "GPU-0", Value: 42.5},
+		{UnixSeconds: 1700000060, MetricName: "cpu_used_percent", Value: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `unix_seconds,metric_name,metric_secondary_name,metric_value
+1700000000,accelerator_nvidia_temperature_current_celsius,GPU-0,42.5
+1700000060,cpu_used_percent,,3
+`
+	if buf.String() != want {
+		t.Errorf("unexpected csv:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatParquet, metrics_state.Metrics{{UnixSeconds: 1700000000, MetricName: "cpu_used_percent", Value: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if !bytes.HasPrefix(b, []byte("PAR1")) || !bytes.HasSuffix(b, []byte("PAR1")) {
+		t.Errorf("unexpected parquet file: % x", b)
+	}
+	if err := Write(&buf, "json", nil); err == nil {
+		t.Error("expected error for the unknown format")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "7d", want: now.Add(-7 * 24 * time.Hour)},
+		{in: "36h", want: now.Add(-36 * time.Hour)},
+		{in: "2024-01-02T15:04:05Z", want: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{in: "xd", wantErr: true},
+		{in: "-1h", wantErr: true},
+		{in: "week", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSince(tt.in, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSince(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("ParseSince(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/units"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MaxConsecutivePanics is the number of the consecutive panics of a component method
+// after which the component is disabled (circuit open), so that one buggy collector
+// can neither take down the whole daemon nor keep panicking on every call.
+const MaxConsecutivePanics = 3
+
+const (
+	// StateNameComponentDisabled is the state of the component disabled after the repeated panics.
+	StateNameComponentDisabled = "component_disabled"
+	// EventNameComponentDisabled is the self-health event recorded when the component is disabled.
+	EventNameComponentDisabled = "component_disabled"
+)
+
+// isolate calls the component method, and recovers the panic as an error.
+// The consecutive panics of the same method open the circuit,
+// so that the successful calls of the other methods (e.g., Events)
+// do not hide a method that panics on every call (e.g., States).
+func (w *watchableComponent) isolate(method string, f func() error) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			w.breakerMu.Lock()
+			delete(w.panics, method)
+			w.breakerMu.Unlock()
+			return
+		}
+
+		log.Logger.Errorw("recovered panic in component", "component", w.Component.Name(), "method", method, "panic", r, "stack", string(debug.Stack()))
+		err = fmt.Errorf("component %s %s panicked: %v", w.Component.Name(), method, r)
+
+		w.breakerMu.Lock()
+		defer w.breakerMu.Unlock()
+		if w.panics == nil {
+			w.panics = make(map[string]int)
+		}
+		w.panics[method]++
+		w.lastPanic = fmt.Sprintf("%s: %v", method, r)
+		if w.panics[method] >= MaxConsecutivePanics && w.disabledAt.IsZero() {
+			w.disabledAt = time.Now().UTC()
+			log.Logger.Errorw("disabling component after repeated panics", "component", w.Component.Name(), "method", method, "panics", w.panics[method])
+		}
+	}()
+	return f()
+}
+
+func (w *watchableComponent) disabled() (time.Time, string, bool) {
+	w.breakerMu.RLock()
+	defer w.breakerMu.RUnlock()
+	return w.disabledAt, w.lastPanic, !w.disabledAt.IsZero()
+}
+
+func (w *watchableComponent) disabledStates() ([]components.State, bool) {
+	disabledAt, lastPanic, disabled := w.disabled()
+	if !disabled {
+		return nil, false
+	}
+	return []components.State{
+		{
+			Name:    StateNameComponentDisabled,
+			Healthy: false,
+			Reason:  fmt.Sprintf("component disabled after %d consecutive panics at %s (restart gpud to re-enable)", MaxConsecutivePanics, disabledAt.Format(time.RFC3339)),
+			Error:   lastPanic,
+		},
+	}, true
+}
+
+func (w *watchableComponent) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	if disabledAt, lastPanic, disabled := w.disabled(); disabled {
+		if disabledAt.Before(since) {
+			return nil, nil
+		}
+		return []components.Event{
+			{
+				Time:    metav1.Time{Time: disabledAt},
+				Name:    EventNameComponentDisabled,
+				Type:    components.EventTypeError,
+				Message: fmt.Sprintf("component %s disabled after %d consecutive panics (last %s)", w.Component.Name(), MaxConsecutivePanics, lastPanic),
+			},
+		}, nil
+	}
+
+	var events []components.Event
+	err := w.isolate("Events", func() (err error) {
+		events, err = w.Component.Events(ctx, since)
+		return err
+	})
+	return events, err
+}
+
+func (w *watchableComponent) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	if _, _, disabled := w.disabled(); disabled {
+		return nil, nil
+	}
+
+	var metrics []components.Metric
+	err := w.isolate("Metrics", func() (err error) {
+		metrics, err = w.Component.Metrics(ctx, since)
+		return err
+	})
+
+	// attach the units inferred from the metric names (e.g., "watts" for "_watts"),
+	// unless set by the component
+	for i := range metrics {
+		if metrics[i].Unit == "" {
+			metrics[i].Unit = units.FromMetricName(metrics[i].MetricName)
+		}
+	}
+	return metrics, err
+}
+
+func (w *watchableComponent) Close() error {
+	return w.isolate("Close", w.Component.Close)
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+)
+
+type panickyComponent struct {
+	panic bool
+}
+
+func (c *panickyComponent) Name() string { return "panicky" }
+
+func (c *panickyComponent) States(ctx context.Context) ([]components.State, error) {
+	if c.panic {
+		panic("boom")
+	}
+	return []components.State{{Name: "ok", Healthy: true}}, nil
+}
+
+func (c *panickyComponent) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	return nil, nil
+}
+
+func (c *panickyComponent) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	return nil, nil
+}
+
+func (c *panickyComponent) Close() error { return nil }
+
+func TestWatchableComponentIsolation(t *testing.T) {
+	ctx := context.Background()
+	c := &panickyComponent{panic: true}
+	w := NewWatchableComponent(c)
+
+	// a successful call resets the consecutive panics
+	for i := 0; i < MaxConsecutivePanics-1; i++ {
+		if _, err := w.States(ctx); err == nil {
+			t.Fatal("expected error from panic")
+		}
+	}
+	c.panic = false
+	if _, err := w.States(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	c.panic = true
+	start := time.Now().UTC().Add(-time.Second)
+	for i := 0; i < MaxConsecutivePanics; i++ {
+		if _, err := w.States(ctx); err == nil {
+			t.Fatal("expected error from panic")
+		}
+	}
+
+	// disabled, no longer calls the component
+	states, err := w.States(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states[0].Name != StateNameComponentDisabled || states[0].Healthy {
+		t.Fatalf("unexpected states %+v", states)
+	}
+
+	events, err := w.Events(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Name != EventNameComponentDisabled || events[0].Type != components.EventTypeError {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	events, err = w.Events(ctx, time.Now().UTC().Add(time.Minute))
+	if err != nil || len(events) != 0 {
+		t.Fatalf("expected no events after since, got %+v, %v", events, err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchableComponentIsolationPerMethod(t *testing.T) {
+	ctx := context.Background()
+	c := &panickyComponent{panic: true}
+	w := NewWatchableComponent(c)
+
+	// the healthy Events calls in between do not reset the States panics
+	for i := 0; i < MaxConsecutivePanics; i++ {
+		if _, err := w.States(ctx); err == nil {
+			t.Fatal("expected error from panic")
+		}
+		if _, err := w.Events(ctx, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	states, err := w.States(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states[0].Name != StateNameComponentDisabled || states[0].Error != "States: boom" {
+		t.Fatalf("unexpected states %+v", states)
+	}
+}
@@ -3,8 +3,12 @@ package metrics
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/log"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -194,17 +198,40 @@ func (w *watchableComponent) Unwrap() interface{} {
 	return w.Component
 }
 
+// watchableComponent records the component health metrics,
+// and isolates the component panics (see isolation.go).
 type watchableComponent struct {
 	components.Component
+
+	breakerMu sync.RWMutex
+	// consecutive panics per method
+	panics     map[string]int
+	lastPanic  string
+	disabledAt time.Time
 }
 
 func (w *watchableComponent) States(ctx context.Context) ([]components.State, error) {
-	states, err := w.Component.States(ctx)
+	if states, disabled := w.disabledStates(); disabled {
+		SetUnhealthy(w.Component.Name())
+		return states, nil
+	}
+
+	var states []components.State
+	err := w.isolate("States", func() (err error) {
+		states, err = w.Component.States(ctx)
+		return err
+	})
 	if err != nil {
 		SetUnhealthy(w.Component.Name())
 		return nil, err
 	}
 
+	if components.ValidateStatesEnabled() {
+		if err := components.ValidateStates(w.Component.Name(), states...); err != nil {
+			log.Logger.Warnw("component states do not match the schema", "component", w.Component.Name(), "error", err)
+		}
+	}
+
 	healthy := true
 	for _, state := range states {
 		if !state.Healthy {
@@ -320,6 +320,49 @@ func EMASince(ctx context.Context, db *sql.DB, tableName string, name string, se
 	return ema.Float64, nil
 }
 
+// ReadAllMetricsSince reads all the metrics since the given time,
+// ordered by the time and the metric names (e.g., to export the stored metrics).
+// Returns nil if no record is found ("database/sql.ErrNoRows").
+func ReadAllMetricsSince(ctx context.Context, db *sql.DB, tableName string, since time.Time) (Metrics, error) {
+	query := fmt.Sprintf(`
+SELECT %s, %s, COALESCE(%s, ''), %s
+FROM %s
+WHERE %s >= ?
+ORDER BY %s ASC, %s ASC, %s ASC;`,
+		ColumnUnixSeconds,
+		ColumnMetricName,
+		ColumnMetricSecondaryName,
+		ColumnMetricValue,
+		tableName,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+		ColumnMetricName,
+		ColumnMetricSecondaryName,
+	)
+
+	queryRows, err := db.QueryContext(ctx, query, since.Unix())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer queryRows.Close()
+
+	rows := make(Metrics, 0)
+	for queryRows.Next() {
+		var metric Metric
+		if err := queryRows.Scan(&metric.UnixSeconds, &metric.MetricName, &metric.MetricSecondaryName, &metric.Value); err != nil {
+			return nil, err
+		}
+		rows = append(rows, metric)
+	}
+	if err := queryRows.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
 func PurgeMetrics(ctx context.Context, db *sql.DB, tableName string, before time.Time) (int, error) {
 	query := fmt.Sprintf(`
 DELETE FROM %s WHERE %s < ?;`, tableName, ColumnUnixSeconds)
@@ -333,3 +376,26 @@ DELETE FROM %s WHERE %s < ?;`, tableName, ColumnUnixSeconds)
 	}
 	return int(affected), nil
 }
+
+// RenameMetric re-keys the rows of the metric recorded under its old name (e.g., before the unit rename),
+// scaling the values by the factor (e.g., 1e-3 from milliwatts to watts).
+// The rows already recorded under the new name at the same time are replaced.
+// Returns the number of the renamed rows (zero once migrated).
+func RenameMetric(ctx context.Context, db *sql.DB, tableName string, oldName string, newName string, factor float64) (int, error) {
+	query := fmt.Sprintf(`
+UPDATE OR REPLACE %s SET %s = ?, %s = %s * ? WHERE %s = ?;`,
+		tableName,
+		ColumnMetricName,
+		ColumnMetricValue, ColumnMetricValue,
+		ColumnMetricName,
+	)
+	rs, err := db.ExecContext(ctx, query, newName, factor, oldName)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
@@ -305,3 +305,110 @@ func TestStateMoreDataPoints(t *testing.T) {
 		}
 	}
 }
+
+func TestReadAllMetricsSince(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tableName := "test_metrics"
+	if err := CreateTableMetrics(ctx, db, tableName); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	for _, m := range []Metric{
+		{UnixSeconds: now.Unix(), MetricName: "b", MetricSecondaryName: "GPU-0", Value: 2},
+		{UnixSeconds: now.Unix(), MetricName: "a", Value: 1},
+		{UnixSeconds: now.Add(-time.Hour).Unix(), MetricName: "a", Value: 0},
+		{UnixSeconds: now.Add(time.Minute).Unix(), MetricName: "a", Value: 3},
+	} {
+		if err := InsertMetric(ctx, db, tableName, m); err != nil {
+			t.Fatalf("failed to insert metric: %v", err)
+		}
+	}
+
+	metrics, err := ReadAllMetricsSince(ctx, db, tableName, now)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	want := Metrics{
+		{UnixSeconds: now.Unix(), MetricName: "a", Value: 1},
+		{UnixSeconds: now.Unix(), MetricName: "b", MetricSecondaryName: "GPU-0", Value: 2},
+		{UnixSeconds: now.Add(time.Minute).Unix(), MetricName: "a", Value: 3},
+	}
+	if len(metrics) != len(want) {
+		t.Fatalf("expected %d metrics, got %+v", len(want), metrics)
+	}
+	for i := range want {
+		if metrics[i] != want[i] {
+			t.Errorf("metric %d: expected %+v, got %+v", i, want[i], metrics[i])
+		}
+	}
+}
+
+func TestRenameMetric(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tableName := "test_metrics"
+	if err := CreateTableMetrics(ctx, db, tableName); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	now := time.Now()
+	for _, m := range []Metric{
+		{UnixSeconds: now.Unix() - 2, MetricName: "power_milli_watts", MetricSecondaryName: "GPU-0", Value: 300000},
+		{UnixSeconds: now.Unix() - 1, MetricName: "power_milli_watts", MetricSecondaryName: "GPU-0", Value: 250000},
+		{UnixSeconds: now.Unix(), MetricName: "power_watts", MetricSecondaryName: "GPU-0", Value: 200},
+		{UnixSeconds: now.Unix(), MetricName: "other", MetricSecondaryName: "GPU-0", Value: 1},
+	} {
+		if err := InsertMetric(ctx, db, tableName, m); err != nil {
+			t.Fatalf("failed to insert metric: %v", err)
+		}
+	}
+
+	renamed, err := RenameMetric(ctx, db, tableName, "power_milli_watts", "power_watts", 1e-3)
+	if err != nil {
+		t.Fatalf("failed to rename metric: %v", err)
+	}
+	if renamed != 2 {
+		t.Errorf("expected 2 renamed rows, got %d", renamed)
+	}
+
+	ms, err := ReadMetricsSince(ctx, db, tableName, "power_watts", "GPU-0", now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	if len(ms) != 3 {
+		t.Fatalf("expected 3 metrics, got %d", len(ms))
+	}
+	for i, want := range []float64{300, 250, 200} {
+		if math.Abs(ms[i].Value-want) > 1e-9 {
+			t.Errorf("metric %d: expected %v, got %v", i, want, ms[i].Value)
+		}
+	}
+
+	renamed, err = RenameMetric(ctx, db, tableName, "power_milli_watts", "power_watts", 1e-3)
+	if err != nil {
+		t.Fatalf("failed to rename metric: %v", err)
+	}
+	if renamed != 0 {
+		t.Errorf("expected no renamed rows once migrated, got %d", renamed)
+	}
+}
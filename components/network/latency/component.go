@@ -83,9 +83,9 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
-	edgeLatencies, err := metrics.ReadEdgeInMilliseconds(ctx, since)
+	edgeLatencies, err := metrics.ReadEdgeSeconds(ctx, since)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read running pids: %w", err)
+		return nil, fmt.Errorf("failed to read edge latencies: %w", err)
 	}
 
 	ms := make([]components.Metric, 0, len(edgeLatencies))
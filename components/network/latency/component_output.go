@@ -15,6 +15,7 @@ import (
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/pkg/latency"
 	latency_edge "github.com/leptonai/gpud/pkg/latency/edge"
+	"github.com/leptonai/gpud/pkg/units"
 )
 
 type Output struct {
@@ -42,6 +43,12 @@ const (
 	StateKeyLatencyEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameLatency, "global network connectivity statistics"),
+	)
+}
+
 func ParseStateLatency(m map[string]string) (*Output, error) {
 	data := m[StateKeyLatencyData]
 	return ParseOutputJSON([]byte(data))
@@ -149,10 +156,10 @@ func createGetFunc(cfg Config) query.GetFunc {
 		}
 
 		for _, latency := range o.EgressLatencies {
-			if err := metrics.SetEdgeInMilliseconds(
+			if err := metrics.SetEdgeSeconds(
 				ctx,
 				fmt.Sprintf("%s (%s)", latency.RegionName, latency.Provider),
-				float64(latency.LatencyMilliseconds),
+				units.MillisecondsToSeconds(float64(latency.LatencyMilliseconds)),
 				now,
 			); err != nil {
 				return nil, err
@@ -24,7 +24,19 @@ var (
 		},
 	)
 
-	edgeInMilliseconds = prometheus.NewGaugeVec(
+	edgeSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "edge_seconds",
+			Help:      "tracks the edge latency in seconds",
+		},
+		[]string{"provider_region"},
+	)
+	edgeSecondsAverager = components_metrics.NewNoOpAverager()
+
+	// the metric in milliseconds before the SI unit rename, still exported for the existing dashboards
+	edgeInMilliseconds = components_metrics.NewDeprecatedGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "",
 			Subsystem: SubSystem,
@@ -32,28 +44,36 @@ var (
 			Help:      "tracks the edge latency in milliseconds",
 		},
 		[]string{"provider_region"},
+		SubSystem+"_edge_seconds",
+		1e3,
 	)
-	edgeInMillisecondsAverager = components_metrics.NewNoOpAverager()
 )
 
+// renamedMetrics are the averager metric names before the SI unit rename,
+// to re-key the rows recorded by the previous versions.
+var renamedMetrics = []components_metrics.RenamedMetric{
+	{From: SubSystem + "_edge_in_milliseconds", To: SubSystem + "_edge_seconds", Factor: 1e-3},
+}
+
 func InitAveragers(db *sql.DB, tableName string) {
-	edgeInMillisecondsAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_edge_in_milliseconds")
+	edgeSecondsAverager = components_metrics.NewAverager(db, tableName, SubSystem+"_edge_seconds")
 }
 
-func ReadEdgeInMilliseconds(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
-	return edgeInMillisecondsAverager.Read(ctx, components_metrics.WithSince(since))
+func ReadEdgeSeconds(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
+	return edgeSecondsAverager.Read(ctx, components_metrics.WithSince(since))
 }
 
 func SetLastUpdateUnixSeconds(unixSeconds float64) {
 	lastUpdateUnixSeconds.Set(unixSeconds)
 }
 
-func SetEdgeInMilliseconds(ctx context.Context, providerRegion string, latencyInMilliseconds float64, currentTime time.Time) error {
-	edgeInMilliseconds.WithLabelValues(providerRegion).Set(latencyInMilliseconds)
+func SetEdgeSeconds(ctx context.Context, providerRegion string, seconds float64, currentTime time.Time) error {
+	edgeSeconds.WithLabelValues(providerRegion).Set(seconds)
+	edgeInMilliseconds.Set(seconds, providerRegion)
 
-	if err := edgeInMillisecondsAverager.Observe(
+	if err := edgeSecondsAverager.Observe(
 		ctx,
-		latencyInMilliseconds,
+		seconds,
 		components_metrics.WithMetricSecondaryName(providerRegion),
 	); err != nil {
 		return err
@@ -63,11 +83,17 @@ func SetEdgeInMilliseconds(ctx context.Context, providerRegion string, latencyIn
 }
 
 func Register(reg *prometheus.Registry, db *sql.DB, tableName string) error {
+	if err := components_metrics.MigrateRenamedMetrics(db, tableName, renamedMetrics...); err != nil {
+		return err
+	}
 	InitAveragers(db, tableName)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
 	}
+	if err := reg.Register(edgeSeconds); err != nil {
+		return err
+	}
 	if err := reg.Register(edgeInMilliseconds); err != nil {
 		return err
 	}
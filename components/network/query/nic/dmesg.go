@@ -0,0 +1,128 @@
+// Package nic contains the dmesg rules for the common NIC driver failures (mlx5, bnxt),
+// since the fabric NIC faults break the training jobs as badly as the GPU faults.
+package nic
+
+import (
+	"github.com/leptonai/gpud/components/common"
+)
+
+const (
+	// e.g.,
+	// mlx5_core 0000:5e:00.0: poll_health:835:(pid 0): Fatal error 1 detected
+	// mlx5_core 0000:5e:00.0: mlx5_fw_fatal_reporter_err_work:1898:(pid 1234): Driver is in error state. Unloading
+	// mlx5_core 0000:5e:00.0: print_health_info:430:(pid 0): device's health compromised - reached miss count
+	EventMlx5FirmwareFatal = "nic_mlx5_firmware_fatal"
+	RegexMlx5FirmwareFatal = `mlx5_core .*(Fatal error \d+ detected|health compromised|mlx5_fw_fatal_reporter_err_work|Driver is in error state)`
+
+	// e.g.,
+	// mlx5_core 0000:5e:00.0: wait_func:1102:(pid 1234): ACCESS_REG(0x805) timeout. Will cause a leak of a command resource
+	// mlx5_core 0000:5e:00.0: mlx5_cmd_comp_handler:1650:(pid 0): Command completion arrived after timeout (entry idx = 0)
+	EventMlx5CommandTimeout = "nic_mlx5_command_timeout"
+	RegexMlx5CommandTimeout = `mlx5_core .*(\(0x[0-9a-fA-F]+\) timeout|Command completion arrived after timeout)`
+
+	// e.g.,
+	// mlx5_core 0000:5e:00.0 eth2: mlx5e_tx_timeout:4675:(pid 1234): TX timeout detected
+	// NETDEV WATCHDOG: eth2 (mlx5_core): transmit queue 7 timed out
+	// bnxt_en 0000:41:00.0 eth0: TX timeout detected, starting reset task!
+	EventInterfaceReset = "nic_interface_reset"
+	RegexInterfaceReset = `(NETDEV WATCHDOG: .* transmit queue \d+ timed out|mlx5e_tx_timeout|bnxt_en .*TX timeout detected|bnxt_en .*(Resetting|reset) (device|task))`
+
+	// e.g.,
+	// mlx5_0:dump_cqe:272:(pid 1234): dump error cqe
+	// mlx5_core 0000:5e:00.0 eth2: Error cqe on cqn 0x41e, ci 0x2, qn 0x1a8b, opcode 0xd, syndrome 0x4, vendor syndrome 0x32
+	// bnxt_re0: bnxt_re_process_req_wc: ... CQE error
+	EventCQEError = "nic_cqe_error"
+	RegexCQEError = `(?i)(dump error cqe|error cqe on cqn|cqe error)`
+
+	// e.g.,
+	// bnxt_en 0000:41:00.0 eth0: Firmware fatal reset event received
+	// bnxt_en 0000:41:00.0: Firmware not responding, status: 0x5
+	// bnxt_en 0000:41:00.0 eth0: Error (timeout: 500015) msg {0xb4 0x2cb} len:0
+	EventBnxtFirmwareFailure = "nic_bnxt_firmware_failure"
+	RegexBnxtFirmwareFailure = `bnxt_en .*(Firmware fatal|Firmware not responding|Firmware exception|Error \(timeout: \d+\) msg)`
+)
+
+// Rule maps a NIC driver failure in dmesg to its severity and the suggested actions.
+type Rule struct {
+	Name  string
+	Regex string
+
+	Description string
+	// Critical is true if the failure breaks the NIC until the repair
+	// (thus marks the component unhealthy), otherwise the failure is transient.
+	Critical bool
+	// SuggestedActions is nil if no repair action is required.
+	SuggestedActions *common.SuggestedActions
+}
+
+// Rules is the rule set for the common NIC driver failures.
+var Rules = []Rule{
+	{
+		Name:        EventMlx5FirmwareFatal,
+		Regex:       RegexMlx5FirmwareFatal,
+		Description: "mlx5 NIC firmware fatal error (NIC unusable until the driver reloads)",
+		Critical:    true,
+		SuggestedActions: &common.SuggestedActions{
+			Descriptions: []string{
+				"reboot the system to recover the mlx5 NIC firmware",
+				"inspect the NIC (firmware version, cabling, transceiver) if the firmware fatal error recurs",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRebootSystem,
+				common.RepairActionTypeHardwareInspection,
+			},
+		},
+	},
+	{
+		Name:        EventMlx5CommandTimeout,
+		Regex:       RegexMlx5CommandTimeout,
+		Description: "mlx5 NIC firmware command timeout (firmware not responding)",
+		Critical:    true,
+		SuggestedActions: &common.SuggestedActions{
+			Descriptions: []string{
+				"reboot the system to recover the unresponsive mlx5 NIC firmware",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRebootSystem,
+			},
+		},
+	},
+	{
+		Name:        EventInterfaceReset,
+		Regex:       RegexInterfaceReset,
+		Description: "NIC transmit queue timeout and interface reset (in-flight traffic dropped)",
+		Critical:    false,
+	},
+	{
+		Name:        EventCQEError,
+		Regex:       RegexCQEError,
+		Description: "RDMA completion queue entry (CQE) error (e.g., remote access or transport retry exceeded)",
+		Critical:    false,
+	},
+	{
+		Name:        EventBnxtFirmwareFailure,
+		Regex:       RegexBnxtFirmwareFailure,
+		Description: "bnxt NIC firmware failure (firmware reset or not responding)",
+		Critical:    true,
+		SuggestedActions: &common.SuggestedActions{
+			Descriptions: []string{
+				"reboot the system to recover the bnxt NIC firmware",
+				"inspect the NIC (firmware version, cabling, transceiver) if the firmware failure recurs",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRebootSystem,
+				common.RepairActionTypeHardwareInspection,
+			},
+		},
+	},
+}
+
+// GetRule returns the rule by the event name.
+func GetRule(name string) (Rule, bool) {
+	for _, r := range Rules {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,66 @@
+package nic
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		log  string
+		want string
+	}{
+		{"mlx5_core 0000:5e:00.0: poll_health:835:(pid 0): Fatal error 1 detected", EventMlx5FirmwareFatal},
+		{"mlx5_core 0000:5e:00.0: print_health_info:430:(pid 0): device's health compromised - reached miss count", EventMlx5FirmwareFatal},
+		{"mlx5_core 0000:5e:00.0: mlx5_fw_fatal_reporter_err_work:1898:(pid 1234): Driver is in error state. Unloading", EventMlx5FirmwareFatal},
+		{"mlx5_core 0000:5e:00.0: wait_func:1102:(pid 1234): ACCESS_REG(0x805) timeout. Will cause a leak of a command resource", EventMlx5CommandTimeout},
+		{"mlx5_core 0000:5e:00.0: mlx5_cmd_comp_handler:1650:(pid 0): Command completion arrived after timeout (entry idx = 0)", EventMlx5CommandTimeout},
+		{"mlx5_core 0000:5e:00.0 eth2: mlx5e_tx_timeout:4675:(pid 1234): TX timeout detected", EventInterfaceReset},
+		{"NETDEV WATCHDOG: eth2 (mlx5_core): transmit queue 7 timed out", EventInterfaceReset},
+		{"bnxt_en 0000:41:00.0 eth0: TX timeout detected, starting reset task!", EventInterfaceReset},
+		{"mlx5_0:dump_cqe:272:(pid 1234): dump error cqe", EventCQEError},
+		{"mlx5_core 0000:5e:00.0 eth2: Error cqe on cqn 0x41e, ci 0x2, qn 0x1a8b, opcode 0xd, syndrome 0x4, vendor syndrome 0x32", EventCQEError},
+		{"bnxt_en 0000:41:00.0 eth0: Firmware fatal reset event received", EventBnxtFirmwareFailure},
+		{"bnxt_en 0000:41:00.0: Firmware not responding, status: 0x5", EventBnxtFirmwareFailure},
+		{"bnxt_en 0000:41:00.0 eth0: Error (timeout: 500015) msg {0xb4 0x2cb} len:0", EventBnxtFirmwareFailure},
+		{"mlx5_core 0000:5e:00.0: firmware version: 28.39.1002", ""},
+		{"mlx5_core 0000:5e:00.0 eth2: Link up", ""},
+		{"bnxt_en 0000:41:00.0 eth0: NIC Link is Up, 100000 Mbps full duplex", ""},
+	}
+
+	res := make(map[string]*regexp.Regexp, len(Rules))
+	for _, r := range Rules {
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			t.Fatalf("failed to compile %q: %v", r.Name, err)
+		}
+		res[r.Name] = re
+	}
+
+	for _, tt := range tests {
+		matched := ""
+		for _, r := range Rules {
+			if res[r.Name].MatchString(tt.log) {
+				if matched != "" {
+					t.Errorf("%q matched both %q and %q", tt.log, matched, r.Name)
+				}
+				matched = r.Name
+			}
+		}
+		if matched != tt.want {
+			t.Errorf("%q matched %q, want %q", tt.log, matched, tt.want)
+		}
+	}
+}
+
+func TestGetRule(t *testing.T) {
+	r, ok := GetRule(EventMlx5FirmwareFatal)
+	if !ok || !r.Critical || r.SuggestedActions == nil {
+		t.Errorf("unexpected rule %+v", r)
+	}
+	if _, ok := GetRule("unknown"); ok {
+		t.Error("expected no rule for unknown event")
+	}
+}
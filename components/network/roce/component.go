@@ -0,0 +1,199 @@
+// Package roce monitors the RoCE (RDMA over Converged Ethernet) fabric congestion,
+// using the per-priority PFC pause frames and the ECN marked packets from the NIC counters.
+// Fabric congestion often shows up as unexplained NCCL slowdowns.
+package roce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/dmesg"
+	network_query_nic "github.com/leptonai/gpud/components/network/query/nic"
+	network_roce_id "github.com/leptonai/gpud/components/network/roce/id"
+	"github.com/leptonai/gpud/components/network/roce/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const Name = network_roce_id.Name
+
+func New(ctx context.Context, cfg Config) components.Component {
+	cfg.SetDefaultsIfNotSet()
+	setDefaultPoller(cfg)
+
+	cctx, ccancel := context.WithCancel(ctx)
+	getDefaultPoller().Start(cctx, cfg.Query, Name)
+
+	return &component{
+		rootCtx: ctx,
+		cancel:  ccancel,
+		poller:  getDefaultPoller(),
+		cfg:     cfg,
+	}
+}
+
+var _ components.Component = (*component)(nil)
+
+type component struct {
+	rootCtx  context.Context
+	cancel   context.CancelFunc
+	poller   query.Poller
+	gatherer prometheus.Gatherer
+	cfg      Config
+}
+
+func (c *component) Name() string { return Name }
+
+func (c *component) States(ctx context.Context) ([]components.State, error) {
+	states, err := c.pollerStates()
+	if err != nil {
+		return nil, err
+	}
+
+	lookback := c.cfg.DriverErrorsLookbackPeriod.Duration
+	evs, err := c.Events(ctx, time.Now().Add(-lookback))
+	if err != nil {
+		return nil, err
+	}
+	return append(states, DriverErrorsState(evs, lookback)), nil
+}
+
+func (c *component) pollerStates() ([]components.State, error) {
+	last, err := c.poller.Last()
+	if err == query.ErrNoData { // no data
+		log.Logger.Debugw("nothing found in last state (no data collected yet)", "component", Name)
+		return []components.State{
+			{
+				Name:    Name,
+				Healthy: true,
+				Reason:  query.ErrNoData.Error(),
+			},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if last.Error != nil {
+		return []components.State{
+			{
+				Name:    Name,
+				Healthy: false,
+				Error:   last.Error.Error(),
+				Reason:  "last query failed",
+			},
+		}, nil
+	}
+	if last.Output == nil {
+		return []components.State{
+			{
+				Name:    Name,
+				Healthy: true,
+				Reason:  "no output",
+			},
+		}, nil
+	}
+
+	output, ok := last.Output.(*Output)
+	if !ok {
+		return nil, fmt.Errorf("invalid output type: %T", last.Output)
+	}
+	return output.States(c.cfg)
+}
+
+const (
+	EventKeyDriverErrorUnixSeconds = "unix_seconds"
+	EventKeyDriverErrorLogLine     = "log_line"
+)
+
+// Events returns the NIC driver failures from dmesg.
+// Returns no event if the dmesg component is not enabled.
+func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	dmesgC, err := components.GetComponent(dmesg.Name)
+	if err != nil {
+		log.Logger.Debugw("dmesg component not found -- skipping nic driver errors", "error", err)
+		return nil, nil
+	}
+
+	var dmesgComponent *dmesg.Component
+	if o, ok := dmesgC.(interface{ Unwrap() interface{} }); ok {
+		if unwrapped, ok := o.Unwrap().(*dmesg.Component); ok {
+			dmesgComponent = unwrapped
+		} else {
+			return nil, fmt.Errorf("expected *dmesg.Component, got %T", dmesgC)
+		}
+	}
+	if dmesgComponent == nil {
+		return nil, nil
+	}
+	dmesgTailResults, err := dmesgComponent.TailScan()
+	if err != nil {
+		return nil, err
+	}
+
+	// dedup by minute level per rule, since a single failure often logs many lines
+	seenMinute := make(map[string]struct{})
+	events := make([]components.Event, 0)
+	for _, logItem := range dmesgTailResults.TailScanMatched {
+		if logItem.Error != nil || logItem.Matched == nil {
+			continue
+		}
+		if logItem.Time.Time.Before(since) {
+			continue
+		}
+		rule, ok := network_query_nic.GetRule(logItem.Matched.Name)
+		if !ok {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%d", rule.Name, logItem.Time.Unix()/60)
+		if _, ok := seenMinute[key]; ok {
+			continue
+		}
+		seenMinute[key] = struct{}{}
+
+		ev := components.Event{
+			Time:    logItem.Time,
+			Name:    rule.Name,
+			Type:    components.EventTypeWarn,
+			Message: rule.Description,
+			ExtraInfo: map[string]string{
+				EventKeyDriverErrorUnixSeconds: strconv.FormatInt(logItem.Time.Unix(), 10),
+				EventKeyDriverErrorLogLine:     logItem.Line,
+			},
+			SuggestedActions: rule.SuggestedActions,
+		}
+		if rule.Critical {
+			ev.Type = components.EventTypeError
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	log.Logger.Debugw("querying metrics", "since", since)
+
+	return nil, nil
+}
+
+func (c *component) Close() error {
+	log.Logger.Debugw("closing component")
+
+	// safe to call stop multiple times
+	c.poller.Stop(Name)
+
+	return nil
+}
+
+var _ components.PromRegisterer = (*component)(nil)
+
+func (c *component) RegisterCollectors(reg *prometheus.Registry, db *sql.DB, tableName string) error {
+	c.gatherer = reg
+	return metrics.Register(reg)
+}
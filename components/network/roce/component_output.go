@@ -0,0 +1,278 @@
+package roce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/network/roce/metrics"
+	"github.com/leptonai/gpud/components/query"
+)
+
+type Output struct {
+	// Ports is the list of the RoCE port congestion rates,
+	// computed from the counters between the two consecutive polls.
+	Ports []PortRates `json:"ports"`
+}
+
+// PortRates is the per-second congestion counter rates of a RoCE port.
+type PortRates struct {
+	Device    string `json:"device"`
+	Port      int    `json:"port"`
+	Interface string `json:"interface,omitempty"`
+
+	// RxPauseFramesPerSecond is the received PFC pause frames per second per priority.
+	// Non-zero means the link partner (switch) is congested and asks this port to stop.
+	RxPauseFramesPerSecond map[int]float64 `json:"rx_pause_frames_per_second,omitempty"`
+	// TxPauseFramesPerSecond is the transmitted PFC pause frames per second per priority.
+	// Non-zero means this port is congested and asks the link partner to stop.
+	TxPauseFramesPerSecond map[int]float64 `json:"tx_pause_frames_per_second,omitempty"`
+
+	ECNMarkedPacketsPerSecond float64 `json:"ecn_marked_packets_per_second"`
+	CNPSentPerSecond          float64 `json:"cnp_sent_per_second"`
+	CNPHandledPerSecond       float64 `json:"cnp_handled_per_second"`
+}
+
+func (p PortRates) ID() string {
+	return p.Device + "/" + strconv.Itoa(p.Port)
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameRoCE = "network-roce"
+
+	StateKeyRoCEData         = "data"
+	StateKeyRoCEEncoding     = "encoding"
+	StateKeyRoCEEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameRoCE, "RoCE NIC configuration and counters"),
+	)
+}
+
+func ParseStateRoCE(m map[string]string) (*Output, error) {
+	data := m[StateKeyRoCEData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameRoCE:
+			o, err := ParseStateRoCE(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		case StateNameDriverErrors:
+			continue
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no roce state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate(cfg Config) (string, bool) {
+	if len(o.Ports) == 0 {
+		return "no roce port found", true
+	}
+
+	unhealthyReasons := []string{}
+	for _, p := range o.Ports {
+		if cfg.PauseFramesPerSecondThreshold > 0 {
+			for _, prio := range sortedPriorities(p.RxPauseFramesPerSecond) {
+				if v := p.RxPauseFramesPerSecond[prio]; v >= cfg.PauseFramesPerSecondThreshold {
+					unhealthyReasons = append(unhealthyReasons, fmt.Sprintf("%s received %.1f pause frames/s on priority %d (threshold %.1f)", p.ID(), v, prio, cfg.PauseFramesPerSecondThreshold))
+				}
+			}
+			for _, prio := range sortedPriorities(p.TxPauseFramesPerSecond) {
+				if v := p.TxPauseFramesPerSecond[prio]; v >= cfg.PauseFramesPerSecondThreshold {
+					unhealthyReasons = append(unhealthyReasons, fmt.Sprintf("%s sent %.1f pause frames/s on priority %d (threshold %.1f)", p.ID(), v, prio, cfg.PauseFramesPerSecondThreshold))
+				}
+			}
+		}
+		if cfg.ECNMarkedPacketsPerSecondThreshold > 0 && p.ECNMarkedPacketsPerSecond >= cfg.ECNMarkedPacketsPerSecondThreshold {
+			unhealthyReasons = append(unhealthyReasons, fmt.Sprintf("%s received %.1f ecn marked packets/s (threshold %.1f)", p.ID(), p.ECNMarkedPacketsPerSecond, cfg.ECNMarkedPacketsPerSecondThreshold))
+		}
+	}
+
+	if len(unhealthyReasons) > 0 {
+		return "fabric congestion detected: " + strings.Join(unhealthyReasons, "; "), false
+	}
+	return fmt.Sprintf("no fabric congestion detected (%d roce port(s))", len(o.Ports)), true
+}
+
+func (o *Output) States(cfg Config) ([]components.State, error) {
+	reason, healthy := o.Evaluate(cfg)
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameRoCE,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyRoCEData:     string(b),
+			StateKeyRoCEEncoding: StateKeyRoCEEncodingJSON,
+		},
+	}
+	return []components.State{state}, nil
+}
+
+func sortedPriorities(m map[int]float64) []int {
+	prios := make([]int, 0, len(m))
+	for prio := range m {
+		prios = append(prios, prio)
+	}
+	sort.Ints(prios)
+	return prios
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the previous counters
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, createGetFunc(cfg))
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+type sample struct {
+	ts       time.Time
+	counters map[string]PortCounters
+}
+
+var (
+	prevMu sync.RWMutex
+	prev   *sample
+)
+
+func createGetFunc(cfg Config) query.GetFunc {
+	classDir := cfg.InfinibandClassDir
+	if classDir == "" {
+		classDir = DefaultInfinibandClassDir
+	}
+
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		now := time.Now().UTC()
+		metrics.SetLastUpdateUnixSeconds(float64(now.Unix()))
+
+		cctx, ccancel := context.WithTimeout(ctx, 30*time.Second)
+		counters, err := ReadPortCounters(cctx, classDir)
+		ccancel()
+		if err != nil {
+			return nil, err
+		}
+
+		cur := &sample{ts: now, counters: make(map[string]PortCounters, len(counters))}
+		for _, c := range counters {
+			cur.counters[c.ID()] = c
+		}
+
+		prevMu.Lock()
+		last := prev
+		prev = cur
+		prevMu.Unlock()
+
+		o := &Output{}
+		if last == nil {
+			// rates require two samples
+			return o, nil
+		}
+		for _, c := range counters {
+			p, ok := last.counters[c.ID()]
+			if !ok {
+				continue
+			}
+			rates := computeRates(p, c, now.Sub(last.ts))
+			o.Ports = append(o.Ports, rates)
+
+			for prio, v := range rates.RxPauseFramesPerSecond {
+				metrics.SetPauseFramesPerSecond(rates.ID(), "rx", strconv.Itoa(prio), v)
+			}
+			for prio, v := range rates.TxPauseFramesPerSecond {
+				metrics.SetPauseFramesPerSecond(rates.ID(), "tx", strconv.Itoa(prio), v)
+			}
+			metrics.SetECNMarkedPacketsPerSecond(rates.ID(), rates.ECNMarkedPacketsPerSecond)
+			metrics.SetCNPSentPerSecond(rates.ID(), rates.CNPSentPerSecond)
+		}
+		return o, nil
+	}
+}
+
+// computeRates computes the per-second rates between two counter samples.
+// Counter resets (e.g., driver reload) are treated as zero rate.
+func computeRates(prev PortCounters, cur PortCounters, elapsed time.Duration) PortRates {
+	r := PortRates{
+		Device:    cur.Device,
+		Port:      cur.Port,
+		Interface: cur.Interface,
+	}
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return r
+	}
+
+	rate := func(p, c uint64) float64 {
+		if c < p {
+			return 0
+		}
+		return float64(c-p) / secs
+	}
+
+	if len(cur.RxPauseFrames) > 0 {
+		r.RxPauseFramesPerSecond = make(map[int]float64, len(cur.RxPauseFrames))
+		for prio, c := range cur.RxPauseFrames {
+			r.RxPauseFramesPerSecond[prio] = rate(prev.RxPauseFrames[prio], c)
+		}
+	}
+	if len(cur.TxPauseFrames) > 0 {
+		r.TxPauseFramesPerSecond = make(map[int]float64, len(cur.TxPauseFrames))
+		for prio, c := range cur.TxPauseFrames {
+			r.TxPauseFramesPerSecond[prio] = rate(prev.TxPauseFrames[prio], c)
+		}
+	}
+	r.ECNMarkedPacketsPerSecond = rate(prev.ECNMarkedPackets, cur.ECNMarkedPackets)
+	r.CNPSentPerSecond = rate(prev.CNPSent, cur.CNPSent)
+	r.CNPHandledPerSecond = rate(prev.CNPHandled, cur.CNPHandled)
+	return r
+}
@@ -0,0 +1,87 @@
+package roce
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultPauseFramesPerSecondThreshold is the default threshold of the PFC pause frames
+	// per second (per priority, either direction) to report the fabric congestion.
+	DefaultPauseFramesPerSecondThreshold = 1000
+	// DefaultECNMarkedPacketsPerSecondThreshold is the default threshold of the ECN marked
+	// RoCE packets per second to report the fabric congestion.
+	DefaultECNMarkedPacketsPerSecondThreshold = 10000
+	// DefaultDriverErrorsLookbackPeriod is the default period to look back
+	// for the NIC driver failures in dmesg.
+	DefaultDriverErrorsLookbackPeriod = 24 * time.Hour
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// InfinibandClassDir is the sysfs directory for the RDMA devices.
+	// If not set, it defaults to "/sys/class/infiniband".
+	InfinibandClassDir string `json:"infiniband_class_dir"`
+
+	// PauseFramesPerSecondThreshold is the threshold of the PFC pause frames
+	// per second for any priority, in either direction.
+	// If not set, it defaults to DefaultPauseFramesPerSecondThreshold.
+	PauseFramesPerSecondThreshold float64 `json:"pause_frames_per_second_threshold"`
+
+	// ECNMarkedPacketsPerSecondThreshold is the threshold of the ECN marked RoCE packets per second.
+	// If not set, it defaults to DefaultECNMarkedPacketsPerSecondThreshold.
+	ECNMarkedPacketsPerSecondThreshold float64 `json:"ecn_marked_packets_per_second_threshold"`
+
+	// DriverErrorsLookbackPeriod is the period to look back for the NIC driver failures
+	// (e.g., mlx5 firmware fatal errors) in dmesg. Defaults to 24 hours.
+	DriverErrorsLookbackPeriod metav1.Duration `json:"driver_errors_lookback_period"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	cfg.Query.SetDefaultsIfNotSet()
+	if cfg.PauseFramesPerSecondThreshold == 0 {
+		cfg.PauseFramesPerSecondThreshold = DefaultPauseFramesPerSecondThreshold
+	}
+	if cfg.ECNMarkedPacketsPerSecondThreshold == 0 {
+		cfg.ECNMarkedPacketsPerSecondThreshold = DefaultECNMarkedPacketsPerSecondThreshold
+	}
+	if cfg.DriverErrorsLookbackPeriod.Duration == 0 {
+		cfg.DriverErrorsLookbackPeriod = metav1.Duration{Duration: DefaultDriverErrorsLookbackPeriod}
+	}
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	if cfg.PauseFramesPerSecondThreshold < 0 {
+		return fmt.Errorf("pause frames per second threshold must be non-negative, got %f", cfg.PauseFramesPerSecondThreshold)
+	}
+	if cfg.ECNMarkedPacketsPerSecondThreshold < 0 {
+		return fmt.Errorf("ecn marked packets per second threshold must be non-negative, got %f", cfg.ECNMarkedPacketsPerSecondThreshold)
+	}
+	if cfg.DriverErrorsLookbackPeriod.Duration < 0 {
+		return fmt.Errorf("driver errors lookback period must be non-negative, got %v", cfg.DriverErrorsLookbackPeriod.Duration)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package roce
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigSetDefaultsIfNotSet(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        map[string]interface{}
+		wantPause    float64
+		wantECN      float64
+		wantLookback time.Duration
+		wantClassDir string
+		wantValidErr bool
+	}{
+		{
+			name:         "no threshold set",
+			input:        map[string]interface{}{"infiniband_class_dir": "/tmp/infiniband"},
+			wantPause:    DefaultPauseFramesPerSecondThreshold,
+			wantECN:      DefaultECNMarkedPacketsPerSecondThreshold,
+			wantLookback: DefaultDriverErrorsLookbackPeriod,
+			wantClassDir: "/tmp/infiniband",
+		},
+		{
+			name: "thresholds set",
+			input: map[string]interface{}{
+				"pause_frames_per_second_threshold":       500,
+				"ecn_marked_packets_per_second_threshold": 2000,
+				"driver_errors_lookback_period":           "1h",
+			},
+			wantPause:    500,
+			wantECN:      2000,
+			wantLookback: time.Hour,
+		},
+		{
+			name:         "negative threshold",
+			input:        map[string]interface{}{"pause_frames_per_second_threshold": -1},
+			wantPause:    -1,
+			wantECN:      DefaultECNMarkedPacketsPerSecondThreshold,
+			wantLookback: DefaultDriverErrorsLookbackPeriod,
+			wantValidErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(tt.input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg.SetDefaultsIfNotSet()
+
+			if cfg.PauseFramesPerSecondThreshold != tt.wantPause {
+				t.Errorf("pause frames threshold = %f, want %f", cfg.PauseFramesPerSecondThreshold, tt.wantPause)
+			}
+			if cfg.ECNMarkedPacketsPerSecondThreshold != tt.wantECN {
+				t.Errorf("ecn marked packets threshold = %f, want %f", cfg.ECNMarkedPacketsPerSecondThreshold, tt.wantECN)
+			}
+			if cfg.DriverErrorsLookbackPeriod.Duration != tt.wantLookback {
+				t.Errorf("driver errors lookback = %v, want %v", cfg.DriverErrorsLookbackPeriod.Duration, tt.wantLookback)
+			}
+			if cfg.InfinibandClassDir != tt.wantClassDir {
+				t.Errorf("infiniband class dir = %q, want %q", cfg.InfinibandClassDir, tt.wantClassDir)
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantValidErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantValidErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package roce
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/execlimit"
+)
+
+// DefaultInfinibandClassDir is the sysfs directory for the RDMA devices
+// (both InfiniBand and RoCE).
+const DefaultInfinibandClassDir = "/sys/class/infiniband"
+
+// RoCE congestion control counters in the "hw_counters" directory.
+// ref. https://enterprise-support.nvidia.com/s/article/understanding-mlx5-linux-counters-and-status-parameters
+const (
+	// The number of RoCEv2 packets received that were marked for experiencing the congestion (ECN CE bit).
+	hwCounterECNMarkedRoCEPackets = "np_ecn_marked_roce_packets"
+	// The number of congestion notification packets (CNPs) sent by the notification point.
+	hwCounterCNPSent = "np_cnp_sent"
+	// The number of congestion notification packets (CNPs) handled by the reaction point.
+	hwCounterCNPHandled = "rp_cnp_handled"
+)
+
+// PortCounters is the cumulative congestion counters of a RoCE port.
+type PortCounters struct {
+	// Device is the RDMA device name (e.g., "mlx5_0").
+	Device string `json:"device"`
+	// Port is the RDMA device port number.
+	Port int `json:"port"`
+	// Interface is the network interface name (e.g., "eth0").
+	// Empty if no network interface is found for the device.
+	Interface string `json:"interface,omitempty"`
+
+	// RxPauseFrames is the number of the received PFC pause frames per priority.
+	RxPauseFrames map[int]uint64 `json:"rx_pause_frames,omitempty"`
+	// TxPauseFrames is the number of the transmitted PFC pause frames per priority.
+	TxPauseFrames map[int]uint64 `json:"tx_pause_frames,omitempty"`
+
+	ECNMarkedPackets uint64 `json:"ecn_marked_packets"`
+	CNPSent          uint64 `json:"cnp_sent"`
+	CNPHandled       uint64 `json:"cnp_handled"`
+}
+
+// ID returns the unique identifier of the port (e.g., "mlx5_0/1").
+func (c PortCounters) ID() string {
+	return c.Device + "/" + strconv.Itoa(c.Port)
+}
+
+// ReadPortCounters reads the congestion counters of all the RoCE ports
+// (RDMA ports with the "Ethernet" link layer) under the class directory.
+// PFC pause frame counters are read via "ethtool -S", if available.
+func ReadPortCounters(ctx context.Context, classDir string) ([]PortCounters, error) {
+	devs, err := os.ReadDir(classDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	ethtoolPath, _ := exec.LookPath("ethtool")
+
+	counters := make([]PortCounters, 0)
+	for _, dev := range devs {
+		devDir := filepath.Join(classDir, dev.Name())
+
+		ports, err := os.ReadDir(filepath.Join(devDir, "ports"))
+		if err != nil {
+			log.Logger.Debugw("failed to read ports", "device", dev.Name(), "error", err)
+			continue
+		}
+
+		// only RoCE ports report PFC/ECN counters,
+		// and the InfiniBand devices are skipped without running "ethtool"
+		var rocePorts []int
+		for _, port := range ports {
+			portNum, err := strconv.Atoi(port.Name())
+			if err != nil {
+				continue
+			}
+			linkLayer, err := os.ReadFile(filepath.Join(devDir, "ports", port.Name(), "link_layer"))
+			if err != nil || strings.TrimSpace(string(linkLayer)) != "Ethernet" {
+				continue
+			}
+			rocePorts = append(rocePorts, portNum)
+		}
+		if len(rocePorts) == 0 {
+			continue
+		}
+
+		iface := findNetInterface(devDir)
+
+		var rxPause, txPause map[int]uint64
+		if ethtoolPath != "" && iface != "" {
+			if err := execlimit.Wait(ctx, "ethtool"); err != nil {
+				return nil, err
+			}
+			out, err := exec.CommandContext(ctx, ethtoolPath, "-S", iface).Output()
+			if err != nil {
+				log.Logger.Debugw("failed to run ethtool", "interface", iface, "error", err)
+			} else {
+				rxPause, txPause = ParseEthtoolPauseFrames(out)
+			}
+		}
+
+		for _, portNum := range rocePorts {
+			c := PortCounters{
+				Device:        dev.Name(),
+				Port:          portNum,
+				Interface:     iface,
+				RxPauseFrames: rxPause,
+				TxPauseFrames: txPause,
+			}
+			hwDir := filepath.Join(devDir, "ports", strconv.Itoa(portNum), "hw_counters")
+			c.ECNMarkedPackets = readCounter(filepath.Join(hwDir, hwCounterECNMarkedRoCEPackets))
+			c.CNPSent = readCounter(filepath.Join(hwDir, hwCounterCNPSent))
+			c.CNPHandled = readCounter(filepath.Join(hwDir, hwCounterCNPHandled))
+
+			counters = append(counters, c)
+		}
+	}
+
+	sort.Slice(counters, func(i, j int) bool {
+		return counters[i].ID() < counters[j].ID()
+	})
+	return counters, nil
+}
+
+// findNetInterface returns the network interface name of the RDMA device,
+// e.g., "/sys/class/infiniband/mlx5_0/device/net/eth0".
+func findNetInterface(devDir string) string {
+	entries, err := os.ReadDir(filepath.Join(devDir, "device", "net"))
+	if err != nil || len(entries) == 0 {
+		return ""
+	}
+	return entries[0].Name()
+}
+
+func readCounter(file string) uint64 {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return 0
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// e.g.,
+// rx_prio3_pause: 12
+// tx_prio3_pause: 0
+var regexEthtoolPause = regexp.MustCompile(`^\s*(rx|tx)_prio(\d)_pause:\s*(\d+)\s*$`)
+
+// ParseEthtoolPauseFrames parses the "ethtool -S" output
+// and returns the received and transmitted pause frames per priority.
+func ParseEthtoolPauseFrames(b []byte) (map[int]uint64, map[int]uint64) {
+	rx := make(map[int]uint64)
+	tx := make(map[int]uint64)
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		m := regexEthtoolPause.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		prio, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		v, err := strconv.ParseUint(m[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		if m[1] == "rx" {
+			rx[prio] = v
+		} else {
+			tx[prio] = v
+		}
+	}
+	return rx, tx
+}
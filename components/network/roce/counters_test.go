@@ -0,0 +1,129 @@
+package roce
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseEthtoolPauseFrames(t *testing.T) {
+	out := []byte(`NIC statistics:
+     rx_packets: 123
+     rx_prio0_pause: 0
+     rx_prio3_pause: 1500
+     rx_prio3_pause_duration: 90
+     tx_prio3_pause: 42
+     tx_pause_ctrl_phy: 7
+`)
+	rx, tx := ParseEthtoolPauseFrames(out)
+	if !reflect.DeepEqual(rx, map[int]uint64{0: 0, 3: 1500}) {
+		t.Errorf("unexpected rx pause frames %v", rx)
+	}
+	if !reflect.DeepEqual(tx, map[int]uint64{3: 42}) {
+		t.Errorf("unexpected tx pause frames %v", tx)
+	}
+}
+
+func TestReadPortCounters(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile := func(p string, s string) {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// RoCE port
+	writeFile(filepath.Join(dir, "mlx5_0", "ports", "1", "link_layer"), "Ethernet\n")
+	writeFile(filepath.Join(dir, "mlx5_0", "ports", "1", "hw_counters", "np_ecn_marked_roce_packets"), "100\n")
+	writeFile(filepath.Join(dir, "mlx5_0", "ports", "1", "hw_counters", "np_cnp_sent"), "20\n")
+	writeFile(filepath.Join(dir, "mlx5_0", "ports", "1", "hw_counters", "rp_cnp_handled"), "5\n")
+
+	writeFile(filepath.Join(dir, "mlx5_0", "device", "net", "eth0", "ifindex"), "2\n")
+
+	// InfiniBand port should be skipped
+	writeFile(filepath.Join(dir, "mlx5_1", "ports", "1", "link_layer"), "InfiniBand\n")
+	writeFile(filepath.Join(dir, "mlx5_1", "device", "net", "ib0", "ifindex"), "3\n")
+
+	// fake "ethtool" recording the interfaces it is run for
+	binDir := t.TempDir()
+	calls := filepath.Join(binDir, "calls")
+	ethtool := filepath.Join(binDir, "ethtool")
+	writeFile(ethtool, "#!/bin/sh\necho \"$2\" >> "+calls+"\necho '     rx_prio3_pause: 1500'\n")
+	if err := os.Chmod(ethtool, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+
+	counters, err := ReadPortCounters(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counters) != 1 {
+		t.Fatalf("expected 1 roce port, got %d", len(counters))
+	}
+	c := counters[0]
+	if c.ID() != "mlx5_0/1" || c.Interface != "eth0" || c.ECNMarkedPackets != 100 || c.CNPSent != 20 || c.CNPHandled != 5 {
+		t.Errorf("unexpected counters %+v", c)
+	}
+	if !reflect.DeepEqual(c.RxPauseFrames, map[int]uint64{3: 1500}) {
+		t.Errorf("unexpected rx pause frames %v", c.RxPauseFrames)
+	}
+	b, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "eth0\n" {
+		t.Errorf("expected ethtool to run only for the roce interface, got %q", string(b))
+	}
+
+	counters, err = ReadPortCounters(context.Background(), filepath.Join(dir, "not-exist"))
+	if err != nil || len(counters) != 0 {
+		t.Errorf("expected no counters and no error for missing dir, got %v, %v", counters, err)
+	}
+}
+
+func TestComputeRatesAndEvaluate(t *testing.T) {
+	prev := PortCounters{
+		Device:           "mlx5_0",
+		Port:             1,
+		RxPauseFrames:    map[int]uint64{3: 0},
+		TxPauseFrames:    map[int]uint64{3: 100},
+		ECNMarkedPackets: 1000,
+	}
+	cur := PortCounters{
+		Device:           "mlx5_0",
+		Port:             1,
+		RxPauseFrames:    map[int]uint64{3: 20000},
+		TxPauseFrames:    map[int]uint64{3: 50}, // counter reset
+		ECNMarkedPackets: 3000,
+	}
+
+	r := computeRates(prev, cur, 10*time.Second)
+	if r.RxPauseFramesPerSecond[3] != 2000 {
+		t.Errorf("expected 2000 rx pause frames/s, got %f", r.RxPauseFramesPerSecond[3])
+	}
+	if r.TxPauseFramesPerSecond[3] != 0 {
+		t.Errorf("expected 0 tx pause frames/s on counter reset, got %f", r.TxPauseFramesPerSecond[3])
+	}
+	if r.ECNMarkedPacketsPerSecond != 200 {
+		t.Errorf("expected 200 ecn marked packets/s, got %f", r.ECNMarkedPacketsPerSecond)
+	}
+
+	o := &Output{Ports: []PortRates{r}}
+	if _, healthy := o.Evaluate(Config{PauseFramesPerSecondThreshold: DefaultPauseFramesPerSecondThreshold}); healthy {
+		t.Error("expected unhealthy with pause frames above threshold")
+	}
+	if _, healthy := o.Evaluate(Config{ECNMarkedPacketsPerSecondThreshold: DefaultECNMarkedPacketsPerSecondThreshold}); !healthy {
+		t.Error("expected healthy with ecn marks below threshold")
+	}
+	if _, healthy := o.Evaluate(Config{}); !healthy {
+		t.Error("expected healthy with thresholds disabled")
+	}
+}
@@ -0,0 +1,71 @@
+package roce
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/common"
+)
+
+const StateNameDriverErrors = "nic_driver_errors"
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateNameDriverErrors,
+			Description: "NIC driver failures from dmesg within the lookback period",
+		},
+	)
+}
+
+// DriverErrorsState evaluates the NIC driver failure events within the lookback period.
+// Unhealthy if any critical failure (e.g., firmware fatal error) is found,
+// while the transient failures (e.g., interface resets, CQE errors) are only reported.
+func DriverErrorsState(evs []components.Event, lookback time.Duration) components.State {
+	state := components.State{
+		Name:    StateNameDriverErrors,
+		Healthy: true,
+		Reason:  fmt.Sprintf("no nic driver error in the last %v", lookback),
+	}
+	if len(evs) == 0 {
+		return state
+	}
+
+	counts := make(map[string]int)
+	var actions *common.SuggestedActions
+	for _, ev := range evs {
+		counts[ev.Name]++
+		if ev.Type != components.EventTypeError {
+			continue
+		}
+		state.Healthy = false
+		if ev.SuggestedActions == nil {
+			continue
+		}
+		if actions == nil {
+			actions = &common.SuggestedActions{}
+		}
+		actions.Add(ev.SuggestedActions)
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	found := make([]string, 0, len(names))
+	for _, name := range names {
+		found = append(found, fmt.Sprintf("%s (%d)", name, counts[name]))
+	}
+
+	if state.Healthy {
+		state.Reason = fmt.Sprintf("transient nic driver errors in the last %v: %s", lookback, strings.Join(found, ", "))
+	} else {
+		state.Reason = fmt.Sprintf("critical nic driver errors in the last %v: %s", lookback, strings.Join(found, ", "))
+		state.SuggestedActions = actions
+	}
+	return state
+}
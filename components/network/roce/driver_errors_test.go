@@ -0,0 +1,60 @@
+package roce
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	network_query_nic "github.com/leptonai/gpud/components/network/query/nic"
+)
+
+func TestDriverErrorsState(t *testing.T) {
+	fatal, _ := network_query_nic.GetRule(network_query_nic.EventMlx5FirmwareFatal)
+
+	tests := []struct {
+		name        string
+		evs         []components.Event
+		wantHealthy bool
+		wantActions bool
+	}{
+		{
+			name:        "no event",
+			wantHealthy: true,
+		},
+		{
+			name: "transient only",
+			evs: []components.Event{
+				{Name: network_query_nic.EventInterfaceReset, Type: components.EventTypeWarn},
+				{Name: network_query_nic.EventCQEError, Type: components.EventTypeWarn},
+			},
+			wantHealthy: true,
+		},
+		{
+			name: "critical",
+			evs: []components.Event{
+				{Name: network_query_nic.EventInterfaceReset, Type: components.EventTypeWarn},
+				{Name: fatal.Name, Type: components.EventTypeError, SuggestedActions: fatal.SuggestedActions},
+				{Name: fatal.Name, Type: components.EventTypeError, SuggestedActions: fatal.SuggestedActions},
+			},
+			wantHealthy: false,
+			wantActions: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := DriverErrorsState(tt.evs, time.Hour)
+			if state.Healthy != tt.wantHealthy {
+				t.Errorf("Healthy = %v, want %v (%s)", state.Healthy, tt.wantHealthy, state.Reason)
+			}
+			if (state.SuggestedActions != nil) != tt.wantActions {
+				t.Fatalf("SuggestedActions = %+v, want set %v", state.SuggestedActions, tt.wantActions)
+			}
+			if tt.wantActions && !state.SuggestedActions.RequiresReboot() {
+				t.Errorf("expected reboot in %v", state.SuggestedActions.RepairActions)
+			}
+			if tt.wantActions && len(state.SuggestedActions.RepairActions) != len(fatal.SuggestedActions.RepairActions) {
+				t.Errorf("expected deduplicated actions %v, got %v", fatal.SuggestedActions.RepairActions, state.SuggestedActions.RepairActions)
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+package id
+
+const Name = "network-roce"
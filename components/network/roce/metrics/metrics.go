@@ -0,0 +1,81 @@
+// Package metrics implements the RoCE congestion metrics collection and reporting.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const SubSystem = "network_roce"
+
+var (
+	lastUpdateUnixSeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "last_update_unix_seconds",
+			Help:      "tracks the last update time in unix seconds",
+		},
+	)
+
+	pauseFramesPerSecond = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "pause_frames_per_second",
+			Help:      "tracks the PFC pause frames per second per priority",
+		},
+		[]string{"port", "direction", "priority"},
+	)
+
+	ecnMarkedPacketsPerSecond = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "ecn_marked_packets_per_second",
+			Help:      "tracks the ECN marked RoCE packets per second",
+		},
+		[]string{"port"},
+	)
+
+	cnpSentPerSecond = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "",
+			Subsystem: SubSystem,
+			Name:      "cnp_sent_per_second",
+			Help:      "tracks the congestion notification packets sent per second",
+		},
+		[]string{"port"},
+	)
+)
+
+func SetLastUpdateUnixSeconds(unixSeconds float64) {
+	lastUpdateUnixSeconds.Set(unixSeconds)
+}
+
+func SetPauseFramesPerSecond(port string, direction string, priority string, v float64) {
+	pauseFramesPerSecond.WithLabelValues(port, direction, priority).Set(v)
+}
+
+func SetECNMarkedPacketsPerSecond(port string, v float64) {
+	ecnMarkedPacketsPerSecond.WithLabelValues(port).Set(v)
+}
+
+func SetCNPSentPerSecond(port string, v float64) {
+	cnpSentPerSecond.WithLabelValues(port).Set(v)
+}
+
+func Register(reg *prometheus.Registry) error {
+	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
+		return err
+	}
+	if err := reg.Register(pauseFramesPerSecond); err != nil {
+		return err
+	}
+	if err := reg.Register(ecnMarkedPacketsPerSecond); err != nil {
+		return err
+	}
+	if err := reg.Register(cnpSentPerSecond); err != nil {
+		return err
+	}
+	return nil
+}
@@ -52,7 +52,7 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	if err != nil {
 		return nil, err
 	}
-	if last.Error != nil {
+	if last.Error != nil && !last.IsPartial() {
 		return []components.State{
 			{
 				Name:    Name,
@@ -109,7 +109,7 @@ func (c *component) Output() (any, error) {
 		log.Logger.Debugw("nothing found in last state (no data collected yet)", "component", Name)
 		return nil, query.ErrNoData
 	}
-	if last.Error != nil {
+	if last.Error != nil && !last.IsPartial() {
 		return nil, last.Error
 	}
 	if last.Output == nil {
@@ -8,11 +8,12 @@ import (
 	"sync"
 	"time"
 
-	"github.com/dustin/go-humanize"
 	"github.com/leptonai/gpud/components"
 	components_metrics "github.com/leptonai/gpud/components/metrics"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/pkg/file"
+	pkg_host "github.com/leptonai/gpud/pkg/host"
+	"github.com/leptonai/gpud/pkg/locale"
 	"github.com/leptonai/gpud/pkg/process"
 
 	"github.com/shirou/gopsutil/v4/host"
@@ -20,11 +21,16 @@ import (
 )
 
 type Output struct {
-	Host                        Host     `json:"host"`
-	Kernel                      Kernel   `json:"kernel"`
-	Platform                    Platform `json:"platform"`
-	Uptimes                     Uptimes  `json:"uptimes"`
-	ProcessCountZombieProcesses int      `json:"process_count_zombie_processes"`
+	VirtualizationEnvironment   pkg_host.VirtualizationEnvironment `json:"virtualization_environment"`
+	Host                        Host                               `json:"host"`
+	Kernel                      Kernel                             `json:"kernel"`
+	Platform                    Platform                           `json:"platform"`
+	Uptimes                     Uptimes                            `json:"uptimes"`
+	ProcessCountZombieProcesses int                                `json:"process_count_zombie_processes"`
+
+	// Errors is the error message of each field that failed to be collected,
+	// keyed by its state name (e.g., "uptimes"), while the other fields are still reported.
+	Errors map[string]string `json:"errors,omitempty"`
 }
 
 type Host struct {
@@ -63,6 +69,12 @@ func ParseOutputJSON(data []byte) (*Output, error) {
 }
 
 const (
+	StateNameVirtualizationEnvironment         = "virtualization_environment"
+	StateKeyVirtualizationEnvironmentType      = "type"
+	StateKeyVirtualizationEnvironmentRole      = "role"
+	StateKeyVirtualizationEnvironmentVMGuest   = "vm_guest"
+	StateKeyVirtualizationEnvironmentBareMetal = "bare_metal"
+
 	StateNameHost  = "host"
 	StateKeyHostID = "id"
 
@@ -85,6 +97,69 @@ const (
 	StateKeyProcessCountZombieProcesses = "process_count_zombie_processes"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateNameVirtualizationEnvironment,
+			Description: "virtualization environment of the host",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyVirtualizationEnvironmentType, Type: components.ExtraInfoTypeString, Description: "virtualization type (e.g., \"kvm\", empty for the bare metal)"},
+				{Key: StateKeyVirtualizationEnvironmentRole, Type: components.ExtraInfoTypeString, Description: "virtualization role (e.g., \"guest\", \"host\")"},
+				{Key: StateKeyVirtualizationEnvironmentVMGuest, Type: components.ExtraInfoTypeBool, Description: "true if the host is a virtual machine guest"},
+				{Key: StateKeyVirtualizationEnvironmentBareMetal, Type: components.ExtraInfoTypeBool, Description: "true if the host is a bare metal"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateNameHost,
+			Description: "host identity",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyHostID, Type: components.ExtraInfoTypeString, Description: "host ID"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateNameKernel,
+			Description: "kernel information",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyKernelArch, Type: components.ExtraInfoTypeString, Description: "kernel architecture (e.g., \"x86_64\")"},
+				{Key: StateKeyKernelVersion, Type: components.ExtraInfoTypeString, Description: "kernel version"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateNamePlatform,
+			Description: "OS platform information",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyPlatformName, Type: components.ExtraInfoTypeString, Description: "platform name (e.g., \"ubuntu\")"},
+				{Key: StateKeyPlatformFamily, Type: components.ExtraInfoTypeString, Description: "platform family (e.g., \"debian\")"},
+				{Key: StateKeyPlatformVersion, Type: components.ExtraInfoTypeString, Description: "platform version"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateNameUptimes,
+			Description: "host uptime and boot time",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyUptimesSeconds, Type: components.ExtraInfoTypeUint, Unit: "seconds", Description: "host uptime"},
+				{Key: StateKeyUptimesHumanized, Type: components.ExtraInfoTypeString, Description: "host uptime, humanized"},
+				{Key: StateKeyUptimesBootTimeUnixSeconds, Type: components.ExtraInfoTypeUint, Unit: "seconds", Description: "unix time of the last boot"},
+				{Key: StateKeyUptimesBootTimeHumanized, Type: components.ExtraInfoTypeString, Description: "last boot time, humanized"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateNameProcessCountsByStatus,
+			Description: "process counts by the process status",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyProcessCountZombieProcesses, Type: components.ExtraInfoTypeInt, Unit: "processes", Description: "number of the zombie processes"},
+			},
+		},
+	)
+}
+
+func ParseStateVirtualizationEnvironment(m map[string]string) (pkg_host.VirtualizationEnvironment, error) {
+	v := pkg_host.VirtualizationEnvironment{}
+	v.Type = m[StateKeyVirtualizationEnvironmentType]
+	v.Role = m[StateKeyVirtualizationEnvironmentRole]
+	return v, nil
+}
+
 func ParseStateHost(m map[string]string) (Host, error) {
 	h := Host{}
 	h.ID = m[StateKeyHostID]
@@ -140,7 +215,21 @@ func ParseStateProcessCountZombieProcesses(m map[string]string) (int, error) {
 func ParseStatesToOutput(states ...components.State) (*Output, error) {
 	o := &Output{}
 	for _, state := range states {
+		if state.Error != "" {
+			if o.Errors == nil {
+				o.Errors = make(map[string]string)
+			}
+			o.Errors[state.Name] = state.Error
+		}
+
 		switch state.Name {
+		case StateNameVirtualizationEnvironment:
+			virtEnv, err := ParseStateVirtualizationEnvironment(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			o.VirtualizationEnvironment = virtEnv
+
 		case StateNameHost:
 			host, err := ParseStateHost(state.ExtraInfo)
 			if err != nil {
@@ -184,7 +273,22 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 }
 
 func (o *Output) States() ([]components.State, error) {
+	virtType := o.VirtualizationEnvironment.Type
+	if virtType == "" {
+		virtType = "none"
+	}
 	states := []components.State{
+		{
+			Name:    StateNameVirtualizationEnvironment,
+			Healthy: true,
+			Reason:  fmt.Sprintf("type: %s, role: %s, vm guest: %v", virtType, o.VirtualizationEnvironment.Role, o.VirtualizationEnvironment.IsVMGuest()),
+			ExtraInfo: map[string]string{
+				StateKeyVirtualizationEnvironmentType:      o.VirtualizationEnvironment.Type,
+				StateKeyVirtualizationEnvironmentRole:      o.VirtualizationEnvironment.Role,
+				StateKeyVirtualizationEnvironmentVMGuest:   fmt.Sprintf("%v", o.VirtualizationEnvironment.IsVMGuest()),
+				StateKeyVirtualizationEnvironmentBareMetal: fmt.Sprintf("%v", o.VirtualizationEnvironment.IsBareMetal()),
+			},
+		},
 		{
 			Name:    StateNameHost,
 			Healthy: true,
@@ -240,6 +344,15 @@ func (o *Output) States() ([]components.State, error) {
 	}
 
 	states = append(states, stateProcCounts)
+
+	// reports the failed fields as unhealthy, while keeping the others
+	for i := range states {
+		if errMsg, ok := o.Errors[states[i].Name]; ok {
+			states[i].Healthy = false
+			states[i].Error = errMsg
+			states[i].Reason = "failed to collect " + states[i].Name
+		}
+	}
 	return states, nil
 }
 
@@ -272,6 +385,8 @@ func getDefaultPoller() query.Poller {
 	return defaultPoller
 }
 
+// Get returns the partial output along with the *query.PartialError,
+// if some fields fail to be collected while the others succeed.
 func Get(ctx context.Context) (_ any, e error) {
 	defer func() {
 		if e != nil {
@@ -282,57 +397,84 @@ func Get(ctx context.Context) (_ any, e error) {
 	}()
 
 	o := &Output{}
+	pe := &query.PartialError{}
+
+	virtEnv, err := pkg_host.GetVirtualizationEnvironment(ctx)
+	if err != nil {
+		pe.Add(StateNameVirtualizationEnvironment, err)
+	} else {
+		o.VirtualizationEnvironment = virtEnv
+	}
 
 	hostID, err := host.HostID()
 	if err != nil {
-		return nil, err
+		pe.Add(StateNameHost, err)
+	} else {
+		o.Host = Host{ID: hostID}
 	}
-	o.Host = Host{ID: hostID}
 
-	arch, err := host.KernelArch()
+	if kernel, err := getKernel(); err != nil {
+		pe.Add(StateNameKernel, err)
+	} else {
+		o.Kernel = kernel
+	}
+
+	platform, family, version, err := host.PlatformInformation()
 	if err != nil {
-		return nil, err
+		pe.Add(StateNamePlatform, err)
+	} else {
+		o.Platform = Platform{Name: platform, Family: family, Version: version}
 	}
-	kernelVer, err := host.KernelVersion()
+
+	if uptimes, err := getUptimes(ctx); err != nil {
+		pe.Add(StateNameUptimes, err)
+	} else {
+		o.Uptimes = uptimes
+	}
+
+	allProcs, err := process.CountProcessesByStatus(ctx)
 	if err != nil {
-		return nil, err
+		pe.Add(StateNameProcessCountsByStatus, err)
+	} else {
+		for status, procsWithStatus := range allProcs {
+			if status == procs.Zombie {
+				o.ProcessCountZombieProcesses = len(procsWithStatus)
+				break
+			}
+		}
 	}
-	o.Kernel = Kernel{Arch: arch, Version: kernelVer}
 
-	platform, family, version, err := host.PlatformInformation()
+	o.Errors = pe.Messages()
+	return o, pe.Err()
+}
+
+func getKernel() (Kernel, error) {
+	arch, err := host.KernelArch()
 	if err != nil {
-		return nil, err
+		return Kernel{}, err
 	}
-	o.Platform = Platform{Name: platform, Family: family, Version: version}
+	kernelVer, err := host.KernelVersion()
+	if err != nil {
+		return Kernel{}, err
+	}
+	return Kernel{Arch: arch, Version: kernelVer}, nil
+}
 
+func getUptimes(ctx context.Context) (Uptimes, error) {
 	uptime, err := host.UptimeWithContext(ctx)
 	if err != nil {
-		return nil, err
+		return Uptimes{}, err
 	}
 	boottime, err := host.BootTimeWithContext(ctx)
 	if err != nil {
-		return nil, err
+		return Uptimes{}, err
 	}
 
 	now := time.Now().UTC()
-	o.Uptimes = Uptimes{
+	return Uptimes{
 		Seconds:             uptime,
-		SecondsHumanized:    humanize.RelTime(now.Add(time.Duration(-int64(uptime))*time.Second), now, "ago", "from now"),
+		SecondsHumanized:    locale.RelTime(now.Add(time.Duration(-int64(uptime))*time.Second), now),
 		BootTimeUnixSeconds: boottime,
-		BootTimeHumanized:   humanize.RelTime(time.Unix(int64(boottime), 0), now, "ago", "from now"),
-	}
-
-	allProcs, err := process.CountProcessesByStatus(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	for status, procsWithStatus := range allProcs {
-		if status == procs.Zombie {
-			o.ProcessCountZombieProcesses = len(procsWithStatus)
-			break
-		}
-	}
-
-	return o, nil
+		BootTimeHumanized:   locale.RelTime(time.Unix(int64(boottime), 0), now),
+	}, nil
 }
@@ -39,3 +39,37 @@ func TestComponent(t *testing.T) {
 	}
 	t.Logf("parsed output: %+v", parsedOutput)
 }
+
+func TestOutputStatesPartial(t *testing.T) {
+	o := &Output{
+		Platform: Platform{Name: "ubuntu", Family: "debian", Version: "22.04"},
+		Errors:   map[string]string{StateNameUptimes: "failed to read /proc/uptime"},
+	}
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range states {
+		switch s.Name {
+		case StateNameUptimes:
+			if s.Healthy || s.Error != "failed to read /proc/uptime" {
+				t.Errorf("expected uptimes failed, got %+v", s)
+			}
+		default:
+			if !s.Healthy || s.Error != "" {
+				t.Errorf("expected %s healthy, got %+v", s.Name, s)
+			}
+		}
+	}
+
+	parsed, err := ParseStatesToOutput(states...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Platform != o.Platform {
+		t.Errorf("expected platform %+v, got %+v", o.Platform, parsed.Platform)
+	}
+	if parsed.Errors[StateNameUptimes] != "failed to read /proc/uptime" {
+		t.Errorf("expected uptimes error, got %v", parsed.Errors)
+	}
+}
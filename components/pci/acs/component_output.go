@@ -0,0 +1,264 @@
+package acs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/common"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/pci"
+)
+
+// Port is the ACS setting of a PCIe switch port upstream of the GPUs or NICs.
+type Port struct {
+	BDF string `json:"bdf"`
+	// Downstream is the BDFs of the GPUs and NICs behind the port.
+	Downstream []string `json:"downstream"`
+
+	// Supported is false if the port has no ACS capability.
+	Supported bool `json:"supported"`
+	// Control is the ACS control flags in the "lspci -vvv" format.
+	Control string `json:"control,omitempty"`
+	// RedirectsP2P is true if the ACS forces the peer-to-peer traffic through the root complex.
+	RedirectsP2P bool `json:"redirects_p2p"`
+
+	// Disabled is true if the ACS was disabled by the enforcement in this check.
+	Disabled bool   `json:"disabled,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Output struct {
+	Ports []Port `json:"ports"`
+
+	// Enforced is true if the ACS enforcement is enabled.
+	Enforced bool `json:"enforced"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameACS = "acs"
+
+	StateKeyACSData           = "data"
+	StateKeyACSEncoding       = "encoding"
+	StateValueACSEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameACS, "PCIe ACS settings upstream of the GPUs and NICs"),
+	)
+}
+
+func ParseStateACS(m map[string]string) (*Output, error) {
+	data := m[StateKeyACSData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameACS:
+			o, err := ParseStateACS(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// RedirectingPorts returns the BDFs of the switch ports that still redirect the peer-to-peer traffic.
+func (o *Output) RedirectingPorts() []string {
+	ports := make([]string, 0)
+	for _, p := range o.Ports {
+		if p.RedirectsP2P {
+			ports = append(ports, p.BDF)
+		}
+	}
+	return ports
+}
+
+// Returns the output evaluation reason and whether the ACS settings degrade the peer-to-peer traffic.
+func (o *Output) Evaluate() (string, bool) {
+	if len(o.Ports) == 0 {
+		return "no PCIe switch port found upstream of the GPUs or NICs", false
+	}
+
+	var failed []string
+	disabled := 0
+	for _, p := range o.Ports {
+		if p.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s (%s)", p.BDF, p.Error))
+		}
+		if p.Disabled {
+			disabled++
+		}
+	}
+
+	reason := fmt.Sprintf("checked ACS on %d PCIe switch port(s)", len(o.Ports))
+	if disabled > 0 {
+		reason += fmt.Sprintf(", disabled ACS on %d port(s)", disabled)
+	}
+	if len(failed) > 0 {
+		reason += fmt.Sprintf(", failed on %d port(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	redirecting := o.RedirectingPorts()
+	if len(redirecting) > 0 {
+		return reason + fmt.Sprintf(", ACS redirects the peer-to-peer traffic to the root complex on %d port(s): %s", len(redirecting), strings.Join(redirecting, ", ")), true
+	}
+	return reason, false
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, degraded := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:     StateNameACS,
+		Healthy:  true,
+		Degraded: degraded,
+		Reason:   reason,
+		ExtraInfo: map[string]string{
+			StateKeyACSData:     string(b),
+			StateKeyACSEncoding: StateValueACSEncodingJSON,
+		},
+	}
+	if degraded {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"the enabled ACS silently routes the GPU peer-to-peer and GPUDirect RDMA traffic through the root complex, disable the ACS on the PCIe switch ports if the peer-to-peer isolation is not required",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeDisableACS,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since the enforcement writes to the PCI configuration space
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg))
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+func CreateGet(cfg Config) query.GetFunc {
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		devs, err := pci.List(cfg.DevicesDir)
+		if err != nil {
+			return nil, err
+		}
+
+		o := &Output{Enforced: cfg.Enforce != nil}
+		for _, p := range switchPorts(devs) {
+			checkPort(cfg, &p)
+			o.Ports = append(o.Ports, p)
+		}
+		return o, nil
+	}
+}
+
+// switchPorts returns the PCIe switch ports upstream of the GPUs and NICs, sorted by the BDF.
+// The root ports are excluded, since the peer-to-peer traffic across the root ports
+// goes through the root complex regardless of the ACS.
+func switchPorts(devs []pci.Device) []Port {
+	downstream := make(map[string][]string)
+	for _, dev := range devs {
+		if !dev.IsNVIDIAGPU() && !dev.IsNIC() {
+			continue
+		}
+		if len(dev.Upstream) < 2 {
+			continue
+		}
+		for _, bdf := range dev.Upstream[1:] {
+			downstream[bdf] = append(downstream[bdf], dev.BDF)
+		}
+	}
+
+	ports := make([]Port, 0, len(downstream))
+	for bdf, ds := range downstream {
+		ports = append(ports, Port{BDF: bdf, Downstream: ds})
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].BDF < ports[j].BDF
+	})
+	return ports
+}
+
+// checkPort reads the ACS control of the port, and disables its peer-to-peer redirects
+// if the policy allows (the other ACS protections are kept).
+func checkPort(cfg Config, p *Port) {
+	ctl, err := pci.ReadACSControl(cfg.DevicesDir, p.BDF)
+	if errors.Is(err, pci.ErrACSNotSupported) {
+		return
+	}
+	if err != nil {
+		p.Error = err.Error()
+		return
+	}
+	p.Supported = true
+	p.Control = ctl.String()
+	p.RedirectsP2P = ctl.RedirectsP2P()
+
+	// only writes the configuration space when the redirects are set
+	want := ctl.WithoutP2PRedirects()
+	if want == ctl || !cfg.Enforce.Allows(p.BDF) {
+		return
+	}
+
+	log.Logger.Infow("disabling ACS peer-to-peer redirects", "port", p.BDF, "control", p.Control)
+	if err := pci.WriteACSControl(cfg.DevicesDir, p.BDF, want); err != nil {
+		p.Error = fmt.Sprintf("failed to disable ACS: %v", err)
+		return
+	}
+	ctl, err = pci.ReadACSControl(cfg.DevicesDir, p.BDF)
+	if err != nil {
+		p.Error = err.Error()
+		return
+	}
+	p.Control = ctl.String()
+	p.RedirectsP2P = ctl.RedirectsP2P()
+	p.Disabled = !p.RedirectsP2P
+}
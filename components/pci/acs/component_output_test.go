@@ -0,0 +1,178 @@
+package acs
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/pci"
+)
+
+func TestSwitchPorts(t *testing.T) {
+	devs := []pci.Device{
+		{BDF: "0000:00:01.0", Class: 0x060400},
+		{BDF: "0000:01:00.0", Class: 0x060400, Upstream: []string{"0000:00:01.0"}},
+		{BDF: "0000:02:00.0", Class: 0x060400, Upstream: []string{"0000:00:01.0", "0000:01:00.0"}},
+		{BDF: "0000:05:00.0", Vendor: pci.VendorNVIDIA, Class: 0x030200, Upstream: []string{"0000:00:01.0", "0000:01:00.0", "0000:02:00.0"}},
+		{BDF: "0000:06:00.0", Vendor: 0x15b3, Class: 0x020700, Upstream: []string{"0000:00:01.0", "0000:01:00.0", "0000:02:00.0"}},
+		// directly under the root port
+		{BDF: "0000:41:00.0", Vendor: pci.VendorNVIDIA, Class: 0x030200, Upstream: []string{"0000:40:01.0"}},
+		// neither a GPU nor a NIC
+		{BDF: "0000:07:00.0", Vendor: 0x1000, Class: 0x010700, Upstream: []string{"0000:00:01.0", "0000:03:00.0"}},
+	}
+
+	want := []Port{
+		{BDF: "0000:01:00.0", Downstream: []string{"0000:05:00.0", "0000:06:00.0"}},
+		{BDF: "0000:02:00.0", Downstream: []string{"0000:05:00.0", "0000:06:00.0"}},
+	}
+	if got := switchPorts(devs); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestOutputStates(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       *Output
+		wantDegraded bool
+	}{
+		{
+			name:   "no port",
+			output: &Output{},
+		},
+		{
+			name: "acs not redirecting",
+			output: &Output{Ports: []Port{
+				{BDF: "0000:02:00.0", Supported: true, Control: "SrcValid+ TransBlk- ReqRedir- CmpltRedir- UpstreamFwd- EgressCtrl- DirectTrans-"},
+			}},
+		},
+		{
+			name: "acs redirecting",
+			output: &Output{Ports: []Port{
+				{BDF: "0000:02:00.0", Supported: true, RedirectsP2P: true},
+				{BDF: "0000:03:00.0", Supported: false},
+			}},
+			wantDegraded: true,
+		},
+		{
+			name: "acs disabled by enforcement",
+			output: &Output{Enforced: true, Ports: []Port{
+				{BDF: "0000:02:00.0", Supported: true, Disabled: true},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states, err := tt.output.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != 1 {
+				t.Fatalf("expected 1 state, got %d", len(states))
+			}
+			if !states[0].Healthy {
+				t.Errorf("expected healthy, got %+v", states[0])
+			}
+			if states[0].Degraded != tt.wantDegraded {
+				t.Errorf("expected degraded %v, got %+v", tt.wantDegraded, states[0])
+			}
+			if got := states[0].SuggestedActions.RequiresACSDisable(); got != tt.wantDegraded {
+				t.Errorf("expected acs disable action %v, got %v", tt.wantDegraded, got)
+			}
+
+			parsed, err := ParseStatesToOutput(states...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed.Ports) != len(tt.output.Ports) {
+				t.Errorf("expected %d ports, got %d", len(tt.output.Ports), len(parsed.Ports))
+			}
+		})
+	}
+}
+
+func TestEnforcePolicyAllows(t *testing.T) {
+	var p *EnforcePolicy
+	if p.Allows("0000:02:00.0") {
+		t.Error("expected nil policy to disallow")
+	}
+	p = &EnforcePolicy{Ports: []string{"0000:03:00.0"}}
+	if !p.Allows("0000:03:00.0") {
+		t.Error("expected listed port to be allowed")
+	}
+	if p.Allows("0000:02:00.0") {
+		t.Error("expected unlisted port to be disallowed")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("expected no error without enforcement, got %v", err)
+	}
+	if err := (Config{Enforce: &EnforcePolicy{}}).Validate(); err == nil {
+		t.Error("expected error for the enforcement without ports")
+	}
+	if err := (Config{Enforce: &EnforcePolicy{Ports: []string{"0000:02:00.0"}}}).Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestCheckPortEnforce(t *testing.T) {
+	dir := t.TempDir()
+	writeACS := func(bdf string, ctl pci.ACSControl) {
+		config := make([]byte, 0x1000)
+		// ACS (ID 0x000d) as the first extended capability, end of the list
+		binary.LittleEndian.PutUint32(config[0x100:], 0x000d)
+		binary.LittleEndian.PutUint16(config[0x106:], uint16(ctl))
+		if err := os.MkdirAll(filepath.Join(dir, bdf), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, bdf, "config"), config, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	redirecting := pci.ACSSourceValidation | pci.ACSTranslationBlocking | pci.ACSP2PRequestRedirect | pci.ACSP2PCompletionRedirect | pci.ACSP2PEgressControl
+	writeACS("0000:02:00.0", redirecting)
+	writeACS("0000:03:00.0", redirecting)
+	writeACS("0000:04:00.0", pci.ACSSourceValidation)
+
+	cfg := Config{DevicesDir: dir, Enforce: &EnforcePolicy{Ports: []string{"0000:02:00.0", "0000:04:00.0"}}}
+
+	p := Port{BDF: "0000:02:00.0"}
+	checkPort(cfg, &p)
+	if !p.Disabled || p.RedirectsP2P || p.Error != "" {
+		t.Errorf("expected the redirects disabled, got %+v", p)
+	}
+	ctl, err := pci.ReadACSControl(dir, "0000:02:00.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctl != pci.ACSSourceValidation|pci.ACSTranslationBlocking {
+		t.Errorf("expected the source validation and the translation blocking kept, got %s", ctl)
+	}
+
+	// not in the allow-list
+	p = Port{BDF: "0000:03:00.0"}
+	checkPort(cfg, &p)
+	if p.Disabled || !p.RedirectsP2P {
+		t.Errorf("expected the port kept as is, got %+v", p)
+	}
+
+	// no redirect, the configuration space is not written
+	file := filepath.Join(dir, "0000:04:00.0", "config")
+	old := time.Unix(1000, 0)
+	if err := os.Chtimes(file, old, old); err != nil {
+		t.Fatal(err)
+	}
+	p = Port{BDF: "0000:04:00.0"}
+	checkPort(cfg, &p)
+	if p.Disabled || p.RedirectsP2P {
+		t.Errorf("expected no change, got %+v", p)
+	}
+	if fi, err := os.Stat(file); err != nil || !fi.ModTime().Equal(old) {
+		t.Errorf("expected the configuration space not written (%v)", err)
+	}
+}
@@ -0,0 +1,66 @@
+package acs
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// DevicesDir is the sysfs directory of the PCI devices.
+	// If not set, it defaults to "/sys/bus/pci/devices".
+	DevicesDir string `json:"devices_dir"`
+
+	// Enforce disables the ACS peer-to-peer redirects on the listed PCIe switch ports
+	// upstream of the GPUs and NICs, if set.
+	// Only enable when the peer-to-peer isolation between the devices is not required
+	// (e.g., no GPU passthrough to the virtual machines).
+	Enforce *EnforcePolicy `json:"enforce,omitempty"`
+}
+
+// EnforcePolicy is the policy to disable the ACS on the PCIe switch ports.
+type EnforcePolicy struct {
+	// Ports is the BDFs of the switch ports to disable the ACS peer-to-peer redirects on
+	// (e.g., "0000:02:00.0"). Required, the other ports are kept as is.
+	Ports []string `json:"ports"`
+}
+
+// Allows returns true if the policy allows disabling the ACS on the switch port.
+func (p *EnforcePolicy) Allows(bdf string) bool {
+	if p == nil {
+		return false
+	}
+	for _, port := range p.Ports {
+		if port == bdf {
+			return true
+		}
+	}
+	return false
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Enforce != nil && len(cfg.Enforce.Ports) == 0 {
+		return errors.New("enforce requires the switch ports to disable the ACS on")
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package iommu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/common"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/pkg/pci"
+)
+
+const (
+	DeviceKindGPU = "gpu"
+	DeviceKindNIC = "nic"
+)
+
+// Device is the effective DMA mapping mode of a GPU or NIC.
+type Device struct {
+	BDF  string `json:"bdf"`
+	Kind string `json:"kind"`
+
+	pci.IOMMUGroup
+	Error string `json:"error,omitempty"`
+}
+
+type Output struct {
+	Devices []Device         `json:"devices"`
+	Cmdline pci.IOMMUCmdline `json:"cmdline"`
+
+	// AllowedDMAModes is the DMA mapping modes allowed by the policy.
+	AllowedDMAModes []string `json:"allowed_dma_modes"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameIOMMU = "iommu"
+
+	StateKeyIOMMUData           = "data"
+	StateKeyIOMMUEncoding       = "encoding"
+	StateValueIOMMUEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameIOMMU, "IOMMU DMA mapping mode per GPU and NIC"),
+	)
+}
+
+func ParseStateIOMMU(m map[string]string) (*Output, error) {
+	data := m[StateKeyIOMMUData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameIOMMU:
+			o, err := ParseStateIOMMU(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// MisconfiguredDevices returns the devices whose DMA mapping mode is not allowed by the policy.
+// The devices managed by the user space (e.g., vfio-pci for the virtual machines) are excluded.
+func (o *Output) MisconfiguredDevices() []Device {
+	allowed := make(map[string]struct{}, len(o.AllowedDMAModes))
+	for _, mode := range o.AllowedDMAModes {
+		allowed[mode] = struct{}{}
+	}
+
+	devs := make([]Device, 0)
+	for _, dev := range o.Devices {
+		if dev.Error != "" || dev.DMAMode == pci.DMAModeUnmanaged {
+			continue
+		}
+		if _, ok := allowed[dev.DMAMode]; !ok {
+			devs = append(devs, dev)
+		}
+	}
+	return devs
+}
+
+// Returns the output evaluation reason and whether the DMA mapping modes degrade GPUDirect RDMA.
+func (o *Output) Evaluate() (string, bool) {
+	if len(o.Devices) == 0 {
+		return "no GPU or NIC found", false
+	}
+
+	modes := make(map[string]int)
+	var failed []string
+	for _, dev := range o.Devices {
+		if dev.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s (%s)", dev.BDF, dev.Error))
+			continue
+		}
+		modes[dev.DMAMode]++
+	}
+	counts := make([]string, 0, len(modes))
+	for mode, cnt := range modes {
+		counts = append(counts, fmt.Sprintf("%d %s", cnt, mode))
+	}
+	sort.Strings(counts)
+
+	reason := fmt.Sprintf("DMA mapping mode of %d GPU(s)/NIC(s): %s", len(o.Devices), strings.Join(counts, ", "))
+	if len(failed) > 0 {
+		reason += fmt.Sprintf(", failed on %d device(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	misconfigured := o.MisconfiguredDevices()
+	if len(misconfigured) == 0 {
+		return reason, false
+	}
+	bdfs := make([]string, 0, len(misconfigured))
+	for _, dev := range misconfigured {
+		bdfs = append(bdfs, fmt.Sprintf("%s (%s)", dev.BDF, dev.DMAMode))
+	}
+	return reason + fmt.Sprintf(", DMA mapping mode not allowed by the policy (%s) on %d device(s): %s",
+		strings.Join(o.AllowedDMAModes, ", "), len(misconfigured), strings.Join(bdfs, ", ")), true
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, degraded := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:     StateNameIOMMU,
+		Healthy:  true,
+		Degraded: degraded,
+		Reason:   reason,
+		ExtraInfo: map[string]string{
+			StateKeyIOMMUData:     string(b),
+			StateKeyIOMMUEncoding: StateValueIOMMUEncodingJSON,
+		},
+	}
+	if degraded {
+		state.SuggestedActions = &common.SuggestedActions{
+			References: []string{
+				"https://docs.nvidia.com/cuda/gpudirect-rdma/index.html",
+			},
+			Descriptions: []string{
+				"the IOMMU DMA translation breaks or slows down GPUDirect RDMA, set the IOMMU kernel parameters (e.g., \"iommu=pt\") per the fleet policy and reboot the system",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRebootSystem,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only set once since it relies on the configured policy
+func setDefaultPoller(cfg Config) {
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg))
+	})
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+func CreateGet(cfg Config) query.GetFunc {
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		devs, err := pci.List(cfg.DevicesDir)
+		if err != nil {
+			return nil, err
+		}
+
+		o := &Output{AllowedDMAModes: cfg.AllowedDMAModes}
+		if b, err := os.ReadFile(DefaultCmdlineFile); err == nil {
+			o.Cmdline = pci.ParseIOMMUCmdline(string(b))
+		}
+
+		for _, dev := range devs {
+			d := Device{BDF: dev.BDF}
+			switch {
+			case dev.IsNVIDIAGPU():
+				d.Kind = DeviceKindGPU
+			case dev.IsNIC():
+				d.Kind = DeviceKindNIC
+			default:
+				continue
+			}
+
+			group, err := pci.ReadIOMMUGroup(cfg.DevicesDir, dev.BDF)
+			if err != nil {
+				d.Error = err.Error()
+			} else {
+				d.IOMMUGroup = group
+			}
+			o.Devices = append(o.Devices, d)
+		}
+		return o, nil
+	}
+}
@@ -0,0 +1,76 @@
+package iommu
+
+import (
+	"testing"
+
+	"github.com/leptonai/gpud/pkg/pci"
+)
+
+func TestOutputStates(t *testing.T) {
+	tests := []struct {
+		name              string
+		devices           []Device
+		wantMisconfigured int
+	}{
+		{
+			name: "no device",
+		},
+		{
+			name: "passthrough",
+			devices: []Device{
+				{BDF: "0000:05:00.0", Kind: DeviceKindGPU, IOMMUGroup: pci.IOMMUGroup{ID: "25", DomainType: "identity", DMAMode: pci.DMAModePassthrough}},
+				{BDF: "0000:06:00.0", Kind: DeviceKindNIC, IOMMUGroup: pci.IOMMUGroup{ID: "26", DomainType: "identity", DMAMode: pci.DMAModePassthrough}},
+			},
+		},
+		{
+			name: "translated",
+			devices: []Device{
+				{BDF: "0000:05:00.0", Kind: DeviceKindGPU, IOMMUGroup: pci.IOMMUGroup{ID: "25", DomainType: "DMA-FQ", DMAMode: pci.DMAModeTranslated}},
+				{BDF: "0000:06:00.0", Kind: DeviceKindNIC, IOMMUGroup: pci.IOMMUGroup{ID: "26", DomainType: "DMA", DMAMode: pci.DMAModeTranslated}},
+				// bound to vfio-pci
+				{BDF: "0000:07:00.0", Kind: DeviceKindGPU, IOMMUGroup: pci.IOMMUGroup{ID: "27", DomainType: "unmanaged", DMAMode: pci.DMAModeUnmanaged}},
+				{BDF: "0000:08:00.0", Kind: DeviceKindGPU, Error: "permission denied"},
+			},
+			wantMisconfigured: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Output{Devices: tt.devices, AllowedDMAModes: DefaultAllowedDMAModes}
+			if got := len(o.MisconfiguredDevices()); got != tt.wantMisconfigured {
+				t.Errorf("expected %d misconfigured devices, got %d", tt.wantMisconfigured, got)
+			}
+
+			states, err := o.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != 1 || !states[0].Healthy {
+				t.Fatalf("expected 1 healthy state, got %+v", states)
+			}
+			if states[0].Degraded != (tt.wantMisconfigured > 0) {
+				t.Errorf("unexpected degraded %v", states[0].Degraded)
+			}
+
+			parsed, err := ParseStatesToOutput(states...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed.Devices) != len(tt.devices) {
+				t.Errorf("expected %d devices, got %d", len(tt.devices), len(parsed.Devices))
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaultsIfNotSet()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.AllowedDMAModes = []string{"identity"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error for the raw domain type")
+	}
+}
@@ -0,0 +1,63 @@
+package iommu
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/pkg/pci"
+)
+
+// DefaultAllowedDMAModes is the default DMA mapping modes that GPUDirect RDMA works with,
+// either the IOMMU passthrough (e.g., "iommu=pt") or the IOMMU disabled.
+var DefaultAllowedDMAModes = []string{pci.DMAModePassthrough, pci.DMAModeDisabled}
+
+// DefaultCmdlineFile is the file of the kernel command line.
+const DefaultCmdlineFile = "/proc/cmdline"
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// DevicesDir is the sysfs directory of the PCI devices.
+	// If not set, it defaults to "/sys/bus/pci/devices".
+	DevicesDir string `json:"devices_dir"`
+
+	// AllowedDMAModes is the fleet policy of the DMA mapping modes for the GPUs and NICs
+	// ("passthrough", "translated", or "disabled").
+	// If not set, it defaults to "passthrough" and "disabled".
+	AllowedDMAModes []string `json:"allowed_dma_modes"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if len(cfg.AllowedDMAModes) == 0 {
+		cfg.AllowedDMAModes = DefaultAllowedDMAModes
+	}
+}
+
+func (cfg Config) Validate() error {
+	for _, mode := range cfg.AllowedDMAModes {
+		switch mode {
+		case pci.DMAModePassthrough, pci.DMAModeTranslated, pci.DMAModeDisabled:
+		default:
+			return fmt.Errorf("invalid dma mode %q (must be one of %q, %q, %q)", mode, pci.DMAModePassthrough, pci.DMAModeTranslated, pci.DMAModeDisabled)
+		}
+	}
+	return nil
+}
@@ -38,6 +38,19 @@ const (
 	StateKeyBatteryCapacityFound = "battery_capacity_found"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateNamePowerSupply,
+			Description: "power supply battery capacity",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyBatteryCapacity, Type: components.ExtraInfoTypeUint, Unit: "percent", Description: "battery capacity"},
+				{Key: StateKeyBatteryCapacityFound, Type: components.ExtraInfoTypeBool, Description: "true if the battery capacity is found"},
+			},
+		},
+	)
+}
+
 func ParseStatePowerSupply(m map[string]string) (*Output, error) {
 	o := &Output{}
 
@@ -84,6 +84,9 @@ type poller struct {
 
 	bufferedItemsMu sync.RWMutex
 	bufferedItems   []Item
+	// number of the items dropped since the last flush, since the buffer was full
+	// (e.g., log storms)
+	droppedItems int
 }
 
 func New(ctx context.Context, cfg query_log_config.Config, extractTime query_log_common.ExtractTimeFunc, processMatched query_log_common.ProcessMatchedFunc) (Poller, error) {
@@ -129,6 +132,10 @@ func newPoller(ctx context.Context, cfg query_log_config.Config, extractTime que
 		copied := make([]Item, len(pl.bufferedItems))
 		copy(copied, pl.bufferedItems)
 		pl.bufferedItems = pl.bufferedItems[:0]
+		if pl.droppedItems > 0 {
+			log.Logger.Warnw("log buffer full, dropped items", "file", pl.cfg.File, "dropped", pl.droppedItems, "bufferSize", pl.cfg.BufferSize)
+			pl.droppedItems = 0
+		}
 		return copied, nil
 	}
 
@@ -163,8 +170,14 @@ func (pl *poller) pollSync(ctx context.Context) {
 			Error:   line.Err,
 		}
 
+		// bounded by the buffer size, not to grow the memory during the log storms
+		// (the matched lines are still processed by the tailer)
 		pl.bufferedItemsMu.Lock()
-		pl.bufferedItems = append(pl.bufferedItems, item)
+		if len(pl.bufferedItems) < pl.cfg.BufferSize {
+			pl.bufferedItems = append(pl.bufferedItems, item)
+		} else {
+			pl.droppedItems++
+		}
 		pl.bufferedItemsMu.Unlock()
 
 		pl.tailFileSeekInfoMu.Lock()
@@ -0,0 +1,288 @@
+// Package queue implements the bounded in-memory queue of the matched log lines,
+// which batches the event store writes off the log tailer, so that a log storm
+// (e.g., 100k dmesg error lines per minute) cannot block the tailer nor wedge the daemon.
+package queue
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	query_log_common "github.com/leptonai/gpud/components/query/log/common"
+	"github.com/leptonai/gpud/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultCapacity is the default maximum number of the queued lines.
+	DefaultCapacity = 10000
+	// DefaultBatchSize is the default maximum number of the lines per flush.
+	DefaultBatchSize = 500
+	// DefaultFlushInterval is the default interval to flush the queued lines.
+	DefaultFlushInterval = time.Second
+)
+
+type Config struct {
+	// Capacity is the maximum number of the queued lines.
+	// The new lines are dropped and counted when the queue is full,
+	// and the dropped counts are summarized in the next batch.
+	Capacity int `json:"capacity"`
+	// BatchSize is the maximum number of the lines per flush.
+	BatchSize int `json:"batch_size"`
+	// FlushInterval is the interval to flush the queued lines.
+	// The queue is also flushed as soon as a full batch is queued.
+	FlushInterval metav1.Duration `json:"flush_interval"`
+
+	// Compress compresses the queued raw log lines in memory,
+	// to bound the memory usage during the storms (at the cost of CPU).
+	Compress bool `json:"compress"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.Capacity == 0 {
+		cfg.Capacity = DefaultCapacity
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval.Duration == 0 {
+		cfg.FlushInterval.Duration = DefaultFlushInterval
+	}
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Capacity < 0 {
+		return errors.New("queue capacity must be non-negative")
+	}
+	if cfg.BatchSize < 0 {
+		return errors.New("queue batch size must be non-negative")
+	}
+	if cfg.FlushInterval.Duration < 0 {
+		return errors.New("queue flush interval must be non-negative")
+	}
+	return nil
+}
+
+// Line is a matched log line.
+type Line struct {
+	Time   time.Time
+	Line   []byte
+	Filter *query_log_common.Filter
+}
+
+// Batch is the queued lines to flush, in the order of the arrival.
+type Batch struct {
+	Lines []Line
+	// Dropped is the number of the lines dropped since the last batch
+	// because the queue was full, keyed by the matched filter name.
+	Dropped map[string]int
+	// Remaining is the number of the lines still queued after this batch.
+	Remaining int
+}
+
+// DroppedTotal returns the total number of the dropped lines in the batch.
+func (b Batch) DroppedTotal() int {
+	total := 0
+	for _, n := range b.Dropped {
+		total += n
+	}
+	return total
+}
+
+// FlushFunc processes the batch (e.g., writes the events to the store).
+type FlushFunc func(ctx context.Context, b Batch)
+
+// Queue is the bounded in-memory queue of the matched log lines,
+// flushed in batches by a single worker.
+type Queue struct {
+	cfg   Config
+	flush FlushFunc
+
+	mu      sync.Mutex
+	lines   []Line
+	dropped map[string]int
+
+	flushCh chan struct{}
+	doneCh  chan struct{}
+}
+
+// New creates the queue and starts its worker, which flushes the remaining lines
+// and exits when the context is canceled.
+func New(ctx context.Context, cfg Config, flush FlushFunc) *Queue {
+	cfg.SetDefaultsIfNotSet()
+	q := &Queue{
+		cfg:     cfg,
+		flush:   flush,
+		dropped: make(map[string]int),
+		flushCh: make(chan struct{}, 1),
+		doneCh:  make(chan struct{}),
+	}
+	go q.run(ctx)
+	return q
+}
+
+// Push queues the line, or drops and counts it if the queue is full.
+// Returns false if the line is dropped.
+// The line is copied, so the caller may reuse its buffer.
+func (q *Queue) Push(ts time.Time, line []byte, filter *query_log_common.Filter) bool {
+	// checks before copying (or compressing) the line, not to waste the CPU during the storms
+	q.mu.Lock()
+	full := q.dropIfFullLocked(filter)
+	q.mu.Unlock()
+	if full {
+		return false
+	}
+
+	// copies (or compresses) outside the lock, not to block the other producers
+	l := Line{Time: ts, Filter: filter}
+	if q.cfg.Compress {
+		l.Line = compressLine(line)
+	} else {
+		l.Line = append([]byte(nil), line...)
+	}
+
+	q.mu.Lock()
+	if q.dropIfFullLocked(filter) {
+		q.mu.Unlock()
+		return false
+	}
+	q.lines = append(q.lines, l)
+	batched := len(q.lines) >= q.cfg.BatchSize
+	q.mu.Unlock()
+
+	if batched {
+		select {
+		case q.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// dropIfFullLocked counts the line as dropped if the queue is full.
+// Returns true if dropped.
+func (q *Queue) dropIfFullLocked(filter *query_log_common.Filter) bool {
+	if len(q.lines) < q.cfg.Capacity {
+		return false
+	}
+	name := ""
+	if filter != nil {
+		name = filter.Name
+	}
+	q.dropped[name]++
+	return true
+}
+
+// ProcessMatched returns the process matched function that queues the lines,
+// to be passed to the log poller in place of the one that writes to the store.
+func (q *Queue) ProcessMatched() query_log_common.ProcessMatchedFunc {
+	return func(parsedTime time.Time, line []byte, filter *query_log_common.Filter) {
+		q.Push(parsedTime, line, filter)
+	}
+}
+
+// Len returns the number of the queued lines.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.lines)
+}
+
+// Done returns the channel closed when the worker exits.
+func (q *Queue) Done() <-chan struct{} {
+	return q.doneCh
+}
+
+func (q *Queue) run(ctx context.Context) {
+	defer close(q.doneCh)
+
+	ticker := time.NewTicker(q.cfg.FlushInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// flush the remaining lines with a fresh context, since the queue context is done
+			q.drain(context.Background())
+			return
+		case <-ticker.C:
+		case <-q.flushCh:
+		}
+		q.drain(ctx)
+	}
+}
+
+// drain flushes the queued lines in batches until the queue is empty.
+func (q *Queue) drain(ctx context.Context) {
+	for {
+		b, ok := q.next()
+		if !ok {
+			return
+		}
+		if n := b.DroppedTotal(); n > 0 {
+			log.Logger.Warnw("log queue full, dropped lines", "dropped", n, "perFilter", b.Dropped)
+		}
+		q.flush(ctx, b)
+	}
+}
+
+// next dequeues the next batch.
+// Returns false if there is no line nor dropped count to flush.
+func (q *Queue) next() (Batch, bool) {
+	q.mu.Lock()
+	n := len(q.lines)
+	if n > q.cfg.BatchSize {
+		n = q.cfg.BatchSize
+	}
+	b := Batch{Lines: make([]Line, n)}
+	copy(b.Lines, q.lines[:n])
+	q.lines = append(q.lines[:0], q.lines[n:]...)
+	b.Remaining = len(q.lines)
+	if len(q.dropped) > 0 {
+		b.Dropped = q.dropped
+		q.dropped = make(map[string]int)
+	}
+	q.mu.Unlock()
+
+	if len(b.Lines) == 0 && len(b.Dropped) == 0 {
+		return Batch{}, false
+	}
+	if q.cfg.Compress {
+		for i := range b.Lines {
+			b.Lines[i].Line = decompressLine(b.Lines[i].Line)
+		}
+	}
+	return b, true
+}
+
+var flateWriters = sync.Pool{
+	New: func() any {
+		w, _ := flate.NewWriter(nil, flate.BestSpeed)
+		return w
+	},
+}
+
+func compressLine(line []byte) []byte {
+	var buf bytes.Buffer
+	w := flateWriters.Get().(*flate.Writer)
+	w.Reset(&buf)
+	_, _ = w.Write(line)
+	_ = w.Close()
+	flateWriters.Put(w)
+	return buf.Bytes()
+}
+
+func decompressLine(b []byte) []byte {
+	r := flate.NewReader(bytes.NewReader(b))
+	defer r.Close()
+	line, err := io.ReadAll(r)
+	if err != nil {
+		log.Logger.Warnw("failed to decompress queued line", "error", err)
+	}
+	return line
+}
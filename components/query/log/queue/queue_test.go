@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	query_log_common "github.com/leptonai/gpud/components/query/log/common"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQueue(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compress=%v", compress), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			var (
+				mu      sync.Mutex
+				lines   []string
+				batches int
+				dropped = make(map[string]int)
+			)
+			q := New(ctx, Config{
+				Capacity:      10,
+				BatchSize:     4,
+				FlushInterval: metav1.Duration{Duration: time.Hour},
+				Compress:      compress,
+			}, func(_ context.Context, b Batch) {
+				mu.Lock()
+				defer mu.Unlock()
+				if len(b.Lines) > 4 {
+					t.Errorf("batch size %d exceeds 4", len(b.Lines))
+				}
+				batches++
+				for _, l := range b.Lines {
+					lines = append(lines, string(l.Line))
+				}
+				for k, v := range b.Dropped {
+					dropped[k] += v
+				}
+			})
+
+			filter := &query_log_common.Filter{Name: "xid"}
+			buf := make([]byte, 0, 16)
+			pushed := 0
+			for i := 0; i < 3; i++ {
+				buf = append(buf[:0], fmt.Sprintf("line %d", i)...)
+				if q.Push(time.Now(), buf, filter) {
+					pushed++
+				}
+			}
+			if pushed != 3 {
+				t.Fatalf("expected 3 pushed, got %d", pushed)
+			}
+
+			// fills up the queue without signaling the worker (long flush interval)
+			filler := []byte("filler")
+			if compress {
+				filler = compressLine(filler)
+			}
+			q.mu.Lock()
+			for len(q.lines) < 10 {
+				q.lines = append(q.lines, Line{Line: filler})
+			}
+			q.mu.Unlock()
+			for i := 0; i < 5; i++ {
+				if q.Push(time.Now(), []byte("storm"), filter) {
+					t.Fatal("expected the line dropped when the queue is full")
+				}
+			}
+
+			cancel()
+			<-q.Done()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(lines) != 10 {
+				t.Fatalf("expected 10 flushed lines, got %d", len(lines))
+			}
+			for i := 0; i < 3; i++ {
+				if want := fmt.Sprintf("line %d", i); lines[i] != want {
+					t.Errorf("line %d: expected %q, got %q", i, want, lines[i])
+				}
+			}
+			if lines[9] != "filler" {
+				t.Errorf("expected filler, got %q", lines[9])
+			}
+			if batches < 3 {
+				t.Errorf("expected at least 3 batches, got %d", batches)
+			}
+			if dropped["xid"] != 5 {
+				t.Errorf("expected 5 dropped xid lines, got %v", dropped)
+			}
+		})
+	}
+}
+
+func TestCompress(t *testing.T) {
+	line := []byte("NVRM: Xid (PCI:0000:9b:00): 79, pid=0, GPU has fallen off the bus.")
+	if got := decompressLine(compressLine(line)); string(got) != string(line) {
+		t.Fatalf("expected %q, got %q", line, got)
+	}
+}
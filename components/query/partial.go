@@ -0,0 +1,75 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PartialError is returned by the get function along with the partial output,
+// when some fields of the output fail to be collected while the others succeed.
+// The poller then keeps both the output and the error, instead of discarding
+// the whole output (e.g., the OS platform info is still reported even if the uptime fails).
+type PartialError struct {
+	// Errors is the error of each failed field, keyed by the field name.
+	Errors map[string]error
+}
+
+// Add records the error of the field.
+// No-op if the error is nil.
+func (e *PartialError) Add(field string, err error) {
+	if err == nil {
+		return
+	}
+	if e.Errors == nil {
+		e.Errors = make(map[string]error)
+	}
+	e.Errors[field] = err
+}
+
+// Err returns the partial error, or nil if no field failed.
+func (e *PartialError) Err() error {
+	if e == nil || len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Messages returns the error messages keyed by the field name (e.g., to embed in the output).
+// Returns nil if no field failed.
+func (e *PartialError) Messages() map[string]string {
+	if e == nil || len(e.Errors) == 0 {
+		return nil
+	}
+	msgs := make(map[string]string, len(e.Errors))
+	for field, err := range e.Errors {
+		msgs[field] = err.Error()
+	}
+	return msgs
+}
+
+func (e *PartialError) Error() string {
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	msgs := make([]string, 0, len(fields))
+	for _, field := range fields {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", field, e.Errors[field]))
+	}
+	return fmt.Sprintf("partial output (%d field(s) failed: %s)", len(fields), strings.Join(msgs, "; "))
+}
+
+// IsPartial returns true if the item has the partial output,
+// with some fields failed to be collected.
+func (item *Item) IsPartial() bool {
+	return isPartial(item.Output, item.Error)
+}
+
+func isPartial(output any, err error) bool {
+	var pe *PartialError
+	return output != nil && errors.As(err, &pe)
+}
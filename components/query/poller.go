@@ -3,17 +3,33 @@ package query
 import (
 	"context"
 	"errors"
+	"fmt"
+	"runtime/debug"
 	"sync"
 	"time"
 
 	query_config "github.com/leptonai/gpud/components/query/config"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/lifecycle"
+	"github.com/leptonai/gpud/pkg/lowoverhead"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var ErrNoData = errors.New("no data collected yet in the poller")
 
+var (
+	// ErrPanic wraps the panic recovered from the get function,
+	// so that one buggy collector does not take down the whole daemon.
+	ErrPanic = errors.New("poller get function panicked")
+	// ErrCircuitOpen is returned once the poller is disabled after the repeated panics.
+	ErrCircuitOpen = errors.New("poller disabled after repeated panics")
+)
+
+// MaxConsecutivePanics is the number of the consecutive panics
+// after which the poller stops calling the get function.
+const MaxConsecutivePanics = 3
+
 // Defines the common query/poller interface.
 // It polls the data source (rather than watch) in order
 // to share the same data source with multiple components (consumer).
@@ -44,6 +60,15 @@ type Poller interface {
 	All(since time.Time) ([]Item, error)
 }
 
+// Pusher is implemented by the poller that also accepts the items
+// pushed by the event-driven sources (e.g., NVML event sets),
+// so that the events are captured as soon as they fire, instead of at the poll boundaries.
+type Pusher interface {
+	// Push inserts the item to the poller queue.
+	// No-op if the poller is not started.
+	Push(item Item)
+}
+
 // Item is the basic unit of data that poller returns.
 // If enabled, each result is persisted in the storage.
 type Item struct {
@@ -58,6 +83,8 @@ type Item struct {
 
 // Queries the component data from the host.
 // Each get output is persisted to the storage if enabled.
+// It may return the partial output along with the *PartialError,
+// in which case the item keeps both (see Item.IsPartial).
 type GetFunc func(context.Context) (any, error)
 
 func New(id string, cfg query_config.Config, getFunc GetFunc) Poller {
@@ -103,6 +130,8 @@ func pollLoops(ctx context.Context, id string, ch chan<- Item, interval time.Dur
 	// to get output very first time and start wait
 	ticker := time.NewTicker(1)
 	defer ticker.Stop()
+
+	panics := 0
 	for {
 		select {
 		case <-ctx.Done():
@@ -117,13 +146,30 @@ func pollLoops(ctx context.Context, id string, ch chan<- Item, interval time.Dur
 			return
 
 		case <-ticker.C:
-			ticker.Reset(interval)
+			ticker.Reset(lifecycle.PollInterval(lowoverhead.PollInterval(interval)))
 		}
 
 		log.Logger.Debugw("polling", "id", id)
 
-		output, err := get(ctx)
-		if err != nil {
+		output, err := safeGet(ctx, id, get)
+		if errors.Is(err, ErrPanic) {
+			panics++
+		} else {
+			panics = 0
+		}
+		if panics >= MaxConsecutivePanics {
+			log.Logger.Errorw("disabling poller after repeated panics", "id", id, "panics", panics, "error", err)
+			select {
+			case ch <- Item{
+				Time:  metav1.Time{Time: time.Now().UTC()},
+				Error: fmt.Errorf("%w (%d consecutive panics, last %v)", ErrCircuitOpen, panics, err),
+			}:
+			default:
+				log.Logger.Debugw("channel is full, skip this result and continue")
+			}
+			return
+		}
+		if err != nil && !isPartial(output, err) {
 			log.Logger.Debugw("polling error", "id", id, "error", err)
 			select {
 			case <-ctx.Done():
@@ -149,6 +195,7 @@ func pollLoops(ctx context.Context, id string, ch chan<- Item, interval time.Dur
 		case ch <- Item{
 			Time:   metav1.Time{Time: time.Now().UTC()},
 			Output: output,
+			Error:  err, // only set for the partial output
 		}:
 		default:
 			log.Logger.Debugw("channel is full, skip this result and continue")
@@ -156,6 +203,18 @@ func pollLoops(ctx context.Context, id string, ch chan<- Item, interval time.Dur
 	}
 }
 
+// safeGet calls the get function, and recovers the panic as an error.
+func safeGet(ctx context.Context, id string, get GetFunc) (output any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Logger.Errorw("recovered panic in poller", "id", id, "panic", r, "stack", string(debug.Stack()))
+			output = nil
+			err = fmt.Errorf("%w: %v", ErrPanic, r)
+		}
+	}()
+	return get(ctx)
+}
+
 func (pl *poller) ID() string {
 	return pl.id
 }
@@ -176,6 +235,7 @@ func (pl *poller) Start(ctx context.Context, cfg query_config.Config, componentN
 	pl.cfg = cfg
 
 	pl.inflightComponents[componentName] = struct{}{}
+	register(componentName, pl)
 	started := pl.ctx != nil
 	if started {
 		return
@@ -208,6 +268,7 @@ func (pl *poller) Stop(componentName string) bool {
 		panic("inflightComponents is 0 but poller context is set -- should never happen")
 	}
 	delete(pl.inflightComponents, componentName)
+	unregister(componentName, pl)
 
 	// do not cancel if there's any inflight component "after" this
 	if len(pl.inflightComponents) > 0 {
@@ -223,6 +284,12 @@ func (pl *poller) Stop(componentName string) bool {
 	return true
 }
 
+var _ Pusher = (*poller)(nil)
+
+func (pl *poller) Push(item Item) {
+	pl.processItem(item)
+}
+
 func (pl *poller) processItem(item Item) {
 	pl.ctxMu.RLock()
 	canceled := pl.ctx == nil
@@ -2,6 +2,7 @@ package query
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -162,6 +163,10 @@ func TestPollerStartStop(t *testing.T) {
 	q.Start(ctx, query_config.Config{Interval: metav1.Duration{Duration: time.Second}}, "test2")
 	q.Start(ctx, query_config.Config{Interval: metav1.Duration{Duration: time.Second}}, "test3")
 
+	if p, ok := GetPoller("test1"); !ok || p != q {
+		t.Errorf("expected the poller registered for test1, got %v, %v", p, ok)
+	}
+
 	q.cancel = context.CancelFunc(func() {
 		t.Log("cancel called")
 		cancelCalled++
@@ -171,6 +176,12 @@ func TestPollerStartStop(t *testing.T) {
 	if canceled {
 		t.Errorf("expected cancel to be called, got true")
 	}
+	if _, ok := GetPoller("test1"); ok {
+		t.Errorf("expected the poller unregistered for test1")
+	}
+	if _, ok := GetPoller("test2"); !ok {
+		t.Errorf("expected the poller registered for test2")
+	}
 	// do not cancel if there's an inflight
 	if cancelCalled != 0 {
 		t.Errorf("expected cancel to be called 0 time, got %d", cancelCalled)
@@ -222,3 +233,175 @@ func TestPollerStartStop(t *testing.T) {
 		t.Errorf("expected startFunc to be called 1 time, got %d", startFuncCalled)
 	}
 }
+
+func TestPollLoopsPanicCircuitBreaker(t *testing.T) {
+	calls := 0
+	get := func(ctx context.Context) (any, error) {
+		calls++
+		panic("buggy collector")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ch := make(chan Item, 10)
+	done := make(chan struct{})
+	go func() {
+		pollLoops(ctx, "test", ch, time.Millisecond, get)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("expected poll loops to stop after repeated panics")
+	}
+
+	if calls != MaxConsecutivePanics {
+		t.Fatalf("expected %d calls, got %d", MaxConsecutivePanics, calls)
+	}
+	close(ch)
+
+	var items []Item
+	for item := range ch {
+		items = append(items, item)
+	}
+	if len(items) != MaxConsecutivePanics {
+		t.Fatalf("expected %d items, got %d", MaxConsecutivePanics, len(items))
+	}
+	for _, item := range items[:len(items)-1] {
+		if !errors.Is(item.Error, ErrPanic) {
+			t.Errorf("expected panic error, got %v", item.Error)
+		}
+	}
+	if last := items[len(items)-1]; !errors.Is(last.Error, ErrCircuitOpen) {
+		t.Errorf("expected circuit open error, got %v", last.Error)
+	}
+}
+
+func TestPollLoopsPanicRecovers(t *testing.T) {
+	calls := 0
+	get := func(ctx context.Context) (any, error) {
+		calls++
+		// panics are not consecutive
+		if calls%2 == 1 {
+			panic("flaky collector")
+		}
+		return calls, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Item, 100)
+	done := make(chan struct{})
+	go func() {
+		pollLoops(ctx, "test", ch, time.Millisecond, get)
+		close(done)
+	}()
+
+	outputs := 0
+	for item := range ch {
+		if errors.Is(item.Error, ErrCircuitOpen) {
+			t.Fatal("unexpected circuit open")
+		}
+		if item.Output != nil {
+			outputs++
+		}
+		if outputs == 3 {
+			break
+		}
+	}
+	cancel()
+	<-done
+}
+
+func TestPollLoopsPartialOutput(t *testing.T) {
+	get := func(ctx context.Context) (any, error) {
+		pe := &PartialError{}
+		pe.Add("uptimes", errors.New("uptime failed"))
+		return "platform", pe.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Item, 10)
+	done := make(chan struct{})
+	go func() {
+		pollLoops(ctx, "test", ch, time.Hour, get)
+		close(done)
+	}()
+
+	item := <-ch
+	cancel()
+	<-done
+
+	if !item.IsPartial() {
+		t.Fatalf("expected partial item, got %+v", item)
+	}
+	if item.Output != "platform" {
+		t.Errorf("expected output kept, got %v", item.Output)
+	}
+	var pe *PartialError
+	if !errors.As(item.Error, &pe) || pe.Messages()["uptimes"] != "uptime failed" {
+		t.Errorf("expected uptimes error, got %v", item.Error)
+	}
+}
+
+func TestPartialError(t *testing.T) {
+	pe := &PartialError{}
+	pe.Add("host", nil)
+	if pe.Err() != nil {
+		t.Fatalf("expected no error, got %v", pe.Err())
+	}
+	if pe.Messages() != nil {
+		t.Fatalf("expected no messages, got %v", pe.Messages())
+	}
+
+	pe.Add("uptimes", errors.New("b"))
+	pe.Add("kernel", errors.New("a"))
+	if want := "partial output (2 field(s) failed: kernel: a; uptimes: b)"; pe.Error() != want {
+		t.Errorf("expected %q, got %q", want, pe.Error())
+	}
+
+	if (&Item{Error: pe}).IsPartial() {
+		t.Error("expected not partial without output")
+	}
+	if (&Item{Output: 1, Error: errors.New("failed")}).IsPartial() {
+		t.Error("expected not partial with non-partial error")
+	}
+}
+
+func TestPollerPush(t *testing.T) {
+	q := &poller{
+		startPollFunc: func(ctx context.Context, id string, interval time.Duration, _ GetFunc) <-chan Item {
+			return make(<-chan Item)
+		},
+		inflightComponents: make(map[string]any),
+	}
+
+	// not started yet
+	q.Push(Item{Output: 1})
+	if _, err := q.Last(); err != ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx, query_config.Config{Interval: metav1.Duration{Duration: time.Hour}, QueueSize: 2}, "test")
+
+	for i := 0; i < 3; i++ {
+		q.Push(Item{Time: metav1.Time{Time: time.Now()}, Output: i})
+	}
+	last, err := q.Last()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Output != 2 {
+		t.Errorf("expected last output 2, got %v", last.Output)
+	}
+	items, err := q.All(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Errorf("expected 2 items (queue size), got %d", len(items))
+	}
+}
@@ -0,0 +1,34 @@
+package query
+
+import "sync"
+
+var (
+	registryMu sync.RWMutex
+	// the started pollers by the component name,
+	// multiple components may share the same poller (e.g., the NVIDIA query poller)
+	registry = make(map[string]Poller)
+)
+
+func register(componentName string, p Poller) {
+	registryMu.Lock()
+	registry[componentName] = p
+	registryMu.Unlock()
+}
+
+func unregister(componentName string, p Poller) {
+	registryMu.Lock()
+	if registry[componentName] == p {
+		delete(registry, componentName)
+	}
+	registryMu.Unlock()
+}
+
+// GetPoller returns the poller started by the component,
+// to debug the raw output of the last get call before it is converted to the states.
+// Returns false if the component has not started a poller (e.g., the event-driven components).
+func GetPoller(componentName string) (Poller, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := registry[componentName]
+	return p, ok
+}
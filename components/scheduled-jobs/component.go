@@ -0,0 +1,387 @@
+// Package scheduledjobs runs the periodic active probes (e.g., weekly DCGM diagnostics, monthly SMART long tests)
+// on the cron-like schedules, and records the results as events.
+package scheduledjobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/cron"
+	"github.com/leptonai/gpud/pkg/probegate"
+	"github.com/leptonai/gpud/pkg/warmstate"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const Name = "scheduled-jobs"
+
+const (
+	checkInterval  = time.Minute
+	runsRetention  = 90 * 24 * time.Hour
+	maxOutputBytes = 4096
+)
+
+const (
+	EventKeyJob      = "job"
+	EventKeyDuration = "duration"
+	EventKeyOutput   = "output"
+)
+
+const (
+	StateKeySchedule = "schedule"
+	StateKeyRunning  = "running"
+	StateKeyNextRun  = "next_run"
+	StateKeyLastRun  = "last_run"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			// the states are named after the user-defined jobs
+			Name:        "",
+			NamePrefix:  true,
+			Description: "job status, one state per job named after the job",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeySchedule, Type: components.ExtraInfoTypeString, Description: "cron schedule of the job"},
+				{Key: StateKeyRunning, Type: components.ExtraInfoTypeBool, Description: "true if the job is running"},
+				{Key: StateKeyNextRun, Type: components.ExtraInfoTypeString, Description: "next run time (RFC3339)"},
+				{Key: StateKeyLastRun, Type: components.ExtraInfoTypeString, Description: "last run time (RFC3339)"},
+			},
+		},
+	)
+}
+
+func New(ctx context.Context, cfg Config) (components.Component, error) {
+	cfg.SetDefaultsIfNotSet()
+
+	var db *sql.DB
+	if cfg.Query.State != nil {
+		db = cfg.Query.State.DB
+	}
+	if db == nil {
+		return nil, fmt.Errorf("%s requires the state database", Name)
+	}
+	if err := CreateTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	schedules := make(map[string]*cron.Schedule, len(cfg.Jobs))
+	for _, job := range cfg.Jobs {
+		s, err := cron.Parse(job.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("job %q has invalid schedule: %w", job.Name, err)
+		}
+		schedules[job.Name] = s
+	}
+
+	// the jobs also run on the CPU-only nodes, where the GPU loads are not available
+	// (checking them would keep the node busy forever)
+	var gpuLoads probegate.GPULoadsFunc
+	nvidiaInstalled, err := nvidia_query.GPUsInstalled(ctx)
+	if err != nil {
+		// keep checking the GPU loads, so the jobs never run on a busy GPU node
+		log.Logger.Warnw("failed to check nvidia gpu installed, checking the gpu loads", "error", err)
+	}
+	if nvidiaInstalled || err != nil {
+		gpuLoads = nvidia_query_nvml.GetGPULoads
+	}
+
+	cctx, ccancel := context.WithCancel(ctx)
+	c := &component{
+		cancel:    ccancel,
+		cfg:       cfg,
+		db:        db,
+		schedules: schedules,
+		gate:      probegate.New(cfg.Gate, gpuLoads),
+		running:   make(map[string]bool),
+		next:      make(map[string]time.Time),
+	}
+	go c.schedule(cctx)
+	return c, nil
+}
+
+var _ components.Component = (*component)(nil)
+
+type component struct {
+	cancel context.CancelFunc
+	cfg    Config
+	db     *sql.DB
+
+	schedules map[string]*cron.Schedule
+	gate      *probegate.Gate
+
+	mu      sync.RWMutex
+	running map[string]bool
+	next    map[string]time.Time
+}
+
+func (c *component) Name() string { return Name }
+
+func (c *component) States(ctx context.Context) ([]components.State, error) {
+	lastRuns, err := ReadLastRuns(ctx, c.db)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	states := make([]components.State, 0, len(c.cfg.Jobs))
+	for _, job := range c.cfg.Jobs {
+		state := components.State{
+			Name:    job.Name,
+			Healthy: true,
+			ExtraInfo: map[string]string{
+				StateKeySchedule: job.Schedule,
+				StateKeyRunning:  fmt.Sprintf("%v", c.running[job.Name]),
+			},
+		}
+		if next := c.next[job.Name]; !next.IsZero() {
+			state.ExtraInfo[StateKeyNextRun] = next.UTC().Format(time.RFC3339)
+		}
+
+		run, ok := lastRuns[job.Name]
+		if !ok {
+			state.Reason = "no run yet"
+			states = append(states, state)
+			continue
+		}
+		state.ExtraInfo[StateKeyLastRun] = time.Unix(run.UnixSeconds, 0).UTC().Format(time.RFC3339)
+		state.Reason = fmt.Sprintf("last run %s (took %.0fs)", run.Status, run.DurationSeconds)
+		if run.Status == RunStatusFailed {
+			state.Healthy = false
+			state.Error = run.Output
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	runs, err := ReadRuns(ctx, c.db, since)
+	if err != nil {
+		return nil, err
+	}
+
+	evs := make([]components.Event, 0, len(runs))
+	for _, run := range runs {
+		ev := components.Event{
+			Time:    metav1.Time{Time: time.Unix(run.UnixSeconds, 0).UTC()},
+			Name:    "job_" + run.Status,
+			Type:    components.EventTypeInfo,
+			Message: fmt.Sprintf("job %q %s", run.Job, run.Status),
+			ExtraInfo: map[string]string{
+				EventKeyJob:      run.Job,
+				EventKeyDuration: (time.Duration(run.DurationSeconds * float64(time.Second))).String(),
+				EventKeyOutput:   run.Output,
+			},
+		}
+		switch run.Status {
+		case RunStatusFailed:
+			ev.Type = components.EventTypeError
+		case RunStatusSkipped:
+			ev.Type = components.EventTypeWarn
+			ev.Message = fmt.Sprintf("job %q skipped: %s", run.Job, run.Output)
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	return nil, nil
+}
+
+func (c *component) Close() error {
+	log.Logger.Debugw("closing component", "component", Name)
+	c.cancel()
+	return nil
+}
+
+// schedule checks the due jobs every minute, and runs them in the background
+// (at most one run per job at a time).
+func (c *component) schedule(ctx context.Context) {
+	now := time.Now()
+	saved := c.loadTimers(ctx)
+	c.mu.Lock()
+	for _, job := range c.cfg.Jobs {
+		// resume the timer of the unchanged schedule, so a run due (or deferred)
+		// during the restart is not skipped until the next schedule
+		if t, ok := saved[job.Name]; ok && t.Schedule == job.Schedule && !t.Next.IsZero() {
+			c.next[job.Name] = t.Next
+			continue
+		}
+		c.next[job.Name] = c.schedules[job.Name].Next(now)
+	}
+	c.mu.Unlock()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	lastPurge := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		for _, job := range c.cfg.Jobs {
+			c.checkJob(ctx, job, now)
+		}
+		c.saveTimers(ctx)
+
+		if now.Sub(lastPurge) > 24*time.Hour {
+			lastPurge = now
+			if _, err := Purge(ctx, c.db, now.Add(-runsRetention)); err != nil {
+				log.Logger.Warnw("failed to purge scheduled job runs", "error", err)
+			}
+		}
+	}
+}
+
+func (c *component) checkJob(ctx context.Context, job Job, now time.Time) {
+	c.mu.Lock()
+	next := c.next[job.Name]
+	if next.IsZero() || now.Before(next) {
+		c.mu.Unlock()
+		return
+	}
+
+	if c.running[job.Name] {
+		c.next[job.Name] = c.schedules[job.Name].Next(now)
+		c.mu.Unlock()
+		c.recordSkipped(ctx, job, now, "previous run still in progress")
+		return
+	}
+
+	if job.RequireIdle {
+		if idle, reason := c.gate.Check(ctx); !idle {
+			if now.Sub(next) < job.MaxDelay.Duration {
+				// retry in the next check
+				c.mu.Unlock()
+				log.Logger.Debugw("node busy -- deferring scheduled job", "job", job.Name, "reason", reason)
+				return
+			}
+			c.next[job.Name] = c.schedules[job.Name].Next(now)
+			c.mu.Unlock()
+			c.recordSkipped(ctx, job, now, fmt.Sprintf("node busy for %v (%s)", job.MaxDelay.Duration, reason))
+			return
+		}
+	}
+
+	c.running[job.Name] = true
+	c.next[job.Name] = c.schedules[job.Name].Next(now)
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running[job.Name] = false
+			c.mu.Unlock()
+		}()
+		c.run(ctx, job)
+	}()
+}
+
+func (c *component) run(ctx context.Context, job Job) {
+	log.Logger.Infow("running scheduled job", "job", job.Name, "command", job.Command)
+
+	start := time.Now().UTC()
+	var b []byte
+	probe := func(pctx context.Context) error {
+		cctx, ccancel := context.WithTimeout(pctx, job.Timeout.Duration)
+		defer ccancel()
+
+		var err error
+		b, err = exec.CommandContext(cctx, "bash", "-c", job.Command).CombinedOutput()
+		return err
+	}
+	var err error
+	if job.RequireIdle {
+		err = c.gate.Run(ctx, probe)
+	} else {
+		err = probe(ctx)
+	}
+	took := time.Since(start)
+
+	out := strings.TrimSpace(string(b))
+	if len(out) > maxOutputBytes {
+		out = out[len(out)-maxOutputBytes:]
+	}
+
+	run := Run{
+		UnixSeconds:     start.Unix(),
+		Job:             job.Name,
+		Status:          RunStatusSucceeded,
+		DurationSeconds: took.Seconds(),
+		Output:          out,
+	}
+	switch {
+	case errors.Is(err, probegate.ErrAborted):
+		// not a probe failure, the node became busy
+		log.Logger.Warnw("scheduled job aborted", "job", job.Name, "error", err)
+		run.Status = RunStatusSkipped
+		run.Output = fmt.Sprintf("%v\n%s", err, out)
+	case err != nil:
+		log.Logger.Warnw("scheduled job failed", "job", job.Name, "error", err)
+		run.Status = RunStatusFailed
+		run.Output = fmt.Sprintf("%v\n%s", err, out)
+	}
+	if err := InsertRun(ctx, c.db, run); err != nil {
+		log.Logger.Warnw("failed to record scheduled job run", "job", job.Name, "error", err)
+	}
+}
+
+func (c *component) recordSkipped(ctx context.Context, job Job, now time.Time, reason string) {
+	log.Logger.Warnw("skipping scheduled job", "job", job.Name, "reason", reason)
+	if err := InsertRun(ctx, c.db, Run{
+		UnixSeconds: now.UTC().Unix(),
+		Job:         job.Name,
+		Status:      RunStatusSkipped,
+		Output:      reason,
+	}); err != nil {
+		log.Logger.Warnw("failed to record scheduled job run", "job", job.Name, "error", err)
+	}
+}
+
+// timersStateKey is the warm state key of the job timers.
+const timersStateKey = "scheduled-jobs/timers"
+
+// jobTimer is the next run time of a job persisted across the restarts.
+type jobTimer struct {
+	Schedule string    `json:"schedule"`
+	Next     time.Time `json:"next"`
+}
+
+func (c *component) loadTimers(ctx context.Context) map[string]jobTimer {
+	timers := make(map[string]jobTimer)
+	if _, err := warmstate.Load(ctx, c.db, timersStateKey, warmstate.DefaultMaxAge, &timers); err != nil {
+		log.Logger.Warnw("failed to load scheduled job timers", "error", err)
+	}
+	return timers
+}
+
+func (c *component) saveTimers(ctx context.Context) {
+	c.mu.RLock()
+	timers := make(map[string]jobTimer, len(c.cfg.Jobs))
+	for _, job := range c.cfg.Jobs {
+		timers[job.Name] = jobTimer{Schedule: job.Schedule, Next: c.next[job.Name]}
+	}
+	c.mu.RUnlock()
+
+	if err := warmstate.Save(ctx, c.db, timersStateKey, timers, time.Now()); err != nil {
+		log.Logger.Warnw("failed to save scheduled job timers", "error", err)
+	}
+}
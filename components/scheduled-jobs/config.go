@@ -0,0 +1,104 @@
+package scheduledjobs
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/pkg/cron"
+	"github.com/leptonai/gpud/pkg/probegate"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultJobTimeout = time.Hour
+	DefaultMaxDelay   = 6 * time.Hour
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	Jobs []Job `json:"jobs"`
+
+	// Gate defines when the node is busy for the jobs that require the idle node.
+	Gate probegate.Config `json:"gate"`
+}
+
+// Job is a periodic active task (e.g., weekly "dcgmi diag -r 2", nightly bandwidth test).
+type Job struct {
+	Name string `json:"name"`
+	// Cron expression in the local time (e.g., "0 3 * * 0" for every Sunday 03:00, "@weekly").
+	Schedule string `json:"schedule"`
+	// Command to run with "bash -c".
+	Command string `json:"command"`
+	// Timeout of the command, defaults to 1 hour.
+	Timeout metav1.Duration `json:"timeout"`
+
+	// Set true to only run when the node is idle (see the gate config).
+	// If the node is busy at the scheduled time, the job is retried every minute
+	// until the max delay elapses, then skipped until the next scheduled time.
+	// A running job is aborted if a training job starts on the node.
+	RequireIdle bool `json:"require_idle"`
+	// Maximum delay to wait for the idle node, defaults to 6 hours.
+	MaxDelay metav1.Duration `json:"max_delay"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	cfg.Gate.SetDefaultsIfNotSet()
+	for i := range cfg.Jobs {
+		if cfg.Jobs[i].Timeout.Duration == 0 {
+			cfg.Jobs[i].Timeout = metav1.Duration{Duration: DefaultJobTimeout}
+		}
+		if cfg.Jobs[i].MaxDelay.Duration == 0 {
+			cfg.Jobs[i].MaxDelay = metav1.Duration{Duration: DefaultMaxDelay}
+		}
+	}
+}
+
+func (cfg Config) Validate() error {
+	if err := cfg.Gate.Validate(); err != nil {
+		return fmt.Errorf("invalid gate: %w", err)
+	}
+
+	names := make(map[string]struct{}, len(cfg.Jobs))
+	for _, job := range cfg.Jobs {
+		if job.Name == "" {
+			return errors.New("job name is required")
+		}
+		if _, ok := names[job.Name]; ok {
+			return fmt.Errorf("duplicate job %q", job.Name)
+		}
+		names[job.Name] = struct{}{}
+
+		if job.Command == "" {
+			return fmt.Errorf("job %q command is required", job.Name)
+		}
+		if _, err := cron.Parse(job.Schedule); err != nil {
+			return fmt.Errorf("job %q has invalid schedule: %w", job.Name, err)
+		}
+		if job.MaxDelay.Duration < 0 {
+			return fmt.Errorf("job %q max_delay must be non-negative", job.Name)
+		}
+	}
+	return nil
+}
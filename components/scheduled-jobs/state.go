@@ -0,0 +1,139 @@
+package scheduledjobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const TableNameJobRuns = "components_scheduled_jobs_runs"
+
+const (
+	ColumnUnixSeconds     = "unix_seconds"
+	ColumnJob             = "job"
+	ColumnStatus          = "status"
+	ColumnDurationSeconds = "duration_seconds"
+	ColumnOutput          = "output"
+)
+
+const (
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+	RunStatusSkipped   = "skipped"
+)
+
+// Run is the result of a scheduled job run.
+type Run struct {
+	UnixSeconds     int64
+	Job             string
+	Status          string
+	DurationSeconds float64
+	// Tail of the command output, or the reason if skipped.
+	Output string
+}
+
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s REAL NOT NULL,
+	%s TEXT
+);`, TableNameJobRuns,
+		ColumnUnixSeconds,
+		ColumnJob,
+		ColumnStatus,
+		ColumnDurationSeconds,
+		ColumnOutput,
+	))
+	return err
+}
+
+func InsertRun(ctx context.Context, db *sql.DB, run Run) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?);`,
+		TableNameJobRuns,
+		ColumnUnixSeconds,
+		ColumnJob,
+		ColumnStatus,
+		ColumnDurationSeconds,
+		ColumnOutput,
+	), run.UnixSeconds, run.Job, run.Status, run.DurationSeconds, run.Output)
+	return err
+}
+
+// ReadRuns returns the runs since the given time in the ascending order of the run time.
+func ReadRuns(ctx context.Context, db *sql.DB, since time.Time) ([]Run, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT %s, %s, %s, %s, COALESCE(%s, '') FROM %s WHERE %s >= ? ORDER BY %s ASC;`,
+		ColumnUnixSeconds,
+		ColumnJob,
+		ColumnStatus,
+		ColumnDurationSeconds,
+		ColumnOutput,
+		TableNameJobRuns,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+	), since.UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var run Run
+		if err := rows.Scan(&run.UnixSeconds, &run.Job, &run.Status, &run.DurationSeconds, &run.Output); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// ReadLastRuns returns the last non-skipped run of each job.
+func ReadLastRuns(ctx context.Context, db *sql.DB) (map[string]Run, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT MAX(%s), %s, %s, %s, COALESCE(%s, '') FROM %s WHERE %s != ? GROUP BY %s;`,
+		ColumnUnixSeconds,
+		ColumnJob,
+		ColumnStatus,
+		ColumnDurationSeconds,
+		ColumnOutput,
+		TableNameJobRuns,
+		ColumnStatus,
+		ColumnJob,
+	), RunStatusSkipped)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make(map[string]Run)
+	for rows.Next() {
+		var run Run
+		if err := rows.Scan(&run.UnixSeconds, &run.Job, &run.Status, &run.DurationSeconds, &run.Output); err != nil {
+			return nil, err
+		}
+		runs[run.Job] = run
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// Purge deletes the runs before the given time.
+func Purge(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?;`, TableNameJobRuns, ColumnUnixSeconds), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
@@ -0,0 +1,69 @@
+package scheduledjobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestInsertAndReadRuns(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTable(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	now := time.Now().UTC()
+	runs := []Run{
+		{UnixSeconds: now.Add(-3 * time.Hour).Unix(), Job: "dcgm-diag", Status: RunStatusFailed, DurationSeconds: 60, Output: "exit status 1"},
+		{UnixSeconds: now.Add(-2 * time.Hour).Unix(), Job: "dcgm-diag", Status: RunStatusSucceeded, DurationSeconds: 55},
+		{UnixSeconds: now.Add(-time.Hour).Unix(), Job: "dcgm-diag", Status: RunStatusSkipped, Output: "gpus not idle"},
+		{UnixSeconds: now.Add(-time.Hour).Unix(), Job: "smart-long", Status: RunStatusFailed, DurationSeconds: 10},
+	}
+	for _, run := range runs {
+		if err := InsertRun(ctx, db, run); err != nil {
+			t.Fatalf("InsertRun failed: %v", err)
+		}
+	}
+
+	read, err := ReadRuns(ctx, db, now.Add(-150*time.Minute))
+	if err != nil {
+		t.Fatalf("ReadRuns failed: %v", err)
+	}
+	if len(read) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(read))
+	}
+
+	last, err := ReadLastRuns(ctx, db)
+	if err != nil {
+		t.Fatalf("ReadLastRuns failed: %v", err)
+	}
+	if len(last) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(last))
+	}
+	if last["dcgm-diag"].Status != RunStatusSucceeded {
+		t.Errorf("expected the last dcgm-diag run to be %q (skipped runs ignored), got %q", RunStatusSucceeded, last["dcgm-diag"].Status)
+	}
+	if last["smart-long"].Status != RunStatusFailed {
+		t.Errorf("expected the last smart-long run to be %q, got %q", RunStatusFailed, last["smart-long"].Status)
+	}
+
+	purged, err := Purge(ctx, db, now.Add(-90*time.Minute))
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("expected 2 purged runs, got %d", purged)
+	}
+}
@@ -0,0 +1,236 @@
+package components
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Defines the value types of the state extra info.
+// All the extra info values are strings, and the type describes how to parse the string.
+const (
+	ExtraInfoTypeString = "string"
+	ExtraInfoTypeInt    = "int"
+	ExtraInfoTypeUint   = "uint"
+	ExtraInfoTypeFloat  = "float"
+	ExtraInfoTypeBool   = "bool"
+	ExtraInfoTypeJSON   = "json"
+)
+
+// ExtraInfoSchema describes a state extra info key.
+type ExtraInfoSchema struct {
+	// Key is the extra info key (e.g., "process_count_zombie_processes").
+	Key string `json:"key"`
+	// Type is the value type (e.g., "uint"), one of the "ExtraInfoType*" constants.
+	Type string `json:"type"`
+	// Unit is the value unit if any (e.g., "bytes", "seconds", "percent").
+	Unit string `json:"unit,omitempty"`
+	// Description describes the value.
+	Description string `json:"description,omitempty"`
+}
+
+// StateSchema describes a state name and its extra info keys.
+type StateSchema struct {
+	// Name is the state name (e.g., "process_counts_by_status"),
+	// or the prefix of the state names if NamePrefix is true.
+	Name string `json:"name"`
+	// NamePrefix is true if the state name is a prefix
+	// (e.g., "ecc_" for the per-GPU states "ecc_<GPU UUID>"),
+	// where the empty prefix matches any state name (e.g., the user-defined job names).
+	NamePrefix bool `json:"name_prefix,omitempty"`
+	// Description describes the state.
+	Description string `json:"description,omitempty"`
+	// ExtraInfo is the extra info keys of the state.
+	// The keys may be omitted in the state, but the unknown keys are invalid
+	// unless DynamicExtraInfo is true.
+	ExtraInfo []ExtraInfoSchema `json:"extra_info,omitempty"`
+	// DynamicExtraInfo is true if the state has the arbitrary extra info keys
+	// (e.g., the user-defined annotations), in addition to the ones in ExtraInfo.
+	DynamicExtraInfo bool `json:"dynamic_extra_info,omitempty"`
+}
+
+// JSONStateSchema returns the schema of the state that encodes the component output
+// in the "data" extra info key as JSON, the most common state layout.
+func JSONStateSchema(name string, description string, extraInfo ...ExtraInfoSchema) StateSchema {
+	return StateSchema{
+		Name:        name,
+		Description: description,
+		ExtraInfo: append([]ExtraInfoSchema{
+			{Key: "data", Type: ExtraInfoTypeJSON, Description: "component output"},
+			{Key: "encoding", Type: ExtraInfoTypeString, Description: "encoding of the data (always \"json\")"},
+		}, extraInfo...),
+	}
+}
+
+// ComponentStateSchemas is the state schemas of a component.
+type ComponentStateSchemas struct {
+	Component string        `json:"component"`
+	States    []StateSchema `json:"states"`
+}
+
+var (
+	stateSchemasMu sync.RWMutex
+	stateSchemas   = make(map[string][]StateSchema)
+
+	validateStatesEnabled atomic.Bool
+)
+
+// RegisterStateSchemas registers the state schemas of the component,
+// appending to the ones already registered.
+// Usually called in the "init" function of the component package.
+func RegisterStateSchemas(component string, schemas ...StateSchema) {
+	stateSchemasMu.Lock()
+	defer stateSchemasMu.Unlock()
+
+	stateSchemas[component] = append(stateSchemas[component], schemas...)
+}
+
+// GetStateSchemas returns the state schemas of the component.
+// Returns false if the component has no state schema registered.
+func GetStateSchemas(component string) (ComponentStateSchemas, bool) {
+	stateSchemasMu.RLock()
+	defer stateSchemasMu.RUnlock()
+
+	schemas, ok := stateSchemas[component]
+	if !ok {
+		return ComponentStateSchemas{}, false
+	}
+	return ComponentStateSchemas{
+		Component: component,
+		States:    append([]StateSchema(nil), schemas...),
+	}, true
+}
+
+// GetAllStateSchemas returns the state schemas of all the components, sorted by the component name.
+func GetAllStateSchemas() []ComponentStateSchemas {
+	stateSchemasMu.RLock()
+	defer stateSchemasMu.RUnlock()
+
+	all := make([]ComponentStateSchemas, 0, len(stateSchemas))
+	for component, schemas := range stateSchemas {
+		all = append(all, ComponentStateSchemas{
+			Component: component,
+			States:    append([]StateSchema(nil), schemas...),
+		})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Component < all[j].Component
+	})
+	return all
+}
+
+// SetValidateStates enables or disables validating the component states
+// against the registered schemas at runtime (see "ValidateStatesEnabled").
+func SetValidateStates(enabled bool) {
+	validateStatesEnabled.Store(enabled)
+}
+
+// ValidateStatesEnabled returns true if the component states should be validated at runtime.
+func ValidateStatesEnabled() bool {
+	return validateStatesEnabled.Load()
+}
+
+// ValidateStates validates the states of the component against its registered schemas.
+// The unnamed states and the states named after the component (e.g., the query failures)
+// are not validated unless a schema is registered for the name.
+// Returns nil if the component has no state schema registered.
+func ValidateStates(component string, states ...State) error {
+	cs, ok := GetStateSchemas(component)
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	for _, st := range states {
+		schema, found := cs.find(st.Name)
+		if !found {
+			if st.Name == "" || st.Name == component {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("unknown state name %q", st.Name))
+			continue
+		}
+		if err := schema.Validate(st); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("component %q: %w", component, errors.Join(errs...))
+	}
+	return nil
+}
+
+func (cs ComponentStateSchemas) find(name string) (StateSchema, bool) {
+	// prefer the exact name over the prefix
+	for _, s := range cs.States {
+		if !s.NamePrefix && s.Name == name {
+			return s, true
+		}
+	}
+	for _, s := range cs.States {
+		if s.NamePrefix && strings.HasPrefix(name, s.Name) {
+			return s, true
+		}
+	}
+	return StateSchema{}, false
+}
+
+// Validate validates the extra info keys and values of the state against the schema.
+func (s StateSchema) Validate(st State) error {
+	keys := make(map[string]ExtraInfoSchema, len(s.ExtraInfo))
+	for _, k := range s.ExtraInfo {
+		keys[k.Key] = k
+	}
+
+	var errs []error
+	for k, v := range st.ExtraInfo {
+		ks, ok := keys[k]
+		if !ok {
+			if s.DynamicExtraInfo {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("state %q: unknown extra info key %q", st.Name, k))
+			continue
+		}
+		if err := ks.validateValue(v); err != nil {
+			errs = append(errs, fmt.Errorf("state %q: extra info key %q: %w", st.Name, k, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateValue validates the value against the type,
+// where the empty value is always valid (e.g., the value not available).
+func (k ExtraInfoSchema) validateValue(v string) error {
+	if v == "" {
+		return nil
+	}
+
+	var err error
+	switch k.Type {
+	case ExtraInfoTypeString:
+	case ExtraInfoTypeInt:
+		_, err = strconv.ParseInt(v, 10, 64)
+	case ExtraInfoTypeUint:
+		_, err = strconv.ParseUint(v, 10, 64)
+	case ExtraInfoTypeFloat:
+		_, err = strconv.ParseFloat(v, 64)
+	case ExtraInfoTypeBool:
+		_, err = strconv.ParseBool(v)
+	case ExtraInfoTypeJSON:
+		if !json.Valid([]byte(v)) {
+			err = errors.New("malformed")
+		}
+	default:
+		err = fmt.Errorf("unknown type %q", k.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s value %q: %w", k.Type, v, err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package components_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leptonai/gpud/components"
+	amd_ecc "github.com/leptonai/gpud/components/accelerator/amd/ecc"
+	amd_info "github.com/leptonai/gpud/components/accelerator/amd/info"
+	amd_temperature "github.com/leptonai/gpud/components/accelerator/amd/temperature"
+	amd_xgmi "github.com/leptonai/gpud/components/accelerator/amd/xgmi"
+	neuron_health "github.com/leptonai/gpud/components/accelerator/neuron/health"
+	nvidia_badenvs "github.com/leptonai/gpud/components/accelerator/nvidia/bad-envs"
+	nvidia_badenvs_id "github.com/leptonai/gpud/components/accelerator/nvidia/bad-envs/id"
+	nvidia_clock "github.com/leptonai/gpud/components/accelerator/nvidia/clock"
+	nvidia_clockspeed "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed"
+	nvidia_compatibility "github.com/leptonai/gpud/components/accelerator/nvidia/compatibility"
+	nvidia_confidential_compute "github.com/leptonai/gpud/components/accelerator/nvidia/confidential-compute"
+	nvidia_consistency "github.com/leptonai/gpud/components/accelerator/nvidia/consistency"
+	nvidia_cuda_canary "github.com/leptonai/gpud/components/accelerator/nvidia/cuda-canary"
+	nvidia_dcgm_diag "github.com/leptonai/gpud/components/accelerator/nvidia/dcgm-diag"
+	nvidia_driver_maintenance "github.com/leptonai/gpud/components/accelerator/nvidia/driver-maintenance"
+	nvidia_ecc "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
+	nvidia_error "github.com/leptonai/gpud/components/accelerator/nvidia/error"
+	nvidia_component_error_xid_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/error-xid-sxid"
+	nvidia_component_error_xid_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error-xid-sxid/id"
+	nvidia_error_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/error/sxid"
+	nvidia_component_error_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error/sxid/id"
+	nvidia_error_xid "github.com/leptonai/gpud/components/accelerator/nvidia/error/xid"
+	nvidia_component_error_xid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error/xid/id"
+	nvidia_fabric_manager "github.com/leptonai/gpud/components/accelerator/nvidia/fabric-manager"
+	nvidia_gpm "github.com/leptonai/gpud/components/accelerator/nvidia/gpm"
+	nvidia_gpu_count "github.com/leptonai/gpud/components/accelerator/nvidia/gpu-count"
+	nvidia_gpu_order "github.com/leptonai/gpud/components/accelerator/nvidia/gpu-order"
+	nvidia_gsp_firmware_mode "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode"
+	nvidia_gsp_firmware_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode/id"
+	nvidia_infiniband "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband"
+	nvidia_infiniband_id "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband/id"
+	nvidia_info "github.com/leptonai/gpud/components/accelerator/nvidia/info"
+	nvidia_inforom "github.com/leptonai/gpud/components/accelerator/nvidia/inforom"
+	nvidia_kernel_module "github.com/leptonai/gpud/components/accelerator/nvidia/kernel-module"
+	nvidia_license "github.com/leptonai/gpud/components/accelerator/nvidia/license"
+	nvidia_memory "github.com/leptonai/gpud/components/accelerator/nvidia/memory"
+	nvidia_mig "github.com/leptonai/gpud/components/accelerator/nvidia/mig"
+	nvidia_nvlink "github.com/leptonai/gpud/components/accelerator/nvidia/nvlink"
+	nvidia_pcie "github.com/leptonai/gpud/components/accelerator/nvidia/pcie"
+	nvidia_peermem "github.com/leptonai/gpud/components/accelerator/nvidia/peermem"
+	nvidia_peermem_id "github.com/leptonai/gpud/components/accelerator/nvidia/peermem/id"
+	nvidia_persistence_mode "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode"
+	nvidia_persistence_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode/id"
+	nvidia_power "github.com/leptonai/gpud/components/accelerator/nvidia/power"
+	nvidia_processes "github.com/leptonai/gpud/components/accelerator/nvidia/processes"
+	nvidia_remapped_rows "github.com/leptonai/gpud/components/accelerator/nvidia/remapped-rows"
+	nvidia_temperature "github.com/leptonai/gpud/components/accelerator/nvidia/temperature"
+	nvidia_topology "github.com/leptonai/gpud/components/accelerator/nvidia/topology"
+	nvidia_utilization "github.com/leptonai/gpud/components/accelerator/nvidia/utilization"
+	"github.com/leptonai/gpud/components/capability"
+	containerd_pod "github.com/leptonai/gpud/components/containerd/pod"
+	"github.com/leptonai/gpud/components/cpu"
+	"github.com/leptonai/gpud/components/disk"
+	"github.com/leptonai/gpud/components/dmesg"
+	docker_container "github.com/leptonai/gpud/components/docker/container"
+	"github.com/leptonai/gpud/components/fd"
+	"github.com/leptonai/gpud/components/file"
+	file_id "github.com/leptonai/gpud/components/file/id"
+	"github.com/leptonai/gpud/components/info"
+	k8s_pod "github.com/leptonai/gpud/components/k8s/pod"
+	kernel_module_id "github.com/leptonai/gpud/components/kernel-module/id"
+	"github.com/leptonai/gpud/components/library"
+	"github.com/leptonai/gpud/components/memory"
+	network_latency "github.com/leptonai/gpud/components/network/latency"
+	network_roce "github.com/leptonai/gpud/components/network/roce"
+	"github.com/leptonai/gpud/components/os"
+	pci_acs "github.com/leptonai/gpud/components/pci/acs"
+	pci_iommu "github.com/leptonai/gpud/components/pci/iommu"
+	power_supply "github.com/leptonai/gpud/components/power-supply"
+	scheduled_jobs "github.com/leptonai/gpud/components/scheduled-jobs"
+	"github.com/leptonai/gpud/components/systemd"
+	"github.com/leptonai/gpud/components/tailscale"
+	"github.com/leptonai/gpud/components/udev"
+)
+
+// TestValidateRegisteredStates validates the sample states of every component
+// against its registered schemas, so that a new state key (or a changed value type)
+// without the schema update fails here rather than in the "/v1/states/schema" consumers.
+func TestValidateRegisteredStates(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		component string
+		states    func() ([]components.State, error)
+	}{
+		{amd_ecc.Name, (&amd_ecc.Output{UncorrectableErrors: []string{"umc"}}).States},
+		{amd_info.Name, (&amd_info.Output{SMIGPUCount: 7, PCIGPUCount: 8}).States},
+		{amd_temperature.Name, (&amd_temperature.Output{}).States},
+		{amd_xgmi.Name, (&amd_xgmi.Output{LinkErrors: []string{"xgmi0"}}).States},
+		{neuron_health.Name, (&neuron_health.Output{}).States},
+		{nvidia_badenvs_id.Name, (&nvidia_badenvs.Output{FoundBadEnvsForCUDA: map[string]string{"CUDA_PROFILE": "enabled"}}).States},
+		{nvidia_clock.Name, (&nvidia_clock.Output{}).States},
+		{nvidia_clockspeed.Name, (&nvidia_clockspeed.Output{}).States},
+		{nvidia_compatibility.Name, (&nvidia_compatibility.Output{}).States},
+		{nvidia_confidential_compute.Name, func() ([]components.State, error) {
+			return (&nvidia_confidential_compute.Output{}).States(nvidia_confidential_compute.Config{})
+		}},
+		{nvidia_consistency.Name, (&nvidia_consistency.Output{}).States},
+		{nvidia_cuda_canary.Name, (&nvidia_cuda_canary.Output{}).States},
+		{nvidia_dcgm_diag.Name, (&nvidia_dcgm_diag.Output{}).States},
+		{nvidia_driver_maintenance.Name, (&nvidia_driver_maintenance.Output{}).States},
+		{nvidia_ecc.Name, (&nvidia_ecc.Output{}).States},
+		{nvidia_error.Name, (&nvidia_error.Output{}).States},
+		{nvidia_component_error_xid_sxid_id.Name, func() ([]components.State, error) {
+			dbe, err := (&nvidia_component_error_xid_sxid.DBEDecision{
+				Xids:         []int{48},
+				Action:       nvidia_component_error_xid_sxid.DBEActionResetGPU,
+				AffectedGPUs: []string{"GPU-0"},
+			}).State()
+			if err != nil {
+				return nil, err
+			}
+			recovery, err := (&nvidia_component_error_xid_sxid.DriverRecoveryIncident{
+				Xids:    []int{119},
+				Outcome: nvidia_component_error_xid_sxid.DriverRecoveryOutcomeRecovered,
+			}).State()
+			if err != nil {
+				return nil, err
+			}
+			return []components.State{dbe, recovery}, nil
+		}},
+		{nvidia_component_error_sxid_id.Name, func() ([]components.State, error) {
+			return nvidia_error_sxid.New().States(ctx)
+		}},
+		{nvidia_component_error_xid_id.Name, func() ([]components.State, error) {
+			return nvidia_error_xid.GPUStates(nil, []string{"GPU-0"}, nil), nil
+		}},
+		{nvidia_fabric_manager.Name, (&nvidia_fabric_manager.Output{}).States},
+		{nvidia_gpm.Name, (&nvidia_gpm.Output{}).States},
+		{nvidia_gpu_count.Name, (&nvidia_gpu_count.Output{}).States},
+		{nvidia_gpu_order.Name, (&nvidia_gpu_order.Output{}).States},
+		{nvidia_gsp_firmware_mode_id.Name, (&nvidia_gsp_firmware_mode.Output{}).States},
+		{nvidia_infiniband_id.Name, func() ([]components.State, error) {
+			return (&nvidia_infiniband.Output{}).States(nvidia_infiniband.Config{})
+		}},
+		{nvidia_info.Name, (&nvidia_info.Output{
+			GPU:    nvidia_info.GPU{DeviceCount: 8, Attached: 8},
+			Memory: nvidia_info.Memory{TotalBytes: 85899345920, TotalHumanized: "80 GiB"},
+		}).States},
+		{nvidia_inforom.Name, (&nvidia_inforom.Output{}).States},
+		{nvidia_kernel_module.Name, (&nvidia_kernel_module.Output{}).States},
+		{nvidia_license.Name, func() ([]components.State, error) {
+			return (&nvidia_license.Output{}).States(nvidia_license.Config{})
+		}},
+		{nvidia_memory.Name, (&nvidia_memory.Output{}).States},
+		{nvidia_mig.Name, (&nvidia_mig.Output{}).States},
+		{nvidia_nvlink.Name, (&nvidia_nvlink.Output{}).States},
+		{nvidia_pcie.Name, (&nvidia_pcie.Output{}).States},
+		{nvidia_peermem_id.Name, (&nvidia_peermem.Output{}).States},
+		{nvidia_persistence_mode_id.Name, (&nvidia_persistence_mode.Output{}).States},
+		{nvidia_power.Name, (&nvidia_power.Output{}).States},
+		{nvidia_processes.Name, (&nvidia_processes.Output{}).States},
+		{nvidia_remapped_rows.Name, (&nvidia_remapped_rows.Output{}).States},
+		{nvidia_temperature.Name, (&nvidia_temperature.Output{}).States},
+		{nvidia_topology.Name, (&nvidia_topology.Output{}).States},
+		{nvidia_utilization.Name, (&nvidia_utilization.Output{}).States},
+		{capability.Name, func() ([]components.State, error) {
+			return capability.New(capability.Report{
+				Components: []capability.ComponentReport{
+					{Component: "cpu", Capable: true},
+					{Component: "accelerator-nvidia-dcgm-diag", Capable: false, Missing: []string{"dcgmi"}},
+				},
+			}).States(ctx)
+		}},
+		{containerd_pod.Name, (&containerd_pod.Output{}).States},
+		{cpu.Name, (&cpu.Output{
+			Cores: cpu.Cores{Physical: 64, Logical: 128},
+			Usage: cpu.Usage{UsedPercent: "12.50", LoadAvg1Min: "1.00", LoadAvg5Min: "0.75", LoadAvg15Min: "0.50", StealPercent: "0.10"},
+		}).States},
+		{disk.Name, (&disk.Output{
+			ExtPartitions: []disk.Partition{{Device: "/dev/sda1", MountPoint: "/", Fstype: "ext4"}},
+			Usages: []disk.Usage{{
+				MountPoint:        "/",
+				Fstype:            "ext4",
+				TotalBytes:        1 << 40,
+				FreeBytes:         1 << 39,
+				UsedBytes:         1 << 39,
+				UsedPercent:       "50.00",
+				InodesTotal:       1000,
+				InodesUsed:        100,
+				InodesFree:        900,
+				InodesUsedPercent: "10.00",
+			}},
+		}).States},
+		{dmesg.Name, func() ([]components.State, error) {
+			return (&dmesg.State{File: "/var/log/dmesg"}).States(), nil
+		}},
+		{docker_container.Name, func() ([]components.State, error) {
+			return (&docker_container.Output{}).States(docker_container.Config{})
+		}},
+		{fd.Name, (&fd.Output{
+			RunningPIDs:          100,
+			Usage:                1000,
+			Limit:                10000,
+			UsedPercent:          "10.00",
+			FDLimitSupported:     true,
+			ThresholdLimit:       5000,
+			ThresholdUsedPercent: "20.00",
+		}).States},
+		{file_id.Name, func() ([]components.State, error) {
+			return file.New(nil).States(ctx)
+		}},
+		{info.Name, func() ([]components.State, error) {
+			return info.New(map[string]string{"a": "b"}).States(ctx)
+		}},
+		{k8s_pod.Name, func() ([]components.State, error) {
+			return (&k8s_pod.Output{}).States(k8s_pod.Config{})
+		}},
+		{library.Name, func() ([]components.State, error) {
+			return library.New(library.Config{}).States(ctx)
+		}},
+		{memory.Name, (&memory.Output{
+			TotalBytes:     1 << 30,
+			AvailableBytes: 1 << 29,
+			UsedBytes:      1 << 29,
+			UsedPercent:    "50.00",
+			FreeBytes:      1 << 29,
+		}).States},
+		{network_latency.Name, func() ([]components.State, error) {
+			return (&network_latency.Output{}).States(network_latency.Config{})
+		}},
+		{network_roce.Name, func() ([]components.State, error) {
+			states, err := (&network_roce.Output{}).States(network_roce.Config{})
+			if err != nil {
+				return nil, err
+			}
+			return append(states, network_roce.DriverErrorsState(nil, 0)), nil
+		}},
+		{os.Name, (&os.Output{
+			Uptimes:                     os.Uptimes{Seconds: 3600, BootTimeUnixSeconds: 1700000000},
+			ProcessCountZombieProcesses: 1,
+		}).States},
+		{pci_acs.Name, (&pci_acs.Output{}).States},
+		{pci_iommu.Name, (&pci_iommu.Output{}).States},
+		{power_supply.Name, (&power_supply.Output{BatteryCapacity: 100, BatteryCapacityFound: true}).States},
+		{systemd.Name, (&systemd.Output{
+			SystemdVersion: "249",
+			Units:          []systemd.Unit{{Name: "gpud.service", Active: true, UptimeSeconds: 3600}},
+		}).States},
+		{tailscale.Name, (&tailscale.Output{}).States},
+		{udev.Name, (&udev.Output{}).States},
+	}
+
+	// the components whose states cannot be sampled without the host or the database
+	notSampled := map[string]string{
+		kernel_module_id.Name: "reads /etc/modules of the host",
+		scheduled_jobs.Name:   "reads the last job runs from the database",
+
+		"test-schema-component": "registered by TestValidateStates",
+	}
+
+	sampled := make(map[string]bool, len(tests))
+	for _, tt := range tests {
+		sampled[tt.component] = true
+		t.Run(tt.component, func(t *testing.T) {
+			states, err := tt.states()
+			if err != nil {
+				t.Fatalf("failed to get the sample states: %v", err)
+			}
+			if len(states) == 0 {
+				t.Fatal("no sample state")
+			}
+			if err := components.ValidateStates(tt.component, states...); err != nil {
+				t.Errorf("sample states do not match the registered schemas: %v", err)
+			}
+		})
+	}
+
+	for _, s := range components.GetAllStateSchemas() {
+		if _, ok := notSampled[s.Component]; ok {
+			continue
+		}
+		if !sampled[s.Component] {
+			t.Errorf("component %q registers the state schemas but has no sample states in this test", s.Component)
+		}
+	}
+}
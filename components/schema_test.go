@@ -0,0 +1,100 @@
+package components
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateStates(t *testing.T) {
+	const component = "test-schema-component"
+	RegisterStateSchemas(component,
+		StateSchema{
+			Name: "usage",
+			ExtraInfo: []ExtraInfoSchema{
+				{Key: "used_bytes", Type: ExtraInfoTypeUint, Unit: "bytes"},
+				{Key: "used_percent", Type: ExtraInfoTypeFloat, Unit: "percent"},
+				{Key: "ok", Type: ExtraInfoTypeBool},
+			},
+		},
+		StateSchema{Name: "usage_", NamePrefix: true, ExtraInfo: []ExtraInfoSchema{{Key: "gpu_uuid", Type: ExtraInfoTypeString}}},
+		JSONStateSchema("output", "json output"),
+		StateSchema{Name: "annotations", DynamicExtraInfo: true, ExtraInfo: []ExtraInfoSchema{{Key: "count", Type: ExtraInfoTypeInt}}},
+	)
+
+	tests := []struct {
+		name    string
+		states  []State
+		wantErr string
+	}{
+		{
+			name: "valid",
+			states: []State{
+				{Name: "usage", ExtraInfo: map[string]string{"used_bytes": "10", "used_percent": "1.5", "ok": "true"}},
+				{Name: "usage_GPU-0", ExtraInfo: map[string]string{"gpu_uuid": "GPU-0"}},
+				{Name: "output", ExtraInfo: map[string]string{"data": `{"a":1}`, "encoding": "json"}},
+				{Name: "usage", ExtraInfo: map[string]string{"used_bytes": ""}},
+			},
+		},
+		{
+			name: "failure states not validated",
+			states: []State{
+				{Name: component, Error: "failed", ExtraInfo: map[string]string{"smi_exists": "true"}},
+				{Error: "failed"},
+			},
+		},
+		{
+			name:    "unknown state name",
+			states:  []State{{Name: "unknown"}},
+			wantErr: `unknown state name "unknown"`,
+		},
+		{
+			name:    "unknown key",
+			states:  []State{{Name: "usage", ExtraInfo: map[string]string{"free_bytes": "10"}}},
+			wantErr: `unknown extra info key "free_bytes"`,
+		},
+		{
+			name:    "invalid uint",
+			states:  []State{{Name: "usage", ExtraInfo: map[string]string{"used_bytes": "-1"}}},
+			wantErr: `extra info key "used_bytes"`,
+		},
+		{
+			name:    "invalid dynamic state known key",
+			states:  []State{{Name: "annotations", ExtraInfo: map[string]string{"count": "x"}}},
+			wantErr: `extra info key "count"`,
+		},
+		{
+			name:    "invalid json",
+			states:  []State{{Name: "output", ExtraInfo: map[string]string{"data": "{"}}},
+			wantErr: `invalid json value "{"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStates(component, tt.states...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// no schema registered
+	if err := ValidateStates("test-schema-unregistered", State{Name: "anything"}); err != nil {
+		t.Errorf("expected no error for the component without the schema, got %v", err)
+	}
+
+	found := false
+	for _, cs := range GetAllStateSchemas() {
+		if cs.Component == component {
+			found = len(cs.States) == 4
+		}
+	}
+	if !found {
+		t.Error("expected the registered schemas")
+	}
+}
@@ -0,0 +1,171 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	TableNameAnnotations = "components_annotations"
+
+	ColumnEventUnixSeconds   = "event_unix_seconds"
+	ColumnKey                = "key"
+	ColumnValue              = "value"
+	ColumnUpdatedUnixSeconds = "updated_unix_seconds"
+)
+
+// Annotation is an operator key/value annotation (e.g., ticket ID, maintenance reference)
+// attached to a component, or to a component event if the event name is set.
+type Annotation struct {
+	Component string `json:"component"`
+
+	// Set with the event time to annotate a specific event (matched by name and time in seconds).
+	EventName string    `json:"event_name,omitempty"`
+	EventTime time.Time `json:"event_time,omitempty"`
+
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func CreateTableAnnotations(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	PRIMARY KEY (%s, %s, %s, %s)
+);`, TableNameAnnotations,
+		ColumnComponent,
+		ColumnEventName,
+		ColumnEventUnixSeconds,
+		ColumnKey,
+		ColumnValue,
+		ColumnUpdatedUnixSeconds,
+		ColumnComponent,
+		ColumnEventName,
+		ColumnEventUnixSeconds,
+		ColumnKey,
+	))
+	return err
+}
+
+// SetAnnotation inserts or updates the annotation.
+// An empty value deletes the annotation.
+func SetAnnotation(ctx context.Context, db *sql.DB, a Annotation) error {
+	if a.Component == "" || a.Key == "" {
+		return fmt.Errorf("component and key are required")
+	}
+	if a.EventName != "" && a.EventTime.IsZero() {
+		return fmt.Errorf("event time is required to annotate event %q", a.EventName)
+	}
+	if a.UpdatedAt.IsZero() {
+		a.UpdatedAt = time.Now()
+	}
+
+	var eventUnix int64
+	if a.EventName != "" {
+		eventUnix = a.EventTime.UTC().Unix()
+	}
+
+	if a.Value == "" {
+		_, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s = ? AND %s = ? AND %s = ? AND %s = ?;`,
+			TableNameAnnotations,
+			ColumnComponent,
+			ColumnEventName,
+			ColumnEventUnixSeconds,
+			ColumnKey,
+		), a.Component, a.EventName, eventUnix, a.Key)
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`INSERT OR REPLACE INTO %s (%s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?);`,
+		TableNameAnnotations,
+		ColumnComponent,
+		ColumnEventName,
+		ColumnEventUnixSeconds,
+		ColumnKey,
+		ColumnValue,
+		ColumnUpdatedUnixSeconds,
+	), a.Component, a.EventName, eventUnix, a.Key, a.Value, a.UpdatedAt.UTC().Unix())
+	return err
+}
+
+// ReadAnnotations returns all the annotations of the component (including its events).
+func ReadAnnotations(ctx context.Context, db *sql.DB, component string) ([]Annotation, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT %s, %s, %s, %s, %s FROM %s WHERE %s = ? ORDER BY %s, %s, %s;`,
+		ColumnEventName,
+		ColumnEventUnixSeconds,
+		ColumnKey,
+		ColumnValue,
+		ColumnUpdatedUnixSeconds,
+		TableNameAnnotations,
+		ColumnComponent,
+		ColumnEventName,
+		ColumnEventUnixSeconds,
+		ColumnKey,
+	), component)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var as []Annotation
+	for rows.Next() {
+		var (
+			a           Annotation
+			eventUnix   int64
+			updatedUnix int64
+		)
+		if err := rows.Scan(&a.EventName, &eventUnix, &a.Key, &a.Value, &updatedUnix); err != nil {
+			return nil, err
+		}
+		a.Component = component
+		if a.EventName != "" {
+			a.EventTime = time.Unix(eventUnix, 0).UTC()
+		}
+		a.UpdatedAt = time.Unix(updatedUnix, 0).UTC()
+		as = append(as, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+// ComponentAnnotations returns the component-level annotations as a map.
+// Returns nil if there is none.
+func ComponentAnnotations(as []Annotation) map[string]string {
+	var m map[string]string
+	for _, a := range as {
+		if a.EventName != "" {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m[a.Key] = a.Value
+	}
+	return m
+}
+
+// EventAnnotations returns the annotations of the event as a map.
+// Returns nil if there is none.
+func EventAnnotations(as []Annotation, eventName string, eventTime time.Time) map[string]string {
+	var m map[string]string
+	for _, a := range as {
+		if a.EventName == "" || a.EventName != eventName || a.EventTime.Unix() != eventTime.Unix() {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m[a.Key] = a.Value
+	}
+	return m
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestAnnotations(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := CreateTableAnnotations(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	evTime := time.Unix(1700000000, 0).UTC()
+	for _, a := range []Annotation{
+		{Component: "disk", Key: "ticket", Value: "OPS-1"},
+		{Component: "disk", Key: "ticket", Value: "OPS-2"}, // replaces
+		{Component: "disk", Key: "maintenance", Value: "mw-42"},
+		{Component: "disk", EventName: "usage", EventTime: evTime, Key: "ticket", Value: "OPS-3"},
+		{Component: "memory", Key: "ticket", Value: "OPS-4"},
+	} {
+		if err := SetAnnotation(ctx, db, a); err != nil {
+			t.Fatalf("SetAnnotation failed: %v", err)
+		}
+	}
+	if err := SetAnnotation(ctx, db, Annotation{Component: "disk", EventName: "usage", Key: "ticket", Value: "x"}); err == nil {
+		t.Fatal("expected error without event time")
+	}
+
+	as, err := ReadAnnotations(ctx, db, "disk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ComponentAnnotations(as); !reflect.DeepEqual(got, map[string]string{"ticket": "OPS-2", "maintenance": "mw-42"}) {
+		t.Fatalf("unexpected component annotations %v", got)
+	}
+	if got := EventAnnotations(as, "usage", evTime); !reflect.DeepEqual(got, map[string]string{"ticket": "OPS-3"}) {
+		t.Fatalf("unexpected event annotations %v", got)
+	}
+	if got := EventAnnotations(as, "usage", evTime.Add(time.Second)); got != nil {
+		t.Fatalf("unexpected event annotations %v", got)
+	}
+
+	// empty value deletes
+	if err := SetAnnotation(ctx, db, Annotation{Component: "disk", Key: "maintenance"}); err != nil {
+		t.Fatal(err)
+	}
+	as, err = ReadAnnotations(ctx, db, "disk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != 2 {
+		t.Fatalf("expected 2 annotations, got %+v", as)
+	}
+}
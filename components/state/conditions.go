@@ -0,0 +1,95 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition reasons, in the Kubernetes condition reason format (CamelCase).
+const (
+	ConditionReasonHealthy     = "Healthy"
+	ConditionReasonUnhealthy   = "Unhealthy"
+	ConditionReasonDegraded    = "Degraded"
+	ConditionReasonUnavailable = "StatesUnavailable"
+)
+
+// ComponentCondition renders the component states as a Kubernetes-style condition
+// (e.g., for the node-problem-detector or the custom controllers),
+// where the condition type is the component name, and the status is "True" if the component is healthy
+// (including the degraded states, e.g., during the driver maintenance or acknowledged as known issues), "False" if unhealthy,
+// or "Unknown" if no state is available.
+func ComponentCondition(component string, states []components.State, lastTransitionTime time.Time) metav1.Condition {
+	cond := metav1.Condition{
+		Type:               component,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonHealthy,
+		LastTransitionTime: metav1.Time{Time: lastTransitionTime.UTC()},
+	}
+	if len(states) == 0 {
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = ConditionReasonUnavailable
+		cond.Message = "no state available"
+		return cond
+	}
+
+	msgs := make([]string, 0, len(states))
+	for _, s := range states {
+		switch {
+		case !s.Healthy:
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = ConditionReasonUnhealthy
+		case s.Degraded && cond.Status == metav1.ConditionTrue:
+			cond.Reason = ConditionReasonDegraded
+		}
+
+		msg := s.Reason
+		if msg == "" {
+			msg = s.Error
+		}
+		if msg == "" {
+			continue
+		}
+		if s.Name != "" && s.Name != component {
+			msg = s.Name + ": " + msg
+		}
+		msgs = append(msgs, msg)
+	}
+	cond.Message = strings.Join(msgs, "; ")
+	return cond
+}
+
+// ReadLastTransitionTime returns the time the component health last changed to the given health
+// (i.e., the start of the latest run of the recorded states with the same health).
+// Returns a zero time if no state with the health was recorded.
+func ReadLastTransitionTime(ctx context.Context, db *sql.DB, component string, healthy bool) (time.Time, error) {
+	var startUnix sql.NullInt64
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`
+SELECT MIN(%s) FROM %s
+WHERE %s = ? AND %s = ? AND %s > (
+	SELECT COALESCE(MAX(%s), 0) FROM %s WHERE %s = ? AND %s != ?
+);`,
+		ColumnStartUnixSeconds,
+		TableNameStatesHistory,
+		ColumnComponent,
+		ColumnHealthy,
+		ColumnStartUnixSeconds,
+		ColumnStartUnixSeconds,
+		TableNameStatesHistory,
+		ColumnComponent,
+		ColumnHealthy,
+	), component, healthy, component, healthy).Scan(&startUnix)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !startUnix.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(startUnix.Int64, 0).UTC(), nil
+}
@@ -0,0 +1,126 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComponentCondition(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Unix(1700000000, 0).UTC()
+
+	tests := []struct {
+		name       string
+		states     []components.State
+		wantStatus metav1.ConditionStatus
+		wantReason string
+		wantMsg    string
+	}{
+		{
+			name:       "healthy",
+			states:     []components.State{{Name: "accelerator-nvidia-ecc", Healthy: true, Reason: "no ecc error found"}},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: ConditionReasonHealthy,
+			wantMsg:    "no ecc error found",
+		},
+		{
+			name: "unhealthy",
+			states: []components.State{
+				{Name: "accelerator-nvidia-ecc", Healthy: true, Reason: "no ecc error found"},
+				{Name: "ecc_volatile", Healthy: false, Error: "uncorrected errors found"},
+			},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: ConditionReasonUnhealthy,
+			wantMsg:    "no ecc error found; ecc_volatile: uncorrected errors found",
+		},
+		{
+			name:       "degraded",
+			states:     []components.State{{Name: "accelerator-nvidia-ecc", Healthy: true, Degraded: true, Reason: "known issue"}},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: ConditionReasonDegraded,
+			wantMsg:    "known issue",
+		},
+		{
+			name:       "no state",
+			wantStatus: metav1.ConditionUnknown,
+			wantReason: ConditionReasonUnavailable,
+			wantMsg:    "no state available",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond := ComponentCondition("accelerator-nvidia-ecc", tt.states, ts)
+			if cond.Type != "accelerator-nvidia-ecc" || !cond.LastTransitionTime.Time.Equal(ts) {
+				t.Errorf("unexpected condition: %+v", cond)
+			}
+			if cond.Status != tt.wantStatus || cond.Reason != tt.wantReason || cond.Message != tt.wantMsg {
+				t.Errorf("ComponentCondition() = %s/%s/%q, want %s/%s/%q", cond.Status, cond.Reason, cond.Message, tt.wantStatus, tt.wantReason, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestReadLastTransitionTime(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := CreateTableStatesHistory(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	base := time.Unix(1700000000, 0).UTC()
+	records := []struct {
+		at     time.Time
+		states []components.State
+	}{
+		{base, []components.State{{Name: "a", Healthy: true}}},
+		{base.Add(time.Minute), []components.State{{Name: "a", Healthy: false}}},
+		{base.Add(2 * time.Minute), []components.State{{Name: "a", Healthy: true}}},
+		// different signature with the same health
+		{base.Add(3 * time.Minute), []components.State{{Name: "a", Healthy: true}, {Name: "b", Healthy: true}}},
+	}
+	for _, r := range records {
+		if err := RecordStates(ctx, db, "c", r.states, r.at); err != nil {
+			t.Fatalf("failed to record states: %v", err)
+		}
+	}
+
+	got, err := ReadLastTransitionTime(ctx, db, "c", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("expected %v, got %v", base.Add(2*time.Minute), got)
+	}
+
+	got, err = ReadLastTransitionTime(ctx, db, "c", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsZero() {
+		t.Errorf("expected zero time for the health not current, got %v", got)
+	}
+
+	got, err = ReadLastTransitionTime(ctx, db, "unknown", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsZero() {
+		t.Errorf("expected zero time, got %v", got)
+	}
+}
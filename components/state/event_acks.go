@@ -0,0 +1,183 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	TableNameEventAcks = "components_event_acks"
+
+	ColumnEventName           = "event_name"
+	ColumnAckedBy             = "acked_by"
+	ColumnNote                = "note"
+	ColumnAckedUnixSeconds    = "acked_unix_seconds"
+	ColumnResolvedUnixSeconds = "resolved_unix_seconds"
+)
+
+const (
+	// EventAckAnnotationKeyAcknowledged is attached to the unhealthy states
+	// covered by an active component-wide acknowledgment (set to the note, or "true" if no note).
+	EventAckAnnotationKeyAcknowledged = "acknowledged"
+	// EventAckAnnotationKeyAckedBy is the operator who acknowledged the state, if any.
+	EventAckAnnotationKeyAckedBy = "acknowledged-by"
+)
+
+// EventAck is an operator acknowledgment of the component events as a known issue.
+// Active until resolved.
+type EventAck struct {
+	Component string `json:"component"`
+	// EventName is the name of the acknowledged events,
+	// or empty to acknowledge all the events of the component.
+	EventName string `json:"event_name,omitempty"`
+
+	AckedBy string    `json:"acked_by,omitempty"`
+	Note    string    `json:"note,omitempty"`
+	AckedAt time.Time `json:"acked_at"`
+
+	// Zero if not resolved yet.
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// Matches returns true if the active acknowledgment covers the component event.
+func (a EventAck) Matches(component string, eventName string) bool {
+	if !a.ResolvedAt.IsZero() || a.Component != component {
+		return false
+	}
+	return a.EventName == "" || a.EventName == eventName
+}
+
+// CoversStates returns true if the active acknowledgment covers the states of the component,
+// which requires all the events of the component to be acknowledged (empty event name).
+// The per-event acknowledgments only silence the repeat notifications,
+// as the event names do not map to the state names.
+func (a EventAck) CoversStates(component string) bool {
+	return a.ResolvedAt.IsZero() && a.Component == component && a.EventName == ""
+}
+
+func CreateTableEventAcks(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT,
+	%s TEXT,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL DEFAULT 0
+);`, TableNameEventAcks,
+		ColumnComponent,
+		ColumnEventName,
+		ColumnAckedBy,
+		ColumnNote,
+		ColumnAckedUnixSeconds,
+		ColumnResolvedUnixSeconds,
+	))
+	return err
+}
+
+// AckEvent records the acknowledgment.
+// If the same component event is already acknowledged, it replaces the active acknowledgment.
+func AckEvent(ctx context.Context, db *sql.DB, ack EventAck) error {
+	if ack.Component == "" {
+		return fmt.Errorf("component is required")
+	}
+	if ack.AckedAt.IsZero() {
+		ack.AckedAt = time.Now()
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s = ? AND %s = ? AND %s = 0;`,
+		TableNameEventAcks,
+		ColumnComponent,
+		ColumnEventName,
+		ColumnResolvedUnixSeconds,
+	), ack.Component, ack.EventName); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?);`,
+		TableNameEventAcks,
+		ColumnComponent,
+		ColumnEventName,
+		ColumnAckedBy,
+		ColumnNote,
+		ColumnAckedUnixSeconds,
+	), ack.Component, ack.EventName, ack.AckedBy, ack.Note, ack.AckedAt.UTC().Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ResolveEventAck resolves the active acknowledgment of the component event,
+// so that the following events are notified again.
+// Returns false if no active acknowledgment is found.
+func ResolveEventAck(ctx context.Context, db *sql.DB, component string, eventName string, now time.Time) (bool, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET %s = ? WHERE %s = ? AND %s = ? AND %s = 0;`,
+		TableNameEventAcks,
+		ColumnResolvedUnixSeconds,
+		ColumnComponent,
+		ColumnEventName,
+		ColumnResolvedUnixSeconds,
+	), now.UTC().Unix(), component, eventName)
+	if err != nil {
+		return false, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ReadEventAcks returns the acknowledgments in the ascending order of the ack time.
+// If activeOnly is true, it only returns the unresolved acknowledgments.
+func ReadEventAcks(ctx context.Context, db *sql.DB, activeOnly bool) ([]EventAck, error) {
+	query := fmt.Sprintf(`SELECT %s, %s, COALESCE(%s, ''), COALESCE(%s, ''), %s, %s FROM %s`,
+		ColumnComponent,
+		ColumnEventName,
+		ColumnAckedBy,
+		ColumnNote,
+		ColumnAckedUnixSeconds,
+		ColumnResolvedUnixSeconds,
+		TableNameEventAcks,
+	)
+	if activeOnly {
+		query += fmt.Sprintf(" WHERE %s = 0", ColumnResolvedUnixSeconds)
+	}
+	query += fmt.Sprintf(" ORDER BY %s ASC;", ColumnAckedUnixSeconds)
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var acks []EventAck
+	for rows.Next() {
+		var (
+			ack          EventAck
+			ackedUnix    int64
+			resolvedUnix int64
+		)
+		if err := rows.Scan(&ack.Component, &ack.EventName, &ack.AckedBy, &ack.Note, &ackedUnix, &resolvedUnix); err != nil {
+			return nil, err
+		}
+		ack.AckedAt = time.Unix(ackedUnix, 0).UTC()
+		if resolvedUnix > 0 {
+			ack.ResolvedAt = time.Unix(resolvedUnix, 0).UTC()
+		}
+		acks = append(acks, ack)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return acks, nil
+}
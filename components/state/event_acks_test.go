@@ -0,0 +1,80 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestEventAcks(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := CreateTableEventAcks(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	now := time.Unix(1700000000, 0).UTC()
+	if err := AckEvent(ctx, db, EventAck{Component: "disk", EventName: "usage", AckedBy: "alice", Note: "old", AckedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	// replaces the active ack
+	if err := AckEvent(ctx, db, EventAck{Component: "disk", EventName: "usage", AckedBy: "bob", Note: "ticket 1", AckedAt: now.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AckEvent(ctx, db, EventAck{Component: "memory", AckedBy: "bob", AckedAt: now.Add(2 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AckEvent(ctx, db, EventAck{}); err == nil {
+		t.Fatal("expected error for empty component")
+	}
+
+	acks, err := ReadEventAcks(ctx, db, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acks) != 2 || acks[0].Note != "ticket 1" || acks[0].AckedBy != "bob" {
+		t.Fatalf("unexpected acks %+v", acks)
+	}
+	if !acks[0].Matches("disk", "usage") || acks[0].Matches("disk", "other") || !acks[1].Matches("memory", "oom") {
+		t.Fatalf("unexpected matches %+v", acks)
+	}
+	// only the component-wide ack covers the states
+	if acks[0].CoversStates("disk") || !acks[1].CoversStates("memory") || acks[1].CoversStates("disk") {
+		t.Fatalf("unexpected states coverage %+v", acks)
+	}
+
+	resolved, err := ResolveEventAck(ctx, db, "disk", "usage", now.Add(time.Hour))
+	if err != nil || !resolved {
+		t.Fatalf("expected resolved, got %v (%v)", resolved, err)
+	}
+	resolved, err = ResolveEventAck(ctx, db, "disk", "usage", now.Add(time.Hour))
+	if err != nil || resolved {
+		t.Fatalf("expected not resolved twice, got %v (%v)", resolved, err)
+	}
+
+	acks, err = ReadEventAcks(ctx, db, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acks) != 1 || acks[0].Component != "memory" {
+		t.Fatalf("unexpected active acks %+v", acks)
+	}
+	acks, err = ReadEventAcks(ctx, db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acks) != 2 || acks[0].ResolvedAt.IsZero() || acks[0].Matches("disk", "usage") {
+		t.Fatalf("unexpected acks %+v", acks)
+	}
+}
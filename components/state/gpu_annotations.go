@@ -0,0 +1,232 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+)
+
+const (
+	TableNameGPUAnnotations = "components_gpu_annotations"
+
+	ColumnGPUUUID = "gpu_uuid"
+)
+
+const (
+	// GPUAnnotationKeySuspect marks the GPU as suspect (e.g., "true" or the ticket ID).
+	// The automated remediation never resets a suspect GPU, and only pages the operator.
+	GPUAnnotationKeySuspect = "suspect"
+	// GPUAnnotationKeyBenchmarkingOnly marks the GPU to be used for benchmarking only.
+	GPUAnnotationKeyBenchmarkingOnly = "benchmarking-only"
+
+	// GPUAnnotationPrefix is the prefix of the GPU annotation keys
+	// when attached to the component states and events
+	// (e.g., "gpu/GPU-xxx/suspect").
+	GPUAnnotationPrefix = "gpu/"
+)
+
+// GPUAnnotation is an operator key/value annotation (e.g., "suspect") attached to a GPU,
+// identified by its UUID. GPU annotations persist across restarts and apply to all
+// the component states and events that refer to the GPU.
+type GPUAnnotation struct {
+	GPUUUID   string    `json:"gpu_uuid"`
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func CreateTableGPUAnnotations(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	PRIMARY KEY (%s, %s)
+);`, TableNameGPUAnnotations,
+		ColumnGPUUUID,
+		ColumnKey,
+		ColumnValue,
+		ColumnUpdatedUnixSeconds,
+		ColumnGPUUUID,
+		ColumnKey,
+	))
+	return err
+}
+
+// SetGPUAnnotation inserts or updates the GPU annotation.
+// An empty value deletes the annotation.
+func SetGPUAnnotation(ctx context.Context, db *sql.DB, a GPUAnnotation) error {
+	if a.GPUUUID == "" || a.Key == "" {
+		return fmt.Errorf("gpu uuid and key are required")
+	}
+	if strings.Contains(a.Key, "/") {
+		return fmt.Errorf("invalid key %q (must not contain '/')", a.Key)
+	}
+	if a.UpdatedAt.IsZero() {
+		a.UpdatedAt = time.Now()
+	}
+
+	if a.Value == "" {
+		_, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s = ? AND %s = ?;`,
+			TableNameGPUAnnotations,
+			ColumnGPUUUID,
+			ColumnKey,
+		), a.GPUUUID, a.Key)
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`INSERT OR REPLACE INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?);`,
+		TableNameGPUAnnotations,
+		ColumnGPUUUID,
+		ColumnKey,
+		ColumnValue,
+		ColumnUpdatedUnixSeconds,
+	), a.GPUUUID, a.Key, a.Value, a.UpdatedAt.UTC().Unix())
+	return err
+}
+
+// ReadGPUAnnotations returns the annotations of the GPU.
+// Returns the annotations of all the GPUs if the uuid is empty.
+func ReadGPUAnnotations(ctx context.Context, db *sql.DB, uuid string) ([]GPUAnnotation, error) {
+	query := fmt.Sprintf(`SELECT %s, %s, %s, %s FROM %s`,
+		ColumnGPUUUID,
+		ColumnKey,
+		ColumnValue,
+		ColumnUpdatedUnixSeconds,
+		TableNameGPUAnnotations,
+	)
+	var params []any
+	if uuid != "" {
+		query += fmt.Sprintf(` WHERE %s = ?`, ColumnGPUUUID)
+		params = append(params, uuid)
+	}
+	query += fmt.Sprintf(` ORDER BY %s, %s;`, ColumnGPUUUID, ColumnKey)
+
+	rows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var as []GPUAnnotation
+	for rows.Next() {
+		var (
+			a           GPUAnnotation
+			updatedUnix int64
+		)
+		if err := rows.Scan(&a.GPUUUID, &a.Key, &a.Value, &updatedUnix); err != nil {
+			return nil, err
+		}
+		a.UpdatedAt = time.Unix(updatedUnix, 0).UTC()
+		as = append(as, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+// GPUAnnotations maps the GPU UUID to its annotations.
+type GPUAnnotations map[string]map[string]string
+
+// GPUAnnotationsByUUID groups the GPU annotations by the GPU UUID.
+// Returns nil if there is none.
+func GPUAnnotationsByUUID(as []GPUAnnotation) GPUAnnotations {
+	var m GPUAnnotations
+	for _, a := range as {
+		if m == nil {
+			m = make(GPUAnnotations)
+		}
+		if m[a.GPUUUID] == nil {
+			m[a.GPUUUID] = make(map[string]string)
+		}
+		m[a.GPUUUID][a.Key] = a.Value
+	}
+	return m
+}
+
+// IsSuspect returns true if the GPU is annotated as suspect.
+func (m GPUAnnotations) IsSuspect(uuid string) bool {
+	return m[uuid][GPUAnnotationKeySuspect] != ""
+}
+
+// Match returns the annotations of the GPUs whose UUID appears in any of the texts
+// (e.g., state name, reason, extra info values), keyed by "gpu/<uuid>/<key>".
+// Returns nil if no annotated GPU is found.
+func (m GPUAnnotations) Match(texts ...string) map[string]string {
+	uuids := make([]string, 0, len(m))
+	for uuid := range m {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	var matched map[string]string
+	for _, uuid := range uuids {
+		found := false
+		for _, s := range texts {
+			if strings.Contains(s, uuid) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		if matched == nil {
+			matched = make(map[string]string)
+		}
+		for k, v := range m[uuid] {
+			matched[GPUAnnotationPrefix+uuid+"/"+k] = v
+		}
+	}
+	return matched
+}
+
+// AnnotateStates attaches the annotations of the GPUs referred by each state
+// (in its name, reason, error, or extra info values).
+func (m GPUAnnotations) AnnotateStates(states []components.State) {
+	if len(m) == 0 {
+		return
+	}
+	for i, st := range states {
+		texts := []string{st.Name, st.Reason, st.Error}
+		for _, v := range st.ExtraInfo {
+			texts = append(texts, v)
+		}
+		states[i].Annotations = mergeAnnotations(st.Annotations, m.Match(texts...))
+	}
+}
+
+// AnnotateEvents attaches the annotations of the GPUs referred by each event
+// (in its name, message, or extra info values).
+func (m GPUAnnotations) AnnotateEvents(events []components.Event) {
+	if len(m) == 0 {
+		return
+	}
+	for i, ev := range events {
+		texts := []string{ev.Name, ev.Message}
+		for _, v := range ev.ExtraInfo {
+			texts = append(texts, v)
+		}
+		events[i].Annotations = mergeAnnotations(ev.Annotations, m.Match(texts...))
+	}
+}
+
+func mergeAnnotations(to, from map[string]string) map[string]string {
+	if len(from) == 0 {
+		return to
+	}
+	if to == nil {
+		to = make(map[string]string, len(from))
+	}
+	for k, v := range from {
+		to[k] = v
+	}
+	return to
+}
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestGPUAnnotations(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := CreateTableGPUAnnotations(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	for _, a := range []GPUAnnotation{
+		{GPUUUID: "GPU-0", Key: GPUAnnotationKeySuspect, Value: "true"},
+		{GPUUUID: "GPU-0", Key: GPUAnnotationKeySuspect, Value: "OPS-1"}, // replaces
+		{GPUUUID: "GPU-0", Key: GPUAnnotationKeyBenchmarkingOnly, Value: "true"},
+		{GPUUUID: "GPU-1", Key: GPUAnnotationKeyBenchmarkingOnly, Value: "true"},
+	} {
+		if err := SetGPUAnnotation(ctx, db, a); err != nil {
+			t.Fatalf("SetGPUAnnotation failed: %v", err)
+		}
+	}
+	if err := SetGPUAnnotation(ctx, db, GPUAnnotation{Key: "suspect", Value: "true"}); err == nil {
+		t.Fatal("expected error without gpu uuid")
+	}
+	if err := SetGPUAnnotation(ctx, db, GPUAnnotation{GPUUUID: "GPU-0", Key: "a/b", Value: "true"}); err == nil {
+		t.Fatal("expected error with invalid key")
+	}
+
+	as, err := ReadGPUAnnotations(ctx, db, "GPU-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != 2 {
+		t.Fatalf("expected 2 annotations, got %+v", as)
+	}
+
+	as, err = ReadGPUAnnotations(ctx, db, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := GPUAnnotationsByUUID(as)
+	expected := GPUAnnotations{
+		"GPU-0": {GPUAnnotationKeySuspect: "OPS-1", GPUAnnotationKeyBenchmarkingOnly: "true"},
+		"GPU-1": {GPUAnnotationKeyBenchmarkingOnly: "true"},
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("unexpected annotations %v", m)
+	}
+	if !m.IsSuspect("GPU-0") || m.IsSuspect("GPU-1") || m.IsSuspect("GPU-2") {
+		t.Fatal("unexpected suspect GPUs")
+	}
+
+	// empty value deletes
+	if err := SetGPUAnnotation(ctx, db, GPUAnnotation{GPUUUID: "GPU-0", Key: GPUAnnotationKeySuspect}); err != nil {
+		t.Fatal(err)
+	}
+	as, err = ReadGPUAnnotations(ctx, db, "GPU-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if GPUAnnotationsByUUID(as).IsSuspect("GPU-0") {
+		t.Fatal("expected suspect annotation deleted")
+	}
+}
+
+func TestGPUAnnotationsAnnotate(t *testing.T) {
+	t.Parallel()
+
+	m := GPUAnnotations{
+		"GPU-0": {GPUAnnotationKeySuspect: "OPS-1"},
+		"GPU-1": {GPUAnnotationKeyBenchmarkingOnly: "true"},
+	}
+
+	states := []components.State{
+		{Name: "error_xid_GPU-0", Annotations: map[string]string{"ticket": "OPS-2"}},
+		{Name: "clock", ExtraInfo: map[string]string{"gpu_uuid": "GPU-1"}},
+		{Name: "disk"},
+	}
+	m.AnnotateStates(states)
+	if !reflect.DeepEqual(states[0].Annotations, map[string]string{"ticket": "OPS-2", "gpu/GPU-0/suspect": "OPS-1"}) {
+		t.Errorf("unexpected annotations %v", states[0].Annotations)
+	}
+	if !reflect.DeepEqual(states[1].Annotations, map[string]string{"gpu/GPU-1/benchmarking-only": "true"}) {
+		t.Errorf("unexpected annotations %v", states[1].Annotations)
+	}
+	if states[2].Annotations != nil {
+		t.Errorf("unexpected annotations %v", states[2].Annotations)
+	}
+
+	events := []components.Event{
+		{Name: "error_xid", Message: "xid 79 on GPU-0 and GPU-1"},
+		{Name: "error_xid", Message: "xid 79 on GPU-2"},
+	}
+	m.AnnotateEvents(events)
+	if !reflect.DeepEqual(events[0].Annotations, map[string]string{"gpu/GPU-0/suspect": "OPS-1", "gpu/GPU-1/benchmarking-only": "true"}) {
+		t.Errorf("unexpected annotations %v", events[0].Annotations)
+	}
+	if events[1].Annotations != nil {
+		t.Errorf("unexpected annotations %v", events[1].Annotations)
+	}
+}
@@ -0,0 +1,169 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	SLOPeriodDaily  = "daily"
+	SLOPeriodWeekly = "weekly"
+)
+
+// SLOWindow is a time range to compute the health SLOs over.
+type SLOWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// SLOWindows returns the last "count" complete daily or weekly windows before the given time,
+// in the ascending order. Daily windows are aligned to the UTC midnight,
+// and weekly windows are aligned to the Monday UTC midnight.
+func SLOWindows(now time.Time, period string, count int) ([]SLOWindow, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive, got %d", count)
+	}
+
+	now = now.UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var dur time.Duration
+	switch period {
+	case SLOPeriodDaily:
+		dur = 24 * time.Hour
+	case SLOPeriodWeekly:
+		dur = 7 * 24 * time.Hour
+		// time.Sunday is 0
+		end = end.AddDate(0, 0, -((int(end.Weekday()) + 6) % 7))
+	default:
+		return nil, fmt.Errorf("unknown period %q (must be %q or %q)", period, SLOPeriodDaily, SLOPeriodWeekly)
+	}
+
+	windows := make([]SLOWindow, count)
+	for i := count - 1; i >= 0; i-- {
+		windows[i] = SLOWindow{Start: end.Add(-dur), End: end}
+		end = end.Add(-dur)
+	}
+	return windows, nil
+}
+
+// SLO is the percentage of time healthy over a window.
+// The time with no recorded state (e.g., gpud was not running) is excluded.
+type SLO struct {
+	ObservedSeconds int64 `json:"observed_seconds"`
+	HealthySeconds  int64 `json:"healthy_seconds"`
+
+	// ObservedPercent is the percentage of the window with the recorded states.
+	ObservedPercent float64 `json:"observed_percent"`
+	// HealthyPercent is the percentage of the observed time being healthy.
+	HealthyPercent float64 `json:"healthy_percent"`
+}
+
+type ComponentSLO struct {
+	Component string `json:"component"`
+	SLO
+}
+
+// SLOReport is the per-component and per-node health SLOs over a window.
+type SLOReport struct {
+	Window SLOWindow `json:"window"`
+
+	// Node is healthy only when all the observed components are healthy.
+	Node       SLO            `json:"node"`
+	Components []ComponentSLO `json:"components"`
+}
+
+// ComputeSLOReport computes the health SLOs over the window,
+// from the states history of each component (see ReadStatesHistory).
+func ComputeSLOReport(window SLOWindow, histories map[string][]StatesHistory) SLOReport {
+	comps := make([]string, 0, len(histories))
+	for c := range histories {
+		comps = append(comps, c)
+	}
+	sort.Strings(comps)
+
+	// each record covers from its start until the next interval
+	// (or the start of the next record, whichever comes first)
+	type span struct {
+		start   int64
+		end     int64
+		healthy bool
+	}
+	winStart, winEnd := window.Start.Unix(), window.End.Unix()
+	spans := make(map[string][]span, len(comps))
+	boundaries := []int64{winStart, winEnd}
+	for _, c := range comps {
+		hs := histories[c]
+		for i, h := range hs {
+			start := h.Start.Unix()
+			end := h.End.Add(DefaultStatesHistoryInterval).Unix()
+			if i+1 < len(hs) && hs[i+1].Start.Unix() < end {
+				end = hs[i+1].Start.Unix()
+			}
+			start, end = max(start, winStart), min(end, winEnd)
+			if start >= end {
+				continue
+			}
+			spans[c] = append(spans[c], span{start: start, end: end, healthy: h.Healthy})
+			boundaries = append(boundaries, start, end)
+		}
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i] < boundaries[j] })
+
+	report := SLOReport{Window: window}
+	compSLOs := make(map[string]*SLO, len(comps))
+	for _, c := range comps {
+		compSLOs[c] = &SLO{}
+	}
+	idx := make(map[string]int, len(comps))
+	for i := 0; i+1 < len(boundaries); i++ {
+		segStart, segEnd := boundaries[i], boundaries[i+1]
+		if segStart == segEnd {
+			continue
+		}
+		secs := segEnd - segStart
+
+		observed, healthy := false, true
+		for _, c := range comps {
+			ss := spans[c]
+			for idx[c] < len(ss) && ss[idx[c]].end <= segStart {
+				idx[c]++
+			}
+			if idx[c] >= len(ss) || ss[idx[c]].start > segStart {
+				continue
+			}
+			observed = true
+			compSLOs[c].ObservedSeconds += secs
+			if ss[idx[c]].healthy {
+				compSLOs[c].HealthySeconds += secs
+			} else {
+				healthy = false
+			}
+		}
+		if observed {
+			report.Node.ObservedSeconds += secs
+			if healthy {
+				report.Node.HealthySeconds += secs
+			}
+		}
+	}
+
+	windowSeconds := winEnd - winStart
+	report.Node.setPercents(windowSeconds)
+	for _, c := range comps {
+		slo := compSLOs[c]
+		slo.setPercents(windowSeconds)
+		report.Components = append(report.Components, ComponentSLO{Component: c, SLO: *slo})
+	}
+	return report
+}
+
+func (s *SLO) setPercents(windowSeconds int64) {
+	if windowSeconds > 0 {
+		s.ObservedPercent = float64(s.ObservedSeconds) / float64(windowSeconds) * 100
+	}
+	if s.ObservedSeconds > 0 {
+		s.HealthyPercent = float64(s.HealthySeconds) / float64(s.ObservedSeconds) * 100
+	}
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSLOWindows(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday
+	now := time.Date(2024, 11, 13, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period    string
+		count     int
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{SLOPeriodDaily, 2, time.Date(2024, 11, 11, 0, 0, 0, 0, time.UTC), time.Date(2024, 11, 13, 0, 0, 0, 0, time.UTC), false},
+		{SLOPeriodWeekly, 1, time.Date(2024, 11, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 11, 11, 0, 0, 0, 0, time.UTC), false},
+		{"monthly", 1, time.Time{}, time.Time{}, true},
+		{SLOPeriodDaily, 0, time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			ws, err := SLOWindows(now, tt.period, tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(ws) != tt.count {
+				t.Fatalf("expected %d windows, got %d", tt.count, len(ws))
+			}
+			if !ws[0].Start.Equal(tt.wantStart) || !ws[len(ws)-1].End.Equal(tt.wantEnd) {
+				t.Fatalf("unexpected windows %+v", ws)
+			}
+		})
+	}
+}
+
+func TestComputeSLOReport(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 11, 13, 0, 0, 0, 0, time.UTC)
+	window := SLOWindow{Start: base, End: base.Add(time.Hour)}
+
+	histories := map[string][]StatesHistory{
+		// healthy for the first 30 minutes, unhealthy for the next 10 minutes, then unknown
+		"a": {
+			{Start: base, End: base.Add(29 * time.Minute), Healthy: true},
+			{Start: base.Add(30 * time.Minute), End: base.Add(39 * time.Minute), Healthy: false},
+		},
+		// healthy for the whole hour (record started before the window)
+		"b": {
+			{Start: base.Add(-time.Hour), End: base.Add(time.Hour), Healthy: true},
+		},
+	}
+
+	report := ComputeSLOReport(window, histories)
+	if len(report.Components) != 2 || report.Components[0].Component != "a" {
+		t.Fatalf("unexpected components %+v", report.Components)
+	}
+
+	a := report.Components[0]
+	if a.ObservedSeconds != 40*60 || a.HealthySeconds != 30*60 {
+		t.Fatalf("unexpected slo for a %+v", a)
+	}
+	b := report.Components[1]
+	if b.ObservedSeconds != 3600 || b.HealthySeconds != 3600 || b.HealthyPercent != 100 || b.ObservedPercent != 100 {
+		t.Fatalf("unexpected slo for b %+v", b)
+	}
+
+	// node is unhealthy while "a" is unhealthy
+	if report.Node.ObservedSeconds != 3600 || report.Node.HealthySeconds != 50*60 {
+		t.Fatalf("unexpected node slo %+v", report.Node)
+	}
+
+	empty := ComputeSLOReport(window, nil)
+	if empty.Node.ObservedSeconds != 0 || empty.Node.HealthyPercent != 0 {
+		t.Fatalf("unexpected empty report %+v", empty)
+	}
+}
@@ -0,0 +1,244 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+)
+
+const (
+	TableNameStatesHistory = "components_states_history"
+
+	ColumnComponent        = "component"
+	ColumnStartUnixSeconds = "start_unix_seconds"
+	ColumnEndUnixSeconds   = "end_unix_seconds"
+	ColumnHealthy          = "healthy"
+	ColumnStates           = "states"
+	ColumnSignature        = "signature"
+)
+
+// DefaultStatesHistoryInterval is the interval to record the component states.
+// A recorded state is considered valid until its end time plus twice the interval,
+// after which the state is unknown (e.g., gpud was not running).
+const DefaultStatesHistoryInterval = time.Minute
+
+// ErrNoStatesHistory is returned when no state was recorded for the component at the given time.
+var ErrNoStatesHistory = errors.New("no states history")
+
+// StatesHistory is a time range where the component health remained the same.
+type StatesHistory struct {
+	Component string `json:"component"`
+
+	// Start is the first time the component was observed with this health.
+	Start time.Time `json:"start"`
+	// End is the last time the component was observed with this health.
+	End time.Time `json:"end"`
+
+	// Healthy is true if all the states were healthy.
+	Healthy bool `json:"healthy"`
+	// States are the component states as of the start time.
+	States []components.State `json:"states"`
+}
+
+func CreateTableStatesHistory(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL
+);`, TableNameStatesHistory,
+		ColumnComponent,
+		ColumnStartUnixSeconds,
+		ColumnEndUnixSeconds,
+		ColumnHealthy,
+		ColumnStates,
+		ColumnSignature,
+	)); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_%s_%s ON %s(%s, %s);`,
+		TableNameStatesHistory, ColumnComponent, ColumnStartUnixSeconds,
+		TableNameStatesHistory, ColumnComponent, ColumnStartUnixSeconds,
+	))
+	return err
+}
+
+// RecordStates records the component states observed at the given time.
+// If the component health is unchanged since the last record (and the record is not stale),
+// it only extends the end time of the last record, in order to keep the history compact.
+// Otherwise, it inserts a new record.
+func RecordStates(ctx context.Context, db *sql.DB, component string, states []components.State, now time.Time) error {
+	healthy, signature := healthSignature(states)
+
+	var (
+		rowID         int64
+		lastEnd       int64
+		lastSignature string
+	)
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`
+SELECT rowid, %s, %s FROM %s
+WHERE %s = ?
+ORDER BY %s DESC
+LIMIT 1;`,
+		ColumnEndUnixSeconds,
+		ColumnSignature,
+		TableNameStatesHistory,
+		ColumnComponent,
+		ColumnStartUnixSeconds,
+	), component).Scan(&rowID, &lastEnd, &lastSignature)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	nowUnix := now.UTC().Unix()
+	stale := nowUnix-lastEnd > int64(2*DefaultStatesHistoryInterval/time.Second)
+	if err == nil && lastSignature == signature && !stale {
+		_, err = db.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET %s = ? WHERE rowid = ?;`,
+			TableNameStatesHistory,
+			ColumnEndUnixSeconds,
+		), nowUnix, rowID)
+		return err
+	}
+
+	b, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?);`,
+		TableNameStatesHistory,
+		ColumnComponent,
+		ColumnStartUnixSeconds,
+		ColumnEndUnixSeconds,
+		ColumnHealthy,
+		ColumnStates,
+		ColumnSignature,
+	), component, nowUnix, nowUnix, healthy, string(b), signature)
+	return err
+}
+
+// ReadStatesAt returns the component states as of the given time.
+// Returns ErrNoStatesHistory if no state was recorded around the time.
+func ReadStatesAt(ctx context.Context, db *sql.DB, component string, at time.Time) (StatesHistory, error) {
+	row := db.QueryRowContext(ctx, fmt.Sprintf(`
+SELECT %s, %s, %s, %s FROM %s
+WHERE %s = ? AND %s <= ?
+ORDER BY %s DESC
+LIMIT 1;`,
+		ColumnStartUnixSeconds,
+		ColumnEndUnixSeconds,
+		ColumnHealthy,
+		ColumnStates,
+		TableNameStatesHistory,
+		ColumnComponent,
+		ColumnStartUnixSeconds,
+		ColumnStartUnixSeconds,
+	), component, at.UTC().Unix())
+
+	h, err := scanStatesHistory(component, row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return StatesHistory{}, ErrNoStatesHistory
+	}
+	if err != nil {
+		return StatesHistory{}, err
+	}
+	if at.Sub(h.End) > 2*DefaultStatesHistoryInterval {
+		return StatesHistory{}, ErrNoStatesHistory
+	}
+	return h, nil
+}
+
+// ReadStatesHistory returns the component states history overlapping with the time range,
+// in the ascending order of the start time.
+func ReadStatesHistory(ctx context.Context, db *sql.DB, component string, start time.Time, end time.Time) ([]StatesHistory, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+SELECT %s, %s, %s, %s FROM %s
+WHERE %s = ? AND %s >= ? AND %s <= ?
+ORDER BY %s ASC;`,
+		ColumnStartUnixSeconds,
+		ColumnEndUnixSeconds,
+		ColumnHealthy,
+		ColumnStates,
+		TableNameStatesHistory,
+		ColumnComponent,
+		ColumnEndUnixSeconds,
+		ColumnStartUnixSeconds,
+		ColumnStartUnixSeconds,
+	), component, start.UTC().Unix(), end.UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hs []StatesHistory
+	for rows.Next() {
+		h, err := scanStatesHistory(component, rows)
+		if err != nil {
+			return nil, err
+		}
+		hs = append(hs, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return hs, nil
+}
+
+// PurgeStatesHistory deletes the states history that ended before the given time.
+func PurgeStatesHistory(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?;`, TableNameStatesHistory, ColumnEndUnixSeconds), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
+func scanStatesHistory(component string, row interface{ Scan(...any) error }) (StatesHistory, error) {
+	var (
+		startUnix int64
+		endUnix   int64
+		healthy   bool
+		states    string
+	)
+	if err := row.Scan(&startUnix, &endUnix, &healthy, &states); err != nil {
+		return StatesHistory{}, err
+	}
+	h := StatesHistory{
+		Component: component,
+		Start:     time.Unix(startUnix, 0).UTC(),
+		End:       time.Unix(endUnix, 0).UTC(),
+		Healthy:   healthy,
+	}
+	if err := json.Unmarshal([]byte(states), &h.States); err != nil {
+		return StatesHistory{}, err
+	}
+	return h, nil
+}
+
+// healthSignature returns whether all the states are healthy,
+// and the signature that only changes when the health of any state changes
+// (not when the reason changes, which often embeds the latest readings).
+func healthSignature(states []components.State) (bool, string) {
+	healthy := true
+	parts := make([]string, 0, len(states))
+	for _, s := range states {
+		if !s.Healthy {
+			healthy = false
+		}
+		parts = append(parts, fmt.Sprintf("%s=%t", s.Name, s.Healthy))
+	}
+	return healthy, strings.Join(parts, ",")
+}
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestStatesHistory(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := CreateTableStatesHistory(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	base := time.Unix(1700000000, 0).UTC()
+	healthy := []components.State{{Name: "a", Healthy: true, Reason: "temp 50C"}}
+	healthyNewReason := []components.State{{Name: "a", Healthy: true, Reason: "temp 51C"}}
+	unhealthy := []components.State{{Name: "a", Healthy: false, Reason: "xid 79"}}
+
+	records := []struct {
+		at     time.Time
+		states []components.State
+	}{
+		{base, healthy},
+		{base.Add(time.Minute), healthyNewReason}, // extends the first record
+		{base.Add(2 * time.Minute), unhealthy},    // new record
+		{base.Add(3 * time.Minute), unhealthy},    // extends the second record
+		{base.Add(time.Hour), unhealthy},          // new record after the gap
+	}
+	for _, r := range records {
+		if err := RecordStates(ctx, db, "comp", r.states, r.at); err != nil {
+			t.Fatalf("RecordStates failed: %v", err)
+		}
+	}
+
+	hs, err := ReadStatesHistory(ctx, db, "comp", base, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("ReadStatesHistory failed: %v", err)
+	}
+	if len(hs) != 3 {
+		t.Fatalf("expected 3 records, got %d (%+v)", len(hs), hs)
+	}
+	if !hs[0].Healthy || !hs[0].End.Equal(base.Add(time.Minute)) || hs[0].States[0].Reason != "temp 50C" {
+		t.Fatalf("unexpected first record %+v", hs[0])
+	}
+	if hs[1].Healthy || !hs[1].Start.Equal(base.Add(2*time.Minute)) || !hs[1].End.Equal(base.Add(3*time.Minute)) {
+		t.Fatalf("unexpected second record %+v", hs[1])
+	}
+
+	tests := []struct {
+		at          time.Time
+		wantHealthy bool
+		wantErr     error
+	}{
+		{base.Add(-time.Minute), false, ErrNoStatesHistory},
+		{base.Add(30 * time.Second), true, nil},
+		{base.Add(150 * time.Second), false, nil},
+		{base.Add(30 * time.Minute), false, ErrNoStatesHistory},
+		{base.Add(time.Hour), false, nil},
+	}
+	for _, tt := range tests {
+		h, err := ReadStatesAt(ctx, db, "comp", tt.at)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("at %v: expected error %v, got %v", tt.at, tt.wantErr, err)
+		}
+		if err == nil && h.Healthy != tt.wantHealthy {
+			t.Fatalf("at %v: expected healthy %v, got %v", tt.at, tt.wantHealthy, h.Healthy)
+		}
+	}
+
+	purged, err := PurgeStatesHistory(ctx, db, base.Add(10*time.Minute))
+	if err != nil {
+		t.Fatalf("PurgeStatesHistory failed: %v", err)
+	}
+	if purged != 2 {
+		t.Fatalf("expected 2 purged, got %d", purged)
+	}
+}
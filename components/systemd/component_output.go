@@ -8,12 +8,11 @@ import (
 	"sync"
 	"time"
 
-	"github.com/dustin/go-humanize"
-
 	"github.com/leptonai/gpud/components"
 	components_metrics "github.com/leptonai/gpud/components/metrics"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/locale"
 	"github.com/leptonai/gpud/pkg/systemd"
 )
 
@@ -52,6 +51,28 @@ const (
 	StateKeyUnitUptimeHumanized = "uptime_humanized"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.StateSchema{
+			Name:        StateNameSystemd,
+			Description: "systemd information",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeySystemdVersion, Type: components.ExtraInfoTypeString, Description: "systemd version"},
+			},
+		},
+		components.StateSchema{
+			Name:        StateNameUnit,
+			Description: "systemd unit status, one state per unit",
+			ExtraInfo: []components.ExtraInfoSchema{
+				{Key: StateKeyUnitName, Type: components.ExtraInfoTypeString, Description: "unit name"},
+				{Key: StateKeyUnitActive, Type: components.ExtraInfoTypeBool, Description: "true if the unit is active"},
+				{Key: StateKeyUnitUptimeSeconds, Type: components.ExtraInfoTypeInt, Unit: "seconds", Description: "unit uptime"},
+				{Key: StateKeyUnitUptimeHumanized, Type: components.ExtraInfoTypeString, Description: "unit uptime, humanized"},
+			},
+		},
+	)
+}
+
 func ParseStateSystemd(m map[string]string) (*Output, error) {
 	o := &Output{}
 	o.SystemdVersion = m[StateKeySystemdVersion]
@@ -183,7 +204,7 @@ func CreateGet(cfg Config) query.GetFunc {
 				uptimeSeconds = int64(uptime.Seconds())
 
 				now := time.Now().UTC()
-				uptimeDescription = humanize.RelTime(now.Add(-*uptime), now, "ago", "from now")
+				uptimeDescription = locale.RelTime(now.Add(-*uptime), now)
 			}
 			o.Units = append(o.Units, Unit{
 				Name:            unit,
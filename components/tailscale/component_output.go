@@ -38,6 +38,12 @@ const (
 	StateValueTailscaleVersionEncodingJSON = "json"
 )
 
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameTailscaleVersion, "tailscale version"),
+	)
+}
+
 func ParseStateTailscale(m map[string]string) (*Output, error) {
 	o := &Output{}
 
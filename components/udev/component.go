@@ -0,0 +1,165 @@
+// Package udev listens to the kernel device events (uevents) for the GPU, NVMe, and NIC devices,
+// and reports the device add/remove/change events and the devices disappeared since the boot.
+package udev
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/uevent"
+
+	"github.com/shirou/gopsutil/v4/host"
+)
+
+const Name = "udev"
+
+// purgeInterval is the interval to purge the device events older than the retention.
+const purgeInterval = time.Hour
+
+func New(ctx context.Context, cfg Config) (components.Component, error) {
+	cfg.SetDefaultsIfNotSet()
+
+	var db *sql.DB
+	if cfg.Query.State != nil {
+		db = cfg.Query.State.DB
+	}
+	if db == nil {
+		return nil, fmt.Errorf("%s requires the state database", Name)
+	}
+	if err := CreateTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	cctx, ccancel := context.WithCancel(ctx)
+	c := &component{
+		cancel: ccancel,
+		cfg:    cfg,
+		db:     db,
+	}
+
+	ch, unsubscribe, err := uevent.Subscribe()
+	if err != nil {
+		log.Logger.Warnw("kernel uevents not available -- not tracking device events", "error", err)
+		return c, nil
+	}
+	c.listening = true
+	go c.record(cctx, ch, unsubscribe)
+	go c.purge(cctx)
+
+	return c, nil
+}
+
+var _ components.Component = (*component)(nil)
+
+type component struct {
+	cancel context.CancelFunc
+	cfg    Config
+	db     *sql.DB
+
+	listening bool
+}
+
+func (c *component) Name() string { return Name }
+
+func (c *component) States(ctx context.Context) ([]components.State, error) {
+	// the devices removed before the reboot are re-enumerated without the uevents
+	bootTime, err := host.BootTimeWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	since := time.Unix(int64(bootTime), 0)
+	if retained := time.Now().Add(-c.cfg.Retention.Duration); retained.After(since) {
+		since = retained
+	}
+
+	evs, err := ReadEvents(ctx, c.db, since)
+	if err != nil {
+		return nil, err
+	}
+	return ToOutput(c.listening, evs).States()
+}
+
+func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	evs, err := ReadEvents(ctx, c.db, since)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]components.Event, 0, len(evs))
+	for _, ev := range evs {
+		events = append(events, ev.ToComponentEvent())
+	}
+	return events, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	return nil, nil
+}
+
+func (c *component) Close() error {
+	log.Logger.Debugw("closing component", "component", Name)
+	c.cancel()
+	return nil
+}
+
+// record records the GPU, NVMe, and NIC add/remove/change events until the context is canceled.
+func (c *component) record(ctx context.Context, ch <-chan uevent.Event, unsubscribe func()) {
+	defer unsubscribe()
+
+	for {
+		var ev uevent.Event
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-ch:
+			if !ok {
+				log.Logger.Warnw("kernel uevents closed -- not tracking device events")
+				return
+			}
+			ev = e
+		}
+
+		switch ev.Action {
+		case uevent.ActionAdd, uevent.ActionRemove, uevent.ActionChange:
+		default:
+			continue
+		}
+		dev, ok := Classify(ev)
+		if !ok {
+			continue
+		}
+
+		log.Logger.Infow("device event", "action", ev.Action, "device", dev.String(), "devpath", ev.DevPath)
+		if err := InsertEvent(ctx, c.db, DeviceEvent{
+			UnixSeconds: time.Now().UTC().Unix(),
+			Action:      ev.Action,
+			Device:      dev,
+			DevPath:     ev.DevPath,
+		}); err != nil {
+			log.Logger.Warnw("failed to record device event", "error", err)
+		}
+	}
+}
+
+func (c *component) purge(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := Purge(ctx, c.db, time.Now().Add(-c.cfg.Retention.Duration))
+		if err != nil {
+			log.Logger.Warnw("failed to purge device events", "error", err)
+			continue
+		}
+		log.Logger.Debugw("purged device events", "purged", purged)
+	}
+}
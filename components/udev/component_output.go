@@ -0,0 +1,178 @@
+package udev
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/common"
+	"github.com/leptonai/gpud/pkg/uevent"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	EventNameDeviceAdded   = "device_added"
+	EventNameDeviceRemoved = "device_removed"
+	EventNameDeviceChanged = "device_changed"
+)
+
+// ToComponentEvent converts the device event to the component event,
+// a warning for the removed device and an info for the others.
+func (ev DeviceEvent) ToComponentEvent() components.Event {
+	e := components.Event{
+		Time: metav1.Time{Time: time.Unix(ev.UnixSeconds, 0).UTC()},
+		Type: components.EventTypeInfo,
+		ExtraInfo: map[string]string{
+			"action":  ev.Action,
+			"kind":    ev.Device.Kind,
+			"device":  ev.Device.Name,
+			"devpath": ev.DevPath,
+		},
+	}
+	switch ev.Action {
+	case uevent.ActionAdd:
+		e.Name = EventNameDeviceAdded
+		e.Message = fmt.Sprintf("%s added", ev.Device)
+	case uevent.ActionRemove:
+		e.Name = EventNameDeviceRemoved
+		e.Type = components.EventTypeWarn
+		e.Message = fmt.Sprintf("%s removed", ev.Device)
+	default:
+		e.Name = EventNameDeviceChanged
+		e.Message = fmt.Sprintf("%s changed", ev.Device)
+	}
+	return e
+}
+
+// Disappeared is a device removed and not added back.
+type Disappeared struct {
+	Device    Device    `json:"device"`
+	RemovedAt time.Time `json:"removed_at"`
+}
+
+type Output struct {
+	// Listening is false if the kernel uevents are not available (e.g., non-linux).
+	Listening   bool          `json:"listening"`
+	Disappeared []Disappeared `json:"disappeared,omitempty"`
+}
+
+// ToOutput finds the devices whose latest event is the removal,
+// from the device events in the ascending order of the time.
+func ToOutput(listening bool, evs []DeviceEvent) *Output {
+	o := &Output{Listening: listening}
+
+	latest := make(map[Device]DeviceEvent)
+	var order []Device
+	for _, ev := range evs {
+		if ev.Action != uevent.ActionAdd && ev.Action != uevent.ActionRemove {
+			continue
+		}
+		if _, ok := latest[ev.Device]; !ok {
+			order = append(order, ev.Device)
+		}
+		latest[ev.Device] = ev
+	}
+	for _, dev := range order {
+		if ev := latest[dev]; ev.Action == uevent.ActionRemove {
+			o.Disappeared = append(o.Disappeared, Disappeared{
+				Device:    dev,
+				RemovedAt: time.Unix(ev.UnixSeconds, 0).UTC(),
+			})
+		}
+	}
+	return o
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNameDevices = "devices"
+
+	StateKeyDevicesData           = "data"
+	StateKeyDevicesEncoding       = "encoding"
+	StateValueDevicesEncodingJSON = "json"
+)
+
+func init() {
+	components.RegisterStateSchemas(Name,
+		components.JSONStateSchema(StateNameDevices, "device add/remove events since the boot"),
+	)
+}
+
+func ParseStateDevices(m map[string]string) (*Output, error) {
+	data := m[StateKeyDevicesData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNameDevices:
+			o, err := ParseStateDevices(state.ExtraInfo)
+			if err != nil {
+				return nil, err
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, errors.New("no state found")
+}
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool) {
+	if len(o.Disappeared) > 0 {
+		msgs := make([]string, 0, len(o.Disappeared))
+		for _, d := range o.Disappeared {
+			msgs = append(msgs, fmt.Sprintf("%s removed at %s", d.Device, d.RemovedAt.Format(time.RFC3339)))
+		}
+		return "device(s) disappeared: " + strings.Join(msgs, ", "), false
+	}
+	if !o.Listening {
+		return "kernel uevents not available (no device event tracked)", true
+	}
+	return "no device disappeared", true
+}
+
+func (o *Output) States() ([]components.State, error) {
+	reason, healthy := o.Evaluate()
+
+	b, _ := o.JSON()
+	state := components.State{
+		Name:    StateNameDevices,
+		Healthy: healthy,
+		Reason:  reason,
+		ExtraInfo: map[string]string{
+			StateKeyDevicesData:     string(b),
+			StateKeyDevicesEncoding: StateValueDevicesEncodingJSON,
+		},
+	}
+	if !healthy {
+		state.SuggestedActions = &common.SuggestedActions{
+			Descriptions: []string{
+				"check the dmesg for the device errors (e.g., the GPU fallen off the bus, the NVMe controller reset)",
+				"reboot the system to re-enumerate the device, and inspect the hardware if it disappears again",
+			},
+			RepairActions: []common.RepairActionType{
+				common.RepairActionTypeRebootSystem,
+			},
+		}
+	}
+	return []components.State{state}, nil
+}
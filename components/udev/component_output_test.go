@@ -0,0 +1,49 @@
+package udev
+
+import (
+	"testing"
+
+	"github.com/leptonai/gpud/pkg/uevent"
+)
+
+func TestToOutput(t *testing.T) {
+	gpu := Device{Kind: KindGPU, Name: "0000:18:00.0"}
+	nic := Device{Kind: KindNIC, Name: "ens3f0"}
+	nvme := Device{Kind: KindNVMe, Name: "nvme0"}
+
+	evs := []DeviceEvent{
+		{UnixSeconds: 100, Action: uevent.ActionRemove, Device: nic},
+		{UnixSeconds: 110, Action: uevent.ActionRemove, Device: gpu},
+		{UnixSeconds: 120, Action: uevent.ActionAdd, Device: nic},
+		{UnixSeconds: 130, Action: uevent.ActionChange, Device: gpu},
+		{UnixSeconds: 140, Action: uevent.ActionChange, Device: nvme},
+	}
+
+	o := ToOutput(true, evs)
+	if len(o.Disappeared) != 1 || o.Disappeared[0].Device != gpu || o.Disappeared[0].RemovedAt.Unix() != 110 {
+		t.Fatalf("unexpected disappeared devices %+v", o.Disappeared)
+	}
+	if _, healthy := o.Evaluate(); healthy {
+		t.Error("expected unhealthy")
+	}
+
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseStatesToOutput(states...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Disappeared) != 1 || parsed.Disappeared[0].Device != gpu {
+		t.Errorf("unexpected parsed output %+v", parsed)
+	}
+
+	if _, healthy := ToOutput(false, nil).Evaluate(); !healthy {
+		t.Error("expected healthy")
+	}
+
+	if ev := evs[1].ToComponentEvent(); ev.Name != EventNameDeviceRemoved || ev.ExtraInfo["device"] != gpu.Name {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
@@ -0,0 +1,51 @@
+package udev
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const DefaultRetention = 3 * 24 * time.Hour
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// Retention is the period to keep the device events, defaults to 3 days.
+	Retention metav1.Duration `json:"retention"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	cfg.Query.SetDefaultsIfNotSet()
+	if cfg.Retention.Duration == 0 {
+		cfg.Retention.Duration = DefaultRetention
+	}
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Retention.Duration < 0 {
+		return fmt.Errorf("invalid retention %v", cfg.Retention.Duration)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package udev
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/leptonai/gpud/pkg/uevent"
+)
+
+const (
+	KindGPU  = "gpu"
+	KindNVMe = "nvme"
+	KindNIC  = "nic"
+)
+
+// e.g., "nvme0n1" (namespace), not the partitions (e.g., "nvme0n1p1")
+var regexNVMeNamespace = regexp.MustCompile(`^nvme[0-9]+n[0-9]+$`)
+
+// Device is the device of a uevent.
+type Device struct {
+	Kind string `json:"kind"`
+	// Name is the PCI address of the GPU (e.g., "0000:18:00.0"),
+	// the NVMe controller or namespace (e.g., "nvme0", "nvme0n1"),
+	// or the network interface or the InfiniBand device (e.g., "eth0", "mlx5_0").
+	Name string `json:"name"`
+}
+
+func (d Device) String() string {
+	return d.Kind + " " + d.Name
+}
+
+// Classify returns the GPU, NVMe, or NIC device of the uevent.
+// Returns false for the other devices, including the virtual network interfaces
+// (e.g., the veth pairs of the containers).
+func Classify(ev uevent.Event) (Device, bool) {
+	switch ev.Subsystem {
+	case "pci":
+		// e.g., PCI_ID=10DE:2330, PCI_CLASS=30200
+		vendor, _, _ := strings.Cut(ev.Env["PCI_ID"], ":")
+		class := ev.Env["PCI_CLASS"]
+		// the class is printed without the leading zero (e.g., "30000" for 0x030000)
+		if strings.EqualFold(vendor, "10de") && len(class) == 5 && class[0] == '3' {
+			return Device{Kind: KindGPU, Name: pciSlotName(ev)}, true
+		}
+
+	case "nvme":
+		return Device{Kind: KindNVMe, Name: devName(ev)}, true
+
+	case "block":
+		if name := devName(ev); regexNVMeNamespace.MatchString(name) {
+			return Device{Kind: KindNVMe, Name: name}, true
+		}
+
+	case "net":
+		if strings.HasPrefix(ev.DevPath, "/devices/virtual/") {
+			return Device{}, false
+		}
+		name := ev.Env["INTERFACE"]
+		if name == "" {
+			name = path.Base(ev.DevPath)
+		}
+		return Device{Kind: KindNIC, Name: name}, true
+
+	case "infiniband":
+		name := ev.Env["NAME"]
+		if name == "" {
+			name = path.Base(ev.DevPath)
+		}
+		return Device{Kind: KindNIC, Name: name}, true
+	}
+	return Device{}, false
+}
+
+func pciSlotName(ev uevent.Event) string {
+	if name := ev.Env["PCI_SLOT_NAME"]; name != "" {
+		return name
+	}
+	return path.Base(ev.DevPath)
+}
+
+func devName(ev uevent.Event) string {
+	if name := ev.Env["DEVNAME"]; name != "" {
+		return path.Base(name)
+	}
+	return path.Base(ev.DevPath)
+}
@@ -0,0 +1,95 @@
+package udev
+
+import (
+	"testing"
+
+	"github.com/leptonai/gpud/pkg/uevent"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		ev       uevent.Event
+		expected Device
+		ok       bool
+	}{
+		{
+			name: "nvidia gpu",
+			ev: uevent.Event{
+				Subsystem: "pci",
+				DevPath:   "/devices/pci0000:00/0000:00:01.0/0000:18:00.0",
+				Env:       map[string]string{"PCI_ID": "10DE:2330", "PCI_CLASS": "30200", "PCI_SLOT_NAME": "0000:18:00.0"},
+			},
+			expected: Device{Kind: KindGPU, Name: "0000:18:00.0"},
+			ok:       true,
+		},
+		{
+			name: "nvidia nvswitch bridge",
+			ev: uevent.Event{
+				Subsystem: "pci",
+				Env:       map[string]string{"PCI_ID": "10DE:22A3", "PCI_CLASS": "68000", "PCI_SLOT_NAME": "0000:07:00.0"},
+			},
+		},
+		{
+			name: "nvme controller",
+			ev: uevent.Event{
+				Subsystem: "nvme",
+				DevPath:   "/devices/pci0000:00/0000:00:02.0/0000:02:00.0/nvme/nvme0",
+				Env:       map[string]string{"DEVNAME": "nvme0"},
+			},
+			expected: Device{Kind: KindNVMe, Name: "nvme0"},
+			ok:       true,
+		},
+		{
+			name: "nvme namespace",
+			ev: uevent.Event{
+				Subsystem: "block",
+				Env:       map[string]string{"DEVNAME": "nvme0n1", "DEVTYPE": "disk"},
+			},
+			expected: Device{Kind: KindNVMe, Name: "nvme0n1"},
+			ok:       true,
+		},
+		{
+			name: "nvme partition",
+			ev: uevent.Event{
+				Subsystem: "block",
+				Env:       map[string]string{"DEVNAME": "nvme0n1p1", "DEVTYPE": "partition"},
+			},
+		},
+		{
+			name: "physical nic",
+			ev: uevent.Event{
+				Subsystem: "net",
+				DevPath:   "/devices/pci0000:00/0000:00:03.0/0000:03:00.0/net/ens3f0",
+				Env:       map[string]string{"INTERFACE": "ens3f0"},
+			},
+			expected: Device{Kind: KindNIC, Name: "ens3f0"},
+			ok:       true,
+		},
+		{
+			name: "container veth",
+			ev: uevent.Event{
+				Subsystem: "net",
+				DevPath:   "/devices/virtual/net/veth1234",
+				Env:       map[string]string{"INTERFACE": "veth1234"},
+			},
+		},
+		{
+			name: "infiniband device",
+			ev: uevent.Event{
+				Subsystem: "infiniband",
+				DevPath:   "/devices/pci0000:00/0000:00:03.0/0000:03:00.0/infiniband/mlx5_0",
+			},
+			expected: Device{Kind: KindNIC, Name: "mlx5_0"},
+			ok:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Classify(tt.ev)
+			if ok != tt.ok || got != tt.expected {
+				t.Errorf("Classify() = %+v, %v, want %+v, %v", got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package udev
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const TableNameDeviceEvents = "components_udev_device_events"
+
+const (
+	ColumnUnixSeconds = "unix_seconds"
+	ColumnAction      = "action"
+	ColumnKind        = "kind"
+	ColumnDevice      = "device"
+	ColumnDevPath     = "devpath"
+)
+
+// DeviceEvent is a device add/remove/change uevent.
+type DeviceEvent struct {
+	UnixSeconds int64
+	// Action is "add", "remove", or "change".
+	Action  string
+	Device  Device
+	DevPath string
+}
+
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL
+);`, TableNameDeviceEvents,
+		ColumnUnixSeconds,
+		ColumnAction,
+		ColumnKind,
+		ColumnDevice,
+		ColumnDevPath,
+	))
+	return err
+}
+
+func InsertEvent(ctx context.Context, db *sql.DB, ev DeviceEvent) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?);`,
+		TableNameDeviceEvents,
+		ColumnUnixSeconds,
+		ColumnAction,
+		ColumnKind,
+		ColumnDevice,
+		ColumnDevPath,
+	), ev.UnixSeconds, ev.Action, ev.Device.Kind, ev.Device.Name, ev.DevPath)
+	return err
+}
+
+// ReadEvents returns the device events since the given time, in the ascending order of the time.
+func ReadEvents(ctx context.Context, db *sql.DB, since time.Time) ([]DeviceEvent, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT %s, %s, %s, %s, %s
+FROM %s
+WHERE %s >= ?
+ORDER BY %s ASC`,
+		ColumnUnixSeconds, ColumnAction, ColumnKind, ColumnDevice, ColumnDevPath,
+		TableNameDeviceEvents,
+		ColumnUnixSeconds,
+		ColumnUnixSeconds,
+	), since.UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var evs []DeviceEvent
+	for rows.Next() {
+		var ev DeviceEvent
+		if err := rows.Scan(&ev.UnixSeconds, &ev.Action, &ev.Device.Kind, &ev.Device.Name, &ev.DevPath); err != nil {
+			return nil, err
+		}
+		evs = append(evs, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return evs, nil
+}
+
+// Purge deletes the device events before the given time.
+func Purge(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?`, TableNameDeviceEvents, ColumnUnixSeconds), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
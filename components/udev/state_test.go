@@ -0,0 +1,54 @@
+package udev
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+	"github.com/leptonai/gpud/pkg/uevent"
+)
+
+func TestInsertAndReadEvents(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTable(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	now := time.Now().UTC()
+	evs := []DeviceEvent{
+		{UnixSeconds: now.Add(-2 * time.Hour).Unix(), Action: uevent.ActionRemove, Device: Device{Kind: KindGPU, Name: "0000:18:00.0"}, DevPath: "/devices/pci0000:00/0000:18:00.0"},
+		{UnixSeconds: now.Add(-time.Hour).Unix(), Action: uevent.ActionAdd, Device: Device{Kind: KindNIC, Name: "ens3f0"}, DevPath: "/devices/pci0000:00/0000:03:00.0/net/ens3f0"},
+	}
+	for _, ev := range evs {
+		if err := InsertEvent(ctx, db, ev); err != nil {
+			t.Fatalf("InsertEvent failed: %v", err)
+		}
+	}
+
+	read, err := ReadEvents(ctx, db, now.Add(-90*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 1 || read[0] != evs[1] {
+		t.Fatalf("unexpected events %+v", read)
+	}
+
+	purged, err := Purge(ctx, db, now.Add(-90*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Errorf("expected 1 purged, got %d", purged)
+	}
+}
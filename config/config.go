@@ -8,6 +8,14 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/leptonai/gpud/internal/notify"
+	"github.com/leptonai/gpud/internal/upgrade"
+	"github.com/leptonai/gpud/internal/validate"
+	"github.com/leptonai/gpud/pkg/execlimit"
+	"github.com/leptonai/gpud/pkg/locale"
+	"github.com/leptonai/gpud/pkg/lowoverhead"
+	"github.com/leptonai/gpud/pkg/netutil"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 )
@@ -22,9 +30,23 @@ type Config struct {
 	// Address for the server to listen on.
 	Address string `json:"address"`
 
+	// IP address family of the listeners, probes, and push endpoints
+	// ("dual", "ipv4", or "ipv6" for the IPv6-only clusters).
+	// Defaults to dual-stack if not set.
+	AddressFamily netutil.AddressFamily `json:"address_family,omitempty"`
+
+	// Egress HTTP(S) proxy and custom CA bundle for the outbound calls
+	// (e.g., webhooks, session push, self-update).
+	// Falls back to the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables if not set.
+	Proxy *netutil.ProxyConfig `json:"proxy,omitempty"`
+
 	// Component specific configurations.
 	Components map[string]any `json:"components,omitempty"`
 
+	// Named group of the components enabled (e.g., "minimal", "full", "fabric").
+	// Enables all the auto-detected components if not set.
+	Profile Profile `json:"profile,omitempty"`
+
 	// State file that persists the latest status.
 	// If empty, the states are not persisted to file.
 	State string `json:"state"`
@@ -33,10 +55,78 @@ type Config struct {
 	// Once elapsed, old states/metrics are purged/compacted.
 	RetentionPeriod metav1.Duration `json:"retention_period"`
 
+	// Amount of time to retain the component states history for,
+	// which is used to query the past states (e.g., "was this node healthy at time T").
+	// Disables the states history if not set.
+	StatesHistoryRetentionPeriod metav1.Duration `json:"states_history_retention_period"`
+
+	// Amount of time the node must be fully healthy for to take the last-known-good snapshot
+	// of the driver/firmware/config state, which the critical event notifications are compared against.
+	// Disables the last-known-good tracking if not set.
+	LastKnownGoodPeriod metav1.Duration `json:"last_known_good_period"`
+
 	// Interval at which to refresh selected components.
 	// Disables refresh if not set.
 	RefreshComponentsInterval metav1.Duration `json:"refresh_components_interval"`
 
+	// Configures the event notifications (e.g., Slack, pager webhooks).
+	// Disables the notifications if not set.
+	Notify *notify.Config `json:"notify,omitempty"`
+
+	// Set true to annotate the unhealthy states of the components with all events acknowledged
+	// (known issues), so the operators can tell them from the new issues.
+	// The states are still reported as unhealthy, unless "acked_as_degraded" is set.
+	// The per-event acknowledgments only silence the repeat notifications.
+	AnnotateAckedStates bool `json:"annotate_acked_states,omitempty"`
+	// Set true to report the unhealthy states of the components with all events acknowledged
+	// (known issues) as degraded (healthy) instead of unhealthy, with the annotations.
+	AckedAsDegraded bool `json:"acked_as_degraded,omitempty"`
+
+	// Locale of the humanized durations and sizes in the component outputs
+	// (e.g., "3 hours ago", "83 MB"), such as "en", "zh", "ja", "ko", or "none"
+	// to disable the humanization. The rest of the reasons are always in English.
+	// Defaults to English if not set.
+	Locale string `json:"locale,omitempty"`
+
+	// Configures the post-repair validation checklist, run via the "/v1/lifecycle/validate" API
+	// or automatically when gpud starts in the "repairing" lifecycle state (e.g., after the reboot).
+	// Defaults to the GPU count, NVLink, DCGM diagnostics, and PCIe bandwidth checks if not set.
+	PostRepairValidation *validate.Config `json:"post_repair_validation,omitempty"`
+
+	// Configures the driver upgrade canary, which runs the smoke test, the PCIe bandwidth probe,
+	// and the ECC check when the NVIDIA driver version changes, and compares against the pre-upgrade baseline.
+	// Disabled if not set.
+	DriverUpgradeCanary *upgrade.Config `json:"driver_upgrade_canary,omitempty"`
+
+	// Configures the low-overhead mode for the latency-sensitive nodes (e.g., inference fleets),
+	// which reduces the sampling frequency, disables the active probes, and caps the gpud CPU usage.
+	// Disabled if not set.
+	LowOverhead *lowoverhead.Config `json:"low_overhead,omitempty"`
+
+	// Configures the global and per-component rate limits of the external command executions
+	// (e.g., "lspci", "nvidia-smi"), so the short poll intervals do not fork too many processes.
+	// Defaults to 10 commands per second globally and 1 per second per component (or command) if not set.
+	ExecRateLimit *execlimit.Config `json:"exec_rate_limit,omitempty"`
+
+	// Path of the JSON or YAML file of the NVIDIA XID/SXid details (e.g., shipped by NVIDIA or the fleet team),
+	// which override or add to the embedded details, so that the new error codes are classified
+	// without a gpud release. Reloaded when the file changes.
+	// Uses the embedded details only if not set.
+	XidSXidDetailsFile string `json:"xid_sxid_details_file,omitempty"`
+
+	// Configures the optional shared-memory ring buffer of the high-frequency GPU metrics.
+	// Disabled if not set.
+	HighFrequencyMetrics *HighFrequencyMetrics `json:"high_frequency_metrics,omitempty"`
+
+	// Set true to validate the component states against the registered state schemas
+	// (see the "/v1/states/schemas" API), logging the mismatches (e.g., unknown extra info keys).
+	ValidateStates bool `json:"validate_states,omitempty"`
+
+	// Set true if the node is expected to have the NVIDIA GPUs, to report the NVIDIA components unhealthy
+	// ("driver missing") when the NVIDIA driver is not loaded or the NVIDIA libraries are not found.
+	// Otherwise, the NVIDIA components report healthy ("not applicable"), e.g., on the CPU-only nodes.
+	GPUExpected bool `json:"gpu_expected,omitempty"`
+
 	// Set true to enable profiler.
 	Pprof bool `json:"pprof"`
 
@@ -67,15 +157,90 @@ type Web struct {
 	SincePeriod metav1.Duration `json:"since_period"`
 }
 
+// Configures the memory-mapped ring buffer of the high-frequency GPU metric samples
+// (utilization and power), for a co-located reader (e.g., a profiler) to read without the HTTP overhead.
+// See the "pkg/shmring" package for the file layout.
+type HighFrequencyMetrics struct {
+	// Path of the ring buffer file (e.g., "/dev/shm/gpud-metrics.ring").
+	Path string `json:"path"`
+
+	// Interval to sample the metrics.
+	Interval metav1.Duration `json:"interval"`
+
+	// Number of the samples (one per GPU per interval) to keep.
+	Capacity uint64 `json:"capacity"`
+}
+
+func (cfg *HighFrequencyMetrics) Validate() error {
+	if cfg.Path == "" {
+		return errors.New("path is required")
+	}
+	if cfg.Interval.Duration < 10*time.Millisecond {
+		return fmt.Errorf("interval must be at least 10 milliseconds, got %v", cfg.Interval.Duration)
+	}
+	if cfg.Capacity == 0 {
+		return errors.New("capacity must be greater than 0")
+	}
+	return nil
+}
+
 var ErrInvalidAutoUpdateExitCode = errors.New("auto_update_exit_code is only valid when auto_update is enabled")
 
 func (config *Config) Validate() error {
 	if config.Address == "" {
 		return errors.New("address is required")
 	}
+	if err := config.AddressFamily.Validate(); err != nil {
+		return err
+	}
+	if err := config.Proxy.Validate(); err != nil {
+		return fmt.Errorf("invalid proxy config: %w", err)
+	}
+	if err := config.Profile.Validate(); err != nil {
+		return err
+	}
+	if err := locale.Validate(config.Locale); err != nil {
+		return err
+	}
 	if config.RetentionPeriod.Duration < time.Minute {
 		return fmt.Errorf("retention_period must be at least 1 minute, got %d", config.RetentionPeriod.Duration)
 	}
+	if config.StatesHistoryRetentionPeriod.Duration != 0 && config.StatesHistoryRetentionPeriod.Duration < time.Hour {
+		return fmt.Errorf("states_history_retention_period must be at least 1 hour, got %d", config.StatesHistoryRetentionPeriod.Duration)
+	}
+	if config.LastKnownGoodPeriod.Duration != 0 && config.LastKnownGoodPeriod.Duration < time.Hour {
+		return fmt.Errorf("last_known_good_period must be at least 1 hour, got %d", config.LastKnownGoodPeriod.Duration)
+	}
+	if config.Notify != nil {
+		if err := config.Notify.Validate(); err != nil {
+			return fmt.Errorf("invalid notify config: %w", err)
+		}
+	}
+	if config.PostRepairValidation != nil {
+		if err := config.PostRepairValidation.Validate(); err != nil {
+			return fmt.Errorf("invalid post_repair_validation config: %w", err)
+		}
+	}
+	if config.DriverUpgradeCanary != nil {
+		if err := config.DriverUpgradeCanary.Validate(); err != nil {
+			return fmt.Errorf("invalid driver_upgrade_canary config: %w", err)
+		}
+	}
+	if config.LowOverhead != nil {
+		if err := config.LowOverhead.Validate(); err != nil {
+			return fmt.Errorf("invalid low_overhead config: %w", err)
+		}
+	}
+	if config.ExecRateLimit != nil {
+		if err := config.ExecRateLimit.Validate(); err != nil {
+			return fmt.Errorf("invalid exec_rate_limit config: %w", err)
+		}
+	}
+	if config.HighFrequencyMetrics != nil {
+		if err := config.HighFrequencyMetrics.Validate(); err != nil {
+			return fmt.Errorf("invalid high_frequency_metrics config: %w", err)
+		}
+	}
 	if config.RefreshComponentsInterval.Duration < time.Minute {
 		return fmt.Errorf("refresh_components_interval must be at least 1 minute, got %d", config.RefreshComponentsInterval.Duration)
 	}
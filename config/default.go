@@ -9,8 +9,19 @@ import (
 	"runtime"
 	"time"
 
+	amd_ecc "github.com/leptonai/gpud/components/accelerator/amd/ecc"
+	amd_info "github.com/leptonai/gpud/components/accelerator/amd/info"
+	amd_query "github.com/leptonai/gpud/components/accelerator/amd/query"
+	amd_temperature "github.com/leptonai/gpud/components/accelerator/amd/temperature"
+	amd_xgmi "github.com/leptonai/gpud/components/accelerator/amd/xgmi"
+	neuron_health "github.com/leptonai/gpud/components/accelerator/neuron/health"
+	neuron_query "github.com/leptonai/gpud/components/accelerator/neuron/query"
 	nvidia_clock "github.com/leptonai/gpud/components/accelerator/nvidia/clock"
 	nvidia_clockspeed "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed"
+	nvidia_compatibility "github.com/leptonai/gpud/components/accelerator/nvidia/compatibility"
+	nvidia_confidential_compute "github.com/leptonai/gpud/components/accelerator/nvidia/confidential-compute"
+	nvidia_consistency "github.com/leptonai/gpud/components/accelerator/nvidia/consistency"
+	nvidia_driver_maintenance "github.com/leptonai/gpud/components/accelerator/nvidia/driver-maintenance"
 	nvidia_ecc "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
 	nvidia_error "github.com/leptonai/gpud/components/accelerator/nvidia/error"
 	nvidia_component_error_xid_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error-xid-sxid/id"
@@ -18,13 +29,20 @@ import (
 	nvidia_component_error_xid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error/xid/id"
 	nvidia_fabric_manager "github.com/leptonai/gpud/components/accelerator/nvidia/fabric-manager"
 	nvidia_gpm "github.com/leptonai/gpud/components/accelerator/nvidia/gpm"
+	nvidia_gpu_count "github.com/leptonai/gpud/components/accelerator/nvidia/gpu-count"
+	nvidia_gpu_order "github.com/leptonai/gpud/components/accelerator/nvidia/gpu-order"
 	nvidia_gsp_firmware_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode/id"
 	nvidia_infiniband "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband"
 	nvidia_infiniband_id "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband/id"
 	nvidia_info "github.com/leptonai/gpud/components/accelerator/nvidia/info"
+	nvidia_inforom "github.com/leptonai/gpud/components/accelerator/nvidia/inforom"
+	nvidia_kernel_module "github.com/leptonai/gpud/components/accelerator/nvidia/kernel-module"
+	nvidia_license "github.com/leptonai/gpud/components/accelerator/nvidia/license"
 	nvidia_memory "github.com/leptonai/gpud/components/accelerator/nvidia/memory"
+	nvidia_mig "github.com/leptonai/gpud/components/accelerator/nvidia/mig"
 	nvidia_nccl_id "github.com/leptonai/gpud/components/accelerator/nvidia/nccl/id"
 	nvidia_nvlink "github.com/leptonai/gpud/components/accelerator/nvidia/nvlink"
+	nvidia_pcie "github.com/leptonai/gpud/components/accelerator/nvidia/pcie"
 	nvidia_peermem_id "github.com/leptonai/gpud/components/accelerator/nvidia/peermem/id"
 	nvidia_persistence_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode/id"
 	nvidia_power "github.com/leptonai/gpud/components/accelerator/nvidia/power"
@@ -33,6 +51,7 @@ import (
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	nvidia_remapped_rows "github.com/leptonai/gpud/components/accelerator/nvidia/remapped-rows"
 	nvidia_temperature "github.com/leptonai/gpud/components/accelerator/nvidia/temperature"
+	nvidia_topology "github.com/leptonai/gpud/components/accelerator/nvidia/topology"
 	nvidia_utilization "github.com/leptonai/gpud/components/accelerator/nvidia/utilization"
 	containerd_pod "github.com/leptonai/gpud/components/containerd/pod"
 	"github.com/leptonai/gpud/components/cpu"
@@ -47,13 +66,18 @@ import (
 	"github.com/leptonai/gpud/components/library"
 	"github.com/leptonai/gpud/components/memory"
 	network_latency "github.com/leptonai/gpud/components/network/latency"
+	network_roce "github.com/leptonai/gpud/components/network/roce"
 	"github.com/leptonai/gpud/components/os"
+	pci_acs "github.com/leptonai/gpud/components/pci/acs"
+	pci_iommu "github.com/leptonai/gpud/components/pci/iommu"
 	power_supply "github.com/leptonai/gpud/components/power-supply"
 	query_config "github.com/leptonai/gpud/components/query/config"
 	component_systemd "github.com/leptonai/gpud/components/systemd"
 	"github.com/leptonai/gpud/components/tailscale"
+	"github.com/leptonai/gpud/components/udev"
 	"github.com/leptonai/gpud/log"
 	pkg_file "github.com/leptonai/gpud/pkg/file"
+	"github.com/leptonai/gpud/pkg/pci"
 	pkd_systemd "github.com/leptonai/gpud/pkg/systemd"
 	"github.com/leptonai/gpud/systemd"
 	"github.com/leptonai/gpud/version"
@@ -68,9 +92,16 @@ const (
 )
 
 var (
-	DefaultRefreshPeriod             = metav1.Duration{Duration: time.Minute}
-	DefaultRetentionPeriod           = metav1.Duration{Duration: 30 * time.Minute}
-	DefaultRefreshComponentsInterval = metav1.Duration{Duration: time.Minute}
+	DefaultRefreshPeriod                = metav1.Duration{Duration: time.Minute}
+	DefaultRetentionPeriod              = metav1.Duration{Duration: 30 * time.Minute}
+	DefaultStatesHistoryRetentionPeriod = metav1.Duration{Duration: 14 * 24 * time.Hour}
+	DefaultLastKnownGoodPeriod          = metav1.Duration{Duration: 6 * time.Hour}
+	DefaultRefreshComponentsInterval    = metav1.Duration{Duration: time.Minute}
+
+	DefaultHighFrequencyMetricsPath     = "/dev/shm/gpud-metrics.ring"
+	DefaultHighFrequencyMetricsInterval = metav1.Duration{Duration: 100 * time.Millisecond}
+	// 8 GPUs for about 13 minutes at the 100ms interval (2 MiB)
+	DefaultHighFrequencyMetricsCapacity uint64 = 65536
 )
 
 var (
@@ -104,6 +135,9 @@ func DefaultConfig(ctx context.Context, opts ...OpOption) (*Config, error) {
 	if err := options.ApplyOpts(opts); err != nil {
 		return nil, err
 	}
+	if err := options.Profile.Validate(); err != nil {
+		return nil, err
+	}
 
 	cfg := &Config{
 		APIVersion: DefaultAPIVersion,
@@ -125,9 +159,12 @@ func DefaultConfig(ctx context.Context, opts ...OpOption) (*Config, error) {
 			kernel_module_id.Name: nil,
 		},
 
-		RetentionPeriod:           DefaultRetentionPeriod,
-		RefreshComponentsInterval: DefaultRefreshComponentsInterval,
-		Pprof:                     false,
+		RetentionPeriod:              DefaultRetentionPeriod,
+		StatesHistoryRetentionPeriod: DefaultStatesHistoryRetentionPeriod,
+		LastKnownGoodPeriod:          DefaultLastKnownGoodPeriod,
+		RefreshComponentsInterval:    DefaultRefreshComponentsInterval,
+		Pprof:                        false,
+		GPUExpected:                  options.GPUExpected,
 
 		Web: &Web{
 			Enable:        true,
@@ -170,6 +207,18 @@ func DefaultConfig(ctx context.Context, opts ...OpOption) (*Config, error) {
 
 	cfg.Components[network_latency.Name] = nil
 
+	if runtime.GOOS == "linux" {
+		if _, err := stdos.Stat(network_roce.DefaultInfinibandClassDir); err == nil {
+			log.Logger.Debugw("auto-detected rdma devices -- configuring roce component")
+			cfg.Components[network_roce.Name] = nil
+		}
+		if _, err := stdos.Stat(pci.DefaultDevicesDir); err == nil {
+			cfg.Components[pci_acs.Name] = nil
+			cfg.Components[pci_iommu.Name] = nil
+		}
+		cfg.Components[udev.Name] = nil
+	}
+
 	if runtime.GOOS == "linux" {
 		if pkd_systemd.SystemdExists() && pkd_systemd.SystemctlExists() {
 			if err := systemd.CreateDefaultEnvFile(); err != nil {
@@ -240,14 +289,33 @@ func DefaultConfig(ctx context.Context, opts ...OpOption) (*Config, error) {
 			log.Logger.Warnw("old nvidia driver -- skipping clock events in the default config, see https://github.com/NVIDIA/go-nvml/pull/123", "version", driverVersion)
 		}
 
+		cfg.Components[nvidia_compatibility.Name] = nvidia_compatibility.Config{
+			Query:             query_config.DefaultConfig(),
+			LibrarySearchDirs: DefaultNVIDIALibrariesSearchDirs,
+		}
+		cfg.Components[nvidia_confidential_compute.Name] = nil
+		cfg.Components[nvidia_consistency.Name] = nil
+		cfg.Components[nvidia_driver_maintenance.Name] = nil
 		cfg.Components[nvidia_ecc.Name] = nil
 		cfg.Components[nvidia_error.Name] = nil
+		cfg.Components[nvidia_gpu_count.Name] = nil
+		cfg.Components[nvidia_gpu_order.Name] = nil
 		if _, ok := cfg.Components[dmesg.Name]; ok {
 			cfg.Components[nvidia_component_error_xid_id.Name] = nil
 			cfg.Components[nvidia_component_error_sxid_id.Name] = nil
 			cfg.Components[nvidia_component_error_xid_sxid_id.Name] = nil
 		}
 		cfg.Components[nvidia_info.Name] = nil
+		cfg.Components[nvidia_inforom.Name] = nil
+
+		kernelModuleCfg := nvidia_kernel_module.Config{Query: query_config.DefaultConfig()}
+		kernelModuleCfg.SetDefaultsIfNotSet()
+		if _, err := stdos.Stat(network_roce.DefaultInfinibandClassDir); err == nil {
+			log.Logger.Debugw("auto-detected rdma devices -- expecting nvidia_peermem kernel module")
+			kernelModuleCfg.Modules = append(kernelModuleCfg.Modules, nvidia_kernel_module.ModulePeermem)
+		}
+		cfg.Components[nvidia_kernel_module.Name] = kernelModuleCfg
+		cfg.Components[nvidia_license.Name] = nil
 
 		cfg.Components[nvidia_clockspeed.Name] = nil
 		cfg.Components[nvidia_memory.Name] = nil
@@ -264,9 +332,24 @@ func DefaultConfig(ctx context.Context, opts ...OpOption) (*Config, error) {
 			log.Logger.Warnw("failed to check gpm supported or not", "error", err)
 		}
 
+		featureMatrix, err := nvidia_query_nvml.GetFeatureMatrix()
+		if err == nil {
+			for _, gpu := range featureMatrix.GPUs {
+				if gpu.MIGEnabled {
+					log.Logger.Infow("auto-detected mig enabled")
+					cfg.Components[nvidia_mig.Name] = nil
+					break
+				}
+			}
+		} else {
+			log.Logger.Warnw("failed to check mig enabled or not", "error", err)
+		}
+
 		cfg.Components[nvidia_nvlink.Name] = nil
+		cfg.Components[nvidia_pcie.Name] = nil
 		cfg.Components[nvidia_power.Name] = nil
 		cfg.Components[nvidia_temperature.Name] = nil
+		cfg.Components[nvidia_topology.Name] = nil
 		cfg.Components[nvidia_utilization.Name] = nil
 		cfg.Components[nvidia_processes.Name] = nil
 		cfg.Components[nvidia_remapped_rows.Name] = nil
@@ -289,10 +372,55 @@ func DefaultConfig(ctx context.Context, opts ...OpOption) (*Config, error) {
 		cfg.Components[nvidia_peermem_id.Name] = nil
 		cfg.Components[nvidia_persistence_mode_id.Name] = nil
 		cfg.Components[nvidia_gsp_firmware_mode_id.Name] = nil
+	} else if runtime.GOOS == "linux" && options.GPUExpected {
+		// e.g., the driver being reinstalled, report the driver missing rather than skipping the nvidia components
+		log.Logger.Warnw("gpu expected but nvidia not detected -- configuring the core nvidia components")
+		cfg.Components[nvidia_ecc.Name] = nil
+		cfg.Components[nvidia_gpu_count.Name] = nil
+		cfg.Components[nvidia_info.Name] = nil
+		cfg.Components[nvidia_memory.Name] = nil
+		cfg.Components[nvidia_power.Name] = nil
+		cfg.Components[nvidia_temperature.Name] = nil
+		cfg.Components[nvidia_utilization.Name] = nil
 	} else {
 		log.Logger.Debugw("auto-detect nvidia not supported -- skipping", "os", runtime.GOOS)
 	}
 
+	if runtime.GOOS == "linux" {
+		amdInstalled, err := amd_query.GPUsInstalled(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if amdInstalled {
+			log.Logger.Debugw("auto-detected amd -- configuring amd components")
+			cfg.Components[amd_info.Name] = nil
+			cfg.Components[amd_temperature.Name] = nil
+			cfg.Components[amd_ecc.Name] = nil
+			cfg.Components[amd_xgmi.Name] = nil
+		}
+	} else {
+		log.Logger.Debugw("auto-detect amd not supported -- skipping", "os", runtime.GOOS)
+	}
+
+	if runtime.GOOS == "linux" {
+		neuronInstalled, err := neuron_query.DevicesInstalled()
+		if err != nil {
+			return nil, err
+		}
+		if neuronInstalled {
+			log.Logger.Debugw("auto-detected aws neuron -- configuring neuron components")
+			cfg.Components[neuron_health.Name] = nil
+		}
+	} else {
+		log.Logger.Debugw("auto-detect aws neuron not supported -- skipping", "os", runtime.GOOS)
+	}
+
+	if options.Profile != "" {
+		log.Logger.Debugw("applying component profile", "profile", options.Profile)
+		cfg.Profile = options.Profile
+		options.Profile.Apply(cfg, runtime.GOOS == "linux" && nvidiaInstalled)
+	}
+
 	if cfg.State == "" {
 		var err error
 		cfg.State, err = DefaultStateFile()
@@ -8,6 +8,8 @@ type Op struct {
 	ExpectedPortStates            *infiniband.ExpectedPortStates
 	DockerIgnoreConnectionErrors  bool
 	KubeletIgnoreConnectionErrors bool
+	Profile                       Profile
+	GPUExpected                   bool
 }
 
 type OpOption func(*Op)
@@ -49,3 +51,18 @@ func WithKubeletIgnoreConnectionErrors(b bool) OpOption {
 		op.KubeletIgnoreConnectionErrors = b
 	}
 }
+
+// WithProfile sets the named group of the components to enable.
+func WithProfile(p Profile) OpOption {
+	return func(op *Op) {
+		op.Profile = p
+	}
+}
+
+// WithGPUExpected sets whether the node is expected to have the NVIDIA GPUs,
+// enabling the NVIDIA components even if the NVIDIA driver is not detected.
+func WithGPUExpected(b bool) OpOption {
+	return func(op *Op) {
+		op.GPUExpected = b
+	}
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+
+	nvidia_ecc "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
+	nvidia_error "github.com/leptonai/gpud/components/accelerator/nvidia/error"
+	nvidia_component_error_xid_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error-xid-sxid/id"
+	nvidia_component_error_sxid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error/sxid/id"
+	nvidia_component_error_xid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error/xid/id"
+	nvidia_infiniband_id "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband/id"
+	nvidia_info "github.com/leptonai/gpud/components/accelerator/nvidia/info"
+	nvidia_memory "github.com/leptonai/gpud/components/accelerator/nvidia/memory"
+	nvidia_nccl_id "github.com/leptonai/gpud/components/accelerator/nvidia/nccl/id"
+	nvidia_peermem_id "github.com/leptonai/gpud/components/accelerator/nvidia/peermem/id"
+	nvidia_persistence_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode/id"
+	nvidia_power "github.com/leptonai/gpud/components/accelerator/nvidia/power"
+	nvidia_remapped_rows "github.com/leptonai/gpud/components/accelerator/nvidia/remapped-rows"
+	nvidia_temperature "github.com/leptonai/gpud/components/accelerator/nvidia/temperature"
+	"github.com/leptonai/gpud/components/cpu"
+	"github.com/leptonai/gpud/components/disk"
+	"github.com/leptonai/gpud/components/dmesg"
+	"github.com/leptonai/gpud/components/fd"
+	file_id "github.com/leptonai/gpud/components/file/id"
+	"github.com/leptonai/gpud/components/info"
+	kernel_module_id "github.com/leptonai/gpud/components/kernel-module/id"
+	"github.com/leptonai/gpud/components/library"
+	"github.com/leptonai/gpud/components/memory"
+	network_roce_id "github.com/leptonai/gpud/components/network/roce/id"
+	"github.com/leptonai/gpud/components/os"
+	power_supply "github.com/leptonai/gpud/components/power-supply"
+	component_systemd "github.com/leptonai/gpud/components/systemd"
+)
+
+// Profile is a named group of the components to enable,
+// so that each node class does not need to list the components individually.
+type Profile string
+
+const (
+	// ProfileMinimal enables the host basics and the core GPU health components only
+	// (e.g., edge inference boxes).
+	ProfileMinimal Profile = "minimal"
+	// ProfileFull enables all the auto-detected components except the multi-node fabric ones
+	// (e.g., single-node training or inference servers).
+	ProfileFull Profile = "full"
+	// ProfileFabric enables all the auto-detected components, and requires the multi-node fabric
+	// components (InfiniBand, RoCE, NCCL, peermem) even if not auto-detected
+	// (e.g., HGX training nodes), so a missing fabric reports unhealthy rather than going unmonitored.
+	ProfileFabric Profile = "fabric"
+)
+
+// Profiles lists the supported profiles.
+var Profiles = []Profile{ProfileMinimal, ProfileFull, ProfileFabric}
+
+// minimalComponents is the set of the components enabled by the minimal profile
+// (if auto-detected).
+var minimalComponents = map[string]struct{}{
+	cpu.Name:              {},
+	disk.Name:             {},
+	fd.Name:               {},
+	info.Name:             {},
+	memory.Name:           {},
+	os.Name:               {},
+	kernel_module_id.Name: {},
+	file_id.Name:          {},
+	library.Name:          {},
+	dmesg.Name:            {},
+	power_supply.Name:     {},
+
+	component_systemd.Name: {},
+
+	nvidia_info.Name:                        {},
+	nvidia_ecc.Name:                         {},
+	nvidia_error.Name:                       {},
+	nvidia_component_error_xid_id.Name:      {},
+	nvidia_component_error_sxid_id.Name:     {},
+	nvidia_component_error_xid_sxid_id.Name: {},
+	nvidia_memory.Name:                      {},
+	nvidia_power.Name:                       {},
+	nvidia_temperature.Name:                 {},
+	nvidia_remapped_rows.Name:               {},
+	nvidia_persistence_mode_id.Name:         {},
+}
+
+// fabricComponents is the set of the multi-node fabric components,
+// disabled by the full profile and required by the fabric profile.
+var fabricComponents = map[string]struct{}{
+	nvidia_infiniband_id.Name: {},
+	nvidia_nccl_id.Name:       {},
+	nvidia_peermem_id.Name:    {},
+	network_roce_id.Name:      {},
+}
+
+// Validate returns an error if the profile is not supported.
+// The empty profile is valid, and enables all the auto-detected components.
+func (p Profile) Validate() error {
+	if p == "" {
+		return nil
+	}
+	for _, known := range Profiles {
+		if p == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown profile %q (expected one of %v)", p, Profiles)
+}
+
+// Includes returns true if the profile enables the component (if auto-detected).
+func (p Profile) Includes(componentName string) bool {
+	switch p {
+	case ProfileMinimal:
+		_, ok := minimalComponents[componentName]
+		return ok
+	case ProfileFull:
+		_, ok := fabricComponents[componentName]
+		return !ok
+	default:
+		return true
+	}
+}
+
+// Apply removes the components that the profile does not include from the config,
+// and adds the components that the profile requires.
+// The nvidia fabric components are only required if the nvidia GPUs are installed.
+func (p Profile) Apply(cfg *Config, nvidiaInstalled bool) {
+	for name := range cfg.Components {
+		if !p.Includes(name) {
+			delete(cfg.Components, name)
+		}
+	}
+
+	if p != ProfileFabric || runtime.GOOS != "linux" {
+		return
+	}
+	for _, name := range p.RequiredComponents(nvidiaInstalled) {
+		if _, ok := cfg.Components[name]; !ok {
+			cfg.Components[name] = nil
+		}
+	}
+}
+
+// RequiredComponents returns the names of the components that the profile requires,
+// regardless of the auto-detection.
+func (p Profile) RequiredComponents(nvidiaInstalled bool) []string {
+	if p != ProfileFabric {
+		return nil
+	}
+	names := []string{network_roce_id.Name}
+	if nvidiaInstalled {
+		for name := range fabricComponents {
+			if name != network_roce_id.Name {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+
+	nvidia_ecc "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
+	nvidia_infiniband_id "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband/id"
+	nvidia_nvlink "github.com/leptonai/gpud/components/accelerator/nvidia/nvlink"
+	"github.com/leptonai/gpud/components/cpu"
+	network_roce_id "github.com/leptonai/gpud/components/network/roce/id"
+)
+
+func TestProfileValidate(t *testing.T) {
+	tests := []struct {
+		profile Profile
+		wantErr bool
+	}{
+		{profile: "", wantErr: false},
+		{profile: ProfileMinimal, wantErr: false},
+		{profile: ProfileFull, wantErr: false},
+		{profile: ProfileFabric, wantErr: false},
+		{profile: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.profile), func(t *testing.T) {
+			if err := tt.profile.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfileApply(t *testing.T) {
+	newCfg := func() *Config {
+		return &Config{Components: map[string]any{
+			cpu.Name:                  nil,
+			nvidia_ecc.Name:           nil,
+			nvidia_nvlink.Name:        nil,
+			nvidia_infiniband_id.Name: nil,
+		}}
+	}
+
+	tests := []struct {
+		profile Profile
+		want    []string
+	}{
+		{
+			profile: "",
+			want:    []string{cpu.Name, nvidia_ecc.Name, nvidia_nvlink.Name, nvidia_infiniband_id.Name},
+		},
+		{
+			profile: ProfileMinimal,
+			want:    []string{cpu.Name, nvidia_ecc.Name},
+		},
+		{
+			profile: ProfileFull,
+			want:    []string{cpu.Name, nvidia_ecc.Name, nvidia_nvlink.Name},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.profile), func(t *testing.T) {
+			cfg := newCfg()
+			tt.profile.Apply(cfg, true)
+
+			want := make(map[string]any)
+			for _, name := range tt.want {
+				want[name] = nil
+			}
+			if !reflect.DeepEqual(cfg.Components, want) {
+				t.Errorf("Apply() components = %v, want %v", cfg.Components, want)
+			}
+		})
+	}
+}
+
+func TestProfileFabricRequiredComponents(t *testing.T) {
+	if got := ProfileFull.RequiredComponents(true); len(got) != 0 {
+		t.Errorf("full profile requires %v, want none", got)
+	}
+	if got := ProfileFabric.RequiredComponents(false); !reflect.DeepEqual(got, []string{network_roce_id.Name}) {
+		t.Errorf("fabric profile without nvidia requires %v", got)
+	}
+	if got := ProfileFabric.RequiredComponents(true); len(got) != len(fabricComponents) {
+		t.Errorf("fabric profile with nvidia requires %v, want %d components", got, len(fabricComponents))
+	}
+
+	cfg := &Config{Components: map[string]any{cpu.Name: nil}}
+	ProfileFabric.Apply(cfg, true)
+	if runtime.GOOS != "linux" {
+		return
+	}
+	for name := range fabricComponents {
+		if _, ok := cfg.Components[name]; !ok {
+			t.Errorf("fabric profile did not enable %q", name)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package explain implements the health check dry-run explain mode,
+// which runs a component once and explains how its health verdict is reached
+// (the states, and the rules evaluated against the observed values with the thresholds applied),
+// for the operators tuning the thresholds.
+package explain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/internal/npd"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+// DefaultTimeout is the default timeout to wait for the component first poll.
+const DefaultTimeout = time.Minute
+
+// Report is the explanation of the component health verdict.
+type Report struct {
+	Component string `json:"component"`
+	Healthy   bool   `json:"healthy"`
+	// Verdict explains why the component is healthy or unhealthy.
+	Verdict string `json:"verdict"`
+
+	States []components.State `json:"states"`
+	// Rules is the rules evaluated by the component.
+	// Empty if the component does not implement components.Explainer.
+	Rules []components.Rule `json:"rules,omitempty"`
+}
+
+// Explain creates the component with the default config, waits for its first poll (up to the timeout),
+// and returns the report of its health verdict.
+// Only the components supported in the node-problem-detector plugin mode are supported,
+// since the others rely on the events tracked by the daemon.
+func Explain(ctx context.Context, name string, timeout time.Duration) (*Report, error) {
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// same as the npd plugin mode, the in-memory database is only written by the pollers
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	c, err := npd.NewComponent(cctx, name, db)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := c.Close(); err != nil {
+			log.Logger.Warnw("failed to close component", "component", name, "error", err)
+		}
+	}()
+
+	states, err := npd.WaitStates(cctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []components.Rule
+	if explainer, ok := c.(components.Explainer); ok {
+		rules, err = explainer.Explain(cctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to explain %s: %w", name, err)
+		}
+	}
+	return NewReport(name, states, rules), nil
+}
+
+// NewReport returns the report with the verdict of the states and the rules.
+// The component is unhealthy if any state is unhealthy (the degraded states are healthy),
+// and the verdict lists the unhealthy states and the failed rules.
+func NewReport(name string, states []components.State, rules []components.Rule) *Report {
+	r := &Report{
+		Component: name,
+		Healthy:   true,
+		States:    states,
+		Rules:     rules,
+	}
+
+	var reasons []string
+	for _, s := range states {
+		if s.Healthy {
+			continue
+		}
+		r.Healthy = false
+		reasons = append(reasons, fmt.Sprintf("state %q is unhealthy (%s)", s.Name, firstLine(s.Reason)))
+	}
+	for _, rule := range rules {
+		if rule.Passed {
+			continue
+		}
+		reasons = append(reasons, fmt.Sprintf("rule %q failed for %s (observed %s, threshold %s)", rule.Name, subject(rule), rule.Observed, threshold(rule)))
+	}
+
+	switch {
+	case !r.Healthy:
+		r.Verdict = "unhealthy: " + strings.Join(reasons, "; ")
+	case len(reasons) > 0:
+		// e.g., the failed rules of the degraded states during the driver maintenance
+		r.Verdict = "healthy (the failed rules are not reported as unhealthy states): " + strings.Join(reasons, "; ")
+	case len(rules) > 0:
+		r.Verdict = fmt.Sprintf("healthy: all %d state(s) healthy, and all %d rule(s) passed", len(states), len(rules))
+	default:
+		r.Verdict = fmt.Sprintf("healthy: all %d state(s) healthy", len(states))
+	}
+	return r
+}
+
+// Print writes the human-readable report.
+func (r *Report) Print(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "component: %s\n\n", r.Component)
+
+	fmt.Fprintf(&b, "states (%d):\n", len(r.States))
+	for _, s := range r.States {
+		mark := "healthy"
+		if !s.Healthy {
+			mark = "unhealthy"
+		} else if s.Degraded {
+			mark = "degraded"
+		}
+		fmt.Fprintf(&b, "  - %s [%s]\n", s.Name, mark)
+		if s.Reason != "" {
+			fmt.Fprintf(&b, "    reason: %s\n", indent(s.Reason, "      "))
+		}
+		if s.Error != "" {
+			fmt.Fprintf(&b, "    error: %s\n", s.Error)
+		}
+	}
+
+	if len(r.Rules) > 0 {
+		fmt.Fprintf(&b, "\nrules (%d):\n", len(r.Rules))
+		for _, rule := range r.Rules {
+			result := "pass"
+			if !rule.Passed {
+				result = "FAIL"
+			}
+			fmt.Fprintf(&b, "  - [%s] %s (%s): observed %s, threshold %s", result, rule.Name, subject(rule), rule.Observed, threshold(rule))
+			if rule.ThresholdSource != "" {
+				fmt.Fprintf(&b, ", from %s", rule.ThresholdSource)
+			}
+			b.WriteString("\n")
+		}
+	} else {
+		b.WriteString("\nrules: not explained by the component (see the state reasons)\n")
+	}
+
+	fmt.Fprintf(&b, "\nverdict: %s\n", r.Verdict)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func subject(rule components.Rule) string {
+	if rule.Subject == "" {
+		return "component"
+	}
+	return rule.Subject
+}
+
+func threshold(rule components.Rule) string {
+	if rule.Threshold == "" {
+		return "none (disabled)"
+	}
+	return rule.Threshold
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " ..."
+	}
+	return s
+}
+
+func indent(s string, prefix string) string {
+	return strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n"+prefix)
+}
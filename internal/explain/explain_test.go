@@ -0,0 +1,78 @@
+package explain
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/leptonai/gpud/components"
+)
+
+func TestNewReport(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		states      []components.State
+		rules       []components.Rule
+		wantHealthy bool
+		wantVerdict string
+	}{
+		{
+			name:        "healthy without rules",
+			states:      []components.State{{Name: "cpu", Healthy: true}},
+			wantHealthy: true,
+			wantVerdict: "healthy: all 1 state(s) healthy",
+		},
+		{
+			name:        "healthy with rules",
+			states:      []components.State{{Name: "temperature", Healthy: true}},
+			rules:       []components.Rule{{Name: "max_temperature_celsius", Subject: "GPU-0", Observed: "60 C", Threshold: "85 C", Passed: true}},
+			wantHealthy: true,
+			wantVerdict: "healthy: all 1 state(s) healthy, and all 1 rule(s) passed",
+		},
+		{
+			name:   "unhealthy",
+			states: []components.State{{Name: "temperature", Reason: "1 GPU(s) at or above the 85 C threshold\nGPU-0"}},
+			rules: []components.Rule{
+				{Name: "max_temperature_celsius", Subject: "GPU-0", Observed: "90 C", Threshold: "85 C"},
+				{Name: "max_temperature_celsius", Subject: "GPU-1", Observed: "60 C", Threshold: "85 C", Passed: true},
+			},
+			wantVerdict: `unhealthy: state "temperature" is unhealthy (1 GPU(s) at or above the 85 C threshold ...); rule "max_temperature_celsius" failed for GPU-0 (observed 90 C, threshold 85 C)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReport("test", tt.states, tt.rules)
+			if r.Healthy != tt.wantHealthy {
+				t.Errorf("healthy = %v, want %v", r.Healthy, tt.wantHealthy)
+			}
+			if r.Verdict != tt.wantVerdict {
+				t.Errorf("verdict = %q, want %q", r.Verdict, tt.wantVerdict)
+			}
+		})
+	}
+}
+
+func TestReportPrint(t *testing.T) {
+	t.Parallel()
+
+	r := NewReport("accelerator-nvidia-temperature",
+		[]components.State{{Name: "temperature", Healthy: true, Reason: "ok"}},
+		[]components.Rule{{Name: "max_temperature_celsius", Subject: "GPU-0", Observed: "60 C", ThresholdSource: "no preset for the GPU product (disabled unless overridden)", Passed: true}},
+	)
+	var buf bytes.Buffer
+	if err := r.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"component: accelerator-nvidia-temperature",
+		"  - temperature [healthy]",
+		"  - [pass] max_temperature_celsius (GPU-0): observed 60 C, threshold none (disabled), from no preset for the GPU product",
+		"verdict: healthy",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in the output:\n%s", want, buf.String())
+		}
+	}
+}
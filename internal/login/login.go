@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/leptonai/gpud/config"
@@ -60,7 +62,7 @@ func Login(name string, token string, endpoint string, components string, uid st
 		if strings.Contains(errorResponse.Status, "invalid workspace token") {
 			return fmt.Errorf("invalid token provided, please use the workspace token under Setting/Tokens and execute\n    gpud login --token yourToken")
 		}
-		return fmt.Errorf("\nCurrently, we only support machines with a public IP address. Please ensure that your public IP and port combination (%s:%d) is reachable.\nerror: %v", ip, config.DefaultGPUdPort, errorResponse)
+		return fmt.Errorf("\nCurrently, we only support machines with a public IP address. Please ensure that your public IP and port combination (%s) is reachable.\nerror: %v", net.JoinHostPort(ip, strconv.Itoa(config.DefaultGPUdPort)), errorResponse)
 	}
 	return nil
 }
@@ -107,11 +109,16 @@ func Gossip(endpoint string, uid string, address string) error {
 	return nil
 }
 
+// PublicIP returns the public IPv4 address, or the public IPv6 address
+// if the machine has no IPv4 connectivity (e.g., IPv6-only clusters).
 func PublicIP() (string, error) {
-	cmd := exec.Command("curl", "-4", "ifconfig.me")
-	output, err := cmd.Output()
+	output, err := exec.Command("curl", "-4", "ifconfig.me").Output()
 	if err != nil {
-		return "", err
+		var err6 error
+		output, err6 = exec.Command("curl", "-6", "ifconfig.me").Output()
+		if err6 != nil {
+			return "", fmt.Errorf("failed to fetch public ipv4 (%v) and ipv6 (%v)", err, err6)
+		}
 	}
 	ip := strings.TrimSpace(string(output))
 	return ip, nil
@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	NotifierTypeWebhook = "webhook"
+	NotifierTypeSlack   = "slack"
+)
+
+const DefaultPollInterval = time.Minute
+
+// Config configures where to send the component events.
+type Config struct {
+	// Interval to poll the component events.
+	PollInterval metav1.Duration `json:"poll_interval"`
+
+	Notifiers []NotifierConfig `json:"notifiers"`
+
+	// Routes are evaluated in order, and an event is sent to the notifiers
+	// of the first matching route (or all matching routes if "continue" is set).
+	// The events that match no route are not sent.
+	Routes []Route `json:"routes"`
+}
+
+type NotifierConfig struct {
+	// Name is referenced by the routes (e.g., "fabric-team-pager").
+	Name string `json:"name"`
+	// Type is either "webhook" (raw JSON payload) or "slack" (incoming webhook).
+	Type string `json:"type"`
+	URL  string `json:"url"`
+
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	// Minimum interval between the notifications of the same component event (by name),
+	// to not flood the channel with the repeating events.
+	// The throttled events are held for the digest (if enabled), or dropped.
+	MinInterval metav1.Duration `json:"min_interval,omitempty"`
+	// Set to batch the non-critical events into a periodic summary,
+	// instead of sending each event immediately.
+	Digest *Digest `json:"digest,omitempty"`
+}
+
+type Route struct {
+	Match     Match    `json:"match"`
+	Notifiers []string `json:"notifiers"`
+
+	// Set true to keep evaluating the following routes when this route matches.
+	Continue bool `json:"continue,omitempty"`
+}
+
+// Match matches an event when all the non-empty fields match.
+// Each field matches if any of its values matches (glob patterns, see path.Match).
+type Match struct {
+	// Component names (e.g., "accelerator-nvidia-*").
+	Components []string `json:"components,omitempty"`
+	// Event types (e.g., "error", "warn").
+	Severities []string `json:"severities,omitempty"`
+	// GPU product names (e.g., "NVIDIA H100*").
+	GPUModels []string `json:"gpu_models,omitempty"`
+	// Node labels from the gpud annotations (e.g., {"rack": "r12*"}).
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.PollInterval.Duration == 0 {
+		cfg.PollInterval = metav1.Duration{Duration: DefaultPollInterval}
+	}
+	for i := range cfg.Notifiers {
+		if cfg.Notifiers[i].Digest != nil && cfg.Notifiers[i].Digest.Interval.Duration == 0 {
+			cfg.Notifiers[i].Digest.Interval = metav1.Duration{Duration: DefaultDigestInterval}
+		}
+	}
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.PollInterval.Duration != 0 && cfg.PollInterval.Duration < 10*time.Second {
+		return fmt.Errorf("poll_interval must be at least 10 seconds, got %v", cfg.PollInterval.Duration)
+	}
+
+	names := make(map[string]struct{}, len(cfg.Notifiers))
+	for _, n := range cfg.Notifiers {
+		if n.Name == "" {
+			return errors.New("notifier name is required")
+		}
+		if _, ok := names[n.Name]; ok {
+			return fmt.Errorf("duplicate notifier %q", n.Name)
+		}
+		names[n.Name] = struct{}{}
+
+		if n.Type != NotifierTypeWebhook && n.Type != NotifierTypeSlack {
+			return fmt.Errorf("notifier %q has unknown type %q", n.Name, n.Type)
+		}
+		if n.URL == "" {
+			return fmt.Errorf("notifier %q url is required", n.Name)
+		}
+		if n.QuietHours != nil {
+			if _, _, _, err := n.QuietHours.parse(); err != nil {
+				return fmt.Errorf("notifier %q has invalid quiet hours: %w", n.Name, err)
+			}
+		}
+		if n.MinInterval.Duration < 0 {
+			return fmt.Errorf("notifier %q min_interval must be non-negative", n.Name)
+		}
+		if n.Digest != nil && n.Digest.Interval.Duration != 0 && n.Digest.Interval.Duration < time.Minute {
+			return fmt.Errorf("notifier %q digest interval must be at least 1 minute, got %v", n.Name, n.Digest.Interval.Duration)
+		}
+	}
+
+	for i, r := range cfg.Routes {
+		if len(r.Notifiers) == 0 {
+			return fmt.Errorf("route %d has no notifier", i)
+		}
+		for _, n := range r.Notifiers {
+			if _, ok := names[n]; !ok {
+				return fmt.Errorf("route %d references unknown notifier %q", i, n)
+			}
+		}
+		for _, patterns := range [][]string{r.Match.Components, r.Match.Severities, r.Match.GPUModels} {
+			for _, p := range patterns {
+				if _, err := path.Match(p, ""); err != nil {
+					return fmt.Errorf("route %d has invalid pattern %q: %w", i, p, err)
+				}
+			}
+		}
+		for _, p := range r.Match.Labels {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("route %d has invalid label pattern %q: %w", i, p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// QuietHours is the daily time range when only the critical ("error") events are sent immediately.
+// The other events are held for the digest (if enabled), or dropped.
+type QuietHours struct {
+	// Start and end in "HH:MM" (e.g., "22:00" to "06:00" wraps around midnight).
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// IANA time zone name (e.g., "America/Los_Angeles"), defaults to UTC.
+	TimeZone string `json:"time_zone,omitempty"`
+}
+
+// Digest batches the non-critical events into a periodic summary.
+type Digest struct {
+	// Interval to send the summary, defaults to 1 hour.
+	Interval metav1.Duration `json:"interval"`
+}
+
+const DefaultDigestInterval = time.Hour
@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Notifier sends the notification to an external channel.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NewNotifier creates a notifier from the config.
+func NewNotifier(cfg NotifierConfig) (Notifier, error) {
+	switch cfg.Type {
+	case NotifierTypeWebhook:
+		return &webhookNotifier{name: cfg.Name, url: cfg.URL, payload: func(n Notification) any { return n }}, nil
+	case NotifierTypeSlack:
+		return &webhookNotifier{name: cfg.Name, url: cfg.URL, payload: slackPayload}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", cfg.Type)
+	}
+}
+
+var _ Notifier = (*webhookNotifier)(nil)
+
+type webhookNotifier struct {
+	name    string
+	url     string
+	payload func(Notification) any
+}
+
+func (w *webhookNotifier) Name() string { return w.name }
+
+func (w *webhookNotifier) Notify(ctx context.Context, n Notification) error {
+	b, err := json.Marshal(w.payload(n))
+	if err != nil {
+		return err
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(cctx, http.MethodPost, w.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notifier %q returned status %d", w.name, resp.StatusCode)
+	}
+	return nil
+}
+
+// ref. https://api.slack.com/messaging/webhooks
+func slackPayload(n Notification) any {
+	return map[string]string{"text": n.Summary()}
+}
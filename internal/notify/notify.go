@@ -0,0 +1,227 @@
+// Package notify routes the component events to the external notifiers (e.g., Slack, pager webhooks).
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query_driver_maintenance "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-maintenance"
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/lifecycle"
+	"github.com/leptonai/gpud/pkg/lkg"
+)
+
+// Node is the node information to match the routes against and to include in the notifications.
+type Node struct {
+	MachineID string            `json:"machine_id,omitempty"`
+	GPUModel  string            `json:"gpu_model,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+
+	// Lifecycle is the node lifecycle state when the notification was sent.
+	Lifecycle lifecycle.State `json:"lifecycle,omitempty"`
+}
+
+// Notification is a component event to send to the notifiers.
+type Notification struct {
+	Node      Node             `json:"node"`
+	Component string           `json:"component"`
+	Event     components.Event `json:"event"`
+
+	// LastKnownGoodDelta is the configuration changes since the last-known-good snapshot,
+	// only set for the critical ("error") events to speed up the root-causing.
+	LastKnownGoodDelta []lkg.Change `json:"last_known_good_delta,omitempty"`
+}
+
+// Summary returns the one-line human-readable summary of the notification.
+func (n Notification) Summary() string {
+	summary := fmt.Sprintf("[%s] %s %s/%s: %s", n.Event.Type, n.Node.MachineID, n.Component, n.Event.Name, n.Event.Message)
+	if len(n.LastKnownGoodDelta) > 0 {
+		changes := make([]string, 0, len(n.LastKnownGoodDelta))
+		for _, c := range n.LastKnownGoodDelta {
+			changes = append(changes, c.String())
+		}
+		summary += fmt.Sprintf(" (changed since last known good: %s)", strings.Join(changes, ", "))
+	}
+	return summary
+}
+
+// Dispatcher polls the component events and sends them to the notifiers based on the routes.
+type Dispatcher struct {
+	cfg       Config
+	node      Node
+	db        *sql.DB
+	notifiers map[string]Notifier
+	policies  map[string]*policy
+
+	// events already sent, to not send the same event twice
+	// when the polling windows overlap
+	sent map[string]time.Time
+
+	// optional, to include the last-known-good delta in the critical events
+	lastKnownGood *lkg.Tracker
+}
+
+// NewDispatcher creates a dispatcher.
+// If the db is not nil, the acknowledged events (see state.AckEvent) are not notified.
+func NewDispatcher(cfg Config, node Node, db *sql.DB) (*Dispatcher, error) {
+	cfg.SetDefaultsIfNotSet()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	notifiers := make(map[string]Notifier, len(cfg.Notifiers))
+	policies := make(map[string]*policy, len(cfg.Notifiers))
+	for _, nc := range cfg.Notifiers {
+		n, err := NewNotifier(nc)
+		if err != nil {
+			return nil, err
+		}
+		notifiers[nc.Name] = n
+		policies[nc.Name] = newPolicy(nc, now)
+	}
+	return &Dispatcher{
+		cfg:       cfg,
+		node:      node,
+		db:        db,
+		notifiers: notifiers,
+		policies:  policies,
+		sent:      make(map[string]time.Time),
+	}, nil
+}
+
+// SetLastKnownGood sets the last-known-good tracker,
+// to include the configuration changes since the snapshot in the critical event notifications.
+func (d *Dispatcher) SetLastKnownGood(t *lkg.Tracker) {
+	d.lastKnownGood = t
+}
+
+// Start polls the events of the components until the context is canceled.
+func (d *Dispatcher) Start(ctx context.Context, comps []components.Component) {
+	interval := d.cfg.PollInterval.Duration
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// resume from the last poll before the restart (if any),
+	// so the events during the restart are neither missed nor sent twice
+	since := d.loadState(ctx, time.Now().UTC())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now().UTC()
+
+		var acks []state.EventAck
+		if d.db != nil {
+			var err error
+			acks, err = state.ReadEventAcks(ctx, d.db, true)
+			if err != nil {
+				log.Logger.Warnw("failed to read event acks", "error", err)
+			}
+		}
+
+		for _, c := range comps {
+			// look back one more interval for the events recorded with a delay
+			evs, err := c.Events(ctx, since.Add(-interval))
+			if err != nil {
+				log.Logger.Debugw("failed to get events for notifications", "component", c.Name(), "error", err)
+				continue
+			}
+			for _, ev := range evs {
+				if acked(acks, c.Name(), ev.Name) {
+					log.Logger.Debugw("skipping acknowledged event", "component", c.Name(), "event", ev.Name)
+					continue
+				}
+				d.dispatch(ctx, Notification{Node: d.node, Component: c.Name(), Event: ev})
+			}
+		}
+		since = now
+
+		for name, p := range d.policies {
+			digest, ok := p.flushDigest(d.node, now)
+			if !ok {
+				continue
+			}
+			d.send(ctx, name, digest)
+		}
+
+		for k, t := range d.sent {
+			if now.Sub(t) > 3*interval {
+				delete(d.sent, k)
+			}
+		}
+
+		d.saveState(ctx, since)
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, n Notification) {
+	if n.Event.Type == components.EventTypeMetric {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s/%d/%s", n.Component, n.Event.Name, n.Event.Time.Unix(), n.Event.Message)
+	if _, ok := d.sent[key]; ok {
+		return
+	}
+	d.sent[key] = time.Now().UTC()
+
+	// failures are expected while provisioning
+	if lifecycle.AlertsSuppressed() {
+		log.Logger.Debugw("skipping notification while provisioning", "component", n.Component, "event", n.Event.Name)
+		return
+	}
+	// failures of the nvidia components are expected while the driver is upgraded
+	if m := nvidia_query_driver_maintenance.Current(); m.Affects(n.Component) {
+		log.Logger.Debugw("skipping notification during driver maintenance", "component", n.Component, "event", n.Event.Name, "reason", m.Reason)
+		return
+	}
+	n.Node.Lifecycle = lifecycle.Current().State
+
+	if n.Event.Type == components.EventTypeError && d.lastKnownGood != nil {
+		delta, err := d.lastKnownGood.Delta(ctx)
+		if err != nil {
+			log.Logger.Warnw("failed to get last-known-good delta", "error", err)
+		}
+		n.LastKnownGoodDelta = delta
+	}
+
+	now := time.Now().UTC()
+	for _, name := range Resolve(d.cfg.Routes, n) {
+		p := d.policies[name]
+		switch p.decide(n, now) {
+		case decisionSend:
+			d.send(ctx, name, n)
+		case decisionDigest:
+			p.hold(n)
+		case decisionDrop:
+			log.Logger.Debugw("dropped notification", "notifier", name, "component", n.Component, "event", n.Event.Name)
+		}
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, name string, n Notification) {
+	if err := d.notifiers[name].Notify(ctx, n); err != nil {
+		log.Logger.Warnw("failed to send notification", "notifier", name, "component", n.Component, "event", n.Event.Name, "error", err)
+		return
+	}
+	log.Logger.Debugw("sent notification", "notifier", name, "component", n.Component, "event", n.Event.Name)
+}
+
+func acked(acks []state.EventAck, component string, eventName string) bool {
+	for _, a := range acks {
+		if a.Matches(component, eventName) {
+			return true
+		}
+	}
+	return false
+}
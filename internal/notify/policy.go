@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// parse returns the start and end minutes of the day, and the time zone.
+func (q QuietHours) parse() (int, int, *time.Location, error) {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("invalid start: %w", err)
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("invalid end: %w", err)
+	}
+	loc := time.UTC
+	if q.TimeZone != "" {
+		loc, err = time.LoadLocation(q.TimeZone)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+	}
+	return start, end, loc, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains returns true if the time is within the quiet hours.
+func (q QuietHours) Contains(t time.Time) bool {
+	start, end, loc, err := q.parse()
+	if err != nil {
+		return false
+	}
+	t = t.In(loc)
+	m := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return start <= m && m < end
+	}
+	// wraps around midnight
+	return m >= start || m < end
+}
+
+type decision int
+
+const (
+	decisionSend decision = iota
+	decisionDigest
+	decisionDrop
+)
+
+// policy applies the quiet hours, throttles, and digest of a notifier.
+type policy struct {
+	cfg NotifierConfig
+
+	// last sent time by the component event
+	lastSent map[string]time.Time
+
+	pending    []Notification
+	lastDigest time.Time
+}
+
+func newPolicy(cfg NotifierConfig, now time.Time) *policy {
+	return &policy{
+		cfg:        cfg,
+		lastSent:   make(map[string]time.Time),
+		lastDigest: now,
+	}
+}
+
+// decide decides whether to send the notification now, hold it for the digest, or drop it.
+func (p *policy) decide(n Notification, now time.Time) decision {
+	critical := n.Event.Type == components.EventTypeError
+
+	held := decisionDrop
+	if p.cfg.Digest != nil {
+		held = decisionDigest
+	}
+
+	if !critical && p.cfg.QuietHours != nil && p.cfg.QuietHours.Contains(now) {
+		return held
+	}
+
+	key := n.Component + "/" + n.Event.Name
+	if last, ok := p.lastSent[key]; ok && p.cfg.MinInterval.Duration > 0 && now.Sub(last) < p.cfg.MinInterval.Duration {
+		return held
+	}
+
+	if !critical && p.cfg.Digest != nil {
+		return decisionDigest
+	}
+
+	p.lastSent[key] = now
+	return decisionSend
+}
+
+func (p *policy) hold(n Notification) {
+	p.pending = append(p.pending, n)
+}
+
+// flushDigest returns the digest notification if the digest is due and any event is pending.
+// Returns false if nothing to send.
+func (p *policy) flushDigest(node Node, now time.Time) (Notification, bool) {
+	if p.cfg.Digest == nil || now.Sub(p.lastDigest) < p.cfg.Digest.Interval.Duration {
+		return Notification{}, false
+	}
+	// no digest during the quiet hours, unless the summary would be a day old
+	if p.cfg.QuietHours != nil && p.cfg.QuietHours.Contains(now) && now.Sub(p.lastDigest) < 24*time.Hour {
+		return Notification{}, false
+	}
+	p.lastDigest = now
+	if len(p.pending) == 0 {
+		return Notification{}, false
+	}
+
+	lines := make([]string, 0, len(p.pending))
+	for _, n := range p.pending {
+		lines = append(lines, n.Summary())
+	}
+	digest := Notification{
+		Node:      node,
+		Component: "gpud",
+		Event: components.Event{
+			Time:    metav1.Time{Time: now},
+			Name:    "digest",
+			Type:    components.EventTypeInfo,
+			Message: fmt.Sprintf("%d event(s) since %s\n%s", len(p.pending), p.pending[0].Event.Time.UTC().Format(time.RFC3339), strings.Join(lines, "\n")),
+			ExtraInfo: map[string]string{
+				"count": fmt.Sprintf("%d", len(p.pending)),
+			},
+		},
+	}
+	p.pending = nil
+	return digest, true
+}
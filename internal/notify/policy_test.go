@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuietHoursContains(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		q    QuietHours
+		at   time.Time
+		want bool
+	}{
+		{"within same day", QuietHours{Start: "09:00", End: "17:00"}, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), true},
+		{"end is exclusive", QuietHours{Start: "09:00", End: "17:00"}, time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC), false},
+		{"wraps before midnight", QuietHours{Start: "22:00", End: "06:00"}, time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC), true},
+		{"wraps after midnight", QuietHours{Start: "22:00", End: "06:00"}, time.Date(2024, 1, 1, 5, 59, 0, 0, time.UTC), true},
+		{"outside wrap", QuietHours{Start: "22:00", End: "06:00"}, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), false},
+		{"time zone", QuietHours{Start: "22:00", End: "06:00", TimeZone: "Asia/Tokyo"}, time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC), true},
+		{"invalid", QuietHours{Start: "25:00", End: "06:00"}, time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Contains(tt.at); got != tt.want {
+				t.Errorf("Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyDecide(t *testing.T) {
+	t.Parallel()
+
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	night := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	warn := Notification{Component: "disk", Event: components.Event{Name: "usage", Type: components.EventTypeWarn}}
+	crit := Notification{Component: "accelerator-nvidia-error-xid", Event: components.Event{Name: "xid", Type: components.EventTypeError}}
+	quiet := &QuietHours{Start: "22:00", End: "06:00"}
+
+	tests := []struct {
+		name string
+		cfg  NotifierConfig
+		seq  []Notification
+		at   []time.Time
+		want []decision
+	}{
+		{
+			name: "no policy",
+			seq:  []Notification{warn, warn},
+			at:   []time.Time{noon, noon},
+			want: []decision{decisionSend, decisionSend},
+		},
+		{
+			name: "quiet hours drop non-critical",
+			cfg:  NotifierConfig{QuietHours: quiet},
+			seq:  []Notification{warn, crit},
+			at:   []time.Time{night, night},
+			want: []decision{decisionDrop, decisionSend},
+		},
+		{
+			name: "throttle the same event",
+			cfg:  NotifierConfig{MinInterval: metav1.Duration{Duration: 10 * time.Minute}},
+			seq:  []Notification{crit, crit, warn, crit},
+			at:   []time.Time{noon, noon.Add(time.Minute), noon.Add(time.Minute), noon.Add(11 * time.Minute)},
+			want: []decision{decisionSend, decisionDrop, decisionSend, decisionSend},
+		},
+		{
+			name: "digest non-critical and throttled",
+			cfg:  NotifierConfig{MinInterval: metav1.Duration{Duration: 10 * time.Minute}, Digest: &Digest{Interval: metav1.Duration{Duration: time.Hour}}},
+			seq:  []Notification{warn, crit, crit},
+			at:   []time.Time{noon, noon, noon.Add(time.Minute)},
+			want: []decision{decisionDigest, decisionSend, decisionDigest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPolicy(tt.cfg, noon)
+			for i, n := range tt.seq {
+				if got := p.decide(n, tt.at[i]); got != tt.want[i] {
+					t.Fatalf("decide() #%d = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyFlushDigest(t *testing.T) {
+	t.Parallel()
+
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := newPolicy(NotifierConfig{Digest: &Digest{Interval: metav1.Duration{Duration: time.Hour}}}, noon)
+
+	p.hold(Notification{Component: "disk", Event: components.Event{Time: metav1.NewTime(noon), Name: "usage", Type: components.EventTypeWarn}})
+	p.hold(Notification{Component: "memory", Event: components.Event{Time: metav1.NewTime(noon), Name: "oom", Type: components.EventTypeWarn}})
+
+	if _, ok := p.flushDigest(Node{}, noon.Add(30*time.Minute)); ok {
+		t.Fatal("expected no digest before the interval")
+	}
+	digest, ok := p.flushDigest(Node{}, noon.Add(time.Hour))
+	if !ok {
+		t.Fatal("expected digest")
+	}
+	if digest.Event.ExtraInfo["count"] != "2" {
+		t.Fatalf("unexpected digest %+v", digest)
+	}
+	if _, ok := p.flushDigest(Node{}, noon.Add(2*time.Hour)); ok {
+		t.Fatal("expected no digest without pending events")
+	}
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"path"
+	"strings"
+)
+
+// Resolve returns the names of the notifiers to send the notification to,
+// deduplicated and in the order of the routes.
+func Resolve(routes []Route, n Notification) []string {
+	var notifiers []string
+	seen := make(map[string]struct{})
+	for _, r := range routes {
+		if !r.Match.Matches(n) {
+			continue
+		}
+		for _, name := range r.Notifiers {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			notifiers = append(notifiers, name)
+		}
+		if !r.Continue {
+			break
+		}
+	}
+	return notifiers
+}
+
+// Matches returns true if the notification matches all the non-empty fields.
+func (m Match) Matches(n Notification) bool {
+	if len(m.Components) > 0 && !matchAny(m.Components, n.Component) {
+		return false
+	}
+	if len(m.Severities) > 0 && !matchAny(m.Severities, strings.ToLower(n.Event.Type)) {
+		return false
+	}
+	if len(m.GPUModels) > 0 && !matchAny(m.GPUModels, n.Node.GPUModel) {
+		return false
+	}
+	for k, pattern := range m.Labels {
+		v, ok := n.Node.Labels[k]
+		if !ok || !matchAny([]string{pattern}, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(p, s); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/leptonai/gpud/components"
+)
+
+func TestRoute(t *testing.T) {
+	t.Parallel()
+
+	routes := []Route{
+		{
+			Match:     Match{Components: []string{"accelerator-nvidia-fabric-manager", "accelerator-nvidia-nvlink"}, Severities: []string{"error"}},
+			Notifiers: []string{"fabric-pager"},
+			Continue:  true,
+		},
+		{
+			Match:     Match{Components: []string{"disk"}},
+			Notifiers: []string{"slack"},
+		},
+		{
+			Match:     Match{GPUModels: []string{"NVIDIA H100*"}, Labels: map[string]string{"rack": "r1*"}},
+			Notifiers: []string{"slack", "rack-ops"},
+		},
+	}
+
+	h100 := Node{GPUModel: "NVIDIA H100 80GB HBM3", Labels: map[string]string{"rack": "r12"}}
+	a100 := Node{GPUModel: "NVIDIA A100-SXM4-80GB", Labels: map[string]string{"rack": "r12"}}
+
+	tests := []struct {
+		name string
+		n    Notification
+		want []string
+	}{
+		{
+			name: "nvswitch error pages fabric team and continues",
+			n:    Notification{Node: h100, Component: "accelerator-nvidia-fabric-manager", Event: components.Event{Type: components.EventTypeError}},
+			want: []string{"fabric-pager", "slack", "rack-ops"},
+		},
+		{
+			name: "nvswitch warning skips the pager",
+			n:    Notification{Node: a100, Component: "accelerator-nvidia-fabric-manager", Event: components.Event{Type: components.EventTypeWarn}},
+			want: nil,
+		},
+		{
+			name: "disk warning only to slack",
+			n:    Notification{Node: h100, Component: "disk", Event: components.Event{Type: components.EventTypeWarn}},
+			want: []string{"slack"},
+		},
+		{
+			name: "label mismatch",
+			n:    Notification{Node: Node{GPUModel: "NVIDIA H100 80GB HBM3", Labels: map[string]string{"rack": "r2"}}, Component: "memory", Event: components.Event{Type: components.EventTypeWarn}},
+			want: nil,
+		},
+		{
+			name: "missing label",
+			n:    Notification{Node: Node{GPUModel: "NVIDIA H100 80GB HBM3"}, Component: "memory", Event: components.Event{Type: components.EventTypeWarn}},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resolve(routes, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			cfg: Config{
+				Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/x"}},
+				Routes:    []Route{{Match: Match{Components: []string{"disk"}}, Notifiers: []string{"slack"}}},
+			},
+		},
+		{
+			name: "unknown notifier in route",
+			cfg: Config{
+				Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/x"}},
+				Routes:    []Route{{Notifiers: []string{"pager"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unknown notifier type",
+			cfg:     Config{Notifiers: []NotifierConfig{{Name: "x", Type: "email", URL: "x"}}},
+			wantErr: true,
+		},
+		{
+			name: "invalid pattern",
+			cfg: Config{
+				Notifiers: []NotifierConfig{{Name: "slack", Type: NotifierTypeSlack, URL: "https://hooks.slack.com/x"}},
+				Routes:    []Route{{Match: Match{Components: []string{"["}}, Notifiers: []string{"slack"}}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/warmstate"
+)
+
+// warmStateKey is the warm state key of the dispatcher.
+const warmStateKey = "notify/dispatcher"
+
+// dispatcherState is the dispatcher state persisted across the restarts,
+// so that an upgrade neither re-sends the already sent events
+// nor resets the throttle windows and the pending digests.
+type dispatcherState struct {
+	Since    time.Time              `json:"since"`
+	Sent     map[string]time.Time   `json:"sent,omitempty"`
+	Policies map[string]policyState `json:"policies,omitempty"`
+}
+
+type policyState struct {
+	LastSent   map[string]time.Time `json:"last_sent,omitempty"`
+	Pending    []Notification       `json:"pending,omitempty"`
+	LastDigest time.Time            `json:"last_digest"`
+}
+
+func (d *Dispatcher) snapshot(since time.Time) dispatcherState {
+	s := dispatcherState{
+		Since:    since,
+		Sent:     d.sent,
+		Policies: make(map[string]policyState, len(d.policies)),
+	}
+	for name, p := range d.policies {
+		s.Policies[name] = policyState{
+			LastSent:   p.lastSent,
+			Pending:    p.pending,
+			LastDigest: p.lastDigest,
+		}
+	}
+	return s
+}
+
+// restore restores the dispatcher state, and returns the restored polling start time.
+// The policies of the notifiers removed from the config are discarded.
+func (d *Dispatcher) restore(s dispatcherState) time.Time {
+	if s.Sent != nil {
+		d.sent = s.Sent
+	}
+	for name, ps := range s.Policies {
+		p, ok := d.policies[name]
+		if !ok {
+			continue
+		}
+		if ps.LastSent != nil {
+			p.lastSent = ps.LastSent
+		}
+		p.pending = ps.Pending
+		if !ps.LastDigest.IsZero() {
+			p.lastDigest = ps.LastDigest
+		}
+	}
+	return s.Since
+}
+
+// loadState restores the dispatcher state saved before the restart (if any),
+// and returns the polling start time (now if nothing to restore).
+func (d *Dispatcher) loadState(ctx context.Context, now time.Time) time.Time {
+	if d.db == nil {
+		return now
+	}
+
+	var s dispatcherState
+	ok, err := warmstate.Load(ctx, d.db, warmStateKey, warmstate.DefaultMaxAge, &s)
+	if err != nil {
+		log.Logger.Warnw("failed to load notification dispatcher state", "error", err)
+		return now
+	}
+	if !ok || s.Since.IsZero() {
+		return now
+	}
+	log.Logger.Infow("restored notification dispatcher state", "since", s.Since, "sent", len(s.Sent))
+	return d.restore(s)
+}
+
+func (d *Dispatcher) saveState(ctx context.Context, since time.Time) {
+	if d.db == nil {
+		return
+	}
+	if err := warmstate.Save(ctx, d.db, warmStateKey, d.snapshot(since), time.Now()); err != nil {
+		log.Logger.Warnw("failed to save notification dispatcher state", "error", err)
+	}
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/sqlite"
+	"github.com/leptonai/gpud/pkg/warmstate"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDispatcherWarmState(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := warmstate.CreateTable(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	cfg := Config{
+		Notifiers: []NotifierConfig{{
+			Name:        "slack",
+			Type:        NotifierTypeSlack,
+			URL:         "https://hooks.slack.com/x",
+			MinInterval: metav1.Duration{Duration: time.Hour},
+			Digest:      &Digest{Interval: metav1.Duration{Duration: time.Hour}},
+		}},
+		Routes: []Route{{Notifiers: []string{"slack"}}},
+	}
+	d, err := NewDispatcher(cfg, Node{MachineID: "a"}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	since := now.Add(-time.Minute)
+	if got := d.loadState(ctx, now); !got.Equal(now) {
+		t.Fatalf("expected no state to restore, got since %v", got)
+	}
+
+	d.sent["disk/full/1/msg"] = now
+	d.policies["slack"].lastSent["disk/full"] = now
+	d.policies["slack"].hold(Notification{Component: "disk", Event: components.Event{Name: "full", Message: "msg"}})
+	d.saveState(ctx, since)
+
+	restarted, err := NewDispatcher(cfg, Node{MachineID: "a"}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restarted.loadState(ctx, now.Add(time.Minute)); !got.Equal(since) {
+		t.Fatalf("restored since %v, want %v", got, since)
+	}
+	if _, ok := restarted.sent["disk/full/1/msg"]; !ok {
+		t.Fatalf("sent events not restored: %v", restarted.sent)
+	}
+	p := restarted.policies["slack"]
+	if last, ok := p.lastSent["disk/full"]; !ok || !last.Equal(now) {
+		t.Fatalf("throttle window not restored: %v", p.lastSent)
+	}
+	if len(p.pending) != 1 || p.pending[0].Event.Name != "full" {
+		t.Fatalf("pending digest not restored: %v", p.pending)
+	}
+
+	// the throttle window still applies after the restart
+	if dec := p.decide(Notification{Component: "disk", Event: components.Event{Name: "full", Type: components.EventTypeError}}, now.Add(time.Minute)); dec != decisionDigest {
+		t.Fatalf("expected the repeating event to be throttled after restart, got %v", dec)
+	}
+}
@@ -0,0 +1,104 @@
+package npd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_clock "github.com/leptonai/gpud/components/accelerator/nvidia/clock"
+	nvidia_clockspeed "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed"
+	nvidia_ecc "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
+	nvidia_gsp_firmware_mode "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode"
+	nvidia_gsp_firmware_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode/id"
+	nvidia_memory "github.com/leptonai/gpud/components/accelerator/nvidia/memory"
+	nvidia_nvlink "github.com/leptonai/gpud/components/accelerator/nvidia/nvlink"
+	nvidia_persistence_mode "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode"
+	nvidia_persistence_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode/id"
+	nvidia_power "github.com/leptonai/gpud/components/accelerator/nvidia/power"
+	nvidia_remapped_rows "github.com/leptonai/gpud/components/accelerator/nvidia/remapped-rows"
+	nvidia_temperature "github.com/leptonai/gpud/components/accelerator/nvidia/temperature"
+	nvidia_utilization "github.com/leptonai/gpud/components/accelerator/nvidia/utilization"
+	"github.com/leptonai/gpud/components/cpu"
+	"github.com/leptonai/gpud/components/disk"
+	"github.com/leptonai/gpud/components/fd"
+	"github.com/leptonai/gpud/components/memory"
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+// newFuncs creates the components with the default configs.
+// Only the components whose states are fully derived from the polled data are supported,
+// since the events tracked by the daemon (e.g., Xid from the dmesg) are not available in a single invocation
+// (use the NPD kernel monitor instead).
+var newFuncs = map[string]func(ctx context.Context, q query_config.Config) components.Component{
+	cpu.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return cpu.New(ctx, cpu.Config{Query: q})
+	},
+	disk.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		cfg := disk.DefaultConfig()
+		cfg.Query = q
+		return disk.New(ctx, cfg)
+	},
+	fd.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return fd.New(ctx, fd.Config{Query: q})
+	},
+	memory.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return memory.New(ctx, memory.Config{Query: q})
+	},
+
+	nvidia_clock.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_clock.New(ctx, nvidia_clock.Config{Query: q})
+	},
+	nvidia_clockspeed.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_clockspeed.New(ctx, nvidia_clockspeed.Config{Query: q})
+	},
+	nvidia_ecc.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_ecc.New(ctx, nvidia_ecc.Config{Query: q})
+	},
+	nvidia_gsp_firmware_mode_id.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_gsp_firmware_mode.New(ctx, nvidia_gsp_firmware_mode.Config{Query: q})
+	},
+	nvidia_memory.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_memory.New(ctx, nvidia_memory.Config{Query: q})
+	},
+	nvidia_nvlink.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_nvlink.New(ctx, nvidia_nvlink.Config{Query: q})
+	},
+	nvidia_persistence_mode_id.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_persistence_mode.New(ctx, nvidia_persistence_mode.Config{Query: q})
+	},
+	nvidia_power.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_power.New(ctx, nvidia_power.Config{Query: q})
+	},
+	nvidia_remapped_rows.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_remapped_rows.New(ctx, nvidia_remapped_rows.Config{Query: q})
+	},
+	nvidia_temperature.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_temperature.New(ctx, nvidia_temperature.Config{Query: q})
+	},
+	nvidia_utilization.Name: func(ctx context.Context, q query_config.Config) components.Component {
+		return nvidia_utilization.New(ctx, nvidia_utilization.Config{Query: q})
+	},
+}
+
+// SupportedComponents returns the sorted names of the components supported in the plugin mode.
+func SupportedComponents() []string {
+	names := make([]string, 0, len(newFuncs))
+	for name := range newFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewComponent creates the component with the default config, writing to the given database.
+// Returns an error if the component is not supported in the plugin mode (see SupportedComponents).
+func NewComponent(ctx context.Context, name string, db *sql.DB) (components.Component, error) {
+	f, ok := newFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("component %q not supported in the npd plugin mode", name)
+	}
+
+	return f(ctx, query_config.Config{State: &query_config.State{DB: db}}), nil
+}
@@ -0,0 +1,141 @@
+// Package npd implements the node-problem-detector (NPD) custom plugin mode,
+// which checks a single component per invocation and reports the result
+// with the NPD custom plugin protocol (exit code, and the message in the stdout),
+// so that the clusters already standardized on NPD can adopt the gpud checks
+// without running the gpud daemon.
+//
+// ref. https://github.com/kubernetes/node-problem-detector/blob/master/docs/custom_plugin_monitor.md
+package npd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Exit codes of the NPD custom plugin protocol.
+const (
+	ExitCodeOK      = 0
+	ExitCodeNonOK   = 1
+	ExitCodeUnknown = 2
+)
+
+const (
+	// DefaultMaxOutputLength is the default "max_output_length" of the NPD custom plugin monitor,
+	// the longer messages are truncated by NPD.
+	DefaultMaxOutputLength = 80
+
+	// DefaultTimeout is the default timeout to wait for the component states,
+	// which should be shorter than the "timeout" of the NPD custom plugin monitor.
+	DefaultTimeout = 30 * time.Second
+)
+
+// interval to re-read the states until the first poll is done
+const waitInterval = 500 * time.Millisecond
+
+// Check creates the component, waits for its first poll (up to the timeout),
+// and returns the exit code and the message for the NPD custom plugin protocol.
+func Check(ctx context.Context, name string, timeout time.Duration, maxOutputLength int) (int, string) {
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// same as the one-off scan, the in-memory database is only written by the pollers
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		return Result(name, nil, fmt.Errorf("failed to open database: %w", err), maxOutputLength)
+	}
+	defer db.Close()
+
+	c, err := NewComponent(cctx, name, db)
+	if err != nil {
+		return Result(name, nil, err, maxOutputLength)
+	}
+	defer func() {
+		if err := c.Close(); err != nil {
+			log.Logger.Warnw("failed to close component", "component", name, "error", err)
+		}
+	}()
+
+	states, err := WaitStates(cctx, c)
+	return Result(name, states, err, maxOutputLength)
+}
+
+// WaitStates reads the component states until the poller collects the first data.
+func WaitStates(ctx context.Context, c components.Component) ([]components.State, error) {
+	for {
+		states, err := c.States(ctx)
+		if err != nil || !noData(states) {
+			return states, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w (%v)", query.ErrNoData, ctx.Err())
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+func noData(states []components.State) bool {
+	for _, s := range states {
+		if s.Reason == query.ErrNoData.Error() {
+			return true
+		}
+	}
+	return false
+}
+
+// Result returns the exit code and the message of the component states,
+// "OK" if the component is healthy (including the degraded states), "NonOK" if unhealthy,
+// or "Unknown" if the states are not available.
+// The message of the unhealthy component only includes the unhealthy states,
+// and is truncated to the max output length (if positive).
+func Result(name string, states []components.State, err error, maxOutputLength int) (int, string) {
+	if err != nil {
+		return ExitCodeUnknown, truncate(fmt.Sprintf("failed to check %s: %v", name, err), maxOutputLength)
+	}
+
+	cond := state.ComponentCondition(name, states, time.Time{})
+	switch cond.Status {
+	case metav1.ConditionTrue:
+		msg := cond.Message
+		if msg == "" {
+			msg = cond.Reason
+		}
+		return ExitCodeOK, truncate(msg, maxOutputLength)
+
+	case metav1.ConditionFalse:
+		unhealthy := make([]components.State, 0, len(states))
+		for _, s := range states {
+			if !s.Healthy {
+				unhealthy = append(unhealthy, s)
+			}
+		}
+		msg := state.ComponentCondition(name, unhealthy, time.Time{}).Message
+		if msg == "" {
+			msg = cond.Reason
+		}
+		return ExitCodeNonOK, truncate(msg, maxOutputLength)
+
+	default:
+		return ExitCodeUnknown, truncate(cond.Message, maxOutputLength)
+	}
+}
+
+func truncate(msg string, maxLen int) string {
+	if maxLen <= 0 || len(msg) <= maxLen {
+		return msg
+	}
+	if maxLen <= 3 {
+		return msg[:maxLen]
+	}
+	return msg[:maxLen-3] + "..."
+}
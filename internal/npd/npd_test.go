@@ -0,0 +1,88 @@
+package npd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/query"
+)
+
+func TestResult(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		states   []components.State
+		err      error
+		maxLen   int
+		wantCode int
+		wantMsg  string
+	}{
+		{
+			name:     "healthy",
+			states:   []components.State{{Name: "accelerator-nvidia-ecc", Healthy: true, Reason: "no ecc error found"}},
+			maxLen:   DefaultMaxOutputLength,
+			wantCode: ExitCodeOK,
+			wantMsg:  "no ecc error found",
+		},
+		{
+			name:     "degraded",
+			states:   []components.State{{Name: "accelerator-nvidia-ecc", Healthy: true, Degraded: true}},
+			maxLen:   DefaultMaxOutputLength,
+			wantCode: ExitCodeOK,
+			wantMsg:  "Degraded",
+		},
+		{
+			name: "unhealthy only reports the unhealthy states",
+			states: []components.State{
+				{Name: "accelerator-nvidia-ecc", Healthy: true, Reason: "no ecc error found"},
+				{Name: "ecc_volatile", Healthy: false, Error: "uncorrected errors found"},
+			},
+			maxLen:   DefaultMaxOutputLength,
+			wantCode: ExitCodeNonOK,
+			wantMsg:  "ecc_volatile: uncorrected errors found",
+		},
+		{
+			name:     "truncated",
+			states:   []components.State{{Name: "accelerator-nvidia-ecc", Healthy: false, Reason: "uncorrected errors found"}},
+			maxLen:   12,
+			wantCode: ExitCodeNonOK,
+			wantMsg:  "uncorrect...",
+		},
+		{
+			name:     "no state",
+			maxLen:   DefaultMaxOutputLength,
+			wantCode: ExitCodeUnknown,
+			wantMsg:  "no state available",
+		},
+		{
+			name:     "error",
+			err:      query.ErrNoData,
+			maxLen:   0,
+			wantCode: ExitCodeUnknown,
+			wantMsg:  "failed to check accelerator-nvidia-ecc: no data collected yet in the poller",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, msg := Result("accelerator-nvidia-ecc", tt.states, tt.err, tt.maxLen)
+			if code != tt.wantCode || msg != tt.wantMsg {
+				t.Errorf("Result() = %d/%q, want %d/%q", code, msg, tt.wantCode, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestNewComponentUnsupported(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"accelerator-nvidia-error-xid", "unknown"} {
+		if _, err := NewComponent(context.Background(), name, nil); err == nil {
+			t.Errorf("expected error for the unsupported component %q", name)
+		}
+	}
+	if names := SupportedComponents(); len(names) == 0 || names[0] != "accelerator-nvidia-clock" {
+		t.Errorf("unexpected supported components: %v", names)
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"runtime"
+
+	nvidia_clock "github.com/leptonai/gpud/components/accelerator/nvidia/clock"
+	nvidia_clockspeed "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed"
+	nvidia_compatibility "github.com/leptonai/gpud/components/accelerator/nvidia/compatibility"
+	nvidia_confidential_compute "github.com/leptonai/gpud/components/accelerator/nvidia/confidential-compute"
+	nvidia_consistency "github.com/leptonai/gpud/components/accelerator/nvidia/consistency"
+	nvidia_cuda_canary "github.com/leptonai/gpud/components/accelerator/nvidia/cuda-canary"
+	nvidia_dcgm_diag "github.com/leptonai/gpud/components/accelerator/nvidia/dcgm-diag"
+	nvidia_ecc "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
+	nvidia_error "github.com/leptonai/gpud/components/accelerator/nvidia/error"
+	nvidia_gpm "github.com/leptonai/gpud/components/accelerator/nvidia/gpm"
+	nvidia_gpu_count "github.com/leptonai/gpud/components/accelerator/nvidia/gpu-count"
+	nvidia_gpu_order "github.com/leptonai/gpud/components/accelerator/nvidia/gpu-order"
+	nvidia_gsp_firmware_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode/id"
+	nvidia_info "github.com/leptonai/gpud/components/accelerator/nvidia/info"
+	nvidia_inforom "github.com/leptonai/gpud/components/accelerator/nvidia/inforom"
+	nvidia_kernel_module "github.com/leptonai/gpud/components/accelerator/nvidia/kernel-module"
+	nvidia_license "github.com/leptonai/gpud/components/accelerator/nvidia/license"
+	nvidia_memory "github.com/leptonai/gpud/components/accelerator/nvidia/memory"
+	nvidia_mig "github.com/leptonai/gpud/components/accelerator/nvidia/mig"
+	nvidia_nvlink "github.com/leptonai/gpud/components/accelerator/nvidia/nvlink"
+	nvidia_pcie "github.com/leptonai/gpud/components/accelerator/nvidia/pcie"
+	nvidia_persistence_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode/id"
+	nvidia_power "github.com/leptonai/gpud/components/accelerator/nvidia/power"
+	nvidia_processes "github.com/leptonai/gpud/components/accelerator/nvidia/processes"
+	nvidia_remapped_rows "github.com/leptonai/gpud/components/accelerator/nvidia/remapped-rows"
+	nvidia_temperature "github.com/leptonai/gpud/components/accelerator/nvidia/temperature"
+	nvidia_topology "github.com/leptonai/gpud/components/accelerator/nvidia/topology"
+	nvidia_utilization "github.com/leptonai/gpud/components/accelerator/nvidia/utilization"
+	"github.com/leptonai/gpud/components/capability"
+	"github.com/leptonai/gpud/components/dmesg"
+	kernel_module_id "github.com/leptonai/gpud/components/kernel-module/id"
+	network_roce "github.com/leptonai/gpud/components/network/roce"
+	pci_acs "github.com/leptonai/gpud/components/pci/acs"
+	pci_iommu "github.com/leptonai/gpud/components/pci/iommu"
+	power_supply "github.com/leptonai/gpud/components/power-supply"
+	scheduled_jobs "github.com/leptonai/gpud/components/scheduled-jobs"
+	component_systemd "github.com/leptonai/gpud/components/systemd"
+	"github.com/leptonai/gpud/components/tailscale"
+	lepconfig "github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/pkg/pci"
+)
+
+// nvmlLibrary is the NVML library loaded by the nvml-based components.
+const nvmlLibrary = "libnvidia-ml.so.1"
+
+// nvmlComponents are the components that query the GPUs via the NVML library.
+var nvmlComponents = []string{
+	nvidia_clock.Name,
+	nvidia_clockspeed.Name,
+	nvidia_compatibility.Name,
+	nvidia_confidential_compute.Name,
+	nvidia_consistency.Name,
+	nvidia_cuda_canary.Name,
+	nvidia_dcgm_diag.Name,
+	nvidia_ecc.Name,
+	nvidia_error.Name,
+	nvidia_gpm.Name,
+	nvidia_gpu_count.Name,
+	nvidia_gpu_order.Name,
+	nvidia_gsp_firmware_mode_id.Name,
+	nvidia_info.Name,
+	nvidia_inforom.Name,
+	nvidia_license.Name,
+	nvidia_memory.Name,
+	nvidia_mig.Name,
+	nvidia_nvlink.Name,
+	nvidia_pcie.Name,
+	nvidia_persistence_mode_id.Name,
+	nvidia_power.Name,
+	nvidia_processes.Name,
+	nvidia_remapped_rows.Name,
+	nvidia_temperature.Name,
+	nvidia_utilization.Name,
+}
+
+// componentRequirements defines the host capabilities that the components require to possibly work.
+// The components with the optional dependencies (e.g., ibstat for infiniband)
+// are not listed, since they report the missing dependencies in their own states.
+func componentRequirements() map[string]capability.Requirement {
+	reqs := make(map[string]capability.Requirement)
+	for name, deps := range componentDependencies {
+		reqs[name] = capability.Requirement{Components: deps}
+	}
+	if runtime.GOOS != "linux" {
+		return reqs
+	}
+
+	// reading the kernel ring buffer requires the root privileges
+	// when "kernel.dmesg_restrict" is set
+	reqs[dmesg.Name] = capability.Requirement{Root: true, Binaries: []string{"dmesg"}}
+	reqs[kernel_module_id.Name] = capability.Requirement{Files: []string{"/proc/modules"}}
+	reqs[nvidia_kernel_module.Name] = capability.Requirement{Files: []string{nvidia_kernel_module.DefaultProcModulesPath, nvidia_kernel_module.DefaultTaintedPath}}
+	reqs[network_roce.Name] = capability.Requirement{Files: []string{network_roce.DefaultInfinibandClassDir}}
+	// reading the PCIe extended configuration space requires the root privileges
+	reqs[pci_acs.Name] = capability.Requirement{Root: true, Files: []string{pci.DefaultDevicesDir}}
+	reqs[pci_iommu.Name] = capability.Requirement{Files: []string{pci.DefaultDevicesDir}}
+	reqs[power_supply.Name] = capability.Requirement{Files: []string{power_supply.DefaultBatteryCapacityFile}}
+	reqs[component_systemd.Name] = capability.Requirement{Binaries: []string{"systemctl"}}
+	reqs[tailscale.Name] = capability.Requirement{Binaries: []string{"tailscale"}}
+	reqs[scheduled_jobs.Name] = capability.Requirement{Binaries: []string{"bash"}}
+	reqs[nvidia_dcgm_diag.Name] = capability.Requirement{Binaries: []string{"dcgmi"}}
+	reqs[nvidia_topology.Name] = capability.Requirement{Binaries: []string{"nvidia-smi"}}
+	for _, name := range nvmlComponents {
+		req := reqs[name]
+		req.Libraries = append(req.Libraries, nvmlLibrary)
+		reqs[name] = req
+	}
+	return reqs
+}
+
+// checkCapabilities probes the host capabilities required by the enabled components.
+func checkCapabilities(config *lepconfig.Config) capability.Report {
+	enabled := make([]string, 0, len(config.Components))
+	for name := range config.Components {
+		enabled = append(enabled, name)
+	}
+	return capability.Check(enabled, componentRequirements(), capability.WithLibrarySearchDirs(lepconfig.DefaultNVIDIALibrariesSearchDirs...))
+}
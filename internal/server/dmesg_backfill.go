@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/leptonai/gpud/components/dmesg"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/warmstate"
+
+	"github.com/shirou/gopsutil/v4/host"
+)
+
+const (
+	dmesgBackfillWarmStateKey = "dmesg/backfill"
+
+	// interval to record that gpud is running, to detect the downtime on the next start
+	dmesgBackfillHeartbeatInterval = 10 * time.Minute
+)
+
+type dmesgBackfillState struct {
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// backfillDmesg backfills the dmesg events with their original timestamps
+// on the first start or after a long downtime, so the errors before the start are not missed,
+// and then records the heartbeat until the context is canceled.
+func backfillDmesg(ctx context.Context, db *sql.DB, c *dmesg.Component, maxLookback time.Duration) {
+	var st dmesgBackfillState
+	found, err := warmstate.Load(ctx, db, dmesgBackfillWarmStateKey, 0, &st)
+	if err != nil {
+		log.Logger.Warnw("failed to load dmesg backfill state", "error", err)
+	}
+
+	if since, ok := dmesg.BackfillSince(st.LastSeen, found, time.Now().UTC(), maxLookback); ok {
+		var bootTime time.Time
+		if secs, err := host.BootTimeWithContext(ctx); err != nil {
+			log.Logger.Warnw("failed to get boot time", "error", err)
+		} else {
+			bootTime = time.Unix(int64(secs), 0).UTC()
+		}
+
+		processed, err := c.Backfill(ctx, since, bootTime)
+		if err != nil {
+			// retry on the next start, without recording the heartbeat
+			log.Logger.Warnw("failed to backfill dmesg", "since", since, "error", err)
+			return
+		}
+		log.Logger.Infow("backfilled dmesg", "since", since, "firstStart", !found, "processed", processed)
+	}
+
+	ticker := time.NewTicker(dmesgBackfillHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		if err := warmstate.Save(ctx, db, dmesgBackfillWarmStateKey, dmesgBackfillState{LastSeen: time.Now().UTC()}, time.Now()); err != nil {
+			log.Logger.Warnw("failed to save dmesg backfill state", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
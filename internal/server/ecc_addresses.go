@@ -0,0 +1,29 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	components_nvidia_ecc_addresses "github.com/leptonai/gpud/components/accelerator/nvidia/query/ecc-addresses"
+	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	"github.com/leptonai/gpud/log"
+)
+
+// recordRowRemapperAddress records the address of the row marked for remapping in the dmesg Xid line,
+// resolving the GPU by its PCI bus number.
+func recordRowRemapperAddress(ctx context.Context, db *sql.DB, line string, addr uint64, ts time.Time) {
+	bus, ok := nvidia_query_xid.ExtractNVRMXidBusNumber(line)
+	if !ok {
+		log.Logger.Warnw("failed to find the gpu bus id in the xid line", "line", line)
+		return
+	}
+	uuid, ok := gpuBusIDs()[bus]
+	if !ok {
+		log.Logger.Warnw("failed to find the gpu by the bus id -- skipping row remapper address", "bus", bus)
+		return
+	}
+	if _, err := components_nvidia_ecc_addresses.Record(ctx, db, uuid, addr, components_nvidia_ecc_addresses.SourceRowRemapper, ts); err != nil {
+		log.Logger.Errorw("failed to record row remapper address", "uuid", uuid, "address", addr, "error", err)
+	}
+}
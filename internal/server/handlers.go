@@ -1,6 +1,7 @@
 package server
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -22,12 +23,13 @@ import (
 type globalHandler struct {
 	cfg        *lep_config.Config
 	components map[string]lep_components.Component
+	db         *sql.DB
 
 	componentNamesMu sync.RWMutex
 	componentNames   []string
 }
 
-func newGlobalHandler(cfg *lep_config.Config, components map[string]lep_components.Component) *globalHandler {
+func newGlobalHandler(cfg *lep_config.Config, components map[string]lep_components.Component, db *sql.DB) *globalHandler {
 	var componentNames []string
 	for name := range components {
 		componentNames = append(componentNames, name)
@@ -37,6 +39,7 @@ func newGlobalHandler(cfg *lep_config.Config, components map[string]lep_componen
 	return &globalHandler{
 		cfg:            cfg,
 		components:     components,
+		db:             db,
 		componentNames: componentNames,
 	}
 }
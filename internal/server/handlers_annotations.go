@@ -0,0 +1,228 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/errdefs"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathAnnotations     = "/annotations"
+	URLPathAnnotationsDesc = "Get or set the operator annotations (e.g., ticket IDs) on the components and events"
+)
+
+// getAnnotations godoc
+// @Summary Query the annotations
+// @Description get the operator annotations on the components and their events
+// @ID getAnnotations
+// @Param   component     query    string     false        "Component Name, leave empty to query all components"
+// @Produce  json
+// @Success 200 {object} []state.Annotation
+// @Router /v1/annotations [get]
+func (g *globalHandler) getAnnotations(c *gin.Context) {
+	components, err := g.getReqComponents(c)
+	if err != nil {
+		if errors.Is(err, errdefs.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "component not found: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse components: " + err.Error()})
+		return
+	}
+
+	annotations := make([]state.Annotation, 0)
+	for _, componentName := range components {
+		as, err := state.ReadAnnotations(c, g.db, componentName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read annotations " + err.Error()})
+			return
+		}
+		annotations = append(annotations, as...)
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(annotations)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal annotations " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, annotations)
+			return
+		}
+		c.JSON(http.StatusOK, annotations)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
+
+// AnnotationsRequest sets the annotations on a component, or on a component event.
+type AnnotationsRequest struct {
+	Component string `json:"component"`
+	// Set with the event time (unix seconds) to annotate a specific event.
+	EventName        string `json:"event_name,omitempty"`
+	EventUnixSeconds int64  `json:"event_unix_seconds,omitempty"`
+
+	// Empty value deletes the annotation.
+	Annotations map[string]string `json:"annotations"`
+}
+
+// postAnnotations godoc
+// @Summary Set the annotations
+// @Description set the operator annotations on a component or a component event, empty value deletes the annotation
+// @ID postAnnotations
+// @Accept  json
+// @Produce  json
+// @Param   request     body    AnnotationsRequest     true        "Annotations"
+// @Success 200 {object} []state.Annotation
+// @Router /v1/annotations [post]
+func (g *globalHandler) postAnnotations(c *gin.Context) {
+	var req AnnotationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse request: " + err.Error()})
+		return
+	}
+	if _, ok := g.components[req.Component]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "component not found: " + req.Component})
+		return
+	}
+	if len(req.Annotations) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "annotations are required"})
+		return
+	}
+
+	now := time.Now().UTC()
+	for k, v := range req.Annotations {
+		a := state.Annotation{
+			Component: req.Component,
+			EventName: req.EventName,
+			Key:       k,
+			Value:     v,
+			UpdatedAt: now,
+		}
+		if req.EventUnixSeconds > 0 {
+			a.EventTime = time.Unix(req.EventUnixSeconds, 0)
+		}
+		if err := state.SetAnnotation(c, g.db, a); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to set annotation " + err.Error()})
+			return
+		}
+	}
+
+	as, err := state.ReadAnnotations(c, g.db, req.Component)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read annotations " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, as)
+}
+
+const (
+	URLPathGPUAnnotations     = "/annotations/gpus"
+	URLPathGPUAnnotationsDesc = "Get or set the persistent operator annotations (e.g., suspect) on the GPUs"
+)
+
+// getGPUAnnotations godoc
+// @Summary Query the GPU annotations
+// @Description get the persistent operator annotations on the GPUs
+// @ID getGPUAnnotations
+// @Param   gpu_uuid     query    string     false        "GPU UUID, leave empty to query all GPUs"
+// @Produce  json
+// @Success 200 {object} []state.GPUAnnotation
+// @Router /v1/annotations/gpus [get]
+func (g *globalHandler) getGPUAnnotations(c *gin.Context) {
+	annotations, err := state.ReadGPUAnnotations(c, g.db, c.Query("gpu_uuid"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read gpu annotations " + err.Error()})
+		return
+	}
+	if annotations == nil {
+		annotations = make([]state.GPUAnnotation, 0)
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(annotations)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal gpu annotations " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, annotations)
+			return
+		}
+		c.JSON(http.StatusOK, annotations)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
+
+// GPUAnnotationsRequest sets the annotations on a GPU.
+type GPUAnnotationsRequest struct {
+	GPUUUID string `json:"gpu_uuid"`
+
+	// Empty value deletes the annotation.
+	Annotations map[string]string `json:"annotations"`
+}
+
+// postGPUAnnotations godoc
+// @Summary Set the GPU annotations
+// @Description set the persistent operator annotations on a GPU, empty value deletes the annotation
+// @ID postGPUAnnotations
+// @Accept  json
+// @Produce  json
+// @Param   request     body    GPUAnnotationsRequest     true        "GPU annotations"
+// @Success 200 {object} []state.GPUAnnotation
+// @Router /v1/annotations/gpus [post]
+func (g *globalHandler) postGPUAnnotations(c *gin.Context) {
+	var req GPUAnnotationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse request: " + err.Error()})
+		return
+	}
+	if req.GPUUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "gpu uuid is required"})
+		return
+	}
+	if len(req.Annotations) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "annotations are required"})
+		return
+	}
+
+	now := time.Now().UTC()
+	for k, v := range req.Annotations {
+		a := state.GPUAnnotation{
+			GPUUUID:   req.GPUUUID,
+			Key:       k,
+			Value:     v,
+			UpdatedAt: now,
+		}
+		if err := state.SetGPUAnnotation(c, g.db, a); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to set gpu annotation " + err.Error()})
+			return
+		}
+	}
+
+	as, err := state.ReadGPUAnnotations(c, g.db, req.GPUUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read gpu annotations " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, as)
+}
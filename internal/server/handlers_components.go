@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"sort"
@@ -8,11 +9,15 @@ import (
 
 	v1 "github.com/leptonai/gpud/api/v1"
 	lep_components "github.com/leptonai/gpud/components"
+	nvidia_query_driver_maintenance "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-maintenance"
 	"github.com/leptonai/gpud/components/query"
+	lep_state "github.com/leptonai/gpud/components/state"
 	"github.com/leptonai/gpud/errdefs"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/lifecycle"
 
 	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 )
 
@@ -22,6 +27,10 @@ const (
 	RequestHeaderYAML        = "application/yaml"
 	RequestHeaderJSONIndent  = "json-indent"
 
+	// RequestHeaderCanonical set to "true" renders the canonical output
+	// (sorted states and events, indented JSON), for diff-based tooling and snapshot tests.
+	RequestHeaderCanonical = "canonical"
+
 	RequestHeaderAcceptEncoding = "Accept-Encoding"
 	RequestHeaderEncodingGzip   = "gzip"
 )
@@ -109,18 +118,28 @@ func (g *globalHandler) getComponents(c *gin.Context) {
 
 const (
 	URLPathStates     = "/states"
-	URLPathStatesDesc = "Get the states of all gpud components"
+	URLPathStatesDesc = "Get the states of all gpud components (set \"format=conditions\" for the Kubernetes-style conditions)"
 )
 
+// StatesFormatConditions renders the states as the Kubernetes-style conditions, one per component.
+const StatesFormatConditions = "conditions"
+
 // getStates godoc
 // @Summary Query component States interface in gpud
 // @Description get component States interface by component name
 // @ID getStates
 // @Param   component     query    string     false        "Component Name, leave empty to query all components"
+// @Param   format        query    string     false        "Set \"conditions\" to render the states as the Kubernetes-style conditions"
 // @Produce  json
 // @Success 200 {object} v1.LeptonStates
 // @Router /v1/states [get]
 func (g *globalHandler) getStates(c *gin.Context) {
+	format := c.Query("format")
+	if format != "" && format != StatesFormatConditions {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid format " + format})
+		return
+	}
+
 	var states v1.LeptonStates
 	components, err := g.getReqComponents(c)
 	if err != nil {
@@ -132,9 +151,19 @@ func (g *globalHandler) getStates(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse components: " + err.Error()})
 		return
 	}
+	var acks []lep_state.EventAck
+	if (g.cfg.AnnotateAckedStates || g.cfg.AckedAsDegraded) && g.db != nil {
+		acks, err = lep_state.ReadEventAcks(c, g.db, true)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read event acks " + err.Error()})
+			return
+		}
+	}
+	gpuAnnotations := g.readGPUAnnotations(c)
 	for _, componentName := range components {
 		currState := v1.LeptonComponentStates{
 			Component: componentName,
+			Lifecycle: string(lifecycle.Current().State),
 		}
 		component, err := lep_components.GetComponent(componentName)
 		if err != nil {
@@ -157,14 +186,29 @@ func (g *globalHandler) getStates(c *gin.Context) {
 			)
 		} else {
 			log.Logger.Debugw("successfully got states", "component", componentName)
-			currState.States = state
+			currState.States = annotateAckedStates(componentName, state, acks, g.cfg.AckedAsDegraded)
+			currState.States = degradeMaintenanceStates(componentName, currState.States, nvidia_query_driver_maintenance.Current())
+			lep_components.SortStates(currState.States)
+			gpuAnnotations.AnnotateStates(currState.States)
+		}
+		if g.db != nil {
+			if as, err := lep_state.ReadAnnotations(c, g.db, componentName); err != nil {
+				log.Logger.Warnw("failed to read annotations", "component", componentName, "error", err)
+			} else {
+				currState.Annotations = lep_state.ComponentAnnotations(as)
+			}
 		}
 		states = append(states, currState)
 	}
 
+	var out any = states
+	if format == StatesFormatConditions {
+		out = g.statesConditions(c, states)
+	}
+
 	switch c.GetHeader(RequestHeaderContentType) {
 	case RequestHeaderYAML:
-		yb, err := yaml.Marshal(states)
+		yb, err := yaml.Marshal(out)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal states " + err.Error()})
 			return
@@ -172,17 +216,51 @@ func (g *globalHandler) getStates(c *gin.Context) {
 		c.String(http.StatusOK, string(yb))
 
 	case RequestHeaderJSON, "":
-		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
-			c.IndentedJSON(http.StatusOK, states)
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" || c.GetHeader(RequestHeaderCanonical) == "true" {
+			c.IndentedJSON(http.StatusOK, out)
 			return
 		}
-		c.JSON(http.StatusOK, states)
+		c.JSON(http.StatusOK, out)
 
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
 	}
 }
 
+// statesConditions renders the component states as the Kubernetes-style conditions,
+// with the last transition times from the states history (or now, if not recorded yet or the history is disabled).
+func (g *globalHandler) statesConditions(ctx context.Context, states v1.LeptonStates) []metav1.Condition {
+	now := time.Now().UTC()
+	conds := make([]metav1.Condition, 0, len(states))
+	for _, cs := range states {
+		// the states history records the health before the states are degraded (e.g., driver maintenance)
+		healthy := true
+		for _, s := range cs.States {
+			if !s.Healthy || s.Degraded {
+				healthy = false
+			}
+		}
+
+		transition := now
+		if g.statesHistoryEnabled() && len(cs.States) > 0 {
+			t, err := lep_state.ReadLastTransitionTime(ctx, g.db, cs.Component, healthy)
+			if err != nil {
+				log.Logger.Warnw("failed to read last transition time", "component", cs.Component, "error", err)
+			} else if !t.IsZero() {
+				transition = t
+			}
+		}
+		conds = append(conds, lep_state.ComponentCondition(cs.Component, cs.States, transition))
+	}
+	return conds
+}
+
+// statesHistoryEnabled returns true if the states history is recorded
+// (otherwise, its table does not exist).
+func (g *globalHandler) statesHistoryEnabled() bool {
+	return g.db != nil && g.cfg != nil && g.cfg.StatesHistoryRetentionPeriod.Duration > 0
+}
+
 const (
 	URLPathEvents     = "/events"
 	URLPathEventsDesc = "Get the events of all gpud components"
@@ -213,11 +291,14 @@ func (g *globalHandler) getEvents(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse time: " + err.Error()})
 		return
 	}
+	canonical := c.GetHeader(RequestHeaderCanonical) == "true"
+	gpuAnnotations := g.readGPUAnnotations(c)
 	for _, componentName := range components {
 		currEvent := v1.LeptonComponentEvents{
 			Component: componentName,
 			StartTime: startTime,
 			EndTime:   endTime,
+			Lifecycle: string(lifecycle.Current().State),
 		}
 		component, err := lep_components.GetComponent(componentName)
 		if err != nil {
@@ -244,6 +325,19 @@ func (g *globalHandler) getEvents(c *gin.Context) {
 		} else {
 			currEvent.Events = event
 		}
+		if g.db != nil && len(currEvent.Events) > 0 {
+			if as, err := lep_state.ReadAnnotations(c, g.db, componentName); err != nil {
+				log.Logger.Warnw("failed to read annotations", "component", componentName, "error", err)
+			} else {
+				for i, ev := range currEvent.Events {
+					currEvent.Events[i].Annotations = lep_state.EventAnnotations(as, ev.Name, ev.Time.Time)
+				}
+			}
+		}
+		gpuAnnotations.AnnotateEvents(currEvent.Events)
+		if canonical {
+			lep_components.SortEvents(currEvent.Events)
+		}
 		events = append(events, currEvent)
 	}
 
@@ -257,7 +351,7 @@ func (g *globalHandler) getEvents(c *gin.Context) {
 		c.String(http.StatusOK, string(yb))
 
 	case RequestHeaderJSON, "":
-		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" || canonical {
 			c.IndentedJSON(http.StatusOK, events)
 			return
 		}
@@ -317,6 +411,7 @@ func (g *globalHandler) getInfo(c *gin.Context) {
 			Component: componentName,
 			StartTime: startTime,
 			EndTime:   endTime,
+			Lifecycle: string(lifecycle.Current().State),
 			Info:      lep_components.Info{},
 		}
 		component, err := lep_components.GetComponent(componentName)
@@ -428,6 +523,7 @@ func (g *globalHandler) getMetrics(c *gin.Context) {
 	for _, componentName := range components {
 		currMetrics := v1.LeptonComponentMetrics{
 			Component: componentName,
+			Lifecycle: string(lifecycle.Current().State),
 		}
 		component, err := lep_components.GetComponent(componentName)
 		if err != nil {
@@ -472,3 +568,34 @@ func (g *globalHandler) getMetrics(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
 	}
 }
+
+// degradeMaintenanceStates reports the unhealthy states of the NVIDIA components as degraded (healthy)
+// while the driver install/upgrade is in progress, since the failures are expected (e.g., NVML unloaded).
+func degradeMaintenanceStates(component string, states []lep_components.State, maintenance nvidia_query_driver_maintenance.Status) []lep_components.State {
+	if !maintenance.Affects(component) {
+		return states
+	}
+	for i := range states {
+		if states[i].Healthy {
+			continue
+		}
+		states[i].Healthy = true
+		states[i].Degraded = true
+		states[i].Reason = "driver maintenance in progress (" + maintenance.Reason + "): " + states[i].Reason
+	}
+	return states
+}
+
+// readGPUAnnotations returns the persistent GPU annotations to attach to the states and events.
+// Returns nil if the database is not available or the read fails.
+func (g *globalHandler) readGPUAnnotations(ctx context.Context) lep_state.GPUAnnotations {
+	if g.db == nil {
+		return nil
+	}
+	as, err := lep_state.ReadGPUAnnotations(ctx, g.db, "")
+	if err != nil {
+		log.Logger.Warnw("failed to read gpu annotations", "error", err)
+		return nil
+	}
+	return lep_state.GPUAnnotationsByUUID(as)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/leptonai/gpud/components/query"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathDebugRaw     = "/debug/raw/:component"
+	URLPathDebugRawDesc = "Get the last raw payload collected by the component before the state conversion"
+)
+
+// RawPayload is the last raw output of the component poller.
+type RawPayload struct {
+	Component string `json:"component"`
+	// PollerID is the poller shared by the components (e.g., the NVIDIA query poller).
+	PollerID string    `json:"poller_id"`
+	Time     time.Time `json:"time"`
+	Error    string    `json:"error,omitempty"`
+	Output   any       `json:"output,omitempty"`
+}
+
+// getDebugRaw godoc
+// @Summary Fetch the last raw payload of the component
+// @Description get the last raw output of the component poller (before the state conversion), to debug the discrepancies between the collected data and the reported states
+// @ID getDebugRaw
+// @Produce  json
+// @Param component path string true "Component name"
+// @Success 200 {object} RawPayload
+// @Router /admin/debug/raw/{component} [get]
+func createDebugRawHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		name := c.Param("component")
+		poller, ok := query.GetPoller(name)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "no poller found for component " + name})
+			return
+		}
+		last, err := poller.Last()
+		if err == query.ErrNoData {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to get last payload " + err.Error()})
+			return
+		}
+
+		payload := RawPayload{
+			Component: name,
+			PollerID:  poller.ID(),
+			Time:      last.Time.Time,
+			Output:    last.Output,
+		}
+		if last.Error != nil {
+			payload.Error = last.Error.Error()
+		}
+
+		switch c.GetHeader(RequestHeaderContentType) {
+		case RequestHeaderYAML:
+			yb, err := yaml.Marshal(payload)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal raw payload " + err.Error()})
+				return
+			}
+			c.String(http.StatusOK, string(yb))
+
+		default:
+			if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+				c.IndentedJSON(http.StatusOK, payload)
+				return
+			}
+			c.JSON(http.StatusOK, payload)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/errdefs"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathEventAcks     = "/events/acks"
+	URLPathEventAcksDesc = "Get, acknowledge, or resolve the known issue events (acknowledged events are not notified again)"
+)
+
+// getEventAcks godoc
+// @Summary Query the event acknowledgments
+// @Description get the event acknowledgments, set "active=false" to include the resolved ones
+// @ID getEventAcks
+// @Param   active     query    string     false        "true (default) to only return the unresolved acknowledgments"
+// @Produce  json
+// @Success 200 {object} []state.EventAck
+// @Router /v1/events/acks [get]
+func (g *globalHandler) getEventAcks(c *gin.Context) {
+	acks, err := state.ReadEventAcks(c, g.db, c.DefaultQuery("active", "true") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read event acks " + err.Error()})
+		return
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(acks)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal event acks " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, acks)
+			return
+		}
+		c.JSON(http.StatusOK, acks)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
+
+// EventAckRequest is the request to acknowledge or resolve the component events.
+type EventAckRequest struct {
+	Component string `json:"component"`
+	// Empty to acknowledge all the events of the component.
+	EventName string `json:"event_name,omitempty"`
+	AckedBy   string `json:"acked_by,omitempty"`
+	Note      string `json:"note,omitempty"`
+
+	// Set true to resolve the active acknowledgment instead.
+	Resolve bool `json:"resolve,omitempty"`
+}
+
+// postEventAck godoc
+// @Summary Acknowledge or resolve the component events
+// @Description acknowledge the component events as a known issue with a note, or resolve the acknowledgment
+// @ID postEventAck
+// @Accept  json
+// @Produce  json
+// @Param   request     body    EventAckRequest     true        "Acknowledgment"
+// @Success 200 {object} state.EventAck
+// @Router /v1/events/acks [post]
+func (g *globalHandler) postEventAck(c *gin.Context) {
+	var req EventAckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse request: " + err.Error()})
+		return
+	}
+	if req.Component == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "component is required"})
+		return
+	}
+	if _, err := components.GetComponent(req.Component); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "component not found: " + err.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	if req.Resolve {
+		resolved, err := state.ResolveEventAck(c, g.db, req.Component, req.EventName, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to resolve event ack " + err.Error()})
+			return
+		}
+		if !resolved {
+			c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "no active event ack found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "resolved"})
+		return
+	}
+
+	ack := state.EventAck{
+		Component: req.Component,
+		EventName: req.EventName,
+		AckedBy:   req.AckedBy,
+		Note:      req.Note,
+		AckedAt:   now,
+	}
+	if err := state.AckEvent(c, g.db, ack); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to ack event " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ack)
+}
+
+// annotateAckedStates annotates the unhealthy states of the component
+// with all events acknowledged as a known issue (see "state.EventAck.CoversStates").
+// If degrade is true, the states are reported as degraded (healthy) instead of unhealthy.
+func annotateAckedStates(component string, states []components.State, acks []state.EventAck, degrade bool) []components.State {
+	var ack *state.EventAck
+	for i := range acks {
+		if acks[i].CoversStates(component) {
+			ack = &acks[i]
+			break
+		}
+	}
+	if ack == nil {
+		return states
+	}
+
+	for i := range states {
+		if states[i].Healthy {
+			continue
+		}
+		states[i].Annotations = mergeAckAnnotations(states[i].Annotations, *ack)
+		if degrade {
+			states[i].Healthy = true
+			states[i].Degraded = true
+			states[i].Reason = "acknowledged known issue (" + ack.Note + "): " + states[i].Reason
+		}
+	}
+	return states
+}
+
+func mergeAckAnnotations(to map[string]string, ack state.EventAck) map[string]string {
+	if to == nil {
+		to = make(map[string]string, 2)
+	}
+	note := ack.Note
+	if note == "" {
+		note = "true"
+	}
+	to[state.EventAckAnnotationKeyAcknowledged] = note
+	if ack.AckedBy != "" {
+		to[state.EventAckAnnotationKeyAckedBy] = ack.AckedBy
+	}
+	return to
+}
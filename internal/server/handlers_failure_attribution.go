@@ -0,0 +1,102 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	failure_attribution "github.com/leptonai/gpud/components/accelerator/nvidia/query/failure-attribution"
+	gpu_processes "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-processes"
+	xidsxidstate "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
+	"github.com/leptonai/gpud/errdefs"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathFailureAttribution     = "/reports/failure-attribution"
+	URLPathFailureAttributionDesc = "Get the GPU errors in a time range aggregated by the pods/processes using the failed GPUs"
+)
+
+// defaultFailureAttributionRange is the report time range if the start time is not specified.
+const defaultFailureAttributionRange = 24 * time.Hour
+
+// getFailureAttribution godoc
+// @Summary Query the GPU failure attribution report
+// @Description list the GPUs that experienced Xid errors in the time range and the pods/processes running on them at the time, aggregated per job
+// @ID getFailureAttribution
+// @Param   startTime     query    string     false        "Start time of the report (unix seconds), defaults to 24 hours before the end time"
+// @Param   endTime       query    string     false        "End time of the report (unix seconds), defaults to now"
+// @Produce  json
+// @Success 200 {object} failure_attribution.Report
+// @Router /v1/reports/failure-attribution [get]
+func (g *globalHandler) getFailureAttribution(c *gin.Context) {
+	startTime, endTime, err := g.getReqTime(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse time: " + err.Error()})
+		return
+	}
+	if c.Query("startTime") == "" {
+		startTime = endTime.Add(-defaultFailureAttributionRange)
+	}
+	if startTime.After(endTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "start time is after the end time"})
+		return
+	}
+
+	events, err := xidsxidstate.ReadEvents(c, g.db, xidsxidstate.WithSince(startTime), xidsxidstate.WithSortUnixSecondsAscendingOrder())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read xid events " + err.Error()})
+		return
+	}
+	procs, err := gpu_processes.ReadEntries(c, g.db, startTime.Add(-failure_attribution.DefaultWindow), endTime.Add(failure_attribution.DefaultWindow))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read gpu processes " + err.Error()})
+		return
+	}
+
+	resp := failure_attribution.Build(events, procs, gpuBusIDs(), startTime, endTime, failure_attribution.DefaultWindow)
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(resp)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal failure attribution report " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, resp)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
+
+// gpuBusIDs returns the PCI bus number to the GPU UUID mappings
+// from the last nvidia query, or nil if not available (e.g., no nvidia GPU).
+func gpuBusIDs() map[uint32]string {
+	poller := nvidia_query.GetDefaultPoller()
+	if poller == nil {
+		return nil
+	}
+	last, err := poller.Last()
+	if err != nil || last == nil {
+		return nil
+	}
+	o, ok := last.Output.(*nvidia_query.Output)
+	if !ok || o == nil || o.NVML == nil {
+		return nil
+	}
+	busIDs := make(map[uint32]string, len(o.NVML.DeviceInfos))
+	for _, dev := range o.NVML.DeviceInfos {
+		busIDs[dev.BusID] = dev.UUID
+	}
+	return busIDs
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathGPUFeatures     = "/gpu-features"
+	URLPathGPUFeaturesDesc = "Get the compute capability and the supported features of each GPU"
+)
+
+// getGPUFeatures godoc
+// @Summary Fetch the GPU feature matrix
+// @Description get the compute capability, supported features (e.g., FP8, NVLink C2C, MIG, confidential compute), and driver capability bits of each GPU
+// @ID getGPUFeatures
+// @Produce  json
+// @Success 200 {object} nvml.FeatureMatrix
+// @Router /v1/gpu-features [get]
+func createGPUFeaturesHandler() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		m, err := nvidia_query_nvml.GetFeatureMatrix()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to get gpu features " + err.Error()})
+			return
+		}
+
+		switch c.GetHeader(RequestHeaderContentType) {
+		case RequestHeaderYAML:
+			yb, err := yaml.Marshal(m)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal gpu features " + err.Error()})
+				return
+			}
+			c.String(http.StatusOK, string(yb))
+
+		default:
+			if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+				c.IndentedJSON(http.StatusOK, m)
+				return
+			}
+			c.JSON(http.StatusOK, m)
+		}
+	}
+}
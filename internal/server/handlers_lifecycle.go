@@ -0,0 +1,153 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/leptonai/gpud/errdefs"
+	"github.com/leptonai/gpud/internal/validate"
+	"github.com/leptonai/gpud/pkg/lifecycle"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathLifecycle     = "/lifecycle"
+	URLPathLifecycleDesc = "Get or set the node lifecycle state (provisioning, in-service, draining, repairing)"
+)
+
+// maxLifecycleTransitions is the number of the recent transitions to return.
+const maxLifecycleTransitions = 20
+
+// Lifecycle is the current node lifecycle state and the recent transitions.
+type Lifecycle struct {
+	lifecycle.Status
+	Transitions []lifecycle.Transition `json:"transitions,omitempty"`
+}
+
+// getLifecycle godoc
+// @Summary Query the node lifecycle state
+// @Description get the current node lifecycle state and the recent transitions
+// @ID getLifecycle
+// @Produce  json
+// @Success 200 {object} Lifecycle
+// @Router /v1/lifecycle [get]
+func (g *globalHandler) getLifecycle(c *gin.Context) {
+	ts, err := lifecycle.ReadTransitions(c, g.db, maxLifecycleTransitions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read lifecycle transitions " + err.Error()})
+		return
+	}
+	resp := Lifecycle{
+		Status:      lifecycle.Current(),
+		Transitions: ts,
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(resp)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal lifecycle " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, resp)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
+
+// LifecycleRequest sets the node lifecycle state.
+type LifecycleRequest struct {
+	State  lifecycle.State `json:"state"`
+	Reason string          `json:"reason,omitempty"`
+}
+
+// postLifecycle godoc
+// @Summary Set the node lifecycle state
+// @Description transition the node to the lifecycle state, returns 409 if the transition is not allowed from the current state
+// @ID postLifecycle
+// @Accept  json
+// @Produce  json
+// @Param   request     body    LifecycleRequest     true        "Lifecycle state"
+// @Success 200 {object} lifecycle.Status
+// @Router /v1/lifecycle [post]
+func (g *globalHandler) postLifecycle(c *gin.Context) {
+	var req LifecycleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse request: " + err.Error()})
+		return
+	}
+	if err := req.State.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": err.Error()})
+		return
+	}
+
+	st, err := lifecycle.Set(c, g.db, req.State, req.Reason, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, lifecycle.ErrInvalidTransition) {
+			c.JSON(http.StatusConflict, gin.H{"code": errdefs.ErrFailedPrecondition, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to set lifecycle state " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+const (
+	URLPathLifecycleValidate     = "/lifecycle/validate"
+	URLPathLifecycleValidateDesc = "Run the post-repair validation checklist, or get the last result"
+)
+
+// getLifecycleValidate godoc
+// @Summary Query the post-repair validation result
+// @Description get the last (or running) post-repair validation result
+// @ID getLifecycleValidate
+// @Produce  json
+// @Success 200 {object} validate.Result
+// @Router /v1/lifecycle/validate [get]
+func createGetLifecycleValidateHandler(runner *validate.Runner) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		last := runner.Last()
+		if last == nil {
+			c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "no validation run yet"})
+			return
+		}
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, last)
+			return
+		}
+		c.JSON(http.StatusOK, last)
+	}
+}
+
+// postLifecycleValidate godoc
+// @Summary Run the post-repair validation
+// @Description start the post-repair validation checklist in the background, which transitions the node lifecycle to in-service only when all the checks pass
+// @ID postLifecycleValidate
+// @Produce  json
+// @Success 202 {object} validate.Result
+// @Router /v1/lifecycle/validate [post]
+func createPostLifecycleValidateHandler(runner *validate.Runner) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if err := runner.Start(); err != nil {
+			if errors.Is(err, validate.ErrAlreadyRunning) {
+				c.JSON(http.StatusConflict, gin.H{"code": errdefs.ErrAlreadyExists, "message": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to start validation " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, runner.Last())
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	pstate_history "github.com/leptonai/gpud/components/accelerator/nvidia/query/pstate-history"
+	"github.com/leptonai/gpud/errdefs"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathPStateHistory     = "/reports/pstate-history"
+	URLPathPStateHistoryDesc = "Get the per-GPU performance state (P-state) transitions in a time range with the clocks and the active throttle reasons"
+)
+
+// defaultPStateHistoryRange is the report time range if the start time is not specified.
+const defaultPStateHistoryRange = 24 * time.Hour
+
+// getPStateHistory godoc
+// @Summary Query the GPU performance state history
+// @Description list the performance state (P-state) transitions of the GPUs in the time range, with the clocks, the active clock event (throttle) reasons, and the time spent in each state
+// @ID getPStateHistory
+// @Param   startTime     query    string     false        "Start time of the report (unix seconds), defaults to 24 hours before the end time"
+// @Param   endTime       query    string     false        "End time of the report (unix seconds), defaults to now"
+// @Param   uuid          query    string     false        "GPU UUID to filter (default: all GPUs)"
+// @Produce  json
+// @Success 200 {object} pstate_history.Report
+// @Router /v1/reports/pstate-history [get]
+func (g *globalHandler) getPStateHistory(c *gin.Context) {
+	startTime, endTime, err := g.getReqTime(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse time: " + err.Error()})
+		return
+	}
+	if c.Query("startTime") == "" {
+		startTime = endTime.Add(-defaultPStateHistoryRange)
+	}
+	if startTime.After(endTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "start time is after the end time"})
+		return
+	}
+
+	trs, err := pstate_history.ReadTransitions(c, g.db, startTime, endTime, c.Query("uuid"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read pstate transitions " + err.Error()})
+		return
+	}
+
+	resp := pstate_history.Build(trs, startTime, endTime)
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(resp)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal pstate history report " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, resp)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/errdefs"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathStateSchemas     = "/states/schemas"
+	URLPathStateSchemasDesc = "Get the state names and extra info keys of the components with their types and units"
+)
+
+// getStateSchemas godoc
+// @Summary Fetch the component state schemas
+// @Description get the state names and the extra info keys (with types and units) of the components, set "components" to filter by the comma-separated component names
+// @ID getStateSchemas
+// @Produce  json
+// @Success 200 {object} []components.ComponentStateSchemas
+// @Router /v1/states/schemas [get]
+func (g *globalHandler) getStateSchemas(c *gin.Context) {
+	var schemas []components.ComponentStateSchemas
+	if names := c.Query("components"); names != "" {
+		for _, name := range strings.Split(names, ",") {
+			cs, ok := components.GetStateSchemas(name)
+			if !ok {
+				c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "state schemas not found: " + name})
+				return
+			}
+			schemas = append(schemas, cs)
+		}
+	} else {
+		schemas = components.GetAllStateSchemas()
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(schemas)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal state schemas " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	default:
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, schemas)
+			return
+		}
+		c.JSON(http.StatusOK, schemas)
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/errdefs"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathStatesHistory     = "/states/history"
+	URLPathStatesHistoryDesc = "Get the states of all gpud components as of a past time"
+)
+
+// getStatesHistory godoc
+// @Summary Query component states as of a past time
+// @Description reconstruct the component states as of the given time from the states history (e.g., "was this node healthy at time T?")
+// @ID getStatesHistory
+// @Param   time          query    string     true         "Unix timestamp in seconds"
+// @Param   component     query    string     false        "Component Name, leave empty to query all components"
+// @Produce  json
+// @Success 200 {object} v1.LeptonNodeStatesAt
+// @Router /v1/states/history [get]
+func (g *globalHandler) getStatesHistory(c *gin.Context) {
+	timeStr := c.Query("time")
+	if timeStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "time is required"})
+		return
+	}
+	unixSeconds, err := strconv.ParseInt(timeStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse time: " + err.Error()})
+		return
+	}
+	at := time.Unix(unixSeconds, 0).UTC()
+
+	components, err := g.getReqComponents(c)
+	if err != nil {
+		if errors.Is(err, errdefs.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "component not found: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse components: " + err.Error()})
+		return
+	}
+
+	resp := v1.LeptonNodeStatesAt{
+		Time:    at,
+		Healthy: true,
+	}
+	for _, componentName := range components {
+		currState := v1.LeptonComponentStatesAt{
+			Component: componentName,
+		}
+
+		h, err := state.ReadStatesAt(c, g.db, componentName, at)
+		switch {
+		case err == nil:
+			currState.Known = true
+			currState.Since = h.Start
+			currState.States = h.States
+			if !h.Healthy {
+				resp.Healthy = false
+			}
+
+		case errors.Is(err, state.ErrNoStatesHistory):
+			log.Logger.Debugw("no states history found", "component", componentName, "time", at)
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read states history " + err.Error()})
+			return
+		}
+		resp.Components = append(resp.Components, currState)
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(resp)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal states history " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, resp)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
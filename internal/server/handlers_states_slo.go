@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/errdefs"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const RequestHeaderCSV = "text/csv"
+
+const (
+	URLPathStatesSLO     = "/states/slo"
+	URLPathStatesSLODesc = "Get the per-component and per-node health SLOs (percentage of time healthy) over daily/weekly windows"
+)
+
+const defaultSLOCount = 7
+
+// getStatesSLO godoc
+// @Summary Query the health SLOs
+// @Description compute the per-component and per-node health SLOs (percentage of time healthy) over the last complete daily or weekly windows, set "Content-Type: text/csv" for CSV export
+// @ID getStatesSLO
+// @Param   period        query    string     false        "daily (default) or weekly"
+// @Param   count         query    string     false        "Number of windows (default 7)"
+// @Param   component     query    string     false        "Component Name, leave empty to query all components"
+// @Produce  json
+// @Success 200 {object} []state.SLOReport
+// @Router /v1/states/slo [get]
+func (g *globalHandler) getStatesSLO(c *gin.Context) {
+	period := c.DefaultQuery("period", state.SLOPeriodDaily)
+	count := defaultSLOCount
+	if countStr := c.Query("count"); countStr != "" {
+		var err error
+		count, err = strconv.Atoi(countStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse count: " + err.Error()})
+			return
+		}
+	}
+	windows, err := state.SLOWindows(time.Now(), period, count)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid window: " + err.Error()})
+		return
+	}
+
+	components, err := g.getReqComponents(c)
+	if err != nil {
+		if errors.Is(err, errdefs.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "component not found: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse components: " + err.Error()})
+		return
+	}
+
+	histories := make(map[string][]state.StatesHistory, len(components))
+	for _, componentName := range components {
+		hs, err := state.ReadStatesHistory(c, g.db, componentName, windows[0].Start.Add(-state.DefaultStatesHistoryInterval), windows[len(windows)-1].End)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read states history " + err.Error()})
+			return
+		}
+		histories[componentName] = hs
+	}
+
+	reports := make([]state.SLOReport, 0, len(windows))
+	for _, w := range windows {
+		reports = append(reports, state.ComputeSLOReport(w, histories))
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderCSV:
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=gpud-slo-%s.csv", period))
+		c.Status(http.StatusOK)
+		if err := writeSLOReportsCSV(csv.NewWriter(c.Writer), reports); err != nil {
+			_ = c.Error(err)
+		}
+
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(reports)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal slo reports " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, reports)
+			return
+		}
+		c.JSON(http.StatusOK, reports)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
+
+// writeSLOReportsCSV writes one row per node and component for each window.
+func writeSLOReportsCSV(w *csv.Writer, reports []state.SLOReport) error {
+	if err := w.Write([]string{"window_start", "window_end", "scope", "component", "observed_seconds", "healthy_seconds", "observed_percent", "healthy_percent"}); err != nil {
+		return err
+	}
+	for _, r := range reports {
+		start, end := r.Window.Start.Format(time.RFC3339), r.Window.End.Format(time.RFC3339)
+		if err := w.Write(sloCSVRow(start, end, "node", "", r.Node)); err != nil {
+			return err
+		}
+		for _, cs := range r.Components {
+			if err := w.Write(sloCSVRow(start, end, "component", cs.Component, cs.SLO)); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func sloCSVRow(start, end, scope, component string, slo state.SLO) []string {
+	return []string{
+		start,
+		end,
+		scope,
+		component,
+		strconv.FormatInt(slo.ObservedSeconds, 10),
+		strconv.FormatInt(slo.HealthySeconds, 10),
+		strconv.FormatFloat(slo.ObservedPercent, 'f', 3, 64),
+		strconv.FormatFloat(slo.HealthyPercent, 'f', 3, 64),
+	}
+}
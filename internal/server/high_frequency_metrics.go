@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"os"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	lepconfig "github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/shmring"
+)
+
+// exportHighFrequencyMetrics samples the GPU utilization and power usage at the configured interval
+// into the memory-mapped ring buffer, until the context is canceled.
+func exportHighFrequencyMetrics(ctx context.Context, cfg *lepconfig.HighFrequencyMetrics) {
+	inst := nvidia_query_nvml.DefaultInstance()
+	if inst == nil {
+		log.Logger.Warnw("nvml instance not found, skipping high frequency metrics")
+		return
+	}
+
+	// only the device handles are needed, which do not change until the restart
+	out, err := inst.Get()
+	if err != nil || out == nil || len(out.DeviceInfos) == 0 {
+		log.Logger.Warnw("failed to get nvml devices, skipping high frequency metrics", "error", err)
+		return
+	}
+	devs := out.DeviceInfos
+
+	w, err := shmring.Create(cfg.Path, cfg.Capacity, cfg.Interval.Duration)
+	if err != nil {
+		log.Logger.Warnw("failed to create high frequency metrics ring buffer", "path", cfg.Path, "error", err)
+		return
+	}
+	defer func() {
+		if err := w.Close(); err != nil {
+			log.Logger.Warnw("failed to close high frequency metrics ring buffer", "error", err)
+		}
+		// readers see no stale samples after gpud exits
+		if err := os.Remove(cfg.Path); err != nil && !os.IsNotExist(err) {
+			log.Logger.Warnw("failed to remove high frequency metrics ring buffer", "path", cfg.Path, "error", err)
+		}
+	}()
+	log.Logger.Infow("exporting high frequency metrics", "path", cfg.Path, "interval", cfg.Interval.Duration, "capacity", cfg.Capacity, "devices", len(devs))
+
+	ticker := time.NewTicker(cfg.Interval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now().UTC()
+		for _, dev := range devs {
+			sample, err := nvidia_query_nvml.SampleHighFrequency(dev, now)
+			if err != nil {
+				log.Logger.Debugw("failed to sample high frequency metrics", "uuid", dev.UUID, "error", err)
+				continue
+			}
+			w.Append(sample)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	lepconfig "github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/lkg"
+	"github.com/leptonai/gpud/version"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shirou/gopsutil/v4/host"
+	"sigs.k8s.io/yaml"
+)
+
+// lastKnownGoodInterval is the interval to check the overall node health for the last-known-good snapshot.
+const lastKnownGoodInterval = time.Minute
+
+// collectLastKnownGoodValues returns the function that collects
+// the driver/firmware/config state for the last-known-good snapshot.
+func collectLastKnownGoodValues(config *lepconfig.Config, nvidiaExists bool) lkg.CollectFunc {
+	return func(ctx context.Context) (map[string]string, error) {
+		values := map[string]string{
+			"gpud_version": version.Version,
+		}
+
+		kernelVer, err := host.KernelVersionWithContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values["kernel_version"] = kernelVer
+
+		b, err := json.Marshal(config.Components)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(b)
+		values["components_config_sha256"] = hex.EncodeToString(sum[:])
+
+		if !nvidiaExists {
+			return values, nil
+		}
+
+		driverVer, err := nvidia_query_nvml.GetDriverVersion()
+		if err != nil {
+			return nil, err
+		}
+		values["driver_version"] = driverVer
+
+		cudaVer, err := nvidia_query_nvml.GetCUDADriverVersion()
+		if err != nil {
+			return nil, err
+		}
+		values["cuda_driver_version"] = cudaVer
+
+		if inst := nvidia_query_nvml.DefaultInstance(); inst != nil {
+			out, err := inst.Get()
+			if err != nil {
+				return nil, err
+			}
+			for _, dev := range out.DeviceInfos {
+				if !dev.GSPFirmwareMode.Supported {
+					continue
+				}
+				values["gsp_firmware_enabled/"+dev.UUID] = boolString(dev.GSPFirmwareMode.Enabled)
+			}
+		}
+		return values, nil
+	}
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// trackLastKnownGood periodically observes whether all the components are healthy,
+// so the tracker takes the snapshot once the node has been healthy for the period.
+func trackLastKnownGood(ctx context.Context, tracker *lkg.Tracker, comps []components.Component) {
+	ticker := time.NewTicker(lastKnownGoodInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		healthy := true
+		for _, c := range comps {
+			states, err := c.States(ctx)
+			if err != nil {
+				log.Logger.Debugw("failed to get states for last-known-good", "component", c.Name(), "error", err)
+				healthy = false
+				break
+			}
+			for _, s := range states {
+				if !s.Healthy {
+					healthy = false
+					break
+				}
+			}
+			if !healthy {
+				break
+			}
+		}
+
+		if err := tracker.Observe(ctx, healthy, time.Now().UTC()); err != nil {
+			log.Logger.Warnw("failed to take last-known-good snapshot", "error", err)
+		}
+	}
+}
+
+const (
+	URLPathLastKnownGood     = "/last-known-good"
+	URLPathLastKnownGoodDesc = "Get the last-known-good snapshot and the changes since"
+)
+
+// LastKnownGood is the last-known-good snapshot and the changes since.
+type LastKnownGood struct {
+	Snapshot *lkg.Snapshot `json:"snapshot,omitempty"`
+	Delta    []lkg.Change  `json:"delta,omitempty"`
+}
+
+// getLastKnownGood godoc
+// @Summary Fetch the last-known-good snapshot
+// @Description get the driver/firmware/config snapshot taken when the node was last fully healthy for the configured period, and the changes since
+// @ID getLastKnownGood
+// @Produce  json
+// @Success 200 {object} LastKnownGood
+// @Router /v1/last-known-good [get]
+func createLastKnownGoodHandler(tracker *lkg.Tracker) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		delta, err := tracker.Delta(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to get last-known-good delta " + err.Error()})
+			return
+		}
+		resp := LastKnownGood{
+			Snapshot: tracker.LastKnownGood(),
+			Delta:    delta,
+		}
+
+		switch c.GetHeader(RequestHeaderContentType) {
+		case RequestHeaderYAML:
+			yb, err := yaml.Marshal(resp)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal last-known-good " + err.Error()})
+				return
+			}
+			c.String(http.StatusOK, string(yb))
+
+		default:
+			if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+				c.IndentedJSON(http.StatusOK, resp)
+				return
+			}
+			c.JSON(http.StatusOK, resp)
+		}
+	}
+}
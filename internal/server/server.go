@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	goOS "os"
@@ -30,10 +31,21 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	"github.com/leptonai/gpud/components"
+	amd_ecc "github.com/leptonai/gpud/components/accelerator/amd/ecc"
+	amd_info "github.com/leptonai/gpud/components/accelerator/amd/info"
+	amd_temperature "github.com/leptonai/gpud/components/accelerator/amd/temperature"
+	amd_xgmi "github.com/leptonai/gpud/components/accelerator/amd/xgmi"
+	neuron_health "github.com/leptonai/gpud/components/accelerator/neuron/health"
 	nvidia_badenvs "github.com/leptonai/gpud/components/accelerator/nvidia/bad-envs"
 	nvidia_badenvs_id "github.com/leptonai/gpud/components/accelerator/nvidia/bad-envs/id"
 	nvidia_clock "github.com/leptonai/gpud/components/accelerator/nvidia/clock"
 	nvidia_clockspeed "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed"
+	nvidia_compatibility "github.com/leptonai/gpud/components/accelerator/nvidia/compatibility"
+	nvidia_confidential_compute "github.com/leptonai/gpud/components/accelerator/nvidia/confidential-compute"
+	nvidia_consistency "github.com/leptonai/gpud/components/accelerator/nvidia/consistency"
+	nvidia_cuda_canary "github.com/leptonai/gpud/components/accelerator/nvidia/cuda-canary"
+	nvidia_dcgm_diag "github.com/leptonai/gpud/components/accelerator/nvidia/dcgm-diag"
+	nvidia_driver_maintenance "github.com/leptonai/gpud/components/accelerator/nvidia/driver-maintenance"
 	nvidia_ecc "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
 	nvidia_error "github.com/leptonai/gpud/components/accelerator/nvidia/error"
 	nvidia_component_error_xid_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/error-xid-sxid"
@@ -44,15 +56,22 @@ import (
 	nvidia_component_error_xid_id "github.com/leptonai/gpud/components/accelerator/nvidia/error/xid/id"
 	nvidia_fabric_manager "github.com/leptonai/gpud/components/accelerator/nvidia/fabric-manager"
 	nvidia_gpm "github.com/leptonai/gpud/components/accelerator/nvidia/gpm"
+	nvidia_gpu_count "github.com/leptonai/gpud/components/accelerator/nvidia/gpu-count"
+	nvidia_gpu_order "github.com/leptonai/gpud/components/accelerator/nvidia/gpu-order"
 	nvidia_gsp_firmware_mode "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode"
 	nvidia_gsp_firmware_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode/id"
 	nvidia_infiniband "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband"
 	nvidia_infiniband_id "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband/id"
 	nvidia_info "github.com/leptonai/gpud/components/accelerator/nvidia/info"
+	nvidia_inforom "github.com/leptonai/gpud/components/accelerator/nvidia/inforom"
+	nvidia_kernel_module "github.com/leptonai/gpud/components/accelerator/nvidia/kernel-module"
+	nvidia_license "github.com/leptonai/gpud/components/accelerator/nvidia/license"
 	nvidia_memory "github.com/leptonai/gpud/components/accelerator/nvidia/memory"
+	nvidia_mig "github.com/leptonai/gpud/components/accelerator/nvidia/mig"
 	nvidia_nccl "github.com/leptonai/gpud/components/accelerator/nvidia/nccl"
 	nvidia_nccl_id "github.com/leptonai/gpud/components/accelerator/nvidia/nccl/id"
 	nvidia_nvlink "github.com/leptonai/gpud/components/accelerator/nvidia/nvlink"
+	nvidia_pcie "github.com/leptonai/gpud/components/accelerator/nvidia/pcie"
 	nvidia_peermem "github.com/leptonai/gpud/components/accelerator/nvidia/peermem"
 	nvidia_peermem_id "github.com/leptonai/gpud/components/accelerator/nvidia/peermem/id"
 	nvidia_persistence_mode "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode"
@@ -60,13 +79,27 @@ import (
 	nvidia_power "github.com/leptonai/gpud/components/accelerator/nvidia/power"
 	nvidia_processes "github.com/leptonai/gpud/components/accelerator/nvidia/processes"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	components_nvidia_cooling_trend "github.com/leptonai/gpud/components/accelerator/nvidia/query/cooling-trend"
+	components_nvidia_counter_reset_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/counter-reset-state"
+	components_nvidia_driver_baseline "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-baseline"
+	components_nvidia_driver_upgrade "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-upgrade"
+	components_nvidia_ecc_addresses "github.com/leptonai/gpud/components/accelerator/nvidia/query/ecc-addresses"
+	components_nvidia_gpu_ledger "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-ledger"
+	components_nvidia_gpu_processes "github.com/leptonai/gpud/components/accelerator/nvidia/query/gpu-processes"
+	components_nvidia_memory_scrub "github.com/leptonai/gpud/components/accelerator/nvidia/query/memory-scrub"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	components_nvidia_pcie_aer "github.com/leptonai/gpud/components/accelerator/nvidia/query/pcie-aer"
+	components_nvidia_pstate_history "github.com/leptonai/gpud/components/accelerator/nvidia/query/pstate-history"
 	nvidia_query_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/query/sxid"
+	components_nvidia_threshold_breach_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/threshold-breach-state"
 	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	nvidia_query_xid_sxid_details "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-details"
 	components_nvidia_xid_sxid_state "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid-sxid-state"
 	nvidia_remapped_rows "github.com/leptonai/gpud/components/accelerator/nvidia/remapped-rows"
 	nvidia_temperature "github.com/leptonai/gpud/components/accelerator/nvidia/temperature"
+	nvidia_topology "github.com/leptonai/gpud/components/accelerator/nvidia/topology"
 	nvidia_utilization "github.com/leptonai/gpud/components/accelerator/nvidia/utilization"
+	"github.com/leptonai/gpud/components/capability"
 	containerd_pod "github.com/leptonai/gpud/components/containerd/pod"
 	"github.com/leptonai/gpud/components/cpu"
 	"github.com/leptonai/gpud/components/disk"
@@ -84,23 +117,38 @@ import (
 	"github.com/leptonai/gpud/components/metrics"
 	components_metrics_state "github.com/leptonai/gpud/components/metrics/state"
 	network_latency "github.com/leptonai/gpud/components/network/latency"
+	network_roce "github.com/leptonai/gpud/components/network/roce"
 	"github.com/leptonai/gpud/components/os"
+	pci_acs "github.com/leptonai/gpud/components/pci/acs"
+	pci_iommu "github.com/leptonai/gpud/components/pci/iommu"
 	power_supply "github.com/leptonai/gpud/components/power-supply"
 	query_config "github.com/leptonai/gpud/components/query/config"
 	query_log_common "github.com/leptonai/gpud/components/query/log/common"
 	query_log_config "github.com/leptonai/gpud/components/query/log/config"
 	query_log_state "github.com/leptonai/gpud/components/query/log/state"
+	scheduled_jobs "github.com/leptonai/gpud/components/scheduled-jobs"
 	"github.com/leptonai/gpud/components/state"
 	component_systemd "github.com/leptonai/gpud/components/systemd"
 	"github.com/leptonai/gpud/components/tailscale"
+	"github.com/leptonai/gpud/components/udev"
 	gpud_config "github.com/leptonai/gpud/config"
 	lepconfig "github.com/leptonai/gpud/config"
 	_ "github.com/leptonai/gpud/docs/apis"
 	"github.com/leptonai/gpud/internal/login"
+	"github.com/leptonai/gpud/internal/notify"
 	"github.com/leptonai/gpud/internal/session"
+	"github.com/leptonai/gpud/internal/upgrade"
+	"github.com/leptonai/gpud/internal/validate"
 	"github.com/leptonai/gpud/log"
 	"github.com/leptonai/gpud/manager"
+	"github.com/leptonai/gpud/pkg/execlimit"
+	"github.com/leptonai/gpud/pkg/lifecycle"
+	"github.com/leptonai/gpud/pkg/lkg"
+	"github.com/leptonai/gpud/pkg/lowoverhead"
+	"github.com/leptonai/gpud/pkg/netutil"
+	pkg_nvml "github.com/leptonai/gpud/pkg/nvml"
 	"github.com/leptonai/gpud/pkg/sqlite"
+	"github.com/leptonai/gpud/pkg/warmstate"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -115,6 +163,8 @@ type Server struct {
 	session               *session.Session
 	enableAutoUpdate      bool
 	autoUpdateExitCode    int
+	addressFamily         netutil.AddressFamily
+	proxyConfig           *netutil.ProxyConfig
 }
 
 func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID string, packageManager *manager.Manager, opts ...gpud_config.OpOption) (_ *Server, retErr error) {
@@ -127,6 +177,23 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		return nil, fmt.Errorf("failed to validate config: %w", err)
 	}
 
+	// the default HTTP client is used by the login, gossip, and notifications
+	netutil.SetDefaultTransport(config.AddressFamily, &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	})
+	if err := netutil.SetDefaultTransportProxy(config.Proxy); err != nil {
+		return nil, fmt.Errorf("failed to set proxy: %w", err)
+	}
+
+	// loaded before the dmesg watchers start, to classify the xids/sxids with the updated details
+	if config.XidSXidDetailsFile != "" {
+		if err := nvidia_query_xid_sxid_details.Load(config.XidSXidDetailsFile); err != nil {
+			return nil, fmt.Errorf("failed to load xid/sxid details file: %w", err)
+		}
+		go nvidia_query_xid_sxid_details.Watch(ctx, config.XidSXidDetailsFile, nvidia_query_xid_sxid_details.DefaultReloadInterval)
+	}
+
 	stateFile := ":memory:"
 	if config.State != "" {
 		stateFile = config.State
@@ -144,6 +211,8 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		fifoPath:           fifoPath,
 		enableAutoUpdate:   config.EnableAutoUpdate,
 		autoUpdateExitCode: config.AutoUpdateExitCode,
+		addressFamily:      config.AddressFamily,
+		proxyConfig:        config.Proxy,
 	}
 	defer func() {
 		if retErr != nil {
@@ -166,10 +235,52 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		return nil, fmt.Errorf("api version mismatch: %s (only supports v1)", ver)
 	}
 
+	if config.StatesHistoryRetentionPeriod.Duration > 0 {
+		if err := state.CreateTableStatesHistory(ctx, db); err != nil {
+			return nil, fmt.Errorf("failed to create states history table: %w", err)
+		}
+	}
+
+	if err := state.CreateTableEventAcks(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create event acks table: %w", err)
+	}
+	if err := state.CreateTableAnnotations(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create annotations table: %w", err)
+	}
+	if err := state.CreateTableGPUAnnotations(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create gpu annotations table: %w", err)
+	}
+	if err := lifecycle.CreateTable(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create lifecycle table: %w", err)
+	}
+	if err := lifecycle.Load(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to load lifecycle state: %w", err)
+	}
+
 	if err := query_log_state.CreateTableLogFileSeekInfo(ctx, db); err != nil {
 		return nil, fmt.Errorf("failed to create query log state table: %w", err)
 	}
 
+	// warm state persists the in-memory states (e.g., flap counters, dedup windows) across restarts
+	if err := warmstate.CreateTable(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create warm state table: %w", err)
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Hour):
+				purged, err := warmstate.Purge(ctx, db, time.Now().Add(-warmstate.DefaultMaxAge))
+				if err != nil {
+					log.Logger.Warnw("failed to purge warm states", "error", err)
+				} else {
+					log.Logger.Debugw("purged warm states", "purged", purged)
+				}
+			}
+		}
+	}()
+
 	if err := components_metrics_state.CreateTableMetrics(ctx, db, components_metrics_state.DefaultTableName); err != nil {
 		return nil, fmt.Errorf("failed to create metrics table: %w", err)
 	}
@@ -196,6 +307,33 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 	if err := components_nvidia_xid_sxid_state.CreateTableXidSXidEventHistory(ctx, db); err != nil {
 		return nil, fmt.Errorf("failed to create nvidia xid/sxid state table: %w", err)
 	}
+	if err := components_nvidia_gpu_processes.CreateTableGPUProcessHistory(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia gpu process history table: %w", err)
+	}
+	// ecc error addresses are kept across reboots to detect the errors in the known bad regions, thus not purged
+	if err := components_nvidia_ecc_addresses.CreateTables(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia ecc error addresses tables: %w", err)
+	}
+	if err := components_nvidia_threshold_breach_state.CreateTableThresholdBreachHistory(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia threshold breach history table: %w", err)
+	}
+	if err := components_nvidia_pcie_aer.CreateTablePCIeAERErrors(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia pcie aer errors table: %w", err)
+	}
+	if err := components_nvidia_pstate_history.CreateTablePStateHistory(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia pstate history table: %w", err)
+	}
+	if err := components_nvidia_memory_scrub.CreateTableMemoryScrubs(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia memory scrubs table: %w", err)
+	}
+	// cooling trend buckets are purged by the tracker with its own (weeks long) window
+	if err := components_nvidia_cooling_trend.CreateTables(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia cooling trend tables: %w", err)
+	}
+	// driver upgrade verdicts are rare and kept as the upgrade history, thus not purged
+	if err := components_nvidia_driver_upgrade.CreateTableDriverUpgradeVerdicts(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia driver upgrade verdicts table: %w", err)
+	}
 	go func() {
 		dur := config.RetentionPeriod.Duration
 		for {
@@ -212,10 +350,62 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 				} else {
 					log.Logger.Debugw("deleted nvidia xid/sxid events", "before", before, "purged", purged)
 				}
+
+				purged, err = components_nvidia_gpu_processes.Purge(ctx, db, before)
+				if err != nil {
+					log.Logger.Warnw("failed to delete nvidia gpu process history", "error", err)
+				} else {
+					log.Logger.Debugw("deleted nvidia gpu process history", "before", before, "purged", purged)
+				}
+
+				purged, err = components_nvidia_threshold_breach_state.Purge(ctx, db, before)
+				if err != nil {
+					log.Logger.Warnw("failed to delete nvidia threshold breach history", "error", err)
+				} else {
+					log.Logger.Debugw("deleted nvidia threshold breach history", "before", before, "purged", purged)
+				}
+
+				purged, err = components_nvidia_pcie_aer.Purge(ctx, db, before)
+				if err != nil {
+					log.Logger.Warnw("failed to delete nvidia pcie aer errors", "error", err)
+				} else {
+					log.Logger.Debugw("deleted nvidia pcie aer errors", "before", before, "purged", purged)
+				}
+
+				purged, err = components_nvidia_pstate_history.Purge(ctx, db, before)
+				if err != nil {
+					log.Logger.Warnw("failed to delete nvidia pstate history", "error", err)
+				} else {
+					log.Logger.Debugw("deleted nvidia pstate history", "before", before, "purged", purged)
+				}
+
+				purged, err = components_nvidia_memory_scrub.Purge(ctx, db, before)
+				if err != nil {
+					log.Logger.Warnw("failed to delete nvidia memory scrubs", "error", err)
+				} else {
+					log.Logger.Debugw("deleted nvidia memory scrubs", "before", before, "purged", purged)
+				}
 			}
 		}
 	}()
 
+	// counter reset history is an audit trail, thus not purged with the retention period
+	if err := components_nvidia_counter_reset_state.CreateTableCounterResetHistory(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia counter reset state table: %w", err)
+	}
+	// gpu ledger records the per-gpu test verdicts, thus not purged either
+	if err := components_nvidia_gpu_ledger.CreateTableGPULedger(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia gpu ledger table: %w", err)
+	}
+	// driver baseline tells the driver crashed from the driver never installed, thus not purged either
+	if err := components_nvidia_driver_baseline.CreateTableBaseline(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create nvidia driver baseline table: %w", err)
+	}
+
+	// merges the repeated xid/sxid lines into one event with the occurrence counter,
+	// so that a GPU spewing thousands of identical lines does not flood the event store
+	xidSXidDeduper := components_nvidia_xid_sxid_state.NewDeduper(db, components_nvidia_xid_sxid_state.DefaultDedupWindow, components_nvidia_xid_sxid_state.DefaultMaxDistinctEventsPerWindow)
+
 	dmesgProcessMatched := func(ts time.Time, line []byte, matchedFilter *query_log_common.Filter) {
 		if ts.IsZero() {
 			return
@@ -251,18 +441,19 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 					EventDetails: ev.LogItem.Line,
 				}
 
-				found, err := components_nvidia_xid_sxid_state.FindEvent(cctx, db, eventToInsert)
+				inserted, err := xidSXidDeduper.Record(cctx, eventToInsert)
 				if err != nil {
-					log.Logger.Errorw("failed to find xid event in database", "error", err)
+					log.Logger.Errorw("failed to record xid event into database", "error", err)
 					continue
 				}
-				if found {
-					log.Logger.Debugw("xid event already exists in database", "event", eventToInsert)
+				if !inserted {
+					log.Logger.Debugw("xid event already recorded or merged into the existing event", "event", eventToInsert)
 					continue
 				}
-				if werr := components_nvidia_xid_sxid_state.InsertEvent(cctx, db, eventToInsert); werr != nil {
-					log.Logger.Errorw("failed to insert xid event into database", "error", werr)
-					continue
+
+				// persist the remapped row addresses to detect the new errors in the same regions across reboots
+				if addr, ok := nvidia_query_xid.ExtractNVRMXidRowRemapperAddress(ev.LogItem.Line); ok {
+					recordRowRemapperAddress(cctx, db, ev.LogItem.Line, addr, ts)
 				}
 
 			case nvidia_component_error_sxid_id.Name:
@@ -284,23 +475,45 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 					EventDetails: ev.LogItem.Line,
 				}
 
-				found, err := components_nvidia_xid_sxid_state.FindEvent(cctx, db, eventToInsert)
+				inserted, err := xidSXidDeduper.Record(cctx, eventToInsert)
 				if err != nil {
-					log.Logger.Errorw("failed to find sxid event in database", "error", err)
+					log.Logger.Errorw("failed to record sxid event into database", "error", err)
 					continue
 				}
-				if found {
-					log.Logger.Debugw("sxid event already exists in database", "event", eventToInsert)
+				if !inserted {
+					log.Logger.Debugw("sxid event already recorded or merged into the existing event", "event", eventToInsert)
 					continue
 				}
-				if werr := components_nvidia_xid_sxid_state.InsertEvent(cctx, db, eventToInsert); werr != nil {
-					log.Logger.Errorw("failed to insert sxid event into database", "error", werr)
+
+			case nvidia_component_error_xid_sxid_id.Name:
+				aerErr, ok := components_nvidia_pcie_aer.ParseDmesgLine(ts, string(line))
+				if !ok {
+					log.Logger.Debugw("failed to parse pcie aer dmesg line", "line", string(line))
+					continue
+				}
+				if _, werr := components_nvidia_pcie_aer.InsertError(cctx, db, aerErr); werr != nil {
+					log.Logger.Errorw("failed to insert pcie aer error into database", "error", werr)
 					continue
 				}
 			}
 		}
 	}
 
+	if config.ExecRateLimit != nil {
+		execCfg := *config.ExecRateLimit
+		execCfg.SetDefaultsIfNotSet()
+		execlimit.Configure(execCfg)
+		log.Logger.Infow("configured command execution rate limits", "globalPerSecond", execCfg.GlobalPerSecond, "keyPerSecond", execCfg.KeyPerSecond)
+	}
+
+	if config.LowOverhead != nil {
+		lowCfg := *config.LowOverhead
+		lowCfg.SetDefaultsIfNotSet()
+		lowoverhead.Enable(lowCfg)
+		go lowoverhead.Govern(ctx, lowCfg, lowoverhead.SelfCPUTime)
+		log.Logger.Infow("low-overhead mode enabled", "minPollInterval", lowCfg.MinPollInterval.Duration, "cpuBudgetPercent", lowCfg.CPUBudgetPercent)
+	}
+
 	defaultStateCfg := query_config.State{DB: db}
 	defaultQueryCfg := query_config.Config{State: &defaultStateCfg}
 	defaultLogCfg := query_log_config.Config{
@@ -316,12 +529,24 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		return nil, fmt.Errorf("dependency check failed: %w", err)
 	}
 
+	// probe the host capabilities once at startup, and do not start the components
+	// that cannot possibly work (reported by the capability component instead)
+	capabilityReport := checkCapabilities(config)
+	for _, r := range capabilityReport.Incapable() {
+		log.Logger.Warnw("component not started due to missing capabilities", "component", r.Component, "missing", r.Missing)
+	}
+
 	allComponents := make([]components.Component, 0)
 	if _, ok := config.Components[os.Name]; !ok {
 		allComponents = append(allComponents, os.New(ctx, os.Config{Query: defaultQueryCfg}))
 	}
+	allComponents = append(allComponents, capability.New(capabilityReport))
 
 	for k, configValue := range config.Components {
+		if !capabilityReport.Capable(k) {
+			continue
+		}
+
 		switch k {
 		case cpu.Name:
 			cfg := cpu.Config{Query: defaultQueryCfg}
@@ -436,6 +661,9 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			}
 			allComponents = append(allComponents, c)
 
+			// e.g., errors before gpud was installed or while gpud was down
+			go backfillDmesg(ctx, db, c.(*dmesg.Component), config.RetentionPeriod.Duration)
+
 		case fd.Name:
 			cfg := fd.Config{Query: defaultQueryCfg, ThresholdLimit: fd.DefaultThresholdLimit}
 			if configValue != nil {
@@ -521,6 +749,28 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			}
 			allComponents = append(allComponents, power_supply.New(ctx, cfg))
 
+		case scheduled_jobs.Name:
+			if config.LowOverhead != nil {
+				log.Logger.Infow("low-overhead mode -- skipping active probe component", "component", k)
+				continue
+			}
+			cfg := scheduled_jobs.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := scheduled_jobs.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			c, err := scheduled_jobs.New(ctx, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
+			}
+			allComponents = append(allComponents, c)
+
 		case component_systemd.Name:
 			cfg := component_systemd.Config{Query: defaultQueryCfg}
 			if configValue != nil {
@@ -539,6 +789,24 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			}
 			allComponents = append(allComponents, c)
 
+		case udev.Name:
+			cfg := udev.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := udev.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			c, err := udev.New(ctx, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
+			}
+			allComponents = append(allComponents, c)
+
 		case tailscale.Name:
 			cfg := tailscale.Config{Query: defaultQueryCfg}
 			if configValue != nil {
@@ -553,6 +821,76 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			}
 			allComponents = append(allComponents, tailscale.New(ctx, cfg))
 
+		case amd_info.Name:
+			cfg := amd_info.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := amd_info.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, amd_info.New(ctx, cfg))
+
+		case amd_temperature.Name:
+			cfg := amd_temperature.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := amd_temperature.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, amd_temperature.New(ctx, cfg))
+
+		case amd_ecc.Name:
+			cfg := amd_ecc.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := amd_ecc.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, amd_ecc.New(ctx, cfg))
+
+		case amd_xgmi.Name:
+			cfg := amd_xgmi.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := amd_xgmi.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, amd_xgmi.New(ctx, cfg))
+
+		case neuron_health.Name:
+			cfg := neuron_health.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := neuron_health.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, neuron_health.New(ctx, cfg))
+
 		case nvidia_info.Name:
 			cfg := nvidia_info.Config{Query: defaultQueryCfg}
 			if configValue != nil {
@@ -567,6 +905,38 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			}
 			allComponents = append(allComponents, nvidia_info.New(ctx, cfg))
 
+		case nvidia_consistency.Name:
+			cfg := nvidia_consistency.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_consistency.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_consistency.New(ctx, cfg))
+
+		case nvidia_cuda_canary.Name:
+			if config.LowOverhead != nil {
+				log.Logger.Infow("low-overhead mode -- skipping active probe component", "component", k)
+				continue
+			}
+			cfg := nvidia_cuda_canary.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_cuda_canary.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_cuda_canary.New(ctx, cfg))
+
 		case nvidia_badenvs_id.Name:
 			cfg := nvidia_badenvs.Config{Query: defaultQueryCfg}
 			if configValue != nil {
@@ -656,6 +1026,70 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			}
 			allComponents = append(allComponents, nvidia_clockspeed.New(ctx, cfg))
 
+		case nvidia_confidential_compute.Name:
+			cfg := nvidia_confidential_compute.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_confidential_compute.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_confidential_compute.New(ctx, cfg))
+
+		case nvidia_dcgm_diag.Name:
+			if config.LowOverhead != nil {
+				log.Logger.Infow("low-overhead mode -- skipping active probe component", "component", k)
+				continue
+			}
+			cfg := nvidia_dcgm_diag.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_dcgm_diag.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			c, err := nvidia_dcgm_diag.New(ctx, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
+			}
+			allComponents = append(allComponents, c)
+
+		case nvidia_driver_maintenance.Name:
+			cfg := nvidia_driver_maintenance.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_driver_maintenance.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_driver_maintenance.New(ctx, cfg))
+
+		case nvidia_compatibility.Name:
+			cfg := nvidia_compatibility.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_compatibility.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_compatibility.New(ctx, cfg))
+
 		case nvidia_ecc.Name:
 			cfg := nvidia_ecc.Config{Query: defaultQueryCfg}
 			if configValue != nil {
@@ -668,8 +1102,54 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			if err := cfg.Validate(); err != nil {
 				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
 			}
+			if config.LowOverhead != nil && cfg.Scrub != nil {
+				log.Logger.Infow("low-overhead mode -- disabling memory scrub", "component", k)
+				cfg.Scrub = nil
+			}
 			allComponents = append(allComponents, nvidia_ecc.New(ctx, cfg))
 
+		case nvidia_inforom.Name:
+			cfg := nvidia_inforom.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_inforom.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_inforom.New(ctx, cfg))
+
+		case nvidia_kernel_module.Name:
+			cfg := nvidia_kernel_module.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_kernel_module.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_kernel_module.New(ctx, cfg))
+
+		case nvidia_license.Name:
+			cfg := nvidia_license.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_license.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_license.New(ctx, cfg))
+
 		case nvidia_memory.Name:
 			cfg := nvidia_memory.Config{Query: defaultQueryCfg}
 			if configValue != nil {
@@ -698,6 +1178,48 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			}
 			allComponents = append(allComponents, nvidia_gpm.New(ctx, cfg))
 
+		case nvidia_gpu_count.Name:
+			cfg := nvidia_gpu_count.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_gpu_count.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_gpu_count.New(ctx, cfg))
+
+		case nvidia_gpu_order.Name:
+			cfg := nvidia_gpu_order.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_gpu_order.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_gpu_order.New(ctx, cfg))
+
+		case nvidia_mig.Name:
+			cfg := nvidia_mig.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_mig.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_mig.New(ctx, cfg))
+
 		case nvidia_nvlink.Name:
 			cfg := nvidia_nvlink.Config{Query: defaultQueryCfg}
 			if configValue != nil {
@@ -712,6 +1234,20 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			}
 			allComponents = append(allComponents, nvidia_nvlink.New(ctx, cfg))
 
+		case nvidia_pcie.Name:
+			cfg := nvidia_pcie.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_pcie.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_pcie.New(ctx, cfg))
+
 		case nvidia_power.Name:
 			cfg := nvidia_power.Config{Query: defaultQueryCfg}
 			if configValue != nil {
@@ -740,6 +1276,20 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			}
 			allComponents = append(allComponents, nvidia_temperature.New(ctx, cfg))
 
+		case nvidia_topology.Name:
+			cfg := nvidia_topology.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := nvidia_topology.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, nvidia_topology.New(ctx, cfg))
+
 		case nvidia_utilization.Name:
 			cfg := nvidia_utilization.Config{Query: defaultQueryCfg}
 			if configValue != nil {
@@ -931,6 +1481,49 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			}
 			allComponents = append(allComponents, network_latency.New(ctx, cfg))
 
+		case network_roce.Name:
+			cfg := network_roce.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := network_roce.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, network_roce.New(ctx, cfg))
+
+		case pci_acs.Name:
+			cfg := pci_acs.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := pci_acs.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, pci_acs.New(ctx, cfg))
+
+		case pci_iommu.Name:
+			cfg := pci_iommu.Config{Query: defaultQueryCfg}
+			if configValue != nil {
+				parsed, err := pci_iommu.ParseConfig(configValue, db)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
+				}
+				cfg = *parsed
+			}
+			cfg.SetDefaultsIfNotSet()
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
+			}
+			allComponents = append(allComponents, pci_iommu.New(ctx, cfg))
+
 		default:
 			return nil, fmt.Errorf("unknown component %s", k)
 		}
@@ -944,6 +1537,9 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 	if err := state.Register(promReg); err != nil {
 		return nil, fmt.Errorf("failed to register state metrics: %w", err)
 	}
+	if err := lifecycle.Register(promReg); err != nil {
+		return nil, fmt.Errorf("failed to register lifecycle metrics: %w", err)
+	}
 	go func() {
 		ticker := time.NewTicker(time.Minute) // only first run is 1-minute wait
 		defer ticker.Stop()
@@ -1017,6 +1613,8 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		}()
 	}
 
+	components.SetValidateStates(config.ValidateStates)
+	nvidia_query.SetGPUExpected(config.GPUExpected)
 	for i := range allComponents {
 		metrics.SetRegistered(allComponents[i].Name())
 		allComponents[i] = metrics.NewWatchableComponent(allComponents[i])
@@ -1051,6 +1649,19 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		}
 	}
 
+	if config.StatesHistoryRetentionPeriod.Duration > 0 {
+		go recordStatesHistory(ctx, db, allComponents, config.StatesHistoryRetentionPeriod.Duration)
+	}
+
+	var lastKnownGood *lkg.Tracker
+	if config.LastKnownGoodPeriod.Duration > 0 {
+		lastKnownGood, err = lkg.NewTracker(ctx, db, config.LastKnownGoodPeriod.Duration, collectLastKnownGoodValues(config, s.nvidiaComponentsExist))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create last-known-good tracker: %w", err)
+		}
+		go trackLastKnownGood(ctx, lastKnownGood, allComponents)
+	}
+
 	// to not start healthz until the initial gpu data is ready
 	if s.nvidiaComponentsExist {
 		log.Logger.Debugw("waiting for nvml instance to be ready")
@@ -1070,6 +1681,42 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		}
 	}
 
+	var validator *validate.Runner
+	if config.PostRepairValidation != nil || s.nvidiaComponentsExist {
+		vcfg := validate.Config{}
+		if config.PostRepairValidation != nil {
+			vcfg = *config.PostRepairValidation
+		}
+		vcfg.SetDefaultsIfNotSet()
+		validator = validate.NewRunner(ctx, vcfg, db)
+
+		// e.g., rebooted during the repair
+		if lifecycle.Current().State == lifecycle.StateRepairing {
+			log.Logger.Infow("started in repairing state -- running post-repair validation")
+			if err := validator.Start(); err != nil {
+				log.Logger.Warnw("failed to start post-repair validation", "error", err)
+			}
+		}
+	}
+
+	if config.DriverUpgradeCanary != nil {
+		if s.nvidiaComponentsExist {
+			ucfg := *config.DriverUpgradeCanary
+			ucfg.SetDefaultsIfNotSet()
+			upgrade.New(ucfg, db).Start(ctx)
+		} else {
+			log.Logger.Warnw("driver upgrade canary enabled but no nvidia gpu found, skipping")
+		}
+	}
+
+	if config.HighFrequencyMetrics != nil {
+		if s.nvidiaComponentsExist {
+			go exportHighFrequencyMetrics(ctx, config.HighFrequencyMetrics)
+		} else {
+			log.Logger.Warnw("high frequency metrics enabled but no nvidia gpu found, skipping")
+		}
+	}
+
 	uid, _, err := state.CreateMachineIDIfNotExist(ctx, db, cliUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create machine uid: %w", err)
@@ -1079,6 +1726,29 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		return nil, fmt.Errorf("failed to update components: %w", err)
 	}
 
+	if config.Notify != nil {
+		node := notify.Node{
+			MachineID: uid,
+			Labels:    config.Annotations,
+		}
+		if s.nvidiaComponentsExist {
+			featureMatrix, err := nvidia_query_nvml.GetFeatureMatrix()
+			if err != nil {
+				log.Logger.Warnw("failed to get gpu model for notifications", "error", err)
+			} else if len(featureMatrix.GPUs) > 0 {
+				node.GPUModel = featureMatrix.GPUs[0].Name
+			}
+		}
+		dispatcher, err := notify.NewDispatcher(*config.Notify, node, db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create notification dispatcher: %w", err)
+		}
+		if lastKnownGood != nil {
+			dispatcher.SetLastKnownGood(lastKnownGood)
+		}
+		go dispatcher.Start(ctx, allComponents)
+	}
+
 	// TODO: implement configuration file refresh + apply
 
 	router := gin.Default()
@@ -1098,8 +1768,81 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 	// the middleware automatically gzip-compresses the response with the response header "Content-Encoding: gzip"
 	v1.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{"/update/"})))
 
-	ghler := newGlobalHandler(config, components.GetAllComponents())
+	ghler := newGlobalHandler(config, components.GetAllComponents(), db)
 	registeredPaths := ghler.registerComponentRoutes(v1)
+	v1.GET(URLPathAnnotations, ghler.getAnnotations)
+	v1.POST(URLPathAnnotations, ghler.postAnnotations)
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: URLPathAnnotations,
+		Desc: URLPathAnnotationsDesc,
+	})
+	v1.GET(URLPathGPUAnnotations, ghler.getGPUAnnotations)
+	v1.POST(URLPathGPUAnnotations, ghler.postGPUAnnotations)
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: URLPathGPUAnnotations,
+		Desc: URLPathGPUAnnotationsDesc,
+	})
+	v1.GET(URLPathLifecycle, ghler.getLifecycle)
+	v1.POST(URLPathLifecycle, ghler.postLifecycle)
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: URLPathLifecycle,
+		Desc: URLPathLifecycleDesc,
+	})
+	v1.GET(URLPathFailureAttribution, ghler.getFailureAttribution)
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: URLPathFailureAttribution,
+		Desc: URLPathFailureAttributionDesc,
+	})
+	v1.GET(URLPathPStateHistory, ghler.getPStateHistory)
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: URLPathPStateHistory,
+		Desc: URLPathPStateHistoryDesc,
+	})
+	v1.GET(URLPathStateSchemas, ghler.getStateSchemas)
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: URLPathStateSchemas,
+		Desc: URLPathStateSchemasDesc,
+	})
+	v1.GET(URLPathEventAcks, ghler.getEventAcks)
+	v1.POST(URLPathEventAcks, ghler.postEventAck)
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: URLPathEventAcks,
+		Desc: URLPathEventAcksDesc,
+	})
+	if config.StatesHistoryRetentionPeriod.Duration > 0 {
+		v1.GET(URLPathStatesHistory, ghler.getStatesHistory)
+		registeredPaths = append(registeredPaths, componentHandlerDescription{
+			Path: URLPathStatesHistory,
+			Desc: URLPathStatesHistoryDesc,
+		})
+		v1.GET(URLPathStatesSLO, ghler.getStatesSLO)
+		registeredPaths = append(registeredPaths, componentHandlerDescription{
+			Path: URLPathStatesSLO,
+			Desc: URLPathStatesSLODesc,
+		})
+	}
+	if validator != nil {
+		v1.GET(URLPathLifecycleValidate, createGetLifecycleValidateHandler(validator))
+		v1.POST(URLPathLifecycleValidate, createPostLifecycleValidateHandler(validator))
+		registeredPaths = append(registeredPaths, componentHandlerDescription{
+			Path: URLPathLifecycleValidate,
+			Desc: URLPathLifecycleValidateDesc,
+		})
+	}
+	if lastKnownGood != nil {
+		v1.GET(URLPathLastKnownGood, createLastKnownGoodHandler(lastKnownGood))
+		registeredPaths = append(registeredPaths, componentHandlerDescription{
+			Path: URLPathLastKnownGood,
+			Desc: URLPathLastKnownGoodDesc,
+		})
+	}
+	if s.nvidiaComponentsExist {
+		v1.GET(URLPathGPUFeatures, createGPUFeaturesHandler())
+		registeredPaths = append(registeredPaths, componentHandlerDescription{
+			Path: URLPathGPUFeatures,
+			Desc: URLPathGPUFeaturesDesc,
+		})
+	}
 	for i := range registeredPaths {
 		registeredPaths[i].Path = path.Join(v1.BasePath(), registeredPaths[i].Path)
 	}
@@ -1132,6 +1875,12 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		Path: path.Join("/admin", URLPathPackages),
 		Desc: URLPathPackagesDesc,
 	})
+	// the raw payloads may include the sensitive host details (e.g., the process environments)
+	admin.GET(URLPathDebugRaw, createDebugRawHandler())
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: path.Join("/admin", URLPathDebugRaw),
+		Desc: URLPathDebugRawDesc,
+	})
 
 	if config.Pprof {
 		log.Logger.Debugw("registering pprof handlers")
@@ -1146,10 +1895,7 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		if config.Web.Enable {
 			go func() {
 				time.Sleep(2 * time.Second)
-				url := "https://" + config.Address
-				if !strings.HasPrefix(config.Address, "127.0.0.1") && !strings.HasPrefix(config.Address, "0.0.0.0") && !strings.HasPrefix(config.Address, "localhost") {
-					url = "https://localhost" + config.Address
-				}
+				url := "https://" + netutil.LocalURLHost(config.Address)
 				fmt.Printf("\n\n\n\n\n%s serving %s\n\n\n\n\n", checkMark, url)
 			}()
 		}
@@ -1179,6 +1925,9 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 					if _, ok := componentSet[name]; ok {
 						continue
 					}
+					if !options.Profile.Includes(name) {
+						continue
+					}
 
 					if cc, exists := lepconfig.DefaultContainerdComponent(ctx); exists {
 						ccfg := containerd_pod.Config{Query: defaultQueryCfg}
@@ -1290,8 +2039,13 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			},
 		}
 		log.Logger.Infof("serving %s", config.Address)
+		ln, err := config.AddressFamily.Listen(config.Address)
+		if err != nil {
+			s.Stop()
+			log.Logger.Fatalf("listen %v failure %v", config.Address, err)
+		}
 		// Start HTTPS server
-		err = srv.ListenAndServeTLS("", "")
+		err = srv.ServeTLS(ln, "", "")
 		if err != nil {
 			s.Stop()
 			log.Logger.Fatalf("serve %v failure %v", config.Address, err)
@@ -1324,6 +2078,9 @@ func (s *Server) Stop() {
 	if s.nvidiaComponentsExist {
 		serr := nvidia_query_nvml.DefaultInstance().Shutdown()
 		if serr != nil {
+			log.Logger.Warnw("failed to shutdown NVML instance", "error", serr)
+		}
+		if serr := pkg_nvml.Default().Shutdown(); serr != nil {
 			log.Logger.Warnw("failed to shutdown NVML", "error", serr)
 		}
 	}
@@ -1397,6 +2154,8 @@ func (s *Server) updateToken(ctx context.Context, db *sql.DB, uid string, endpoi
 			session.WithPipeInterval(3*time.Second),
 			session.WithEnableAutoUpdate(s.enableAutoUpdate),
 			session.WithAutoUpdateExitCode(s.autoUpdateExitCode),
+			session.WithAddressFamily(s.addressFamily),
+			session.WithProxyConfig(s.proxyConfig),
 		)
 		if err != nil {
 			log.Logger.Errorw("error creating session", "error", err)
@@ -1442,6 +2201,8 @@ func (s *Server) updateToken(ctx context.Context, db *sql.DB, uid string, endpoi
 				session.WithPipeInterval(3*time.Second),
 				session.WithEnableAutoUpdate(s.enableAutoUpdate),
 				session.WithAutoUpdateExitCode(s.autoUpdateExitCode),
+				session.WithAddressFamily(s.addressFamily),
+				session.WithProxyConfig(s.proxyConfig),
 			)
 			if err != nil {
 				log.Logger.Errorw("error creating session", "error", err)
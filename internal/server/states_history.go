@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/log"
+)
+
+// recordStatesHistory periodically records the states of all the components,
+// and purges the states history older than the retention period.
+func recordStatesHistory(ctx context.Context, db *sql.DB, comps []components.Component, retention time.Duration) {
+	ticker := time.NewTicker(state.DefaultStatesHistoryInterval)
+	defer ticker.Stop()
+
+	lastPurge := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now().UTC()
+		for _, c := range comps {
+			states, err := c.States(ctx)
+			if err != nil {
+				log.Logger.Debugw("failed to get states for history", "component", c.Name(), "error", err)
+				continue
+			}
+			if err := state.RecordStates(ctx, db, c.Name(), states, now); err != nil {
+				log.Logger.Warnw("failed to record states history", "component", c.Name(), "error", err)
+			}
+		}
+
+		if now.Sub(lastPurge) < time.Hour {
+			continue
+		}
+		lastPurge = now
+		purged, err := state.PurgeStatesHistory(ctx, db, now.Add(-retention))
+		if err != nil {
+			log.Logger.Warnw("failed to purge states history", "error", err)
+		} else {
+			log.Logger.Debugw("purged states history", "purged", purged)
+		}
+	}
+}
@@ -13,8 +13,11 @@ import (
 
 	v1 "github.com/leptonai/gpud/api/v1"
 	"github.com/leptonai/gpud/components"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/lifecycle"
+	"github.com/leptonai/gpud/pkg/nvidiareset"
 	"github.com/leptonai/gpud/pkg/reboot"
 	"github.com/leptonai/gpud/pkg/systemd"
 	"github.com/leptonai/gpud/update"
@@ -29,6 +32,8 @@ type Request struct {
 	EndTime       time.Time     `json:"end_time"`
 	Since         time.Duration `json:"since"`
 	UpdateVersion string        `json:"update_version,omitempty"`
+	// GPUUUIDs are the GPUs to reset for the "resetGPU" method.
+	GPUUUIDs []string `json:"gpu_uuids,omitempty"`
 }
 
 type Response struct {
@@ -79,6 +84,9 @@ func (s *Session) serve() {
 		case "delete":
 			go s.deleteMachine(ctx, payload)
 
+		case "resetGPU":
+			response.Error = resetGPUs(ctx, payload.GPUUUIDs, nvidiareset.Reset, nvidia_query_nvml.SuspendDefaultInstance, nvidia_query_nvml.ReinitDefaultInstance)
+
 		case "update":
 			if targetVersion := strings.Split(payload.UpdateVersion, ":"); len(targetVersion) == 2 {
 				err := update.PackageUpdate(targetVersion[0], targetVersion[1], update.DefaultUpdateURL)
@@ -133,6 +141,40 @@ func (s *Session) serve() {
 	}
 }
 
+// resetGPUs runs the "RESET_GPU" repair action on the GPUs.
+// The NVML session of gpud is released during the reset,
+// as the GPU reset fails while any process (including the monitoring) keeps the GPU open,
+// and re-initialized afterwards for the new device handles.
+func resetGPUs(
+	ctx context.Context,
+	uuids []string,
+	reset func(ctx context.Context, uuid string) error,
+	suspendNVML func() error,
+	reinitNVML func() error,
+) error {
+	if len(uuids) == 0 {
+		return errors.New("no gpu uuid specified")
+	}
+
+	if err := suspendNVML(); err != nil {
+		log.Logger.Warnw("failed to suspend NVML before the gpu reset", "error", err)
+	}
+	defer func() {
+		if err := reinitNVML(); err != nil {
+			log.Logger.Warnw("failed to re-initialize NVML after the gpu reset", "error", err)
+		}
+	}()
+
+	var errs []error
+	for _, uuid := range uuids {
+		if err := reset(ctx, uuid); err != nil {
+			log.Logger.Errorw("failed to reset gpu", "uuid", uuid, "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (s *Session) deleteMachine(ctx context.Context, payload Request) {
 	// cleanup packages
 	if err := createNeedDeleteFiles("/var/lib/gpud/packages"); err != nil {
@@ -182,6 +224,7 @@ func (s *Session) getEvents(ctx context.Context, payload Request) (v1.LeptonEven
 			Component: componentName,
 			StartTime: startTime,
 			EndTime:   endTime,
+			Lifecycle: string(lifecycle.Current().State),
 		}
 		component, err := components.GetComponent(componentName)
 		if err != nil {
@@ -232,6 +275,7 @@ func (s *Session) getMetrics(ctx context.Context, payload Request) (v1.LeptonMet
 	for _, componentName := range allComponents {
 		currMetrics := v1.LeptonComponentMetrics{
 			Component: componentName,
+			Lifecycle: string(lifecycle.Current().State),
 		}
 		component, err := components.GetComponent(componentName)
 		if err != nil {
@@ -270,6 +314,7 @@ func (s *Session) getStates(ctx context.Context, payload Request) (v1.LeptonStat
 	for _, componentName := range allComponents {
 		currState := v1.LeptonComponentStates{
 			Component: componentName,
+			Lifecycle: string(lifecycle.Current().State),
 		}
 		component, err := components.GetComponent(componentName)
 		if err != nil {
@@ -293,6 +338,7 @@ func (s *Session) getStates(ctx context.Context, payload Request) (v1.LeptonStat
 		} else {
 			log.Logger.Debugw("successfully got states", "component", componentName)
 			currState.States = state
+			components.SortStates(currState.States)
 		}
 		states = append(states, currState)
 	}
@@ -2,15 +2,19 @@ package session
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"sort"
 	"time"
 
 	"github.com/leptonai/gpud/components"
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/netutil"
 )
 
 type Op struct {
@@ -18,6 +22,8 @@ type Op struct {
 	pipeInterval       time.Duration
 	enableAutoUpdate   bool
 	autoUpdateExitCode int
+	addressFamily      netutil.AddressFamily
+	proxyConfig        *netutil.ProxyConfig
 }
 
 type OpOption func(*Op)
@@ -65,6 +71,21 @@ func WithAutoUpdateExitCode(autoUpdateExitCode int) OpOption {
 	}
 }
 
+// Sets the IP address family to connect to the session endpoint with (e.g., IPv6-only clusters).
+func WithAddressFamily(f netutil.AddressFamily) OpOption {
+	return func(op *Op) {
+		op.addressFamily = f
+	}
+}
+
+// Sets the egress proxy and the CA bundle to connect to the session endpoint with
+// (e.g., behind the TLS inspection proxy).
+func WithProxyConfig(c *netutil.ProxyConfig) OpOption {
+	return func(op *Op) {
+		op.proxyConfig = c
+	}
+}
+
 type Session struct {
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -86,6 +107,10 @@ type Session struct {
 
 	enableAutoUpdate   bool
 	autoUpdateExitCode int
+
+	addressFamily netutil.AddressFamily
+	proxy         func(*http.Request) (*url.URL, error)
+	tlsConfig     *tls.Config
 }
 
 func NewSession(ctx context.Context, endpoint string, opts ...OpOption) (*Session, error) {
@@ -94,11 +119,17 @@ func NewSession(ctx context.Context, endpoint string, opts ...OpOption) (*Sessio
 		return nil, err
 	}
 
+	tlsConfig, err := op.proxyConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	cps := make([]string, 0)
 	allComponents := components.GetAllComponents()
 	for key := range allComponents {
 		cps = append(cps, key)
 	}
+	sort.Strings(cps)
 
 	cctx, ccancel := context.WithCancel(ctx)
 	s := &Session{
@@ -114,6 +145,10 @@ func NewSession(ctx context.Context, endpoint string, opts ...OpOption) (*Sessio
 
 		enableAutoUpdate:   op.enableAutoUpdate,
 		autoUpdateExitCode: op.autoUpdateExitCode,
+
+		addressFamily: op.addressFamily,
+		proxy:         op.proxyConfig.Proxy(),
+		tlsConfig:     tlsConfig,
 	}
 
 	s.reader = make(chan Body, 20)
@@ -168,8 +203,9 @@ func (s *Session) startWriter() {
 
 		client := &http.Client{
 			Transport: &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
-				DialContext: (&net.Dialer{
+				Proxy:           s.proxy,
+				TLSClientConfig: s.tlsConfig,
+				DialContext: s.addressFamily.DialContext(&net.Dialer{
 					Timeout: 30 * time.Second,
 					KeepAliveConfig: net.KeepAliveConfig{
 						Enable:   true,
@@ -178,7 +214,7 @@ func (s *Session) startWriter() {
 						Count:    3,
 					},
 					FallbackDelay: 300 * time.Millisecond,
-				}).DialContext,
+				}),
 				ForceAttemptHTTP2:     true,
 				MaxIdleConns:          10,
 				IdleConnTimeout:       30 * time.Second,
@@ -251,8 +287,9 @@ func (s *Session) startReader() {
 
 		client := &http.Client{
 			Transport: &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
-				DialContext: (&net.Dialer{
+				Proxy:           s.proxy,
+				TLSClientConfig: s.tlsConfig,
+				DialContext: s.addressFamily.DialContext(&net.Dialer{
 					Timeout: 30 * time.Second,
 					KeepAliveConfig: net.KeepAliveConfig{
 						Enable:   true,
@@ -261,7 +298,7 @@ func (s *Session) startReader() {
 						Count:    3,
 					},
 					FallbackDelay: 300 * time.Millisecond,
-				}).DialContext,
+				}),
 				ForceAttemptHTTP2:     true,
 				MaxIdleConns:          10,
 				IdleConnTimeout:       30 * time.Second,
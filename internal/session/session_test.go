@@ -3,8 +3,10 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -205,3 +207,40 @@ func TestStartWriterAndReader(t *testing.T) {
 		})
 	}
 }
+
+func TestResetGPUs(t *testing.T) {
+	var calls []string
+	errFailed := errors.New("failed")
+	reset := func(ctx context.Context, uuid string) error {
+		calls = append(calls, "reset "+uuid)
+		if uuid == "GPU-2" {
+			return errFailed
+		}
+		return nil
+	}
+	suspend := func() error {
+		calls = append(calls, "suspend")
+		return nil
+	}
+	reinit := func() error {
+		calls = append(calls, "reinit")
+		return nil
+	}
+
+	err := resetGPUs(context.Background(), []string{"GPU-1", "GPU-2", "GPU-3"}, reset, suspend, reinit)
+	if !errors.Is(err, errFailed) {
+		t.Errorf("expected the reset error, got %v", err)
+	}
+	want := []string{"suspend", "reset GPU-1", "reset GPU-2", "reset GPU-3", "reinit"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	calls = nil
+	if err := resetGPUs(context.Background(), nil, reset, suspend, reinit); err == nil {
+		t.Error("expected error without gpu uuids")
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected no call without gpu uuids, got %v", calls)
+	}
+}
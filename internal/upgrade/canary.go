@@ -0,0 +1,236 @@
+// Package upgrade implements the driver upgrade canary, which records the pre-upgrade baseline
+// (driver version, GPU count, ECC state), and when the NVIDIA driver version changes,
+// runs the smoke test, the PCIe bandwidth probe, and the ECC check against the baseline,
+// and records the pass/fail upgrade verdict.
+package upgrade
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	nvidia_query_driver_upgrade "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-upgrade"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/internal/validate"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/warmstate"
+)
+
+const warmStateKey = "upgrade/baseline"
+
+// ECCState is the ECC state of a GPU in the baseline.
+type ECCState struct {
+	Enabled bool `json:"enabled"`
+	// Aggregate (lifetime) uncorrected ECC errors.
+	AggregateUncorrected uint64 `json:"aggregate_uncorrected"`
+}
+
+// Snapshot is the GPU state to compare before and after the upgrade.
+type Snapshot struct {
+	// ECC states by the GPU UUID.
+	ECC map[string]ECCState `json:"ecc"`
+}
+
+// Baseline is the last known good state before the upgrade.
+type Baseline struct {
+	Time          time.Time `json:"time"`
+	DriverVersion string    `json:"driver_version"`
+	Snapshot      Snapshot  `json:"snapshot"`
+
+	// Driver version that failed the canary checks against this baseline,
+	// to not rerun the checks until the driver version changes again.
+	FailedVersion string `json:"failed_version,omitempty"`
+}
+
+// Canary runs the canary checks after the driver upgrades.
+type Canary struct {
+	cfg Config
+	db  *sql.DB
+
+	getDriverVersion func() (string, error)
+	getSnapshot      func() (Snapshot, error)
+	runCheck         func(ctx context.Context, c validate.Check) validate.CheckResult
+	now              func() time.Time
+}
+
+func New(cfg Config, db *sql.DB) *Canary {
+	return &Canary{
+		cfg:              cfg,
+		db:               db,
+		getDriverVersion: nvidia_query_nvml.GetDriverVersion,
+		getSnapshot:      getSnapshot,
+		runCheck: func(ctx context.Context, c validate.Check) validate.CheckResult {
+			return validate.RunCheck(ctx, db, c)
+		},
+		now: func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Start checks the driver version right away (e.g., restarted after the upgrade),
+// and then periodically until the context is canceled.
+func (c *Canary) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(c.cfg.Interval.Duration)
+		defer ticker.Stop()
+
+		for {
+			if _, err := c.Check(ctx); err != nil {
+				log.Logger.Warnw("failed to run driver upgrade canary", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Check compares the current driver version against the baseline,
+// and runs the canary checks if the driver was upgraded (or downgraded).
+// Returns the verdict if the checks ran, or nil otherwise.
+func (c *Canary) Check(ctx context.Context) (*nvidia_query_driver_upgrade.Verdict, error) {
+	ver, err := c.getDriverVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	var base Baseline
+	found, err := warmstate.Load(ctx, c.db, warmStateKey, 0, &base)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !found:
+		log.Logger.Infow("recording initial driver upgrade baseline", "driverVersion", ver)
+		return nil, c.saveBaseline(ctx, ver)
+
+	case base.DriverVersion == ver:
+		// refresh the baseline so the latest state before the next upgrade is compared
+		return nil, c.saveBaseline(ctx, ver)
+
+	case base.FailedVersion == ver:
+		// keep the pre-upgrade baseline for the next upgrade (e.g., rollback to a fixed version),
+		// re-saved to not be purged with the stale warm states
+		return nil, warmstate.Save(ctx, c.db, warmStateKey, base, c.now())
+	}
+
+	log.Logger.Infow("driver version changed -- running upgrade canary checks", "from", base.DriverVersion, "to", ver)
+	verdict := c.runCanary(ctx, base, ver)
+	if err := nvidia_query_driver_upgrade.InsertVerdict(ctx, c.db, verdict); err != nil {
+		return nil, err
+	}
+	log.Logger.Infow("driver upgrade canary completed", "from", verdict.FromVersion, "to", verdict.ToVersion, "verdict", verdict.Verdict, "details", verdict.Details)
+
+	if verdict.Verdict == nvidia_query_driver_upgrade.VerdictPass {
+		return &verdict, c.saveBaseline(ctx, ver)
+	}
+	base.FailedVersion = ver
+	return &verdict, warmstate.Save(ctx, c.db, warmStateKey, base, c.now())
+}
+
+func (c *Canary) saveBaseline(ctx context.Context, ver string) error {
+	snap, err := c.getSnapshot()
+	if err != nil {
+		return err
+	}
+	now := c.now()
+	return warmstate.Save(ctx, c.db, warmStateKey, Baseline{Time: now, DriverVersion: ver, Snapshot: snap}, now)
+}
+
+func (c *Canary) runCanary(ctx context.Context, base Baseline, ver string) nvidia_query_driver_upgrade.Verdict {
+	checks := []validate.Check{c.smokeTest()}
+	if !c.cfg.SkipPCIeBandwidth {
+		checks = append(checks, validate.Check{Name: "pcie-bandwidth", Type: validate.CheckTypePCIeBandwidth, Timeout: c.cfg.Timeout})
+	}
+
+	passed := true
+	details := make([]string, 0, len(checks)+1)
+	for _, chk := range checks {
+		rs := c.runCheck(ctx, chk)
+		passed = passed && rs.Passed
+		details = append(details, formatResult(chk.Name, rs.Passed, rs.Message))
+	}
+
+	snap, err := c.getSnapshot()
+	if err != nil {
+		passed = false
+		details = append(details, formatResult("ecc", false, err.Error()))
+	} else {
+		reasons := compareECC(base.Snapshot, snap)
+		passed = passed && len(reasons) == 0
+		details = append(details, formatResult("ecc", len(reasons) == 0, strings.Join(reasons, ", ")))
+	}
+
+	verdict := nvidia_query_driver_upgrade.Verdict{
+		UnixSeconds: c.now().Unix(),
+		FromVersion: base.DriverVersion,
+		ToVersion:   ver,
+		Verdict:     nvidia_query_driver_upgrade.VerdictFail,
+		Details:     strings.Join(details, "; "),
+	}
+	if passed {
+		verdict.Verdict = nvidia_query_driver_upgrade.VerdictPass
+	}
+	return verdict
+}
+
+func (c *Canary) smokeTest() validate.Check {
+	if c.cfg.SmokeTestCommand != "" {
+		return validate.Check{Name: "smoke-test", Type: validate.CheckTypeCommand, Command: c.cfg.SmokeTestCommand, Timeout: c.cfg.Timeout}
+	}
+	return validate.Check{Name: "smoke-test", Type: validate.CheckTypeDiag, DiagLevel: DefaultSmokeTestDiagLevel, Timeout: c.cfg.Timeout}
+}
+
+func formatResult(name string, passed bool, msg string) string {
+	if passed {
+		return name + ": passed"
+	}
+	return fmt.Sprintf("%s: failed (%s)", name, msg)
+}
+
+// compareECC returns the reasons the ECC state regressed from the baseline, or nil if none.
+func compareECC(base, cur Snapshot) []string {
+	uuids := make([]string, 0, len(base.ECC))
+	for uuid := range base.ECC {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	var reasons []string
+	for _, uuid := range uuids {
+		b := base.ECC[uuid]
+		s, ok := cur.ECC[uuid]
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("%s missing", uuid))
+			continue
+		}
+		if b.Enabled && !s.Enabled {
+			reasons = append(reasons, fmt.Sprintf("%s ecc mode disabled", uuid))
+		}
+		if s.AggregateUncorrected > b.AggregateUncorrected {
+			reasons = append(reasons, fmt.Sprintf("%s uncorrected errors %d -> %d", uuid, b.AggregateUncorrected, s.AggregateUncorrected))
+		}
+	}
+	return reasons
+}
+
+func getSnapshot() (Snapshot, error) {
+	out, err := nvidia_query_nvml.DefaultInstance().Get()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snap := Snapshot{ECC: make(map[string]ECCState, len(out.DeviceInfos))}
+	for _, dev := range out.DeviceInfos {
+		snap.ECC[dev.UUID] = ECCState{
+			Enabled:              dev.ECCMode.EnabledCurrent,
+			AggregateUncorrected: dev.ECCErrors.Aggregate.Total.Uncorrected,
+		}
+	}
+	return snap, nil
+}
@@ -0,0 +1,138 @@
+package upgrade
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	nvidia_query_driver_upgrade "github.com/leptonai/gpud/components/accelerator/nvidia/query/driver-upgrade"
+	"github.com/leptonai/gpud/internal/validate"
+	"github.com/leptonai/gpud/pkg/sqlite"
+	"github.com/leptonai/gpud/pkg/warmstate"
+)
+
+func TestCompareECC(t *testing.T) {
+	t.Parallel()
+
+	base := Snapshot{ECC: map[string]ECCState{
+		"GPU-0": {Enabled: true, AggregateUncorrected: 1},
+		"GPU-1": {Enabled: true},
+		"GPU-2": {Enabled: true},
+	}}
+	cur := Snapshot{ECC: map[string]ECCState{
+		"GPU-0": {Enabled: true, AggregateUncorrected: 1},
+		"GPU-1": {Enabled: false, AggregateUncorrected: 2},
+	}}
+
+	want := []string{
+		"GPU-1 ecc mode disabled",
+		"GPU-1 uncorrected errors 0 -> 2",
+		"GPU-2 missing",
+	}
+	if got := compareECC(base, cur); !reflect.DeepEqual(got, want) {
+		t.Errorf("compareECC() = %v, want %v", got, want)
+	}
+	if got := compareECC(base, base); got != nil {
+		t.Errorf("expected no regression, got %v", got)
+	}
+}
+
+func TestCanaryCheck(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := warmstate.CreateTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := nvidia_query_driver_upgrade.CreateTableDriverUpgradeVerdicts(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	ver := "535.161.08"
+	snap := Snapshot{ECC: map[string]ECCState{"GPU-0": {Enabled: true}}}
+	smokePassed := true
+	var ran []string
+
+	cfg := Config{}
+	cfg.SetDefaultsIfNotSet()
+	c := New(cfg, db)
+	c.getDriverVersion = func() (string, error) { return ver, nil }
+	c.getSnapshot = func() (Snapshot, error) { return snap, nil }
+	c.runCheck = func(ctx context.Context, chk validate.Check) validate.CheckResult {
+		ran = append(ran, chk.Name)
+		if chk.Type == validate.CheckTypeDiag && !smokePassed {
+			return validate.CheckResult{Name: chk.Name, Passed: false, Message: "diag failed"}
+		}
+		return validate.CheckResult{Name: chk.Name, Passed: true}
+	}
+
+	check := func() *nvidia_query_driver_upgrade.Verdict {
+		t.Helper()
+		v, err := c.Check(ctx)
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		return v
+	}
+
+	// initial baseline, and no upgrade
+	for i := 0; i < 2; i++ {
+		if v := check(); v != nil {
+			t.Fatalf("expected no verdict, got %+v", v)
+		}
+	}
+	if len(ran) != 0 {
+		t.Fatalf("expected no checks, got %v", ran)
+	}
+
+	// upgrade that breaks the smoke test and increases the uncorrected errors
+	ver = "550.54.15"
+	smokePassed = false
+	snap = Snapshot{ECC: map[string]ECCState{"GPU-0": {Enabled: true, AggregateUncorrected: 1}}}
+	v := check()
+	if v == nil || v.Verdict != nvidia_query_driver_upgrade.VerdictFail || v.FromVersion != "535.161.08" || v.ToVersion != "550.54.15" {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+	if want := "smoke-test: failed (diag failed); pcie-bandwidth: passed; ecc: failed (GPU-0 uncorrected errors 0 -> 1)"; v.Details != want {
+		t.Errorf("unexpected details %q, want %q", v.Details, want)
+	}
+	if !reflect.DeepEqual(ran, []string{"smoke-test", "pcie-bandwidth"}) {
+		t.Errorf("unexpected checks: %v", ran)
+	}
+
+	// failed version is not checked again
+	if v := check(); v != nil {
+		t.Fatalf("expected no verdict, got %+v", v)
+	}
+
+	// upgrade to the fixed version, compared against the same pre-upgrade baseline
+	ver = "550.90.07"
+	smokePassed = true
+	snap = Snapshot{ECC: map[string]ECCState{"GPU-0": {Enabled: true}}}
+	v = check()
+	if v == nil || v.Verdict != nvidia_query_driver_upgrade.VerdictPass || v.FromVersion != "535.161.08" {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+
+	// passed version becomes the new baseline
+	if v := check(); v != nil {
+		t.Fatalf("expected no verdict, got %+v", v)
+	}
+
+	verdicts, err := nvidia_query_driver_upgrade.ReadVerdicts(ctx, db, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verdicts) != 2 {
+		t.Fatalf("expected 2 verdicts, got %+v", verdicts)
+	}
+}
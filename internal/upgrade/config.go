@@ -0,0 +1,54 @@
+package upgrade
+
+import (
+	"errors"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultInterval is the default interval to check the driver version
+	// and refresh the pre-upgrade baseline.
+	DefaultInterval = time.Hour
+	// DefaultSmokeTestDiagLevel is the DCGM diagnostics level of the default smoke test
+	// ("dcgmi diag -r 1", the quick deployment checks).
+	DefaultSmokeTestDiagLevel = 1
+)
+
+// Config configures the driver upgrade canary.
+type Config struct {
+	// Interval to check the driver version and refresh the pre-upgrade baseline,
+	// defaults to 1 hour.
+	Interval metav1.Duration `json:"interval"`
+
+	// Command to run with "bash -c" as the smoke test,
+	// defaults to the DCGM diagnostics level 1 if not set.
+	SmokeTestCommand string `json:"smoke_test_command,omitempty"`
+
+	// Set true to skip the PCIe bandwidth probe
+	// (e.g., no slot baselines recorded with "gpud pcie-bandwidth-test").
+	SkipPCIeBandwidth bool `json:"skip_pcie_bandwidth,omitempty"`
+
+	// Timeout of each canary check, defaults to 30 minutes.
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.Interval.Duration == 0 {
+		cfg.Interval = metav1.Duration{Duration: DefaultInterval}
+	}
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Interval.Duration < 0 {
+		return errors.New("interval must be non-negative")
+	}
+	if cfg.Interval.Duration != 0 && cfg.Interval.Duration < time.Minute {
+		return errors.New("interval must be at least 1 minute")
+	}
+	if cfg.Timeout.Duration < 0 {
+		return errors.New("timeout must be non-negative")
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckType is the type of the validation check.
+type CheckType string
+
+const (
+	// CheckTypeGPUCount checks the number of the GPUs visible to NVML.
+	CheckTypeGPUCount CheckType = "gpu-count"
+	// CheckTypeNVLink checks the number of the enabled NVLink links (the link width) of each GPU.
+	CheckTypeNVLink CheckType = "nvlink"
+	// CheckTypeDiag runs the DCGM diagnostics ("dcgmi diag -r <level>").
+	CheckTypeDiag CheckType = "diag"
+	// CheckTypePCIeBandwidth runs the PCIe bandwidth test per GPU slot
+	// against the slot baselines (see "gpud pcie-bandwidth-test").
+	CheckTypePCIeBandwidth CheckType = "pcie-bandwidth"
+	// CheckTypeCommand runs the command, and passes if the command exits with zero.
+	CheckTypeCommand CheckType = "command"
+)
+
+const (
+	DefaultDiagLevel     = 2
+	DefaultCheckTimeout  = 30 * time.Minute
+	DefaultPCIeTolerance = 10
+)
+
+// Check is a validation check in the checklist.
+type Check struct {
+	Name string    `json:"name"`
+	Type CheckType `json:"type"`
+
+	// Expected number of the GPUs for the "gpu-count" check,
+	// defaults to the number of the GPU device files (e.g., "/dev/nvidia0").
+	ExpectedGPUCount int `json:"expected_gpu_count,omitempty"`
+	// Expected number of the enabled NVLink links per GPU for the "nvlink" check,
+	// defaults to the maximum across the GPUs (i.e., all the GPUs have the same width).
+	ExpectedNVLinks int `json:"expected_nvlinks,omitempty"`
+	// Diagnostics level for the "diag" check, defaults to 2.
+	DiagLevel int `json:"diag_level,omitempty"`
+	// Command to run with "bash -c" for the "command" check.
+	Command string `json:"command,omitempty"`
+
+	// Timeout of the check, defaults to 30 minutes.
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+// Config is the post-repair validation checklist.
+type Config struct {
+	Checks []Check `json:"checks"`
+}
+
+// DefaultChecks returns the default checklist.
+func DefaultChecks() []Check {
+	return []Check{
+		{Name: "gpu-count", Type: CheckTypeGPUCount},
+		{Name: "nvlink", Type: CheckTypeNVLink},
+		{Name: "diag", Type: CheckTypeDiag, DiagLevel: DefaultDiagLevel},
+		{Name: "pcie-bandwidth", Type: CheckTypePCIeBandwidth},
+	}
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if len(cfg.Checks) == 0 {
+		cfg.Checks = DefaultChecks()
+	}
+	for i := range cfg.Checks {
+		if cfg.Checks[i].Name == "" {
+			cfg.Checks[i].Name = string(cfg.Checks[i].Type)
+		}
+		if cfg.Checks[i].Type == CheckTypeDiag && cfg.Checks[i].DiagLevel == 0 {
+			cfg.Checks[i].DiagLevel = DefaultDiagLevel
+		}
+		if cfg.Checks[i].Timeout.Duration == 0 {
+			cfg.Checks[i].Timeout = metav1.Duration{Duration: DefaultCheckTimeout}
+		}
+	}
+}
+
+func (cfg Config) Validate() error {
+	names := make(map[string]struct{}, len(cfg.Checks))
+	for _, c := range cfg.Checks {
+		switch c.Type {
+		case CheckTypeGPUCount, CheckTypeNVLink, CheckTypePCIeBandwidth:
+		case CheckTypeDiag:
+			if c.DiagLevel < 0 || c.DiagLevel > 4 {
+				return fmt.Errorf("check %q: diag_level must be between 1 and 4, got %d", c.Name, c.DiagLevel)
+			}
+		case CheckTypeCommand:
+			if c.Command == "" {
+				return fmt.Errorf("check %q: command is required", c.Name)
+			}
+		default:
+			return fmt.Errorf("check %q: unknown type %q", c.Name, c.Type)
+		}
+		if c.ExpectedGPUCount < 0 || c.ExpectedNVLinks < 0 {
+			return fmt.Errorf("check %q: expected counts must be non-negative", c.Name)
+		}
+		if c.Timeout.Duration < 0 {
+			return fmt.Errorf("check %q: timeout must be non-negative", c.Name)
+		}
+		if c.Name != "" {
+			if _, ok := names[c.Name]; ok {
+				return fmt.Errorf("duplicate check name %q", c.Name)
+			}
+			names[c.Name] = struct{}{}
+		}
+	}
+	return nil
+}
+
+var ErrAlreadyRunning = errors.New("validation already running")
@@ -0,0 +1,321 @@
+// Package validate implements the post-repair validation pipeline,
+// which runs the checklist (e.g., GPU count, NVLink widths, DCGM diagnostics, PCIe bandwidth)
+// after a repair or reboot, and transitions the node lifecycle back to in-service
+// only when all the checks pass.
+package validate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	nvidia_query_pciebw "github.com/leptonai/gpud/components/accelerator/nvidia/query/pciebw"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/lifecycle"
+	"github.com/leptonai/gpud/pkg/warmstate"
+)
+
+// maxOutputBytes is the maximum bytes of the command output to keep in the check result.
+const maxOutputBytes = 1024
+
+const warmStateKey = "lifecycle/validation"
+
+// CheckResult is the result of a validation check.
+type CheckResult struct {
+	Name    string    `json:"name"`
+	Type    CheckType `json:"type"`
+	Passed  bool      `json:"passed"`
+	Message string    `json:"message"`
+	Took    string    `json:"took"`
+}
+
+// Result is the result of a validation run.
+type Result struct {
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time,omitempty"`
+	Running   bool          `json:"running"`
+	Passed    bool          `json:"passed"`
+	Checks    []CheckResult `json:"checks"`
+	// Lifecycle is the node lifecycle state after the validation.
+	Lifecycle lifecycle.State `json:"lifecycle,omitempty"`
+}
+
+// CheckFunc runs the check, and returns the details, or an error if the check fails.
+type CheckFunc func(ctx context.Context, c Check) (string, error)
+
+// Runner runs the validation checklist, one run at a time.
+type Runner struct {
+	// root context for the background runs, not canceled with the API requests
+	ctx    context.Context
+	cfg    Config
+	db     *sql.DB
+	checks map[CheckType]CheckFunc
+
+	mu      sync.RWMutex
+	running bool
+	last    *Result
+}
+
+// NewRunner creates the runner, and restores the last result saved before the restart (if any).
+func NewRunner(ctx context.Context, cfg Config, db *sql.DB) *Runner {
+	r := &Runner{
+		ctx:    ctx,
+		cfg:    cfg,
+		db:     db,
+		checks: defaultCheckFuncs(db),
+	}
+
+	var last Result
+	found, err := warmstate.Load(ctx, db, warmStateKey, 7*24*time.Hour, &last)
+	if err != nil {
+		log.Logger.Warnw("failed to load last validation result", "error", err)
+	}
+	if found {
+		// interrupted by the restart (e.g., reboot during the diagnostics)
+		last.Running = false
+		r.last = &last
+	}
+	return r
+}
+
+func defaultCheckFuncs(db *sql.DB) map[CheckType]CheckFunc {
+	return map[CheckType]CheckFunc{
+		CheckTypeGPUCount:      checkGPUCount,
+		CheckTypeNVLink:        checkNVLink,
+		CheckTypeDiag:          checkDiag,
+		CheckTypePCIeBandwidth: checkPCIeBandwidth(db),
+		CheckTypeCommand:       checkCommand,
+	}
+}
+
+// RunCheck runs a single check outside of the checklist (e.g., the driver upgrade canary),
+// with the defaults set for the unset fields.
+func RunCheck(ctx context.Context, db *sql.DB, c Check) CheckResult {
+	cfg := Config{Checks: []Check{c}}
+	cfg.SetDefaultsIfNotSet()
+
+	r := &Runner{checks: defaultCheckFuncs(db)}
+	return r.runCheck(ctx, cfg.Checks[0])
+}
+
+// Last returns the last validation result, or nil if none.
+func (r *Runner) Last() *Result {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.last == nil {
+		return nil
+	}
+	cp := *r.last
+	cp.Checks = append([]CheckResult(nil), r.last.Checks...)
+	return &cp
+}
+
+// Start starts the validation in the background.
+// Returns ErrAlreadyRunning if a validation is running.
+func (r *Runner) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running {
+		return ErrAlreadyRunning
+	}
+	r.running = true
+	r.last = &Result{StartTime: time.Now().UTC(), Running: true}
+
+	go r.run(r.ctx)
+	return nil
+}
+
+func (r *Runner) run(ctx context.Context) {
+	log.Logger.Infow("starting post-repair validation", "checks", len(r.cfg.Checks))
+
+	passed := true
+	for _, c := range r.cfg.Checks {
+		res := r.runCheck(ctx, c)
+		if !res.Passed {
+			passed = false
+		}
+		log.Logger.Infow("validation check done", "check", res.Name, "passed", res.Passed, "message", res.Message)
+
+		r.mu.Lock()
+		r.last.Checks = append(r.last.Checks, res)
+		r.mu.Unlock()
+	}
+
+	// only the all-pass transitions the node back to in-service,
+	// otherwise the node stays in the current state (e.g., repairing)
+	st := lifecycle.Current()
+	if passed && st.State != lifecycle.StateInService {
+		var err error
+		st, err = lifecycle.Set(ctx, r.db, lifecycle.StateInService, "post-repair validation passed", time.Now().UTC())
+		if err != nil {
+			log.Logger.Warnw("failed to transition to in-service after validation", "error", err)
+			st = lifecycle.Current()
+		}
+	}
+
+	r.mu.Lock()
+	r.running = false
+	r.last.Running = false
+	r.last.Passed = passed
+	r.last.EndTime = time.Now().UTC()
+	r.last.Lifecycle = st.State
+	last := *r.last
+	r.mu.Unlock()
+
+	log.Logger.Infow("post-repair validation done", "passed", passed, "lifecycle", st.State)
+	if err := warmstate.Save(ctx, r.db, warmStateKey, last, last.EndTime); err != nil {
+		log.Logger.Warnw("failed to save validation result", "error", err)
+	}
+}
+
+func (r *Runner) runCheck(ctx context.Context, c Check) CheckResult {
+	res := CheckResult{Name: c.Name, Type: c.Type}
+
+	f, ok := r.checks[c.Type]
+	if !ok {
+		res.Message = fmt.Sprintf("unknown check type %q", c.Type)
+		return res
+	}
+
+	start := time.Now()
+	cctx, cancel := context.WithTimeout(ctx, c.Timeout.Duration)
+	msg, err := f(cctx, c)
+	cancel()
+	res.Took = time.Since(start).Round(time.Second).String()
+
+	res.Passed = err == nil
+	res.Message = msg
+	if err != nil {
+		res.Message = err.Error()
+	}
+	return res
+}
+
+func checkGPUCount(ctx context.Context, c Check) (string, error) {
+	expected := c.ExpectedGPUCount
+	if expected == 0 {
+		var err error
+		expected, err = nvidia_query.CountAllDevicesFromDevDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to count gpu device files: %w", err)
+		}
+	}
+
+	links, err := nvidia_query_nvml.GetPCIeLinks()
+	if err != nil {
+		return "", fmt.Errorf("failed to list gpus: %w", err)
+	}
+	if len(links) != expected {
+		return "", fmt.Errorf("found %d gpu(s), expected %d", len(links), expected)
+	}
+	return fmt.Sprintf("found %d gpu(s)", len(links)), nil
+}
+
+func checkNVLink(ctx context.Context, c Check) (string, error) {
+	inst := nvidia_query_nvml.DefaultInstance()
+	if inst == nil {
+		return "", fmt.Errorf("nvml not initialized")
+	}
+	out, err := inst.Get()
+	if err != nil {
+		return "", fmt.Errorf("failed to query nvml: %w", err)
+	}
+	return compareNVLinks(out.DeviceInfos, c.ExpectedNVLinks)
+}
+
+// compareNVLinks checks the number of the enabled links of each GPU
+// against the expected, or the maximum across the GPUs if the expected is zero.
+func compareNVLinks(devs []*nvidia_query_nvml.DeviceInfo, expected int) (string, error) {
+	enabled := make(map[string]int, len(devs))
+	maxEnabled := 0
+	for _, dev := range devs {
+		n := 0
+		for _, s := range dev.NVLink.States {
+			if s.FeatureEnabled {
+				n++
+			}
+		}
+		enabled[dev.UUID] = n
+		if n > maxEnabled {
+			maxEnabled = n
+		}
+	}
+	if expected == 0 {
+		expected = maxEnabled
+	}
+
+	var degraded []string
+	for _, dev := range devs {
+		if n := enabled[dev.UUID]; n < expected {
+			degraded = append(degraded, fmt.Sprintf("%s %d/%d", dev.UUID, n, expected))
+		}
+	}
+	if len(degraded) > 0 {
+		return "", fmt.Errorf("nvlink(s) down: %s", strings.Join(degraded, ", "))
+	}
+	return fmt.Sprintf("%d gpu(s) with %d nvlink(s) enabled", len(devs), expected), nil
+}
+
+func checkDiag(ctx context.Context, c Check) (string, error) {
+	return runCommand(ctx, "dcgmi", "diag", "-r", strconv.Itoa(c.DiagLevel))
+}
+
+func checkCommand(ctx context.Context, c Check) (string, error) {
+	return runCommand(ctx, "bash", "-c", c.Command)
+}
+
+func runCommand(ctx context.Context, name string, args ...string) (string, error) {
+	b, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	out := strings.TrimSpace(string(b))
+	if len(out) > maxOutputBytes {
+		out = out[len(out)-maxOutputBytes:]
+	}
+	if err != nil {
+		return "", fmt.Errorf("%q failed: %w (%s)", strings.Join(append([]string{name}, args...), " "), err, out)
+	}
+	return out, nil
+}
+
+func checkPCIeBandwidth(db *sql.DB) CheckFunc {
+	return func(ctx context.Context, c Check) (string, error) {
+		links, err := nvidia_query_nvml.GetPCIeLinks()
+		if err != nil {
+			return "", fmt.Errorf("failed to get pcie links: %w", err)
+		}
+		if err := nvidia_query_pciebw.CreateTableBaseline(ctx, db); err != nil {
+			return "", err
+		}
+		baselines, err := nvidia_query_pciebw.ReadBaselines(ctx, db)
+		if err != nil {
+			return "", err
+		}
+
+		var failures []string
+		for _, link := range links {
+			m, err := nvidia_query_pciebw.Measure(ctx, link, nvidia_query_pciebw.DefaultIterations, nil)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", link.BusID, err))
+				continue
+			}
+			var baseline *nvidia_query_pciebw.Baseline
+			if b, ok := baselines[link.BusID]; ok {
+				baseline = &b
+			}
+			if passed, reasons := nvidia_query_pciebw.Compare(m, baseline, DefaultPCIeTolerance); !passed {
+				failures = append(failures, fmt.Sprintf("%s: %s", link.BusID, strings.Join(reasons, "; ")))
+			}
+		}
+		if len(failures) > 0 {
+			return "", fmt.Errorf("pcie bandwidth below baseline: %s", strings.Join(failures, ", "))
+		}
+		return fmt.Sprintf("%d pcie link(s) passed", len(links)), nil
+	}
+}
@@ -0,0 +1,128 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
+	"github.com/leptonai/gpud/pkg/lifecycle"
+	"github.com/leptonai/gpud/pkg/sqlite"
+	"github.com/leptonai/gpud/pkg/warmstate"
+)
+
+func TestCompareNVLinks(t *testing.T) {
+	dev := func(uuid string, enabled int) *nvidia_query_nvml.DeviceInfo {
+		d := &nvidia_query_nvml.DeviceInfo{UUID: uuid}
+		for i := 0; i < 18; i++ {
+			d.NVLink.States = append(d.NVLink.States, nvidia_query_nvml.NVLinkState{Link: i, FeatureEnabled: i < enabled})
+		}
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		devs     []*nvidia_query_nvml.DeviceInfo
+		expected int
+		wantErr  bool
+	}{
+		{name: "all same", devs: []*nvidia_query_nvml.DeviceInfo{dev("a", 18), dev("b", 18)}},
+		{name: "one degraded", devs: []*nvidia_query_nvml.DeviceInfo{dev("a", 18), dev("b", 16)}, wantErr: true},
+		{name: "below expected", devs: []*nvidia_query_nvml.DeviceInfo{dev("a", 12), dev("b", 12)}, expected: 18, wantErr: true},
+		{name: "no nvlink", devs: []*nvidia_query_nvml.DeviceInfo{{UUID: "a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compareNVLinks(tt.devs, tt.expected)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("compareNVLinks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunner(t *testing.T) {
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := warmstate.CreateTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := lifecycle.CreateTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := lifecycle.Load(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lifecycle.Set(ctx, db, lifecycle.StateRepairing, "gpu replaced", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_, _ = lifecycle.Set(ctx, db, lifecycle.StateInService, "", time.Now())
+	}()
+
+	cfg := Config{Checks: []Check{
+		{Name: "gpus", Type: CheckTypeGPUCount},
+		{Name: "echo", Type: CheckTypeCommand, Command: "echo ok"},
+	}}
+	cfg.SetDefaultsIfNotSet()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	gpuCountErr := errors.New("found 7 gpu(s), expected 8")
+	r := NewRunner(ctx, cfg, db)
+	r.checks[CheckTypeGPUCount] = func(ctx context.Context, c Check) (string, error) {
+		return "", gpuCountErr
+	}
+	if r.Last() != nil {
+		t.Fatal("expected no result")
+	}
+
+	wait := func() *Result {
+		for {
+			if res := r.Last(); res != nil && !res.Running {
+				return res
+			}
+			select {
+			case <-ctx.Done():
+				t.Fatal("timed out")
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	// failed check keeps the node in repairing
+	if err := r.Start(); err != nil {
+		t.Fatal(err)
+	}
+	res := wait()
+	if res.Passed || len(res.Checks) != 2 || res.Checks[0].Passed || !res.Checks[1].Passed || res.Lifecycle != lifecycle.StateRepairing {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res.Checks[1].Message != "ok" {
+		t.Fatalf("unexpected command output %q", res.Checks[1].Message)
+	}
+
+	gpuCountErr = nil
+	if err := r.Start(); err != nil {
+		t.Fatal(err)
+	}
+	res = wait()
+	if !res.Passed || res.Lifecycle != lifecycle.StateInService || lifecycle.Current().State != lifecycle.StateInService {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	// restored after the restart
+	r2 := NewRunner(ctx, cfg, db)
+	if last := r2.Last(); last == nil || !last.Passed {
+		t.Fatalf("expected the last result restored, got %+v", last)
+	}
+}
@@ -0,0 +1,154 @@
+// Package cron implements the standard 5-field cron schedule expressions.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression
+// ("minute hour day-of-month month day-of-week").
+type Schedule struct {
+	minutes [60]bool
+	hours   [24]bool
+	doms    [32]bool
+	months  [13]bool
+	dows    [7]bool
+
+	// true if the field is not "*"
+	domRestricted bool
+	dowRestricted bool
+}
+
+var descriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// Parse parses the 5-field cron expression (e.g., "0 3 * * 0" for every Sunday 03:00),
+// or one of the descriptors ("@hourly", "@daily", "@weekly", "@monthly", "@yearly").
+// Each field supports "*", numbers, ranges ("1-5"), lists ("1,3,5"), and steps ("*/15").
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := descriptors[expr]; ok {
+		expr = d
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d in %q", len(fields), expr)
+	}
+
+	s := &Schedule{
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}
+	if err := parseField(fields[0], 0, 59, s.minutes[:]); err != nil {
+		return nil, fmt.Errorf("invalid minute: %w", err)
+	}
+	if err := parseField(fields[1], 0, 23, s.hours[:]); err != nil {
+		return nil, fmt.Errorf("invalid hour: %w", err)
+	}
+	if err := parseField(fields[2], 1, 31, s.doms[:]); err != nil {
+		return nil, fmt.Errorf("invalid day of month: %w", err)
+	}
+	if err := parseField(fields[3], 1, 12, s.months[:]); err != nil {
+		return nil, fmt.Errorf("invalid month: %w", err)
+	}
+
+	// 7 is also Sunday
+	var dows [8]bool
+	if err := parseField(fields[4], 0, 7, dows[:]); err != nil {
+		return nil, fmt.Errorf("invalid day of week: %w", err)
+	}
+	copy(s.dows[:], dows[:7])
+	s.dows[0] = s.dows[0] || dows[7]
+
+	return s, nil
+}
+
+func parseField(field string, min int, max int, set []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return fmt.Errorf("invalid step %q", part)
+			}
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return fmt.Errorf("invalid range %q", part)
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return fmt.Errorf("invalid value %q", part)
+			}
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("%q out of range [%d, %d]", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+// Next returns the next scheduled time strictly after the given time,
+// in the location of the given time.
+// Returns zero time if none is found within 5 years (e.g., "0 0 30 2 *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		if !s.months[t.Month()] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// ref. "man 5 crontab": if both day fields are restricted, either matching is sufficient
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom, dow := s.doms[t.Day()], s.dows[t.Weekday()]
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,50 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNext(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday
+	base := time.Date(2024, 11, 13, 15, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		expr    string
+		want    time.Time
+		wantErr bool
+	}{
+		{expr: "* * * * *", want: time.Date(2024, 11, 13, 15, 31, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", want: time.Date(2024, 11, 13, 15, 45, 0, 0, time.UTC)},
+		{expr: "0 3 * * *", want: time.Date(2024, 11, 14, 3, 0, 0, 0, time.UTC)},
+		{expr: "@daily", want: time.Date(2024, 11, 14, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 2 * * 0", want: time.Date(2024, 11, 17, 2, 0, 0, 0, time.UTC)},
+		{expr: "0 2 * * 7", want: time.Date(2024, 11, 17, 2, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", want: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "30 1 1-7 * 6", want: time.Date(2024, 11, 16, 1, 30, 0, 0, time.UTC)},
+		{expr: "0 9,17 * * 1-5", want: time.Date(2024, 11, 13, 17, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 1 *", want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", want: time.Time{}},
+		{expr: "0 3 * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "5-1 * * * *", wantErr: true},
+		{expr: "a * * * *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := s.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
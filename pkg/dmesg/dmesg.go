@@ -30,6 +30,43 @@ func ParseISOtimeWithError(line []byte) (time.Time, []byte, error) {
 	return parsedTime, extractedLine, nil
 }
 
+// "journalctl -o short-iso-precise" timestamp formats,
+// with the colon in the timezone offset since systemd v250.
+var journalISOTimeFormats = []string{
+	"2006-01-02T15:04:05.999999-07:00",
+	"2006-01-02T15:04:05.999999-0700",
+}
+
+// Parses the timestamp from "journalctl -k -o short-iso-precise" output lines,
+// and strips the hostname and the "kernel:" identifier to match the dmesg output lines.
+//
+// Example input: 2024-11-15T12:02:03.561522+00:00 gpu-node-1 kernel: NVRM: Xid ...
+func ParseJournalISOTimeWithError(line []byte) (time.Time, []byte, error) {
+	ts, rest, ok := bytes.Cut(line, []byte(" "))
+	if !ok {
+		return time.Time{}, nil, errors.New("no timestamp found")
+	}
+
+	var (
+		parsedTime time.Time
+		err        error
+	)
+	for _, layout := range journalISOTimeFormats {
+		parsedTime, err = time.Parse(layout, string(ts))
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	if _, msg, ok := bytes.Cut(rest, []byte(" kernel: ")); ok {
+		rest = msg
+	}
+	return parsedTime, bytes.TrimSpace(rest), nil
+}
+
 var regexForDmesgTime = regexp.MustCompile(`^\[([^\]]+)\]`)
 
 // Parses the timestamp from "dmesg --ctime" output lines.
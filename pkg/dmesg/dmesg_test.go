@@ -73,6 +73,60 @@ func TestParseISOtimeWithError(t *testing.T) {
 	}
 }
 
+func TestParseJournalISOTimeWithError(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     []byte
+		want     time.Time
+		wantLine []byte
+		wantErr  bool
+	}{
+		{
+			name:     "ColonOffset",
+			line:     []byte("2024-11-15T12:02:03.561522+00:00 gpu-node-1 kernel: NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus."),
+			want:     time.Date(2024, 11, 15, 12, 2, 3, 561522000, time.UTC),
+			wantLine: []byte("NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus."),
+		},
+		{
+			name:     "NoColonOffset",
+			line:     []byte("2024-11-15T21:02:03.561522+0900 gpu-node-1 kernel: abc"),
+			want:     time.Date(2024, 11, 15, 12, 2, 3, 561522000, time.UTC),
+			wantLine: []byte("abc"),
+		},
+		{
+			name:     "NoIdentifier",
+			line:     []byte("2024-11-15T12:02:03.561522+00:00 abc"),
+			want:     time.Date(2024, 11, 15, 12, 2, 3, 561522000, time.UTC),
+			wantLine: []byte("abc"),
+		},
+		{
+			name:    "BootSeparator",
+			line:    []byte("-- Boot 0a1b2c3d --"),
+			wantErr: true,
+		},
+		{
+			name:    "NoSpace",
+			line:    []byte("2024-11-15T12:02:03.561522+00:00"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, line, err := ParseJournalISOTimeWithError(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseJournalISOTimeWithError() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseJournalISOTimeWithError() got = %v, want %v", got, tt.want)
+			}
+			if !bytes.Equal(line, tt.wantLine) {
+				t.Errorf("ParseJournalISOTimeWithError() line = %v, want %v", string(line), string(tt.wantLine))
+			}
+		})
+	}
+}
+
 func TestParseCtime(t *testing.T) {
 	tests := []struct {
 		name     string
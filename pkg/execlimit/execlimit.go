@@ -0,0 +1,198 @@
+// Package execlimit rate limits the external command executions (e.g., "lspci", "nvidia-smi"),
+// globally and per component, with the token buckets.
+// Otherwise, a misconfigured short poll interval can fork dozens of processes per second on the large nodes.
+package execlimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/log"
+)
+
+const (
+	DefaultGlobalPerSecond = 10.0
+	DefaultGlobalBurst     = 20
+	DefaultKeyPerSecond    = 1.0
+	DefaultKeyBurst        = 5
+)
+
+// Config configures the external command rate limits.
+type Config struct {
+	// Rate of the command executions per second across all components, defaults to 10.
+	GlobalPerSecond float64 `json:"global_per_second"`
+	// Maximum burst of the command executions across all components, defaults to 20.
+	GlobalBurst int `json:"global_burst"`
+
+	// Rate of the command executions per second per component (or command), defaults to 1.
+	KeyPerSecond float64 `json:"key_per_second"`
+	// Maximum burst of the command executions per component (or command), defaults to 5.
+	KeyBurst int `json:"key_burst"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.GlobalPerSecond == 0 {
+		cfg.GlobalPerSecond = DefaultGlobalPerSecond
+	}
+	if cfg.GlobalBurst == 0 {
+		cfg.GlobalBurst = DefaultGlobalBurst
+	}
+	if cfg.KeyPerSecond == 0 {
+		cfg.KeyPerSecond = DefaultKeyPerSecond
+	}
+	if cfg.KeyBurst == 0 {
+		cfg.KeyBurst = DefaultKeyBurst
+	}
+}
+
+func (cfg Config) Validate() error {
+	if cfg.GlobalPerSecond < 0 {
+		return fmt.Errorf("global_per_second must be non-negative, got %v", cfg.GlobalPerSecond)
+	}
+	if cfg.GlobalBurst < 0 {
+		return fmt.Errorf("global_burst must be non-negative, got %d", cfg.GlobalBurst)
+	}
+	if cfg.KeyPerSecond < 0 {
+		return fmt.Errorf("key_per_second must be non-negative, got %v", cfg.KeyPerSecond)
+	}
+	if cfg.KeyBurst < 0 {
+		return fmt.Errorf("key_burst must be non-negative, got %d", cfg.KeyBurst)
+	}
+	if cfg.KeyBurst > 0 && cfg.GlobalBurst > 0 && cfg.KeyBurst > cfg.GlobalBurst {
+		return errors.New("key_burst must not exceed global_burst")
+	}
+	return nil
+}
+
+// bucket is a token bucket, refilled at the rate up to the burst.
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int, now time.Time) *bucket {
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// wait returns the duration until a token is available.
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Limiter rate limits the command executions.
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu     sync.Mutex
+	global *bucket
+	keys   map[string]*bucket
+}
+
+// New creates a limiter with the config.
+func New(cfg Config) *Limiter {
+	cfg.SetDefaultsIfNotSet()
+	return &Limiter{
+		cfg:  cfg,
+		now:  time.Now,
+		keys: make(map[string]*bucket),
+	}
+}
+
+// reserve takes a token from both the global and the key buckets if available,
+// otherwise returns the duration to wait before retrying.
+func (l *Limiter) reserve(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.global == nil {
+		l.global = newBucket(l.cfg.GlobalPerSecond, l.cfg.GlobalBurst, now)
+	}
+	l.global.refill(now)
+	wait := l.global.wait()
+
+	var kb *bucket
+	if key != "" {
+		kb = l.keys[key]
+		if kb == nil {
+			kb = newBucket(l.cfg.KeyPerSecond, l.cfg.KeyBurst, now)
+			l.keys[key] = kb
+		}
+		kb.refill(now)
+		if w := kb.wait(); w > wait {
+			wait = w
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	l.global.tokens--
+	if kb != nil {
+		kb.tokens--
+	}
+	return 0
+}
+
+// Wait blocks until the command execution for the key (e.g., the component name) is allowed,
+// or the context is done. The empty key is only limited by the global rate.
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	logged := false
+	for {
+		wait := l.reserve(key)
+		if wait == 0 {
+			return nil
+		}
+		if !logged {
+			log.Logger.Debugw("rate limiting command execution", "key", key, "wait", wait)
+			logged = true
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultLimiter = New(Config{})
+)
+
+// Configure replaces the default limiter with the config.
+func Configure(cfg Config) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLimiter = New(cfg)
+}
+
+// Wait blocks until the command execution for the key is allowed by the default limiter.
+func Wait(ctx context.Context, key string) error {
+	defaultMu.RLock()
+	l := defaultLimiter
+	defaultMu.RUnlock()
+	return l.Wait(ctx, key)
+}
@@ -0,0 +1,64 @@
+package execlimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterReserve(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := New(Config{GlobalPerSecond: 10, GlobalBurst: 3, KeyPerSecond: 1, KeyBurst: 2})
+	l.now = func() time.Time { return now }
+
+	// the key burst is exhausted first
+	for i := 0; i < 2; i++ {
+		if wait := l.reserve("a"); wait != 0 {
+			t.Fatalf("expected no wait for the burst, got %v", wait)
+		}
+	}
+	if wait := l.reserve("a"); wait != time.Second {
+		t.Fatalf("expected 1s wait for the key, got %v", wait)
+	}
+
+	// the other key is only limited by the remaining global token
+	if wait := l.reserve("b"); wait != 0 {
+		t.Fatalf("expected no wait, got %v", wait)
+	}
+	if wait := l.reserve("b"); wait != 100*time.Millisecond {
+		t.Fatalf("expected 100ms wait for the global, got %v", wait)
+	}
+
+	now = now.Add(time.Second)
+	if wait := l.reserve("a"); wait != 0 {
+		t.Fatalf("expected no wait after the refill, got %v", wait)
+	}
+	if wait := l.reserve(""); wait != 0 {
+		t.Fatalf("expected no wait for the empty key, got %v", wait)
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := New(Config{GlobalPerSecond: 1000, GlobalBurst: 1, KeyPerSecond: 0.001, KeyBurst: 1})
+	if err := l.Wait(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "a"); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaultsIfNotSet()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.KeyBurst = cfg.GlobalBurst + 1
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for the key burst exceeding the global burst")
+	}
+}
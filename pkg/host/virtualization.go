@@ -0,0 +1,64 @@
+package host
+
+import (
+	"context"
+
+	"github.com/shirou/gopsutil/v4/host"
+)
+
+const (
+	VirtualizationRoleGuest = "guest"
+	VirtualizationRoleHost  = "host"
+)
+
+// VirtualizationEnvironment represents the virtualization environment of the host.
+type VirtualizationEnvironment struct {
+	// Type is the virtualization technology (e.g., "kvm", "xen", "hyperv", "vmware", "docker").
+	// Empty if no virtualization is detected (bare metal).
+	Type string `json:"type"`
+	// Role is either "guest" or "host".
+	// Empty if no virtualization is detected (bare metal).
+	Role string `json:"role"`
+}
+
+// containerTypes are the virtualization types that do not run a separate kernel.
+// The container shares the hardware with the host, so the host is still
+// considered bare metal in terms of hardware access (e.g., IPMI).
+var containerTypes = map[string]struct{}{
+	"docker":        {},
+	"lxc":           {},
+	"rkt":           {},
+	"openvz":        {},
+	"linux-vserver": {},
+	"podman":        {},
+}
+
+// IsBareMetal returns true if the host is not running as a VM guest.
+// Hypervisor hosts (e.g., KVM host) and containers running on bare metal
+// are considered bare metal.
+func (v VirtualizationEnvironment) IsBareMetal() bool {
+	return !v.IsVMGuest()
+}
+
+// IsVMGuest returns true if the host is a virtual machine guest
+// (e.g., KVM, Xen, Hyper-V, VMware).
+func (v VirtualizationEnvironment) IsVMGuest() bool {
+	if v.Role != VirtualizationRoleGuest {
+		return false
+	}
+	_, isContainer := containerTypes[v.Type]
+	return !isContainer
+}
+
+// GetVirtualizationEnvironment detects the virtualization environment of the host.
+// The result is cached by the underlying library after the first call.
+func GetVirtualizationEnvironment(ctx context.Context) (VirtualizationEnvironment, error) {
+	system, role, err := host.VirtualizationWithContext(ctx)
+	if err != nil {
+		return VirtualizationEnvironment{}, err
+	}
+	return VirtualizationEnvironment{
+		Type: system,
+		Role: role,
+	}, nil
+}
@@ -0,0 +1,31 @@
+package host
+
+import "testing"
+
+func TestVirtualizationEnvironment(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       VirtualizationEnvironment
+		vmGuest   bool
+		bareMetal bool
+	}{
+		{name: "bare metal", env: VirtualizationEnvironment{}, vmGuest: false, bareMetal: true},
+		{name: "kvm guest", env: VirtualizationEnvironment{Type: "kvm", Role: VirtualizationRoleGuest}, vmGuest: true, bareMetal: false},
+		{name: "kvm host", env: VirtualizationEnvironment{Type: "kvm", Role: VirtualizationRoleHost}, vmGuest: false, bareMetal: true},
+		{name: "xen guest", env: VirtualizationEnvironment{Type: "xen", Role: VirtualizationRoleGuest}, vmGuest: true, bareMetal: false},
+		{name: "hyperv guest", env: VirtualizationEnvironment{Type: "hyperv", Role: VirtualizationRoleGuest}, vmGuest: true, bareMetal: false},
+		{name: "docker container", env: VirtualizationEnvironment{Type: "docker", Role: VirtualizationRoleGuest}, vmGuest: false, bareMetal: true},
+		{name: "lxc container", env: VirtualizationEnvironment{Type: "lxc", Role: VirtualizationRoleGuest}, vmGuest: false, bareMetal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.env.IsVMGuest(); got != tt.vmGuest {
+				t.Errorf("IsVMGuest() = %v, want %v", got, tt.vmGuest)
+			}
+			if got := tt.env.IsBareMetal(); got != tt.bareMetal {
+				t.Errorf("IsBareMetal() = %v, want %v", got, tt.bareMetal)
+			}
+		})
+	}
+}
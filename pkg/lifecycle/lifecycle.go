@@ -0,0 +1,270 @@
+// Package lifecycle implements the node lifecycle state machine
+// (provisioning, in-service, draining, repairing), stored by gpud and set by the operators,
+// which modulates the gpud behavior (e.g., no alerts during provisioning).
+package lifecycle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// State is the node lifecycle state.
+type State string
+
+const (
+	// StateProvisioning is the node being set up (e.g., driver install, burn-in),
+	// where the failures are expected, thus no alert is sent.
+	StateProvisioning State = "provisioning"
+	// StateInService is the node serving the workloads (default).
+	StateInService State = "in-service"
+	// StateDraining is the node not accepting the new workloads,
+	// while the existing workloads complete.
+	StateDraining State = "draining"
+	// StateRepairing is the node out of service for the repair and its validation,
+	// where the components are polled and the active probes run aggressively.
+	StateRepairing State = "repairing"
+)
+
+// States lists the supported states.
+var States = []State{StateProvisioning, StateInService, StateDraining, StateRepairing}
+
+// DefaultState is the state of the node without any transition recorded.
+const DefaultState = StateInService
+
+// RepairPollInterval is the maximum poll interval while repairing.
+const RepairPollInterval = 15 * time.Second
+
+var ErrInvalidTransition = errors.New("invalid lifecycle transition")
+
+// transitions defines the allowed next states of each state.
+var transitions = map[State][]State{
+	StateProvisioning: {StateInService, StateRepairing},
+	StateInService:    {StateDraining, StateRepairing},
+	StateDraining:     {StateInService, StateRepairing},
+	StateRepairing:    {StateProvisioning, StateInService, StateDraining},
+}
+
+// Validate returns an error if the state is not supported.
+func (s State) Validate() error {
+	if _, ok := transitions[s]; !ok {
+		return fmt.Errorf("unknown lifecycle state %q (expected one of %v)", s, States)
+	}
+	return nil
+}
+
+// CanTransition returns true if the state can transition to the next state.
+func CanTransition(from State, to State) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+const TableNameLifecycleTransitions = "node_lifecycle_transitions"
+
+const (
+	ColumnUnixSeconds = "unix_seconds"
+	ColumnFromState   = "from_state"
+	ColumnToState     = "to_state"
+	ColumnReason      = "reason"
+)
+
+// Transition is a recorded lifecycle state change.
+type Transition struct {
+	Time   time.Time `json:"time"`
+	From   State     `json:"from"`
+	To     State     `json:"to"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT
+);`, TableNameLifecycleTransitions,
+		ColumnUnixSeconds,
+		ColumnFromState,
+		ColumnToState,
+		ColumnReason,
+	))
+	return err
+}
+
+func InsertTransition(ctx context.Context, db *sql.DB, t Transition) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?);`,
+		TableNameLifecycleTransitions,
+		ColumnUnixSeconds,
+		ColumnFromState,
+		ColumnToState,
+		ColumnReason,
+	), t.Time.UTC().Unix(), string(t.From), string(t.To), t.Reason)
+	return err
+}
+
+// ReadTransitions returns the recorded transitions, the latest first.
+// Zero limit returns all the transitions.
+func ReadTransitions(ctx context.Context, db *sql.DB, limit int) ([]Transition, error) {
+	query := fmt.Sprintf(`SELECT %s, %s, %s, %s FROM %s ORDER BY %s DESC, rowid DESC`,
+		ColumnUnixSeconds,
+		ColumnFromState,
+		ColumnToState,
+		ColumnReason,
+		TableNameLifecycleTransitions,
+		ColumnUnixSeconds,
+	)
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	query += ";"
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ts []Transition
+	for rows.Next() {
+		var (
+			unixSecs int64
+			from, to string
+			reason   sql.NullString
+		)
+		if err := rows.Scan(&unixSecs, &from, &to, &reason); err != nil {
+			return nil, err
+		}
+		ts = append(ts, Transition{
+			Time:   time.Unix(unixSecs, 0).UTC(),
+			From:   State(from),
+			To:     State(to),
+			Reason: reason.String,
+		})
+	}
+	return ts, rows.Err()
+}
+
+// Status is the current lifecycle state of the node.
+type Status struct {
+	State State `json:"state"`
+	// Since is the time of the last transition, zero if no transition is recorded.
+	Since  time.Time `json:"since,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+var (
+	mu      sync.RWMutex
+	current = Status{State: DefaultState}
+)
+
+// Load restores the current state from the latest recorded transition.
+func Load(ctx context.Context, db *sql.DB) error {
+	ts, err := ReadTransitions(ctx, db, 1)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	current = Status{State: DefaultState}
+	if len(ts) > 0 {
+		current = Status{State: ts[0].To, Since: ts[0].Time, Reason: ts[0].Reason}
+	}
+	setGauge(current.State)
+	return nil
+}
+
+// Current returns the current lifecycle status of the node.
+func Current() Status {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Set transitions the node to the state, and records the transition.
+// Returns ErrInvalidTransition if the current state cannot transition to the state.
+// Setting the current state is a no-op.
+func Set(ctx context.Context, db *sql.DB, to State, reason string, now time.Time) (Status, error) {
+	if err := to.Validate(); err != nil {
+		return Status{}, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if current.State == to {
+		return current, nil
+	}
+	if !CanTransition(current.State, to) {
+		return Status{}, fmt.Errorf("%w from %q to %q (expected one of %v)", ErrInvalidTransition, current.State, to, transitions[current.State])
+	}
+
+	t := Transition{Time: now.UTC(), From: current.State, To: to, Reason: reason}
+	if err := InsertTransition(ctx, db, t); err != nil {
+		return Status{}, err
+	}
+	current = Status{State: to, Since: t.Time, Reason: reason}
+	setGauge(current.State)
+	return current, nil
+}
+
+// AlertsSuppressed returns true if the notifications should not be sent
+// in the current state (e.g., provisioning).
+func AlertsSuppressed() bool {
+	return Current().State == StateProvisioning
+}
+
+// Repairing returns true if the node is being repaired (and validated),
+// where the active probes do not wait for the idle node.
+func Repairing() bool {
+	return Current().State == StateRepairing
+}
+
+// PollInterval returns the poll interval to use for the configured interval,
+// capped to RepairPollInterval while repairing.
+func PollInterval(interval time.Duration) time.Duration {
+	if Repairing() && interval > RepairPollInterval {
+		return RepairPollInterval
+	}
+	return interval
+}
+
+var gaugeState = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "gpud",
+		Subsystem: "node",
+		Name:      "lifecycle_state",
+		Help:      "tracks the current node lifecycle state (1 for the current state, 0 otherwise)",
+	},
+	[]string{"state"},
+)
+
+func init() {
+	setGauge(DefaultState)
+}
+
+func setGauge(cur State) {
+	for _, s := range States {
+		v := 0.0
+		if s == cur {
+			v = 1.0
+		}
+		gaugeState.WithLabelValues(string(s)).Set(v)
+	}
+}
+
+func Register(reg *prometheus.Registry) error {
+	return reg.Register(gaugeState)
+}
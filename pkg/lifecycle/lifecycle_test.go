@@ -0,0 +1,100 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from     State
+		to       State
+		expected bool
+	}{
+		{from: StateProvisioning, to: StateInService, expected: true},
+		{from: StateProvisioning, to: StateDraining, expected: false},
+		{from: StateInService, to: StateDraining, expected: true},
+		{from: StateInService, to: StateProvisioning, expected: false},
+		{from: StateDraining, to: StateRepairing, expected: true},
+		{from: StateRepairing, to: StateProvisioning, expected: true},
+		{from: StateRepairing, to: StateInService, expected: true},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.expected {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.expected)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if got := Current(); got.State != DefaultState || !got.Since.IsZero() {
+		t.Fatalf("expected default state, got %+v", got)
+	}
+
+	if _, err := Set(ctx, db, State("unknown"), "", time.Now()); err == nil {
+		t.Fatal("expected error for unknown state")
+	}
+	if _, err := Set(ctx, db, StateProvisioning, "", time.Now()); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected %v, got %v", ErrInvalidTransition, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if _, err := Set(ctx, db, StateRepairing, "xid 79", now); err != nil {
+		t.Fatal(err)
+	}
+	if !Repairing() || PollInterval(time.Minute) != RepairPollInterval || PollInterval(time.Second) != time.Second {
+		t.Fatal("expected repairing behavior")
+	}
+	if _, err := Set(ctx, db, StateProvisioning, "reimage", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if !AlertsSuppressed() {
+		t.Fatal("expected alerts suppressed while provisioning")
+	}
+	// no-op
+	if _, err := Set(ctx, db, StateProvisioning, "", now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := ReadTransitions(ctx, db, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 2 || ts[0].From != StateRepairing || ts[0].To != StateProvisioning || ts[1].Reason != "xid 79" {
+		t.Fatalf("unexpected transitions %+v", ts)
+	}
+
+	// restored after the restart
+	mu.Lock()
+	current = Status{State: DefaultState}
+	mu.Unlock()
+	if err := Load(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if got := Current(); got.State != StateProvisioning || got.Reason != "reimage" || !got.Since.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected restored status %+v", got)
+	}
+
+	mu.Lock()
+	current = Status{State: DefaultState}
+	mu.Unlock()
+}
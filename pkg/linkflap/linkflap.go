@@ -0,0 +1,152 @@
+// Package linkflap implements the stateful link flap detection,
+// counting the link down to up transitions within a sliding window.
+// A flapping link may be up at the time of the check, but still
+// degrades the collective communication performance intermittently.
+package linkflap
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWindow is the default sliding window to count the flaps.
+	DefaultWindow = time.Hour
+
+	// DefaultThreshold is the default number of flaps within the window
+	// to consider the link as flapping.
+	DefaultThreshold = 3
+)
+
+// Detector tracks the link states and counts the flaps per link.
+type Detector struct {
+	mu     sync.Mutex
+	window time.Duration
+	links  map[string]*linkState
+}
+
+type linkState struct {
+	up           bool
+	lastObserved time.Time
+	flaps        []time.Time
+}
+
+// New creates a new flap detector with the sliding window.
+// If the window is zero, it defaults to DefaultWindow.
+func New(window time.Duration) *Detector {
+	if window == 0 {
+		window = DefaultWindow
+	}
+	return &Detector{
+		window: window,
+		links:  make(map[string]*linkState),
+	}
+}
+
+// Observe records the link state at the given time.
+// A down to up transition is counted as a flap.
+// Observations older than or equal to the last observation are ignored,
+// so it is safe to observe the same sample multiple times.
+func (d *Detector) Observe(link string, up bool, ts time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	st, ok := d.links[link]
+	if !ok {
+		d.links[link] = &linkState{up: up, lastObserved: ts}
+		return
+	}
+	if !ts.After(st.lastObserved) {
+		return
+	}
+
+	if !st.up && up {
+		st.flaps = append(st.flaps, ts)
+	}
+	st.up = up
+	st.lastObserved = ts
+	st.flaps = prune(st.flaps, ts.Add(-d.window))
+}
+
+// Flaps returns the number of flaps per link within the window
+// ending at the given time. Links without any flap are omitted.
+func (d *Detector) Flaps(now time.Time) map[string]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	since := now.Add(-d.window)
+	flaps := make(map[string]int)
+	for link, st := range d.links {
+		st.flaps = prune(st.flaps, since)
+		if len(st.flaps) > 0 {
+			flaps[link] = len(st.flaps)
+		}
+	}
+	return flaps
+}
+
+// Flapping returns the sorted list of links whose flap count within the window
+// is equal to or greater than the threshold.
+func (d *Detector) Flapping(now time.Time, threshold int) []string {
+	links := make([]string, 0)
+	for link, cnt := range d.Flaps(now) {
+		if cnt >= threshold {
+			links = append(links, link)
+		}
+	}
+	sort.Strings(links)
+	return links
+}
+
+func prune(flaps []time.Time, since time.Time) []time.Time {
+	idx := 0
+	for idx < len(flaps) && flaps[idx].Before(since) {
+		idx++
+	}
+	return flaps[idx:]
+}
+
+// Snapshot is the serializable state of the detector,
+// to restore the flap counts across the restarts.
+type Snapshot struct {
+	Links map[string]LinkSnapshot `json:"links"`
+}
+
+// LinkSnapshot is the serializable state of a link.
+type LinkSnapshot struct {
+	Up           bool        `json:"up"`
+	LastObserved time.Time   `json:"last_observed"`
+	Flaps        []time.Time `json:"flaps,omitempty"`
+}
+
+// Snapshot returns the current state of the detector.
+func (d *Detector) Snapshot() Snapshot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s := Snapshot{Links: make(map[string]LinkSnapshot, len(d.links))}
+	for link, st := range d.links {
+		s.Links[link] = LinkSnapshot{
+			Up:           st.up,
+			LastObserved: st.lastObserved,
+			Flaps:        append([]time.Time(nil), st.flaps...),
+		}
+	}
+	return s
+}
+
+// Restore replaces the state of the detector with the snapshot.
+func (d *Detector) Restore(s Snapshot) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.links = make(map[string]*linkState, len(s.Links))
+	for link, ls := range s.Links {
+		d.links[link] = &linkState{
+			up:           ls.Up,
+			lastObserved: ls.LastObserved,
+			flaps:        append([]time.Time(nil), ls.Flaps...),
+		}
+	}
+}
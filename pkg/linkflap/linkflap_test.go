@@ -0,0 +1,72 @@
+package linkflap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetector(t *testing.T) {
+	d := New(time.Hour)
+	now := time.Unix(0, 0)
+
+	// down -> up -> down -> up -> down -> up: 3 flaps on "a"
+	states := []bool{true, false, true, false, true, false, true}
+	for i, up := range states {
+		d.Observe("a", up, now.Add(time.Duration(i)*time.Minute))
+	}
+	// "b" is always up
+	for i := 0; i < len(states); i++ {
+		d.Observe("b", true, now.Add(time.Duration(i)*time.Minute))
+	}
+
+	// duplicate observation must be ignored
+	d.Observe("a", false, now.Add(6*time.Minute))
+
+	ts := now.Add(10 * time.Minute)
+	flaps := d.Flaps(ts)
+	if !reflect.DeepEqual(flaps, map[string]int{"a": 3}) {
+		t.Fatalf("unexpected flaps %v", flaps)
+	}
+	if links := d.Flapping(ts, 3); !reflect.DeepEqual(links, []string{"a"}) {
+		t.Fatalf("unexpected flapping links %v", links)
+	}
+	if links := d.Flapping(ts, 4); len(links) != 0 {
+		t.Fatalf("unexpected flapping links %v", links)
+	}
+
+	// the flaps expire after the window
+	if flaps := d.Flaps(now.Add(2 * time.Hour)); len(flaps) != 0 {
+		t.Fatalf("expected no flaps after the window, got %v", flaps)
+	}
+}
+
+func TestDetectorDefaultWindow(t *testing.T) {
+	d := New(0)
+	if d.window != DefaultWindow {
+		t.Fatalf("expected window %v, got %v", DefaultWindow, d.window)
+	}
+}
+
+func TestDetectorSnapshotRestore(t *testing.T) {
+	d := New(time.Hour)
+	now := time.Unix(0, 0)
+	for i, up := range []bool{true, false, true, false, true} {
+		d.Observe("a", up, now.Add(time.Duration(i)*time.Minute))
+	}
+
+	restored := New(time.Hour)
+	restored.Restore(d.Snapshot())
+
+	ts := now.Add(10 * time.Minute)
+	if !reflect.DeepEqual(restored.Flaps(ts), d.Flaps(ts)) {
+		t.Fatalf("restored flaps %v, want %v", restored.Flaps(ts), d.Flaps(ts))
+	}
+
+	// the restored detector keeps counting from the last observation
+	restored.Observe("a", false, now.Add(5*time.Minute))
+	restored.Observe("a", true, now.Add(6*time.Minute))
+	if flaps := restored.Flaps(ts); flaps["a"] != 3 {
+		t.Fatalf("expected 3 flaps after restore, got %v", flaps)
+	}
+}
@@ -0,0 +1,211 @@
+// Package lkg tracks the last-known-good (LKG) snapshot of the node configuration
+// (e.g., driver, firmware, kernel versions), taken when the node has been fully healthy
+// for a period, so that the subsequent failures can be compared against it.
+package lkg
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/log"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const TableNameLastKnownGood = "last_known_good"
+
+const (
+	// fixed row id, as only the latest snapshot is kept
+	ColumnID = "id"
+
+	// unix timestamp in seconds when the snapshot was taken
+	ColumnUnixSeconds = "unix_seconds"
+
+	// JSON-encoded snapshot values
+	ColumnValues = "snapshot_values"
+)
+
+const snapshotID = 1
+
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL PRIMARY KEY,
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL
+);`, TableNameLastKnownGood,
+		ColumnID,
+		ColumnUnixSeconds,
+		ColumnValues,
+	))
+	return err
+}
+
+// Snapshot is the node configuration at a point in time
+// (e.g., {"driver_version": "550.90.07", "kernel_version": "5.15.0-1053"}).
+type Snapshot struct {
+	Time   time.Time         `json:"time"`
+	Values map[string]string `json:"values"`
+}
+
+// Change is a configuration value changed since the last-known-good snapshot.
+// An empty value means the key is missing.
+type Change struct {
+	Key           string `json:"key"`
+	LastKnownGood string `json:"last_known_good"`
+	Current       string `json:"current"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Key, c.LastKnownGood, c.Current)
+}
+
+// Diff returns the changes from the last-known-good values to the current values, sorted by key.
+func Diff(lastKnownGood map[string]string, current map[string]string) []Change {
+	var changes []Change
+	for k, v := range lastKnownGood {
+		if cur := current[k]; cur != v {
+			changes = append(changes, Change{Key: k, LastKnownGood: v, Current: cur})
+		}
+	}
+	for k, cur := range current {
+		if _, ok := lastKnownGood[k]; !ok {
+			changes = append(changes, Change{Key: k, Current: cur})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// Save replaces the saved snapshot.
+func Save(ctx context.Context, db *sql.DB, s Snapshot) error {
+	b, err := json.Marshal(s.Values)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`INSERT OR REPLACE INTO %s (%s, %s, %s) VALUES (?, ?, ?);`,
+		TableNameLastKnownGood,
+		ColumnID,
+		ColumnUnixSeconds,
+		ColumnValues,
+	), snapshotID, s.Time.UTC().Unix(), string(b))
+	return err
+}
+
+// Load returns the saved snapshot, or nil if not found.
+func Load(ctx context.Context, db *sql.DB) (*Snapshot, error) {
+	var (
+		unixSecs int64
+		values   string
+	)
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s = ?;`,
+		ColumnUnixSeconds,
+		ColumnValues,
+		TableNameLastKnownGood,
+		ColumnID,
+	), snapshotID).Scan(&unixSecs, &values)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snapshot{Time: time.Unix(unixSecs, 0).UTC()}
+	if err := json.Unmarshal([]byte(values), &s.Values); err != nil {
+		return nil, fmt.Errorf("failed to decode last-known-good snapshot: %w", err)
+	}
+	return s, nil
+}
+
+// CollectFunc collects the current node configuration values.
+type CollectFunc func(ctx context.Context) (map[string]string, error)
+
+// Tracker takes the last-known-good snapshot whenever the node has been
+// fully healthy for the healthy period, and at most once per the period.
+type Tracker struct {
+	db            *sql.DB
+	healthyPeriod time.Duration
+	collect       CollectFunc
+
+	mu           sync.RWMutex
+	healthySince time.Time
+	lastKnown    *Snapshot
+}
+
+// NewTracker creates the table (if not exists) and loads the saved snapshot.
+func NewTracker(ctx context.Context, db *sql.DB, healthyPeriod time.Duration, collect CollectFunc) (*Tracker, error) {
+	if err := CreateTable(ctx, db); err != nil {
+		return nil, err
+	}
+	s, err := Load(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	return &Tracker{
+		db:            db,
+		healthyPeriod: healthyPeriod,
+		collect:       collect,
+		lastKnown:     s,
+	}, nil
+}
+
+// Observe records the overall node health at the given time,
+// and takes the snapshot if the node has been healthy for the healthy period.
+func (t *Tracker) Observe(ctx context.Context, healthy bool, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !healthy {
+		t.healthySince = time.Time{}
+		return nil
+	}
+	if t.healthySince.IsZero() {
+		t.healthySince = now
+	}
+	if now.Sub(t.healthySince) < t.healthyPeriod {
+		return nil
+	}
+	if t.lastKnown != nil && now.Sub(t.lastKnown.Time) < t.healthyPeriod {
+		return nil
+	}
+
+	values, err := t.collect(ctx)
+	if err != nil {
+		return err
+	}
+	s := Snapshot{Time: now.UTC(), Values: values}
+	if err := Save(ctx, t.db, s); err != nil {
+		return err
+	}
+	log.Logger.Infow("saved last-known-good snapshot", "healthySince", t.healthySince, "values", len(values))
+	t.lastKnown = &s
+	return nil
+}
+
+// LastKnownGood returns the last-known-good snapshot, or nil if not taken yet.
+func (t *Tracker) LastKnownGood() *Snapshot {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.lastKnown
+}
+
+// Delta returns the changes from the last-known-good snapshot to the current values.
+// Returns nil if no snapshot is taken yet.
+func (t *Tracker) Delta(ctx context.Context) ([]Change, error) {
+	s := t.LastKnownGood()
+	if s == nil {
+		return nil, nil
+	}
+	current, err := t.collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(s.Values, current), nil
+}
@@ -0,0 +1,121 @@
+package lkg
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		lkg      map[string]string
+		current  map[string]string
+		expected []Change
+	}{
+		{
+			name:    "no change",
+			lkg:     map[string]string{"driver_version": "550.90.07"},
+			current: map[string]string{"driver_version": "550.90.07"},
+		},
+		{
+			name:    "changed, removed, and added",
+			lkg:     map[string]string{"driver_version": "550.90.07", "gsp_firmware_mode": "enabled"},
+			current: map[string]string{"driver_version": "560.35.03", "kernel_version": "6.8.0"},
+			expected: []Change{
+				{Key: "driver_version", LastKnownGood: "550.90.07", Current: "560.35.03"},
+				{Key: "gsp_firmware_mode", LastKnownGood: "enabled", Current: ""},
+				{Key: "kernel_version", LastKnownGood: "", Current: "6.8.0"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.lkg, tt.current); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	current := map[string]string{"driver_version": "550.90.07"}
+	collect := func(ctx context.Context) (map[string]string, error) {
+		cp := make(map[string]string, len(current))
+		for k, v := range current {
+			cp[k] = v
+		}
+		return cp, nil
+	}
+
+	tr, err := NewTracker(ctx, db, time.Hour, collect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.LastKnownGood() != nil {
+		t.Fatal("expected no snapshot")
+	}
+	if delta, err := tr.Delta(ctx); err != nil || delta != nil {
+		t.Fatalf("expected no delta without snapshot, got %v, %v", delta, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	steps := []struct {
+		healthy  bool
+		offset   time.Duration
+		snapshot bool
+	}{
+		{healthy: true, offset: 0},
+		{healthy: true, offset: 30 * time.Minute},
+		// unhealthy resets the healthy period
+		{healthy: false, offset: 40 * time.Minute},
+		{healthy: true, offset: 50 * time.Minute},
+		{healthy: true, offset: 90 * time.Minute},
+		{healthy: true, offset: 110 * time.Minute, snapshot: true},
+	}
+	for i, s := range steps {
+		if err := tr.Observe(ctx, s.healthy, now.Add(s.offset)); err != nil {
+			t.Fatal(err)
+		}
+		if got := tr.LastKnownGood() != nil; got != s.snapshot {
+			t.Fatalf("step %d: snapshot taken = %v, want %v", i, got, s.snapshot)
+		}
+	}
+
+	current["driver_version"] = "560.35.03"
+	delta, err := tr.Delta(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Change{{Key: "driver_version", LastKnownGood: "550.90.07", Current: "560.35.03"}}
+	if !reflect.DeepEqual(delta, expected) {
+		t.Fatalf("Delta() = %+v, want %+v", delta, expected)
+	}
+
+	// the snapshot is restored after the restart
+	tr2, err := NewTracker(ctx, db, time.Hour, collect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tr2.LastKnownGood()
+	if s == nil {
+		t.Fatal("expected snapshot to be restored")
+	}
+	if !s.Time.Equal(now.Add(110*time.Minute)) || s.Values["driver_version"] != "550.90.07" {
+		t.Fatalf("unexpected restored snapshot %+v", s)
+	}
+}
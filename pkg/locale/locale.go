@@ -0,0 +1,155 @@
+// Package locale localizes the humanized durations and sizes (e.g., "3 hours ago", "83 MB")
+// embedded in the component outputs, or disables the humanization entirely for the downstream
+// dashboards that parse or render the raw values in their own language.
+// The rest of the state reasons and event messages are always in English.
+package locale
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	English  = "en"
+	Chinese  = "zh"
+	Japanese = "ja"
+	Korean   = "ko"
+
+	// None disables the humanization, where the times are formatted in RFC3339
+	// and the bytes are formatted in raw numbers.
+	None = "none"
+
+	Default = English
+)
+
+// Supported lists the supported locales.
+var Supported = []string{English, Chinese, Japanese, Korean, None}
+
+var (
+	mu      sync.RWMutex
+	current = Default
+)
+
+// Validate returns an error if the locale is not supported.
+// The empty locale is valid, and defaults to English.
+func Validate(l string) error {
+	if l == "" {
+		return nil
+	}
+	for _, s := range Supported {
+		if l == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown locale %q (expected one of %v)", l, Supported)
+}
+
+// Set sets the process-wide locale of the humanized strings.
+// The empty locale resets to the default.
+func Set(l string) error {
+	if err := Validate(l); err != nil {
+		return err
+	}
+	if l == "" {
+		l = Default
+	}
+	mu.Lock()
+	current = l
+	mu.Unlock()
+	return nil
+}
+
+// Get returns the current locale.
+func Get() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Time formats the time relative to now (e.g., "3 hours ago").
+func Time(t time.Time) string {
+	return RelTime(t, time.Now())
+}
+
+// RelTime formats the time "a" relative to the time "b"
+// (e.g., "3 hours ago" if "a" is 3 hours before "b").
+func RelTime(a, b time.Time) string {
+	l := Get()
+	if l == None {
+		return a.UTC().Format(time.RFC3339)
+	}
+	u, ok := units[l]
+	if !ok {
+		return humanize.RelTime(a, b, "ago", "from now")
+	}
+	return humanize.CustomRelTime(a, b, u.ago, u.fromNow, u.magnitudes())
+}
+
+// Bytes formats the bytes in the SI units (e.g., "83 MB"),
+// which are the same across the supported languages.
+func Bytes(b uint64) string {
+	if Get() == None {
+		return strconv.FormatUint(b, 10)
+	}
+	return humanize.Bytes(b)
+}
+
+// timeUnits are the relative time words of a language without the plural forms.
+type timeUnits struct {
+	now       string
+	longWhile string
+
+	second string
+	minute string
+	hour   string
+	day    string
+	week   string
+	month  string
+	year   string
+
+	// separator between the quantity with the unit and the label
+	sep     string
+	ago     string
+	fromNow string
+}
+
+var units = map[string]timeUnits{
+	Chinese: {
+		now: "刚刚", longWhile: "很久",
+		second: "秒", minute: "分钟", hour: "小时", day: "天", week: "周", month: "个月", year: "年",
+		ago: "前", fromNow: "后",
+	},
+	Japanese: {
+		now: "今", longWhile: "ずっと",
+		second: "秒", minute: "分", hour: "時間", day: "日", week: "週間", month: "か月", year: "年",
+		ago: "前", fromNow: "後",
+	},
+	Korean: {
+		now: "지금", longWhile: "오래",
+		second: "초", minute: "분", hour: "시간", day: "일", week: "주", month: "개월", year: "년",
+		sep: " ", ago: "전", fromNow: "후",
+	},
+}
+
+// magnitudes returns the relative time formats with the same boundaries as the English ones.
+func (u timeUnits) magnitudes() []humanize.RelTimeMagnitude {
+	f := func(unit string) string {
+		return "%d" + unit + u.sep + "%s"
+	}
+	return []humanize.RelTimeMagnitude{
+		{D: time.Second, Format: u.now, DivBy: time.Second},
+		{D: time.Minute, Format: f(u.second), DivBy: time.Second},
+		{D: time.Hour, Format: f(u.minute), DivBy: time.Minute},
+		{D: humanize.Day, Format: f(u.hour), DivBy: time.Hour},
+		{D: humanize.Week, Format: f(u.day), DivBy: humanize.Day},
+		{D: humanize.Month, Format: f(u.week), DivBy: humanize.Week},
+		{D: humanize.Year, Format: f(u.month), DivBy: humanize.Month},
+		{D: humanize.LongTime, Format: f(u.year), DivBy: humanize.Year},
+		{D: math.MaxInt64, Format: u.longWhile + u.sep + "%s", DivBy: 1},
+	}
+}
@@ -0,0 +1,64 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelTime(t *testing.T) {
+	b := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale string
+		a      time.Time
+		want   string
+	}{
+		{English, b.Add(-3 * time.Hour), "3 hours ago"},
+		{English, b.Add(2 * 24 * time.Hour), "2 days from now"},
+		{Chinese, b.Add(-3 * time.Hour), "3小时前"},
+		{Chinese, b.Add(2 * 24 * time.Hour), "2天后"},
+		{Chinese, b, "刚刚"},
+		{Japanese, b.Add(-5 * time.Minute), "5分前"},
+		{Korean, b.Add(-3 * time.Hour), "3시간 전"},
+		{Korean, b.Add(-40 * 365 * 24 * time.Hour), "오래 전"},
+		{None, b.Add(-3 * time.Hour), "2024-01-01T09:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale+"/"+tt.want, func(t *testing.T) {
+			if err := Set(tt.locale); err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = Set("") }()
+
+			if got := RelTime(tt.a, b); got != tt.want {
+				t.Errorf("RelTime() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBytes(t *testing.T) {
+	if got := Bytes(83 * 1000 * 1000); got != "83 MB" {
+		t.Errorf("Bytes() = %q, want %q", got, "83 MB")
+	}
+
+	if err := Set(None); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = Set("") }()
+	if got := Bytes(83 * 1000 * 1000); got != "83000000" {
+		t.Errorf("Bytes() = %q, want %q", got, "83000000")
+	}
+}
+
+func TestSet(t *testing.T) {
+	if err := Set("fr"); err == nil {
+		t.Fatal("expected error for unsupported locale")
+	}
+	if err := Set(""); err != nil {
+		t.Fatal(err)
+	}
+	if Get() != Default {
+		t.Fatalf("expected default locale, got %q", Get())
+	}
+}
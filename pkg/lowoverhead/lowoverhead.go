@@ -0,0 +1,187 @@
+// Package lowoverhead implements the low-overhead mode for the latency-sensitive nodes
+// (e.g., inference fleets), which reduces the sampling frequency, disables the active probes,
+// and keeps the gpud CPU usage below the configured budget by stretching the poll intervals.
+package lowoverhead
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/log"
+
+	procs "github.com/shirou/gopsutil/v4/process"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultMinPollInterval  = 5 * time.Minute
+	DefaultCPUBudgetPercent = 0.5
+	DefaultCheckInterval    = time.Minute
+
+	// MaxScale is the maximum multiplier of the poll intervals
+	// while the CPU usage exceeds the budget.
+	MaxScale = 32
+)
+
+// Config configures the low-overhead mode.
+type Config struct {
+	// Minimum interval of the component polls, defaults to 5 minutes.
+	// The shorter configured intervals are raised to this.
+	MinPollInterval metav1.Duration `json:"min_poll_interval"`
+	// CPU usage budget of gpud in percent of one core, defaults to 0.5%.
+	// The poll intervals are doubled (up to 32 times) while the usage exceeds the budget,
+	// and halved back while the usage is below the half of the budget.
+	CPUBudgetPercent float64 `json:"cpu_budget_percent"`
+	// Interval to check the CPU usage against the budget, defaults to 1 minute.
+	CheckInterval metav1.Duration `json:"check_interval"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.MinPollInterval.Duration == 0 {
+		cfg.MinPollInterval = metav1.Duration{Duration: DefaultMinPollInterval}
+	}
+	if cfg.CPUBudgetPercent == 0 {
+		cfg.CPUBudgetPercent = DefaultCPUBudgetPercent
+	}
+	if cfg.CheckInterval.Duration == 0 {
+		cfg.CheckInterval = metav1.Duration{Duration: DefaultCheckInterval}
+	}
+}
+
+func (cfg Config) Validate() error {
+	if cfg.MinPollInterval.Duration < 0 {
+		return fmt.Errorf("min_poll_interval must be non-negative, got %v", cfg.MinPollInterval.Duration)
+	}
+	if cfg.CPUBudgetPercent < 0 || cfg.CPUBudgetPercent > 100 {
+		return fmt.Errorf("cpu_budget_percent must be between 0 and 100, got %v", cfg.CPUBudgetPercent)
+	}
+	if cfg.CheckInterval.Duration != 0 && cfg.CheckInterval.Duration < 10*time.Second {
+		return errors.New("check_interval must be at least 10 seconds")
+	}
+	return nil
+}
+
+var (
+	mu      sync.RWMutex
+	enabled bool
+	minPoll time.Duration
+	scale   = 1
+)
+
+// Enable enables the low-overhead mode for the process.
+// It also limits the Go scheduler to a single core.
+func Enable(cfg Config) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	enabled = true
+	minPoll = cfg.MinPollInterval.Duration
+	scale = 1
+	runtime.GOMAXPROCS(1)
+}
+
+// Enabled returns true if the low-overhead mode is enabled.
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return enabled
+}
+
+// Scale returns the current multiplier of the poll intervals.
+func Scale() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return scale
+}
+
+// PollInterval returns the poll interval to use for the configured interval.
+// Returns the configured interval as is if the low-overhead mode is disabled.
+func PollInterval(interval time.Duration) time.Duration {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if !enabled {
+		return interval
+	}
+	if interval < minPoll {
+		interval = minPoll
+	}
+	return interval * time.Duration(scale)
+}
+
+// adjust doubles or halves the poll interval scale based on the CPU usage,
+// and returns the new scale.
+func adjust(cpuPercent float64, budgetPercent float64) int {
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch {
+	case cpuPercent > budgetPercent && scale < MaxScale:
+		scale *= 2
+	case cpuPercent < budgetPercent/2 && scale > 1:
+		scale /= 2
+	}
+	return scale
+}
+
+// CPUTimeFunc returns the total CPU time (user + system) consumed by the process.
+type CPUTimeFunc func(ctx context.Context) (time.Duration, error)
+
+// SelfCPUTime returns the total CPU time consumed by the current process.
+func SelfCPUTime(ctx context.Context) (time.Duration, error) {
+	p, err := procs.NewProcessWithContext(ctx, int32(os.Getpid()))
+	if err != nil {
+		return 0, err
+	}
+	ts, err := p.TimesWithContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration((ts.User + ts.System) * float64(time.Second)), nil
+}
+
+// Govern checks the CPU usage of the process at the check interval,
+// and adjusts the poll interval scale to keep the usage below the budget,
+// until the context is canceled.
+func Govern(ctx context.Context, cfg Config, cpuTime CPUTimeFunc) {
+	ticker := time.NewTicker(cfg.CheckInterval.Duration)
+	defer ticker.Stop()
+
+	prevCPU, err := cpuTime(ctx)
+	if err != nil {
+		log.Logger.Warnw("failed to get cpu time, not enforcing the cpu budget", "error", err)
+		return
+	}
+	prevWall := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cur, err := cpuTime(ctx)
+		if err != nil {
+			log.Logger.Warnw("failed to get cpu time", "error", err)
+			continue
+		}
+		now := time.Now()
+		elapsed := now.Sub(prevWall)
+		if elapsed <= 0 {
+			continue
+		}
+		cpuPercent := float64(cur-prevCPU) / float64(elapsed) * 100
+		prevCPU, prevWall = cur, now
+
+		prevScale := Scale()
+		if s := adjust(cpuPercent, cfg.CPUBudgetPercent); s != prevScale {
+			log.Logger.Infow("adjusted poll interval scale for the cpu budget", "cpuPercent", cpuPercent, "budgetPercent", cfg.CPUBudgetPercent, "scale", s)
+		}
+	}
+}
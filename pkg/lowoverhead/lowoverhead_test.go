@@ -0,0 +1,75 @@
+package lowoverhead
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollInterval(t *testing.T) {
+	if got := PollInterval(time.Minute); got != time.Minute {
+		t.Fatalf("disabled PollInterval() = %v, want %v", got, time.Minute)
+	}
+
+	cfg := Config{}
+	cfg.SetDefaultsIfNotSet()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	Enable(cfg)
+	defer func() {
+		mu.Lock()
+		enabled, scale = false, 1
+		mu.Unlock()
+	}()
+
+	if got := PollInterval(time.Minute); got != DefaultMinPollInterval {
+		t.Fatalf("PollInterval() = %v, want %v", got, DefaultMinPollInterval)
+	}
+	if got := PollInterval(time.Hour); got != time.Hour {
+		t.Fatalf("PollInterval() = %v, want %v", got, time.Hour)
+	}
+
+	tests := []struct {
+		cpuPercent float64
+		expected   int
+	}{
+		{cpuPercent: 2, expected: 2},
+		{cpuPercent: 1, expected: 4},
+		// within the budget
+		{cpuPercent: 0.4, expected: 4},
+		{cpuPercent: 0.1, expected: 2},
+		{cpuPercent: 0.1, expected: 1},
+		{cpuPercent: 0.1, expected: 1},
+	}
+	for i, tt := range tests {
+		if got := adjust(tt.cpuPercent, cfg.CPUBudgetPercent); got != tt.expected {
+			t.Fatalf("step %d: adjust(%v) = %d, want %d", i, tt.cpuPercent, got, tt.expected)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		adjust(100, cfg.CPUBudgetPercent)
+	}
+	if got := Scale(); got != MaxScale {
+		t.Fatalf("Scale() = %d, want %d", got, MaxScale)
+	}
+	if got := PollInterval(time.Minute); got != DefaultMinPollInterval*MaxScale {
+		t.Fatalf("PollInterval() = %v, want %v", got, DefaultMinPollInterval*MaxScale)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		cfg     Config
+		wantErr bool
+	}{
+		{cfg: Config{}, wantErr: false},
+		{cfg: Config{CPUBudgetPercent: 101}, wantErr: true},
+		{cfg: Config{CPUBudgetPercent: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+		}
+	}
+}
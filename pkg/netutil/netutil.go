@@ -0,0 +1,102 @@
+// Package netutil provides the address family (IPv4, IPv6, or dual-stack) aware network helpers
+// for the listeners, probes, and push endpoints.
+package netutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// AddressFamily is the IP address family to listen on and dial with.
+type AddressFamily string
+
+const (
+	// AddressFamilyDualStack uses both IPv4 and IPv6 (default).
+	AddressFamilyDualStack AddressFamily = "dual"
+	// AddressFamilyIPv4 uses IPv4 only.
+	AddressFamilyIPv4 AddressFamily = "ipv4"
+	// AddressFamilyIPv6 uses IPv6 only (e.g., IPv6-only clusters).
+	AddressFamilyIPv6 AddressFamily = "ipv6"
+)
+
+// Validate returns an error if the address family is unknown.
+// The empty address family is valid and treated as dual-stack.
+func (f AddressFamily) Validate() error {
+	switch f {
+	case "", AddressFamilyDualStack, AddressFamilyIPv4, AddressFamilyIPv6:
+		return nil
+	default:
+		return fmt.Errorf("unknown address family %q (must be one of %q, %q, %q)", f, AddressFamilyDualStack, AddressFamilyIPv4, AddressFamilyIPv6)
+	}
+}
+
+// Network returns the network restricted to the address family
+// (e.g., "tcp" to "tcp6" for IPv6).
+// Returns the network as is for dual-stack, or the non-IP networks (e.g., "unix").
+func (f AddressFamily) Network(network string) string {
+	switch network {
+	case "tcp", "udp", "ip":
+	default:
+		return network
+	}
+	switch f {
+	case AddressFamilyIPv4:
+		return network + "4"
+	case AddressFamilyIPv6:
+		return network + "6"
+	default:
+		return network
+	}
+}
+
+// Listen listens on the TCP address with the address family.
+// The wildcard address (e.g., ":15132", "0.0.0.0:15132", "[::]:15132")
+// listens on all the addresses of the address family (both IPv4 and IPv6 for dual-stack).
+func (f AddressFamily) Listen(address string) (net.Listener, error) {
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			address = net.JoinHostPort("", port)
+		}
+	}
+	return net.Listen(f.Network("tcp"), address)
+}
+
+// DialContext returns the dial function of the dialer restricted to the address family.
+func (f AddressFamily) DialContext(d *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		return d.DialContext(ctx, f.Network(network), address)
+	}
+}
+
+// SetDefaultTransport restricts the address family of the default HTTP transport,
+// which is used by the default HTTP client (e.g., login, gossip, notifications).
+// No-op for dual-stack.
+func SetDefaultTransport(f AddressFamily, d *net.Dialer) {
+	if f == "" || f == AddressFamilyDualStack {
+		return
+	}
+	if tr, ok := http.DefaultTransport.(*http.Transport); ok {
+		tr.DialContext = f.DialContext(d)
+	}
+}
+
+// LocalURLHost returns the host:port to reach the listen address from the local host,
+// replacing the unspecified or empty host with "localhost"
+// (e.g., ":15132", "0.0.0.0:15132", and "[::]:15132" to "localhost:15132").
+func LocalURLHost(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	if host == "" || host == "localhost" {
+		return net.JoinHostPort("localhost", port)
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	if ip != nil && ip.IsUnspecified() {
+		return net.JoinHostPort("localhost", port)
+	}
+	return net.JoinHostPort(host, port)
+}
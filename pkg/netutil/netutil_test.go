@@ -0,0 +1,140 @@
+package netutil
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddressFamilyNetwork(t *testing.T) {
+	tests := []struct {
+		family  AddressFamily
+		network string
+		want    string
+	}{
+		{"", "tcp", "tcp"},
+		{AddressFamilyDualStack, "tcp", "tcp"},
+		{AddressFamilyIPv4, "tcp", "tcp4"},
+		{AddressFamilyIPv6, "tcp", "tcp6"},
+		{AddressFamilyIPv6, "udp", "udp6"},
+		{AddressFamilyIPv6, "tcp6", "tcp6"},
+		{AddressFamilyIPv6, "unix", "unix"},
+	}
+	for _, tt := range tests {
+		if got := tt.family.Network(tt.network); got != tt.want {
+			t.Errorf("%q.Network(%q) = %q, want %q", tt.family, tt.network, got, tt.want)
+		}
+	}
+}
+
+func TestAddressFamilyValidate(t *testing.T) {
+	for _, f := range []AddressFamily{"", AddressFamilyDualStack, AddressFamilyIPv4, AddressFamilyIPv6} {
+		if err := f.Validate(); err != nil {
+			t.Errorf("unexpected error for %q: %v", f, err)
+		}
+	}
+	if err := AddressFamily("ipv5").Validate(); err == nil {
+		t.Error("expected error for unknown address family")
+	}
+}
+
+func TestLocalURLHost(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{":15132", "localhost:15132"},
+		{"0.0.0.0:15132", "localhost:15132"},
+		{"[::]:15132", "localhost:15132"},
+		{"localhost:15132", "localhost:15132"},
+		{"127.0.0.1:15132", "127.0.0.1:15132"},
+		{"[fd00::1]:15132", "[fd00::1]:15132"},
+		{"invalid", "invalid"},
+	}
+	for _, tt := range tests {
+		if got := LocalURLHost(tt.address); got != tt.want {
+			t.Errorf("LocalURLHost(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestListen(t *testing.T) {
+	l, err := AddressFamilyIPv4.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if l.Addr().Network() != "tcp" {
+		t.Errorf("unexpected network %q", l.Addr().Network())
+	}
+
+	if _, err := AddressFamilyIPv6.Listen("127.0.0.1:0"); err == nil {
+		t.Error("expected error listening on the IPv4 address with IPv6 only")
+	}
+
+	// the wildcard address listens on the wildcard of the address family
+	l, err = AddressFamilyIPv4.Listen("[::]:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+}
+
+func TestProxyConfig(t *testing.T) {
+	var nilCfg *ProxyConfig
+	if err := nilCfg.Validate(); err != nil {
+		t.Fatalf("unexpected error for nil config: %v", err)
+	}
+	if tlsCfg, err := nilCfg.TLSConfig(); err != nil || tlsCfg != nil {
+		t.Fatalf("expected nil tls config, got %v, %v", tlsCfg, err)
+	}
+
+	if err := (&ProxyConfig{HTTPSProxy: "proxy.internal"}).Validate(); err == nil {
+		t.Error("expected error for proxy url without host")
+	}
+	if err := (&ProxyConfig{CABundle: filepath.Join(t.TempDir(), "missing.pem")}).Validate(); err == nil {
+		t.Error("expected error for missing ca bundle")
+	}
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(invalid, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&ProxyConfig{CABundle: invalid}).Validate(); err == nil {
+		t.Error("expected error for ca bundle without certificate")
+	}
+
+	cfg := &ProxyConfig{
+		HTTPSProxy: "http://proxy.internal:3128",
+		NoProxy:    "direct.internal",
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	proxy := cfg.Proxy()
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://gpud.example.com/api/v1/session", "http://proxy.internal:3128"},
+		{"https://direct.internal/hook", ""},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if u != nil {
+			got = u.String()
+		}
+		if got != tt.want {
+			t.Errorf("proxy(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
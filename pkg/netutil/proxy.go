@@ -0,0 +1,131 @@
+package netutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyConfig is the egress HTTP(S) proxy and the custom CA bundle
+// for the outbound calls (e.g., webhooks, session push, self-update).
+// The unset proxy fields fall back to the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables.
+type ProxyConfig struct {
+	// HTTPProxy is the proxy URL for the HTTP requests (e.g., "http://proxy.internal:3128").
+	HTTPProxy string `json:"http_proxy,omitempty"`
+	// HTTPSProxy is the proxy URL for the HTTPS requests.
+	HTTPSProxy string `json:"https_proxy,omitempty"`
+	// NoProxy is the comma-separated list of the hosts, domains, or CIDRs
+	// to connect directly (e.g., "localhost,10.0.0.0/8,.svc.cluster.local").
+	NoProxy string `json:"no_proxy,omitempty"`
+
+	// CABundle is the path to the PEM encoded CA certificates to trust
+	// in addition to the system roots (e.g., the TLS inspection proxy CA).
+	CABundle string `json:"ca_bundle,omitempty"`
+}
+
+// Validate returns an error if the proxy URLs are invalid, or the CA bundle cannot be loaded.
+func (c *ProxyConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	for _, p := range []string{c.HTTPProxy, c.HTTPSProxy} {
+		if p == "" {
+			continue
+		}
+		u, err := url.Parse(p)
+		if err != nil {
+			return fmt.Errorf("invalid proxy url %q: %w", p, err)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid proxy url %q (missing host)", p)
+		}
+	}
+	if _, err := c.RootCAs(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Proxy returns the proxy function for the HTTP transport.
+// Returns the environment proxy function if the config is nil.
+func (c *ProxyConfig) Proxy() func(*http.Request) (*url.URL, error) {
+	if c == nil || (c.HTTPProxy == "" && c.HTTPSProxy == "" && c.NoProxy == "") {
+		return http.ProxyFromEnvironment
+	}
+
+	cfg := httpproxy.FromEnvironment()
+	if c.HTTPProxy != "" {
+		cfg.HTTPProxy = c.HTTPProxy
+	}
+	if c.HTTPSProxy != "" {
+		cfg.HTTPSProxy = c.HTTPSProxy
+	}
+	if c.NoProxy != "" {
+		cfg.NoProxy = c.NoProxy
+	}
+	proxyFunc := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+}
+
+// RootCAs returns the system roots with the CA bundle appended.
+// Returns nil (use the system roots) if no CA bundle is configured.
+func (c *ProxyConfig) RootCAs() (*x509.CertPool, error) {
+	if c == nil || c.CABundle == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(c.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in ca bundle %q", c.CABundle)
+	}
+	return pool, nil
+}
+
+// TLSConfig returns the TLS client config trusting the CA bundle.
+// Returns nil (the default TLS client config) if no CA bundle is configured.
+func (c *ProxyConfig) TLSConfig() (*tls.Config, error) {
+	pool, err := c.RootCAs()
+	if err != nil {
+		return nil, err
+	}
+	if pool == nil {
+		return nil, nil
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// SetDefaultTransportProxy sets the proxy and the CA bundle of the default HTTP transport,
+// which is used by the default HTTP client (e.g., login, notifications, self-update).
+// No-op if the config is nil.
+func SetDefaultTransportProxy(c *ProxyConfig) error {
+	if c == nil {
+		return nil
+	}
+	tr, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return errors.New("default transport is not *http.Transport")
+	}
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return err
+	}
+	tr.Proxy = c.Proxy()
+	if tlsConfig != nil {
+		tr.TLSClientConfig = tlsConfig
+	}
+	return nil
+}
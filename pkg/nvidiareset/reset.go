@@ -0,0 +1,109 @@
+// Package nvidiareset provides a function to reset an NVIDIA GPU without rebooting the system.
+package nvidiareset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdos "os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/leptonai/gpud/log"
+)
+
+var (
+	ErrNotRoot  = errors.New("must be run as sudo/root")
+	ErrGPUInUse = errors.New("gpu is in use")
+)
+
+// Reset resets the GPU with the given UUID, equivalent to "nvidia-smi --gpu-reset -i <uuid>".
+// It returns ErrGPUInUse without resetting, if any compute process holds the GPU.
+// ref. https://docs.nvidia.com/deploy/nvidia-smi/index.html
+func Reset(ctx context.Context, uuid string) error {
+	asRoot := stdos.Geteuid() == 0 // running as root
+	if !asRoot {
+		return ErrNotRoot
+	}
+	return newResetter().reset(ctx, uuid)
+}
+
+// resetter runs the GPU reset.
+// The steps are overridable for testing.
+type resetter struct {
+	listPIDs func(ctx context.Context, uuid string) ([]int, error)
+	run      func(ctx context.Context, args ...string) (string, error)
+}
+
+func newResetter() *resetter {
+	return &resetter{
+		listPIDs: listComputeAppPIDs,
+		run:      runNvidiaSMI,
+	}
+}
+
+func (r *resetter) reset(ctx context.Context, uuid string) error {
+	if !strings.HasPrefix(uuid, "GPU-") {
+		return fmt.Errorf("invalid gpu uuid %q", uuid)
+	}
+
+	pids, err := r.listPIDs(ctx, uuid)
+	if err != nil {
+		return fmt.Errorf("failed to list the processes on %s: %w", uuid, err)
+	}
+	if len(pids) > 0 {
+		return fmt.Errorf("%w: %d process(es) hold %s %v", ErrGPUInUse, len(pids), uuid, pids)
+	}
+
+	log.Logger.Infow("resetting gpu", "uuid", uuid)
+	out, err := r.run(ctx, "--gpu-reset", "-i", uuid)
+	if err != nil {
+		return fmt.Errorf("failed to reset %s: %w (%s)", uuid, err, out)
+	}
+	log.Logger.Infow("successfully reset gpu", "uuid", uuid, "output", out)
+	return nil
+}
+
+// listComputeAppPIDs returns the PIDs of the compute processes on the GPU.
+func listComputeAppPIDs(ctx context.Context, uuid string) ([]int, error) {
+	out, err := runNvidiaSMI(ctx, "--query-compute-apps=gpu_uuid,pid", "--format=csv,noheader,nounits")
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s)", err, out)
+	}
+	return parseComputeAppPIDs(out, uuid)
+}
+
+// parseComputeAppPIDs parses the "nvidia-smi --query-compute-apps=gpu_uuid,pid --format=csv,noheader" output
+// (e.g., "GPU-3b1c7a56-..., 1234"), and returns the PIDs on the GPU.
+func parseComputeAppPIDs(out string, uuid string) ([]int, error) {
+	var pids []int
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "No running") {
+			continue
+		}
+		gpu, pid, ok := strings.Cut(line, ",")
+		if !ok {
+			return nil, fmt.Errorf("unexpected compute apps line %q", line)
+		}
+		if strings.TrimSpace(gpu) != uuid {
+			continue
+		}
+		p, err := strconv.Atoi(strings.TrimSpace(pid))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected pid in %q: %w", line, err)
+		}
+		pids = append(pids, p)
+	}
+	return pids, nil
+}
+
+func runNvidiaSMI(ctx context.Context, args ...string) (string, error) {
+	p, err := exec.LookPath("nvidia-smi")
+	if err != nil {
+		return "", fmt.Errorf("nvidia-smi not found (%w)", err)
+	}
+	b, err := exec.CommandContext(ctx, p, args...).CombinedOutput()
+	return strings.TrimSpace(string(b)), err
+}
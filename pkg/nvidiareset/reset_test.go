@@ -0,0 +1,74 @@
+package nvidiareset
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseComputeAppPIDs(t *testing.T) {
+	out := `GPU-aaaa, 1234
+GPU-bbbb, 5678
+GPU-aaaa, 91011
+`
+	pids, err := parseComputeAppPIDs(out, "GPU-aaaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pids, []int{1234, 91011}) {
+		t.Errorf("expected [1234 91011], got %v", pids)
+	}
+
+	pids, err = parseComputeAppPIDs("", "GPU-aaaa")
+	if err != nil || len(pids) != 0 {
+		t.Errorf("expected no pids, got %v (%v)", pids, err)
+	}
+
+	if _, err := parseComputeAppPIDs("GPU-aaaa 1234", "GPU-aaaa"); err == nil {
+		t.Error("expected error for malformed line")
+	}
+}
+
+func TestReset(t *testing.T) {
+	tests := []struct {
+		name    string
+		uuid    string
+		pids    []int
+		runErr  error
+		wantRun bool
+		wantErr error
+	}{
+		{name: "idle gpu", uuid: "GPU-aaaa", wantRun: true},
+		{name: "gpu in use", uuid: "GPU-aaaa", pids: []int{1234}, wantErr: ErrGPUInUse},
+		{name: "reset failed", uuid: "GPU-aaaa", runErr: errors.New("exit status 255"), wantRun: true},
+		{name: "invalid uuid", uuid: "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			r := &resetter{
+				listPIDs: func(context.Context, string) ([]int, error) { return tt.pids, nil },
+				run: func(_ context.Context, args ...string) (string, error) {
+					ran = true
+					if !reflect.DeepEqual(args, []string{"--gpu-reset", "-i", tt.uuid}) {
+						t.Errorf("unexpected args %v", args)
+					}
+					return "", tt.runErr
+				},
+			}
+			err := r.reset(context.Background(), tt.uuid)
+			if ran != tt.wantRun {
+				t.Errorf("expected run %v, got %v", tt.wantRun, ran)
+			}
+			switch {
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			case tt.wantErr == nil && (tt.runErr != nil || tt.uuid == "0") && err == nil:
+				t.Error("expected error, got nil")
+			case tt.wantErr == nil && tt.runErr == nil && tt.uuid != "0" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
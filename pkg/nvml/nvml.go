@@ -0,0 +1,225 @@
+// Package nvml manages the NVML library session shared across the NVIDIA components,
+// so that NVML is initialized once rather than on every poll (each initialization
+// loads the library and opens the device files), and re-initialized after the driver reload.
+package nvml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/uevent"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+var (
+	// ErrLibraryNotFound is the NVML initialization error when the NVML library is not found
+	// (e.g., the NVIDIA driver not installed).
+	ErrLibraryNotFound = errors.New("NVML library not found")
+	// ErrDriverNotLoaded is the NVML initialization error when the NVIDIA kernel driver is not loaded
+	// (e.g., the driver being reinstalled).
+	ErrDriverNotLoaded = errors.New("NVIDIA driver not loaded")
+	// ErrSuspended is the error when the session is suspended (e.g., the NVIDIA kernel modules
+	// being unloaded), so that NVML does not re-open the device files until resumed.
+	ErrSuspended = errors.New("NVML session suspended")
+)
+
+// Session is the NVML library initialized once and shared by the callers.
+type Session struct {
+	newLib func() nvml.Interface
+
+	mu          sync.Mutex
+	lib         nvml.Interface
+	initialized bool
+	suspended   bool
+	// incremented on every initialization, for the callers caching
+	// the device handles to detect the re-initialization
+	generation uint64
+}
+
+// NewSession creates the NVML session with the library constructor (e.g., "nvml.New").
+// NVML is not initialized until the first "Lib" call.
+func NewSession(newLib func() nvml.Interface) *Session {
+	return &Session{newLib: newLib}
+}
+
+var (
+	defaultSession = NewSession(func() nvml.Interface {
+		return nvml.New()
+	})
+	defaultWatchOnce sync.Once
+)
+
+// Default returns the process-wide NVML session,
+// watching the driver reloads from the first call.
+func Default() *Session {
+	defaultWatchOnce.Do(func() {
+		// best-effort, the session still re-initializes on the driver errors (see "Check")
+		if err := defaultSession.Watch(context.Background()); err != nil {
+			log.Logger.Debugw("failed to watch nvidia driver reloads", "error", err)
+		}
+	})
+	return defaultSession
+}
+
+// Lib returns the shared NVML library, initializing NVML on the first call
+// or after the driver reload.
+// The initialized library is probed on every call, and re-initialized if the probe
+// returns the driver errors (see "NeedsReinit"), so that the callers do not need to
+// check the return codes to recover from the driver reload.
+// The caller must not shut down the returned library.
+func (s *Session) Lib() (nvml.Interface, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.suspended {
+		return nil, ErrSuspended
+	}
+
+	if s.initialized {
+		_, ret := s.lib.SystemGetDriverVersion()
+		if !NeedsReinit(ret) {
+			return s.lib, nil
+		}
+		log.Logger.Warnw("nvidia driver unloaded or reloaded, re-initializing NVML", "return", nvml.ErrorString(ret))
+		if err := s.shutdown(); err != nil {
+			log.Logger.Debugw("failed to shutdown NVML", "error", err)
+		}
+	}
+
+	lib := s.newLib()
+	if ret := lib.Init(); ret != nvml.SUCCESS {
+		switch ret {
+		case nvml.ERROR_LIBRARY_NOT_FOUND:
+			return nil, fmt.Errorf("failed to initialize NVML: %w", ErrLibraryNotFound)
+		case nvml.ERROR_DRIVER_NOT_LOADED:
+			return nil, fmt.Errorf("failed to initialize NVML: %w", ErrDriverNotLoaded)
+		}
+		return nil, fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	}
+	s.lib = lib
+	s.initialized = true
+	s.generation++
+	return lib, nil
+}
+
+// Generation returns the number of the NVML initializations,
+// which changes when the session is re-initialized (e.g., after the driver reload),
+// in which case the device handles from the previous library must not be used.
+func (s *Session) Generation() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.generation
+}
+
+// Check shuts down NVML to re-initialize on the next "Lib"
+// if the return code indicates the driver was unloaded or reloaded.
+// Returns true if the session was reset.
+func (s *Session) Check(ret nvml.Return) bool {
+	if !NeedsReinit(ret) {
+		return false
+	}
+	log.Logger.Warnw("nvidia driver unloaded or reloaded, re-initializing NVML", "return", nvml.ErrorString(ret))
+	if err := s.Shutdown(); err != nil {
+		log.Logger.Debugw("failed to shutdown NVML", "error", err)
+	}
+	return true
+}
+
+// NeedsReinit returns true if the NVML return code requires re-initializing NVML
+// (e.g., the driver was reloaded underneath the initialized library,
+// or the GPU was reset and its device handles are no longer valid).
+func NeedsReinit(ret nvml.Return) bool {
+	switch ret {
+	case nvml.ERROR_UNINITIALIZED, nvml.ERROR_DRIVER_NOT_LOADED, nvml.ERROR_LIB_RM_VERSION_MISMATCH, nvml.ERROR_GPU_IS_LOST:
+		return true
+	default:
+		return false
+	}
+}
+
+// Shutdown shuts down NVML, if initialized.
+// The next "Lib" call initializes NVML again.
+func (s *Session) Shutdown() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.shutdown()
+}
+
+// Suspend shuts down NVML and fails the "Lib" calls with "ErrSuspended" until "Resume",
+// so that no caller keeps the NVIDIA device files open (e.g., while unloading the kernel modules).
+func (s *Session) Suspend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.suspended = true
+	return s.shutdown()
+}
+
+// Resume allows the next "Lib" call to initialize NVML again, after "Suspend".
+func (s *Session) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.suspended = false
+}
+
+func (s *Session) shutdown() error {
+	if !s.initialized {
+		return nil
+	}
+	lib := s.lib
+	s.lib = nil
+	s.initialized = false
+
+	if ret := lib.Shutdown(); ret != nvml.SUCCESS {
+		return fmt.Errorf("failed to shutdown NVML: %v", nvml.ErrorString(ret))
+	}
+	return nil
+}
+
+// Watch subscribes to the kernel uevents and shuts down NVML when the nvidia kernel module
+// is loaded or unloaded, to re-initialize on the next "Lib", until the context is canceled.
+// Returns an error if the uevents cannot be received, in which case the session
+// relies on the return codes (see "Check") to detect the driver reloads.
+func (s *Session) Watch(ctx context.Context) error {
+	ch, unsubscribe, err := uevent.Subscribe()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				if IsDriverReloadEvent(ev) {
+					log.Logger.Infow("nvidia kernel module event, re-initializing NVML", "action", ev.Action, "devpath", ev.DevPath)
+					if err := s.Shutdown(); err != nil {
+						log.Logger.Debugw("failed to shutdown NVML", "error", err)
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// IsDriverReloadEvent returns true if the uevent loads or unloads the nvidia kernel module.
+func IsDriverReloadEvent(ev uevent.Event) bool {
+	if ev.Subsystem != "module" || ev.DevPath != "/module/nvidia" {
+		return false
+	}
+	return ev.Action == uevent.ActionAdd || ev.Action == uevent.ActionRemove
+}
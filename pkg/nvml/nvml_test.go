@@ -0,0 +1,155 @@
+package nvml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leptonai/gpud/pkg/uevent"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/NVIDIA/go-nvml/pkg/nvml/mock"
+)
+
+func TestSession(t *testing.T) {
+	news, inits, shutdowns := 0, 0, 0
+	initRet, probeRet := nvml.SUCCESS, nvml.SUCCESS
+	s := NewSession(func() nvml.Interface {
+		news++
+		return &mock.Interface{
+			InitFunc: func() nvml.Return {
+				inits++
+				return initRet
+			},
+			ShutdownFunc: func() nvml.Return {
+				shutdowns++
+				return nvml.SUCCESS
+			},
+			SystemGetDriverVersionFunc: func() (string, nvml.Return) {
+				return "535.161.08", probeRet
+			},
+		}
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.Lib(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if news != 1 || inits != 1 {
+		t.Fatalf("expected NVML initialized once, got %d new, %d init", news, inits)
+	}
+
+	// not a driver error, keeps the session
+	if s.Check(nvml.ERROR_NOT_SUPPORTED) {
+		t.Fatal("expected no reset")
+	}
+	if !s.Check(nvml.ERROR_DRIVER_NOT_LOADED) {
+		t.Fatal("expected reset")
+	}
+	if shutdowns != 1 {
+		t.Fatalf("expected 1 shutdown, got %d", shutdowns)
+	}
+
+	// driver still not loaded
+	initRet = nvml.ERROR_DRIVER_NOT_LOADED
+	if _, err := s.Lib(); !errors.Is(err, ErrDriverNotLoaded) {
+		t.Fatalf("expected ErrDriverNotLoaded, got %v", err)
+	}
+
+	initRet = nvml.SUCCESS
+	if _, err := s.Lib(); err != nil {
+		t.Fatal(err)
+	}
+	if inits != 3 {
+		t.Fatalf("expected 3 inits, got %d", inits)
+	}
+
+	if err := s.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if shutdowns != 2 {
+		t.Fatalf("expected 2 shutdowns, got %d", shutdowns)
+	}
+}
+
+func TestSessionReinit(t *testing.T) {
+	inits, shutdowns := 0, 0
+	probeRet := nvml.SUCCESS
+	s := NewSession(func() nvml.Interface {
+		return &mock.Interface{
+			InitFunc: func() nvml.Return {
+				inits++
+				return nvml.SUCCESS
+			},
+			ShutdownFunc: func() nvml.Return {
+				shutdowns++
+				return nvml.SUCCESS
+			},
+			SystemGetDriverVersionFunc: func() (string, nvml.Return) {
+				return "535.161.08", probeRet
+			},
+		}
+	})
+
+	if _, err := s.Lib(); err != nil {
+		t.Fatal(err)
+	}
+	if g := s.Generation(); g != 1 {
+		t.Fatalf("expected generation 1, got %d", g)
+	}
+
+	// the driver reloaded underneath the initialized library
+	probeRet = nvml.ERROR_UNINITIALIZED
+	if _, err := s.Lib(); err != nil {
+		t.Fatal(err)
+	}
+	if inits != 2 || shutdowns != 1 {
+		t.Fatalf("expected re-initialized, got %d init, %d shutdown", inits, shutdowns)
+	}
+	if g := s.Generation(); g != 2 {
+		t.Fatalf("expected generation 2, got %d", g)
+	}
+	probeRet = nvml.SUCCESS
+
+	if err := s.Suspend(); err != nil {
+		t.Fatal(err)
+	}
+	if shutdowns != 2 {
+		t.Fatalf("expected 2 shutdowns, got %d", shutdowns)
+	}
+	if _, err := s.Lib(); !errors.Is(err, ErrSuspended) {
+		t.Fatalf("expected ErrSuspended, got %v", err)
+	}
+	if inits != 2 {
+		t.Fatalf("expected no init while suspended, got %d", inits)
+	}
+
+	s.Resume()
+	if _, err := s.Lib(); err != nil {
+		t.Fatal(err)
+	}
+	if g := s.Generation(); g != 3 {
+		t.Fatalf("expected generation 3, got %d", g)
+	}
+}
+
+func TestIsDriverReloadEvent(t *testing.T) {
+	tests := []struct {
+		ev   uevent.Event
+		want bool
+	}{
+		{uevent.Event{Action: uevent.ActionRemove, DevPath: "/module/nvidia", Subsystem: "module"}, true},
+		{uevent.Event{Action: uevent.ActionAdd, DevPath: "/module/nvidia", Subsystem: "module"}, true},
+		{uevent.Event{Action: uevent.ActionAdd, DevPath: "/module/nvidia_uvm", Subsystem: "module"}, false},
+		{uevent.Event{Action: uevent.ActionChange, DevPath: "/module/nvidia", Subsystem: "module"}, false},
+		{uevent.Event{Action: uevent.ActionRemove, DevPath: "/devices/pci0000:00/0000:00:01.0", Subsystem: "pci"}, false},
+	}
+	for _, tt := range tests {
+		if got := IsDriverReloadEvent(tt.ev); got != tt.want {
+			t.Errorf("IsDriverReloadEvent(%+v) = %v, want %v", tt.ev, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Package parquet implements a minimal Apache Parquet file writer
+// (single row group, required flat columns, plain encoding, uncompressed),
+// enough to export the flat tables (e.g., the stored metrics) for the columnar analysis tools.
+//
+// ref. https://github.com/apache/parquet-format
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const magic = "PAR1"
+
+// Type is the parquet physical type.
+type Type int32
+
+const (
+	TypeInt64     Type = 2
+	TypeDouble    Type = 5
+	TypeByteArray Type = 6
+)
+
+// parquet enum values used in the metadata
+const (
+	pageTypeDataPage        = 0
+	encodingPlain           = 0
+	encodingRLE             = 3
+	repetitionRequired      = 0
+	convertedTypeUTF8       = 0
+	compressionUncompressed = 0
+)
+
+// Column defines a required column.
+type Column struct {
+	Name string
+	Type Type
+	// UTF8 annotates the byte array column as the UTF-8 string.
+	UTF8 bool
+}
+
+// Writer buffers the rows in memory, and writes a single row group file on Close.
+type Writer struct {
+	w       io.Writer
+	columns []Column
+	values  []bytes.Buffer
+	rows    int64
+	closed  bool
+}
+
+// NewWriter creates a new writer with the given columns.
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	return &Writer{
+		w:       w,
+		columns: columns,
+		values:  make([]bytes.Buffer, len(columns)),
+	}
+}
+
+var ErrClosed = errors.New("parquet writer closed")
+
+// Write appends a row, with the values in the column order
+// (int64 for TypeInt64, float64 for TypeDouble, and string or []byte for TypeByteArray).
+func (w *Writer) Write(row ...any) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(w.columns), len(row))
+	}
+	if w.rows >= math.MaxInt32 {
+		return fmt.Errorf("too many rows (max %d)", math.MaxInt32)
+	}
+
+	// validate the whole row first, not to write the partial row
+	for i, col := range w.columns {
+		if err := checkValue(col, row[i]); err != nil {
+			return err
+		}
+	}
+
+	var b [8]byte
+	for i, col := range w.columns {
+		buf := &w.values[i]
+		switch col.Type {
+		case TypeInt64:
+			binary.LittleEndian.PutUint64(b[:], uint64(row[i].(int64)))
+			buf.Write(b[:])
+		case TypeDouble:
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(row[i].(float64)))
+			buf.Write(b[:])
+		case TypeByteArray:
+			var v []byte
+			switch s := row[i].(type) {
+			case string:
+				v = []byte(s)
+			case []byte:
+				v = s
+			}
+			binary.LittleEndian.PutUint32(b[:4], uint32(len(v)))
+			buf.Write(b[:4])
+			buf.Write(v)
+		}
+	}
+	w.rows++
+	return nil
+}
+
+func checkValue(col Column, v any) error {
+	ok := false
+	switch col.Type {
+	case TypeInt64:
+		_, ok = v.(int64)
+	case TypeDouble:
+		_, ok = v.(float64)
+	case TypeByteArray:
+		switch v.(type) {
+		case string, []byte:
+			ok = true
+		}
+	default:
+		return fmt.Errorf("column %q has unsupported type %d", col.Name, col.Type)
+	}
+	if !ok {
+		return fmt.Errorf("column %q has invalid value type %T", col.Name, v)
+	}
+	return nil
+}
+
+// Close writes the file with the buffered rows.
+// It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	cw := &countingWriter{w: w.w}
+	if _, err := io.WriteString(cw, magic); err != nil {
+		return err
+	}
+
+	type chunk struct {
+		offset int64
+		size   int64
+		values int64
+	}
+	var chunks []chunk
+	if w.rows > 0 {
+		for i := range w.columns {
+			data := w.values[i].Bytes()
+			if len(data) > math.MaxInt32 {
+				return fmt.Errorf("column %q too large (%d bytes)", w.columns[i].Name, len(data))
+			}
+			header := encodePageHeader(len(data), w.rows)
+
+			offset := cw.n
+			if _, err := cw.Write(header); err != nil {
+				return err
+			}
+			if _, err := cw.Write(data); err != nil {
+				return err
+			}
+			chunks = append(chunks, chunk{offset: offset, size: cw.n - offset, values: w.rows})
+		}
+	}
+
+	t := newCompactWriter()
+	t.i32Field(1, 1) // version
+	t.structListField(2, len(w.columns)+1, func(i int) {
+		if i == 0 {
+			t.stringField(4, "schema")
+			t.i32Field(5, int32(len(w.columns)))
+			return
+		}
+		col := w.columns[i-1]
+		t.i32Field(1, int32(col.Type))
+		t.i32Field(3, repetitionRequired)
+		t.stringField(4, col.Name)
+		if col.UTF8 {
+			t.i32Field(6, convertedTypeUTF8)
+		}
+	})
+	t.i64Field(3, w.rows)
+	rowGroups := 0
+	if len(chunks) > 0 {
+		rowGroups = 1
+	}
+	t.structListField(4, rowGroups, func(int) {
+		var total int64
+		t.structListField(1, len(chunks), func(i int) {
+			c := chunks[i]
+			total += c.size
+			t.i64Field(2, c.offset) // file_offset
+			t.structField(3, func() {
+				t.i32Field(1, int32(w.columns[i].Type))
+				t.i32ListField(2, encodingPlain)
+				t.stringListField(3, w.columns[i].Name)
+				t.i32Field(4, compressionUncompressed)
+				t.i64Field(5, c.values)
+				t.i64Field(6, c.size)   // total_uncompressed_size
+				t.i64Field(7, c.size)   // total_compressed_size
+				t.i64Field(9, c.offset) // data_page_offset
+			})
+		})
+		t.i64Field(2, total)
+		t.i64Field(3, w.rows)
+	})
+	t.stringField(6, "gpud")
+	t.structEnd()
+
+	meta := t.Bytes()
+	if _, err := cw.Write(meta); err != nil {
+		return err
+	}
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(len(meta)))
+	if _, err := cw.Write(b[:]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(cw, magic)
+	return err
+}
+
+func encodePageHeader(size int, rows int64) []byte {
+	t := newCompactWriter()
+	t.i32Field(1, pageTypeDataPage)
+	t.i32Field(2, int32(size)) // uncompressed_page_size
+	t.i32Field(3, int32(size)) // compressed_page_size
+	t.structField(5, func() {
+		t.i32Field(1, int32(rows))
+		t.i32Field(2, encodingPlain)
+		t.i32Field(3, encodingRLE) // definition_level_encoding
+		t.i32Field(4, encodingRLE) // repetition_level_encoding
+	})
+	t.structEnd()
+	return t.Bytes()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,100 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestEncodePageHeader(t *testing.T) {
+	t.Parallel()
+
+	// type=0, uncompressed=160, compressed=160, data_page_header={20 values, plain, rle, rle}
+	want := []byte{
+		0x15, 0x00,
+		0x15, 0xc0, 0x02,
+		0x15, 0xc0, 0x02,
+		0x2c, 0x15, 0x28, 0x15, 0x00, 0x15, 0x06, 0x15, 0x06, 0x00,
+		0x00,
+	}
+	if got := encodePageHeader(160, 20); !bytes.Equal(got, want) {
+		t.Errorf("encodePageHeader() = % x, want % x", got, want)
+	}
+}
+
+func TestCompactWriterLongFieldDelta(t *testing.T) {
+	t.Parallel()
+
+	tw := newCompactWriter()
+	tw.i32Field(20, 1)
+	tw.i32Field(1, -1)
+	tw.structEnd()
+	// field delta out of [1, 15] writes the zigzag field id
+	want := []byte{0x05, 0x28, 0x02, 0x05, 0x02, 0x01, 0x00}
+	if got := tw.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("Bytes() = % x, want % x", got, want)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{
+		{Name: "unix_seconds", Type: TypeInt64},
+		{Name: "metric_name", Type: TypeByteArray, UTF8: true},
+		{Name: "metric_value", Type: TypeDouble},
+	})
+	if err := w.Write(int64(1700000000), "gpu_temp", 42.5); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(int64(1700000001), []byte("gpu_temp"), 43.0); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(int64(1), "gpu_temp"); err == nil {
+		t.Error("expected error for the missing value")
+	}
+	if err := w.Write(1, "gpu_temp", 43.0); err == nil {
+		t.Error("expected error for the invalid value type")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(int64(1), "gpu_temp", 1.0); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+
+	b := buf.Bytes()
+	if string(b[:4]) != magic || string(b[len(b)-4:]) != magic {
+		t.Fatalf("unexpected magic bytes: % x", b)
+	}
+	metaLen := int(binary.LittleEndian.Uint32(b[len(b)-8 : len(b)-4]))
+	meta := b[len(b)-8-metaLen : len(b)-8]
+	// the first column chunk (page header and 2 int64 values) follows the magic bytes
+	header := encodePageHeader(16, 2)
+	if !bytes.HasPrefix(b[4:], header) {
+		t.Errorf("expected the first page header % x, got % x", header, b[4:4+len(header)])
+	}
+	if got := binary.LittleEndian.Uint64(b[4+len(header):]); got != 1700000000 {
+		t.Errorf("expected the first value 1700000000, got %d", got)
+	}
+	// version 1, and the created_by at the end
+	if meta[0] != 0x15 || meta[1] != 0x02 || !bytes.HasSuffix(meta, []byte("gpud\x00")) {
+		t.Errorf("unexpected file metadata: % x", meta)
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{Name: "v", Type: TypeDouble}})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	metaLen := int(binary.LittleEndian.Uint32(b[len(b)-8 : len(b)-4]))
+	if 4+metaLen+8 != len(b) {
+		t.Errorf("expected no column chunk, got %d bytes with %d bytes metadata", len(b), metaLen)
+	}
+}
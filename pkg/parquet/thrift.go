@@ -0,0 +1,120 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol types.
+// ref. https://github.com/apache/thrift/blob/master/doc/specs/thrift-compact-protocol.md
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// compactWriter encodes the thrift structs with the compact protocol,
+// only with the types used by the parquet file metadata.
+type compactWriter struct {
+	buf bytes.Buffer
+	// last field IDs of the nested structs, for the field ID deltas
+	lastIDs []int16
+}
+
+func newCompactWriter() *compactWriter {
+	return &compactWriter{lastIDs: []int16{0}}
+}
+
+func (t *compactWriter) Bytes() []byte { return t.buf.Bytes() }
+
+func (t *compactWriter) writeUvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	t.buf.Write(b[:n])
+}
+
+func (t *compactWriter) writeVarint(v int64) {
+	// zigzag
+	t.writeUvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *compactWriter) writeString(s string) {
+	t.writeUvarint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *compactWriter) fieldHeader(id int16, typ byte) {
+	last := &t.lastIDs[len(t.lastIDs)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.writeVarint(int64(id))
+	}
+	*last = id
+}
+
+func (t *compactWriter) i32Field(id int16, v int32) {
+	t.fieldHeader(id, compactI32)
+	t.writeVarint(int64(v))
+}
+
+func (t *compactWriter) i64Field(id int16, v int64) {
+	t.fieldHeader(id, compactI64)
+	t.writeVarint(v)
+}
+
+func (t *compactWriter) stringField(id int16, s string) {
+	t.fieldHeader(id, compactBinary)
+	t.writeString(s)
+}
+
+func (t *compactWriter) listHeader(id int16, elemType byte, size int) {
+	t.fieldHeader(id, compactList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	t.buf.WriteByte(0xf0 | elemType)
+	t.writeUvarint(uint64(size))
+}
+
+func (t *compactWriter) i32ListField(id int16, vs ...int32) {
+	t.listHeader(id, compactI32, len(vs))
+	for _, v := range vs {
+		t.writeVarint(int64(v))
+	}
+}
+
+func (t *compactWriter) stringListField(id int16, vs ...string) {
+	t.listHeader(id, compactBinary, len(vs))
+	for _, v := range vs {
+		t.writeString(v)
+	}
+}
+
+// structListField writes the list of the structs, each written by the given function.
+func (t *compactWriter) structListField(id int16, size int, write func(i int)) {
+	t.listHeader(id, compactStruct, size)
+	for i := 0; i < size; i++ {
+		t.lastIDs = append(t.lastIDs, 0)
+		write(i)
+		t.structEnd()
+	}
+}
+
+// structField writes the nested struct written by the given function.
+func (t *compactWriter) structField(id int16, write func()) {
+	t.fieldHeader(id, compactStruct)
+	t.lastIDs = append(t.lastIDs, 0)
+	write()
+	t.structEnd()
+}
+
+// structEnd writes the stop field of the current struct.
+func (t *compactWriter) structEnd() {
+	t.buf.WriteByte(0)
+	t.lastIDs = t.lastIDs[:len(t.lastIDs)-1]
+}
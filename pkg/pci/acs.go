@@ -0,0 +1,153 @@
+package pci
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrACSNotSupported is returned when the device has no ACS extended capability.
+var ErrACSNotSupported = errors.New("ACS capability not found")
+
+// ErrExtendedConfigNotReadable is returned when the extended configuration space is not readable,
+// since the non-root users can only read the first 64 bytes of the configuration space.
+var ErrExtendedConfigNotReadable = errors.New("extended configuration space not readable (requires root)")
+
+const (
+	extendedCapabilityOffset = 0x100
+	extendedConfigSize       = 4096
+
+	// ref. "PCI Express Base Specification", "ACS Extended Capability"
+	extendedCapabilityIDACS = 0x000d
+	acsControlOffset        = 6
+)
+
+// ACSControl is the ACS control register of a PCIe port.
+type ACSControl uint16
+
+const (
+	ACSSourceValidation      ACSControl = 1 << 0
+	ACSTranslationBlocking   ACSControl = 1 << 1
+	ACSP2PRequestRedirect    ACSControl = 1 << 2
+	ACSP2PCompletionRedirect ACSControl = 1 << 3
+	ACSUpstreamForwarding    ACSControl = 1 << 4
+	ACSP2PEgressControl      ACSControl = 1 << 5
+	ACSDirectTranslatedP2P   ACSControl = 1 << 6
+)
+
+var acsControlNames = []struct {
+	flag ACSControl
+	name string
+}{
+	// same as the "ACSCtl" names in "lspci -vvv"
+	{ACSSourceValidation, "SrcValid"},
+	{ACSTranslationBlocking, "TransBlk"},
+	{ACSP2PRequestRedirect, "ReqRedir"},
+	{ACSP2PCompletionRedirect, "CmpltRedir"},
+	{ACSUpstreamForwarding, "UpstreamFwd"},
+	{ACSP2PEgressControl, "EgressCtrl"},
+	{ACSDirectTranslatedP2P, "DirectTrans"},
+}
+
+// ACSP2PRedirects are the control flags that redirect (or block) the peer-to-peer traffic.
+const ACSP2PRedirects = ACSP2PRequestRedirect | ACSP2PCompletionRedirect | ACSP2PEgressControl
+
+// RedirectsP2P returns true if the port redirects (or blocks) the peer-to-peer traffic
+// to the root complex, instead of routing it directly within the switch.
+func (c ACSControl) RedirectsP2P() bool {
+	return c&ACSP2PRedirects != 0
+}
+
+// WithoutP2PRedirects returns the control with only the peer-to-peer redirect flags cleared,
+// keeping the other protections (e.g., the source validation and the translation blocking).
+func (c ACSControl) WithoutP2PRedirects() ACSControl {
+	return c &^ ACSP2PRedirects
+}
+
+// String returns the control flags in the "lspci -vvv" format
+// (e.g., "SrcValid+ TransBlk- ReqRedir+ CmpltRedir+ UpstreamFwd+ EgressCtrl- DirectTrans-").
+func (c ACSControl) String() string {
+	flags := make([]string, 0, len(acsControlNames))
+	for _, n := range acsControlNames {
+		if c&n.flag != 0 {
+			flags = append(flags, n.name+"+")
+		} else {
+			flags = append(flags, n.name+"-")
+		}
+	}
+	return strings.Join(flags, " ")
+}
+
+// findExtendedCapability returns the offset of the extended capability in the configuration space,
+// or zero if not found.
+func findExtendedCapability(config []byte, id uint16) int {
+	offset := extendedCapabilityOffset
+	for visited := 0; offset >= extendedCapabilityOffset && offset+4 <= len(config); visited++ {
+		// the list cannot be longer than the extended configuration space
+		if visited > (extendedConfigSize-extendedCapabilityOffset)/4 {
+			return 0
+		}
+		header := binary.LittleEndian.Uint32(config[offset : offset+4])
+		if header == 0 || header == 0xffffffff {
+			return 0
+		}
+		if uint16(header&0xffff) == id {
+			return offset
+		}
+		offset = int(header>>20) &^ 0x3
+	}
+	return 0
+}
+
+// ReadACSControl reads the ACS control register of the device from its sysfs configuration space.
+func ReadACSControl(dir string, bdf string) (ACSControl, error) {
+	if dir == "" {
+		dir = DefaultDevicesDir
+	}
+	config, err := os.ReadFile(filepath.Join(dir, bdf, "config"))
+	if err != nil {
+		return 0, err
+	}
+	if len(config) <= extendedCapabilityOffset {
+		return 0, ErrExtendedConfigNotReadable
+	}
+	offset := findExtendedCapability(config, extendedCapabilityIDACS)
+	if offset == 0 || offset+acsControlOffset+2 > len(config) {
+		return 0, ErrACSNotSupported
+	}
+	return ACSControl(binary.LittleEndian.Uint16(config[offset+acsControlOffset:])), nil
+}
+
+// WriteACSControl writes the ACS control register of the device to its sysfs configuration space,
+// equivalent to "setpci -s <bdf> ECAP_ACS+0x6.w=<value>".
+// The setting is reset on the reboot or the hot-plug.
+func WriteACSControl(dir string, bdf string, c ACSControl) error {
+	if dir == "" {
+		dir = DefaultDevicesDir
+	}
+	file := filepath.Join(dir, bdf, "config")
+	config, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if len(config) <= extendedCapabilityOffset {
+		return ErrExtendedConfigNotReadable
+	}
+	offset := findExtendedCapability(config, extendedCapabilityIDACS)
+	if offset == 0 || offset+acsControlOffset+2 > len(config) {
+		return ErrACSNotSupported
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, uint16(c))
+	_, err = f.WriteAt(b, int64(offset+acsControlOffset))
+	return err
+}
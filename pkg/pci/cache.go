@@ -0,0 +1,105 @@
+package pci
+
+import (
+	"context"
+	"sync"
+
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/uevent"
+)
+
+// Cache caches the PCI devices listed from the sysfs, and only rescans
+// the sysfs after a PCI device is hot-added or removed.
+// Without the kernel uevents (e.g., non-root, non-linux), every List rescans the sysfs.
+type Cache struct {
+	dir string
+
+	mu       sync.Mutex
+	devs     []Device
+	valid    bool
+	watching bool
+}
+
+// NewCache creates the cache of the PCI devices in the sysfs directory
+// (defaults to "/sys/bus/pci/devices").
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// List returns the cached PCI devices, rescanning the sysfs if invalidated.
+// The returned slice must not be modified.
+func (c *Cache) List() ([]Device, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.valid && c.watching {
+		return c.devs, nil
+	}
+
+	devs, err := List(c.dir)
+	if err != nil {
+		return nil, err
+	}
+	c.devs = devs
+	c.valid = true
+	return devs, nil
+}
+
+// Invalidate marks the cached devices stale, to rescan the sysfs on the next List.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	c.valid = false
+	c.mu.Unlock()
+}
+
+// Watch subscribes to the kernel uevents and invalidates the cache
+// on the PCI device add/remove events, until the context is canceled.
+// Returns an error if the uevents cannot be received, in which case
+// the cache keeps rescanning on every List.
+func (c *Cache) Watch(ctx context.Context) error {
+	ch, unsubscribe, err := uevent.Subscribe()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.watching = true
+	// the devices may have changed before the subscription
+	c.valid = false
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			unsubscribe()
+
+			c.mu.Lock()
+			c.watching = false
+			c.mu.Unlock()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-ch:
+				if !ok {
+					log.Logger.Warnw("uevents closed, falling back to rescan pci devices on every list")
+					return
+				}
+				if IsHotplugEvent(ev) {
+					log.Logger.Infow("pci device hotplug event, invalidating pci device cache", "action", ev.Action, "devpath", ev.DevPath)
+					c.Invalidate()
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// IsHotplugEvent returns true if the uevent adds or removes a PCI device.
+func IsHotplugEvent(ev uevent.Event) bool {
+	if ev.Subsystem != "pci" {
+		return false
+	}
+	return ev.Action == uevent.ActionAdd || ev.Action == uevent.ActionRemove
+}
@@ -0,0 +1,113 @@
+package pci
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DMA mapping modes of a device, as effective in its IOMMU group.
+const (
+	// DMAModePassthrough is the identity mapping (e.g., "iommu=pt"), where the devices access
+	// the physical addresses directly, as required by GPUDirect RDMA for the best performance.
+	DMAModePassthrough = "passthrough"
+	// DMAModeTranslated is the full DMA translation by the IOMMU.
+	DMAModeTranslated = "translated"
+	// DMAModeDisabled is when the IOMMU is disabled (or not present), so the device has no IOMMU group.
+	DMAModeDisabled = "disabled"
+	// DMAModeUnmanaged is when the device is managed by the user space (e.g., vfio-pci for the device passthrough).
+	DMAModeUnmanaged = "unmanaged"
+	// DMAModeBlocked is when the device DMA is blocked.
+	DMAModeBlocked = "blocked"
+)
+
+// IOMMUGroup is the IOMMU group of a device.
+type IOMMUGroup struct {
+	// ID is the IOMMU group number, empty if the device has no IOMMU group.
+	ID string `json:"id,omitempty"`
+	// DomainType is the raw IOMMU domain type of the group
+	// (e.g., "identity", "DMA", "DMA-FQ") from "/sys/kernel/iommu_groups/<id>/type".
+	DomainType string `json:"domain_type,omitempty"`
+	// DMAMode is the effective DMA mapping mode.
+	DMAMode string `json:"dma_mode"`
+}
+
+// ReadIOMMUGroup reads the IOMMU group of the device and its effective DMA mapping mode.
+func ReadIOMMUGroup(dir string, bdf string) (IOMMUGroup, error) {
+	if dir == "" {
+		dir = DefaultDevicesDir
+	}
+
+	// e.g., "../../../../kernel/iommu_groups/25"
+	groupDir, err := filepath.EvalSymlinks(filepath.Join(dir, bdf, "iommu_group"))
+	if errors.Is(err, os.ErrNotExist) {
+		return IOMMUGroup{DMAMode: DMAModeDisabled}, nil
+	}
+	if err != nil {
+		return IOMMUGroup{}, err
+	}
+	group := IOMMUGroup{ID: filepath.Base(groupDir)}
+
+	b, err := os.ReadFile(filepath.Join(groupDir, "type"))
+	if err != nil {
+		return IOMMUGroup{}, err
+	}
+	group.DomainType = strings.TrimSpace(string(b))
+	group.DMAMode = ParseIOMMUDomainType(group.DomainType)
+	return group, nil
+}
+
+// ParseIOMMUDomainType maps the IOMMU group domain type to the DMA mapping mode,
+// or returns the domain type as is if unknown.
+// ref. https://www.kernel.org/doc/Documentation/ABI/testing/sysfs-kernel-iommu_groups
+func ParseIOMMUDomainType(typ string) string {
+	switch typ {
+	case "identity":
+		return DMAModePassthrough
+	case "DMA", "DMA-FQ", "auto":
+		return DMAModeTranslated
+	case "unmanaged":
+		return DMAModeUnmanaged
+	case "blocked":
+		return DMAModeBlocked
+	default:
+		return typ
+	}
+}
+
+// IOMMUCmdline is the IOMMU kernel parameters.
+// ref. https://www.kernel.org/doc/html/latest/admin-guide/kernel-parameters.html
+type IOMMUCmdline struct {
+	// IOMMU is the "iommu" parameter (e.g., "pt", "off", "nopt").
+	IOMMU string `json:"iommu,omitempty"`
+	// IntelIOMMU is the "intel_iommu" parameter (e.g., "on", "off").
+	IntelIOMMU string `json:"intel_iommu,omitempty"`
+	// AMDIOMMU is the "amd_iommu" parameter (e.g., "on", "off").
+	AMDIOMMU string `json:"amd_iommu,omitempty"`
+	// Passthrough is the "iommu.passthrough" parameter (e.g., "1", "0").
+	Passthrough string `json:"iommu_passthrough,omitempty"`
+}
+
+// ParseIOMMUCmdline parses the IOMMU kernel parameters from the "/proc/cmdline" contents.
+// The last one wins if the parameter is set multiple times.
+func ParseIOMMUCmdline(cmdline string) IOMMUCmdline {
+	var c IOMMUCmdline
+	for _, field := range strings.Fields(cmdline) {
+		k, v, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "iommu":
+			c.IOMMU = v
+		case "intel_iommu":
+			c.IntelIOMMU = v
+		case "amd_iommu":
+			c.AMDIOMMU = v
+		case "iommu.passthrough":
+			c.Passthrough = v
+		}
+	}
+	return c
+}
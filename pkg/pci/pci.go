@@ -0,0 +1,143 @@
+// Package pci enumerates the PCI devices and their upstream bridges from the sysfs.
+package pci
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultDevicesDir is the sysfs directory of the PCI devices.
+const DefaultDevicesDir = "/sys/bus/pci/devices"
+
+const (
+	VendorNVIDIA = 0x10de
+	VendorAMD    = 0x1002
+
+	// ref. https://pcisig.com/sites/default/files/files/PCI_Code-ID_r_1_11__v24_Jan_2019.pdf
+	classDisplay               = 0x03
+	classNetwork               = 0x02
+	classBridge                = 0x06
+	classProcessingAccelerator = 0x12
+	subclassPCI2PCI            = 0x04
+)
+
+// e.g., "0000:00:01.0"
+var regexBDF = regexp.MustCompile(`^[0-9a-fA-F]{4}:[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]$`)
+
+// Device is a PCI device in the sysfs.
+type Device struct {
+	// BDF is the PCI address in the "domain:bus:device.function" format (e.g., "0000:05:00.0").
+	BDF    string `json:"bdf"`
+	Vendor uint16 `json:"vendor"`
+	Device uint16 `json:"device"`
+	// Class is the 24-bit class code (e.g., 0x030200 for the 3D controller).
+	Class uint32 `json:"class"`
+	// Upstream is the BDFs of the upstream bridges, from the root port down to the parent bridge.
+	Upstream []string `json:"upstream,omitempty"`
+}
+
+// IsNVIDIAGPU returns true if the device is an NVIDIA display or 3D controller.
+func (d Device) IsNVIDIAGPU() bool {
+	return d.Vendor == VendorNVIDIA && d.Class>>16 == classDisplay
+}
+
+// IsAMDGPU returns true if the device is an AMD display controller (e.g., MI250)
+// or processing accelerator (e.g., MI300X).
+func (d Device) IsAMDGPU() bool {
+	return d.Vendor == VendorAMD && (d.Class>>16 == classDisplay || d.Class>>16 == classProcessingAccelerator)
+}
+
+// IsNIC returns true if the device is a network controller (e.g., Ethernet or InfiniBand).
+func (d Device) IsNIC() bool {
+	return d.Class>>16 == classNetwork
+}
+
+// IsBridge returns true if the device is a PCI-to-PCI bridge (e.g., a root port or a switch port).
+func (d Device) IsBridge() bool {
+	return d.Class>>16 == classBridge && (d.Class>>8)&0xff == subclassPCI2PCI
+}
+
+// List lists the PCI devices in the sysfs directory (defaults to "/sys/bus/pci/devices"),
+// sorted by the BDF.
+func List(dir string) ([]Device, error) {
+	if dir == "" {
+		dir = DefaultDevicesDir
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	devs := make([]Device, 0, len(entries))
+	for _, entry := range entries {
+		if !regexBDF.MatchString(entry.Name()) {
+			continue
+		}
+		dev, err := readDevice(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		devs = append(devs, dev)
+	}
+	sort.Slice(devs, func(i, j int) bool {
+		return devs[i].BDF < devs[j].BDF
+	})
+	return devs, nil
+}
+
+func readDevice(dir string, bdf string) (Device, error) {
+	dev := Device{BDF: bdf}
+
+	vendor, err := readHex(filepath.Join(dir, bdf, "vendor"))
+	if err != nil {
+		return Device{}, err
+	}
+	dev.Vendor = uint16(vendor)
+
+	device, err := readHex(filepath.Join(dir, bdf, "device"))
+	if err != nil {
+		return Device{}, err
+	}
+	dev.Device = uint16(device)
+
+	class, err := readHex(filepath.Join(dir, bdf, "class"))
+	if err != nil {
+		return Device{}, err
+	}
+	dev.Class = uint32(class)
+
+	// e.g., "../../../devices/pci0000:00/0000:00:01.0/0000:01:00.0/0000:02:00.0/0000:05:00.0"
+	resolved, err := filepath.EvalSymlinks(filepath.Join(dir, bdf))
+	if err != nil {
+		return Device{}, err
+	}
+	dev.Upstream = parseUpstream(resolved, bdf)
+
+	return dev, nil
+}
+
+// parseUpstream returns the BDFs in the resolved sysfs device path before the device itself.
+func parseUpstream(path string, bdf string) []string {
+	var upstream []string
+	for _, elem := range strings.Split(filepath.ToSlash(path), "/") {
+		if elem == bdf {
+			break
+		}
+		if regexBDF.MatchString(elem) {
+			upstream = append(upstream, elem)
+		}
+	}
+	return upstream
+}
+
+func readHex(file string) (uint64, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(string(b)), "0x"), 16, 32)
+}
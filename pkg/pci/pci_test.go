@@ -0,0 +1,235 @@
+package pci
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/leptonai/gpud/pkg/uevent"
+)
+
+// writeDevice creates a fake sysfs device under "devices/pci0000:00/<path...>"
+// and its symlink in the "bus/pci/devices" directory.
+func writeDevice(t *testing.T, root string, vendor, device, class string, config []byte, path ...string) {
+	t.Helper()
+
+	dir := filepath.Join(append([]string{root, "devices", "pci0000:00"}, path...)...)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, v := range map[string]string{"vendor": vendor, "device": device, "class": class} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(v+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if config != nil {
+		if err := os.WriteFile(filepath.Join(dir, "config"), config, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	busDir := filepath.Join(root, "bus", "pci", "devices")
+	if err := os.MkdirAll(busDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dir, filepath.Join(busDir, path[len(path)-1])); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// acsConfig returns a configuration space with an AER capability followed by the ACS capability.
+func acsConfig(ctl ACSControl) []byte {
+	config := make([]byte, extendedConfigSize)
+	// AER (ID 0x0001) at 0x100, next at 0x148
+	binary.LittleEndian.PutUint32(config[0x100:], 0x0001|1<<16|0x148<<20)
+	// ACS (ID 0x000d) at 0x148, end of the list
+	binary.LittleEndian.PutUint32(config[0x148:], extendedCapabilityIDACS|1<<16)
+	binary.LittleEndian.PutUint16(config[0x148+acsControlOffset:], uint16(ctl))
+	return config
+}
+
+func TestListAndACS(t *testing.T) {
+	root := t.TempDir()
+	writeDevice(t, root, "0x8086", "0x2030", "0x060400", acsConfig(ACSSourceValidation), "0000:00:01.0")
+	writeDevice(t, root, "0x1000", "0xc010", "0x060400", acsConfig(0), "0000:00:01.0", "0000:01:00.0")
+	writeDevice(t, root, "0x1000", "0xc010", "0x060400", acsConfig(ACSSourceValidation|ACSP2PRequestRedirect|ACSP2PCompletionRedirect|ACSUpstreamForwarding), "0000:00:01.0", "0000:01:00.0", "0000:02:00.0")
+	writeDevice(t, root, "0x10de", "0x2330", "0x030200", nil, "0000:00:01.0", "0000:01:00.0", "0000:02:00.0", "0000:05:00.0")
+	writeDevice(t, root, "0x15b3", "0x1021", "0x020700", make([]byte, 64), "0000:00:01.0", "0000:01:00.0", "0000:02:00.0", "0000:06:00.0")
+
+	dir := filepath.Join(root, "bus", "pci", "devices")
+	devs, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 5 {
+		t.Fatalf("expected 5 devices, got %d", len(devs))
+	}
+
+	gpu := devs[3]
+	if gpu.BDF != "0000:05:00.0" || !gpu.IsNVIDIAGPU() || gpu.IsNIC() || gpu.IsBridge() {
+		t.Errorf("unexpected gpu %+v", gpu)
+	}
+	if want := []string{"0000:00:01.0", "0000:01:00.0", "0000:02:00.0"}; !reflect.DeepEqual(gpu.Upstream, want) {
+		t.Errorf("expected upstream %v, got %v", want, gpu.Upstream)
+	}
+	if nic := devs[4]; !nic.IsNIC() || nic.IsNVIDIAGPU() {
+		t.Errorf("unexpected nic %+v", nic)
+	}
+	if !devs[0].IsBridge() || len(devs[0].Upstream) != 0 {
+		t.Errorf("unexpected root port %+v", devs[0])
+	}
+
+	ctl, err := ReadACSControl(dir, "0000:02:00.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ctl.RedirectsP2P() {
+		t.Errorf("expected p2p redirect, got %s", ctl)
+	}
+	if want := "SrcValid+ TransBlk- ReqRedir+ CmpltRedir+ UpstreamFwd+ EgressCtrl- DirectTrans-"; ctl.String() != want {
+		t.Errorf("expected %q, got %q", want, ctl.String())
+	}
+
+	if err := WriteACSControl(dir, "0000:02:00.0", ctl.WithoutP2PRedirects()); err != nil {
+		t.Fatal(err)
+	}
+	ctl, err = ReadACSControl(dir, "0000:02:00.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctl.RedirectsP2P() {
+		t.Errorf("expected the ACS redirects disabled, got %s", ctl)
+	}
+	if ctl != ACSSourceValidation|ACSUpstreamForwarding {
+		t.Errorf("expected the other ACS flags kept, got %s", ctl)
+	}
+
+	if _, err := ReadACSControl(dir, "0000:06:00.0"); err != ErrExtendedConfigNotReadable {
+		t.Errorf("expected %v, got %v", ErrExtendedConfigNotReadable, err)
+	}
+}
+
+func TestFindExtendedCapabilityLoop(t *testing.T) {
+	config := make([]byte, extendedConfigSize)
+	// points to itself
+	binary.LittleEndian.PutUint32(config[0x100:], 0x0001|1<<16|0x100<<20)
+	if offset := findExtendedCapability(config, extendedCapabilityIDACS); offset != 0 {
+		t.Errorf("expected no capability, got %d", offset)
+	}
+}
+
+func TestReadIOMMUGroup(t *testing.T) {
+	root := t.TempDir()
+	writeDevice(t, root, "0x10de", "0x2330", "0x030200", nil, "0000:05:00.0")
+	writeDevice(t, root, "0x15b3", "0x1021", "0x020700", nil, "0000:06:00.0")
+
+	groupDir := filepath.Join(root, "kernel", "iommu_groups", "25")
+	if err := os.MkdirAll(groupDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(groupDir, "type"), []byte("DMA-FQ\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(groupDir, filepath.Join(root, "devices", "pci0000:00", "0000:05:00.0", "iommu_group")); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(root, "bus", "pci", "devices")
+	group, err := ReadIOMMUGroup(dir, "0000:05:00.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (IOMMUGroup{ID: "25", DomainType: "DMA-FQ", DMAMode: DMAModeTranslated}); group != want {
+		t.Errorf("expected %+v, got %+v", want, group)
+	}
+
+	group, err = ReadIOMMUGroup(dir, "0000:06:00.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.DMAMode != DMAModeDisabled {
+		t.Errorf("expected %q, got %+v", DMAModeDisabled, group)
+	}
+}
+
+func TestParseIOMMUCmdline(t *testing.T) {
+	got := ParseIOMMUCmdline("BOOT_IMAGE=/vmlinuz-6.8.0 root=UUID=abc ro intel_iommu=on iommu=pt quiet iommu=nopt")
+	want := IOMMUCmdline{IOMMU: "nopt", IntelIOMMU: "on"}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestCache(t *testing.T) {
+	root := t.TempDir()
+	writeDevice(t, root, "0x10de", "0x2330", "0x030200", nil, "0000:05:00.0")
+	dir := filepath.Join(root, "bus", "pci", "devices")
+
+	c := NewCache(dir)
+	devs, err := c.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 1 {
+		t.Fatalf("expected 1 device, got %d", len(devs))
+	}
+
+	// pretend the hotplug events are being received
+	c.watching = true
+	writeDevice(t, root, "0x10de", "0x2330", "0x030200", nil, "0000:06:00.0")
+	devs, err = c.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 1 {
+		t.Fatalf("expected the cached 1 device, got %d", len(devs))
+	}
+
+	c.Invalidate()
+	devs, err = c.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 2 {
+		t.Fatalf("expected 2 devices after invalidation, got %d", len(devs))
+	}
+}
+
+func TestIsHotplugEvent(t *testing.T) {
+	tests := []struct {
+		ev       uevent.Event
+		expected bool
+	}{
+		{ev: uevent.Event{Action: uevent.ActionAdd, Subsystem: "pci"}, expected: true},
+		{ev: uevent.Event{Action: uevent.ActionRemove, Subsystem: "pci"}, expected: true},
+		{ev: uevent.Event{Action: uevent.ActionBind, Subsystem: "pci"}, expected: false},
+		{ev: uevent.Event{Action: uevent.ActionAdd, Subsystem: "net"}, expected: false},
+	}
+	for _, tt := range tests {
+		if got := IsHotplugEvent(tt.ev); got != tt.expected {
+			t.Errorf("IsHotplugEvent(%+v) = %v, want %v", tt.ev, got, tt.expected)
+		}
+	}
+}
+
+func TestIsAMDGPU(t *testing.T) {
+	tests := []struct {
+		dev  Device
+		want bool
+	}{
+		// MI250X (display controller)
+		{Device{Vendor: VendorAMD, Class: 0x038000}, true},
+		// MI300X (processing accelerator)
+		{Device{Vendor: VendorAMD, Class: 0x120000}, true},
+		// PCI bridge of the AMD host
+		{Device{Vendor: VendorAMD, Class: 0x060400}, false},
+		{Device{Vendor: VendorNVIDIA, Class: 0x030200}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.dev.IsAMDGPU(); got != tt.want {
+			t.Errorf("IsAMDGPU(%+v) = %v, want %v", tt.dev, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Package probegate checks the node load before running any active probe or benchmark
+// (e.g., DCGM diagnostics, memory scrubs, bandwidth tests), so that the monitoring
+// never perturbs the training jobs running on the node.
+package probegate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/lifecycle"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	procs "github.com/shirou/gopsutil/v4/process"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	// ErrBusy is returned when the node stays busy until the max delay elapses,
+	// thus the probe is deferred to its next schedule.
+	ErrBusy = errors.New("node is busy")
+	// ErrAborted is returned when a job starts while the probe is running,
+	// thus the probe is aborted.
+	ErrAborted = errors.New("probe aborted")
+)
+
+const (
+	DefaultMaxGPUUsedPercent = 5
+	DefaultMaxCPUUsedPercent = 50
+	DefaultCheckInterval     = time.Minute
+)
+
+// Config defines when the node is considered busy.
+type Config struct {
+	// Maximum GPU utilization of any GPU to consider the node idle, defaults to 5%.
+	MaxGPUUsedPercent uint32 `json:"max_gpu_used_percent"`
+	// Maximum host CPU utilization to consider the node idle, defaults to 50%.
+	MaxCPUUsedPercent float64 `json:"max_cpu_used_percent"`
+	// Set true to ignore the processes running on the GPUs
+	// (by default, any GPU process makes the node busy).
+	AllowGPUProcesses bool `json:"allow_gpu_processes,omitempty"`
+
+	// Files (glob patterns) whose existence signals a running job
+	// (e.g., the scheduler prolog/epilog lock files "/var/run/slurm-job-*").
+	JobMarkerFiles []string `json:"job_marker_files,omitempty"`
+	// Process names (glob patterns) that signal a running job (e.g., "torchrun", "slurmstepd").
+	JobProcessNames []string `json:"job_process_names,omitempty"`
+
+	// Interval to re-check the load while deferring or running a probe, defaults to 1 minute.
+	CheckInterval metav1.Duration `json:"check_interval"`
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.MaxGPUUsedPercent == 0 {
+		cfg.MaxGPUUsedPercent = DefaultMaxGPUUsedPercent
+	}
+	if cfg.MaxCPUUsedPercent == 0 {
+		cfg.MaxCPUUsedPercent = DefaultMaxCPUUsedPercent
+	}
+	if cfg.CheckInterval.Duration == 0 {
+		cfg.CheckInterval = metav1.Duration{Duration: DefaultCheckInterval}
+	}
+}
+
+func (cfg Config) Validate() error {
+	if cfg.MaxGPUUsedPercent > 100 {
+		return fmt.Errorf("max_gpu_used_percent must be <= 100, got %d", cfg.MaxGPUUsedPercent)
+	}
+	if cfg.MaxCPUUsedPercent < 0 || cfg.MaxCPUUsedPercent > 100 {
+		return fmt.Errorf("max_cpu_used_percent must be between 0 and 100, got %v", cfg.MaxCPUUsedPercent)
+	}
+	if cfg.CheckInterval.Duration < 0 {
+		return errors.New("check_interval must be non-negative")
+	}
+	for _, patterns := range [][]string{cfg.JobMarkerFiles, cfg.JobProcessNames} {
+		for _, p := range patterns {
+			if _, err := filepath.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// GPULoad is the current load of a GPU.
+type GPULoad struct {
+	UUID             string `json:"uuid"`
+	UsedPercent      uint32 `json:"used_percent"`
+	RunningProcesses int    `json:"running_processes"`
+}
+
+// Load is the sampled node load.
+type Load struct {
+	GPUs           []GPULoad `json:"gpus"`
+	CPUUsedPercent float64   `json:"cpu_used_percent"`
+	// RunningJobs are the running-job signals (the matched marker files or process names).
+	RunningJobs []string `json:"running_jobs,omitempty"`
+}
+
+// Busy returns true with the reason if the load exceeds the thresholds.
+func (cfg Config) Busy(load Load) (bool, string) {
+	if len(load.RunningJobs) > 0 {
+		return true, fmt.Sprintf("running job(s) %s", strings.Join(load.RunningJobs, ", "))
+	}
+	for _, g := range load.GPUs {
+		if !cfg.AllowGPUProcesses && g.RunningProcesses > 0 {
+			return true, fmt.Sprintf("%s has %d running process(es)", g.UUID, g.RunningProcesses)
+		}
+		if g.UsedPercent > cfg.MaxGPUUsedPercent {
+			return true, fmt.Sprintf("%s utilization %d%% > %d%%", g.UUID, g.UsedPercent, cfg.MaxGPUUsedPercent)
+		}
+	}
+	if load.CPUUsedPercent > cfg.MaxCPUUsedPercent {
+		return true, fmt.Sprintf("cpu utilization %.1f%% > %.1f%%", load.CPUUsedPercent, cfg.MaxCPUUsedPercent)
+	}
+	return false, ""
+}
+
+// GPULoadsFunc returns the current GPU loads (e.g., from NVML).
+type GPULoadsFunc func(ctx context.Context) ([]GPULoad, error)
+
+// Gate checks the node load before and during the probes.
+type Gate struct {
+	cfg      Config
+	gpuLoads GPULoadsFunc
+}
+
+// New creates a gate. If gpuLoads is nil, only the CPU and job signals are checked.
+func New(cfg Config, gpuLoads GPULoadsFunc) *Gate {
+	cfg.SetDefaultsIfNotSet()
+	return &Gate{cfg: cfg, gpuLoads: gpuLoads}
+}
+
+// Sample samples the current node load.
+func (g *Gate) Sample(ctx context.Context) (Load, error) {
+	var load Load
+	if g.gpuLoads != nil {
+		gpus, err := g.gpuLoads(ctx)
+		if err != nil {
+			return Load{}, fmt.Errorf("failed to get gpu loads: %w", err)
+		}
+		load.GPUs = gpus
+	}
+
+	usages, err := cpu.PercentWithContext(ctx, time.Second, false)
+	if err != nil {
+		return Load{}, fmt.Errorf("failed to get cpu usage: %w", err)
+	}
+	if len(usages) > 0 {
+		load.CPUUsedPercent = usages[0]
+	}
+
+	jobs, err := g.runningJobs(ctx)
+	if err != nil {
+		return Load{}, err
+	}
+	load.RunningJobs = jobs
+	return load, nil
+}
+
+func (g *Gate) runningJobs(ctx context.Context) ([]string, error) {
+	var jobs []string
+	for _, p := range g.cfg.JobMarkerFiles {
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, matches...)
+	}
+
+	if len(g.cfg.JobProcessNames) == 0 {
+		return jobs, nil
+	}
+	ps, err := procs.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	seen := make(map[string]struct{})
+	for _, p := range ps {
+		name, err := p.NameWithContext(ctx)
+		if err != nil {
+			// process may have exited
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		for _, pattern := range g.cfg.JobProcessNames {
+			if matched, _ := filepath.Match(pattern, name); matched {
+				seen[name] = struct{}{}
+				jobs = append(jobs, name)
+				break
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// Check returns true if the node is idle, or false with the reason.
+// A failure to sample the load is treated as busy.
+// The node being repaired is always treated as idle, so the validation probes run without delay.
+func (g *Gate) Check(ctx context.Context) (bool, string) {
+	if lifecycle.Repairing() {
+		return true, ""
+	}
+	load, err := g.Sample(ctx)
+	if err != nil {
+		return false, err.Error()
+	}
+	busy, reason := g.cfg.Busy(load)
+	return !busy, reason
+}
+
+// Wait blocks until the node is idle, and returns an ErrBusy error
+// if the node stays busy until the max delay elapses.
+// Zero max delay checks the load only once.
+func (g *Gate) Wait(ctx context.Context, maxDelay time.Duration) error {
+	deadline := time.Now().Add(maxDelay)
+	for {
+		idle, reason := g.Check(ctx)
+		if idle {
+			return nil
+		}
+		if !time.Now().Add(g.cfg.CheckInterval.Duration).Before(deadline) {
+			return fmt.Errorf("%w: %s", ErrBusy, reason)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(g.cfg.CheckInterval.Duration):
+		}
+	}
+}
+
+// Run runs the probe, expected to be called once the node is idle (see Check and Wait).
+// While the probe runs, the probe context is canceled and ErrAborted is returned
+// as soon as a running-job signal shows up.
+// The GPU/CPU utilization is not checked during the run, since the probe itself loads the node.
+func (g *Gate) Run(ctx context.Context, probe func(ctx context.Context) error) error {
+	pctx, pcancel := context.WithCancel(ctx)
+	defer pcancel()
+
+	aborted := make(chan string, 1)
+	go func() {
+		ticker := time.NewTicker(g.cfg.CheckInterval.Duration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-pctx.Done():
+				return
+			case <-ticker.C:
+			}
+			jobs, err := g.runningJobs(pctx)
+			if err != nil || len(jobs) == 0 {
+				continue
+			}
+			aborted <- strings.Join(jobs, ", ")
+			pcancel()
+			return
+		}
+	}()
+
+	err := probe(pctx)
+	pcancel()
+
+	select {
+	case jobs := <-aborted:
+		return fmt.Errorf("%w: running job(s) %s", ErrAborted, jobs)
+	default:
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package probegate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBusy(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaultsIfNotSet()
+
+	tests := []struct {
+		name string
+		cfg  Config
+		load Load
+		want bool
+	}{
+		{
+			name: "idle",
+			cfg:  cfg,
+			load: Load{GPUs: []GPULoad{{UUID: "GPU-0"}, {UUID: "GPU-1", UsedPercent: 5}}, CPUUsedPercent: 10},
+			want: false,
+		},
+		{
+			name: "gpu process",
+			cfg:  cfg,
+			load: Load{GPUs: []GPULoad{{UUID: "GPU-0", RunningProcesses: 1}}},
+			want: true,
+		},
+		{
+			name: "gpu process allowed",
+			cfg:  Config{MaxGPUUsedPercent: 5, MaxCPUUsedPercent: 50, AllowGPUProcesses: true},
+			load: Load{GPUs: []GPULoad{{UUID: "GPU-0", RunningProcesses: 1}}},
+			want: false,
+		},
+		{
+			name: "gpu utilization",
+			cfg:  cfg,
+			load: Load{GPUs: []GPULoad{{UUID: "GPU-0", UsedPercent: 60}}},
+			want: true,
+		},
+		{
+			name: "cpu utilization",
+			cfg:  cfg,
+			load: Load{CPUUsedPercent: 90},
+			want: true,
+		},
+		{
+			name: "running job",
+			cfg:  cfg,
+			load: Load{RunningJobs: []string{"torchrun"}},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			busy, reason := tt.cfg.Busy(tt.load)
+			if busy != tt.want {
+				t.Errorf("Busy() = %v (%q), want %v", busy, reason, tt.want)
+			}
+			if busy && reason == "" {
+				t.Error("expected a reason when busy")
+			}
+		})
+	}
+}
+
+func TestRunAborted(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "job-1")
+
+	g := New(Config{
+		JobMarkerFiles: []string{filepath.Join(dir, "job-*")},
+		CheckInterval:  metav1.Duration{Duration: 10 * time.Millisecond},
+	}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := g.Run(ctx, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("Run() without job = %v, want nil", err)
+	}
+
+	err := g.Run(ctx, func(ctx context.Context) error {
+		if err := os.WriteFile(marker, nil, 0644); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, ErrAborted) {
+		t.Fatalf("Run() with job = %v, want %v", err, ErrAborted)
+	}
+}
@@ -20,6 +20,9 @@ type Op struct {
 	runAsBashScript         bool
 
 	restartConfig *RestartConfig
+
+	rateLimited  bool
+	rateLimitKey string
 }
 
 func (op *Op) applyOpts(opts []OpOption) error {
@@ -122,6 +125,16 @@ func WithRestartConfig(config RestartConfig) OpOption {
 	}
 }
 
+// Rate limits the process start with the global and the per-key (e.g., component name)
+// command execution rate limits, see "pkg/execlimit".
+// The empty key is only limited by the global rate.
+func WithRateLimit(key string) OpOption {
+	return func(op *Op) {
+		op.rateLimited = true
+		op.rateLimitKey = key
+	}
+}
+
 func commandExists(name string) bool {
 	p, err := exec.LookPath(name)
 	if err != nil {
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/pkg/execlimit"
 )
 
 type Process interface {
@@ -80,6 +81,9 @@ type process struct {
 	stderrReader io.ReadCloser
 
 	restartConfig *RestartConfig
+
+	rateLimited  bool
+	rateLimitKey string
 }
 
 func New(opts ...OpOption) (Process, error) {
@@ -139,10 +143,20 @@ func New(opts ...OpOption) (Process, error) {
 		outputFile:  op.outputFile,
 
 		restartConfig: op.restartConfig,
+
+		rateLimited:  op.rateLimited,
+		rateLimitKey: op.rateLimitKey,
 	}, nil
 }
 
 func (p *process) Start(ctx context.Context) error {
+	// wait before locking, not to block the abort
+	if p.rateLimited {
+		if err := execlimit.Wait(ctx, p.rateLimitKey); err != nil {
+			return err
+		}
+	}
+
 	p.cmdMu.Lock()
 	defer p.cmdMu.Unlock()
 
@@ -0,0 +1,70 @@
+//go:build !windows
+// +build !windows
+
+package shmring
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Create creates (or truncates) the ring buffer file with the capacity (number of records),
+// and maps it to the memory for writing (e.g., "/dev/shm/gpud-metrics.ring").
+func Create(path string, capacity uint64, interval time.Duration) (*Writer, error) {
+	if capacity == 0 {
+		return nil, fmt.Errorf("invalid capacity %d", capacity)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	size := Size(capacity)
+	if err := f.Truncate(int64(size)); err != nil {
+		return nil, err
+	}
+	buf, err := unix.Mmap(int(f.Fd()), 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mmap %s: %w", path, err)
+	}
+	initHeader(buf, capacity, interval)
+
+	return &Writer{
+		r:     ring{buf: buf, capacity: capacity},
+		unmap: func() error { return unix.Munmap(buf) },
+	}, nil
+}
+
+// Open maps the existing ring buffer file to the memory for reading.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	buf, err := unix.Mmap(int(f.Fd()), 0, int(fi.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mmap %s: %w", path, err)
+	}
+
+	capacity, interval, err := parseHeader(buf)
+	if err != nil {
+		_ = unix.Munmap(buf)
+		return nil, err
+	}
+	return &Reader{
+		r:        ring{buf: buf, capacity: capacity},
+		interval: interval,
+		unmap:    func() error { return unix.Munmap(buf) },
+	}, nil
+}
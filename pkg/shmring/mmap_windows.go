@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package shmring
+
+import (
+	"errors"
+	"time"
+)
+
+var errNotSupported = errors.New("memory-mapped ring buffer not supported on windows")
+
+func Create(path string, capacity uint64, interval time.Duration) (*Writer, error) {
+	return nil, errNotSupported
+}
+
+func Open(path string) (*Reader, error) {
+	return nil, errNotSupported
+}
@@ -0,0 +1,197 @@
+// Package shmring implements the memory-mapped ring buffer of the high-frequency GPU metric samples,
+// so that a co-located reader (e.g., a profiler) can read the samples without the HTTP overhead.
+//
+// The file layout is:
+//
+//	header (64 bytes)
+//	  [0:8]   magic "GPUDRING"
+//	  [8:12]  version (uint32)
+//	  [12:16] record size in bytes (uint32)
+//	  [16:24] capacity in records (uint64)
+//	  [24:32] write sequence, the total number of records written (uint64)
+//	  [32:40] sample interval in nanoseconds (uint64)
+//	records (capacity * 32 bytes)
+//	  [0:8]   record sequence + 1, or 0 while being written (uint64)
+//	  [8:16]  unix timestamp in nanoseconds (int64)
+//	  [16:20] GPU minor number (uint32)
+//	  [20:24] GPU utilization percent (uint32)
+//	  [24:28] GPU memory utilization percent (uint32)
+//	  [28:32] GPU power usage in milliwatts (uint32)
+//
+// All the fields are in the little-endian byte order.
+// The single writer updates the record sequence before and after writing the record,
+// so the reader discards the records overwritten while being read.
+package shmring
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+const (
+	Magic   = "GPUDRING"
+	Version = 1
+
+	HeaderSize = 64
+	RecordSize = 32
+)
+
+const (
+	offsetVersion    = 8
+	offsetRecordSize = 12
+	offsetCapacity   = 16
+	offsetWriteSeq   = 24
+	offsetInterval   = 32
+)
+
+var (
+	ErrInvalidMagic   = errors.New("invalid ring buffer magic")
+	ErrInvalidVersion = errors.New("unsupported ring buffer version")
+)
+
+// Sample is the high-frequency metric sample of a GPU.
+type Sample struct {
+	Time                time.Time `json:"time"`
+	MinorNumber         uint32    `json:"minor_number"`
+	GPUUsedPercent      uint32    `json:"gpu_used_percent"`
+	MemoryUsedPercent   uint32    `json:"memory_used_percent"`
+	PowerUsageMilliWatt uint32    `json:"power_usage_milli_watt"`
+}
+
+// Size returns the file size of the ring buffer with the capacity.
+func Size(capacity uint64) int {
+	return HeaderSize + int(capacity)*RecordSize
+}
+
+type ring struct {
+	buf      []byte
+	capacity uint64
+}
+
+// uint64At returns the pointer to the 8-byte aligned field for the atomic access.
+// The mapped memory is page-aligned, and the header/record sizes are multiples of 8.
+func (r *ring) uint64At(off int) *uint64 {
+	return (*uint64)(unsafe.Pointer(&r.buf[off]))
+}
+
+func recordOffset(seq uint64, capacity uint64) int {
+	return HeaderSize + int(seq%capacity)*RecordSize
+}
+
+func initHeader(buf []byte, capacity uint64, interval time.Duration) {
+	copy(buf[0:8], Magic)
+	binary.LittleEndian.PutUint32(buf[offsetVersion:], Version)
+	binary.LittleEndian.PutUint32(buf[offsetRecordSize:], RecordSize)
+	binary.LittleEndian.PutUint64(buf[offsetCapacity:], capacity)
+	binary.LittleEndian.PutUint64(buf[offsetWriteSeq:], 0)
+	binary.LittleEndian.PutUint64(buf[offsetInterval:], uint64(interval))
+}
+
+func parseHeader(buf []byte) (capacity uint64, interval time.Duration, err error) {
+	if len(buf) < HeaderSize {
+		return 0, 0, fmt.Errorf("ring buffer too small (%d bytes)", len(buf))
+	}
+	if !bytes.Equal(buf[0:8], []byte(Magic)) {
+		return 0, 0, ErrInvalidMagic
+	}
+	if v := binary.LittleEndian.Uint32(buf[offsetVersion:]); v != Version {
+		return 0, 0, fmt.Errorf("%w %d", ErrInvalidVersion, v)
+	}
+	if rs := binary.LittleEndian.Uint32(buf[offsetRecordSize:]); rs != RecordSize {
+		return 0, 0, fmt.Errorf("unexpected record size %d", rs)
+	}
+	capacity = binary.LittleEndian.Uint64(buf[offsetCapacity:])
+	if capacity == 0 || len(buf) < Size(capacity) {
+		return 0, 0, fmt.Errorf("ring buffer size %d too small for capacity %d", len(buf), capacity)
+	}
+	return capacity, time.Duration(binary.LittleEndian.Uint64(buf[offsetInterval:])), nil
+}
+
+// Writer appends the samples to the ring buffer.
+// Only a single writer is supported.
+type Writer struct {
+	r     ring
+	unmap func() error
+}
+
+// Append writes the sample, overwriting the oldest one if the ring buffer is full.
+func (w *Writer) Append(s Sample) {
+	writeSeq := w.r.uint64At(offsetWriteSeq)
+	seq := atomic.LoadUint64(writeSeq)
+	off := recordOffset(seq, w.r.capacity)
+
+	recSeq := w.r.uint64At(off)
+	atomic.StoreUint64(recSeq, 0)
+
+	rec := w.r.buf[off : off+RecordSize]
+	binary.LittleEndian.PutUint64(rec[8:], uint64(s.Time.UnixNano()))
+	binary.LittleEndian.PutUint32(rec[16:], s.MinorNumber)
+	binary.LittleEndian.PutUint32(rec[20:], s.GPUUsedPercent)
+	binary.LittleEndian.PutUint32(rec[24:], s.MemoryUsedPercent)
+	binary.LittleEndian.PutUint32(rec[28:], s.PowerUsageMilliWatt)
+
+	atomic.StoreUint64(recSeq, seq+1)
+	atomic.StoreUint64(writeSeq, seq+1)
+}
+
+// Close unmaps the ring buffer. The file is kept for the readers.
+func (w *Writer) Close() error {
+	return w.unmap()
+}
+
+// Reader reads the samples from the ring buffer.
+type Reader struct {
+	r        ring
+	interval time.Duration
+	unmap    func() error
+}
+
+// Interval returns the sample interval of the writer.
+func (rd *Reader) Interval() time.Duration {
+	return rd.interval
+}
+
+// ReadSince returns the samples written since the write sequence (0 to read all),
+// and the next write sequence to read from.
+// The samples already overwritten by the writer are skipped.
+func (rd *Reader) ReadSince(from uint64) ([]Sample, uint64) {
+	to := atomic.LoadUint64(rd.r.uint64At(offsetWriteSeq))
+	if to > rd.r.capacity && from < to-rd.r.capacity {
+		from = to - rd.r.capacity
+	}
+
+	var samples []Sample
+	for seq := from; seq < to; seq++ {
+		off := recordOffset(seq, rd.r.capacity)
+		recSeq := rd.r.uint64At(off)
+		if atomic.LoadUint64(recSeq) != seq+1 {
+			continue
+		}
+
+		rec := rd.r.buf[off : off+RecordSize]
+		s := Sample{
+			Time:                time.Unix(0, int64(binary.LittleEndian.Uint64(rec[8:]))).UTC(),
+			MinorNumber:         binary.LittleEndian.Uint32(rec[16:]),
+			GPUUsedPercent:      binary.LittleEndian.Uint32(rec[20:]),
+			MemoryUsedPercent:   binary.LittleEndian.Uint32(rec[24:]),
+			PowerUsageMilliWatt: binary.LittleEndian.Uint32(rec[28:]),
+		}
+
+		// overwritten while being read
+		if atomic.LoadUint64(recSeq) != seq+1 {
+			continue
+		}
+		samples = append(samples, s)
+	}
+	return samples, to
+}
+
+// Close unmaps the ring buffer.
+func (rd *Reader) Close() error {
+	return rd.unmap()
+}
@@ -0,0 +1,87 @@
+package shmring
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRing(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "gpud-metrics.ring")
+	w, err := Create(path, 4, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() != int64(Size(4)) {
+		t.Fatalf("size = %d, want %d", fi.Size(), Size(4))
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Interval() != 100*time.Millisecond {
+		t.Fatalf("interval = %v", r.Interval())
+	}
+
+	samples, next := r.ReadSince(0)
+	if len(samples) != 0 || next != 0 {
+		t.Fatalf("expected no samples, got %+v, %d", samples, next)
+	}
+
+	now := time.Now().UTC()
+	sample := func(i int) Sample {
+		return Sample{
+			Time:                now.Add(time.Duration(i) * 100 * time.Millisecond),
+			MinorNumber:         uint32(i % 2),
+			GPUUsedPercent:      uint32(i),
+			MemoryUsedPercent:   uint32(i * 2),
+			PowerUsageMilliWatt: uint32(i * 1000),
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		w.Append(sample(i))
+	}
+	samples, next = r.ReadSince(0)
+	if next != 3 || !reflect.DeepEqual(samples, []Sample{sample(0), sample(1), sample(2)}) {
+		t.Fatalf("unexpected samples %+v, next %d", samples, next)
+	}
+
+	// overflows the capacity, so the oldest samples are dropped
+	for i := 3; i < 7; i++ {
+		w.Append(sample(i))
+	}
+	samples, next = r.ReadSince(next)
+	if next != 7 || !reflect.DeepEqual(samples, []Sample{sample(3), sample(4), sample(5), sample(6)}) {
+		t.Fatalf("unexpected samples %+v, next %d", samples, next)
+	}
+	samples, next = r.ReadSince(next)
+	if len(samples) != 0 || next != 7 {
+		t.Fatalf("expected no new samples, got %+v, %d", samples, next)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "invalid.ring")
+	if err := os.WriteFile(path, make([]byte, Size(4)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); !errors.Is(err, ErrInvalidMagic) {
+		t.Fatalf("expected %v, got %v", ErrInvalidMagic, err)
+	}
+}
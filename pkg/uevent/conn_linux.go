@@ -0,0 +1,56 @@
+//go:build linux
+// +build linux
+
+package uevent
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// kernel uevents multicast group (the udev-processed events are sent to the group 2)
+const kernelGroup = 1
+
+// Conn is the netlink socket subscribed to the kernel uevents.
+type Conn struct {
+	fd int
+}
+
+// Open opens the netlink socket to receive the kernel uevents.
+func Open() (*Conn, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Pid: 0, Groups: kernelGroup}); err != nil {
+		_ = unix.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	return &Conn{fd: fd}, nil
+}
+
+// Read blocks until the next kernel uevent is received.
+// The malformed messages are skipped.
+func (c *Conn) Read() (Event, error) {
+	buf := make([]byte, 16*1024)
+	for {
+		n, _, err := unix.Recvfrom(c.fd, buf, 0)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return Event{}, os.NewSyscallError("recvfrom", err)
+		}
+		ev, err := Parse(buf[:n])
+		if err != nil {
+			continue
+		}
+		return ev, nil
+	}
+}
+
+// Close closes the socket, which unblocks the pending Read.
+func (c *Conn) Close() error {
+	return unix.Close(c.fd)
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+// +build !linux
+
+package uevent
+
+// Conn is the netlink socket subscribed to the kernel uevents.
+type Conn struct{}
+
+// Open returns ErrNotSupported on the non-linux platforms.
+func Open() (*Conn, error) {
+	return nil, ErrNotSupported
+}
+
+func (c *Conn) Read() (Event, error) {
+	return Event{}, ErrNotSupported
+}
+
+func (c *Conn) Close() error {
+	return nil
+}
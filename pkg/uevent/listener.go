@@ -0,0 +1,114 @@
+package uevent
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/leptonai/gpud/log"
+)
+
+// ErrListenerClosed is returned when subscribing to a listener that stopped receiving the uevents.
+var ErrListenerClosed = errors.New("uevent listener closed")
+
+// subscriberBuffer is the number of the events buffered per subscriber,
+// the events are dropped for the subscriber that falls behind.
+const subscriberBuffer = 256
+
+// Listener fans out the kernel uevents from a single netlink socket to the subscribers.
+type Listener struct {
+	conn *Conn
+
+	mu     sync.Mutex
+	subs   map[chan Event]struct{}
+	closed bool
+}
+
+// NewListener opens the netlink socket and starts receiving the kernel uevents,
+// until the socket is closed or fails.
+func NewListener() (*Listener, error) {
+	conn, err := Open()
+	if err != nil {
+		return nil, err
+	}
+	l := &Listener{conn: conn, subs: make(map[chan Event]struct{})}
+	go l.run()
+	return l, nil
+}
+
+var (
+	defaultListenerOnce sync.Once
+	defaultListener     *Listener
+	defaultListenerErr  error
+)
+
+// Subscribe subscribes to the process-wide listener, started on the first call.
+// The channel is closed when the listener stops receiving the uevents.
+// Call the returned function to unsubscribe.
+func Subscribe() (<-chan Event, func(), error) {
+	defaultListenerOnce.Do(func() {
+		defaultListener, defaultListenerErr = NewListener()
+	})
+	if defaultListenerErr != nil {
+		return nil, nil, defaultListenerErr
+	}
+	return defaultListener.Subscribe()
+}
+
+// Subscribe subscribes to the listener.
+// The channel is closed when the listener stops receiving the uevents.
+// Call the returned function to unsubscribe.
+func (l *Listener) Subscribe() (<-chan Event, func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil, nil, ErrListenerClosed
+	}
+	ch := make(chan Event, subscriberBuffer)
+	l.subs[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if _, ok := l.subs[ch]; ok {
+				delete(l.subs, ch)
+				close(ch)
+			}
+		})
+	}, nil
+}
+
+// Close closes the socket and all the subscriber channels.
+func (l *Listener) Close() error {
+	return l.conn.Close()
+}
+
+func (l *Listener) run() {
+	for {
+		ev, err := l.conn.Read()
+		if err != nil {
+			log.Logger.Warnw("stopped receiving uevents", "error", err)
+			break
+		}
+
+		l.mu.Lock()
+		for ch := range l.subs {
+			select {
+			case ch <- ev:
+			default:
+				log.Logger.Warnw("uevent subscriber falling behind -- dropping event", "action", ev.Action, "devpath", ev.DevPath)
+			}
+		}
+		l.mu.Unlock()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	for ch := range l.subs {
+		close(ch)
+	}
+	l.subs = nil
+}
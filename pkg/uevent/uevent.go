@@ -0,0 +1,65 @@
+// Package uevent receives the kernel device events (the "uevents" udev consumes)
+// over the netlink socket, e.g., the PCI device hot-add and removal.
+package uevent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotSupported is returned when the kernel uevents are not available on the platform.
+var ErrNotSupported = errors.New("kernel uevents not supported")
+
+const (
+	ActionAdd    = "add"
+	ActionRemove = "remove"
+	ActionChange = "change"
+	ActionBind   = "bind"
+	ActionUnbind = "unbind"
+)
+
+// Event is a kernel uevent.
+type Event struct {
+	Action string `json:"action"`
+	// DevPath is the sysfs path of the device without the "/sys" prefix
+	// (e.g., "/devices/pci0000:00/0000:00:01.0/0000:01:00.0").
+	DevPath   string `json:"devpath"`
+	Subsystem string `json:"subsystem"`
+	// Env is the remaining key-value pairs of the event (e.g., "PCI_SLOT_NAME", "DEVNAME").
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// Parse parses the kernel uevent message, the "ACTION@DEVPATH" header followed by the
+// NUL-separated "KEY=VALUE" pairs.
+// e.g.,
+//
+//	remove@/devices/pci0000:00/0000:00:01.0/0000:01:00.0\x00ACTION=remove\x00DEVPATH=...\x00SUBSYSTEM=pci\x00SEQNUM=1234\x00
+func Parse(b []byte) (Event, error) {
+	fields := bytes.Split(b, []byte{0})
+	header := string(fields[0])
+	action, devPath, ok := strings.Cut(header, "@")
+	if !ok || action == "" || devPath == "" {
+		return Event{}, fmt.Errorf("invalid uevent header %q", header)
+	}
+
+	ev := Event{Action: action, DevPath: devPath, Env: make(map[string]string)}
+	for _, f := range fields[1:] {
+		k, v, ok := strings.Cut(string(f), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "ACTION":
+			ev.Action = v
+		case "DEVPATH":
+			ev.DevPath = v
+		case "SUBSYSTEM":
+			ev.Subsystem = v
+		default:
+			ev.Env[k] = v
+		}
+	}
+	return ev, nil
+}
@@ -0,0 +1,56 @@
+package uevent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Event
+		wantErr  bool
+	}{
+		{
+			name:  "pci remove",
+			input: "remove@/devices/pci0000:00/0000:00:01.0/0000:01:00.0\x00ACTION=remove\x00DEVPATH=/devices/pci0000:00/0000:00:01.0/0000:01:00.0\x00SUBSYSTEM=pci\x00PCI_SLOT_NAME=0000:01:00.0\x00SEQNUM=1234\x00",
+			expected: Event{
+				Action:    ActionRemove,
+				DevPath:   "/devices/pci0000:00/0000:00:01.0/0000:01:00.0",
+				Subsystem: "pci",
+				Env:       map[string]string{"PCI_SLOT_NAME": "0000:01:00.0", "SEQNUM": "1234"},
+			},
+		},
+		{
+			name:  "header only",
+			input: "add@/devices/virtual/net/lo",
+			expected: Event{
+				Action:  ActionAdd,
+				DevPath: "/devices/virtual/net/lo",
+				Env:     map[string]string{},
+			},
+		},
+		{
+			name:    "libudev message",
+			input:   "libudev\x00\xfe\xed\xca\xfe",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Package units defines the SI units of the exported metrics,
+// and converts the values between the SI units and the scaled units
+// reported by the drivers and the tools (e.g., milliwatts, MHz, MiB).
+package units
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Defines the units of the exported metrics.
+// All the metrics are exported in the base units, following the Prometheus naming conventions
+// where the metric name ends with the unit (e.g., "_bytes", "_watts").
+const (
+	Bytes   = "bytes"
+	Celsius = "celsius"
+	Watts   = "watts"
+	Hertz   = "hertz"
+	Seconds = "seconds"
+	Percent = "percent"
+
+	// BytesPerSecond is the unit of the throughput metrics (e.g., "_bytes_per_second").
+	BytesPerSecond = "bytes_per_second"
+	// PerSecond is the unit of the rate metrics (e.g., "_packets_per_second").
+	PerSecond = "per_second"
+)
+
+// Defines the scaled units to convert from or to the base units.
+const (
+	MilliWatts   = "milliwatts"
+	KiloHertz    = "kilohertz"
+	MegaHertz    = "megahertz"
+	Milliseconds = "milliseconds"
+	Microseconds = "microseconds"
+	KiB          = "kibibytes"
+	MiB          = "mebibytes"
+	GiB          = "gibibytes"
+)
+
+var scales = map[string]struct {
+	base   string
+	factor float64
+}{
+	Bytes:          {Bytes, 1},
+	Celsius:        {Celsius, 1},
+	Watts:          {Watts, 1},
+	Hertz:          {Hertz, 1},
+	Seconds:        {Seconds, 1},
+	Percent:        {Percent, 1},
+	BytesPerSecond: {BytesPerSecond, 1},
+	PerSecond:      {PerSecond, 1},
+
+	MilliWatts:   {Watts, 1e-3},
+	KiloHertz:    {Hertz, 1e3},
+	MegaHertz:    {Hertz, 1e6},
+	Milliseconds: {Seconds, 1e-3},
+	Microseconds: {Seconds, 1e-6},
+	KiB:          {Bytes, 1 << 10},
+	MiB:          {Bytes, 1 << 20},
+	GiB:          {Bytes, 1 << 30},
+}
+
+// Convert converts the value between the units of the same dimension
+// (e.g., from "watts" to "milliwatts").
+func Convert(v float64, from string, to string) (float64, error) {
+	f, ok := scales[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", from)
+	}
+	t, ok := scales[to]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", to)
+	}
+	if f.base != t.base {
+		return 0, fmt.Errorf("cannot convert %q to %q", from, to)
+	}
+	return v * f.factor / t.factor, nil
+}
+
+// MilliWattsToWatts converts the milliwatts (e.g., as reported by NVML) to watts.
+func MilliWattsToWatts(mw float64) float64 {
+	return mw / 1e3
+}
+
+// MHzToHertz converts the megahertz (e.g., the GPU clock speeds) to hertz.
+func MHzToHertz(mhz float64) float64 {
+	return mhz * 1e6
+}
+
+// MillisecondsToSeconds converts the milliseconds to seconds.
+func MillisecondsToSeconds(ms float64) float64 {
+	return ms / 1e3
+}
+
+// MiBToBytes converts the mebibytes (e.g., as reported by nvidia-smi) to bytes.
+func MiBToBytes(mib float64) float64 {
+	return mib * (1 << 20)
+}
+
+// metric name suffixes to the units, in the order of matching
+// (e.g., "_bytes_per_second" before "_per_second")
+var suffixes = []struct {
+	suffix string
+	unit   string
+}{
+	{"_bytes_per_second", BytesPerSecond},
+	{"_per_second", PerSecond},
+	{"_bytes_total", Bytes},
+	{"_bytes", Bytes},
+	{"_celsius", Celsius},
+	{"_watts", Watts},
+	{"_hertz", Hertz},
+	{"_seconds", Seconds},
+	{"_percent", Percent},
+}
+
+// FromMetricName returns the unit of the metric from its name suffix
+// (e.g., "watts" for "accelerator_nvidia_power_current_usage_watts"),
+// ignoring the "_avg" and "_ema" suffixes of the averaged metrics.
+// Returns an empty string if the metric has no unit (e.g., the counts).
+func FromMetricName(name string) string {
+	name = strings.TrimSuffix(name, "_avg")
+	name = strings.TrimSuffix(name, "_ema")
+	for _, s := range suffixes {
+		if strings.HasSuffix(name, s.suffix) {
+			return s.unit
+		}
+	}
+	return ""
+}
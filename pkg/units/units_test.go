@@ -0,0 +1,66 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		v       float64
+		from    string
+		to      string
+		want    float64
+		wantErr bool
+	}{
+		{v: 300000, from: MilliWatts, to: Watts, want: 300},
+		{v: 300, from: Watts, to: MilliWatts, want: 300000},
+		{v: 1980, from: MegaHertz, to: Hertz, want: 1.98e9},
+		{v: 1.98e9, from: Hertz, to: MegaHertz, want: 1980},
+		{v: 2, from: GiB, to: MiB, want: 2048},
+		{v: 1048576, from: Bytes, to: MiB, want: 1},
+		{v: 250, from: Milliseconds, to: Seconds, want: 0.25},
+		{v: 80, from: Celsius, to: Celsius, want: 80},
+		{v: 1, from: Watts, to: Bytes, wantErr: true},
+		{v: 1, from: "fahrenheit", to: Celsius, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.v, tt.from, tt.to)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Convert(%v, %q, %q) expected error", tt.v, tt.from, tt.to)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Convert(%v, %q, %q) unexpected error: %v", tt.v, tt.from, tt.to, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9*math.Max(1, math.Abs(tt.want)) {
+			t.Errorf("Convert(%v, %q, %q) = %v, want %v", tt.v, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestFromMetricName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"accelerator_nvidia_power_current_usage_watts", Watts},
+		{"accelerator_nvidia_power_current_usage_watts_avg", Watts},
+		{"accelerator_nvidia_clock_speed_graphics_hertz_ema", Hertz},
+		{"accelerator_nvidia_temperature_current_celsius", Celsius},
+		{"accelerator_nvidia_memory_used_bytes", Bytes},
+		{"network_roce_rx_bytes_total", Bytes},
+		{"network_roce_pause_frames_per_second", PerSecond},
+		{"network_latency_edge_seconds", Seconds},
+		{"accelerator_nvidia_utilization_gpu_util_percent", Percent},
+		{"accelerator_nvidia_ecc_volatile_total_corrected", ""},
+	}
+	for _, tt := range tests {
+		if got := FromMetricName(tt.name); got != tt.want {
+			t.Errorf("FromMetricName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
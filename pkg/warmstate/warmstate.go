@@ -0,0 +1,108 @@
+// Package warmstate persists the in-memory states (e.g., dedup windows, flap counters, job timers)
+// across the gpud restarts, so that an upgrade does not cause a burst of duplicate events
+// or premature health flips.
+package warmstate
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const TableNameWarmState = "warm_state"
+
+const (
+	// unique key of the state owner (e.g., "linkflap/accelerator-nvidia-infiniband")
+	ColumnKey = "key"
+
+	// JSON-encoded state
+	ColumnValue = "value"
+
+	// unix timestamp in seconds when the state was saved
+	ColumnUnixSeconds = "unix_seconds"
+)
+
+// DefaultMaxAge is the default maximum age of a saved state to restore.
+// The states saved before a longer downtime are stale
+// (e.g., the dedup windows would have expired anyway).
+const DefaultMaxAge = 24 * time.Hour
+
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s TEXT NOT NULL PRIMARY KEY,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL
+);`, TableNameWarmState,
+		ColumnKey,
+		ColumnValue,
+		ColumnUnixSeconds,
+	))
+	return err
+}
+
+// Save encodes the state in JSON and replaces the saved state of the key.
+func Save(ctx context.Context, db *sql.DB, key string, v any, now time.Time) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode state %q: %w", key, err)
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`INSERT OR REPLACE INTO %s (%s, %s, %s) VALUES (?, ?, ?);`,
+		TableNameWarmState,
+		ColumnKey,
+		ColumnValue,
+		ColumnUnixSeconds,
+	), key, string(b), now.UTC().Unix())
+	return err
+}
+
+// Load decodes the saved state of the key into v.
+// Returns false if no state is saved or the saved state is older than the max age
+// (no max age if zero).
+func Load(ctx context.Context, db *sql.DB, key string, maxAge time.Duration, v any) (bool, error) {
+	var (
+		value    string
+		unixSecs int64
+	)
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s = ?;`,
+		ColumnValue,
+		ColumnUnixSeconds,
+		TableNameWarmState,
+		ColumnKey,
+	), key).Scan(&value, &unixSecs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if maxAge > 0 && time.Since(time.Unix(unixSecs, 0)) > maxAge {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return false, fmt.Errorf("failed to decode state %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// Purge deletes the states saved before the given time, and returns the number of deleted states.
+func Purge(ctx context.Context, db *sql.DB, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?;`,
+		TableNameWarmState,
+		ColumnUnixSeconds,
+	), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
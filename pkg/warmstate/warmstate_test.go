@@ -0,0 +1,73 @@
+package warmstate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestSaveLoadPurge(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := CreateTable(ctx, db); err != nil {
+		t.Fatal("failed to create table:", err)
+	}
+
+	type state struct {
+		Counts map[string]int `json:"counts"`
+	}
+
+	var got state
+	ok, err := Load(ctx, db, "a", 0, &got)
+	if err != nil || ok {
+		t.Fatalf("expected no state, got ok=%v err=%v", ok, err)
+	}
+
+	now := time.Now()
+	if err := Save(ctx, db, "a", state{Counts: map[string]int{"x": 1}}, now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(ctx, db, "a", state{Counts: map[string]int{"x": 2}}, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(ctx, db, "b", state{Counts: map[string]int{"y": 1}}, now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err = Load(ctx, db, "a", time.Hour, &got)
+	if err != nil || !ok {
+		t.Fatalf("expected state, got ok=%v err=%v", ok, err)
+	}
+	if got.Counts["x"] != 2 {
+		t.Fatalf("expected the latest state, got %+v", got)
+	}
+
+	// stale state is not restored
+	ok, err = Load(ctx, db, "b", time.Hour, &got)
+	if err != nil || ok {
+		t.Fatalf("expected stale state to be skipped, got ok=%v err=%v", ok, err)
+	}
+	ok, err = Load(ctx, db, "b", 0, &got)
+	if err != nil || !ok {
+		t.Fatalf("expected state without max age, got ok=%v err=%v", ok, err)
+	}
+
+	purged, err := Purge(ctx, db, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged, got %d", purged)
+	}
+}